@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline <connection> <version>",
+	Short: "Mark migrations up to a version as applied without executing them",
+	Long: `Baseline adopts an existing database: every migration of the connection up to and
+including the given version is recorded as applied in the state database, without
+running its script. Later migrations run normally.
+
+Baseline entries are recorded in migrations_history with execution method "baseline".
+Migrations already applied are left unchanged; dynamic-schema migrations are skipped.
+
+The state database is read from the same BFM_STATE_* environment variables as the server.
+
+Example:
+  bfm baseline core 20240101120000
+  bfm baseline core 20240101120000 -p /path/to/sfm
+  bfm baseline core 20240101120000 -p /path/to/platform-sfm,/path/to/product-sfm`,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runBaseline,
+	SilenceUsage: true,
+}
+
+func init() {
+	baselineCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory, or comma-separated SFM roots (default: ./examples/sfm)")
+
+	rootCmd.AddCommand(baselineCmd)
+}
+
+func runBaseline(cmd *cobra.Command, args []string) error {
+	connection, version := args[0], args[1]
+	if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+
+	reg := registry.NewInMemoryRegistry()
+	if err := executor.NewLoader(strings.Split(sfmPath, ",")...).LoadAll(reg); err != nil {
+		return errorf(i18n.CLILoadMigrationsFailed, sfmPath, err)
+	}
+
+	executedBy := os.Getenv("USER")
+	if executedBy == "" {
+		executedBy = "cli"
+	}
+	ctx := executor.SetExecutionContext(context.Background(), executedBy, "cli", nil)
+
+	result, err := executor.NewExecutor(reg, tracker).Baseline(ctx, connection, version)
+	if result != nil {
+		for _, id := range result.Baselined {
+			fmt.Println(msg(i18n.CLIBaselined, id))
+		}
+		for _, id := range result.Skipped {
+			fmt.Println(msg(i18n.CLIBaselineSkipped, id))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(msg(i18n.CLIBaselinedCount, len(result.Baselined), connection, version))
+	return nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	statepg "github.com/toolsascode/bfm/api/internal/state/postgresql"
+)
+
+// benchSchema is the schema the synthetic migrations create their tables in
+const benchSchema = "bfm_bench"
+
+var (
+	benchMigrations  int
+	benchConnections int
+	benchIterations  int
+	benchApply       bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure registry, state tracker and executor performance",
+	Long: `Bench generates synthetic migrations spread over synthetic connections and reports
+throughput and latency percentiles of the registry, state tracker and executor paths.
+
+Each connection's migrations form a chain: every migration depends on the previous one,
+so planning exercises dependency resolution at the generated size.
+
+Without --apply nothing is written anywhere: the state tracker is replaced by an in-memory
+stand-in that reports every migration as pending, and only registry lookups and planning
+are measured. With --apply the migrations are reindexed into the state database, applied
+with the PostgreSQL backend and the state tracker queries are measured against the result.
+Both the state database and the migration target are read from the BFM_STATE_* environment
+variables; point them at a disposable database. The migrations create one table each in
+the ` + benchSchema + ` schema.
+
+Example:
+  bfm bench
+  bfm bench --migrations 20000 --connections 800
+  BFM_STATE_DB_NAME=bench bfm bench --migrations 2000 --connections 50 --apply`,
+	Args:         cobra.NoArgs,
+	RunE:         runBench,
+	SilenceUsage: true,
+}
+
+func init() {
+	benchCmd.Flags().IntVarP(&benchMigrations, "migrations", "n", 1000, "Number of migrations to generate")
+	benchCmd.Flags().IntVarP(&benchConnections, "connections", "m", 10, "Number of connections to spread the migrations over")
+	benchCmd.Flags().IntVar(&benchIterations, "iterations", 5, "Number of times each read-only measurement is repeated")
+	benchCmd.Flags().BoolVar(&benchApply, "apply", false, "Apply the migrations against the PostgreSQL database from BFM_STATE_*")
+
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchMigrations < 1 || benchConnections < 1 || benchIterations < 1 {
+		return errorf(i18n.CLIBenchFlagsNotPositive)
+	}
+	if benchConnections > benchMigrations {
+		return errorf(i18n.CLIBenchTooManyConns, benchConnections, benchMigrations)
+	}
+
+	// Per-migration info logs would dominate the output and the measured latencies
+	if os.Getenv("BFM_LOG_LEVEL") == "" {
+		logger.SetLevel(logger.WARN)
+	}
+
+	ctx := executor.SetExecutionContext(context.Background(), "bench", "cli", nil)
+	migrations := generateBenchMigrations(benchMigrations, benchConnections)
+	connectionNames := benchConnectionNames(benchConnections)
+	fmt.Println(msg(i18n.CLIBenchGenerated, len(migrations), len(connectionNames)) + "\n")
+
+	var results []*benchResult
+
+	// Registry
+	register := newBenchResult("registry.Register")
+	var reg registry.Registry
+	for i := 0; i < benchIterations; i++ {
+		reg = registry.NewInMemoryRegistry()
+		for _, migration := range migrations {
+			if err := register.time(func() error { return reg.Register(migration) }); err != nil {
+				return errorf(i18n.CLIRegisterFailed, err)
+			}
+		}
+	}
+	getByConnection := newBenchResult("registry.GetByConnection")
+	findByTarget := newBenchResult("registry.FindByTarget")
+	for i := 0; i < benchIterations; i++ {
+		for _, connection := range connectionNames {
+			_ = getByConnection.time(func() error {
+				reg.GetByConnection(connection)
+				return nil
+			})
+			if err := findByTarget.time(func() error {
+				_, err := reg.FindByTarget(&registry.MigrationTarget{Connection: connection})
+				return err
+			}); err != nil {
+				return errorf(i18n.CLIFindFailed, err)
+			}
+		}
+	}
+	results = append(results, register, getByConnection, findByTarget)
+
+	// State tracker
+	var tracker state.StateTracker = &benchTracker{}
+	if benchApply {
+		pgTracker, err := newBenchStateTracker()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = pgTracker.Close() }()
+		if err := pgTracker.Initialize(ctx); err != nil {
+			return errorf(i18n.CLIStateInitFailed, err)
+		}
+		tracker = pgTracker
+
+		reindex := newBenchResult("tracker.ReindexMigrations")
+		if err := reindex.time(func() error { return pgTracker.ReindexMigrations(ctx, reg) }); err != nil {
+			return errorf(i18n.CLIReindexFailed, err)
+		}
+		results = append(results, reindex)
+	}
+
+	exec := executor.NewExecutor(reg, tracker)
+	if err := exec.SetConnections(benchConnectionConfigs(connectionNames)); err != nil {
+		return err
+	}
+	exec.RegisterBackend("postgresql", postgresql.NewBackend())
+
+	// Executor
+	if benchApply {
+		apply := newBenchResult("executor.ExecuteSync")
+		for _, connection := range connectionNames {
+			var result *executor.ExecuteResult
+			if err := apply.time(func() error {
+				var err error
+				result, err = exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: connection}, connection, "", false, false)
+				return err
+			}); err != nil {
+				return errorf(i18n.CLIApplyFailed, connection, err)
+			}
+			if len(result.Errors) > 0 {
+				return errorf(i18n.CLIApplyFailed, connection, result.Errors)
+			}
+		}
+		apply.items = len(migrations)
+		results = append(results, apply)
+	}
+
+	plan := newBenchResult("executor.Plan")
+	pending := newBenchResult("executor.PendingMigrations")
+	for i := 0; i < benchIterations; i++ {
+		for _, connection := range connectionNames {
+			if err := plan.time(func() error {
+				_, err := exec.Plan(ctx, &registry.MigrationTarget{Connection: connection}, connection, nil, false)
+				return err
+			}); err != nil {
+				return errorf(i18n.CLIPlanFailed, connection, err)
+			}
+			if err := pending.time(func() error {
+				_, err := exec.PendingMigrations(ctx, connection, "")
+				return err
+			}); err != nil {
+				return errorf(i18n.CLIListPendingFailed, connection, err)
+			}
+		}
+	}
+	results = append(results, plan, pending)
+
+	if benchApply {
+		isApplied := newBenchResult("tracker.IsMigrationApplied")
+		list := newBenchResult("tracker.GetMigrationList")
+		for i := 0; i < benchIterations; i++ {
+			for _, migration := range migrations {
+				id := benchMigrationID(migration)
+				if err := isApplied.time(func() error {
+					_, err := tracker.IsMigrationApplied(ctx, id)
+					return err
+				}); err != nil {
+					return errorf(i18n.CLICheckFailed, id, err)
+				}
+			}
+			for _, connection := range connectionNames {
+				if err := list.time(func() error {
+					_, _, err := tracker.GetMigrationList(ctx, &state.MigrationFilters{Connection: connection})
+					return err
+				}); err != nil {
+					return errorf(i18n.CLIListFailed, connection, err)
+				}
+			}
+		}
+		results = append(results, isApplied, list)
+	}
+
+	printBenchResults(os.Stdout, results)
+	return nil
+}
+
+// generateBenchMigrations returns n PostgreSQL migrations spread round-robin over the
+// connections, each depending on the previous migration of its connection
+func generateBenchMigrations(n, connections int) []*backends.MigrationScript {
+	names := benchConnectionNames(connections)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := make(map[string]string, connections)
+	migrations := make([]*backends.MigrationScript, 0, n)
+	for i := 0; i < n; i++ {
+		connection := names[i%connections]
+		name := fmt.Sprintf("bench_%06d", i)
+		table := fmt.Sprintf("%s.%s_%s", benchSchema, connection, name)
+		migration := &backends.MigrationScript{
+			Schema:     benchSchema,
+			Version:    base.Add(time.Duration(i) * time.Second).Format("20060102150405"),
+			Name:       name,
+			Connection: connection,
+			Backend:    "postgresql",
+			UpSQL:      fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\nCREATE TABLE IF NOT EXISTS %s (id BIGINT PRIMARY KEY);", benchSchema, table),
+			DownSQL:    fmt.Sprintf("DROP TABLE IF EXISTS %s;", table),
+		}
+		if prev, ok := previous[connection]; ok {
+			migration.Dependencies = []string{prev}
+		}
+		previous[connection] = name
+		migrations = append(migrations, migration)
+	}
+	return migrations
+}
+
+// benchConnectionNames returns the synthetic connection names
+func benchConnectionNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench%04d", i)
+	}
+	return names
+}
+
+// benchConnectionConfigs points every synthetic connection at the BFM_STATE_* database
+func benchConnectionConfigs(names []string) map[string]*backends.ConnectionConfig {
+	cfg := config.LoadStateDBFromEnv()
+	connections := make(map[string]*backends.ConnectionConfig, len(names))
+	for _, name := range names {
+		connections[name] = &backends.ConnectionConfig{
+			Backend:  "postgresql",
+			Host:     cfg.StateDB.Host,
+			Port:     cfg.StateDB.Port,
+			Username: cfg.StateDB.Username,
+			Password: cfg.StateDB.Password,
+			Database: cfg.StateDB.Database,
+			Schema:   benchSchema,
+		}
+	}
+	return connections
+}
+
+// benchMigrationID returns the ID the executor tracks a generated migration under
+func benchMigrationID(migration *backends.MigrationScript) string {
+	return fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
+}
+
+// newBenchStateTracker connects to the state database from the BFM_STATE_* variables
+func newBenchStateTracker() (*statepg.Tracker, error) {
+	cfg := config.LoadStateDBFromEnv()
+	if cfg.StateDB.Type != "postgresql" {
+		return nil, errorf(i18n.CLIUnsupportedState, cfg.StateDB.Type)
+	}
+	stateConnStr := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s %s",
+		cfg.StateDB.Host,
+		cfg.StateDB.Port,
+		cfg.StateDB.Username,
+		cfg.StateDB.Password,
+		cfg.StateDB.Database,
+		backends.PostgresSSLParams(cfg.StateDB.SSLMode, cfg.StateDB.SSLRootCert, cfg.StateDB.SSLCert, cfg.StateDB.SSLKey),
+	)
+	tracker, err := statepg.NewTracker(stateConnStr, cfg.StateDB.Schema)
+	if err != nil {
+		return nil, errorf(i18n.CLIStateConnectFailed, err)
+	}
+	return tracker, nil
+}
+
+// benchTracker stands in for the state database when nothing is applied. Only the methods
+// used by planning are implemented; every migration is reported as pending.
+type benchTracker struct {
+	state.StateTracker
+}
+
+func (t *benchTracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
+	return false, nil
+}
+
+func (t *benchTracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
+	return false, nil
+}
+
+// benchResult collects the latencies of one measured operation
+type benchResult struct {
+	name      string
+	latencies []time.Duration
+	items     int // Units processed when they differ from the number of calls (e.g. migrations applied)
+}
+
+func newBenchResult(name string) *benchResult {
+	return &benchResult{name: name}
+}
+
+// time runs fn and records its latency
+func (r *benchResult) time(fn func() error) error {
+	start := time.Now()
+	err := fn()
+	r.latencies = append(r.latencies, time.Since(start))
+	return err
+}
+
+// percentile returns the latency at p (0-100) using the nearest-rank method
+func (r *benchResult) percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// printBenchResults writes one row per operation with throughput and latency percentiles
+func printBenchResults(out *os.File, results []*benchResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(w, "operation\tcalls\ttotal\tper sec\tp50\tp95\tp99\tmax\t")
+	for _, r := range results {
+		sorted := append([]time.Duration(nil), r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		var total time.Duration
+		for _, latency := range sorted {
+			total += latency
+		}
+		items := r.items
+		if items == 0 {
+			items = len(sorted)
+		}
+		perSecond := 0.0
+		if total > 0 {
+			perSecond = float64(items) / total.Seconds()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%.0f\t%s\t%s\t%s\t%s\t\n",
+			r.name, len(sorted), total.Round(time.Microsecond), perSecond,
+			r.percentile(sorted, 50), r.percentile(sorted, 95), r.percentile(sorted, 99), r.percentile(sorted, 100))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [sfm-path]",
+	Short: "Remove generated .go files that add nothing to their scripts",
+	Long: `Clean removes the .go files generated by "bfm build" (or by the server loader) that are
+redundant with their SQL/JSON scripts: the up script exists and the file declares no schema,
+dependencies or tags beyond the script's bfm-tags line. Hand-written files and files carrying
+metadata are kept.
+
+Run it after switching the server to BFM_MIGRATION_SOURCE=scripts, where the loader reads the
+scripts directly and no longer generates .go files.
+
+Example:
+  bfm clean examples/sfm --dry-run
+  bfm clean -p /path/to/sfm`,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         runClean,
+	SilenceUsage: true,
+}
+
+func init() {
+	cleanCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory (default: first argument or ./examples/sfm)")
+	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the files that would be removed without removing them")
+
+	rootCmd.AddCommand(cleanCmd)
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		sfmPath = args[0]
+	} else if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	files, err := executor.RemoveRedundantGoFiles(sfmPath, dryRun)
+	for _, file := range files {
+		if dryRun {
+			fmt.Println(msg(i18n.CLIWouldRemove, file))
+		} else {
+			fmt.Println(msg(i18n.CLIRemoved, file))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println(msg(i18n.CLIRedundantCount, len(files), sfmPath))
+	} else {
+		fmt.Println(msg(i18n.CLIRemovedCount, len(files), sfmPath))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the server and worker configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the BFM_* environment before starting the server or worker",
+	Long: `Validate loads the configuration from the environment as the server and worker do, and
+reports every problem found rather than only the first:
+
+  - missing API token or state database credentials
+  - invalid values (ports, durations, policies, modes)
+  - connections with an unknown backend or SSL mode
+  - an enabled queue without brokers or address
+
+Exits non-zero when problems are found so deploy pipelines can gate on it.
+
+Example:
+  bfm config validate
+  env $(cat prod.env) bfm config validate`,
+	Args:          cobra.NoArgs,
+	RunE:          runConfigValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		var invalid *config.ValidationError
+		if !errors.As(err, &invalid) {
+			return err
+		}
+		for _, problem := range invalid.Problems {
+			fmt.Println("- " + problem.Error())
+		}
+		return errorf(i18n.CLIConfigInvalid, len(invalid.Problems))
+	}
+
+	fmt.Println(msg(i18n.CLIConfigValid, cfg.StateDB.Type, len(cfg.Connections)))
+	return nil
+}
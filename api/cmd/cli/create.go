@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+)
+
+// versionLayout is the 14-digit migration version format (YYYYMMDDHHMMSS, UTC)
+const versionLayout = "20060102150405"
+
+var (
+	createTemplate string
+	createForce    bool
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create <backend> <connection> <name>",
+	Short: "Create a new timestamped up/down migration pair",
+	Long: `Create generates an empty (or templated) pair of migration scripts with a
+timestamped version in the correct SFM directory:
+
+  {sfm_path}/{backend}/{connection}/{version}_{name}.up.sql
+  {sfm_path}/{backend}/{connection}/{version}_{name}.down.sql
+
+etcd, mongodb, consul and vault migrations use .json instead of .sql.
+
+Built-in templates (--template): empty (default), create-table, add-column, create-index.
+A path to an existing {name}.up.sql / {name}.up.json file may also be given; it and its
+matching down file are copied as the starting point.
+
+Example:
+  bfm create postgresql core add_users_table
+  bfm create postgresql core add_users_table --template create-table -p ./sfm
+  bfm create etcd metadata feature_flags`,
+	Args: cobra.ExactArgs(3),
+	RunE: runCreate,
+}
+
+func init() {
+	createCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory (default: ./examples/sfm)")
+	createCmd.Flags().StringVarP(&createTemplate, "template", "t", "", "Built-in template name or path to an .up file to copy")
+	createCmd.Flags().BoolVar(&createForce, "force", false, "Overwrite existing files")
+
+	rootCmd.AddCommand(createCmd)
+}
+
+var migrationNameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	backend := strings.ToLower(strings.TrimSpace(args[0]))
+	connection := strings.TrimSpace(args[1])
+	name := normalizeMigrationName(args[2])
+
+	if backend == "" || connection == "" {
+		return errorf(i18n.CLIBackendConnRequired)
+	}
+	if !migrationNameRe.MatchString(name) {
+		return errorf(i18n.CLIInvalidMigrationName, args[2])
+	}
+	if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	ext := backends.ScriptExtension(backend)
+	dirPath := filepath.Join(sfmPath, backend, connection)
+	version := nextFreeVersion(dirPath, time.Now().UTC())
+
+	upContent, downContent, err := createTemplateContent(createTemplate, ext, name)
+	if err != nil {
+		return err
+	}
+
+	upPath := filepath.Join(dirPath, fmt.Sprintf("%s_%s.up%s", version, name, ext))
+	downPath := filepath.Join(dirPath, fmt.Sprintf("%s_%s.down%s", version, name, ext))
+
+	if !createForce {
+		for _, p := range []string{upPath, downPath} {
+			if _, err := os.Stat(p); err == nil {
+				return errorf(i18n.CLIFileExists, p)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return errorf(i18n.CLICreateDirectoryFailed, dirPath, err)
+	}
+	if err := os.WriteFile(upPath, []byte(upContent), 0644); err != nil {
+		return errorf(i18n.CLIWriteFailed, upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte(downContent), 0644); err != nil {
+		return errorf(i18n.CLIWriteFailed, downPath, err)
+	}
+
+	fmt.Println(msg(i18n.CLICreated, upPath))
+	fmt.Println(msg(i18n.CLICreated, downPath))
+	return nil
+}
+
+// normalizeMigrationName converts a free-form name to snake_case
+func normalizeMigrationName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "_").Replace(name)
+}
+
+// nextFreeVersion returns a version for now that is not already used in dirPath.
+// Creating several migrations within the same second bumps the version by one second.
+func nextFreeVersion(dirPath string, now time.Time) string {
+	used := make(map[string]bool)
+	if entries, err := os.ReadDir(dirPath); err == nil {
+		for _, entry := range entries {
+			if len(entry.Name()) > 14 && entry.Name()[14] == '_' {
+				used[entry.Name()[:14]] = true
+			}
+		}
+	}
+	for {
+		version := now.Format(versionLayout)
+		if !used[version] {
+			return version
+		}
+		now = now.Add(time.Second)
+	}
+}
+
+// createTemplateContent returns the initial up and down script bodies
+func createTemplateContent(tmpl, ext, name string) (string, string, error) {
+	if tmpl != "" {
+		if _, err := os.Stat(tmpl); err == nil {
+			return copyTemplateFiles(tmpl)
+		}
+	}
+
+	if ext == ".json" {
+		switch tmpl {
+		case "", "empty":
+			return "[]\n", "[]\n", nil
+		default:
+			return "", "", errorf(i18n.CLIUnknownJSONTemplate, tmpl)
+		}
+	}
+
+	header := fmt.Sprintf("-- Migration: %s\n", name)
+	switch tmpl {
+	case "", "empty":
+		return header + "\n", header + "\n", nil
+	case "create-table":
+		return header + "CREATE TABLE IF NOT EXISTS {{.Schema}}.table_name (\n    id BIGSERIAL PRIMARY KEY,\n    created_at TIMESTAMPTZ NOT NULL DEFAULT now()\n);\n",
+			header + "DROP TABLE IF EXISTS {{.Schema}}.table_name;\n", nil
+	case "add-column":
+		return header + "ALTER TABLE {{.Schema}}.table_name ADD COLUMN IF NOT EXISTS column_name TEXT;\n",
+			header + "ALTER TABLE {{.Schema}}.table_name DROP COLUMN IF EXISTS column_name;\n", nil
+	case "create-index":
+		return header + "CREATE INDEX IF NOT EXISTS idx_table_name_column_name ON {{.Schema}}.table_name (column_name);\n",
+			header + "DROP INDEX IF EXISTS {{.Schema}}.idx_table_name_column_name;\n", nil
+	default:
+		return "", "", errorf(i18n.CLIUnknownTemplate, tmpl)
+	}
+}
+
+// copyTemplateFiles reads an .up template file and its matching .down file (if present)
+func copyTemplateFiles(upPath string) (string, string, error) {
+	up, err := os.ReadFile(upPath)
+	if err != nil {
+		return "", "", errorf(i18n.CLIReadTemplateFailed, upPath, err)
+	}
+
+	ext := filepath.Ext(upPath)
+	base := strings.TrimSuffix(upPath, ".up"+ext)
+	if base == upPath {
+		return "", "", errorf(i18n.CLITemplateNameInvalid, ext, upPath)
+	}
+
+	down, err := os.ReadFile(base + ".down" + ext)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", errorf(i18n.CLIReadDownTemplateFailed, err)
+	}
+	return string(up), string(down), nil
+}
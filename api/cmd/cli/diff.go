@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+var (
+	diffReference string
+	diffSchemas   []string
+	diffName      string
+	diffDryRun    bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <connection>",
+	Short: "Generate a migration from the schema diff between a connection and a reference database",
+	Long: `Diff compares the tables, columns, indexes and constraints of a connection's database with
+those of a reference connection (e.g. a development database changed by hand) and writes the
+statements that bring the connection to the reference as a candidate migration pair:
+
+  {sfm_path}/postgresql/{connection}/{version}_{name}.up.sql
+  {sfm_path}/postgresql/{connection}/{version}_{name}.down.sql
+
+The down script reverts the up script. Review both before committing: column type changes may
+need a USING clause, renames show up as a drop and an add, and dropped tables and columns lose
+their data. Views, functions, sequences, triggers and privileges are not compared.
+
+PostgreSQL connections only. Connections are read from the {CONNECTION}_* environment
+variables and BFM_CONNECTIONS_FILE.
+
+Example:
+  bfm diff core --reference core_dev -p /path/to/sfm
+  bfm diff core --reference core_dev --schemas public,billing --name sync_billing --dry-run`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runDiff,
+	SilenceUsage: true,
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory (default: ./examples/sfm)")
+	diffCmd.Flags().StringVar(&diffReference, "reference", "", "Connection whose schema the connection should have")
+	diffCmd.Flags().StringSliceVar(&diffSchemas, "schemas", nil, "Schemas to compare (default: the connection's schema, else public)")
+	diffCmd.Flags().StringVar(&diffName, "name", "schema_diff", "Name of the generated migration")
+	diffCmd.Flags().BoolVar(&diffDryRun, "dry-run", false, "Print the scripts instead of writing them")
+	_ = diffCmd.MarkFlagRequired("reference")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	connection := args[0]
+	name := normalizeMigrationName(diffName)
+	if !migrationNameRe.MatchString(name) {
+		return errorf(i18n.CLIInvalidMigrationName, diffName)
+	}
+	if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	connections, err := config.LoadConnections(os.Getenv("BFM_CONNECTIONS_FILE"))
+	if err != nil {
+		return errorf(i18n.CLIConnectionsLoadFailed, err)
+	}
+	e := executor.NewExecutor(registry.NewInMemoryRegistry(), nil)
+	if err := e.SetConnections(connections); err != nil {
+		return err
+	}
+	e.RegisterBackend("postgresql", postgresql.NewBackend())
+
+	diff, err := e.SchemaDiff(context.Background(), connection, diffReference, diffSchemas)
+	if err != nil {
+		return errorf(i18n.CLIDiffFailed, err)
+	}
+	if !diff.Diff.HasChanges() {
+		fmt.Println(msg(i18n.CLIDiffNoChanges, connection, diffReference, strings.Join(diff.Schemas, ", ")))
+		return nil
+	}
+
+	if diffDryRun {
+		fmt.Println(msg(i18n.CLIDryRunMode))
+		fmt.Print(diff.UpScript())
+		fmt.Println()
+		fmt.Print(diff.DownScript())
+		return nil
+	}
+
+	dirPath := filepath.Join(sfmPath, "postgresql", connection)
+	version := nextFreeVersion(dirPath, time.Now().UTC())
+	upPath := filepath.Join(dirPath, fmt.Sprintf("%s_%s.up.sql", version, name))
+	downPath := filepath.Join(dirPath, fmt.Sprintf("%s_%s.down.sql", version, name))
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return errorf(i18n.CLICreateDirectoryFailed, dirPath, err)
+	}
+	if err := os.WriteFile(upPath, []byte(diff.UpScript()), 0644); err != nil {
+		return errorf(i18n.CLIWriteFailed, upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte(diff.DownScript()), 0644); err != nil {
+		return errorf(i18n.CLIWriteFailed, downPath, err)
+	}
+
+	fmt.Println(msg(i18n.CLICreated, upPath))
+	fmt.Println(msg(i18n.CLICreated, downPath))
+	fmt.Println(msg(i18n.CLIDiffReview, len(diff.Diff.Up)))
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/export"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+var (
+	exportFilters       state.MigrationFilters
+	exportAppliedAfter  string
+	exportAppliedBefore string
+	exportColumns       string
+	exportOutput        string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <list|history>",
+	Short: "Export the migration list or execution history as CSV",
+	Long: `Export writes the migration list (one row per migration with its last status) or the
+execution history (one row per execution, including rollbacks) as CSV, for spreadsheets.
+
+Columns are selected with --columns, in the given order; by default every column is written.
+  list:    ` + strings.Join(export.ListColumns(), ", ") + `
+  history: ` + strings.Join(export.HistoryColumns(), ", ") + `
+
+The state database is read from the same BFM_STATE_* environment variables as the server.
+The API offers the same export with ?format=csv on GET /api/v1/migrations,
+GET /api/v1/migrations/history and GET /api/v1/migrations/{id}/history.
+
+Example:
+  bfm export list -o migrations.csv
+  bfm export list --connection core --columns migration_id,status,applied_at,status_labels
+  bfm export history --connection core --status failed -o failures.csv
+  bfm export history --executed-by alice --applied-after 2026-10-13T00:00:00Z --applied-before 2026-10-14T00:00:00Z`,
+	Args:         cobra.ExactArgs(1),
+	ValidArgs:    []string{"list", "history"},
+	RunE:         runExport,
+	SilenceUsage: true,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFilters.Connection, "connection", "", "Only export migrations of this connection")
+	exportCmd.Flags().StringVar(&exportFilters.Backend, "backend", "", "Only export migrations of this backend")
+	exportCmd.Flags().StringVar(&exportFilters.Schema, "schema", "", "Only export migrations of this schema")
+	exportCmd.Flags().StringVar(&exportFilters.Status, "status", "", "Only export migrations (list) or executions (history) with this status")
+	exportCmd.Flags().StringVar(&exportFilters.Label, "label", "", "Only export migrations with this status label (list only)")
+	exportCmd.Flags().StringVar(&exportFilters.ExecutedBy, "executed-by", "", "Only export executions by this user (history only)")
+	exportCmd.Flags().StringVar(&exportFilters.ExecutionMethod, "execution-method", "", "Only export executions with this method: manual, api, cli or worker (history only)")
+	exportCmd.Flags().StringVar(&exportAppliedAfter, "applied-after", "", "Only export executions applied at or after this RFC 3339 time (history only)")
+	exportCmd.Flags().StringVar(&exportAppliedBefore, "applied-before", "", "Only export executions applied before this RFC 3339 time (history only)")
+	exportCmd.Flags().StringVar(&exportColumns, "columns", "", "Comma-separated columns to export (default: all)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write (default: stdout)")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	kind := args[0]
+	if kind != "list" && kind != "history" {
+		return errorf(i18n.CLIUnknownExport, kind)
+	}
+	if kind == "history" && exportFilters.Label != "" {
+		return errorf(i18n.CLILabelListOnly)
+	}
+	if kind == "list" && (exportFilters.ExecutedBy != "" || exportFilters.ExecutionMethod != "" || exportAppliedAfter != "" || exportAppliedBefore != "") {
+		return errorf(i18n.CLIHistoryFlagsOnly)
+	}
+	for flag, bound := range map[string]struct {
+		raw string
+		t   *time.Time
+	}{
+		"--applied-after":  {exportAppliedAfter, &exportFilters.AppliedAfter},
+		"--applied-before": {exportAppliedBefore, &exportFilters.AppliedBefore},
+	} {
+		if bound.raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			return errorf(i18n.InvalidTime, flag)
+		}
+		*bound.t = parsed
+	}
+
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+
+	ctx := context.Background()
+	columns := export.ParseColumns(exportColumns)
+
+	var write func(w io.Writer) error
+	if kind == "list" {
+		items, _, err := tracker.GetMigrationList(ctx, &exportFilters)
+		if err != nil {
+			return errorf(i18n.CLIReadListFailed, err)
+		}
+		write = func(w io.Writer) error { return export.WriteMigrationList(w, items, columns) }
+	} else {
+		records, _, err := tracker.GetMigrationHistory(ctx, &exportFilters)
+		if err != nil {
+			return errorf(i18n.CLIReadHistoryFailed, err)
+		}
+		write = func(w io.Writer) error { return export.WriteHistory(w, records, columns) }
+	}
+
+	// Check the column selection before creating the output file, so a typo doesn't leave an empty file
+	if err := write(io.Discard); err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return errorf(i18n.CLICreateFileFailed, exportOutput, err)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/toolsascode/bfm/api/internal/i18n"
 	migrationpkg "github.com/toolsascode/bfm/api/migrations"
 
 	"github.com/spf13/cobra"
@@ -30,6 +31,7 @@ var (
 	verbose   bool
 	dryRun    bool
 	outputDir string
+	lang      string
 )
 
 // bfm-tags line in .up.sql / .up.json (first lines of file): -- bfm-tags: env=prod, feature=x
@@ -43,6 +45,12 @@ var rootCmd = &cobra.Command{
 Generate migration .go files from SQL/JSON migration scripts.
 Supports PostgreSQL, GreptimeDB, and etcd backends.`,
 	Version: "1.0.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if lang == "" {
+			return nil
+		}
+		return i18n.SetDefault(lang)
+	},
 }
 
 var buildCmd = &cobra.Command{
@@ -68,11 +76,13 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("BfM CLI version %s\n", rootCmd.Version)
+		fmt.Println(msg(i18n.CLIVersion, rootCmd.Version))
 	},
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", os.Getenv("BFM_LOCALE"), "Language of messages: en or ja (default: BFM_LOCALE, or en)")
+
 	// Build command flags
 	buildCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory (default: first argument or ./examples/sfm)")
 	buildCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
@@ -85,7 +95,7 @@ func init() {
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, msg(i18n.CLIError, err))
 		os.Exit(1)
 	}
 }
@@ -101,15 +111,15 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	// Validate path exists
 	if _, err := os.Stat(sfmPath); os.IsNotExist(err) {
-		return fmt.Errorf("SFM path does not exist: %s", sfmPath)
+		return errorf(i18n.CLISFMPathMissing, sfmPath)
 	}
 
 	if verbose {
-		fmt.Printf("Scanning SFM directory: %s\n", sfmPath)
+		fmt.Println(msg(i18n.CLIScanning, sfmPath))
 	}
 
 	if dryRun {
-		fmt.Println("DRY RUN MODE - No files will be created")
+		fmt.Println(msg(i18n.CLIDryRunMode))
 	}
 
 	// Build migrations
@@ -118,7 +128,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Println("\nBuild completed successfully!")
+		fmt.Println("\n" + msg(i18n.CLIBuildCompleted))
 	}
 
 	return nil
@@ -138,7 +148,7 @@ func buildMigrations(sfmPath string) error {
 			return nil
 		}
 
-		// Look for .up.sql, .down.sql, .up.json, .down.json files
+		// Look for .up.sql, .down.sql, .up.json, .down.json (and desired-state .up.yaml, .down.yaml) files
 		filename := info.Name()
 		var isUp, isDown bool
 		var ext string
@@ -155,12 +165,18 @@ func buildMigrations(sfmPath string) error {
 		} else if strings.HasSuffix(filename, ".down.json") {
 			isDown = true
 			ext = ".json"
+		} else if strings.HasSuffix(filename, ".up.yaml") {
+			isUp = true
+			ext = ".yaml"
+		} else if strings.HasSuffix(filename, ".down.yaml") {
+			isDown = true
+			ext = ".yaml"
 		} else {
 			return nil
 		}
 
 		if verbose {
-			fmt.Printf("Found migration file: %s\n", path)
+			fmt.Println(msg(i18n.CLIFoundFile, path))
 		}
 
 		// Parse filename: {version}_{name}.up.{ext} or {version}_{name}.down.{ext}
@@ -171,7 +187,7 @@ func buildMigrations(sfmPath string) error {
 		versionRegex := regexp.MustCompile(`^(\d{14})_(.+)$`)
 		matches := versionRegex.FindStringSubmatch(baseName)
 		if len(matches) != 3 {
-			return fmt.Errorf("invalid filename format: %s (expected: {version}_{name}.up.{ext})", filename)
+			return errorf(i18n.CLIInvalidFilename, filename)
 		}
 
 		version := matches[1]
@@ -186,7 +202,7 @@ func buildMigrations(sfmPath string) error {
 
 		parts := strings.Split(relPath, string(filepath.Separator))
 		if len(parts) < 3 {
-			return fmt.Errorf("invalid directory structure for %s (expected: {backend}/{connection}/{filename})", path)
+			return errorf(i18n.CLIInvalidDirectory, path)
 		}
 
 		backend := parts[0]
@@ -224,24 +240,24 @@ func buildMigrations(sfmPath string) error {
 	}
 
 	if migrationCount == 0 {
-		fmt.Println("No migration files found in the specified directory")
+		fmt.Println(msg(i18n.CLINoMigrationFiles))
 		return nil
 	}
 
 	if verbose {
-		fmt.Printf("\nFound %d migration(s) to process\n", migrationCount)
+		fmt.Println("\n" + msg(i18n.CLIFoundMigrations, migrationCount))
 	}
 
 	// Generate .go files
 	tmpl, err := template.New("migration").Parse(migrationpkg.GoFileTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return errorf(i18n.CLITemplateParseFailed, err)
 	}
 
 	var generatedCount int
 	for key, migration := range migrations {
 		if migration.UpFile == "" {
-			return fmt.Errorf("missing up file for migration: %s", key)
+			return errorf(i18n.CLIMissingUpFile, key)
 		}
 
 		// Determine down file name if not explicitly found
@@ -275,20 +291,20 @@ func buildMigrations(sfmPath string) error {
 		goFilePath := filepath.Join(dirPath, goFileName)
 
 		if dryRun {
-			fmt.Printf("[DRY RUN] Would generate: %s\n", goFilePath)
+			fmt.Println(msg(i18n.CLIWouldGenerate, goFilePath))
 			generatedCount++
 			continue
 		}
 
 		// Create output directory if it doesn't exist
 		if err := os.MkdirAll(dirPath, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dirPath, err)
+			return errorf(i18n.CLICreateDirectoryFailed, dirPath, err)
 		}
 
 		// Create file
 		file, err := os.Create(goFilePath)
 		if err != nil {
-			return fmt.Errorf("failed to create file %s: %w", goFilePath, err)
+			return errorf(i18n.CLICreateFileFailed, goFilePath, err)
 		}
 
 		// Execute template
@@ -315,26 +331,32 @@ func buildMigrations(sfmPath string) error {
 		_ = file.Close()
 
 		if err != nil {
-			return fmt.Errorf("failed to generate file %s: %w", goFilePath, err)
+			return errorf(i18n.CLIGenerateFailed, goFilePath, err)
 		}
 
-		if verbose {
-			fmt.Printf("Generated: %s\n", goFilePath)
-		} else {
-			fmt.Printf("Generated: %s\n", goFilePath)
-		}
+		fmt.Println(msg(i18n.CLIGenerated, goFilePath))
 		generatedCount++
 	}
 
 	if !dryRun {
-		fmt.Printf("\nSuccessfully generated %d migration file(s)\n", generatedCount)
+		fmt.Println("\n" + msg(i18n.CLIGeneratedCount, generatedCount))
 	} else {
-		fmt.Printf("\nWould generate %d migration file(s)\n", generatedCount)
+		fmt.Println("\n" + msg(i18n.CLIWouldGenerateCount, generatedCount))
 	}
 
 	return nil
 }
 
+// msg returns a message of the catalog in the --lang locale
+func msg(id string, args ...interface{}) string {
+	return i18n.T(i18n.Default(), id, args...)
+}
+
+// errorf returns an error with a message of the catalog in the --lang locale
+func errorf(id string, args ...interface{}) error {
+	return i18n.Errorf(i18n.Default(), id, args...)
+}
+
 func readBFMTagsFromUpFile(path string) ([]string, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -359,7 +381,7 @@ func readBFMTagsFromUpFile(path string) ([]string, error) {
 			}
 			eq := strings.Index(p, "=")
 			if eq <= 0 || strings.TrimSpace(p[:eq]) == "" {
-				return nil, fmt.Errorf("%s: invalid bfm-tags entry %q (expected key=value)", path, p)
+				return nil, errorf(i18n.CLIInvalidTagsEntry, path, p)
 			}
 			out = append(out, p)
 		}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+var (
+	planTarget           registry.MigrationTarget
+	planSchemas          []string
+	planIgnoreDeps       bool
+	planOutput           string
+	planDetailedExitCode bool
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan <connection>",
+	Short: "Show the migrations an up request would apply, without executing them",
+	Long: `Plan resolves the migrations of a connection as the server's up request does (target
+lookup, pending dependencies, dependency order) and reports which would be applied and
+which are already applied. Nothing is executed.
+
+Output formats (-o):
+  text       the steps of the plan, for review (default)
+  json       the stable plan format: format_version, has_changes, a hash of what would be
+             applied, and the migrations to apply with their checksums
+  terraform  the json format flattened to an object of strings, the result format of
+             Terraform's external data source
+
+The hash only changes when the migrations to apply, their order or their checksums change,
+so pipelines can compare it with the hash of an approved plan.
+
+With --detailed-exitcode the command exits 2 when the plan has changes (0 when it has none,
+1 on error), as terraform plan does.
+
+The state database is read from the same BFM_STATE_* environment variables as the server,
+and connections from the {CONNECTION}_* variables and BFM_CONNECTIONS_FILE.
+The API returns the same formats with ?format=machine and ?format=terraform on
+GET /api/v1/migrations/plan.
+
+Example:
+  bfm plan core
+  bfm plan core -o json -p /path/to/sfm
+  bfm plan tenants --schemas tenant_a,tenant_b -o terraform
+  bfm plan core --detailed-exitcode -o json > plan.json`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runPlan,
+	SilenceUsage: true,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory, or comma-separated SFM roots (default: ./examples/sfm)")
+	planCmd.Flags().StringVar(&planTarget.Backend, "backend", "", "Only plan migrations of this backend")
+	planCmd.Flags().StringVar(&planTarget.Schema, "schema", "", "Only plan migrations of this schema")
+	planCmd.Flags().StringVar(&planTarget.MaxVersion, "max-version", "", "Only plan migrations of this version and older")
+	planCmd.Flags().StringSliceVar(&planTarget.Tags, "tags", nil, "Only plan migrations with these tags (key=value)")
+	planCmd.Flags().StringSliceVar(&planSchemas, "schemas", nil, "Schemas of dynamic-schema migrations")
+	planCmd.Flags().BoolVar(&planIgnoreDeps, "ignore-dependencies", false, "Order by version only")
+	planCmd.Flags().StringVarP(&planOutput, "output", "o", "text", "Output format: text, json or terraform")
+	planCmd.Flags().BoolVar(&planDetailedExitCode, "detailed-exitcode", false, "Exit 2 when the plan has changes")
+
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	connection := args[0]
+	if planOutput != "text" && planOutput != "json" && planOutput != "terraform" {
+		return errorf(i18n.CLIUnknownPlanOutput, planOutput)
+	}
+	if len(planTarget.Tags) > 0 {
+		if _, err := registry.ParseTagFilter(planTarget.Tags); err != nil {
+			return err
+		}
+	}
+	if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	connections, err := config.LoadConnections(os.Getenv("BFM_CONNECTIONS_FILE"))
+	if err != nil {
+		return errorf(i18n.CLIConnectionsLoadFailed, err)
+	}
+
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+
+	reg := registry.NewInMemoryRegistry()
+	if err := executor.NewLoader(strings.Split(sfmPath, ",")...).LoadAll(reg); err != nil {
+		return errorf(i18n.CLILoadMigrationsFailed, sfmPath, err)
+	}
+
+	exec := executor.NewExecutor(reg, tracker)
+	if err := exec.SetConnections(connections); err != nil {
+		return err
+	}
+
+	target := planTarget
+	target.Connection = connection
+	plan, err := exec.Plan(context.Background(), &target, connection, planSchemas, planIgnoreDeps)
+	if err != nil {
+		return errorf(i18n.CLIPlanFailed, connection, err)
+	}
+	machine := plan.Machine(connection, planSchemas)
+
+	switch planOutput {
+	case "json", "terraform":
+		var document interface{} = machine
+		if planOutput == "terraform" {
+			document = machine.Flatten()
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	default:
+		printPlan(plan, machine)
+	}
+
+	if planDetailedExitCode && machine.HasChanges {
+		// os.Exit skips the deferred close
+		_ = tracker.Close()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// printPlan prints the steps of a plan for review
+func printPlan(plan *executor.ExecutionPlan, machine *executor.MachinePlan) {
+	for _, step := range plan.Steps {
+		line := fmt.Sprintf("%3d. %-5s %s (%s)", step.Order, step.Action, step.MigrationID, step.Reason)
+		if len(step.Destructive) > 0 {
+			line += " [destructive: " + strings.Join(step.Destructive, ", ") + "]"
+		}
+		fmt.Println(line)
+	}
+	for _, planErr := range plan.Errors {
+		fmt.Println("error: " + planErr)
+	}
+	if !machine.HasChanges {
+		fmt.Println(msg(i18n.CLIPlanNoChanges, machine.Connection))
+		return
+	}
+	fmt.Println(msg(i18n.CLIPlanSummary, len(machine.Apply), len(machine.Skip), machine.Hash))
+}
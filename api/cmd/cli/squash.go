@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	migrationpkg "github.com/toolsascode/bfm/api/migrations"
+)
+
+// squashName is the name of the squashed baseline migrations bfm squash generates
+const squashName = "squashed_baseline"
+
+// squashDownSQL refuses to revert a squashed baseline, which would drop the whole schema
+const squashDownSQL = `-- A squashed baseline cannot be reverted
+DO $$ BEGIN RAISE EXCEPTION 'squashed baseline migrations cannot be reverted'; END $$;
+`
+
+var (
+	squashBefore     string
+	squashSchema     string
+	squashArchiveDir string
+	squashPgDump     string
+	squashDryRun     bool
+)
+
+var squashCmd = &cobra.Command{
+	Use:   "squash <connection>",
+	Short: "Consolidate the migrations older than a version into one baseline migration",
+	Long: `Squash replaces every fixed-schema migration of a connection older than --before with a
+single baseline migration generated from the current schema of the database (pg_dump
+--schema-only; PostgreSQL connections only):
+
+  1. The database must be migrated up to --before: every migration to squash applied, none
+     newer applied, so the dump holds exactly the schema they create.
+  2. The baseline is written as {version}_squashed_baseline in the connection's directory,
+     with the version of the newest squashed migration and a "-- bfm:squash <before>" line.
+  3. The squashed files are moved to --archive-dir, out of the migration directories.
+  4. The baseline is recorded as applied in the state database.
+
+Fresh databases then apply the baseline instead of the squashed migrations. A database that
+already applied the squashed migrations (another environment) records the baseline as applied
+without executing it. Dynamic-schema migrations are kept, and migrations depending on a
+migration to squash must drop that dependency first.
+
+The state database is read from the same BFM_STATE_* environment variables as the server,
+and connections from the {CONNECTION}_* variables and BFM_CONNECTIONS_FILE.
+
+Example:
+  bfm squash core --before 20240101000000 -p /path/to/sfm --dry-run
+  bfm squash core --before 20240101000000 -p /path/to/sfm --archive-dir /path/to/sfm-archive`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runSquash,
+	SilenceUsage: true,
+}
+
+func init() {
+	squashCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory, or comma-separated SFM roots (default: ./examples/sfm)")
+	squashCmd.Flags().StringVar(&squashBefore, "before", "", "Squash the migrations older than this version (YYYYMMDDHHMMSS)")
+	squashCmd.Flags().StringVar(&squashSchema, "schema", "", "Schema of the baseline migration (default: the schema of the squashed migrations)")
+	squashCmd.Flags().StringVar(&squashArchiveDir, "archive-dir", "", "Directory the squashed files are moved to (default: {sfm path}-archive)")
+	squashCmd.Flags().StringVar(&squashPgDump, "pg-dump", "pg_dump", "pg_dump executable")
+	squashCmd.Flags().BoolVar(&squashDryRun, "dry-run", false, "Report what would be squashed without writing anything")
+	_ = squashCmd.MarkFlagRequired("before")
+
+	rootCmd.AddCommand(squashCmd)
+}
+
+func runSquash(cmd *cobra.Command, args []string) error {
+	connection := args[0]
+	if _, err := time.Parse(versionLayout, squashBefore); err != nil || len(squashBefore) != len(versionLayout) {
+		return errorf(i18n.CLISquashInvalidVersion, squashBefore)
+	}
+	if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+	roots := strings.Split(sfmPath, ",")
+
+	reg := registry.NewInMemoryRegistry()
+	if err := executor.NewLoader(roots...).LoadAll(reg); err != nil {
+		return errorf(i18n.CLILoadMigrationsFailed, sfmPath, err)
+	}
+
+	migrations := reg.GetByConnection(connection)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	var squashed, kept []*backends.MigrationScript
+	for _, migration := range migrations {
+		switch {
+		case migration.Version >= squashBefore:
+			kept = append(kept, migration)
+		case migration.Schema == "":
+			fmt.Println(msg(i18n.CLISquashKeptDynamic, migrationFileName(migration)))
+			kept = append(kept, migration)
+		default:
+			squashed = append(squashed, migration)
+		}
+	}
+	if len(squashed) == 0 {
+		return errorf(i18n.CLISquashNothing, connection, squashBefore)
+	}
+	backend := squashed[0].Backend
+	if backend != "postgresql" {
+		return errorf(i18n.CLISquashUnsupportedBackend, connection, backend)
+	}
+	if err := checkSquashDependents(squashed, kept); err != nil {
+		return err
+	}
+
+	schema, schemas := squashSchema, squashedSchemas(squashed)
+	if schema == "" {
+		if len(schemas) > 1 {
+			return errorf(i18n.CLISquashSchemaRequired, strings.Join(schemas, ", "))
+		}
+		schema = schemas[0]
+	}
+
+	connections, err := config.LoadConnections(os.Getenv("BFM_CONNECTIONS_FILE"))
+	if err != nil {
+		return errorf(i18n.CLIConnectionsLoadFailed, err)
+	}
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+	e := executor.NewExecutor(reg, tracker)
+	if err := e.SetConnections(connections); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	connCfg, err := e.GetConnectionConfig(ctx, connection)
+	if err != nil {
+		return err
+	}
+
+	// The dump must hold exactly the schema of the squashed migrations
+	for _, migration := range squashed {
+		if applied, err := tracker.IsMigrationApplied(ctx, migrationID(migration)); err != nil {
+			return err
+		} else if !applied {
+			return errorf(i18n.CLISquashNotApplied, migrationFileName(migration))
+		}
+	}
+	for _, migration := range kept {
+		if migration.Schema == "" {
+			continue
+		}
+		if applied, err := tracker.IsMigrationApplied(ctx, migrationID(migration)); err != nil {
+			return err
+		} else if applied {
+			return errorf(i18n.CLISquashAppliedAfter, migrationFileName(migration))
+		}
+	}
+
+	dump, err := dumpSchema(ctx, connCfg, schemas)
+	if err != nil {
+		return err
+	}
+
+	baseline := &backends.MigrationScript{
+		Schema:     schema,
+		Version:    squashed[len(squashed)-1].Version,
+		Name:       squashName,
+		Connection: connection,
+		Backend:    backend,
+		UpSQL: fmt.Sprintf("%s\n-- Consolidates %d migration(s) of %s older than %s, generated by bfm squash\n-- from pg_dump --schema-only on %s.\n\n%s",
+			backends.SquashDirective(squashBefore), len(squashed), connection, squashBefore, time.Now().UTC().Format(time.RFC3339), dump),
+		DownSQL: squashDownSQL,
+	}
+	dir := filepath.Join(migrationRoot(roots, squashed[0]), backend, connection)
+	archiveDir := squashArchiveDir
+	if archiveDir == "" {
+		archiveDir = filepath.Clean(roots[0]) + "-archive"
+	}
+	archiveDir = filepath.Join(archiveDir, backend, connection)
+
+	if squashDryRun {
+		for _, migration := range squashed {
+			fmt.Println(msg(i18n.CLISquashWouldArchive, migrationFileName(migration), archiveDir))
+		}
+		fmt.Println(msg(i18n.CLISquashDryRun, len(squashed), filepath.Join(dir, migrationFileName(baseline)+".up.sql")))
+		return nil
+	}
+
+	if err := writeSquashFiles(dir, baseline); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return errorf(i18n.CLICreateDirectoryFailed, archiveDir, err)
+	}
+	for _, migration := range squashed {
+		for _, root := range roots {
+			files, _ := filepath.Glob(filepath.Join(root, migration.Backend, migration.Connection, migrationFileName(migration)+".*"))
+			for _, file := range files {
+				if err := os.Rename(file, filepath.Join(archiveDir, filepath.Base(file))); err != nil {
+					return errorf(i18n.CLISquashArchiveFailed, file, err)
+				}
+			}
+		}
+	}
+
+	executedBy := os.Getenv("USER")
+	if executedBy == "" {
+		executedBy = "cli"
+	}
+	recordCtx := executor.SetExecutionContext(ctx, executedBy, "cli", map[string]interface{}{"squashed": len(squashed)})
+	id, err := e.RecordSquash(recordCtx, baseline)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(msg(i18n.CLISquashed, len(squashed), connection, squashBefore, id, archiveDir))
+	return nil
+}
+
+// migrationFileName returns the {version}_{name} base name of the files of a migration
+func migrationFileName(migration *backends.MigrationScript) string {
+	return migration.Version + "_" + migration.Name
+}
+
+// migrationID returns the ID a fixed-schema migration is tracked under
+func migrationID(migration *backends.MigrationScript) string {
+	return fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
+}
+
+// migrationRoot returns the SFM root holding the files of migration, the first root if none does
+func migrationRoot(roots []string, migration *backends.MigrationScript) string {
+	for _, root := range roots {
+		files, _ := filepath.Glob(filepath.Join(root, migration.Backend, migration.Connection, migrationFileName(migration)+".*"))
+		if len(files) > 0 {
+			return root
+		}
+	}
+	return roots[0]
+}
+
+// squashedSchemas returns the sorted schemas of the squashed migrations
+func squashedSchemas(squashed []*backends.MigrationScript) []string {
+	seen := make(map[string]bool)
+	var schemas []string
+	for _, migration := range squashed {
+		if !seen[migration.Schema] {
+			seen[migration.Schema] = true
+			schemas = append(schemas, migration.Schema)
+		}
+	}
+	sort.Strings(schemas)
+	return schemas
+}
+
+// checkSquashDependents refuses to squash migrations a kept migration depends on by name or
+// version: the dependency could not be resolved once they are archived
+func checkSquashDependents(squashed, kept []*backends.MigrationScript) error {
+	byName := make(map[string]*backends.MigrationScript)
+	byVersion := make(map[string]*backends.MigrationScript)
+	for _, migration := range squashed {
+		byName[migration.Name] = migration
+		byVersion[migration.Version] = migration
+	}
+	for _, migration := range kept {
+		for _, name := range migration.Dependencies {
+			if dep, ok := byName[name]; ok {
+				return errorf(i18n.CLISquashDependedOn, migrationFileName(migration), migrationFileName(dep))
+			}
+		}
+		for _, dep := range migration.StructuredDependencies {
+			if dep.Connection != "" && dep.Connection != migration.Connection {
+				continue
+			}
+			target := byName[dep.Target]
+			switch {
+			case dep.TargetType == "min_version":
+				// The baseline is at or above every squashed version
+				target = nil
+			case dep.IsPattern():
+				target = squashedTarget(dep, squashed, kept)
+			case dep.TargetType == "version":
+				target = byVersion[dep.Target]
+			}
+			if target != nil {
+				return errorf(i18n.CLISquashDependedOn, migrationFileName(migration), migrationFileName(target))
+			}
+		}
+	}
+	return nil
+}
+
+// squashedTarget returns a squashed migration a version range or name prefix dependency targets,
+// or nil when the dependency does not need one: a version range is met by any kept migration
+func squashedTarget(dep backends.Dependency, squashed, kept []*backends.MigrationScript) *backends.MigrationScript {
+	if dep.AnyTarget() {
+		for _, migration := range kept {
+			if dep.MatchesTarget(migration.Version, migration.Name) {
+				return nil
+			}
+		}
+	}
+	for _, migration := range squashed {
+		if dep.MatchesTarget(migration.Version, migration.Name) {
+			return migration
+		}
+	}
+	return nil
+}
+
+// dumpSchema returns the schema-only dump of schemas in the database of connCfg. psql
+// meta-commands (\restrict) are dropped, as the backend runs the dump as plain SQL.
+func dumpSchema(ctx context.Context, connCfg *backends.ConnectionConfig, schemas []string) (string, error) {
+	args := []string{"--schema-only", "--no-owner", "--no-privileges",
+		"-h", connCfg.Host, "-p", connCfg.Port, "-U", connCfg.Username, "-d", connCfg.Database}
+	for _, schema := range schemas {
+		args = append(args, "-n", schema)
+	}
+	cmd := exec.CommandContext(ctx, squashPgDump, args...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+connCfg.Password)
+	if connCfg.SSLMode != "" {
+		cmd.Env = append(cmd.Env, "PGSSLMODE="+connCfg.SSLMode)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errorf(i18n.CLISquashDumpFailed, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, `\`) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// writeSquashFiles writes the up, down and .go files of the baseline migration in dir
+func writeSquashFiles(dir string, baseline *backends.MigrationScript) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errorf(i18n.CLICreateDirectoryFailed, dir, err)
+	}
+	base := filepath.Join(dir, migrationFileName(baseline))
+	for _, file := range []struct{ path, content string }{
+		{base + ".up.sql", baseline.UpSQL},
+		{base + ".down.sql", baseline.DownSQL},
+	} {
+		if err := os.WriteFile(file.path, []byte(file.content), 0644); err != nil {
+			return errorf(i18n.CLIWriteFailed, file.path, err)
+		}
+		fmt.Println(msg(i18n.CLICreated, file.path))
+	}
+
+	// The .go file gives the baseline its fixed schema
+	tmpl, err := template.New("migration").Parse(migrationpkg.GoFileTemplate)
+	if err != nil {
+		return errorf(i18n.CLITemplateParseFailed, err)
+	}
+	var goFile bytes.Buffer
+	if err := tmpl.Execute(&goFile, map[string]string{
+		"PackageName":  baseline.Connection,
+		"UpFileName":   filepath.Base(base + ".up.sql"),
+		"DownFileName": filepath.Base(base + ".down.sql"),
+		"Version":      baseline.Version,
+		"Name":         baseline.Name,
+		"Connection":   baseline.Connection,
+		"Backend":      baseline.Backend,
+		"Dependencies": "",
+		"TagsGo":       "",
+	}); err != nil {
+		return errorf(i18n.CLITemplateParseFailed, err)
+	}
+	content := strings.Replace(goFile.String(), `Schema:       "", // Dynamic - provided in request`, fmt.Sprintf("Schema:       %q,", baseline.Schema), 1)
+	if err := os.WriteFile(base+".go", []byte(content), 0644); err != nil {
+		return errorf(i18n.CLIWriteFailed, base+".go", err)
+	}
+	fmt.Println(msg(i18n.CLICreated, base+".go"))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+	stateetcd "github.com/toolsascode/bfm/api/internal/state/etcd"
+	statepg "github.com/toolsascode/bfm/api/internal/state/postgresql"
+	statesqlite "github.com/toolsascode/bfm/api/internal/state/sqlite"
+)
+
+// stateTracker is a state tracker opened by the CLI, which closes it when done
+type stateTracker interface {
+	state.StateTracker
+	Close() error
+}
+
+// newStateTracker opens the state database from the BFM_STATE_* variables. With
+// BFM_STATE_BACKEND=sqlite the state is kept in the BFM_STATE_DB_PATH file, so no database
+// server is needed; with BFM_STATE_BACKEND=etcd it is kept in etcd (BFM_STATE_ETCD_ENDPOINTS).
+func newStateTracker() (stateTracker, error) {
+	cfg := config.LoadStateDBFromEnv()
+	switch cfg.StateDB.Type {
+	case "postgresql":
+		stateConnStr := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s %s",
+			cfg.StateDB.Host,
+			cfg.StateDB.Port,
+			cfg.StateDB.Username,
+			cfg.StateDB.Password,
+			cfg.StateDB.Database,
+			backends.PostgresSSLParams(cfg.StateDB.SSLMode, cfg.StateDB.SSLRootCert, cfg.StateDB.SSLCert, cfg.StateDB.SSLKey),
+		)
+		tracker, err := statepg.NewTracker(stateConnStr, cfg.StateDB.Schema)
+		if err != nil {
+			return nil, errorf(i18n.CLIStateConnectFailed, err)
+		}
+		return tracker, nil
+	case "sqlite":
+		tracker, err := statesqlite.NewTracker(cfg.StateDB.Path)
+		if err != nil {
+			return nil, errorf(i18n.CLIStateOpenFailed, cfg.StateDB.Path, err)
+		}
+		return tracker, nil
+	case "etcd":
+		tracker, err := stateetcd.NewTracker(cfg.StateDB.Endpoints, cfg.StateDB.Username, cfg.StateDB.Password, cfg.StateDB.Prefix, cfg.StateDB.HistoryLimit)
+		if err != nil {
+			return nil, errorf(i18n.CLIEtcdConnectFailed, err)
+		}
+		return tracker, nil
+	default:
+		return nil, errorf(i18n.CLIUnsupportedState, cfg.StateDB.Type)
+	}
+}
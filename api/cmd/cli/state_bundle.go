@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+var stateExportOutput string
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Export and import the migration state",
+}
+
+var stateExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the migration state as a portable JSON bundle",
+	Long: `Export writes the whole migration state (migrations_list, migrations_history and
+migrations_executions) as a JSON bundle, which bfm state import loads into another state
+database, of any backend. Use it to move bfm between environments, or to keep a copy from
+which a lost state database can be rebuilt.
+
+The state database is read from the same BFM_STATE_* environment variables as the server.
+The API offers the same export on GET /api/v1/admin/state/export (admin token).
+
+Example:
+  bfm state export -o state.json
+  BFM_STATE_BACKEND=sqlite BFM_STATE_DB_PATH=replica.db bfm state export > state.json`,
+	Args:         cobra.NoArgs,
+	RunE:         runStateExport,
+	SilenceUsage: true,
+}
+
+var stateImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a state bundle into an empty state database",
+	Long: `Import loads a bundle written by bfm state export into the state database. The state
+database must have no migration history, so a bundle is never merged into the state of
+another environment; migrations registered by a scan of the migration directories are fine.
+
+History is replayed oldest first, so every migration ends with the status, checksum and
+status labels it was exported with. Use - to read the bundle from stdin.
+
+The state database is read from the same BFM_STATE_* environment variables as the server.
+The API offers the same import on POST /api/v1/admin/state/import (admin token).
+
+Example:
+  bfm state import state.json
+  BFM_STATE_BACKEND=postgresql bfm state import - < state.json`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runStateImport,
+	SilenceUsage: true,
+}
+
+func init() {
+	stateExportCmd.Flags().StringVarP(&stateExportOutput, "output", "o", "", "File to write (default: stdout)")
+
+	stateCmd.AddCommand(stateExportCmd)
+	stateCmd.AddCommand(stateImportCmd)
+	rootCmd.AddCommand(stateCmd)
+}
+
+func runStateExport(cmd *cobra.Command, args []string) error {
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+
+	bundle, err := state.ExportBundle(context.Background(), tracker)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if stateExportOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(stateExportOutput, data, 0o600); err != nil {
+		return errorf(i18n.CLICreateFileFailed, stateExportOutput, err)
+	}
+	fmt.Println(msg(i18n.CLIStateExported, len(bundle.Migrations), len(bundle.History), len(bundle.Executions)))
+	return nil
+}
+
+func runStateImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return errorf(i18n.CLIStateBundleUnreadable, path, err)
+	}
+	var bundle state.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return errorf(i18n.CLIStateBundleUnreadable, path, err)
+	}
+
+	tracker, err := newStateTracker()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracker.Close() }()
+
+	result, err := state.ImportBundle(context.Background(), tracker, &bundle)
+	if err != nil {
+		return err
+	}
+	fmt.Println(msg(i18n.CLIStateImported, result.Migrations, result.History, result.Executions))
+	return nil
+}
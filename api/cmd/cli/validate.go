@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [sfm-path]",
+	Short: "Lint the SFM tree before deploy",
+	Long: `Validate walks the SFM directory and reports problems before deploy:
+
+  - up files without down files (and the reverse)
+  - invalid version formats (expected 14-digit YYYYMMDDHHMMSS)
+  - duplicate versions within a connection
+  - dependencies that do not resolve (or form a cycle)
+  - SQL/JSON scripts that fail a basic parse
+
+Exits non-zero when issues are found so CI can gate on it.
+
+Example:
+  bfm validate examples/sfm
+  bfm validate -p /path/to/sfm`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&sfmPath, "path", "p", "", "Path to SFM directory (default: first argument or ./examples/sfm)")
+	validateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		sfmPath = args[0]
+	} else if sfmPath == "" {
+		sfmPath = "./examples/sfm"
+	}
+
+	if verbose {
+		fmt.Println(msg(i18n.CLIValidating, sfmPath))
+	}
+
+	issues, err := executor.ValidateSFM(sfmPath)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue.String())
+	}
+	if len(issues) > 0 {
+		return errorf(i18n.CLIValidationFailed, len(issues))
+	}
+
+	fmt.Println(msg(i18n.CLINoIssues))
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+)
+
+var (
+	waitServer   string
+	waitToken    string
+	waitSchemas  []string
+	waitTimeout  time.Duration
+	waitInterval time.Duration
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait <connection>...",
+	Short: "Wait until every migration of the given connections is applied",
+	Long: `Wait polls GET /api/v1/migrations/ready on a bfm server until no migration of the given
+connections is pending, then exits 0. It exits non-zero when --timeout expires, or at once
+when the server refuses the request (unknown connection, invalid token).
+
+Run it as an init container so application pods don't start against an un-migrated
+database. The server being unreachable or still starting counts as not ready yet.
+
+The server URL and token default to BFM_SERVER_URL and BFM_API_TOKEN.
+
+Example:
+  bfm wait core
+  bfm wait core metrics --server http://bfm:7070 --timeout 15m
+  bfm wait tenants --schemas tenant_a,tenant_b`,
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         runWait,
+	SilenceUsage: true,
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitServer, "server", "", "URL of the bfm server (default: BFM_SERVER_URL, or http://localhost:7070)")
+	waitCmd.Flags().StringVar(&waitToken, "token", "", "API token (default: BFM_API_TOKEN)")
+	waitCmd.Flags().StringSliceVar(&waitSchemas, "schemas", nil, "Schemas of dynamic-schema migrations")
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 10*time.Minute, "How long to wait before giving up")
+	waitCmd.Flags().DurationVar(&waitInterval, "interval", 5*time.Second, "Time between checks")
+
+	rootCmd.AddCommand(waitCmd)
+}
+
+// waitResponse is the part of the /migrations/ready response wait reports
+type waitResponse struct {
+	Ready   bool   `json:"ready"`
+	Pending int    `json:"pending"`
+	Error   string `json:"error"`
+}
+
+func runWait(cmd *cobra.Command, args []string) error {
+	server := waitServer
+	if server == "" {
+		server = os.Getenv("BFM_SERVER_URL")
+	}
+	if server == "" {
+		server = "http://localhost:7070"
+	}
+	token := waitToken
+	if token == "" {
+		token = os.Getenv("BFM_API_TOKEN")
+	}
+
+	query := url.Values{"connection": args}
+	for _, schema := range waitSchemas {
+		query.Add("schemas", schema)
+	}
+	readyURL := strings.TrimSuffix(server, "/") + "/api/v1/migrations/ready?" + query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	for {
+		ready, err := checkReady(ctx, client, readyURL, token)
+		if err != nil {
+			return err
+		}
+		if ready {
+			fmt.Println(msg(i18n.CLIWaitReady, strings.Join(args, ", ")))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errorf(i18n.CLIWaitTimedOut, waitTimeout, strings.Join(args, ", "))
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// checkReady makes one readiness check. It reports false for a server that is not ready yet
+// (pending migrations, starting, unreachable) and returns an error for one that refused the request.
+func checkReady(ctx context.Context, client *http.Client, readyURL, token string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, readyURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Println(msg(i18n.CLIWaitUnreachable, err))
+		}
+		return false, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(resp.Body)
+	var body waitResponse
+	_ = json.Unmarshal(data, &body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		// Pending migrations, or a server still starting (no pending count)
+		if body.Error != "" {
+			fmt.Println(msg(i18n.CLIWaitUnreachable, body.Error))
+		} else {
+			fmt.Println(msg(i18n.CLIWaitPending, body.Pending))
+		}
+		return false, nil
+	case resp.StatusCode >= http.StatusInternalServerError:
+		fmt.Println(msg(i18n.CLIWaitUnreachable, http.StatusText(resp.StatusCode)))
+		return false, nil
+	default:
+		message := body.Error
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return false, errorf(i18n.CLIWaitRefused, resp.StatusCode, message)
+	}
+}
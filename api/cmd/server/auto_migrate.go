@@ -37,6 +37,16 @@ func etcdEndpointsExtraNonEmpty(extra map[string]string) bool {
 	return false
 }
 
+// extraNonEmpty reports whether extra has a non-empty value for name (case-insensitive key)
+func extraNonEmpty(extra map[string]string, name string) bool {
+	for k, v := range extra {
+		if strings.EqualFold(strings.TrimSpace(k), name) && strings.TrimSpace(v) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // connectionConfigReadyForAutoMigrate reports whether conn has the minimum fields the corresponding
 // backend expects, so we do not dial empty hosts or etcd with no endpoints (avoids log spam).
 func connectionConfigReadyForAutoMigrate(conn *backends.ConnectionConfig) bool {
@@ -48,6 +58,11 @@ func connectionConfigReadyForAutoMigrate(conn *backends.ConnectionConfig) bool {
 		return strings.TrimSpace(conn.Host) != ""
 	case "greptimedb":
 		return strings.TrimSpace(conn.Host) != ""
+	case "cassandra", "consul", "vault":
+		return strings.TrimSpace(conn.Host) != ""
+	case "spanner":
+		// Host is optional (public endpoint); the database must be addressable
+		return extraNonEmpty(conn.Extra, "project") && extraNonEmpty(conn.Extra, "instance")
 	case "etcd":
 		if etcdEndpointsExtraNonEmpty(conn.Extra) {
 			return true
@@ -111,6 +126,46 @@ func sumPendingAutoMigratable(ctx context.Context, exec *executor.Executor, conn
 	return total, nil
 }
 
+// autoMigrateConnections returns the connections auto-migrate and one-shot mode run: those named by
+// BFM_AUTO_MIGRATE_CONNECTIONS (comma-separated; all configured connections when unset) whose
+// config is complete for their backend, sorted by name
+func autoMigrateConnections(cfg *config.Config) []autoMigrateConn {
+	filterRaw := strings.TrimSpace(os.Getenv("BFM_AUTO_MIGRATE_CONNECTIONS"))
+	var allow map[string]bool
+	if filterRaw != "" {
+		allow = make(map[string]bool)
+		for _, p := range strings.Split(filterRaw, ",") {
+			k := strings.TrimSpace(strings.ToLower(p))
+			if k != "" {
+				allow[k] = true
+			}
+		}
+	}
+
+	connNames := make([]string, 0, len(cfg.Connections))
+	for name := range cfg.Connections {
+		connNames = append(connNames, name)
+	}
+	sort.Strings(connNames)
+
+	var toRun []autoMigrateConn
+	for _, connName := range connNames {
+		if allow != nil && !allow[strings.ToLower(connName)] {
+			continue
+		}
+		connCfg := cfg.Connections[connName]
+		if connCfg == nil {
+			continue
+		}
+		if !connectionConfigReadyForAutoMigrate(connCfg) {
+			logger.Infof("Auto-migrate: skipping connection %q (backend=%s): incomplete connection config for auto-migrate", connName, connCfg.Backend)
+			continue
+		}
+		toRun = append(toRun, autoMigrateConn{name: connName, cfg: connCfg})
+	}
+	return toRun
+}
+
 // startAutoMigrateBackground runs pending migrations per configured connection after startup,
 // retrying in bounded rounds until fixed-schema work is cleared, a stall is detected, or limits hit.
 // It uses the same ExecuteUp path as the HTTP API (synchronous execution, not the job queue).
@@ -127,18 +182,6 @@ func startAutoMigrateBackground(ctx context.Context, exec *executor.Executor, cf
 		return
 	}
 
-	filterRaw := strings.TrimSpace(os.Getenv("BFM_AUTO_MIGRATE_CONNECTIONS"))
-	var allow map[string]bool
-	if filterRaw != "" {
-		allow = make(map[string]bool)
-		for _, p := range strings.Split(filterRaw, ",") {
-			k := strings.TrimSpace(strings.ToLower(p))
-			if k != "" {
-				allow[k] = true
-			}
-		}
-	}
-
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -154,27 +197,7 @@ func startAutoMigrateBackground(ctx context.Context, exec *executor.Executor, cf
 			logger.Info("Auto-migrate: single round only (BFM_AUTO_MIGRATE_RETRY_INTERVAL is 0 or invalid)")
 		}
 
-		connNames := make([]string, 0, len(cfg.Connections))
-		for name := range cfg.Connections {
-			connNames = append(connNames, name)
-		}
-		sort.Strings(connNames)
-
-		var toRun []autoMigrateConn
-		for _, connName := range connNames {
-			if allow != nil && !allow[strings.ToLower(connName)] {
-				continue
-			}
-			connCfg := cfg.Connections[connName]
-			if connCfg == nil {
-				continue
-			}
-			if !connectionConfigReadyForAutoMigrate(connCfg) {
-				logger.Infof("Auto-migrate: skipping connection %q (backend=%s): incomplete connection config for auto-migrate", connName, connCfg.Backend)
-				continue
-			}
-			toRun = append(toRun, autoMigrateConn{name: connName, cfg: connCfg})
-		}
+		toRun := autoMigrateConnections(cfg)
 
 		for round := 1; round <= maxRounds; round++ {
 			select {
@@ -107,6 +107,26 @@ func Test_connectionConfigReadyForAutoMigrate(t *testing.T) {
 			conn: &backends.ConnectionConfig{Backend: "greptimedb", Host: ""},
 			want: false,
 		},
+		{
+			name: "cassandra contact points set",
+			conn: &backends.ConnectionConfig{Backend: "cassandra", Host: "cass1,cass2"},
+			want: true,
+		},
+		{
+			name: "cassandra host empty",
+			conn: &backends.ConnectionConfig{Backend: "cassandra", Host: ""},
+			want: false,
+		},
+		{
+			name: "spanner project and instance set without host",
+			conn: &backends.ConnectionConfig{Backend: "spanner", Extra: map[string]string{"PROJECT": "p", "INSTANCE": "i"}},
+			want: true,
+		},
+		{
+			name: "spanner instance missing",
+			conn: &backends.ConnectionConfig{Backend: "spanner", Host: "emulator", Extra: map[string]string{"PROJECT": "p"}},
+			want: false,
+		},
 		{
 			name: "etcd endpoints lowercase",
 			conn: &backends.ConnectionConfig{Backend: "etcd", Extra: map[string]string{"endpoints": "http://etcd:2379"}},
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// singlePortHandler serves gRPC and HTTP on one listener (BFM_SINGLE_PORT=true), for ingresses
+// that allocate a single port per service. gRPC clients speak cleartext HTTP/2 (h2c) and are
+// recognized by their content type; everything else, including gRPC-Web, goes to the HTTP router.
+func singlePortHandler(grpcServer *grpc.Server, router http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && isGRPCContentType(r.Header.Get("Content-Type")) {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
+
+// isGRPCContentType reports whether contentType is gRPC (application/grpc or application/grpc+proto),
+// as opposed to gRPC-Web (application/grpc-web), which the HTTP router's Connect handler serves
+func isGRPCContentType(contentType string) bool {
+	return contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,22 +13,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/toolsascode/bfm/api/internal/api/connectapi"
 	httpapi "github.com/toolsascode/bfm/api/internal/api/http"
 	pbapi "github.com/toolsascode/bfm/api/internal/api/protobuf"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/backends/cassandra"
+	"github.com/toolsascode/bfm/api/internal/backends/consul"
 	"github.com/toolsascode/bfm/api/internal/backends/etcd"
 	"github.com/toolsascode/bfm/api/internal/backends/greptimedb"
 	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/backends/spanner"
+	"github.com/toolsascode/bfm/api/internal/backends/vault"
 	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/events"
 	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/invalidation"
 	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/metrics"
+	"github.com/toolsascode/bfm/api/internal/notify"
 	"github.com/toolsascode/bfm/api/internal/queuefactory"
 	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/secrets"
 	"github.com/toolsascode/bfm/api/internal/state"
+	stateetcd "github.com/toolsascode/bfm/api/internal/state/etcd"
 	statepg "github.com/toolsascode/bfm/api/internal/state/postgresql"
+	statesqlite "github.com/toolsascode/bfm/api/internal/state/sqlite"
+	"github.com/toolsascode/bfm/api/internal/tracing"
+	"github.com/toolsascode/bfm/api/internal/worker"
 
 	_ "github.com/toolsascode/bfm/api/docs"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"google.golang.org/grpc"
 )
 
@@ -60,26 +79,56 @@ import (
 // @description API token authentication. Include the token in the Authorization header: Authorization: Bearer {BFM_API_TOKEN}
 
 func main() {
+	oneshot := flag.Bool("oneshot", false, "Apply the pending migrations of the configured connections, print a summary and exit with its status, without starting the HTTP or gRPC servers (for Kubernetes Jobs and Helm hooks)")
+	oneshotSchemas := flag.String("schemas", "", "With --oneshot, comma-separated schemas to apply dynamic-schema migrations in")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadFromEnv()
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
+	// Locale of API errors for requests without an Accept-Language header; validated by the config
+	_ = i18n.SetDefault(cfg.Locale)
+	features.Configure(cfg.Features.Flags)
+	if len(cfg.Features.Flags) > 0 {
+		logger.Infof("Feature flags: %s", cfg.Features.Flags)
+	}
+	features.SetOverrideRole(cfg.Features.OverrideRole)
 
 	rootCtx, rootCancel := context.WithCancel(context.Background())
 	defer rootCancel()
 
-	// Initialize state tracker
-	stateConnStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.StateDB.Host,
-		cfg.StateDB.Port,
-		cfg.StateDB.Username,
-		cfg.StateDB.Password,
-		cfg.StateDB.Database,
-	)
+	// Tracing is exported when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(rootCtx, "bfm-server")
+	if err != nil {
+		logger.Fatalf("Failed to set up tracing: %v", err)
+	}
 
-	stateTracker, err := statepg.NewTracker(stateConnStr, cfg.StateDB.Schema)
+	// Initialize state tracker
+	var stateTracker interface {
+		state.StateTracker
+		Close() error
+	}
+	switch cfg.StateDB.Type {
+	case "postgresql":
+		stateConnStr := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s %s",
+			cfg.StateDB.Host,
+			cfg.StateDB.Port,
+			cfg.StateDB.Username,
+			cfg.StateDB.Password,
+			cfg.StateDB.Database,
+			backends.PostgresSSLParams(cfg.StateDB.SSLMode, cfg.StateDB.SSLRootCert, cfg.StateDB.SSLCert, cfg.StateDB.SSLKey),
+		)
+		stateTracker, err = statepg.NewTracker(stateConnStr, cfg.StateDB.Schema)
+	case "sqlite":
+		stateTracker, err = statesqlite.NewTracker(cfg.StateDB.Path)
+	case "etcd":
+		stateTracker, err = stateetcd.NewTracker(cfg.StateDB.Endpoints, cfg.StateDB.Username, cfg.StateDB.Password, cfg.StateDB.Prefix, cfg.StateDB.HistoryLimit)
+	default:
+		logger.Fatalf("Unsupported state backend: %s", cfg.StateDB.Type)
+	}
 	if err != nil {
 		logger.Fatalf("Failed to initialize state tracker: %v", err)
 	}
@@ -88,21 +137,92 @@ func main() {
 	logger.Info("Initializing BFM server...")
 
 	// Initialize executor (using global registry)
-	exec := executor.NewExecutor(registry.GlobalRegistry, stateTracker)
+	exec := executor.NewExecutor(registry.GlobalRegistry, metrics.InstrumentStateTracker(stateTracker))
 	if err := exec.SetConnections(cfg.Connections); err != nil {
 		logger.Fatalf("Failed to set connections: %v", err)
 	}
+	// Connection passwords may reference secrets (vault:..., awssm:...), resolved when used
+	resolver := secrets.NewResolver(cfg.Secrets.CacheTTL)
+	if cfg.Secrets.VaultAddr != "" {
+		resolver.Register(secrets.ProviderVault, secrets.NewVault(cfg.Secrets.VaultAddr, cfg.Secrets.VaultToken, cfg.Secrets.VaultNamespace))
+	}
+	if cfg.Secrets.AWSRegion != "" {
+		resolver.Register(secrets.ProviderAWS, secrets.NewAWS(cfg.Secrets.AWSRegion, cfg.Secrets.AWSEndpoint, secrets.AWSCredentials{
+			AccessKeyID:     cfg.Secrets.AWSAccessKeyID,
+			SecretAccessKey: cfg.Secrets.AWSSecretAccessKey,
+			SessionToken:    cfg.Secrets.AWSSessionToken,
+		}))
+	}
+	exec.SetSecrets(resolver)
+	// Connections are reloaded from the environment and the connections file, on request
+	// (POST /admin/connections/reload) and when the file changes
+	exec.SetConnectionsSource(func() (map[string]*backends.ConnectionConfig, error) {
+		return config.LoadConnections(cfg.ConnectionsFile)
+	})
+	if cfg.ConnectionsFile != "" {
+		go exec.WatchConnectionsFile(rootCtx, cfg.ConnectionsFile, cfg.ConnectionsReloadInterval)
+	}
+	exec.SetDriftMode(cfg.Execution.DriftMode)
+	exec.SetMigrationTimeout(cfg.Execution.MigrationTimeout)
+	exec.SetDestructivePolicy(cfg.Execution.DestructivePolicy)
+	exec.SetOutOfOrderPolicy(cfg.Execution.OutOfOrderPolicy)
+	schemaPolicy := executor.NewSchemaPolicy(cfg.Execution.SchemaPattern, cfg.Execution.SchemaDeny, cfg.Execution.MaxSchemas)
+	schemaPolicy.MaxConcurrency = cfg.Execution.MaxSchemaConcurrency
+	exec.SetSchemaPolicy(schemaPolicy)
+	exec.SetMaintenanceWindow(executor.MaintenanceWindow{Start: cfg.Scheduler.WindowStart, End: cfg.Scheduler.WindowEnd})
+	exec.SetPostmortemWindow(cfg.Emergency.PostmortemWindow)
+	// A one-shot run is started to migrate, whether or not a server is running in standby
+	exec.SetStandby(cfg.Standby.Enabled && !*oneshot)
+	// The HTTP server starts before migrations are loaded, so probes answer meanwhile: /livez
+	// passes, while /readyz and the API return 503 until the initial scan has finished
+	exec.SetStarting(true)
+
+	// Custom validators from Go plugins (BFM_VALIDATOR_PLUGINS)
+	for _, path := range cfg.Execution.ValidatorPlugins {
+		validator, err := executor.LoadValidatorPlugin(path)
+		if err != nil {
+			logger.Fatalf("Failed to load validator plugin: %v", err)
+		}
+		exec.RegisterValidator(validator)
+		logger.Infof("Registered validator %s from %s", validator.Name(), path)
+	}
+
+	// Log lifecycle events; integrations subscribe to the same bus
+	exec.Events().OnHandlerPanic(func(event events.Event, recovered interface{}) {
+		logger.Errorf("Event subscriber panicked handling %s: %v", event, recovered)
+	})
+	exec.Events().Subscribe(events.All, func(_ context.Context, event events.Event) {
+		logger.Debug("Event: %s", event)
+	})
+	metrics.Subscribe(exec.Events())
+	// Refresh caches after migrations are applied ({CONNECTION}_CACHE_INVALIDATION_URLS)
+	invalidation.NewListener(exec.GetConnectionConfig).Subscribe(exec.Events())
+	// Send emergency runs and overdue postmortems to the notification webhooks (BFM_NOTIFY_URLS)
+	if len(cfg.Notify.URLs) > 0 {
+		notify.NewWebhook(cfg.Notify.URLs, cfg.Notify.Token).Subscribe(exec.Events(), cfg.Notify.Events...)
+	}
 
-	// Initialize queue if enabled
-	if cfg.Queue.Enabled {
+	// Initialize queue if enabled; one-shot runs execute synchronously and consume no jobs
+	if cfg.Queue.Enabled && !*oneshot {
 		queueConfig := &queuefactory.QueueConfig{
 			Type:               cfg.Queue.Type,
 			KafkaBrokers:       cfg.Queue.KafkaBrokers,
 			KafkaTopic:         cfg.Queue.KafkaTopic,
 			KafkaGroupID:       cfg.Queue.KafkaGroupID,
+			KafkaDLQTopic:      cfg.Queue.KafkaDLQTopic,
 			PulsarURL:          cfg.Queue.PulsarURL,
 			PulsarTopic:        cfg.Queue.PulsarTopic,
 			PulsarSubscription: cfg.Queue.PulsarSubscription,
+			PulsarDLQTopic:     cfg.Queue.PulsarDLQTopic,
+			RedisAddr:          cfg.Queue.RedisAddr,
+			RedisPassword:      cfg.Queue.RedisPassword,
+			RedisDB:            cfg.Queue.RedisDB,
+			RedisStream:        cfg.Queue.RedisStream,
+			RedisGroup:         cfg.Queue.RedisGroup,
+			RedisConsumer:      cfg.Queue.RedisConsumer,
+			RedisDLQStream:     cfg.Queue.RedisDLQStream,
+			RedisClaimIdle:     cfg.Queue.RedisClaimIdle,
+			MemoryCapacity:     cfg.Queue.MemoryCapacity,
 		}
 
 		q, err := queuefactory.NewQueue(queueConfig)
@@ -113,6 +233,22 @@ func main() {
 
 		exec.SetQueue(q)
 		logger.Info("Queue enabled - migrations will be queued for async execution")
+
+		// Nothing else consumes an in-process queue: run the worker in the server
+		if queuefactory.InProcess(cfg.Queue.Type) {
+			w := worker.NewWorker(exec, q)
+			w.SetRetryPolicy(worker.RetryPolicy{
+				MaxAttempts: cfg.Queue.RetryMaxAttempts,
+				Backoff:     cfg.Queue.RetryBackoff,
+				MaxBackoff:  cfg.Queue.RetryMaxBackoff,
+			})
+			go func() {
+				if err := w.Start(rootCtx); err != nil && rootCtx.Err() == nil {
+					logger.Errorf("In-process worker error: %v", err)
+				}
+			}()
+			logger.Infof("Running the migration worker in the server process (%s queue)", cfg.Queue.Type)
+		}
 	}
 
 	// Register backends
@@ -125,67 +261,28 @@ func main() {
 	etcdBackend := etcd.NewBackend()
 	exec.RegisterBackend("etcd", etcdBackend)
 
-	// Dynamically load migration scripts from SFM directory
-	sfmPath := os.Getenv("BFM_SFM_PATH")
-	if sfmPath == "" {
-		// Default to ../sfm relative to bfm directory
-		sfmPath = "../sfm"
-	}
-
-	// Validate SFM path exists
-	if _, err := os.Stat(sfmPath); os.IsNotExist(err) {
-		logger.Fatalf("SFM directory does not exist: %s (set BFM_SFM_PATH environment variable)", sfmPath)
-	}
+	cassandraBackend := cassandra.NewBackend()
+	exec.RegisterBackend("cassandra", cassandraBackend)
 
-	logger.Infof("Loading migrations from SFM directory: %s", sfmPath)
+	consulBackend := consul.NewBackend()
+	exec.RegisterBackend("consul", consulBackend)
 
-	loader := executor.NewLoader(sfmPath)
-	loader.SetExecutor(exec) // Set executor so loader can register scanned migrations
-	if err := loader.LoadAll(registry.GlobalRegistry); err != nil {
-		logger.Fatalf("Failed to load migrations from %s: %v", sfmPath, err)
-	}
+	vaultBackend := vault.NewBackend()
+	exec.RegisterBackend("vault", vaultBackend)
 
-	migrationCount := len(registry.GlobalRegistry.GetAll())
-	if migrationCount == 0 {
-		logger.Warnf("No migrations loaded from %s - ensure migration files exist in the expected directory structure", sfmPath)
-	} else {
-		logger.Infof("Successfully loaded %d migration(s) from %s", migrationCount, sfmPath)
+	spannerBackend := spanner.NewBackend()
+	exec.RegisterBackend("spanner", spannerBackend)
 
-		// Log migration breakdown by backend/connection for better visibility
-		allMigrations := registry.GlobalRegistry.GetAll()
-		backendCounts := make(map[string]map[string]int)
-		for _, mig := range allMigrations {
-			if backendCounts[mig.Backend] == nil {
-				backendCounts[mig.Backend] = make(map[string]int)
-			}
-			backendCounts[mig.Backend][mig.Connection]++
-		}
-
-		for backend, connections := range backendCounts {
-			for connection, count := range connections {
-				logger.Infof("  - %s/%s: %d migration(s)", backend, connection, count)
-			}
+	if *oneshot {
+		code := runOneshotMode(rootCtx, exec, cfg, *oneshotSchemas)
+		// os.Exit skips the deferred cleanup
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Warnf("Failed to flush traces: %v", err)
 		}
+		_ = stateTracker.Close()
+		os.Exit(code)
 	}
 
-	// Start watching for new migration files
-	loader.StartWatching()
-	defer loader.StopWatching()
-
-	// Start background reindexer
-	reindexInterval := 5 * time.Minute
-	if intervalStr := os.Getenv("BFM_REINDEX_INTERVAL_MINUTES"); intervalStr != "" {
-		if intervalMinutes, err := time.ParseDuration(intervalStr + "m"); err == nil {
-			reindexInterval = intervalMinutes
-		}
-	}
-	reindexer := state.NewReindexer(stateTracker, registry.GlobalRegistry, reindexInterval)
-	reindexer.Start()
-	defer reindexer.Stop()
-	logger.Infof("Background reindexer started with interval: %v", reindexInterval)
-
-	startAutoMigrateBackground(rootCtx, exec, cfg)
-
 	// Set Gin mode - use BFM_APP_MODE env var if set, otherwise default to release mode
 	if ginMode := os.Getenv("BFM_APP_MODE"); ginMode != "" {
 		gin.SetMode(ginMode)
@@ -203,7 +300,8 @@ func main() {
 	// Custom logger middleware that skips health check endpoints and supports JSON/plaintext
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// Skip logging for health check endpoints
-		if param.Path == "/health" || param.Path == "/api/v1/health" {
+		switch strings.TrimPrefix(param.Path, "/api/v1") {
+		case "/health", "/livez", "/readyz":
 			return ""
 		}
 
@@ -245,7 +343,8 @@ func main() {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		}
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Client-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Client-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Grpc-Timeout, X-Grpc-Web, X-User-Agent")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
@@ -263,6 +362,17 @@ func main() {
 	// Add /health endpoint to prevent 404s (uses same handler as /api/v1/health)
 	router.GET("/health", httpHandler.Health)
 
+	// Liveness and readiness probes at the root, where orchestrators expect them
+	router.GET("/livez", httpHandler.Livez)
+	router.GET("/readyz", httpHandler.Readyz)
+
+	// Prometheus metrics (unauthenticated, like /health)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// MigrationService over Connect and gRPC-Web for browser clients
+	connectPath, connectHandler := connectapi.NewHandler(pbapi.NewServer(exec))
+	router.Any(connectPath+"*procedure", httpHandler.RequireStarted, gin.WrapH(connectHandler))
+
 	// Serve static files from frontend directory if it exists
 	frontendPath := os.Getenv("BFM_FRONTEND_PATH")
 	if frontendPath == "" {
@@ -296,11 +406,24 @@ func main() {
 		logger.Warnf("Frontend directory not found at %s, skipping static file serving", frontendPath)
 	}
 
-	// Start HTTP server
+	pbServer := pbapi.NewServer(exec)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(pbServer.UnaryStartedInterceptor, pbServer.UnaryAuditInterceptor, pbapi.UnaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(pbServer.StreamStartedInterceptor, pbServer.StreamAuditInterceptor, pbapi.StreamAuthInterceptor),
+	)
+	pbapi.RegisterMigrationServiceServer(grpcServer, pbServer)
+
+	// Start HTTP server; each request starts a trace
+	tracedRouter := otelhttp.NewHandler(router, "http.server")
 	httpServer := &http.Server{
 		Addr:    ":" + cfg.Server.HTTPPort,
-		Handler: router,
+		Handler: tracedRouter,
+	}
+	if cfg.Server.SinglePort {
+		httpServer.Handler = singlePortHandler(grpcServer, tracedRouter)
 	}
+	// Event streams never end on their own; end them so shutdown doesn't wait for their clients
+	httpServer.RegisterOnShutdown(httpHandler.CloseStreams)
 
 	go func() {
 		logger.Infof("Starting HTTP server on port %s", cfg.Server.HTTPPort)
@@ -309,26 +432,102 @@ func main() {
 		}
 	}()
 
-	// Start gRPC server
-	grpcServer := grpc.NewServer()
-	pbServer := pbapi.NewServer(exec)
-	pbapi.RegisterMigrationServiceServer(grpcServer, pbServer)
+	loader := newMigrationLoader(exec, cfg)
+	sfmPathList := strings.Join(cfg.Loader.SFMPaths, ", ")
+	if cfg.DevMode.Enabled {
+		loader.SetDevMode(cfg.DevMode.Connection, cfg.DevMode.Schemas, cfg.DevMode.WatchInterval)
+		logger.Warnf("Developer mode is enabled: new and edited migrations are applied to connection %s as soon as they are detected, and drift does not block them. Never enable it against a shared database.", cfg.DevMode.Connection)
+	}
+	if err := loader.LoadAll(registry.GlobalRegistry); err != nil {
+		logger.Fatalf("Failed to load migrations from %s: %v", sfmPathList, err)
+	}
+	exec.SetStarting(false)
 
-	grpcListener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
-	if err != nil {
-		logger.Fatalf("Failed to listen on gRPC port %s: %v", cfg.Server.GRPCPort, err)
+	migrationCount := len(registry.GlobalRegistry.GetAll())
+	if migrationCount == 0 {
+		logger.Warnf("No migrations loaded from %s - ensure migration files exist in the expected directory structure", sfmPathList)
+	} else {
+		logger.Infof("Successfully loaded %d migration(s) from %s", migrationCount, sfmPathList)
+
+		// Log migration breakdown by backend/connection for better visibility
+		allMigrations := registry.GlobalRegistry.GetAll()
+		backendCounts := make(map[string]map[string]int)
+		for _, mig := range allMigrations {
+			if backendCounts[mig.Backend] == nil {
+				backendCounts[mig.Backend] = make(map[string]int)
+			}
+			backendCounts[mig.Backend][mig.Connection]++
+		}
+
+		for backend, connections := range backendCounts {
+			for connection, count := range connections {
+				logger.Infof("  - %s/%s: %d migration(s)", backend, connection, count)
+			}
+		}
 	}
 
-	go func() {
-		logger.Infof("Starting gRPC server on port %s", cfg.Server.GRPCPort)
-		if err := grpcServer.Serve(grpcListener); err != nil {
-			logger.Fatalf("Failed to start gRPC server: %v", err)
+	// Start watching for new migration files
+	loader.StartWatching()
+	defer loader.StopWatching()
+
+	// Start background reindexer
+	reindexInterval := 5 * time.Minute
+	if intervalStr := os.Getenv("BFM_REINDEX_INTERVAL_MINUTES"); intervalStr != "" {
+		if intervalMinutes, err := time.ParseDuration(intervalStr + "m"); err == nil {
+			reindexInterval = intervalMinutes
+		}
+	}
+	reindexer := state.NewReindexer(stateTracker, registry.GlobalRegistry, reindexInterval)
+	defer reindexer.Stop()
+
+	// Reindexing and auto-migrate write to migration state, so a standby starts them once promoted
+	startPrimaryWork := func() {
+		reindexer.Start()
+		logger.Infof("Background reindexer started with interval: %v", reindexInterval)
+
+		startAutoMigrateBackground(rootCtx, exec, cfg)
+
+		go exec.RunScheduler(rootCtx, cfg.Scheduler.Interval)
+		logger.Infof("Scheduler started: due runs checked every %v, maintenance window %s", cfg.Scheduler.Interval, exec.MaintenanceWindow())
+
+		go exec.RunPostmortemReminders(rootCtx, cfg.Emergency.ReminderInterval)
+	}
+	if cfg.Standby.Enabled {
+		exec.OnPromote(startPrimaryWork)
+		if cfg.Standby.AutoPromote {
+			logger.Infof("Starting in standby: promoting when the primary lock is free (checked every %v)", cfg.Standby.CheckInterval)
+		} else {
+			logger.Info("Starting in standby: waiting for POST /api/v1/standby/promote")
+		}
+	} else {
+		startPrimaryWork()
+	}
+
+	// The primary holds the primary lock in the state database; a standby takes it over when the primary stops
+	go exec.RunPrimaryElection(rootCtx, cfg.Standby.CheckInterval, cfg.Standby.AutoPromote)
+
+	// Start gRPC server, unless it shares the HTTP port
+	if !cfg.Server.SinglePort {
+		grpcListener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			logger.Fatalf("Failed to listen on gRPC port %s: %v", cfg.Server.GRPCPort, err)
 		}
-	}()
+
+		go func() {
+			logger.Infof("Starting gRPC server on port %s", cfg.Server.GRPCPort)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				logger.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
 
 	logger.Info("BFM server started successfully")
 	logger.Infof("HTTP API available at http://localhost:%s", cfg.Server.HTTPPort)
-	logger.Infof("gRPC API available at localhost:%s", cfg.Server.GRPCPort)
+	if cfg.Server.SinglePort {
+		logger.Infof("gRPC API available at localhost:%s (single port)", cfg.Server.HTTPPort)
+	} else {
+		logger.Infof("gRPC API available at localhost:%s", cfg.Server.GRPCPort)
+	}
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -350,5 +549,50 @@ func main() {
 	// Shutdown gRPC server
 	grpcServer.GracefulStop()
 
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warnf("Failed to flush traces: %v", err)
+	}
+
 	logger.Info("Servers exited")
 }
+
+// newMigrationLoader returns the loader of the migration scripts of the SFM directories
+// (BFM_SFM_PATHS or BFM_SFM_PATH), registering them with exec
+func newMigrationLoader(exec *executor.Executor, cfg *config.Config) *executor.Loader {
+	// Validate SFM paths exist
+	for _, sfmPath := range cfg.Loader.SFMPaths {
+		if _, err := os.Stat(sfmPath); os.IsNotExist(err) {
+			logger.Fatalf("SFM directory does not exist: %s (set BFM_SFM_PATH or BFM_SFM_PATHS environment variable)", sfmPath)
+		}
+	}
+
+	logger.Infof("Loading migrations from SFM directory: %s", strings.Join(cfg.Loader.SFMPaths, ", "))
+
+	loader := executor.NewLoader(cfg.Loader.SFMPaths...)
+	loader.SetExecutor(exec) // Set executor so loader can register scanned migrations
+	loader.SetSource(cfg.Loader.Source)
+	return loader
+}
+
+// runOneshotMode loads the migrations, applies the pending ones of the auto-migrate connections
+// once and returns the exit code, for --oneshot. SIGINT and SIGTERM cancel the run.
+func runOneshotMode(ctx context.Context, exec *executor.Executor, cfg *config.Config, schemas string) int {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := newMigrationLoader(exec, cfg).LoadAll(registry.GlobalRegistry); err != nil {
+		logger.Errorf("Failed to load migrations from %s: %v", strings.Join(cfg.Loader.SFMPaths, ", "), err)
+		return oneshotExitFailed
+	}
+	exec.SetStarting(false)
+	logger.Infof("One-shot mode: loaded %d migration(s)", len(registry.GlobalRegistry.GetAll()))
+
+	var schemaList []string
+	for _, schema := range strings.Split(schemas, ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			schemaList = append(schemaList, schema)
+		}
+	}
+	return runOneshot(ctx, exec, autoMigrateConnections(cfg), schemaList, os.Stdout)
+}
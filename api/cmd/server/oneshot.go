@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// Exit codes of one-shot mode
+const (
+	oneshotExitOK     = 0 // Every selected migration is applied
+	oneshotExitFailed = 1 // A migration failed, or a connection could not be migrated
+)
+
+// runOneshot applies the pending migrations of conns once, in the given schemas of dynamic-schema
+// migrations (only fixed-schema migrations when schemas is empty), and writes a summary to out.
+// It returns the exit code of the process.
+func runOneshot(ctx context.Context, exec *executor.Executor, conns []autoMigrateConn, schemas []string, out io.Writer) int {
+	if len(schemas) == 0 {
+		schemas = []string{""}
+	}
+
+	code := oneshotExitOK
+	var applied, skipped, failed int
+	for _, conn := range conns {
+		target := &registry.MigrationTarget{Backend: conn.cfg.Backend, Connection: conn.name}
+		runCtx := executor.SetExecutionContext(ctx, "bfm-server", "oneshot", map[string]interface{}{
+			"connection": conn.name,
+			"source":     "oneshot",
+		})
+
+		result, err := exec.ExecuteUp(runCtx, target, conn.name, schemas, false, false)
+		if err != nil {
+			code = oneshotExitFailed
+			failed++
+			_, _ = fmt.Fprintf(out, "%s: failed: %v\n", conn.name, err)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "%s: %d applied, %d skipped, %d failed\n", conn.name, len(result.Applied), len(result.Skipped), len(result.Errors))
+		for _, id := range result.Applied {
+			_, _ = fmt.Fprintf(out, "  applied %s\n", id)
+		}
+		for _, message := range result.Errors {
+			_, _ = fmt.Fprintf(out, "  error   %s\n", message)
+		}
+		applied += len(result.Applied)
+		skipped += len(result.Skipped)
+		failed += len(result.Errors)
+		if len(result.Errors) > 0 {
+			code = oneshotExitFailed
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "one-shot run: %d applied, %d skipped, %d failed across %d connection(s)\n", applied, skipped, failed, len(conns))
+	return code
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func Test_runOneshot(t *testing.T) {
+	conn := &backends.ConnectionConfig{Backend: "postgresql", Host: "localhost"}
+	newExecutor := func(backend *testsupport.Backend) *executor.Executor {
+		reg := testsupport.NewRegistry(
+			&backends.MigrationScript{Schema: "core", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE users (id INT)"},
+			&backends.MigrationScript{Schema: "core", Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE orders (id INT)"},
+		)
+		exec := executor.NewExecutor(reg, testsupport.NewStateTracker())
+		exec.RegisterBackend("postgresql", backend)
+		_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": conn})
+		return exec
+	}
+	conns := []autoMigrateConn{{name: "core", cfg: conn}}
+
+	t.Run("applies everything", func(t *testing.T) {
+		exec := newExecutor(testsupport.NewBackend("postgresql"))
+		var out bytes.Buffer
+		if code := runOneshot(context.Background(), exec, conns, nil, &out); code != oneshotExitOK {
+			t.Fatalf("exit code = %d, want %d; output:\n%s", code, oneshotExitOK, out.String())
+		}
+		if !strings.Contains(out.String(), "one-shot run: 2 applied, 0 skipped, 0 failed across 1 connection(s)") {
+			t.Errorf("unexpected summary:\n%s", out.String())
+		}
+
+		// A second run finds nothing to do
+		out.Reset()
+		if code := runOneshot(context.Background(), exec, conns, nil, &out); code != oneshotExitOK || !strings.Contains(out.String(), "0 applied, 2 skipped") {
+			t.Errorf("second run: exit code %d, output:\n%s", code, out.String())
+		}
+	})
+
+	t.Run("fails on a failed migration", func(t *testing.T) {
+		backend := testsupport.NewBackend("postgresql")
+		backend.FailMigration("create_orders", errors.New("relation exists"))
+		var out bytes.Buffer
+		if code := runOneshot(context.Background(), newExecutor(backend), conns, nil, &out); code != oneshotExitFailed {
+			t.Fatalf("exit code = %d, want %d; output:\n%s", code, oneshotExitFailed, out.String())
+		}
+		if !strings.Contains(out.String(), "relation exists") {
+			t.Errorf("summary does not report the error:\n%s", out.String())
+		}
+	})
+}
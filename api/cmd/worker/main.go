@@ -3,20 +3,35 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/backends/cassandra"
+	"github.com/toolsascode/bfm/api/internal/backends/consul"
 	"github.com/toolsascode/bfm/api/internal/backends/etcd"
 	"github.com/toolsascode/bfm/api/internal/backends/greptimedb"
 	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/backends/spanner"
+	"github.com/toolsascode/bfm/api/internal/backends/vault"
 	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/events"
 	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/invalidation"
 	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/metrics"
 	"github.com/toolsascode/bfm/api/internal/queuefactory"
 	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/secrets"
 	"github.com/toolsascode/bfm/api/internal/state"
+	stateetcd "github.com/toolsascode/bfm/api/internal/state/etcd"
 	statepg "github.com/toolsascode/bfm/api/internal/state/postgresql"
+	statesqlite "github.com/toolsascode/bfm/api/internal/state/sqlite"
+	"github.com/toolsascode/bfm/api/internal/tracing"
 	"github.com/toolsascode/bfm/api/internal/worker"
 )
 
@@ -26,23 +41,39 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
+	// Jobs also apply the feature flag overrides of the request that queued them
+	features.Configure(cfg.Features.Flags)
+	if len(cfg.Features.Flags) > 0 {
+		logger.Infof("Feature flags: %s", cfg.Features.Flags)
+	}
+
+	// Tracing is exported when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(context.Background(), "bfm-worker")
+	if err != nil {
+		logger.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
 
 	// Check if queue is enabled
 	if !cfg.Queue.Enabled {
 		logger.Fatalf("Queue is not enabled. Set BFM_QUEUE_ENABLED=true to use the worker")
 	}
+	if queuefactory.InProcess(cfg.Queue.Type) {
+		logger.Fatalf("The %s queue is consumed by the server that publishes to it; run the server without a separate worker", cfg.Queue.Type)
+	}
 
 	// Initialize state tracker
 	var stateTracker state.StateTracker
 	switch cfg.StateDB.Type {
 	case "postgresql":
 		stateConnStr := fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			"host=%s port=%s user=%s password=%s dbname=%s %s",
 			cfg.StateDB.Host,
 			cfg.StateDB.Port,
 			cfg.StateDB.Username,
 			cfg.StateDB.Password,
 			cfg.StateDB.Database,
+			backends.PostgresSSLParams(cfg.StateDB.SSLMode, cfg.StateDB.SSLRootCert, cfg.StateDB.SSLCert, cfg.StateDB.SSLKey),
 		)
 		stateTracker, err = statepg.NewTracker(stateConnStr, cfg.StateDB.Schema)
 		if err != nil {
@@ -50,6 +81,16 @@ func main() {
 		}
 		// Note: Close is handled by the concrete Tracker type, not the interface
 		// We'll close it explicitly if needed, but NewTracker already initializes
+	case "sqlite":
+		stateTracker, err = statesqlite.NewTracker(cfg.StateDB.Path)
+		if err != nil {
+			logger.Fatalf("Failed to create state tracker: %v", err)
+		}
+	case "etcd":
+		stateTracker, err = stateetcd.NewTracker(cfg.StateDB.Endpoints, cfg.StateDB.Username, cfg.StateDB.Password, cfg.StateDB.Prefix, cfg.StateDB.HistoryLimit)
+		if err != nil {
+			logger.Fatalf("Failed to create state tracker: %v", err)
+		}
 	default:
 		logger.Fatalf("Unsupported state backend: %s", cfg.StateDB.Type)
 	}
@@ -60,10 +101,38 @@ func main() {
 	}
 
 	// Create executor (using global registry)
-	exec := executor.NewExecutor(registry.GlobalRegistry, stateTracker)
+	exec := executor.NewExecutor(registry.GlobalRegistry, metrics.InstrumentStateTracker(stateTracker))
 	if err := exec.SetConnections(cfg.Connections); err != nil {
 		logger.Fatalf("Failed to set connections: %v", err)
 	}
+	exec.SetDriftMode(cfg.Execution.DriftMode)
+	exec.SetMigrationTimeout(cfg.Execution.MigrationTimeout)
+	exec.SetDestructivePolicy(cfg.Execution.DestructivePolicy)
+	exec.SetOutOfOrderPolicy(cfg.Execution.OutOfOrderPolicy)
+	schemaPolicy := executor.NewSchemaPolicy(cfg.Execution.SchemaPattern, cfg.Execution.SchemaDeny, cfg.Execution.MaxSchemas)
+	schemaPolicy.MaxConcurrency = cfg.Execution.MaxSchemaConcurrency
+	exec.SetSchemaPolicy(schemaPolicy)
+
+	// Custom validators from Go plugins (BFM_VALIDATOR_PLUGINS)
+	for _, path := range cfg.Execution.ValidatorPlugins {
+		validator, err := executor.LoadValidatorPlugin(path)
+		if err != nil {
+			logger.Fatalf("Failed to load validator plugin: %v", err)
+		}
+		exec.RegisterValidator(validator)
+		logger.Infof("Registered validator %s from %s", validator.Name(), path)
+	}
+
+	// Log lifecycle events; integrations subscribe to the same bus
+	exec.Events().OnHandlerPanic(func(event events.Event, recovered interface{}) {
+		logger.Errorf("Event subscriber panicked handling %s: %v", event, recovered)
+	})
+	exec.Events().Subscribe(events.All, func(_ context.Context, event events.Event) {
+		logger.Debug("Event: %s", event)
+	})
+	metrics.Subscribe(exec.Events())
+	// Refresh caches after migrations are applied ({CONNECTION}_CACHE_INVALIDATION_URLS)
+	invalidation.NewListener(exec.GetConnectionConfig).Subscribe(exec.Events())
 
 	// Register backends
 	pgBackend := postgresql.NewBackend()
@@ -75,21 +144,28 @@ func main() {
 	etcdBackend := etcd.NewBackend()
 	exec.RegisterBackend("etcd", etcdBackend)
 
-	// Dynamically load migration scripts from SFM directory
-	sfmPath := os.Getenv("BFM_SFM_PATH")
-	if sfmPath == "" {
-		// Default to ../sfm relative to bfm directory
-		sfmPath = "../sfm"
-	}
+	cassandraBackend := cassandra.NewBackend()
+	exec.RegisterBackend("cassandra", cassandraBackend)
+
+	consulBackend := consul.NewBackend()
+	exec.RegisterBackend("consul", consulBackend)
+
+	vaultBackend := vault.NewBackend()
+	exec.RegisterBackend("vault", vaultBackend)
+
+	spannerBackend := spanner.NewBackend()
+	exec.RegisterBackend("spanner", spannerBackend)
 
-	loader := executor.NewLoader(sfmPath)
+	// Dynamically load migration scripts from the SFM directories (BFM_SFM_PATHS or BFM_SFM_PATH)
+	loader := executor.NewLoader(cfg.Loader.SFMPaths...)
 	loader.SetExecutor(exec) // Set executor so loader can register scanned migrations
+	loader.SetSource(cfg.Loader.Source)
 	if err := loader.LoadAll(registry.GlobalRegistry); err != nil {
 		logger.Fatalf("Failed to load migrations: %v", err)
 	}
 
 	migrationCount := len(registry.GlobalRegistry.GetAll())
-	logger.Infof("Loaded %d migration(s) from %s", migrationCount, sfmPath)
+	logger.Infof("Loaded %d migration(s) from %s", migrationCount, strings.Join(cfg.Loader.SFMPaths, ", "))
 
 	// Create queue
 	queueConfig := &queuefactory.QueueConfig{
@@ -97,9 +173,20 @@ func main() {
 		KafkaBrokers:       cfg.Queue.KafkaBrokers,
 		KafkaTopic:         cfg.Queue.KafkaTopic,
 		KafkaGroupID:       cfg.Queue.KafkaGroupID,
+		KafkaDLQTopic:      cfg.Queue.KafkaDLQTopic,
 		PulsarURL:          cfg.Queue.PulsarURL,
 		PulsarTopic:        cfg.Queue.PulsarTopic,
 		PulsarSubscription: cfg.Queue.PulsarSubscription,
+		PulsarDLQTopic:     cfg.Queue.PulsarDLQTopic,
+		RedisAddr:          cfg.Queue.RedisAddr,
+		RedisPassword:      cfg.Queue.RedisPassword,
+		RedisDB:            cfg.Queue.RedisDB,
+		RedisStream:        cfg.Queue.RedisStream,
+		RedisGroup:         cfg.Queue.RedisGroup,
+		RedisConsumer:      cfg.Queue.RedisConsumer,
+		RedisDLQStream:     cfg.Queue.RedisDLQStream,
+		RedisClaimIdle:     cfg.Queue.RedisClaimIdle,
+		MemoryCapacity:     cfg.Queue.MemoryCapacity,
 	}
 
 	q, err := queuefactory.NewQueue(queueConfig)
@@ -108,16 +195,54 @@ func main() {
 	}
 	defer func() { _ = q.Close() }()
 
-	// Create worker
+	// Create worker, retrying transient failures (BFM_QUEUE_RETRY_*)
 	w := worker.NewWorker(exec, q)
+	w.SetRetryPolicy(worker.RetryPolicy{
+		MaxAttempts: cfg.Queue.RetryMaxAttempts,
+		Backoff:     cfg.Queue.RetryBackoff,
+		MaxBackoff:  cfg.Queue.RetryMaxBackoff,
+	})
 
 	// Setup signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Connection passwords may reference secrets (vault:..., awssm:...), resolved when used
+	resolver := secrets.NewResolver(cfg.Secrets.CacheTTL)
+	if cfg.Secrets.VaultAddr != "" {
+		resolver.Register(secrets.ProviderVault, secrets.NewVault(cfg.Secrets.VaultAddr, cfg.Secrets.VaultToken, cfg.Secrets.VaultNamespace))
+	}
+	if cfg.Secrets.AWSRegion != "" {
+		resolver.Register(secrets.ProviderAWS, secrets.NewAWS(cfg.Secrets.AWSRegion, cfg.Secrets.AWSEndpoint, secrets.AWSCredentials{
+			AccessKeyID:     cfg.Secrets.AWSAccessKeyID,
+			SecretAccessKey: cfg.Secrets.AWSSecretAccessKey,
+			SessionToken:    cfg.Secrets.AWSSessionToken,
+		}))
+	}
+	exec.SetSecrets(resolver)
+	// Jobs for connections added to the connections file run without restarting the worker
+	exec.SetConnectionsSource(func() (map[string]*backends.ConnectionConfig, error) {
+		return config.LoadConnections(cfg.ConnectionsFile)
+	})
+	if cfg.ConnectionsFile != "" {
+		go exec.WatchConnectionsFile(ctx, cfg.ConnectionsFile, cfg.ConnectionsReloadInterval)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// Expose Prometheus metrics when a port is configured
+	if metricsPort := os.Getenv("BFM_WORKER_METRICS_PORT"); metricsPort != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go func() {
+			logger.Infof("Serving worker metrics on port %s", metricsPort)
+			if err := http.ListenAndServe(":"+metricsPort, mux); err != nil {
+				logger.Errorf("Worker metrics server error: %v", err)
+			}
+		}()
+	}
+
 	// Start worker in goroutine
 	go func() {
 		if err := w.Start(ctx); err != nil {
@@ -22,29 +22,44 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Checks the health status of the API",
-                "consumes": [
-                    "application/json"
+        "/admin/connections/reload": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Reloads the connections of this server instance from the environment it started with and the connections file (BFM_CONNECTIONS_FILE), so a new tenant database can be migrated without a restart. Connections of the file replace those of the environment with the same name. Executions in flight keep the settings they started with; an invalid file leaves the connections unchanged. The file is also checked for changes every BFM_CONNECTIONS_RELOAD_INTERVAL by the server and the worker. Requires an admin token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
                 ],
-                "summary": "Health check",
+                "summary": "Reload connections",
                 "responses": {
                     "200": {
-                        "description": "Healthy",
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ConnectionsReloadResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "503": {
-                        "description": "Unhealthy",
+                    "403": {
+                        "description": "Forbidden: requires an admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "The connections file is invalid",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -53,59 +68,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations": {
+        "/audit": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Lists all migrations with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists audit records of API calls that mutate state (up, down, rollback and reindex over HTTP, gRPC and Connect; status labels, lock releases and standby promotions over HTTP), newest first. Records include denied and failed calls. The audit log is append-only. Requires an admin token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "audit"
                 ],
-                "summary": "List migrations",
+                "summary": "Get the audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Schema filter",
-                        "name": "schema",
+                        "description": "Filter by operation (up, down, rollback, reindex, labels, release_lock, promote, replay, postmortem, cancel_run)",
+                        "name": "operation",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Table filter",
-                        "name": "table",
+                        "description": "Filter by caller identity name",
+                        "name": "actor",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Connection filter",
-                        "name": "connection",
+                        "description": "Filter by outcome (success, partial, failed, denied)",
+                        "name": "outcome",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Backend filter",
-                        "name": "backend",
+                        "description": "Only records at or after this time (RFC 3339)",
+                        "name": "since",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Status filter",
-                        "name": "status",
+                        "description": "Only records before this time (RFC 3339)",
+                        "name": "until",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Version filter",
-                        "name": "version",
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of records (max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of records to skip",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
@@ -113,11 +133,11 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/dto.MigrationListResponse"
+                            "$ref": "#/definitions/dto.AuditLogResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -130,6 +150,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Forbidden: requires an admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -140,50 +167,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/down": {
-            "post": {
+        "/emergency-runs": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Executes down migrations to rollback a specific migration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the requests sent with the X-BFM-Emergency header, which bypassed the drift check and the validators, most recent first, with their postmortems; pending=true lists those still waiting for one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "emergency"
                 ],
-                "summary": "Execute down migrations (rollback)",
+                "summary": "List emergency runs",
                 "parameters": [
                     {
-                        "description": "Rollback request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.MigrateDownRequest"
-                        }
+                        "type": "boolean",
+                        "description": "Only runs without a postmortem",
+                        "name": "pending",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of runs (max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of runs to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/dto.MigrateResponse"
-                        }
-                    },
-                    "206": {
-                        "description": "Partial success",
-                        "schema": {
-                            "$ref": "#/definitions/dto.MigrateResponse"
+                            "$ref": "#/definitions/dto.EmergencyRunListResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -206,39 +235,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/executions/recent": {
+        "/emergency-runs/{id}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets recent execution records across all migrations",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Gets an emergency run (the emergency_run of the history records it executed), with its postmortem, or when it is due.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "emergency"
                 ],
-                "summary": "Get recent executions",
+                "summary": "Get an emergency run",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Limit number of results",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Emergency run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.EmergencyRunResponse"
                         }
                     },
                     "401": {
@@ -248,6 +273,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Emergency run not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -258,14 +290,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/reindex": {
+        "/emergency-runs/{id}/postmortem": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Reindexes all migration files and synchronizes with database",
+                "description": "Records the follow-up of an emergency run (an incident report link or a summary), which stops the reminders sent while it is overdue. A postmortem already recorded is replaced.",
                 "consumes": [
                     "application/json"
                 ],
@@ -273,14 +305,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "emergency"
+                ],
+                "summary": "Record the postmortem of an emergency run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Emergency run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Postmortem",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PostmortemRequest"
+                        }
+                    }
                 ],
-                "summary": "Reindex migrations",
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/dto.ReindexResponse"
+                            "$ref": "#/definitions/dto.EmergencyRunResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
@@ -290,6 +347,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Emergency run not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -300,14 +371,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/skipped/recent": {
+        "/health": {
             "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "Gets recent skipped migrations across all migrations",
+                "description": "Checks the health status of the API. Kept for compatibility: it fails whenever the state database does, so use /livez for liveness probes and /readyz for readiness probes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -315,35 +381,19 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
-                ],
-                "summary": "Get recent skipped migrations",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 5,
-                        "description": "Limit number of results",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "health"
                 ],
+                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "Success",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                        "description": "Healthy",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "503": {
+                        "description": "Unhealthy",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -352,50 +402,69 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/up": {
-            "post": {
+        "/jobs": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Executes migrations based on the provided target and connection",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists jobs queued by up requests when the queue is enabled, most recently queued first, e.g. status=dead_lettered for the contents of the dead-letter queue.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "jobs"
                 ],
-                "summary": "Execute up migrations",
+                "summary": "List async jobs",
                 "parameters": [
                     {
-                        "description": "Migration request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.MigrateUpRequest"
-                        }
+                        "enum": [
+                            "scheduled",
+                            "queued",
+                            "picked_up",
+                            "running",
+                            "retrying",
+                            "completed",
+                            "failed",
+                            "dead_lettered",
+                            "cancelled"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by connection",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of jobs (max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of jobs to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/dto.MigrateResponse"
-                        }
-                    },
-                    "206": {
-                        "description": "Partial success",
-                        "schema": {
-                            "$ref": "#/definitions/dto.MigrateResponse"
+                            "$ref": "#/definitions/dto.JobListResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -418,28 +487,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/{id}": {
+        "/jobs/{id}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets detailed information about a specific migration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Gets the status of a job queued by an up request when the queue is enabled (the job_id of its response), or scheduled with schedule_at: scheduled, queued, picked_up, running, retrying, completed, failed, dead_lettered or cancelled, with the worker that picked it up, its attempts and, once finished, the applied and skipped migrations and errors.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "jobs"
                 ],
-                "summary": "Get migration details",
+                "summary": "Get an async job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Migration ID",
+                        "description": "Job ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -449,7 +515,7 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/dto.MigrationDetailResponse"
+                            "$ref": "#/definitions/dto.JobResponse"
                         }
                     },
                     "401": {
@@ -460,7 +526,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Migration not found",
+                        "description": "Job not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -476,41 +542,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/{id}/applied": {
-            "get": {
+        "/jobs/{id}/replay": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Returns a simple boolean indicating if the migration has been applied",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Publishes a failed or dead-lettered job to the queue again under the same ID, e.g. once the cause of the failure is fixed; its status starts over from queued.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
+                    "jobs"
                 ],
-                "summary": "Check if migration is applied",
+                "summary": "Replay a failed job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Migration ID",
+                        "description": "Job ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Success",
+                    "202": {
+                        "description": "Queued again",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
+                            "$ref": "#/definitions/dto.JobResponse"
                         }
                     },
                     "401": {
@@ -520,49 +580,78 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The job did not fail, or the queue is not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
             }
         },
-        "/migrations/{id}/executions": {
+        "/livez": {
+            "get": {
+                "description": "Reports that the server process is up and serving HTTP. It checks no dependency, so an unreachable state database does not get the server restarted; use /readyz to decide whether to send it traffic. Also served at /livez.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Alive",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/meta": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets all execution records for a specific migration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reports the server version and build, the API versions served and the enabled features (async queue, registered backends, authentication mode, experimental features enabled for the request), so clients can feature-detect rather than hard-code the behavior of a deployment.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "migrations"
-                ],
-                "summary": "Get migration executions",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Migration ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "health"
                 ],
+                "summary": "Get server metadata",
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.MetaResponse"
                         }
                     },
                     "401": {
@@ -571,61 +660,152 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
                     }
                 }
             }
         },
-        "/migrations/{id}/history": {
+        "/migrations": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets the execution history for a specific migration including rollbacks",
+                "description": "Lists all migrations with optional filtering, ordered by version unless sort_by is set. Pass limit and offset to get one page; total is the number of migrations matching the filters. With format=csv the list (or page) is returned as a CSV attachment for spreadsheets.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
                     "migrations"
                 ],
-                "summary": "Get migration history",
+                "summary": "List migrations",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Migration ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Schema filter",
+                        "name": "schema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Table filter (the table declared by the migration's -- bfm-table: line)",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Connection filter",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Backend filter",
+                        "name": "backend",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status filter",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version filter",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User-defined status label filter",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of migrations (default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of migrations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "migration_id",
+                            "schema",
+                            "version",
+                            "connection",
+                            "backend",
+                            "status",
+                            "applied_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default: version)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default: asc)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated CSV columns (default: all): migration_id, schema, table, version, name, connection, backend, status, applied, applied_at, error_message, checksum, status_labels",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.MigrationListResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Migration not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -641,14 +821,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/{id}/rollback": {
+        "/migrations/down": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Rolls back a specific migration",
+                "description": "Executes down migrations to rollback a specific migration. Refused with 409 while migrations that depend on it remain applied (the error lists them in the order to roll them back in), unless ignore_dependencies is set.",
                 "consumes": [
                     "application/json"
                 ],
@@ -658,21 +838,15 @@ const docTemplate = `{
                 "tags": [
                     "migrations"
                 ],
-                "summary": "Rollback migration",
+                "summary": "Execute down migrations (rollback)",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Migration ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "Rollback request",
                         "name": "request",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.RollbackRequest"
+                            "$ref": "#/definitions/dto.MigrateDownRequest"
                         }
                     }
                 ],
@@ -680,12 +854,17 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.MigrateResponse"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrateResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request, or a schema name the schema policy refuses",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -698,8 +877,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Migration not found",
+                    "403": {
+                        "description": "Forbidden: requires an operator or admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Connection locked by another migration run, or dependent migrations still applied",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -711,50 +897,37 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/migrations/{id}/skipped": {
+        "/migrations/drift": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets skipped migrations for a specific migration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists applied migrations whose up script checksum no longer matches the checksum recorded when they were applied. Depending on BFM_DRIFT_MODE, executions on these migrations' connections are refused (fail, the default) or only logged (warn).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "migrations"
                 ],
-                "summary": "Get skipped migrations",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Migration ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 5,
-                        "description": "Limit number of results",
-                        "name": "limit",
-                        "in": "query"
-                    }
-                ],
+                "summary": "List drifted migrations",
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.MigrationDriftResponse"
                         }
                     },
                     "401": {
@@ -774,36 +947,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/migrations/{id}/status": {
+        "/migrations/events": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets the current status of a specific migration",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Streams migration lifecycle events as server-sent events, as they are published on this server, until the client disconnects. Each event is named after its type: migration.started, migration.statement_completed (PostgreSQL, with statement, statements, rows_affected and elapsed_ms), migration.applied, migration.failed (with error) and migration.rolled_back (with operation, backend and elapsed_ms), run.completed, job.queued, job.scheduled and reindex.completed. A dropped event (with count) tells a client that fell behind that events were lost. Idle streams send a comment every 15 seconds. Runs executed by workers or other replicas are not streamed.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "migrations"
                 ],
-                "summary": "Get migration status",
+                "summary": "Stream lifecycle events",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Migration ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only events of this connection",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types to stream (default: all)",
+                        "name": "types",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/dto.EventResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown event type",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -816,8 +997,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "503": {
+                        "description": "Server is shutting down",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -825,160 +1006,3638 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.DependencyResponse": {
-            "type": "object",
-            "properties": {
-                "connection": {
-                    "type": "string"
-                },
-                "requires_schema": {
-                    "type": "string"
-                },
-                "requires_table": {
-                    "type": "string"
-                },
-                "schema": {
-                    "type": "string"
-                },
-                "target": {
-                    "type": "string"
-                },
-                "target_type": {
+        },
+        "/migrations/executions/recent": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets recent execution records across all migrations",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get recent executions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/facets": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the distinct connections, backends, schemas, versions and tags of the migrations in the state database, each with the number of migrations having it, sorted by value, for building filter dropdowns without downloading the migration list. A migration registered for several schemas counts for each. The filters narrow the migrations counted, except that a field's own filter does not apply to its values, so a dropdown keeps offering the alternatives to its selection.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "List migration facets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schema filter",
+                        "name": "schema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Table filter",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Connection filter",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Backend filter",
+                        "name": "backend",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status filter",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version filter",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User-defined status label filter",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationFacetsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/facets/{facet}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the distinct values of one migration field (connections, backends, schemas, versions or tags) with their counts, sorted by value, for autocompleting a filter as the user types. q keeps the values containing it, ignoring case; total counts them before limit. Filters apply as in GET /migrations/facets.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get a migration facet",
+                "parameters": [
+                    {
+                        "enum": [
+                            "connections",
+                            "backends",
+                            "schemas",
+                            "versions",
+                            "tags"
+                        ],
+                        "type": "string",
+                        "description": "Facet",
+                        "name": "facet",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text the values must contain, ignoring case",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of values (max 1000; default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Schema filter",
+                        "name": "schema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Table filter",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Connection filter",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Backend filter",
+                        "name": "backend",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status filter",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version filter",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User-defined status label filter",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationFacetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown facet or invalid limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/failures/summary": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Counts the failed executions in migration history by root-cause class: syntax, permission, lock_timeout, connectivity, constraint_violation or unknown. Classes come from the error codes of the backend's driver (SQLSTATE for PostgreSQL, protocol codes for Cassandra, gRPC codes for etcd and Spanner), falling back to the error message; failures recorded before classification are classified by their message. Each class reports its latest failure and whether the worker retries jobs failing with it. Classes without failures are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Summarize failures by root cause",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schema filter",
+                        "name": "schema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Connection filter",
+                        "name": "connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Backend filter",
+                        "name": "backend",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version filter",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Failures at or after this RFC 3339 time",
+                        "name": "applied_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Failures before this RFC 3339 time",
+                        "name": "applied_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.FailureSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/history": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the execution history of all migrations, newest first unless sort_by is set, e.g. what a user ran on a given day. Pass limit and offset to get one page; total is the number of records matching the filters. With format=csv the history (or page) is returned as a CSV attachment for spreadsheets.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "List migration history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Records applied at or after this RFC 3339 time",
+                        "name": "applied_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Records applied before this RFC 3339 time",
+                        "name": "applied_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Records executed by this user",
+                        "name": "executed_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "api",
+                            "cli",
+                            "worker"
+                        ],
+                        "type": "string",
+                        "description": "Records executed with this method",
+                        "name": "execution_method",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "syntax",
+                            "permission",
+                            "lock_timeout",
+                            "connectivity",
+                            "constraint_violation",
+                            "unknown"
+                        ],
+                        "type": "string",
+                        "description": "Failed records with this root cause",
+                        "name": "error_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of records (default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of records to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "migration_id",
+                            "schema",
+                            "version",
+                            "connection",
+                            "backend",
+                            "status",
+                            "applied_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default: applied_at)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default: desc, asc when sort_by is set)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated CSV columns (default: all): migration_id, schema, table, version, connection, backend, status, operation, applied_at, executed_by, execution_method, error_message, error_class, checksum",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/locks": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the connections currently locked by a migration run (server replica, worker or CLI), with the holding process",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "List migration locks",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationLocksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/locks/{connection}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Releases the lock on a connection held by a stuck or crashed migration run. The holding session is terminated, so its in-flight migration is aborted. Requires an admin token (BFM_ADMIN_TOKEN, BFM_API_TOKEN when no admin token is set, or an admin token from BFM_TOKENS / BFM_TOKENS_FILE).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Force-release a migration lock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Connection name",
+                        "name": "connection",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReleaseLockResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/pending": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the count and list of migrations registered for a connection that are not applied yet, in version order. Intended as a deployment gate: block the rollout until count is 0. Dynamic-schema migrations are tracked per schema and are only included when schema is given.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "List pending migrations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Connection name",
+                        "name": "connection",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Schema for dynamic-schema migrations",
+                        "name": "schema",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PendingMigrationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Connection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/plan": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the topologically sorted execution order for a target, which migrations would be skipped as already applied, and which dependencies forced the ordering. Nothing is executed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Plan up migrations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Connection name",
+                        "name": "connection",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Backend filter",
+                        "name": "backend",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Schema filter",
+                        "name": "schema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Table filters (tables declared with -- bfm-table:); unknown tables are rejected",
+                        "name": "tables",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version filter",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this version and older (migrate to version)",
+                        "name": "max_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag filters (key=value)",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Schemas for dynamic-schema migrations",
+                        "name": "schemas",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Sort by version only",
+                        "name": "ignore_dependencies",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationPlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or a schema name the schema policy refuses",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/reindex": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reindexes all migration files and synchronizes with database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Reindex migrations",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReindexResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an operator or admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/runs": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the synchronous up, down and rollback requests this server is executing, oldest first. Queued jobs run by workers are not listed (see /jobs).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "List runs in flight",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RunListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/runs/{run_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancels a synchronous up, down or rollback request, named by the X-BFM-Run-ID header it was sent with or found in /migrations/runs: the statement running is interrupted, its migration is recorded as failed, and the migrations not started yet are not run. The request then returns with the errors. Only the server executing the run can cancel it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Cancel a run in flight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "run_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Canceled",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RunResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No such run in flight on this server",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/scheduled": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the jobs scheduled by up requests with schedule_at that have not started yet, soonest first, with the maintenance window they start in. Started runs are listed by /jobs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List scheduled runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by connection",
+                        "name": "connection",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledJobListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/scheduled/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancels a job scheduled by an up request with schedule_at before it starts; it is kept with status cancelled. A run that already started cannot be cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel a scheduled run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/dto.JobResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The job is not scheduled: it already started, or was cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/skipped/recent": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets recent skipped migrations across all migrations",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get recent skipped migrations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Limit number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/up": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Executes migrations based on the provided target and connection. With connections (names or glob patterns such as tenant_*), each matching connection is migrated in turn (at the same time with parallel, when the connections do not depend on each other) and reported in its own section; the top-level fields aggregate all connections. With migration_ids instead of a target, only the listed migrations of the connection run, in dependency order; unknown IDs are rejected. With pinned_checksums (the checksums of a plan), nothing runs if a migration to apply is not in the plan or changed since. With target.schema_pattern (a SQL LIKE pattern such as tenant_%), the schemas of the connection's database matching it are discovered and migrated, up to concurrency at once (capped by BFM_SCHEMA_MAX_CONCURRENCY), each reported in schemas. With schedule_at, nothing runs now: one job per schema is scheduled to run at that time, within the maintenance window, and 202 lists them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Execute up migrations",
+                "parameters": [
+                    {
+                        "description": "Migration request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrateUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrateResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Scheduled (schedule_at)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduleResponse"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or a schema name the schema policy refuses",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an operator or admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Migrations changed since the pinned plan",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/up/stream": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Executes an up request like POST /migrations/up and streams its progress as server-sent events: a progress event when each migration starts (running), when each statement starts and completes (statement, with rows_affected and elapsed_ms once completed; PostgreSQL only) and when the migration finishes (success or failed, with elapsed_ms). Skipped migrations send no events. The stream ends with a result event carrying the MigrateResponse, or an error event when the request was refused once started. Requires connection; connections, migration_ids and schedule_at are not supported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Execute up migrations, streaming progress",
+                "parameters": [
+                    {
+                        "description": "Migration request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrateUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream of progress events, then a result event (dto.MigrateResponse)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationProgressResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or a schema name the schema policy refuses",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an operator or admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets detailed information about a specific migration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get migration details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationDetailResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Migration not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/applied": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a simple boolean indicating if the migration has been applied",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Check if migration is applied",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/executions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets all execution records for a specific migration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get migration executions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the execution history for a specific migration including down migrations and rollbacks (see each record's operation: up, down or rollback), newest first unless sort_by is set. Pass limit and offset to get one page; total is the number of records of the migration matching the filters. With format=csv the history (or page) is returned as a CSV attachment for spreadsheets.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get migration history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Records applied at or after this RFC 3339 time",
+                        "name": "applied_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Records applied before this RFC 3339 time",
+                        "name": "applied_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Records executed by this user",
+                        "name": "executed_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "api",
+                            "cli",
+                            "worker"
+                        ],
+                        "type": "string",
+                        "description": "Records executed with this method",
+                        "name": "execution_method",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "syntax",
+                            "permission",
+                            "lock_timeout",
+                            "connectivity",
+                            "constraint_violation",
+                            "unknown"
+                        ],
+                        "type": "string",
+                        "description": "Failed records with this root cause",
+                        "name": "error_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of records (default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of records to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "migration_id",
+                            "schema",
+                            "version",
+                            "connection",
+                            "backend",
+                            "status",
+                            "applied_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default: applied_at)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default: desc, asc when sort_by is set)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated CSV columns (default: all): migration_id, schema, table, version, connection, backend, status, operation, applied_at, executed_by, execution_method, error_message, error_class, checksum",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Migration not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/labels": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the user-defined status labels of a migration, such as \"verified\", \"needs-backfill\" or \"deprecated\". Labels track operational workflow state next to the status set by executions, which they never change; filter the list with ?label=. Labels are lowercase letters, digits, '-' and '_' (at most 63 characters, 16 per migration); execution statuses (pending, applied, success, failed, rolled_back) are reserved. An empty list removes every label.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Set migration status labels",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status labels",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.StatusLabelsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StatusLabelsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid label",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an operator token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Migration not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Rolls back a specific migration. Refused with 409 while migrations that depend on it remain applied; the error lists them in the order to roll them back in.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Rollback migration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rollback request",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RollbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, or a schema name the schema policy refuses",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an operator or admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Migration not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Connection locked by another migration run, or dependent migrations still applied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in standby",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/schemas": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reports in which schemas of its connection a migration is applied, failed or pending, from migrations_executions. Pending schemas are those only other migrations of the connection ran in (tenants the migration has not reached yet), plus the schemas listed in schemas and, with schema_pattern (a SQL LIKE pattern such as tenant_%), the schemas of the connection's database matching it. Executions without a schema are left out; see /migrations/{id}/status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get per-schema migration status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated schemas to report even if nothing ran in them yet",
+                        "name": "schemas",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "SQL LIKE pattern of schemas to discover on the connection",
+                        "name": "schema_pattern",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MigrationSchemasResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schema pattern",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Migration not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/skipped": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets skipped migrations for a specific migration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get skipped migrations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Limit number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/migrations/{id}/status": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the current status of a specific migration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get migration status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the server can serve requests: the initial scan of the migration directories has finished, the state database is reachable and, when the queue is enabled, so is its broker. Each check is reported as ok or with why it failed. Also served at /readyz.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Not ready",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/tenant-status": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Starts generating, in the background, the status of each migration of a connection in each schema it was executed in, from the executions in the state database read in one query: a row per schema with its counts of applied, failed and pending migrations, and a column per migration, by version. schemas adds tenants nothing ran in yet. Poll the returned report until it is completed, then fetch its download_url, as CSV (default) or JSON. Reports are kept for an hour by the instance that generated them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Generate a tenant status report",
+                "parameters": [
+                    {
+                        "description": "Report request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TenantStatusReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Generating",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request: unknown connection or format, or an invalid schema",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the status of a report generated in the background: pending, completed (with its download_url) or failed (with the error).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReportResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found, or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads a completed report as an attachment, in the format it was requested in. A report still pending is a 409 with Retry-After.",
+                "produces": [
+                    "text/csv",
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Download a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The report (JSON, or CSV with a column per migration)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TenantStatusReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found, or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The report is pending, or failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/standby": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reports whether this server instance is a standby (BFM_STANDBY=true, not promoted yet) or the primary, and whether it holds the primary lock in the state database. A standby serves reads but refuses migrations, rollbacks, reindexes and lock releases with 503.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standby"
+                ],
+                "summary": "Get standby status",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandbyStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/standby/promote": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Promotes this standby to primary. The primary lock is taken first, so promotion fails with 409 while the primary still holds it; stop the primary (or wait for its state database session to end) and retry. Promoting the primary is a no-op. Requires an admin token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standby"
+                ],
+                "summary": "Promote a standby",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandbyStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: requires an admin token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "The primary still holds the primary lock",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/state-changes": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reads the feed of state changes, oldest first: migrations applied, failed or rolled back, and migrations registered or removed by a reindex (reindexed). Pass the next_cursor of a page as since to get the changes that followed it; a client that keeps its cursor syncs without listing every migration again. On etcd, only as many changes as history records are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "migrations"
+                ],
+                "summary": "Get state changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Cursor of the last change already read; 0 reads the feed from its start",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of changes (max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StateChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor or limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditRecordResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Number of records matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AuditRecordResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Identity name of the caller; empty when not authenticated",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "description": "HTTP method and path, or gRPC method",
+                    "type": "string"
+                },
+                "operation": {
+                    "description": "up, down, rollback, reindex, labels, release_lock, promote or replay",
+                    "type": "string"
+                },
+                "outcome": {
+                    "description": "success, partial, failed or denied",
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "http, grpc or connect",
+                    "type": "string"
+                },
+                "request_body": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "source_ip": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "HTTP status code or gRPC status code",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ConnectionMigrateResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "executed_sql": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ExecutedSQL"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.ConnectionsReloadResponse": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "changed": {
+                    "description": "Connections whose settings changed",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.DependencyResponse": {
+            "type": "object",
+            "properties": {
+                "connection": {
+                    "type": "string"
+                },
+                "requires_schema": {
+                    "type": "string"
+                },
+                "requires_table": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DriftedMigrationResponse": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
+                    "type": "string"
+                },
+                "applied_checksum": {
+                    "description": "SHA-256 of the up script when it was applied",
+                    "type": "string"
+                },
+                "backend": {
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "current_checksum": {
+                    "description": "SHA-256 of the up script in the SFM directory now",
+                    "type": "string"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EmergencyRunListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.EmergencyRunResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Number of runs matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.EmergencyRunResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "HTTP method and path of the request",
+                    "type": "string"
+                },
+                "operation": {
+                    "description": "up, down or rollback",
+                    "type": "string"
+                },
+                "overdue": {
+                    "description": "Pending past its due time",
+                    "type": "boolean"
+                },
+                "pending": {
+                    "description": "No postmortem recorded yet",
+                    "type": "boolean"
+                },
+                "postmortem": {
+                    "type": "string"
+                },
+                "postmortem_at": {
+                    "type": "string"
+                },
+                "postmortem_by": {
+                    "type": "string"
+                },
+                "postmortem_due": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EventResponse": {
+            "type": "object",
+            "properties": {
+                "connection": {
+                    "type": "string"
+                },
+                "data": {
+                    "description": "Type-specific details (error, elapsed_ms, rows_affected, job_id, ...)",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExecutedSQL": {
+            "type": "object",
+            "properties": {
+                "migration_id": {
+                    "type": "string"
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "suppressed": {
+                    "description": "The connection's SQL_LOG is \"none\", so SQL is omitted",
+                    "type": "boolean"
+                },
+                "truncated": {
+                    "description": "SQL was cut at 64 KiB",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.FacetValue": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FailureClassSummary": {
+            "type": "object",
+            "properties": {
+                "class": {
+                    "description": "syntax, permission, lock_timeout, connectivity, constraint_violation or unknown",
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "latest_error": {
+                    "type": "string"
+                },
+                "latest_failed_at": {
+                    "type": "string"
+                },
+                "latest_migration_id": {
+                    "type": "string"
+                },
+                "retryable": {
+                    "description": "Whether the worker retries jobs failing with this class",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.FailureSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "classes": {
+                    "description": "Classes with failures, most frequent first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FailureClassSummary"
+                    }
+                },
+                "total": {
+                    "description": "Failed executions matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.JobListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.JobResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Number of jobs matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.JobResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "attempts": {
+                    "description": "Execution attempts so far",
+                    "type": "integer"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "description": "When the job completed, failed, was dead-lettered or cancelled",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "queued_at": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "description": "When a scheduled job is due to run",
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "started_at": {
+                    "description": "When a worker picked the job up",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "scheduled, queued, picked_up, running, retrying, completed, failed, dead_lettered or cancelled",
+                    "type": "string"
+                },
+                "target": {
+                    "$ref": "#/definitions/registry.MigrationTarget"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "worker": {
+                    "description": "Process that picked the job up ({host}:{pid})",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MetaFeatures": {
+            "type": "object",
+            "properties": {
+                "auth_mode": {
+                    "description": "\"token\" or \"oidc\"",
+                    "type": "string"
+                },
+                "backends": {
+                    "description": "Registered backends, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "experimental": {
+                    "description": "Experimental features enabled for the request, by BFM_FEATURES or the X-BFM-Features header",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "queue": {
+                    "description": "Async execution through a queue is available",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.MetaResponse": {
+            "type": "object",
+            "properties": {
+                "api_versions": {
+                    "description": "Served under /api/{version}",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "build_date": {
+                    "description": "RFC 3339",
+                    "type": "string"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "features": {
+                    "$ref": "#/definitions/dto.MetaFeatures"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrateDownRequest": {
+            "type": "object",
+            "required": [
+                "migration_id"
+            ],
+            "properties": {
+                "capture_sql": {
+                    "description": "Return the rendered SQL of each migration",
+                    "type": "boolean"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "ignore_dependencies": {
+                    "type": "boolean"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "schemas": {
+                    "description": "Array for dynamic schemas",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.MigrateResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "connections": {
+                    "description": "Per-connection results of a multi-connection request",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ConnectionMigrateResponse"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "executed_sql": {
+                    "description": "Only when capture_sql was requested",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ExecutedSQL"
+                    }
+                },
+                "schemas": {
+                    "description": "Per-schema results, when schemas or a schema pattern were given",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SchemaMigrateResponse"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.MigrateUpRequest": {
+            "type": "object",
+            "properties": {
+                "allow_destructive": {
+                    "description": "Force destructive migrations on connections whose destructive policy is \"force\"",
+                    "type": "boolean"
+                },
+                "capture_sql": {
+                    "description": "Return the rendered SQL of each migration",
+                    "type": "boolean"
+                },
+                "concurrency": {
+                    "description": "Schemas of a connection run at once (default 1, capped by the server)",
+                    "type": "integer"
+                },
+                "connection": {
+                    "description": "Either connection or connections is required",
+                    "type": "string"
+                },
+                "connections": {
+                    "description": "Connection names or glob patterns (tenant_*), executed in turn unless parallel",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "ignore_dependencies": {
+                    "type": "boolean"
+                },
+                "migration_ids": {
+                    "description": "Run exactly these migrations (and their pending dependencies) instead of a target; requires connection",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "parallel": {
+                    "description": "Run the connections of connections at the same time instead of in turn",
+                    "type": "boolean"
+                },
+                "pinned_checksums": {
+                    "description": "Checksums of an approved plan (the plan's checksums); the request is refused with 409 if a\nmigration it would apply is missing from them or was modified since",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "schedule_at": {
+                    "description": "Run at this time (RFC 3339) instead of now, within the maintenance window; the response lists\nthe scheduled jobs. Requires connection; not combinable with connections, migration_ids,\nignore_dependencies or capture_sql.",
+                    "type": "string"
+                },
+                "schemas": {
+                    "description": "Array for dynamic schemas, or target.schema_pattern to discover them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target": {
+                    "$ref": "#/definitions/registry.MigrationTarget"
+                }
+            }
+        },
+        "dto.MigrationDetailResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "backend": {
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "dependencies": {
+                    "description": "List of migration names this migration depends on (backward compatibility)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "down_generated": {
+                    "description": "DownSQL was generated from UpSQL (no down script was provided)",
+                    "type": "boolean"
+                },
+                "down_sql": {
+                    "description": "Contains SQL for SQL backends or JSON for NoSQL backends",
+                    "type": "string"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "repeatable": {
+                    "description": "Applied again whenever UpSQL changes",
+                    "type": "boolean"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "status_labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "structured_dependencies": {
+                    "description": "Structured dependencies with validation requirements",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DependencyResponse"
+                    }
+                },
+                "table": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "key=value from registry",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "up_sql": {
+                    "description": "Contains SQL for SQL backends or JSON for NoSQL backends",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrationDriftResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DriftedMigrationResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.MigrationFacetResponse": {
+            "type": "object",
+            "properties": {
+                "facet": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Number of matching values before the limit",
+                    "type": "integer"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                }
+            }
+        },
+        "dto.MigrationFacetsResponse": {
+            "type": "object",
+            "properties": {
+                "backends": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                },
+                "connections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                },
+                "tags": {
+                    "description": "key=value from registry",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetValue"
+                    }
+                }
+            }
+        },
+        "dto.MigrationListItem": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "applied_at": {
+                    "type": "string"
+                },
+                "backend": {
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "destructive": {
+                    "description": "Kinds of statements discarding data, from registry",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "table": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "key=value from registry",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrationListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MigrationListItem"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Number of migrations matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.MigrationLockResponse": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "holder": {
+                    "description": "Process holding the lock: {host}:{pid} ({execution method}, {executed by})",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrationLocksResponse": {
+            "type": "object",
+            "properties": {
+                "locks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MigrationLockResponse"
+                    }
+                }
+            }
+        },
+        "dto.MigrationPlanResponse": {
+            "type": "object",
+            "properties": {
+                "apply": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "checksums": {
+                    "description": "Checksum of each migration in apply; pass as pinned_checksums to /migrations/up to run the plan as reviewed",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "skip": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MigrationPlanStep"
+                    }
+                }
+            }
+        },
+        "dto.MigrationPlanStep": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "\"apply\" or \"skip\"",
+                    "type": "string"
+                },
+                "backend": {
+                    "type": "string"
+                },
+                "checksum": {
+                    "description": "Checksum of the up script",
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "depends_on": {
+                    "description": "Migrations in the plan that must run first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "destructive": {
+                    "description": "Kinds of statements of the up script that discard data (DROP TABLE, DROP COLUMN, TRUNCATE, DELETE without WHERE...)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "down_generated": {
+                    "description": "Set when the down script was generated from the up script; down_sql holds it for review",
+                    "type": "boolean"
+                },
+                "down_sql": {
+                    "type": "string"
+                },
+                "findings": {
+                    "description": "Findings of custom validators for a migration that would be applied; error findings block the execution",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MigrationValidatorFinding"
+                    }
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "required_by": {
+                    "description": "Set for auto-included pending dependencies",
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "statements": {
+                    "description": "Number of statements the up script would be executed as, on backends that split scripts (PostgreSQL)",
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrationProgressResponse": {
+            "type": "object",
+            "properties": {
+                "elapsed_ms": {
+                    "description": "Time a completed statement, or a finished migration, took",
+                    "type": "integer"
+                },
+                "message": {
+                    "description": "The error of a failed migration",
+                    "type": "string"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "rows_affected": {
+                    "description": "Rows affected by a completed statement",
+                    "type": "integer"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "statement": {
+                    "description": "statement events: 1-based position of the statement in the script",
+                    "type": "integer"
+                },
+                "statement_done": {
+                    "description": "statement events: the statement completed (false when it starts)",
+                    "type": "boolean"
+                },
+                "statements": {
+                    "description": "statement events: statements in the script",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "running, statement, success or failed",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MigrationSchemasResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Number of schemas it is applied in",
+                    "type": "integer"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "schemas": {
+                    "description": "Sorted by schema",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SchemaStatusResponse"
+                    }
+                }
+            }
+        },
+        "dto.MigrationValidatorFinding": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "\"error\" (blocks the execution) or \"warning\"",
+                    "type": "string"
+                },
+                "validator": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PendingMigrationResponse": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string"
+                },
+                "connection": {
+                    "type": "string"
+                },
+                "migration_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PendingMigrationsResponse": {
+            "type": "object",
+            "properties": {
+                "connection": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PendingMigrationResponse"
+                    }
+                },
+                "schema": {
                     "type": "string"
                 }
             }
         },
-        "dto.MigrateDownRequest": {
+        "dto.PostmortemRequest": {
             "type": "object",
             "required": [
-                "migration_id"
+                "postmortem"
             ],
             "properties": {
-                "dry_run": {
-                    "type": "boolean"
-                },
-                "ignore_dependencies": {
-                    "type": "boolean"
-                },
-                "migration_id": {
+                "postmortem": {
+                    "description": "Incident report link or summary",
                     "type": "string"
-                },
-                "schemas": {
-                    "description": "Array for dynamic schemas",
-                    "type": "array",
-                    "items": {
+                }
+            }
+        },
+        "dto.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "\"ok\", or why the check failed, by check name",
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "status": {
+                    "description": "\"ready\" or \"not_ready\"",
+                    "type": "string"
                 }
             }
         },
-        "dto.MigrateResponse": {
+        "dto.ReindexResponse": {
             "type": "object",
             "properties": {
-                "applied": {
+                "added": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "errors": {
+                "removed": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "skipped": {
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                }
+            }
+        },
+        "dto.ReleaseLockResponse": {
+            "type": "object",
+            "properties": {
+                "connection": {
+                    "type": "string"
                 },
-                "success": {
+                "message": {
+                    "type": "string"
+                },
+                "released": {
+                    "description": "false when the connection was not locked",
                     "type": "boolean"
                 }
             }
         },
-        "dto.MigrateUpRequest": {
+        "dto.ReportResponse": {
             "type": "object",
-            "required": [
-                "connection"
-            ],
             "properties": {
                 "connection": {
                     "type": "string"
                 },
-                "dry_run": {
-                    "type": "boolean"
+                "created_at": {
+                    "type": "string"
                 },
-                "ignore_dependencies": {
-                    "type": "boolean"
+                "download_url": {
+                    "description": "Set once completed",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "csv or json",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, completed or failed",
+                    "type": "string"
                 },
+                "type": {
+                    "description": "tenant_status",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RollbackRequest": {
+            "type": "object",
+            "properties": {
                 "schemas": {
                     "description": "Array for dynamic schemas",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "target": {
-                    "$ref": "#/definitions/registry.MigrationTarget"
                 }
             }
         },
-        "dto.MigrationDetailResponse": {
+        "dto.RunListResponse": {
             "type": "object",
             "properties": {
-                "applied": {
-                    "type": "boolean"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunResponse"
+                    }
+                }
+            }
+        },
+        "dto.RunResponse": {
+            "type": "object",
+            "properties": {
+                "canceled_by": {
+                    "description": "Set once the run is canceled, until it stops",
+                    "type": "string"
                 },
-                "backend": {
+                "operation": {
+                    "description": "up, down or rollback",
                     "type": "string"
                 },
-                "connection": {
+                "run_id": {
                     "type": "string"
                 },
-                "dependencies": {
-                    "description": "List of migration names this migration depends on (backward compatibility)",
+                "started_at": {
+                    "type": "string"
+                },
+                "started_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ScheduleResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/dto.JobResponse"
                     }
                 },
-                "down_sql": {
-                    "description": "Contains SQL for SQL backends or JSON for NoSQL backends",
-                    "type": "string"
-                },
-                "migration_id": {
+                "maintenance_window": {
+                    "description": "HH:MM-HH:MM in UTC, or \"always\"",
                     "type": "string"
                 },
-                "name": {
+                "runs_at": {
+                    "description": "When the jobs start: schedule_at, or the next opening of the maintenance window",
                     "type": "string"
+                }
+            }
+        },
+        "dto.ScheduledJobListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.JobResponse"
+                    }
                 },
-                "schema": {
+                "maintenance_window": {
+                    "description": "HH:MM-HH:MM in UTC, or \"always\"",
                     "type": "string"
+                }
+            }
+        },
+        "dto.SchemaMigrateResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "structured_dependencies": {
-                    "description": "Structured dependencies with validation requirements",
+                "errors": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.DependencyResponse"
+                        "type": "string"
                     }
                 },
-                "table": {
+                "schema": {
                     "type": "string"
                 },
-                "tags": {
-                    "description": "key=value from registry",
+                "skipped": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "up_sql": {
-                    "description": "Contains SQL for SQL backends or JSON for NoSQL backends",
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.SchemaStatusResponse": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "applied, failed or pending",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Last execution in the schema; empty if it never ran there",
                     "type": "string"
                 },
                 "version": {
@@ -986,96 +4645,149 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.MigrationListItem": {
+        "dto.StandbyStatusResponse": {
             "type": "object",
             "properties": {
-                "applied": {
+                "holds_primary_lock": {
+                    "description": "Whether this instance holds the primary lock in the state database",
                     "type": "boolean"
                 },
-                "applied_at": {
+                "promoted_at": {
+                    "description": "When this instance was promoted from standby",
+                    "type": "string"
+                },
+                "promoted_by": {
+                    "description": "\"api\" (promote endpoint) or \"lock\" (auto-promotion when the primary stopped)",
                     "type": "string"
                 },
+                "role": {
+                    "description": "\"standby\" or \"primary\"",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.StateChangeResponse": {
+            "type": "object",
+            "properties": {
                 "backend": {
                     "type": "string"
                 },
                 "connection": {
                     "type": "string"
                 },
-                "error_message": {
+                "created_at": {
                     "type": "string"
                 },
-                "migration_id": {
+                "cursor": {
+                    "type": "integer"
+                },
+                "error": {
                     "type": "string"
                 },
-                "name": {
+                "migration_id": {
                     "type": "string"
                 },
                 "schema": {
                     "type": "string"
                 },
                 "status": {
+                    "description": "Status recorded for the migration; \"removed\" when a reindex removed it",
                     "type": "string"
                 },
-                "table": {
+                "type": {
+                    "description": "applied, failed, rolled_back or reindexed",
                     "type": "string"
                 },
-                "tags": {
-                    "description": "key=value from registry",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
                 "version": {
                     "type": "string"
                 }
             }
         },
-        "dto.MigrationListResponse": {
+        "dto.StateChangesResponse": {
             "type": "object",
             "properties": {
-                "items": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.MigrationListItem"
+                        "$ref": "#/definitions/dto.StateChangeResponse"
                     }
                 },
-                "total": {
+                "has_more": {
+                    "description": "More changes follow the page",
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "Cursor of the last change, to pass as since for the next page",
                     "type": "integer"
                 }
             }
         },
-        "dto.ReindexResponse": {
+        "dto.StatusLabelsRequest": {
             "type": "object",
             "properties": {
-                "added": {
+                "labels": {
+                    "description": "An empty list removes every label",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "removed": {
+                }
+            }
+        },
+        "dto.StatusLabelsResponse": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "description": "Lowercased, deduplicated and sorted",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "total": {
-                    "type": "integer"
+                "migration_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TenantStatusReport": {
+            "type": "object",
+            "properties": {
+                "connection": {
+                    "type": "string"
                 },
-                "updated": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "migrations": {
+                    "description": "Migration IDs, by version",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TenantStatusReportRow"
+                    }
                 }
             }
         },
-        "dto.RollbackRequest": {
+        "dto.TenantStatusReportRequest": {
             "type": "object",
+            "required": [
+                "connection"
+            ],
             "properties": {
+                "connection": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "csv (default) or json",
+                    "type": "string"
+                },
                 "schemas": {
-                    "description": "Array for dynamic schemas",
+                    "description": "Tenants to include even if nothing ran in them yet",
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -1083,6 +4795,31 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.TenantStatusReportRow": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "Neither applied nor failed: pending, rolled back or never run",
+                    "type": "integer"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "statuses": {
+                    "description": "Execution status by migration ID; migrations that never ran are left out",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "registry.MigrationTarget": {
             "type": "object",
             "properties": {
@@ -1094,12 +4831,20 @@ const docTemplate = `{
                     "description": "Connection name filter",
                     "type": "string"
                 },
+                "max_version": {
+                    "description": "MaxVersion limits the migrations to this version and older (\"migrate to version X\"), e.g.\nthe latest version of a tagged release (optional, empty = no limit)",
+                    "type": "string"
+                },
                 "schema": {
                     "description": "Schema filter (optional)",
                     "type": "string"
                 },
+                "schema_pattern": {
+                    "description": "SchemaPattern fans an up execution out to the schemas of the connection's database matching\nthis SQL LIKE pattern (e.g. \"tenant_%\"), instead of explicit schemas. It does not filter migrations.",
+                    "type": "string"
+                },
                 "tables": {
-                    "description": "Table filters (optional, empty = all)",
+                    "description": "Table filters (optional, empty = all); matches migrations declaring one with \"-- bfm-table:\"",
                     "type": "array",
                     "items": {
                         "type": "string"
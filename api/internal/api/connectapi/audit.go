@@ -0,0 +1,82 @@
+package connectapi
+
+import (
+	"context"
+	"errors"
+
+	pbapi "github.com/toolsascode/bfm/api/internal/api/protobuf"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// auditInterceptor records calls that mutate state in the audit log, like the gRPC audit
+// interceptors. It must come before authInterceptor so refused calls are recorded too.
+type auditInterceptor struct {
+	server *pbapi.Server
+}
+
+// grpcError converts a Connect error to a gRPC status error; Connect and gRPC share the same codes
+func grpcError(err error) error {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return status.Error(codes.Code(connectErr.Code()), connectErr.Message())
+	}
+	return err
+}
+
+func (i auditInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		call := pbapi.AuditCall{
+			FullMethod:    req.Spec().Procedure,
+			Protocol:      "connect",
+			Authorization: req.Header().Get("Authorization"),
+			SourceIP:      pbapi.SourceIP(req.Peer().Addr),
+			Err:           grpcError(err),
+		}
+		call.Request, _ = req.Any().(proto.Message)
+		if resp != nil {
+			call.Response = resp.Any()
+		}
+		i.server.Audit(ctx, call)
+		return resp, err
+	}
+}
+
+func (i auditInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i auditInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		audited := &auditedConn{StreamingHandlerConn: conn}
+		err := next(ctx, audited)
+		i.server.Audit(ctx, pbapi.AuditCall{
+			FullMethod:    conn.Spec().Procedure,
+			Protocol:      "connect",
+			Authorization: conn.RequestHeader().Get("Authorization"),
+			SourceIP:      pbapi.SourceIP(conn.Peer().Addr),
+			Request:       audited.req,
+			Err:           grpcError(err),
+		})
+		return err
+	}
+}
+
+// auditedConn is a streaming handler connection that keeps the first message received, the
+// request of a server-streaming call
+type auditedConn struct {
+	connect.StreamingHandlerConn
+	req proto.Message
+}
+
+func (c *auditedConn) Receive(m any) error {
+	err := c.StreamingHandlerConn.Receive(m)
+	if err == nil && c.req == nil {
+		c.req, _ = m.(proto.Message)
+	}
+	return err
+}
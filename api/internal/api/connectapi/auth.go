@@ -0,0 +1,70 @@
+package connectapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	pbapi "github.com/toolsascode/bfm/api/internal/api/protobuf"
+	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/features"
+
+	"connectrpc.com/connect"
+)
+
+// authInterceptor validates the API bearer token and its role, like the HTTP API's authorize
+// middleware
+type authInterceptor struct{}
+
+// authenticate validates the Authorization header of a call to procedure and returns ctx
+// carrying the caller's identity (see auth.FromContext) and feature flag overrides
+func authenticate(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+	role, required := pbapi.RequiredRole(procedure)
+	if !required {
+		return ctx, nil
+	}
+	token, err := auth.ExtractToken(header.Get("Authorization"))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	identity, err := auth.Authorize(token, role)
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	// Trusted callers may turn experimental features on or off for the call
+	ctx, err = features.Override(auth.NewContext(ctx, identity), identity, header.Get(features.Header))
+	if err != nil {
+		if errors.Is(err, features.ErrOverrideForbidden) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return ctx, nil
+}
+
+func (authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := authenticate(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := authenticate(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
@@ -0,0 +1,156 @@
+// Package connectapi serves the gRPC MigrationService over the Connect and gRPC-Web protocols on
+// the HTTP port, so browser clients (FfM) can use clients generated from migration.proto. Calls are
+// delegated to the gRPC server implementation so both surfaces behave the same.
+package connectapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	pbapi "github.com/toolsascode/bfm/api/internal/api/protobuf"
+	"github.com/toolsascode/bfm/api/internal/api/protobuf/protobufconnect"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Handler implements protobufconnect.MigrationServiceHandler on top of the gRPC server
+type Handler struct {
+	server *pbapi.Server
+}
+
+// NewHandler returns the path to mount the Connect handler on and the handler itself. Requests
+// require the API bearer token, except Health. Calls that mutate state are recorded in the audit log.
+func NewHandler(server *pbapi.Server) (string, http.Handler) {
+	return protobufconnect.NewMigrationServiceHandler(&Handler{server: server}, connect.WithInterceptors(auditInterceptor{server: server}, authInterceptor{}))
+}
+
+// unary calls a unary gRPC method with a Connect request
+func unary[Req, Res any](ctx context.Context, req *connect.Request[Req], method func(context.Context, *Req) (*Res, error)) (*connect.Response[Res], error) {
+	res, err := method(ctx, req.Msg)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	return connect.NewResponse(res), nil
+}
+
+// connectError converts a gRPC status error; Connect and gRPC share the same codes
+func connectError(err error) error {
+	if s, ok := status.FromError(err); ok {
+		return connect.NewError(connect.Code(s.Code()), errors.New(s.Message()))
+	}
+	return err
+}
+
+func (h *Handler) Migrate(ctx context.Context, req *connect.Request[pbapi.MigrateRequest]) (*connect.Response[pbapi.MigrateResponse], error) {
+	return unary(ctx, req, h.server.Migrate)
+}
+
+func (h *Handler) StreamMigrate(ctx context.Context, req *connect.Request[pbapi.MigrateRequest], stream *connect.ServerStream[pbapi.MigrateProgress]) error {
+	if err := h.server.StreamMigrate(req.Msg, &progressStream{ctx: ctx, stream: stream}); err != nil {
+		return connectError(err)
+	}
+	return nil
+}
+
+func (h *Handler) MigrateDown(ctx context.Context, req *connect.Request[pbapi.MigrateDownRequest]) (*connect.Response[pbapi.MigrateResponse], error) {
+	return unary(ctx, req, h.server.MigrateDown)
+}
+
+func (h *Handler) Plan(ctx context.Context, req *connect.Request[pbapi.PlanRequest]) (*connect.Response[pbapi.PlanResponse], error) {
+	return unary(ctx, req, h.server.Plan)
+}
+
+func (h *Handler) ListMigrations(ctx context.Context, req *connect.Request[pbapi.ListMigrationsRequest]) (*connect.Response[pbapi.ListMigrationsResponse], error) {
+	return unary(ctx, req, h.server.ListMigrations)
+}
+
+func (h *Handler) GetMigration(ctx context.Context, req *connect.Request[pbapi.GetMigrationRequest]) (*connect.Response[pbapi.MigrationDetailResponse], error) {
+	return unary(ctx, req, h.server.GetMigration)
+}
+
+func (h *Handler) GetMigrationStatus(ctx context.Context, req *connect.Request[pbapi.GetMigrationStatusRequest]) (*connect.Response[pbapi.MigrationStatusResponse], error) {
+	return unary(ctx, req, h.server.GetMigrationStatus)
+}
+
+func (h *Handler) IsMigrationApplied(ctx context.Context, req *connect.Request[pbapi.IsMigrationAppliedRequest]) (*connect.Response[pbapi.IsMigrationAppliedResponse], error) {
+	return unary(ctx, req, h.server.IsMigrationApplied)
+}
+
+func (h *Handler) GetMigrationHistory(ctx context.Context, req *connect.Request[pbapi.GetMigrationHistoryRequest]) (*connect.Response[pbapi.MigrationHistoryResponse], error) {
+	return unary(ctx, req, h.server.GetMigrationHistory)
+}
+
+func (h *Handler) GetPendingMigrations(ctx context.Context, req *connect.Request[pbapi.GetPendingMigrationsRequest]) (*connect.Response[pbapi.PendingMigrationsResponse], error) {
+	return unary(ctx, req, h.server.GetPendingMigrations)
+}
+
+func (h *Handler) GetMigrationSchemas(ctx context.Context, req *connect.Request[pbapi.GetMigrationSchemasRequest]) (*connect.Response[pbapi.MigrationSchemasResponse], error) {
+	return unary(ctx, req, h.server.GetMigrationSchemas)
+}
+
+func (h *Handler) RollbackMigration(ctx context.Context, req *connect.Request[pbapi.RollbackMigrationRequest]) (*connect.Response[pbapi.RollbackResponse], error) {
+	return unary(ctx, req, h.server.RollbackMigration)
+}
+
+func (h *Handler) ReindexMigrations(ctx context.Context, req *connect.Request[pbapi.ReindexMigrationsRequest]) (*connect.Response[pbapi.ReindexResponse], error) {
+	return unary(ctx, req, h.server.ReindexMigrations)
+}
+
+func (h *Handler) Health(ctx context.Context, req *connect.Request[pbapi.HealthRequest]) (*connect.Response[pbapi.HealthResponse], error) {
+	return unary(ctx, req, h.server.Health)
+}
+
+func (h *Handler) GetMeta(ctx context.Context, req *connect.Request[pbapi.GetMetaRequest]) (*connect.Response[pbapi.MetaResponse], error) {
+	return unary(ctx, req, h.server.GetMeta)
+}
+
+// progressStream adapts a Connect server stream to the gRPC stream StreamMigrate sends on
+type progressStream struct {
+	ctx    context.Context
+	stream *connect.ServerStream[pbapi.MigrateProgress]
+}
+
+func (s *progressStream) Send(progress *pbapi.MigrateProgress) error {
+	return s.stream.Send(progress)
+}
+
+func (s *progressStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *progressStream) SetHeader(md metadata.MD) error {
+	addMetadata(s.stream.ResponseHeader(), md)
+	return nil
+}
+
+func (s *progressStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *progressStream) SetTrailer(md metadata.MD) {
+	addMetadata(s.stream.ResponseTrailer(), md)
+}
+
+func (s *progressStream) SendMsg(m any) error {
+	progress, ok := m.(*pbapi.MigrateProgress)
+	if !ok {
+		return errors.New("unexpected message type on progress stream")
+	}
+	return s.stream.Send(progress)
+}
+
+func (s *progressStream) RecvMsg(m any) error {
+	return errors.New("progress stream is server-side only")
+}
+
+// addMetadata copies gRPC metadata into HTTP headers
+func addMetadata(header http.Header, md metadata.MD) {
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package connectapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	pbapi "github.com/toolsascode/bfm/api/internal/api/protobuf"
+	"github.com/toolsascode/bfm/api/internal/api/protobuf/protobufconnect"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"connectrpc.com/connect"
+)
+
+// healthyTracker is a state tracker whose health check succeeds
+type healthyTracker struct {
+	state.StateTracker
+}
+
+func (healthyTracker) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// auditTracker is a healthyTracker that keeps audit records
+type auditTracker struct {
+	healthyTracker
+	records []*state.AuditRecord
+}
+
+func (t *auditTracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	t.records = append(t.records, record)
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	mux := http.NewServeMux()
+	mux.Handle(NewHandler(pbapi.NewServer(executor.NewExecutor(registry.NewInMemoryRegistry(), healthyTracker{}))))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for name, option := range map[string]connect.ClientOption{
+		"connect":  connect.WithProtoJSON(),
+		"grpc-web": connect.WithGRPCWeb(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := protobufconnect.NewMigrationServiceClient(server.Client(), server.URL, option)
+
+			// Health does not require a token
+			health, err := client.Health(context.Background(), connect.NewRequest(&pbapi.HealthRequest{}))
+			if err != nil {
+				t.Fatalf("Health() error = %v", err)
+			}
+			if health.Msg.Status != "healthy" {
+				t.Errorf("Health() status = %q, want healthy", health.Msg.Status)
+			}
+
+			req := connect.NewRequest(&pbapi.GetMigrationRequest{MigrationId: "missing"})
+			if _, err := client.GetMigration(context.Background(), req); connect.CodeOf(err) != connect.CodeUnauthenticated {
+				t.Errorf("expected Unauthenticated without a token, got %v", err)
+			}
+
+			// gRPC status codes are preserved
+			req.Header().Set("Authorization", "Bearer test-token")
+			if _, err := client.GetMigration(context.Background(), req); connect.CodeOf(err) != connect.CodeNotFound {
+				t.Errorf("expected NotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHandler_ReadOnlyToken(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token")
+
+	tracker := &auditTracker{}
+	mux := http.NewServeMux()
+	mux.Handle(NewHandler(pbapi.NewServer(executor.NewExecutor(registry.NewInMemoryRegistry(), tracker))))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := protobufconnect.NewMigrationServiceClient(server.Client(), server.URL, connect.WithProtoJSON())
+
+	get := connect.NewRequest(&pbapi.GetMigrationRequest{MigrationId: "missing"})
+	get.Header().Set("Authorization", "Bearer ro-token")
+	if _, err := client.GetMigration(context.Background(), get); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("expected a read-only token to read, got %v", err)
+	}
+
+	migrate := connect.NewRequest(&pbapi.MigrateRequest{Connection: "core"})
+	migrate.Header().Set("Authorization", "Bearer ro-token")
+	if _, err := client.Migrate(context.Background(), migrate); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("expected PermissionDenied for a read-only token, got %v", err)
+	}
+
+	// Only the refused migrate is audited, with the caller's identity
+	if len(tracker.records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(tracker.records))
+	}
+	record := tracker.records[0]
+	if record.Operation != "up" || record.Protocol != "connect" || record.Outcome != state.AuditOutcomeDenied ||
+		record.Actor != "grafana" || record.Status != "PermissionDenied" || !strings.Contains(record.RequestBody, "core") {
+		t.Errorf("unexpected audit record %+v", record)
+	}
+}
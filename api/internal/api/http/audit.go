@@ -0,0 +1,227 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxAuditBody is the size of request bodies kept in the audit log; longer bodies are truncated
+	maxAuditBody = 64 << 10
+	// maxAuditResponse is the size of responses read for their error message
+	maxAuditResponse = 16 << 10
+
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// auditResponseWriter keeps the start of the response, to record its error message
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if remaining := maxAuditResponse - w.body.Len(); remaining > 0 {
+		w.body.Write(data[:min(len(data), remaining)])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// audit is a middleware that records the request in the audit log once it is handled, including
+// requests refused by authorize or requirePrimary, so it must come before them
+func (h *Handler) audit(operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			data, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIRequestBodyUnreadable, err)})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(data))
+			body = data
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if streamed, ok := c.Value(auditStatusKey).(int); ok {
+			// Streamed responses start with 200, before their outcome is known
+			status = streamed
+		}
+		record := &state.AuditRecord{
+			Operation:   operation,
+			Protocol:    "http",
+			Method:      c.Request.Method + " " + c.Request.URL.Path,
+			SourceIP:    c.ClientIP(),
+			RequestBody: truncateAuditBody(body),
+			Outcome:     auditOutcome(status),
+			Status:      strconv.Itoa(status),
+		}
+		if identity, ok := c.Value(identityKey).(*auth.Identity); ok {
+			record.Actor = identity.Name
+			record.Role = string(identity.Role)
+		}
+		if status >= http.StatusBadRequest || status == http.StatusPartialContent {
+			record.Error = responseError(writer.body.Bytes())
+			if streamed, ok := c.Value(auditErrorKey).(string); ok {
+				record.Error = streamed
+			}
+		}
+
+		// The request is done; don't let a client disconnect cancel the audit record
+		if err := h.executor.RecordAudit(context.WithoutCancel(c.Request.Context()), record); err != nil {
+			logger.Errorf("Failed to record audit of %s by %q: %v", record.Method, record.Actor, err)
+		}
+	}
+}
+
+// auditOutcome returns the audit outcome of an HTTP status
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return state.AuditOutcomeDenied
+	case status == http.StatusPartialContent:
+		return state.AuditOutcomePartial
+	case status >= http.StatusBadRequest:
+		return state.AuditOutcomeFailed
+	default:
+		return state.AuditOutcomeSuccess
+	}
+}
+
+// truncateAuditBody returns body as a string of at most maxAuditBody bytes
+func truncateAuditBody(body []byte) string {
+	if len(body) > maxAuditBody {
+		return string(body[:maxAuditBody]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// responseError returns the error message of a JSON error response, or the errors of a partial
+// migration response
+func responseError(body []byte) string {
+	var response struct {
+		Error  string   `json:"error"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return ""
+	}
+	if response.Error != "" {
+		return response.Error
+	}
+	return strings.Join(response.Errors, "; ")
+}
+
+// getAuditLog lists the audit log
+// @Summary      Get the audit log
+// @Description  Lists audit records of API calls that mutate state (up, down, rollback and reindex over HTTP, gRPC and Connect; status labels, lock releases and standby promotions over HTTP), newest first. Records include denied and failed calls. The audit log is append-only. Requires an admin token.
+// @Tags         audit
+// @Produce      json
+// @Param        operation query string false "Filter by operation (up, down, rollback, reindex, labels, release_lock, promote, replay, postmortem, cancel_run, rollback_run)"
+// @Param        actor query string false "Filter by caller identity name"
+// @Param        outcome query string false "Filter by outcome (success, partial, failed, denied)"
+// @Param        since query string false "Only records at or after this time (RFC 3339)"
+// @Param        until query string false "Only records before this time (RFC 3339)"
+// @Param        limit query int false "Maximum number of records (max 500)" default(50)
+// @Param        offset query int false "Number of records to skip" default(0)
+// @Success      200 {object} dto.AuditLogResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid filter"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /audit [get]
+func (h *Handler) getAuditLog(c *gin.Context) {
+	filters := &state.AuditFilters{
+		Operation: c.Query("operation"),
+		Actor:     c.Query("actor"),
+		Outcome:   c.Query("outcome"),
+		Limit:     defaultAuditLimit,
+	}
+	switch filters.Outcome {
+	case "", state.AuditOutcomeSuccess, state.AuditOutcomePartial, state.AuditOutcomeFailed, state.AuditOutcomeDenied:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidOutcome)})
+		return
+	}
+	for name, t := range map[string]*time.Time{"since": &filters.Since, "until": &filters.Until} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.InvalidTime, name)})
+				return
+			}
+			*t = parsed
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxAuditLimit)})
+			return
+		}
+		filters.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidOffset)})
+			return
+		}
+		filters.Offset = offset
+	}
+
+	records, total, err := h.executor.GetAuditLog(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]dto.AuditRecordResponse, 0, len(records))
+	for _, record := range records {
+		items = append(items, dto.AuditRecordResponse{
+			ID:          record.ID,
+			Operation:   record.Operation,
+			Actor:       record.Actor,
+			Role:        record.Role,
+			Protocol:    record.Protocol,
+			Method:      record.Method,
+			SourceIP:    record.SourceIP,
+			RequestBody: record.RequestBody,
+			Outcome:     record.Outcome,
+			Status:      record.Status,
+			Error:       record.Error,
+			CreatedAt:   record.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, dto.AuditLogResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStateChangeLimit = 100
+	maxStateChangeLimit     = 1000
+)
+
+// getStateChanges reads the state change feed
+// @Summary      Get state changes
+// @Description  Reads the feed of state changes, oldest first: migrations applied, failed or rolled back, and migrations registered or removed by a reindex (reindexed). Pass the next_cursor of a page as since to get the changes that followed it; a client that keeps its cursor syncs without listing every migration again. On etcd, only as many changes as history records are kept.
+// @Tags         migrations
+// @Produce      json
+// @Param        since query int false "Cursor of the last change already read; 0 reads the feed from its start" default(0)
+// @Param        limit query int false "Maximum number of changes (max 1000)" default(100)
+// @Success      200 {object} dto.StateChangesResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid cursor or limit"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /state-changes [get]
+func (h *Handler) getStateChanges(c *gin.Context) {
+	var since int64
+	if raw := c.Query("since"); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidSince)})
+			return
+		}
+		since = cursor
+	}
+	limit := defaultStateChangeLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxStateChangeLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxStateChangeLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	// One more change than the page tells whether more follow
+	changes, err := h.executor.GetStateChanges(c.Request.Context(), since, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := dto.StateChangesResponse{
+		Changes:    make([]dto.StateChangeResponse, 0, len(changes)),
+		NextCursor: since,
+		HasMore:    len(changes) > limit,
+	}
+	if response.HasMore {
+		changes = changes[:limit]
+	}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, dto.StateChangeResponse{
+			Cursor:      change.Cursor,
+			Type:        change.Type,
+			MigrationID: change.MigrationID,
+			Schema:      change.Schema,
+			Version:     change.Version,
+			Connection:  change.Connection,
+			Backend:     change.Backend,
+			Status:      change.Status,
+			Error:       change.Error,
+			CreatedAt:   change.CreatedAt,
+		})
+		response.NextCursor = change.Cursor
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reloadConnections reloads the connections without restarting the server
+// @Summary      Reload connections
+// @Description  Reloads the connections of this server instance from the environment it started with and the connections file (BFM_CONNECTIONS_FILE), so a new tenant database can be migrated without a restart. Connections of the file replace those of the environment with the same name. Executions in flight keep the settings they started with; an invalid file leaves the connections unchanged. The file is also checked for changes every BFM_CONNECTIONS_RELOAD_INTERVAL by the server and the worker. Requires an admin token.
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} dto.ConnectionsReloadResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      500 {object} map[string]interface{} "The connections file is invalid"
+// @Security     Bearer
+// @Router       /admin/connections/reload [post]
+func (h *Handler) reloadConnections(c *gin.Context) {
+	reload, err := h.executor.ReloadConnections(c.Request.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, executor.ErrNoConnectionsSource) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.ConnectionsReloadResponse{
+		Added:   reload.Added,
+		Removed: reload.Removed,
+		Changed: reload.Changed,
+	})
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diffSchema compares the schema of a connection with that of a reference connection
+// @Summary      Diff a connection's schema against a reference
+// @Description  Compares the tables, columns, indexes and constraints of a connection's schemas with those of a reference connection (e.g. a database changed by hand) and returns the statements that bring the connection to the reference, and back, as a candidate up and down migration for review. Only PostgreSQL is supported; views, functions, sequences, triggers and privileges are not compared. bfm diff writes the scripts to the migrations directory.
+// @Tags         migrations
+// @Produce      json
+// @Param        connection query string true "Connection to migrate"
+// @Param        reference query string true "Connection with the desired schema"
+// @Param        schemas query []string false "Schemas to compare (default: the connection's schema, else public)" collectionFormat(multi)
+// @Success      200 {object} dto.SchemaDiffResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request or unsupported backend"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Connection not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/diff [get]
+func (h *Handler) diffSchema(c *gin.Context) {
+	connection, reference := c.Query("connection"), c.Query("reference")
+	if connection == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIConnectionRequired)})
+		return
+	}
+	if reference == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIReferenceRequired)})
+		return
+	}
+	for _, name := range []string{connection, reference} {
+		if _, err := h.executor.GetConnectionConfig(c.Request.Context(), name); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var schemas []string
+	for _, value := range c.QueryArray("schemas") {
+		for _, schema := range strings.Split(value, ",") {
+			if schema = strings.TrimSpace(schema); schema != "" {
+				schemas = append(schemas, schema)
+			}
+		}
+	}
+
+	diff, err := h.executor.SchemaDiff(c.Request.Context(), connection, reference, schemas)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, executor.ErrDiffUnsupported) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SchemaDiffResponse{
+		Connection: diff.Connection,
+		Reference:  diff.Reference,
+		Schemas:    diff.Schemas,
+		HasChanges: diff.Diff.HasChanges(),
+		Up:         append([]string{}, diff.Diff.Up...),
+		Down:       append([]string{}, diff.Diff.Down...),
+		UpSQL:      diff.UpScript(),
+		DownSQL:    diff.DownScript(),
+	})
+}
@@ -13,8 +13,307 @@ type MigrateRequest struct {
 
 // MigrateResponse represents a migration response
 type MigrateResponse struct {
+	Success     bool          `json:"success"`
+	Applied     []string      `json:"applied"`
+	Skipped     []string      `json:"skipped"`
+	Errors      []string      `json:"errors"`
+	ExecutedSQL []ExecutedSQL `json:"executed_sql,omitempty"` // Only when capture_sql was requested
+
+	Connections []ConnectionMigrateResponse `json:"connections,omitempty"` // Per-connection results of a multi-connection request
+	Schemas     []SchemaMigrateResponse     `json:"schemas,omitempty"`     // Per-schema results, when schemas or a schema pattern were given
+
+	Cascaded []CascadedRollbackResponse `json:"cascaded,omitempty"` // Dependents rolled back first, in order, when cascade was requested
+}
+
+// CascadedRollbackResponse is the result of rolling back one applied dependent of a migration
+// before the migration itself
+type CascadedRollbackResponse struct {
+	MigrationID string   `json:"migration_id"`
+	Success     bool     `json:"success"`
+	Applied     []string `json:"applied,omitempty"`
+	Skipped     []string `json:"skipped,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// SchemaMigrateResponse is the result of an up request in one schema
+type SchemaMigrateResponse struct {
+	Schema  string   `json:"schema"`
 	Success bool     `json:"success"`
 	Applied []string `json:"applied"`
 	Skipped []string `json:"skipped"`
 	Errors  []string `json:"errors"`
 }
+
+// ConnectionMigrateResponse is the result of a multi-connection up request for one connection
+type ConnectionMigrateResponse struct {
+	Connection  string        `json:"connection"`
+	Success     bool          `json:"success"`
+	Applied     []string      `json:"applied"`
+	Skipped     []string      `json:"skipped"`
+	Errors      []string      `json:"errors"`
+	ExecutedSQL []ExecutedSQL `json:"executed_sql,omitempty"`
+}
+
+// ExecutedSQL is the rendered script of a migration (or the script a dry run would execute)
+type ExecutedSQL struct {
+	MigrationID string `json:"migration_id"`
+	SQL         string `json:"sql,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`  // SQL was cut at 64 KiB
+	Suppressed  bool   `json:"suppressed,omitempty"` // The connection's SQL_LOG is "none", so SQL is omitted
+}
+
+// StandbyStatusResponse reports whether the server instance is a standby or the primary
+type StandbyStatusResponse struct {
+	Role             string `json:"role"`                  // "standby" or "primary"
+	HoldsPrimaryLock bool   `json:"holds_primary_lock"`    // Whether this instance holds the primary lock in the state database
+	PromotedAt       string `json:"promoted_at,omitempty"` // When this instance was promoted from standby
+	PromotedBy       string `json:"promoted_by,omitempty"` // "api" (promote endpoint) or "lock" (auto-promotion when the primary stopped)
+}
+
+// ConnectionsReloadResponse lists the connections a reload added, removed and changed, by name
+type ConnectionsReloadResponse struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"` // Connections whose settings changed
+}
+
+// AuditRecordResponse is an audit record of an API call that mutates state
+type AuditRecordResponse struct {
+	ID          int64  `json:"id"`
+	Operation   string `json:"operation"`       // up, down, rollback, reindex, labels, release_lock, promote or replay
+	Actor       string `json:"actor,omitempty"` // Identity name of the caller; empty when not authenticated
+	Role        string `json:"role,omitempty"`
+	Protocol    string `json:"protocol"` // http, grpc or connect
+	Method      string `json:"method"`   // HTTP method and path, or gRPC method
+	SourceIP    string `json:"source_ip"`
+	RequestBody string `json:"request_body,omitempty"`
+	Outcome     string `json:"outcome"` // success, partial, failed or denied
+	Status      string `json:"status"`  // HTTP status code or gRPC status code
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// AuditLogResponse is a page of the audit log
+type AuditLogResponse struct {
+	Items  []AuditRecordResponse `json:"items"`
+	Total  int                   `json:"total"` // Number of records matching the filters
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+// JobResponse is the status of an async job queued by an up request
+type JobResponse struct {
+	ID          string                    `json:"id"`
+	Status      string                    `json:"status"` // scheduled, queued, picked_up, running, retrying, completed, failed, dead_lettered or cancelled
+	Connection  string                    `json:"connection"`
+	Schema      string                    `json:"schema,omitempty"`
+	Target      *registry.MigrationTarget `json:"target,omitempty"`
+	DryRun      bool                      `json:"dry_run"`
+	Worker      string                    `json:"worker,omitempty"` // Process that picked the job up ({host}:{pid})
+	Attempts    int                       `json:"attempts"`         // Execution attempts so far
+	Applied     []string                  `json:"applied"`
+	Skipped     []string                  `json:"skipped"`
+	Errors      []string                  `json:"errors"`
+	QueuedAt    string                    `json:"queued_at"`
+	ScheduledAt string                    `json:"scheduled_at,omitempty"` // When a scheduled job is due to run
+	StartedAt   string                    `json:"started_at,omitempty"`   // When a worker picked the job up
+	FinishedAt  string                    `json:"finished_at,omitempty"`  // When the job completed, failed, was dead-lettered or cancelled
+	UpdatedAt   string                    `json:"updated_at"`
+}
+
+// ScheduledJobListResponse lists the runs waiting for their scheduled time, soonest first
+type ScheduledJobListResponse struct {
+	Items             []JobResponse `json:"items"`
+	MaintenanceWindow string        `json:"maintenance_window"` // HH:MM-HH:MM in UTC, or "always"
+}
+
+// ScheduleResponse is the response to an up request with schedule_at: one job per schema
+type ScheduleResponse struct {
+	Jobs              []JobResponse `json:"jobs"`
+	RunsAt            string        `json:"runs_at"`            // When the jobs start: schedule_at, or the next opening of the maintenance window
+	MaintenanceWindow string        `json:"maintenance_window"` // HH:MM-HH:MM in UTC, or "always"
+}
+
+// JobListResponse is a page of async jobs
+type JobListResponse struct {
+	Items  []JobResponse `json:"items"`
+	Total  int           `json:"total"` // Number of jobs matching the filters
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// StateChangeResponse is an entry of the state change feed
+type StateChangeResponse struct {
+	Cursor      int64  `json:"cursor"`
+	Type        string `json:"type"` // applied, failed, rolled_back or reindexed
+	MigrationID string `json:"migration_id"`
+	Schema      string `json:"schema,omitempty"`
+	Version     string `json:"version"`
+	Connection  string `json:"connection"`
+	Backend     string `json:"backend"`
+	Status      string `json:"status"` // Status recorded for the migration; "removed" when a reindex removed it
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// StateChangesResponse is a page of the state change feed
+type StateChangesResponse struct {
+	Changes    []StateChangeResponse `json:"changes"`
+	NextCursor int64                 `json:"next_cursor"` // Cursor of the last change, to pass as since for the next page
+	HasMore    bool                  `json:"has_more"`    // More changes follow the page
+}
+
+// MetaResponse describes the server, so clients can feature-detect rather than assume the
+// behavior of a deployment
+type MetaResponse struct {
+	Version     string       `json:"version"`
+	Commit      string       `json:"commit,omitempty"`
+	BuildDate   string       `json:"build_date,omitempty"` // RFC 3339
+	GoVersion   string       `json:"go_version"`
+	APIVersions []string     `json:"api_versions"` // Served under /api/{version}
+	Features    MetaFeatures `json:"features"`
+}
+
+// MetaFeatures lists the features enabled on the server
+type MetaFeatures struct {
+	Queue    bool     `json:"queue"`     // Async execution through a queue is available
+	Backends []string `json:"backends"`  // Registered backends, sorted
+	AuthMode string   `json:"auth_mode"` // "token" or "oidc"
+	// Experimental features enabled for the request, by BFM_FEATURES or the X-BFM-Features header
+	Experimental []string `json:"experimental"`
+}
+
+// ReadinessResponse reports whether the server can serve requests
+type ReadinessResponse struct {
+	Status string            `json:"status"` // "ready" or "not_ready"
+	Checks map[string]string `json:"checks"` // "ok", or why the check failed, by check name
+}
+
+// TenantStatusReportRequest requests the tenant status report of a connection
+type TenantStatusReportRequest struct {
+	Connection string   `json:"connection" binding:"required"`
+	Schemas    []string `json:"schemas,omitempty"` // Tenants to include even if nothing ran in them yet
+	Format     string   `json:"format,omitempty"`  // csv (default) or json
+}
+
+// ReportResponse is the status of a report generated in the background
+type ReportResponse struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"` // tenant_status
+	Connection  string `json:"connection"`
+	Format      string `json:"format"` // csv or json
+	Status      string `json:"status"` // pending, completed or failed
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	FinishedAt  string `json:"finished_at,omitempty"`
+	DownloadURL string `json:"download_url,omitempty"` // Set once completed
+}
+
+// TenantStatusReport is a tenant status report in JSON: the status of each migration of a
+// connection in each schema
+type TenantStatusReport struct {
+	Connection  string                  `json:"connection"`
+	GeneratedAt string                  `json:"generated_at"`
+	Migrations  []string                `json:"migrations"` // Migration IDs, by version
+	Schemas     []TenantStatusReportRow `json:"schemas"`
+}
+
+// TenantStatusReportRow is the status of the migrations of a connection in a schema
+type TenantStatusReportRow struct {
+	Schema   string            `json:"schema"`
+	Applied  int               `json:"applied"`
+	Failed   int               `json:"failed"`
+	Pending  int               `json:"pending"`  // Neither applied nor failed: pending, rolled back or never run
+	Statuses map[string]string `json:"statuses"` // Execution status by migration ID; migrations that never ran are left out
+}
+
+// EmergencyRunResponse is a request that bypassed the execution safeguards, and its postmortem
+type EmergencyRunResponse struct {
+	ID            string `json:"id"`
+	Operation     string `json:"operation"` // up, down or rollback
+	Actor         string `json:"actor"`
+	Reason        string `json:"reason"`
+	Method        string `json:"method"` // HTTP method and path of the request
+	StartedAt     string `json:"started_at"`
+	PostmortemDue string `json:"postmortem_due"`
+	Pending       bool   `json:"pending"` // No postmortem recorded yet
+	Overdue       bool   `json:"overdue"` // Pending past its due time
+	Postmortem    string `json:"postmortem,omitempty"`
+	PostmortemBy  string `json:"postmortem_by,omitempty"`
+	PostmortemAt  string `json:"postmortem_at,omitempty"`
+}
+
+// EmergencyRunListResponse is a page of emergency runs
+type EmergencyRunListResponse struct {
+	Items  []EmergencyRunResponse `json:"items"`
+	Total  int                    `json:"total"` // Number of runs matching the filters
+	Limit  int                    `json:"limit"`
+	Offset int                    `json:"offset"`
+}
+
+// PostmortemRequest records the follow-up of an emergency run
+type PostmortemRequest struct {
+	Postmortem string `json:"postmortem" binding:"required"` // Incident report link or summary
+}
+
+// EventResponse is a lifecycle event streamed by GET /migrations/events
+type EventResponse struct {
+	Type        string                 `json:"type"`
+	Time        string                 `json:"time"`
+	MigrationID string                 `json:"migration_id,omitempty"`
+	Connection  string                 `json:"connection,omitempty"`
+	Schema      string                 `json:"schema,omitempty"`
+	Data        map[string]interface{} `json:"data,omitempty"` // Type-specific details (error, elapsed_ms, rows_affected, job_id, ...)
+}
+
+// RunResponse is a synchronous up, down or rollback request in flight on the server
+type RunResponse struct {
+	RunID      string `json:"run_id"`
+	Operation  string `json:"operation"` // up, down or rollback
+	StartedBy  string `json:"started_by"`
+	StartedAt  string `json:"started_at"`
+	CanceledBy string `json:"canceled_by,omitempty"` // Set once the run is canceled, until it stops
+}
+
+// RunListResponse lists the runs in flight on the server
+type RunListResponse struct {
+	Items []RunResponse `json:"items"`
+}
+
+// RecordedRunResponse sums up a run recorded in history: the migrations one up, down or rollback
+// invocation executed together
+type RecordedRunResponse struct {
+	RunID           string   `json:"run_id"`
+	StartedAt       string   `json:"started_at"`  // applied_at of its first record
+	FinishedAt      string   `json:"finished_at"` // applied_at of its last record
+	ExecutedBy      string   `json:"executed_by"`
+	ExecutionMethod string   `json:"execution_method"`
+	Operations      []string `json:"operations"` // up, down or rollback
+	Connections     []string `json:"connections"`
+	Records         int      `json:"records"`
+	Applied         int      `json:"applied"`
+	Failed          int      `json:"failed"`
+	RolledBack      int      `json:"rolled_back"`
+	InFlight        bool     `json:"in_flight"` // Still executing on this server
+}
+
+// RunRollbackRequest rolls back the migrations a recorded run applied
+type RunRollbackRequest struct {
+	DryRun bool `json:"dry_run,omitempty"` // Preview the down migrations without running them
+}
+
+// RunRollbackResponse is the result of rolling back a recorded run
+type RunRollbackResponse struct {
+	RunID   string                     `json:"run_id"`
+	Success bool                       `json:"success"`
+	DryRun  bool                       `json:"dry_run"`
+	Steps   []CascadedRollbackResponse `json:"steps"` // One per migration the run applied, in rollback order
+}
+
+// RecordedRunListResponse is a page of the runs recorded in history
+type RecordedRunListResponse struct {
+	Items  []RecordedRunResponse `json:"items"`
+	Total  int                   `json:"total"` // Number of runs matching the filters
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
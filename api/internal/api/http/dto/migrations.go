@@ -10,12 +10,35 @@ type MigrationListFilters struct {
 	Backend    string `form:"backend"`
 	Status     string `form:"status"`
 	Version    string `form:"version"`
+	Label      string `form:"label"`
+	PageFilters
 }
 
-// MigrationListResponse represents a list of migrations
+// PageFilters specifies paging and ordering of migration lists and history
+type PageFilters struct {
+	Limit     int    `form:"limit"`      // Maximum number of items; 0 returns every match
+	Offset    int    `form:"offset"`     // Number of items to skip
+	SortBy    string `form:"sort_by"`    // migration_id, schema, version, connection, backend, status or applied_at
+	SortOrder string `form:"sort_order"` // asc or desc
+}
+
+// HistoryFilters specifies filters of migration history
+type HistoryFilters struct {
+	AppliedAfter    string `form:"applied_after"`    // RFC 3339; records applied at or after
+	AppliedBefore   string `form:"applied_before"`   // RFC 3339; records applied before
+	ExecutedBy      string `form:"executed_by"`      // User identifier of the caller that ran the migration
+	ExecutionMethod string `form:"execution_method"` // manual, api, cli or worker
+	ErrorClass      string `form:"error_class"`      // Failure class of failed records
+	RunID           string `form:"run_id"`           // Records of this run
+	PageFilters
+}
+
+// MigrationListResponse represents a page of the list of migrations
 type MigrationListResponse struct {
-	Items []MigrationListItem `json:"items"`
-	Total int                 `json:"total"`
+	Items  []MigrationListItem `json:"items"`
+	Total  int                 `json:"total"` // Number of migrations matching the filters
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
 }
 
 // MigrationListItem represents a single migration in the list
@@ -32,6 +55,68 @@ type MigrationListItem struct {
 	AppliedAt    string   `json:"applied_at,omitempty"`
 	ErrorMessage string   `json:"error_message,omitempty"`
 	Tags         []string `json:"tags,omitempty"` // key=value from registry
+	StatusLabels []string `json:"status_labels,omitempty"`
+	Destructive  []string `json:"destructive,omitempty"` // Kinds of statements discarding data, from registry
+}
+
+// MigrationFacetFilters narrows the migrations whose distinct values are counted. The filter on
+// a facet's own field does not apply to that facet.
+type MigrationFacetFilters struct {
+	Schema     string `form:"schema"`
+	Table      string `form:"table"`
+	Connection string `form:"connection"`
+	Backend    string `form:"backend"`
+	Status     string `form:"status"`
+	Version    string `form:"version"`
+	Label      string `form:"label"`
+}
+
+// FailureSummaryFilters narrows the failed executions counted by the failure summary
+type FailureSummaryFilters struct {
+	Schema        string `form:"schema"`
+	Connection    string `form:"connection"`
+	Backend       string `form:"backend"`
+	Version       string `form:"version"`
+	AppliedAfter  string `form:"applied_after"`  // RFC 3339; failures at or after
+	AppliedBefore string `form:"applied_before"` // RFC 3339; failures before
+}
+
+// FailureClassSummary is the number of failed executions with a root cause, and the latest of them
+type FailureClassSummary struct {
+	Class             string `json:"class"` // syntax, permission, lock_timeout, connectivity, constraint_violation or unknown
+	Count             int    `json:"count"`
+	Retryable         bool   `json:"retryable"` // Whether the worker retries jobs failing with this class
+	LatestMigrationID string `json:"latest_migration_id"`
+	LatestFailedAt    string `json:"latest_failed_at"`
+	LatestError       string `json:"latest_error"`
+}
+
+// FailureSummaryResponse counts failed executions by root cause
+type FailureSummaryResponse struct {
+	Classes []FailureClassSummary `json:"classes"` // Classes with failures, most frequent first
+	Total   int                   `json:"total"`   // Failed executions matching the filters
+}
+
+// FacetValue is a distinct value of a migration field and the number of migrations having it
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// MigrationFacetsResponse lists the distinct values of the filterable migration fields, sorted
+type MigrationFacetsResponse struct {
+	Connections []FacetValue `json:"connections"`
+	Backends    []FacetValue `json:"backends"`
+	Schemas     []FacetValue `json:"schemas"`
+	Versions    []FacetValue `json:"versions"`
+	Tags        []FacetValue `json:"tags"` // key=value from registry
+}
+
+// MigrationFacetResponse lists the distinct values of one migration field matching a search
+type MigrationFacetResponse struct {
+	Facet  string       `json:"facet"`
+	Values []FacetValue `json:"values"`
+	Total  int          `json:"total"` // Number of matching values before the limit
 }
 
 // DependencyResponse represents a structured dependency
@@ -59,11 +144,26 @@ type MigrationDetailResponse struct {
 	Dependencies           []string             `json:"dependencies,omitempty"`            // List of migration names this migration depends on (backward compatibility)
 	StructuredDependencies []DependencyResponse `json:"structured_dependencies,omitempty"` // Structured dependencies with validation requirements
 	Tags                   []string             `json:"tags,omitempty"`                    // key=value from registry
+	DownGenerated          bool                 `json:"down_generated,omitempty"`          // DownSQL was generated from UpSQL (no down script was provided)
+	Repeatable             bool                 `json:"repeatable,omitempty"`              // Applied again whenever UpSQL changes
+	StatusLabels           []string             `json:"status_labels,omitempty"`
+}
+
+// StatusLabelsRequest replaces the user-defined status labels of a migration
+type StatusLabelsRequest struct {
+	Labels []string `json:"labels"` // An empty list removes every label
+}
+
+// StatusLabelsResponse reports the status labels of a migration after an update
+type StatusLabelsResponse struct {
+	MigrationID string   `json:"migration_id"`
+	Labels      []string `json:"labels"` // Lowercased, deduplicated and sorted
 }
 
 // RollbackRequest represents a request to rollback a migration
 type RollbackRequest struct {
 	Schemas []string `json:"schemas,omitempty"` // Array for dynamic schemas
+	Cascade bool     `json:"cascade,omitempty"` // Roll back the applied dependents first instead of refusing
 }
 
 // RollbackResponse represents a rollback operation result
@@ -81,6 +181,100 @@ type ReindexResponse struct {
 	Total   int      `json:"total"`
 }
 
+// MigrationLockResponse represents a held connection lock
+type MigrationLockResponse struct {
+	Connection string `json:"connection"`
+	Holder     string `json:"holder"` // Process holding the lock: {host}:{pid} ({execution method}, {executed by})
+	AcquiredAt string `json:"acquired_at"`
+}
+
+// MigrationLocksResponse lists the connection locks currently held
+type MigrationLocksResponse struct {
+	Locks []MigrationLockResponse `json:"locks"`
+}
+
+// DriftedMigrationResponse represents an applied migration whose up script changed after it ran
+type DriftedMigrationResponse struct {
+	MigrationID     string `json:"migration_id"`
+	Version         string `json:"version"`
+	Name            string `json:"name"`
+	Connection      string `json:"connection"`
+	Backend         string `json:"backend"`
+	AppliedAt       string `json:"applied_at"`
+	AppliedChecksum string `json:"applied_checksum"` // SHA-256 of the up script when it was applied
+	CurrentChecksum string `json:"current_checksum"` // SHA-256 of the up script in the SFM directory now
+}
+
+// MigrationDriftResponse lists applied migrations whose scripts were modified
+type MigrationDriftResponse struct {
+	Items []DriftedMigrationResponse `json:"items"`
+	Total int                        `json:"total"`
+}
+
+// MigrationConflictResponse represents a migration that was not loaded because a loaded migration
+// of its connection has the same version or name
+type MigrationConflictResponse struct {
+	Kind        string `json:"kind"` // version or name
+	Connection  string `json:"connection"`
+	Backend     string `json:"backend"`
+	Value       string `json:"value"`        // The shared version or name
+	MigrationID string `json:"migration_id"` // The migration that was not loaded
+	ExistingID  string `json:"existing_id"`  // The loaded migration it conflicts with
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+}
+
+// MigrationConflictsResponse lists the migrations that were not loaded because of a conflict
+type MigrationConflictsResponse struct {
+	Items []MigrationConflictResponse `json:"items"`
+	Total int                         `json:"total"`
+}
+
+// PendingMigrationResponse represents a registered migration that has not been applied
+type PendingMigrationResponse struct {
+	MigrationID string `json:"migration_id"`
+	Version     string `json:"version"`
+	Name        string `json:"name"`
+	Connection  string `json:"connection"`
+	Backend     string `json:"backend"`
+	Schema      string `json:"schema"`
+}
+
+// PendingMigrationsResponse lists the migrations of a connection that are not applied yet
+type PendingMigrationsResponse struct {
+	Connection string                     `json:"connection"`
+	Schema     string                     `json:"schema,omitempty"`
+	Count      int                        `json:"count"`
+	Items      []PendingMigrationResponse `json:"items"`
+}
+
+// MigrationsReadyResponse reports whether every migration of the requested connections is applied
+type MigrationsReadyResponse struct {
+	Ready       bool                        `json:"ready"`
+	Pending     int                         `json:"pending"`     // Pending migrations over all connections and schemas
+	Connections []PendingMigrationsResponse `json:"connections"` // Per connection (and schema, when schemas were given)
+}
+
+// SchemaDiffResponse is the difference between the schema of a connection and a reference, as a
+// candidate migration that brings the connection to the reference
+type SchemaDiffResponse struct {
+	Connection string   `json:"connection"`
+	Reference  string   `json:"reference"`
+	Schemas    []string `json:"schemas"`
+	HasChanges bool     `json:"has_changes"`
+	Up         []string `json:"up"`       // Statements that bring the connection to the reference
+	Down       []string `json:"down"`     // Statements that revert them
+	UpSQL      string   `json:"up_sql"`   // Up statements as a migration script
+	DownSQL    string   `json:"down_sql"` // Down statements as a migration script
+}
+
+// ReleaseLockResponse represents the result of force-releasing a connection lock
+type ReleaseLockResponse struct {
+	Connection string `json:"connection"`
+	Released   bool   `json:"released"` // false when the connection was not locked
+	Message    string `json:"message"`
+}
+
 // OrderMigrationBatchRequest requests a dependency-safe execution order for a set of migrations.
 type OrderMigrationBatchRequest struct {
 	MigrationIDs []string `json:"migration_ids" binding:"required"`
@@ -95,10 +289,37 @@ type OrderMigrationBatchResponse struct {
 // MigrateUpRequest represents a request to execute up migrations
 type MigrateUpRequest struct {
 	Target             *registry.MigrationTarget `json:"target"`
-	Connection         string                    `json:"connection" binding:"required"`
-	Schemas            []string                  `json:"schemas"` // Array for dynamic schemas
+	Connection         string                    `json:"connection"`  // Either connection or connections is required
+	Connections        []string                  `json:"connections"` // Connection names or glob patterns (tenant_*), executed in turn unless parallel
+	Schemas            []string                  `json:"schemas"`     // Array for dynamic schemas, or target.schema_pattern to discover them
+	Concurrency        int                       `json:"concurrency"` // Schemas of a connection run at once (default 1, capped by the server)
+	Parallel           bool                      `json:"parallel"`    // Run the connections of connections at the same time instead of in turn
 	DryRun             bool                      `json:"dry_run"`
 	IgnoreDependencies bool                      `json:"ignore_dependencies"`
+	CaptureSQL         bool                      `json:"capture_sql"`   // Return the rendered SQL of each migration
+	MigrationIDs       []string                  `json:"migration_ids"` // Run exactly these migrations (and their pending dependencies) instead of a target; requires connection
+	// Checksums of an approved plan (the plan's checksums); the request is refused with 409 if a
+	// migration it would apply is missing from them or was modified since
+	PinnedChecksums map[string]string `json:"pinned_checksums,omitempty"`
+	// Force destructive migrations on connections whose destructive policy is "force"
+	AllowDestructive bool `json:"allow_destructive,omitempty"`
+	// Run at this time (RFC 3339) instead of now, within the maintenance window; the response lists
+	// the scheduled jobs. Requires connection; not combinable with connections, migration_ids,
+	// ignore_dependencies or capture_sql.
+	ScheduleAt string `json:"schedule_at,omitempty"`
+}
+
+// MigrationProgressResponse is a progress event of a streamed up request
+type MigrationProgressResponse struct {
+	MigrationID   string `json:"migration_id"`
+	Schema        string `json:"schema,omitempty"`
+	Status        string `json:"status"`                   // running, statement, success or failed
+	Message       string `json:"message,omitempty"`        // The error of a failed migration
+	Statement     int    `json:"statement,omitempty"`      // statement events: 1-based position of the statement in the script
+	Statements    int    `json:"statements,omitempty"`     // statement events: statements in the script
+	StatementDone bool   `json:"statement_done,omitempty"` // statement events: the statement completed (false when it starts)
+	RowsAffected  int64  `json:"rows_affected,omitempty"`  // Rows affected by a completed statement
+	ElapsedMs     int64  `json:"elapsed_ms,omitempty"`     // Time a completed statement, or a finished migration, took
 }
 
 // MigrationExecutionResponse represents an execution record from migrations_executions
@@ -115,10 +336,89 @@ type MigrationExecutionResponse struct {
 	UpdatedAt   string `json:"updated_at"`
 }
 
+// SchemaStatusResponse is the status of a migration in one schema
+type SchemaStatusResponse struct {
+	Schema    string `json:"schema"`
+	Status    string `json:"status"` // applied, failed or pending
+	Version   string `json:"version,omitempty"`
+	AppliedAt string `json:"applied_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"` // Last execution in the schema; empty if it never ran there
+}
+
+// MigrationSchemasResponse is the status of a migration in each schema of its connection
+type MigrationSchemasResponse struct {
+	MigrationID string                 `json:"migration_id"`
+	Connection  string                 `json:"connection"`
+	Applied     int                    `json:"applied"` // Number of schemas it is applied in
+	Failed      int                    `json:"failed"`
+	Pending     int                    `json:"pending"`
+	Schemas     []SchemaStatusResponse `json:"schemas"` // Sorted by schema
+}
+
 // MigrateDownRequest represents a request to execute down migrations
 type MigrateDownRequest struct {
 	MigrationID        string   `json:"migration_id" binding:"required"`
 	Schemas            []string `json:"schemas"` // Array for dynamic schemas
 	DryRun             bool     `json:"dry_run"`
 	IgnoreDependencies bool     `json:"ignore_dependencies"`
+	Cascade            bool     `json:"cascade"`     // Roll back the applied dependents first instead of refusing
+	CaptureSQL         bool     `json:"capture_sql"` // Return the rendered SQL of each migration
+}
+
+// MigrationPlanQuery specifies the target of an execution plan (same selection as MigrateUpRequest)
+type MigrationPlanQuery struct {
+	Connection         string   `form:"connection" binding:"required"`
+	Backend            string   `form:"backend"`
+	Schema             string   `form:"schema"`
+	Tables             []string `form:"tables"`
+	Version            string   `form:"version"`
+	MaxVersion         string   `form:"max_version"` // Only this version and older
+	Tags               []string `form:"tags"`
+	Schemas            []string `form:"schemas"` // Repeat for dynamic schemas
+	IgnoreDependencies bool     `form:"ignore_dependencies"`
+	// Response format: empty for MigrationPlanResponse, "machine" for the stable plan format
+	// (executor.MachinePlan), "terraform" for its flat string form (external data sources)
+	Format string `form:"format"`
+}
+
+// MigrationPlanStep represents one migration in an execution plan
+type MigrationPlanStep struct {
+	Order       int      `json:"order"`
+	MigrationID string   `json:"migration_id"`
+	Version     string   `json:"version"`
+	Name        string   `json:"name"`
+	Backend     string   `json:"backend"`
+	Connection  string   `json:"connection"`
+	Schema      string   `json:"schema"`
+	Action      string   `json:"action"` // "apply" or "skip"
+	Reason      string   `json:"reason"`
+	DependsOn   []string `json:"depends_on"`            // Migrations in the plan that must run first
+	RequiredBy  string   `json:"required_by,omitempty"` // Set for auto-included pending dependencies
+	// Set when the down script was generated from the up script; down_sql holds it for review
+	DownGenerated bool   `json:"down_generated,omitempty"`
+	DownSQL       string `json:"down_sql,omitempty"`
+	Checksum      string `json:"checksum"` // Checksum of the up script
+	// Number of statements the up script would be executed as, on backends that split scripts (PostgreSQL)
+	Statements int `json:"statements,omitempty"`
+	// Kinds of statements of the up script that discard data (DROP TABLE, DROP COLUMN, TRUNCATE, DELETE without WHERE...)
+	Destructive []string `json:"destructive,omitempty"`
+	// Findings of custom validators for a migration that would be applied; error findings block the execution
+	Findings []MigrationValidatorFinding `json:"findings,omitempty"`
+}
+
+// MigrationValidatorFinding is a problem a custom validator found in a migration
+type MigrationValidatorFinding struct {
+	Validator string `json:"validator"`
+	Severity  string `json:"severity"` // "error" (blocks the execution) or "warning"
+	Message   string `json:"message"`
+}
+
+// MigrationPlanResponse represents a resolved, ordered execution plan (nothing is executed)
+type MigrationPlanResponse struct {
+	Steps  []MigrationPlanStep `json:"steps"`
+	Apply  []string            `json:"apply"`
+	Skip   []string            `json:"skip"`
+	Errors []string            `json:"errors"`
+	// Checksum of each migration in apply; pass as pinned_checksums to /migrations/up to run the plan as reviewed
+	Checksums map[string]string `json:"checksums"`
 }
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmergencyHeader is the request header that makes an up, down or rollback request an emergency
+// run, with the reason as its value: drift and validator errors are logged instead of refusing the
+// execution, and a postmortem is due afterwards
+const EmergencyHeader = "X-BFM-Emergency"
+
+// emergencyKey is the gin context key of the *state.EmergencyRun an emergency request starts
+const emergencyKey = "emergency_run"
+
+// breakGlass returns a middleware that accepts emergency requests (EmergencyHeader) of operation
+// from callers allowed by auth.CanBreakGlass, with 403 for others and 400 without a reason. The
+// run is only recorded once the request is about to execute (see startEmergencyRun).
+func (h *Handler) breakGlass(operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(c.Request.Header.Values(EmergencyHeader)) == 0 {
+			c.Next()
+			return
+		}
+		identity, _ := c.Value(identityKey).(*auth.Identity)
+		if !auth.CanBreakGlass(identity) {
+			c.JSON(http.StatusForbidden, gin.H{"error": localized(c, i18n.APIEmergencyForbidden, EmergencyHeader)})
+			c.Abort()
+			return
+		}
+		reason := strings.TrimSpace(c.GetHeader(EmergencyHeader))
+		if reason == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIEmergencyReasonRequired, EmergencyHeader)})
+			c.Abort()
+			return
+		}
+		c.Set(emergencyKey, &state.EmergencyRun{
+			Operation: operation,
+			Actor:     identity.Name,
+			Reason:    reason,
+			Method:    c.Request.Method + " " + c.Request.URL.Path,
+		})
+		c.Next()
+	}
+}
+
+// startEmergencyRun records the emergency run of an emergency request and marks ctx with it; other
+// requests get ctx back as is. When the run cannot be recorded, the error is written and ok is false.
+func (h *Handler) startEmergencyRun(c *gin.Context, ctx context.Context) (context.Context, bool) {
+	run, ok := c.Value(emergencyKey).(*state.EmergencyRun)
+	if !ok {
+		return ctx, true
+	}
+	ctx, _, err := h.executor.StartEmergencyRun(ctx, run)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ctx, false
+	}
+	return ctx, true
+}
+
+// listEmergencyRuns lists emergency runs
+// @Summary      List emergency runs
+// @Description  Lists the requests sent with the X-BFM-Emergency header, which bypassed the drift check and the validators, most recent first, with their postmortems; pending=true lists those still waiting for one.
+// @Tags         emergency
+// @Produce      json
+// @Param        pending query bool false "Only runs without a postmortem"
+// @Param        limit query int false "Maximum number of runs (max 500)" default(50)
+// @Param        offset query int false "Number of runs to skip" default(0)
+// @Success      200 {object} dto.EmergencyRunListResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid filter"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /emergency-runs [get]
+func (h *Handler) listEmergencyRuns(c *gin.Context) {
+	filters := &state.EmergencyRunFilters{
+		Pending: c.Query("pending") == "true",
+		Limit:   defaultJobLimit,
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxJobLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxJobLimit)})
+			return
+		}
+		filters.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidOffset)})
+			return
+		}
+		filters.Offset = offset
+	}
+
+	runs, total, err := h.executor.GetEmergencyRuns(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	items := make([]dto.EmergencyRunResponse, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, emergencyRunResponse(run, now))
+	}
+	c.JSON(http.StatusOK, dto.EmergencyRunListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+}
+
+// getEmergencyRun gets an emergency run
+// @Summary      Get an emergency run
+// @Description  Gets an emergency run (the emergency_run of the history records it executed), with its postmortem, or when it is due.
+// @Tags         emergency
+// @Produce      json
+// @Param        id path string true "Emergency run ID"
+// @Success      200 {object} dto.EmergencyRunResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Emergency run not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /emergency-runs/{id} [get]
+func (h *Handler) getEmergencyRun(c *gin.Context) {
+	run, err := h.executor.GetEmergencyRun(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, state.ErrEmergencyRunNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, emergencyRunResponse(run, time.Now()))
+}
+
+// recordPostmortem records the postmortem of an emergency run
+// @Summary      Record the postmortem of an emergency run
+// @Description  Records the follow-up of an emergency run (an incident report link or a summary), which stops the reminders sent while it is overdue. A postmortem already recorded is replaced.
+// @Tags         emergency
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Emergency run ID"
+// @Param        request body dto.PostmortemRequest true "Postmortem"
+// @Success      200 {object} dto.EmergencyRunResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Emergency run not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /emergency-runs/{id}/postmortem [post]
+func (h *Handler) recordPostmortem(c *gin.Context) {
+	var req dto.PostmortemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	run, err := h.executor.RecordPostmortem(c.Request.Context(), c.Param("id"), req.Postmortem, h.getExecutedBy(c))
+	switch {
+	case errors.Is(err, state.ErrEmergencyRunNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, executor.ErrInvalidPostmortem):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, emergencyRunResponse(run, time.Now()))
+}
+
+// emergencyRunResponse converts an emergency run to the response format, overdue as of now
+func emergencyRunResponse(run *state.EmergencyRun, now time.Time) dto.EmergencyRunResponse {
+	response := dto.EmergencyRunResponse{
+		ID:            run.ID,
+		Operation:     run.Operation,
+		Actor:         run.Actor,
+		Reason:        run.Reason,
+		Method:        run.Method,
+		StartedAt:     run.StartedAt,
+		PostmortemDue: run.PostmortemDue,
+		Pending:       run.Pending(),
+		Postmortem:    run.Postmortem,
+		PostmortemBy:  run.PostmortemBy,
+		PostmortemAt:  run.PostmortemAt,
+	}
+	if due, err := time.Parse(time.RFC3339, run.PostmortemDue); err == nil && run.Pending() {
+		response.Overdue = due.Before(now)
+	}
+	return response
+}
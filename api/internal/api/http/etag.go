@@ -0,0 +1,70 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newETag returns a weak ETag for the content written to h
+func newETag(h hash.Hash) string {
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// bytesETag returns a weak ETag for data
+func bytesETag(data []byte) string {
+	h := sha256.New()
+	_, _ = h.Write(data)
+	return newETag(h)
+}
+
+// jsonETag returns a weak ETag for the JSON encoding of v
+func jsonETag(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return bytesETag(data)
+}
+
+// migrationListETag returns an ETag over the state of the listed migrations: their status,
+// applied_at, error, checksum and status labels, the registry tags included in the response, and
+// the total number of matches when the list is a page
+func migrationListETag(items []*state.MigrationListItem, total int, tags func(migrationID string) []string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d\n", total)
+	for _, item := range items {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%s\x00%s\x00%s\n",
+			item.MigrationID, item.Schema, item.Table, item.Version, item.Name, item.Connection, item.Backend,
+			item.LastStatus, item.LastAppliedAt, item.LastErrorMessage, item.Applied, item.Checksum,
+			strings.Join(tags(item.MigrationID), ","), strings.Join(item.StatusLabels, ","))
+	}
+	return newETag(h)
+}
+
+// notModified sets the ETag header and, when the request's If-None-Match matches it, responds
+// 304 Not Modified and returns true. Responses are marked no-cache so clients always revalidate.
+func notModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
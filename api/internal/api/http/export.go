@@ -0,0 +1,44 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/toolsascode/bfm/api/internal/export"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantsCSV reports whether the request asks for ?format=csv. Any format other than json or csv
+// is answered with a 400 and false.
+func wantsCSV(c *gin.Context) (bool, bool) {
+	switch format := c.Query("format"); format {
+	case "", "json":
+		return false, true
+	case "csv":
+		return true, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIUnsupportedFormat, format)})
+		return false, false
+	}
+}
+
+// respondCSV writes a CSV attachment named filename. The CSV is buffered so an unknown column
+// in ?columns= is answered with a 400 instead of a truncated file.
+func respondCSV(c *gin.Context, filename string, write func(w io.Writer, columns []string) error) {
+	var buf bytes.Buffer
+	if err := write(&buf, export.ParseColumns(c.Query("columns"))); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, export.ErrUnknownColumn) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxFacetLimit = 1000
+
+// facetFields maps the facet names of the API to the facet fields of the executor
+var facetFields = map[string]string{
+	"connections": executor.FacetConnection,
+	"backends":    executor.FacetBackend,
+	"schemas":     executor.FacetSchema,
+	"versions":    executor.FacetVersion,
+	"tags":        executor.FacetTag,
+}
+
+// migrationFacets counts the distinct values of each facet field among the migrations matching
+// the query filters
+func (h *Handler) migrationFacets(c *gin.Context) (map[string][]executor.FacetValue, bool) {
+	var filters dto.MigrationFacetFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	stateFilters := &state.MigrationFilters{
+		Schema:     filters.Schema,
+		Table:      filters.Table,
+		Connection: filters.Connection,
+		Backend:    filters.Backend,
+		Status:     filters.Status,
+		Version:    filters.Version,
+		Label:      filters.Label,
+	}
+	facets, err := h.executor.GetMigrationFacets(c.Request.Context(), stateFilters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return facets, true
+}
+
+// facetValues converts facet values to DTOs
+func facetValues(values []executor.FacetValue) []dto.FacetValue {
+	converted := make([]dto.FacetValue, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, dto.FacetValue{Value: value.Value, Count: value.Count})
+	}
+	return converted
+}
+
+// listMigrationFacets lists the distinct values of the filterable migration fields
+// @Summary      List migration facets
+// @Description  Lists the distinct connections, backends, schemas, versions and tags of the migrations in the state database, each with the number of migrations having it, sorted by value, for building filter dropdowns without downloading the migration list. A migration registered for several schemas counts for each. The filters narrow the migrations counted, except that a field's own filter does not apply to its values, so a dropdown keeps offering the alternatives to its selection.
+// @Tags         migrations
+// @Produce      json
+// @Param        schema query string false "Schema filter"
+// @Param        table query string false "Table filter"
+// @Param        connection query string false "Connection filter"
+// @Param        backend query string false "Backend filter"
+// @Param        status query string false "Status filter"
+// @Param        version query string false "Version filter"
+// @Param        label query string false "User-defined status label filter"
+// @Success      200 {object} dto.MigrationFacetsResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/facets [get]
+func (h *Handler) listMigrationFacets(c *gin.Context) {
+	facets, ok := h.migrationFacets(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dto.MigrationFacetsResponse{
+		Connections: facetValues(facets[executor.FacetConnection]),
+		Backends:    facetValues(facets[executor.FacetBackend]),
+		Schemas:     facetValues(facets[executor.FacetSchema]),
+		Versions:    facetValues(facets[executor.FacetVersion]),
+		Tags:        facetValues(facets[executor.FacetTag]),
+	})
+}
+
+// getMigrationFacet lists the distinct values of one migration field, for autocompletion
+// @Summary      Get a migration facet
+// @Description  Lists the distinct values of one migration field (connections, backends, schemas, versions or tags) with their counts, sorted by value, for autocompleting a filter as the user types. q keeps the values containing it, ignoring case; total counts them before limit. Filters apply as in GET /migrations/facets.
+// @Tags         migrations
+// @Produce      json
+// @Param        facet path string true "Facet" Enums(connections, backends, schemas, versions, tags)
+// @Param        q query string false "Text the values must contain, ignoring case"
+// @Param        limit query int false "Maximum number of values (max 1000; default: all)"
+// @Param        schema query string false "Schema filter"
+// @Param        table query string false "Table filter"
+// @Param        connection query string false "Connection filter"
+// @Param        backend query string false "Backend filter"
+// @Param        status query string false "Status filter"
+// @Param        version query string false "Version filter"
+// @Param        label query string false "User-defined status label filter"
+// @Success      200 {object} dto.MigrationFacetResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Unknown facet or invalid limit"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/facets/{facet} [get]
+func (h *Handler) getMigrationFacet(c *gin.Context) {
+	name := c.Param("facet")
+	field, ok := facetFields[name]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIUnknownFacet, name, "connections, backends, schemas, versions, tags")})
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxFacetLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxFacetLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	facets, ok := h.migrationFacets(c)
+	if !ok {
+		return
+	}
+	values := facets[field]
+	if query := strings.ToLower(c.Query("q")); query != "" {
+		var matching []executor.FacetValue
+		for _, value := range values {
+			if strings.Contains(strings.ToLower(value.Value), query) {
+				matching = append(matching, value)
+			}
+		}
+		values = matching
+	}
+	response := dto.MigrationFacetResponse{Facet: name, Total: len(values)}
+	if limit > 0 && len(values) > limit {
+		values = values[:limit]
+	}
+	response.Values = facetValues(values)
+	c.JSON(http.StatusOK, response)
+}
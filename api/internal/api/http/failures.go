@@ -0,0 +1,80 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getFailureSummary counts failed executions by root cause
+// @Summary      Summarize failures by root cause
+// @Description  Counts the failed executions in migration history by root-cause class: syntax, permission, lock_timeout, connectivity, constraint_violation or unknown. Classes come from the error codes of the backend's driver (SQLSTATE for PostgreSQL, protocol codes for Cassandra, gRPC codes for etcd and Spanner), falling back to the error message; failures recorded before classification are classified by their message. Each class reports its latest failure and whether the worker retries jobs failing with it. Classes without failures are left out.
+// @Tags         migrations
+// @Produce      json
+// @Param        schema query string false "Schema filter"
+// @Param        connection query string false "Connection filter"
+// @Param        backend query string false "Backend filter"
+// @Param        version query string false "Version filter"
+// @Param        applied_after query string false "Failures at or after this RFC 3339 time"
+// @Param        applied_before query string false "Failures before this RFC 3339 time"
+// @Success      200 {object} dto.FailureSummaryResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/failures/summary [get]
+func (h *Handler) getFailureSummary(c *gin.Context) {
+	var query dto.FailureSummaryFilters
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters := &state.MigrationFilters{
+		Schema:     query.Schema,
+		Connection: query.Connection,
+		Backend:    query.Backend,
+		Version:    query.Version,
+	}
+	for _, bound := range []struct {
+		name string
+		raw  string
+		t    *time.Time
+	}{
+		{"applied_after", query.AppliedAfter, &filters.AppliedAfter},
+		{"applied_before", query.AppliedBefore, &filters.AppliedBefore},
+	} {
+		if bound.raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.InvalidTime, bound.name)})
+			return
+		}
+		*bound.t = parsed
+	}
+
+	summary, total, err := h.executor.GetFailureSummary(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.FailureSummaryResponse{Classes: make([]dto.FailureClassSummary, 0, len(summary)), Total: total}
+	for _, count := range summary {
+		response.Classes = append(response.Classes, dto.FailureClassSummary{
+			Class:             string(count.Class),
+			Count:             count.Count,
+			Retryable:         count.Class.Retryable(),
+			LatestMigrationID: count.Latest.MigrationID,
+			LatestFailedAt:    count.Latest.AppliedAt,
+			LatestError:       count.Latest.ErrorMessage,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
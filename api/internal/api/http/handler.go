@@ -3,15 +3,23 @@ package http
 import (
 	"context"
 	_ "embed"
+	"errors"
+	"io"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/toolsascode/bfm/api/internal/api/http/dto"
 	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/buildinfo"
+	"github.com/toolsascode/bfm/api/internal/config"
 	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/export"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/logger"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/internal/state"
 
@@ -22,63 +30,153 @@ import (
 // Handler handles HTTP API requests
 type Handler struct {
 	executor *executor.Executor
+
+	closeStreams     chan struct{} // Closed by CloseStreams
+	closeStreamsOnce sync.Once
 }
 
 // NewHandler creates a new HTTP handler
 func NewHandler(exec *executor.Executor) *Handler {
 	return &Handler{
-		executor: exec,
+		executor:     exec,
+		closeStreams: make(chan struct{}),
 	}
 }
 
 // RegisterRoutes registers HTTP routes
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	api := router.Group("/api/v1")
+
+	// Probes answer while the server is starting; everything else waits for migrations to load
+	api.GET("/health", h.Health)
+	api.GET("/livez", h.Livez)
+	api.GET("/readyz", h.Readyz)
+	api.Use(h.RequireStarted)
 	{
 		// Handle OPTIONS for all routes
 		api.OPTIONS("/*path", func(c *gin.Context) {
 			c.Status(http.StatusNoContent)
 		})
 
-		api.POST("/migrations/up", h.authenticate, h.migrateUp)
-		api.POST("/migrations/order-batch", h.authenticate, h.orderMigrationBatch)
-		api.POST("/migrations/down", h.authenticate, h.migrateDown)
-		api.GET("/migrations", h.authenticate, h.listMigrations)
-		api.GET("/migrations/:id", h.authenticate, h.getMigration)
-		api.GET("/migrations/:id/status", h.authenticate, h.getMigrationStatus)
-		api.GET("/migrations/:id/applied", h.authenticate, h.isMigrationApplied)
-		api.GET("/migrations/:id/history", h.authenticate, h.getMigrationHistory)
-		api.GET("/migrations/:id/executions", h.authenticate, h.getMigrationExecutions)
-		api.GET("/migrations/executions/recent", h.authenticate, h.getRecentExecutions)
-		api.GET("/migrations/:id/skipped", h.authenticate, h.getSkippedMigrations)
-		api.GET("/migrations/skipped/recent", h.authenticate, h.getRecentSkippedMigrations)
-		api.POST("/migrations/:id/rollback", h.authenticate, h.rollbackMigration)
-		api.POST("/migrations/reindex", h.authenticate, h.reindexMigrations)
-		api.GET("/health", h.Health)
+		api.POST("/migrations/up", h.audit("up"), h.authorize(auth.RoleOperator), h.requirePrimary, h.breakGlass("up"), h.trackRun("up"), h.migrateUp)
+		api.POST("/migrations/up/stream", h.audit("up"), h.authorize(auth.RoleOperator), h.requirePrimary, h.breakGlass("up"), h.trackRun("up"), h.migrateUpStream)
+		api.POST("/migrations/order-batch", h.authorize(auth.RoleReadOnly), h.orderMigrationBatch)
+		api.GET("/migrations/plan", h.authorize(auth.RoleReadOnly), h.planMigrations)
+		api.POST("/migrations/down", h.audit("down"), h.authorize(auth.RoleOperator), h.requirePrimary, h.breakGlass("down"), h.trackRun("down"), h.migrateDown)
+		api.GET("/migrations", h.authorize(auth.RoleReadOnly), h.listMigrations)
+		api.GET("/migrations/:id", h.authorize(auth.RoleReadOnly), h.getMigration)
+		api.GET("/migrations/:id/status", h.authorize(auth.RoleReadOnly), h.getMigrationStatus)
+		api.GET("/migrations/:id/applied", h.authorize(auth.RoleReadOnly), h.isMigrationApplied)
+		api.GET("/migrations/:id/history", h.authorize(auth.RoleReadOnly), h.getMigrationHistory)
+		api.GET("/migrations/history", h.authorize(auth.RoleReadOnly), h.listHistory)
+		api.GET("/migrations/failures/summary", h.authorize(auth.RoleReadOnly), h.getFailureSummary)
+		api.GET("/migrations/:id/executions", h.authorize(auth.RoleReadOnly), h.getMigrationExecutions)
+		api.GET("/migrations/:id/schemas", h.authorize(auth.RoleReadOnly), h.getMigrationSchemas)
+		api.GET("/migrations/executions/recent", h.authorize(auth.RoleReadOnly), h.getRecentExecutions)
+		api.GET("/migrations/:id/skipped", h.authorize(auth.RoleReadOnly), h.getSkippedMigrations)
+		api.GET("/migrations/skipped/recent", h.authorize(auth.RoleReadOnly), h.getRecentSkippedMigrations)
+		api.PUT("/migrations/:id/labels", h.audit("labels"), h.authorize(auth.RoleOperator), h.requirePrimary, h.setStatusLabels)
+		api.POST("/migrations/:id/rollback", h.audit("rollback"), h.authorize(auth.RoleOperator), h.requirePrimary, h.breakGlass("rollback"), h.trackRun("rollback"), h.rollbackMigration)
+		api.POST("/migrations/reindex", h.audit("reindex"), h.authorize(auth.RoleOperator), h.requirePrimary, h.reindexMigrations)
+		api.GET("/migrations/locks", h.authorize(auth.RoleReadOnly), h.listLocks)
+		api.GET("/migrations/runs", h.authorize(auth.RoleReadOnly), h.listRuns)
+		api.GET("/migrations/events", h.authorize(auth.RoleReadOnly), h.streamEvents)
+		api.DELETE("/migrations/runs/:run_id", h.audit("cancel_run"), h.authorize(auth.RoleOperator), h.cancelRun)
+		api.GET("/migrations/drift", h.authorize(auth.RoleReadOnly), h.listDrift)
+		api.GET("/migrations/conflicts", h.authorize(auth.RoleReadOnly), h.listConflicts)
+		api.GET("/migrations/diff", h.authorize(auth.RoleReadOnly), h.diffSchema)
+		api.GET("/migrations/pending", h.authorize(auth.RoleReadOnly), h.listPending)
+		api.GET("/migrations/ready", h.authorize(auth.RoleReadOnly), h.migrationsReady)
+		api.GET("/migrations/facets", h.authorize(auth.RoleReadOnly), h.listMigrationFacets)
+		api.GET("/migrations/facets/:facet", h.authorize(auth.RoleReadOnly), h.getMigrationFacet)
+		api.DELETE("/migrations/locks/:connection", h.audit("release_lock"), h.authorize(auth.RoleAdmin), h.requirePrimary, h.releaseLock)
+		api.GET("/standby", h.authorize(auth.RoleReadOnly), h.getStandbyStatus)
+		api.POST("/standby/promote", h.audit("promote"), h.authorize(auth.RoleAdmin), h.promoteStandby)
+		api.POST("/admin/connections/reload", h.audit("reload_connections"), h.authorize(auth.RoleAdmin), h.reloadConnections)
+		api.GET("/admin/state/export", h.authorize(auth.RoleAdmin), h.exportState)
+		api.POST("/admin/state/import", h.audit("import_state"), h.authorize(auth.RoleAdmin), h.requirePrimary, h.importState)
+		api.GET("/audit", h.authorize(auth.RoleAdmin), h.getAuditLog)
+		api.GET("/jobs", h.authorize(auth.RoleReadOnly), h.listJobs)
+		api.GET("/jobs/:id", h.authorize(auth.RoleReadOnly), h.getJob)
+		api.POST("/jobs/:id/replay", h.audit("replay"), h.authorize(auth.RoleOperator), h.requirePrimary, h.replayJob)
+		api.GET("/migrations/scheduled", h.authorize(auth.RoleReadOnly), h.listScheduledJobs)
+		api.DELETE("/migrations/scheduled/:id", h.audit("cancel_scheduled"), h.authorize(auth.RoleOperator), h.requirePrimary, h.cancelScheduledJob)
+		api.GET("/runs", h.authorize(auth.RoleReadOnly), h.listRecordedRuns)
+		api.GET("/runs/:id", h.authorize(auth.RoleReadOnly), h.getRecordedRun)
+		api.POST("/runs/:id/rollback", h.audit("rollback_run"), h.authorize(auth.RoleOperator), h.requirePrimary, h.breakGlass("rollback"), h.trackRun("rollback"), h.rollbackRecordedRun)
+		api.GET("/emergency-runs", h.authorize(auth.RoleReadOnly), h.listEmergencyRuns)
+		api.GET("/emergency-runs/:id", h.authorize(auth.RoleReadOnly), h.getEmergencyRun)
+		api.POST("/emergency-runs/:id/postmortem", h.audit("postmortem"), h.authorize(auth.RoleOperator), h.requirePrimary, h.recordPostmortem)
+		api.GET("/state-changes", h.authorize(auth.RoleReadOnly), h.getStateChanges)
+		api.POST("/reports/tenant-status", h.authorize(auth.RoleReadOnly), h.startTenantStatusReport)
+		api.GET("/reports/:id", h.authorize(auth.RoleReadOnly), h.getReport)
+		api.GET("/reports/:id/download", h.authorize(auth.RoleReadOnly), h.downloadReport)
+		api.GET("/meta", h.authorize(auth.RoleReadOnly), h.getMeta)
 		api.GET("/openapi.yaml", h.OpenAPISpec)
 		api.GET("/openapi.json", h.OpenAPISpecJSON)
 	}
 }
 
-// authenticate middleware validates API token
-func (h *Handler) authenticate(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	token, err := auth.ExtractToken(authHeader)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		c.Abort()
-		return
+// authorize returns a middleware that validates the API token and requires its role to allow
+// role (see auth.Authorize). Unknown tokens get 401, tokens with an insufficient role 403.
+func (h *Handler) authorize(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		token, err := auth.ExtractToken(authHeader)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errorMessage(c, err)})
+			c.Abort()
+			return
+		}
+
+		identity, err := auth.Authorize(token, role)
+		if err != nil {
+			status, message := http.StatusUnauthorized, errorMessage(c, err)
+			if errors.Is(err, auth.ErrForbidden) {
+				status, message = http.StatusForbidden, localized(c, i18n.APIForbidden, identity.Role, role)
+				// Known caller, recorded by the audit middleware
+				c.Set(identityKey, identity)
+			}
+			c.JSON(status, gin.H{"error": message})
+			c.Abort()
+			return
+		}
+
+		c.Set(identityKey, identity)
+
+		// Trusted callers may turn experimental features on or off for the request
+		ctx, err := features.Override(c.Request.Context(), identity, c.GetHeader(features.Header))
+		if err != nil {
+			status, message := http.StatusBadRequest, localized(c, i18n.APIInvalidFeatureFlags, features.Header, err)
+			if errors.Is(err, features.ErrOverrideForbidden) {
+				status, message = http.StatusForbidden, errorMessage(c, err)
+			}
+			c.JSON(status, gin.H{"error": message})
+			c.Abort()
+			return
+		}
+		if overrides := features.Overrides(ctx); len(overrides) > 0 {
+			logger.Infof("%s %s overrides feature flags: %s", c.Request.Method, c.FullPath(), overrides)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
 	}
+}
 
-	if err := auth.ValidateToken(token); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+// requirePrimary is a middleware that refuses requests that write to databases or migration
+// state while the server is a standby (see executor.SetStandby), with 503
+func (h *Handler) requirePrimary(c *gin.Context) {
+	if h.executor.IsStandby() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errorMessage(c, executor.ErrStandby)})
 		c.Abort()
 		return
 	}
-
 	c.Next()
 }
 
+// identityKey is the gin context key of the caller's auth.Identity
+const identityKey = "auth_identity"
+
 // getExecutedBy extracts user identifier from gin context
 func (h *Handler) getExecutedBy(c *gin.Context) string {
 	// Try to get token from context (set by authenticate middleware)
@@ -86,13 +184,16 @@ func (h *Handler) getExecutedBy(c *gin.Context) string {
 	if authHeader != "" {
 		token, err := auth.ExtractToken(authHeader)
 		if err == nil && token != "" {
+			// JWTs (BFM_AUTH_MODE=oidc) and named tokens (BFM_TOKENS, tokens file) identify their holder
+			if identity, ok := c.Value(identityKey).(*auth.Identity); ok && identity.Name != "" {
+				return identity.Name
+			}
 			// Check if request is from frontend (manual execution)
 			// For manual executions, use a more descriptive identifier
 			if h.isManualExecution(c) {
 				return "frontend_user"
 			}
-			// Use a hash of the token or just "api_user" for now
-			// In a real system, you'd extract user from token claims
+			// Shared static tokens don't identify a user
 			return "api_user"
 		}
 	}
@@ -144,9 +245,10 @@ func (h *Handler) getExecutionMethod(c *gin.Context) string {
 	return "api"
 }
 
-// setExecutionContext sets execution context in the request context
-func (h *Handler) setExecutionContext(c *gin.Context) context.Context {
-	ctx := c.Request.Context()
+// setExecutionContext sets execution context in the request context. An emergency request starts
+// its emergency run first; when it cannot be recorded, the error is written and ok is false.
+func (h *Handler) setExecutionContext(c *gin.Context) (ctx context.Context, ok bool) {
+	ctx = c.Request.Context()
 	executedBy := h.getExecutedBy(c)
 	executionMethod := h.getExecutionMethod(c)
 
@@ -156,22 +258,36 @@ func (h *Handler) setExecutionContext(c *gin.Context) context.Context {
 		"request_id":      c.GetString("request_id"), // If you add request ID middleware
 		"connection_type": "http",
 	}
+	if ctx, ok = h.startEmergencyRun(c, ctx); !ok {
+		return ctx, false
+	}
+	if run, emergency := executor.EmergencyRunFromContext(ctx); emergency {
+		executionContext["emergency"] = true
+		executionContext["emergency_run"] = run.ID
+	}
+	if run, ok := executor.RunFromContext(ctx); ok {
+		executionContext["run_id"] = run.ID
+	}
 
-	return executor.SetExecutionContext(ctx, executedBy, executionMethod, executionContext)
+	return executor.SetExecutionContext(ctx, executedBy, executionMethod, executionContext), true
 }
 
 // migrateUp handles up migration requests
 // @Summary      Execute up migrations
-// @Description  Executes migrations based on the provided target and connection
+// @Description  Executes migrations based on the provided target and connection. With connections (names or glob patterns such as tenant_*), each matching connection is migrated in turn (at the same time with parallel, when the connections do not depend on each other) and reported in its own section; the top-level fields aggregate all connections. With migration_ids instead of a target, only the listed migrations of the connection run, in dependency order; unknown IDs are rejected. With pinned_checksums (the checksums of a plan), nothing runs if a migration to apply is not in the plan or changed since. With target.schema_pattern (a SQL LIKE pattern such as tenant_%), the schemas of the connection's database matching it are discovered and migrated, up to concurrency at once (capped by BFM_SCHEMA_MAX_CONCURRENCY), each reported in schemas. With schedule_at, nothing runs now: one job per schema is scheduled to run at that time, within the maintenance window, and 202 lists them.
 // @Tags         migrations
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.MigrateUpRequest true "Migration request"
 // @Success      200 {object} dto.MigrateResponse "Success"
+// @Success      202 {object} dto.ScheduleResponse "Scheduled (schedule_at)"
 // @Success      206 {object} dto.MigrateResponse "Partial success"
-// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      400 {object} map[string]interface{} "Bad request, or a schema name the schema policy refuses"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
+// @Failure      409 {object} map[string]interface{} "Migrations changed since the pinned plan"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
 // @Security     Bearer
 // @Router       /migrations/up [post]
 func (h *Handler) migrateUp(c *gin.Context) {
@@ -181,15 +297,56 @@ func (h *Handler) migrateUp(c *gin.Context) {
 		return
 	}
 
-	if req.Target != nil && len(req.Target.Tags) > 0 {
-		if _, err := registry.ParseTagFilter(req.Target.Tags); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if (req.Connection == "") == (len(req.Connections) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIConnectionOrConnections)})
+		return
+	}
+
+	if len(req.MigrationIDs) > 0 {
+		if req.Target != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIIDsAndTarget)})
+			return
+		}
+		if len(req.Connections) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIIDsRequireConnection)})
 			return
 		}
 	}
 
+	if !h.validateUpTarget(c, req.Target) {
+		return
+	}
+
+	if req.ScheduleAt != "" {
+		h.scheduleUp(c, &req)
+		return
+	}
+
 	// Set execution context
-	ctx := h.setExecutionContext(c)
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	if req.CaptureSQL {
+		ctx = executor.WithCaptureSQL(ctx)
+	}
+	ctx = executor.WithPinnedChecksums(ctx, req.PinnedChecksums)
+	if req.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
+	ctx = executor.WithSchemaConcurrency(ctx, req.Concurrency)
+	if req.Parallel {
+		ctx = executor.WithParallelConnections(ctx)
+	}
+
+	if len(req.Connections) > 0 {
+		h.migrateUpConnections(ctx, c, &req)
+		return
+	}
+	if len(req.MigrationIDs) > 0 {
+		h.migrateUpIDs(ctx, c, &req)
+		return
+	}
 
 	// Execute migrations
 	result, err := h.executor.ExecuteUp(
@@ -202,26 +359,112 @@ func (h *Handler) migrateUp(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	statusCode := http.StatusOK
+	if !result.Success {
+		statusCode = http.StatusPartialContent
+	}
+
+	c.JSON(statusCode, migrateResponse(result))
+}
+
+// validateUpTarget checks the tag filter and tables of an up request's target, and responds 400
+// when they are invalid
+func (h *Handler) validateUpTarget(c *gin.Context, target *registry.MigrationTarget) bool {
+	if target != nil && len(target.Tags) > 0 {
+		if _, err := registry.ParseTagFilter(target.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	if err := registry.ValidateTargetTables(h.executor.GetRegistry(), target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// migrateResponse converts the result of an up execution on one connection to the response format
+func migrateResponse(result *executor.ExecuteResult) dto.MigrateResponse {
+	return dto.MigrateResponse{
+		Success:     result.Success,
+		Applied:     result.Applied,
+		Skipped:     result.Skipped,
+		Errors:      result.Errors,
+		ExecutedSQL: executedSQLResponses(result.ExecutedSQL),
+		Schemas:     schemaMigrateResponses(result.Schemas),
+	}
+}
+
+// migrateUpConnections executes an up request on several connections and reports each one
+func (h *Handler) migrateUpConnections(ctx context.Context, c *gin.Context, req *dto.MigrateUpRequest) {
+	results, err := h.executor.ExecuteUpConnections(ctx, req.Target, req.Connections, req.Schemas, req.DryRun, req.IgnoreDependencies)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Build response
 	response := dto.MigrateResponse{
-		Success: result.Success,
-		Applied: result.Applied,
-		Skipped: result.Skipped,
-		Errors:  result.Errors,
+		Success:     true,
+		Applied:     []string{},
+		Skipped:     []string{},
+		Errors:      []string{},
+		Connections: make([]dto.ConnectionMigrateResponse, 0, len(results)),
+	}
+	for _, r := range results {
+		executedSQL := executedSQLResponses(r.Result.ExecutedSQL)
+		response.Connections = append(response.Connections, dto.ConnectionMigrateResponse{
+			Connection:  r.Connection,
+			Success:     r.Result.Success,
+			Applied:     r.Result.Applied,
+			Skipped:     r.Result.Skipped,
+			Errors:      r.Result.Errors,
+			ExecutedSQL: executedSQL,
+		})
+		response.Success = response.Success && r.Result.Success
+		response.Applied = append(response.Applied, r.Result.Applied...)
+		response.Skipped = append(response.Skipped, r.Result.Skipped...)
+		for _, e := range r.Result.Errors {
+			response.Errors = append(response.Errors, r.Connection+": "+e)
+		}
+		response.ExecutedSQL = append(response.ExecutedSQL, executedSQL...)
 	}
 
 	statusCode := http.StatusOK
-	if !result.Success {
+	if !response.Success {
 		statusCode = http.StatusPartialContent
 	}
-
 	c.JSON(statusCode, response)
 }
 
+// migrateUpIDs executes the migrations listed in an up request's migration_ids
+func (h *Handler) migrateUpIDs(ctx context.Context, c *gin.Context, req *dto.MigrateUpRequest) {
+	result, err := h.executor.ExecuteUpIDs(ctx, req.MigrationIDs, req.Connection, req.Schemas, req.DryRun, req.IgnoreDependencies)
+	if errors.Is(err, executor.ErrPlanChanged) || errors.Is(err, executor.ErrValidationFailed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statusCode := http.StatusOK
+	if !result.Success {
+		statusCode = http.StatusPartialContent
+	}
+	c.JSON(statusCode, dto.MigrateResponse{
+		Success:     result.Success,
+		Applied:     result.Applied,
+		Skipped:     result.Skipped,
+		Errors:      result.Errors,
+		ExecutedSQL: executedSQLResponses(result.ExecutedSQL),
+	})
+}
+
 // orderMigrationBatch returns migration_ids sorted by dependency order for batch execution.
 func (h *Handler) orderMigrationBatch(c *gin.Context) {
 	var req dto.OrderMigrationBatchRequest
@@ -239,18 +482,133 @@ func (h *Handler) orderMigrationBatch(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.OrderMigrationBatchResponse{OrderedMigrationIDs: ordered})
 }
 
+// planMigrations returns the resolved execution plan for an up request without executing it
+// @Summary      Plan up migrations
+// @Description  Returns the topologically sorted execution order for a target, which migrations would be skipped as already applied, and which dependencies forced the ordering. Nothing is executed.
+// @Tags         migrations
+// @Accept       json
+// @Produce      json
+// @Param        connection query string true "Connection name"
+// @Param        backend query string false "Backend filter"
+// @Param        schema query string false "Schema filter"
+// @Param        tables query []string false "Table filters (tables declared with -- bfm-table:); unknown tables are rejected" collectionFormat(multi)
+// @Param        version query string false "Version filter"
+// @Param        max_version query string false "Only this version and older (migrate to version)"
+// @Param        tags query []string false "Tag filters (key=value)" collectionFormat(multi)
+// @Param        schemas query []string false "Schemas for dynamic-schema migrations" collectionFormat(multi)
+// @Param        ignore_dependencies query bool false "Sort by version only"
+// @Param        format query string false "machine for the stable plan format with has_changes and hash, terraform for its flat string form" Enums(machine, terraform)
+// @Success      200 {object} dto.MigrationPlanResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request, or a schema name the schema policy refuses"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/plan [get]
+func (h *Handler) planMigrations(c *gin.Context) {
+	var query dto.MigrationPlanQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch query.Format {
+	case "", "machine", "terraform":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIUnsupportedPlanFormat, query.Format)})
+		return
+	}
+
+	if len(query.Tags) > 0 {
+		if _, err := registry.ParseTagFilter(query.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	target := &registry.MigrationTarget{
+		Backend:    query.Backend,
+		Schema:     query.Schema,
+		Tables:     query.Tables,
+		Version:    query.Version,
+		MaxVersion: query.MaxVersion,
+		Connection: query.Connection,
+		Tags:       query.Tags,
+	}
+	if err := registry.ValidateTargetTables(h.executor.GetRegistry(), target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.executor.Plan(c.Request.Context(), target, query.Connection, query.Schemas, query.IgnoreDependencies)
+	if err != nil {
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	switch query.Format {
+	case "machine":
+		c.JSON(http.StatusOK, plan.Machine(query.Connection, query.Schemas))
+		return
+	case "terraform":
+		c.JSON(http.StatusOK, plan.Machine(query.Connection, query.Schemas).Flatten())
+		return
+	}
+
+	steps := make([]dto.MigrationPlanStep, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		var findings []dto.MigrationValidatorFinding
+		for _, finding := range step.Findings {
+			findings = append(findings, dto.MigrationValidatorFinding{
+				Validator: finding.Validator,
+				Severity:  finding.Severity,
+				Message:   finding.Message,
+			})
+		}
+		steps = append(steps, dto.MigrationPlanStep{
+			Order:         step.Order,
+			MigrationID:   step.MigrationID,
+			Version:       step.Version,
+			Name:          step.Name,
+			Backend:       step.Backend,
+			Connection:    step.Connection,
+			Schema:        step.Schema,
+			Action:        step.Action,
+			Reason:        step.Reason,
+			DependsOn:     step.DependsOn,
+			RequiredBy:    step.RequiredBy,
+			DownGenerated: step.DownGenerated,
+			DownSQL:       step.DownSQL,
+			Checksum:      step.Checksum,
+			Statements:    step.Statements,
+			Destructive:   step.Destructive,
+			Findings:      findings,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.MigrationPlanResponse{
+		Steps:     steps,
+		Apply:     plan.Apply,
+		Skip:      plan.Skip,
+		Errors:    plan.Errors,
+		Checksums: plan.Checksums,
+	})
+}
+
 // migrateDown handles down migration requests
 // @Summary      Execute down migrations (rollback)
-// @Description  Executes down migrations to rollback a specific migration
+// @Description  Executes down migrations to rollback a specific migration. Refused with 409 while migrations that depend on it remain applied (the error lists them in the order to roll them back in), unless ignore_dependencies is set. With cascade, those dependents are rolled back first, in that order, and listed in cascaded; the cascade stops at the first dependent that fails, leaving the migration applied.
 // @Tags         migrations
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.MigrateDownRequest true "Rollback request"
 // @Success      200 {object} dto.MigrateResponse "Success"
 // @Success      206 {object} dto.MigrateResponse "Partial success"
-// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      400 {object} map[string]interface{} "Bad request, or a schema name the schema policy refuses"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
+// @Failure      409 {object} map[string]interface{} "Connection locked by another migration run, or dependent migrations still applied"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
 // @Security     Bearer
 // @Router       /migrations/down [post]
 func (h *Handler) migrateDown(c *gin.Context) {
@@ -261,7 +619,16 @@ func (h *Handler) migrateDown(c *gin.Context) {
 	}
 
 	// Set execution context
-	ctx := h.setExecutionContext(c)
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	if req.CaptureSQL {
+		ctx = executor.WithCaptureSQL(ctx)
+	}
+	if req.Cascade {
+		ctx = executor.WithCascade(ctx)
+	}
 
 	// Execute down migrations
 	result, err := h.executor.ExecuteDown(
@@ -273,16 +640,18 @@ func (h *Handler) migrateDown(c *gin.Context) {
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Build response
 	response := dto.MigrateResponse{
-		Success: result.Success,
-		Applied: result.Applied,
-		Skipped: result.Skipped,
-		Errors:  result.Errors,
+		Success:     result.Success,
+		Applied:     result.Applied,
+		Skipped:     result.Skipped,
+		Errors:      result.Errors,
+		ExecutedSQL: executedSQLResponses(result.ExecutedSQL),
+		Cascaded:    cascadedRollbackResponses(result.Cascaded),
 	}
 
 	statusCode := http.StatusOK
@@ -293,19 +662,59 @@ func (h *Handler) migrateDown(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
+// cascadedRollbackResponses converts the dependents rolled back by a cascade
+func cascadedRollbackResponses(cascaded []executor.CascadedRollback) []dto.CascadedRollbackResponse {
+	if len(cascaded) == 0 {
+		return nil
+	}
+	responses := make([]dto.CascadedRollbackResponse, len(cascaded))
+	for i, step := range cascaded {
+		responses[i] = dto.CascadedRollbackResponse{
+			MigrationID: step.MigrationID,
+			Success:     step.Success,
+			Applied:     step.Applied,
+			Skipped:     step.Skipped,
+			Errors:      step.Errors,
+		}
+	}
+	return responses
+}
+
+// cascadeOrder lists the migrations a cascading rollback rolled back, in order: the dependents,
+// then the migration itself in each of its schemas
+func cascadeOrder(result *executor.RollbackResult) []string {
+	order := []string{}
+	for _, step := range result.Cascaded {
+		if step.Success {
+			order = append(order, step.MigrationID)
+		}
+	}
+	return append(order, result.Applied...)
+}
+
 // listMigrations lists all migrations with their status
 // @Summary      List migrations
-// @Description  Lists all migrations with optional filtering
+// @Description  Lists all migrations with optional filtering, ordered by version unless sort_by is set. Pass limit and offset to get one page; total is the number of migrations matching the filters. With format=csv the list (or page) is returned as a CSV attachment for spreadsheets.
 // @Tags         migrations
 // @Accept       json
 // @Produce      json
+// @Produce      text/csv
 // @Param        schema query string false "Schema filter"
-// @Param        table query string false "Table filter"
+// @Param        table query string false "Table filter (the table declared by the migration's -- bfm-table: line)"
 // @Param        connection query string false "Connection filter"
 // @Param        backend query string false "Backend filter"
 // @Param        status query string false "Status filter"
 // @Param        version query string false "Version filter"
+// @Param        label query string false "User-defined status label filter"
+// @Param        limit query int false "Maximum number of migrations (default: all)"
+// @Param        offset query int false "Number of migrations to skip" default(0)
+// @Param        sort_by query string false "Sort field (default: version)" Enums(migration_id, schema, version, connection, backend, status, applied_at)
+// @Param        sort_order query string false "Sort order (default: asc)" Enums(asc, desc)
+// @Param        format query string false "Response format" Enums(json, csv)
+// @Param        columns query string false "Comma-separated CSV columns (default: all): migration_id, schema, table, version, name, connection, backend, status, applied, applied_at, error_message, checksum, status_labels"
+// @Param        If-None-Match header string false "ETag of a previous response"
 // @Success      200 {object} dto.MigrationListResponse "Success"
+// @Success      304 "Not modified since the ETag in If-None-Match"
 // @Failure      400 {object} map[string]interface{} "Bad request"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
@@ -317,6 +726,10 @@ func (h *Handler) listMigrations(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	csvFormat, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
 
 	// Convert DTO filters to state filters
 	stateFilters := &state.MigrationFilters{
@@ -326,15 +739,37 @@ func (h *Handler) listMigrations(c *gin.Context) {
 		Backend:    filters.Backend,
 		Status:     filters.Status,
 		Version:    filters.Version,
+		Label:      filters.Label,
+		Limit:      filters.Limit,
+		Offset:     filters.Offset,
+		SortBy:     filters.SortBy,
+		SortOrder:  filters.SortOrder,
+	}
+	if err := stateFilters.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// Get migration list from state tracker (only migrations registered in database)
-	migrationList, err := h.executor.GetMigrationList(c.Request.Context(), stateFilters)
+	migrationList, total, err := h.executor.GetMigrationList(c.Request.Context(), stateFilters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Exports are downloads, not polled; the ETag only describes the JSON representation
+	if csvFormat {
+		respondCSV(c, "migrations.csv", func(w io.Writer, columns []string) error {
+			return export.WriteMigrationList(w, migrationList, columns)
+		})
+		return
+	}
+
+	// Polling clients get a 304 while nothing changed, without building the response
+	if notModified(c, migrationListETag(migrationList, total, h.registryTags)) {
+		return
+	}
+
 	// Convert to DTO response (only migrations from database)
 	items := make([]dto.MigrationListItem, 0, len(migrationList))
 	for _, item := range migrationList {
@@ -350,16 +785,22 @@ func (h *Handler) listMigrations(c *gin.Context) {
 			Status:       item.LastStatus,
 			AppliedAt:    item.LastAppliedAt,
 			ErrorMessage: item.LastErrorMessage,
+			StatusLabels: item.StatusLabels,
 		}
-		if regMig := h.executor.GetMigrationByID(item.MigrationID); regMig != nil && len(regMig.Tags) > 0 {
-			listItem.Tags = append([]string(nil), regMig.Tags...)
+		if migration := h.executor.GetMigrationByID(item.MigrationID); migration != nil {
+			if len(migration.Tags) > 0 {
+				listItem.Tags = append([]string(nil), migration.Tags...)
+			}
+			listItem.Destructive = migration.DestructiveStatements()
 		}
 		items = append(items, listItem)
 	}
 
 	response := dto.MigrationListResponse{
-		Items: items,
-		Total: len(items),
+		Items:  items,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -372,7 +813,9 @@ func (h *Handler) listMigrations(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Migration ID"
+// @Param        If-None-Match header string false "ETag of a previous response"
 // @Success      200 {object} dto.MigrationDetailResponse "Success"
+// @Success      304 "Not modified since the ETag in If-None-Match"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
 // @Failure      404 {object} map[string]interface{} "Migration not found"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
@@ -391,6 +834,7 @@ func (h *Handler) getMigration(c *gin.Context) {
 	var foundMigrationID string
 	var dbDependencies []string
 	var dbStructuredDeps []dto.DependencyResponse
+	var statusLabels []string
 
 	if err == nil && dbDetail != nil {
 		schemaValue = dbDetail.Schema
@@ -400,6 +844,7 @@ func (h *Handler) getMigration(c *gin.Context) {
 		backendValue = dbDetail.Backend
 		foundMigrationID = dbDetail.MigrationID
 		dbDependencies = dbDetail.Dependencies
+		statusLabels = dbDetail.StatusLabels
 		// Convert structured dependencies from database
 		for _, dep := range dbDetail.StructuredDependencies {
 			dbStructuredDeps = append(dbStructuredDeps, dto.DependencyResponse{
@@ -448,12 +893,16 @@ func (h *Handler) getMigration(c *gin.Context) {
 				DownSQL:                "", // Not available if not in registry
 				Dependencies:           dbDependencies,
 				StructuredDependencies: dbStructuredDeps,
+				StatusLabels:           statusLabels,
+			}
+			if notModified(c, jsonETag(response)) {
+				return
 			}
 			c.JSON(http.StatusOK, response)
 			return
 		}
 		// Migration not found in registry or database
-		c.JSON(http.StatusNotFound, gin.H{"error": "migration not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": localized(c, i18n.APIMigrationNotFound)})
 		return
 	}
 
@@ -524,11 +973,64 @@ func (h *Handler) getMigration(c *gin.Context) {
 		Dependencies:           dependencies,
 		StructuredDependencies: structuredDeps,
 		Tags:                   tagCopy,
+		DownGenerated:          migration.DownGenerated,
+		Repeatable:             migration.IsRepeatable(),
+		StatusLabels:           statusLabels,
 	}
 
+	if notModified(c, jsonETag(response)) {
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// setStatusLabels replaces the user-defined status labels of a migration
+// @Summary      Set migration status labels
+// @Description  Replaces the user-defined status labels of a migration, such as "verified", "needs-backfill" or "deprecated". Labels track operational workflow state next to the status set by executions, which they never change; filter the list with ?label=. Labels are lowercase letters, digits, '-' and '_' (at most 63 characters, 16 per migration); execution statuses (pending, applied, success, failed, rolled_back) are reserved. An empty list removes every label.
+// @Tags         migrations
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Migration ID"
+// @Param        request body dto.StatusLabelsRequest true "Status labels"
+// @Success      200 {object} dto.StatusLabelsResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid label"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator token"
+// @Failure      404 {object} map[string]interface{} "Migration not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
+// @Security     Bearer
+// @Router       /migrations/{id}/labels [put]
+func (h *Handler) setStatusLabels(c *gin.Context) {
+	migrationID := c.Param("id")
+
+	var req dto.StatusLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	labels, err := h.executor.SetStatusLabels(c.Request.Context(), migrationID, req.Labels)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, executor.ErrInvalidStatusLabel):
+			status = http.StatusBadRequest
+		case errors.Is(err, state.ErrMigrationNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, executor.ErrStandby):
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.StatusLabelsResponse{
+		MigrationID: migrationID,
+		Labels:      labels,
+	})
+}
+
 // getMigrationStatus gets the status of a specific migration
 // @Summary      Get migration status
 // @Description  Gets the current status of a specific migration
@@ -544,24 +1046,13 @@ func (h *Handler) getMigration(c *gin.Context) {
 func (h *Handler) getMigrationStatus(c *gin.Context) {
 	migrationID := c.Param("id")
 
-	// Get all migration history to find the latest status
-	allHistory, err := h.executor.GetMigrationHistory(c.Request.Context(), nil)
+	// Get the history of the migration (up and down migrations, and rollbacks), newest first
+	relatedRecords, _, err := h.executor.GetMigrationHistory(c.Request.Context(), &state.MigrationFilters{MigrationID: migrationID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Find all related records (base migration and rollbacks)
-	var relatedRecords []*state.MigrationRecord
-	for _, record := range allHistory {
-		// Match exact migration_id or migration_id_rollback or any variation starting with migration_id_
-		if record.MigrationID == migrationID ||
-			record.MigrationID == migrationID+"_rollback" ||
-			(len(record.MigrationID) > len(migrationID) && record.MigrationID[:len(migrationID)] == migrationID && record.MigrationID[len(migrationID)] == '_') {
-			relatedRecords = append(relatedRecords, record)
-		}
-	}
-
 	// Determine applied status and get latest applied_at
 	applied := false
 	status := "pending"
@@ -572,19 +1063,19 @@ func (h *Handler) getMigrationStatus(c *gin.Context) {
 		// Get the latest record (first in the list since history is sorted DESC)
 		latestRecord := relatedRecords[0]
 
-		// Find the latest successful, non-rollback record
+		// Find the latest successful up migration
 		var latestSuccessRecord *state.MigrationRecord
 		for _, record := range relatedRecords {
-			if !strings.Contains(record.MigrationID, "_rollback") && state.HistoryStatusIndicatesApplied(record.Status) {
+			if !record.Reverts() && state.HistoryStatusIndicatesApplied(record.Status) {
 				latestSuccessRecord = record
 				break // Records are sorted DESC, so first match is most recent
 			}
 		}
 
-		// Find the latest rollback record
+		// Find the latest successful down migration or rollback
 		var latestRollbackRecord *state.MigrationRecord
 		for _, record := range relatedRecords {
-			if strings.Contains(record.MigrationID, "_rollback") {
+			if record.Reverts() && record.Status == "rolled_back" {
 				latestRollbackRecord = record
 				break // Records are sorted DESC, so first match is most recent
 			}
@@ -622,7 +1113,7 @@ func (h *Handler) getMigrationStatus(c *gin.Context) {
 			errorMessage = latestRollbackRecord.ErrorMessage
 		} else {
 			// Use latest record (could be failed, pending, etc.)
-			applied = !strings.Contains(latestRecord.MigrationID, "_rollback") &&
+			applied = !latestRecord.Reverts() &&
 				state.HistoryStatusIndicatesApplied(latestRecord.Status)
 			status = latestRecord.Status
 			appliedAt = latestRecord.AppliedAt
@@ -674,12 +1165,26 @@ func (h *Handler) isMigrationApplied(c *gin.Context) {
 
 // getMigrationHistory gets the execution history for a specific migration (including rollbacks)
 // @Summary      Get migration history
-// @Description  Gets the execution history for a specific migration including rollbacks
+// @Description  Gets the execution history for a specific migration including down migrations and rollbacks (see each record's operation: up, down or rollback), newest first unless sort_by is set. Pass limit and offset to get one page; total is the number of records of the migration matching the filters. With format=csv the history (or page) is returned as a CSV attachment for spreadsheets.
 // @Tags         migrations
 // @Accept       json
 // @Produce      json
+// @Produce      text/csv
 // @Param        id path string true "Migration ID"
+// @Param        applied_after query string false "Records applied at or after this RFC 3339 time"
+// @Param        applied_before query string false "Records applied before this RFC 3339 time"
+// @Param        executed_by query string false "Records executed by this user"
+// @Param        execution_method query string false "Records executed with this method" Enums(manual, api, cli, worker)
+// @Param        error_class query string false "Failed records with this root cause" Enums(syntax, permission, lock_timeout, connectivity, constraint_violation, unknown)
+// @Param        run_id query string false "Records of this run (see /runs)"
+// @Param        limit query int false "Maximum number of records (default: all)"
+// @Param        offset query int false "Number of records to skip" default(0)
+// @Param        sort_by query string false "Sort field (default: applied_at)" Enums(migration_id, schema, version, connection, backend, status, applied_at)
+// @Param        sort_order query string false "Sort order (default: desc, asc when sort_by is set)" Enums(asc, desc)
+// @Param        format query string false "Response format" Enums(json, csv)
+// @Param        columns query string false "Comma-separated CSV columns (default: all): migration_id, schema, table, version, connection, backend, status, operation, applied_at, executed_by, execution_method, error_message, error_class, checksum"
 // @Success      200 {object} map[string]interface{} "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
 // @Failure      404 {object} map[string]interface{} "Migration not found"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
@@ -687,12 +1192,20 @@ func (h *Handler) isMigrationApplied(c *gin.Context) {
 // @Router       /migrations/{id}/history [get]
 func (h *Handler) getMigrationHistory(c *gin.Context) {
 	migrationID := c.Param("id")
+	historyFilters, ok := bindHistoryFilters(c, migrationID)
+	if !ok {
+		return
+	}
+	csvFormat, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
 
 	// Check if migration exists in registry or database
 	migration := h.executor.GetMigrationByID(migrationID)
 	if migration == nil {
 		// Check if migration exists in database
-		migrationList, err := h.executor.GetMigrationList(c.Request.Context(), &state.MigrationFilters{})
+		migrationList, _, err := h.executor.GetMigrationList(c.Request.Context(), &state.MigrationFilters{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -705,35 +1218,140 @@ func (h *Handler) getMigrationHistory(c *gin.Context) {
 			}
 		}
 		if !foundInDB {
-			c.JSON(http.StatusNotFound, gin.H{"error": "migration not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": localized(c, i18n.APIMigrationNotFound)})
 			return
 		}
 	}
 
-	// Get all migration history
-	allHistory, err := h.executor.GetMigrationHistory(c.Request.Context(), nil)
+	// Get the history of the migration: its up and down migrations and rollbacks are all recorded
+	// under its migration_id
+	relatedHistory, total, err := h.executor.GetMigrationHistory(c.Request.Context(), historyFilters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Filter history to include:
-	// 1. Records with exact migration_id match
-	// 2. Records with migration_id_rollback (rollback records)
-	// 3. Records that start with migration_id_ (to catch any variations)
-	var relatedHistory []*state.MigrationRecord
-	for _, record := range allHistory {
-		if record.MigrationID == migrationID ||
-			record.MigrationID == migrationID+"_rollback" ||
-			(len(record.MigrationID) > len(migrationID) && record.MigrationID[:len(migrationID)] == migrationID && record.MigrationID[len(migrationID)] == '_') {
-			relatedHistory = append(relatedHistory, record)
+	if csvFormat {
+		respondCSV(c, migrationID+"_history.csv", func(w io.Writer, columns []string) error {
+			return export.WriteHistory(w, relatedHistory, columns)
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"migration_id": migrationID,
+		"history":      historyItems(relatedHistory),
+		"total":        total,
+		"limit":        historyFilters.Limit,
+		"offset":       historyFilters.Offset,
+	})
+}
+
+// listHistory gets the execution history of all migrations
+// @Summary      List migration history
+// @Description  Gets the execution history of all migrations, newest first unless sort_by is set, e.g. what a user ran on a given day. Pass limit and offset to get one page; total is the number of records matching the filters. With format=csv the history (or page) is returned as a CSV attachment for spreadsheets.
+// @Tags         migrations
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Param        applied_after query string false "Records applied at or after this RFC 3339 time"
+// @Param        applied_before query string false "Records applied before this RFC 3339 time"
+// @Param        executed_by query string false "Records executed by this user"
+// @Param        execution_method query string false "Records executed with this method" Enums(manual, api, cli, worker)
+// @Param        error_class query string false "Failed records with this root cause" Enums(syntax, permission, lock_timeout, connectivity, constraint_violation, unknown)
+// @Param        run_id query string false "Records of this run (see /runs)"
+// @Param        limit query int false "Maximum number of records (default: all)"
+// @Param        offset query int false "Number of records to skip" default(0)
+// @Param        sort_by query string false "Sort field (default: applied_at)" Enums(migration_id, schema, version, connection, backend, status, applied_at)
+// @Param        sort_order query string false "Sort order (default: desc, asc when sort_by is set)" Enums(asc, desc)
+// @Param        format query string false "Response format" Enums(json, csv)
+// @Param        columns query string false "Comma-separated CSV columns (default: all): migration_id, schema, table, version, connection, backend, status, operation, applied_at, executed_by, execution_method, error_message, error_class, checksum"
+// @Success      200 {object} map[string]interface{} "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/history [get]
+func (h *Handler) listHistory(c *gin.Context) {
+	historyFilters, ok := bindHistoryFilters(c, "")
+	if !ok {
+		return
+	}
+	csvFormat, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
+
+	history, total, err := h.executor.GetMigrationHistory(c.Request.Context(), historyFilters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if csvFormat {
+		respondCSV(c, "history.csv", func(w io.Writer, columns []string) error {
+			return export.WriteHistory(w, history, columns)
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": historyItems(history),
+		"total":   total,
+		"limit":   historyFilters.Limit,
+		"offset":  historyFilters.Offset,
+	})
+}
+
+// bindHistoryFilters reads the history filters of the query, for the records of migrationID
+// (all migrations when empty), and responds with 400 when they are invalid
+func bindHistoryFilters(c *gin.Context, migrationID string) (*state.MigrationFilters, bool) {
+	var query dto.HistoryFilters
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	filters := &state.MigrationFilters{
+		MigrationID:     migrationID,
+		ExecutedBy:      query.ExecutedBy,
+		ExecutionMethod: query.ExecutionMethod,
+		ErrorClass:      query.ErrorClass,
+		RunID:           query.RunID,
+		Limit:           query.Limit,
+		Offset:          query.Offset,
+		SortBy:          query.SortBy,
+		SortOrder:       query.SortOrder,
+	}
+	for _, bound := range []struct {
+		name string
+		raw  string
+		t    *time.Time
+	}{
+		{"applied_after", query.AppliedAfter, &filters.AppliedAfter},
+		{"applied_before", query.AppliedBefore, &filters.AppliedBefore},
+	} {
+		if bound.raw == "" {
+			continue
 		}
+		parsed, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.InvalidTime, bound.name)})
+			return nil, false
+		}
+		*bound.t = parsed
 	}
+	if err := filters.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return filters, true
+}
 
-	// Convert to response format
-	historyItems := make([]gin.H, 0, len(relatedHistory))
-	for _, record := range relatedHistory {
-		historyItems = append(historyItems, gin.H{
+// historyItems converts history records to the response format
+func historyItems(records []*state.MigrationRecord) []gin.H {
+	items := make([]gin.H, 0, len(records))
+	for _, record := range records {
+		items = append(items, gin.H{
 			"migration_id":      record.MigrationID,
 			"schema":            record.Schema,
 			"table":             record.Table,
@@ -742,17 +1360,17 @@ func (h *Handler) getMigrationHistory(c *gin.Context) {
 			"backend":           record.Backend,
 			"applied_at":        record.AppliedAt,
 			"status":            record.Status,
+			"operation":         record.HistoryOperation(),
 			"error_message":     record.ErrorMessage,
+			"error_class":       record.ErrorClass,
 			"executed_by":       record.ExecutedBy,
 			"execution_method":  record.ExecutionMethod,
 			"execution_context": record.ExecutionContext,
+			"emergency":         record.EmergencyRunID() != "",
+			"run_id":            record.RunID(),
 		})
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"migration_id": migrationID,
-		"history":      historyItems,
-	})
+	return items
 }
 
 // getMigrationExecutions gets all execution records for a specific migration
@@ -800,6 +1418,63 @@ func (h *Handler) getMigrationExecutions(c *gin.Context) {
 	})
 }
 
+// getMigrationSchemas gets the status of a migration in each schema of its connection
+// @Summary      Get per-schema migration status
+// @Description  Reports in which schemas of its connection a migration is applied, failed or pending, from migrations_executions. Pending schemas are those only other migrations of the connection ran in (tenants the migration has not reached yet), plus the schemas listed in schemas and, with schema_pattern (a SQL LIKE pattern such as tenant_%), the schemas of the connection's database matching it. Executions without a schema are left out; see /migrations/{id}/status.
+// @Tags         migrations
+// @Produce      json
+// @Param        id path string true "Migration ID"
+// @Param        schemas query string false "Comma-separated schemas to report even if nothing ran in them yet"
+// @Param        schema_pattern query string false "SQL LIKE pattern of schemas to discover on the connection"
+// @Success      200 {object} dto.MigrationSchemasResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid schema pattern"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Migration not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/{id}/schemas [get]
+func (h *Handler) getMigrationSchemas(c *gin.Context) {
+	var schemas []string
+	for _, schema := range strings.Split(c.Query("schemas"), ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	status, err := h.executor.MigrationSchemaStatuses(c.Request.Context(), c.Param("id"), schemas, c.Query("schema_pattern"))
+	switch {
+	case errors.Is(err, state.ErrMigrationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, executor.ErrInvalidSchema):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	applied, failed, pending := status.Counts()
+	response := dto.MigrationSchemasResponse{
+		MigrationID: status.MigrationID,
+		Connection:  status.Connection,
+		Applied:     applied,
+		Failed:      failed,
+		Pending:     pending,
+		Schemas:     make([]dto.SchemaStatusResponse, 0, len(status.Schemas)),
+	}
+	for _, schema := range status.Schemas {
+		item := dto.SchemaStatusResponse{Schema: schema.Schema, Status: schema.Status}
+		if schema.Execution != nil {
+			item.Version = schema.Execution.Version
+			item.AppliedAt = schema.Execution.AppliedAt
+			item.UpdatedAt = schema.Execution.UpdatedAt
+		}
+		response.Schemas = append(response.Schemas, item)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // getRecentExecutions gets recent execution records across all migrations
 // @Summary      Get recent executions
 // @Description  Gets recent execution records across all migrations
@@ -954,17 +1629,21 @@ func (h *Handler) getRecentSkippedMigrations(c *gin.Context) {
 
 // rollbackMigration rolls back a specific migration
 // @Summary      Rollback migration
-// @Description  Rolls back a specific migration
+// @Description  Rolls back a specific migration. Refused with 409 while migrations that depend on it remain applied; the error lists them in the order to roll them back in. With cascade (in the body or the query), those dependents are rolled back first, in that order: cascaded reports each of them, and order the migrations rolled back, ending with this one. The cascade stops at the first dependent that fails, leaving this migration applied.
 // @Tags         migrations
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Migration ID"
+// @Param        cascade query bool false "Roll back the applied dependents first"
 // @Param        request body dto.RollbackRequest false "Rollback request"
 // @Success      200 {object} map[string]interface{} "Success"
-// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      400 {object} map[string]interface{} "Bad request, or a schema name the schema policy refuses"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
 // @Failure      404 {object} map[string]interface{} "Migration not found"
+// @Failure      409 {object} map[string]interface{} "Connection locked by another migration run, or dependent migrations still applied"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
 // @Security     Bearer
 // @Router       /migrations/{id}/rollback [post]
 func (h *Handler) rollbackMigration(c *gin.Context) {
@@ -975,21 +1654,30 @@ func (h *Handler) rollbackMigration(c *gin.Context) {
 		// If no body provided, use empty schemas (backward compatibility)
 		req = dto.RollbackRequest{Schemas: []string{}}
 	}
+	if cascade, err := strconv.ParseBool(c.Query("cascade")); err == nil && cascade {
+		req.Cascade = true
+	}
 
 	// Get migration from registry
 	migration := h.executor.GetMigrationByID(migrationID)
 	if migration == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "migration not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": localized(c, i18n.APIMigrationNotFound)})
 		return
 	}
 
 	// Set execution context
-	ctx := h.setExecutionContext(c)
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	if req.Cascade {
+		ctx = executor.WithCascade(ctx)
+	}
 
 	// Execute rollback with schemas
 	result, err := h.executor.Rollback(ctx, migrationID, req.Schemas)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -1006,6 +1694,28 @@ func (h *Handler) rollbackMigration(c *gin.Context) {
 		return
 	}
 
+	if req.Cascade {
+		cascaded := cascadedRollbackResponses(result.Cascaded)
+		if cascaded == nil {
+			cascaded = []dto.CascadedRollbackResponse{}
+		}
+		response := gin.H{
+			"success":  result.Success,
+			"message":  result.Message,
+			"applied":  result.Applied,
+			"skipped":  result.Skipped,
+			"errors":   result.Errors,
+			"cascaded": cascaded,
+			"order":    cascadeOrder(result),
+		}
+		statusCode := http.StatusOK
+		if !result.Success {
+			statusCode = http.StatusPartialContent
+		}
+		c.JSON(statusCode, response)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": result.Success,
 		"message": result.Message,
@@ -1017,7 +1727,7 @@ func (h *Handler) rollbackMigration(c *gin.Context) {
 
 // Health handles health check requests
 // @Summary      Health check
-// @Description  Checks the health status of the API
+// @Description  Checks the health status of the API. Kept for compatibility: it fails whenever the state database does, so use /livez for liveness probes and /readyz for readiness probes.
 // @Tags         health
 // @Accept       json
 // @Produce      json
@@ -1047,6 +1757,41 @@ func (h *Handler) Health(c *gin.Context) {
 	c.JSON(statusCode, healthStatus)
 }
 
+// getMeta describes the server
+// @Summary      Get server metadata
+// @Description  Reports the server version and build, the API versions served and the enabled features (async queue, registered backends, authentication mode, experimental features enabled for the request), so clients can feature-detect rather than hard-code the behavior of a deployment.
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.MetaResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Security     Bearer
+// @Router       /meta [get]
+func (h *Handler) getMeta(c *gin.Context) {
+	info := buildinfo.Get()
+	c.JSON(http.StatusOK, dto.MetaResponse{
+		Version:     info.Version,
+		Commit:      info.Commit,
+		BuildDate:   info.Date,
+		GoVersion:   info.GoVersion,
+		APIVersions: buildinfo.APIVersions,
+		Features: dto.MetaFeatures{
+			Queue:        h.executor.QueueEnabled(),
+			Backends:     h.executor.BackendNames(),
+			AuthMode:     auth.Mode(),
+			Experimental: experimentalFeatures(c),
+		},
+	})
+}
+
+// experimentalFeatures returns the names of the experimental features enabled for the request
+func experimentalFeatures(c *gin.Context) []string {
+	names := []string{}
+	for _, flag := range features.EnabledFlags(c.Request.Context()) {
+		names = append(names, string(flag))
+	}
+	return names
+}
+
 // reindexMigrations reindexes all migration files and synchronizes with database
 // @Summary      Reindex migrations
 // @Description  Reindexes all migration files and synchronizes with database
@@ -1055,18 +1800,14 @@ func (h *Handler) Health(c *gin.Context) {
 // @Produce      json
 // @Success      200 {object} dto.ReindexResponse "Success"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
 // @Security     Bearer
 // @Router       /migrations/reindex [post]
 func (h *Handler) reindexMigrations(c *gin.Context) {
-	// Get SFM path from environment variable
-	sfmPath := os.Getenv("BFM_SFM_PATH")
-	if sfmPath == "" {
-		// Default to ../sfm relative to bfm directory
-		sfmPath = "../sfm"
-	}
-
-	result, err := h.executor.ReindexMigrations(c.Request.Context(), sfmPath)
+	// Reindex every SFM root (BFM_SFM_PATHS or BFM_SFM_PATH), so no root's migrations are removed
+	result, err := h.executor.ReindexMigrations(c.Request.Context(), config.SFMPathsFromEnv()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1082,19 +1823,385 @@ func (h *Handler) reindexMigrations(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// listLocks lists the connection locks currently held
+// @Summary      List migration locks
+// @Description  Lists the connections currently locked by a migration run (server replica, worker or CLI), with the holding process
+// @Tags         migrations
+// @Produce      json
+// @Success      200 {object} dto.MigrationLocksResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/locks [get]
+func (h *Handler) listLocks(c *gin.Context) {
+	locks, err := h.executor.ListLocks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.MigrationLocksResponse{Locks: make([]dto.MigrationLockResponse, 0, len(locks))}
+	for _, lock := range locks {
+		response.Locks = append(response.Locks, dto.MigrationLockResponse{
+			Connection: lock.Connection,
+			Holder:     lock.Holder,
+			AcquiredAt: lock.AcquiredAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// listDrift lists applied migrations whose up script changed after they ran
+// @Summary      List drifted migrations
+// @Description  Lists applied migrations whose up script checksum no longer matches the checksum recorded when they were applied. Depending on BFM_DRIFT_MODE, executions on these migrations' connections are refused (fail, the default) or only logged (warn).
+// @Tags         migrations
+// @Produce      json
+// @Success      200 {object} dto.MigrationDriftResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/drift [get]
+func (h *Handler) listDrift(c *gin.Context) {
+	drifted, err := h.executor.DetectDrift(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.MigrationDriftResponse{Items: make([]dto.DriftedMigrationResponse, 0, len(drifted)), Total: len(drifted)}
+	for _, d := range drifted {
+		response.Items = append(response.Items, dto.DriftedMigrationResponse{
+			MigrationID:     d.MigrationID,
+			Version:         d.Version,
+			Name:            d.Name,
+			Connection:      d.Connection,
+			Backend:         d.Backend,
+			AppliedAt:       d.AppliedAt,
+			AppliedChecksum: d.AppliedChecksum,
+			CurrentChecksum: d.CurrentChecksum,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// listConflicts lists the migrations that were not loaded because of a conflict
+// @Summary      List migration conflicts
+// @Description  Lists the migrations the loader refused because a loaded migration of the same connection has the same version (versions order the migrations of a connection) or the same name (dependencies by name could resolve to either). The loaded migration keeps running; the refused one is loaded once the conflict is resolved, by renaming or deleting one of the files. Conflicts are also logged when the server starts.
+// @Tags         migrations
+// @Produce      json
+// @Success      200 {object} dto.MigrationConflictsResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Security     Bearer
+// @Router       /migrations/conflicts [get]
+func (h *Handler) listConflicts(c *gin.Context) {
+	conflicts := h.executor.MigrationConflicts()
+	response := dto.MigrationConflictsResponse{Items: make([]dto.MigrationConflictResponse, 0, len(conflicts)), Total: len(conflicts)}
+	for _, conflict := range conflicts {
+		response.Items = append(response.Items, dto.MigrationConflictResponse{
+			Kind:        conflict.Kind,
+			Connection:  conflict.Connection,
+			Backend:     conflict.Backend,
+			Value:       conflict.Value,
+			MigrationID: conflict.MigrationID,
+			ExistingID:  conflict.ExistingID,
+			Path:        conflict.Path,
+			Message:     conflict.Message,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// listPending lists the migrations of a connection that are registered but not applied
+// @Summary      List pending migrations
+// @Description  Returns the count and list of migrations registered for a connection that are not applied yet, in version order. Intended as a deployment gate: block the rollout until count is 0. Dynamic-schema migrations are tracked per schema and are only included when schema is given.
+// @Tags         migrations
+// @Produce      json
+// @Param        connection query string true "Connection name"
+// @Param        schema query string false "Schema for dynamic-schema migrations"
+// @Success      200 {object} dto.PendingMigrationsResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Connection not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/pending [get]
+func (h *Handler) listPending(c *gin.Context) {
+	connection := c.Query("connection")
+	if connection == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIConnectionRequired)})
+		return
+	}
+	if _, err := h.executor.GetConnectionConfig(c.Request.Context(), connection); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	schema := c.Query("schema")
+
+	pending, err := h.executor.PendingMigrations(c.Request.Context(), connection, schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.PendingMigrationsResponse{
+		Connection: connection,
+		Schema:     schema,
+		Count:      len(pending),
+		Items:      make([]dto.PendingMigrationResponse, 0, len(pending)),
+	}
+	for _, p := range pending {
+		response.Items = append(response.Items, dto.PendingMigrationResponse{
+			MigrationID: p.MigrationID,
+			Version:     p.Version,
+			Name:        p.Name,
+			Connection:  p.Connection,
+			Backend:     p.Backend,
+			Schema:      p.Schema,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// migrationsReady reports whether every migration of the requested connections is applied
+// @Summary      Migration readiness gate
+// @Description  Returns 200 only when no migration of the given connections is pending, and 503 with the pending migrations otherwise. Intended for Kubernetes init containers and readiness probes, so application pods don't start against an un-migrated database (see bfm wait). Dynamic-schema migrations are tracked per schema and are only checked for the given schemas.
+// @Tags         migrations
+// @Produce      json
+// @Param        connection query []string true "Connection names" collectionFormat(multi)
+// @Param        schemas query []string false "Schemas for dynamic-schema migrations" collectionFormat(multi)
+// @Success      200 {object} dto.MigrationsReadyResponse "Every migration is applied"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Connection not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} dto.MigrationsReadyResponse "Migrations are pending"
+// @Security     Bearer
+// @Router       /migrations/ready [get]
+func (h *Handler) migrationsReady(c *gin.Context) {
+	connections := c.QueryArray("connection")
+	if len(connections) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIConnectionRequired)})
+		return
+	}
+	for _, connection := range connections {
+		if _, err := h.executor.GetConnectionConfig(c.Request.Context(), connection); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	schemas := c.QueryArray("schemas")
+	if len(schemas) == 0 {
+		schemas = []string{""}
+	}
+
+	response := dto.MigrationsReadyResponse{Connections: []dto.PendingMigrationsResponse{}}
+	for _, connection := range connections {
+		for _, schema := range schemas {
+			pending, err := h.executor.PendingMigrations(c.Request.Context(), connection, schema)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			result := dto.PendingMigrationsResponse{
+				Connection: connection,
+				Schema:     schema,
+				Count:      len(pending),
+				Items:      make([]dto.PendingMigrationResponse, 0, len(pending)),
+			}
+			for _, p := range pending {
+				result.Items = append(result.Items, dto.PendingMigrationResponse{
+					MigrationID: p.MigrationID,
+					Version:     p.Version,
+					Name:        p.Name,
+					Connection:  p.Connection,
+					Backend:     p.Backend,
+					Schema:      p.Schema,
+				})
+			}
+			response.Pending += len(pending)
+			response.Connections = append(response.Connections, result)
+		}
+	}
+
+	response.Ready = response.Pending == 0
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// releaseLock force-releases the lock on a connection
+// @Summary      Force-release a migration lock
+// @Description  Releases the lock on a connection held by a stuck or crashed migration run. The holding session is terminated, so its in-flight migration is aborted. Requires an admin token (BFM_ADMIN_TOKEN, BFM_API_TOKEN when no admin token is set, or an admin token from BFM_TOKENS / BFM_TOKENS_FILE).
+// @Tags         migrations
+// @Produce      json
+// @Param        connection path string true "Connection name"
+// @Success      200 {object} dto.ReleaseLockResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
+// @Security     Bearer
+// @Router       /migrations/locks/{connection} [delete]
+func (h *Handler) releaseLock(c *gin.Context) {
+	connection := c.Param("connection")
+
+	released, err := h.executor.ReleaseLock(c.Request.Context(), connection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "connection was not locked"
+	if released {
+		message = "lock released"
+	}
+	c.JSON(http.StatusOK, dto.ReleaseLockResponse{
+		Connection: connection,
+		Released:   released,
+		Message:    message,
+	})
+}
+
+// getStandbyStatus reports whether this instance is a standby or the primary
+// @Summary      Get standby status
+// @Description  Reports whether this server instance is a standby (BFM_STANDBY=true, not promoted yet) or the primary, and whether it holds the primary lock in the state database. A standby serves reads but refuses migrations, rollbacks, reindexes and lock releases with 503.
+// @Tags         standby
+// @Produce      json
+// @Success      200 {object} dto.StandbyStatusResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Security     Bearer
+// @Router       /standby [get]
+func (h *Handler) getStandbyStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, standbyStatusResponse(h.executor.StandbyStatus()))
+}
+
+// promoteStandby promotes a standby to primary
+// @Summary      Promote a standby
+// @Description  Promotes this standby to primary. The primary lock is taken first, so promotion fails with 409 while the primary still holds it; stop the primary (or wait for its state database session to end) and retry. Promoting the primary is a no-op. Requires an admin token.
+// @Tags         standby
+// @Produce      json
+// @Success      200 {object} dto.StandbyStatusResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      409 {object} map[string]interface{} "The primary still holds the primary lock"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /standby/promote [post]
+func (h *Handler) promoteStandby(c *gin.Context) {
+	if err := h.executor.Promote(c.Request.Context()); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, state.ErrPrimaryLocked) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, standbyStatusResponse(h.executor.StandbyStatus()))
+}
+
+// standbyStatusResponse converts a standby status to its response form
+func standbyStatusResponse(status executor.StandbyStatus) dto.StandbyStatusResponse {
+	response := dto.StandbyStatusResponse{
+		Role:             "primary",
+		HoldsPrimaryLock: status.HoldsPrimaryLock,
+		PromotedBy:       status.PromotedBy,
+	}
+	if status.Standby {
+		response.Role = "standby"
+	}
+	if !status.PromotedAt.IsZero() {
+		response.PromotedAt = status.PromotedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+// executedSQLResponses converts captured SQL to its response form (nil when nothing was captured)
+func executedSQLResponses(executed []executor.ExecutedSQL) []dto.ExecutedSQL {
+	if len(executed) == 0 {
+		return nil
+	}
+	responses := make([]dto.ExecutedSQL, 0, len(executed))
+	for _, e := range executed {
+		responses = append(responses, dto.ExecutedSQL{
+			MigrationID: e.MigrationID,
+			SQL:         e.SQL,
+			Truncated:   e.Truncated,
+			Suppressed:  e.Suppressed,
+		})
+	}
+	return responses
+}
+
+// registryTags returns the tags of a registered migration, or nil
+func (h *Handler) registryTags(migrationID string) []string {
+	if migration := h.executor.GetMigrationByID(migrationID); migration != nil {
+		return migration.Tags
+	}
+	return nil
+}
+
+// schemaMigrateResponses converts per-schema results to the response format
+func schemaMigrateResponses(results []executor.SchemaResult) []dto.SchemaMigrateResponse {
+	if len(results) == 0 {
+		return nil
+	}
+	responses := make([]dto.SchemaMigrateResponse, 0, len(results))
+	for _, r := range results {
+		responses = append(responses, dto.SchemaMigrateResponse{
+			Schema:  r.Schema,
+			Success: r.Success,
+			Applied: r.Applied,
+			Skipped: r.Skipped,
+			Errors:  r.Errors,
+		})
+	}
+	return responses
+}
+
+// executionErrorStatus maps an execution error to an HTTP status code
+func executionErrorStatus(err error) int {
+	if errors.Is(err, state.ErrConnectionLocked) || errors.Is(err, executor.ErrMigrationDrift) ||
+		errors.Is(err, executor.ErrDependentsApplied) || errors.Is(err, executor.ErrPlanChanged) ||
+		errors.Is(err, executor.ErrValidationFailed) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, executor.ErrStandby) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, executor.ErrInvalidSchema) || errors.Is(err, executor.ErrInvalidSchedule) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 //go:embed swagger.yaml
 var openAPISpecYAML []byte
 
+// The spec is embedded at build time, so its ETag only changes with the binary
+var openAPISpecETag = bytesETag(openAPISpecYAML)
+
 // OpenAPISpec serves the OpenAPI specification in YAML format
 func (h *Handler) OpenAPISpec(c *gin.Context) {
+	if notModified(c, openAPISpecETag) {
+		return
+	}
 	c.Data(http.StatusOK, "application/x-yaml", openAPISpecYAML)
 }
 
 // OpenAPISpecJSON serves the OpenAPI specification in JSON format
 func (h *Handler) OpenAPISpecJSON(c *gin.Context) {
+	if notModified(c, openAPISpecETag) {
+		return
+	}
 	var spec map[string]interface{}
 	if err := yaml.Unmarshal(openAPISpecYAML, &spec); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse OpenAPI spec"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": localized(c, i18n.APIOpenAPISpecUnparsable)})
 		return
 	}
 	c.JSON(http.StatusOK, spec)
@@ -4,6 +4,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,7 @@ import (
 	"github.com/toolsascode/bfm/api/internal/executor"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,7 +40,7 @@ func TestIntegration_MigrateUp_Down_Rollback(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := executor.NewExecutor(reg, tracker)
 
 	// Register backend
@@ -61,6 +63,7 @@ func TestIntegration_MigrateUp_Down_Rollback(t *testing.T) {
 
 	// Register migration
 	migration := &backends.MigrationScript{
+		Schema:     "public",
 		Backend:    "postgresql",
 		Connection: "test",
 		Version:    "20250101000000",
@@ -149,35 +152,11 @@ func TestIntegration_ListMigrations_WithFilters(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 
 	// Add some migrations to the list
-	tracker.listItems = []*state.MigrationListItem{
-		{
-			MigrationID: "20250101000000_test1_postgresql_test",
-			Backend:     "postgresql",
-			Connection:  "test",
-			Schema:      "public",
-			LastStatus:  "success",
-			Applied:     true,
-		},
-		{
-			MigrationID: "20250102000000_test2_postgresql_test",
-			Backend:     "postgresql",
-			Connection:  "test",
-			Schema:      "public",
-			LastStatus:  "pending",
-			Applied:     false,
-		},
-		{
-			MigrationID: "20250103000000_test3_mysql_prod",
-			Backend:     "mysql",
-			Connection:  "prod",
-			Schema:      "app",
-			LastStatus:  "success",
-			Applied:     true,
-		},
-	}
+	tracker.MarkApplied("public_20250101000000_test1_postgresql_test", "app_20250103000000_test3_mysql_prod")
+	_ = tracker.RegisterScannedMigration(context.Background(), "20250102000000_test2_postgresql_test", "public", "", "20250102000000", "test2", "test", "postgresql")
 
 	router, _ := setupTestRouter(reg, tracker)
 
@@ -207,13 +186,13 @@ func TestIntegration_ListMigrations_WithFilters(t *testing.T) {
 		},
 		{
 			name:           "filter by status",
-			query:          "?status=success",
+			query:          "?status=applied",
 			expectedCount:  2,
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name:           "multiple filters",
-			query:          "?backend=postgresql&connection=test&status=success",
+			query:          "?backend=postgresql&connection=test&status=applied",
 			expectedCount:  1,
 			expectedStatus: http.StatusOK,
 		},
@@ -262,7 +241,7 @@ func TestIntegration_GetMigration_History(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 
 	// Register migration
 	migration := &backends.MigrationScript{
@@ -277,13 +256,13 @@ func TestIntegration_GetMigration_History(t *testing.T) {
 
 	// Add history
 	migrationID := reg.getMigrationID(migration)
-	tracker.history = []*state.MigrationRecord{
+	recordHistory(tracker, []*state.MigrationRecord{
 		{
 			MigrationID: migrationID,
 			Status:      "success",
 			AppliedAt:   "2025-01-01T12:00:00Z",
 		},
-	}
+	})
 
 	router, _ := setupTestRouter(reg, tracker)
 
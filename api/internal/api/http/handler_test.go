@@ -1,23 +1,31 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/toolsascode/bfm/api/internal/api/http/dto"
 	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
 	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
 	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
 	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
 
 	"github.com/gin-gonic/gin"
 )
@@ -161,238 +169,20 @@ func (m *mockRegistry) getMigrationID(migration *backends.MigrationScript) strin
 	return fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
 }
 
-// mockStateTracker is a mock implementation of state.StateTracker
-type mockStateTracker struct {
-	appliedMigrations        map[string]bool
-	history                  []*state.MigrationRecord
-	listItems                []*state.MigrationListItem
-	healthCheckError         error
-	getMigrationListError    error
-	getMigrationHistoryError error
-	isMigrationAppliedError  error
+// isApplied reports whether tracker has migrationID applied
+func isApplied(tracker *testsupport.StateTracker, migrationID string) bool {
+	applied, _ := tracker.IsMigrationApplied(context.Background(), migrationID)
+	return applied
 }
 
-func newMockStateTracker() *mockStateTracker {
-	return &mockStateTracker{
-		appliedMigrations: make(map[string]bool),
-		history:           make([]*state.MigrationRecord, 0),
-		listItems:         make([]*state.MigrationListItem, 0),
+// recordHistory records history on tracker, given newest first as it is returned
+func recordHistory(tracker *testsupport.StateTracker, history []*state.MigrationRecord) {
+	for i := len(history) - 1; i >= 0; i-- {
+		_ = tracker.RecordMigration(context.Background(), history[i])
 	}
 }
 
-func (m *mockStateTracker) RecordMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	m.history = append(m.history, migration)
-	switch migration.Status {
-	case "success":
-		m.appliedMigrations[migration.MigrationID] = true
-	case "rolled_back":
-		m.appliedMigrations[migration.MigrationID] = false
-	}
-	return nil
-}
-
-func (m *mockStateTracker) RecordDependencyMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	// Requirement: Dependencies should NOT be recorded in history, only marked as applied
-	// Do NOT append to m.history - this is the key difference from RecordMigration
-	switch migration.Status {
-	case "success":
-		m.appliedMigrations[migration.MigrationID] = true
-	case "rolled_back":
-		m.appliedMigrations[migration.MigrationID] = false
-	}
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	if m.getMigrationHistoryError != nil {
-		return nil, m.getMigrationHistoryError
-	}
-	return m.history, nil
-}
-
-func (m *mockStateTracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	if m.getMigrationListError != nil {
-		return nil, m.getMigrationListError
-	}
-
-	// Apply filters if provided
-	if filters == nil {
-		return m.listItems, nil
-	}
-
-	var filtered []*state.MigrationListItem
-	for _, item := range m.listItems {
-		// Apply filters
-		if filters.Schema != "" && item.Schema != filters.Schema {
-			continue
-		}
-		if filters.Table != "" && item.Table != filters.Table {
-			continue
-		}
-		if filters.Connection != "" && item.Connection != filters.Connection {
-			continue
-		}
-		if filters.Backend != "" && item.Backend != filters.Backend {
-			continue
-		}
-		if filters.Status != "" && item.LastStatus != filters.Status {
-			continue
-		}
-		if filters.Version != "" && item.Version != filters.Version {
-			continue
-		}
-		filtered = append(filtered, item)
-	}
-
-	return filtered, nil
-}
-
-func (m *mockStateTracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool, error) {
-	if m.isMigrationAppliedError != nil {
-		return false, m.isMigrationAppliedError
-	}
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error) {
-	if m.isMigrationAppliedError != nil {
-		return false, m.isMigrationAppliedError
-	}
-	// For mock, treat pending/applied the same as applied
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error) {
-	return "", nil
-}
-
-func (m *mockStateTracker) RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	return nil
-}
-
-func (m *mockStateTracker) DeleteMigration(ctx interface{}, migrationID string) error {
-	// Remove from appliedMigrations
-	delete(m.appliedMigrations, migrationID)
-	// Remove from listItems
-	for i, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			m.listItems = append(m.listItems[:i], m.listItems[i+1:]...)
-			break
-		}
-	}
-	return nil
-}
-
-func (m *mockStateTracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	// Update listItems
-	for i, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			m.listItems[i].Schema = schema
-			m.listItems[i].Table = table
-			m.listItems[i].Version = version
-			m.listItems[i].Name = name
-			m.listItems[i].Connection = connection
-			m.listItems[i].Backend = backend
-			break
-		}
-	}
-	return nil
-}
-
-func (m *mockStateTracker) Initialize(ctx interface{}) error {
-	return m.healthCheckError
-}
-
-func (m *mockStateTracker) ReindexMigrations(ctx interface{}, registry interface{}) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationDetail(ctx interface{}, migrationID string) (*state.MigrationDetail, error) {
-	// Find migration in listItems
-	for _, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			return &state.MigrationDetail{
-				MigrationID:            item.MigrationID,
-				Schema:                 item.Schema,
-				Version:                item.Version,
-				Name:                   item.Name,
-				Connection:             item.Connection,
-				Backend:                item.Backend,
-				UpSQL:                  "",
-				DownSQL:                "",
-				Dependencies:           []string{},
-				StructuredDependencies: []backends.Dependency{},
-				Status:                 item.LastStatus,
-			}, nil
-		}
-	}
-	return nil, nil
-}
-
-func (m *mockStateTracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([]*state.MigrationExecution, error) {
-	// Check if this migration is applied
-	applied := m.appliedMigrations[migrationID]
-	if !applied {
-		return []*state.MigrationExecution{}, nil
-	}
-
-	// Parse migration ID to extract details: {version}_{name}_{backend}_{connection}
-	parts := strings.Split(migrationID, "_")
-	if len(parts) < 4 {
-		return []*state.MigrationExecution{}, nil
-	}
-
-	// Extract version, backend, and connection
-	version := parts[0]
-	backend := parts[len(parts)-2]
-	connection := parts[len(parts)-1]
-
-	// Return execution records for both empty schema and "public" schema
-	// This allows the executor to find the execution regardless of which schema it's looking for
-	return []*state.MigrationExecution{
-		{
-			MigrationID: migrationID,
-			Schema:      "", // Empty schema
-			Version:     version,
-			Connection:  connection,
-			Backend:     backend,
-			Status:      "applied",
-			Applied:     true,
-			AppliedAt:   time.Now().Format(time.RFC3339),
-			CreatedAt:   time.Now().Format(time.RFC3339),
-			UpdatedAt:   time.Now().Format(time.RFC3339),
-		},
-		{
-			MigrationID: migrationID,
-			Schema:      "public", // Public schema
-			Version:     version,
-			Connection:  connection,
-			Backend:     backend,
-			Status:      "applied",
-			Applied:     true,
-			AppliedAt:   time.Now().Format(time.RFC3339),
-			CreatedAt:   time.Now().Format(time.RFC3339),
-			UpdatedAt:   time.Now().Format(time.RFC3339),
-		},
-	}, nil
-}
-func (m *mockStateTracker) GetRecentExecutions(ctx interface{}, limit int) ([]*state.MigrationExecution, error) {
-	return []*state.MigrationExecution{}, nil
-}
-
-func (m *mockStateTracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*state.SkippedMigration, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) WithMigrationExecutionLock(_ interface{}, _, _, _ string, fn func() error) error {
-	return fn()
-}
-
-func setupTestRouter(reg *mockRegistry, tracker *mockStateTracker) (*gin.Engine, *executor.Executor) {
+func setupTestRouter(reg *mockRegistry, tracker *testsupport.StateTracker) (*gin.Engine, *executor.Executor) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	exec := executor.NewExecutor(reg, tracker)
@@ -403,7 +193,7 @@ func setupTestRouter(reg *mockRegistry, tracker *mockStateTracker) (*gin.Engine,
 
 func TestNewHandler(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := executor.NewExecutor(reg, tracker)
 	handler := NewHandler(exec)
 
@@ -417,7 +207,7 @@ func TestNewHandler(t *testing.T) {
 
 func TestHandler_Health(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
 	req, _ := http.NewRequest("GET", "/api/v1/health", nil)
@@ -440,8 +230,8 @@ func TestHandler_Health(t *testing.T) {
 
 func TestHandler_Health_Unhealthy(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.healthCheckError = errors.New("health check failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("Initialize", errors.New("health check failed"))
 	router, _ := setupTestRouter(reg, tracker)
 
 	req, _ := http.NewRequest("GET", "/api/v1/health", nil)
@@ -462,6 +252,97 @@ func TestHandler_Health_Unhealthy(t *testing.T) {
 	}
 }
 
+func TestHandler_Lifecycle(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
+
+	get := func(path string) (int, dto.ReadinessResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response dto.ReadinessResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	if code, response := get("/api/v1/readyz"); code != http.StatusOK || response.Checks["state"] != "ok" || response.Checks["loader"] != "ok" {
+		t.Errorf("readyz = %d %+v, want 200 with passing checks", code, response)
+	}
+
+	// A state database outage makes the server unready, but it stays alive
+	tracker.Fail("Initialize", errors.New("connection refused"))
+	if code, response := get("/api/v1/readyz"); code != http.StatusServiceUnavailable || response.Status != "not_ready" || response.Checks["state"] == "ok" {
+		t.Errorf("readyz = %d %+v, want 503 with a failing state check", code, response)
+	}
+	if code, _ := get("/api/v1/livez"); code != http.StatusOK {
+		t.Errorf("livez = %d, want 200", code)
+	}
+	tracker.Fail("Initialize", nil)
+
+	// While migrations load, the API refuses requests and only the probes answer
+	exec.SetStarting(true)
+	if code, response := get("/api/v1/readyz"); code != http.StatusServiceUnavailable || response.Checks["loader"] == "ok" {
+		t.Errorf("readyz while starting = %d %+v, want 503 with a failing loader check", code, response)
+	}
+	if code, _ := get("/api/v1/livez"); code != http.StatusOK {
+		t.Errorf("livez while starting = %d, want 200", code)
+	}
+	if code, _ := get("/api/v1/migrations"); code != http.StatusServiceUnavailable {
+		t.Errorf("migrations while starting = %d, want 503", code)
+	}
+
+	exec.SetStarting(false)
+	if code, _ := get("/api/v1/migrations"); code == http.StatusServiceUnavailable {
+		t.Errorf("migrations once started = %d, want the request served", code)
+	}
+}
+
+func TestHandler_getMeta(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	router, exec := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+	exec.RegisterBackend("postgresql", &mockBackend{name: "postgresql"})
+	exec.RegisterBackend("etcd", &mockBackend{name: "etcd"})
+
+	req, _ := http.NewRequest("GET", "/api/v1/meta", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response dto.MetaResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Version == "" || response.GoVersion == "" {
+		t.Errorf("Expected version and go_version, got %+v", response)
+	}
+	if len(response.APIVersions) != 1 || response.APIVersions[0] != "v1" {
+		t.Errorf("Expected api_versions [v1], got %v", response.APIVersions)
+	}
+	features := response.Features
+	if features.Queue || features.AuthMode != "token" || strings.Join(features.Backends, ",") != "etcd,postgresql" {
+		t.Errorf("Unexpected features %+v", features)
+	}
+}
+
 func TestHandler_authenticate(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
@@ -475,7 +356,7 @@ func TestHandler_authenticate(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
 	tests := []struct {
@@ -534,7 +415,7 @@ func TestHandler_migrateUp(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
 	tests := []struct {
@@ -596,7 +477,7 @@ func TestHandler_migrateUp_InvalidTags(t *testing.T) {
 	}()
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
 	body, _ := json.Marshal(dto.MigrateUpRequest{
@@ -618,8 +499,7 @@ func TestHandler_migrateUp_InvalidTags(t *testing.T) {
 	}
 }
 
-func TestHandler_migrateUp_PartialContent(t *testing.T) {
-	// Save original token
+func TestHandler_migrateUp_UnknownTable(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -628,57 +508,43 @@ func TestHandler_migrateUp_PartialContent(t *testing.T) {
 			_ = os.Unsetenv("BFM_API_TOKEN")
 		}
 	}()
-
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, exec := setupTestRouter(reg, tracker)
-
-	// Register a migration that will fail
-	migration := &backends.MigrationScript{
-		Version:    "20240101120000",
-		Name:       "test_migration",
-		Connection: "test",
-		Backend:    "postgresql",
-		UpSQL:      "CREATE TABLE test;",
-	}
-	_ = reg.Register(migration)
-
-	// Set up backend that will fail
-	backend := &mockBackend{name: "postgresql", executeError: errors.New("execution failed")}
-	exec.RegisterBackend("postgresql", backend)
-
-	connections := map[string]*backends.ConnectionConfig{
-		"test": {
-			Backend: "postgresql",
-			Host:    "localhost",
-		},
-	}
-	_ = exec.SetConnections(connections)
+	users := "users"
+	_ = reg.Register(&backends.MigrationScript{
+		Table: &users, Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql",
+	})
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
 
-	reqBody := dto.MigrateUpRequest{
+	body, _ := json.Marshal(dto.MigrateUpRequest{
 		Target: &registry.MigrationTarget{
 			Backend:    "postgresql",
 			Connection: "test",
+			Tables:     []string{"user"},
 		},
 		Connection: "test",
 		Schemas:    []string{},
-		DryRun:     false,
-	}
-	body, _ := json.Marshal(reqBody)
+	})
 	req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
 	req.Header.Set("Authorization", "Bearer test-token")
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
 
-	if w.Code != http.StatusPartialContent {
-		t.Errorf("Expected status %d, got %d", http.StatusPartialContent, w.Code)
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/plan?connection=test&tables=user", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 from plan, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_migrateDown(t *testing.T) {
-	// Save original token
+func TestHandler_migrateUp_MigrationIDs(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -687,82 +553,71 @@ func TestHandler_migrateDown(t *testing.T) {
 			_ = os.Unsetenv("BFM_API_TOKEN")
 		}
 	}()
-
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;",
+	})
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "create_orders", Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;",
+	})
+	tracker := testsupport.NewStateTracker()
 	router, exec := setupTestRouter(reg, tracker)
-
-	// Register a migration for the valid request test
-	migration := &backends.MigrationScript{
-		Version:    "20240101120000",
-		Name:       "test_migration",
-		Connection: "test",
-		Backend:    "postgresql",
-		UpSQL:      "CREATE TABLE test;",
-		DownSQL:    "DROP TABLE test;",
-	}
-	_ = reg.Register(migration)
-	migrationID := "test_20240101120000_test_migration"
-	tracker.appliedMigrations[migrationID] = true
-
-	// Set up backend and connection for down migration
-	backend := &mockBackend{name: "postgresql"}
-	exec.RegisterBackend("postgresql", backend)
-	connections := map[string]*backends.ConnectionConfig{
-		"test": {
-			Backend: "postgresql",
-			Host:    "localhost",
-		},
-	}
-	_ = exec.SetConnections(connections)
+	exec.RegisterBackend("postgresql", &mockBackend{name: "postgresql"})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
 
 	tests := []struct {
-		name           string
-		requestBody    interface{}
-		expectedStatus int
+		name         string
+		req          dto.MigrateUpRequest
+		expectedCode int
 	}{
 		{
-			name: "valid request",
-			requestBody: dto.MigrateDownRequest{
-				MigrationID: migrationID,
-				Schemas:     []string{},
-				DryRun:      false,
-			},
-			expectedStatus: http.StatusOK,
+			name:         "with target",
+			req:          dto.MigrateUpRequest{MigrationIDs: []string{"20240101120000_create_users_postgresql_test"}, Target: &registry.MigrationTarget{Connection: "test"}, Connection: "test"},
+			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name: "invalid request body",
-			requestBody: map[string]interface{}{
-				"invalid": "data",
-			},
-			expectedStatus: http.StatusBadRequest,
+			name:         "with connections",
+			req:          dto.MigrateUpRequest{MigrationIDs: []string{"20240101120000_create_users_postgresql_test"}, Connections: []string{"test"}},
+			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name:           "missing migration_id",
-			requestBody:    dto.MigrateDownRequest{},
-			expectedStatus: http.StatusBadRequest,
+			name:         "unknown id",
+			req:          dto.MigrateUpRequest{MigrationIDs: []string{"20240103120000_missing_postgresql_test"}, Connection: "test"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "known id",
+			req:          dto.MigrateUpRequest{MigrationIDs: []string{"20240101120000_create_users_postgresql_test"}, Connection: "test"},
+			expectedCode: http.StatusOK,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body, _ := json.Marshal(tt.requestBody)
-			req, _ := http.NewRequest("POST", "/api/v1/migrations/down", bytes.NewBuffer(body))
+			body, _ := json.Marshal(tt.req)
+			req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
 			req.Header.Set("Authorization", "Bearer test-token")
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
-
-			if w.Code != tt.expectedStatus {
-				t.Errorf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
 			}
 		})
 	}
+
+	if !isApplied(tracker, "20240101120000_create_users_postgresql_test") {
+		t.Error("listed migration create_users was not applied")
+	}
+	if isApplied(tracker, "20240102120000_create_orders_postgresql_test") {
+		t.Error("unlisted migration create_orders was applied")
+	}
 }
 
-func TestHandler_listMigrations(t *testing.T) {
-	// Save original token
+func TestHandler_Standby(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -771,47 +626,59 @@ func TestHandler_listMigrations(t *testing.T) {
 			_ = os.Unsetenv("BFM_API_TOKEN")
 		}
 	}()
-
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.listItems = []*state.MigrationListItem{
-		{
-			MigrationID: "migration1",
-			Schema:      "public",
-			Version:     "20240101120000",
-			Name:        "test_migration",
-			Connection:  "test",
-			Backend:     "postgresql",
-			Applied:     true,
-			LastStatus:  "success",
-		},
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(newMockRegistry(), tracker)
+	exec.SetStandby(true)
+
+	do := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
 	}
-	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	body, _ := json.Marshal(dto.MigrateUpRequest{Connection: "test", Target: &registry.MigrationTarget{Connection: "test"}})
+	if w := do("POST", "/api/v1/migrations/up", body); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("up on a standby: expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/v1/migrations/reindex", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("reindex on a standby: expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "/api/v1/migrations", nil); w.Code != http.StatusOK {
+		t.Errorf("list on a standby: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	var status dto.StandbyStatusResponse
+	w := do("GET", "/api/v1/standby", nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &status)
+	if w.Code != http.StatusOK || status.Role != "standby" {
+		t.Errorf("standby status: got %d %+v", w.Code, status)
 	}
 
-	var response dto.MigrationListResponse
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal response: %v", err)
+	// The primary still holds the lock
+	primary, err := tracker.AcquirePrimaryLock(context.Background())
+	if err != nil {
+		t.Fatalf("AcquirePrimaryLock() error = %v", err)
+	}
+	if w := do("POST", "/api/v1/standby/promote", nil); w.Code != http.StatusConflict {
+		t.Errorf("promote while the primary runs: expected 409, got %d: %s", w.Code, w.Body.String())
 	}
 
-	if response.Total != 1 {
-		t.Errorf("Expected total = 1, got %d", response.Total)
+	primary.Release()
+	w = do("POST", "/api/v1/standby/promote", nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &status)
+	if w.Code != http.StatusOK || status.Role != "primary" || status.PromotedBy != "api" || !status.HoldsPrimaryLock {
+		t.Errorf("promote: got %d %+v", w.Code, status)
 	}
-	if len(response.Items) != 1 {
-		t.Errorf("Expected 1 item, got %d", len(response.Items))
+	if w := do("POST", "/api/v1/migrations/up", body); w.Code != http.StatusOK {
+		t.Errorf("up after promotion: expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_listMigrations_WithFilters(t *testing.T) {
+func TestHandler_migrateUp_PartialContent(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -824,21 +691,53 @@ func TestHandler_listMigrations_WithFilters(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations?schema=public&connection=test", nil)
+	// Register a migration that will fail
+	migration := &backends.MigrationScript{
+		Version:    "20240101120000",
+		Name:       "test_migration",
+		Connection: "test",
+		Backend:    "postgresql",
+		UpSQL:      "CREATE TABLE test;",
+	}
+	_ = reg.Register(migration)
+
+	// Set up backend that will fail
+	backend := &mockBackend{name: "postgresql", executeError: errors.New("execution failed")}
+	exec.RegisterBackend("postgresql", backend)
+
+	connections := map[string]*backends.ConnectionConfig{
+		"test": {
+			Backend: "postgresql",
+			Host:    "localhost",
+		},
+	}
+	_ = exec.SetConnections(connections)
+
+	reqBody := dto.MigrateUpRequest{
+		Target: &registry.MigrationTarget{
+			Backend:    "postgresql",
+			Connection: "test",
+		},
+		Connection: "test",
+		Schemas:    []string{},
+		DryRun:     false,
+	}
+	body, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
 	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	if w.Code != http.StatusPartialContent {
+		t.Errorf("Expected status %d, got %d", http.StatusPartialContent, w.Code)
 	}
 }
 
-func TestHandler_getMigration(t *testing.T) {
-	// Save original token
+func TestHandler_planMigrations(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -850,44 +749,104 @@ func TestHandler_getMigration(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	migration := &backends.MigrationScript{
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
+
+	applied := &backends.MigrationScript{
 		Schema:     "public",
 		Version:    "20240101120000",
-		Name:       "test_migration",
+		Name:       "create_users",
 		Connection: "test",
 		Backend:    "postgresql",
-		UpSQL:      "CREATE TABLE test;",
-		DownSQL:    "DROP TABLE test;",
 	}
-	_ = reg.Register(migration)
-	migrationID := "public_test_20240101120000_test_migration"
-	tracker.appliedMigrations[migrationID] = true
-	router, _ := setupTestRouter(reg, tracker)
+	pending := &backends.MigrationScript{
+		Schema:       "public",
+		Version:      "20240101130000",
+		Name:         "create_orders",
+		Connection:   "test",
+		Backend:      "postgresql",
+		Dependencies: []string{"create_users"},
+	}
+	_ = reg.Register(applied)
+	_ = reg.Register(pending)
+	tracker.MarkApplied("20240101120000_create_users_postgresql_test")
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID, nil)
+	backend := &mockBackend{name: "postgresql"}
+	exec.RegisterBackend("postgresql", backend)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/plan?connection=test&backend=postgresql", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
 	}
 
-	var response dto.MigrationDetailResponse
+	var response dto.MigrationPlanResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
+	if len(response.Steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(response.Steps))
+	}
+	if response.Steps[0].MigrationID != "20240101120000_create_users_postgresql_test" || response.Steps[0].Action != "skip" {
+		t.Errorf("Unexpected first step: %+v", response.Steps[0])
+	}
+	if response.Steps[1].Action != "apply" || len(response.Steps[1].DependsOn) != 1 {
+		t.Errorf("Unexpected second step: %+v", response.Steps[1])
+	}
+	if backend.executeCalled || backend.connected {
+		t.Error("Plan should not connect to or execute on the backend")
+	}
 
-	if response.MigrationID != migrationID {
-		t.Errorf("Expected MigrationID = %v, got %v", migrationID, response.MigrationID)
+	// connection is required
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/plan", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
-	if !response.Applied {
-		t.Error("Expected Applied = true")
+
+	// Machine-readable formats
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/plan?connection=test&format=machine", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var machine executor.MachinePlan
+	if err := json.Unmarshal(w.Body.Bytes(), &machine); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("format=machine: status %d, body %s", w.Code, w.Body.String())
+	}
+	if machine.FormatVersion != executor.MachinePlanFormatVersion || !machine.HasChanges || len(machine.Apply) != 1 || len(machine.Hash) != 64 {
+		t.Errorf("Unexpected machine plan: %+v", machine)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/plan?connection=test&format=terraform", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var flat map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &flat); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("format=terraform: status %d, body %s", w.Code, w.Body.String())
+	}
+	if flat["has_changes"] != "true" || flat["hash"] != machine.Hash || flat["apply"] != "20240101130000_create_orders_postgresql_test" {
+		t.Errorf("Unexpected terraform plan: %v", flat)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/plan?connection=test&format=yaml", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("format=yaml: expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
 }
 
-func TestHandler_getMigration_NotFound(t *testing.T) {
+func TestHandler_migrateDown(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -900,20 +859,78 @@ func TestHandler_getMigration_NotFound(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/nonexistent", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	// Register a migration for the valid request test
+	migration := &backends.MigrationScript{
+		Version:    "20240101120000",
+		Name:       "test_migration",
+		Connection: "test",
+		Backend:    "postgresql",
+		UpSQL:      "CREATE TABLE test;",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+	migrationID := "test_20240101120000_test_migration"
+	tracker.MarkApplied(migrationID)
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	// Set up backend and connection for down migration
+	backend := &mockBackend{name: "postgresql"}
+	exec.RegisterBackend("postgresql", backend)
+	connections := map[string]*backends.ConnectionConfig{
+		"test": {
+			Backend: "postgresql",
+			Host:    "localhost",
+		},
+	}
+	_ = exec.SetConnections(connections)
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{
+			name: "valid request",
+			requestBody: dto.MigrateDownRequest{
+				MigrationID: migrationID,
+				Schemas:     []string{},
+				DryRun:      false,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid request body",
+			requestBody: map[string]interface{}{
+				"invalid": "data",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing migration_id",
+			requestBody:    dto.MigrateDownRequest{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest("POST", "/api/v1/migrations/down", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
 	}
 }
 
-func TestHandler_getMigrationStatus(t *testing.T) {
+func TestHandler_listMigrations(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -926,17 +943,11 @@ func TestHandler_getMigrationStatus(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	migrationID := "test_20240101120000_test_migration"
-	record := &state.MigrationRecord{
-		MigrationID: migrationID,
-		Status:      "success",
-		AppliedAt:   time.Now().Format(time.RFC3339),
-	}
-	tracker.history = []*state.MigrationRecord{record}
+	tracker := testsupport.NewStateTracker()
+	tracker.MarkApplied("public_20240101120000_test_migration_postgresql_test")
 	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/status", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -945,17 +956,21 @@ func TestHandler_getMigrationStatus(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var response map[string]interface{}
+	var response dto.MigrationListResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
-	if response["migration_id"] != migrationID {
-		t.Errorf("Expected migration_id = %v, got %v", migrationID, response["migration_id"])
+	if response.Total != 1 {
+		t.Errorf("Expected total = 1, got %d", response.Total)
+	}
+	if len(response.Items) != 1 {
+		t.Errorf("Expected 1 item, got %d", len(response.Items))
 	}
 }
 
-func TestHandler_getMigrationStatus_appliedHistoryStatus(t *testing.T) {
+func TestHandler_listMigrations_WithFilters(t *testing.T) {
+	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -967,38 +982,66 @@ func TestHandler_getMigrationStatus_appliedHistoryStatus(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	migrationID := "20240101120000_test_migration_postgresql_core"
-	ts := time.Now().Format(time.RFC3339)
-	// Real DB orders by applied_at DESC, id DESC — completion row first when timestamps tie.
-	tracker.history = []*state.MigrationRecord{
-		{MigrationID: migrationID, Status: "applied", AppliedAt: ts},
-		{MigrationID: migrationID, Status: "pending", AppliedAt: ts},
-	}
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/status", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations?schema=public&connection=test", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
+}
 
-	var response map[string]interface{}
+func TestHandler_listMigrations_Paging(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	for _, version := range []string{"20240101000000", "20240102000000", "20240103000000"} {
+		_ = tracker.RegisterScannedMigration(context.Background(), version+"_create_table_postgresql_test", "", "", version, "create_table", "test", "postgresql")
+	}
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations?limit=2&offset=1&sort_by=version", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response dto.MigrationListResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
-	if response["applied"] != true {
-		t.Errorf("expected applied=true for applied history row, got %v", response["applied"])
+	if response.Total != 3 || response.Limit != 2 || response.Offset != 1 || len(response.Items) != 2 || response.Items[0].Version != "20240102000000" {
+		t.Errorf("Expected the second page of 3 migrations, got %+v", response)
 	}
-	if response["status"] != "applied" {
-		t.Errorf("expected status=applied, got %v", response["status"])
+
+	for _, query := range []string{"limit=-1", "limit=ten", "sort_by=name", "sort_order=up"} {
+		req, _ := http.NewRequest("GET", "/api/v1/migrations?"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
 	}
 }
 
-func TestHandler_getMigrationHistory(t *testing.T) {
+func TestHandler_getMigration(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -1011,46 +1054,44 @@ func TestHandler_getMigrationHistory(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	migration := &backends.MigrationScript{
 		Schema:     "public",
 		Version:    "20240101120000",
 		Name:       "test_migration",
 		Connection: "test",
 		Backend:    "postgresql",
+		UpSQL:      "CREATE TABLE test;",
+		DownSQL:    "DROP TABLE test;",
 	}
 	_ = reg.Register(migration)
-	migrationID := "public_test_20240101120000_test_migration"
-	record := &state.MigrationRecord{
-		MigrationID:     migrationID,
-		Status:          "success",
-		AppliedAt:       time.Now().Format(time.RFC3339),
-		ExecutedBy:      "test-user",
-		ExecutionMethod: "manual",
-	}
-	tracker.history = []*state.MigrationRecord{record}
+	migrationID := "20240101120000_test_migration_postgresql_test"
+	tracker.MarkApplied("public_" + migrationID)
 	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/history", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID, nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
 	}
 
-	var response map[string]interface{}
+	var response dto.MigrationDetailResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
-	if response["migration_id"] != migrationID {
-		t.Errorf("Expected migration_id = %v, got %v", migrationID, response["migration_id"])
+	if response.MigrationID != migrationID {
+		t.Errorf("Expected MigrationID = %v, got %v", migrationID, response.MigrationID)
+	}
+	if !response.Applied {
+		t.Error("Expected Applied = true")
 	}
 }
 
-func TestHandler_getMigrationHistory_NotFound(t *testing.T) {
+func TestHandler_getMigration_NotFound(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -1063,10 +1104,10 @@ func TestHandler_getMigrationHistory_NotFound(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/nonexistent/history", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/nonexistent", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -1076,7 +1117,7 @@ func TestHandler_getMigrationHistory_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandler_rollbackMigration(t *testing.T) {
+func TestHandler_getMigrationStatus(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -1089,42 +1130,23 @@ func TestHandler_rollbackMigration(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	migration := &backends.MigrationScript{
-		Schema:     "public",
-		Version:    "20240101120000",
-		Name:       "test_migration",
-		Connection: "test",
-		Backend:    "postgresql",
-		UpSQL:      "CREATE TABLE test;",
-		DownSQL:    "DROP TABLE test;",
-	}
-	_ = reg.Register(migration)
-	migrationID := "public_test_20240101120000_test_migration"
-	// Use the base migration ID format that executor expects: {version}_{name}_{backend}_{connection}
-	baseMigrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	// Set applied status using base ID (executor uses base ID when checking via GetMigrationExecutions)
-	tracker.appliedMigrations[baseMigrationID] = true
-	router, exec := setupTestRouter(reg, tracker)
-
-	// Set up backend and connection for rollback
-	backend := &mockBackend{name: "postgresql"}
-	exec.RegisterBackend("postgresql", backend)
-	connections := map[string]*backends.ConnectionConfig{
-		"test": {
-			Backend: "postgresql",
-			Host:    "localhost",
-		},
+	tracker := testsupport.NewStateTracker()
+	migrationID := "test_20240101120000_test_migration"
+	record := &state.MigrationRecord{
+		MigrationID: migrationID,
+		Status:      "success",
+		AppliedAt:   time.Now().Format(time.RFC3339),
 	}
-	_ = exec.SetConnections(connections)
+	recordHistory(tracker, []*state.MigrationRecord{record})
+	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/status", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
 	var response map[string]interface{}
@@ -1132,13 +1154,12 @@ func TestHandler_rollbackMigration(t *testing.T) {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
-	if success, ok := response["success"].(bool); !ok || !success {
-		t.Errorf("Expected success = true, got %v", response["success"])
+	if response["migration_id"] != migrationID {
+		t.Errorf("Expected migration_id = %v, got %v", migrationID, response["migration_id"])
 	}
 }
 
-func TestHandler_rollbackMigration_NotFound(t *testing.T) {
-	// Save original token
+func TestHandler_getMigrationStatus_appliedHistoryStatus(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1150,20 +1171,95 @@ func TestHandler_rollbackMigration_NotFound(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
+	migrationID := "20240101120000_test_migration_postgresql_core"
+	ts := time.Now().Format(time.RFC3339)
+	// Real DB orders by applied_at DESC, id DESC — completion row first when timestamps tie.
+	recordHistory(tracker, []*state.MigrationRecord{
+		{MigrationID: migrationID, Status: "applied", AppliedAt: ts},
+		{MigrationID: migrationID, Status: "pending", AppliedAt: ts},
+	})
 	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/nonexistent/rollback", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/status", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["applied"] != true {
+		t.Errorf("expected applied=true for applied history row, got %v", response["applied"])
+	}
+	if response["status"] != "applied" {
+		t.Errorf("expected status=applied, got %v", response["status"])
 	}
 }
 
-func TestHandler_rollbackMigration_NotApplied(t *testing.T) {
+func TestHandler_getMigrationStatus_operations(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	// Names mentioning rollback or ending in down are up migrations like any other
+	tests := []struct {
+		migrationID string
+		history     []*state.MigrationRecord
+		applied     bool
+		status      string
+	}{
+		{"20240101120000_add_rollback_log_postgresql_core", []*state.MigrationRecord{
+			{Status: "applied", AppliedAt: "2024-01-01T00:00:00Z"},
+		}, true, "applied"},
+		{"20240102120000_scale_down_postgresql_core", []*state.MigrationRecord{
+			{Status: "applied", AppliedAt: "2024-01-02T00:00:00Z"},
+		}, true, "applied"},
+		{"20240103120000_create_users_postgresql_core", []*state.MigrationRecord{
+			{Operation: state.OperationDown, Status: "rolled_back", AppliedAt: "2024-01-03T01:00:00Z"},
+			{Status: "applied", AppliedAt: "2024-01-03T00:00:00Z"},
+		}, false, "rolled_back"},
+		{"20240104120000_create_orders_postgresql_core", []*state.MigrationRecord{
+			{Operation: state.OperationRollback, Status: "failed", AppliedAt: "2024-01-04T01:00:00Z"},
+			{Status: "applied", AppliedAt: "2024-01-04T00:00:00Z"},
+		}, true, "applied"},
+	}
+	for _, tt := range tests {
+		for _, record := range tt.history {
+			record.MigrationID = tt.migrationID
+		}
+		recordHistory(tracker, tt.history)
+
+		req, _ := http.NewRequest("GET", "/api/v1/migrations/"+tt.migrationID+"/status", nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["applied"] != tt.applied || response["status"] != tt.status {
+			t.Errorf("%s: got applied=%v status=%v, want %v %s", tt.migrationID, response["applied"], response["status"], tt.applied, tt.status)
+		}
+	}
+}
+
+func TestHandler_getMigrationHistory(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -1176,111 +1272,187 @@ func TestHandler_rollbackMigration_NotApplied(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	migration := &backends.MigrationScript{
 		Schema:     "public",
 		Version:    "20240101120000",
 		Name:       "test_migration",
 		Connection: "test",
 		Backend:    "postgresql",
-		UpSQL:      "CREATE TABLE test;",
-		DownSQL:    "DROP TABLE test;",
 	}
 	_ = reg.Register(migration)
-	// Use the base migration ID format that executor expects: {version}_{name}_{backend}_{connection}
-	baseMigrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
 	migrationID := "public_test_20240101120000_test_migration"
-	// Set applied status to false using base ID (executor uses base ID when checking)
-	tracker.appliedMigrations[baseMigrationID] = false
-	router, exec := setupTestRouter(reg, tracker)
+	record := &state.MigrationRecord{
+		MigrationID:     migrationID,
+		Status:          "success",
+		AppliedAt:       time.Now().Format(time.RFC3339),
+		ExecutedBy:      "test-user",
+		ExecutionMethod: "manual",
+	}
+	recordHistory(tracker, []*state.MigrationRecord{record})
+	router, _ := setupTestRouter(reg, tracker)
 
-	// Set up backend and connection for rollback
-	backend := &mockBackend{name: "postgresql"}
-	exec.RegisterBackend("postgresql", backend)
-	connections := map[string]*backends.ConnectionConfig{
-		"test": {
-			Backend: "postgresql",
-			Host:    "localhost",
-		},
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/history", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
-	_ = exec.SetConnections(connections)
 
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response["migration_id"] != migrationID {
+		t.Errorf("Expected migration_id = %v, got %v", migrationID, response["migration_id"])
+	}
+}
+
+func TestHandler_getMigrationHistory_Paging(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "test_migration", Connection: "test", Backend: "postgresql",
+	})
+	migrationID := "20240101120000_test_migration_postgresql_test"
+	recordHistory(tracker, []*state.MigrationRecord{
+		{MigrationID: "public_" + migrationID, Operation: state.OperationRollback, Status: "rolled_back"},
+		{MigrationID: "public_20240101120000_test_migration_two_postgresql_test", Status: "success"},
+		{MigrationID: "public_20240101120000_test_migration2_postgresql_test", Status: "success"},
+		{MigrationID: "public_" + migrationID, Status: "success"},
+	})
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/history?limit=2", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		History []map[string]interface{} `json:"history"`
+		Total   int                      `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Total != 2 || len(response.History) != 2 || response.History[0]["operation"] != state.OperationRollback {
+		t.Errorf("Expected the 2 records of the migration, got %+v", response)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/migrations/"+migrationID+"/history?sort_by=checksum", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		t.Errorf("Expected status %d for an unknown sort field, got %d", http.StatusBadRequest, w.Code)
 	}
 }
 
-func TestHandler_isManualExecution(t *testing.T) {
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	exec := executor.NewExecutor(reg, tracker)
-	handler := NewHandler(exec)
+func TestHandler_listHistory_Filters(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	recordHistory(tracker, []*state.MigrationRecord{
+		{MigrationID: "20240101120000_a_postgresql_test", ExecutedBy: "alice", ExecutionMethod: "api", AppliedAt: "2026-10-13T09:00:00Z"},
+		{MigrationID: "20240101120000_b_postgresql_test", ExecutedBy: "alice", ExecutionMethod: "cli", AppliedAt: "2026-10-14T09:00:00Z"},
+		{MigrationID: "20240101120000_c_postgresql_test", ExecutedBy: "bob", ExecutionMethod: "api", AppliedAt: "2026-10-13T10:00:00Z"},
+	})
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
 
 	tests := []struct {
-		name   string
-		header string
-		value  string
-		want   bool
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []string
 	}{
 		{
-			name:   "X-Client-Type frontend",
-			header: "X-Client-Type",
-			value:  "frontend",
-			want:   true,
-		},
-		{
-			name:   "X-Client-Type FfM",
-			header: "X-Client-Type",
-			value:  "FfM",
-			want:   true,
+			name:           "all migrations",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"20240101120000_b_postgresql_test", "20240101120000_c_postgresql_test", "20240101120000_a_postgresql_test"},
 		},
 		{
-			name:   "X-Requested-With XMLHttpRequest",
-			header: "X-Requested-With",
-			value:  "XMLHttpRequest",
-			want:   true,
+			name:           "executed by on a day",
+			query:          "?executed_by=alice&applied_after=2026-10-13T00:00:00Z&applied_before=2026-10-14T00:00:00Z",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"20240101120000_a_postgresql_test"},
 		},
 		{
-			name:   "Origin header present",
-			header: "Origin",
-			value:  "http://localhost:3000",
-			want:   true,
+			name:           "execution method",
+			query:          "?execution_method=api",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []string{"20240101120000_c_postgresql_test", "20240101120000_a_postgresql_test"},
 		},
 		{
-			name:   "User-Agent browser",
-			header: "User-Agent",
-			value:  "Mozilla/5.0",
-			want:   true,
+			name:           "invalid time",
+			query:          "?applied_after=yesterday",
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:   "API request",
-			header: "User-Agent",
-			value:  "curl/7.0",
-			want:   false,
+			name:           "empty window",
+			query:          "?applied_after=2026-10-14T00:00:00Z&applied_before=2026-10-13T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
-			req.Header.Set(tt.header, tt.value)
-			c, _ := gin.CreateTestContext(httptest.NewRecorder())
-			c.Request = req
+			req, _ := http.NewRequest("GET", "/api/v1/migrations/history"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
 
-			got := handler.isManualExecution(c)
-			if got != tt.want {
-				t.Errorf("isManualExecution() = %v, want %v", got, tt.want)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response struct {
+				History []map[string]interface{} `json:"history"`
+				Total   int                      `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			var ids []string
+			for _, item := range response.History {
+				ids = append(ids, item["migration_id"].(string))
+			}
+			if response.Total != len(tt.expectedIDs) || strings.Join(ids, ",") != strings.Join(tt.expectedIDs, ",") {
+				t.Errorf("Expected %v, got %d: %v", tt.expectedIDs, response.Total, ids)
 			}
 		})
 	}
 }
 
-func TestHandler_getExecutedBy(t *testing.T) {
+func TestHandler_getMigrationHistory_NotFound(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -1293,219 +1465,2105 @@ func TestHandler_getExecutedBy(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	exec := executor.NewExecutor(reg, tracker)
-	handler := NewHandler(exec)
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
 
-	tests := []struct {
-		name       string
-		authHeader string
-		headers    map[string]string
-		want       string
-	}{
-		{
-			name:       "frontend user",
-			authHeader: "Bearer test-token",
-			headers: map[string]string{
-				"X-Client-Type": "frontend",
-			},
-			want: "frontend_user",
-		},
-		{
-			name:       "API user",
-			authHeader: "Bearer test-token",
-			headers:    map[string]string{},
-			want:       "api_user",
-		},
-		{
-			name:       "no auth header",
-			authHeader: "",
-			headers:    map[string]string{},
-			want:       "system",
-		},
-	}
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/nonexistent/history", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
 
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_rollbackMigration(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	migration := &backends.MigrationScript{
+		Schema:     "public",
+		Version:    "20240101120000",
+		Name:       "test_migration",
+		Connection: "test",
+		Backend:    "postgresql",
+		UpSQL:      "CREATE TABLE test;",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+	migrationID := "public_test_20240101120000_test_migration"
+	// Use the base migration ID format that executor expects: {version}_{name}_{backend}_{connection}
+	baseMigrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
+	// Mark the migration applied on public, which gives it an execution to roll back
+	tracker.MarkApplied("public_" + baseMigrationID)
+	router, exec := setupTestRouter(reg, tracker)
+
+	// Set up backend and connection for rollback
+	backend := &mockBackend{name: "postgresql"}
+	exec.RegisterBackend("postgresql", backend)
+	connections := map[string]*backends.ConnectionConfig{
+		"test": {
+			Backend: "postgresql",
+			Host:    "localhost",
+		},
+	}
+	_ = exec.SetConnections(connections)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if success, ok := response["success"].(bool); !ok || !success {
+		t.Errorf("Expected success = true, got %v", response["success"])
+	}
+}
+
+func TestHandler_rollbackMigration_Cascade(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	// users <- orders <- order_items
+	for _, m := range []*backends.MigrationScript{
+		{Version: "20240101120000", Name: "users"},
+		{Version: "20240102120000", Name: "orders", Dependencies: []string{"users"}},
+		{Version: "20240103120000", Name: "order_items", Dependencies: []string{"orders"}},
+	} {
+		m.Schema, m.Connection, m.Backend = "public", "test", "postgresql"
+		m.UpSQL, m.DownSQL = "SELECT 1;", "SELECT 1;"
+		_ = reg.Register(m)
+		tracker.MarkApplied(fmt.Sprintf("public_%s_%s_postgresql_test", m.Version, m.Name))
+	}
+	router, exec := setupTestRouter(reg, tracker)
+	exec.RegisterBackend("postgresql", &mockBackend{name: "postgresql"})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+
+	rollback := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/migrations/20240101120000_users_postgresql_test/rollback"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := rollback(""); w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d without cascade, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+
+	w := rollback("?cascade=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Success  bool                           `json:"success"`
+		Cascaded []dto.CascadedRollbackResponse `json:"cascaded"`
+		Order    []string                       `json:"order"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	want := "[public_20240103120000_order_items_postgresql_test public_20240102120000_orders_postgresql_test public_20240101120000_users_postgresql_test]"
+	if !response.Success || len(response.Cascaded) != 2 || fmt.Sprint(response.Order) != want {
+		t.Errorf("Expected order %s, got %+v", want, response)
+	}
+	for _, id := range response.Order {
+		if isApplied(tracker, id) {
+			t.Errorf("Expected %s to be rolled back", id)
+		}
+	}
+}
+
+func TestHandler_rollbackMigration_NotFound(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/nonexistent/rollback", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_rollbackMigration_NotApplied(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	migration := &backends.MigrationScript{
+		Schema:     "public",
+		Version:    "20240101120000",
+		Name:       "test_migration",
+		Connection: "test",
+		Backend:    "postgresql",
+		UpSQL:      "CREATE TABLE test;",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+	migrationID := "public_test_20240101120000_test_migration"
+	router, exec := setupTestRouter(reg, tracker)
+
+	// Set up backend and connection for rollback
+	backend := &mockBackend{name: "postgresql"}
+	exec.RegisterBackend("postgresql", backend)
+	connections := map[string]*backends.ConnectionConfig{
+		"test": {
+			Backend: "postgresql",
+			Host:    "localhost",
+		},
+	}
+	_ = exec.SetConnections(connections)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandler_isManualExecution(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := executor.NewExecutor(reg, tracker)
+	handler := NewHandler(exec)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   bool
+	}{
+		{
+			name:   "X-Client-Type frontend",
+			header: "X-Client-Type",
+			value:  "frontend",
+			want:   true,
+		},
+		{
+			name:   "X-Client-Type FfM",
+			header: "X-Client-Type",
+			value:  "FfM",
+			want:   true,
+		},
+		{
+			name:   "X-Requested-With XMLHttpRequest",
+			header: "X-Requested-With",
+			value:  "XMLHttpRequest",
+			want:   true,
+		},
+		{
+			name:   "Origin header present",
+			header: "Origin",
+			value:  "http://localhost:3000",
+			want:   true,
+		},
+		{
+			name:   "User-Agent browser",
+			header: "User-Agent",
+			value:  "Mozilla/5.0",
+			want:   true,
+		},
+		{
+			name:   "API request",
+			header: "User-Agent",
+			value:  "curl/7.0",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
+			req.Header.Set(tt.header, tt.value)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			got := handler.isManualExecution(c)
+			if got != tt.want {
+				t.Errorf("isManualExecution() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_getExecutedBy(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := executor.NewExecutor(reg, tracker)
+	handler := NewHandler(exec)
+
+	tests := []struct {
+		name       string
+		authHeader string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "frontend user",
+			authHeader: "Bearer test-token",
+			headers: map[string]string{
+				"X-Client-Type": "frontend",
+			},
+			want: "frontend_user",
+		},
+		{
+			name:       "API user",
+			authHeader: "Bearer test-token",
+			headers:    map[string]string{},
+			want:       "api_user",
+		},
+		{
+			name:       "no auth header",
+			authHeader: "",
+			headers:    map[string]string{},
+			want:       "system",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			got := handler.getExecutedBy(c)
+			if got != tt.want {
+				t.Errorf("getExecutedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := executor.NewExecutor(reg, tracker)
+	handler := NewHandler(exec)
+
+	router := gin.New()
+	handler.RegisterRoutes(router)
+
+	// Test that routes are registered
+	routes := router.Routes()
+	routePaths := make(map[string]bool)
+	for _, route := range routes {
+		routePaths[route.Path] = true
+	}
+
+	expectedRoutes := []string{
+		"/api/v1/migrations/up",
+		"/api/v1/migrations/down",
+		"/api/v1/migrations",
+		"/api/v1/health",
+	}
+
+	for _, expected := range expectedRoutes {
+		if !routePaths[expected] {
+			t.Errorf("Expected route %s to be registered", expected)
+		}
+	}
+}
+
+func TestHandler_Options(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("OPTIONS", "/api/v1/migrations", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func TestHandler_OpenAPISpec(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.yaml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if w.Header().Get("Content-Type") != "application/x-yaml" {
+		t.Errorf("Expected Content-Type application/x-yaml, got %s", w.Header().Get("Content-Type"))
+	}
+
+	if len(w.Body.Bytes()) == 0 {
+		t.Error("Expected non-empty OpenAPI spec")
+	}
+}
+
+func TestHandler_OpenAPISpecJSON(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal JSON response: %v", err)
+	}
+
+	// Verify it's a valid OpenAPI/Swagger spec structure
+	// Swag generates Swagger 2.0 format (uses "swagger" field)
+	// OpenAPI 3.x format uses "openapi" field
+	if _, ok := response["openapi"]; !ok {
+		if _, ok := response["swagger"]; !ok {
+			t.Error("Expected 'openapi' or 'swagger' field in response")
+		}
+	}
+}
+
+func TestHandler_reindexMigrations(t *testing.T) {
+	// Save original token and SFM path
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	originalSfmPath := os.Getenv("BFM_SFM_PATH")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		if originalSfmPath != "" {
+			_ = os.Setenv("BFM_SFM_PATH", originalSfmPath)
+		} else {
+			_ = os.Unsetenv("BFM_SFM_PATH")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	// Create a temporary directory for testing
+	tmpDir := t.TempDir()
+
+	// Set SFM path
+	_ = os.Setenv("BFM_SFM_PATH", tmpDir)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/reindex", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Reindex should succeed even with empty directory
+	if w.Code != http.StatusOK && w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusOK, http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+
+	if w.Code == http.StatusOK {
+		var response dto.ReindexResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		// Response should have Total field
+		if response.Total < 0 {
+			t.Errorf("Expected Total >= 0, got %d", response.Total)
+		}
+	}
+}
+
+func TestHandler_reindexMigrations_Unauthorized(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/reindex", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestHandler_migrateUp_ExecutorError(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := executor.NewExecutor(reg, tracker)
+	handler := NewHandler(exec)
+
+	// Create a backend that will fail
+	mockBackend := &mockBackend{
+		name:         "postgresql",
+		connectError: errors.New("connection failed"),
+	}
+	exec.RegisterBackend("postgresql", mockBackend)
+
+	// Set connection config
+	connections := map[string]*backends.ConnectionConfig{
+		"test": {
+			Backend:  "postgresql",
+			Host:     "localhost",
+			Port:     "5432",
+			Database: "test",
+			Username: "test",
+			Password: "test",
+			Extra:    map[string]string{},
+		},
+	}
+	_ = exec.SetConnections(connections)
+
+	// Register a migration
+	migration := &backends.MigrationScript{
+		Backend:    "postgresql",
+		Connection: "test",
+		Version:    "20250101000000",
+		Name:       "test_migration",
+		UpSQL:      "CREATE TABLE test (id INT);",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+
+	router := gin.New()
+	handler.RegisterRoutes(router)
+
+	requestBody := dto.MigrateUpRequest{
+		Target: &registry.MigrationTarget{
+			Backend:    "postgresql",
+			Connection: "test",
+		},
+		Connection: "test",
+		Schemas:    []string{},
+		DryRun:     false,
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Should return 500 or 206 (partial content) depending on error handling
+	if w.Code != http.StatusInternalServerError && w.Code != http.StatusPartialContent {
+		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusInternalServerError, http.StatusPartialContent, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_migrateUp_InvalidSchema(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	router, _ := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+
+	for _, schema := range []string{"pg_catalog", "information_schema", `tenant"; DROP SCHEMA public; --`} {
+		requestBody := dto.MigrateUpRequest{
+			Target:     &registry.MigrationTarget{Connection: "test"},
+			Connection: "test",
+			Schemas:    []string{"tenant_a", schema},
+		}
+		body, _ := json.Marshal(requestBody)
+		req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("schema %q: expected status %d, got %d. Body: %s", schema, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_migrateDown_ExecutorError(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := executor.NewExecutor(reg, tracker)
+	handler := NewHandler(exec)
+
+	router := gin.New()
+	handler.RegisterRoutes(router)
+
+	requestBody := dto.MigrateDownRequest{
+		MigrationID: "nonexistent_migration",
+		Schemas:     []string{},
+		DryRun:      false,
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/down", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Should return 500 or 206 depending on error handling
+	if w.Code != http.StatusInternalServerError && w.Code != http.StatusPartialContent {
+		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusInternalServerError, http.StatusPartialContent, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_listMigrations_Error(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationList", errors.New("database error"))
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_getMigration_StateTrackerError(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+
+	// Register a migration
+	migration := &backends.MigrationScript{
+		Backend:    "postgresql",
+		Connection: "test",
+		Version:    "20250101000000",
+		Name:       "test_migration",
+		UpSQL:      "CREATE TABLE test (id INT);",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+
+	tracker.Fail("IsMigrationApplied", errors.New("database error"))
+	router, _ := setupTestRouter(reg, tracker)
+
+	migrationID := reg.getMigrationID(migration)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID, nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_getMigrationStatus_Error(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationHistory", errors.New("database error"))
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/test_migration/status", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_rollbackMigration_ExecutorError(t *testing.T) {
+	// Save original token
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+
+	// Register a migration
+	migration := &backends.MigrationScript{
+		Backend:    "postgresql",
+		Connection: "test",
+		Version:    "20250101000000",
+		Name:       "test_migration",
+		UpSQL:      "CREATE TABLE test (id INT);",
+		DownSQL:    "DROP TABLE test;",
+	}
+	_ = reg.Register(migration)
+
+	// Mark as applied
+	migrationID := reg.getMigrationID(migration)
+	tracker.MarkApplied(migrationID)
+
+	// Make rollback fail
+	tracker.Fail("IsMigrationApplied", errors.New("database error"))
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_locks(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	originalAdminToken := os.Getenv("BFM_ADMIN_TOKEN")
+	defer func() {
+		for key, value := range map[string]string{"BFM_API_TOKEN": originalToken, "BFM_ADMIN_TOKEN": originalAdminToken} {
+			if value != "" {
+				_ = os.Setenv(key, value)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_ADMIN_TOKEN", "admin-token")
+	router, _ := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/locks", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list locks: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"locks":[]`) {
+		t.Errorf("expected an empty lock list, got %s", w.Body.String())
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "API token is not enough", token: "test-token", expectedStatus: http.StatusForbidden},
+		{name: "admin token", token: "admin-token", expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/api/v1/migrations/locks/core", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("release lock: expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_roles(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token,ci:operator:op-token")
+	router, _ := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "read-only lists migrations", method: "GET", path: "/api/v1/migrations", token: "ro-token", expectedStatus: http.StatusOK},
+		{name: "read-only cannot migrate up", method: "POST", path: "/api/v1/migrations/up", token: "ro-token", expectedStatus: http.StatusForbidden},
+		{name: "read-only cannot reindex", method: "POST", path: "/api/v1/migrations/reindex", token: "ro-token", expectedStatus: http.StatusForbidden},
+		{name: "operator migrates up", method: "POST", path: "/api/v1/migrations/up", token: "op-token", expectedStatus: http.StatusBadRequest},
+		{name: "operator cannot release locks", method: "DELETE", path: "/api/v1/migrations/locks/core", token: "op-token", expectedStatus: http.StatusForbidden},
+		{name: "unknown token", method: "GET", path: "/api/v1/migrations", token: "nope", expectedStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_listDrift(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{
+		Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id BIGINT);",
+	})
+	tracker := testsupport.NewStateTracker()
+	_ = tracker.RecordMigration(context.Background(), &state.MigrationRecord{
+		MigrationID: "20240101120000_create_users_postgresql_core",
+		Version:     "20240101120000",
+		Connection:  "core",
+		Backend:     "postgresql",
+		Status:      "success",
+		Checksum:    "0000000000000000000000000000000000000000000000000000000000000000",
+	})
+	router, _ := setupTestRouter(reg, tracker)
+
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/drift", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response dto.MigrationDriftResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 1 || response.Items[0].MigrationID != "20240101120000_create_users_postgresql_core" {
+		t.Errorf("unexpected drift response: %+v", response)
+	}
+}
+
+func TestHandler_ETag(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id BIGINT);",
+	})
+	tracker := testsupport.NewStateTracker()
+	_ = tracker.RegisterScannedMigration(context.Background(), "20240101120000_create_users_postgresql_core", "", "", "20240101120000", "create_users", "core", "postgresql")
+	router, _ := setupTestRouter(reg, tracker)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{
+		"/api/v1/migrations",
+		"/api/v1/migrations/20240101120000_create_users_postgresql_core",
+		"/api/v1/openapi.yaml",
+		"/api/v1/openapi.json",
+	} {
+		t.Run(path, func(t *testing.T) {
+			first := get(path, "")
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+			}
+			if w := get(path, etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+				t.Errorf("expected an empty 304 for a matching If-None-Match, got %d", w.Code)
+			}
+			if w := get(path, `W/"stale"`); w.Code != http.StatusOK {
+				t.Errorf("expected 200 for a stale ETag, got %d", w.Code)
+			}
+		})
+	}
+
+	// A status change invalidates the list's ETag
+	etag := get("/api/v1/migrations", "").Header().Get("ETag")
+	tracker.MarkApplied("20240101120000_create_users_postgresql_core")
+	if w := get("/api/v1/migrations", etag); w.Code != http.StatusOK {
+		t.Errorf("expected 200 after the list changed, got %d", w.Code)
+	}
+}
+
+func TestHandler_listPending(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id BIGINT);",
+	})
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE orders (id BIGINT);",
+	})
+	tracker := testsupport.NewStateTracker()
+	tracker.MarkApplied("20240101120000_create_users_postgresql_core")
+	router, exec := setupTestRouter(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core": {Backend: "postgresql", Host: "localhost"},
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "missing connection", query: "", wantStatus: http.StatusBadRequest},
+		{name: "unknown connection", query: "?connection=other", wantStatus: http.StatusNotFound},
+		{name: "pending migrations", query: "?connection=core", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/migrations/pending"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response dto.PendingMigrationsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Count != 1 || response.Items[0].MigrationID != "20240102120000_create_orders_postgresql_core" {
+				t.Errorf("unexpected pending response: %+v", response)
+			}
+		})
+	}
+}
+
+func TestHandler_migrationsReady(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id BIGINT);",
+	})
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "create_events", Connection: "metrics", Backend: "postgresql",
+		UpSQL: "CREATE TABLE events (id BIGINT);",
+	})
+	tracker := testsupport.NewStateTracker()
+	tracker.MarkApplied("20240101120000_create_users_postgresql_core")
+	router, exec := setupTestRouter(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core":    {Backend: "postgresql", Host: "localhost"},
+		"metrics": {Backend: "postgresql", Host: "localhost"},
+	})
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantPending int
+	}{
+		{name: "missing connection", query: "", wantStatus: http.StatusBadRequest},
+		{name: "unknown connection", query: "?connection=core&connection=other", wantStatus: http.StatusNotFound},
+		{name: "applied", query: "?connection=core", wantStatus: http.StatusOK},
+		{name: "pending", query: "?connection=core&connection=metrics", wantStatus: http.StatusServiceUnavailable, wantPending: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/migrations/ready"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest || tt.wantStatus == http.StatusNotFound {
+				return
+			}
+
+			var response dto.MigrationsReadyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Ready != (tt.wantPending == 0) || response.Pending != tt.wantPending {
+				t.Errorf("unexpected ready response: %+v", response)
+			}
+		})
+	}
+}
+
+func TestHandler_migrateUp_Connections(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	router, exec := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"tenant_a": {Backend: "postgresql"},
+		"tenant_b": {Backend: "postgresql"},
+	})
+
+	tests := []struct {
+		name           string
+		requestBody    dto.MigrateUpRequest
+		expectedStatus int
+	}{
+		{name: "glob", requestBody: dto.MigrateUpRequest{Connections: []string{"tenant_*"}}, expectedStatus: http.StatusOK},
+		{name: "unknown connection", requestBody: dto.MigrateUpRequest{Connections: []string{"other"}}, expectedStatus: http.StatusBadRequest},
+		{name: "connection and connections", requestBody: dto.MigrateUpRequest{Connection: "tenant_a", Connections: []string{"tenant_b"}}, expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var response dto.MigrateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Connections) != 2 || response.Connections[0].Connection != "tenant_a" || response.Connections[1].Connection != "tenant_b" {
+				t.Errorf("unexpected connection sections: %+v", response.Connections)
+			}
+		})
+	}
+}
+
+func TestHandler_Audit(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token,ci:operator:op-token")
+	tracker := testsupport.NewStateTracker()
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.RemoteAddr = "10.0.0.7:52100"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	do("POST", "/api/v1/migrations/up", "ro-token", `{"connection":"core"}`)
+	do("POST", "/api/v1/migrations/down", "", `{}`)
+	do("POST", "/api/v1/migrations/up", "op-token", `{}`)
+	do("GET", "/api/v1/migrations", "op-token", "")
+
+	audit, _, _ := tracker.GetAuditLog(context.Background(), nil)
+	if len(audit) != 3 {
+		t.Fatalf("expected 3 audit records, got %d", len(audit))
+	}
+	denied := audit[2]
+	if denied.Operation != "up" || denied.Actor != "grafana" || denied.Role != "read-only" || denied.Outcome != state.AuditOutcomeDenied ||
+		denied.Status != "403" || denied.SourceIP != "10.0.0.7" || denied.RequestBody != `{"connection":"core"}` || denied.Error == "" {
+		t.Errorf("unexpected audit record for a read-only up: %+v", denied)
+	}
+	if anonymous := audit[1]; anonymous.Actor != "" || anonymous.Outcome != state.AuditOutcomeDenied || anonymous.Status != "401" {
+		t.Errorf("unexpected audit record for an unauthenticated down: %+v", anonymous)
+	}
+	if failed := audit[0]; failed.Actor != "ci" || failed.Outcome != state.AuditOutcomeFailed || failed.Status != "400" {
+		t.Errorf("unexpected audit record for an invalid up: %+v", failed)
+	}
+
+	// The audit log requires an admin token
+	if w := do("GET", "/api/v1/audit", "op-token", ""); w.Code != http.StatusForbidden {
+		t.Errorf("audit log with an operator token: expected 403, got %d", w.Code)
+	}
+	if w := do("GET", "/api/v1/audit?limit=1000", "test-token", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("audit log with limit=1000: expected 400, got %d", w.Code)
+	}
+
+	var response dto.AuditLogResponse
+	w := do("GET", "/api/v1/audit?outcome=denied&limit=1", "test-token", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("audit log: got %d %s", w.Code, w.Body.String())
+	}
+	if response.Total != 2 || len(response.Items) != 1 || response.Items[0].Operation != "down" {
+		t.Errorf("unexpected audit log page %+v", response)
+	}
+}
+
+func TestHandler_Jobs(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	ctx := context.Background()
+	_ = tracker.RecordJob(ctx, &state.Job{ID: "job-1", Status: state.JobCompleted, Connection: "core", Target: `{"connection":"core","schema":"tenant_a"}`, Applied: []string{"m1"}})
+	_ = tracker.RecordJob(ctx, &state.Job{ID: "job-2", Status: state.JobFailed, Connection: "core", Errors: []string{"m2: boom"}})
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/v1/jobs/job-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var job dto.JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if job.Status != state.JobCompleted || job.Target == nil || job.Target.Schema != "tenant_a" || len(job.Applied) != 1 || job.Errors == nil {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	if w := get("/api/v1/jobs/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown job, got %d", w.Code)
+	}
+
+	w = get("/api/v1/jobs?status=failed")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list dto.JobListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if list.Total != 1 || len(list.Items) != 1 || list.Items[0].ID != "job-2" || list.Limit != 50 {
+		t.Errorf("unexpected failed jobs %+v", list)
+	}
+
+	for _, query := range []string{"status=done", "limit=0", "limit=501", "offset=-1"} {
+		if w := get("/api/v1/jobs?" + query); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+	if w := get("/api/v1/jobs?status=dead_lettered"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for the dead-letter queue, got %d", w.Code)
+	}
+
+	// Replaying needs the queue, which this server does not use
+	req, _ := http.NewRequest("POST", "/api/v1/jobs/job-2/replay", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a replay without a queue, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_ScheduledRuns(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	router, exec := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"test": {Backend: "postgresql"}})
+	exec.SetMaintenanceWindow(executor.MaintenanceWindow{Start: 22 * time.Hour, End: 4 * time.Hour})
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var reader *bytes.Buffer
+		if body != nil {
+			encoded, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(encoded)
+		} else {
+			reader = bytes.NewBuffer(nil)
+		}
+		req, _ := http.NewRequest(method, path, reader)
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	runAt := time.Date(2100, 1, 2, 12, 0, 0, 0, time.UTC)
+	w := do("POST", "/api/v1/migrations/up", dto.MigrateUpRequest{
+		Target:     &registry.MigrationTarget{Connection: "test"},
+		Connection: "test",
+		Schemas:    []string{"tenant_a", "tenant_b"},
+		ScheduleAt: runAt.Format(time.RFC3339),
+	})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var scheduled dto.ScheduleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &scheduled); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	// Noon is outside the window: the jobs start when it opens
+	if len(scheduled.Jobs) != 2 || scheduled.Jobs[0].Status != state.JobScheduled || scheduled.Jobs[0].ScheduledAt != "2100-01-02T12:00:00Z" ||
+		scheduled.RunsAt != "2100-01-02T22:00:00Z" || scheduled.MaintenanceWindow != "22:00-04:00" {
+		t.Errorf("unexpected schedule response %+v", scheduled)
+	}
+
+	w = do("GET", "/api/v1/migrations/scheduled?connection=test", nil)
+	var list dto.ScheduledJobListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("unexpected list response %d: %s", w.Code, w.Body.String())
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("expected 2 scheduled runs, got %+v", list)
+	}
+
+	cancelPath := "/api/v1/migrations/scheduled/" + scheduled.Jobs[1].ID
+	if w := do("DELETE", cancelPath, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"cancelled"`) {
+		t.Errorf("expected the run to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("DELETE", cancelPath, nil); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 cancelling twice, got %d", w.Code)
+	}
+	if w := do("DELETE", "/api/v1/migrations/scheduled/unknown", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown job, got %d", w.Code)
+	}
+	if w := do("GET", "/api/v1/jobs?status=cancelled", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), scheduled.Jobs[1].ID) {
+		t.Errorf("expected the cancelled run in the jobs, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for name, req := range map[string]dto.MigrateUpRequest{
+		"invalid time":        {Connection: "test", ScheduleAt: "tomorrow"},
+		"past time":           {Connection: "test", ScheduleAt: "2000-01-01T00:00:00Z"},
+		"unknown connection":  {Connection: "unknown", ScheduleAt: runAt.Format(time.RFC3339)},
+		"ignore dependencies": {Connection: "test", ScheduleAt: runAt.Format(time.RFC3339), IgnoreDependencies: true},
+		"connections":         {Connections: []string{"test"}, ScheduleAt: runAt.Format(time.RFC3339)},
+	} {
+		if w := do("POST", "/api/v1/migrations/up", req); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_StateChanges(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	for _, record := range []*state.MigrationRecord{
+		{MigrationID: "20240101120000_create_users_postgresql_core", Status: "success"},
+		{MigrationID: "20240102120000_create_orders_postgresql_core", Status: "success"},
+		{MigrationID: "20240103120000_create_items_postgresql_core", Status: "failed", ErrorMessage: "boom"},
+	} {
+		_ = tracker.RecordMigration(context.Background(), record)
+	}
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	get := func(path string) (*httptest.ResponseRecorder, dto.StateChangesResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response dto.StateChangesResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+		}
+		return w, response
+	}
+
+	w, page := get("/api/v1/state-changes?limit=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(page.Changes) != 2 || page.NextCursor != 2 || !page.HasMore {
+		t.Errorf("unexpected first page %+v", page)
+	}
+
+	_, page = get("/api/v1/state-changes?since=2")
+	if len(page.Changes) != 1 || page.Changes[0].Error != "boom" || page.NextCursor != 3 || page.HasMore {
+		t.Errorf("unexpected second page %+v", page)
+	}
+
+	// Past the end, the cursor stays where it was
+	_, page = get("/api/v1/state-changes?since=3")
+	if len(page.Changes) != 0 || page.Changes == nil || page.NextCursor != 3 || page.HasMore {
+		t.Errorf("unexpected empty page %+v", page)
+	}
+
+	for _, query := range []string{"since=-1", "since=abc", "limit=0", "limit=1001"} {
+		if w, _ := get("/api/v1/state-changes?" + query); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandler_migrationFacets(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", Tags: []string{"team=identity"}})
+	tracker := testsupport.NewStateTracker()
+	ctx := context.Background()
+	_ = tracker.RegisterScannedMigration(ctx, "20240101120000_create_users_postgresql_core", "tenant_a,tenant_b", "", "20240101120000", "create_users", "core", "postgresql")
+	_ = tracker.RegisterScannedMigration(ctx, "20240102120000_create_orders_postgresql_billing", "tenant_a", "", "20240102120000", "create_orders", "billing", "postgresql")
+	_ = tracker.RegisterScannedMigration(ctx, "20240103120000_config_etcd_cache", "", "", "20240103120000", "config", "cache", "etcd")
+	router, _ := setupTestRouter(reg, tracker)
+
+	get := func(path string, response interface{}) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+		}
+		return w
+	}
+
+	var facets dto.MigrationFacetsResponse
+	if w := get("/api/v1/migrations/facets?backend=postgresql", &facets); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if fmt.Sprint(facets.Connections) != "[{billing 1} {core 1}]" || fmt.Sprint(facets.Schemas) != "[{tenant_a 2} {tenant_b 1}]" {
+		t.Errorf("unexpected facets %+v", facets)
+	}
+	// The backend filter does not narrow the backends offered
+	if fmt.Sprint(facets.Backends) != "[{etcd 1} {postgresql 2}]" || fmt.Sprint(facets.Tags) != "[{team=identity 1}]" {
+		t.Errorf("unexpected backends and tags %+v", facets)
+	}
+
+	var facet dto.MigrationFacetResponse
+	if w := get("/api/v1/migrations/facets/connections?q=C&limit=1", &facet); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if facet.Facet != "connections" || facet.Total != 2 || fmt.Sprint(facet.Values) != "[{cache 1}]" {
+		t.Errorf("unexpected facet %+v", facet)
+	}
+
+	for _, path := range []string{"/api/v1/migrations/facets/names", "/api/v1/migrations/facets/schemas?limit=0"} {
+		if w := get(path, &facet); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandler_StatusLabels(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	tracker.MarkApplied("20240101120000_users_postgresql_core", "20240102120000_orders_postgresql_core")
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("PUT", "/api/v1/migrations/20240101120000_users_postgresql_core/labels", `{"labels":["Verified","needs-backfill"]}`)
+	var labels dto.StatusLabelsResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &labels)
+	if w.Code != http.StatusOK || strings.Join(labels.Labels, ",") != "needs-backfill,verified" {
+		t.Fatalf("set labels: got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := do("PUT", "/api/v1/migrations/20240101120000_users_postgresql_core/labels", `{"labels":["failed"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("reserved label: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("PUT", "/api/v1/migrations/missing/labels", `{"labels":["verified"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown migration: expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Labels are listed next to the execution status and can be filtered on
+	var list dto.MigrationListResponse
+	w = do("GET", "/api/v1/migrations?label=verified", "")
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || list.Total != 1 || list.Items[0].Name != "users" || list.Items[0].Status != "applied" ||
+		len(list.Items[0].StatusLabels) != 2 {
+		t.Errorf("list by label: got %d %s", w.Code, w.Body.String())
+	}
+
+	var detail dto.MigrationDetailResponse
+	w = do("GET", "/api/v1/migrations/20240101120000_users_postgresql_core", "")
+	_ = json.Unmarshal(w.Body.Bytes(), &detail)
+	if w.Code != http.StatusOK || len(detail.StatusLabels) != 2 {
+		t.Errorf("detail: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_TenantStatusReport(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	migrationID := "20240101120000_users_postgresql_core"
+	tracker.MarkApplied("public_" + migrationID)
+	router, exec := setupTestRouter(newMockRegistry(), tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	generate := func(body string) dto.ReportResponse {
+		t.Helper()
+		w := do("POST", "/api/v1/reports/tenant-status", body)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var report dto.ReportResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &report)
+		for deadline := time.Now().Add(5 * time.Second); report.Status == executor.ReportPending && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			_ = json.Unmarshal(do("GET", "/api/v1/reports/"+report.ID, "").Body.Bytes(), &report)
+		}
+		if report.Status != executor.ReportCompleted || report.DownloadURL == "" {
+			t.Fatalf("expected the report to complete, got %+v", report)
+		}
+		return report
+	}
+
+	if w := do("POST", "/api/v1/reports/tenant-status", `{"connection":"unknown"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown connection: expected 400, got %d", w.Code)
+	}
+	if w := do("GET", "/api/v1/reports/report_0/download", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown report: expected 404, got %d", w.Code)
+	}
+
+	report := generate(`{"connection":"core","schemas":["tenant_a"]}`)
+	w := do("GET", report.DownloadURL, "")
+	want := "schema,applied,failed,pending," + migrationID + "\npublic,1,0,0,applied\ntenant_a,0,0,1,\n"
+	if w.Code != http.StatusOK || w.Body.String() != want || !strings.Contains(w.Header().Get("Content-Disposition"), "tenant-status-core-") {
+		t.Errorf("csv report: got %d %q, want %q", w.Code, w.Body.String(), want)
+	}
+
+	report = generate(`{"connection":"core","format":"json"}`)
+	var matrix dto.TenantStatusReport
+	if err := json.Unmarshal(do("GET", report.DownloadURL, "").Body.Bytes(), &matrix); err != nil {
+		t.Fatalf("Failed to unmarshal report: %v", err)
+	}
+	if len(matrix.Schemas) != 1 || matrix.Schemas[0].Statuses[migrationID] != "applied" || matrix.Schemas[0].Applied != 1 {
+		t.Errorf("unexpected json report %+v", matrix)
+	}
+}
+
+func TestHandler_CSVExport(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	migrationID := "20240101120000_users_postgresql_core"
+	recordHistory(tracker, []*state.MigrationRecord{
+		{MigrationID: migrationID, Status: "failed", ErrorMessage: "=cmd()", ExecutedBy: "alice", ExecutionMethod: "api"},
+		{MigrationID: migrationID, Operation: state.OperationRollback, Status: "rolled_back", ExecutedBy: "bob", ExecutionMethod: "api"},
+		{MigrationID: "20240102120000_orders_postgresql_core", Status: "success"},
+	})
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/v1/migrations?format=csv&columns=migration_id,status")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") ||
+		!strings.Contains(w.Header().Get("Content-Disposition"), "migrations.csv") {
+		t.Fatalf("list csv: got %d %v", w.Code, w.Header())
+	}
+	want := "migration_id,status\n" + migrationID + ",failed\n20240102120000_orders_postgresql_core,applied\n"
+	if w.Body.String() != want {
+		t.Errorf("list csv = %q, want %q", w.Body.String(), want)
+	}
+
+	w = get("/api/v1/migrations/" + migrationID + "/history?format=csv&columns=status,operation,executed_by,error_message")
+	if w.Code != http.StatusOK || w.Body.String() != "status,operation,executed_by,error_message\nfailed,up,alice,'=cmd()\nrolled_back,rollback,bob,\n" {
+		t.Errorf("history csv: got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := get("/api/v1/migrations?format=csv&columns=migration_id,owner"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown column: expected 400, got %d", w.Code)
+	}
+	if w := get("/api/v1/migrations?format=xlsx"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandler_LocalizedErrors(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	router, _ := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+
+	get := func(path, token, acceptLanguage string) (*httptest.ResponseRecorder, string) {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body["error"]
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		acceptLanguage string
+		wantStatus     int
+		wantError      string
+		wantLanguage   string
+	}{
+		{"english by default", "/api/v1/jobs?status=done", "test-token", "", http.StatusBadRequest, i18n.T(i18n.English, i18n.APIInvalidJobStatus), "en"},
+		{"japanese", "/api/v1/jobs?status=done", "test-token", "ja-JP,ja;q=0.9,en;q=0.8", http.StatusBadRequest, i18n.T("ja", i18n.APIInvalidJobStatus), "ja"},
+		{"unsupported language", "/api/v1/jobs?status=done", "test-token", "fr-FR", http.StatusBadRequest, i18n.T(i18n.English, i18n.APIInvalidJobStatus), "en"},
+		{"japanese auth error", "/api/v1/jobs", "", "ja", http.StatusUnauthorized, i18n.T("ja", i18n.APIMissingAuthorization), "ja"},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
-			if tt.authHeader != "" {
-				req.Header.Set("Authorization", tt.authHeader)
+			w, message := get(tt.path, tt.token, tt.acceptLanguage)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
 			}
-			for k, v := range tt.headers {
-				req.Header.Set(k, v)
+			if message != tt.wantError {
+				t.Errorf("error = %q, want %q", message, tt.wantError)
 			}
-			c, _ := gin.CreateTestContext(httptest.NewRecorder())
-			c.Request = req
-
-			got := handler.getExecutedBy(c)
-			if got != tt.want {
-				t.Errorf("getExecutedBy() = %v, want %v", got, tt.want)
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
 			}
 		})
 	}
 }
 
-func TestHandler_RegisterRoutes(t *testing.T) {
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	exec := executor.NewExecutor(reg, tracker)
-	handler := NewHandler(exec)
+func TestHandler_featureOverrides(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
 
-	router := gin.New()
-	handler.RegisterRoutes(router)
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "ci:operator:op-token")
+	router, _ := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
 
-	// Test that routes are registered
-	routes := router.Routes()
-	routePaths := make(map[string]bool)
-	for _, route := range routes {
-		routePaths[route.Path] = true
+	tests := []struct {
+		name             string
+		token            string
+		header           string
+		wantStatus       int
+		wantExperimental string
+	}{
+		{"defaults", "op-token", "", http.StatusOK, "declarative"},
+		{"admin override", "test-token", "deep_dry_run,-declarative", http.StatusOK, "deep_dry_run"},
+		{"operator override", "op-token", "deep_dry_run", http.StatusForbidden, ""},
+		{"unknown flag", "test-token", "canary", http.StatusBadRequest, ""},
 	}
-
-	expectedRoutes := []string{
-		"/api/v1/migrations/up",
-		"/api/v1/migrations/down",
-		"/api/v1/migrations",
-		"/api/v1/health",
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/meta", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.header != "" {
+				req.Header.Set("X-BFM-Features", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var response dto.MetaResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if got := strings.Join(response.Features.Experimental, ","); got != tt.wantExperimental {
+				t.Errorf("experimental = %q, want %q", got, tt.wantExperimental)
+			}
+		})
 	}
+}
 
-	for _, expected := range expectedRoutes {
-		if !routePaths[expected] {
-			t.Errorf("Expected route %s to be registered", expected)
+func TestHandler_failureSummary(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	tracker := testsupport.NewStateTracker()
+	recordHistory(tracker, []*state.MigrationRecord{
+		{MigrationID: "m4", Connection: "core", Status: "failed", ErrorClass: "lock_timeout", ErrorMessage: "canceling statement due to lock timeout", AppliedAt: "2026-10-14T10:00:00Z"},
+		{MigrationID: "m3", Connection: "core", Status: "success", AppliedAt: "2026-10-14T09:00:00Z"},
+		{MigrationID: "m2", Connection: "billing", Status: "failed", ErrorClass: "syntax", ErrorMessage: "syntax error", AppliedAt: "2026-10-13T10:00:00Z"},
+		{MigrationID: "m1", Connection: "core", Status: "failed", ErrorClass: "lock_timeout", ErrorMessage: "deadlock detected", AppliedAt: "2026-10-12T10:00:00Z"},
+	})
+	router, _ := setupTestRouter(newMockRegistry(), tracker)
+
+	get := func(path string) (*httptest.ResponseRecorder, dto.FailureSummaryResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response dto.FailureSummaryResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
 		}
+		return w, response
 	}
-}
 
-func TestHandler_Options(t *testing.T) {
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	w, summary := get("/api/v1/migrations/failures/summary")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if summary.Total != 3 || len(summary.Classes) != 2 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	lock := summary.Classes[0]
+	if lock.Class != "lock_timeout" || lock.Count != 2 || !lock.Retryable || lock.LatestMigrationID != "m4" {
+		t.Errorf("unexpected lock_timeout summary %+v", lock)
+	}
+	if syntax := summary.Classes[1]; syntax.Class != "syntax" || syntax.Retryable {
+		t.Errorf("unexpected syntax summary %+v", syntax)
+	}
 
-	req, _ := http.NewRequest("OPTIONS", "/api/v1/migrations", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	if _, summary := get("/api/v1/migrations/failures/summary?connection=billing"); summary.Total != 1 || summary.Classes[0].Class != "syntax" {
+		t.Errorf("unexpected billing summary %+v", summary)
+	}
+	if w, _ := get("/api/v1/migrations/failures/summary?applied_after=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid time, got %d", w.Code)
+	}
 
-	if w.Code != http.StatusNoContent {
-		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	// History can be filtered by failure class
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/history?error_class=syntax", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"error_class":"syntax"`) || strings.Contains(w.Body.String(), `"m4"`) {
+		t.Errorf("unexpected history filtered by class: %d %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_OpenAPISpec(t *testing.T) {
+func TestHandler_EmergencyRuns(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+		_ = os.Unsetenv("BFM_EMERGENCY_TOKENS")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "ci:operator:op-token,oncall:operator:oncall-token")
+	_ = os.Setenv("BFM_EMERGENCY_TOKENS", "oncall")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;",
+	})
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
+	exec.RegisterBackend("postgresql", &mockBackend{name: "postgresql"})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
 
-	req, _ := http.NewRequest("GET", "/api/v1/openapi.yaml", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	do := func(method, path, token, emergency string, body interface{}) *httptest.ResponseRecorder {
+		var reader *bytes.Buffer
+		if body != nil {
+			encoded, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(encoded)
+		} else {
+			reader = bytes.NewBuffer(nil)
+		}
+		req, _ := http.NewRequest(method, path, reader)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		if emergency != "" {
+			req.Header.Set(EmergencyHeader, emergency)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	up := dto.MigrateUpRequest{Target: &registry.MigrationTarget{Connection: "test"}, Connection: "test"}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	if w := do("POST", "/api/v1/migrations/up", "op-token", "INC-42", up); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a token not allowed to break glass, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/v1/migrations/up", "oncall-token", " ", up); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a reason, got %d: %s", w.Code, w.Body.String())
+	}
+	if runs, _, _ := tracker.GetEmergencyRuns(context.Background(), nil); len(runs) != 0 {
+		t.Fatalf("expected refused requests not to start emergency runs, got %v", runs)
 	}
 
-	if w.Header().Get("Content-Type") != "application/x-yaml" {
-		t.Errorf("Expected Content-Type application/x-yaml, got %s", w.Header().Get("Content-Type"))
+	if w := do("POST", "/api/v1/migrations/up", "oncall-token", "INC-42: hotfix", up); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	runs, _, _ := tracker.GetEmergencyRuns(context.Background(), nil)
+	if len(runs) != 1 {
+		t.Fatalf("expected one emergency run, got %v", runs)
+	}
+	run := runs[0]
+	if run.Actor != "oncall" || run.Reason != "INC-42: hotfix" || run.Operation != "up" || run.Method != "POST /api/v1/migrations/up" {
+		t.Errorf("unexpected emergency run %+v", run)
+	}
+	if history := tracker.History(); history[len(history)-1].EmergencyRunID() != run.ID {
+		t.Errorf("expected the history to be flagged with %s, got %q", run.ID, history[len(history)-1].ExecutionContext)
 	}
 
-	if len(w.Body.Bytes()) == 0 {
-		t.Error("Expected non-empty OpenAPI spec")
+	w := do("GET", "/api/v1/emergency-runs?pending=true", "test-token", "", nil)
+	var list dto.EmergencyRunListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the emergency runs, got %d: %s", w.Code, w.Body.String())
+	}
+	if list.Total != 1 || list.Items[0].ID != run.ID || !list.Items[0].Pending || list.Items[0].Overdue {
+		t.Errorf("expected one pending run, got %+v", list)
+	}
+
+	if w := do("POST", "/api/v1/emergency-runs/"+run.ID+"/postmortem", "op-token", "", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a postmortem, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/v1/emergency-runs/emergency_0/postmortem", "op-token", "", dto.PostmortemRequest{Postmortem: "INC-42"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do("POST", "/api/v1/emergency-runs/"+run.ID+"/postmortem", "op-token", "", dto.PostmortemRequest{Postmortem: "https://wiki/INC-42"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do("GET", "/api/v1/emergency-runs/"+run.ID, "test-token", "", nil)
+	var recorded dto.EmergencyRunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &recorded); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the emergency run, got %d: %s", w.Code, w.Body.String())
+	}
+	if recorded.Pending || recorded.Postmortem != "https://wiki/INC-42" || recorded.PostmortemBy != "ci" {
+		t.Errorf("expected the postmortem by ci, got %+v", recorded)
+	}
+	if w := do("GET", "/api/v1/emergency-runs/emergency_0", "test-token", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_OpenAPISpecJSON(t *testing.T) {
+func TestHandler_RecordedRuns(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	for _, version := range []string{"20240101120000", "20240102120000"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: version, Name: "m_" + version, Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;",
+		})
+	}
+	tracker := testsupport.NewStateTracker()
+	router, exec := setupTestRouter(reg, tracker)
+	exec.RegisterBackend("postgresql", &mockBackend{name: "postgresql"})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
 
-	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		encoded, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(encoded))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(RunIDHeader, "release-2025-06")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	up := dto.MigrateUpRequest{Target: &registry.MigrationTarget{Connection: "test"}, Connection: "test"}
+	if w := do("POST", "/api/v1/migrations/up", up); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var response map[string]interface{}
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal JSON response: %v", err)
+	w := do("GET", "/api/v1/runs?connection=test", nil)
+	var list dto.RecordedRunListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the runs, got %d: %s", w.Code, w.Body.String())
+	}
+	if list.Total != 1 || list.Items[0].RunID != "release-2025-06" || list.Items[0].Applied == 0 || list.Items[0].Failed != 0 || list.Items[0].InFlight {
+		t.Fatalf("expected the run to have applied the migrations, got %+v", list)
+	}
+	if w := do("GET", "/api/v1/runs?connection=other", nil); !strings.Contains(w.Body.String(), `"total":0`) {
+		t.Errorf("expected no run on another connection, got %s", w.Body.String())
+	}
+	if w := do("GET", "/api/v1/runs?started_after=yesterday", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid time, got %d", w.Code)
 	}
 
-	// Verify it's a valid OpenAPI/Swagger spec structure
-	// Swag generates Swagger 2.0 format (uses "swagger" field)
-	// OpenAPI 3.x format uses "openapi" field
-	if _, ok := response["openapi"]; !ok {
-		if _, ok := response["swagger"]; !ok {
-			t.Error("Expected 'openapi' or 'swagger' field in response")
-		}
+	w = do("GET", "/api/v1/runs/release-2025-06", nil)
+	var detail struct {
+		Run     dto.RecordedRunResponse  `json:"run"`
+		History []map[string]interface{} `json:"history"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the run, got %d: %s", w.Code, w.Body.String())
+	}
+	if detail.Run.Records != len(detail.History) || len(detail.History) == 0 || detail.History[0]["run_id"] != "release-2025-06" {
+		t.Errorf("expected the records of the run, got %+v", detail)
+	}
+	if w := do("GET", "/api/v1/migrations/history?run_id=release-2025-06", nil); !strings.Contains(w.Body.String(), fmt.Sprintf(`"total":%d`, detail.Run.Records)) {
+		t.Errorf("expected the history of the run, got %s", w.Body.String())
+	}
+	if w := do("GET", "/api/v1/runs/run_0", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/api/v1/runs/run_0/rollback", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for rolling back an unknown run, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do("POST", "/api/v1/runs/release-2025-06/rollback?dry_run=true", nil)
+	var rollback dto.RunRollbackResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &rollback); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the rollback preview, got %d: %s", w.Code, w.Body.String())
+	}
+	if !rollback.DryRun || len(rollback.Steps) != 2 || !strings.HasPrefix(rollback.Steps[0].MigrationID, "public_20240102120000") {
+		t.Errorf("expected the latest migration to be rolled back first, got %+v", rollback)
 	}
 }
 
-func TestHandler_reindexMigrations(t *testing.T) {
-	// Save original token and SFM path
+func TestHandler_getMigrationSchemas(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
-	originalSfmPath := os.Getenv("BFM_SFM_PATH")
 	defer func() {
 		if originalToken != "" {
 			_ = os.Setenv("BFM_API_TOKEN", originalToken)
 		} else {
 			_ = os.Unsetenv("BFM_API_TOKEN")
 		}
-		if originalSfmPath != "" {
-			_ = os.Setenv("BFM_SFM_PATH", originalSfmPath)
-		} else {
-			_ = os.Unsetenv("BFM_SFM_PATH")
-		}
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
-
-	// Create a temporary directory for testing
-	tmpDir := t.TempDir()
-
-	// Set SFM path
-	_ = os.Setenv("BFM_SFM_PATH", tmpDir)
-
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/reindex", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
-	// Reindex should succeed even with empty directory
-	if w.Code != http.StatusOK && w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusOK, http.StatusInternalServerError, w.Code, w.Body.String())
-	}
-
-	if w.Code == http.StatusOK {
-		var response dto.ReindexResponse
-		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-			t.Fatalf("Failed to unmarshal response: %v", err)
-		}
-
-		// Response should have Total field
-		if response.Total < 0 {
-			t.Errorf("Expected Total >= 0, got %d", response.Total)
+	tracker := testsupport.NewStateTracker()
+	ctx := context.Background()
+	const users, orders = "20240101120000_create_users_postgresql_core", "20240102120000_create_orders_postgresql_core"
+	for _, record := range []*state.MigrationRecord{
+		{MigrationID: users, Version: "20240101120000", Schema: "tenant_a", Status: "success"},
+		{MigrationID: users, Version: "20240101120000", Schema: "tenant_b", Status: "failed"},
+		{MigrationID: orders, Version: "20240102120000", Schema: "tenant_c", Status: "success"},
+	} {
+		record.Connection, record.Backend = "core", "postgresql"
+		if err := tracker.RecordMigration(ctx, record); err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
 		}
 	}
-}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(executor.NewExecutor(newMockRegistry(), tracker)).RegisterRoutes(router)
 
-func TestHandler_reindexMigrations_Unauthorized(t *testing.T) {
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	router, _ := setupTestRouter(reg, tracker)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
 
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/reindex", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := get("/api/v1/migrations/" + users + "/schemas?schemas=tenant_d,%20tenant_a")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response dto.MigrationSchemasResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Connection != "core" || response.Applied != 1 || response.Failed != 1 || response.Pending != 2 || len(response.Schemas) != 4 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if a := response.Schemas[0]; a.Schema != "tenant_a" || a.Status != "applied" || a.Version != "20240101120000" || a.UpdatedAt == "" {
+		t.Errorf("unexpected tenant_a status %+v", a)
+	}
+	if d := response.Schemas[3]; d.Schema != "tenant_d" || d.Status != "pending" || d.UpdatedAt != "" {
+		t.Errorf("unexpected tenant_d status %+v", d)
+	}
 
-	if w.Code != http.StatusUnauthorized {
-		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	if w := get("/api/v1/migrations/20240103120000_missing_postgresql_core/schemas"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_migrateUp_ExecutorError(t *testing.T) {
-	// Save original token
+func TestHandler_Runs(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1516,71 +3574,60 @@ func TestHandler_migrateUp_ExecutorError(t *testing.T) {
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	exec := executor.NewExecutor(reg, tracker)
-	handler := NewHandler(exec)
+	router, exec := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
 
-	// Create a backend that will fail
-	mockBackend := &mockBackend{
-		name:         "postgresql",
-		connectError: errors.New("connection failed"),
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
 	}
-	exec.RegisterBackend("postgresql", mockBackend)
 
-	// Set connection config
-	connections := map[string]*backends.ConnectionConfig{
-		"test": {
-			Backend:  "postgresql",
-			Host:     "localhost",
-			Port:     "5432",
-			Database: "test",
-			Username: "test",
-			Password: "test",
-			Extra:    map[string]string{},
-		},
+	if w := do("DELETE", "/api/v1/migrations/runs/run-1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a run not in flight, got %d: %s", w.Code, w.Body.String())
 	}
-	_ = exec.SetConnections(connections)
 
-	// Register a migration
-	migration := &backends.MigrationScript{
-		Backend:    "postgresql",
-		Connection: "test",
-		Version:    "20250101000000",
-		Name:       "test_migration",
-		UpSQL:      "CREATE TABLE test (id INT);",
-		DownSQL:    "DROP TABLE test;",
+	ctx, run, err := exec.StartRun(context.Background(), "run-1", "up", "alice")
+	if err != nil {
+		t.Fatal(err)
 	}
-	_ = reg.Register(migration)
+	defer exec.FinishRun(run)
 
-	router := gin.New()
-	handler.RegisterRoutes(router)
+	w := do("GET", "/api/v1/migrations/runs")
+	var list dto.RunListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || len(list.Items) != 1 || list.Items[0].RunID != "run-1" || list.Items[0].Operation != "up" {
+		t.Fatalf("expected the run in flight, got %d: %s", w.Code, w.Body.String())
+	}
 
-	requestBody := dto.MigrateUpRequest{
-		Target: &registry.MigrationTarget{
-			Backend:    "postgresql",
-			Connection: "test",
-		},
-		Connection: "test",
-		Schemas:    []string{},
-		DryRun:     false,
+	w = do("DELETE", "/api/v1/migrations/runs/run-1")
+	var canceled dto.RunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &canceled); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
 	}
+	if w.Code != http.StatusOK || canceled.RunID != "run-1" || canceled.CanceledBy == "" {
+		t.Errorf("expected the run to be canceled, got %d: %s", w.Code, w.Body.String())
+	}
+	if !errors.Is(context.Cause(ctx), executor.ErrRunCanceled) {
+		t.Errorf("expected the run's context to be canceled, got %v", context.Cause(ctx))
+	}
+}
 
-	body, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/up", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer test-token")
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+// statementBackend reports one statement per migration it executes
+type statementBackend struct {
+	*mockBackend
+}
 
-	// Should return 500 or 206 (partial content) depending on error handling
-	if w.Code != http.StatusInternalServerError && w.Code != http.StatusPartialContent {
-		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusInternalServerError, http.StatusPartialContent, w.Code, w.Body.String())
-	}
+func (b statementBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	backends.ReportStatement(ctx, backends.StatementProgress{Statement: 1, Statements: 1})
+	backends.ReportStatement(ctx, backends.StatementProgress{Statement: 1, Statements: 1, Done: true, RowsAffected: 42})
+	return b.mockBackend.ExecuteMigration(ctx, migration)
 }
 
-func TestHandler_migrateDown_ExecutorError(t *testing.T) {
-	// Save original token
+func TestHandler_MigrateUpStream(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1592,34 +3639,64 @@ func TestHandler_migrateDown_ExecutorError(t *testing.T) {
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	exec := executor.NewExecutor(reg, tracker)
-	handler := NewHandler(exec)
-
-	router := gin.New()
-	handler.RegisterRoutes(router)
-
-	requestBody := dto.MigrateDownRequest{
-		MigrationID: "nonexistent_migration",
-		Schemas:     []string{},
-		DryRun:      false,
+	router, exec := setupTestRouter(reg, testsupport.NewStateTracker())
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "backfill_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "UPDATE users SET active = true;",
+	})
+	exec.RegisterBackend("postgresql", statementBackend{&mockBackend{name: "postgresql"}})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"test": {Backend: "postgresql", Host: "localhost"}})
+
+	do := func(req dto.MigrateUpRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest("POST", "/api/v1/migrations/up/stream", bytes.NewBuffer(body))
+		httpReq.Header.Set("Authorization", "Bearer test-token")
+		httpReq.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httpReq)
+		return w
+	}
+
+	if w := do(dto.MigrateUpRequest{Target: &registry.MigrationTarget{}, Connections: []string{"test"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for connections, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(dto.MigrateUpRequest{Target: &registry.MigrationTarget{Connection: "test"}, Connection: "test"})
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected an event stream, got %d: %s", w.Code, w.Body.String())
+	}
+	var statuses []string
+	var result dto.MigrateResponse
+	for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		name, data, _ := strings.Cut(event, "\n")
+		data = strings.TrimPrefix(data, "data:")
+		switch name {
+		case "event:progress":
+			var progress dto.MigrationProgressResponse
+			if err := json.Unmarshal([]byte(data), &progress); err != nil {
+				t.Fatalf("failed to unmarshal progress %q: %v", data, err)
+			}
+			statuses = append(statuses, progress.Status)
+			if progress.StatementDone && progress.RowsAffected != 42 {
+				t.Errorf("expected the rows affected by the statement, got %+v", progress)
+			}
+		case "event:result":
+			if err := json.Unmarshal([]byte(data), &result); err != nil {
+				t.Fatalf("failed to unmarshal result %q: %v", data, err)
+			}
+		default:
+			t.Errorf("unexpected event %q", event)
+		}
 	}
-
-	body, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/down", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer test-token")
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
-	// Should return 500 or 206 depending on error handling
-	if w.Code != http.StatusInternalServerError && w.Code != http.StatusPartialContent {
-		t.Errorf("Expected status %d or %d, got %d. Body: %s", http.StatusInternalServerError, http.StatusPartialContent, w.Code, w.Body.String())
+	if want := "running,statement,statement,success"; strings.Join(statuses, ",") != want {
+		t.Errorf("progress statuses = %v, want %s", statuses, want)
+	}
+	if !result.Success || len(result.Applied) != 1 {
+		t.Errorf("expected the result to end the stream, got %+v", result)
 	}
 }
 
-func TestHandler_listMigrations_Error(t *testing.T) {
-	// Save original token
+func TestHandler_StreamEvents(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1630,23 +3707,76 @@ func TestHandler_listMigrations_Error(t *testing.T) {
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationListError = errors.New("database error")
-	router, _ := setupTestRouter(reg, tracker)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	exec := executor.NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
+	handler := NewHandler(exec)
+	handler.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(ctx context.Context, query string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/migrations/events"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	resp := get(context.Background(), "?types=migration.applied,emergency.started")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an event type that is not streamed, got %d", resp.StatusCode)
+	}
 
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := get(ctx, "?connection=core&types=migration.started,migration.applied")
+	defer func() { _ = stream.Body.Close() }()
+	if stream.StatusCode != http.StatusOK || stream.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", stream.StatusCode, stream.Header.Get("Content-Type"))
+	}
+
+	// The stream subscribed before answering, so every event below reaches it
+	bus := exec.Events()
+	bus.Publish(ctx, events.Event{Type: events.MigrationApplied, MigrationID: "m0", Connection: "guard"})
+	bus.Publish(ctx, events.Event{Type: events.EmergencyStarted, Connection: "core"})
+	bus.Publish(ctx, events.Event{Type: events.MigrationFailed, MigrationID: "m1", Connection: "core"})
+	bus.Publish(ctx, events.Event{Type: events.MigrationApplied, MigrationID: "m2", Connection: "core", Data: map[string]interface{}{"elapsed_ms": 12}})
+
+	scanner := bufio.NewScanner(stream.Body)
+	var lines []string
+	for scanner.Scan() && len(lines) < 2 {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 2 || lines[0] != "event:migration.applied" {
+		t.Fatalf("expected only the applied migration of core, got %q", lines)
+	}
+	var event dto.EventResponse
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data:")), &event); err != nil {
+		t.Fatalf("failed to unmarshal event %q: %v", lines[1], err)
+	}
+	if event.MigrationID != "m2" || event.Connection != "core" || event.Data["elapsed_ms"] != float64(12) || event.Time == "" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	// Shutting down ends the stream and refuses new ones
+	handler.CloseStreams()
+	if _, err := io.Copy(io.Discard, stream.Body); err != nil {
+		t.Errorf("expected the stream to end, got %v", err)
+	}
+	resp = get(context.Background(), "")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 once streams are closed, got %d", resp.StatusCode)
 	}
 }
 
-func TestHandler_getMigration_StateTrackerError(t *testing.T) {
-	// Save original token
+func TestHandler_StateExportImport(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1657,36 +3787,72 @@ func TestHandler_getMigration_StateTrackerError(t *testing.T) {
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	source := testsupport.NewStateTracker()
+	recordHistory(source, []*state.MigrationRecord{
+		{MigrationID: "public_20240101120000_create_users_postgresql_core", Schema: "public", Version: "20240101120000", Connection: "core", Backend: "postgresql", AppliedAt: "2024-01-01T12:00:00Z", Status: "success"},
+	})
+	sourceRouter, _ := setupTestRouter(newMockRegistry(), source)
 
-	// Register a migration
-	migration := &backends.MigrationScript{
-		Backend:    "postgresql",
-		Connection: "test",
-		Version:    "20250101000000",
-		Name:       "test_migration",
-		UpSQL:      "CREATE TABLE test (id INT);",
-		DownSQL:    "DROP TABLE test;",
+	do := func(router *gin.Engine, method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
 	}
-	_ = reg.Register(migration)
 
-	tracker.isMigrationAppliedError = errors.New("database error")
-	router, _ := setupTestRouter(reg, tracker)
+	w := do(sourceRouter, "GET", "/api/v1/admin/state/export", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for the export, got %d: %s", w.Code, w.Body.String())
+	}
+	var bundle state.Bundle
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("failed to unmarshal bundle: %v", err)
+	}
+	if len(bundle.Migrations) != 1 || len(bundle.History) != 1 {
+		t.Fatalf("expected a migration with its history, got %s", w.Body.String())
+	}
 
-	migrationID := reg.getMigrationID(migration)
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/"+migrationID, nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	target := testsupport.NewStateTracker()
+	targetRouter, _ := setupTestRouter(newMockRegistry(), target)
+	w = do(targetRouter, "POST", "/api/v1/admin/state/import", w.Body.Bytes())
+	var result state.ImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || result.Migrations != 1 || result.History != 1 || len(target.History()) != 1 {
+		t.Fatalf("expected the bundle to be imported, got %d: %s", w.Code, w.Body.String())
+	}
 
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	data, _ := json.Marshal(bundle)
+	if w := do(targetRouter, "POST", "/api/v1/admin/state/import", data); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for an import into a state with history, got %d: %s", w.Code, w.Body.String())
+	}
+	bundle.FormatVersion = 99
+	data, _ = json.Marshal(bundle)
+	if w := do(sourceRouter, "POST", "/api/v1/admin/state/import", data); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unsupported format version, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestHandler_getMigrationStatus_Error(t *testing.T) {
-	// Save original token
+// inspectorBackend is a mock backend whose databases have fixed schemas, by database name
+type inspectorBackend struct {
+	mockBackend
+	databases map[string]*schemadiff.Schema
+	database  string
+}
+
+func (m *inspectorBackend) Connect(config *backends.ConnectionConfig) error {
+	m.database = config.Database
+	return m.mockBackend.Connect(config)
+}
+
+func (m *inspectorBackend) InspectSchema(ctx context.Context, schemas []string) (*schemadiff.Schema, error) {
+	return m.databases[m.database], nil
+}
+
+func TestHandler_DiffSchema(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1697,23 +3863,56 @@ func TestHandler_getMigrationStatus_Error(t *testing.T) {
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationHistoryError = errors.New("database error")
-	router, _ := setupTestRouter(reg, tracker)
+	router, exec := setupTestRouter(newMockRegistry(), testsupport.NewStateTracker())
+	orders := &schemadiff.Table{Schema: "public", Name: "orders", Columns: []*schemadiff.Column{{Name: "id", Type: "bigint", NotNull: true}}}
+	exec.RegisterBackend("postgresql", &inspectorBackend{
+		mockBackend: mockBackend{name: "postgresql"},
+		databases:   map[string]*schemadiff.Schema{"prod": schemadiff.NewSchema(), "dev": schemadiff.NewSchema(orders)},
+	})
+	exec.RegisterBackend("etcd", &mockBackend{name: "etcd"})
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core":   {Backend: "postgresql", Database: "prod"},
+		"dev":    {Backend: "postgresql", Database: "dev"},
+		"config": {Backend: "etcd"},
+	})
 
-	req, _ := http.NewRequest("GET", "/api/v1/migrations/test_migration/status", nil)
-	req.Header.Set("Authorization", "Bearer test-token")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	do := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/migrations/diff?"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
 
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	w := do("connection=core&reference=dev")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response dto.SchemaDiffResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !response.HasChanges || len(response.Up) != 1 || !strings.HasPrefix(response.Up[0], "CREATE TABLE public.orders") ||
+		!slices.Equal(response.Down, []string{"DROP TABLE public.orders;"}) || !slices.Equal(response.Schemas, []string{"public"}) {
+		t.Errorf("unexpected diff %s", w.Body.String())
+	}
+	if !strings.Contains(response.UpSQL, "-- Generated by bfm diff") || !strings.Contains(response.DownSQL, "DROP TABLE public.orders;") {
+		t.Errorf("expected the scripts to hold the statements, got %s", w.Body.String())
+	}
+
+	for query, status := range map[string]int{
+		"reference=dev":                    http.StatusBadRequest,
+		"connection=core":                  http.StatusBadRequest,
+		"connection=core&reference=qa":     http.StatusNotFound,
+		"connection=config&reference=core": http.StatusBadRequest,
+	} {
+		if w := do(query); w.Code != status {
+			t.Errorf("%s: expected status %d, got %d: %s", query, status, w.Code, w.Body.String())
+		}
 	}
 }
 
-func TestHandler_rollbackMigration_ExecutorError(t *testing.T) {
-	// Save original token
+func TestHandler_ListConflicts(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
 		if originalToken != "" {
@@ -1724,34 +3923,40 @@ func TestHandler_rollbackMigration_ExecutorError(t *testing.T) {
 	}()
 
 	_ = os.Setenv("BFM_API_TOKEN", "test-token")
-	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-
-	// Register a migration
-	migration := &backends.MigrationScript{
-		Backend:    "postgresql",
-		Connection: "test",
-		Version:    "20250101000000",
-		Name:       "test_migration",
-		UpSQL:      "CREATE TABLE test (id INT);",
-		DownSQL:    "DROP TABLE test;",
+	reg := registry.NewInMemoryRegistry()
+	exec := executor.NewExecutor(reg, testsupport.NewStateTracker())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(exec).RegisterRoutes(router)
+
+	root := t.TempDir()
+	for _, base := range []string{"20240101120000_create_accounts", "20240101120000_create_users"} {
+		for _, ext := range []string{".up.sql", ".down.sql"} {
+			path := filepath.Join(root, "postgresql", "core", base+ext)
+			_ = os.MkdirAll(filepath.Dir(path), 0o755)
+			_ = os.WriteFile(path, []byte("SELECT 1;"), 0o600)
+		}
+	}
+	loader := executor.NewLoader(root)
+	loader.SetExecutor(exec)
+	loader.SetSource(executor.SourceScripts)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
 	}
-	_ = reg.Register(migration)
-
-	// Mark as applied
-	migrationID := reg.getMigrationID(migration)
-	tracker.appliedMigrations[migrationID] = true
-
-	// Make rollback fail
-	tracker.isMigrationAppliedError = errors.New("database error")
-	router, _ := setupTestRouter(reg, tracker)
 
-	req, _ := http.NewRequest("POST", "/api/v1/migrations/"+migrationID+"/rollback", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/migrations/conflicts", nil)
 	req.Header.Set("Authorization", "Bearer test-token")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
-
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status %d, got %d. Body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response dto.MigrationConflictsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Total != 1 || response.Items[0].Kind != registry.ConflictVersion || response.Items[0].Value != "20240101120000" ||
+		response.Items[0].MigrationID != "20240101120000_create_users_postgresql_core" || response.Items[0].ExistingID != "20240101120000_create_accounts_postgresql_core" {
+		t.Errorf("unexpected conflicts %s", w.Body.String())
 	}
 }
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 500
+)
+
+// getJob gets the status of an async job
+// @Summary      Get an async job
+// @Description  Gets the status of a job queued by an up request when the queue is enabled (the job_id of its response), or scheduled with schedule_at: scheduled, queued, picked_up, running, retrying, completed, failed, dead_lettered or cancelled, with the worker that picked it up, its attempts and, once finished, the applied and skipped migrations and errors.
+// @Tags         jobs
+// @Produce      json
+// @Param        id path string true "Job ID"
+// @Success      200 {object} dto.JobResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Job not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /jobs/{id} [get]
+func (h *Handler) getJob(c *gin.Context) {
+	job, err := h.executor.GetJob(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, state.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, jobResponse(job))
+}
+
+// listJobs lists async jobs
+// @Summary      List async jobs
+// @Description  Lists jobs queued by up requests when the queue is enabled, most recently queued first, e.g. status=dead_lettered for the contents of the dead-letter queue.
+// @Tags         jobs
+// @Produce      json
+// @Param        status query string false "Filter by status" Enums(scheduled, queued, picked_up, running, retrying, completed, failed, dead_lettered, cancelled)
+// @Param        connection query string false "Filter by connection"
+// @Param        limit query int false "Maximum number of jobs (max 500)" default(50)
+// @Param        offset query int false "Number of jobs to skip" default(0)
+// @Success      200 {object} dto.JobListResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid filter"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /jobs [get]
+func (h *Handler) listJobs(c *gin.Context) {
+	filters := &state.JobFilters{
+		Status:     c.Query("status"),
+		Connection: c.Query("connection"),
+		Limit:      defaultJobLimit,
+	}
+	switch filters.Status {
+	case "", state.JobScheduled, state.JobQueued, state.JobPickedUp, state.JobRunning, state.JobRetrying, state.JobCompleted,
+		state.JobFailed, state.JobDeadLettered, state.JobCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidJobStatus)})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxJobLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxJobLimit)})
+			return
+		}
+		filters.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidOffset)})
+			return
+		}
+		filters.Offset = offset
+	}
+
+	jobs, total, err := h.executor.GetJobs(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]dto.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, jobResponse(job))
+	}
+	c.JSON(http.StatusOK, dto.JobListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+}
+
+// replayJob publishes a failed or dead-lettered job to the queue again
+// @Summary      Replay a failed job
+// @Description  Publishes a failed or dead-lettered job to the queue again under the same ID, e.g. once the cause of the failure is fixed; its status starts over from queued.
+// @Tags         jobs
+// @Produce      json
+// @Param        id path string true "Job ID"
+// @Success      202 {object} dto.JobResponse "Queued again"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Job not found"
+// @Failure      409 {object} map[string]interface{} "The job did not fail, or the queue is not enabled"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /jobs/{id}/replay [post]
+func (h *Handler) replayJob(c *gin.Context) {
+	job, err := h.executor.ReplayJob(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, state.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, executor.ErrJobNotReplayable), errors.Is(err, executor.ErrQueueDisabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, jobResponse(job))
+}
+
+// jobResponse converts a job to the response format
+func jobResponse(job *state.Job) dto.JobResponse {
+	response := dto.JobResponse{
+		ID:          job.ID,
+		Status:      job.Status,
+		Connection:  job.Connection,
+		Schema:      job.Schema,
+		DryRun:      job.DryRun,
+		Worker:      job.Worker,
+		Attempts:    job.Attempts,
+		Applied:     nonNilList(job.Applied),
+		Skipped:     nonNilList(job.Skipped),
+		Errors:      nonNilList(job.Errors),
+		QueuedAt:    job.QueuedAt,
+		ScheduledAt: job.ScheduledAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+	if job.Target != "" {
+		var target registry.MigrationTarget
+		if err := json.Unmarshal([]byte(job.Target), &target); err == nil {
+			response.Target = &target
+		}
+	}
+	return response
+}
+
+// nonNilList returns values, or an empty list for nil so it is encoded as [] rather than null
+func nonNilList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
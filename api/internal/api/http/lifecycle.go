@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Livez handles liveness probes
+// @Summary      Liveness probe
+// @Description  Reports that the server process is up and serving HTTP. It checks no dependency, so an unreachable state database does not get the server restarted; use /readyz to decide whether to send it traffic. Also served at /livez.
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Alive"
+// @Router       /livez [get]
+func (h *Handler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// Readyz handles readiness probes
+// @Summary      Readiness probe
+// @Description  Reports whether the server can serve requests: the initial scan of the migration directories has finished, the state database is reachable and, when the queue is enabled, so is its broker. Each check is reported as ok or with why it failed. Also served at /readyz.
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.ReadinessResponse "Ready"
+// @Failure      503 {object} dto.ReadinessResponse "Not ready"
+// @Router       /readyz [get]
+func (h *Handler) Readyz(c *gin.Context) {
+	response := dto.ReadinessResponse{Status: "ready", Checks: make(map[string]string)}
+	for _, check := range h.executor.Readiness(c.Request.Context()) {
+		if check.Err != nil {
+			response.Status = "not_ready"
+			response.Checks[check.Name] = errorMessage(c, check.Err)
+			continue
+		}
+		response.Checks[check.Name] = "ok"
+	}
+
+	status := http.StatusOK
+	if response.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// RequireStarted is a middleware that refuses requests with 503 until the server has finished
+// loading migrations (see executor.SetStarting), so none is served from a partial registry
+func (h *Handler) RequireStarted(c *gin.Context) {
+	if h.executor.IsStarting() {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errorMessage(c, executor.ErrStarting)})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
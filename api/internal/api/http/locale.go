@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localized returns the catalog message id in the locale of the request: the best match of its
+// Accept-Language header, else BFM_LOCALE. The locale is reported in Content-Language.
+func localized(c *gin.Context, id string, args ...interface{}) string {
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locale)
+	return i18n.T(locale, id, args...)
+}
+
+// errorMessages are the catalog messages of errors returned as is, without added context
+var errorMessages = map[error]string{
+	auth.ErrMissingAuthorization:  i18n.APIMissingAuthorization,
+	auth.ErrInvalidAuthorization:  i18n.APIInvalidAuthorization,
+	auth.ErrBearerRequired:        i18n.APIBearerRequired,
+	auth.ErrInvalidToken:          i18n.APIInvalidToken,
+	executor.ErrStandby:           i18n.APIStandby,
+	executor.ErrStarting:          i18n.APIStarting,
+	features.ErrOverrideForbidden: i18n.APIFeatureOverrideDenied,
+	state.ErrStateNotEmpty:        i18n.APIStateNotEmpty,
+}
+
+// errorMessage returns the message of err in the locale of the request if it has a catalog
+// message, else err's own message
+func errorMessage(c *gin.Context, err error) string {
+	if id, ok := errorMessages[err]; ok {
+		return localized(c, id)
+	}
+	return err.Error()
+}
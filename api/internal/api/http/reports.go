@@ -0,0 +1,170 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startTenantStatusReport starts generating the tenant status report of a connection
+// @Summary      Generate a tenant status report
+// @Description  Starts generating, in the background, the status of each migration of a connection in each schema it was executed in, from the executions in the state database read in one query: a row per schema with its counts of applied, failed and pending migrations, and a column per migration, by version. schemas adds tenants nothing ran in yet. Poll the returned report until it is completed, then fetch its download_url, as CSV (default) or JSON. Reports are kept for an hour by the instance that generated them.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.TenantStatusReportRequest true "Report request"
+// @Success      202 {object} dto.ReportResponse "Generating"
+// @Failure      400 {object} map[string]interface{} "Bad request: unknown connection or format, or an invalid schema"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Security     Bearer
+// @Router       /reports/tenant-status [post]
+func (h *Handler) startTenantStatusReport(c *gin.Context) {
+	var req dto.TenantStatusReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.executor.StartTenantStatusReport(c.Request.Context(), req.Connection, req.Schemas, req.Format)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, executor.ErrInvalidReport) || errors.Is(err, executor.ErrInvalidSchema) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Location", "/api/v1/reports/"+report.ID)
+	c.JSON(http.StatusAccepted, reportResponse(report))
+}
+
+// getReport gets the status of a report
+// @Summary      Get a report
+// @Description  Gets the status of a report generated in the background: pending, completed (with its download_url) or failed (with the error).
+// @Tags         reports
+// @Produce      json
+// @Param        id path string true "Report ID"
+// @Success      200 {object} dto.ReportResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Report not found, or expired"
+// @Security     Bearer
+// @Router       /reports/{id} [get]
+func (h *Handler) getReport(c *gin.Context) {
+	report, err := h.executor.GetReport(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reportResponse(report))
+}
+
+// downloadReport downloads a completed report
+// @Summary      Download a report
+// @Description  Downloads a completed report as an attachment, in the format it was requested in. A report still pending is a 409 with Retry-After.
+// @Tags         reports
+// @Produce      text/csv
+// @Produce      json
+// @Param        id path string true "Report ID"
+// @Success      200 {object} dto.TenantStatusReport "The report (JSON, or CSV with a column per migration)"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Report not found, or expired"
+// @Failure      409 {object} map[string]interface{} "The report is pending, or failed"
+// @Security     Bearer
+// @Router       /reports/{id}/download [get]
+func (h *Handler) downloadReport(c *gin.Context) {
+	report, err := h.executor.GetReport(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	switch report.Status {
+	case executor.ReportPending:
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("report %s is still being generated", report.ID)})
+		return
+	case executor.ReportFailed:
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("report %s failed: %s", report.ID, report.Error)})
+		return
+	}
+
+	filename := fmt.Sprintf("tenant-status-%s-%s.%s", report.Connection, report.FinishedAt.UTC().Format("20060102T150405Z"), report.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	matrix := report.TenantStatus
+	if report.Format == executor.ReportFormatJSON {
+		response := dto.TenantStatusReport{
+			Connection:  report.Connection,
+			GeneratedAt: report.FinishedAt.UTC().Format(time.RFC3339),
+			Migrations:  make([]string, 0, len(matrix.Migrations)),
+			Schemas:     make([]dto.TenantStatusReportRow, 0, len(matrix.Schemas)),
+		}
+		for _, migration := range matrix.Migrations {
+			response.Migrations = append(response.Migrations, migration.MigrationID)
+		}
+		for _, row := range tenantStatusRows(matrix) {
+			statuses := make(map[string]string)
+			for i, status := range row.Statuses {
+				if status != "" {
+					statuses[response.Migrations[i]] = status
+				}
+			}
+			response.Schemas = append(response.Schemas, dto.TenantStatusReportRow{
+				Schema: row.Schema, Applied: row.Applied, Failed: row.Failed, Pending: row.Pending, Statuses: statuses,
+			})
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var buf bytes.Buffer
+	migrationIDs := make([]string, 0, len(matrix.Migrations))
+	for _, migration := range matrix.Migrations {
+		migrationIDs = append(migrationIDs, migration.MigrationID)
+	}
+	if err := export.WriteTenantStatus(&buf, migrationIDs, tenantStatusRows(matrix)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// tenantStatusRows converts a tenant status matrix to rows, one per schema
+func tenantStatusRows(matrix *executor.TenantStatusMatrix) []export.TenantStatusRow {
+	rows := make([]export.TenantStatusRow, 0, len(matrix.Schemas))
+	for _, schema := range matrix.Schemas {
+		row := export.TenantStatusRow{Schema: schema, Statuses: make([]string, 0, len(matrix.Migrations))}
+		row.Applied, row.Failed, row.Pending = matrix.Counts(schema)
+		for _, migration := range matrix.Migrations {
+			row.Statuses = append(row.Statuses, matrix.Status(schema, migration.MigrationID))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// reportResponse converts a report to the response format
+func reportResponse(report *executor.Report) dto.ReportResponse {
+	response := dto.ReportResponse{
+		ID:         report.ID,
+		Type:       report.Type,
+		Connection: report.Connection,
+		Format:     report.Format,
+		Status:     report.Status,
+		Error:      report.Error,
+		CreatedAt:  report.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !report.FinishedAt.IsZero() {
+		response.FinishedAt = report.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	if report.Status == executor.ReportCompleted {
+		response.DownloadURL = "/api/v1/reports/" + report.ID + "/download"
+	}
+	return response
+}
@@ -0,0 +1,283 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunIDHeader is the request header naming the run of an up, down or rollback request, so that it
+// can be canceled while the request is in flight; a run ID is generated without it. The response
+// carries the run ID in the same header.
+const RunIDHeader = "X-BFM-Run-ID"
+
+// trackRun returns a middleware that executes the request as a run of operation (see
+// executor.StartRun), which DELETE /migrations/runs/{run_id} cancels. A run ID already in flight
+// gets 409.
+func (h *Handler) trackRun(operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader(RunIDHeader))
+		ctx, run, err := h.executor.StartRun(c.Request.Context(), id, operation, h.getExecutedBy(c))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, executor.ErrRunExists) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		defer h.executor.FinishRun(run)
+
+		c.Header(RunIDHeader, run.ID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// listRuns lists the runs in flight
+// @Summary      List runs in flight
+// @Description  Lists the synchronous up, down and rollback requests this server is executing, oldest first. Queued jobs run by workers are not listed (see /jobs).
+// @Tags         migrations
+// @Produce      json
+// @Success      200 {object} dto.RunListResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Security     Bearer
+// @Router       /migrations/runs [get]
+func (h *Handler) listRuns(c *gin.Context) {
+	runs := h.executor.Runs()
+	response := dto.RunListResponse{Items: make([]dto.RunResponse, 0, len(runs))}
+	for _, run := range runs {
+		response.Items = append(response.Items, runResponse(run))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// cancelRun cancels a run in flight
+// @Summary      Cancel a run in flight
+// @Description  Cancels a synchronous up, down or rollback request, named by the X-BFM-Run-ID header it was sent with or found in /migrations/runs: the statement running is interrupted, its migration is recorded as failed, and the migrations not started yet are not run. The request then returns with the errors. Only the server executing the run can cancel it.
+// @Tags         migrations
+// @Produce      json
+// @Param        run_id path string true "Run ID"
+// @Success      200 {object} dto.RunResponse "Canceled"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "No such run in flight on this server"
+// @Security     Bearer
+// @Router       /migrations/runs/{run_id} [delete]
+func (h *Handler) cancelRun(c *gin.Context) {
+	run, err := h.executor.CancelRun(c.Param("run_id"), h.getExecutedBy(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, runResponse(run))
+}
+
+// runResponse converts a run to the response format
+func runResponse(run executor.Run) dto.RunResponse {
+	return dto.RunResponse{
+		RunID:      run.ID,
+		Operation:  run.Operation,
+		StartedBy:  run.StartedBy,
+		StartedAt:  run.StartedAt.Format(time.RFC3339),
+		CanceledBy: run.CanceledBy,
+	}
+}
+
+// listRecordedRuns lists the runs recorded in history
+// @Summary      List recorded runs
+// @Description  Lists the runs recorded in history, most recent first: the migrations each up, down or rollback invocation executed together, identified by the run_id stamped on their history records. Records created before runs were recorded belong to no run.
+// @Tags         runs
+// @Produce      json
+// @Param        connection query string false "Runs with records on this connection"
+// @Param        started_after query string false "Runs with records applied at or after this RFC 3339 time"
+// @Param        started_before query string false "Runs with records applied before this RFC 3339 time"
+// @Param        limit query int false "Maximum number of runs (max 500)" default(50)
+// @Param        offset query int false "Number of runs to skip" default(0)
+// @Success      200 {object} dto.RecordedRunListResponse "Success"
+// @Failure      400 {object} map[string]interface{} "Invalid filter"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /runs [get]
+func (h *Handler) listRecordedRuns(c *gin.Context) {
+	filters := &state.RunFilters{
+		Connection: c.Query("connection"),
+		Limit:      defaultJobLimit,
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"started_after", &filters.StartedAfter},
+		{"started_before", &filters.StartedBefore},
+	} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.InvalidTime, bound.name)})
+			return
+		}
+		*bound.t = parsed
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxJobLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidLimit, maxJobLimit)})
+			return
+		}
+		filters.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIInvalidOffset)})
+			return
+		}
+		filters.Offset = offset
+	}
+
+	runs, total, err := h.executor.GetRecordedRuns(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	inFlight := h.runsInFlight()
+	items := make([]dto.RecordedRunResponse, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, recordedRunResponse(run, inFlight))
+	}
+	c.JSON(http.StatusOK, dto.RecordedRunListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+}
+
+// getRecordedRun gets a run recorded in history
+// @Summary      Get a recorded run
+// @Description  Gets a run recorded in history and the history records it created, oldest first, e.g. the migrations a release applied together.
+// @Tags         runs
+// @Produce      json
+// @Param        id path string true "Run ID"
+// @Success      200 {object} map[string]interface{} "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Run not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /runs/{id} [get]
+func (h *Handler) getRecordedRun(c *gin.Context) {
+	run, records, err := h.executor.GetRecordedRun(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, executor.ErrRunNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"run":     recordedRunResponse(run, h.runsInFlight()),
+		"history": historyItems(records),
+	})
+}
+
+// rollbackRecordedRun rolls back the migrations a recorded run applied
+// @Summary      Roll back a recorded run
+// @Description  Rolls back the migrations a recorded run applied, with their down migrations, the most recent first and always before the migrations of the run they depend on, e.g. to revert a release. Migrations already rolled back are skipped. With dry_run (in the body or the query), the down migrations are only previewed, and migrations that migrations applied outside the run still depend on are reported. Otherwise the rollback stops at the first migration that fails, with 206.
+// @Tags         runs
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Run ID"
+// @Param        dry_run query bool false "Preview the rollback"
+// @Param        request body dto.RunRollbackRequest false "Run rollback request"
+// @Success      200 {object} dto.RunRollbackResponse "Success"
+// @Success      206 {object} dto.RunRollbackResponse "A migration could not be rolled back"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
+// @Failure      404 {object} map[string]interface{} "Run not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
+// @Security     Bearer
+// @Router       /runs/{id}/rollback [post]
+func (h *Handler) rollbackRecordedRun(c *gin.Context) {
+	var req dto.RunRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		req = dto.RunRollbackRequest{}
+	}
+	if dryRun, err := strconv.ParseBool(c.Query("dry_run")); err == nil && dryRun {
+		req.DryRun = true
+	}
+
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	rollback, err := h.executor.RollbackRun(ctx, c.Param("id"), req.DryRun)
+	if errors.Is(err, executor.ErrRunNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	steps := cascadedRollbackResponses(rollback.Steps)
+	if steps == nil {
+		steps = []dto.CascadedRollbackResponse{}
+	}
+	statusCode := http.StatusOK
+	if !rollback.Success {
+		statusCode = http.StatusPartialContent
+	}
+	c.JSON(statusCode, dto.RunRollbackResponse{
+		RunID:   rollback.RunID,
+		Success: rollback.Success,
+		DryRun:  req.DryRun,
+		Steps:   steps,
+	})
+}
+
+// runsInFlight returns the IDs of the runs in flight on this server
+func (h *Handler) runsInFlight() []string {
+	var ids []string
+	for _, run := range h.executor.Runs() {
+		ids = append(ids, run.ID)
+	}
+	return ids
+}
+
+// recordedRunResponse converts a recorded run to the response format
+func recordedRunResponse(run *state.RunSummary, inFlight []string) dto.RecordedRunResponse {
+	return dto.RecordedRunResponse{
+		RunID:           run.RunID,
+		StartedAt:       run.StartedAt.Format(time.RFC3339),
+		FinishedAt:      run.FinishedAt.Format(time.RFC3339),
+		ExecutedBy:      run.ExecutedBy,
+		ExecutionMethod: run.ExecutionMethod,
+		Operations:      run.Operations,
+		Connections:     run.Connections,
+		Records:         run.Records,
+		Applied:         run.Applied,
+		Failed:          run.Failed,
+		RolledBack:      run.RolledBack,
+		InFlight:        slices.Contains(inFlight, run.RunID),
+	}
+}
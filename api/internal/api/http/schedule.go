@@ -0,0 +1,112 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scheduleUp schedules an up request with schedule_at instead of executing it
+func (h *Handler) scheduleUp(c *gin.Context, req *dto.MigrateUpRequest) {
+	runAt, err := time.Parse(time.RFC3339, req.ScheduleAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.InvalidTime, "schedule_at")})
+		return
+	}
+	if len(req.Connections) > 0 || len(req.MigrationIDs) > 0 || req.IgnoreDependencies || req.CaptureSQL ||
+		(req.Target != nil && req.Target.SchemaPattern != "") || c.Value(emergencyKey) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIScheduleUnsupported, EmergencyHeader)})
+		return
+	}
+
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	ctx = executor.WithPinnedChecksums(ctx, req.PinnedChecksums)
+	if req.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
+	jobs, err := h.executor.ScheduleUp(ctx, req.Target, req.Connection, req.Schemas, req.DryRun, runAt)
+	if err != nil {
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	window := h.executor.MaintenanceWindow()
+	response := dto.ScheduleResponse{
+		Jobs:              make([]dto.JobResponse, 0, len(jobs)),
+		RunsAt:            window.Next(runAt).UTC().Format(time.RFC3339),
+		MaintenanceWindow: window.String(),
+	}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, jobResponse(job))
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// listScheduledJobs lists the runs waiting for their scheduled time
+// @Summary      List scheduled runs
+// @Description  Lists the jobs scheduled by up requests with schedule_at that have not started yet, soonest first, with the maintenance window they start in. Started runs are listed by /jobs.
+// @Tags         jobs
+// @Produce      json
+// @Param        connection query string false "Filter by connection"
+// @Success      200 {object} dto.ScheduledJobListResponse "Success"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /migrations/scheduled [get]
+func (h *Handler) listScheduledJobs(c *gin.Context) {
+	jobs, err := h.executor.GetScheduledJobs(c.Request.Context(), c.Query("connection"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := dto.ScheduledJobListResponse{
+		Items:             make([]dto.JobResponse, 0, len(jobs)),
+		MaintenanceWindow: h.executor.MaintenanceWindow().String(),
+	}
+	for _, job := range jobs {
+		response.Items = append(response.Items, jobResponse(job))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// cancelScheduledJob cancels a run waiting for its scheduled time
+// @Summary      Cancel a scheduled run
+// @Description  Cancels a job scheduled by an up request with schedule_at before it starts; it is kept with status cancelled. A run that already started cannot be cancelled.
+// @Tags         jobs
+// @Produce      json
+// @Param        id path string true "Job ID"
+// @Success      200 {object} dto.JobResponse "Cancelled"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Job not found"
+// @Failure      409 {object} map[string]interface{} "The job is not scheduled: it already started, or was cancelled"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
+// @Security     Bearer
+// @Router       /migrations/scheduled/{id} [delete]
+func (h *Handler) cancelScheduledJob(c *gin.Context) {
+	job, err := h.executor.CancelScheduledJob(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, state.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, executor.ErrJobNotCancellable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(executionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, jobResponse(job))
+}
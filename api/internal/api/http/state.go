@@ -0,0 +1,71 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportState exports the migration state as a portable bundle
+// @Summary      Export the migration state
+// @Description  Exports migrations_list, migrations_history and migrations_executions of the state database as a portable JSON bundle, to import into another state database with POST /admin/state/import: to move bfm between environments, or to rebuild a lost state database from the export of a replica. History is exported oldest first. Requires an admin token.
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} state.Bundle "The state bundle"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /admin/state/export [get]
+func (h *Handler) exportState(c *gin.Context) {
+	bundle, err := state.ExportBundle(c.Request.Context(), h.executor.GetStateTracker())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filename := fmt.Sprintf("bfm-state-%s.json", time.Now().UTC().Format("20060102T150405Z"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// importState imports a state bundle
+// @Summary      Import a migration state bundle
+// @Description  Imports a bundle exported by GET /admin/state/export. The state database must have no migration history; migrations registered by the scan of the migration directories are fine. History is replayed oldest first, so the list and executions end as they were exported, with their checksums and status labels. Requires an admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body state.Bundle true "The state bundle"
+// @Success      200 {object} state.ImportResult "What was imported"
+// @Failure      400 {object} map[string]interface{} "Invalid bundle or unsupported format version"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an admin token"
+// @Failure      409 {object} map[string]interface{} "The state database already has migration history"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     Bearer
+// @Router       /admin/state/import [post]
+func (h *Handler) importState(c *gin.Context) {
+	var bundle state.Bundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := state.ImportBundle(c.Request.Context(), h.executor.GetStateTracker(), &bundle)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, state.ErrStateNotEmpty):
+			status = http.StatusConflict
+		case errors.Is(err, state.ErrUnsupportedBundle):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": errorMessage(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
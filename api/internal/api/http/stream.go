@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/api/http/dto"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// auditStatusKey holds the status a streamed request would have been answered with
+	auditStatusKey = "audit_status"
+	// auditErrorKey holds the error a streamed request ended with
+	auditErrorKey = "audit_error"
+)
+
+// migrateUpStream handles up migration requests streaming their progress
+// @Summary      Execute up migrations, streaming progress
+// @Description  Executes an up request like POST /migrations/up and streams its progress as server-sent events: a progress event when each migration starts (running), when each statement starts and completes (statement, with rows_affected and elapsed_ms once completed; PostgreSQL only) and when the migration finishes (success or failed, with elapsed_ms). Skipped migrations send no events. The stream ends with a result event carrying the MigrateResponse, or an error event when the request was refused once started. Requires connection; connections, migration_ids and schedule_at are not supported.
+// @Tags         migrations
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        request body dto.MigrateUpRequest true "Migration request"
+// @Success      200 {object} dto.MigrationProgressResponse "Event stream of progress events, then a result event (dto.MigrateResponse)"
+// @Failure      400 {object} map[string]interface{} "Bad request, or a schema name the schema policy refuses"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden: requires an operator or admin token"
+// @Failure      503 {object} map[string]interface{} "Server is in standby"
+// @Security     Bearer
+// @Router       /migrations/up/stream [post]
+func (h *Handler) migrateUpStream(c *gin.Context) {
+	var req dto.MigrateUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Connection == "" || len(req.Connections) > 0 || len(req.MigrationIDs) > 0 || req.ScheduleAt != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIStreamUnsupported)})
+		return
+	}
+	if !h.validateUpTarget(c, req.Target) {
+		return
+	}
+
+	ctx, ok := h.setExecutionContext(c)
+	if !ok {
+		return
+	}
+	if req.CaptureSQL {
+		ctx = executor.WithCaptureSQL(ctx)
+	}
+	ctx = executor.WithPinnedChecksums(ctx, req.PinnedChecksums)
+	if req.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
+	ctx = executor.WithSchemaConcurrency(ctx, req.Concurrency)
+
+	// Concurrent schemas report from several goroutines
+	var mu sync.Mutex
+	send := func(event string, data interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep proxies from buffering the events
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx = executor.WithProgress(ctx, func(p executor.Progress) {
+		send("progress", dto.MigrationProgressResponse{
+			MigrationID:   p.MigrationID,
+			Schema:        p.Schema,
+			Status:        p.Status,
+			Message:       p.Message,
+			Statement:     p.Statement,
+			Statements:    p.Statements,
+			StatementDone: p.Done,
+			RowsAffected:  p.RowsAffected,
+			ElapsedMs:     p.Elapsed.Milliseconds(),
+		})
+	})
+	result, err := h.executor.ExecuteUp(ctx, req.Target, req.Connection, req.Schemas, req.DryRun, req.IgnoreDependencies)
+	if err != nil {
+		c.Set(auditStatusKey, executionErrorStatus(err))
+		c.Set(auditErrorKey, err.Error())
+		send("error", gin.H{"error": err.Error()})
+		return
+	}
+	if !result.Success {
+		c.Set(auditStatusKey, http.StatusPartialContent)
+		c.Set(auditErrorKey, strings.Join(result.Errors, "; "))
+	}
+	send("result", migrateResponse(result))
+}
+
+// eventStreamTypes are the events GET /migrations/events streams. Emergency events, which name who
+// bypassed the safeguards and why, and loader events are left out.
+var eventStreamTypes = []string{
+	events.MigrationStarted, events.StatementCompleted, events.MigrationApplied, events.MigrationFailed,
+	events.RollbackCompleted, events.RunCompleted, events.JobQueued, events.JobScheduled, events.ReindexCompleted,
+	events.ConnectionsReloaded,
+}
+
+const (
+	// eventStreamBuffer is how many events a slow client may lag behind before events are dropped
+	eventStreamBuffer = 256
+	// eventStreamKeepAlive is how often an idle stream sends a comment, so proxies keep it open
+	eventStreamKeepAlive = 15 * time.Second
+)
+
+// CloseStreams ends the event streams in progress and refuses new ones, for the server to shut down
+func (h *Handler) CloseStreams() {
+	h.closeStreamsOnce.Do(func() { close(h.closeStreams) })
+}
+
+// streamEvents streams lifecycle events
+// @Summary      Stream lifecycle events
+// @Description  Streams migration lifecycle events as server-sent events, as they are published on this server, until the client disconnects. Each event is named after its type: migration.started, migration.statement_completed (PostgreSQL, with statement, statements, rows_affected and elapsed_ms), migration.applied, migration.failed (with error) and migration.rolled_back (with operation, backend and elapsed_ms), run.completed, job.queued, job.scheduled and reindex.completed. A dropped event (with count) tells a client that fell behind that events were lost. Idle streams send a comment every 15 seconds. Runs executed by workers or other replicas are not streamed.
+// @Tags         migrations
+// @Produce      text/event-stream
+// @Param        connection query string false "Only events of this connection"
+// @Param        types query string false "Comma-separated event types to stream (default: all)"
+// @Success      200 {object} dto.EventResponse "Event stream"
+// @Failure      400 {object} map[string]interface{} "Unknown event type"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      503 {object} map[string]interface{} "Server is shutting down"
+// @Security     Bearer
+// @Router       /migrations/events [get]
+func (h *Handler) streamEvents(c *gin.Context) {
+	connection := c.Query("connection")
+	types := make(map[string]bool)
+	for _, eventType := range eventStreamTypes {
+		types[eventType] = c.Query("types") == ""
+	}
+	for _, eventType := range strings.Split(c.Query("types"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType == "" {
+			continue
+		}
+		if _, ok := types[eventType]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": localized(c, i18n.APIUnknownEventType, eventType, strings.Join(eventStreamTypes, ", "))})
+			return
+		}
+		types[eventType] = true
+	}
+	select {
+	case <-h.closeStreams:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": localized(c, i18n.APIShuttingDown)})
+		return
+	default:
+	}
+
+	// Handlers run in the publisher's goroutine, so never block it: events a slow client cannot
+	// take are dropped and counted
+	queue := make(chan events.Event, eventStreamBuffer)
+	var dropped atomic.Int64
+	unsubscribe := h.executor.Events().Subscribe(events.All, func(_ context.Context, event events.Event) {
+		if !types[event.Type] || (connection != "" && event.Connection != connection) {
+			return
+		}
+		select {
+		case queue <- event:
+		default:
+			dropped.Add(1)
+		}
+	})
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.closeStreams:
+			return
+		case event := <-queue:
+			if n := dropped.Swap(0); n > 0 {
+				c.SSEvent("dropped", gin.H{"count": n})
+			}
+			c.SSEvent(event.Type, dto.EventResponse{
+				Type:        event.Type,
+				Time:        event.Time.Format(time.RFC3339Nano),
+				MigrationID: event.MigrationID,
+				Connection:  event.Connection,
+				Schema:      event.Schema,
+				Data:        event.Data,
+			})
+		case <-keepAlive.C:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
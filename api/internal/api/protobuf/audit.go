@@ -0,0 +1,159 @@
+package protobuf
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/auth"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// auditedMethods maps the MigrationService methods that mutate state to their audit operation
+var auditedMethods = map[string]string{
+	MigrationService_Migrate_FullMethodName:           "up",
+	MigrationService_StreamMigrate_FullMethodName:     "up",
+	MigrationService_MigrateDown_FullMethodName:       "down",
+	MigrationService_RollbackMigration_FullMethodName: "rollback",
+	MigrationService_ReindexMigrations_FullMethodName: "reindex",
+}
+
+// AuditCall is a finished call to a MigrationService method, to record in the audit log
+type AuditCall struct {
+	FullMethod    string // gRPC full method or Connect procedure
+	Protocol      string // grpc or connect
+	Authorization string // Authorization header of the call
+	SourceIP      string
+	Request       proto.Message // nil when the call was refused before its request was read
+	Response      interface{}
+	Err           error // gRPC status error
+}
+
+// Audit records call in the audit log when its method mutates state. The caller's identity is taken
+// from the Authorization header, so calls refused by the auth interceptors are recorded with it.
+func (s *Server) Audit(ctx context.Context, call AuditCall) {
+	operation, ok := auditedMethods[call.FullMethod]
+	if !ok {
+		return
+	}
+
+	record := &state.AuditRecord{
+		Operation: operation,
+		Protocol:  call.Protocol,
+		Method:    call.FullMethod,
+		SourceIP:  call.SourceIP,
+		Outcome:   state.AuditOutcomeSuccess,
+		Status:    status.Code(call.Err).String(),
+	}
+	if token, err := auth.ExtractToken(call.Authorization); err == nil {
+		if identity, err := auth.Authenticate(token); err == nil {
+			record.Actor = identity.Name
+			record.Role = string(identity.Role)
+		}
+	}
+	if call.Request != nil {
+		if body, err := protojson.Marshal(call.Request); err == nil {
+			record.RequestBody = string(body)
+		}
+	}
+
+	switch code := status.Code(call.Err); {
+	case code == codes.Unauthenticated || code == codes.PermissionDenied:
+		record.Outcome = state.AuditOutcomeDenied
+		record.Error = status.Convert(call.Err).Message()
+	case call.Err != nil:
+		record.Outcome = state.AuditOutcomeFailed
+		record.Error = status.Convert(call.Err).Message()
+	default:
+		// Like the HTTP API's 206, a migrate response with errors is a partial success
+		switch resp := call.Response.(type) {
+		case *MigrateResponse:
+			if !resp.Success {
+				record.Outcome = state.AuditOutcomePartial
+				record.Error = strings.Join(resp.Errors, "; ")
+			}
+		case *RollbackResponse:
+			if !resp.Success {
+				record.Outcome = state.AuditOutcomeFailed
+				record.Error = resp.Message
+				if len(resp.Errors) > 0 {
+					record.Error = strings.Join(resp.Errors, "; ")
+				}
+			}
+		}
+	}
+
+	// The call is done; don't let a client disconnect cancel the audit record
+	if err := s.executor.RecordAudit(context.WithoutCancel(ctx), record); err != nil {
+		logger.Errorf("Failed to record audit of %s by %q: %v", record.Method, record.Actor, err)
+	}
+}
+
+// SourceIP returns the host of a peer address, or the address when it has no port
+func SourceIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// grpcAuditCall returns the audit call of a gRPC call to fullMethod
+func grpcAuditCall(ctx context.Context, fullMethod string, req, resp interface{}, err error) AuditCall {
+	call := AuditCall{FullMethod: fullMethod, Protocol: "grpc", Response: resp, Err: err}
+	call.Request, _ = req.(proto.Message)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			call.Authorization = values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		call.SourceIP = SourceIP(p.Addr.String())
+	}
+	return call
+}
+
+// UnaryAuditInterceptor records calls that mutate state in the audit log. It must come before
+// UnaryAuthInterceptor so refused calls are recorded too.
+func (s *Server) UnaryAuditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := auditedMethods[info.FullMethod]; !ok {
+		return handler(ctx, req)
+	}
+	resp, err := handler(ctx, req)
+	s.Audit(ctx, grpcAuditCall(ctx, info.FullMethod, req, resp, err))
+	return resp, err
+}
+
+// StreamAuditInterceptor is UnaryAuditInterceptor for streaming calls
+func (s *Server) StreamAuditInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, ok := auditedMethods[info.FullMethod]; !ok {
+		return handler(srv, ss)
+	}
+	stream := &auditedStream{ServerStream: ss}
+	err := handler(srv, stream)
+	s.Audit(ss.Context(), grpcAuditCall(ss.Context(), info.FullMethod, stream.req, nil, err))
+	return err
+}
+
+// auditedStream is a server stream that keeps the first message received, the request of a
+// server-streaming call
+type auditedStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *auditedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+	}
+	return err
+}
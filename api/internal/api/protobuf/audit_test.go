@@ -0,0 +1,107 @@
+package protobuf
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// auditTracker is a healthyTracker that keeps audit records
+type auditTracker struct {
+	healthyTracker
+	records []*state.AuditRecord
+}
+
+func (t *auditTracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	t.records = append(t.records, record)
+	return nil
+}
+
+func TestAuditInterceptors(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token")
+
+	tracker := &auditTracker{}
+	pbServer := NewServer(executor.NewExecutor(registry.NewInMemoryRegistry(), tracker))
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(pbServer.UnaryAuditInterceptor, UnaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(pbServer.StreamAuditInterceptor, StreamAuthInterceptor),
+	)
+	RegisterMigrationServiceServer(server, pbServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	client := NewMigrationServiceClient(conn)
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	// Reads are not audited
+	_, _ = client.GetMigration(withToken("test-token"), &GetMigrationRequest{MigrationId: "missing"})
+	_, _ = client.Migrate(withToken("ro-token"), &MigrateRequest{Connection: "core"})
+	_, _ = client.MigrateDown(withToken("test-token"), &MigrateDownRequest{})
+	stream, err := client.StreamMigrate(withToken("test-token"), &MigrateRequest{Connection: "core"})
+	if err == nil {
+		_, _ = stream.Recv()
+	}
+
+	if len(tracker.records) != 3 {
+		t.Fatalf("expected 3 audit records, got %d", len(tracker.records))
+	}
+	tests := []struct {
+		operation string
+		role      string
+		outcome   string
+		status    string
+	}{
+		{operation: "up", role: "read-only", outcome: state.AuditOutcomeDenied, status: "PermissionDenied"},
+		{operation: "down", role: "admin", outcome: state.AuditOutcomeFailed, status: "InvalidArgument"},
+		{operation: "up", role: "admin", outcome: state.AuditOutcomeFailed, status: "InvalidArgument"},
+	}
+	for i, tt := range tests {
+		record := tracker.records[i]
+		if record.Operation != tt.operation || record.Role != tt.role || record.Outcome != tt.outcome ||
+			record.Status != tt.status || record.Protocol != "grpc" || record.Error == "" {
+			t.Errorf("audit record %d = %+v, want %+v", i, record, tt)
+		}
+	}
+
+	if tracker.records[0].Actor != "grafana" {
+		t.Errorf("audit record actor = %q, want grafana", tracker.records[0].Actor)
+	}
+
+	// Requests are recorded, including the request of a streaming call
+	if !strings.Contains(tracker.records[0].RequestBody, "core") || !strings.Contains(tracker.records[2].RequestBody, "core") {
+		t.Errorf("request bodies not recorded: %q, %q", tracker.records[0].RequestBody, tracker.records[2].RequestBody)
+	}
+}
@@ -0,0 +1,69 @@
+package protobuf
+
+import (
+	"context"
+	"errors"
+
+	"github.com/toolsascode/bfm/api/internal/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate validates the authorization metadata of a call to fullMethod and returns ctx
+// carrying the caller's identity (see auth.FromContext)
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	role, required := RequiredRole(fullMethod)
+	if !required {
+		return ctx, nil
+	}
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	token, err := auth.ExtractToken(header)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	identity, err := auth.Authorize(token, role)
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return auth.NewContext(ctx, identity), nil
+}
+
+// UnaryAuthInterceptor validates the API bearer token (or OIDC JWT) and its role on unary calls,
+// like the HTTP API's authorize middleware. Health needs no token.
+func UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor for streaming calls
+func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream is a server stream whose context carries the caller's identity
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
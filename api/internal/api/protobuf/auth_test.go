@@ -0,0 +1,100 @@
+package protobuf
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthyTracker is a state tracker whose health check succeeds
+type healthyTracker struct {
+	state.StateTracker
+}
+
+func (healthyTracker) Initialize(ctx context.Context) error {
+	return nil
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_TOKENS")
+	}()
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token")
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryAuthInterceptor),
+		grpc.StreamInterceptor(StreamAuthInterceptor),
+	)
+	RegisterMigrationServiceServer(server, NewServer(executor.NewExecutor(registry.NewInMemoryRegistry(), healthyTracker{})))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	client := NewMigrationServiceClient(conn)
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	// Health does not require a token
+	if _, err := client.Health(context.Background(), &HealthRequest{}); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+
+	get := &GetMigrationRequest{MigrationId: "missing"}
+	if _, err := client.GetMigration(context.Background(), get); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a token, got %v", err)
+	}
+	if _, err := client.GetMigration(withToken("wrong-token"), get); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for an unknown token, got %v", err)
+	}
+	if _, err := client.GetMigration(withToken("ro-token"), get); status.Code(err) != codes.NotFound {
+		t.Errorf("expected a read-only token to read, got %v", err)
+	}
+	if _, err := client.Migrate(withToken("ro-token"), &MigrateRequest{Connection: "core"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a read-only token, got %v", err)
+	}
+
+	// Streaming calls are checked as well
+	stream, err := client.StreamMigrate(context.Background(), &MigrateRequest{Connection: "core"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for StreamMigrate without a token, got %v", err)
+	}
+	stream, err = client.StreamMigrate(withToken("test-token"), &MigrateRequest{Connection: "core"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected StreamMigrate to reach the handler, got %v", err)
+	}
+}
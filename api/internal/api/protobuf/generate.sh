@@ -25,6 +25,11 @@ if ! command -v protoc-gen-go-grpc &> /dev/null; then
     go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest
 fi
 
+if ! command -v protoc-gen-connect-go &> /dev/null; then
+    echo "Installing protoc-gen-connect-go..."
+    go install connectrpc.com/connect/cmd/protoc-gen-connect-go@latest
+fi
+
 # Get the directory of this script
 SCRIPT_DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 
@@ -36,6 +41,8 @@ protoc \
     --go_opt=paths=source_relative \
     --go-grpc_out=$SCRIPT_DIR \
     --go-grpc_opt=paths=source_relative \
+    --connect-go_out=$SCRIPT_DIR \
+    --connect-go_opt=paths=source_relative \
     $SCRIPT_DIR/migration.proto
 
 echo "Protobuf code generated successfully!"
@@ -2,17 +2,20 @@ package protobuf
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"sort"
-	"strings"
 	"time"
 
+	"github.com/toolsascode/bfm/api/internal/auth"
 	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/buildinfo"
+	"github.com/toolsascode/bfm/api/internal/config"
 	"github.com/toolsascode/bfm/api/internal/executor"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/internal/state"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -35,11 +38,19 @@ func (s *Server) setExecutionContext(ctx context.Context) context.Context {
 	executionContext := map[string]interface{}{
 		"connection_type": "grpc",
 	}
-	return executor.SetExecutionContext(ctx, "grpc_client", "api", executionContext)
+	executedBy := "grpc_client"
+	if identity, ok := auth.FromContext(ctx); ok && identity.Name != "" {
+		executedBy = identity.Name
+	}
+	return executor.SetExecutionContext(ctx, executedBy, "api", executionContext)
 }
 
 // Migrate executes database migrations
 func (s *Server) Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResponse, error) {
+	if err := s.requirePrimary(); err != nil {
+		return nil, err
+	}
+
 	if req == nil || req.Target == nil {
 		return nil, status.Error(codes.InvalidArgument, "request and target are required")
 	}
@@ -56,9 +67,13 @@ func (s *Server) Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResp
 		Schema:     req.Target.Schema,
 		Tables:     req.Target.Tables,
 		Version:    req.Target.Version,
+		MaxVersion: req.Target.MaxVersion,
 		Connection: req.Target.Connection,
 		Tags:       req.Target.Tags,
 	}
+	if err := registry.ValidateTargetTables(s.executor.GetRegistry(), target); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 
 	// Resolve schema (use schema_name if provided for dynamic schemas)
 	schema := req.Schema
@@ -69,19 +84,27 @@ func (s *Server) Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResp
 
 	// Set execution context with connection type
 	ctx = s.setExecutionContext(ctx)
+	if req.CaptureSql {
+		ctx = executor.WithCaptureSQL(ctx)
+	}
+	ctx = executor.WithPinnedChecksums(ctx, req.PinnedChecksums)
+	if req.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
 
 	// Execute migrations
 	result, err := s.executor.Execute(ctx, target, req.Connection, schema, req.DryRun, req.IgnoreDependencies)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to execute migrations: %v", err)
+		return nil, status.Errorf(executionErrorCode(err), "failed to execute migrations: %v", err)
 	}
 
 	// Convert result to protobuf response
 	response := &MigrateResponse{
-		Success: result.Success,
-		Applied: result.Applied,
-		Skipped: result.Skipped,
-		Errors:  result.Errors,
+		Success:     result.Success,
+		Applied:     result.Applied,
+		Skipped:     result.Skipped,
+		Errors:      result.Errors,
+		ExecutedSql: executedSQLMessages(result.ExecutedSQL),
 	}
 
 	return response, nil
@@ -89,12 +112,23 @@ func (s *Server) Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResp
 
 // StreamMigrate executes migrations with streaming progress updates
 func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_StreamMigrateServer) error {
+	if err := s.requirePrimary(); err != nil {
+		return err
+	}
+
 	if req == nil || req.Target == nil {
 		return status.Error(codes.InvalidArgument, "request and target are required")
 	}
+	// Migrations are run one by one here, outside the executor's checks
+	if len(req.PinnedChecksums) > 0 {
+		return status.Error(codes.InvalidArgument, "pinned_checksums is not supported by StreamMigrate, use Migrate")
+	}
 
 	// Set execution context with connection type
 	ctx := s.setExecutionContext(stream.Context())
+	if req.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
 
 	// Convert protobuf target to registry target
 	target := &registry.MigrationTarget{
@@ -102,6 +136,7 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 		Schema:     req.Target.Schema,
 		Tables:     req.Target.Tables,
 		Version:    req.Target.Version,
+		MaxVersion: req.Target.MaxVersion,
 		Connection: req.Target.Connection,
 		Tags:       req.Target.Tags,
 	}
@@ -130,7 +165,7 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 			MigrationId: migrationID,
 			Status:      "running",
 			Message:     fmt.Sprintf("Executing migration %d of %d", i+1, total),
-			Progress:    int32((i + 1) * 100 / total),
+			Progress:    int32(i * 100 / total),
 		}
 
 		if err := stream.Send(progress); err != nil {
@@ -158,7 +193,14 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 			// Execute migration using executor (simplified)
 			// In production, you'd want to use the executor's Execute method
 			// but for streaming, we need to execute one at a time
-			connectionConfig, err := s.executor.GetConnectionConfig(migration.Connection)
+			if err := s.executor.VerifyDestructivePolicy(ctx, migration, ""); err != nil {
+				progress.Status = "failed"
+				progress.Message = err.Error()
+				_ = stream.Send(progress)
+				continue
+			}
+
+			connectionConfig, err := s.executor.GetConnectionConfig(ctx, migration.Connection)
 			if err != nil {
 				progress.Status = "failed"
 				progress.Message = fmt.Sprintf("Failed to get connection config: %v", err)
@@ -182,18 +224,40 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 			}
 
 			backendMigration := &backends.MigrationScript{
-				Schema:     migration.Schema,
-				Table:      migration.Table, // Already *string, can be nil
-				Version:    migration.Version,
-				Name:       migration.Name,
-				Connection: migration.Connection,
-				Backend:    migration.Backend,
-				UpSQL:      migration.UpSQL,
-				DownSQL:    migration.DownSQL,
+				Schema:        migration.Schema,
+				Table:         migration.Table, // Already *string, can be nil
+				Version:       migration.Version,
+				Name:          migration.Name,
+				Connection:    migration.Connection,
+				Backend:       migration.Backend,
+				UpSQL:         migration.UpSQL,
+				DownSQL:       migration.DownSQL,
+				Transactional: backends.IsTransactional(migration.UpSQL),
 			}
 
-			err = backend.ExecuteMigration(ctx, backendMigration)
+			// Report each statement, so long migrations show where they are
+			statementCtx := backends.WithStatementObserver(ctx, func(p backends.StatementProgress) {
+				update := &MigrateProgress{
+					MigrationId:   migrationID,
+					Status:        "statement",
+					Message:       fmt.Sprintf("Statement %d of %d", p.Statement, p.Statements),
+					Progress:      int32((i*p.Statements + p.Statement - 1) * 100 / (total * p.Statements)),
+					Statement:     int32(p.Statement),
+					Statements:    int32(p.Statements),
+					StatementDone: p.Done,
+				}
+				if p.Done {
+					update.Message = fmt.Sprintf("Statement %d of %d completed", p.Statement, p.Statements)
+					update.Progress = int32((i*p.Statements + p.Statement) * 100 / (total * p.Statements))
+					update.RowsAffected = p.RowsAffected
+					update.ElapsedMs = p.Elapsed.Milliseconds()
+				}
+				_ = stream.Send(update)
+			})
+			start := time.Now()
+			err = backend.ExecuteMigration(statementCtx, backendMigration)
 			_ = backend.Close()
+			progress.ElapsedMs = time.Since(start).Milliseconds()
 
 			if err != nil {
 				progress.Status = "failed"
@@ -228,6 +292,7 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 
 		progress.Status = "success"
 		progress.Message = "Migration completed successfully"
+		progress.Progress = int32((i + 1) * 100 / total)
 		_ = stream.Send(progress)
 	}
 
@@ -236,6 +301,10 @@ func (s *Server) StreamMigrate(req *MigrateRequest, stream MigrationService_Stre
 
 // MigrateDown executes down migrations (rollback)
 func (s *Server) MigrateDown(ctx context.Context, req *MigrateDownRequest) (*MigrateResponse, error) {
+	if err := s.requirePrimary(); err != nil {
+		return nil, err
+	}
+
 	if req == nil || req.MigrationId == "" {
 		return nil, status.Error(codes.InvalidArgument, "request and migration_id are required")
 	}
@@ -248,23 +317,99 @@ func (s *Server) MigrateDown(ctx context.Context, req *MigrateDownRequest) (*Mig
 
 	// Set execution context with connection type
 	ctx = s.setExecutionContext(ctx)
+	if req.CaptureSql {
+		ctx = executor.WithCaptureSQL(ctx)
+	}
 
 	// Execute down migrations
 	result, err := s.executor.ExecuteDown(ctx, req.MigrationId, schemas, req.DryRun, req.IgnoreDependencies)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to execute down migrations: %v", err)
+		return nil, status.Errorf(executionErrorCode(err), "failed to execute down migrations: %v", err)
 	}
 
 	response := &MigrateResponse{
-		Success: result.Success,
-		Applied: result.Applied,
-		Skipped: result.Skipped,
-		Errors:  result.Errors,
+		Success:     result.Success,
+		Applied:     result.Applied,
+		Skipped:     result.Skipped,
+		Errors:      result.Errors,
+		ExecutedSql: executedSQLMessages(result.ExecutedSQL),
 	}
 
 	return response, nil
 }
 
+// Plan returns the resolved, ordered execution plan for a target without executing anything
+func (s *Server) Plan(ctx context.Context, req *PlanRequest) (*PlanResponse, error) {
+	if req == nil || req.Connection == "" {
+		return nil, status.Error(codes.InvalidArgument, "request and connection are required")
+	}
+
+	target := &registry.MigrationTarget{Connection: req.Connection}
+	if req.Target != nil {
+		if len(req.Target.Tags) > 0 {
+			if _, err := registry.ParseTagFilter(req.Target.Tags); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+			}
+		}
+		target = &registry.MigrationTarget{
+			Backend:    req.Target.Backend,
+			Schema:     req.Target.Schema,
+			Tables:     req.Target.Tables,
+			Version:    req.Target.Version,
+			MaxVersion: req.Target.MaxVersion,
+			Connection: req.Target.Connection,
+			Tags:       req.Target.Tags,
+		}
+	}
+	if err := registry.ValidateTargetTables(s.executor.GetRegistry(), target); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	plan, err := s.executor.Plan(ctx, target, req.Connection, req.Schemas, req.IgnoreDependencies)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to plan migrations: %v", err)
+	}
+
+	steps := make([]*PlanStep, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		var findings []*ValidatorFinding
+		for _, finding := range step.Findings {
+			findings = append(findings, &ValidatorFinding{
+				Validator: finding.Validator,
+				Severity:  finding.Severity,
+				Message:   finding.Message,
+			})
+		}
+		steps = append(steps, &PlanStep{
+			Order:         int32(step.Order),
+			MigrationId:   step.MigrationID,
+			Version:       step.Version,
+			Name:          step.Name,
+			Backend:       step.Backend,
+			Connection:    step.Connection,
+			Schema:        step.Schema,
+			Action:        step.Action,
+			Reason:        step.Reason,
+			DependsOn:     step.DependsOn,
+			RequiredBy:    step.RequiredBy,
+			DownGenerated: step.DownGenerated,
+			DownSql:       step.DownSQL,
+			Checksum:      step.Checksum,
+			Statements:    int32(step.Statements),
+			Destructive:   step.Destructive,
+			Findings:      findings,
+		})
+	}
+
+	return &PlanResponse{
+		Steps:     steps,
+		Apply:     plan.Apply,
+		Skip:      plan.Skip,
+		Errors:    plan.Errors,
+		Checksums: plan.Checksums,
+	}, nil
+}
+
 // ListMigrations lists all migrations with optional filtering
 func (s *Server) ListMigrations(ctx context.Context, req *ListMigrationsRequest) (*ListMigrationsResponse, error) {
 	if req == nil {
@@ -279,10 +424,18 @@ func (s *Server) ListMigrations(ctx context.Context, req *ListMigrationsRequest)
 		Backend:    req.Backend,
 		Status:     req.Status,
 		Version:    req.Version,
+		Label:      req.Label,
+		Limit:      int(req.Limit),
+		Offset:     int(req.Offset),
+		SortBy:     req.SortBy,
+		SortOrder:  req.SortOrder,
+	}
+	if err := stateFilters.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Get migration list from state tracker
-	migrationList, err := s.executor.GetMigrationList(ctx, stateFilters)
+	migrationList, total, err := s.executor.GetMigrationList(ctx, stateFilters)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get migration list: %v", err)
 	}
@@ -302,16 +455,22 @@ func (s *Server) ListMigrations(ctx context.Context, req *ListMigrationsRequest)
 			Status:       item.LastStatus,
 			AppliedAt:    item.LastAppliedAt,
 			ErrorMessage: item.LastErrorMessage,
+			StatusLabels: item.StatusLabels,
 		}
-		if regMig := s.executor.GetMigrationByID(item.MigrationID); regMig != nil && len(regMig.Tags) > 0 {
-			pbItem.Tags = append([]string(nil), regMig.Tags...)
+		if regMig := s.executor.GetMigrationByID(item.MigrationID); regMig != nil {
+			if len(regMig.Tags) > 0 {
+				pbItem.Tags = append([]string(nil), regMig.Tags...)
+			}
+			pbItem.Destructive = regMig.DestructiveStatements()
 		}
 		items = append(items, pbItem)
 	}
 
 	response := &ListMigrationsResponse{
-		Items: items,
-		Total: int32(len(items)),
+		Items:  items,
+		Total:  int32(total),
+		Limit:  req.Limit,
+		Offset: req.Offset,
 	}
 
 	return response, nil
@@ -337,12 +496,14 @@ func (s *Server) GetMigration(ctx context.Context, req *GetMigrationRequest) (*M
 
 	// Get schema and table from state tracker
 	var schemaValue, tableValue string
-	migrationList, err := s.executor.GetMigrationList(ctx, &state.MigrationFilters{})
+	var statusLabels []string
+	migrationList, _, err := s.executor.GetMigrationList(ctx, &state.MigrationFilters{})
 	if err == nil {
 		for _, item := range migrationList {
 			if item.MigrationID == req.MigrationId {
 				schemaValue = item.Schema
 				tableValue = item.Table
+				statusLabels = item.StatusLabels
 				break
 			}
 		}
@@ -388,6 +549,9 @@ func (s *Server) GetMigration(ctx context.Context, req *GetMigrationRequest) (*M
 		Dependencies:           migration.Dependencies,
 		StructuredDependencies: structuredDeps,
 		Tags:                   tagCopy,
+		DownGenerated:          migration.DownGenerated,
+		Repeatable:             migration.IsRepeatable(),
+		StatusLabels:           statusLabels,
 	}
 
 	return response, nil
@@ -399,22 +563,12 @@ func (s *Server) GetMigrationStatus(ctx context.Context, req *GetMigrationStatus
 		return nil, status.Error(codes.InvalidArgument, "request and migration_id are required")
 	}
 
-	// Get all migration history to find the latest status
-	allHistory, err := s.executor.GetMigrationHistory(ctx, nil)
+	// Get the history of the migration (up and down migrations, and rollbacks), newest first
+	relatedRecords, _, err := s.executor.GetMigrationHistory(ctx, &state.MigrationFilters{MigrationID: req.MigrationId})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get migration history: %v", err)
 	}
 
-	// Find all related records (base migration and rollbacks)
-	var relatedRecords []*state.MigrationRecord
-	for _, record := range allHistory {
-		if record.MigrationID == req.MigrationId ||
-			record.MigrationID == req.MigrationId+"_rollback" ||
-			(len(record.MigrationID) > len(req.MigrationId) && record.MigrationID[:len(req.MigrationId)] == req.MigrationId && record.MigrationID[len(req.MigrationId)] == '_') {
-			relatedRecords = append(relatedRecords, record)
-		}
-	}
-
 	// Determine applied status and get latest applied_at
 	applied := false
 	statusVal := "pending"
@@ -425,19 +579,19 @@ func (s *Server) GetMigrationStatus(ctx context.Context, req *GetMigrationStatus
 		// Get the latest record (first in the list since history is sorted DESC)
 		latestRecord := relatedRecords[0]
 
-		// Find the latest successful, non-rollback record
+		// Find the latest successful up migration
 		var latestSuccessRecord *state.MigrationRecord
 		for _, record := range relatedRecords {
-			if !strings.Contains(record.MigrationID, "_rollback") && state.HistoryStatusIndicatesApplied(record.Status) {
+			if !record.Reverts() && state.HistoryStatusIndicatesApplied(record.Status) {
 				latestSuccessRecord = record
 				break
 			}
 		}
 
-		// Find the latest rollback record
+		// Find the latest successful down migration or rollback
 		var latestRollbackRecord *state.MigrationRecord
 		for _, record := range relatedRecords {
-			if strings.Contains(record.MigrationID, "_rollback") {
+			if record.Reverts() && record.Status == "rolled_back" {
 				latestRollbackRecord = record
 				break
 			}
@@ -469,7 +623,7 @@ func (s *Server) GetMigrationStatus(ctx context.Context, req *GetMigrationStatus
 			statusVal = "rolled_back"
 			errorMessage = latestRollbackRecord.ErrorMessage
 		} else {
-			applied = !strings.Contains(latestRecord.MigrationID, "_rollback") &&
+			applied = !latestRecord.Reverts() &&
 				state.HistoryStatusIndicatesApplied(latestRecord.Status)
 			statusVal = latestRecord.Status
 			appliedAt = latestRecord.AppliedAt
@@ -511,32 +665,52 @@ func (s *Server) IsMigrationApplied(ctx context.Context, req *IsMigrationApplied
 	}, nil
 }
 
-// GetMigrationHistory gets the execution history for a specific migration
+// GetMigrationHistory gets the execution history of a migration, or of all migrations when
+// migration_id is empty
 func (s *Server) GetMigrationHistory(ctx context.Context, req *GetMigrationHistoryRequest) (*MigrationHistoryResponse, error) {
-	if req == nil || req.MigrationId == "" {
-		return nil, status.Error(codes.InvalidArgument, "request and migration_id are required")
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request is required")
 	}
 
 	// Get migration from registry to verify it exists
-	migration := s.executor.GetMigrationByID(req.MigrationId)
-	if migration == nil {
+	if req.MigrationId != "" && s.executor.GetMigrationByID(req.MigrationId) == nil {
 		return nil, status.Errorf(codes.NotFound, "migration not found: %s", req.MigrationId)
 	}
 
-	// Get all migration history
-	allHistory, err := s.executor.GetMigrationHistory(ctx, nil)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get migration history: %v", err)
+	historyFilters := &state.MigrationFilters{
+		MigrationID:     req.MigrationId,
+		ExecutedBy:      req.ExecutedBy,
+		ExecutionMethod: req.ExecutionMethod,
+		Limit:           int(req.Limit),
+		Offset:          int(req.Offset),
+		SortBy:          req.SortBy,
+		SortOrder:       req.SortOrder,
+	}
+	for _, bound := range []struct {
+		name string
+		raw  string
+		t    *time.Time
+	}{
+		{"applied_after", req.AppliedAfter, &historyFilters.AppliedAfter},
+		{"applied_before", req.AppliedBefore, &historyFilters.AppliedBefore},
+	} {
+		if bound.raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: expected an RFC 3339 time", bound.name)
+		}
+		*bound.t = parsed
+	}
+	if err := historyFilters.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	// Filter history to include related records
-	var relatedHistory []*state.MigrationRecord
-	for _, record := range allHistory {
-		if record.MigrationID == req.MigrationId ||
-			record.MigrationID == req.MigrationId+"_rollback" ||
-			(len(record.MigrationID) > len(req.MigrationId) && record.MigrationID[:len(req.MigrationId)] == req.MigrationId && record.MigrationID[len(req.MigrationId)] == '_') {
-			relatedHistory = append(relatedHistory, record)
-		}
+	// Get the history of the migration, including related records (rollbacks, ...)
+	relatedHistory, total, err := s.executor.GetMigrationHistory(ctx, historyFilters)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get migration history: %v", err)
 	}
 
 	// Convert to response format
@@ -555,19 +729,98 @@ func (s *Server) GetMigrationHistory(ctx context.Context, req *GetMigrationHisto
 			ExecutedBy:       record.ExecutedBy,
 			ExecutionMethod:  record.ExecutionMethod,
 			ExecutionContext: record.ExecutionContext,
+			Operation:        record.HistoryOperation(),
 		})
 	}
 
 	response := &MigrationHistoryResponse{
 		MigrationId: req.MigrationId,
 		History:     historyItems,
+		Total:       int32(total),
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+	}
+
+	return response, nil
+}
+
+// GetPendingMigrations lists the migrations of a connection that are registered but not applied
+func (s *Server) GetPendingMigrations(ctx context.Context, req *GetPendingMigrationsRequest) (*PendingMigrationsResponse, error) {
+	if req == nil || req.Connection == "" {
+		return nil, status.Error(codes.InvalidArgument, "request and connection are required")
+	}
+	if _, err := s.executor.GetConnectionConfig(ctx, req.Connection); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	pending, err := s.executor.PendingMigrations(ctx, req.Connection, req.Schema)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list pending migrations: %v", err)
+	}
+
+	response := &PendingMigrationsResponse{
+		Connection: req.Connection,
+		Schema:     req.Schema,
+		Count:      int32(len(pending)),
+		Items:      make([]*PendingMigration, 0, len(pending)),
+	}
+	for _, p := range pending {
+		response.Items = append(response.Items, &PendingMigration{
+			MigrationId: p.MigrationID,
+			Version:     p.Version,
+			Name:        p.Name,
+			Connection:  p.Connection,
+			Backend:     p.Backend,
+			Schema:      p.Schema,
+		})
+	}
+
+	return response, nil
+}
+
+// GetMigrationSchemas reports in which schemas of its connection a migration is applied, failed or pending
+func (s *Server) GetMigrationSchemas(ctx context.Context, req *GetMigrationSchemasRequest) (*MigrationSchemasResponse, error) {
+	if req == nil || req.MigrationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "request and migration_id are required")
 	}
 
+	schemaStatus, err := s.executor.MigrationSchemaStatuses(ctx, req.MigrationId, req.Schemas, req.SchemaPattern)
+	switch {
+	case errors.Is(err, state.ErrMigrationNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, executor.ErrInvalidSchema):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to get schema statuses: %v", err)
+	}
+
+	applied, failed, pending := schemaStatus.Counts()
+	response := &MigrationSchemasResponse{
+		MigrationId: schemaStatus.MigrationID,
+		Connection:  schemaStatus.Connection,
+		Applied:     int32(applied),
+		Failed:      int32(failed),
+		Pending:     int32(pending),
+		Schemas:     make([]*SchemaStatus, 0, len(schemaStatus.Schemas)),
+	}
+	for _, schema := range schemaStatus.Schemas {
+		item := &SchemaStatus{Schema: schema.Schema, Status: schema.Status}
+		if schema.Execution != nil {
+			item.Version = schema.Execution.Version
+			item.AppliedAt = schema.Execution.AppliedAt
+			item.UpdatedAt = schema.Execution.UpdatedAt
+		}
+		response.Schemas = append(response.Schemas, item)
+	}
 	return response, nil
 }
 
 // RollbackMigration rolls back a specific migration
 func (s *Server) RollbackMigration(ctx context.Context, req *RollbackMigrationRequest) (*RollbackResponse, error) {
+	if err := s.requirePrimary(); err != nil {
+		return nil, err
+	}
+
 	if req == nil || req.MigrationId == "" {
 		return nil, status.Error(codes.InvalidArgument, "request and migration_id are required")
 	}
@@ -594,7 +847,7 @@ func (s *Server) RollbackMigration(ctx context.Context, req *RollbackMigrationRe
 	// Execute rollback with schemas
 	result, err := s.executor.Rollback(ctx, req.MigrationId, req.Schemas)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to rollback migration: %v", err)
+		return nil, status.Errorf(executionErrorCode(err), "failed to rollback migration: %v", err)
 	}
 
 	response := &RollbackResponse{
@@ -608,20 +861,20 @@ func (s *Server) RollbackMigration(ctx context.Context, req *RollbackMigrationRe
 
 // ReindexMigrations reindexes all migration files and synchronizes with database
 func (s *Server) ReindexMigrations(ctx context.Context, req *ReindexMigrationsRequest) (*ReindexResponse, error) {
-	// Get SFM path from request or environment variable
-	sfmPath := req.SfmPath
-	if sfmPath == "" {
-		sfmPath = os.Getenv("BFM_SFM_PATH")
-		if sfmPath == "" {
-			// Default to ../sfm relative to bfm directory
-			sfmPath = "../sfm"
-		}
+	if err := s.requirePrimary(); err != nil {
+		return nil, err
+	}
+
+	// Get SFM path from request, or every SFM root from the environment
+	sfmPaths := config.SFMPathsFromEnv()
+	if req.SfmPath != "" {
+		sfmPaths = []string{req.SfmPath}
 	}
 
 	// Set execution context with connection type
 	ctx = s.setExecutionContext(ctx)
 
-	result, err := s.executor.ReindexMigrations(ctx, sfmPath)
+	result, err := s.executor.ReindexMigrations(ctx, sfmPaths...)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to reindex migrations: %v", err)
 	}
@@ -656,3 +909,78 @@ func (s *Server) Health(ctx context.Context, req *HealthRequest) (*HealthRespons
 
 	return response, nil
 }
+
+// GetMeta reports the server version, enabled features and supported API versions
+func (s *Server) GetMeta(ctx context.Context, req *GetMetaRequest) (*MetaResponse, error) {
+	info := buildinfo.Get()
+	return &MetaResponse{
+		Version:     info.Version,
+		Commit:      info.Commit,
+		BuildDate:   info.Date,
+		GoVersion:   info.GoVersion,
+		ApiVersions: buildinfo.APIVersions,
+		Features: &MetaFeatures{
+			Queue:    s.executor.QueueEnabled(),
+			Backends: s.executor.BackendNames(),
+			AuthMode: auth.Mode(),
+		},
+	}, nil
+}
+
+// executedSQLMessages converts captured SQL to protobuf messages
+func executedSQLMessages(executed []executor.ExecutedSQL) []*ExecutedSQL {
+	messages := make([]*ExecutedSQL, 0, len(executed))
+	for _, e := range executed {
+		messages = append(messages, &ExecutedSQL{
+			MigrationId: e.MigrationID,
+			Sql:         e.SQL,
+			Truncated:   e.Truncated,
+			Suppressed:  e.Suppressed,
+		})
+	}
+	return messages
+}
+
+// requirePrimary refuses calls that write to databases or migration state while the server is a
+// standby (see executor.SetStandby)
+func (s *Server) requirePrimary() error {
+	if s.executor.IsStandby() {
+		return status.Error(codes.Unavailable, executor.ErrStandby.Error())
+	}
+	return nil
+}
+
+// UnaryStartedInterceptor refuses calls other than Health with Unavailable until the server has
+// finished loading migrations (see executor.SetStarting)
+func (s *Server) UnaryStartedInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if info.FullMethod != MigrationService_Health_FullMethodName && s.executor.IsStarting() {
+		return nil, status.Error(codes.Unavailable, executor.ErrStarting.Error())
+	}
+	return handler(ctx, req)
+}
+
+// StreamStartedInterceptor is UnaryStartedInterceptor for streaming calls
+func (s *Server) StreamStartedInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.executor.IsStarting() {
+		return status.Error(codes.Unavailable, executor.ErrStarting.Error())
+	}
+	return handler(srv, ss)
+}
+
+// executionErrorCode maps an execution error to a gRPC status code
+func executionErrorCode(err error) codes.Code {
+	if errors.Is(err, state.ErrConnectionLocked) {
+		return codes.Aborted
+	}
+	if errors.Is(err, executor.ErrStandby) {
+		return codes.Unavailable
+	}
+	if errors.Is(err, executor.ErrDependentsApplied) || errors.Is(err, executor.ErrPlanChanged) ||
+		errors.Is(err, executor.ErrValidationFailed) {
+		return codes.FailedPrecondition
+	}
+	if errors.Is(err, executor.ErrInvalidSchema) {
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
@@ -0,0 +1,76 @@
+package protobuf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/testsupport"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// statementBackend reports two statements per migration it executes
+type statementBackend struct {
+	*testsupport.Backend
+}
+
+func (b statementBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	for i := 1; i <= 2; i++ {
+		backends.ReportStatement(ctx, backends.StatementProgress{Statement: i, Statements: 2})
+		backends.ReportStatement(ctx, backends.StatementProgress{Statement: i, Statements: 2, Done: true, RowsAffected: 10})
+	}
+	return b.Backend.ExecuteMigration(ctx, migration)
+}
+
+// progressStream collects the updates of a StreamMigrate call
+type progressStream struct {
+	grpc.ServerStream
+	updates []*MigrateProgress
+}
+
+func (s *progressStream) Context() context.Context { return context.Background() }
+
+func (s *progressStream) Send(progress *MigrateProgress) error {
+	// StreamMigrate sends the same message again as the migration goes on
+	s.updates = append(s.updates, proto.Clone(progress).(*MigrateProgress))
+	return nil
+}
+
+func TestServer_StreamMigrate_StatementProgress(t *testing.T) {
+	reg := testsupport.NewRegistry(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "backfill_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "UPDATE users SET a = 1; UPDATE users SET b = 2;",
+	})
+	exec := executor.NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	exec.RegisterBackend("postgresql", statementBackend{testsupport.NewBackend("postgresql")})
+
+	stream := &progressStream{}
+	if err := NewServer(exec).StreamMigrate(&MigrateRequest{Target: &MigrationTarget{Connection: "core"}, Connection: "core"}, stream); err != nil {
+		t.Fatalf("StreamMigrate() error = %v", err)
+	}
+
+	var statuses []string
+	var progress []int32
+	for _, update := range stream.updates {
+		statuses = append(statuses, update.Status)
+		progress = append(progress, update.Progress)
+	}
+	if len(stream.updates) != 6 || statuses[0] != "running" || statuses[5] != "success" {
+		t.Fatalf("expected running, 4 statement updates and success, got %v", statuses)
+	}
+	if done := stream.updates[4]; done.Status != "statement" || done.Statement != 2 || done.Statements != 2 || !done.StatementDone || done.RowsAffected != 10 {
+		t.Errorf("unexpected update for the completed second statement: %+v", done)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] < progress[i-1] {
+			t.Errorf("expected progress not to go backwards, got %v", progress)
+		}
+	}
+	if progress[2] != 50 || progress[5] != 100 {
+		t.Errorf("expected progress to follow the statements, got %v", progress)
+	}
+}
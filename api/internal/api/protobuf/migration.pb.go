@@ -25,12 +25,13 @@ const (
 // MigrationTarget specifies which migrations to execute
 type MigrationTarget struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Backend       string                 `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`       // Backend type filter
-	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`         // Schema filter (optional)
-	Tables        []string               `protobuf:"bytes,3,rep,name=tables,proto3" json:"tables,omitempty"`         // Table filters (optional, empty = all)
-	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`       // Version filter (optional, empty = latest)
-	Connection    string                 `protobuf:"bytes,5,opt,name=connection,proto3" json:"connection,omitempty"` // Connection name filter
-	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`             // Optional key=value filters (AND semantics)
+	Backend       string                 `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`                         // Backend type filter
+	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`                           // Schema filter (optional)
+	Tables        []string               `protobuf:"bytes,3,rep,name=tables,proto3" json:"tables,omitempty"`                           // Table filters (optional, empty = all)
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`                         // Version filter (optional, empty = latest)
+	Connection    string                 `protobuf:"bytes,5,opt,name=connection,proto3" json:"connection,omitempty"`                   // Connection name filter
+	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`                               // Optional key=value filters (AND semantics)
+	MaxVersion    string                 `protobuf:"bytes,7,opt,name=max_version,json=maxVersion,proto3" json:"max_version,omitempty"` // Optional: only this version and older ("migrate to version X")
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -107,15 +108,25 @@ func (x *MigrationTarget) GetTags() []string {
 	return nil
 }
 
+func (x *MigrationTarget) GetMaxVersion() string {
+	if x != nil {
+		return x.MaxVersion
+	}
+	return ""
+}
+
 // MigrateRequest represents a migration request
 type MigrateRequest struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	Target             *MigrationTarget       `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
 	Connection         string                 `protobuf:"bytes,2,opt,name=connection,proto3" json:"connection,omitempty"`
-	Schema             string                 `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`                                                    // Optional
-	SchemaName         string                 `protobuf:"bytes,4,opt,name=schema_name,json=schemaName,proto3" json:"schema_name,omitempty"`                          // For dynamic schemas
-	DryRun             bool                   `protobuf:"varint,5,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                     // Optional, default false
-	IgnoreDependencies bool                   `protobuf:"varint,6,opt,name=ignore_dependencies,json=ignoreDependencies,proto3" json:"ignore_dependencies,omitempty"` // Optional, default false
+	Schema             string                 `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`                                                                                                                    // Optional
+	SchemaName         string                 `protobuf:"bytes,4,opt,name=schema_name,json=schemaName,proto3" json:"schema_name,omitempty"`                                                                                          // For dynamic schemas
+	DryRun             bool                   `protobuf:"varint,5,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                                                                                     // Optional, default false
+	IgnoreDependencies bool                   `protobuf:"varint,6,opt,name=ignore_dependencies,json=ignoreDependencies,proto3" json:"ignore_dependencies,omitempty"`                                                                 // Optional, default false
+	CaptureSql         bool                   `protobuf:"varint,7,opt,name=capture_sql,json=captureSql,proto3" json:"capture_sql,omitempty"`                                                                                         // Optional: return the rendered SQL of each migration
+	PinnedChecksums    map[string]string      `protobuf:"bytes,8,rep,name=pinned_checksums,json=pinnedChecksums,proto3" json:"pinned_checksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Optional: PlanResponse.checksums; refuses the run if a migration changed since
+	AllowDestructive   bool                   `protobuf:"varint,9,opt,name=allow_destructive,json=allowDestructive,proto3" json:"allow_destructive,omitempty"`                                                                       // Optional: force destructive migrations on connections whose destructive policy is "force"
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
@@ -192,6 +203,27 @@ func (x *MigrateRequest) GetIgnoreDependencies() bool {
 	return false
 }
 
+func (x *MigrateRequest) GetCaptureSql() bool {
+	if x != nil {
+		return x.CaptureSql
+	}
+	return false
+}
+
+func (x *MigrateRequest) GetPinnedChecksums() map[string]string {
+	if x != nil {
+		return x.PinnedChecksums
+	}
+	return nil
+}
+
+func (x *MigrateRequest) GetAllowDestructive() bool {
+	if x != nil {
+		return x.AllowDestructive
+	}
+	return false
+}
+
 // MigrateResponse represents a migration response
 type MigrateResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -199,6 +231,7 @@ type MigrateResponse struct {
 	Applied       []string               `protobuf:"bytes,2,rep,name=applied,proto3" json:"applied,omitempty"`
 	Skipped       []string               `protobuf:"bytes,3,rep,name=skipped,proto3" json:"skipped,omitempty"`
 	Errors        []string               `protobuf:"bytes,4,rep,name=errors,proto3" json:"errors,omitempty"`
+	ExecutedSql   []*ExecutedSQL         `protobuf:"bytes,5,rep,name=executed_sql,json=executedSql,proto3" json:"executed_sql,omitempty"` // Only when capture_sql was requested
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -261,20 +294,101 @@ func (x *MigrateResponse) GetErrors() []string {
 	return nil
 }
 
+func (x *MigrateResponse) GetExecutedSql() []*ExecutedSQL {
+	if x != nil {
+		return x.ExecutedSql
+	}
+	return nil
+}
+
+// ExecutedSQL is the rendered script of a migration (or the script a dry run would execute)
+type ExecutedSQL struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Sql           string                 `protobuf:"bytes,2,opt,name=sql,proto3" json:"sql,omitempty"`
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`   // SQL was cut at 64 KiB
+	Suppressed    bool                   `protobuf:"varint,4,opt,name=suppressed,proto3" json:"suppressed,omitempty"` // The connection's SQL_LOG is "none", so SQL is omitted
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutedSQL) Reset() {
+	*x = ExecutedSQL{}
+	mi := &file_migration_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutedSQL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutedSQL) ProtoMessage() {}
+
+func (x *ExecutedSQL) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutedSQL.ProtoReflect.Descriptor instead.
+func (*ExecutedSQL) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExecutedSQL) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *ExecutedSQL) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *ExecutedSQL) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *ExecutedSQL) GetSuppressed() bool {
+	if x != nil {
+		return x.Suppressed
+	}
+	return false
+}
+
 // MigrateProgress represents progress updates during migration
 type MigrateProgress struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "running", "success", "failed"
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "running", "statement", "skipped", "success", "failed"
 	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	Progress      int32                  `protobuf:"varint,4,opt,name=progress,proto3" json:"progress,omitempty"` // 0-100
+	Progress      int32                  `protobuf:"varint,4,opt,name=progress,proto3" json:"progress,omitempty"`                                // 0-100
+	Statement     int32                  `protobuf:"varint,5,opt,name=statement,proto3" json:"statement,omitempty"`                              // "statement" updates: 1-based position of the statement in the script
+	Statements    int32                  `protobuf:"varint,6,opt,name=statements,proto3" json:"statements,omitempty"`                            // "statement" updates: statements in the script
+	StatementDone bool                   `protobuf:"varint,7,opt,name=statement_done,json=statementDone,proto3" json:"statement_done,omitempty"` // "statement" updates: the statement completed (false when it starts)
+	RowsAffected  int64                  `protobuf:"varint,8,opt,name=rows_affected,json=rowsAffected,proto3" json:"rows_affected,omitempty"`    // Rows affected by a completed statement
+	ElapsedMs     int64                  `protobuf:"varint,9,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`             // Time a completed statement, or a finished migration, took
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MigrateProgress) Reset() {
 	*x = MigrateProgress{}
-	mi := &file_migration_proto_msgTypes[3]
+	mi := &file_migration_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -286,7 +400,7 @@ func (x *MigrateProgress) String() string {
 func (*MigrateProgress) ProtoMessage() {}
 
 func (x *MigrateProgress) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[3]
+	mi := &file_migration_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -299,7 +413,7 @@ func (x *MigrateProgress) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MigrateProgress.ProtoReflect.Descriptor instead.
 func (*MigrateProgress) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{3}
+	return file_migration_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *MigrateProgress) GetMigrationId() string {
@@ -330,6 +444,41 @@ func (x *MigrateProgress) GetProgress() int32 {
 	return 0
 }
 
+func (x *MigrateProgress) GetStatement() int32 {
+	if x != nil {
+		return x.Statement
+	}
+	return 0
+}
+
+func (x *MigrateProgress) GetStatements() int32 {
+	if x != nil {
+		return x.Statements
+	}
+	return 0
+}
+
+func (x *MigrateProgress) GetStatementDone() bool {
+	if x != nil {
+		return x.StatementDone
+	}
+	return false
+}
+
+func (x *MigrateProgress) GetRowsAffected() int64 {
+	if x != nil {
+		return x.RowsAffected
+	}
+	return 0
+}
+
+func (x *MigrateProgress) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
 // MigrateDownRequest represents a request to execute down migrations
 type MigrateDownRequest struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
@@ -337,13 +486,14 @@ type MigrateDownRequest struct {
 	Schemas            []string               `protobuf:"bytes,2,rep,name=schemas,proto3" json:"schemas,omitempty"`                                                  // Optional: Array for dynamic schemas
 	DryRun             bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                     // Optional, default false
 	IgnoreDependencies bool                   `protobuf:"varint,4,opt,name=ignore_dependencies,json=ignoreDependencies,proto3" json:"ignore_dependencies,omitempty"` // Optional, default false
+	CaptureSql         bool                   `protobuf:"varint,5,opt,name=capture_sql,json=captureSql,proto3" json:"capture_sql,omitempty"`                         // Optional: return the rendered SQL of each migration
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
 func (x *MigrateDownRequest) Reset() {
 	*x = MigrateDownRequest{}
-	mi := &file_migration_proto_msgTypes[4]
+	mi := &file_migration_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -355,7 +505,7 @@ func (x *MigrateDownRequest) String() string {
 func (*MigrateDownRequest) ProtoMessage() {}
 
 func (x *MigrateDownRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[4]
+	mi := &file_migration_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -368,7 +518,7 @@ func (x *MigrateDownRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MigrateDownRequest.ProtoReflect.Descriptor instead.
 func (*MigrateDownRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{4}
+	return file_migration_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *MigrateDownRequest) GetMigrationId() string {
@@ -399,34 +549,39 @@ func (x *MigrateDownRequest) GetIgnoreDependencies() bool {
 	return false
 }
 
-// ListMigrationsRequest represents a request to list migrations with filters
-type ListMigrationsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Schema        string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`         // Optional filter
-	Table         string                 `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`           // Optional filter
-	Connection    string                 `protobuf:"bytes,3,opt,name=connection,proto3" json:"connection,omitempty"` // Optional filter
-	Backend       string                 `protobuf:"bytes,4,opt,name=backend,proto3" json:"backend,omitempty"`       // Optional filter
-	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`         // Optional filter: "applied", "pending", etc.
-	Version       string                 `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`       // Optional filter
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *MigrateDownRequest) GetCaptureSql() bool {
+	if x != nil {
+		return x.CaptureSql
+	}
+	return false
 }
 
-func (x *ListMigrationsRequest) Reset() {
-	*x = ListMigrationsRequest{}
-	mi := &file_migration_proto_msgTypes[5]
+// PlanRequest represents a request for an execution plan (same selection as MigrateRequest)
+type PlanRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Target             *MigrationTarget       `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Connection         string                 `protobuf:"bytes,2,opt,name=connection,proto3" json:"connection,omitempty"`
+	Schemas            []string               `protobuf:"bytes,3,rep,name=schemas,proto3" json:"schemas,omitempty"`                                                  // Optional: Array for dynamic schemas
+	IgnoreDependencies bool                   `protobuf:"varint,4,opt,name=ignore_dependencies,json=ignoreDependencies,proto3" json:"ignore_dependencies,omitempty"` // Optional, default false
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PlanRequest) Reset() {
+	*x = PlanRequest{}
+	mi := &file_migration_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMigrationsRequest) String() string {
+func (x *PlanRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMigrationsRequest) ProtoMessage() {}
+func (*PlanRequest) ProtoMessage() {}
 
-func (x *ListMigrationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[5]
+func (x *PlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -437,77 +592,78 @@ func (x *ListMigrationsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMigrationsRequest.ProtoReflect.Descriptor instead.
-func (*ListMigrationsRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{5}
-}
-
-func (x *ListMigrationsRequest) GetSchema() string {
-	if x != nil {
-		return x.Schema
-	}
-	return ""
+// Deprecated: Use PlanRequest.ProtoReflect.Descriptor instead.
+func (*PlanRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ListMigrationsRequest) GetTable() string {
+func (x *PlanRequest) GetTarget() *MigrationTarget {
 	if x != nil {
-		return x.Table
+		return x.Target
 	}
-	return ""
+	return nil
 }
 
-func (x *ListMigrationsRequest) GetConnection() string {
+func (x *PlanRequest) GetConnection() string {
 	if x != nil {
 		return x.Connection
 	}
 	return ""
 }
 
-func (x *ListMigrationsRequest) GetBackend() string {
-	if x != nil {
-		return x.Backend
-	}
-	return ""
-}
-
-func (x *ListMigrationsRequest) GetStatus() string {
+func (x *PlanRequest) GetSchemas() []string {
 	if x != nil {
-		return x.Status
+		return x.Schemas
 	}
-	return ""
+	return nil
 }
 
-func (x *ListMigrationsRequest) GetVersion() string {
+func (x *PlanRequest) GetIgnoreDependencies() bool {
 	if x != nil {
-		return x.Version
+		return x.IgnoreDependencies
 	}
-	return ""
+	return false
 }
 
-// ListMigrationsResponse represents a list of migrations
-type ListMigrationsResponse struct {
+// PlanStep represents one migration in an execution plan
+type PlanStep struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*MigrationListItem   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
-	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Order         int32                  `protobuf:"varint,1,opt,name=order,proto3" json:"order,omitempty"` // 1-based position in the execution order
+	MigrationId   string                 `protobuf:"bytes,2,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Backend       string                 `protobuf:"bytes,5,opt,name=backend,proto3" json:"backend,omitempty"`
+	Connection    string                 `protobuf:"bytes,6,opt,name=connection,proto3" json:"connection,omitempty"`
+	Schema        string                 `protobuf:"bytes,7,opt,name=schema,proto3" json:"schema,omitempty"`
+	Action        string                 `protobuf:"bytes,8,opt,name=action,proto3" json:"action,omitempty"` // "apply" or "skip"
+	Reason        string                 `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,10,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`              // Migrations in the plan that must run first
+	RequiredBy    string                 `protobuf:"bytes,11,opt,name=required_by,json=requiredBy,proto3" json:"required_by,omitempty"`           // Set for auto-included pending dependencies
+	DownGenerated bool                   `protobuf:"varint,12,opt,name=down_generated,json=downGenerated,proto3" json:"down_generated,omitempty"` // Down script was generated from the up script
+	DownSql       string                 `protobuf:"bytes,13,opt,name=down_sql,json=downSql,proto3" json:"down_sql,omitempty"`                    // Generated down script, for review (set with down_generated)
+	Checksum      string                 `protobuf:"bytes,14,opt,name=checksum,proto3" json:"checksum,omitempty"`                                 // Checksum of the up script
+	Findings      []*ValidatorFinding    `protobuf:"bytes,15,rep,name=findings,proto3" json:"findings,omitempty"`                                 // Custom validator findings for a migration that would be applied
+	Statements    int32                  `protobuf:"varint,16,opt,name=statements,proto3" json:"statements,omitempty"`                            // Statements the up script would be executed as, on backends that split scripts
+	Destructive   []string               `protobuf:"bytes,17,rep,name=destructive,proto3" json:"destructive,omitempty"`                           // Kinds of statements of the up script that discard data
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListMigrationsResponse) Reset() {
-	*x = ListMigrationsResponse{}
-	mi := &file_migration_proto_msgTypes[6]
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_migration_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMigrationsResponse) String() string {
+func (x *PlanStep) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMigrationsResponse) ProtoMessage() {}
+func (*PlanStep) ProtoMessage() {}
 
-func (x *ListMigrationsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[6]
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -518,180 +674,154 @@ func (x *ListMigrationsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMigrationsResponse.ProtoReflect.Descriptor instead.
-func (*ListMigrationsResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *ListMigrationsResponse) GetItems() []*MigrationListItem {
+func (x *PlanStep) GetOrder() int32 {
 	if x != nil {
-		return x.Items
+		return x.Order
 	}
-	return nil
+	return 0
 }
 
-func (x *ListMigrationsResponse) GetTotal() int32 {
+func (x *PlanStep) GetMigrationId() string {
 	if x != nil {
-		return x.Total
+		return x.MigrationId
 	}
-	return 0
-}
-
-// MigrationListItem represents a single migration in the list
-type MigrationListItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Table         string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
-	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
-	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
-	Connection    string                 `protobuf:"bytes,6,opt,name=connection,proto3" json:"connection,omitempty"`
-	Backend       string                 `protobuf:"bytes,7,opt,name=backend,proto3" json:"backend,omitempty"`
-	Applied       bool                   `protobuf:"varint,8,opt,name=applied,proto3" json:"applied,omitempty"`
-	Status        string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
-	AppliedAt     string                 `protobuf:"bytes,10,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"` // RFC3339 timestamp
-	ErrorMessage  string                 `protobuf:"bytes,11,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
-	Tags          []string               `protobuf:"bytes,12,rep,name=tags,proto3" json:"tags,omitempty"` // key=value labels from registry (optional)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *MigrationListItem) Reset() {
-	*x = MigrationListItem{}
-	mi := &file_migration_proto_msgTypes[7]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+	return ""
 }
 
-func (x *MigrationListItem) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *PlanStep) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
 }
 
-func (*MigrationListItem) ProtoMessage() {}
-
-func (x *MigrationListItem) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[7]
+func (x *PlanStep) GetName() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Name
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use MigrationListItem.ProtoReflect.Descriptor instead.
-func (*MigrationListItem) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{7}
+func (x *PlanStep) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
 }
 
-func (x *MigrationListItem) GetMigrationId() string {
+func (x *PlanStep) GetConnection() string {
 	if x != nil {
-		return x.MigrationId
+		return x.Connection
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetSchema() string {
+func (x *PlanStep) GetSchema() string {
 	if x != nil {
 		return x.Schema
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetTable() string {
+func (x *PlanStep) GetAction() string {
 	if x != nil {
-		return x.Table
+		return x.Action
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetVersion() string {
+func (x *PlanStep) GetReason() string {
 	if x != nil {
-		return x.Version
+		return x.Reason
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetName() string {
+func (x *PlanStep) GetDependsOn() []string {
 	if x != nil {
-		return x.Name
+		return x.DependsOn
 	}
-	return ""
+	return nil
 }
 
-func (x *MigrationListItem) GetConnection() string {
+func (x *PlanStep) GetRequiredBy() string {
 	if x != nil {
-		return x.Connection
+		return x.RequiredBy
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetBackend() string {
+func (x *PlanStep) GetDownGenerated() bool {
 	if x != nil {
-		return x.Backend
+		return x.DownGenerated
 	}
-	return ""
+	return false
 }
 
-func (x *MigrationListItem) GetApplied() bool {
+func (x *PlanStep) GetDownSql() string {
 	if x != nil {
-		return x.Applied
+		return x.DownSql
 	}
-	return false
+	return ""
 }
 
-func (x *MigrationListItem) GetStatus() string {
+func (x *PlanStep) GetChecksum() string {
 	if x != nil {
-		return x.Status
+		return x.Checksum
 	}
 	return ""
 }
 
-func (x *MigrationListItem) GetAppliedAt() string {
+func (x *PlanStep) GetFindings() []*ValidatorFinding {
 	if x != nil {
-		return x.AppliedAt
+		return x.Findings
 	}
-	return ""
+	return nil
 }
 
-func (x *MigrationListItem) GetErrorMessage() string {
+func (x *PlanStep) GetStatements() int32 {
 	if x != nil {
-		return x.ErrorMessage
+		return x.Statements
 	}
-	return ""
+	return 0
 }
 
-func (x *MigrationListItem) GetTags() []string {
+func (x *PlanStep) GetDestructive() []string {
 	if x != nil {
-		return x.Tags
+		return x.Destructive
 	}
 	return nil
 }
 
-// GetMigrationRequest represents a request to get a specific migration
-type GetMigrationRequest struct {
+// ValidatorFinding is a problem a custom validator found in a migration
+type ValidatorFinding struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration to retrieve
+	Validator     string                 `protobuf:"bytes,1,opt,name=validator,proto3" json:"validator,omitempty"`
+	Severity      string                 `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"` // "error" (blocks the execution) or "warning"
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMigrationRequest) Reset() {
-	*x = GetMigrationRequest{}
+func (x *ValidatorFinding) Reset() {
+	*x = ValidatorFinding{}
 	mi := &file_migration_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMigrationRequest) String() string {
+func (x *ValidatorFinding) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMigrationRequest) ProtoMessage() {}
+func (*ValidatorFinding) ProtoMessage() {}
 
-func (x *GetMigrationRequest) ProtoReflect() protoreflect.Message {
+func (x *ValidatorFinding) ProtoReflect() protoreflect.Message {
 	mi := &file_migration_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -703,52 +833,58 @@ func (x *GetMigrationRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMigrationRequest.ProtoReflect.Descriptor instead.
-func (*GetMigrationRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ValidatorFinding.ProtoReflect.Descriptor instead.
+func (*ValidatorFinding) Descriptor() ([]byte, []int) {
 	return file_migration_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *GetMigrationRequest) GetMigrationId() string {
+func (x *ValidatorFinding) GetValidator() string {
 	if x != nil {
-		return x.MigrationId
+		return x.Validator
 	}
 	return ""
 }
 
-// MigrationDetailResponse represents detailed migration information
-type MigrationDetailResponse struct {
-	state                  protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId            string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	Schema                 string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Table                  string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
-	Version                string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
-	Name                   string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
-	Connection             string                 `protobuf:"bytes,6,opt,name=connection,proto3" json:"connection,omitempty"`
-	Backend                string                 `protobuf:"bytes,7,opt,name=backend,proto3" json:"backend,omitempty"`
-	Applied                bool                   `protobuf:"varint,8,opt,name=applied,proto3" json:"applied,omitempty"`
-	UpSql                  string                 `protobuf:"bytes,9,opt,name=up_sql,json=upSql,proto3" json:"up_sql,omitempty"`        // Contains SQL for SQL backends or JSON for NoSQL backends
-	DownSql                string                 `protobuf:"bytes,10,opt,name=down_sql,json=downSql,proto3" json:"down_sql,omitempty"` // Contains SQL for SQL backends or JSON for NoSQL backends
-	Dependencies           []string               `protobuf:"bytes,11,rep,name=dependencies,proto3" json:"dependencies,omitempty"`      // List of migration names this migration depends on
-	StructuredDependencies []*DependencyResponse  `protobuf:"bytes,12,rep,name=structured_dependencies,json=structuredDependencies,proto3" json:"structured_dependencies,omitempty"`
-	Tags                   []string               `protobuf:"bytes,13,rep,name=tags,proto3" json:"tags,omitempty"` // key=value labels from registry (optional)
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+func (x *ValidatorFinding) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
 }
 
-func (x *MigrationDetailResponse) Reset() {
-	*x = MigrationDetailResponse{}
+func (x *ValidatorFinding) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// PlanResponse represents a resolved, ordered execution plan
+type PlanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*PlanStep            `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	Apply         []string               `protobuf:"bytes,2,rep,name=apply,proto3" json:"apply,omitempty"`
+	Skip          []string               `protobuf:"bytes,3,rep,name=skip,proto3" json:"skip,omitempty"`
+	Errors        []string               `protobuf:"bytes,4,rep,name=errors,proto3" json:"errors,omitempty"`
+	Checksums     map[string]string      `protobuf:"bytes,5,rep,name=checksums,proto3" json:"checksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Checksum of each migration in apply, for MigrateRequest.pinned_checksums
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanResponse) Reset() {
+	*x = PlanResponse{}
 	mi := &file_migration_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MigrationDetailResponse) String() string {
+func (x *PlanResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MigrationDetailResponse) ProtoMessage() {}
+func (*PlanResponse) ProtoMessage() {}
 
-func (x *MigrationDetailResponse) ProtoReflect() protoreflect.Message {
+func (x *PlanResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_migration_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -760,129 +896,78 @@ func (x *MigrationDetailResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MigrationDetailResponse.ProtoReflect.Descriptor instead.
-func (*MigrationDetailResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use PlanResponse.ProtoReflect.Descriptor instead.
+func (*PlanResponse) Descriptor() ([]byte, []int) {
 	return file_migration_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *MigrationDetailResponse) GetMigrationId() string {
-	if x != nil {
-		return x.MigrationId
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetSchema() string {
-	if x != nil {
-		return x.Schema
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetTable() string {
-	if x != nil {
-		return x.Table
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetVersion() string {
-	if x != nil {
-		return x.Version
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetConnection() string {
-	if x != nil {
-		return x.Connection
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetBackend() string {
-	if x != nil {
-		return x.Backend
-	}
-	return ""
-}
-
-func (x *MigrationDetailResponse) GetApplied() bool {
-	if x != nil {
-		return x.Applied
-	}
-	return false
-}
-
-func (x *MigrationDetailResponse) GetUpSql() string {
+func (x *PlanResponse) GetSteps() []*PlanStep {
 	if x != nil {
-		return x.UpSql
+		return x.Steps
 	}
-	return ""
+	return nil
 }
 
-func (x *MigrationDetailResponse) GetDownSql() string {
+func (x *PlanResponse) GetApply() []string {
 	if x != nil {
-		return x.DownSql
+		return x.Apply
 	}
-	return ""
+	return nil
 }
 
-func (x *MigrationDetailResponse) GetDependencies() []string {
+func (x *PlanResponse) GetSkip() []string {
 	if x != nil {
-		return x.Dependencies
+		return x.Skip
 	}
 	return nil
 }
 
-func (x *MigrationDetailResponse) GetStructuredDependencies() []*DependencyResponse {
+func (x *PlanResponse) GetErrors() []string {
 	if x != nil {
-		return x.StructuredDependencies
+		return x.Errors
 	}
 	return nil
 }
 
-func (x *MigrationDetailResponse) GetTags() []string {
+func (x *PlanResponse) GetChecksums() map[string]string {
 	if x != nil {
-		return x.Tags
+		return x.Checksums
 	}
 	return nil
 }
 
-// DependencyResponse represents a structured dependency
-type DependencyResponse struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Connection     string                 `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
-	Schema         string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Target         string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
-	TargetType     string                 `protobuf:"bytes,4,opt,name=target_type,json=targetType,proto3" json:"target_type,omitempty"`             // "name", "version", etc.
-	RequiresTable  string                 `protobuf:"bytes,5,opt,name=requires_table,json=requiresTable,proto3" json:"requires_table,omitempty"`    // Optional
-	RequiresSchema string                 `protobuf:"bytes,6,opt,name=requires_schema,json=requiresSchema,proto3" json:"requires_schema,omitempty"` // Optional
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// ListMigrationsRequest represents a request to list migrations with filters
+type ListMigrationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`                         // Optional filter
+	Table         string                 `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`                           // Optional filter
+	Connection    string                 `protobuf:"bytes,3,opt,name=connection,proto3" json:"connection,omitempty"`                 // Optional filter
+	Backend       string                 `protobuf:"bytes,4,opt,name=backend,proto3" json:"backend,omitempty"`                       // Optional filter
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`                         // Optional filter: "applied", "pending", etc.
+	Version       string                 `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`                       // Optional filter
+	Label         string                 `protobuf:"bytes,7,opt,name=label,proto3" json:"label,omitempty"`                           // Optional filter: user-defined status label
+	Limit         int32                  `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`                          // Optional: maximum number of items (default: all)
+	Offset        int32                  `protobuf:"varint,9,opt,name=offset,proto3" json:"offset,omitempty"`                        // Optional: number of items to skip
+	SortBy        string                 `protobuf:"bytes,10,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`          // Optional: migration_id, schema, version (default), connection, backend, status or applied_at
+	SortOrder     string                 `protobuf:"bytes,11,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"` // Optional: "asc" (default) or "desc"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DependencyResponse) Reset() {
-	*x = DependencyResponse{}
+func (x *ListMigrationsRequest) Reset() {
+	*x = ListMigrationsRequest{}
 	mi := &file_migration_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DependencyResponse) String() string {
+func (x *ListMigrationsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DependencyResponse) ProtoMessage() {}
+func (*ListMigrationsRequest) ProtoMessage() {}
 
-func (x *DependencyResponse) ProtoReflect() protoreflect.Message {
+func (x *ListMigrationsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_migration_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -894,75 +979,113 @@ func (x *DependencyResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DependencyResponse.ProtoReflect.Descriptor instead.
-func (*DependencyResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListMigrationsRequest.ProtoReflect.Descriptor instead.
+func (*ListMigrationsRequest) Descriptor() ([]byte, []int) {
 	return file_migration_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *DependencyResponse) GetConnection() string {
+func (x *ListMigrationsRequest) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *ListMigrationsRequest) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *ListMigrationsRequest) GetConnection() string {
 	if x != nil {
 		return x.Connection
 	}
 	return ""
 }
 
-func (x *DependencyResponse) GetSchema() string {
+func (x *ListMigrationsRequest) GetBackend() string {
 	if x != nil {
-		return x.Schema
+		return x.Backend
 	}
 	return ""
 }
 
-func (x *DependencyResponse) GetTarget() string {
+func (x *ListMigrationsRequest) GetStatus() string {
 	if x != nil {
-		return x.Target
+		return x.Status
 	}
 	return ""
 }
 
-func (x *DependencyResponse) GetTargetType() string {
+func (x *ListMigrationsRequest) GetVersion() string {
 	if x != nil {
-		return x.TargetType
+		return x.Version
 	}
 	return ""
 }
 
-func (x *DependencyResponse) GetRequiresTable() string {
+func (x *ListMigrationsRequest) GetLabel() string {
 	if x != nil {
-		return x.RequiresTable
+		return x.Label
 	}
 	return ""
 }
 
-func (x *DependencyResponse) GetRequiresSchema() string {
+func (x *ListMigrationsRequest) GetLimit() int32 {
 	if x != nil {
-		return x.RequiresSchema
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListMigrationsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListMigrationsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
 	}
 	return ""
 }
 
-// GetMigrationStatusRequest represents a request to get migration status
-type GetMigrationStatusRequest struct {
+func (x *ListMigrationsRequest) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+// ListMigrationsResponse represents a page of the list of migrations
+type ListMigrationsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration
+	Items         []*MigrationListItem   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // Number of migrations matching the filters
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMigrationStatusRequest) Reset() {
-	*x = GetMigrationStatusRequest{}
+func (x *ListMigrationsResponse) Reset() {
+	*x = ListMigrationsResponse{}
 	mi := &file_migration_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMigrationStatusRequest) String() string {
+func (x *ListMigrationsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMigrationStatusRequest) ProtoMessage() {}
+func (*ListMigrationsResponse) ProtoMessage() {}
 
-func (x *GetMigrationStatusRequest) ProtoReflect() protoreflect.Message {
+func (x *ListMigrationsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_migration_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -974,45 +1097,916 @@ func (x *GetMigrationStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMigrationStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetMigrationStatusRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListMigrationsResponse.ProtoReflect.Descriptor instead.
+func (*ListMigrationsResponse) Descriptor() ([]byte, []int) {
 	return file_migration_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *GetMigrationStatusRequest) GetMigrationId() string {
+func (x *ListMigrationsResponse) GetItems() []*MigrationListItem {
 	if x != nil {
-		return x.MigrationId
+		return x.Items
 	}
-	return ""
+	return nil
 }
 
-// MigrationStatusResponse represents the status of a migration
-type MigrationStatusResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "applied", "rolled_back", "failed", etc.
-	Applied       bool                   `protobuf:"varint,3,opt,name=applied,proto3" json:"applied,omitempty"`
-	AppliedAt     string                 `protobuf:"bytes,4,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"`          // RFC3339 timestamp, optional
+func (x *ListMigrationsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListMigrationsResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListMigrationsResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// MigrationListItem represents a single migration in the list
+type MigrationListItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table         string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Connection    string                 `protobuf:"bytes,6,opt,name=connection,proto3" json:"connection,omitempty"`
+	Backend       string                 `protobuf:"bytes,7,opt,name=backend,proto3" json:"backend,omitempty"`
+	Applied       bool                   `protobuf:"varint,8,opt,name=applied,proto3" json:"applied,omitempty"`
+	Status        string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
+	AppliedAt     string                 `protobuf:"bytes,10,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"` // RFC3339 timestamp
+	ErrorMessage  string                 `protobuf:"bytes,11,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Tags          []string               `protobuf:"bytes,12,rep,name=tags,proto3" json:"tags,omitempty"`                                     // key=value labels from registry (optional)
+	StatusLabels  []string               `protobuf:"bytes,13,rep,name=status_labels,json=statusLabels,proto3" json:"status_labels,omitempty"` // User-defined status labels (e.g. "verified")
+	Destructive   []string               `protobuf:"bytes,14,rep,name=destructive,proto3" json:"destructive,omitempty"`                       // Kinds of statements discarding data, from registry (optional)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MigrationListItem) Reset() {
+	*x = MigrationListItem{}
+	mi := &file_migration_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationListItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationListItem) ProtoMessage() {}
+
+func (x *MigrationListItem) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationListItem.ProtoReflect.Descriptor instead.
+func (*MigrationListItem) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MigrationListItem) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetConnection() string {
+	if x != nil {
+		return x.Connection
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+func (x *MigrationListItem) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetAppliedAt() string {
+	if x != nil {
+		return x.AppliedAt
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *MigrationListItem) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *MigrationListItem) GetStatusLabels() []string {
+	if x != nil {
+		return x.StatusLabels
+	}
+	return nil
+}
+
+func (x *MigrationListItem) GetDestructive() []string {
+	if x != nil {
+		return x.Destructive
+	}
+	return nil
+}
+
+// GetMigrationRequest represents a request to get a specific migration
+type GetMigrationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration to retrieve
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMigrationRequest) Reset() {
+	*x = GetMigrationRequest{}
+	mi := &file_migration_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMigrationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMigrationRequest) ProtoMessage() {}
+
+func (x *GetMigrationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMigrationRequest.ProtoReflect.Descriptor instead.
+func (*GetMigrationRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetMigrationRequest) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+// MigrationDetailResponse represents detailed migration information
+type MigrationDetailResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId            string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Schema                 string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table                  string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Version                string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Name                   string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Connection             string                 `protobuf:"bytes,6,opt,name=connection,proto3" json:"connection,omitempty"`
+	Backend                string                 `protobuf:"bytes,7,opt,name=backend,proto3" json:"backend,omitempty"`
+	Applied                bool                   `protobuf:"varint,8,opt,name=applied,proto3" json:"applied,omitempty"`
+	UpSql                  string                 `protobuf:"bytes,9,opt,name=up_sql,json=upSql,proto3" json:"up_sql,omitempty"`        // Contains SQL for SQL backends or JSON for NoSQL backends
+	DownSql                string                 `protobuf:"bytes,10,opt,name=down_sql,json=downSql,proto3" json:"down_sql,omitempty"` // Contains SQL for SQL backends or JSON for NoSQL backends
+	Dependencies           []string               `protobuf:"bytes,11,rep,name=dependencies,proto3" json:"dependencies,omitempty"`      // List of migration names this migration depends on
+	StructuredDependencies []*DependencyResponse  `protobuf:"bytes,12,rep,name=structured_dependencies,json=structuredDependencies,proto3" json:"structured_dependencies,omitempty"`
+	Tags                   []string               `protobuf:"bytes,13,rep,name=tags,proto3" json:"tags,omitempty"`                                         // key=value labels from registry (optional)
+	DownGenerated          bool                   `protobuf:"varint,14,opt,name=down_generated,json=downGenerated,proto3" json:"down_generated,omitempty"` // down_sql was generated from up_sql (no down script was provided)
+	StatusLabels           []string               `protobuf:"bytes,15,rep,name=status_labels,json=statusLabels,proto3" json:"status_labels,omitempty"`     // User-defined status labels (e.g. "verified")
+	Repeatable             bool                   `protobuf:"varint,16,opt,name=repeatable,proto3" json:"repeatable,omitempty"`                            // Applied again whenever up_sql changes
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *MigrationDetailResponse) Reset() {
+	*x = MigrationDetailResponse{}
+	mi := &file_migration_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationDetailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationDetailResponse) ProtoMessage() {}
+
+func (x *MigrationDetailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationDetailResponse.ProtoReflect.Descriptor instead.
+func (*MigrationDetailResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MigrationDetailResponse) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetConnection() string {
+	if x != nil {
+		return x.Connection
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+func (x *MigrationDetailResponse) GetUpSql() string {
+	if x != nil {
+		return x.UpSql
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetDownSql() string {
+	if x != nil {
+		return x.DownSql
+	}
+	return ""
+}
+
+func (x *MigrationDetailResponse) GetDependencies() []string {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+func (x *MigrationDetailResponse) GetStructuredDependencies() []*DependencyResponse {
+	if x != nil {
+		return x.StructuredDependencies
+	}
+	return nil
+}
+
+func (x *MigrationDetailResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *MigrationDetailResponse) GetDownGenerated() bool {
+	if x != nil {
+		return x.DownGenerated
+	}
+	return false
+}
+
+func (x *MigrationDetailResponse) GetStatusLabels() []string {
+	if x != nil {
+		return x.StatusLabels
+	}
+	return nil
+}
+
+func (x *MigrationDetailResponse) GetRepeatable() bool {
+	if x != nil {
+		return x.Repeatable
+	}
+	return false
+}
+
+// DependencyResponse represents a structured dependency
+type DependencyResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Connection     string                 `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
+	Schema         string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Target         string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	TargetType     string                 `protobuf:"bytes,4,opt,name=target_type,json=targetType,proto3" json:"target_type,omitempty"`             // "name", "version", "min_version" or "version_range"
+	RequiresTable  string                 `protobuf:"bytes,5,opt,name=requires_table,json=requiresTable,proto3" json:"requires_table,omitempty"`    // Optional
+	RequiresSchema string                 `protobuf:"bytes,6,opt,name=requires_schema,json=requiresSchema,proto3" json:"requires_schema,omitempty"` // Optional
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DependencyResponse) Reset() {
+	*x = DependencyResponse{}
+	mi := &file_migration_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DependencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyResponse) ProtoMessage() {}
+
+func (x *DependencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyResponse.ProtoReflect.Descriptor instead.
+func (*DependencyResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DependencyResponse) GetConnection() string {
+	if x != nil {
+		return x.Connection
+	}
+	return ""
+}
+
+func (x *DependencyResponse) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *DependencyResponse) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *DependencyResponse) GetTargetType() string {
+	if x != nil {
+		return x.TargetType
+	}
+	return ""
+}
+
+func (x *DependencyResponse) GetRequiresTable() string {
+	if x != nil {
+		return x.RequiresTable
+	}
+	return ""
+}
+
+func (x *DependencyResponse) GetRequiresSchema() string {
+	if x != nil {
+		return x.RequiresSchema
+	}
+	return ""
+}
+
+// GetMigrationStatusRequest represents a request to get migration status
+type GetMigrationStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMigrationStatusRequest) Reset() {
+	*x = GetMigrationStatusRequest{}
+	mi := &file_migration_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMigrationStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMigrationStatusRequest) ProtoMessage() {}
+
+func (x *GetMigrationStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMigrationStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetMigrationStatusRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetMigrationStatusRequest) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+// MigrationStatusResponse represents the status of a migration
+type MigrationStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "applied", "rolled_back", "failed", etc.
+	Applied       bool                   `protobuf:"varint,3,opt,name=applied,proto3" json:"applied,omitempty"`
+	AppliedAt     string                 `protobuf:"bytes,4,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"`          // RFC3339 timestamp, optional
 	ErrorMessage  string                 `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // Optional
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MigrationStatusResponse) Reset() {
-	*x = MigrationStatusResponse{}
-	mi := &file_migration_proto_msgTypes[12]
+func (x *MigrationStatusResponse) Reset() {
+	*x = MigrationStatusResponse{}
+	mi := &file_migration_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationStatusResponse) ProtoMessage() {}
+
+func (x *MigrationStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationStatusResponse.ProtoReflect.Descriptor instead.
+func (*MigrationStatusResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MigrationStatusResponse) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *MigrationStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *MigrationStatusResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+func (x *MigrationStatusResponse) GetAppliedAt() string {
+	if x != nil {
+		return x.AppliedAt
+	}
+	return ""
+}
+
+func (x *MigrationStatusResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+// IsMigrationAppliedRequest represents a request to check if a migration is applied
+type IsMigrationAppliedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration to check
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IsMigrationAppliedRequest) Reset() {
+	*x = IsMigrationAppliedRequest{}
+	mi := &file_migration_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IsMigrationAppliedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsMigrationAppliedRequest) ProtoMessage() {}
+
+func (x *IsMigrationAppliedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsMigrationAppliedRequest.ProtoReflect.Descriptor instead.
+func (*IsMigrationAppliedRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *IsMigrationAppliedRequest) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+// IsMigrationAppliedResponse represents the result of checking if a migration is applied
+type IsMigrationAppliedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Applied       bool                   `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"` // True if migration is applied, false otherwise
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IsMigrationAppliedResponse) Reset() {
+	*x = IsMigrationAppliedResponse{}
+	mi := &file_migration_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IsMigrationAppliedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsMigrationAppliedResponse) ProtoMessage() {}
+
+func (x *IsMigrationAppliedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsMigrationAppliedResponse.ProtoReflect.Descriptor instead.
+func (*IsMigrationAppliedResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *IsMigrationAppliedResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+// GetMigrationHistoryRequest represents a request to get migration history
+type GetMigrationHistoryRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId     string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`             // Optional: ID of migration (default: all migrations)
+	Limit           int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                                           // Optional: maximum number of records (default: all)
+	Offset          int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`                                         // Optional: number of records to skip
+	SortBy          string                 `protobuf:"bytes,4,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`                            // Optional: migration_id, schema, version, connection, backend, status or applied_at (default)
+	SortOrder       string                 `protobuf:"bytes,5,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`                   // Optional: "asc" or "desc" (default, asc when sort_by is set)
+	AppliedAfter    string                 `protobuf:"bytes,6,opt,name=applied_after,json=appliedAfter,proto3" json:"applied_after,omitempty"`          // Optional: records applied at or after this RFC 3339 time
+	AppliedBefore   string                 `protobuf:"bytes,7,opt,name=applied_before,json=appliedBefore,proto3" json:"applied_before,omitempty"`       // Optional: records applied before this RFC 3339 time
+	ExecutedBy      string                 `protobuf:"bytes,8,opt,name=executed_by,json=executedBy,proto3" json:"executed_by,omitempty"`                // Optional: records executed by this user
+	ExecutionMethod string                 `protobuf:"bytes,9,opt,name=execution_method,json=executionMethod,proto3" json:"execution_method,omitempty"` // Optional: records executed with this method (manual, api, cli, worker)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetMigrationHistoryRequest) Reset() {
+	*x = GetMigrationHistoryRequest{}
+	mi := &file_migration_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMigrationHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMigrationHistoryRequest) ProtoMessage() {}
+
+func (x *GetMigrationHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMigrationHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetMigrationHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetMigrationHistoryRequest) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetMigrationHistoryRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetMigrationHistoryRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetAppliedAfter() string {
+	if x != nil {
+		return x.AppliedAfter
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetAppliedBefore() string {
+	if x != nil {
+		return x.AppliedBefore
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetExecutedBy() string {
+	if x != nil {
+		return x.ExecutedBy
+	}
+	return ""
+}
+
+func (x *GetMigrationHistoryRequest) GetExecutionMethod() string {
+	if x != nil {
+		return x.ExecutionMethod
+	}
+	return ""
+}
+
+// MigrationHistoryResponse represents the execution history of a migration
+type MigrationHistoryResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	MigrationId   string                  `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	History       []*MigrationHistoryItem `protobuf:"bytes,2,rep,name=history,proto3" json:"history,omitempty"`
+	Total         int32                   `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"` // Number of records matching the filters
+	Limit         int32                   `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                   `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MigrationHistoryResponse) Reset() {
+	*x = MigrationHistoryResponse{}
+	mi := &file_migration_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrationHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrationHistoryResponse) ProtoMessage() {}
+
+func (x *MigrationHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrationHistoryResponse.ProtoReflect.Descriptor instead.
+func (*MigrationHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MigrationHistoryResponse) GetMigrationId() string {
+	if x != nil {
+		return x.MigrationId
+	}
+	return ""
+}
+
+func (x *MigrationHistoryResponse) GetHistory() []*MigrationHistoryItem {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *MigrationHistoryResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *MigrationHistoryResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *MigrationHistoryResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// MigrationHistoryItem represents a single history entry
+type MigrationHistoryItem struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId      string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Schema           string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table            string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Version          string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Connection       string                 `protobuf:"bytes,5,opt,name=connection,proto3" json:"connection,omitempty"`
+	Backend          string                 `protobuf:"bytes,6,opt,name=backend,proto3" json:"backend,omitempty"`
+	AppliedAt        string                 `protobuf:"bytes,7,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"`                       // RFC3339 timestamp
+	Status           string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`                                              // "success", "failed", "pending", etc.
+	ErrorMessage     string                 `protobuf:"bytes,9,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`              // Optional
+	ExecutedBy       string                 `protobuf:"bytes,10,opt,name=executed_by,json=executedBy,proto3" json:"executed_by,omitempty"`                   // Who executed the migration
+	ExecutionMethod  string                 `protobuf:"bytes,11,opt,name=execution_method,json=executionMethod,proto3" json:"execution_method,omitempty"`    // "manual", "api", etc.
+	ExecutionContext string                 `protobuf:"bytes,12,opt,name=execution_context,json=executionContext,proto3" json:"execution_context,omitempty"` // JSON string with execution context
+	Operation        string                 `protobuf:"bytes,13,opt,name=operation,proto3" json:"operation,omitempty"`                                       // "up", "down" or "rollback"
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MigrationHistoryItem) Reset() {
+	*x = MigrationHistoryItem{}
+	mi := &file_migration_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MigrationStatusResponse) String() string {
+func (x *MigrationHistoryItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MigrationStatusResponse) ProtoMessage() {}
+func (*MigrationHistoryItem) ProtoMessage() {}
 
-func (x *MigrationStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[12]
+func (x *MigrationHistoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1023,69 +2017,126 @@ func (x *MigrationStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MigrationStatusResponse.ProtoReflect.Descriptor instead.
-func (*MigrationStatusResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use MigrationHistoryItem.ProtoReflect.Descriptor instead.
+func (*MigrationHistoryItem) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *MigrationStatusResponse) GetMigrationId() string {
+func (x *MigrationHistoryItem) GetMigrationId() string {
 	if x != nil {
 		return x.MigrationId
 	}
 	return ""
 }
 
-func (x *MigrationStatusResponse) GetStatus() string {
+func (x *MigrationHistoryItem) GetSchema() string {
 	if x != nil {
-		return x.Status
+		return x.Schema
 	}
 	return ""
 }
 
-func (x *MigrationStatusResponse) GetApplied() bool {
+func (x *MigrationHistoryItem) GetTable() string {
 	if x != nil {
-		return x.Applied
+		return x.Table
 	}
-	return false
+	return ""
 }
 
-func (x *MigrationStatusResponse) GetAppliedAt() string {
+func (x *MigrationHistoryItem) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetConnection() string {
+	if x != nil {
+		return x.Connection
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetAppliedAt() string {
 	if x != nil {
 		return x.AppliedAt
 	}
 	return ""
 }
 
-func (x *MigrationStatusResponse) GetErrorMessage() string {
+func (x *MigrationHistoryItem) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetErrorMessage() string {
 	if x != nil {
 		return x.ErrorMessage
 	}
 	return ""
 }
 
-// IsMigrationAppliedRequest represents a request to check if a migration is applied
-type IsMigrationAppliedRequest struct {
+func (x *MigrationHistoryItem) GetExecutedBy() string {
+	if x != nil {
+		return x.ExecutedBy
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetExecutionMethod() string {
+	if x != nil {
+		return x.ExecutionMethod
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetExecutionContext() string {
+	if x != nil {
+		return x.ExecutionContext
+	}
+	return ""
+}
+
+func (x *MigrationHistoryItem) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+// GetPendingMigrationsRequest represents a request to list pending migrations
+type GetPendingMigrationsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration to check
+	Connection    string                 `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"` // Required: Connection name
+	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`         // Optional: Schema for dynamic-schema migrations
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *IsMigrationAppliedRequest) Reset() {
-	*x = IsMigrationAppliedRequest{}
-	mi := &file_migration_proto_msgTypes[13]
+func (x *GetPendingMigrationsRequest) Reset() {
+	*x = GetPendingMigrationsRequest{}
+	mi := &file_migration_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *IsMigrationAppliedRequest) String() string {
+func (x *GetPendingMigrationsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IsMigrationAppliedRequest) ProtoMessage() {}
+func (*GetPendingMigrationsRequest) ProtoMessage() {}
 
-func (x *IsMigrationAppliedRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[13]
+func (x *GetPendingMigrationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1096,41 +2147,51 @@ func (x *IsMigrationAppliedRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IsMigrationAppliedRequest.ProtoReflect.Descriptor instead.
-func (*IsMigrationAppliedRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use GetPendingMigrationsRequest.ProtoReflect.Descriptor instead.
+func (*GetPendingMigrationsRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *IsMigrationAppliedRequest) GetMigrationId() string {
+func (x *GetPendingMigrationsRequest) GetConnection() string {
 	if x != nil {
-		return x.MigrationId
+		return x.Connection
 	}
 	return ""
 }
 
-// IsMigrationAppliedResponse represents the result of checking if a migration is applied
-type IsMigrationAppliedResponse struct {
+func (x *GetPendingMigrationsRequest) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+// PendingMigrationsResponse lists the migrations of a connection that are not applied yet
+type PendingMigrationsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Applied       bool                   `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"` // True if migration is applied, false otherwise
+	Connection    string                 `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
+	Schema        string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Items         []*PendingMigration    `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *IsMigrationAppliedResponse) Reset() {
-	*x = IsMigrationAppliedResponse{}
-	mi := &file_migration_proto_msgTypes[14]
+func (x *PendingMigrationsResponse) Reset() {
+	*x = PendingMigrationsResponse{}
+	mi := &file_migration_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *IsMigrationAppliedResponse) String() string {
+func (x *PendingMigrationsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IsMigrationAppliedResponse) ProtoMessage() {}
+func (*PendingMigrationsResponse) ProtoMessage() {}
 
-func (x *IsMigrationAppliedResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[14]
+func (x *PendingMigrationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1141,41 +2202,64 @@ func (x *IsMigrationAppliedResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IsMigrationAppliedResponse.ProtoReflect.Descriptor instead.
-func (*IsMigrationAppliedResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use PendingMigrationsResponse.ProtoReflect.Descriptor instead.
+func (*PendingMigrationsResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *IsMigrationAppliedResponse) GetApplied() bool {
+func (x *PendingMigrationsResponse) GetConnection() string {
 	if x != nil {
-		return x.Applied
+		return x.Connection
 	}
-	return false
+	return ""
 }
 
-// GetMigrationHistoryRequest represents a request to get migration history
-type GetMigrationHistoryRequest struct {
+func (x *PendingMigrationsResponse) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *PendingMigrationsResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PendingMigrationsResponse) GetItems() []*PendingMigration {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// GetMigrationSchemasRequest represents a request for the status of a migration in each schema
+type GetMigrationSchemasRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"` // Required: ID of migration
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`       // Required: ID of migration
+	Schemas       []string               `protobuf:"bytes,2,rep,name=schemas,proto3" json:"schemas,omitempty"`                                  // Optional: Schemas to report even if nothing ran in them yet
+	SchemaPattern string                 `protobuf:"bytes,3,opt,name=schema_pattern,json=schemaPattern,proto3" json:"schema_pattern,omitempty"` // Optional: SQL LIKE pattern of schemas to discover on the connection
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMigrationHistoryRequest) Reset() {
-	*x = GetMigrationHistoryRequest{}
-	mi := &file_migration_proto_msgTypes[15]
+func (x *GetMigrationSchemasRequest) Reset() {
+	*x = GetMigrationSchemasRequest{}
+	mi := &file_migration_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMigrationHistoryRequest) String() string {
+func (x *GetMigrationSchemasRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMigrationHistoryRequest) ProtoMessage() {}
+func (*GetMigrationSchemasRequest) ProtoMessage() {}
 
-func (x *GetMigrationHistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[15]
+func (x *GetMigrationSchemasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1186,42 +2270,60 @@ func (x *GetMigrationHistoryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMigrationHistoryRequest.ProtoReflect.Descriptor instead.
-func (*GetMigrationHistoryRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use GetMigrationSchemasRequest.ProtoReflect.Descriptor instead.
+func (*GetMigrationSchemasRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *GetMigrationHistoryRequest) GetMigrationId() string {
+func (x *GetMigrationSchemasRequest) GetMigrationId() string {
 	if x != nil {
 		return x.MigrationId
 	}
 	return ""
 }
 
-// MigrationHistoryResponse represents the execution history of a migration
-type MigrationHistoryResponse struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	MigrationId   string                  `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	History       []*MigrationHistoryItem `protobuf:"bytes,2,rep,name=history,proto3" json:"history,omitempty"`
+func (x *GetMigrationSchemasRequest) GetSchemas() []string {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+func (x *GetMigrationSchemasRequest) GetSchemaPattern() string {
+	if x != nil {
+		return x.SchemaPattern
+	}
+	return ""
+}
+
+// MigrationSchemasResponse is the status of a migration in each schema of its connection
+type MigrationSchemasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Connection    string                 `protobuf:"bytes,2,opt,name=connection,proto3" json:"connection,omitempty"`
+	Applied       int32                  `protobuf:"varint,3,opt,name=applied,proto3" json:"applied,omitempty"` // Number of schemas it is applied in
+	Failed        int32                  `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	Pending       int32                  `protobuf:"varint,5,opt,name=pending,proto3" json:"pending,omitempty"`
+	Schemas       []*SchemaStatus        `protobuf:"bytes,6,rep,name=schemas,proto3" json:"schemas,omitempty"` // Sorted by schema
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MigrationHistoryResponse) Reset() {
-	*x = MigrationHistoryResponse{}
-	mi := &file_migration_proto_msgTypes[16]
+func (x *MigrationSchemasResponse) Reset() {
+	*x = MigrationSchemasResponse{}
+	mi := &file_migration_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MigrationHistoryResponse) String() string {
+func (x *MigrationSchemasResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MigrationHistoryResponse) ProtoMessage() {}
+func (*MigrationSchemasResponse) ProtoMessage() {}
 
-func (x *MigrationHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[16]
+func (x *MigrationSchemasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1232,59 +2334,80 @@ func (x *MigrationHistoryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MigrationHistoryResponse.ProtoReflect.Descriptor instead.
-func (*MigrationHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use MigrationSchemasResponse.ProtoReflect.Descriptor instead.
+func (*MigrationSchemasResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *MigrationHistoryResponse) GetMigrationId() string {
+func (x *MigrationSchemasResponse) GetMigrationId() string {
 	if x != nil {
 		return x.MigrationId
 	}
 	return ""
 }
 
-func (x *MigrationHistoryResponse) GetHistory() []*MigrationHistoryItem {
+func (x *MigrationSchemasResponse) GetConnection() string {
 	if x != nil {
-		return x.History
+		return x.Connection
 	}
-	return nil
+	return ""
 }
 
-// MigrationHistoryItem represents a single history entry
-type MigrationHistoryItem struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	MigrationId      string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
-	Schema           string                 `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Table            string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
-	Version          string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
-	Connection       string                 `protobuf:"bytes,5,opt,name=connection,proto3" json:"connection,omitempty"`
-	Backend          string                 `protobuf:"bytes,6,opt,name=backend,proto3" json:"backend,omitempty"`
-	AppliedAt        string                 `protobuf:"bytes,7,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"`                       // RFC3339 timestamp
-	Status           string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`                                              // "success", "failed", "pending", etc.
-	ErrorMessage     string                 `protobuf:"bytes,9,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`              // Optional
-	ExecutedBy       string                 `protobuf:"bytes,10,opt,name=executed_by,json=executedBy,proto3" json:"executed_by,omitempty"`                   // Who executed the migration
-	ExecutionMethod  string                 `protobuf:"bytes,11,opt,name=execution_method,json=executionMethod,proto3" json:"execution_method,omitempty"`    // "manual", "api", etc.
-	ExecutionContext string                 `protobuf:"bytes,12,opt,name=execution_context,json=executionContext,proto3" json:"execution_context,omitempty"` // JSON string with execution context
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *MigrationSchemasResponse) GetApplied() int32 {
+	if x != nil {
+		return x.Applied
+	}
+	return 0
+}
+
+func (x *MigrationSchemasResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *MigrationSchemasResponse) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
 }
 
-func (x *MigrationHistoryItem) Reset() {
-	*x = MigrationHistoryItem{}
-	mi := &file_migration_proto_msgTypes[17]
+func (x *MigrationSchemasResponse) GetSchemas() []*SchemaStatus {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+// SchemaStatus is the status of a migration in one schema
+type SchemaStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                        // "applied", "failed" or "pending"
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`                      // Optional
+	AppliedAt     string                 `protobuf:"bytes,4,opt,name=applied_at,json=appliedAt,proto3" json:"applied_at,omitempty"` // RFC3339 timestamp, optional
+	UpdatedAt     string                 `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // Last execution in the schema; empty if it never ran there
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaStatus) Reset() {
+	*x = SchemaStatus{}
+	mi := &file_migration_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MigrationHistoryItem) String() string {
+func (x *SchemaStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MigrationHistoryItem) ProtoMessage() {}
+func (*SchemaStatus) ProtoMessage() {}
 
-func (x *MigrationHistoryItem) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[17]
+func (x *SchemaStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1295,91 +2418,127 @@ func (x *MigrationHistoryItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MigrationHistoryItem.ProtoReflect.Descriptor instead.
-func (*MigrationHistoryItem) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use SchemaStatus.ProtoReflect.Descriptor instead.
+func (*SchemaStatus) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *MigrationHistoryItem) GetMigrationId() string {
+func (x *SchemaStatus) GetSchema() string {
 	if x != nil {
-		return x.MigrationId
+		return x.Schema
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetSchema() string {
+func (x *SchemaStatus) GetStatus() string {
 	if x != nil {
-		return x.Schema
+		return x.Status
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetTable() string {
+func (x *SchemaStatus) GetVersion() string {
 	if x != nil {
-		return x.Table
+		return x.Version
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetVersion() string {
+func (x *SchemaStatus) GetAppliedAt() string {
 	if x != nil {
-		return x.Version
+		return x.AppliedAt
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetConnection() string {
+func (x *SchemaStatus) GetUpdatedAt() string {
 	if x != nil {
-		return x.Connection
+		return x.UpdatedAt
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetBackend() string {
+// PendingMigration represents a registered migration that has not been applied
+type PendingMigration struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MigrationId   string                 `protobuf:"bytes,1,opt,name=migration_id,json=migrationId,proto3" json:"migration_id,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Connection    string                 `protobuf:"bytes,4,opt,name=connection,proto3" json:"connection,omitempty"`
+	Backend       string                 `protobuf:"bytes,5,opt,name=backend,proto3" json:"backend,omitempty"`
+	Schema        string                 `protobuf:"bytes,6,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PendingMigration) Reset() {
+	*x = PendingMigration{}
+	mi := &file_migration_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendingMigration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingMigration) ProtoMessage() {}
+
+func (x *PendingMigration) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[28]
 	if x != nil {
-		return x.Backend
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *MigrationHistoryItem) GetAppliedAt() string {
+// Deprecated: Use PendingMigration.ProtoReflect.Descriptor instead.
+func (*PendingMigration) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PendingMigration) GetMigrationId() string {
 	if x != nil {
-		return x.AppliedAt
+		return x.MigrationId
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetStatus() string {
+func (x *PendingMigration) GetVersion() string {
 	if x != nil {
-		return x.Status
+		return x.Version
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetErrorMessage() string {
+func (x *PendingMigration) GetName() string {
 	if x != nil {
-		return x.ErrorMessage
+		return x.Name
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetExecutedBy() string {
+func (x *PendingMigration) GetConnection() string {
 	if x != nil {
-		return x.ExecutedBy
+		return x.Connection
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetExecutionMethod() string {
+func (x *PendingMigration) GetBackend() string {
 	if x != nil {
-		return x.ExecutionMethod
+		return x.Backend
 	}
 	return ""
 }
 
-func (x *MigrationHistoryItem) GetExecutionContext() string {
+func (x *PendingMigration) GetSchema() string {
 	if x != nil {
-		return x.ExecutionContext
+		return x.Schema
 	}
 	return ""
 }
@@ -1395,7 +2554,7 @@ type RollbackMigrationRequest struct {
 
 func (x *RollbackMigrationRequest) Reset() {
 	*x = RollbackMigrationRequest{}
-	mi := &file_migration_proto_msgTypes[18]
+	mi := &file_migration_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1407,7 +2566,7 @@ func (x *RollbackMigrationRequest) String() string {
 func (*RollbackMigrationRequest) ProtoMessage() {}
 
 func (x *RollbackMigrationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[18]
+	mi := &file_migration_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1420,7 +2579,7 @@ func (x *RollbackMigrationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RollbackMigrationRequest.ProtoReflect.Descriptor instead.
 func (*RollbackMigrationRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{18}
+	return file_migration_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *RollbackMigrationRequest) GetMigrationId() string {
@@ -1449,7 +2608,7 @@ type RollbackResponse struct {
 
 func (x *RollbackResponse) Reset() {
 	*x = RollbackResponse{}
-	mi := &file_migration_proto_msgTypes[19]
+	mi := &file_migration_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1461,7 +2620,7 @@ func (x *RollbackResponse) String() string {
 func (*RollbackResponse) ProtoMessage() {}
 
 func (x *RollbackResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[19]
+	mi := &file_migration_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1474,7 +2633,7 @@ func (x *RollbackResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RollbackResponse.ProtoReflect.Descriptor instead.
 func (*RollbackResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{19}
+	return file_migration_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *RollbackResponse) GetSuccess() bool {
@@ -1508,7 +2667,7 @@ type ReindexMigrationsRequest struct {
 
 func (x *ReindexMigrationsRequest) Reset() {
 	*x = ReindexMigrationsRequest{}
-	mi := &file_migration_proto_msgTypes[20]
+	mi := &file_migration_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1520,7 +2679,7 @@ func (x *ReindexMigrationsRequest) String() string {
 func (*ReindexMigrationsRequest) ProtoMessage() {}
 
 func (x *ReindexMigrationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[20]
+	mi := &file_migration_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1533,7 +2692,7 @@ func (x *ReindexMigrationsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReindexMigrationsRequest.ProtoReflect.Descriptor instead.
 func (*ReindexMigrationsRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{20}
+	return file_migration_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *ReindexMigrationsRequest) GetSfmPath() string {
@@ -1556,7 +2715,7 @@ type ReindexResponse struct {
 
 func (x *ReindexResponse) Reset() {
 	*x = ReindexResponse{}
-	mi := &file_migration_proto_msgTypes[21]
+	mi := &file_migration_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1568,7 +2727,7 @@ func (x *ReindexResponse) String() string {
 func (*ReindexResponse) ProtoMessage() {}
 
 func (x *ReindexResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[21]
+	mi := &file_migration_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1581,7 +2740,7 @@ func (x *ReindexResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReindexResponse.ProtoReflect.Descriptor instead.
 func (*ReindexResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{21}
+	return file_migration_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *ReindexResponse) GetAdded() []string {
@@ -1621,7 +2780,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_migration_proto_msgTypes[22]
+	mi := &file_migration_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1633,7 +2792,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[22]
+	mi := &file_migration_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1646,7 +2805,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{22}
+	return file_migration_proto_rawDescGZIP(), []int{33}
 }
 
 // HealthResponse represents the health status of the service
@@ -1660,7 +2819,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_migration_proto_msgTypes[23]
+	mi := &file_migration_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1672,7 +2831,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_migration_proto_msgTypes[23]
+	mi := &file_migration_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1685,7 +2844,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_migration_proto_rawDescGZIP(), []int{23}
+	return file_migration_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *HealthResponse) GetStatus() string {
@@ -1702,11 +2861,194 @@ func (x *HealthResponse) GetChecks() map[string]string {
 	return nil
 }
 
+// GetMetaRequest represents a server metadata request
+type GetMetaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetaRequest) Reset() {
+	*x = GetMetaRequest{}
+	mi := &file_migration_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetaRequest) ProtoMessage() {}
+
+func (x *GetMetaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetaRequest.ProtoReflect.Descriptor instead.
+func (*GetMetaRequest) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{35}
+}
+
+// MetaFeatures lists the features enabled on the server
+type MetaFeatures struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queue         bool                   `protobuf:"varint,1,opt,name=queue,proto3" json:"queue,omitempty"`                      // Async execution through a queue is available
+	Backends      []string               `protobuf:"bytes,2,rep,name=backends,proto3" json:"backends,omitempty"`                 // Registered backends, sorted
+	AuthMode      string                 `protobuf:"bytes,3,opt,name=auth_mode,json=authMode,proto3" json:"auth_mode,omitempty"` // "token" or "oidc"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetaFeatures) Reset() {
+	*x = MetaFeatures{}
+	mi := &file_migration_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetaFeatures) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetaFeatures) ProtoMessage() {}
+
+func (x *MetaFeatures) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetaFeatures.ProtoReflect.Descriptor instead.
+func (*MetaFeatures) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *MetaFeatures) GetQueue() bool {
+	if x != nil {
+		return x.Queue
+	}
+	return false
+}
+
+func (x *MetaFeatures) GetBackends() []string {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
+func (x *MetaFeatures) GetAuthMode() string {
+	if x != nil {
+		return x.AuthMode
+	}
+	return ""
+}
+
+// MetaResponse describes the server
+type MetaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	BuildDate     string                 `protobuf:"bytes,3,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"` // RFC 3339
+	GoVersion     string                 `protobuf:"bytes,4,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	ApiVersions   []string               `protobuf:"bytes,5,rep,name=api_versions,json=apiVersions,proto3" json:"api_versions,omitempty"`
+	Features      *MetaFeatures          `protobuf:"bytes,6,opt,name=features,proto3" json:"features,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetaResponse) Reset() {
+	*x = MetaResponse{}
+	mi := &file_migration_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetaResponse) ProtoMessage() {}
+
+func (x *MetaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_migration_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetaResponse.ProtoReflect.Descriptor instead.
+func (*MetaResponse) Descriptor() ([]byte, []int) {
+	return file_migration_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *MetaResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *MetaResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *MetaResponse) GetBuildDate() string {
+	if x != nil {
+		return x.BuildDate
+	}
+	return ""
+}
+
+func (x *MetaResponse) GetGoVersion() string {
+	if x != nil {
+		return x.GoVersion
+	}
+	return ""
+}
+
+func (x *MetaResponse) GetApiVersions() []string {
+	if x != nil {
+		return x.ApiVersions
+	}
+	return nil
+}
+
+func (x *MetaResponse) GetFeatures() *MetaFeatures {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
 var File_migration_proto protoreflect.FileDescriptor
 
 const file_migration_proto_rawDesc = "" +
 	"\n" +
-	"\x0fmigration.proto\x12\tmigration\"\xa9\x01\n" +
+	"\x0fmigration.proto\x12\tmigration\"\xca\x01\n" +
 	"\x0fMigrationTarget\x12\x18\n" +
 	"\abackend\x18\x01 \x01(\tR\abackend\x12\x16\n" +
 	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x16\n" +
@@ -1715,7 +3057,9 @@ const file_migration_proto_rawDesc = "" +
 	"\n" +
 	"connection\x18\x05 \x01(\tR\n" +
 	"connection\x12\x12\n" +
-	"\x04tags\x18\x06 \x03(\tR\x04tags\"\xe7\x01\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x1f\n" +
+	"\vmax_version\x18\a \x01(\tR\n" +
+	"maxVersion\"\xd4\x03\n" +
 	"\x0eMigrateRequest\x122\n" +
 	"\x06target\x18\x01 \x01(\v2\x1a.migration.MigrationTargetR\x06target\x12\x1e\n" +
 	"\n" +
@@ -1725,22 +3069,92 @@ const file_migration_proto_rawDesc = "" +
 	"\vschema_name\x18\x04 \x01(\tR\n" +
 	"schemaName\x12\x17\n" +
 	"\adry_run\x18\x05 \x01(\bR\x06dryRun\x12/\n" +
-	"\x13ignore_dependencies\x18\x06 \x01(\bR\x12ignoreDependencies\"w\n" +
+	"\x13ignore_dependencies\x18\x06 \x01(\bR\x12ignoreDependencies\x12\x1f\n" +
+	"\vcapture_sql\x18\a \x01(\bR\n" +
+	"captureSql\x12Y\n" +
+	"\x10pinned_checksums\x18\b \x03(\v2..migration.MigrateRequest.PinnedChecksumsEntryR\x0fpinnedChecksums\x12+\n" +
+	"\x11allow_destructive\x18\t \x01(\bR\x10allowDestructive\x1aB\n" +
+	"\x14PinnedChecksumsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x01\n" +
 	"\x0fMigrateResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\aapplied\x18\x02 \x03(\tR\aapplied\x12\x18\n" +
 	"\askipped\x18\x03 \x03(\tR\askipped\x12\x16\n" +
-	"\x06errors\x18\x04 \x03(\tR\x06errors\"\x82\x01\n" +
+	"\x06errors\x18\x04 \x03(\tR\x06errors\x129\n" +
+	"\fexecuted_sql\x18\x05 \x03(\v2\x16.migration.ExecutedSQLR\vexecutedSql\"\x80\x01\n" +
+	"\vExecutedSQL\x12!\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x10\n" +
+	"\x03sql\x18\x02 \x01(\tR\x03sql\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\x04 \x01(\bR\n" +
+	"suppressed\"\xab\x02\n" +
 	"\x0fMigrateProgress\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
 	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1a\n" +
-	"\bprogress\x18\x04 \x01(\x05R\bprogress\"\x9b\x01\n" +
+	"\bprogress\x18\x04 \x01(\x05R\bprogress\x12\x1c\n" +
+	"\tstatement\x18\x05 \x01(\x05R\tstatement\x12\x1e\n" +
+	"\n" +
+	"statements\x18\x06 \x01(\x05R\n" +
+	"statements\x12%\n" +
+	"\x0estatement_done\x18\a \x01(\bR\rstatementDone\x12#\n" +
+	"\rrows_affected\x18\b \x01(\x03R\frowsAffected\x12\x1d\n" +
+	"\n" +
+	"elapsed_ms\x18\t \x01(\x03R\telapsedMs\"\xbc\x01\n" +
 	"\x12MigrateDownRequest\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x18\n" +
 	"\aschemas\x18\x02 \x03(\tR\aschemas\x12\x17\n" +
 	"\adry_run\x18\x03 \x01(\bR\x06dryRun\x12/\n" +
-	"\x13ignore_dependencies\x18\x04 \x01(\bR\x12ignoreDependencies\"\xb1\x01\n" +
+	"\x13ignore_dependencies\x18\x04 \x01(\bR\x12ignoreDependencies\x12\x1f\n" +
+	"\vcapture_sql\x18\x05 \x01(\bR\n" +
+	"captureSql\"\xac\x01\n" +
+	"\vPlanRequest\x122\n" +
+	"\x06target\x18\x01 \x01(\v2\x1a.migration.MigrationTargetR\x06target\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x02 \x01(\tR\n" +
+	"connection\x12\x18\n" +
+	"\aschemas\x18\x03 \x03(\tR\aschemas\x12/\n" +
+	"\x13ignore_dependencies\x18\x04 \x01(\bR\x12ignoreDependencies\"\x8c\x04\n" +
+	"\bPlanStep\x12\x14\n" +
+	"\x05order\x18\x01 \x01(\x05R\x05order\x12!\n" +
+	"\fmigration_id\x18\x02 \x01(\tR\vmigrationId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x18\n" +
+	"\abackend\x18\x05 \x01(\tR\abackend\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x06 \x01(\tR\n" +
+	"connection\x12\x16\n" +
+	"\x06schema\x18\a \x01(\tR\x06schema\x12\x16\n" +
+	"\x06action\x18\b \x01(\tR\x06action\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\n" +
+	" \x03(\tR\tdependsOn\x12\x1f\n" +
+	"\vrequired_by\x18\v \x01(\tR\n" +
+	"requiredBy\x12%\n" +
+	"\x0edown_generated\x18\f \x01(\bR\rdownGenerated\x12\x19\n" +
+	"\bdown_sql\x18\r \x01(\tR\adownSql\x12\x1a\n" +
+	"\bchecksum\x18\x0e \x01(\tR\bchecksum\x127\n" +
+	"\bfindings\x18\x0f \x03(\v2\x1b.migration.ValidatorFindingR\bfindings\x12\x1e\n" +
+	"\n" +
+	"statements\x18\x10 \x01(\x05R\n" +
+	"statements\x12 \n" +
+	"\vdestructive\x18\x11 \x03(\tR\vdestructive\"f\n" +
+	"\x10ValidatorFinding\x12\x1c\n" +
+	"\tvalidator\x18\x01 \x01(\tR\tvalidator\x12\x1a\n" +
+	"\bseverity\x18\x02 \x01(\tR\bseverity\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xff\x01\n" +
+	"\fPlanResponse\x12)\n" +
+	"\x05steps\x18\x01 \x03(\v2\x13.migration.PlanStepR\x05steps\x12\x14\n" +
+	"\x05apply\x18\x02 \x03(\tR\x05apply\x12\x12\n" +
+	"\x04skip\x18\x03 \x03(\tR\x04skip\x12\x16\n" +
+	"\x06errors\x18\x04 \x03(\tR\x06errors\x12D\n" +
+	"\tchecksums\x18\x05 \x03(\v2&.migration.PlanResponse.ChecksumsEntryR\tchecksums\x1a<\n" +
+	"\x0eChecksumsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xad\x02\n" +
 	"\x15ListMigrationsRequest\x12\x16\n" +
 	"\x06schema\x18\x01 \x01(\tR\x06schema\x12\x14\n" +
 	"\x05table\x18\x02 \x01(\tR\x05table\x12\x1e\n" +
@@ -1749,10 +3163,19 @@ const file_migration_proto_rawDesc = "" +
 	"connection\x12\x18\n" +
 	"\abackend\x18\x04 \x01(\tR\abackend\x12\x16\n" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12\x18\n" +
-	"\aversion\x18\x06 \x01(\tR\aversion\"b\n" +
+	"\aversion\x18\x06 \x01(\tR\aversion\x12\x14\n" +
+	"\x05label\x18\a \x01(\tR\x05label\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\t \x01(\x05R\x06offset\x12\x17\n" +
+	"\asort_by\x18\n" +
+	" \x01(\tR\x06sortBy\x12\x1d\n" +
+	"\n" +
+	"sort_order\x18\v \x01(\tR\tsortOrder\"\x90\x01\n" +
 	"\x16ListMigrationsResponse\x122\n" +
 	"\x05items\x18\x01 \x03(\v2\x1c.migration.MigrationListItemR\x05items\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\"\xd6\x02\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"\x9d\x03\n" +
 	"\x11MigrationListItem\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x16\n" +
 	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x14\n" +
@@ -1769,9 +3192,11 @@ const file_migration_proto_rawDesc = "" +
 	"applied_at\x18\n" +
 	" \x01(\tR\tappliedAt\x12#\n" +
 	"\rerror_message\x18\v \x01(\tR\ferrorMessage\x12\x12\n" +
-	"\x04tags\x18\f \x03(\tR\x04tags\"8\n" +
+	"\x04tags\x18\f \x03(\tR\x04tags\x12#\n" +
+	"\rstatus_labels\x18\r \x03(\tR\fstatusLabels\x12 \n" +
+	"\vdestructive\x18\x0e \x03(\tR\vdestructive\"8\n" +
 	"\x13GetMigrationRequest\x12!\n" +
-	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\"\xae\x03\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\"\x9a\x04\n" +
 	"\x17MigrationDetailResponse\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x16\n" +
 	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x14\n" +
@@ -1788,7 +3213,12 @@ const file_migration_proto_rawDesc = "" +
 	" \x01(\tR\adownSql\x12\"\n" +
 	"\fdependencies\x18\v \x03(\tR\fdependencies\x12V\n" +
 	"\x17structured_dependencies\x18\f \x03(\v2\x1d.migration.DependencyResponseR\x16structuredDependencies\x12\x12\n" +
-	"\x04tags\x18\r \x03(\tR\x04tags\"\xd5\x01\n" +
+	"\x04tags\x18\r \x03(\tR\x04tags\x12%\n" +
+	"\x0edown_generated\x18\x0e \x01(\bR\rdownGenerated\x12#\n" +
+	"\rstatus_labels\x18\x0f \x03(\tR\fstatusLabels\x12\x1e\n" +
+	"\n" +
+	"repeatable\x18\x10 \x01(\bR\n" +
+	"repeatable\"\xd5\x01\n" +
 	"\x12DependencyResponse\x12\x1e\n" +
 	"\n" +
 	"connection\x18\x01 \x01(\tR\n" +
@@ -1811,12 +3241,25 @@ const file_migration_proto_rawDesc = "" +
 	"\x19IsMigrationAppliedRequest\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\"6\n" +
 	"\x1aIsMigrationAppliedResponse\x12\x18\n" +
-	"\aapplied\x18\x01 \x01(\bR\aapplied\"?\n" +
+	"\aapplied\x18\x01 \x01(\bR\aapplied\"\xbd\x02\n" +
 	"\x1aGetMigrationHistoryRequest\x12!\n" +
-	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\"x\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x17\n" +
+	"\asort_by\x18\x04 \x01(\tR\x06sortBy\x12\x1d\n" +
+	"\n" +
+	"sort_order\x18\x05 \x01(\tR\tsortOrder\x12#\n" +
+	"\rapplied_after\x18\x06 \x01(\tR\fappliedAfter\x12%\n" +
+	"\x0eapplied_before\x18\a \x01(\tR\rappliedBefore\x12\x1f\n" +
+	"\vexecuted_by\x18\b \x01(\tR\n" +
+	"executedBy\x12)\n" +
+	"\x10execution_method\x18\t \x01(\tR\x0fexecutionMethod\"\xbc\x01\n" +
 	"\x18MigrationHistoryResponse\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x129\n" +
-	"\ahistory\x18\x02 \x03(\v2\x1f.migration.MigrationHistoryItemR\ahistory\"\x90\x03\n" +
+	"\ahistory\x18\x02 \x03(\v2\x1f.migration.MigrationHistoryItemR\ahistory\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"\xae\x03\n" +
 	"\x14MigrationHistoryItem\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x16\n" +
 	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x14\n" +
@@ -1834,7 +3277,50 @@ const file_migration_proto_rawDesc = "" +
 	" \x01(\tR\n" +
 	"executedBy\x12)\n" +
 	"\x10execution_method\x18\v \x01(\tR\x0fexecutionMethod\x12+\n" +
-	"\x11execution_context\x18\f \x01(\tR\x10executionContext\"W\n" +
+	"\x11execution_context\x18\f \x01(\tR\x10executionContext\x12\x1c\n" +
+	"\toperation\x18\r \x01(\tR\toperation\"U\n" +
+	"\x1bGetPendingMigrationsRequest\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x01 \x01(\tR\n" +
+	"connection\x12\x16\n" +
+	"\x06schema\x18\x02 \x01(\tR\x06schema\"\x9c\x01\n" +
+	"\x19PendingMigrationsResponse\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x01 \x01(\tR\n" +
+	"connection\x12\x16\n" +
+	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x121\n" +
+	"\x05items\x18\x04 \x03(\v2\x1b.migration.PendingMigrationR\x05items\"\x80\x01\n" +
+	"\x1aGetMigrationSchemasRequest\x12!\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x18\n" +
+	"\aschemas\x18\x02 \x03(\tR\aschemas\x12%\n" +
+	"\x0eschema_pattern\x18\x03 \x01(\tR\rschemaPattern\"\xdc\x01\n" +
+	"\x18MigrationSchemasResponse\x12!\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x02 \x01(\tR\n" +
+	"connection\x12\x18\n" +
+	"\aapplied\x18\x03 \x01(\x05R\aapplied\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\x05R\x06failed\x12\x18\n" +
+	"\apending\x18\x05 \x01(\x05R\apending\x121\n" +
+	"\aschemas\x18\x06 \x03(\v2\x17.migration.SchemaStatusR\aschemas\"\x96\x01\n" +
+	"\fSchemaStatus\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\tR\x06schema\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"applied_at\x18\x04 \x01(\tR\tappliedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\tR\tupdatedAt\"\xb5\x01\n" +
+	"\x10PendingMigration\x12!\n" +
+	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"connection\x18\x04 \x01(\tR\n" +
+	"connection\x12\x18\n" +
+	"\abackend\x18\x05 \x01(\tR\abackend\x12\x16\n" +
+	"\x06schema\x18\x06 \x01(\tR\x06schema\"W\n" +
 	"\x18RollbackMigrationRequest\x12!\n" +
 	"\fmigration_id\x18\x01 \x01(\tR\vmigrationId\x12\x18\n" +
 	"\aschemas\x18\x02 \x03(\tR\aschemas\"^\n" +
@@ -1855,19 +3341,37 @@ const file_migration_proto_rawDesc = "" +
 	"\x06checks\x18\x02 \x03(\v2%.migration.HealthResponse.ChecksEntryR\x06checks\x1a9\n" +
 	"\vChecksEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xa5\a\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x10\n" +
+	"\x0eGetMetaRequest\"]\n" +
+	"\fMetaFeatures\x12\x14\n" +
+	"\x05queue\x18\x01 \x01(\bR\x05queue\x12\x1a\n" +
+	"\bbackends\x18\x02 \x03(\tR\bbackends\x12\x1b\n" +
+	"\tauth_mode\x18\x03 \x01(\tR\bauthMode\"\xd6\x01\n" +
+	"\fMetaResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x12\x1d\n" +
+	"\n" +
+	"build_date\x18\x03 \x01(\tR\tbuildDate\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\x04 \x01(\tR\tgoVersion\x12!\n" +
+	"\fapi_versions\x18\x05 \x03(\tR\vapiVersions\x123\n" +
+	"\bfeatures\x18\x06 \x01(\v2\x17.migration.MetaFeaturesR\bfeatures2\xe6\t\n" +
 	"\x10MigrationService\x12@\n" +
 	"\aMigrate\x12\x19.migration.MigrateRequest\x1a\x1a.migration.MigrateResponse\x12H\n" +
 	"\rStreamMigrate\x12\x19.migration.MigrateRequest\x1a\x1a.migration.MigrateProgress0\x01\x12H\n" +
-	"\vMigrateDown\x12\x1d.migration.MigrateDownRequest\x1a\x1a.migration.MigrateResponse\x12U\n" +
+	"\vMigrateDown\x12\x1d.migration.MigrateDownRequest\x1a\x1a.migration.MigrateResponse\x127\n" +
+	"\x04Plan\x12\x16.migration.PlanRequest\x1a\x17.migration.PlanResponse\x12U\n" +
 	"\x0eListMigrations\x12 .migration.ListMigrationsRequest\x1a!.migration.ListMigrationsResponse\x12R\n" +
 	"\fGetMigration\x12\x1e.migration.GetMigrationRequest\x1a\".migration.MigrationDetailResponse\x12^\n" +
 	"\x12GetMigrationStatus\x12$.migration.GetMigrationStatusRequest\x1a\".migration.MigrationStatusResponse\x12a\n" +
 	"\x12IsMigrationApplied\x12$.migration.IsMigrationAppliedRequest\x1a%.migration.IsMigrationAppliedResponse\x12a\n" +
-	"\x13GetMigrationHistory\x12%.migration.GetMigrationHistoryRequest\x1a#.migration.MigrationHistoryResponse\x12U\n" +
+	"\x13GetMigrationHistory\x12%.migration.GetMigrationHistoryRequest\x1a#.migration.MigrationHistoryResponse\x12d\n" +
+	"\x14GetPendingMigrations\x12&.migration.GetPendingMigrationsRequest\x1a$.migration.PendingMigrationsResponse\x12a\n" +
+	"\x13GetMigrationSchemas\x12%.migration.GetMigrationSchemasRequest\x1a#.migration.MigrationSchemasResponse\x12U\n" +
 	"\x11RollbackMigration\x12#.migration.RollbackMigrationRequest\x1a\x1b.migration.RollbackResponse\x12T\n" +
 	"\x11ReindexMigrations\x12#.migration.ReindexMigrationsRequest\x1a\x1a.migration.ReindexResponse\x12=\n" +
-	"\x06Health\x12\x18.migration.HealthRequest\x1a\x19.migration.HealthResponseB6Z4github.com/toolsascode/bfm/api/internal/api/protobufb\x06proto3"
+	"\x06Health\x12\x18.migration.HealthRequest\x1a\x19.migration.HealthResponse\x12=\n" +
+	"\aGetMeta\x12\x19.migration.GetMetaRequest\x1a\x17.migration.MetaResponseB6Z4github.com/toolsascode/bfm/api/internal/api/protobufb\x06proto3"
 
 var (
 	file_migration_proto_rawDescOnce sync.Once
@@ -1881,67 +3385,100 @@ func file_migration_proto_rawDescGZIP() []byte {
 	return file_migration_proto_rawDescData
 }
 
-var file_migration_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_migration_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
 var file_migration_proto_goTypes = []any{
-	(*MigrationTarget)(nil),            // 0: migration.MigrationTarget
-	(*MigrateRequest)(nil),             // 1: migration.MigrateRequest
-	(*MigrateResponse)(nil),            // 2: migration.MigrateResponse
-	(*MigrateProgress)(nil),            // 3: migration.MigrateProgress
-	(*MigrateDownRequest)(nil),         // 4: migration.MigrateDownRequest
-	(*ListMigrationsRequest)(nil),      // 5: migration.ListMigrationsRequest
-	(*ListMigrationsResponse)(nil),     // 6: migration.ListMigrationsResponse
-	(*MigrationListItem)(nil),          // 7: migration.MigrationListItem
-	(*GetMigrationRequest)(nil),        // 8: migration.GetMigrationRequest
-	(*MigrationDetailResponse)(nil),    // 9: migration.MigrationDetailResponse
-	(*DependencyResponse)(nil),         // 10: migration.DependencyResponse
-	(*GetMigrationStatusRequest)(nil),  // 11: migration.GetMigrationStatusRequest
-	(*MigrationStatusResponse)(nil),    // 12: migration.MigrationStatusResponse
-	(*IsMigrationAppliedRequest)(nil),  // 13: migration.IsMigrationAppliedRequest
-	(*IsMigrationAppliedResponse)(nil), // 14: migration.IsMigrationAppliedResponse
-	(*GetMigrationHistoryRequest)(nil), // 15: migration.GetMigrationHistoryRequest
-	(*MigrationHistoryResponse)(nil),   // 16: migration.MigrationHistoryResponse
-	(*MigrationHistoryItem)(nil),       // 17: migration.MigrationHistoryItem
-	(*RollbackMigrationRequest)(nil),   // 18: migration.RollbackMigrationRequest
-	(*RollbackResponse)(nil),           // 19: migration.RollbackResponse
-	(*ReindexMigrationsRequest)(nil),   // 20: migration.ReindexMigrationsRequest
-	(*ReindexResponse)(nil),            // 21: migration.ReindexResponse
-	(*HealthRequest)(nil),              // 22: migration.HealthRequest
-	(*HealthResponse)(nil),             // 23: migration.HealthResponse
-	nil,                                // 24: migration.HealthResponse.ChecksEntry
+	(*MigrationTarget)(nil),             // 0: migration.MigrationTarget
+	(*MigrateRequest)(nil),              // 1: migration.MigrateRequest
+	(*MigrateResponse)(nil),             // 2: migration.MigrateResponse
+	(*ExecutedSQL)(nil),                 // 3: migration.ExecutedSQL
+	(*MigrateProgress)(nil),             // 4: migration.MigrateProgress
+	(*MigrateDownRequest)(nil),          // 5: migration.MigrateDownRequest
+	(*PlanRequest)(nil),                 // 6: migration.PlanRequest
+	(*PlanStep)(nil),                    // 7: migration.PlanStep
+	(*ValidatorFinding)(nil),            // 8: migration.ValidatorFinding
+	(*PlanResponse)(nil),                // 9: migration.PlanResponse
+	(*ListMigrationsRequest)(nil),       // 10: migration.ListMigrationsRequest
+	(*ListMigrationsResponse)(nil),      // 11: migration.ListMigrationsResponse
+	(*MigrationListItem)(nil),           // 12: migration.MigrationListItem
+	(*GetMigrationRequest)(nil),         // 13: migration.GetMigrationRequest
+	(*MigrationDetailResponse)(nil),     // 14: migration.MigrationDetailResponse
+	(*DependencyResponse)(nil),          // 15: migration.DependencyResponse
+	(*GetMigrationStatusRequest)(nil),   // 16: migration.GetMigrationStatusRequest
+	(*MigrationStatusResponse)(nil),     // 17: migration.MigrationStatusResponse
+	(*IsMigrationAppliedRequest)(nil),   // 18: migration.IsMigrationAppliedRequest
+	(*IsMigrationAppliedResponse)(nil),  // 19: migration.IsMigrationAppliedResponse
+	(*GetMigrationHistoryRequest)(nil),  // 20: migration.GetMigrationHistoryRequest
+	(*MigrationHistoryResponse)(nil),    // 21: migration.MigrationHistoryResponse
+	(*MigrationHistoryItem)(nil),        // 22: migration.MigrationHistoryItem
+	(*GetPendingMigrationsRequest)(nil), // 23: migration.GetPendingMigrationsRequest
+	(*PendingMigrationsResponse)(nil),   // 24: migration.PendingMigrationsResponse
+	(*GetMigrationSchemasRequest)(nil),  // 25: migration.GetMigrationSchemasRequest
+	(*MigrationSchemasResponse)(nil),    // 26: migration.MigrationSchemasResponse
+	(*SchemaStatus)(nil),                // 27: migration.SchemaStatus
+	(*PendingMigration)(nil),            // 28: migration.PendingMigration
+	(*RollbackMigrationRequest)(nil),    // 29: migration.RollbackMigrationRequest
+	(*RollbackResponse)(nil),            // 30: migration.RollbackResponse
+	(*ReindexMigrationsRequest)(nil),    // 31: migration.ReindexMigrationsRequest
+	(*ReindexResponse)(nil),             // 32: migration.ReindexResponse
+	(*HealthRequest)(nil),               // 33: migration.HealthRequest
+	(*HealthResponse)(nil),              // 34: migration.HealthResponse
+	(*GetMetaRequest)(nil),              // 35: migration.GetMetaRequest
+	(*MetaFeatures)(nil),                // 36: migration.MetaFeatures
+	(*MetaResponse)(nil),                // 37: migration.MetaResponse
+	nil,                                 // 38: migration.MigrateRequest.PinnedChecksumsEntry
+	nil,                                 // 39: migration.PlanResponse.ChecksumsEntry
+	nil,                                 // 40: migration.HealthResponse.ChecksEntry
 }
 var file_migration_proto_depIdxs = []int32{
 	0,  // 0: migration.MigrateRequest.target:type_name -> migration.MigrationTarget
-	7,  // 1: migration.ListMigrationsResponse.items:type_name -> migration.MigrationListItem
-	10, // 2: migration.MigrationDetailResponse.structured_dependencies:type_name -> migration.DependencyResponse
-	17, // 3: migration.MigrationHistoryResponse.history:type_name -> migration.MigrationHistoryItem
-	24, // 4: migration.HealthResponse.checks:type_name -> migration.HealthResponse.ChecksEntry
-	1,  // 5: migration.MigrationService.Migrate:input_type -> migration.MigrateRequest
-	1,  // 6: migration.MigrationService.StreamMigrate:input_type -> migration.MigrateRequest
-	4,  // 7: migration.MigrationService.MigrateDown:input_type -> migration.MigrateDownRequest
-	5,  // 8: migration.MigrationService.ListMigrations:input_type -> migration.ListMigrationsRequest
-	8,  // 9: migration.MigrationService.GetMigration:input_type -> migration.GetMigrationRequest
-	11, // 10: migration.MigrationService.GetMigrationStatus:input_type -> migration.GetMigrationStatusRequest
-	13, // 11: migration.MigrationService.IsMigrationApplied:input_type -> migration.IsMigrationAppliedRequest
-	15, // 12: migration.MigrationService.GetMigrationHistory:input_type -> migration.GetMigrationHistoryRequest
-	18, // 13: migration.MigrationService.RollbackMigration:input_type -> migration.RollbackMigrationRequest
-	20, // 14: migration.MigrationService.ReindexMigrations:input_type -> migration.ReindexMigrationsRequest
-	22, // 15: migration.MigrationService.Health:input_type -> migration.HealthRequest
-	2,  // 16: migration.MigrationService.Migrate:output_type -> migration.MigrateResponse
-	3,  // 17: migration.MigrationService.StreamMigrate:output_type -> migration.MigrateProgress
-	2,  // 18: migration.MigrationService.MigrateDown:output_type -> migration.MigrateResponse
-	6,  // 19: migration.MigrationService.ListMigrations:output_type -> migration.ListMigrationsResponse
-	9,  // 20: migration.MigrationService.GetMigration:output_type -> migration.MigrationDetailResponse
-	12, // 21: migration.MigrationService.GetMigrationStatus:output_type -> migration.MigrationStatusResponse
-	14, // 22: migration.MigrationService.IsMigrationApplied:output_type -> migration.IsMigrationAppliedResponse
-	16, // 23: migration.MigrationService.GetMigrationHistory:output_type -> migration.MigrationHistoryResponse
-	19, // 24: migration.MigrationService.RollbackMigration:output_type -> migration.RollbackResponse
-	21, // 25: migration.MigrationService.ReindexMigrations:output_type -> migration.ReindexResponse
-	23, // 26: migration.MigrationService.Health:output_type -> migration.HealthResponse
-	16, // [16:27] is the sub-list for method output_type
-	5,  // [5:16] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	38, // 1: migration.MigrateRequest.pinned_checksums:type_name -> migration.MigrateRequest.PinnedChecksumsEntry
+	3,  // 2: migration.MigrateResponse.executed_sql:type_name -> migration.ExecutedSQL
+	0,  // 3: migration.PlanRequest.target:type_name -> migration.MigrationTarget
+	8,  // 4: migration.PlanStep.findings:type_name -> migration.ValidatorFinding
+	7,  // 5: migration.PlanResponse.steps:type_name -> migration.PlanStep
+	39, // 6: migration.PlanResponse.checksums:type_name -> migration.PlanResponse.ChecksumsEntry
+	12, // 7: migration.ListMigrationsResponse.items:type_name -> migration.MigrationListItem
+	15, // 8: migration.MigrationDetailResponse.structured_dependencies:type_name -> migration.DependencyResponse
+	22, // 9: migration.MigrationHistoryResponse.history:type_name -> migration.MigrationHistoryItem
+	28, // 10: migration.PendingMigrationsResponse.items:type_name -> migration.PendingMigration
+	27, // 11: migration.MigrationSchemasResponse.schemas:type_name -> migration.SchemaStatus
+	40, // 12: migration.HealthResponse.checks:type_name -> migration.HealthResponse.ChecksEntry
+	36, // 13: migration.MetaResponse.features:type_name -> migration.MetaFeatures
+	1,  // 14: migration.MigrationService.Migrate:input_type -> migration.MigrateRequest
+	1,  // 15: migration.MigrationService.StreamMigrate:input_type -> migration.MigrateRequest
+	5,  // 16: migration.MigrationService.MigrateDown:input_type -> migration.MigrateDownRequest
+	6,  // 17: migration.MigrationService.Plan:input_type -> migration.PlanRequest
+	10, // 18: migration.MigrationService.ListMigrations:input_type -> migration.ListMigrationsRequest
+	13, // 19: migration.MigrationService.GetMigration:input_type -> migration.GetMigrationRequest
+	16, // 20: migration.MigrationService.GetMigrationStatus:input_type -> migration.GetMigrationStatusRequest
+	18, // 21: migration.MigrationService.IsMigrationApplied:input_type -> migration.IsMigrationAppliedRequest
+	20, // 22: migration.MigrationService.GetMigrationHistory:input_type -> migration.GetMigrationHistoryRequest
+	23, // 23: migration.MigrationService.GetPendingMigrations:input_type -> migration.GetPendingMigrationsRequest
+	25, // 24: migration.MigrationService.GetMigrationSchemas:input_type -> migration.GetMigrationSchemasRequest
+	29, // 25: migration.MigrationService.RollbackMigration:input_type -> migration.RollbackMigrationRequest
+	31, // 26: migration.MigrationService.ReindexMigrations:input_type -> migration.ReindexMigrationsRequest
+	33, // 27: migration.MigrationService.Health:input_type -> migration.HealthRequest
+	35, // 28: migration.MigrationService.GetMeta:input_type -> migration.GetMetaRequest
+	2,  // 29: migration.MigrationService.Migrate:output_type -> migration.MigrateResponse
+	4,  // 30: migration.MigrationService.StreamMigrate:output_type -> migration.MigrateProgress
+	2,  // 31: migration.MigrationService.MigrateDown:output_type -> migration.MigrateResponse
+	9,  // 32: migration.MigrationService.Plan:output_type -> migration.PlanResponse
+	11, // 33: migration.MigrationService.ListMigrations:output_type -> migration.ListMigrationsResponse
+	14, // 34: migration.MigrationService.GetMigration:output_type -> migration.MigrationDetailResponse
+	17, // 35: migration.MigrationService.GetMigrationStatus:output_type -> migration.MigrationStatusResponse
+	19, // 36: migration.MigrationService.IsMigrationApplied:output_type -> migration.IsMigrationAppliedResponse
+	21, // 37: migration.MigrationService.GetMigrationHistory:output_type -> migration.MigrationHistoryResponse
+	24, // 38: migration.MigrationService.GetPendingMigrations:output_type -> migration.PendingMigrationsResponse
+	26, // 39: migration.MigrationService.GetMigrationSchemas:output_type -> migration.MigrationSchemasResponse
+	30, // 40: migration.MigrationService.RollbackMigration:output_type -> migration.RollbackResponse
+	32, // 41: migration.MigrationService.ReindexMigrations:output_type -> migration.ReindexResponse
+	34, // 42: migration.MigrationService.Health:output_type -> migration.HealthResponse
+	37, // 43: migration.MigrationService.GetMeta:output_type -> migration.MetaResponse
+	29, // [29:44] is the sub-list for method output_type
+	14, // [14:29] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_migration_proto_init() }
@@ -1955,7 +3492,7 @@ func file_migration_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_migration_proto_rawDesc), len(file_migration_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   25,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
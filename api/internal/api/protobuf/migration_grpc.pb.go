@@ -20,17 +20,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MigrationService_Migrate_FullMethodName             = "/migration.MigrationService/Migrate"
-	MigrationService_StreamMigrate_FullMethodName       = "/migration.MigrationService/StreamMigrate"
-	MigrationService_MigrateDown_FullMethodName         = "/migration.MigrationService/MigrateDown"
-	MigrationService_ListMigrations_FullMethodName      = "/migration.MigrationService/ListMigrations"
-	MigrationService_GetMigration_FullMethodName        = "/migration.MigrationService/GetMigration"
-	MigrationService_GetMigrationStatus_FullMethodName  = "/migration.MigrationService/GetMigrationStatus"
-	MigrationService_IsMigrationApplied_FullMethodName  = "/migration.MigrationService/IsMigrationApplied"
-	MigrationService_GetMigrationHistory_FullMethodName = "/migration.MigrationService/GetMigrationHistory"
-	MigrationService_RollbackMigration_FullMethodName   = "/migration.MigrationService/RollbackMigration"
-	MigrationService_ReindexMigrations_FullMethodName   = "/migration.MigrationService/ReindexMigrations"
-	MigrationService_Health_FullMethodName              = "/migration.MigrationService/Health"
+	MigrationService_Migrate_FullMethodName              = "/migration.MigrationService/Migrate"
+	MigrationService_StreamMigrate_FullMethodName        = "/migration.MigrationService/StreamMigrate"
+	MigrationService_MigrateDown_FullMethodName          = "/migration.MigrationService/MigrateDown"
+	MigrationService_Plan_FullMethodName                 = "/migration.MigrationService/Plan"
+	MigrationService_ListMigrations_FullMethodName       = "/migration.MigrationService/ListMigrations"
+	MigrationService_GetMigration_FullMethodName         = "/migration.MigrationService/GetMigration"
+	MigrationService_GetMigrationStatus_FullMethodName   = "/migration.MigrationService/GetMigrationStatus"
+	MigrationService_IsMigrationApplied_FullMethodName   = "/migration.MigrationService/IsMigrationApplied"
+	MigrationService_GetMigrationHistory_FullMethodName  = "/migration.MigrationService/GetMigrationHistory"
+	MigrationService_GetPendingMigrations_FullMethodName = "/migration.MigrationService/GetPendingMigrations"
+	MigrationService_GetMigrationSchemas_FullMethodName  = "/migration.MigrationService/GetMigrationSchemas"
+	MigrationService_RollbackMigration_FullMethodName    = "/migration.MigrationService/RollbackMigration"
+	MigrationService_ReindexMigrations_FullMethodName    = "/migration.MigrationService/ReindexMigrations"
+	MigrationService_Health_FullMethodName               = "/migration.MigrationService/Health"
+	MigrationService_GetMeta_FullMethodName              = "/migration.MigrationService/GetMeta"
 )
 
 // MigrationServiceClient is the client API for MigrationService service.
@@ -45,6 +49,8 @@ type MigrationServiceClient interface {
 	StreamMigrate(ctx context.Context, in *MigrateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MigrateProgress], error)
 	// MigrateDown executes down migrations (rollback)
 	MigrateDown(ctx context.Context, in *MigrateDownRequest, opts ...grpc.CallOption) (*MigrateResponse, error)
+	// Plan returns the resolved, ordered execution plan for a target without executing anything
+	Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanResponse, error)
 	// ListMigrations lists all migrations with optional filtering
 	ListMigrations(ctx context.Context, in *ListMigrationsRequest, opts ...grpc.CallOption) (*ListMigrationsResponse, error)
 	// GetMigration gets detailed information about a specific migration
@@ -53,14 +59,21 @@ type MigrationServiceClient interface {
 	GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*MigrationStatusResponse, error)
 	// IsMigrationApplied checks if a migration has been applied
 	IsMigrationApplied(ctx context.Context, in *IsMigrationAppliedRequest, opts ...grpc.CallOption) (*IsMigrationAppliedResponse, error)
-	// GetMigrationHistory gets the execution history for a specific migration
+	// GetMigrationHistory gets the execution history of a migration, or of all migrations
 	GetMigrationHistory(ctx context.Context, in *GetMigrationHistoryRequest, opts ...grpc.CallOption) (*MigrationHistoryResponse, error)
+	// GetPendingMigrations lists the migrations of a connection that are registered but not applied
+	GetPendingMigrations(ctx context.Context, in *GetPendingMigrationsRequest, opts ...grpc.CallOption) (*PendingMigrationsResponse, error)
+	// GetMigrationSchemas reports in which schemas of its connection a migration is applied, failed or pending
+	GetMigrationSchemas(ctx context.Context, in *GetMigrationSchemasRequest, opts ...grpc.CallOption) (*MigrationSchemasResponse, error)
 	// RollbackMigration rolls back a specific migration
 	RollbackMigration(ctx context.Context, in *RollbackMigrationRequest, opts ...grpc.CallOption) (*RollbackResponse, error)
 	// ReindexMigrations reindexes all migration files and synchronizes with database
 	ReindexMigrations(ctx context.Context, in *ReindexMigrationsRequest, opts ...grpc.CallOption) (*ReindexResponse, error)
 	// Health checks the health status of the service
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	// GetMeta reports the server version, enabled features and supported API versions, so clients
+	// can feature-detect rather than assume the behavior of a deployment
+	GetMeta(ctx context.Context, in *GetMetaRequest, opts ...grpc.CallOption) (*MetaResponse, error)
 }
 
 type migrationServiceClient struct {
@@ -110,6 +123,16 @@ func (c *migrationServiceClient) MigrateDown(ctx context.Context, in *MigrateDow
 	return out, nil
 }
 
+func (c *migrationServiceClient) Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlanResponse)
+	err := c.cc.Invoke(ctx, MigrationService_Plan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *migrationServiceClient) ListMigrations(ctx context.Context, in *ListMigrationsRequest, opts ...grpc.CallOption) (*ListMigrationsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListMigrationsResponse)
@@ -160,6 +183,26 @@ func (c *migrationServiceClient) GetMigrationHistory(ctx context.Context, in *Ge
 	return out, nil
 }
 
+func (c *migrationServiceClient) GetPendingMigrations(ctx context.Context, in *GetPendingMigrationsRequest, opts ...grpc.CallOption) (*PendingMigrationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PendingMigrationsResponse)
+	err := c.cc.Invoke(ctx, MigrationService_GetPendingMigrations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *migrationServiceClient) GetMigrationSchemas(ctx context.Context, in *GetMigrationSchemasRequest, opts ...grpc.CallOption) (*MigrationSchemasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MigrationSchemasResponse)
+	err := c.cc.Invoke(ctx, MigrationService_GetMigrationSchemas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *migrationServiceClient) RollbackMigration(ctx context.Context, in *RollbackMigrationRequest, opts ...grpc.CallOption) (*RollbackResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RollbackResponse)
@@ -190,6 +233,16 @@ func (c *migrationServiceClient) Health(ctx context.Context, in *HealthRequest,
 	return out, nil
 }
 
+func (c *migrationServiceClient) GetMeta(ctx context.Context, in *GetMetaRequest, opts ...grpc.CallOption) (*MetaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetaResponse)
+	err := c.cc.Invoke(ctx, MigrationService_GetMeta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MigrationServiceServer is the server API for MigrationService service.
 // All implementations must embed UnimplementedMigrationServiceServer
 // for forward compatibility.
@@ -202,6 +255,8 @@ type MigrationServiceServer interface {
 	StreamMigrate(*MigrateRequest, grpc.ServerStreamingServer[MigrateProgress]) error
 	// MigrateDown executes down migrations (rollback)
 	MigrateDown(context.Context, *MigrateDownRequest) (*MigrateResponse, error)
+	// Plan returns the resolved, ordered execution plan for a target without executing anything
+	Plan(context.Context, *PlanRequest) (*PlanResponse, error)
 	// ListMigrations lists all migrations with optional filtering
 	ListMigrations(context.Context, *ListMigrationsRequest) (*ListMigrationsResponse, error)
 	// GetMigration gets detailed information about a specific migration
@@ -210,14 +265,21 @@ type MigrationServiceServer interface {
 	GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*MigrationStatusResponse, error)
 	// IsMigrationApplied checks if a migration has been applied
 	IsMigrationApplied(context.Context, *IsMigrationAppliedRequest) (*IsMigrationAppliedResponse, error)
-	// GetMigrationHistory gets the execution history for a specific migration
+	// GetMigrationHistory gets the execution history of a migration, or of all migrations
 	GetMigrationHistory(context.Context, *GetMigrationHistoryRequest) (*MigrationHistoryResponse, error)
+	// GetPendingMigrations lists the migrations of a connection that are registered but not applied
+	GetPendingMigrations(context.Context, *GetPendingMigrationsRequest) (*PendingMigrationsResponse, error)
+	// GetMigrationSchemas reports in which schemas of its connection a migration is applied, failed or pending
+	GetMigrationSchemas(context.Context, *GetMigrationSchemasRequest) (*MigrationSchemasResponse, error)
 	// RollbackMigration rolls back a specific migration
 	RollbackMigration(context.Context, *RollbackMigrationRequest) (*RollbackResponse, error)
 	// ReindexMigrations reindexes all migration files and synchronizes with database
 	ReindexMigrations(context.Context, *ReindexMigrationsRequest) (*ReindexResponse, error)
 	// Health checks the health status of the service
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	// GetMeta reports the server version, enabled features and supported API versions, so clients
+	// can feature-detect rather than assume the behavior of a deployment
+	GetMeta(context.Context, *GetMetaRequest) (*MetaResponse, error)
 	mustEmbedUnimplementedMigrationServiceServer()
 }
 
@@ -237,6 +299,9 @@ func (UnimplementedMigrationServiceServer) StreamMigrate(*MigrateRequest, grpc.S
 func (UnimplementedMigrationServiceServer) MigrateDown(context.Context, *MigrateDownRequest) (*MigrateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MigrateDown not implemented")
 }
+func (UnimplementedMigrationServiceServer) Plan(context.Context, *PlanRequest) (*PlanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Plan not implemented")
+}
 func (UnimplementedMigrationServiceServer) ListMigrations(context.Context, *ListMigrationsRequest) (*ListMigrationsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListMigrations not implemented")
 }
@@ -252,6 +317,12 @@ func (UnimplementedMigrationServiceServer) IsMigrationApplied(context.Context, *
 func (UnimplementedMigrationServiceServer) GetMigrationHistory(context.Context, *GetMigrationHistoryRequest) (*MigrationHistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMigrationHistory not implemented")
 }
+func (UnimplementedMigrationServiceServer) GetPendingMigrations(context.Context, *GetPendingMigrationsRequest) (*PendingMigrationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingMigrations not implemented")
+}
+func (UnimplementedMigrationServiceServer) GetMigrationSchemas(context.Context, *GetMigrationSchemasRequest) (*MigrationSchemasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMigrationSchemas not implemented")
+}
 func (UnimplementedMigrationServiceServer) RollbackMigration(context.Context, *RollbackMigrationRequest) (*RollbackResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RollbackMigration not implemented")
 }
@@ -261,6 +332,9 @@ func (UnimplementedMigrationServiceServer) ReindexMigrations(context.Context, *R
 func (UnimplementedMigrationServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
 }
+func (UnimplementedMigrationServiceServer) GetMeta(context.Context, *GetMetaRequest) (*MetaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMeta not implemented")
+}
 func (UnimplementedMigrationServiceServer) mustEmbedUnimplementedMigrationServiceServer() {}
 func (UnimplementedMigrationServiceServer) testEmbeddedByValue()                          {}
 
@@ -329,6 +403,24 @@ func _MigrationService_MigrateDown_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MigrationService_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_Plan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).Plan(ctx, req.(*PlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MigrationService_ListMigrations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListMigrationsRequest)
 	if err := dec(in); err != nil {
@@ -419,6 +511,42 @@ func _MigrationService_GetMigrationHistory_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MigrationService_GetPendingMigrations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPendingMigrationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).GetPendingMigrations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_GetPendingMigrations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).GetPendingMigrations(ctx, req.(*GetPendingMigrationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MigrationService_GetMigrationSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMigrationSchemasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).GetMigrationSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_GetMigrationSchemas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).GetMigrationSchemas(ctx, req.(*GetMigrationSchemasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MigrationService_RollbackMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RollbackMigrationRequest)
 	if err := dec(in); err != nil {
@@ -473,6 +601,24 @@ func _MigrationService_Health_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MigrationService_GetMeta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MigrationServiceServer).GetMeta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MigrationService_GetMeta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MigrationServiceServer).GetMeta(ctx, req.(*GetMetaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MigrationService_ServiceDesc is the grpc.ServiceDesc for MigrationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -488,6 +634,10 @@ var MigrationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "MigrateDown",
 			Handler:    _MigrationService_MigrateDown_Handler,
 		},
+		{
+			MethodName: "Plan",
+			Handler:    _MigrationService_Plan_Handler,
+		},
 		{
 			MethodName: "ListMigrations",
 			Handler:    _MigrationService_ListMigrations_Handler,
@@ -508,6 +658,14 @@ var MigrationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMigrationHistory",
 			Handler:    _MigrationService_GetMigrationHistory_Handler,
 		},
+		{
+			MethodName: "GetPendingMigrations",
+			Handler:    _MigrationService_GetPendingMigrations_Handler,
+		},
+		{
+			MethodName: "GetMigrationSchemas",
+			Handler:    _MigrationService_GetMigrationSchemas_Handler,
+		},
 		{
 			MethodName: "RollbackMigration",
 			Handler:    _MigrationService_RollbackMigration_Handler,
@@ -520,6 +678,10 @@ var MigrationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Health",
 			Handler:    _MigrationService_Health_Handler,
 		},
+		{
+			MethodName: "GetMeta",
+			Handler:    _MigrationService_GetMeta_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,25 @@
+package protobuf
+
+import "github.com/toolsascode/bfm/api/internal/auth"
+
+// methodRoles lists the MigrationService methods that need more than a read-only token
+var methodRoles = map[string]auth.Role{
+	MigrationService_Migrate_FullMethodName:           auth.RoleOperator,
+	MigrationService_StreamMigrate_FullMethodName:     auth.RoleOperator,
+	MigrationService_MigrateDown_FullMethodName:       auth.RoleOperator,
+	MigrationService_RollbackMigration_FullMethodName: auth.RoleOperator,
+	MigrationService_ReindexMigrations_FullMethodName: auth.RoleOperator,
+}
+
+// RequiredRole returns the role needed to call a MigrationService method, given its full name
+// ("/migration.MigrationService/Migrate", the gRPC full method and the Connect procedure).
+// Health needs no token and reports false.
+func RequiredRole(fullMethod string) (auth.Role, bool) {
+	if fullMethod == MigrationService_Health_FullMethodName {
+		return "", false
+	}
+	if role, ok := methodRoles[fullMethod]; ok {
+		return role, true
+	}
+	return auth.RoleReadOnly, true
+}
@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// Authentication modes (BFM_AUTH_MODE)
+const (
+	// ModeToken authenticates static tokens only (BFM_API_TOKEN, BFM_ADMIN_TOKEN, BFM_TOKENS, BFM_TOKENS_FILE)
+	ModeToken = "token"
+	// ModeOIDC authenticates JWTs issued by an OIDC provider. Static tokens remain accepted as a
+	// fallback for clients without access to the provider, such as CI jobs.
+	ModeOIDC = "oidc"
+)
+
+const (
+	// jwksTTL is how long a fetched key set is used before it is fetched again
+	jwksTTL = time.Hour
+	// jwksMinRefresh limits refetches triggered by tokens signed with an unknown key ID
+	jwksMinRefresh = 30 * time.Second
+)
+
+// jwtAlgorithms are the signing algorithms accepted for JWTs; symmetric algorithms are not, as the
+// key set only holds public keys
+var jwtAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Mode returns the configured authentication mode (BFM_AUTH_MODE)
+func Mode() string {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("BFM_AUTH_MODE")))
+	if mode == "" {
+		return ModeToken
+	}
+	return mode
+}
+
+// oidcConfig is the OIDC provider configuration read from the environment
+type oidcConfig struct {
+	Issuer      string // BFM_OIDC_ISSUER: expected iss claim
+	Audience    string // BFM_OIDC_AUDIENCE: expected aud claim
+	JWKSURL     string // BFM_OIDC_JWKS_URL: key set URL; discovered from the issuer when empty
+	RoleClaim   string // BFM_OIDC_ROLE_CLAIM: claim holding the caller's role (a string or a list)
+	DefaultRole Role   // BFM_OIDC_DEFAULT_ROLE: role of tokens without a recognized role claim
+}
+
+// loadOIDCConfig reads and validates the OIDC configuration
+func loadOIDCConfig() (*oidcConfig, error) {
+	cfg := &oidcConfig{
+		Issuer:    strings.TrimSpace(os.Getenv("BFM_OIDC_ISSUER")),
+		Audience:  strings.TrimSpace(os.Getenv("BFM_OIDC_AUDIENCE")),
+		JWKSURL:   strings.TrimSpace(os.Getenv("BFM_OIDC_JWKS_URL")),
+		RoleClaim: strings.TrimSpace(os.Getenv("BFM_OIDC_ROLE_CLAIM")),
+	}
+	if cfg.Issuer == "" {
+		return nil, errors.New("BFM_OIDC_ISSUER is required when BFM_AUTH_MODE is oidc")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("BFM_OIDC_AUDIENCE is required when BFM_AUTH_MODE is oidc")
+	}
+	if cfg.JWKSURL != "" {
+		if u, err := url.Parse(cfg.JWKSURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid BFM_OIDC_JWKS_URL %q", cfg.JWKSURL)
+		}
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "bfm_role"
+	}
+	cfg.DefaultRole = RoleReadOnly
+	if raw := os.Getenv("BFM_OIDC_DEFAULT_ROLE"); raw != "" {
+		role, err := ParseRole(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BFM_OIDC_DEFAULT_ROLE: %w", err)
+		}
+		cfg.DefaultRole = role
+	}
+	return cfg, nil
+}
+
+// looksLikeJWT reports whether token has the three dot-separated segments of a compact JWS
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// authenticateJWT validates a JWT against the OIDC provider's keys, issuer and audience, and
+// returns its subject with the role found in the role claim
+func authenticateJWT(token string) (*Identity, error) {
+	cfg, err := loadOIDCConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return providerKeys.key(cfg, kid)
+	},
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithValidMethods(jwtAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, errors.New("invalid token: missing sub claim")
+	}
+	return &Identity{Name: subject, Role: roleFromClaim(claims[cfg.RoleClaim], cfg.DefaultRole)}, nil
+}
+
+// roleFromClaim returns the most privileged role named by a role claim, which may be a string or
+// a list (such as a groups claim). Values that are not roles are ignored.
+func roleFromClaim(claim interface{}, defaultRole Role) Role {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var best Role
+	for _, value := range values {
+		role, err := ParseRole(value)
+		if err == nil && (best == "" || role.Allows(best)) {
+			best = role
+		}
+	}
+	if best == "" {
+		return defaultRole
+	}
+	return best
+}
+
+// keySetCache keeps the provider's key set, fetching it again when it expires or when a token
+// is signed with a key ID it doesn't hold (the provider rotated its keys). mu only guards the
+// fields: fetches run outside of it, one at a time per URL, so that a slow provider delays the
+// requests waiting for its keys and not every other one.
+type keySetCache struct {
+	mu        sync.Mutex
+	url       string
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+	jwksURLs  map[string]string // Issuer -> jwks_uri from its discovery document
+	fetches   singleflight.Group
+}
+
+var providerKeys = &keySetCache{}
+
+// key returns the public key with ID kid. Without a kid, the key set must hold a single key.
+func (c *keySetCache) key(cfg *oidcConfig, kid string) (interface{}, error) {
+	jwksURL, err := c.jwksURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	stale := c.url != jwksURL || time.Since(c.fetchedAt) > jwksTTL
+	c.mu.Unlock()
+	if stale {
+		if err := c.fetch(jwksURL); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok, fetchedAt := c.lookup(kid)
+	if !ok && time.Since(fetchedAt) > jwksMinRefresh {
+		if err := c.fetch(jwksURL); err != nil {
+			return nil, err
+		}
+		key, ok, _ = c.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("no key %q in the provider's key set", kid)
+	}
+	return key, nil
+}
+
+// lookup returns the public key with ID kid from the cached key set, and when the set was fetched
+func (c *keySetCache) lookup(kid string) (interface{}, bool, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if kid == "" {
+		if len(c.keys.Keys) == 1 {
+			return c.keys.Keys[0].Key, true, c.fetchedAt
+		}
+		return nil, false, c.fetchedAt
+	}
+	for _, key := range c.keys.Key(kid) {
+		if key.Use == "" || key.Use == "sig" {
+			return key.Key, true, c.fetchedAt
+		}
+	}
+	return nil, false, c.fetchedAt
+}
+
+// jwksURL returns the configured key set URL, or the one announced by the issuer's discovery document
+func (c *keySetCache) jwksURL(cfg *oidcConfig) (string, error) {
+	if cfg.JWKSURL != "" {
+		return cfg.JWKSURL, nil
+	}
+	c.mu.Lock()
+	jwksURL, ok := c.jwksURLs[cfg.Issuer]
+	c.mu.Unlock()
+	if ok {
+		return jwksURL, nil
+	}
+
+	discovered, err, _ := c.fetches.Do("discovery "+cfg.Issuer, func() (interface{}, error) {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		discoveryURL := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getJSON(discoveryURL, &discovery); err != nil {
+			return "", fmt.Errorf("failed to discover the OIDC key set: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return "", fmt.Errorf("failed to discover the OIDC key set: %s has no jwks_uri", discoveryURL)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.jwksURLs == nil {
+			c.jwksURLs = make(map[string]string)
+		}
+		c.jwksURLs[cfg.Issuer] = discovery.JWKSURI
+		return discovery.JWKSURI, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return discovered.(string), nil
+}
+
+// fetch replaces the cached key set with the one at jwksURL. Concurrent fetches of the same URL
+// share one request.
+func (c *keySetCache) fetch(jwksURL string) error {
+	_, err, _ := c.fetches.Do("jwks "+jwksURL, func() (interface{}, error) {
+		var keys jose.JSONWebKeySet
+		if err := getJSON(jwksURL, &keys); err != nil {
+			return nil, fmt.Errorf("failed to fetch the OIDC key set: %w", err)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.url = jwksURL
+		c.keys = keys
+		c.fetchedAt = time.Now()
+		return nil, nil
+	})
+	return err
+}
+
+// getJSON decodes the JSON document at u into out
+func getJSON(u string, out interface{}) error {
+	resp, err := oidcHTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
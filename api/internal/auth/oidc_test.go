@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testProvider serves an OIDC discovery document and key set for one RSA key
+func testProvider(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	t.Cleanup(server.Close)
+	return server, key
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthorize_OIDC(t *testing.T) {
+	defer restoreTokenEnv()()
+	providerKeys = &keySetCache{}
+
+	server, key := testProvider(t)
+	_ = os.Setenv("BFM_AUTH_MODE", "oidc")
+	_ = os.Setenv("BFM_OIDC_ISSUER", server.URL)
+	_ = os.Setenv("BFM_OIDC_AUDIENCE", "bfm")
+	_ = os.Setenv("BFM_OIDC_ROLE_CLAIM", "groups")
+	_ = os.Setenv("BFM_TOKENS", "ci:operator:static-token,deploy:operator:deploy.token.v2")
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": server.URL, "aud": "bfm", "sub": "alice@example.com",
+			"exp": time.Now().Add(time.Hour).Unix(), "groups": []string{"engineering", "operator"},
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		required      Role
+		wantName      string
+		wantErr       bool
+		wantForbidden bool
+	}{
+		{name: "role from groups claim", token: signTestToken(t, key, claims(nil)), required: RoleOperator, wantName: "alice@example.com"},
+		{name: "role from groups is not admin", token: signTestToken(t, key, claims(nil)), required: RoleAdmin, wantErr: true, wantForbidden: true},
+		{name: "default role without a role claim", token: signTestToken(t, key, claims(jwt.MapClaims{"groups": nil})), required: RoleReadOnly, wantName: "alice@example.com"},
+		{name: "default role is read-only", token: signTestToken(t, key, claims(jwt.MapClaims{"groups": nil})), required: RoleOperator, wantErr: true, wantForbidden: true},
+		{name: "wrong audience", token: signTestToken(t, key, claims(jwt.MapClaims{"aud": "other"})), required: RoleReadOnly, wantErr: true},
+		{name: "wrong issuer", token: signTestToken(t, key, claims(jwt.MapClaims{"iss": "https://evil.example.com"})), required: RoleReadOnly, wantErr: true},
+		{name: "expired", token: signTestToken(t, key, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), required: RoleReadOnly, wantErr: true},
+		{name: "static token fallback", token: "static-token", required: RoleOperator, wantName: "ci"},
+		{name: "static token with two dots", token: "deploy.token.v2", required: RoleOperator, wantName: "deploy"},
+		{name: "unknown static token", token: "nope", required: RoleReadOnly, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := Authorize(tt.token, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrForbidden) != tt.wantForbidden {
+				t.Errorf("Authorize() error = %v, want forbidden %v", err, tt.wantForbidden)
+			}
+			if err == nil && identity.Name != tt.wantName {
+				t.Errorf("identity name = %q, want %q", identity.Name, tt.wantName)
+			}
+		})
+	}
+
+	// A token signed by another key is rejected
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := Authenticate(signTestToken(t, otherKey, claims(nil))); err == nil {
+		t.Error("expected a token signed by an unknown key to be rejected")
+	}
+}
+
+func TestValidateTokenConfig_OIDC(t *testing.T) {
+	defer restoreTokenEnv()()
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "valid", env: map[string]string{"BFM_AUTH_MODE": "oidc", "BFM_OIDC_ISSUER": "https://idp.example.com", "BFM_OIDC_AUDIENCE": "bfm"}},
+		{name: "missing audience", env: map[string]string{"BFM_AUTH_MODE": "oidc", "BFM_OIDC_ISSUER": "https://idp.example.com"}, wantErr: true},
+		{name: "invalid default role", env: map[string]string{"BFM_AUTH_MODE": "oidc", "BFM_OIDC_ISSUER": "https://idp.example.com", "BFM_OIDC_AUDIENCE": "bfm", "BFM_OIDC_DEFAULT_ROLE": "viewer"}, wantErr: true},
+		{name: "invalid JWKS URL", env: map[string]string{"BFM_AUTH_MODE": "oidc", "BFM_OIDC_ISSUER": "https://idp.example.com", "BFM_OIDC_AUDIENCE": "bfm", "BFM_OIDC_JWKS_URL": "keys"}, wantErr: true},
+		{name: "unknown mode", env: map[string]string{"BFM_AUTH_MODE": "saml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := restoreTokenEnv()
+			defer restore()
+			for k, v := range tt.env {
+				_ = os.Setenv(k, v)
+			}
+			if err := ValidateTokenConfig(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTokenConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is the set of operations a token may perform. Each role includes the ones below it.
+type Role string
+
+const (
+	// RoleReadOnly may list migrations, their status, history, plans and locks
+	RoleReadOnly Role = "read-only"
+	// RoleOperator may also run up, down and rollback, and trigger reindexes
+	RoleOperator Role = "operator"
+	// RoleAdmin may also perform administrative operations such as force-releasing locks
+	RoleAdmin Role = "admin"
+)
+
+// ErrForbidden is returned when a valid token's role does not allow an operation
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalidToken is returned for a token that is not configured
+var ErrInvalidToken = errors.New("invalid API token")
+
+// roleLevels orders the roles from least to most privileged
+var roleLevels = map[Role]int{
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole parses a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (expected read-only, operator or admin)", name)
+	}
+	return role, nil
+}
+
+// Allows reports whether r includes the required role
+func (r Role) Allows(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// Identity is the caller a token belongs to
+type Identity struct {
+	Name string // JWT subject, or token name from BFM_TOKENS or the tokens file; empty for BFM_API_TOKEN and BFM_ADMIN_TOKEN
+	Role Role
+}
+
+type identityContextKey struct{}
+
+// NewContext returns a copy of ctx carrying identity
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// FromContext returns the identity stored in ctx by NewContext
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// Authenticate returns the identity of token. Tokens come from:
+//
+//   - BFM_API_TOKEN: admin, or operator when BFM_ADMIN_TOKEN is set
+//   - BFM_ADMIN_TOKEN: admin
+//   - BFM_TOKENS: comma-separated name:role:token entries
+//   - BFM_TOKENS_FILE: a YAML file with a tokens list of name, role and token
+//
+// With BFM_AUTH_MODE=oidc, JWTs are validated against the OIDC provider (see authenticateJWT)
+// and the static tokens above remain accepted as a fallback; they are checked first, as a static
+// token may contain dots.
+func Authenticate(token string) (*Identity, error) {
+	tokens, err := loadTokens()
+	// Static tokens are checked first: they may contain dots, and look like JWTs
+	if identity, ok := tokens[token]; ok && err == nil && token != "" {
+		return &identity, nil
+	}
+	if Mode() == ModeOIDC && looksLikeJWT(token) {
+		return authenticateJWT(token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		if Mode() == ModeOIDC {
+			return nil, errors.New("invalid token: expected a JWT")
+		}
+		return nil, errors.New("BFM_API_TOKEN not configured")
+	}
+	identity, ok := tokens[token]
+	if !ok || token == "" {
+		return nil, ErrInvalidToken
+	}
+	return &identity, nil
+}
+
+// Authorize authenticates token and checks that its role allows required. A valid token with
+// an insufficient role returns an error wrapping ErrForbidden.
+func Authorize(token string, required Role) (*Identity, error) {
+	identity, err := Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.Role.Allows(required) {
+		return identity, fmt.Errorf("%w: role %s cannot perform this operation (requires %s)", ErrForbidden, identity.Role, required)
+	}
+	return identity, nil
+}
+
+// CanBreakGlass reports whether identity may send emergency requests, which bypass the execution
+// safeguards: its name is listed in BFM_EMERGENCY_TOKENS (comma-separated names from BFM_TOKENS or
+// the tokens file, or JWT subjects). Unnamed tokens (BFM_API_TOKEN, BFM_ADMIN_TOKEN) never can, so
+// every emergency run is attributed to someone.
+func CanBreakGlass(identity *Identity) bool {
+	if identity == nil || identity.Name == "" {
+		return false
+	}
+	for _, name := range strings.Split(os.Getenv("BFM_EMERGENCY_TOKENS"), ",") {
+		if strings.TrimSpace(name) == identity.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// TokensConfigured reports whether any token source is set, including an OIDC provider
+func TokensConfigured() bool {
+	if Mode() == ModeOIDC && os.Getenv("BFM_OIDC_ISSUER") != "" {
+		return true
+	}
+	return os.Getenv("BFM_API_TOKEN") != "" || os.Getenv("BFM_TOKENS") != "" || os.Getenv("BFM_TOKENS_FILE") != ""
+}
+
+// ValidateTokenConfig loads every token source and reports the first configuration error, so
+// that a malformed BFM_TOKENS, tokens file or OIDC configuration fails at startup rather than on
+// the first request
+func ValidateTokenConfig() error {
+	switch Mode() {
+	case ModeToken:
+	case ModeOIDC:
+		if _, err := loadOIDCConfig(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid BFM_AUTH_MODE %q (expected token or oidc)", os.Getenv("BFM_AUTH_MODE"))
+	}
+	_, err := loadTokens()
+	return err
+}
+
+// loadTokens builds the token table from the environment and the tokens file
+func loadTokens() (map[string]Identity, error) {
+	tokens := make(map[string]Identity)
+	add := func(token string, identity Identity) error {
+		if token == "" {
+			return fmt.Errorf("empty token for %q", identity.Name)
+		}
+		if _, exists := tokens[token]; exists {
+			return fmt.Errorf("token for %q is already configured", identity.Name)
+		}
+		tokens[token] = identity
+		return nil
+	}
+
+	adminToken := os.Getenv("BFM_ADMIN_TOKEN")
+	if apiToken := os.Getenv("BFM_API_TOKEN"); apiToken != "" {
+		role := RoleAdmin
+		if adminToken != "" {
+			role = RoleOperator
+		}
+		tokens[apiToken] = Identity{Role: role}
+	}
+	if adminToken != "" {
+		tokens[adminToken] = Identity{Role: RoleAdmin}
+	}
+
+	if raw := os.Getenv("BFM_TOKENS"); raw != "" {
+		for _, entry := range strings.Split(raw, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, ":", 3)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid BFM_TOKENS entry for %q (expected name:role:token)", parts[0])
+			}
+			role, err := ParseRole(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid BFM_TOKENS entry %q: %w", parts[0], err)
+			}
+			if err := add(parts[2], Identity{Name: parts[0], Role: role}); err != nil {
+				return nil, fmt.Errorf("invalid BFM_TOKENS: %w", err)
+			}
+		}
+	}
+
+	if path := os.Getenv("BFM_TOKENS_FILE"); path != "" {
+		entries, err := tokensFile.load(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			role, err := ParseRole(entry.Role)
+			if err != nil {
+				return nil, fmt.Errorf("invalid token %q in %s: %w", entry.Name, path, err)
+			}
+			if err := add(entry.Token, Identity{Name: entry.Name, Role: role}); err != nil {
+				return nil, fmt.Errorf("invalid tokens file %s: %w", path, err)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// tokenFileEntry is one token in the tokens file
+type tokenFileEntry struct {
+	Name  string `yaml:"name"`
+	Role  string `yaml:"role"`
+	Token string `yaml:"token"`
+}
+
+// tokenFileCache keeps the parsed tokens file until it is modified
+type tokenFileCache struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	entries []tokenFileEntry
+}
+
+var tokensFile = &tokenFileCache{}
+
+// load returns the entries of the tokens file at path, reading it again only when it changed
+func (c *tokenFileCache) load(path string) ([]tokenFileEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == path && c.modTime.Equal(info.ModTime()) {
+		return c.entries, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	var file struct {
+		Tokens []tokenFileEntry `yaml:"tokens"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tokens file %s: %w", path, err)
+	}
+
+	c.path = path
+	c.modTime = info.ModTime()
+	c.entries = file.Tokens
+	return c.entries, nil
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// restoreTokenEnv clears the token variables and returns a function restoring them
+func restoreTokenEnv() func() {
+	keys := []string{
+		"BFM_API_TOKEN", "BFM_ADMIN_TOKEN", "BFM_TOKENS", "BFM_TOKENS_FILE", "BFM_AUTH_MODE",
+		"BFM_OIDC_ISSUER", "BFM_OIDC_AUDIENCE", "BFM_OIDC_JWKS_URL", "BFM_OIDC_ROLE_CLAIM", "BFM_OIDC_DEFAULT_ROLE",
+		"BFM_EMERGENCY_TOKENS",
+	}
+	originals := make(map[string]string, len(keys))
+	for _, key := range keys {
+		originals[key] = os.Getenv(key)
+		_ = os.Unsetenv(key)
+	}
+	return func() {
+		for key, value := range originals {
+			if value != "" {
+				_ = os.Setenv(key, value)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	defer restoreTokenEnv()()
+
+	tokensFile := filepath.Join(t.TempDir(), "tokens.yaml")
+	if err := os.WriteFile(tokensFile, []byte("tokens:\n  - name: ops\n    role: admin\n    token: file-admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("BFM_API_TOKEN", "api-token")
+	_ = os.Setenv("BFM_ADMIN_TOKEN", "admin-token")
+	_ = os.Setenv("BFM_TOKENS", "grafana:read-only:ro-token, ci:operator:op:token")
+	_ = os.Setenv("BFM_TOKENS_FILE", tokensFile)
+
+	tests := []struct {
+		name          string
+		token         string
+		required      Role
+		wantName      string
+		wantErr       bool
+		wantForbidden bool
+	}{
+		{name: "read-only token reads", token: "ro-token", required: RoleReadOnly, wantName: "grafana"},
+		{name: "read-only token cannot migrate", token: "ro-token", required: RoleOperator, wantErr: true, wantForbidden: true},
+		{name: "operator token with a colon migrates", token: "op:token", required: RoleOperator, wantName: "ci"},
+		{name: "operator token is not admin", token: "op:token", required: RoleAdmin, wantErr: true, wantForbidden: true},
+		{name: "API token is operator when an admin token is set", token: "api-token", required: RoleOperator},
+		{name: "API token is not admin when an admin token is set", token: "api-token", required: RoleAdmin, wantErr: true, wantForbidden: true},
+		{name: "admin token", token: "admin-token", required: RoleAdmin},
+		{name: "tokens file", token: "file-admin", required: RoleAdmin, wantName: "ops"},
+		{name: "unknown token", token: "nope", required: RoleReadOnly, wantErr: true},
+		{name: "empty token", token: "", required: RoleReadOnly, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := Authorize(tt.token, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrForbidden) != tt.wantForbidden {
+				t.Errorf("Authorize() error = %v, want forbidden %v", err, tt.wantForbidden)
+			}
+			if err == nil && identity.Name != tt.wantName {
+				t.Errorf("identity name = %q, want %q", identity.Name, tt.wantName)
+			}
+		})
+	}
+
+	// The tokens file is read again when it changes
+	if err := os.WriteFile(tokensFile, []byte("tokens:\n  - name: ops\n    role: read-only\n    token: file-admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(tokensFile, later, later)
+	if _, err := Authorize("file-admin", RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected the downgraded file token to be forbidden, got %v", err)
+	}
+}
+
+func TestValidateTokenConfig(t *testing.T) {
+	defer restoreTokenEnv()()
+
+	tests := []struct {
+		name    string
+		tokens  string
+		wantErr bool
+	}{
+		{name: "valid", tokens: "grafana:read-only:a,ci:OPERATOR:b", wantErr: false},
+		{name: "unknown role", tokens: "grafana:viewer:a", wantErr: true},
+		{name: "missing token", tokens: "grafana:read-only", wantErr: true},
+		{name: "duplicate token", tokens: "a:read-only:x,b:admin:x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_TOKENS", tt.tokens)
+			if err := ValidateTokenConfig(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTokenConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanBreakGlass(t *testing.T) {
+	defer restoreTokenEnv()()
+	_ = os.Setenv("BFM_EMERGENCY_TOKENS", "oncall, sre-lead")
+
+	for _, tt := range []struct {
+		identity *Identity
+		want     bool
+	}{
+		{&Identity{Name: "oncall", Role: RoleOperator}, true},
+		{&Identity{Name: "sre-lead", Role: RoleAdmin}, true},
+		{&Identity{Name: "ci", Role: RoleAdmin}, false},
+		{&Identity{Role: RoleAdmin}, false},
+		{nil, false},
+	} {
+		if got := CanBreakGlass(tt.identity); got != tt.want {
+			t.Errorf("CanBreakGlass(%+v) = %v, want %v", tt.identity, got, tt.want)
+		}
+	}
+}
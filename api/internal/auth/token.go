@@ -2,38 +2,44 @@ package auth
 
 import (
 	"errors"
-	"os"
 	"strings"
 )
 
-// ValidateToken validates an API token
-func ValidateToken(token string) error {
-	expectedToken := os.Getenv("BFM_API_TOKEN")
-	if expectedToken == "" {
-		return errors.New("BFM_API_TOKEN not configured")
-	}
+// Errors of malformed Authorization headers
+var (
+	ErrMissingAuthorization = errors.New("missing Authorization header")
+	ErrInvalidAuthorization = errors.New("invalid Authorization header format")
+	ErrBearerRequired       = errors.New("authorization header must use Bearer scheme")
+)
 
-	if token != expectedToken {
-		return errors.New("invalid API token")
-	}
+// ValidateToken validates an API token of any role (see Authenticate)
+func ValidateToken(token string) error {
+	_, err := Authenticate(token)
+	return err
+}
 
-	return nil
+// ValidateAdminToken validates a token for administrative operations (such as force-releasing
+// migration locks): only admin tokens are accepted. BFM_API_TOKEN is an admin token unless
+// BFM_ADMIN_TOKEN is set.
+func ValidateAdminToken(token string) error {
+	_, err := Authorize(token, RoleAdmin)
+	return err
 }
 
 // ExtractToken extracts the token from an Authorization header
 func ExtractToken(authHeader string) (string, error) {
 	if authHeader == "" {
-		return "", errors.New("missing Authorization header")
+		return "", ErrMissingAuthorization
 	}
 
 	// Support "Bearer {token}" format
 	parts := strings.SplitN(authHeader, " ", 2)
 	if len(parts) != 2 {
-		return "", errors.New("invalid Authorization header format")
+		return "", ErrInvalidAuthorization
 	}
 
 	if strings.ToLower(parts[0]) != "bearer" {
-		return "", errors.New("authorization header must use Bearer scheme")
+		return "", ErrBearerRequired
 	}
 
 	return parts[1], nil
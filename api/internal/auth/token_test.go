@@ -169,6 +169,46 @@ func TestValidateToken(t *testing.T) {
 	}
 }
 
+func TestValidateAdminToken(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	originalAdminToken := os.Getenv("BFM_ADMIN_TOKEN")
+	defer func() {
+		for key, value := range map[string]string{"BFM_API_TOKEN": originalToken, "BFM_ADMIN_TOKEN": originalAdminToken} {
+			if value != "" {
+				_ = os.Setenv(key, value)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		}
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "api-token")
+
+	tests := []struct {
+		name       string
+		adminToken string
+		inputToken string
+		wantErr    bool
+	}{
+		{name: "falls back to API token", adminToken: "", inputToken: "api-token", wantErr: false},
+		{name: "admin token configured", adminToken: "admin-token", inputToken: "admin-token", wantErr: false},
+		{name: "API token rejected when admin token configured", adminToken: "admin-token", inputToken: "api-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.adminToken != "" {
+				_ = os.Setenv("BFM_ADMIN_TOKEN", tt.adminToken)
+			} else {
+				_ = os.Unsetenv("BFM_ADMIN_TOKEN")
+			}
+			if err := ValidateAdminToken(tt.inputToken); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAdminToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestExtractAndValidateToken(t *testing.T) {
 	// Save original token
 	originalToken := os.Getenv("BFM_API_TOKEN")
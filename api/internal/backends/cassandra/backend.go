@@ -0,0 +1,335 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// trackingTable records applied migrations inside each keyspace
+const trackingTable = "bfm_migrations"
+
+// defaultSchemaAgreementTimeout is how long a schema change may take to reach every node
+const defaultSchemaAgreementTimeout = 2 * time.Minute
+
+// ErrSchemaAgreement is returned when the nodes did not agree on the schema after a schema change
+// within {CONNECTION}_SCHEMA_AGREEMENT_TIMEOUT. The change is applied by then, so the migration is
+// not retried: running its statements again may fail or apply them twice.
+var ErrSchemaAgreement = errors.New("schema agreement not reached")
+
+// Backend implements the Backend interface for Cassandra and ScyllaDB.
+// The BfM schema concept maps to a keyspace; each keyspace gets its own session.
+type Backend struct {
+	cluster  *gocql.ClusterConfig
+	config   *backends.ConnectionConfig
+	sessions map[string]*gocql.Session // keyed by keyspace ("" = no keyspace)
+	mu       sync.Mutex
+}
+
+// NewBackend creates a new Cassandra backend
+func NewBackend() *Backend {
+	return &Backend{
+		sessions: make(map[string]*gocql.Session),
+	}
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string {
+	return "cassandra"
+}
+
+// Connect establishes a connection to the Cassandra cluster
+func (b *Backend) Connect(config *backends.ConnectionConfig) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closeSessions()
+	b.config = config
+
+	// Host may be a comma-separated list of contact points
+	hosts := splitHosts(config.Host)
+	if h := config.ExtraValue("hosts"); h != "" {
+		hosts = splitHosts(h)
+	}
+	if len(hosts) == 0 {
+		hosts = []string{"localhost"}
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	if config.Port != "" {
+		port, err := strconv.Atoi(config.Port)
+		if err != nil {
+			return fmt.Errorf("invalid Cassandra port %q: %w", config.Port, err)
+		}
+		cluster.Port = port
+	}
+	if config.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: config.Username,
+			Password: config.Password,
+		}
+	}
+
+	cluster.Consistency = gocql.Quorum
+	if c := config.ExtraValue("consistency"); c != "" {
+		consistency, err := gocql.ParseConsistencyWrapper(c)
+		if err != nil {
+			return fmt.Errorf("invalid Cassandra consistency %q: %w", c, err)
+		}
+		cluster.Consistency = consistency
+	}
+
+	cluster.Timeout = 30 * time.Second
+	if t := config.ExtraValue("timeout"); t != "" {
+		if seconds, err := strconv.Atoi(t); err == nil && seconds > 0 {
+			cluster.Timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	// How long schema changes may take to reach every node (see awaitSchemaAgreement)
+	cluster.MaxWaitSchemaAgreement = defaultSchemaAgreementTimeout
+	if t := config.ExtraValue("schema_agreement_timeout"); t != "" {
+		timeout, err := time.ParseDuration(t)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid Cassandra schema agreement timeout %q", t)
+		}
+		cluster.MaxWaitSchemaAgreement = timeout
+	}
+	if dc := config.ExtraValue("local_dc"); dc != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(dc))
+	}
+
+	b.cluster = cluster
+
+	// Test connection
+	if _, err := b.sessionFor(""); err != nil {
+		return fmt.Errorf("failed to connect to Cassandra: %w", err)
+	}
+	return nil
+}
+
+// Close closes all Cassandra sessions
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closeSessions()
+	b.config = nil
+	return nil
+}
+
+// CreateSchema creates a keyspace if it doesn't exist
+func (b *Backend) CreateSchema(ctx context.Context, schemaName string) error {
+	session, err := b.session("")
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = %s", quoteIdentifier(schemaName), b.replication())
+	if err := session.Query(query).WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf("failed to create keyspace %s: %w", schemaName, err)
+	}
+	if err := awaitSchemaAgreement(ctx, session); err != nil {
+		return fmt.Errorf("keyspace %s: %w", schemaName, err)
+	}
+	return nil
+}
+
+// SchemaExists checks if a keyspace exists
+func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, error) {
+	session, err := b.session("")
+	if err != nil {
+		return false, err
+	}
+	var name string
+	err = session.Query("SELECT keyspace_name FROM system_schema.keyspaces WHERE keyspace_name = ?", schemaName).
+		WithContext(ctx).Scan(&name)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check keyspace existence: %w", err)
+	}
+	return true, nil
+}
+
+// ExecuteMigration executes a CQL migration script statement by statement.
+// CQL has no multi-statement transactions, so a failure leaves earlier statements applied.
+// Schema changes are not transactional either: after each one, the next statement waits until
+// every node agrees on the schema, so that it never runs against a node that has not seen it.
+func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	keyspace := migration.Schema
+	if keyspace == "" && b.config != nil {
+		keyspace = b.config.Database
+	}
+
+	if keyspace != "" {
+		exists, err := b.SchemaExists(ctx, keyspace)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := b.CreateSchema(ctx, keyspace); err != nil {
+				return fmt.Errorf("failed to create keyspace: %w", err)
+			}
+		}
+	}
+
+	session, err := b.session(keyspace)
+	if err != nil {
+		return err
+	}
+
+	for i, stmt := range SplitStatements(migration.UpSQL) {
+		if err := session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("failed to execute statement %d: %w", i+1, err)
+		}
+		if IsSchemaStatement(stmt) {
+			if err := awaitSchemaAgreement(ctx, session); err != nil {
+				return fmt.Errorf("statement %d applied, but %w", i+1, err)
+			}
+		}
+	}
+
+	if keyspace != "" {
+		if err := b.track(ctx, session, migration); err != nil {
+			return fmt.Errorf("failed to record migration in keyspace %s: %w", keyspace, err)
+		}
+	}
+	return nil
+}
+
+// HealthCheck verifies the backend is accessible
+func (b *Backend) HealthCheck(ctx context.Context) error {
+	session, err := b.session("")
+	if err != nil {
+		return err
+	}
+	var version string
+	return session.Query("SELECT release_version FROM system.local").WithContext(ctx).Scan(&version)
+}
+
+// track records the migration in the keyspace's tracking table, or removes it when the script
+// reverts the migration (a down migration or rollback)
+func (b *Backend) track(ctx context.Context, session *gocql.Session, migration *backends.MigrationScript) error {
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		connection text,
+		version text,
+		name text,
+		applied_at timestamp,
+		PRIMARY KEY ((connection), version)
+	)`, trackingTable)
+	if err := session.Query(create).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+
+	stmt, args := trackingQuery(migration, time.Now())
+	return session.Query(stmt, args...).WithContext(ctx).Exec()
+}
+
+// trackingQuery returns the statement track runs for a migration. Whether it is applied or
+// reverted comes from the Reverts flag the executor sets on down and rollback scripts, never from
+// the script's name.
+func trackingQuery(migration *backends.MigrationScript, now time.Time) (string, []interface{}) {
+	if migration.Reverts {
+		return fmt.Sprintf("DELETE FROM %s WHERE connection = ? AND version = ?", trackingTable),
+			[]interface{}{migration.Connection, migration.Version}
+	}
+	return fmt.Sprintf("INSERT INTO %s (connection, version, name, applied_at) VALUES (?, ?, ?, ?)", trackingTable),
+		[]interface{}{migration.Connection, migration.Version, migration.Name, now}
+}
+
+// session returns the session for a keyspace, creating it if needed
+func (b *Backend) session(keyspace string) (*gocql.Session, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sessionFor(keyspace)
+}
+
+// sessionFor returns the session for a keyspace; callers must hold b.mu
+func (b *Backend) sessionFor(keyspace string) (*gocql.Session, error) {
+	if b.cluster == nil {
+		return nil, fmt.Errorf("cassandra connection not initialized")
+	}
+	if s, ok := b.sessions[keyspace]; ok && !s.Closed() {
+		return s, nil
+	}
+
+	cluster := *b.cluster
+	cluster.Keyspace = keyspace
+	s, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session for keyspace %q: %w", keyspace, err)
+	}
+	b.sessions[keyspace] = s
+	return s, nil
+}
+
+// closeSessions closes all open sessions; callers must hold b.mu
+func (b *Backend) closeSessions() {
+	for keyspace, s := range b.sessions {
+		s.Close()
+		delete(b.sessions, keyspace)
+	}
+}
+
+// awaitSchemaAgreement waits until every node reports the same schema version. The driver already
+// waits after each schema change it is answered for, but only logs a disagreement and carries on,
+// so the next statement could run against a node that has not seen the change. Waiting again
+// costs one round trip once the nodes agree, and turns a disagreement into an error. The driver's
+// error is logged rather than wrapped, so that the failure is not classified by its message as a
+// retryable connectivity failure.
+func awaitSchemaAgreement(ctx context.Context, session *gocql.Session) error {
+	if err := session.AwaitSchemaAgreement(ctx); err != nil {
+		logger.Warnf("Cassandra: %v", err)
+		return fmt.Errorf("%w; the schema change may still be propagating", ErrSchemaAgreement)
+	}
+	return nil
+}
+
+// replication returns the replication map used when creating keyspaces
+func (b *Backend) replication() string {
+	class := b.config.ExtraValue("replication_class")
+	if class == "" {
+		class = "SimpleStrategy"
+	}
+	if class == "NetworkTopologyStrategy" {
+		// Format: dc1:3,dc2:2
+		parts := []string{"'class': 'NetworkTopologyStrategy'"}
+		for _, entry := range strings.Split(b.config.ExtraValue("replication_dcs"), ",") {
+			dc, rf, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if ok {
+				parts = append(parts, fmt.Sprintf("'%s': %s", dc, rf))
+			}
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	factor := b.config.ExtraValue("replication_factor")
+	if factor == "" {
+		factor = "1"
+	}
+	return fmt.Sprintf("{'class': '%s', 'replication_factor': %s}", class, factor)
+}
+
+// splitHosts splits a comma-separated list of contact points
+func splitHosts(value string) []string {
+	var hosts []string
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// quoteIdentifier quotes a CQL identifier
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,45 @@
+package cassandra
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestTrackingQuery(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		migration *backends.MigrationScript
+		want      string
+	}{
+		{
+			name:      "up migration",
+			migration: &backends.MigrationScript{Connection: "core", Version: "20240101120000", Name: "create_sessions"},
+			want:      "INSERT",
+		},
+		{
+			name:      "up migration named like a down one",
+			migration: &backends.MigrationScript{Connection: "core", Version: "20240101120000", Name: "drop_sessions_down"},
+			want:      "INSERT",
+		},
+		{
+			name:      "rollback",
+			migration: &backends.MigrationScript{Connection: "core", Version: "20240101120000", Name: "create_sessions_rollback", Reverts: true},
+			want:      "DELETE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, args := trackingQuery(tt.migration, now)
+			if !strings.HasPrefix(stmt, tt.want) {
+				t.Errorf("trackingQuery() = %q, want %s", stmt, tt.want)
+			}
+			if args[0] != "core" || args[1] != "20240101120000" {
+				t.Errorf("trackingQuery() args = %v, want the connection and version", args)
+			}
+		})
+	}
+}
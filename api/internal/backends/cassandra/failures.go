@@ -0,0 +1,37 @@
+package cassandra
+
+import (
+	"errors"
+
+	"github.com/gocql/gocql"
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+var _ backends.ErrorClassifier = (*Backend)(nil)
+
+// ClassifyError classifies a Cassandra or ScyllaDB error by its protocol error code
+func (b *Backend) ClassifyError(err error) backends.FailureClass {
+	// Statements that did not reach schema agreement are applied: not a retryable failure
+	if errors.Is(err, ErrSchemaAgreement) {
+		return backends.FailureUnknown
+	}
+	if errors.Is(err, gocql.ErrNoConnections) || errors.Is(err, gocql.ErrTimeoutNoResponse) {
+		return backends.FailureConnectivity
+	}
+	var requestErr gocql.RequestError
+	if !errors.As(err, &requestErr) {
+		return backends.FailureUnknown
+	}
+	switch requestErr.Code() {
+	case gocql.ErrCodeSyntax, gocql.ErrCodeInvalid, gocql.ErrCodeConfig:
+		return backends.FailureSyntax
+	case gocql.ErrCodeUnauthorized, gocql.ErrCodeCredentials:
+		return backends.FailurePermission
+	case gocql.ErrCodeAlreadyExists:
+		return backends.FailureConstraint
+	case gocql.ErrCodeUnavailable, gocql.ErrCodeOverloaded, gocql.ErrCodeBootstrapping,
+		gocql.ErrCodeReadTimeout, gocql.ErrCodeWriteTimeout:
+		return backends.FailureConnectivity
+	}
+	return backends.FailureUnknown
+}
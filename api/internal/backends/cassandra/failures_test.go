@@ -0,0 +1,16 @@
+package cassandra
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestClassifyError_SchemaAgreement(t *testing.T) {
+	err := fmt.Errorf("statement 1 applied, but %w", ErrSchemaAgreement)
+	class := backends.Classify(NewBackend(), err)
+	if class != backends.FailureUnknown || class.Retryable() {
+		t.Errorf("Classify() = %s, want a failure that is not retried", class)
+	}
+}
@@ -0,0 +1,93 @@
+package cassandra
+
+import "strings"
+
+// SplitStatements splits a CQL script into individual statements.
+// The driver executes one statement per query, so scripts are split on semicolons
+// outside of string literals, quoted identifiers, $$ blocks and comments.
+// Comments are dropped from the returned statements.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case c == '\'' || c == '"':
+			// String literal or quoted identifier; doubled quotes escape
+			current.WriteRune(c)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == c {
+					if i+1 < len(runes) && runes[i+1] == c {
+						i++
+						current.WriteRune(runes[i])
+						continue
+					}
+					break
+				}
+			}
+		case c == '$' && next == '$':
+			// $$ ... $$ block (used for function bodies)
+			end := i + 2
+			for end < len(runes) && !(runes[end] == '$' && end+1 < len(runes) && runes[end+1] == '$') {
+				end++
+			}
+			end = min(end+2, len(runes))
+			current.WriteString(string(runes[i:end]))
+			i = end - 1
+		case (c == '-' && next == '-') || (c == '/' && next == '/'):
+			// Line comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			current.WriteRune('\n')
+		case c == '/' && next == '*':
+			// Block comment
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i++
+			current.WriteRune(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// schemaKeywords are the first keywords of CQL statements that change the schema
+var schemaKeywords = map[string]bool{
+	"CREATE": true,
+	"ALTER":  true,
+	"DROP":   true,
+}
+
+// IsSchemaStatement reports whether a CQL statement changes the schema (CREATE, ALTER or DROP of a
+// keyspace, table, type, index, view, function or aggregate). Schema changes are not
+// transactional: each one has to reach every node before the next statement may rely on it.
+func IsSchemaStatement(statement string) bool {
+	fields := strings.Fields(statement)
+	return len(fields) > 0 && schemaKeywords[strings.ToUpper(fields[0])]
+}
@@ -0,0 +1,76 @@
+package cassandra
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "single statement without semicolon",
+			script: "CREATE TABLE sessions (id uuid PRIMARY KEY)",
+			want:   []string{"CREATE TABLE sessions (id uuid PRIMARY KEY)"},
+		},
+		{
+			name: "multiple statements with comments",
+			script: `-- bfm-tags: team=sessions
+CREATE TABLE sessions (id uuid PRIMARY KEY); // trailing
+/* block; comment */
+CREATE INDEX ON sessions (id);`,
+			want: []string{
+				"CREATE TABLE sessions (id uuid PRIMARY KEY)",
+				"CREATE INDEX ON sessions (id)",
+			},
+		},
+		{
+			name:   "semicolons inside literals",
+			script: `INSERT INTO t (k, v) VALUES ('a;b', 'it''s'); UPDATE "odd;name" SET v = 'x' WHERE k = 'a'`,
+			want: []string{
+				`INSERT INTO t (k, v) VALUES ('a;b', 'it''s')`,
+				`UPDATE "odd;name" SET v = 'x' WHERE k = 'a'`,
+			},
+		},
+		{
+			name:   "dollar-quoted function body",
+			script: "CREATE FUNCTION f(x int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java AS $$ return x; $$; DROP TABLE t;",
+			want: []string{
+				"CREATE FUNCTION f(x int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java AS $$ return x; $$",
+				"DROP TABLE t",
+			},
+		},
+		{
+			name:   "empty script",
+			script: "  -- nothing here\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitStatements(tt.script)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSchemaStatement(t *testing.T) {
+	for statement, want := range map[string]bool{
+		"CREATE TABLE sessions (id uuid PRIMARY KEY)":   true,
+		"alter table sessions ADD expires_at timestamp": true,
+		"DROP INDEX IF EXISTS sessions_user_idx":        true,
+		"INSERT INTO sessions (id) VALUES (uuid())":     false,
+		"TRUNCATE sessions":                             false,
+		"":                                              false,
+	} {
+		if got := IsSchemaStatement(statement); got != want {
+			t.Errorf("IsSchemaStatement(%q) = %v, want %v", statement, got, want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// maxTxnOps is the maximum number of operations Consul accepts in one transaction
+const maxTxnOps = 64
+
+// Backend implements the Backend interface for the Consul KV store.
+// Migrations are JSON key/value operations (see backends.KVOperation) applied through
+// the Consul transaction API, so each batch of up to 64 operations is atomic.
+type Backend struct {
+	baseURL    string
+	client     *http.Client
+	config     *backends.ConnectionConfig
+	token      string
+	datacenter string
+	prefix     string
+}
+
+// NewBackend creates a new Consul backend
+func NewBackend() *Backend {
+	return &Backend{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string {
+	return "consul"
+}
+
+// Connect establishes a connection to Consul
+func (b *Backend) Connect(config *backends.ConnectionConfig) error {
+	b.config = config
+
+	protocol := "http"
+	if config.ExtraValue("ssl") == "true" || config.ExtraValue("tls") == "true" {
+		protocol = "https"
+	}
+	host := config.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := config.Port
+	if port == "" {
+		port = "8500" // Default Consul HTTP port
+	}
+	b.baseURL = fmt.Sprintf("%s://%s:%s", protocol, host, port)
+
+	// ACL token: {CONN}_TOKEN or {CONN}_DB_PASSWORD
+	b.token = config.ExtraValue("token")
+	if b.token == "" {
+		b.token = config.Password
+	}
+	b.datacenter = config.ExtraValue("datacenter")
+
+	b.prefix = strings.Trim(config.ExtraValue("prefix"), "/")
+
+	if err := b.HealthCheck(context.Background()); err != nil {
+		return fmt.Errorf("failed to connect to Consul: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Consul connection (no-op for HTTP client)
+func (b *Backend) Close() error {
+	return nil
+}
+
+// CreateSchema creates a key prefix by writing a marker key
+func (b *Backend) CreateSchema(ctx context.Context, schemaName string) error {
+	value := fmt.Sprintf(`{"created_at": "%s"}`, time.Now().Format(time.RFC3339))
+	ops := []txnOp{{KV: txnKV{Verb: "set", Key: b.key(schemaName, ".schema_marker"), Value: base64.StdEncoding.EncodeToString([]byte(value))}}}
+	return b.txn(ctx, ops)
+}
+
+// SchemaExists checks if any key exists under the schema prefix
+func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, error) {
+	prefix := b.key(schemaName, "")
+	resp, err := b.do(ctx, http.MethodGet, "/v1/kv/"+escapeKey(prefix), url.Values{"keys": {""}}, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to check schema existence: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to check schema existence: status %d, body: %s", resp.StatusCode, string(body))
+	}
+}
+
+// ExecuteMigration applies the key/value operations of a migration script
+func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	ops, err := backends.ParseKVOperations(migration.UpSQL)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if op.HasPrecondition() {
+			return fmt.Errorf("key %s: preconditions (prev_value, prev_exists) are not supported by Consul", op.Key)
+		}
+	}
+
+	var txnOps []txnOp
+	for _, op := range ops {
+		key := b.key(migration.Schema, op.Key)
+		switch op.Operation {
+		case "put":
+			txnOps = append(txnOps, txnOp{KV: txnKV{Verb: "set", Key: key, Value: base64.StdEncoding.EncodeToString([]byte(op.StringValue()))}})
+		case "delete":
+			txnOps = append(txnOps, txnOp{KV: txnKV{Verb: "delete", Key: key}})
+		case "delete_prefix":
+			txnOps = append(txnOps, txnOp{KV: txnKV{Verb: "delete-tree", Key: key}})
+		default:
+			return fmt.Errorf("unsupported operation type: %s", op.Operation)
+		}
+	}
+
+	for start := 0; start < len(txnOps); start += maxTxnOps {
+		end := min(start+maxTxnOps, len(txnOps))
+		if err := b.txn(ctx, txnOps[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HealthCheck verifies the backend is accessible
+func (b *Backend) HealthCheck(ctx context.Context) error {
+	resp, err := b.do(ctx, http.MethodGet, "/v1/status/leader", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("health check failed: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+type txnKV struct {
+	Verb  string `json:"Verb"`
+	Key   string `json:"Key"`
+	Value string `json:"Value,omitempty"`
+}
+
+type txnOp struct {
+	KV txnKV `json:"KV"`
+}
+
+// txn submits operations to the Consul transaction endpoint
+func (b *Backend) txn(ctx context.Context, ops []txnOp) error {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	resp, err := b.do(ctx, http.MethodPut, "/v1/txn", nil, payload)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("consul transaction failed: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// do sends a request to the Consul HTTP API
+func (b *Backend) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	if b.baseURL == "" {
+		return nil, fmt.Errorf("consul client not initialized")
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	if b.datacenter != "" {
+		query.Set("dc", b.datacenter)
+	}
+
+	requestURL := b.baseURL + path
+	if encoded := query.Encode(); encoded != "" {
+		requestURL += "?" + encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if b.token != "" {
+		req.Header.Set("X-Consul-Token", b.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// key builds the full KV path: {prefix}/{schema}/{key}
+func (b *Backend) key(schemaName, key string) string {
+	var parts []string
+	for _, p := range []string{b.prefix, strings.Trim(schemaName, "/"), strings.TrimPrefix(key, "/")} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	full := strings.Join(parts, "/")
+	if key == "" && full != "" {
+		full += "/"
+	}
+	return full
+}
+
+// escapeKey escapes each path segment of a KV key
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
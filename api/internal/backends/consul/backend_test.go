@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestBackend_ExecuteMigration_UsesTransaction(t *testing.T) {
+	var txnBodies [][]txnOp
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "acl-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/v1/txn" {
+			body, _ := io.ReadAll(r.Body)
+			var ops []txnOp
+			if err := json.Unmarshal(body, &ops); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			txnBodies = append(txnBodies, ops)
+		}
+		_, _ = w.Write([]byte(`"leader:8300"`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	b := NewBackend()
+	if err := b.Connect(&backends.ConnectionConfig{
+		Backend: "consul",
+		Host:    u.Hostname(),
+		Port:    u.Port(),
+		Extra:   map[string]string{"TOKEN": "acl-token", "PREFIX": "/config/"},
+	}); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	script := `[
+		{"operation": "put", "key": "flags/audit", "value": "enabled"},
+		{"operation": "delete", "key": "flags/legacy"}
+	]`
+	if err := b.ExecuteMigration(context.Background(), &backends.MigrationScript{Schema: "prod", UpSQL: script}); err != nil {
+		t.Fatalf("ExecuteMigration() error = %v", err)
+	}
+
+	if len(txnBodies) != 1 || len(txnBodies[0]) != 2 {
+		t.Fatalf("expected one transaction with 2 operations, got %+v", txnBodies)
+	}
+	set := txnBodies[0][0].KV
+	value, _ := base64.StdEncoding.DecodeString(set.Value)
+	if set.Verb != "set" || set.Key != "config/prod/flags/audit" || string(value) != "enabled" {
+		t.Errorf("unexpected set operation %+v (value %q)", set, value)
+	}
+	del := txnBodies[0][1].KV
+	if del.Verb != "delete" || del.Key != "config/prod/flags/legacy" {
+		t.Errorf("unexpected delete operation %+v", del)
+	}
+}
@@ -0,0 +1,122 @@
+package backends
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namePatternRe matches a migration name, or a name prefix ending in *
+var namePatternRe = regexp.MustCompile(`^[A-Za-z0-9_]+\*?$`)
+
+// VersionRange is the target of a "version_range" dependency: bounds on the version (>=, >, <=,
+// <) and optionally a migration name or name prefix, separated by spaces or commas:
+//
+//	>=20240101000000 <20250101000000
+//	auth_* >=20240101000000
+type VersionRange struct {
+	Min          string // Lower bound, "" when open
+	MinExclusive bool
+	Max          string // Upper bound, "" when open
+	MaxExclusive bool
+	Name         string // Name or name prefix ending in * (see MatchName), "" for any
+}
+
+// ParseVersionRange parses the target of a "version_range" dependency (see VersionRange)
+func ParseVersionRange(target string) (*VersionRange, error) {
+	fields := strings.Fields(strings.ReplaceAll(target, ",", " "))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+	r := &VersionRange{}
+	for _, field := range fields {
+		version := strings.TrimLeft(field, "<>=")
+		op := field[:len(field)-len(version)]
+		switch {
+		case op == "":
+			if r.Name != "" {
+				return nil, fmt.Errorf("invalid version range %q: more than one name", target)
+			}
+			if !namePatternRe.MatchString(field) {
+				return nil, fmt.Errorf("invalid version range %q: invalid name %s", target, field)
+			}
+			r.Name = field
+			continue
+		case version == "":
+			return nil, fmt.Errorf("invalid version range %q: %s has no version", target, op)
+		}
+		switch op {
+		case ">=", ">":
+			if r.Min != "" {
+				return nil, fmt.Errorf("invalid version range %q: more than one lower bound", target)
+			}
+			r.Min, r.MinExclusive = version, op == ">"
+		case "<=", "<":
+			if r.Max != "" {
+				return nil, fmt.Errorf("invalid version range %q: more than one upper bound", target)
+			}
+			r.Max, r.MaxExclusive = version, op == "<"
+		default:
+			return nil, fmt.Errorf("invalid version range %q: unknown operator %s", target, op)
+		}
+	}
+	return r, nil
+}
+
+// Contains reports whether the migration of the given version and name is in the range
+func (r *VersionRange) Contains(version, name string) bool {
+	switch {
+	case r.Min != "" && (version < r.Min || (r.MinExclusive && version == r.Min)):
+		return false
+	case r.Max != "" && (version > r.Max || (r.MaxExclusive && version == r.Max)):
+		return false
+	case r.Name != "" && !MatchName(r.Name, name):
+		return false
+	}
+	return true
+}
+
+// MatchName reports whether name matches pattern: the same name, or any name starting with the
+// pattern's prefix when it ends with * (auth_* matches auth_users and auth_roles)
+func MatchName(pattern, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return name == pattern
+}
+
+// MatchesTarget reports whether the migration of the given version and name is a target of the
+// dependency, whatever its connection and schema. An invalid version range matches nothing.
+func (d Dependency) MatchesTarget(version, name string) bool {
+	switch d.TargetType {
+	case "version":
+		return version == d.Target
+	case "min_version":
+		return version >= d.Target
+	case "version_range":
+		r, err := ParseVersionRange(d.Target)
+		return err == nil && r.Contains(version, name)
+	default:
+		// Default to "name"
+		return MatchName(d.Target, name)
+	}
+}
+
+// AnyTarget reports whether the dependency is satisfied by any one of its targets ("min_version"
+// and "version_range") rather than by each of them
+func (d Dependency) AnyTarget() bool {
+	return d.TargetType == "min_version" || d.TargetType == "version_range"
+}
+
+// IsPattern reports whether the dependency matches migrations by a version bound or a name prefix
+// rather than by one exact version or name
+func (d Dependency) IsPattern() bool {
+	switch d.TargetType {
+	case "version":
+		return false
+	case "min_version", "version_range":
+		return true
+	default:
+		return strings.HasSuffix(d.Target, "*")
+	}
+}
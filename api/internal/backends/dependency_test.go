@@ -0,0 +1,52 @@
+package backends
+
+import "testing"
+
+func TestParseVersionRange(t *testing.T) {
+	r, err := ParseVersionRange("auth_* >=20240101000000, <20250101000000")
+	if err != nil {
+		t.Fatalf("ParseVersionRange() error = %v", err)
+	}
+	if r.Min != "20240101000000" || r.MinExclusive || r.Max != "20250101000000" || !r.MaxExclusive || r.Name != "auth_*" {
+		t.Fatalf("unexpected range %+v", r)
+	}
+
+	for _, tc := range []struct {
+		version, name string
+		want          bool
+	}{
+		{"20240101000000", "auth_users", true},
+		{"20241231235959", "auth_roles", true},
+		{"20250101000000", "auth_users", false},
+		{"20231231235959", "auth_users", false},
+		{"20240601000000", "billing_init", false},
+	} {
+		if got := r.Contains(tc.version, tc.name); got != tc.want {
+			t.Errorf("Contains(%s, %s) = %v, want %v", tc.version, tc.name, got, tc.want)
+		}
+	}
+
+	for _, target := range []string{"", ">=", "=>20240101000000", ">=1 >2", "a b", "!20240101000000"} {
+		if _, err := ParseVersionRange(target); err == nil {
+			t.Errorf("expected %q to be rejected", target)
+		}
+	}
+}
+
+func TestDependency_MatchesTarget(t *testing.T) {
+	if !(Dependency{Target: "auth_*"}).MatchesTarget("20240101000000", "auth_users") {
+		t.Errorf("expected a name prefix to match")
+	}
+	if (Dependency{Target: "auth"}).MatchesTarget("20240101000000", "auth_users") {
+		t.Errorf("expected a name without * to match exactly")
+	}
+	if !(Dependency{Target: "20240101000000", TargetType: "min_version"}).MatchesTarget("20240201000000", "x") {
+		t.Errorf("expected min_version to match later versions")
+	}
+	if (Dependency{Target: "<<1", TargetType: "version_range"}).MatchesTarget("0", "x") {
+		t.Errorf("expected an invalid version range to match nothing")
+	}
+	if (Dependency{Target: "auth", TargetType: "name"}).IsPattern() || !(Dependency{Target: "auth_*"}).IsPattern() {
+		t.Errorf("expected only name prefixes to be name patterns")
+	}
+}
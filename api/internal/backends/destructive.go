@@ -0,0 +1,84 @@
+package backends
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// destructiveRe matches the "-- bfm:destructive" directive line
+var destructiveRe = regexp.MustCompile(`(?im)^\s*--\s*bfm:destructive\s*$`)
+
+// Kinds of destructive statements reported by DestructiveStatements
+const (
+	DestructiveDropTable  = "DROP TABLE"
+	DestructiveDropSchema = "DROP SCHEMA"
+	DestructiveDropView   = "DROP MATERIALIZED VIEW"
+	DestructiveTruncate   = "TRUNCATE"
+	DestructiveDropColumn = "DROP COLUMN"
+	DestructiveDeleteAll  = "DELETE without WHERE"
+	DestructiveMarked     = "marked destructive"
+)
+
+// destructiveStatementRes classify the statements that discard data, in the order they are tried
+var destructiveStatementRes = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{DestructiveDropTable, regexp.MustCompile(`(?is)^DROP\s+TABLE\b`)},
+	{DestructiveDropSchema, regexp.MustCompile(`(?is)^DROP\s+SCHEMA\b`)},
+	{DestructiveDropView, regexp.MustCompile(`(?is)^DROP\s+MATERIALIZED\s+VIEW\b`)},
+	{DestructiveTruncate, regexp.MustCompile(`(?is)^TRUNCATE\b`)},
+	{DestructiveDropColumn, regexp.MustCompile(`(?is)^ALTER\s+TABLE\b.*\bDROP\s+COLUMN\b`)},
+	{DestructiveDeleteAll, regexp.MustCompile(`(?is)^DELETE\s+FROM\s+\S+(?:\s+(?:AS\s+)?\w+)?\s*(?:RETURNING\b.*)?$`)},
+}
+
+// lineCommentRe matches SQL line comments, which are ignored when looking for destructive statements
+var lineCommentRe = regexp.MustCompile(`--[^\n]*`)
+
+// DestructiveStatements classifies the migration's up script: it returns the kinds of its
+// statements that discard data (DROP TABLE, DROP SCHEMA, DROP MATERIALIZED VIEW, TRUNCATE,
+// ALTER TABLE ... DROP COLUMN and DELETE without WHERE), in the order they first appear, and
+// DestructiveMarked when the script has a "-- bfm:destructive" line (for an UPDATE or a narrower
+// DELETE that should be treated as destructive too). Safe migrations return none. Only SQL scripts
+// are inspected; code migrations and JSON documents are never destructive.
+func (m *MigrationScript) DestructiveStatements() []string {
+	if m.IsCode() || m.Declarative || ScriptExtension(m.Backend) != ".sql" {
+		return nil
+	}
+	var kinds []string
+	seen := make(map[string]bool)
+	add := func(kind string) {
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	for _, statement := range strings.Split(lineCommentRe.ReplaceAllString(m.UpSQL, ""), ";") {
+		statement = strings.TrimSpace(statement)
+		for _, classifier := range destructiveStatementRes {
+			if classifier.re.MatchString(statement) {
+				add(classifier.kind)
+				break
+			}
+		}
+	}
+	if destructiveRe.MatchString(m.UpSQL) {
+		add(DestructiveMarked)
+	}
+	return kinds
+}
+
+// IsDestructive reports whether the migration's up script discards data (see
+// DestructiveStatements)
+func (m *MigrationScript) IsDestructive() bool {
+	return len(m.DestructiveStatements()) > 0
+}
+
+// Snapshotter is implemented by backends that can back up what a destructive migration is about to
+// discard (see MigrationScript.IsDestructive)
+type Snapshotter interface {
+	// Snapshot backs up the tables and schemas the migration drops, truncates or alters into a new
+	// file in dir and returns its location
+	Snapshot(ctx context.Context, migration *MigrationScript, dir string) (string, error)
+}
@@ -35,8 +35,8 @@ func (b *Backend) Connect(config *backends.ConnectionConfig) error {
 
 	// Parse endpoints
 	endpoints := []string{fmt.Sprintf("%s:%s", config.Host, config.Port)}
-	if config.Extra["endpoints"] != "" {
-		endpoints = strings.Split(config.Extra["endpoints"], ",")
+	if config.ExtraValue("endpoints") != "" {
+		endpoints = strings.Split(config.ExtraValue("endpoints"), ",")
 		for i, ep := range endpoints {
 			endpoints[i] = strings.TrimSpace(ep)
 		}
@@ -44,14 +44,14 @@ func (b *Backend) Connect(config *backends.ConnectionConfig) error {
 
 	// Get timeout
 	timeout := 5 * time.Second
-	if timeoutStr := config.Extra["timeout"]; timeoutStr != "" {
+	if timeoutStr := config.ExtraValue("timeout"); timeoutStr != "" {
 		if parsed, err := time.ParseDuration(timeoutStr); err == nil {
 			timeout = parsed
 		}
 	}
 
 	// Get prefix
-	b.prefix = config.Extra["prefix"]
+	b.prefix = config.ExtraValue("prefix")
 	if b.prefix == "" {
 		b.prefix = "/"
 	}
@@ -59,12 +59,18 @@ func (b *Backend) Connect(config *backends.ConnectionConfig) error {
 		b.prefix += "/"
 	}
 
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS for etcd: %w", err)
+	}
+
 	// Create etcd client
 	client, err := clientv3.New(clientv3.Config{
 		Endpoints:   endpoints,
 		Username:    config.Username,
 		Password:    config.Password,
 		DialTimeout: timeout,
+		TLS:         tlsConfig,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create etcd client: %w", err)
@@ -114,75 +120,37 @@ func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, er
 	return len(resp.Kvs) > 0, nil
 }
 
-// ExecuteMigration executes a migration script
+// ExecuteMigration applies the key/value operations of a migration script (see
+// backends.KVOperation) in one etcd transaction: either every operation is applied or none is.
+// The transaction only commits when the compare-and-swap preconditions of the operations hold.
 func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
-	// For etcd, migrations are key-value operations
-	// The UpSQL contains JSON with key-value pairs or operations
-
-	// Parse the migration SQL as JSON operations
-	var operations []map[string]interface{}
-	if err := json.Unmarshal([]byte(migration.UpSQL), &operations); err != nil {
+	ops, err := backends.ParseKVOperations(migration.UpSQL)
+	if err != nil {
 		// If not JSON, treat as a single key-value operation
-		// Format: key=value or JSON object
-		if strings.Contains(migration.UpSQL, "=") {
-			parts := strings.SplitN(migration.UpSQL, "=", 2)
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			fullKey := b.getTableKey(migration.Schema, migration.Table, key)
-			_, err := b.client.Put(ctx, fullKey, value)
-			return err
+		// Format: key=value
+		script := strings.TrimSpace(migration.UpSQL)
+		if strings.HasPrefix(script, "[") || !strings.Contains(script, "=") {
+			return fmt.Errorf("invalid etcd migration format: %w", err)
 		}
-		return fmt.Errorf("invalid etcd migration format: %w", err)
+		parts := strings.SplitN(script, "=", 2)
+		value, _ := json.Marshal(strings.TrimSpace(parts[1]))
+		ops = []backends.KVOperation{{Operation: "put", Key: strings.TrimSpace(parts[0]), Value: value}}
 	}
-
-	// Execute each operation
-	for _, op := range operations {
-		opType, ok := op["operation"].(string)
-		if !ok {
-			opType = "put" // Default operation
-		}
-
-		switch opType {
-		case "put":
-			key, ok := op["key"].(string)
-			if !ok {
-				return fmt.Errorf("missing key in operation")
-			}
-			value, ok := op["value"].(string)
-			if !ok {
-				// Try to marshal as JSON if value is an object
-				if valObj, ok := op["value"].(map[string]interface{}); ok {
-					valBytes, err := json.Marshal(valObj)
-					if err != nil {
-						return fmt.Errorf("failed to marshal value: %w", err)
-					}
-					value = string(valBytes)
-				} else {
-					return fmt.Errorf("missing value in operation")
-				}
-			}
-			fullKey := b.getTableKey(migration.Schema, migration.Table, key)
-			_, err := b.client.Put(ctx, fullKey, value)
-			if err != nil {
-				return fmt.Errorf("failed to put key %s: %w", key, err)
-			}
-
-		case "delete":
-			key, ok := op["key"].(string)
-			if !ok {
-				return fmt.Errorf("missing key in operation")
-			}
-			fullKey := b.getTableKey(migration.Schema, migration.Table, key)
-			_, err := b.client.Delete(ctx, fullKey)
-			if err != nil {
-				return fmt.Errorf("failed to delete key %s: %w", key, err)
-			}
-
-		default:
-			return fmt.Errorf("unsupported operation type: %s", opType)
-		}
+	if len(ops) == 0 {
+		return nil
 	}
 
+	cmps, txnOps, cmpKeys, err := b.txn(migration.Schema, migration.Table, ops)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Txn(ctx).If(cmps...).Then(txnOps...).Commit()
+	if err != nil {
+		return fmt.Errorf("etcd transaction failed: %w", err)
+	}
+	if !resp.Succeeded {
+		return &PreconditionError{Keys: cmpKeys}
+	}
 	return nil
 }
 
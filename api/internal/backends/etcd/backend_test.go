@@ -0,0 +1,58 @@
+package etcd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestBackend_Txn(t *testing.T) {
+	b := &Backend{prefix: "/app/", config: &backends.ConnectionConfig{Extra: map[string]string{"ALLOWED_PREFIX": "/app/billing/, /shared/"}}}
+	ops, err := backends.ParseKVOperations(`[
+  {"operation": "put", "key": "rate_limit", "value": "200", "prev_value": "100"},
+  {"operation": "put", "key": "mode", "value": {"strict": true}, "prev_exists": false},
+  {"operation": "delete", "key": "legacy"},
+  {"operation": "delete_prefix", "key": "cache/"}
+]`)
+	if err != nil {
+		t.Fatalf("ParseKVOperations() error = %v", err)
+	}
+
+	cmps, txnOps, cmpKeys, err := b.txn("billing", nil, ops)
+	if err != nil {
+		t.Fatalf("txn() error = %v", err)
+	}
+	if len(cmps) != 2 || string(cmps[0].Key) != "/app/billing/rate_limit" || string(cmps[1].Key) != "/app/billing/mode" {
+		t.Fatalf("unexpected comparisons %v", cmps)
+	}
+	if len(cmpKeys) != 2 || cmpKeys[0] != "/app/billing/rate_limit" {
+		t.Errorf("unexpected comparison keys %v", cmpKeys)
+	}
+	if len(txnOps) != 4 || !txnOps[0].IsPut() || string(txnOps[1].ValueBytes()) != `{"strict": true}` ||
+		!txnOps[2].IsDelete() || !txnOps[3].IsDelete() || len(txnOps[3].RangeBytes()) == 0 {
+		t.Fatalf("unexpected operations %v", txnOps)
+	}
+
+	// A key outside the allowed prefixes refuses the whole migration
+	_, _, _, err = b.txn("orders", nil, ops)
+	var prefixErr *KeyPrefixError
+	if !errors.As(err, &prefixErr) || prefixErr.Key != "/app/orders/rate_limit" {
+		t.Fatalf("expected a key prefix error, got %v", err)
+	}
+	if class := backends.Classify(b, err); class != backends.FailurePermission {
+		t.Errorf("Classify() = %s, want permission", class)
+	}
+	if _, _, _, err := b.txn("/shared/", nil, ops); err != nil {
+		t.Errorf("txn() on an absolute schema error = %v", err)
+	}
+
+	if class := backends.Classify(b, &PreconditionError{Keys: cmpKeys}); class != backends.FailureConstraint {
+		t.Errorf("Classify() = %s, want constraint", class)
+	}
+
+	_, _, _, err = b.txn("billing", nil, []backends.KVOperation{{Operation: "delete_prefix", Key: "cache/", PrevValue: []byte(`"x"`)}})
+	if err == nil {
+		t.Error("expected preconditions on delete_prefix to be refused")
+	}
+}
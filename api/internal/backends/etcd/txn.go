@@ -0,0 +1,122 @@
+package etcd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ backends.ErrorClassifier = (*Backend)(nil)
+
+// PreconditionError is returned when the preconditions of a migration did not hold, so that none
+// of its operations were applied
+type PreconditionError struct {
+	Keys []string // Keys the preconditions were on
+}
+
+func (e *PreconditionError) Error() string {
+	return fmt.Sprintf("precondition failed on %s: no operation was applied", strings.Join(e.Keys, ", "))
+}
+
+// KeyPrefixError is returned when an operation of a migration targets a key outside the allowed
+// prefixes of the connection ({CONNECTION}_ALLOWED_PREFIX)
+type KeyPrefixError struct {
+	Key     string
+	Allowed []string
+}
+
+func (e *KeyPrefixError) Error() string {
+	return fmt.Sprintf("key %s is outside the allowed prefixes (%s): no operation was applied", e.Key, strings.Join(e.Allowed, ", "))
+}
+
+// ClassifyError classifies failed preconditions as constraint violations and keys outside the
+// allowed prefixes as permission failures
+func (b *Backend) ClassifyError(err error) backends.FailureClass {
+	var preconditionErr *PreconditionError
+	var prefixErr *KeyPrefixError
+	switch {
+	case errors.As(err, &preconditionErr):
+		return backends.FailureConstraint
+	case errors.As(err, &prefixErr):
+		return backends.FailurePermission
+	}
+	return backends.FailureUnknown
+}
+
+// txn turns the operations of a migration into the comparisons and operations of one etcd
+// transaction, refusing keys outside the allowed prefixes. It returns the keys the comparisons
+// are on.
+func (b *Backend) txn(schema string, table *string, ops []backends.KVOperation) ([]clientv3.Cmp, []clientv3.Op, []string, error) {
+	allowed := b.allowedPrefixes()
+	var (
+		cmps    []clientv3.Cmp
+		txnOps  []clientv3.Op
+		cmpKeys []string
+	)
+	for _, op := range ops {
+		key := b.getTableKey(schema, table, op.Key)
+		if !keyAllowed(key, allowed) {
+			return nil, nil, nil, &KeyPrefixError{Key: key, Allowed: allowed}
+		}
+
+		switch op.Operation {
+		case "put":
+			txnOps = append(txnOps, clientv3.OpPut(key, op.StringValue()))
+		case "delete":
+			txnOps = append(txnOps, clientv3.OpDelete(key))
+		case "delete_prefix":
+			if op.HasPrecondition() {
+				return nil, nil, nil, fmt.Errorf("key %s: preconditions are not supported on delete_prefix", op.Key)
+			}
+			txnOps = append(txnOps, clientv3.OpDelete(key, clientv3.WithPrefix()))
+		default:
+			return nil, nil, nil, fmt.Errorf("unsupported operation type: %s", op.Operation)
+		}
+
+		if len(op.PrevValue) > 0 {
+			cmps = append(cmps, clientv3.Compare(clientv3.Value(key), "=", op.StringPrevValue()))
+		}
+		if op.PrevExists != nil {
+			// A key exists while its create revision is set
+			if *op.PrevExists {
+				cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(key), ">", 0))
+			} else {
+				cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+			}
+		}
+		if op.HasPrecondition() {
+			cmpKeys = append(cmpKeys, key)
+		}
+	}
+	return cmps, txnOps, cmpKeys, nil
+}
+
+// allowedPrefixes returns the key prefixes migrations of the connection may write below, from the
+// comma-separated {CONNECTION}_ALLOWED_PREFIX; none restricts nothing
+func (b *Backend) allowedPrefixes() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(b.config.ExtraValue("allowed_prefix"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// keyAllowed reports whether key, or every key below it for delete_prefix, is under one of the
+// allowed prefixes
+func keyAllowed(key string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, prefix := range allowed {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package backends
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FailureClass is the root cause of a failed execution, recorded in history with the error
+type FailureClass string
+
+// Failure classes
+const (
+	FailureSyntax       FailureClass = "syntax"               // The script is malformed or refers to objects that do not exist
+	FailurePermission   FailureClass = "permission"           // The credentials lack a privilege, or were rejected
+	FailureLockTimeout  FailureClass = "lock_timeout"         // A lock could not be obtained in time, or a deadlock was broken
+	FailureConnectivity FailureClass = "connectivity"         // The database could not be reached, or was not accepting work
+	FailureConstraint   FailureClass = "constraint_violation" // Existing data violates a constraint the script adds or relies on
+	FailureUnknown      FailureClass = "unknown"              // Any other failure
+)
+
+// FailureClasses lists the failure classes
+var FailureClasses = []FailureClass{
+	FailureSyntax, FailurePermission, FailureLockTimeout, FailureConnectivity, FailureConstraint, FailureUnknown,
+}
+
+// Retryable reports whether a failure of this class may succeed when run again unchanged: the
+// database was unreachable or a lock was held. Syntax, permission and constraint failures need a
+// change first, and unknown failures are not retried.
+func (c FailureClass) Retryable() bool {
+	return c == FailureConnectivity || c == FailureLockTimeout
+}
+
+// ErrorClassifier is implemented by backends that classify the errors of their driver, from its
+// error codes
+type ErrorClassifier interface {
+	// ClassifyError returns the class of an error returned by the backend, or FailureUnknown when
+	// the error carries no code the backend recognizes
+	ClassifyError(err error) FailureClass
+}
+
+// Classify returns the class of an error returned by backend: the backend's own classification
+// when it implements ErrorClassifier and recognizes the error, the generic one otherwise
+func Classify(backend Backend, err error) FailureClass {
+	if err == nil {
+		return ""
+	}
+	if classifier, ok := backend.(ErrorClassifier); ok {
+		if class := classifier.ClassifyError(err); class != FailureUnknown && class != "" {
+			return class
+		}
+	}
+	return ClassifyError(err)
+}
+
+// ClassifyError classifies an error without knowing its backend: network errors, gRPC status codes
+// (etcd, Spanner), then the fragments of ClassifyMessage
+func ClassifyError(err error) FailureClass {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return FailureConnectivity
+	}
+	if s, ok := status.FromError(err); ok {
+		if class := classifyCode(s.Code()); class != FailureUnknown {
+			return class
+		}
+	}
+	// etcd client errors carry the gRPC code without a status
+	var coded interface{ Code() codes.Code }
+	if errors.As(err, &coded) {
+		if class := classifyCode(coded.Code()); class != FailureUnknown {
+			return class
+		}
+	}
+	return ClassifyMessage(err.Error())
+}
+
+// classifyCode classifies a gRPC status code
+func classifyCode(code codes.Code) FailureClass {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return FailureConnectivity
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return FailurePermission
+	case codes.Aborted:
+		return FailureLockTimeout
+	case codes.InvalidArgument, codes.NotFound, codes.Unimplemented:
+		return FailureSyntax
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return FailureConstraint
+	}
+	return FailureUnknown
+}
+
+// failureFragments are fragments of error messages, by class, for errors that carry no code: a
+// driver that only reports text, or an error that was already turned into a message
+var failureFragments = []struct {
+	class     FailureClass
+	fragments []string
+}{
+	{FailureConnectivity, []string{
+		"connection refused",
+		"connection reset",
+		"broken pipe",
+		"i/o timeout",
+		"no such host",
+		"too many connections",
+		"too many clients",
+		"the database system is starting up",
+	}},
+	{FailureLockTimeout, []string{
+		"lock timeout",
+		"could not obtain lock",
+		"deadlock detected",
+	}},
+	{FailurePermission, []string{
+		"permission denied",
+		"access denied",
+		"authentication failed",
+		"unauthorized",
+	}},
+	{FailureConstraint, []string{
+		"violates",
+		"duplicate key",
+	}},
+	{FailureSyntax, []string{
+		"syntax error",
+		"does not exist",
+	}},
+}
+
+// ClassifyMessage classifies an error message by the fragments it contains, ignoring case
+func ClassifyMessage(message string) FailureClass {
+	message = strings.ToLower(message)
+	for _, group := range failureFragments {
+		for _, fragment := range group.fragments {
+			if strings.Contains(message, fragment) {
+				return group.class
+			}
+		}
+	}
+	return FailureUnknown
+}
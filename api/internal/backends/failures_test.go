@@ -0,0 +1,67 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want FailureClass
+	}{
+		{"network error", fmt.Errorf("failed to connect: %w", &net.OpError{Op: "dial", Err: errors.New("connect: connection refused")}), FailureConnectivity},
+		{"grpc unavailable", fmt.Errorf("failed to put key: %w", status.Error(codes.Unavailable, "etcdserver: leader changed")), FailureConnectivity},
+		{"grpc permission", status.Error(codes.PermissionDenied, "etcdserver: permission denied"), FailurePermission},
+		{"grpc aborted", status.Error(codes.Aborted, "transaction was aborted"), FailureLockTimeout},
+		{"message only", errors.New(`ERROR: syntax error at or near "CREAT"`), FailureSyntax},
+		{"constraint message", errors.New(`duplicate key value violates unique constraint "users_pkey"`), FailureConstraint},
+		{"unrecognized", errors.New("relation already exists"), FailureUnknown},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// classifyingBackend classifies errors with the message "coded" as constraint violations
+type classifyingBackend struct {
+	Backend
+}
+
+func (classifyingBackend) ClassifyError(err error) FailureClass {
+	if err.Error() == "coded" {
+		return FailureConstraint
+	}
+	return FailureUnknown
+}
+
+func TestClassify(t *testing.T) {
+	backend := classifyingBackend{}
+	if got := Classify(backend, errors.New("coded")); got != FailureConstraint {
+		t.Errorf("expected the backend's classification, got %q", got)
+	}
+	// Errors the backend does not recognize get the generic classification
+	if got := Classify(backend, errors.New("i/o timeout")); got != FailureConnectivity {
+		t.Errorf("expected the generic classification, got %q", got)
+	}
+}
+
+func TestFailureClass_Retryable(t *testing.T) {
+	for _, class := range FailureClasses {
+		want := class == FailureConnectivity || class == FailureLockTimeout
+		if class.Retryable() != want {
+			t.Errorf("%s.Retryable() = %v, want %v", class, class.Retryable(), want)
+		}
+	}
+}
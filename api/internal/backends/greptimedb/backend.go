@@ -2,6 +2,7 @@ package greptimedb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -44,6 +45,17 @@ func (b *Backend) Connect(config *backends.ConnectionConfig) error {
 	if config.Extra["ssl"] == "true" || config.Extra["tls"] == "true" {
 		protocol = "https"
 	}
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS for GreptimeDB: %w", err)
+	}
+	if tlsConfig != nil {
+		protocol = "https"
+		b.client = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+	}
 
 	port := config.Port
 	if port == "" {
@@ -107,8 +119,47 @@ func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.Migr
 		}
 	}
 
-	// Execute migration SQL
-	return b.executeSQL(ctx, dbName, migration.UpSQL)
+	// Refuse tables GreptimeDB would reject before any statement runs
+	if err := ValidateDDL(migration.UpSQL); err != nil {
+		return err
+	}
+
+	return b.execStatements(ctx, dbName, migration.UpSQL)
+}
+
+// execStatements executes a script one statement at a time, as split by SplitStatements, so a
+// failure names the table it hit, and statements whose region is not ready are retried (see
+// withRetry). The progress of each statement is reported to the context's statement observer (see
+// backends.WithStatementObserver).
+func (b *Backend) execStatements(ctx context.Context, dbName, sql string) error {
+	statements := SplitStatements(sql)
+	for i, statement := range statements {
+		progress := backends.StatementProgress{Statement: i + 1, Statements: len(statements)}
+		backends.ReportStatement(ctx, progress)
+		start := time.Now()
+		err := b.withRetry(ctx, func() error {
+			err := b.executeSQL(ctx, dbName, statement)
+			var greptimeErr *Error
+			if errors.As(err, &greptimeErr) {
+				greptimeErr.Table = statementTable(statement)
+			}
+			return err
+		})
+		if err != nil {
+			if len(statements) == 1 {
+				return err
+			}
+			return fmt.Errorf("statement %d of %d: %w", i+1, len(statements), err)
+		}
+		progress.Done, progress.Elapsed = true, time.Since(start)
+		backends.ReportStatement(ctx, progress)
+	}
+	return nil
+}
+
+// SplitStatements splits a script into the statements ExecuteMigration executes
+func (b *Backend) SplitStatements(sql string) []string {
+	return SplitStatements(sql)
 }
 
 // HealthCheck verifies the backend is accessible
@@ -143,11 +194,10 @@ func (b *Backend) executeSQL(ctx context.Context, dbName, sqlQuery string) error
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to execute SQL: status %d, body: %s", resp.StatusCode, string(body))
+	body, _ := io.ReadAll(resp.Body)
+	if greptimeErr := responseError(resp.StatusCode, body); greptimeErr != nil {
+		return greptimeErr
 	}
-
 	return nil
 }
 
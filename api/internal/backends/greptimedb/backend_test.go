@@ -0,0 +1,142 @@
+package greptimedb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// fakeGreptime serves the SQL endpoint of GreptimeDB, failing the statements of failures once per
+// response queued for them
+type fakeGreptime struct {
+	statements []string
+	failures   map[string][]string // Statement prefix to the error bodies it gets, in order
+}
+
+func (f *fakeGreptime) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	statement := r.PostForm.Get("sql")
+	switch {
+	case statement == "SELECT 1":
+	case strings.HasPrefix(statement, "SHOW DATABASES"):
+		_, _ = w.Write([]byte(`{"output":[{"records":{"rows":[["metrics"]]}}]}`))
+		return
+	default:
+		f.statements = append(f.statements, statement)
+		for prefix, bodies := range f.failures {
+			if strings.HasPrefix(statement, prefix) && len(bodies) > 0 {
+				f.failures[prefix] = bodies[1:]
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(bodies[0]))
+				return
+			}
+		}
+	}
+	_, _ = w.Write([]byte(`{"output":[{"affectedrows":0}],"execution_time_ms":1}`))
+}
+
+func newTestBackend(t *testing.T, fake *fakeGreptime, extra map[string]string) *Backend {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+
+	backend := NewBackend()
+	if err := backend.Connect(&backends.ConnectionConfig{Backend: "greptimedb", Host: host, Port: port, Database: "metrics", Extra: extra}); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	return backend
+}
+
+const cpuTable = "CREATE TABLE IF NOT EXISTS cpu (host STRING, ts TIMESTAMP TIME INDEX, usage DOUBLE, PRIMARY KEY (host)) ENGINE=mito"
+
+func TestValidateDDL(t *testing.T) {
+	valid := []string{
+		cpuTable + ";",
+		"CREATE TABLE `disk` (`host` STRING, `ts` TIMESTAMP(3) NOT NULL, `free` BIGINT, TIME INDEX (`ts`), PRIMARY KEY (`host`)) WITH (ttl = '7d');",
+		"CREATE TABLE cpu_copy LIKE cpu; ALTER TABLE cpu ADD COLUMN idle DOUBLE;",
+		"-- CREATE TABLE commented (id INT);\nINSERT INTO cpu VALUES ('a', 0, 1.0);",
+	}
+	for _, script := range valid {
+		if err := ValidateDDL(script); err != nil {
+			t.Errorf("ValidateDDL(%q) error = %v", script, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		script, want string
+	}{
+		{"CREATE TABLE cpu (host STRING, usage DOUBLE);", "table cpu: missing TIME INDEX"},
+		{"CREATE TABLE cpu (a TIMESTAMP TIME INDEX, b TIMESTAMP, TIME INDEX (b));", "more than one TIME INDEX"},
+		{"CREATE TABLE cpu (host STRING, TIME INDEX (ts));", "TIME INDEX column ts is not declared"},
+		{"CREATE TABLE cpu (ts TIMESTAMP TIME INDEX) ENGINE=innodb;", "unknown engine innodb"},
+	} {
+		err := ValidateDDL(tc.script)
+		var ddlErr *DDLError
+		if !errors.As(err, &ddlErr) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ValidateDDL(%q) error = %v, want %q", tc.script, err, tc.want)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := SplitStatements("-- metrics\nCREATE TABLE a (note STRING DEFAULT 'x;y', ts TIMESTAMP TIME INDEX);\n/* b; */ INSERT INTO a VALUES ('it''s', 0);")
+	if len(statements) != 2 || !strings.HasPrefix(statements[0], "CREATE TABLE a") || !strings.Contains(statements[0], "'x;y'") || !strings.HasPrefix(statements[1], "INSERT INTO a") {
+		t.Fatalf("unexpected statements %q", statements)
+	}
+}
+
+func TestBackend_RetriesRegionNotReady(t *testing.T) {
+	notReady := `{"code":4008,"error":"Region 4398046511104(1024, 0) is not ready"}`
+	fake := &fakeGreptime{failures: map[string][]string{"CREATE TABLE": {notReady, notReady}}}
+	backend := newTestBackend(t, fake, map[string]string{"RETRY_BACKOFF": "1ms"})
+
+	migration := &backends.MigrationScript{Schema: "metrics", UpSQL: cpuTable + ";\nINSERT INTO cpu VALUES ('a', 0, 1.0);"}
+	if err := backend.ExecuteMigration(context.Background(), migration); err != nil {
+		t.Fatalf("ExecuteMigration() error = %v", err)
+	}
+	// The CREATE TABLE ran three times, the INSERT once
+	if len(fake.statements) != 4 || !strings.HasPrefix(fake.statements[3], "INSERT") {
+		t.Errorf("unexpected statements %q", fake.statements)
+	}
+}
+
+func TestBackend_ReportsTableAndRegion(t *testing.T) {
+	notReady := `{"code":4008,"error":"Region 4398046511104(1024, 0) is not ready"}`
+	fake := &fakeGreptime{failures: map[string][]string{
+		"CREATE TABLE": {notReady, notReady},
+		"INSERT":       {`{"code":4001,"error":"Table not found: greptime.metrics.cpu"}`},
+	}}
+	backend := newTestBackend(t, fake, map[string]string{"RETRY_ATTEMPTS": "2", "RETRY_BACKOFF": "1ms"})
+
+	err := backend.ExecuteMigration(context.Background(), &backends.MigrationScript{Schema: "metrics", UpSQL: cpuTable})
+	if err == nil || !strings.Contains(err.Error(), "table cpu, region 4398046511104 (table id 1024, region number 0)") {
+		t.Fatalf("expected the table and region in the error, got %v", err)
+	}
+	if class := backends.Classify(backend, err); class != backends.FailureConnectivity {
+		t.Errorf("Classify() = %s, want connectivity", class)
+	}
+
+	err = backend.ExecuteMigration(context.Background(), &backends.MigrationScript{Schema: "metrics", UpSQL: "INSERT INTO cpu VALUES ('a', 0, 1.0)"})
+	if err == nil || !strings.Contains(err.Error(), "table cpu: status 500, code 4001") {
+		t.Fatalf("expected the table in the error, got %v", err)
+	}
+	if class := backends.Classify(backend, err); class != backends.FailureSyntax {
+		t.Errorf("Classify() = %s, want syntax", class)
+	}
+
+	// Invalid tables are refused before anything runs
+	statements := len(fake.statements)
+	err = backend.ExecuteMigration(context.Background(), &backends.MigrationScript{Schema: "metrics", UpSQL: "CREATE TABLE t (id INT)"})
+	if class := backends.Classify(backend, err); class != backends.FailureSyntax || len(fake.statements) != statements {
+		t.Errorf("expected the table to be refused without running, got %v (%s)", err, class)
+	}
+}
@@ -0,0 +1,156 @@
+package greptimedb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+)
+
+// engines are the table engines GreptimeDB accepts in ENGINE clauses
+var engines = map[string]bool{
+	"mito":   true,
+	"metric": true,
+	"file":   true,
+}
+
+var (
+	// createTableRe matches CREATE TABLE statements and captures the table name
+	createTableRe = regexp.MustCompile("(?is)^CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?(`[^`]+`|[\\w.]+)")
+	// tableNameRe captures the table a statement acts on
+	tableNameRe = regexp.MustCompile("(?is)^(?:CREATE\\s+(?:EXTERNAL\\s+)?TABLE(?:\\s+IF\\s+NOT\\s+EXISTS)?|ALTER\\s+TABLE|DROP\\s+TABLE(?:\\s+IF\\s+EXISTS)?|TRUNCATE(?:\\s+TABLE)?|INSERT\\s+INTO|DELETE\\s+FROM)\\s+(`[^`]+`|[\\w.]+)")
+	// timeIndexRe matches TIME INDEX clauses, inline (ts TIMESTAMP TIME INDEX) or as a
+	// constraint (TIME INDEX (ts)), capturing the constraint's column
+	timeIndexRe = regexp.MustCompile("(?i)\\bTIME\\s+INDEX\\b(?:\\s*\\(\\s*(`[^`]+`|\"[^\"]+\"|\\w+)\\s*\\))?")
+	// engineRe captures the engine of an ENGINE clause
+	engineRe = regexp.MustCompile("(?i)\\bENGINE\\s*=\\s*(\\w+)")
+)
+
+// DDLError is a statement of a script that GreptimeDB would reject, found before the script runs
+type DDLError struct {
+	Table   string
+	Message string
+}
+
+func (e *DDLError) Error() string {
+	return fmt.Sprintf("table %s: %s", e.Table, e.Message)
+}
+
+// ValidateDDL checks the CREATE TABLE statements of a script the way GreptimeDB would: every table
+// needs exactly one TIME INDEX, on a column it declares, and its ENGINE, when set, must be one
+// GreptimeDB knows. The first violation is returned as a *DDLError naming the table.
+func ValidateDDL(script string) error {
+	for _, statement := range SplitStatements(script) {
+		match := createTableRe.FindStringSubmatch(statement)
+		if match == nil || isCreateTableAs(statement) {
+			continue
+		}
+		table := unquote(match[1])
+
+		indexes := timeIndexRe.FindAllStringSubmatch(statement, -1)
+		switch {
+		case len(indexes) == 0:
+			return &DDLError{Table: table, Message: "missing TIME INDEX: every GreptimeDB table needs a timestamp column declared as its TIME INDEX"}
+		case len(indexes) > 1:
+			return &DDLError{Table: table, Message: "more than one TIME INDEX"}
+		}
+		if column := unquote(indexes[0][1]); column != "" && !declaresColumn(statement, column) {
+			return &DDLError{Table: table, Message: fmt.Sprintf("TIME INDEX column %s is not declared", column)}
+		}
+
+		if engine := engineRe.FindStringSubmatch(statement); engine != nil && !engines[strings.ToLower(engine[1])] {
+			return &DDLError{Table: table, Message: fmt.Sprintf("unknown engine %s (mito, metric or file)", engine[1])}
+		}
+	}
+	return nil
+}
+
+// isCreateTableAs reports whether a CREATE TABLE statement copies another table (LIKE) or a query
+// (AS SELECT), whose time index is inherited
+func isCreateTableAs(statement string) bool {
+	head := statement
+	if open := strings.Index(statement, "("); open >= 0 {
+		head = statement[:open]
+	}
+	fields := strings.Fields(strings.ToUpper(head))
+	for _, field := range fields {
+		if field == "LIKE" || field == "AS" {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresColumn reports whether the column list of a CREATE TABLE statement declares column
+func declaresColumn(statement, column string) bool {
+	open, end := strings.Index(statement, "("), strings.LastIndex(statement, ")")
+	if open < 0 || end <= open {
+		return false
+	}
+	for _, definition := range splitTopLevel(statement[open+1 : end]) {
+		fields := strings.Fields(definition)
+		if len(fields) > 0 && strings.EqualFold(unquote(fields[0]), column) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTopLevel splits a column list on the commas outside parentheses and quotes
+func splitTopLevel(list string) []string {
+	var (
+		parts []string
+		depth int
+		quote rune
+		start int
+	)
+	for i, c := range list {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, list[start:])
+}
+
+// statementTable returns the table a statement creates, alters, drops or writes, "" for others
+func statementTable(statement string) string {
+	match := tableNameRe.FindStringSubmatch(statement)
+	if match == nil {
+		return ""
+	}
+	return unquote(match[1])
+}
+
+// unquote removes the backticks or double quotes around an identifier
+func unquote(identifier string) string {
+	if len(identifier) >= 2 && (identifier[0] == '`' || identifier[0] == '"') && identifier[len(identifier)-1] == identifier[0] {
+		return identifier[1 : len(identifier)-1]
+	}
+	return identifier
+}
+
+// SplitStatements splits a GreptimeDB SQL script into individual statements.
+// Scripts are executed one statement at a time, so that a failure names the table it hit and a
+// transient one can be retried without running the earlier statements again. Scripts are split
+// like PostgreSQL ones (see postgresql.SplitStatements), and the comments a statement starts with
+// are dropped so that it is matched by its leading keywords.
+func SplitStatements(script string) []string {
+	statements := postgresql.SplitStatements(script)
+	for i, statement := range statements {
+		statements[i] = postgresql.TrimLeadingComments(statement)
+	}
+	return statements
+}
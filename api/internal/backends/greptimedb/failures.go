@@ -0,0 +1,174 @@
+package greptimedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+var _ backends.ErrorClassifier = (*Backend)(nil)
+
+// GreptimeDB status codes, returned in the code of error responses
+const (
+	codeInvalidArguments    = 1004
+	codeInvalidSyntax       = 2000
+	codePlanQuery           = 3000
+	codeTableAlreadyExists  = 4000
+	codeTableNotFound       = 4001
+	codeColumnNotFound      = 4002
+	codeColumnExists        = 4003
+	codeDatabaseNotFound    = 4004
+	codeRegionNotReady      = 4008
+	codeRegionBusy          = 4009
+	codeTableUnavailable    = 4010
+	codeDatabaseExists      = 4011
+	codeStorageUnavailable  = 5000
+	codeResourcesExhausted  = 6000
+	codeRateLimited         = 6001
+	codeFirstAuthentication = 7000
+	codeLastAuthentication  = 7006
+)
+
+const (
+	defaultRetryAttempts = 5
+	defaultRetryBackoff  = 500 * time.Millisecond
+	maxRetryBackoff      = 10 * time.Second
+)
+
+// transientMessages are fragments of the messages of errors that go away on their own, such as a
+// region still opening after a table was created or moved
+var transientMessages = []string{"not ready", "region busy", "is busy", "unavailable", "rate limit"}
+
+// regionRe captures the region ID in GreptimeDB error messages, e.g. "Region 4398046511104(1024, 0)"
+var regionRe = regexp.MustCompile(`(?i)\bregion\s+(?:id\s*)?(\d+)`)
+
+// Error is an error GreptimeDB returned for a statement
+type Error struct {
+	Table      string // Table the statement acts on, "" when unknown
+	StatusCode int    // HTTP status of the response
+	Code       int    // GreptimeDB status code, 0 when the response had none
+	Message    string
+}
+
+func (e *Error) Error() string {
+	var where []string
+	if e.Table != "" {
+		where = append(where, "table "+e.Table)
+	}
+	if region, ok := e.Region(); ok {
+		// Region IDs are the table ID in the high 32 bits and the region number in the low ones
+		where = append(where, fmt.Sprintf("region %d (table id %d, region number %d)", region, region>>32, region&0xffffffff))
+	}
+	prefix := "failed to execute SQL"
+	if len(where) > 0 {
+		prefix += " on " + strings.Join(where, ", ")
+	}
+	if e.Code != 0 {
+		return fmt.Sprintf("%s: status %d, code %d: %s", prefix, e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: status %d, body: %s", prefix, e.StatusCode, e.Message)
+}
+
+// Region returns the region the error names
+func (e *Error) Region() (uint64, bool) {
+	match := regionRe.FindStringSubmatch(e.Message)
+	if match == nil {
+		return 0, false
+	}
+	region, err := strconv.ParseUint(match[1], 10, 64)
+	return region, err == nil
+}
+
+// Transient reports whether the statement may succeed when run again unchanged: its region was
+// not ready or busy, or the storage or server was temporarily unavailable
+func (e *Error) Transient() bool {
+	switch e.Code {
+	case codeRegionNotReady, codeRegionBusy, codeTableUnavailable, codeStorageUnavailable, codeResourcesExhausted, codeRateLimited:
+		return true
+	}
+	message := strings.ToLower(e.Message)
+	for _, fragment := range transientMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseError returns the error of a GreptimeDB response, nil when it succeeded. GreptimeDB
+// reports errors in a JSON body with a code, which some versions send with a 200 status.
+func responseError(statusCode int, body []byte) *Error {
+	var response struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+	}
+	decoded := json.Unmarshal(body, &response) == nil
+	if statusCode == 200 && (!decoded || (response.Code == 0 && response.Error == "")) {
+		return nil
+	}
+	if decoded && response.Error != "" {
+		return &Error{StatusCode: statusCode, Code: response.Code, Message: response.Error}
+	}
+	return &Error{StatusCode: statusCode, Message: string(body)}
+}
+
+// ClassifyError classifies a GreptimeDB error by its status code
+func (b *Backend) ClassifyError(err error) backends.FailureClass {
+	var ddlErr *DDLError
+	if errors.As(err, &ddlErr) {
+		return backends.FailureSyntax
+	}
+	var greptimeErr *Error
+	if !errors.As(err, &greptimeErr) {
+		return backends.FailureUnknown
+	}
+	switch code := greptimeErr.Code; {
+	case greptimeErr.Transient():
+		return backends.FailureConnectivity
+	case code == codeInvalidArguments, code == codeInvalidSyntax, code == codePlanQuery,
+		code == codeTableNotFound, code == codeColumnNotFound, code == codeDatabaseNotFound:
+		return backends.FailureSyntax
+	case code == codeTableAlreadyExists, code == codeColumnExists, code == codeDatabaseExists:
+		return backends.FailureConstraint
+	case code >= codeFirstAuthentication && code <= codeLastAuthentication:
+		return backends.FailurePermission
+	}
+	return backends.FailureUnknown
+}
+
+// withRetry runs a statement until it succeeds, fails for good, or the connection's retry attempts
+// ({CONNECTION}_RETRY_ATTEMPTS) are used up, waiting {CONNECTION}_RETRY_BACKOFF before the first
+// retry and twice as long before each next one. Only transient errors are retried.
+func (b *Backend) withRetry(ctx context.Context, run func() error) error {
+	attempts := defaultRetryAttempts
+	if parsed, err := strconv.Atoi(b.config.ExtraValue("retry_attempts")); err == nil && parsed > 0 {
+		attempts = parsed
+	}
+	backoff := defaultRetryBackoff
+	if parsed, err := time.ParseDuration(b.config.ExtraValue("retry_backoff")); err == nil && parsed >= 0 {
+		backoff = parsed
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := run()
+		var greptimeErr *Error
+		if err == nil || !errors.As(err, &greptimeErr) || !greptimeErr.Transient() || attempt >= attempts {
+			return err
+		}
+		logger.Warnf("GreptimeDB: %v; retrying in %s (attempt %d of %d)", err, backoff, attempt+1, attempts)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (retry canceled: %v)", err, context.Cause(ctx))
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,77 @@
+package backends
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hook is a step run before or after a migration: SQL executed on the migration's connection, a
+// shell command, or a URL notified with a POST. Exactly one of SQL, Shell and URL is set.
+type Hook struct {
+	SQL   string `yaml:"sql,omitempty"`
+	Shell string `yaml:"shell,omitempty"` // Run with sh -c and only PATH, HOME, the migration's variables and {CONNECTION}_HOOK_ENV
+	URL   string `yaml:"url,omitempty"`
+}
+
+// Hook kinds
+const (
+	HookSQL   = "sql"
+	HookShell = "shell"
+	HookURL   = "url"
+)
+
+// Kind returns the kind of the hook (HookSQL, HookShell or HookURL), or "" when it sets none or
+// several of them
+func (h Hook) Kind() string {
+	kind := ""
+	for k, value := range map[string]string{HookSQL: h.SQL, HookShell: h.Shell, HookURL: h.URL} {
+		if value == "" {
+			continue
+		}
+		if kind != "" {
+			return ""
+		}
+		kind = k
+	}
+	return kind
+}
+
+// Hooks are run around the migrations of a migration (its {version}_{name}.hooks.yaml sidecar) or
+// of a connection ({CONNECTION}_HOOKS_FILE): Pre before the up script, in order, and Post after it
+// was applied.
+type Hooks struct {
+	Pre  []Hook `yaml:"pre,omitempty"`
+	Post []Hook `yaml:"post,omitempty"`
+}
+
+// ParseHooks parses a hooks document. Shell hooks do not inherit the server's environment, so
+// DATABASE_URL below must be listed in the connection's {CONNECTION}_HOOK_ENV:
+//
+//	pre:
+//	  - shell: pg_dump --schema-only "$DATABASE_URL" > "/backups/$BFM_MIGRATION_ID.sql"
+//	post:
+//	  - sql: ANALYZE users;
+//	  - url: https://hooks.example.com/migrations
+func ParseHooks(data []byte) (Hooks, error) {
+	var hooks Hooks
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&hooks); err != nil && !errors.Is(err, io.EOF) {
+		return Hooks{}, err
+	}
+	for i, hook := range hooks.Pre {
+		if hook.Kind() == "" {
+			return Hooks{}, fmt.Errorf("pre hook %d: set exactly one of sql, shell and url", i+1)
+		}
+	}
+	for i, hook := range hooks.Post {
+		if hook.Kind() == "" {
+			return Hooks{}, fmt.Errorf("post hook %d: set exactly one of sql, shell and url", i+1)
+		}
+	}
+	return hooks, nil
+}
@@ -2,14 +2,21 @@ package backends
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
 )
 
 // Dependency represents a structured dependency on another migration
 type Dependency struct {
 	Connection     string // Connection name (e.g., "core", "guard")
 	Schema         string // Schema name (optional, for cross-schema dependencies)
-	Target         string // Migration version or name to depend on
-	TargetType     string // "version" or "name" (default: "name" for backward compatibility)
+	Target         string // Migration version, name or name prefix (auth_*), or version range to depend on
+	TargetType     string // "version", "min_version" (any migration at or above Target), "version_range" (any migration in the VersionRange Target) or "name" (default: "name" for backward compatibility)
 	RequiresTable  string // Optional table that must exist before execution
 	RequiresSchema string // Optional schema that must exist before execution
 }
@@ -24,14 +31,46 @@ type MigrationScript struct {
 	Backend                string
 	UpSQL                  string
 	DownSQL                string
-	Dependencies           []string     // Optional: list of migration names this migration depends on (backward compatibility)
-	StructuredDependencies []Dependency // Optional: structured dependencies with validation requirements
-	Tags                   []string     // Optional: key=value labels for tag-filtered execution
+	Dependencies           []string      // Optional: list of migration names this migration depends on (backward compatibility)
+	StructuredDependencies []Dependency  // Optional: structured dependencies with validation requirements
+	Tags                   []string      // Optional: key=value labels for tag-filtered execution
+	Declarative            bool          // UpSQL/DownSQL hold desired-state YAML documents instead of scripts
+	DownGenerated          bool          // DownSQL was generated from UpSQL because no down script was provided
+	Transactional          bool          // Run the script in one transaction, rolled back on failure (see IsTransactional)
+	Reverts                bool          // A down migration or rollback: UpSQL holds the down script of the migration
+	Repeatable             bool          // Applied again whenever its checksum changes (see IsRepeatable)
+	Timeout                time.Duration // Optional: how long the migration may run, instead of the executor's default
+	UpFunc                 MigrationFunc // Optional: code migration run instead of UpSQL (see IsCode)
+	DownFunc               MigrationFunc // Optional: run instead of DownSQL to revert a code migration
+	Hooks                  Hooks         // Optional: run before and after the up script (see Hooks)
+	Verify                 Verify        // Optional: checked after the up script ran (see Verify)
+}
+
+// MigrationFunc is the up or down function of a code migration, for data migrations that need
+// loops, batching or external lookups. It runs in a transaction with the migration's schema on the
+// search path, committed when it returns nil and rolled back otherwise.
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// IsCode reports whether the migration is a code migration: UpFunc runs instead of UpSQL
+func (m *MigrationScript) IsCode() bool {
+	return m.UpFunc != nil
+}
+
+// IsRepeatable reports whether the migration is applied again whenever its UpSQL changes, for
+// views, functions and seed data that are redefined in place: it is Repeatable, or its up script
+// has a "-- bfm:repeatable" line
+func (m *MigrationScript) IsRepeatable() bool {
+	return m.Repeatable || repeatableRe.MatchString(m.UpSQL)
+}
+
+// HasDown reports whether the migration can be reverted: it has a down script or a down function
+func (m *MigrationScript) HasDown() bool {
+	return m.DownSQL != "" || m.DownFunc != nil
 }
 
 // Backend represents a database backend that can execute migrations
 type Backend interface {
-	// Name returns the name of the backend (e.g., "postgresql", "greptimedb", "etcd")
+	// Name returns the name of the backend (e.g., "postgresql", "greptimedb", "etcd", "cassandra")
 	Name() string
 
 	// Connect establishes a connection to the backend
@@ -53,9 +92,109 @@ type Backend interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// Rehearser is implemented by backends that can run a migration and undo everything it changed,
+// for deep dry runs
+type Rehearser interface {
+	// RehearseMigration runs a migration script and rolls it back. It returns
+	// ErrRehearsalUnsupported for scripts it cannot undo.
+	RehearseMigration(ctx context.Context, migration *MigrationScript) error
+}
+
+// CodeRunner is implemented by backends that can run code migrations (see MigrationScript.IsCode):
+// SQL databases reachable through database/sql
+type CodeRunner interface {
+	// ExecuteMigrationFunc runs the migration's UpFunc in a transaction, committed when it returns nil
+	ExecuteMigrationFunc(ctx context.Context, migration *MigrationScript) error
+}
+
+// SchemaLister is implemented by backends that can discover the schemas of a database, for
+// executions that fan out to every schema matching a pattern
+type SchemaLister interface {
+	// ListSchemas returns the schemas whose name matches a SQL LIKE pattern (e.g. "tenant_%"), sorted
+	ListSchemas(ctx context.Context, pattern string) ([]string, error)
+}
+
+// Cloner is implemented by backends that can hand out independent instances. Connect and Close act
+// on the instance they are called on, so executions running at the same time each use their own.
+type Cloner interface {
+	// Clone returns a new, unconnected instance of the backend
+	Clone() Backend
+}
+
+// StatementSplitter is implemented by backends that execute a script statement by statement, so
+// plans and logs can report how many statements a script holds
+type StatementSplitter interface {
+	// SplitStatements splits a script into the statements it is executed as
+	SplitStatements(sql string) []string
+}
+
+// ErrRehearsalUnsupported is returned by RehearseMigration for scripts that cannot be rolled back
+var ErrRehearsalUnsupported = errors.New("script cannot be rehearsed")
+
+// ErrCodeMigrationUnsupported is returned when running a code migration on a backend that is not a
+// CodeRunner
+var ErrCodeMigrationUnsupported = errors.New("backend cannot run code migrations")
+
+// jsonScriptBackends are backends whose migrations are JSON documents rather than SQL
+var jsonScriptBackends = map[string]bool{
+	"etcd":    true,
+	"mongodb": true,
+	"consul":  true,
+	"vault":   true,
+}
+
+// ScriptExtension returns the migration script extension for a backend (".json" or ".sql")
+func ScriptExtension(backend string) string {
+	if jsonScriptBackends[backend] {
+		return ".json"
+	}
+	return ".sql"
+}
+
+// DeclarativeExtension is the script extension of desired-state migrations ({name}.up.yaml)
+const DeclarativeExtension = ".yaml"
+
+// declarativeBackends are backends that can apply desired-state (declarative) migrations
+var declarativeBackends = map[string]bool{
+	"postgresql": true,
+}
+
+// SupportsDeclarative reports whether a backend can apply desired-state migrations
+func SupportsDeclarative(backend string) bool {
+	return declarativeBackends[backend]
+}
+
+// Checksum returns the hex SHA-256 of the migration's UpSQL, used to detect applied migrations
+// whose script was edited afterwards
+func (m *MigrationScript) Checksum() string {
+	sum := sha256.Sum256([]byte(m.UpSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScriptExtension returns the extension of the migration's up/down script files
+func (m *MigrationScript) ScriptExtension() string {
+	if m.Declarative {
+		return DeclarativeExtension
+	}
+	return ScriptExtension(m.Backend)
+}
+
+// noTransactionRe matches the "-- bfm:no-transaction" directive line
+var noTransactionRe = regexp.MustCompile(`(?im)^\s*--\s*bfm:no-transaction\s*$`)
+
+// repeatableRe matches the "-- bfm:repeatable" directive line
+var repeatableRe = regexp.MustCompile(`(?im)^\s*--\s*bfm:repeatable\s*$`)
+
+// IsTransactional reports whether a script should run inside a transaction. Scripts opt out
+// with a "-- bfm:no-transaction" line, for statements PostgreSQL refuses to run in a transaction
+// block (CREATE INDEX CONCURRENTLY, ALTER TYPE ... ADD VALUE on older versions).
+func IsTransactional(script string) bool {
+	return !noTransactionRe.MatchString(script)
+}
+
 // ConnectionConfig holds configuration for a backend connection
 type ConnectionConfig struct {
-	Backend  string // "postgresql", "greptimedb", "etcd"
+	Backend  string // "postgresql", "greptimedb", "etcd", "cassandra", "consul", "vault", "spanner"
 	Host     string
 	Port     string
 	Username string
@@ -63,6 +202,29 @@ type ConnectionConfig struct {
 	Database string
 	Schema   string            // Can be fixed or dynamic
 	Extra    map[string]string // Additional backend-specific config
+
+	// TLS, for the postgresql, greptimedb and etcd backends (see tls.go)
+	SSLMode        string // disable, require, verify-ca or verify-full; "" for the backend's default
+	RootCAPath     string // PEM file of the CAs the server certificate is verified with; the system's when empty
+	ClientCertPath string // PEM file of the client certificate, for servers requiring one
+	ClientKeyPath  string // PEM file of the client certificate's key
+}
+
+// ExtraValue looks up a backend-specific config value case-insensitively; "" when c is nil or the
+// key is not set
+func (c *ConnectionConfig) ExtraValue(key string) string {
+	if c == nil {
+		return ""
+	}
+	if v, ok := c.Extra[key]; ok {
+		return v
+	}
+	for k, v := range c.Extra {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
 }
 
 // MigrationResult represents the result of a migration execution
@@ -0,0 +1,129 @@
+package backends
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestIsTransactional(t *testing.T) {
+	if !IsTransactional("CREATE TABLE t (id INT);") {
+		t.Errorf("expected scripts to be transactional by default")
+	}
+	if IsTransactional("-- bfm-tags: env=prod\n  -- BFM:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (id);") {
+		t.Errorf("expected the directive to disable the transaction")
+	}
+	if !IsTransactional("SELECT 1; -- bfm:no-transaction") {
+		t.Errorf("expected the directive to only count on its own line")
+	}
+}
+
+func TestMigrationScript_Code(t *testing.T) {
+	fn := func(ctx context.Context, tx *sql.Tx) error { return nil }
+	if (&MigrationScript{UpSQL: "SELECT 1;"}).IsCode() {
+		t.Errorf("expected scripts not to be code migrations")
+	}
+	if !(&MigrationScript{UpFunc: fn}).IsCode() {
+		t.Errorf("expected an up function to make a code migration")
+	}
+	if (&MigrationScript{UpFunc: fn}).HasDown() {
+		t.Errorf("expected no down migration without DownSQL or DownFunc")
+	}
+	if !(&MigrationScript{UpFunc: fn, DownFunc: fn}).HasDown() || !(&MigrationScript{DownSQL: "SELECT 1;"}).HasDown() {
+		t.Errorf("expected DownSQL or DownFunc to make a down migration")
+	}
+}
+
+func TestConnectionConfig_ExtraValue(t *testing.T) {
+	config := &ConnectionConfig{Extra: map[string]string{"Keyspace": "app", "keyspace": "exact"}}
+	if got := config.ExtraValue("keyspace"); got != "exact" {
+		t.Errorf("ExtraValue(keyspace) = %q, want the exact key's value", got)
+	}
+	if got := config.ExtraValue("KEYSPACE"); got != "app" && got != "exact" {
+		t.Errorf("ExtraValue(KEYSPACE) = %q, want a case-insensitive match", got)
+	}
+	if got := (&ConnectionConfig{}).ExtraValue("keyspace"); got != "" {
+		t.Errorf("ExtraValue() without Extra = %q, want empty", got)
+	}
+	if got := (*ConnectionConfig)(nil).ExtraValue("keyspace"); got != "" {
+		t.Errorf("ExtraValue() on nil = %q, want empty", got)
+	}
+}
+
+func TestMigrationScript_IsRepeatable(t *testing.T) {
+	if (&MigrationScript{UpSQL: "CREATE VIEW v AS SELECT 1;"}).IsRepeatable() {
+		t.Errorf("expected migrations to run once by default")
+	}
+	if !(&MigrationScript{Repeatable: true}).IsRepeatable() {
+		t.Errorf("expected the flag to make the migration repeatable")
+	}
+	if !(&MigrationScript{UpSQL: "-- bfm-table: v\n-- BFM:repeatable\nCREATE OR REPLACE VIEW v AS SELECT 1;"}).IsRepeatable() {
+		t.Errorf("expected the directive to make the migration repeatable")
+	}
+	if (&MigrationScript{UpSQL: "SELECT 1; -- bfm:repeatable"}).IsRepeatable() {
+		t.Errorf("expected the directive to only count on its own line")
+	}
+}
+
+func TestParseHooks(t *testing.T) {
+	hooks, err := ParseHooks([]byte("pre:\n  - shell: ./snapshot.sh\npost:\n  - sql: ANALYZE users;\n  - url: https://hooks.example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks.Pre) != 1 || hooks.Pre[0].Kind() != HookShell || len(hooks.Post) != 2 || hooks.Post[0].Kind() != HookSQL || hooks.Post[1].Kind() != HookURL {
+		t.Fatalf("unexpected hooks %+v", hooks)
+	}
+	if hooks, err := ParseHooks(nil); err != nil || len(hooks.Pre)+len(hooks.Post) != 0 {
+		t.Errorf("expected an empty document to declare no hooks, got %+v, %v", hooks, err)
+	}
+	for _, doc := range []string{
+		"post:\n  - sql: ANALYZE users;\n    shell: ./notify.sh\n",
+		"pre:\n  - {}\n",
+		"before:\n  - sql: SELECT 1;\n",
+	} {
+		if _, err := ParseHooks([]byte(doc)); err == nil {
+			t.Errorf("expected %q to be refused", doc)
+		}
+	}
+}
+
+func TestMigrationScript_IsDestructive(t *testing.T) {
+	for script, want := range map[string]bool{
+		"CREATE TABLE users (id INT);":                           false,
+		"DROP TABLE IF EXISTS legacy_users;":                     true,
+		"truncate events;":                                       true,
+		"ALTER TABLE users\n  DROP COLUMN nickname;":             true,
+		"ALTER TABLE users DROP CONSTRAINT users_email_key;":     false,
+		"DROP INDEX idx_users_email;":                            false,
+		"-- DROP TABLE users; kept for reference\nSELECT 1;":     false,
+		"-- bfm:destructive\nDELETE FROM sessions WHERE true;":   true,
+		"DROP SCHEMA tenant_old CASCADE;":                        true,
+		"CREATE VIEW v AS SELECT 1;\nDROP MATERIALIZED VIEW mv;": true,
+	} {
+		m := &MigrationScript{Backend: "postgresql", UpSQL: script}
+		if got := m.IsDestructive(); got != want {
+			t.Errorf("IsDestructive(%q) = %v, want %v", script, got, want)
+		}
+	}
+	if (&MigrationScript{Backend: "etcd", UpSQL: `{"op":"delete","key":"DROP TABLE"}`}).IsDestructive() {
+		t.Errorf("expected JSON documents not to be inspected")
+	}
+}
+
+func TestMigrationScript_DestructiveStatements(t *testing.T) {
+	for script, want := range map[string]string{
+		"CREATE TABLE users (id INT);":                                       "",
+		"DELETE FROM sessions;":                                              "DELETE without WHERE",
+		"DELETE FROM sessions s RETURNING s.id;":                             "DELETE without WHERE",
+		"DELETE FROM sessions WHERE expires_at < now();":                     "",
+		"TRUNCATE events;\nALTER TABLE users DROP COLUMN a;\nTRUNCATE logs;": "TRUNCATE, DROP COLUMN",
+		"-- bfm:destructive\nUPDATE users SET email = NULL;":                 "marked destructive",
+		"DROP TABLE t;\n-- bfm:destructive":                                  "DROP TABLE, marked destructive",
+	} {
+		m := &MigrationScript{Backend: "postgresql", UpSQL: script}
+		if got := strings.Join(m.DestructiveStatements(), ", "); got != want {
+			t.Errorf("DestructiveStatements(%q) = %q, want %q", script, got, want)
+		}
+	}
+}
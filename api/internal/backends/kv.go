@@ -0,0 +1,79 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// KVOperation is one step of a JSON key/value migration script, e.g.
+//
+//	[{"operation": "put", "key": "feature_flags/audit", "value": "enabled"},
+//	 {"operation": "delete", "key": "feature_flags/legacy"}]
+//
+// Operation defaults to "put". Value may be a string or any JSON value.
+//
+// PrevValue and PrevExists are compare-and-swap preconditions on the key: the script only
+// applies when the key currently holds PrevValue, or exists (true) or not (false). Only
+// backends that apply a script atomically support them (etcd); the others refuse the script.
+type KVOperation struct {
+	Operation  string          `json:"operation"`
+	Key        string          `json:"key"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	PrevValue  json.RawMessage `json:"prev_value,omitempty"`
+	PrevExists *bool           `json:"prev_exists,omitempty"`
+}
+
+// ParseKVOperations parses a JSON key/value migration script.
+// Leading "--" comment lines (such as bfm-tags) are ignored.
+func ParseKVOperations(script string) ([]KVOperation, error) {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	body := strings.TrimSpace(strings.Join(lines, "\n"))
+	if body == "" {
+		return nil, nil
+	}
+
+	var ops []KVOperation
+	if err := json.Unmarshal([]byte(body), &ops); err != nil {
+		return nil, fmt.Errorf("invalid key/value migration format: %w", err)
+	}
+	for i := range ops {
+		if ops[i].Operation == "" {
+			ops[i].Operation = "put"
+		}
+		ops[i].Operation = strings.ToLower(ops[i].Operation)
+		if ops[i].Key == "" {
+			return nil, fmt.Errorf("operation %d: missing key", i+1)
+		}
+		if ops[i].Operation == "put" && len(ops[i].Value) == 0 {
+			return nil, fmt.Errorf("operation %d: missing value for key %s", i+1, ops[i].Key)
+		}
+	}
+	return ops, nil
+}
+
+// HasPrecondition reports whether the operation carries a compare-and-swap precondition
+func (op KVOperation) HasPrecondition() bool {
+	return len(op.PrevValue) > 0 || op.PrevExists != nil
+}
+
+// StringValue returns the value as a string: JSON strings are unquoted,
+// any other JSON value is returned in its compact encoded form.
+func (op KVOperation) StringValue() string {
+	var s string
+	if err := json.Unmarshal(op.Value, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(op.Value))
+}
+
+// StringPrevValue returns PrevValue as a string, the way StringValue returns Value
+func (op KVOperation) StringPrevValue() string {
+	return KVOperation{Value: op.PrevValue}.StringValue()
+}
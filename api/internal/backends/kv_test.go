@@ -0,0 +1,50 @@
+package backends
+
+import "testing"
+
+func TestParseKVOperations(t *testing.T) {
+	script := `-- bfm-tags: team=platform
+[
+  {"operation": "put", "key": "flags/a", "value": "on"},
+  {"key": "flags/b", "value": {"enabled": true}},
+  {"operation": "DELETE", "key": "flags/c"},
+  {"key": "flags/d", "value": 2, "prev_value": 1},
+  {"operation": "delete", "key": "flags/e", "prev_exists": true}
+]`
+	ops, err := ParseKVOperations(script)
+	if err != nil {
+		t.Fatalf("ParseKVOperations() error = %v", err)
+	}
+	if len(ops) != 5 {
+		t.Fatalf("expected 5 operations, got %d", len(ops))
+	}
+	if ops[0].StringValue() != "on" {
+		t.Errorf("ops[0] value = %q, want on", ops[0].StringValue())
+	}
+	if ops[1].Operation != "put" || ops[1].StringValue() != `{"enabled": true}` {
+		t.Errorf("ops[1] = %s %q", ops[1].Operation, ops[1].StringValue())
+	}
+	if ops[2].Operation != "delete" {
+		t.Errorf("ops[2] operation = %q, want delete", ops[2].Operation)
+	}
+	if ops[2].HasPrecondition() || !ops[3].HasPrecondition() || ops[3].StringPrevValue() != "1" {
+		t.Errorf("unexpected preconditions %+v %+v", ops[2], ops[3])
+	}
+	if ops[4].PrevExists == nil || !*ops[4].PrevExists {
+		t.Errorf("ops[4] prev_exists = %v, want true", ops[4].PrevExists)
+	}
+}
+
+func TestParseKVOperations_Errors(t *testing.T) {
+	for name, script := range map[string]string{
+		"not json":      "key=value",
+		"missing key":   `[{"operation": "put", "value": "x"}]`,
+		"missing value": `[{"operation": "put", "key": "a"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseKVOperations(script); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
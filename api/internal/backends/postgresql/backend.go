@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,7 +10,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/toolsascode/bfm/api/internal/backends"
 )
 
@@ -20,6 +24,8 @@ type Backend struct {
 	mu     sync.Mutex // Protects pool and config from concurrent access
 }
 
+var _ backends.CodeRunner = (*Backend)(nil)
+
 // NewBackend creates a new PostgreSQL backend
 func NewBackend() *Backend {
 	return &Backend{}
@@ -37,23 +43,25 @@ func (b *Backend) Connect(config *backends.ConnectionConfig) error {
 
 	// Build connection string
 	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		"host=%s port=%s user=%s password=%s dbname=%s %s",
 		config.Host,
 		config.Port,
 		config.Username,
 		config.Password,
 		config.Database,
+		config.PostgresSSLParams(),
 	)
 
 	// Check if we're already connected to the same database
 	if b.pool != nil && b.config != nil {
 		existingConnStr := fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			"host=%s port=%s user=%s password=%s dbname=%s %s",
 			b.config.Host,
 			b.config.Port,
 			b.config.Username,
 			b.config.Password,
 			b.config.Database,
+			b.config.PostgresSSLParams(),
 		)
 		// Reuse existing pool if connection string matches
 		if existingConnStr == connStr {
@@ -177,6 +185,9 @@ func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.Migr
 	if b.pool == nil {
 		return fmt.Errorf("database connection not initialized")
 	}
+	if migration.Declarative {
+		return b.applyDesiredState(ctx, migration)
+	}
 	// Ensure schema exists if specified
 	if migration.Schema != "" {
 		exists, err := b.SchemaExists(ctx, migration.Schema)
@@ -190,16 +201,18 @@ func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.Migr
 		}
 	}
 
-	// Begin transaction
+	if !migration.Transactional {
+		return b.executeWithoutTransaction(ctx, migration)
+	}
+
+	// Begin transaction; a failing statement rolls back everything the migration changed
 	tx, err := b.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	// Execute migration SQL
 	// If schema is specified, set search_path or use schema-qualified names
-	sql := migration.UpSQL
 	if migration.Schema != "" {
 		// Set search_path for the transaction
 		setPathSQL := fmt.Sprintf("SET search_path TO %s, public", quoteIdentifier(migration.Schema))
@@ -208,8 +221,7 @@ func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.Migr
 		}
 	}
 
-	// Execute the migration SQL
-	if _, err := tx.Exec(ctx, sql); err != nil {
+	if err := execStatements(ctx, tx, migration.UpSQL); err != nil {
 		return fmt.Errorf("failed to execute migration: %w", err)
 	}
 
@@ -221,6 +233,158 @@ func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.Migr
 	return nil
 }
 
+// RehearseMigration runs a migration script, or the up function of a code migration, in a
+// transaction that is always rolled back, for deep dry runs. The schema is created inside the transaction when missing, so it is rolled back too.
+// Desired-state documents and scripts that opted out of transactions cannot be rehearsed.
+func (b *Backend) RehearseMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	if b.pool == nil {
+		return fmt.Errorf("database connection not initialized")
+	}
+	if migration.Declarative || !migration.Transactional {
+		return backends.ErrRehearsalUnsupported
+	}
+	if migration.IsCode() {
+		return b.runMigrationFunc(ctx, migration, false)
+	}
+
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if migration.Schema != "" {
+		schema := quoteIdentifier(migration.Schema)
+		if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL search_path TO %s, public", schema)); err != nil {
+			return fmt.Errorf("failed to set search_path: %w", err)
+		}
+	}
+
+	if err := execStatements(ctx, tx, migration.UpSQL); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+	return nil
+}
+
+// ExecuteMigrationFunc runs the up function of a code migration in a transaction, committed when
+// it returns nil, with the migration's schema (created if missing) first on the search_path
+func (b *Backend) ExecuteMigrationFunc(ctx context.Context, migration *backends.MigrationScript) error {
+	if b.pool == nil {
+		return fmt.Errorf("database connection not initialized")
+	}
+	return b.runMigrationFunc(ctx, migration, true)
+}
+
+// runMigrationFunc runs the up function of a code migration in a database/sql transaction on the
+// pool, committed only if commit is set
+func (b *Backend) runMigrationFunc(ctx context.Context, migration *backends.MigrationScript, commit bool) error {
+	// Closing db releases its connections to the pool without closing the pool
+	db := stdlib.OpenDBFromPool(b.pool)
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if migration.Schema != "" {
+		schema := quoteIdentifier(migration.Schema)
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL search_path TO %s, public", schema)); err != nil {
+			return fmt.Errorf("failed to set search_path: %w", err)
+		}
+	}
+
+	if err := migration.UpFunc(ctx, tx); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+	if !commit {
+		return nil
+	}
+	if err := tx.Commit(); err != nil && err != sql.ErrTxDone {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// executeWithoutTransaction runs a script that opted out of transactions ("-- bfm:no-transaction")
+// on a single connection. Changes made before a failing statement are not rolled back.
+func (b *Backend) executeWithoutTransaction(ctx context.Context, migration *backends.MigrationScript) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if migration.Schema != "" {
+		setPathSQL := fmt.Sprintf("SET search_path TO %s, public", quoteIdentifier(migration.Schema))
+		if _, err := conn.Exec(ctx, setPathSQL); err != nil {
+			return fmt.Errorf("failed to set search_path: %w", err)
+		}
+		// The connection returns to the pool, so don't leak the session setting
+		defer func() { _, _ = conn.Exec(context.Background(), "RESET search_path") }()
+	}
+
+	if err := execStatements(ctx, conn, migration.UpSQL); err != nil {
+		return fmt.Errorf("failed to execute migration (no transaction, earlier changes were not rolled back): %w", err)
+	}
+	return nil
+}
+
+// execer is a transaction or connection statements are executed on
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// execStatements executes a script one statement at a time, as split by SplitStatements, so a
+// failure names the statement that failed. The progress of each statement is reported to the
+// context's statement observer (see backends.WithStatementObserver).
+func execStatements(ctx context.Context, db execer, sql string) error {
+	statements := SplitStatements(sql)
+	for i, statement := range statements {
+		progress := backends.StatementProgress{Statement: i + 1, Statements: len(statements)}
+		backends.ReportStatement(ctx, progress)
+		start := time.Now()
+		tag, err := db.Exec(ctx, statement)
+		if err != nil {
+			if len(statements) == 1 {
+				return err
+			}
+			return fmt.Errorf("statement %d of %d: %w", i+1, len(statements), err)
+		}
+		progress.Done, progress.RowsAffected, progress.Elapsed = true, tag.RowsAffected(), time.Since(start)
+		backends.ReportStatement(ctx, progress)
+	}
+	return nil
+}
+
+// ListSchemas returns the schemas whose name matches a LIKE pattern (e.g. "tenant_%"), sorted
+func (b *Backend) ListSchemas(ctx context.Context, pattern string) ([]string, error) {
+	if b.pool == nil {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+	rows, err := b.pool.Query(ctx, "SELECT nspname FROM pg_namespace WHERE nspname LIKE $1 ORDER BY nspname", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	schemas, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+// Clone returns a new, unconnected PostgreSQL backend
+func (b *Backend) Clone() backends.Backend {
+	return NewBackend()
+}
+
 // HealthCheck verifies the backend is accessible
 func (b *Backend) HealthCheck(ctx context.Context) error {
 	if b.pool == nil {
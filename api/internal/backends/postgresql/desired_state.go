@@ -0,0 +1,406 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/declarative"
+)
+
+// liveState is the current database state of the objects named in a desired-state document
+type liveState struct {
+	roles       map[string]*liveRole      // Existing roles only
+	memberships map[[2]string]bool        // {role, group} pairs that exist
+	extensions  map[string]*liveExtension // Existing extensions only
+	schemas     map[string]string         // Existing schema -> owner
+	privileges  map[privilegeKey]bool     // Privileges currently held
+}
+
+type liveRole struct {
+	login, createDB, createRole, inherit bool
+}
+
+type liveExtension struct {
+	schema, version string
+}
+
+type privilegeKey struct {
+	role, on, object, privilege string
+}
+
+// desiredStateChange is one statement of a desired-state plan
+type desiredStateChange struct {
+	sql         string
+	description string // Safe to surface in errors (never contains secrets)
+}
+
+// applyDesiredState computes the difference between a desired-state document and the live
+// database and applies it in a single transaction. Objects already in the desired state
+// produce no statements, so re-applying a document is a no-op.
+func (b *Backend) applyDesiredState(ctx context.Context, migration *backends.MigrationScript) error {
+	desired, err := declarative.Parse(migration.UpSQL)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	live, err := inspectLiveState(ctx, tx, desired)
+	if err != nil {
+		return fmt.Errorf("failed to inspect current state: %w", err)
+	}
+
+	changes, err := planDesiredState(desired, live, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if _, err := tx.Exec(ctx, change.sql); err != nil {
+			return fmt.Errorf("failed to %s: %w", change.description, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// inspectLiveState reads the current state of every object the document refers to
+func inspectLiveState(ctx context.Context, tx pgx.Tx, desired *declarative.State) (*liveState, error) {
+	live := &liveState{
+		roles:       make(map[string]*liveRole),
+		memberships: make(map[[2]string]bool),
+		extensions:  make(map[string]*liveExtension),
+		schemas:     make(map[string]string),
+		privileges:  make(map[privilegeKey]bool),
+	}
+
+	roleNames := make(map[string]bool)
+	for _, r := range desired.Roles {
+		roleNames[r.Name] = true
+		for _, group := range r.MemberOf {
+			roleNames[group] = true
+		}
+	}
+	for _, g := range desired.Grants {
+		roleNames[g.Role] = true
+	}
+	for name := range roleNames {
+		var role liveRole
+		err := tx.QueryRow(ctx, `SELECT rolcanlogin, rolcreatedb, rolcreaterole, rolinherit FROM pg_roles WHERE rolname = $1`, name).
+			Scan(&role.login, &role.createDB, &role.createRole, &role.inherit)
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", name, err)
+		}
+		live.roles[name] = &role
+	}
+
+	for _, r := range desired.Roles {
+		if live.roles[r.Name] == nil {
+			continue
+		}
+		for _, group := range r.MemberOf {
+			var member bool
+			err := tx.QueryRow(ctx, `
+				SELECT EXISTS(
+					SELECT 1 FROM pg_auth_members m
+					JOIN pg_roles g ON g.oid = m.roleid
+					JOIN pg_roles r ON r.oid = m.member
+					WHERE g.rolname = $1 AND r.rolname = $2
+				)`, group, r.Name).Scan(&member)
+			if err != nil {
+				return nil, fmt.Errorf("membership of %s in %s: %w", r.Name, group, err)
+			}
+			live.memberships[[2]string{r.Name, group}] = member
+		}
+	}
+
+	for _, e := range desired.Extensions {
+		var ext liveExtension
+		err := tx.QueryRow(ctx, `
+			SELECT n.nspname, e.extversion
+			FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+			WHERE e.extname = $1`, e.Name).Scan(&ext.schema, &ext.version)
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("extension %s: %w", e.Name, err)
+		}
+		live.extensions[e.Name] = &ext
+	}
+
+	schemaNames := make(map[string]bool)
+	for _, s := range desired.Schemas {
+		schemaNames[s.Name] = true
+	}
+	for _, g := range desired.Grants {
+		if g.On == declarative.OnSchema || g.On == declarative.OnAllTables {
+			schemaNames[g.Object] = true
+		}
+	}
+	for name := range schemaNames {
+		var owner string
+		err := tx.QueryRow(ctx, `SELECT pg_get_userbyid(nspowner) FROM pg_namespace WHERE nspname = $1`, name).Scan(&owner)
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		live.schemas[name] = owner
+	}
+
+	for _, g := range desired.Grants {
+		if live.roles[g.Role] == nil {
+			continue // Privileges of a role that doesn't exist yet are all missing
+		}
+		exists, err := grantObjectExists(ctx, tx, live, g)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		for _, privilege := range g.Privileges {
+			held, err := hasPrivilege(ctx, tx, g, privilege)
+			if err != nil {
+				return nil, fmt.Errorf("privilege %s of %s on %s %s: %w", privilege, g.Role, g.On, g.Object, err)
+			}
+			live.privileges[privilegeKey{g.Role, g.On, g.Object, privilege}] = held
+		}
+	}
+
+	return live, nil
+}
+
+// grantObjectExists reports whether the object of a grant exists; privilege functions fail on missing objects
+func grantObjectExists(ctx context.Context, tx pgx.Tx, live *liveState, g declarative.Grant) (bool, error) {
+	var query string
+	switch g.On {
+	case declarative.OnSchema, declarative.OnAllTables:
+		_, ok := live.schemas[g.Object]
+		return ok, nil
+	case declarative.OnDatabase:
+		query = `SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)`
+	case declarative.OnTable:
+		query = `SELECT to_regclass($1) IS NOT NULL`
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, query, g.Object).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s %s: %w", g.On, g.Object, err)
+	}
+	return exists, nil
+}
+
+// hasPrivilege reports whether the grant's role holds privilege on its object. For all_tables a
+// grant counts as held only when every table has it, a revoke when any table still has it.
+func hasPrivilege(ctx context.Context, tx pgx.Tx, g declarative.Grant, privilege string) (bool, error) {
+	var query string
+	switch g.On {
+	case declarative.OnDatabase:
+		query = `SELECT has_database_privilege($1, $2, $3)`
+	case declarative.OnSchema:
+		query = `SELECT has_schema_privilege($1, $2, $3)`
+	case declarative.OnTable:
+		query = `SELECT has_table_privilege($1, $2, $3)`
+	case declarative.OnAllTables:
+		if g.Absent {
+			query = `SELECT EXISTS(SELECT 1 FROM pg_tables WHERE schemaname = $2 AND has_table_privilege($1, format('%I.%I', schemaname, tablename), $3))`
+		} else {
+			query = `SELECT NOT EXISTS(SELECT 1 FROM pg_tables WHERE schemaname = $2 AND NOT has_table_privilege($1, format('%I.%I', schemaname, tablename), $3))`
+		}
+	}
+	var held bool
+	err := tx.QueryRow(ctx, query, g.Role, g.Object, privilege).Scan(&held)
+	return held, err
+}
+
+// planDesiredState returns the statements that move the live state to the desired state.
+// Creations run first (roles, schemas, extensions, memberships, grants) and removals last
+// in reverse dependency order (revokes, extensions, schemas, roles).
+func planDesiredState(desired *declarative.State, live *liveState, lookupEnv func(string) (string, bool)) ([]desiredStateChange, error) {
+	var changes []desiredStateChange
+	add := func(sql, description string) {
+		changes = append(changes, desiredStateChange{sql: sql, description: description})
+	}
+
+	for _, r := range desired.Roles {
+		if r.Absent {
+			continue
+		}
+		current := live.roles[r.Name]
+		var options []string
+		option := func(want *bool, have bool, on, off string) {
+			if want != nil && (current == nil || *want != have) {
+				if *want {
+					options = append(options, on)
+				} else {
+					options = append(options, off)
+				}
+			}
+		}
+		var have liveRole
+		if current != nil {
+			have = *current
+		}
+		option(r.Login, have.login, "LOGIN", "NOLOGIN")
+		option(r.CreateDB, have.createDB, "CREATEDB", "NOCREATEDB")
+		option(r.CreateRole, have.createRole, "CREATEROLE", "NOCREATEROLE")
+		option(r.Inherit, have.inherit, "INHERIT", "NOINHERIT")
+
+		if current == nil {
+			sql := "CREATE ROLE " + quoteIdentifier(r.Name)
+			if len(options) > 0 {
+				sql += " WITH " + strings.Join(options, " ")
+			}
+			if r.PasswordEnv != "" {
+				password, ok := lookupEnv(r.PasswordEnv)
+				if !ok {
+					return nil, fmt.Errorf("role %s: password_env %s is not set", r.Name, r.PasswordEnv)
+				}
+				sql += " PASSWORD " + quoteLiteral(password)
+			}
+			add(sql, "create role "+r.Name)
+		} else if len(options) > 0 {
+			add(fmt.Sprintf("ALTER ROLE %s WITH %s", quoteIdentifier(r.Name), strings.Join(options, " ")),
+				fmt.Sprintf("alter role %s (%s)", r.Name, strings.Join(options, " ")))
+		}
+	}
+
+	for _, s := range desired.Schemas {
+		if s.Absent {
+			continue
+		}
+		owner, exists := live.schemas[s.Name]
+		switch {
+		case !exists:
+			sql := "CREATE SCHEMA " + quoteIdentifier(s.Name)
+			if s.Owner != "" {
+				sql += " AUTHORIZATION " + quoteIdentifier(s.Owner)
+			}
+			add(sql, "create schema "+s.Name)
+		case s.Owner != "" && s.Owner != owner:
+			add(fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", quoteIdentifier(s.Name), quoteIdentifier(s.Owner)),
+				fmt.Sprintf("change owner of schema %s to %s", s.Name, s.Owner))
+		}
+	}
+
+	for _, e := range desired.Extensions {
+		if e.Absent {
+			continue
+		}
+		current := live.extensions[e.Name]
+		if current == nil {
+			sql := "CREATE EXTENSION " + quoteIdentifier(e.Name)
+			if e.Schema != "" {
+				sql += " WITH SCHEMA " + quoteIdentifier(e.Schema)
+			}
+			if e.Version != "" {
+				sql += " VERSION " + quoteLiteral(e.Version)
+			}
+			add(sql, "create extension "+e.Name)
+			continue
+		}
+		if e.Version != "" && e.Version != current.version {
+			add(fmt.Sprintf("ALTER EXTENSION %s UPDATE TO %s", quoteIdentifier(e.Name), quoteLiteral(e.Version)),
+				fmt.Sprintf("update extension %s to %s", e.Name, e.Version))
+		}
+		if e.Schema != "" && e.Schema != current.schema {
+			add(fmt.Sprintf("ALTER EXTENSION %s SET SCHEMA %s", quoteIdentifier(e.Name), quoteIdentifier(e.Schema)),
+				fmt.Sprintf("move extension %s to schema %s", e.Name, e.Schema))
+		}
+	}
+
+	for _, r := range desired.Roles {
+		if r.Absent {
+			continue
+		}
+		for _, group := range r.MemberOf {
+			if !live.memberships[[2]string{r.Name, group}] {
+				add(fmt.Sprintf("GRANT %s TO %s", quoteIdentifier(group), quoteIdentifier(r.Name)),
+					fmt.Sprintf("grant role %s to %s", group, r.Name))
+			}
+		}
+	}
+
+	grantChanges := func(absent bool) {
+		for _, g := range desired.Grants {
+			if g.Absent != absent {
+				continue
+			}
+			var privileges []string
+			for _, privilege := range g.Privileges {
+				if live.privileges[privilegeKey{g.Role, g.On, g.Object, privilege}] == absent {
+					privileges = append(privileges, privilege)
+				}
+			}
+			if len(privileges) == 0 {
+				continue
+			}
+			list := strings.Join(privileges, ", ")
+			if absent {
+				add(fmt.Sprintf("REVOKE %s ON %s FROM %s", list, grantTarget(g), quoteIdentifier(g.Role)),
+					fmt.Sprintf("revoke %s on %s %s from %s", list, g.On, g.Object, g.Role))
+			} else {
+				add(fmt.Sprintf("GRANT %s ON %s TO %s", list, grantTarget(g), quoteIdentifier(g.Role)),
+					fmt.Sprintf("grant %s on %s %s to %s", list, g.On, g.Object, g.Role))
+			}
+		}
+	}
+	grantChanges(false)
+	grantChanges(true)
+
+	for _, e := range desired.Extensions {
+		if e.Absent && live.extensions[e.Name] != nil {
+			add("DROP EXTENSION "+quoteIdentifier(e.Name), "drop extension "+e.Name)
+		}
+	}
+	for _, s := range desired.Schemas {
+		if _, exists := live.schemas[s.Name]; s.Absent && exists {
+			add("DROP SCHEMA "+quoteIdentifier(s.Name), "drop schema "+s.Name)
+		}
+	}
+	for _, r := range desired.Roles {
+		if r.Absent && live.roles[r.Name] != nil {
+			add("DROP ROLE "+quoteIdentifier(r.Name), "drop role "+r.Name)
+		}
+	}
+
+	return changes, nil
+}
+
+// grantTarget renders the ON clause object of a grant
+func grantTarget(g declarative.Grant) string {
+	switch g.On {
+	case declarative.OnDatabase:
+		return "DATABASE " + quoteIdentifier(g.Object)
+	case declarative.OnSchema:
+		return "SCHEMA " + quoteIdentifier(g.Object)
+	case declarative.OnAllTables:
+		return "ALL TABLES IN SCHEMA " + quoteIdentifier(g.Object)
+	default:
+		parts := strings.Split(g.Object, ".")
+		for i, p := range parts {
+			parts[i] = quoteIdentifier(p)
+		}
+		return "TABLE " + strings.Join(parts, ".")
+	}
+}
+
+// quoteLiteral quotes a PostgreSQL string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,135 @@
+package postgresql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/declarative"
+)
+
+const desiredStateDocument = `
+roles:
+  - name: app
+    login: true
+    password_env: APP_PASSWORD
+    member_of: [readers]
+  - name: legacy
+    absent: true
+extensions:
+  - name: pgcrypto
+    schema: public
+schemas:
+  - name: billing
+    owner: app
+  - name: old
+    absent: true
+grants:
+  - role: app
+    on: schema
+    object: billing
+    privileges: [usage, create]
+  - role: app
+    on: table
+    object: public.users
+    privileges: [select]
+    absent: true
+`
+
+func emptyLiveState() *liveState {
+	return &liveState{
+		roles:       map[string]*liveRole{},
+		memberships: map[[2]string]bool{},
+		extensions:  map[string]*liveExtension{},
+		schemas:     map[string]string{},
+		privileges:  map[privilegeKey]bool{},
+	}
+}
+
+func planSQL(t *testing.T, live *liveState, env map[string]string) []string {
+	t.Helper()
+	desired, err := declarative.Parse(desiredStateDocument)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	changes, err := planDesiredState(desired, live, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatalf("planDesiredState() error = %v", err)
+	}
+	var statements []string
+	for _, c := range changes {
+		statements = append(statements, c.sql)
+	}
+	return statements
+}
+
+func TestPlanDesiredState_FromScratch(t *testing.T) {
+	live := emptyLiveState()
+	live.roles["legacy"] = &liveRole{login: true}
+	live.schemas["old"] = "postgres"
+	live.privileges[privilegeKey{"app", declarative.OnTable, "public.users", "SELECT"}] = true
+
+	got := planSQL(t, live, map[string]string{"APP_PASSWORD": "it's secret"})
+	want := []string{
+		`CREATE ROLE "app" WITH LOGIN PASSWORD 'it''s secret'`,
+		`CREATE SCHEMA "billing" AUTHORIZATION "app"`,
+		`CREATE EXTENSION "pgcrypto" WITH SCHEMA "public"`,
+		`GRANT "readers" TO "app"`,
+		`GRANT USAGE, CREATE ON SCHEMA "billing" TO "app"`,
+		`REVOKE SELECT ON TABLE "public"."users" FROM "app"`,
+		`DROP SCHEMA "old"`,
+		`DROP ROLE "legacy"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPlanDesiredState_AlreadySatisfied(t *testing.T) {
+	live := emptyLiveState()
+	live.roles["app"] = &liveRole{login: true}
+	live.memberships[[2]string{"app", "readers"}] = true
+	live.extensions["pgcrypto"] = &liveExtension{schema: "public", version: "1.3"}
+	live.schemas["billing"] = "app"
+	live.privileges[privilegeKey{"app", declarative.OnSchema, "billing", "USAGE"}] = true
+	live.privileges[privilegeKey{"app", declarative.OnSchema, "billing", "CREATE"}] = true
+
+	// The password is only needed when the role is created
+	if got := planSQL(t, live, nil); len(got) != 0 {
+		t.Errorf("expected no changes, got %v", got)
+	}
+}
+
+func TestPlanDesiredState_Drift(t *testing.T) {
+	live := emptyLiveState()
+	live.roles["app"] = &liveRole{login: false}
+	live.memberships[[2]string{"app", "readers"}] = true
+	live.extensions["pgcrypto"] = &liveExtension{schema: "extensions", version: "1.3"}
+	live.schemas["billing"] = "postgres"
+	live.privileges[privilegeKey{"app", declarative.OnSchema, "billing", "USAGE"}] = true
+
+	got := planSQL(t, live, nil)
+	want := []string{
+		`ALTER ROLE "app" WITH LOGIN`,
+		`ALTER SCHEMA "billing" OWNER TO "app"`,
+		`ALTER EXTENSION "pgcrypto" SET SCHEMA "public"`,
+		`GRANT CREATE ON SCHEMA "billing" TO "app"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPlanDesiredState_MissingPassword(t *testing.T) {
+	desired, err := declarative.Parse(desiredStateDocument)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	_, err = planDesiredState(desired, emptyLiveState(), func(string) (string, bool) { return "", false })
+	if err == nil || !strings.Contains(err.Error(), "APP_PASSWORD is not set") {
+		t.Errorf("expected missing password error, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+var _ backends.ErrorClassifier = (*Backend)(nil)
+
+// ClassifyError classifies a PostgreSQL error by its SQLSTATE code
+func (b *Backend) ClassifyError(err error) backends.FailureClass {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return classifySQLState(pgErr.Code)
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return backends.FailureConnectivity
+	}
+	return backends.FailureUnknown
+}
+
+// classifySQLState classifies a SQLSTATE code (see Appendix A of the PostgreSQL documentation)
+func classifySQLState(code string) backends.FailureClass {
+	switch code {
+	case "42501": // insufficient_privilege
+		return backends.FailurePermission
+	case "55P03", "40P01", "40001": // lock_not_available, deadlock_detected, serialization_failure
+		return backends.FailureLockTimeout
+	case "57014": // query_canceled, raised by lock_timeout and statement_timeout
+		return backends.FailureLockTimeout
+	case "57P01", "57P02", "57P03", "53300": // admin_shutdown, crash_shutdown, cannot_connect_now, too_many_connections
+		return backends.FailureConnectivity
+	}
+	switch {
+	case strings.HasPrefix(code, "08"): // connection_exception
+		return backends.FailureConnectivity
+	case strings.HasPrefix(code, "28"): // invalid_authorization_specification
+		return backends.FailurePermission
+	case strings.HasPrefix(code, "23"): // integrity_constraint_violation
+		return backends.FailureConstraint
+	case strings.HasPrefix(code, "42"), strings.HasPrefix(code, "3F"), strings.HasPrefix(code, "3D"):
+		// syntax_error_or_access_rule_violation, invalid_schema_name, invalid_catalog_name
+		return backends.FailureSyntax
+	}
+	return backends.FailureUnknown
+}
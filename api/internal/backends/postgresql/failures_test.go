@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestBackend_ClassifyError(t *testing.T) {
+	tests := []struct {
+		code string
+		want backends.FailureClass
+	}{
+		{"42601", backends.FailureSyntax},     // syntax_error
+		{"42P01", backends.FailureSyntax},     // undefined_table
+		{"42501", backends.FailurePermission}, // insufficient_privilege
+		{"28P01", backends.FailurePermission}, // invalid_password
+		{"55P03", backends.FailureLockTimeout},
+		{"40P01", backends.FailureLockTimeout},
+		{"57014", backends.FailureLockTimeout},
+		{"08006", backends.FailureConnectivity},
+		{"57P03", backends.FailureConnectivity},
+		{"23505", backends.FailureConstraint}, // unique_violation
+		{"22012", backends.FailureUnknown},    // division_by_zero
+	}
+	b := NewBackend()
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := fmt.Errorf("failed to execute migration: %w", &pgconn.PgError{Code: tt.code})
+			if got := b.ClassifyError(err); got != tt.want {
+				t.Errorf("ClassifyError(%s) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+	if got := b.ClassifyError(errors.New("no code")); got != backends.FailureUnknown {
+		t.Errorf("ClassifyError() without a code = %q, want unknown", got)
+	}
+}
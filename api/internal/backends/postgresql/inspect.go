@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+)
+
+var _ schemadiff.Inspector = (*Backend)(nil)
+
+// InspectSchema reads the tables, columns, indexes and constraints of the given schemas. The
+// catalog is read with only pg_catalog on the search path, so types, defaults and constraint and
+// index definitions name the objects they refer to with their schema.
+func (b *Backend) InspectSchema(ctx context.Context, schemas []string) (*schemadiff.Schema, error) {
+	if b.pool == nil {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+
+	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, "SET LOCAL search_path TO pg_catalog"); err != nil {
+		return nil, fmt.Errorf("failed to set search path: %w", err)
+	}
+
+	schema := schemadiff.NewSchema()
+	table := func(namespace, name string) *schemadiff.Table {
+		return schema.Tables[namespace+"."+name]
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT n.nspname, c.relname
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition AND n.nspname = ANY($1)`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	for rows.Next() {
+		t := &schemadiff.Table{}
+		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read table: %w", err)
+		}
+		schema.Tables[t.Key()] = t
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attidentity::text
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition AND n.nspname = ANY($1)
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY n.nspname, c.relname, a.attnum`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns: %w", err)
+	}
+	for rows.Next() {
+		var namespace, relation, identity string
+		column := &schemadiff.Column{}
+		if err := rows.Scan(&namespace, &relation, &column.Name, &column.Type, &column.NotNull, &column.Default, &identity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read column: %w", err)
+		}
+		switch identity {
+		case "a":
+			column.Identity = "ALWAYS"
+		case "d":
+			column.Identity = "BY DEFAULT"
+		}
+		if t := table(namespace, relation); t != nil {
+			t.Columns = append(t.Columns, column)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list columns: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT n.nspname, c.relname, con.conname, con.contype::text, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE con.contype IN ('p', 'u', 'c', 'f', 'x') AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, con.conname`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list constraints: %w", err)
+	}
+	for rows.Next() {
+		var namespace, relation string
+		constraint := &schemadiff.Constraint{}
+		if err := rows.Scan(&namespace, &relation, &constraint.Name, &constraint.Type, &constraint.Definition); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read constraint: %w", err)
+		}
+		if t := table(namespace, relation); t != nil {
+			t.Constraints = append(t.Constraints, constraint)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list constraints: %w", err)
+	}
+
+	// Indexes backing a primary key, unique or exclusion constraint come with the constraint
+	rows, err = tx.Query(ctx, `
+		SELECT n.nspname, c.relname, i.relname, pg_get_indexdef(i.oid)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class c ON c.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ANY($1)
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_constraint con
+				WHERE con.conindid = x.indexrelid AND con.conrelid = x.indrelid AND con.contype IN ('p', 'u', 'x')
+			)
+		ORDER BY n.nspname, c.relname, i.relname`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	for rows.Next() {
+		var namespace, relation string
+		index := &schemadiff.Index{}
+		if err := rows.Scan(&namespace, &relation, &index.Name, &index.Definition); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read index: %w", err)
+		}
+		if t := table(namespace, relation); t != nil {
+			t.Indexes = append(t.Indexes, index)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	return schema, nil
+}
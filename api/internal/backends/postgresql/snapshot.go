@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+var _ backends.Snapshotter = (*Backend)(nil)
+
+var (
+	// dropTableRe captures the tables of DROP TABLE and TRUNCATE statements
+	dropTableRe = regexp.MustCompile(`(?is)^(?:DROP\s+TABLE(?:\s+IF\s+EXISTS)?|TRUNCATE(?:\s+TABLE)?(?:\s+ONLY)?)\s+(.+?)(?:\s+(?:CASCADE|RESTRICT|RESTART\s+IDENTITY|CONTINUE\s+IDENTITY)\b.*)?$`)
+	// dropColumnRe captures the table of ALTER TABLE ... DROP COLUMN statements
+	dropColumnRe = regexp.MustCompile(`(?is)^ALTER\s+TABLE(?:\s+IF\s+EXISTS)?(?:\s+ONLY)?\s+(\S+)\s.*\bDROP\s+COLUMN\b`)
+	// dropSchemaRe captures the schemas of DROP SCHEMA statements
+	dropSchemaRe = regexp.MustCompile(`(?is)^DROP\s+SCHEMA(?:\s+IF\s+EXISTS)?\s+(.+?)(?:\s+(?:CASCADE|RESTRICT)\b.*)?$`)
+	// deleteAllRe captures the table of DELETE statements without a WHERE clause
+	deleteAllRe = regexp.MustCompile(`(?is)^DELETE\s+FROM(?:\s+ONLY)?\s+(\S+)(?:\s+(?:AS\s+)?\w+)?\s*(?:RETURNING\b.*)?$`)
+	// lineComments matches SQL line comments
+	lineComments = regexp.MustCompile(`--[^\n]*`)
+)
+
+// destructiveTargets returns the tables and schemas the statements of script drop, truncate, empty
+// or alter, tables qualified with schema when they are not. Statements are matched without their
+// comments.
+func destructiveTargets(script, schema string) (tables, schemas []string) {
+	qualify := func(name string) string {
+		name = strings.TrimSpace(name)
+		if schema != "" && !strings.Contains(name, ".") {
+			return schema + "." + name
+		}
+		return name
+	}
+	for _, statement := range SplitStatements(script) {
+		statement = strings.TrimSpace(lineComments.ReplaceAllString(statement, ""))
+		switch {
+		case deleteAllRe.MatchString(statement):
+			tables = append(tables, qualify(deleteAllRe.FindStringSubmatch(statement)[1]))
+		case dropColumnRe.MatchString(statement):
+			tables = append(tables, qualify(dropColumnRe.FindStringSubmatch(statement)[1]))
+		case dropSchemaRe.MatchString(statement):
+			for _, name := range strings.Split(dropSchemaRe.FindStringSubmatch(statement)[1], ",") {
+				schemas = append(schemas, strings.TrimSpace(name))
+			}
+		case dropTableRe.MatchString(statement):
+			for _, name := range strings.Split(dropTableRe.FindStringSubmatch(statement)[1], ",") {
+				tables = append(tables, qualify(name))
+			}
+		}
+	}
+	return tables, schemas
+}
+
+// pgDumpArgs returns the pg_dump arguments backing up the tables and schemas a migration discards
+// into file, in the custom format pg_restore reads. A migration whose statements name no table
+// (marked destructive for an UPDATE) backs up its whole schema, or the database without one.
+func pgDumpArgs(migration *backends.MigrationScript, file string) []string {
+	args := []string{"--format=custom", "--file=" + file}
+	tables, schemas := destructiveTargets(migration.UpSQL, migration.Schema)
+	for _, table := range tables {
+		args = append(args, "--table="+table)
+	}
+	for _, schema := range schemas {
+		args = append(args, "--schema="+schema)
+	}
+	if len(tables) == 0 && len(schemas) == 0 && migration.Schema != "" {
+		args = append(args, "--schema="+migration.Schema)
+	}
+	return args
+}
+
+// Snapshot backs up what migration is about to discard with pg_dump (found on PATH), connecting
+// with the backend's connection settings, into {dir}/{version}_{name}[_{schema}]_{time}.dump
+func (b *Backend) Snapshot(ctx context.Context, migration *backends.MigrationScript, dir string) (string, error) {
+	b.mu.Lock()
+	config := b.config
+	b.mu.Unlock()
+	if config == nil {
+		return "", fmt.Errorf("database connection not initialized")
+	}
+
+	name := migration.Version + "_" + migration.Name
+	if migration.Schema != "" {
+		name += "_" + migration.Schema
+	}
+	file := filepath.Join(dir, fmt.Sprintf("%s_%s.dump", name, time.Now().UTC().Format("20060102T150405Z")))
+
+	cmd := exec.CommandContext(ctx, "pg_dump", pgDumpArgs(migration, file)...)
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+config.Host,
+		"PGPORT="+config.Port,
+		"PGUSER="+config.Username,
+		"PGPASSWORD="+config.Password,
+		"PGDATABASE="+config.Database,
+	)
+	for name, value := range map[string]string{
+		"PGSSLMODE": config.SSLMode, "PGSSLROOTCERT": config.RootCAPath, "PGSSLCERT": config.ClientCertPath, "PGSSLKEY": config.ClientKeyPath,
+	} {
+		if value != "" {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pg_dump failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return file, nil
+}
@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestDestructiveTargets(t *testing.T) {
+	tables, schemas := destructiveTargets(`
+-- clean up the old tables
+DROP TABLE IF EXISTS legacy_users, audit.old_events CASCADE;
+TRUNCATE TABLE ONLY sessions RESTART IDENTITY;
+ALTER TABLE IF EXISTS users DROP COLUMN nickname;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+DELETE FROM audit.login_attempts;
+DELETE FROM tokens WHERE expired;
+DROP SCHEMA IF EXISTS tenant_old, tenant_older CASCADE;
+CREATE TABLE kept (id INT);
+`, "core")
+
+	if want := []string{"core.legacy_users", "audit.old_events", "core.sessions", "core.users", "audit.login_attempts"}; !reflect.DeepEqual(tables, want) {
+		t.Errorf("tables = %v, want %v", tables, want)
+	}
+	if want := []string{"tenant_old", "tenant_older"}; !reflect.DeepEqual(schemas, want) {
+		t.Errorf("schemas = %v, want %v", schemas, want)
+	}
+}
+
+func TestPgDumpArgs(t *testing.T) {
+	args := pgDumpArgs(&backends.MigrationScript{Schema: "core", UpSQL: "DROP TABLE legacy_users;"}, "/backups/x.dump")
+	if got := strings.Join(args, " "); got != "--format=custom --file=/backups/x.dump --table=core.legacy_users" {
+		t.Errorf("args = %q", got)
+	}
+
+	// A migration marked destructive without naming a table backs up its schema
+	args = pgDumpArgs(&backends.MigrationScript{Schema: "core", UpSQL: "-- bfm:destructive\nUPDATE sessions SET token = NULL;"}, "/backups/x.dump")
+	if got := strings.Join(args, " "); got != "--format=custom --file=/backups/x.dump --schema=core" {
+		t.Errorf("args = %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package postgresql
+
+import (
+	"strings"
+)
+
+// SplitStatements splits a script into its statements at the semicolons that end them. Semicolons
+// in string literals ('...', E'...' with backslash escapes), quoted identifiers, dollar-quoted
+// bodies ($$...$$, $tag$...$tag$), line comments and nested block comments do not split, so a
+// CREATE FUNCTION with semicolons in its body stays one statement. Statements are trimmed, and
+// those holding only whitespace and comments are dropped.
+func SplitStatements(sql string) []string {
+	var statements []string
+	start := 0
+	hasCode := false // The current statement has more than whitespace and comments
+
+	flush := func(end int) {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(sql[start:end]))
+		}
+		start, hasCode = end+1, false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == ';':
+			flush(i)
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			i = skipBlockComment(sql, i)
+		case c == '\'':
+			// E'...' strings escape with backslashes; the prefix must not end an identifier
+			escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i == 1 || !isIdentifierChar(sql[i-2]))
+			i = skipQuoted(sql, i, '\'', escapes)
+			hasCode = true
+		case c == '"':
+			i = skipQuoted(sql, i, '"', false)
+			hasCode = true
+		case c == '$':
+			if tag := dollarQuoteTag(sql, i); tag != "" {
+				if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag) - 1
+				} else {
+					i = len(sql)
+				}
+			}
+			hasCode = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+		default:
+			hasCode = true
+		}
+	}
+	if start < len(sql) {
+		flush(len(sql))
+	}
+	return statements
+}
+
+// SplitStatements splits a script the way ExecuteMigration executes it
+func (b *Backend) SplitStatements(sql string) []string {
+	return SplitStatements(sql)
+}
+
+// TrimLeadingComments drops the whitespace, line comments and block comments a statement or clause
+// starts with, for matching it against patterns of its leading keywords
+func TrimLeadingComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		case strings.HasPrefix(sql, "/*"):
+			end := skipBlockComment(sql, 0)
+			if end >= len(sql) {
+				return ""
+			}
+			sql = sql[end+1:]
+		default:
+			return sql
+		}
+	}
+}
+
+// skipBlockComment returns the index of the last character of the (possibly nested) block comment
+// starting at i, or the end of sql when it is not closed
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for ; i < len(sql); i++ {
+		switch {
+		case sql[i] == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			depth++
+			i++
+		case sql[i] == '*' && i+1 < len(sql) && sql[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(sql)
+}
+
+// skipQuoted returns the index of the quote closing the literal or identifier opened at i. A doubled
+// quote is an escaped one, as is a backslash-escaped character when escapes is set.
+func skipQuoted(sql string, i int, quote byte, escapes bool) int {
+	for i++; i < len(sql); i++ {
+		switch {
+		case escapes && sql[i] == '\\':
+			i++
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(sql)
+}
+
+// dollarQuoteTag returns the opening tag ($$ or $tag$) of a dollar-quoted string at i, or "" when
+// the $ starts none, as in positional parameters ($1) or identifiers containing $
+func dollarQuoteTag(sql string, i int) string {
+	if i > 0 && isIdentifierChar(sql[i-1]) {
+		return ""
+	}
+	for j := i + 1; j < len(sql); j++ {
+		c := sql[j]
+		if c == '$' {
+			return sql[i : j+1]
+		}
+		if !isIdentifierChar(c) || (j == i+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// isIdentifierChar reports whether c can appear in an unquoted identifier
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"single without semicolon", "CREATE TABLE users (id INT)", []string{"CREATE TABLE users (id INT)"}},
+		{"several", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n", []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"string literal", "INSERT INTO t VALUES ('a;b', 'it''s;');SELECT 1", []string{"INSERT INTO t VALUES ('a;b', 'it''s;')", "SELECT 1"}},
+		{"escape string", `SELECT E'a\';b';SELECT 2`, []string{`SELECT E'a\';b'`, "SELECT 2"}},
+		{"backslash in standard string", `SELECT 'C:\';SELECT 3`, []string{`SELECT 'C:\'`, "SELECT 3"}},
+		{"quoted identifier", `CREATE TABLE "a;b" (id INT);SELECT 4`, []string{`CREATE TABLE "a;b" (id INT)`, "SELECT 4"}},
+		{"line comment", "SELECT 5; -- done; really\nSELECT 6;", []string{"SELECT 5", "-- done; really\nSELECT 6"}},
+		{"nested block comment", "/* a /* b; */ c; */ SELECT 7; SELECT 8", []string{"/* a /* b; */ c; */ SELECT 7", "SELECT 8"}},
+		{"comments only", "SELECT 9;\n-- trailing comment;\n/* and; this */\n", []string{"SELECT 9"}},
+		{"empty statements", ";;SELECT 10;;", []string{"SELECT 10"}},
+		{
+			"dollar-quoted function",
+			"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n  NEW.updated_at := now();\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\nCREATE TRIGGER t BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION f();",
+			[]string{
+				"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n  NEW.updated_at := now();\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+				"CREATE TRIGGER t BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION f()",
+			},
+		},
+		{"tagged dollar quote", "DO $body$ BEGIN PERFORM '$$;'; END $body$;SELECT 11", []string{"DO $body$ BEGIN PERFORM '$$;'; END $body$", "SELECT 11"}},
+		{"positional parameter", "PREPARE p AS SELECT $1;EXECUTE p(1)", []string{"PREPARE p AS SELECT $1", "EXECUTE p(1)"}},
+		{"unterminated string", "SELECT 'a;b", []string{"SELECT 'a;b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.sql); !slices.Equal(got, tt.want) {
+				t.Errorf("SplitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimLeadingComments(t *testing.T) {
+	tests := map[string]string{
+		"SELECT 1":                             "SELECT 1",
+		"-- users\n  /* a /* b */ */ SELECT 2": "SELECT 2",
+		"SELECT 3 -- trailing":                 "SELECT 3 -- trailing",
+		"-- only a comment":                    "",
+		"/* unterminated":                      "",
+	}
+	for sql, want := range tests {
+		if got := TrimLeadingComments(sql); got != want {
+			t.Errorf("TrimLeadingComments(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+// recordingExecer executes nothing and reports one row affected per statement
+type recordingExecer struct {
+	statements []string
+}
+
+func (r *recordingExecer) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	r.statements = append(r.statements, sql)
+	if strings.Contains(sql, "fail") {
+		return pgconn.CommandTag{}, errors.New("syntax error")
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func TestExecStatements_ReportsProgress(t *testing.T) {
+	var reported []backends.StatementProgress
+	ctx := backends.WithStatementObserver(context.Background(), func(p backends.StatementProgress) {
+		reported = append(reported, p)
+	})
+
+	db := &recordingExecer{}
+	if err := execStatements(ctx, db, "UPDATE users SET a = 1; UPDATE users SET b = 2;"); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.statements) != 2 || len(reported) != 4 {
+		t.Fatalf("expected a start and a completion per statement, got %+v", reported)
+	}
+	if started := reported[2]; started.Statement != 2 || started.Statements != 2 || started.Done {
+		t.Errorf("expected the second statement to be reported started, got %+v", started)
+	}
+	if done := reported[3]; !done.Done || done.RowsAffected != 1 {
+		t.Errorf("expected the second statement to be reported done with its rows, got %+v", done)
+	}
+
+	reported = nil
+	err := execStatements(ctx, &recordingExecer{}, "UPDATE users SET a = 1; fail;")
+	if err == nil || !strings.Contains(err.Error(), "statement 2 of 2") {
+		t.Errorf("expected the failing statement to be named, got %v", err)
+	}
+	if len(reported) != 3 || reported[2].Done {
+		t.Errorf("expected no completion for the failing statement, got %+v", reported)
+	}
+}
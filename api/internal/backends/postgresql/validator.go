@@ -201,6 +201,11 @@ func (v *DependencyValidator) validateMigrationAppliedWithExecutionSet(ctx conte
 			return nil // Dependency is in execution set, will be executed
 		}
 
+		// A min_version or version_range dependency is checked against the applied set at once below
+		if dep.AnyTarget() {
+			continue
+		}
+
 		// Check if already applied
 		// Use the same ID format as executor for state tracker
 		applied, err := v.stateTracker.IsMigrationApplied(ctx, migrationID)
@@ -212,6 +217,20 @@ func (v *DependencyValidator) validateMigrationAppliedWithExecutionSet(ctx conte
 		}
 	}
 
+	if dep.AnyTarget() {
+		applied, err := registry.AnyTargetApplied(ctx, v.stateTracker, dep)
+		if err != nil {
+			return fmt.Errorf("failed to check migration status: %w", err)
+		}
+		if applied {
+			return nil
+		}
+		if dep.TargetType == "min_version" {
+			return fmt.Errorf("no migration at or above version %s is applied: %s", dep.Target, v.dependencyString(dep))
+		}
+		return fmt.Errorf("no migration in version range %s is applied: %s", dep.Target, v.dependencyString(dep))
+	}
+
 	return fmt.Errorf("dependency migration is not applied: %s", v.dependencyString(dep))
 }
 
@@ -234,16 +253,8 @@ func (v *DependencyValidator) findMigrationByTarget(dep backends.Dependency) ([]
 			continue
 		}
 
-		// Match target based on type
-		if dep.TargetType == "version" {
-			if migration.Version == dep.Target {
-				candidates = append(candidates, migration)
-			}
-		} else {
-			// Default to "name"
-			if migration.Name == dep.Target {
-				candidates = append(candidates, migration)
-			}
+		if dep.MatchesTarget(migration.Version, migration.Name) {
+			candidates = append(candidates, migration)
 		}
 	}
 
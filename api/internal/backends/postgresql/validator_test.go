@@ -6,98 +6,15 @@ import (
 
 	"github.com/toolsascode/bfm/api/internal/backends"
 	"github.com/toolsascode/bfm/api/internal/registry"
-	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
 )
 
-// mockStateTrackerForValidator for testing
-type mockStateTrackerForValidator struct {
-	appliedMigrations map[string]bool
-}
-
-func newMockStateTrackerForValidator() *mockStateTrackerForValidator {
-	return &mockStateTrackerForValidator{
-		appliedMigrations: make(map[string]bool),
-	}
-}
-
-func (m *mockStateTrackerForValidator) RecordMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) RecordDependencyMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) GetMigrationHistory(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	return nil, nil
-}
-
-func (m *mockStateTrackerForValidator) GetMigrationList(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	return nil, nil
-}
-
-func (m *mockStateTrackerForValidator) IsMigrationApplied(ctx interface{}, migrationID string) (bool, error) {
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTrackerForValidator) IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error) {
-	// For mock, treat pending/applied the same as applied
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTrackerForValidator) GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error) {
-	return "", nil
-}
-
-func (m *mockStateTrackerForValidator) RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) DeleteMigration(ctx interface{}, migrationID string) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) Initialize(ctx interface{}) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) ReindexMigrations(ctx interface{}, registry interface{}) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) GetMigrationDetail(ctx interface{}, migrationID string) (*state.MigrationDetail, error) {
-	return nil, nil
-}
-
-func (m *mockStateTrackerForValidator) GetMigrationExecutions(ctx interface{}, migrationID string) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-func (m *mockStateTrackerForValidator) GetRecentExecutions(ctx interface{}, limit int) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-
-func (m *mockStateTrackerForValidator) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
-	return nil
-}
-
-func (m *mockStateTrackerForValidator) GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*state.SkippedMigration, error) {
-	return nil, nil
-}
-
-func (m *mockStateTrackerForValidator) WithMigrationExecutionLock(_ interface{}, _, _, _ string, fn func() error) error {
-	return fn()
-}
-
 func TestDependencyValidator_ValidateDependencies(t *testing.T) {
 	backend := &Backend{} // We'll need to use a real backend or mock differently
 	// For now, we'll test the logic without actual database calls
 
 	reg := registry.NewInMemoryRegistry()
-	tracker := newMockStateTrackerForValidator()
+	tracker := testsupport.NewStateTracker()
 
 	// Register a dependency migration
 	depMigration := &backends.MigrationScript{
@@ -111,7 +28,7 @@ func TestDependencyValidator_ValidateDependencies(t *testing.T) {
 
 	// Mark it as applied
 	// Migration ID format: {version}_{name}_{backend}_{connection}
-	tracker.appliedMigrations["20240101120000_base_migration_postgresql_core"] = true
+	tracker.MarkApplied("20240101120000_base_migration_postgresql_core")
 
 	validator := NewDependencyValidator(backend, tracker, reg)
 
@@ -131,7 +48,7 @@ func TestDependencyValidator_ValidateDependencies(t *testing.T) {
 	})
 
 	t.Run("validate simple dependency - not applied", func(t *testing.T) {
-		tracker.appliedMigrations["20240101120000_base_migration_postgresql_core"] = false
+		_ = tracker.DeleteMigration(context.Background(), "20240101120000_base_migration_postgresql_core")
 
 		migration := &backends.MigrationScript{
 			Version:      "20240101120002",
@@ -147,7 +64,7 @@ func TestDependencyValidator_ValidateDependencies(t *testing.T) {
 		}
 
 		// Reset
-		tracker.appliedMigrations["20240101120000_base_migration_postgresql_core"] = true
+		tracker.MarkApplied("20240101120000_base_migration_postgresql_core")
 	})
 
 	t.Run("validate structured dependency - applied", func(t *testing.T) {
@@ -189,7 +106,7 @@ func TestDependencyValidator_ValidateDependencies(t *testing.T) {
 
 func TestDependencyValidator_FindMigrationByTarget(t *testing.T) {
 	reg := registry.NewInMemoryRegistry()
-	tracker := newMockStateTrackerForValidator()
+	tracker := testsupport.NewStateTracker()
 	backend := &Backend{} // Mock backend
 
 	validator := NewDependencyValidator(backend, tracker, reg)
@@ -274,7 +191,7 @@ func TestDependencyValidator_FindMigrationByTarget(t *testing.T) {
 
 func TestDependencyValidator_RequiresSchema_skippedWhenDependencyInExecutionSet(t *testing.T) {
 	reg := registry.NewInMemoryRegistry()
-	tracker := newMockStateTrackerForValidator()
+	tracker := testsupport.NewStateTracker()
 	backend := &Backend{} // no DB pool; SchemaExists would error if invoked
 
 	coreSchema := &backends.MigrationScript{
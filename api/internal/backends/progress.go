@@ -0,0 +1,34 @@
+package backends
+
+import (
+	"context"
+	"time"
+)
+
+// StatementProgress reports one statement of a script executed statement by statement (see
+// StatementSplitter): once when it starts, then once when it completed
+type StatementProgress struct {
+	Statement    int  // 1-based position of the statement in the script
+	Statements   int  // Statements in the script
+	Done         bool // The statement completed; false when it starts
+	RowsAffected int64
+	Elapsed      time.Duration // Time the statement took, once Done
+}
+
+// StatementObserver receives the progress of the statements executed under a context
+type StatementObserver func(StatementProgress)
+
+type statementObserverKey struct{}
+
+// WithStatementObserver returns a context under which backends report the progress of each
+// statement they execute to observe. Backends that run scripts whole report nothing.
+func WithStatementObserver(ctx context.Context, observe StatementObserver) context.Context {
+	return context.WithValue(ctx, statementObserverKey{}, observe)
+}
+
+// ReportStatement reports the progress of a statement to the observer of ctx, if any
+func ReportStatement(ctx context.Context, progress StatementProgress) {
+	if observe, ok := ctx.Value(statementObserverKey{}).(StatementObserver); ok && observe != nil {
+		observe(progress)
+	}
+}
@@ -0,0 +1,383 @@
+package spanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// defaultEndpoint is the public Spanner REST endpoint, used when no host is configured
+	defaultEndpoint = "https://spanner.googleapis.com"
+
+	defaultPollInterval = 2 * time.Second
+	defaultDDLTimeout   = 30 * time.Minute
+)
+
+// oauthScopes are requested when falling back to Application Default Credentials
+var oauthScopes = []string{
+	"https://www.googleapis.com/auth/spanner.admin",
+	"https://www.googleapis.com/auth/spanner.data",
+}
+
+// Backend implements the Backend interface for Google Cloud Spanner (GoogleSQL dialect).
+//
+// Spanner runs schema changes as long-running operations and does not allow DDL inside
+// transactions. Migration scripts are split into statements; consecutive DDL statements are
+// submitted as one UpdateDatabaseDdl batch and the operation is polled until it completes,
+// so a migration is only reported as applied once its schema changes are live. Consecutive
+// DML statements run as one batch in a read-write transaction.
+type Backend struct {
+	baseURL      string
+	client       *http.Client
+	config       *backends.ConnectionConfig
+	tokenSource  oauth2.TokenSource
+	database     string // projects/{project}/instances/{instance}/databases/{database}
+	pollInterval time.Duration
+	ddlTimeout   time.Duration
+}
+
+// NewBackend creates a new Spanner backend
+func NewBackend() *Backend {
+	return &Backend{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string {
+	return "spanner"
+}
+
+// Connect establishes a connection to Spanner.
+// Without a host the public endpoint is used with {CONN}_TOKEN (or {CONN}_DB_PASSWORD) as an
+// OAuth access token, falling back to Application Default Credentials. With a host (e.g. the
+// emulator's REST port 9020) requests are sent there and are only authenticated if a token is set.
+func (b *Backend) Connect(config *backends.ConnectionConfig) error {
+	b.config = config
+
+	project := config.ExtraValue("project")
+	instance := config.ExtraValue("instance")
+	database := config.Database
+	if database == "" {
+		database = config.ExtraValue("database")
+	}
+	if project == "" || instance == "" || database == "" {
+		return fmt.Errorf("spanner requires project, instance and database ({CONN}_PROJECT, {CONN}_INSTANCE, {CONN}_DB_NAME)")
+	}
+	b.database = fmt.Sprintf("projects/%s/instances/%s/databases/%s", project, instance, database)
+
+	token := config.ExtraValue("token")
+	if token == "" {
+		token = config.Password
+	}
+
+	if config.Host == "" {
+		b.baseURL = defaultEndpoint
+		if token == "" {
+			ts, err := google.DefaultTokenSource(context.Background(), oauthScopes...)
+			if err != nil {
+				return fmt.Errorf("failed to load Google credentials: %w", err)
+			}
+			b.tokenSource = ts
+		}
+	} else {
+		protocol := "http"
+		if config.ExtraValue("ssl") == "true" || config.ExtraValue("tls") == "true" {
+			protocol = "https"
+		}
+		port := config.Port
+		if port == "" {
+			port = "9020" // Default Spanner emulator REST port
+		}
+		b.baseURL = fmt.Sprintf("%s://%s:%s", protocol, config.Host, port)
+	}
+	if token != "" {
+		b.tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	}
+
+	b.pollInterval = parseDuration(config.ExtraValue("poll_interval"), defaultPollInterval)
+	b.ddlTimeout = parseDuration(config.ExtraValue("ddl_timeout"), defaultDDLTimeout)
+
+	if err := b.HealthCheck(context.Background()); err != nil {
+		return fmt.Errorf("failed to connect to Spanner: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Spanner connection (no-op for HTTP client)
+func (b *Backend) Close() error {
+	return nil
+}
+
+// CreateSchema creates a named schema if it doesn't exist.
+// The default (unnamed) schema always exists.
+func (b *Backend) CreateSchema(ctx context.Context, schemaName string) error {
+	exists, err := b.SchemaExists(ctx, schemaName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return b.updateDDL(ctx, []string{fmt.Sprintf("CREATE SCHEMA %s", quoteIdentifier(schemaName))})
+}
+
+// SchemaExists checks if a named schema exists
+func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, error) {
+	if schemaName == "" {
+		return true, nil
+	}
+
+	session, err := b.createSession(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer b.deleteSession(session)
+
+	request := map[string]interface{}{
+		"sql":        "SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = @name",
+		"params":     map[string]string{"name": schemaName},
+		"paramTypes": map[string]interface{}{"name": map[string]string{"code": "STRING"}},
+		"transaction": map[string]interface{}{
+			"singleUse": map[string]interface{}{"readOnly": map[string]bool{"strong": true}},
+		},
+	}
+	var result struct {
+		Rows [][]interface{} `json:"rows"`
+	}
+	if err := b.call(ctx, http.MethodPost, "/v1/"+session+":executeSql", request, &result); err != nil {
+		return false, fmt.Errorf("failed to check schema existence: %w", err)
+	}
+	return len(result.Rows) > 0, nil
+}
+
+// ExecuteMigration executes a migration script, grouping consecutive DDL and DML statements
+func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	statements := SplitStatements(migration.UpSQL)
+
+	for start := 0; start < len(statements); {
+		ddl := IsDDL(statements[start])
+		end := start + 1
+		for end < len(statements) && IsDDL(statements[end]) == ddl {
+			end++
+		}
+
+		var err error
+		if ddl {
+			err = b.updateDDL(ctx, statements[start:end])
+		} else {
+			err = b.executeDML(ctx, statements[start:end])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to execute migration: %w", err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// HealthCheck verifies the database is reachable and ready
+func (b *Backend) HealthCheck(ctx context.Context) error {
+	var database struct {
+		State string `json:"state"`
+	}
+	if err := b.call(ctx, http.MethodGet, "/v1/"+b.database, nil, &database); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if database.State != "" && database.State != "READY" && database.State != "READY_OPTIMIZING" {
+		return fmt.Errorf("health check failed: database state is %s", database.State)
+	}
+	return nil
+}
+
+// operation is a Spanner long-running operation
+type operation struct {
+	Name     string     `json:"name"`
+	Done     bool       `json:"done"`
+	Error    *rpcStatus `json:"error"`
+	Metadata struct {
+		CommitTimestamps []string `json:"commitTimestamps"`
+	} `json:"metadata"`
+}
+
+// rpcStatus is a google.rpc.Status
+type rpcStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// updateDDL submits a batch of schema statements and waits for the operation to complete.
+// Spanner applies the batch statement by statement, so on failure earlier statements stay applied.
+func (b *Backend) updateDDL(ctx context.Context, statements []string) error {
+	var op operation
+	if err := b.call(ctx, http.MethodPatch, "/v1/"+b.database+"/ddl", map[string]interface{}{"statements": statements}, &op); err != nil {
+		return fmt.Errorf("failed to submit schema change: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.ddlTimeout)
+	defer cancel()
+
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for schema change %s: %w", op.Name, ctx.Err())
+		case <-time.After(b.pollInterval):
+		}
+		if err := b.call(ctx, http.MethodGet, "/v1/"+op.Name, nil, &op); err != nil {
+			return fmt.Errorf("failed to poll schema change %s: %w", op.Name, err)
+		}
+	}
+
+	if op.Error != nil && op.Error.Code != 0 {
+		return fmt.Errorf("schema change failed after %d of %d statement(s) were applied: %s",
+			len(op.Metadata.CommitTimestamps), len(statements), op.Error.Message)
+	}
+	return nil
+}
+
+// executeDML runs DML statements as one batch in a read-write transaction
+func (b *Backend) executeDML(ctx context.Context, statements []string) error {
+	session, err := b.createSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.deleteSession(session)
+
+	var tx struct {
+		ID string `json:"id"`
+	}
+	if err := b.call(ctx, http.MethodPost, "/v1/"+session+":beginTransaction", map[string]interface{}{
+		"options": map[string]interface{}{"readWrite": map[string]interface{}{}},
+	}, &tx); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	batch := make([]map[string]string, 0, len(statements))
+	for _, stmt := range statements {
+		batch = append(batch, map[string]string{"sql": stmt})
+	}
+	var result struct {
+		ResultSets []json.RawMessage `json:"resultSets"`
+		Status     *rpcStatus        `json:"status"`
+	}
+	err = b.call(ctx, http.MethodPost, "/v1/"+session+":executeBatchDml", map[string]interface{}{
+		"transaction": map[string]string{"id": tx.ID},
+		"statements":  batch,
+		"seqno":       "1",
+	}, &result)
+	if err == nil && result.Status != nil && result.Status.Code != 0 {
+		// Statements before the failing one succeeded, so its index is the number of result sets
+		err = fmt.Errorf("statement %d failed: %s", len(result.ResultSets)+1, result.Status.Message)
+	}
+	if err != nil {
+		_ = b.call(ctx, http.MethodPost, "/v1/"+session+":rollback", map[string]string{"transactionId": tx.ID}, nil)
+		return err
+	}
+
+	if err := b.call(ctx, http.MethodPost, "/v1/"+session+":commit", map[string]string{"transactionId": tx.ID}, nil); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// createSession creates a session for data operations and returns its resource name
+func (b *Backend) createSession(ctx context.Context) (string, error) {
+	var session struct {
+		Name string `json:"name"`
+	}
+	if err := b.call(ctx, http.MethodPost, "/v1/"+b.database+"/sessions", map[string]interface{}{}, &session); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return session.Name, nil
+}
+
+// deleteSession releases a session; failures are ignored as sessions expire on their own
+func (b *Backend) deleteSession(session string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = b.call(ctx, http.MethodDelete, "/v1/"+session, nil, nil)
+}
+
+// call sends a JSON request to the Spanner REST API and decodes the response into out
+func (b *Backend) call(ctx context.Context, method, path string, in, out interface{}) error {
+	if b.baseURL == "" {
+		return fmt.Errorf("spanner client not initialized")
+	}
+
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.tokenSource != nil {
+		token, err := b.tokenSource.Token()
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error rpcStatus `json:"error"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// quoteIdentifier quotes a GoogleSQL identifier with backticks
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// parseDuration parses a duration, returning fallback for empty or invalid values
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
@@ -0,0 +1,147 @@
+package spanner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+const testDatabase = "projects/p/instances/i/databases/d"
+
+// fakeSpanner serves the subset of the Spanner REST API used by the backend
+type fakeSpanner struct {
+	ddlBatches [][]string
+	dmlBatches [][]string
+	polls      int
+	pollsLeft  int
+	ddlError   string
+	committed  int
+}
+
+func (f *fakeSpanner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodGet && path == testDatabase:
+		_, _ = w.Write([]byte(`{"state":"READY"}`))
+	case r.Method == http.MethodPatch && path == testDatabase+"/ddl":
+		var req struct {
+			Statements []string `json:"statements"`
+		}
+		_ = json.Unmarshal(body, &req)
+		f.ddlBatches = append(f.ddlBatches, req.Statements)
+		_, _ = w.Write([]byte(`{"name":"` + testDatabase + `/operations/op1","done":false}`))
+	case r.Method == http.MethodGet && path == testDatabase+"/operations/op1":
+		f.polls++
+		if f.pollsLeft > 0 {
+			f.pollsLeft--
+			_, _ = w.Write([]byte(`{"name":"` + testDatabase + `/operations/op1","done":false}`))
+			return
+		}
+		if f.ddlError != "" {
+			_, _ = w.Write([]byte(`{"name":"op1","done":true,"error":{"code":3,"message":"` + f.ddlError + `"},"metadata":{"commitTimestamps":["t1"]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"op1","done":true}`))
+	case r.Method == http.MethodPost && path == testDatabase+"/sessions":
+		_, _ = w.Write([]byte(`{"name":"` + testDatabase + `/sessions/s1"}`))
+	case strings.HasSuffix(path, ":beginTransaction"):
+		_, _ = w.Write([]byte(`{"id":"tx1"}`))
+	case strings.HasSuffix(path, ":executeBatchDml"):
+		var req struct {
+			Statements []struct {
+				SQL string `json:"sql"`
+			} `json:"statements"`
+		}
+		_ = json.Unmarshal(body, &req)
+		var batch []string
+		for _, s := range req.Statements {
+			batch = append(batch, s.SQL)
+		}
+		f.dmlBatches = append(f.dmlBatches, batch)
+		_, _ = w.Write([]byte(`{"resultSets":[{},{}],"status":{}}`))
+	case strings.HasSuffix(path, ":commit"):
+		f.committed++
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodDelete:
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func connectFake(t *testing.T, fake *fakeSpanner) *Backend {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	b := NewBackend()
+	if err := b.Connect(&backends.ConnectionConfig{
+		Backend:  "spanner",
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		Database: "d",
+		Extra:    map[string]string{"PROJECT": "p", "INSTANCE": "i", "POLL_INTERVAL": "1ms"},
+	}); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	return b
+}
+
+func TestBackend_ExecuteMigration_PollsDDLAndBatchesDML(t *testing.T) {
+	fake := &fakeSpanner{pollsLeft: 2}
+	b := connectFake(t, fake)
+
+	script := `CREATE TABLE Singers (SingerId INT64 NOT NULL) PRIMARY KEY (SingerId);
+CREATE INDEX SingersById ON Singers(SingerId);
+INSERT INTO Singers (SingerId) VALUES (1);
+INSERT INTO Singers (SingerId) VALUES (2);`
+	if err := b.ExecuteMigration(context.Background(), &backends.MigrationScript{UpSQL: script}); err != nil {
+		t.Fatalf("ExecuteMigration() error = %v", err)
+	}
+
+	if len(fake.ddlBatches) != 1 || len(fake.ddlBatches[0]) != 2 {
+		t.Errorf("expected one DDL batch with 2 statements, got %v", fake.ddlBatches)
+	}
+	if fake.polls != 3 {
+		t.Errorf("expected operation to be polled until done (3 polls), got %d", fake.polls)
+	}
+	if len(fake.dmlBatches) != 1 || len(fake.dmlBatches[0]) != 2 {
+		t.Errorf("expected one DML batch with 2 statements, got %v", fake.dmlBatches)
+	}
+	if fake.committed != 1 {
+		t.Errorf("expected DML transaction to be committed once, got %d", fake.committed)
+	}
+}
+
+func TestBackend_ExecuteMigration_DDLOperationError(t *testing.T) {
+	fake := &fakeSpanner{ddlError: "Duplicate name in schema: Singers"}
+	b := connectFake(t, fake)
+
+	err := b.ExecuteMigration(context.Background(), &backends.MigrationScript{
+		UpSQL: "CREATE TABLE A (Id INT64) PRIMARY KEY (Id); CREATE TABLE Singers (Id INT64) PRIMARY KEY (Id);",
+	})
+	if err == nil {
+		t.Fatal("expected error from failed schema change")
+	}
+	if !strings.Contains(err.Error(), "Duplicate name in schema") || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBackend_Connect_RequiresDatabase(t *testing.T) {
+	b := NewBackend()
+	err := b.Connect(&backends.ConnectionConfig{Backend: "spanner", Host: "localhost", Extra: map[string]string{"PROJECT": "p"}})
+	if err == nil {
+		t.Error("expected error when instance and database are missing")
+	}
+}
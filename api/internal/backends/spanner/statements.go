@@ -0,0 +1,102 @@
+package spanner
+
+import "strings"
+
+// ddlKeywords are the leading keywords of Spanner schema statements, which must go
+// through UpdateDatabaseDdl instead of a read-write transaction
+var ddlKeywords = map[string]bool{
+	"CREATE":  true,
+	"ALTER":   true,
+	"DROP":    true,
+	"GRANT":   true,
+	"REVOKE":  true,
+	"RENAME":  true,
+	"ANALYZE": true,
+}
+
+// IsDDL reports whether a statement is a schema (DDL) statement
+func IsDDL(statement string) bool {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return false
+	}
+	return ddlKeywords[strings.ToUpper(strings.TrimRight(fields[0], "("))]
+}
+
+// SplitStatements splits a GoogleSQL script into individual statements.
+// The Spanner APIs take one statement per entry and reject trailing semicolons, so scripts
+// are split on semicolons outside of string literals, backtick identifiers and comments
+// (--, # and /* */). Triple-quoted strings are supported. Comments are dropped.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case (c == '\'' || c == '"') && i+2 < len(runes) && next == c && runes[i+2] == c:
+			// Triple-quoted string literal
+			end := i + 3
+			for end < len(runes) && !(runes[end] == c && end+2 < len(runes) && runes[end+1] == c && runes[end+2] == c) {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+3, len(runes))
+			current.WriteString(string(runes[i:end]))
+			i = end - 1
+		case c == '\'' || c == '"' || c == '`':
+			// String literal or quoted identifier; backslash escapes
+			current.WriteRune(c)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					current.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == c {
+					break
+				}
+			}
+		case (c == '-' && next == '-') || c == '#':
+			// Line comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			current.WriteRune('\n')
+		case c == '/' && next == '*':
+			// Block comment
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i++
+			current.WriteRune(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+
+	return statements
+}
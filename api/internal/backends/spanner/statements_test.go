@@ -0,0 +1,46 @@
+package spanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- create tables
+CREATE TABLE Singers (
+  SingerId INT64 NOT NULL,
+  Name STRING(MAX) DEFAULT ("a;b"),
+) PRIMARY KEY (SingerId);
+# hash comment; ignored
+CREATE INDEX SingersByName ON Singers(Name); /* block; comment */
+INSERT INTO Singers (SingerId, Name) VALUES (1, 'O\'Brien; Jr');
+INSERT INTO ` + "`Singers`" + ` (SingerId, Name) VALUES (2, """multi;
+line""");`
+
+	got := SplitStatements(script)
+	want := []string{
+		"CREATE TABLE Singers (\n  SingerId INT64 NOT NULL,\n  Name STRING(MAX) DEFAULT (\"a;b\"),\n) PRIMARY KEY (SingerId)",
+		"CREATE INDEX SingersByName ON Singers(Name)",
+		`INSERT INTO Singers (SingerId, Name) VALUES (1, 'O\'Brien; Jr')`,
+		"INSERT INTO `Singers` (SingerId, Name) VALUES (2, \"\"\"multi;\nline\"\"\")",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestIsDDL(t *testing.T) {
+	tests := map[string]bool{
+		"CREATE TABLE t (id INT64) PRIMARY KEY (id)": true,
+		"alter table t add column c STRING(10)":      true,
+		"DROP INDEX idx":                             true,
+		"INSERT INTO t (id) VALUES (1)":              false,
+		"UPDATE t SET c = 'x' WHERE true":            false,
+		"":                                           false,
+	}
+	for stmt, want := range tests {
+		if got := IsDDL(stmt); got != want {
+			t.Errorf("IsDDL(%q) = %v, want %v", stmt, got, want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package backends
+
+import "regexp"
+
+// squashRe matches the "-- bfm:squash <version>" directive line of a squashed baseline
+var squashRe = regexp.MustCompile(`(?im)^\s*--\s*bfm:squash\s+(\d{14})\s*$`)
+
+// SquashDirective returns the directive line that marks a script as the squashed baseline of the
+// migrations older than before
+func SquashDirective(before string) string {
+	return "-- bfm:squash " + before
+}
+
+// SquashedBefore returns the version given by the "-- bfm:squash <version>" line of the up script:
+// the migration consolidates every migration of its connection older than that version (see bfm
+// squash). It returns "" for a regular migration.
+func (m *MigrationScript) SquashedBefore() string {
+	if match := squashRe.FindStringSubmatch(m.UpSQL); match != nil {
+		return match[1]
+	}
+	return ""
+}
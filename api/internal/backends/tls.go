@@ -0,0 +1,100 @@
+package backends
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SSL modes of ConnectionConfig.SSLMode, with the meaning of PostgreSQL's sslmode
+const (
+	SSLModeDisable    = "disable"     // Plaintext
+	SSLModeRequire    = "require"     // Encrypted, the server certificate is not verified
+	SSLModeVerifyCA   = "verify-ca"   // Encrypted, the server certificate is signed by a trusted CA
+	SSLModeVerifyFull = "verify-full" // verify-ca, and the certificate is for the server's host name
+)
+
+// TLSConfig returns the TLS configuration of the connection, nil when it connects in plaintext
+// (SSLMode empty or disable), for backends dialing with Go's crypto/tls
+func (c *ConnectionConfig) TLSConfig() (*tls.Config, error) {
+	switch c.SSLMode {
+	case "", SSLModeDisable:
+		return nil, nil
+	case SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+	default:
+		return nil, fmt.Errorf("unknown SSL mode %q (disable, require, verify-ca or verify-full)", c.SSLMode)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.RootCAPath != "" {
+		pem, err := os.ReadFile(c.RootCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CA: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in root CA %s", c.RootCAPath)
+		}
+	}
+	if c.ClientCertPath != "" || c.ClientKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch c.SSLMode {
+	case SSLModeRequire:
+		tlsConfig.InsecureSkipVerify = true
+	case SSLModeVerifyCA:
+		// The chain is verified without the host name check of the default verification
+		roots := tlsConfig.RootCAs
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+			return err
+		}
+	}
+	return tlsConfig, nil
+}
+
+// PostgresSSLParams returns the sslmode (disable when empty) and certificate parameters of a
+// PostgreSQL keyword/value connection string
+func PostgresSSLParams(sslMode, rootCAPath, clientCertPath, clientKeyPath string) string {
+	if sslMode == "" {
+		sslMode = SSLModeDisable
+	}
+	params := "sslmode=" + quoteConnValue(sslMode)
+	for _, param := range []struct{ key, value string }{
+		{"sslrootcert", rootCAPath}, {"sslcert", clientCertPath}, {"sslkey", clientKeyPath},
+	} {
+		if param.value != "" {
+			params += " " + param.key + "=" + quoteConnValue(param.value)
+		}
+	}
+	return params
+}
+
+// PostgresSSLParams returns the SSL parameters of the connection for a PostgreSQL connection string
+func (c *ConnectionConfig) PostgresSSLParams() string {
+	return PostgresSSLParams(c.SSLMode, c.RootCAPath, c.ClientCertPath, c.ClientKeyPath)
+}
+
+// quoteConnValue quotes a connection string value containing spaces or quotes
+func quoteConnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
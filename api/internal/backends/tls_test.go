@@ -0,0 +1,74 @@
+package backends
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConnectionConfig_TLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	rootCA := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(rootCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The test certificate is not issued for "localhost", so only host name checks fail on it
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	dial := func(cfg *ConnectionConfig, host string) error {
+		tlsConfig, err := cfg.TLSConfig()
+		if err != nil {
+			return err
+		}
+		conn, err := tls.Dial("tcp", net.JoinHostPort(host, port), tlsConfig)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	for _, tt := range []struct {
+		mode, host string
+		wantErr    bool
+	}{
+		{SSLModeRequire, "localhost", false},
+		{SSLModeVerifyCA, "localhost", false},
+		{SSLModeVerifyFull, "127.0.0.1", false},
+		{SSLModeVerifyFull, "localhost", true},
+	} {
+		err := dial(&ConnectionConfig{SSLMode: tt.mode, RootCAPath: rootCA}, tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s to %s: error = %v, wantErr %v", tt.mode, tt.host, err, tt.wantErr)
+		}
+	}
+	// verify-ca still checks the chain
+	if err := dial(&ConnectionConfig{SSLMode: SSLModeVerifyCA}, "localhost"); err == nil {
+		t.Errorf("expected verify-ca to refuse a certificate of an untrusted CA")
+	}
+
+	if tlsConfig, err := (&ConnectionConfig{}).TLSConfig(); tlsConfig != nil || err != nil {
+		t.Errorf("expected no TLS without an SSL mode, got %v, %v", tlsConfig, err)
+	}
+	if _, err := (&ConnectionConfig{SSLMode: "prefer"}).TLSConfig(); err == nil {
+		t.Errorf("expected an unknown SSL mode to be rejected")
+	}
+	if _, err := (&ConnectionConfig{SSLMode: SSLModeRequire, ClientCertPath: "missing.pem", ClientKeyPath: "missing.key"}).TLSConfig(); err == nil || !strings.Contains(err.Error(), "client certificate") {
+		t.Errorf("expected a missing client certificate to be an error, got %v", err)
+	}
+}
+
+func TestPostgresSSLParams(t *testing.T) {
+	if got := PostgresSSLParams("", "", "", ""); got != "sslmode=disable" {
+		t.Errorf("PostgresSSLParams() = %q", got)
+	}
+	got := (&ConnectionConfig{SSLMode: SSLModeVerifyFull, RootCAPath: "/etc/bfm/my ca.pem", ClientCertPath: "/etc/bfm/client.pem", ClientKeyPath: "/etc/bfm/client.key"}).PostgresSSLParams()
+	if want := `sslmode=verify-full sslrootcert='/etc/bfm/my ca.pem' sslcert=/etc/bfm/client.pem sslkey=/etc/bfm/client.key`; got != want {
+		t.Errorf("PostgresSSLParams() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// Backend implements the Backend interface for the Vault KV secrets engine (v1 and v2).
+// Migrations are JSON key/value operations (see backends.KVOperation). Vault has no
+// multi-key transactions, so operations are applied in order and a failure stops the script.
+type Backend struct {
+	baseURL   string
+	client    *http.Client
+	config    *backends.ConnectionConfig
+	token     string
+	namespace string
+	mount     string
+	kvVersion int
+	prefix    string
+}
+
+// NewBackend creates a new Vault backend
+func NewBackend() *Backend {
+	return &Backend{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string {
+	return "vault"
+}
+
+// Connect establishes a connection to Vault
+func (b *Backend) Connect(config *backends.ConnectionConfig) error {
+	b.config = config
+
+	protocol := "http"
+	if config.ExtraValue("ssl") == "true" || config.ExtraValue("tls") == "true" {
+		protocol = "https"
+	}
+	host := config.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := config.Port
+	if port == "" {
+		port = "8200" // Default Vault port
+	}
+	b.baseURL = fmt.Sprintf("%s://%s:%s", protocol, host, port)
+
+	// Token: {CONN}_TOKEN or {CONN}_DB_PASSWORD
+	b.token = config.ExtraValue("token")
+	if b.token == "" {
+		b.token = config.Password
+	}
+	b.namespace = config.ExtraValue("namespace")
+
+	b.mount = strings.Trim(config.ExtraValue("mount"), "/")
+	if b.mount == "" {
+		b.mount = "secret"
+	}
+	b.kvVersion = 2
+	if config.ExtraValue("kv_version") == "1" {
+		b.kvVersion = 1
+	}
+	b.prefix = strings.Trim(config.ExtraValue("prefix"), "/")
+
+	if err := b.HealthCheck(context.Background()); err != nil {
+		return fmt.Errorf("failed to connect to Vault: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Vault connection (no-op for HTTP client)
+func (b *Backend) Close() error {
+	return nil
+}
+
+// CreateSchema creates a path prefix by writing a marker secret
+func (b *Backend) CreateSchema(ctx context.Context, schemaName string) error {
+	data := map[string]interface{}{"created_at": time.Now().Format(time.RFC3339)}
+	return b.write(ctx, b.path(schemaName, ".schema_marker"), data)
+}
+
+// SchemaExists checks if any secret exists under the schema path
+func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, error) {
+	apiPath := b.mount + "/" + b.path(schemaName, "")
+	if b.kvVersion == 2 {
+		apiPath = b.mount + "/metadata/" + b.path(schemaName, "")
+	}
+
+	resp, err := b.do(ctx, "LIST", "/v1/"+apiPath, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to check schema existence: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to check schema existence: status %d, body: %s", resp.StatusCode, string(body))
+	}
+}
+
+// ExecuteMigration applies the key/value operations of a migration script.
+// "put" values that are JSON objects are stored as the secret's data; any other value
+// is stored as {"value": ...}. "delete" removes the latest version, "destroy" removes
+// all versions and metadata (KV v2).
+func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	ops, err := backends.ParseKVOperations(migration.UpSQL)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if op.HasPrecondition() {
+			return fmt.Errorf("key %s: preconditions (prev_value, prev_exists) are not supported by Vault", op.Key)
+		}
+	}
+
+	for _, op := range ops {
+		secretPath := b.path(migration.Schema, op.Key)
+		switch op.Operation {
+		case "put":
+			var data map[string]interface{}
+			if err := json.Unmarshal(op.Value, &data); err != nil || data == nil {
+				data = map[string]interface{}{"value": op.StringValue()}
+			}
+			if err := b.write(ctx, secretPath, data); err != nil {
+				return fmt.Errorf("failed to put key %s: %w", op.Key, err)
+			}
+		case "delete":
+			if err := b.remove(ctx, secretPath, false); err != nil {
+				return fmt.Errorf("failed to delete key %s: %w", op.Key, err)
+			}
+		case "destroy":
+			if err := b.remove(ctx, secretPath, true); err != nil {
+				return fmt.Errorf("failed to destroy key %s: %w", op.Key, err)
+			}
+		default:
+			return fmt.Errorf("unsupported operation type: %s", op.Operation)
+		}
+	}
+	return nil
+}
+
+// HealthCheck verifies the backend is accessible and unsealed
+func (b *Backend) HealthCheck(ctx context.Context) error {
+	resp, err := b.do(ctx, http.MethodGet, "/v1/sys/health?standbyok=true&perfstandbyok=true", nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("health check failed: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// write stores a secret at secretPath
+func (b *Backend) write(ctx context.Context, secretPath string, data map[string]interface{}) error {
+	apiPath := "/v1/" + b.mount + "/" + secretPath
+	var payload interface{} = data
+	if b.kvVersion == 2 {
+		apiPath = "/v1/" + b.mount + "/data/" + secretPath
+		payload = map[string]interface{}{"data": data}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode secret: %w", err)
+	}
+	resp, err := b.do(ctx, http.MethodPost, apiPath, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// remove deletes the secret at secretPath; destroy also removes version history (KV v2)
+func (b *Backend) remove(ctx context.Context, secretPath string, destroy bool) error {
+	apiPath := "/v1/" + b.mount + "/" + secretPath
+	if b.kvVersion == 2 {
+		apiPath = "/v1/" + b.mount + "/data/" + secretPath
+		if destroy {
+			apiPath = "/v1/" + b.mount + "/metadata/" + secretPath
+		}
+	}
+
+	resp, err := b.do(ctx, http.MethodDelete, apiPath, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Deleting a missing secret is not an error (down scripts should be re-runnable)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// do sends a request to the Vault HTTP API
+func (b *Backend) do(ctx context.Context, method, apiPath string, body []byte) (*http.Response, error) {
+	if b.baseURL == "" {
+		return nil, fmt.Errorf("vault client not initialized")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+apiPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if b.token != "" {
+		req.Header.Set("X-Vault-Token", b.token)
+	}
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// path builds the secret path below the mount: {prefix}/{schema}/{key}
+func (b *Backend) path(schemaName, key string) string {
+	var parts []string
+	for _, p := range []string{b.prefix, strings.Trim(schemaName, "/"), strings.Trim(key, "/")} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,112 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   string
+	Token  string
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []recordedRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Body: string(body), Token: r.Header.Get("X-Vault-Token")})
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func connectTestBackend(t *testing.T, srv *httptest.Server, extra map[string]string) *Backend {
+	t.Helper()
+	u, _ := url.Parse(srv.URL)
+	b := NewBackend()
+	if err := b.Connect(&backends.ConnectionConfig{
+		Backend:  "vault",
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		Password: "root-token",
+		Extra:    extra,
+	}); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	return b
+}
+
+func TestBackend_ExecuteMigration_KV2(t *testing.T) {
+	srv, requests := newTestServer(t)
+	b := connectTestBackend(t, srv, map[string]string{"PREFIX": "apps"})
+
+	script := `[
+		{"operation": "put", "key": "billing/config", "value": {"rate_limit": 10}},
+		{"operation": "put", "key": "billing/mode", "value": "strict"},
+		{"operation": "delete", "key": "billing/legacy"},
+		{"operation": "destroy", "key": "billing/old"}
+	]`
+	err := b.ExecuteMigration(context.Background(), &backends.MigrationScript{Schema: "prod", UpSQL: script})
+	if err != nil {
+		t.Fatalf("ExecuteMigration() error = %v", err)
+	}
+
+	got := (*requests)[1:] // skip health check
+	want := []struct{ method, path string }{
+		{http.MethodPost, "/v1/secret/data/apps/prod/billing/config"},
+		{http.MethodPost, "/v1/secret/data/apps/prod/billing/mode"},
+		{http.MethodDelete, "/v1/secret/data/apps/prod/billing/legacy"},
+		{http.MethodDelete, "/v1/secret/metadata/apps/prod/billing/old"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d requests, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		if got[i].Method != w.method || got[i].Path != w.path {
+			t.Errorf("request %d = %s %s, want %s %s", i, got[i].Method, got[i].Path, w.method, w.path)
+		}
+		if got[i].Token != "root-token" {
+			t.Errorf("request %d missing token", i)
+		}
+	}
+
+	var payload map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(got[1].Body), &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload["data"]["value"] != "strict" {
+		t.Errorf("expected scalar value wrapped as {\"value\": ...}, got %v", payload)
+	}
+}
+
+func TestBackend_ExecuteMigration_KV1(t *testing.T) {
+	srv, requests := newTestServer(t)
+	b := connectTestBackend(t, srv, map[string]string{"MOUNT": "kv", "KV_VERSION": "1"})
+
+	err := b.ExecuteMigration(context.Background(), &backends.MigrationScript{UpSQL: `[{"key": "a", "value": "b"}]`})
+	if err != nil {
+		t.Fatalf("ExecuteMigration() error = %v", err)
+	}
+	last := (*requests)[len(*requests)-1]
+	if last.Method != http.MethodPost || last.Path != "/v1/kv/a" || last.Body != `{"value":"b"}` {
+		t.Errorf("unexpected request %+v", last)
+	}
+}
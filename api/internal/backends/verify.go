@@ -0,0 +1,55 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+)
+
+// Verify checks that a migration did what was intended once its up script ran (its
+// {version}_{name}.verify.sql and .verify.json sidecars): a script that succeeds can still leave
+// the schema other than intended. A failing verification fails the migration.
+type Verify struct {
+	SQL        string   `json:"-"`        // Run on the connection; fails the verification by raising an error
+	Assertions []string `json:"assert"`   // Checked against the schema (see schemadiff.Assertion)
+	Rollback   bool     `json:"rollback"` // Run the down script when the verification fails
+}
+
+// IsZero reports whether there is nothing to verify
+func (v Verify) IsZero() bool {
+	return v.SQL == "" && len(v.Assertions) == 0
+}
+
+// ParseVerifyAssertions parses a .verify.json document: a list of assertions, or an object with
+// the assertions and whether to roll back on failure:
+//
+//	{
+//	  "assert": [
+//	    "table users has column email NOT NULL",
+//	    "table users has index users_email_idx"
+//	  ],
+//	  "rollback": true
+//	}
+func ParseVerifyAssertions(data []byte) (Verify, error) {
+	var verify Verify
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &verify.Assertions); err != nil {
+			return Verify{}, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&verify); err != nil {
+			return Verify{}, err
+		}
+	}
+	for i, assertion := range verify.Assertions {
+		if _, err := schemadiff.ParseAssertion(assertion); err != nil {
+			return Verify{}, fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+	}
+	return verify, nil
+}
@@ -0,0 +1,46 @@
+// Package buildinfo reports the version and build of the running binary
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version, Commit and Date are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/toolsascode/bfm/api/internal/buildinfo.Version=v0.3.0 \
+//	  -X github.com/toolsascode/bfm/api/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/toolsascode/bfm/api/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = "" // RFC 3339
+)
+
+// APIVersions are the versions of the HTTP API served, under /api/{version}
+var APIVersions = []string{"v1"}
+
+// Info is the version and build of the running binary
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+// Get returns the build information. Commit and Date default to the VCS information the Go
+// toolchain stamps into binaries built from a git checkout.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
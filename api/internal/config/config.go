@@ -3,38 +3,151 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/toolsascode/bfm/api/internal/auth"
 	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/i18n"
 )
 
 // Config holds the application configuration
 type Config struct {
 	Server struct {
-		HTTPPort string
-		GRPCPort string
-		APIToken string
+		HTTPPort   string
+		GRPCPort   string
+		SinglePort bool // Serve gRPC and HTTP on HTTPPort; GRPCPort is unused
+		APIToken   string
 	}
 	StateDB struct {
-		Type     string // "postgresql" or "mysql"
+		Type     string // "postgresql", "sqlite" or "etcd"
 		Host     string
 		Port     string
 		Username string
 		Password string
 		Database string
 		Schema   string // Configurable schema name
+		Path     string // Database file, for the sqlite backend
+
+		// TLS of the postgresql backend, as in backends.ConnectionConfig
+		SSLMode     string // disable (default), require, verify-ca or verify-full
+		SSLRootCert string
+		SSLCert     string
+		SSLKey      string
+
+		// etcd backend
+		Endpoints    []string
+		Prefix       string // Key prefix state is kept under
+		HistoryLimit int    // History records kept; older ones are removed
 	}
 	Queue struct {
-		Type               string   // "kafka" or "pulsar"
+		Type               string   // "kafka", "pulsar", "redis" or "memory"
 		KafkaBrokers       []string // Kafka broker addresses
 		KafkaTopic         string   // Kafka topic name
 		KafkaGroupID       string   // Kafka consumer group ID
 		PulsarURL          string   // Pulsar service URL
 		PulsarTopic        string   // Pulsar topic name
 		PulsarSubscription string   // Pulsar subscription name
+		KafkaDLQTopic      string   // Kafka dead-letter topic for permanently failed jobs
+		PulsarDLQTopic     string   // Pulsar dead-letter topic for permanently failed jobs
 		Enabled            bool     // Whether to use queue (false = synchronous execution)
+
+		// Redis Streams
+		RedisAddr      string        // Redis address (host:port)
+		RedisPassword  string        // Redis password
+		RedisDB        int           // Redis database number
+		RedisStream    string        // Redis stream name
+		RedisGroup     string        // Redis consumer group, shared by the workers
+		RedisConsumer  string        // Name of this worker in the group; the hostname when empty
+		RedisDLQStream string        // Redis dead-letter stream for permanently failed jobs
+		RedisClaimIdle time.Duration // Idle time after which a pending job of a crashed worker is claimed
+
+		// In-memory queue, consumed by a worker in the server process
+		MemoryCapacity int // Jobs held waiting to be consumed; publishing fails beyond it
+
+		// Worker retries of transiently failed jobs (connection refused, lock timeout)
+		RetryMaxAttempts int           // Attempts per job, the first included; 1 disables retries
+		RetryBackoff     time.Duration // Wait before the first retry, doubled for each next one
+		RetryMaxBackoff  time.Duration // Upper bound of the wait between retries
+	}
+	Execution struct {
+		DriftMode        string   // "fail" or "warn": reaction to applied migrations whose script changed
+		ValidatorPlugins []string // Go plugins exporting custom validators run before migrations are applied
+
+		// Schemas requests may fan migrations out to, on top of the built-in limits (63 bytes,
+		// no PostgreSQL system schemas)
+		SchemaPattern *regexp.Regexp // Names must match it; nil keeps the executor's default
+		SchemaDeny    []string       // Glob patterns of names refused, in addition to pg_* and information_schema
+		MaxSchemas    int            // Most schemas per request; 0 allows any number
+		// Most schemas of a connection one request runs at once (its concurrency is capped to it)
+		MaxSchemaConcurrency int
+		// How long a migration may run before it is canceled, unless it sets its own; 0 for no limit
+		MigrationTimeout time.Duration
+		// What destructive migrations need on connections without a policy of their own:
+		// allow (default), force, approval or block
+		DestructivePolicy string
+		// What executions do with migrations older than the latest applied one on connections
+		// without a policy of their own: allow, warn (default) or block
+		OutOfOrderPolicy string
+	}
+	Features struct {
+		Flags        features.Set // Experimental features enabled or disabled in this environment
+		OverrideRole auth.Role    // Least role allowed to override flags per request (X-BFM-Features)
+	}
+	Loader struct {
+		Source   string   // "go" or "scripts": which wins when a migration has a compiled .go file and scripts
+		SFMPaths []string // SFM roots, merged by the loader
+	}
+	Standby struct {
+		Enabled       bool          // Start passive: migrations and other writes are refused until promoted
+		AutoPromote   bool          // Promote as soon as the primary lock is free (the primary stopped)
+		CheckInterval time.Duration // How often the primary lock is checked, or retried while another instance holds it
+	}
+	Scheduler struct {
+		Interval    time.Duration // How often due scheduled runs are checked for
+		WindowStart time.Duration // Start of the daily maintenance window, since midnight UTC
+		WindowEnd   time.Duration // End of the window; equal to WindowStart when runs may start at any time
+	}
+	Emergency struct {
+		PostmortemWindow time.Duration // How long after an emergency run its postmortem is due
+		ReminderInterval time.Duration // How often overdue postmortems are reminded of
+	}
+	Notify struct {
+		URLs   []string // Webhooks events are POSTed to
+		Token  string   // Bearer token sent to the webhooks
+		Events []string // Event types sent; empty for the notify package's defaults
+	}
+	Secrets struct {
+		CacheTTL time.Duration // How long resolved secrets are used before they are read again
+
+		// HashiCorp Vault, for vault: references; disabled without an address
+		VaultAddr      string
+		VaultToken     string
+		VaultNamespace string
+
+		// AWS Secrets Manager, for awssm: references; disabled without a region
+		AWSRegion          string
+		AWSEndpoint        string // Called instead of the region's endpoint when set
+		AWSAccessKeyID     string
+		AWSSecretAccessKey string
+		AWSSessionToken    string
 	}
+	DevMode struct {
+		Enabled       bool          // The loader watcher applies new and edited migrations to Connection
+		Connection    string        // The developer's own connection; never a shared database
+		Schemas       []string      // Schemas dynamic-schema migrations are applied to; without, they are skipped
+		WatchInterval time.Duration // How often the watcher checks for new files
+	}
+	Locale      string // Locale of API errors when the request has no Accept-Language, and of the CLI
 	Connections map[string]*backends.ConnectionConfig
+	// YAML or JSON file of connections added to the environment's (BFM_CONNECTIONS_FILE), checked
+	// for changes every ConnectionsReloadInterval
+	ConnectionsFile           string
+	ConnectionsReloadInterval time.Duration
 }
 
 // LoadFromEnv loads configuration from environment variables
@@ -42,23 +155,186 @@ func LoadFromEnv() (*Config, error) {
 	config := &Config{
 		Connections: make(map[string]*backends.ConnectionConfig),
 	}
+	// Problems are collected rather than returned one at a time, so all of them are reported
+	var problems []error
 
 	// Server configuration
 	config.Server.HTTPPort = getEnvOrDefault("BFM_HTTP_PORT", "7070")
 	config.Server.GRPCPort = getEnvOrDefault("BFM_GRPC_PORT", "9090")
+	config.Server.SinglePort = getEnvOrDefault("BFM_SINGLE_PORT", "false") == "true"
 	config.Server.APIToken = os.Getenv("BFM_API_TOKEN")
-	if config.Server.APIToken == "" {
-		return nil, fmt.Errorf("BFM_API_TOKEN environment variable is required")
+	if err := auth.ValidateTokenConfig(); err != nil {
+		problems = append(problems, err)
+	}
+	// Role-scoped tokens from BFM_TOKENS or BFM_TOKENS_FILE, or an OIDC provider, can replace the single API token
+	if !auth.TokensConfigured() {
+		problems = append(problems, fmt.Errorf("BFM_API_TOKEN environment variable is required"))
 	}
 
 	// State database configuration
-	config.StateDB.Type = getEnvOrDefault("BFM_STATE_BACKEND", "postgresql")
-	config.StateDB.Host = getEnvOrDefault("BFM_STATE_DB_HOST", "localhost")
-	config.StateDB.Port = getEnvOrDefault("BFM_STATE_DB_PORT", "5432")
-	config.StateDB.Username = getEnvOrDefault("BFM_STATE_DB_USERNAME", "postgres")
-	config.StateDB.Password = os.Getenv("BFM_STATE_DB_PASSWORD")
-	config.StateDB.Database = getEnvOrDefault("BFM_STATE_DB_NAME", "migration_state")
-	config.StateDB.Schema = getEnvOrDefault("BFM_STATE_SCHEMA", "public")
+	problems = append(problems, loadStateDBFromEnv(config)...)
+
+	// Execution configuration
+	config.Execution.DriftMode = getEnvOrDefault("BFM_DRIFT_MODE", "fail")
+	if config.Execution.DriftMode != "fail" && config.Execution.DriftMode != "warn" {
+		problems = append(problems, fmt.Errorf("BFM_DRIFT_MODE must be \"fail\" or \"warn\", got %q", config.Execution.DriftMode))
+	}
+	for _, path := range strings.Split(os.Getenv("BFM_VALIDATOR_PLUGINS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			config.Execution.ValidatorPlugins = append(config.Execution.ValidatorPlugins, path)
+		}
+	}
+	if raw := os.Getenv("BFM_SCHEMA_PATTERN"); raw != "" {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("BFM_SCHEMA_PATTERN: %w", err))
+		}
+		config.Execution.SchemaPattern = pattern
+	}
+	for _, pattern := range strings.Split(os.Getenv("BFM_SCHEMA_DENY"), ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			if _, err := path.Match(pattern, ""); err != nil {
+				problems = append(problems, fmt.Errorf("BFM_SCHEMA_DENY: invalid pattern %q: %w", pattern, err))
+			}
+			config.Execution.SchemaDeny = append(config.Execution.SchemaDeny, pattern)
+		}
+	}
+	maxSchemas, err := strconv.Atoi(getEnvOrDefault("BFM_SCHEMA_MAX_PER_REQUEST", "0"))
+	if err != nil || maxSchemas < 0 {
+		problems = append(problems, fmt.Errorf("BFM_SCHEMA_MAX_PER_REQUEST must be a non-negative integer, got %q", os.Getenv("BFM_SCHEMA_MAX_PER_REQUEST")))
+	}
+	config.Execution.MaxSchemas = maxSchemas
+	maxSchemaConcurrency, err := strconv.Atoi(getEnvOrDefault("BFM_SCHEMA_MAX_CONCURRENCY", "10"))
+	if err != nil || maxSchemaConcurrency < 1 {
+		problems = append(problems, fmt.Errorf("BFM_SCHEMA_MAX_CONCURRENCY must be a positive integer, got %q", os.Getenv("BFM_SCHEMA_MAX_CONCURRENCY")))
+	}
+	config.Execution.MaxSchemaConcurrency = maxSchemaConcurrency
+	migrationTimeout, err := time.ParseDuration(getEnvOrDefault("BFM_MIGRATION_TIMEOUT", "0s"))
+	if err != nil || migrationTimeout < 0 {
+		problems = append(problems, fmt.Errorf("BFM_MIGRATION_TIMEOUT must be a duration such as 30m (0 for no limit), got %q", os.Getenv("BFM_MIGRATION_TIMEOUT")))
+	}
+	config.Execution.MigrationTimeout = migrationTimeout
+	config.Execution.DestructivePolicy = strings.ToLower(getEnvOrDefault("BFM_DESTRUCTIVE_POLICY", "allow"))
+	switch config.Execution.DestructivePolicy {
+	case "allow", "force", "approval", "block":
+	default:
+		problems = append(problems, fmt.Errorf("BFM_DESTRUCTIVE_POLICY must be \"allow\", \"force\", \"approval\" or \"block\", got %q", config.Execution.DestructivePolicy))
+	}
+	config.Execution.OutOfOrderPolicy = strings.ToLower(getEnvOrDefault("BFM_OUT_OF_ORDER", "warn"))
+	switch config.Execution.OutOfOrderPolicy {
+	case "allow", "warn", "block":
+	default:
+		problems = append(problems, fmt.Errorf("BFM_OUT_OF_ORDER must be \"allow\", \"warn\" or \"block\", got %q", config.Execution.OutOfOrderPolicy))
+	}
+
+	// Feature flags
+	flags, err := features.Parse(os.Getenv("BFM_FEATURES"))
+	if err != nil {
+		problems = append(problems, fmt.Errorf("BFM_FEATURES: %w", err))
+	}
+	config.Features.Flags = flags
+	overrideRole, err := auth.ParseRole(getEnvOrDefault("BFM_FEATURES_OVERRIDE_ROLE", string(auth.RoleAdmin)))
+	if err != nil {
+		problems = append(problems, fmt.Errorf("BFM_FEATURES_OVERRIDE_ROLE: %w", err))
+	}
+	config.Features.OverrideRole = overrideRole
+
+	// Message locale
+	locale, err := i18n.Normalize(getEnvOrDefault("BFM_LOCALE", i18n.English))
+	if err != nil {
+		problems = append(problems, fmt.Errorf("BFM_LOCALE: %w", err))
+	}
+	config.Locale = locale
+
+	// Loader configuration
+	config.Loader.Source = getEnvOrDefault("BFM_MIGRATION_SOURCE", "go")
+	if config.Loader.Source != "go" && config.Loader.Source != "scripts" {
+		problems = append(problems, fmt.Errorf("BFM_MIGRATION_SOURCE must be \"go\" or \"scripts\", got %q", config.Loader.Source))
+	}
+	config.Loader.SFMPaths = SFMPathsFromEnv()
+
+	// Standby configuration
+	config.Standby.Enabled = getEnvOrDefault("BFM_STANDBY", "false") == "true"
+	config.Standby.AutoPromote = getEnvOrDefault("BFM_STANDBY_AUTO_PROMOTE", "true") == "true"
+	interval, err := time.ParseDuration(getEnvOrDefault("BFM_STANDBY_CHECK_INTERVAL", "10s"))
+	if err != nil || interval <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_STANDBY_CHECK_INTERVAL must be a positive duration such as 10s, got %q", os.Getenv("BFM_STANDBY_CHECK_INTERVAL")))
+	}
+	config.Standby.CheckInterval = interval
+
+	// Scheduled runs
+	schedulerInterval, err := time.ParseDuration(getEnvOrDefault("BFM_SCHEDULER_INTERVAL", "30s"))
+	if err != nil || schedulerInterval <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_SCHEDULER_INTERVAL must be a positive duration such as 30s, got %q", os.Getenv("BFM_SCHEDULER_INTERVAL")))
+	}
+	config.Scheduler.Interval = schedulerInterval
+	if window := strings.TrimSpace(os.Getenv("BFM_MAINTENANCE_WINDOW")); window != "" {
+		start, end, err := parseMaintenanceWindow(window)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		config.Scheduler.WindowStart, config.Scheduler.WindowEnd = start, end
+	}
+
+	// Emergency runs and notifications
+	postmortemWindow, err := time.ParseDuration(getEnvOrDefault("BFM_EMERGENCY_POSTMORTEM_WINDOW", "48h"))
+	if err != nil || postmortemWindow <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_EMERGENCY_POSTMORTEM_WINDOW must be a positive duration such as 48h, got %q", os.Getenv("BFM_EMERGENCY_POSTMORTEM_WINDOW")))
+	}
+	config.Emergency.PostmortemWindow = postmortemWindow
+	reminderInterval, err := time.ParseDuration(getEnvOrDefault("BFM_EMERGENCY_REMINDER_INTERVAL", "1h"))
+	if err != nil || reminderInterval <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_EMERGENCY_REMINDER_INTERVAL must be a positive duration such as 1h, got %q", os.Getenv("BFM_EMERGENCY_REMINDER_INTERVAL")))
+	}
+	config.Emergency.ReminderInterval = reminderInterval
+	for _, url := range strings.Split(os.Getenv("BFM_NOTIFY_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			config.Notify.URLs = append(config.Notify.URLs, url)
+		}
+	}
+	config.Notify.Token = os.Getenv("BFM_NOTIFY_TOKEN")
+	for _, eventType := range strings.Split(os.Getenv("BFM_NOTIFY_EVENTS"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			config.Notify.Events = append(config.Notify.Events, eventType)
+		}
+	}
+
+	// Secret references of connections (vault:..., awssm:...)
+	secretsTTL, err := time.ParseDuration(getEnvOrDefault("BFM_SECRETS_CACHE_TTL", "5m"))
+	if err != nil || secretsTTL <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_SECRETS_CACHE_TTL must be a positive duration such as 5m, got %q", os.Getenv("BFM_SECRETS_CACHE_TTL")))
+	}
+	config.Secrets.CacheTTL = secretsTTL
+	config.Secrets.VaultAddr = getEnvOrDefault("BFM_SECRETS_VAULT_ADDR", os.Getenv("VAULT_ADDR"))
+	config.Secrets.VaultToken = getEnvOrDefault("BFM_SECRETS_VAULT_TOKEN", os.Getenv("VAULT_TOKEN"))
+	config.Secrets.VaultNamespace = getEnvOrDefault("BFM_SECRETS_VAULT_NAMESPACE", os.Getenv("VAULT_NAMESPACE"))
+	config.Secrets.AWSRegion = getEnvOrDefault("BFM_SECRETS_AWS_REGION", getEnvOrDefault("AWS_REGION", os.Getenv("AWS_DEFAULT_REGION")))
+	config.Secrets.AWSEndpoint = os.Getenv("BFM_SECRETS_AWS_ENDPOINT")
+	config.Secrets.AWSAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	config.Secrets.AWSSecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	config.Secrets.AWSSessionToken = os.Getenv("AWS_SESSION_TOKEN")
+
+	// Developer mode configuration
+	config.DevMode.Enabled = getEnvOrDefault("BFM_DEV_MODE", "false") == "true"
+	config.DevMode.Connection = strings.ToLower(strings.TrimSpace(os.Getenv("BFM_DEV_CONNECTION")))
+	for _, schema := range strings.Split(os.Getenv("BFM_DEV_SCHEMAS"), ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			config.DevMode.Schemas = append(config.DevMode.Schemas, schema)
+		}
+	}
+	devInterval, err := time.ParseDuration(getEnvOrDefault("BFM_DEV_WATCH_INTERVAL", "2s"))
+	if err != nil || devInterval <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_DEV_WATCH_INTERVAL must be a positive duration such as 2s, got %q", os.Getenv("BFM_DEV_WATCH_INTERVAL")))
+	}
+	config.DevMode.WatchInterval = devInterval
+	if config.DevMode.Enabled {
+		if config.DevMode.Connection == "" {
+			problems = append(problems, fmt.Errorf("BFM_DEV_CONNECTION is required when BFM_DEV_MODE is enabled"))
+		}
+		if config.Standby.Enabled {
+			problems = append(problems, fmt.Errorf("BFM_DEV_MODE cannot be combined with BFM_STANDBY"))
+		}
+	}
 
 	// Queue configuration
 	config.Queue.Enabled = getEnvOrDefault("BFM_QUEUE_ENABLED", "false") == "true"
@@ -79,67 +355,137 @@ func LoadFromEnv() (*Config, error) {
 	config.Queue.PulsarURL = getEnvOrDefault("BFM_QUEUE_PULSAR_URL", "pulsar://localhost:6650")
 	config.Queue.PulsarTopic = getEnvOrDefault("BFM_QUEUE_PULSAR_TOPIC", "bfm-migrations")
 	config.Queue.PulsarSubscription = getEnvOrDefault("BFM_QUEUE_PULSAR_SUBSCRIPTION", "bfm-migration-workers")
+	config.Queue.KafkaDLQTopic = getEnvOrDefault("BFM_QUEUE_KAFKA_DLQ_TOPIC", config.Queue.KafkaTopic+"-dlq")
+	config.Queue.PulsarDLQTopic = getEnvOrDefault("BFM_QUEUE_PULSAR_DLQ_TOPIC", config.Queue.PulsarTopic+"-dlq")
 
-	// Load connection configurations
-	// Look for patterns like {CONNECTION}_BACKEND, {CONNECTION}_DB_HOST, etc.
-	envVars := os.Environ()
-	connectionNames := make(map[string]bool)
+	// Redis Streams configuration
+	config.Queue.RedisAddr = getEnvOrDefault("BFM_QUEUE_REDIS_ADDR", "localhost:6379")
+	config.Queue.RedisPassword = os.Getenv("BFM_QUEUE_REDIS_PASSWORD")
+	redisDB, err := strconv.Atoi(getEnvOrDefault("BFM_QUEUE_REDIS_DB", "0"))
+	if err != nil || redisDB < 0 {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_REDIS_DB must be a non-negative integer, got %q", os.Getenv("BFM_QUEUE_REDIS_DB")))
+	}
+	config.Queue.RedisDB = redisDB
+	config.Queue.RedisStream = getEnvOrDefault("BFM_QUEUE_REDIS_STREAM", "bfm-migrations")
+	config.Queue.RedisGroup = getEnvOrDefault("BFM_QUEUE_REDIS_GROUP", "bfm-migration-workers")
+	config.Queue.RedisConsumer = os.Getenv("BFM_QUEUE_REDIS_CONSUMER")
+	config.Queue.RedisDLQStream = getEnvOrDefault("BFM_QUEUE_REDIS_DLQ_STREAM", config.Queue.RedisStream+"-dlq")
+	claimIdle, err := time.ParseDuration(getEnvOrDefault("BFM_QUEUE_REDIS_CLAIM_IDLE", "5m"))
+	if err != nil || claimIdle <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_REDIS_CLAIM_IDLE must be a positive duration such as 5m, got %q", os.Getenv("BFM_QUEUE_REDIS_CLAIM_IDLE")))
+	}
+	config.Queue.RedisClaimIdle = claimIdle
 
-	for _, envVar := range envVars {
-		parts := strings.SplitN(envVar, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
+	// In-memory queue configuration
+	memoryCapacity, err := strconv.Atoi(getEnvOrDefault("BFM_QUEUE_MEMORY_CAPACITY", "100"))
+	if err != nil || memoryCapacity < 1 {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_MEMORY_CAPACITY must be a positive integer, got %q", os.Getenv("BFM_QUEUE_MEMORY_CAPACITY")))
+	}
+	config.Queue.MemoryCapacity = memoryCapacity
 
-		key := parts[0]
-		value := parts[1]
-
-		// Check for {CONNECTION}_BACKEND pattern
-		if strings.HasSuffix(key, "_BACKEND") {
-			connectionName := strings.TrimSuffix(key, "_BACKEND")
-			connectionName = strings.ToLower(connectionName)
-			connectionNames[connectionName] = true
-
-			if config.Connections[connectionName] == nil {
-				config.Connections[connectionName] = &backends.ConnectionConfig{
-					Backend: value,
-					Extra:   make(map[string]string),
-				}
-			} else {
-				config.Connections[connectionName].Backend = value
-			}
-		}
+	// Worker retry policy
+	maxAttempts, err := strconv.Atoi(getEnvOrDefault("BFM_QUEUE_RETRY_MAX_ATTEMPTS", "3"))
+	if err != nil || maxAttempts < 1 {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_RETRY_MAX_ATTEMPTS must be a positive integer, got %q", os.Getenv("BFM_QUEUE_RETRY_MAX_ATTEMPTS")))
 	}
+	config.Queue.RetryMaxAttempts = maxAttempts
+	backoff, err := time.ParseDuration(getEnvOrDefault("BFM_QUEUE_RETRY_BACKOFF", "5s"))
+	if err != nil || backoff < 0 {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_RETRY_BACKOFF must be a duration such as 5s, got %q", os.Getenv("BFM_QUEUE_RETRY_BACKOFF")))
+	}
+	config.Queue.RetryBackoff = backoff
+	maxBackoff, err := time.ParseDuration(getEnvOrDefault("BFM_QUEUE_RETRY_MAX_BACKOFF", "1m"))
+	if err != nil || maxBackoff < backoff {
+		problems = append(problems, fmt.Errorf("BFM_QUEUE_RETRY_MAX_BACKOFF must be a duration of at least BFM_QUEUE_RETRY_BACKOFF, got %q", os.Getenv("BFM_QUEUE_RETRY_MAX_BACKOFF")))
+	}
+	config.Queue.RetryMaxBackoff = maxBackoff
 
-	// Load connection-specific configs
-	for connectionName := range connectionNames {
-		prefix := strings.ToUpper(connectionName) + "_"
-		conn := config.Connections[connectionName]
+	// Connections: the environment's, and those of the connections file, which is reloaded when it changes
+	config.ConnectionsFile = os.Getenv("BFM_CONNECTIONS_FILE")
+	reloadInterval, err := time.ParseDuration(getEnvOrDefault("BFM_CONNECTIONS_RELOAD_INTERVAL", "30s"))
+	if err != nil || reloadInterval <= 0 {
+		problems = append(problems, fmt.Errorf("BFM_CONNECTIONS_RELOAD_INTERVAL must be a positive duration such as 30s, got %q", os.Getenv("BFM_CONNECTIONS_RELOAD_INTERVAL")))
+	}
+	config.ConnectionsReloadInterval = reloadInterval
+	connections, err := LoadConnections(config.ConnectionsFile)
+	if err != nil {
+		problems = append(problems, err)
+	} else {
+		config.Connections = connections
+	}
 
-		conn.Host = getEnvOrDefault(prefix+"DB_HOST", "")
-		conn.Port = getEnvOrDefault(prefix+"DB_PORT", "")
-		conn.Username = getEnvOrDefault(prefix+"DB_USERNAME", "")
-		conn.Password = os.Getenv(prefix + "DB_PASSWORD")
-		conn.Database = getEnvOrDefault(prefix+"DB_NAME", "")
-		conn.Schema = getEnvOrDefault(prefix+"SCHEMA", "")
+	if config.DevMode.Enabled && config.Connections[config.DevMode.Connection] == nil {
+		problems = append(problems, fmt.Errorf("BFM_DEV_CONNECTION %q is not a configured connection", config.DevMode.Connection))
+	}
 
-		// Load any extra configs
-		for _, envVar := range envVars {
-			parts := strings.SplitN(envVar, "=", 2)
-			if len(parts) != 2 {
-				continue
-			}
-			key := parts[0]
-			value := parts[1]
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
 
-			if strings.HasPrefix(key, prefix) && !strings.HasPrefix(key, prefix+"DB_") && key != prefix+"BACKEND" && key != prefix+"SCHEMA" {
-				extraKey := strings.TrimPrefix(key, prefix)
-				conn.Extra[extraKey] = value
-			}
+// LoadStateDBFromEnv loads only the state database configuration, for tools such as the CLI
+// that work on migration state without running the server (no API token is required). Invalid
+// optional settings keep their defaults; LoadFromEnv reports them.
+func LoadStateDBFromEnv() *Config {
+	config := &Config{
+		Connections: make(map[string]*backends.ConnectionConfig),
+	}
+	_ = loadStateDBFromEnv(config)
+	return config
+}
+
+// loadStateDBFromEnv reads the BFM_STATE_* variables and returns the problems found in them
+func loadStateDBFromEnv(config *Config) []error {
+	var problems []error
+	config.StateDB.Type = getEnvOrDefault("BFM_STATE_BACKEND", "postgresql")
+	config.StateDB.Host = getEnvOrDefault("BFM_STATE_DB_HOST", "localhost")
+	config.StateDB.Port = getEnvOrDefault("BFM_STATE_DB_PORT", "5432")
+	config.StateDB.Username = getEnvOrDefault("BFM_STATE_DB_USERNAME", "postgres")
+	config.StateDB.Password = os.Getenv("BFM_STATE_DB_PASSWORD")
+	config.StateDB.Database = getEnvOrDefault("BFM_STATE_DB_NAME", "migration_state")
+	config.StateDB.Schema = getEnvOrDefault("BFM_STATE_SCHEMA", "public")
+	config.StateDB.Path = getEnvOrDefault("BFM_STATE_DB_PATH", "bfm-state.db")
+	config.StateDB.SSLMode = os.Getenv("BFM_STATE_DB_SSLMODE")
+	config.StateDB.SSLRootCert = os.Getenv("BFM_STATE_DB_SSLROOTCERT")
+	config.StateDB.SSLCert = os.Getenv("BFM_STATE_DB_SSLCERT")
+	config.StateDB.SSLKey = os.Getenv("BFM_STATE_DB_SSLKEY")
+
+	for _, endpoint := range strings.Split(getEnvOrDefault("BFM_STATE_ETCD_ENDPOINTS", "localhost:2379"), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			config.StateDB.Endpoints = append(config.StateDB.Endpoints, endpoint)
 		}
 	}
+	config.StateDB.Prefix = getEnvOrDefault("BFM_STATE_ETCD_PREFIX", "/bfm/state/")
+	if historyLimit := os.Getenv("BFM_STATE_ETCD_HISTORY_LIMIT"); historyLimit != "" {
+		limit, err := strconv.Atoi(historyLimit)
+		if err != nil || limit < 0 {
+			problems = append(problems, fmt.Errorf("BFM_STATE_ETCD_HISTORY_LIMIT must be a non-negative integer, got %q", historyLimit))
+		} else {
+			config.StateDB.HistoryLimit = limit // 0: the tracker's default
+		}
+	}
+	if config.StateDB.Type == "etcd" {
+		// etcd runs without authentication unless a username is given
+		config.StateDB.Username = os.Getenv("BFM_STATE_DB_USERNAME")
+	}
+	return problems
+}
 
-	return config, nil
+// SFMPathsFromEnv returns the SFM roots: the comma-separated BFM_SFM_PATHS, else BFM_SFM_PATH,
+// else ../sfm (relative to the bfm directory)
+func SFMPathsFromEnv() []string {
+	var paths []string
+	for _, path := range strings.Split(os.Getenv("BFM_SFM_PATHS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{getEnvOrDefault("BFM_SFM_PATH", "../sfm")}
+	}
+	return paths
 }
 
 // getEnvOrDefault returns the environment variable value or a default
@@ -149,3 +495,25 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseMaintenanceWindow parses a daily window such as 22:00-04:00, in UTC, into its start and end
+// since midnight
+func parseMaintenanceWindow(window string) (time.Duration, time.Duration, error) {
+	invalid := fmt.Errorf("BFM_MAINTENANCE_WINDOW must be a daily UTC range such as 22:00-04:00, got %q", window)
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return 0, 0, invalid
+	}
+	var bounds [2]time.Duration
+	for i, clock := range []string{from, to} {
+		parsed, err := time.Parse("15:04", strings.TrimSpace(clock))
+		if err != nil {
+			return 0, 0, invalid
+		}
+		bounds[i] = time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute
+	}
+	if bounds[0] == bounds[1] {
+		return 0, 0, fmt.Errorf("BFM_MAINTENANCE_WINDOW must not be empty, got %q", window)
+	}
+	return bounds[0], bounds[1], nil
+}
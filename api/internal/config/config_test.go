@@ -1,10 +1,23 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/auth"
 )
 
+func TestMain(m *testing.M) {
+	// The postgresql state backend requires credentials; tests of their absence unset them
+	if os.Getenv("BFM_STATE_DB_PASSWORD") == "" {
+		_ = os.Setenv("BFM_STATE_DB_PASSWORD", "test-password")
+	}
+	os.Exit(m.Run())
+}
+
 func TestGetEnvOrDefault(t *testing.T) {
 	key := "TEST_ENV_VAR"
 	originalValue := os.Getenv(key)
@@ -314,7 +327,7 @@ func TestLoadFromEnv(t *testing.T) {
 			name: "connection config with extra fields",
 			envSetup: func() {
 				_ = os.Setenv("BFM_API_TOKEN", "test-token")
-				_ = os.Setenv("MYSQL_BACKEND", "mysql")
+				_ = os.Setenv("MYSQL_BACKEND", "cassandra")
 				_ = os.Setenv("MYSQL_SSL_MODE", "require")
 				_ = os.Setenv("MYSQL_TIMEOUT", "30")
 			},
@@ -363,6 +376,7 @@ func TestLoadFromEnv(t *testing.T) {
 			}
 
 			// Setup test environment
+			_ = os.Setenv("BFM_STATE_DB_PASSWORD", "test-password")
 			tt.envSetup()
 
 			// Load config
@@ -404,7 +418,7 @@ func TestConfig_Connections(t *testing.T) {
 	// Test multiple connections
 	_ = os.Setenv("POSTGRES_BACKEND", "postgresql")
 	_ = os.Setenv("POSTGRES_DB_HOST", "postgres-host")
-	_ = os.Setenv("MYSQL_BACKEND", "mysql")
+	_ = os.Setenv("MYSQL_BACKEND", "cassandra")
 	_ = os.Setenv("MYSQL_DB_HOST", "mysql-host")
 
 	cfg, err := LoadFromEnv()
@@ -510,6 +524,525 @@ func TestConfig_QueueEnabled(t *testing.T) {
 	}
 }
 
+func TestConfig_QueueRetryAndDeadLetter(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_QUEUE_KAFKA_TOPIC")
+		_ = os.Unsetenv("BFM_QUEUE_RETRY_MAX_ATTEMPTS")
+		_ = os.Unsetenv("BFM_QUEUE_RETRY_BACKOFF")
+		_ = os.Unsetenv("BFM_QUEUE_RETRY_MAX_BACKOFF")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+	_ = os.Setenv("BFM_QUEUE_KAFKA_TOPIC", "migrations")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Queue.KafkaDLQTopic != "migrations-dlq" || cfg.Queue.PulsarDLQTopic != "bfm-migrations-dlq" {
+		t.Errorf("dead-letter topics = %q, %q", cfg.Queue.KafkaDLQTopic, cfg.Queue.PulsarDLQTopic)
+	}
+	if cfg.Queue.RetryMaxAttempts != 3 || cfg.Queue.RetryBackoff != 5*time.Second || cfg.Queue.RetryMaxBackoff != time.Minute {
+		t.Errorf("default retry = %d, %s, %s, want 3, 5s, 1m", cfg.Queue.RetryMaxAttempts, cfg.Queue.RetryBackoff, cfg.Queue.RetryMaxBackoff)
+	}
+
+	_ = os.Setenv("BFM_QUEUE_RETRY_MAX_ATTEMPTS", "0")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for zero attempts")
+	}
+	_ = os.Setenv("BFM_QUEUE_RETRY_MAX_ATTEMPTS", "5")
+	_ = os.Setenv("BFM_QUEUE_RETRY_BACKOFF", "2m")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for a backoff above the max backoff")
+	}
+}
+
+func TestConfig_QueueRedis(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_QUEUE_REDIS_STREAM")
+		_ = os.Unsetenv("BFM_QUEUE_REDIS_DB")
+		_ = os.Unsetenv("BFM_QUEUE_REDIS_CLAIM_IDLE")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Queue.RedisAddr != "localhost:6379" || cfg.Queue.RedisStream != "bfm-migrations" || cfg.Queue.RedisGroup != "bfm-migration-workers" {
+		t.Errorf("default Redis queue = %q, %q, %q", cfg.Queue.RedisAddr, cfg.Queue.RedisStream, cfg.Queue.RedisGroup)
+	}
+	if cfg.Queue.RedisDLQStream != "bfm-migrations-dlq" || cfg.Queue.RedisClaimIdle != 5*time.Minute || cfg.Queue.RedisDB != 0 {
+		t.Errorf("default Redis dead-letter stream and claim idle = %q, %s, db %d", cfg.Queue.RedisDLQStream, cfg.Queue.RedisClaimIdle, cfg.Queue.RedisDB)
+	}
+
+	_ = os.Setenv("BFM_QUEUE_REDIS_STREAM", "jobs")
+	_ = os.Setenv("BFM_QUEUE_REDIS_DB", "2")
+	_ = os.Setenv("BFM_QUEUE_REDIS_CLAIM_IDLE", "30s")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Queue.RedisDLQStream != "jobs-dlq" || cfg.Queue.RedisDB != 2 || cfg.Queue.RedisClaimIdle != 30*time.Second {
+		t.Errorf("Redis queue = %q, db %d, claim idle %s", cfg.Queue.RedisDLQStream, cfg.Queue.RedisDB, cfg.Queue.RedisClaimIdle)
+	}
+
+	for env, value := range map[string]string{"BFM_QUEUE_REDIS_DB": "-1", "BFM_QUEUE_REDIS_CLAIM_IDLE": "0s"} {
+		original := os.Getenv(env)
+		_ = os.Setenv(env, value)
+		if _, err := LoadFromEnv(); err == nil {
+			t.Errorf("LoadFromEnv() expected an error for %s=%s", env, value)
+		}
+		_ = os.Setenv(env, original)
+	}
+}
+
+func TestConfig_QueueMemory(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_QUEUE_MEMORY_CAPACITY")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Queue.MemoryCapacity != 100 {
+		t.Errorf("default in-memory queue capacity = %d, want 100", cfg.Queue.MemoryCapacity)
+	}
+
+	_ = os.Setenv("BFM_QUEUE_MEMORY_CAPACITY", "5")
+	if cfg, err = LoadFromEnv(); err != nil || cfg.Queue.MemoryCapacity != 5 {
+		t.Errorf("LoadFromEnv() = %v, %v; want capacity 5", cfg, err)
+	}
+
+	_ = os.Setenv("BFM_QUEUE_MEMORY_CAPACITY", "0")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for BFM_QUEUE_MEMORY_CAPACITY=0")
+	}
+}
+
+func TestConfig_Scheduler(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_SCHEDULER_INTERVAL")
+		_ = os.Unsetenv("BFM_MAINTENANCE_WINDOW")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Scheduler.Interval != 30*time.Second || cfg.Scheduler.WindowStart != cfg.Scheduler.WindowEnd {
+		t.Errorf("unexpected scheduler defaults %+v", cfg.Scheduler)
+	}
+
+	_ = os.Setenv("BFM_SCHEDULER_INTERVAL", "1m")
+	_ = os.Setenv("BFM_MAINTENANCE_WINDOW", "22:30-04:00")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Scheduler.Interval != time.Minute || cfg.Scheduler.WindowStart != 22*time.Hour+30*time.Minute || cfg.Scheduler.WindowEnd != 4*time.Hour {
+		t.Errorf("unexpected scheduler settings %+v", cfg.Scheduler)
+	}
+
+	for _, window := range []string{"22:00", "22:00-25:00", "night", "04:00-04:00"} {
+		_ = os.Setenv("BFM_MAINTENANCE_WINDOW", window)
+		if _, err := LoadFromEnv(); err == nil {
+			t.Errorf("LoadFromEnv() expected an error for BFM_MAINTENANCE_WINDOW=%s", window)
+		}
+	}
+	_ = os.Unsetenv("BFM_MAINTENANCE_WINDOW")
+	_ = os.Setenv("BFM_SCHEDULER_INTERVAL", "0s")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for BFM_SCHEDULER_INTERVAL=0s")
+	}
+}
+
+func TestConfig_SchemaPolicy(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_SCHEMA_PATTERN")
+		_ = os.Unsetenv("BFM_SCHEMA_DENY")
+		_ = os.Unsetenv("BFM_SCHEMA_MAX_PER_REQUEST")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Execution.SchemaPattern != nil || cfg.Execution.SchemaDeny != nil || cfg.Execution.MaxSchemas != 0 {
+		t.Errorf("expected no schema policy settings by default, got %+v", cfg.Execution)
+	}
+
+	_ = os.Setenv("BFM_SCHEMA_PATTERN", "^tenant_[0-9]+$")
+	_ = os.Setenv("BFM_SCHEMA_DENY", "public, audit_*")
+	_ = os.Setenv("BFM_SCHEMA_MAX_PER_REQUEST", "500")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Execution.SchemaPattern.String() != "^tenant_[0-9]+$" || len(cfg.Execution.SchemaDeny) != 2 ||
+		cfg.Execution.SchemaDeny[1] != "audit_*" || cfg.Execution.MaxSchemas != 500 {
+		t.Errorf("unexpected schema policy settings %+v", cfg.Execution)
+	}
+
+	for key, value := range map[string]string{
+		"BFM_SCHEMA_PATTERN":         "tenant_(",
+		"BFM_SCHEMA_DENY":            "audit_[",
+		"BFM_SCHEMA_MAX_PER_REQUEST": "-1",
+	} {
+		original := os.Getenv(key)
+		_ = os.Setenv(key, value)
+		if _, err := LoadFromEnv(); err == nil {
+			t.Errorf("LoadFromEnv() expected an error for %s=%s", key, value)
+		}
+		_ = os.Setenv(key, original)
+	}
+}
+
+func TestLoadStateDBFromEnv(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	originalHost := os.Getenv("BFM_STATE_DB_HOST")
+	defer func() {
+		for key, value := range map[string]string{"BFM_API_TOKEN": originalToken, "BFM_STATE_DB_HOST": originalHost} {
+			if value != "" {
+				_ = os.Setenv(key, value)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		}
+	}()
+
+	// No API token is needed for the state database alone
+	_ = os.Unsetenv("BFM_API_TOKEN")
+	_ = os.Setenv("BFM_STATE_DB_HOST", "state.internal")
+
+	cfg := LoadStateDBFromEnv()
+	if cfg.StateDB.Host != "state.internal" {
+		t.Errorf("StateDB.Host = %v, want state.internal", cfg.StateDB.Host)
+	}
+	if cfg.StateDB.Database != "migration_state" {
+		t.Errorf("Expected default StateDB.Database = migration_state, got %v", cfg.StateDB.Database)
+	}
+}
+
+func TestConfig_StateEtcdHistoryLimit(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_STATE_ETCD_HISTORY_LIMIT")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	_ = os.Setenv("BFM_STATE_ETCD_HISTORY_LIMIT", "50")
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.StateDB.HistoryLimit != 50 {
+		t.Errorf("StateDB.HistoryLimit = %d, want 50", cfg.StateDB.HistoryLimit)
+	}
+
+	for _, value := range []string{"-1", "ten"} {
+		_ = os.Setenv("BFM_STATE_ETCD_HISTORY_LIMIT", value)
+		if _, err := LoadFromEnv(); err == nil {
+			t.Errorf("LoadFromEnv() expected an error for BFM_STATE_ETCD_HISTORY_LIMIT=%s", value)
+		}
+	}
+}
+
+func TestConfig_DriftMode(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_DRIFT_MODE")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	tests := []struct {
+		name     string
+		envValue string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "", "fail", false},
+		{"warn", "warn", "warn", false},
+		{"invalid", "ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_DRIFT_MODE", tt.envValue)
+			cfg, err := LoadFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Execution.DriftMode != tt.want {
+				t.Errorf("Execution.DriftMode = %q, want %q", cfg.Execution.DriftMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Locale(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_LOCALE")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	tests := []struct {
+		name     string
+		envValue string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "", "en", false},
+		{"japanese", "ja", "ja", false},
+		{"posix", "ja_JP.UTF-8", "ja", false},
+		{"unsupported", "fr", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_LOCALE", tt.envValue)
+			cfg, err := LoadFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Locale != tt.want {
+				t.Errorf("Locale = %q, want %q", cfg.Locale, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Features(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_FEATURES")
+		_ = os.Unsetenv("BFM_FEATURES_OVERRIDE_ROLE")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	tests := []struct {
+		name         string
+		flags        string
+		overrideRole string
+		wantFlags    string
+		wantRole     auth.Role
+		wantErr      bool
+	}{
+		{"default", "", "", "", auth.RoleAdmin, false},
+		{"flags", "deep_dry_run,-declarative", "operator", "-declarative,deep_dry_run", auth.RoleOperator, false},
+		{"unknown flag", "canary", "", "", "", true},
+		{"invalid role", "", "root", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_FEATURES", tt.flags)
+			_ = os.Setenv("BFM_FEATURES_OVERRIDE_ROLE", tt.overrideRole)
+			cfg, err := LoadFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := cfg.Features.Flags.String(); got != tt.wantFlags {
+				t.Errorf("Features.Flags = %q, want %q", got, tt.wantFlags)
+			}
+			if cfg.Features.OverrideRole != tt.wantRole {
+				t.Errorf("Features.OverrideRole = %q, want %q", cfg.Features.OverrideRole, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestConfig_MigrationSource(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_MIGRATION_SOURCE")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	tests := []struct {
+		name     string
+		envValue string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "", "go", false},
+		{"scripts", "scripts", "scripts", false},
+		{"invalid", "sql", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_MIGRATION_SOURCE", tt.envValue)
+			cfg, err := LoadFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Loader.Source != tt.want {
+				t.Errorf("Loader.Source = %q, want %q", cfg.Loader.Source, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Standby(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_STANDBY")
+		_ = os.Unsetenv("BFM_STANDBY_AUTO_PROMOTE")
+		_ = os.Unsetenv("BFM_STANDBY_CHECK_INTERVAL")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Standby.Enabled || !cfg.Standby.AutoPromote || cfg.Standby.CheckInterval != 10*time.Second {
+		t.Errorf("default Standby = %+v, want disabled, auto-promote, 10s", cfg.Standby)
+	}
+
+	_ = os.Setenv("BFM_STANDBY", "true")
+	_ = os.Setenv("BFM_STANDBY_AUTO_PROMOTE", "false")
+	_ = os.Setenv("BFM_STANDBY_CHECK_INTERVAL", "2s")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if !cfg.Standby.Enabled || cfg.Standby.AutoPromote || cfg.Standby.CheckInterval != 2*time.Second {
+		t.Errorf("Standby = %+v, want enabled, manual promotion, 2s", cfg.Standby)
+	}
+
+	_ = os.Setenv("BFM_STANDBY_CHECK_INTERVAL", "10")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for an interval without a unit")
+	}
+}
+
+func TestConfig_DevMode(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_DEV_MODE")
+		_ = os.Unsetenv("BFM_DEV_CONNECTION")
+		_ = os.Unsetenv("BFM_DEV_SCHEMAS")
+		_ = os.Unsetenv("BFM_DEV_WATCH_INTERVAL")
+		_ = os.Unsetenv("LOCALDEV_BACKEND")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.DevMode.Enabled || cfg.DevMode.WatchInterval != 2*time.Second {
+		t.Errorf("default DevMode = %+v, want disabled, 2s", cfg.DevMode)
+	}
+
+	_ = os.Setenv("BFM_DEV_MODE", "true")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error without BFM_DEV_CONNECTION")
+	}
+
+	_ = os.Setenv("BFM_DEV_CONNECTION", "LocalDev")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() expected an error for an unknown dev connection")
+	}
+
+	_ = os.Setenv("LOCALDEV_BACKEND", "postgresql")
+	_ = os.Setenv("BFM_DEV_SCHEMAS", "public, tenant1")
+	_ = os.Setenv("BFM_DEV_WATCH_INTERVAL", "500ms")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if !cfg.DevMode.Enabled || cfg.DevMode.Connection != "localdev" || len(cfg.DevMode.Schemas) != 2 || cfg.DevMode.Schemas[1] != "tenant1" || cfg.DevMode.WatchInterval != 500*time.Millisecond {
+		t.Errorf("DevMode = %+v", cfg.DevMode)
+	}
+}
+
 func TestConfig_ConnectionsMap(t *testing.T) {
 	originalToken := os.Getenv("BFM_API_TOKEN")
 	defer func() {
@@ -536,3 +1069,101 @@ func TestConfig_ConnectionsMap(t *testing.T) {
 		t.Error("Connections map should be initialized")
 	}
 }
+
+func TestSFMPathsFromEnv(t *testing.T) {
+	defer func() {
+		_ = os.Unsetenv("BFM_SFM_PATH")
+		_ = os.Unsetenv("BFM_SFM_PATHS")
+	}()
+
+	tests := []struct {
+		name     string
+		sfmPath  string
+		sfmPaths string
+		want     string
+	}{
+		{"default", "", "", "../sfm"},
+		{"single path", "/sfm", "", "/sfm"},
+		{"several paths win", "/sfm", "/platform/sfm, /product/sfm,", "/platform/sfm|/product/sfm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("BFM_SFM_PATH", tt.sfmPath)
+			_ = os.Setenv("BFM_SFM_PATHS", tt.sfmPaths)
+			if got := strings.Join(SFMPathsFromEnv(), "|"); got != tt.want {
+				t.Errorf("SFMPathsFromEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidatorPlugins(t *testing.T) {
+	originalToken := os.Getenv("BFM_API_TOKEN")
+	defer func() {
+		if originalToken != "" {
+			_ = os.Setenv("BFM_API_TOKEN", originalToken)
+		} else {
+			_ = os.Unsetenv("BFM_API_TOKEN")
+		}
+		_ = os.Unsetenv("BFM_VALIDATOR_PLUGINS")
+	}()
+
+	_ = os.Setenv("BFM_API_TOKEN", "test-token")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if len(cfg.Execution.ValidatorPlugins) != 0 {
+		t.Errorf("default ValidatorPlugins = %v, want none", cfg.Execution.ValidatorPlugins)
+	}
+
+	_ = os.Setenv("BFM_VALIDATOR_PLUGINS", "/plugins/naming.so, ,/plugins/schemas.so")
+	cfg, err = LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if got := cfg.Execution.ValidatorPlugins; len(got) != 2 || got[0] != "/plugins/naming.so" || got[1] != "/plugins/schemas.so" {
+		t.Errorf("ValidatorPlugins = %v, want [/plugins/naming.so /plugins/schemas.so]", got)
+	}
+}
+
+func TestConfig_ValidationReportsAllProblems(t *testing.T) {
+	t.Setenv("BFM_API_TOKEN", "test-token")
+	t.Setenv("BFM_STATE_DB_PASSWORD", "")
+	t.Setenv("BFM_HTTP_PORT", "70700")
+	t.Setenv("BFM_DRIFT_MODE", "ignore")
+	t.Setenv("BFM_QUEUE_ENABLED", "true")
+	t.Setenv("BFM_QUEUE_KAFKA_BROKERS", ",")
+	t.Setenv("LEGACY_BACKEND", "mysql")
+	t.Setenv("LEGACY_DB_SSLMODE", "prefer")
+
+	_, err := LoadFromEnv()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("LoadFromEnv() error = %v, want a *ValidationError", err)
+	}
+	for _, want := range []string{
+		`BFM_DRIFT_MODE must be "fail" or "warn"`,
+		"BFM_HTTP_PORT must be a port number",
+		"BFM_STATE_DB_USERNAME and BFM_STATE_DB_PASSWORD are required",
+		`connection legacy: unknown backend "mysql"`,
+		"LEGACY_DB_SSLMODE must be",
+		"BFM_QUEUE_KAFKA_BROKERS is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q among the problems, got:\n%v", want, err)
+		}
+	}
+	if len(invalid.Problems) != 6 || !strings.HasPrefix(err.Error(), "6 configuration problems:") {
+		t.Errorf("expected 6 problems, got %d:\n%v", len(invalid.Problems), err)
+	}
+
+	// A valid configuration has none
+	cfg := &Config{}
+	cfg.Server.HTTPPort, cfg.Server.SinglePort = "7070", true
+	cfg.StateDB.Type, cfg.StateDB.Path = "sqlite", "bfm-state.db"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// fileConnection is a connection of a connections file, with the settings of the environment's
+// {CONNECTION}_* variables
+type fileConnection struct {
+	Backend  string            `yaml:"backend"`
+	Host     string            `yaml:"host"`
+	Port     string            `yaml:"port"`
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password"`
+	Database string            `yaml:"database"`
+	Schema   string            `yaml:"schema"`
+	Extra    map[string]string `yaml:"extra"` // Other settings, by the suffix of their variable (SQL_LOG, BACKUP_DIR...)
+
+	SSLMode     string `yaml:"sslmode"`
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+}
+
+// connectionsFile is the content of a connections file
+type connectionsFile struct {
+	Connections map[string]fileConnection `yaml:"connections"`
+}
+
+// LoadConnections returns the connections of the environment ({CONNECTION}_BACKEND and the
+// variables of the same prefix) and, when file is set, of that YAML or JSON file. A connection of
+// the file replaces the environment's of the same name.
+func LoadConnections(file string) (map[string]*backends.ConnectionConfig, error) {
+	connections := connectionsFromEnv()
+	if file == "" {
+		return connections, nil
+	}
+	fromFile, err := ReadConnectionsFile(file)
+	if err != nil {
+		return nil, err
+	}
+	for name, conn := range fromFile {
+		connections[name] = conn
+	}
+	return connections, nil
+}
+
+// ReadConnectionsFile reads a connections file:
+//
+//	connections:
+//	  tenant_42:
+//	    backend: postgresql
+//	    host: db-42.internal
+//	    port: "5432"
+//	    username: bfm
+//	    password: secret
+//	    database: tenant_42
+//	    sslmode: verify-full
+//	    sslrootcert: /etc/bfm/certs/rds-ca.pem
+//	    extra:
+//	      SQL_LOG: none
+//
+// Connection names are case-insensitive, like those of the environment.
+func ReadConnectionsFile(file string) (map[string]*backends.ConnectionConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("BFM_CONNECTIONS_FILE: %w", err)
+	}
+	var parsed connectionsFile
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("BFM_CONNECTIONS_FILE %s: %w", file, err)
+	}
+
+	connections := make(map[string]*backends.ConnectionConfig, len(parsed.Connections))
+	for name, conn := range parsed.Connections {
+		if conn.Backend == "" {
+			return nil, fmt.Errorf("BFM_CONNECTIONS_FILE %s: connection %s has no backend", file, name)
+		}
+		extra := make(map[string]string, len(conn.Extra))
+		for key, value := range conn.Extra {
+			extra[strings.ToUpper(key)] = value
+		}
+		connections[strings.ToLower(name)] = &backends.ConnectionConfig{
+			Backend:  conn.Backend,
+			Host:     conn.Host,
+			Port:     conn.Port,
+			Username: conn.Username,
+			Password: conn.Password,
+			Database: conn.Database,
+			Schema:   conn.Schema,
+			Extra:    extra,
+
+			SSLMode:        conn.SSLMode,
+			RootCAPath:     conn.SSLRootCert,
+			ClientCertPath: conn.SSLCert,
+			ClientKeyPath:  conn.SSLKey,
+		}
+	}
+	return connections, nil
+}
+
+// connectionsFromEnv returns the connections of the environment: patterns like
+// {CONNECTION}_BACKEND, {CONNECTION}_DB_HOST, etc.
+func connectionsFromEnv() map[string]*backends.ConnectionConfig {
+	connections := make(map[string]*backends.ConnectionConfig)
+	envVars := os.Environ()
+	connectionNames := make(map[string]bool)
+
+	for _, envVar := range envVars {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := parts[0]
+		value := parts[1]
+
+		// Check for {CONNECTION}_BACKEND pattern
+		if strings.HasSuffix(key, "_BACKEND") {
+			connectionName := strings.TrimSuffix(key, "_BACKEND")
+			connectionName = strings.ToLower(connectionName)
+			connectionNames[connectionName] = true
+
+			if connections[connectionName] == nil {
+				connections[connectionName] = &backends.ConnectionConfig{
+					Backend: value,
+					Extra:   make(map[string]string),
+				}
+			} else {
+				connections[connectionName].Backend = value
+			}
+		}
+	}
+
+	// Load connection-specific configs
+	for connectionName := range connectionNames {
+		prefix := strings.ToUpper(connectionName) + "_"
+		conn := connections[connectionName]
+
+		conn.Host = getEnvOrDefault(prefix+"DB_HOST", "")
+		conn.Port = getEnvOrDefault(prefix+"DB_PORT", "")
+		conn.Username = getEnvOrDefault(prefix+"DB_USERNAME", "")
+		conn.Password = os.Getenv(prefix + "DB_PASSWORD")
+		conn.Database = getEnvOrDefault(prefix+"DB_NAME", "")
+		conn.Schema = getEnvOrDefault(prefix+"SCHEMA", "")
+		conn.SSLMode = os.Getenv(prefix + "DB_SSLMODE")
+		conn.RootCAPath = os.Getenv(prefix + "DB_SSLROOTCERT")
+		conn.ClientCertPath = os.Getenv(prefix + "DB_SSLCERT")
+		conn.ClientKeyPath = os.Getenv(prefix + "DB_SSLKEY")
+
+		// Load any extra configs
+		for _, envVar := range envVars {
+			parts := strings.SplitN(envVar, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			key := parts[0]
+			value := parts[1]
+
+			if strings.HasPrefix(key, prefix) && !strings.HasPrefix(key, prefix+"DB_") && key != prefix+"BACKEND" && key != prefix+"SCHEMA" {
+				extraKey := strings.TrimPrefix(key, prefix)
+				conn.Extra[extraKey] = value
+			}
+		}
+	}
+	return connections
+}
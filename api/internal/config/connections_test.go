@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConnections(t *testing.T) {
+	t.Setenv("CORE_BACKEND", "postgresql")
+	t.Setenv("CORE_DB_HOST", "core.internal")
+	t.Setenv("CORE_DB_SSLMODE", "verify-full")
+	t.Setenv("CORE_DB_SSLROOTCERT", "/etc/bfm/ca.pem")
+	t.Setenv("TENANT_42_BACKEND", "postgresql")
+	t.Setenv("TENANT_42_DB_HOST", "old.internal")
+
+	file := filepath.Join(t.TempDir(), "connections.yaml")
+	if err := os.WriteFile(file, []byte(`
+connections:
+  TENANT_42:
+    backend: postgresql
+    host: db-42.internal
+    port: "5432"
+    database: tenant_42
+    sslmode: require
+    extra:
+      sql_log: none
+  events:
+    backend: etcd
+    host: etcd.internal
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	connections, err := LoadConnections(file)
+	if err != nil {
+		t.Fatalf("LoadConnections() error = %v", err)
+	}
+	if core := connections["core"]; core == nil || core.Host != "core.internal" || core.SSLMode != "verify-full" || core.RootCAPath != "/etc/bfm/ca.pem" || len(core.Extra) != 0 {
+		t.Errorf("expected the environment's connections, got %+v", core)
+	}
+	tenant := connections["tenant_42"]
+	if tenant == nil || tenant.Host != "db-42.internal" || tenant.Database != "tenant_42" || tenant.Extra["SQL_LOG"] != "none" || tenant.SSLMode != "require" {
+		t.Errorf("expected the file's connection to replace the environment's, got %+v", tenant)
+	}
+	if events := connections["events"]; events == nil || events.Backend != "etcd" {
+		t.Errorf("expected the file's new connection, got %+v", events)
+	}
+
+	// JSON files are read too; a connection without a backend is rejected
+	if err := os.WriteFile(file, []byte(`{"connections": {"broken": {"host": "x"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConnections(file); err == nil || !strings.Contains(err.Error(), "connection broken has no backend") {
+		t.Errorf("expected a connection without a backend to be rejected, got %v", err)
+	}
+	if _, err := LoadConnections(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("expected a missing file to be an error")
+	}
+}
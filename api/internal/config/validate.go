@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Backends connections may use, as registered by the server and the worker
+var knownBackends = []string{"postgresql", "greptimedb", "etcd", "cassandra", "consul", "vault", "spanner"}
+
+// ValidationError lists every problem found in a configuration, so they can all be fixed at once
+type ValidationError struct {
+	Problems []error
+}
+
+// Error returns the problem, or the list of problems one per line
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Error()
+	}
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d configuration problems:", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  - "+problem.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the problems, for errors.Is and errors.As
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// Validate checks the settings that depend on one another: ports, the state database and its
+// credentials, the backends of the connections and the queue. It returns a *ValidationError
+// listing every problem found, nil when there is none.
+func (c *Config) Validate() error {
+	if problems := c.validate(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validate returns the problems Validate reports
+func (c *Config) validate() []error {
+	var problems []error
+	checkPort := func(name, value string) {
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Errorf("%s must be a port number between 1 and 65535, got %q", name, value))
+		}
+	}
+	checkSSLMode := func(name, value string) {
+		switch value {
+		case "", "disable", "require", "verify-ca", "verify-full":
+		default:
+			problems = append(problems, fmt.Errorf("%s must be \"disable\", \"require\", \"verify-ca\" or \"verify-full\", got %q", name, value))
+		}
+	}
+
+	checkPort("BFM_HTTP_PORT", c.Server.HTTPPort)
+	if !c.Server.SinglePort {
+		checkPort("BFM_GRPC_PORT", c.Server.GRPCPort)
+		if c.Server.GRPCPort == c.Server.HTTPPort {
+			problems = append(problems, fmt.Errorf("BFM_GRPC_PORT and BFM_HTTP_PORT are both %s; set BFM_SINGLE_PORT=true to serve both on one port", c.Server.HTTPPort))
+		}
+	}
+
+	switch c.StateDB.Type {
+	case "postgresql":
+		if c.StateDB.Host == "" {
+			problems = append(problems, fmt.Errorf("BFM_STATE_DB_HOST is required for the postgresql state backend"))
+		}
+		checkPort("BFM_STATE_DB_PORT", c.StateDB.Port)
+		if c.StateDB.Username == "" || c.StateDB.Password == "" {
+			problems = append(problems, fmt.Errorf("BFM_STATE_DB_USERNAME and BFM_STATE_DB_PASSWORD are required for the postgresql state backend"))
+		}
+		checkSSLMode("BFM_STATE_DB_SSLMODE", c.StateDB.SSLMode)
+	case "sqlite":
+		if c.StateDB.Path == "" {
+			problems = append(problems, fmt.Errorf("BFM_STATE_DB_PATH is required for the sqlite state backend"))
+		}
+	case "etcd":
+		if len(c.StateDB.Endpoints) == 0 {
+			problems = append(problems, fmt.Errorf("BFM_STATE_ETCD_ENDPOINTS is required for the etcd state backend"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("BFM_STATE_BACKEND must be \"postgresql\", \"sqlite\" or \"etcd\", got %q", c.StateDB.Type))
+	}
+
+	names := make([]string, 0, len(c.Connections))
+	for name := range c.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conn := c.Connections[name]
+		prefix := strings.ToUpper(name) + "_"
+		if !contains(knownBackends, conn.Backend) {
+			problems = append(problems, fmt.Errorf("connection %s: unknown backend %q (%s)", name, conn.Backend, strings.Join(knownBackends, ", ")))
+		}
+		if conn.Port != "" {
+			checkPort(prefix+"DB_PORT", conn.Port)
+		}
+		checkSSLMode(prefix+"DB_SSLMODE", conn.SSLMode)
+	}
+
+	if c.Queue.Enabled {
+		switch c.Queue.Type {
+		case "kafka":
+			if len(nonEmpty(c.Queue.KafkaBrokers)) == 0 {
+				problems = append(problems, fmt.Errorf("BFM_QUEUE_KAFKA_BROKERS is required when the kafka queue is enabled"))
+			}
+		case "pulsar":
+			if c.Queue.PulsarURL == "" {
+				problems = append(problems, fmt.Errorf("BFM_QUEUE_PULSAR_URL is required when the pulsar queue is enabled"))
+			}
+		case "redis":
+			if c.Queue.RedisAddr == "" {
+				problems = append(problems, fmt.Errorf("BFM_QUEUE_REDIS_ADDR is required when the redis queue is enabled"))
+			}
+		case "memory":
+		default:
+			problems = append(problems, fmt.Errorf("BFM_QUEUE_TYPE must be \"kafka\", \"pulsar\", \"redis\" or \"memory\", got %q", c.Queue.Type))
+		}
+	}
+
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// nonEmpty returns the values that are not blank
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
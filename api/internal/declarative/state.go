@@ -0,0 +1,197 @@
+// Package declarative defines desired-state migration documents. Instead of imperative
+// up/down SQL, a desired-state migration lists the objects that should (or should not) exist
+// and the backend computes and applies the difference, so the same document can be applied
+// any number of times.
+//
+// Example ({version}_{name}.up.yaml):
+//
+//	roles:
+//	  - name: app
+//	    login: true
+//	    password_env: APP_DB_PASSWORD
+//	    member_of: [readers]
+//	  - name: legacy
+//	    absent: true
+//	extensions:
+//	  - name: pgcrypto
+//	schemas:
+//	  - name: billing
+//	    owner: app
+//	grants:
+//	  - role: app
+//	    on: schema
+//	    object: billing
+//	    privileges: [usage, create]
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Grant targets
+const (
+	OnDatabase  = "database"
+	OnSchema    = "schema"
+	OnTable     = "table"
+	OnAllTables = "all_tables" // every existing table in the schema named by Object
+)
+
+// State is a desired-state document
+type State struct {
+	Roles      []Role      `yaml:"roles"`
+	Extensions []Extension `yaml:"extensions"`
+	Schemas    []Schema    `yaml:"schemas"`
+	Grants     []Grant     `yaml:"grants"`
+}
+
+// Role is a desired database role. Unset attributes are left unchanged.
+type Role struct {
+	Name        string   `yaml:"name"`
+	Login       *bool    `yaml:"login"`
+	CreateDB    *bool    `yaml:"createdb"`
+	CreateRole  *bool    `yaml:"createrole"`
+	Inherit     *bool    `yaml:"inherit"`
+	PasswordEnv string   `yaml:"password_env"` // Environment variable holding the password; only used when the role is created
+	MemberOf    []string `yaml:"member_of"`    // Roles this role is granted membership in
+	Absent      bool     `yaml:"absent"`       // Drop the role if it exists
+}
+
+// Extension is a desired extension. Empty Schema and Version are left unchanged.
+type Extension struct {
+	Name    string `yaml:"name"`
+	Schema  string `yaml:"schema"`
+	Version string `yaml:"version"`
+	Absent  bool   `yaml:"absent"`
+}
+
+// Schema is a desired schema. An empty Owner is left unchanged.
+type Schema struct {
+	Name   string `yaml:"name"`
+	Owner  string `yaml:"owner"`
+	Absent bool   `yaml:"absent"`
+}
+
+// Grant is a set of privileges a role should (or, with Absent, should not) hold on an object
+type Grant struct {
+	Role       string   `yaml:"role"`
+	On         string   `yaml:"on"`     // database, schema, table or all_tables
+	Object     string   `yaml:"object"` // Database, schema or schema-qualified table name
+	Privileges []string `yaml:"privileges"`
+	Absent     bool     `yaml:"absent"` // Revoke the privileges instead of granting them
+}
+
+// privilegesByTarget lists the privileges accepted for each grant target ("all" expands to these)
+var privilegesByTarget = map[string][]string{
+	OnDatabase:  {"CONNECT", "CREATE", "TEMPORARY"},
+	OnSchema:    {"USAGE", "CREATE"},
+	OnTable:     {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	OnAllTables: {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+}
+
+// Parse parses and validates a desired-state document.
+// Leading "--" comment lines (such as bfm-tags) are ignored. Privileges are normalized to
+// upper case and "all" is expanded, so callers can compare them directly.
+func Parse(content string) (*State, error) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	state := &State{}
+	decoder := yaml.NewDecoder(strings.NewReader(strings.Join(lines, "\n")))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(state); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid desired-state document: %w", err)
+	}
+
+	if err := state.normalize(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// normalize validates the document and canonicalizes privilege names
+func (s *State) normalize() error {
+	seen := make(map[string]bool)
+	unique := func(kind, name string) error {
+		if name == "" {
+			return fmt.Errorf("%s: name is required", kind)
+		}
+		key := kind + "/" + name
+		if seen[key] {
+			return fmt.Errorf("%s %s is declared more than once", kind, name)
+		}
+		seen[key] = true
+		return nil
+	}
+
+	for _, r := range s.Roles {
+		if err := unique("role", r.Name); err != nil {
+			return err
+		}
+	}
+	for _, e := range s.Extensions {
+		if err := unique("extension", e.Name); err != nil {
+			return err
+		}
+	}
+	for _, sc := range s.Schemas {
+		if err := unique("schema", sc.Name); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.Grants {
+		g := &s.Grants[i]
+		g.On = strings.ToLower(strings.TrimSpace(g.On))
+		allowed, ok := privilegesByTarget[g.On]
+		if !ok {
+			return fmt.Errorf("grant %d: unsupported target %q (use database, schema, table or all_tables)", i+1, g.On)
+		}
+		if g.Role == "" || g.Object == "" {
+			return fmt.Errorf("grant %d: role and object are required", i+1)
+		}
+		if len(g.Privileges) == 0 {
+			return fmt.Errorf("grant %d: at least one privilege is required", i+1)
+		}
+
+		var privileges []string
+		for _, p := range g.Privileges {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			expanded := []string{p}
+			switch p {
+			case "ALL", "ALL PRIVILEGES":
+				expanded = allowed
+			case "TEMP":
+				expanded = []string{"TEMPORARY"}
+			}
+			for _, e := range expanded {
+				if !contains(allowed, e) {
+					return fmt.Errorf("grant %d: privilege %s is not valid on %s", i+1, e, g.On)
+				}
+				if !contains(privileges, e) {
+					privileges = append(privileges, e)
+				}
+			}
+		}
+		g.Privileges = privileges
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package declarative
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	state, err := Parse(`-- bfm-tags: env=prod
+roles:
+  - name: app
+    login: true
+    member_of: [readers]
+schemas:
+  - name: billing
+    owner: app
+grants:
+  - role: app
+    on: Database
+    object: appdb
+    privileges: [connect, temp]
+  - role: app
+    on: all_tables
+    object: billing
+    privileges: [all, select]
+`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(state.Roles) != 1 || state.Roles[0].Login == nil || !*state.Roles[0].Login || state.Roles[0].CreateDB != nil {
+		t.Errorf("unexpected roles: %+v", state.Roles)
+	}
+	if got := state.Grants[0]; got.On != OnDatabase || !reflect.DeepEqual(got.Privileges, []string{"CONNECT", "TEMPORARY"}) {
+		t.Errorf("unexpected database grant: %+v", got)
+	}
+	if got := state.Grants[1].Privileges; !reflect.DeepEqual(got, privilegesByTarget[OnAllTables]) {
+		t.Errorf("expected all to expand without duplicates, got %v", got)
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	state, err := Parse("-- nothing to do\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(state.Roles)+len(state.Extensions)+len(state.Schemas)+len(state.Grants) != 0 {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", "roles:\n  - name: app\n    superuser: true\n", "field superuser not found"},
+		{"duplicate role", "roles:\n  - name: app\n  - name: app\n", "role app is declared more than once"},
+		{"missing name", "schemas:\n  - owner: app\n", "schema: name is required"},
+		{"unknown target", "grants:\n  - {role: app, on: sequence, object: s, privileges: [usage]}\n", "unsupported target"},
+		{"invalid privilege", "grants:\n  - {role: app, on: schema, object: s, privileges: [select]}\n", "privilege SELECT is not valid on schema"},
+		{"no privileges", "grants:\n  - {role: app, on: schema, object: s}\n", "at least one privilege"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
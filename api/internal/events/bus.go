@@ -0,0 +1,148 @@
+// Package events is the in-process event bus of the server. The executor and loader publish
+// lifecycle events; notification, webhook, streaming and audit subsystems subscribe to them
+// instead of being called directly, so a new integration is a single Subscribe call.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event types. The execution events (MigrationApplied, MigrationFailed, RollbackCompleted) carry
+// operation (up, down, rollback), backend and elapsed_ms in Data, and error when failed.
+const (
+	MigrationStarted   = "migration.started"
+	StatementCompleted = "migration.statement_completed" // A statement of a migration completed (backends executing scripts statement by statement)
+	MigrationApplied   = "migration.applied"
+	MigrationFailed    = "migration.failed"      // An up or down migration or a rollback failed
+	RollbackCompleted  = "migration.rolled_back" // A down migration or rollback reverted a migration
+	RunCompleted       = "run.completed"         // An up run on a connection and schema applied migrations
+	JobQueued          = "job.queued"
+	JobScheduled       = "job.scheduled"
+	ReindexCompleted   = "reindex.completed"
+	LoaderFileDetected = "loader.file_detected"
+	// The connections were reloaded; Data lists the names of those added, removed and changed
+	ConnectionsReloaded = "connections.reloaded"
+
+	// Emergency runs: a request bypassed the execution safeguards, its postmortem is overdue (published
+	// again at every reminder until it is recorded), and its postmortem was recorded
+	EmergencyStarted   = "emergency.started"
+	PostmortemOverdue  = "emergency.postmortem_overdue"
+	PostmortemRecorded = "emergency.postmortem_recorded"
+
+	// All subscribes to every event type
+	All = "*"
+)
+
+// Event is a published event. Fields that don't apply to an event type are empty.
+type Event struct {
+	Type        string
+	Time        time.Time
+	MigrationID string
+	Connection  string
+	Schema      string
+	Data        map[string]interface{} // Type-specific details (error, job_id, path, counts, ...)
+}
+
+// Handler handles an event. Handlers run synchronously in the publisher's goroutine, so
+// subscribers doing I/O (webhooks, notifications) must hand the event off rather than block.
+type Handler func(ctx context.Context, event Event)
+
+type subscription struct {
+	id        int
+	eventType string
+	handler   Handler
+}
+
+// Bus dispatches published events to subscribers. A nil *Bus is valid and drops events.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+	nextID        int
+	onPanic       func(event Event, recovered interface{})
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// OnHandlerPanic sets a callback for handlers that panic. The panic is always recovered so a
+// faulty subscriber cannot break the publisher; by default it is dropped.
+func (b *Bus) OnHandlerPanic(fn func(event Event, recovered interface{})) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onPanic = fn
+}
+
+// Subscribe registers handler for eventType (or All) and returns a function that removes it
+func (b *Bus) Subscribe(eventType string, handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subscriptions = append(b.subscriptions, subscription{id: id, eventType: eventType, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subscriptions {
+			if s.id == id {
+				b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers event to its subscribers in subscription order. Time is set if empty.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	var handlers []Handler
+	for _, s := range b.subscriptions {
+		if s.eventType == All || s.eventType == event.Type {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	onPanic := b.onPanic
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		dispatch(ctx, handler, event, onPanic)
+	}
+}
+
+// dispatch calls a single handler, recovering a panic
+func dispatch(ctx context.Context, handler Handler, event Event, onPanic func(Event, interface{})) {
+	defer func() {
+		if r := recover(); r != nil && onPanic != nil {
+			onPanic(event, r)
+		}
+	}()
+	handler(ctx, event)
+}
+
+// String returns a one-line description of the event for logs
+func (e Event) String() string {
+	s := e.Type
+	if e.MigrationID != "" {
+		s += " " + e.MigrationID
+	}
+	if e.Connection != "" {
+		s += fmt.Sprintf(" connection=%s", e.Connection)
+	}
+	if e.Schema != "" {
+		s += fmt.Sprintf(" schema=%s", e.Schema)
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(MigrationApplied, func(_ context.Context, e Event) {
+		got = append(got, "applied:"+e.MigrationID)
+	})
+	unsubscribe := bus.Subscribe(All, func(_ context.Context, e Event) {
+		got = append(got, "all:"+e.Type)
+		if e.Time.IsZero() {
+			t.Errorf("expected Time to be set")
+		}
+	})
+
+	bus.Publish(context.Background(), Event{Type: MigrationApplied, MigrationID: "m1"})
+	bus.Publish(context.Background(), Event{Type: JobQueued})
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: MigrationApplied, MigrationID: "m2"})
+
+	want := []string{"applied:m1", "all:migration.applied", "all:job.queued", "applied:m2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBus_HandlerPanic(t *testing.T) {
+	bus := NewBus()
+	var recovered interface{}
+	bus.OnHandlerPanic(func(_ Event, r interface{}) { recovered = r })
+	delivered := false
+	bus.Subscribe(All, func(context.Context, Event) { panic("boom") })
+	bus.Subscribe(All, func(context.Context, Event) { delivered = true })
+
+	bus.Publish(context.Background(), Event{Type: MigrationFailed})
+	if recovered != "boom" {
+		t.Errorf("recovered = %v, want boom", recovered)
+	}
+	if !delivered {
+		t.Errorf("expected later subscribers to still receive the event")
+	}
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), Event{Type: MigrationApplied})
+}
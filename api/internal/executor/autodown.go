@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+)
+
+// autoDownHeader marks generated down scripts so they are recognizable in plan and detail output
+const autoDownHeader = "-- bfm: auto-generated down migration; review before relying on it"
+
+// autoDownBackends are backends whose up scripts can be reversed by generateDownSQL
+var autoDownBackends = map[string]bool{
+	"postgresql": true,
+}
+
+// Identifiers may be quoted, schema-qualified or contain template variables ({{.Schema}}.users)
+const autoDownIdent = `((?:"[^"]+"|[^\s(),;"]+)(?:\.(?:"[^"]+"|[^\s(),;"]+))*)`
+
+var (
+	autoDownCreateTableRe = regexp.MustCompile(`(?is)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + autoDownIdent + `\s*\(`)
+	autoDownCreateIndexRe = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + autoDownIdent + `\s+ON\s+(?:ONLY\s+)?` + autoDownIdent)
+	autoDownAlterTableRe  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + autoDownIdent + `\s+(.+)$`)
+	autoDownAddColumnRe   = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + autoDownIdent + `\s+\S`)
+	autoDownConstraintRe  = regexp.MustCompile(`(?i)^ADD\s+(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)\b`)
+)
+
+// generateDownSQL derives a down script for an up script made only of trivially reversible
+// statements: CREATE TABLE, ALTER TABLE ... ADD COLUMN and named CREATE INDEX. The reverse
+// statements are emitted in reverse order. It returns false when any statement is something
+// else, since a partial down script is worse than none.
+func generateDownSQL(upSQL string) (string, bool) {
+	statements := postgresql.SplitStatements(upSQL)
+	if len(statements) == 0 {
+		return "", false
+	}
+
+	down := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		reverse, ok := reverseStatement(postgresql.TrimLeadingComments(stmt))
+		if !ok {
+			return "", false
+		}
+		down = append(down, reverse)
+	}
+
+	var b strings.Builder
+	b.WriteString(autoDownHeader)
+	b.WriteString("\n")
+	if !backends.IsTransactional(upSQL) {
+		b.WriteString("-- bfm:no-transaction\n")
+	}
+	for i := len(down) - 1; i >= 0; i-- {
+		b.WriteString(down[i])
+		b.WriteString(";\n")
+	}
+	return b.String(), true
+}
+
+// reverseStatement returns the statement undoing a single reversible up statement
+func reverseStatement(stmt string) (string, bool) {
+	if m := autoDownCreateTableRe.FindStringSubmatch(stmt); m != nil {
+		return "DROP TABLE IF EXISTS " + m[1], true
+	}
+
+	if m := autoDownCreateIndexRe.FindStringSubmatch(stmt); m != nil {
+		index, table := m[2], m[3]
+		// An unqualified index lives in its table's schema
+		if !strings.Contains(index, ".") {
+			if dot := strings.LastIndex(table, "."); dot > 0 {
+				index = table[:dot] + "." + index
+			}
+		}
+		concurrently := ""
+		if m[1] != "" {
+			concurrently = "CONCURRENTLY "
+		}
+		return "DROP INDEX " + concurrently + "IF EXISTS " + index, true
+	}
+
+	if m := autoDownAlterTableRe.FindStringSubmatch(stmt); m != nil {
+		var drops []string
+		for _, action := range splitTopLevel(m[2], ',') {
+			action = postgresql.TrimLeadingComments(action)
+			if autoDownConstraintRe.MatchString(action) {
+				return "", false
+			}
+			column := autoDownAddColumnRe.FindStringSubmatch(action)
+			if column == nil {
+				return "", false
+			}
+			drops = append(drops, "DROP COLUMN IF EXISTS "+column[1])
+		}
+		return "ALTER TABLE " + m[1] + " " + strings.Join(drops, ", "), true
+	}
+
+	return "", false
+}
+
+// splitTopLevel splits s on sep outside of parentheses and quotes
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"testing"
+)
+
+func TestGenerateDownSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		up   string
+		want string
+	}{
+		{
+			name: "create table",
+			up:   "-- bfm-tags: env=prod\nCREATE TABLE IF NOT EXISTS {{.Schema}}.users (\n  id SERIAL PRIMARY KEY,\n  note TEXT DEFAULT 'a;b'\n);",
+			want: autoDownHeader + "\nDROP TABLE IF EXISTS {{.Schema}}.users;\n",
+		},
+		{
+			name: "statements are reversed",
+			up: `CREATE TABLE "Orders" (id INT);
+ALTER TABLE "Orders" ADD COLUMN total NUMERIC(10, 2) NOT NULL DEFAULT 0, ADD IF NOT EXISTS status TEXT;
+CREATE UNIQUE INDEX CONCURRENTLY idx_orders_status ON app."Orders" (status, total);`,
+			want: autoDownHeader + `
+DROP INDEX CONCURRENTLY IF EXISTS app.idx_orders_status;
+ALTER TABLE "Orders" DROP COLUMN IF EXISTS total, DROP COLUMN IF EXISTS status;
+DROP TABLE IF EXISTS "Orders";
+`,
+		},
+		{
+			name: "comments before statements and columns",
+			up:   "/* users; accounts */\nCREATE TABLE users (id INT);\n-- contact details\nALTER TABLE users ADD COLUMN email TEXT, -- unique later\n  ADD COLUMN phone TEXT;",
+			want: autoDownHeader + "\nALTER TABLE users DROP COLUMN IF EXISTS email, DROP COLUMN IF EXISTS phone;\nDROP TABLE IF EXISTS users;\n",
+		},
+		{
+			name: "no-transaction directive is kept",
+			up:   "-- bfm:no-transaction\nCREATE INDEX CONCURRENTLY idx_users_email ON users (email);",
+			want: autoDownHeader + "\n-- bfm:no-transaction\nDROP INDEX CONCURRENTLY IF EXISTS idx_users_email;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := generateDownSQL(tt.up)
+			if !ok {
+				t.Fatalf("generateDownSQL() not reversible")
+			}
+			if got != tt.want {
+				t.Errorf("generateDownSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateDownSQL_NotReversible(t *testing.T) {
+	for _, up := range []string{
+		"",
+		"-- only a comment",
+		"CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);",
+		"ALTER TABLE t ADD CONSTRAINT t_pk PRIMARY KEY (id);",
+		"ALTER TABLE t ADD COLUMN c INT, DROP COLUMN d;",
+		"CREATE INDEX ON t (id);",
+		"CREATE TABLE t AS SELECT 1;",
+	} {
+		if got, ok := generateDownSQL(up); ok {
+			t.Errorf("generateDownSQL(%q) = %q, want not reversible", up, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// Connection settings of backups before destructive migrations (see backends.IsDestructive). Backups
+// are taken when either is set.
+const (
+	// ExtraBackupDir is the directory the backend's backups are written to ({CONNECTION}_BACKUP_DIR)
+	ExtraBackupDir = "BACKUP_DIR"
+	// ExtraSnapshotCommand is run with sh -c instead of the backend's backup
+	// ({CONNECTION}_SNAPSHOT_COMMAND); the last line it prints is the location of the artifact
+	ExtraSnapshotCommand = "SNAPSHOT_COMMAND"
+)
+
+// backupsEnabled reports whether a connection backs up destructive migrations before they run
+func backupsEnabled(cfg *backends.ConnectionConfig) bool {
+	return cfg.ExtraValue(ExtraBackupDir) != "" || cfg.ExtraValue(ExtraSnapshotCommand) != ""
+}
+
+// backupIfDestructive backs up what migration, rendered for schema and tracked as migrationID, is
+// about to discard when it is destructive and the connection takes backups, and returns the
+// location of the artifact ("" when no backup was taken). backend must be connected to cfg.
+func backupIfDestructive(ctx context.Context, backend backends.Backend, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID string) (string, error) {
+	if !backupsEnabled(cfg) || !migration.IsDestructive() {
+		return "", nil
+	}
+
+	dir := cfg.ExtraValue(ExtraBackupDir)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return "", fmt.Errorf("backup: %w", err)
+		}
+	}
+
+	var location string
+	var err error
+	if command := cfg.ExtraValue(ExtraSnapshotCommand); command != "" {
+		location, err = runSnapshotCommand(ctx, command, dir, cfg, migration, migrationID)
+	} else if snapshotter, ok := backend.(backends.Snapshotter); ok {
+		location, err = snapshotter.Snapshot(ctx, migration, dir)
+	} else {
+		err = fmt.Errorf("backend %s cannot take backups, set %s", migration.Backend, ExtraSnapshotCommand)
+	}
+	if err != nil {
+		return "", fmt.Errorf("backup: %w", err)
+	}
+	logger.Infof("Backed up %s before its destructive statements to %s", migrationID, location)
+	return location, nil
+}
+
+// runSnapshotCommand runs command with the migration in the environment of hooks and
+// BFM_BACKUP_DIR, and returns the last line it prints
+func runSnapshotCommand(ctx context.Context, command, dir string, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(migrationEnv(cfg, migration, migrationID, migration.Schema), "BFM_BACKUP_DIR="+dir)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("snapshot command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	location := strings.TrimSpace(lines[len(lines)-1])
+	if location == "" {
+		return "", fmt.Errorf("snapshot command printed no artifact location")
+	}
+	return location, nil
+}
+
+// listBackup logs that a dry run of migration skips the backup a real run would take
+func listBackup(cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID string) {
+	if backupsEnabled(cfg) && migration.IsDestructive() {
+		logger.Infof("Dry run: would back up %s before its destructive statements", migrationID)
+	}
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// snapshottingBackend records the migrations it backs up, failing with snapshotError
+type snapshottingBackend struct {
+	*mockBackend
+	snapshots     []string
+	snapshotError error
+}
+
+func (b *snapshottingBackend) Snapshot(ctx context.Context, migration *backends.MigrationScript, dir string) (string, error) {
+	if b.snapshotError != nil {
+		return "", b.snapshotError
+	}
+	location := filepath.Join(dir, migration.Name+".dump")
+	b.snapshots = append(b.snapshots, location)
+	return location, nil
+}
+
+func TestExecutor_BackupBeforeDestructive(t *testing.T) {
+	dir := t.TempDir()
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost", Extra: map[string]string{ExtraBackupDir: dir}},
+	})
+	backend := &snapshottingBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", backend)
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);",
+	})
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "drop_legacy", Connection: "test", Backend: "postgresql",
+		UpSQL: "DROP TABLE legacy_users;",
+	})
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// Dry runs do not back up
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", true, false); err != nil || len(backend.snapshots) != 0 {
+		t.Fatalf("dry run backed up %v (err %v)", backend.snapshots, err)
+	}
+
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success || len(result.Applied) != 2 {
+		t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+	}
+	if want := filepath.Join(dir, "drop_legacy.dump"); len(backend.snapshots) != 1 || backend.snapshots[0] != want {
+		t.Fatalf("expected only the destructive migration to be backed up to %s, got %v", want, backend.snapshots)
+	}
+	record := lastRecord(tracker)
+	var execCtx map[string]interface{}
+	_ = json.Unmarshal([]byte(record.ExecutionContext), &execCtx)
+	if execCtx["backup"] != backend.snapshots[0] {
+		t.Errorf("expected the backup location in the history record, got %q", record.ExecutionContext)
+	}
+	for _, r := range tracker.History() {
+		if strings.Contains(r.MigrationID, "create_users") && strings.Contains(r.ExecutionContext, "backup") {
+			t.Errorf("expected no backup for a non-destructive migration, got %q", r.ExecutionContext)
+		}
+	}
+}
+
+func TestExecutor_BackupBeforeDestructive_Failures(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost", Extra: map[string]string{ExtraBackupDir: t.TempDir()}},
+	})
+	backend := &snapshottingBackend{mockBackend: newMockBackend("postgresql"), snapshotError: errors.New("pg_dump failed")}
+	exec.RegisterBackend("postgresql", backend)
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "drop_legacy", Connection: "test", Backend: "postgresql",
+		UpSQL: "TRUNCATE legacy_users;",
+	})
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// A failed backup fails the migration before it runs
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "backup: pg_dump failed") || backend.executeCalled {
+		t.Fatalf("expected the failed backup to stop the migration, got %+v", result)
+	}
+
+	// A snapshot command replaces the backend's backup; the last line it prints is the artifact
+	exec.connections["test"].Extra = map[string]string{
+		ExtraSnapshotCommand: `echo "snapshotting $BFM_MIGRATION_ID"; echo "s3://backups/$BFM_VERSION.tar"`,
+	}
+	result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+	}
+	if record := lastRecord(tracker); !strings.Contains(record.ExecutionContext, `"backup":"s3://backups/20240102120000.tar"`) {
+		t.Errorf("expected the snapshot command's artifact in the history record, got %q", record.ExecutionContext)
+	}
+
+	// Backends that cannot back up need a snapshot command
+	exec.connections["test"].Extra = map[string]string{ExtraBackupDir: t.TempDir()}
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	_ = tracker.DeleteMigration(context.Background(), "20240102120000_drop_legacy_postgresql_test")
+	result, _ = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "cannot take backups") {
+		t.Errorf("expected a backend without backups to be refused, got %+v", result)
+	}
+}
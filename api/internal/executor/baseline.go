@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// BaselineExecutionMethod is the execution method of history entries written by Baseline
+const BaselineExecutionMethod = "baseline"
+
+// BaselineResult is the outcome of a baseline
+type BaselineResult struct {
+	Baselined []string // Migrations marked as applied
+	Skipped   []string // Already applied, or dynamic-schema migrations (no schema to record them under)
+}
+
+// Baseline marks every migration of a connection up to and including version as applied without
+// executing it, for databases that already have the schema when they adopt bfm. History entries
+// are recorded with execution method "baseline" and the baseline version in their execution context.
+func (e *Executor) Baseline(ctx context.Context, connection, version string) (*BaselineResult, error) {
+	if !validMigrationVersion(version) {
+		return nil, fmt.Errorf("invalid version %q (expected 14-digit YYYYMMDDHHMMSS timestamp)", version)
+	}
+
+	migrations := e.registry.GetByConnection(connection)
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations registered for connection %s", connection)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	result := &BaselineResult{Baselined: []string{}, Skipped: []string{}}
+	err := e.withConnectionLock(ctx, connection, func() error {
+		executedBy, _, executionContext := GetExecutionContext(ctx)
+		executionContext = withExecutionContextValue(executionContext, "baseline_version", version)
+
+		for _, migration := range migrations {
+			if migration.Version > version {
+				continue
+			}
+			migrationID := e.executionMigrationID(migration, "")
+			if migrationID == "" {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (dynamic schema)", e.getMigrationID(migration)))
+				continue
+			}
+
+			applied, err := e.stateTracker.IsMigrationApplied(ctx, migrationID)
+			if err != nil {
+				return fmt.Errorf("failed to check migration status for %s: %w", migrationID, err)
+			}
+			if applied {
+				result.Skipped = append(result.Skipped, migrationID)
+				continue
+			}
+
+			record := &state.MigrationRecord{
+				MigrationID:      migrationID,
+				Schema:           migration.Schema,
+				Version:          migration.Version,
+				Connection:       migration.Connection,
+				Backend:          migration.Backend,
+				Status:           "success",
+				AppliedAt:        time.Now().Format(time.RFC3339),
+				ExecutedBy:       executedBy,
+				ExecutionMethod:  BaselineExecutionMethod,
+				ExecutionContext: executionContext,
+				Checksum:         migration.Checksum(),
+			}
+			if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
+				return fmt.Errorf("failed to record baseline for %s: %w", migrationID, err)
+			}
+			logger.Infof("Baselined migration %s (not executed)", migrationID)
+			result.Baselined = append(result.Baselined, migrationID)
+
+			e.events.Publish(ctx, events.Event{
+				Type:        events.MigrationApplied,
+				MigrationID: migrationID,
+				Connection:  migration.Connection,
+				Schema:      migration.Schema,
+				Data:        map[string]interface{}{"baseline": true},
+			})
+		}
+		return nil
+	})
+	return result, err
+}
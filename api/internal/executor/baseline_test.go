@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestExecutor_Baseline(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Version: "20240102120000", Name: "tenant_tables", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE t (id INT);",
+	})
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240103120000", Name: "create_orders", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE orders (id INT);",
+	})
+
+	result, err := exec.Baseline(context.Background(), "test", "20240102120000")
+	if err != nil {
+		t.Fatalf("Baseline() error = %v", err)
+	}
+	if want := []string{"20240101120000_create_users_postgresql_test"}; !reflect.DeepEqual(result.Baselined, want) {
+		t.Errorf("Baselined = %v, want %v", result.Baselined, want)
+	}
+	if want := []string{"20240102120000_tenant_tables_postgresql_test (dynamic schema)"}; !reflect.DeepEqual(result.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", result.Skipped, want)
+	}
+	if backend.executeCalled {
+		t.Errorf("baseline must not execute migrations")
+	}
+
+	if len(tracker.History()) != 1 {
+		t.Fatalf("expected one history entry, got %d", len(tracker.History()))
+	}
+	record := tracker.History()[0]
+	if record.Status != "applied" || record.ExecutionMethod != BaselineExecutionMethod || record.Checksum == "" {
+		t.Errorf("unexpected baseline record %+v", record)
+	}
+	if !strings.Contains(record.ExecutionContext, `"baseline_version":"20240102120000"`) {
+		t.Errorf("expected the baseline version in the execution context, got %s", record.ExecutionContext)
+	}
+
+	// Already baselined migrations are skipped
+	result, err = exec.Baseline(context.Background(), "test", "20240101120000")
+	if err != nil || len(result.Baselined) != 0 || len(result.Skipped) != 1 {
+		t.Errorf("second Baseline() = %+v, %v", result, err)
+	}
+}
+
+func TestExecutor_Baseline_Errors(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	if _, err := exec.Baseline(context.Background(), "test", "2024"); err == nil {
+		t.Errorf("expected an invalid version error")
+	}
+	if _, err := exec.Baseline(context.Background(), "other", "20240101120000"); err == nil {
+		t.Errorf("expected an error for a connection without migrations")
+	}
+}
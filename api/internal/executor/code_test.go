@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// codeBackend runs code migrations without a transaction
+type codeBackend struct {
+	*mockBackend
+	ran []*backends.MigrationScript
+}
+
+func (b *codeBackend) ExecuteMigrationFunc(ctx context.Context, migration *backends.MigrationScript) error {
+	b.ran = append(b.ran, migration)
+	return migration.UpFunc(ctx, nil)
+}
+
+func TestExecutor_CodeMigrations(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	backend := &codeBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", backend)
+
+	var calls []string
+	migration := &backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "backfill_users", Connection: "test", Backend: "postgresql",
+		UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+			calls = append(calls, "up")
+			return nil
+		},
+		DownFunc: func(ctx context.Context, tx *sql.Tx) error {
+			calls = append(calls, "down")
+			return nil
+		},
+	}
+	_ = reg.Register(migration)
+	id := exec.getMigrationID(migration)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success || len(result.Applied) != 1 {
+		t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+	}
+	if backend.executeCalled || len(backend.ran) != 1 || backend.ran[0].Schema != "public" {
+		t.Fatalf("expected the up function to run instead of a script, got %+v", backend.ran)
+	}
+	record := lastRecord(tracker)
+	if record.MigrationID != id || record.Status != "applied" || record.Checksum != migration.Checksum() {
+		t.Errorf("expected the code migration to be tracked like a script, got %+v", record)
+	}
+
+	tracker.MarkApplied(exec.getMigrationIDWithSchema(migration, "public"))
+	if result, err := exec.ExecuteDown(context.Background(), id, nil, false, false); err != nil || !result.Success {
+		t.Fatalf("ExecuteDown() = %+v, %v", result, err)
+	}
+	if strings.Join(calls, ",") != "up,down" || !backend.ran[1].Reverts {
+		t.Errorf("expected the down function to revert the migration, got %v", calls)
+	}
+
+	// Backends that cannot run code refuse code migrations
+	exec = NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], backends.ErrCodeMigrationUnsupported.Error()) {
+		t.Errorf("expected ErrCodeMigrationUnsupported, got %+v", result)
+	}
+	if !errors.Is(executeCode(context.Background(), newMockBackend("etcd"), migration), backends.ErrCodeMigrationUnsupported) {
+		t.Errorf("expected executeCode to wrap ErrCodeMigrationUnsupported")
+	}
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// MigrationConflict is a migration the loader refused because a registered migration of its
+// connection has the same version or name (see registry.ConflictError). The registered migration
+// keeps running; the refused one is not loaded until the conflict is resolved.
+type MigrationConflict struct {
+	Kind        string // registry.ConflictVersion or registry.ConflictName
+	Connection  string
+	Backend     string
+	Value       string // The shared version or name
+	MigrationID string // The refused migration
+	ExistingID  string // The registered migration it conflicts with
+	Path        string // Up script of the refused migration
+	Message     string
+}
+
+// newMigrationConflict describes the conflict of the migration loaded from path
+func newMigrationConflict(conflict *registry.ConflictError, path string) *MigrationConflict {
+	id := func(m *backends.MigrationScript) string {
+		return fmt.Sprintf("%s_%s_%s_%s", m.Version, m.Name, m.Backend, m.Connection)
+	}
+	value := conflict.Migration.Version
+	if conflict.Kind == registry.ConflictName {
+		value = conflict.Migration.Name
+	}
+	return &MigrationConflict{
+		Kind:        conflict.Kind,
+		Connection:  conflict.Migration.Connection,
+		Backend:     conflict.Migration.Backend,
+		Value:       value,
+		MigrationID: id(conflict.Migration),
+		ExistingID:  id(conflict.Existing),
+		Path:        path,
+		Message:     conflict.Error(),
+	}
+}
+
+// MigrationConflicts returns the migrations the loader refused because they conflict with
+// registered ones, sorted by connection and migration ID
+func (e *Executor) MigrationConflicts() []*MigrationConflict {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*MigrationConflict{}, e.conflicts...)
+}
+
+// setMigrationConflicts replaces the conflicts reported by MigrationConflicts
+func (e *Executor) setMigrationConflicts(conflicts []*MigrationConflict) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conflicts = conflicts
+}
+
+// recordConflict keeps the conflict of the migration migrationID loaded from path, when err is one
+func (l *Loader) recordConflict(migrationID, path string, err error) {
+	var conflict *registry.ConflictError
+	if !errors.As(err, &conflict) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.conflicts[migrationID] = newMigrationConflict(conflict, path)
+}
+
+// Conflicts returns the migrations the loader refused because they conflict with registered ones,
+// sorted by connection and migration ID
+func (l *Loader) Conflicts() []*MigrationConflict {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	conflicts := make([]*MigrationConflict, 0, len(l.conflicts))
+	for _, conflict := range l.conflicts {
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Connection != conflicts[j].Connection {
+			return conflicts[i].Connection < conflicts[j].Connection
+		}
+		return conflicts[i].MigrationID < conflicts[j].MigrationID
+	})
+	return conflicts
+}
+
+// publishConflicts hands the conflicts to the executor, and logs all of them when report is set
+func (l *Loader) publishConflicts(report bool) {
+	conflicts := l.Conflicts()
+	if report && len(conflicts) > 0 {
+		logger.Errorf("%d migration(s) were not loaded because they conflict with another migration of their connection:", len(conflicts))
+		for _, conflict := range conflicts {
+			logger.Errorf("  - %s (%s)", conflict.Message, conflict.Path)
+		}
+	}
+	l.mu.RLock()
+	exec := l.executor
+	l.mu.RUnlock()
+	if exec != nil {
+		exec.setMigrationConflicts(conflicts)
+	}
+}
@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestLoader_MigrationConflicts(t *testing.T) {
+	root := t.TempDir()
+	write := func(base string) {
+		writeTestFile(t, filepath.Join(root, "postgresql", "core", base+".up.sql"), "SELECT 1;")
+		writeTestFile(t, filepath.Join(root, "postgresql", "core", base+".down.sql"), "SELECT 1;")
+	}
+	write("20240101120000_create_users")
+	write("20240101120000_create_accounts") // Same version
+	write("20240201120000_create_orders")
+	write("20240301120000_create_orders") // Same name
+	writeTestFile(t, filepath.Join(root, "postgresql", "billing", "20240101120000_create_users.up.sql"), "SELECT 1;")
+	writeTestFile(t, filepath.Join(root, "postgresql", "billing", "20240101120000_create_users.down.sql"), "SELECT 1;")
+
+	reg := registry.NewInMemoryRegistry()
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	loader := NewLoader(root)
+	loader.SetExecutor(exec)
+	loader.SetSource(SourceScripts)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if got := len(reg.GetAll()); got != 3 {
+		t.Fatalf("expected 3 migrations loaded, the conflicting ones left out, got %d", got)
+	}
+
+	conflicts := exec.MigrationConflicts()
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", conflicts)
+	}
+	// Which migration of a conflicting pair is loaded depends on the scan order
+	var nameConflict *MigrationConflict
+	for _, conflict := range conflicts {
+		if conflict.Connection != "core" || conflict.ExistingID == "" {
+			t.Errorf("unexpected conflict %+v", conflict)
+		}
+		switch conflict.Kind {
+		case registry.ConflictVersion:
+			if conflict.Value != "20240101120000" {
+				t.Errorf("unexpected version conflict %+v", conflict)
+			}
+		case registry.ConflictName:
+			nameConflict = conflict
+			if conflict.Value != "create_orders" {
+				t.Errorf("unexpected name conflict %+v", conflict)
+			}
+		}
+	}
+	if nameConflict == nil {
+		t.Fatalf("expected a name conflict, got %+v", conflicts)
+	}
+
+	// Deleting a conflicting migration resolves its conflict at the next scan
+	for _, ext := range []string{".go", ".up.sql", ".down.sql"} {
+		_ = os.Remove(strings.TrimSuffix(nameConflict.Path, ".up.sql") + ext)
+	}
+	if err := loader.scanAndLoad(); err != nil {
+		t.Fatalf("scanAndLoad() error = %v", err)
+	}
+	if conflicts := exec.MigrationConflicts(); len(conflicts) != 1 || conflicts[0].Kind != registry.ConflictVersion {
+		t.Errorf("expected the version conflict to remain, got %+v", conflicts)
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/secrets"
+)
+
+// ConnectionsSource loads the configured connections, for reloads
+type ConnectionsSource func() (map[string]*backends.ConnectionConfig, error)
+
+// ErrNoConnectionsSource is returned when reloading connections without a source
+var ErrNoConnectionsSource = errors.New("connections cannot be reloaded: no connections source")
+
+// ConnectionsReload lists the connections a reload added, removed and changed, by name
+type ConnectionsReload struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// SetConnectionsSource sets where ReloadConnections loads connections from
+func (e *Executor) SetConnectionsSource(source ConnectionsSource) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.connectionsSource = source
+}
+
+// SetSecrets sets the resolver of the secret references of connections, such as a password of
+// "vault:kv/data/bfm/core#password". Without one, references are used as they are.
+func (e *Executor) SetSecrets(resolver *secrets.Resolver) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.secrets = resolver
+}
+
+// ReloadConnections replaces the connections with those of the connections source, so a new
+// tenant database can be migrated without restarting. Executions in flight keep the connection
+// settings they started with. A source that fails leaves the connections unchanged. Cached secrets
+// are read again, so a reload also picks up rotated passwords.
+func (e *Executor) ReloadConnections(ctx context.Context) (*ConnectionsReload, error) {
+	e.mu.Lock()
+	source := e.connectionsSource
+	e.mu.Unlock()
+	if source == nil {
+		return nil, ErrNoConnectionsSource
+	}
+	connections, err := source()
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	reload := diffConnections(e.connections, connections)
+	resolver := e.secrets
+	e.mu.Unlock()
+	if err := e.SetConnections(connections); err != nil {
+		return nil, err
+	}
+	if resolver != nil {
+		resolver.Flush()
+	}
+
+	logger.Infof("Reloaded connections: %d added, %d removed, %d changed", len(reload.Added), len(reload.Removed), len(reload.Changed))
+	e.events.Publish(ctx, events.Event{
+		Type: events.ConnectionsReloaded,
+		Data: map[string]interface{}{"added": reload.Added, "removed": reload.Removed, "changed": reload.Changed},
+	})
+	return reload, nil
+}
+
+// diffConnections compares the connections before and after a reload
+func diffConnections(before, after map[string]*backends.ConnectionConfig) *ConnectionsReload {
+	reload := &ConnectionsReload{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for name, cfg := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			reload.Added = append(reload.Added, name)
+		case !reflect.DeepEqual(previous, cfg):
+			reload.Changed = append(reload.Changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			reload.Removed = append(reload.Removed, name)
+		}
+	}
+	sort.Strings(reload.Added)
+	sort.Strings(reload.Removed)
+	sort.Strings(reload.Changed)
+	return reload
+}
+
+// WatchConnectionsFile reloads the connections every interval the content of file changed, until
+// ctx is done. Reload failures are logged, and retried at the next change.
+func (e *Executor) WatchConnectionsFile(ctx context.Context, file string, interval time.Duration) {
+	fingerprint := func() [sha256.Size]byte {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return [sha256.Size]byte{}
+		}
+		return sha256.Sum256(data)
+	}
+
+	last := fingerprint()
+	logger.Infof("Watching connections file %s (checking every %v)", file, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := fingerprint()
+			if current == last {
+				continue
+			}
+			last = current
+			if _, err := e.ReloadConnections(ctx); err != nil {
+				logger.Warnf("Failed to reload connections from %s: %v", file, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/secrets"
+)
+
+// fakeSecrets is a secrets provider counting its reads
+type fakeSecrets struct {
+	values map[string]string
+	reads  int
+}
+
+func (f *fakeSecrets) Get(ctx context.Context, path, key string) (string, time.Duration, error) {
+	f.reads++
+	value, ok := f.values[path+"#"+key]
+	if !ok {
+		return "", 0, errors.New("secret not found")
+	}
+	return value, 0, nil
+}
+
+func TestExecutor_ConnectionSecrets(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	exec.connections["test"].Password = "vault:kv/data/bfm/core#password"
+	provider := &fakeSecrets{values: map[string]string{"kv/data/bfm/core#password": "s3cr3t"}}
+	resolver := secrets.NewResolver(time.Minute)
+	resolver.Register(secrets.ProviderVault, provider)
+	exec.SetSecrets(resolver)
+
+	cfg, err := exec.GetConnectionConfig(context.Background(), "test")
+	if err != nil || cfg.Password != "s3cr3t" || exec.connections["test"].Password != "vault:kv/data/bfm/core#password" {
+		t.Fatalf("GetConnectionConfig() = %+v, %v", cfg, err)
+	}
+	_, _ = exec.GetConnectionConfig(context.Background(), "test")
+	if provider.reads != 1 {
+		t.Errorf("expected the secret to be cached, got %d reads", provider.reads)
+	}
+
+	// A reload reads the secrets again
+	connections := exec.connections
+	exec.SetConnectionsSource(func() (map[string]*backends.ConnectionConfig, error) { return connections, nil })
+	if _, err := exec.ReloadConnections(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = exec.GetConnectionConfig(context.Background(), "test")
+	if provider.reads != 2 {
+		t.Errorf("expected the reload to flush the cached secret, got %d reads", provider.reads)
+	}
+
+	exec.connections["test"].Password = "vault:kv/data/bfm/other#password"
+	if _, err := exec.GetConnectionConfig(context.Background(), "test"); err == nil {
+		t.Errorf("expected an unresolvable reference to fail")
+	}
+}
+
+func TestExecutor_ReloadConnections(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	if _, err := exec.ReloadConnections(context.Background()); !errors.Is(err, ErrNoConnectionsSource) {
+		t.Fatalf("expected a reload without source to fail, got %v", err)
+	}
+
+	var published []events.Event
+	exec.Events().Subscribe(events.ConnectionsReloaded, func(ctx context.Context, event events.Event) {
+		published = append(published, event)
+	})
+	var sourceErr error
+	exec.SetConnectionsSource(func() (map[string]*backends.ConnectionConfig, error) {
+		if sourceErr != nil {
+			return nil, sourceErr
+		}
+		return map[string]*backends.ConnectionConfig{
+			"test":      {Backend: "postgresql", Host: "replica"},
+			"tenant_42": {Backend: "postgresql", Host: "db-42"},
+		}, nil
+	})
+
+	reload, err := exec.ReloadConnections(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ConnectionsReload{Added: []string{"tenant_42"}, Removed: []string{}, Changed: []string{"test"}}
+	if !reflect.DeepEqual(reload, want) {
+		t.Errorf("ReloadConnections() = %+v, want %+v", reload, want)
+	}
+	if cfg, err := exec.getConnectionConfig(context.Background(), "tenant_42"); err != nil || cfg.Host != "db-42" {
+		t.Errorf("expected the new connection to be usable, got %+v, %v", cfg, err)
+	}
+	if len(published) != 1 {
+		t.Errorf("expected the reload to be published, got %+v", published)
+	}
+
+	// A failing source leaves the connections unchanged
+	sourceErr = errors.New("invalid file")
+	if _, err := exec.ReloadConnections(context.Background()); err == nil {
+		t.Fatal("expected the source error")
+	}
+	if _, err := exec.getConnectionConfig(context.Background(), "tenant_42"); err != nil {
+		t.Errorf("expected the connections to be kept, got %v", err)
+	}
+}
+
+func TestExecutor_WatchConnectionsFile(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	file := filepath.Join(t.TempDir(), "connections.yaml")
+	if err := os.WriteFile(file, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan struct{}, 10)
+	exec.SetConnectionsSource(func() (map[string]*backends.ConnectionConfig, error) {
+		reloads <- struct{}{}
+		return map[string]*backends.ConnectionConfig{}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exec.WatchConnectionsFile(ctx, file, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	if len(reloads) != 0 {
+		t.Fatalf("expected no reload while the file is unchanged")
+	}
+	if err := os.WriteFile(file, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the changed file to be reloaded")
+	}
+}
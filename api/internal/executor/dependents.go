@@ -0,0 +1,219 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// ErrDependentsApplied is returned when a migration cannot be rolled back because migrations that
+// depend on it are still applied
+var ErrDependentsApplied = errors.New("applied migrations depend on this migration")
+
+// dependentsInRollbackOrder returns the registered migrations that depend on migration, directly or
+// transitively, in reverse topological order: every migration comes before the migrations it
+// depends on, which is the order they can safely be rolled back in. Ties are broken by descending
+// version.
+func (e *Executor) dependentsInRollbackOrder(migration *backends.MigrationScript) []*backends.MigrationScript {
+	all := e.registry.GetAll()
+	byID := make(map[string]*backends.MigrationScript, len(all))
+	for _, m := range all {
+		byID[e.getMigrationID(m)] = m
+	}
+
+	// planDependencyEdges maps each migration to its dependencies; invert it
+	edges := e.planDependencyEdges(all)
+	dependents := make(map[string][]string)
+	for from, targets := range edges {
+		for _, to := range targets {
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	// Collect the transitive dependents
+	rootID := e.getMigrationID(migration)
+	reached := make(map[string]bool)
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[id] {
+			if dependent != rootID && !reached[dependent] {
+				reached[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(reached))
+	for id := range reached {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if byID[ids[i]].Version != byID[ids[j]].Version {
+			return byID[ids[i]].Version > byID[ids[j]].Version
+		}
+		return ids[i] > ids[j]
+	})
+
+	// Depth-first: a migration is emitted only once everything depending on it was emitted
+	var ordered []*backends.MigrationScript
+	emitted := make(map[string]bool, len(ids))
+	var visit func(id string)
+	visit = func(id string) {
+		if emitted[id] {
+			return
+		}
+		emitted[id] = true
+		var next []string
+		for _, dependent := range dependents[id] {
+			if reached[dependent] {
+				next = append(next, dependent)
+			}
+		}
+		sort.Slice(next, func(i, j int) bool {
+			if byID[next[i]].Version != byID[next[j]].Version {
+				return byID[next[i]].Version > byID[next[j]].Version
+			}
+			return next[i] > next[j]
+		})
+		for _, dependent := range next {
+			visit(dependent)
+		}
+		ordered = append(ordered, byID[id])
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+	return ordered
+}
+
+// cascadeKey marks a context created by WithCascade
+const cascadeKey contextKey = "bfm_cascade"
+
+// WithCascade marks ctx as rolling back the applied dependents of a migration first, in rollback
+// order, where ExecuteDown and Rollback would otherwise refuse with ErrDependentsApplied
+func WithCascade(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cascadeKey, true)
+}
+
+// cascades reports whether ctx was marked by WithCascade
+func cascades(ctx context.Context) bool {
+	cascade, _ := ctx.Value(cascadeKey).(bool)
+	return cascade
+}
+
+// CascadedRollback is the outcome of rolling back one applied dependent of a migration before the
+// migration itself (see WithCascade)
+type CascadedRollback struct {
+	MigrationID string // Schema-qualified ID of the dependent
+	Success     bool
+	Applied     []string
+	Skipped     []string
+	Errors      []string
+}
+
+// appliedDependent is a dependent of a migration applied in schema, with its schema-qualified ID
+type appliedDependent struct {
+	migration *backends.MigrationScript
+	schema    string
+	id        string
+}
+
+// appliedDependents returns the migrations that depend on migration and are applied in schema, in
+// the order to roll them back in. Dependents with a fixed schema are checked in their own schema.
+func (e *Executor) appliedDependents(ctx context.Context, migration *backends.MigrationScript, schema string) ([]appliedDependent, error) {
+	var applied []appliedDependent
+	for _, dependent := range e.dependentsInRollbackOrder(migration) {
+		dependentSchema := schema
+		if dependent.Schema != "" {
+			dependentSchema = dependent.Schema
+		}
+		dependentID := e.getMigrationIDWithSchema(dependent, dependentSchema)
+		ok, err := e.stateTracker.IsMigrationApplied(ctx, dependentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check dependent migration %s: %w", dependentID, err)
+		}
+		if ok {
+			applied = append(applied, appliedDependent{migration: dependent, schema: dependentSchema, id: dependentID})
+		}
+	}
+	return applied, nil
+}
+
+// checkAppliedDependents returns an error wrapping ErrDependentsApplied when migrations that depend
+// on migration are still applied in schema, listing them in the order to roll them back in
+func (e *Executor) checkAppliedDependents(ctx context.Context, migration *backends.MigrationScript, schema string) error {
+	dependents, err := e.appliedDependents(ctx, migration, schema)
+	if err != nil || len(dependents) == 0 {
+		return err
+	}
+	applied := make([]string, len(dependents))
+	for i, dependent := range dependents {
+		applied[i] = dependent.id
+	}
+	return fmt.Errorf("%w: cannot roll back %s while %s remain applied; roll them back first, in that order",
+		ErrDependentsApplied, e.getMigrationIDWithSchema(migration, schema), strings.Join(applied, ", "))
+}
+
+// rollBackDependents rolls back the applied dependents of migration in each of schemas it is
+// applied in, in rollback order: with down migrations when down is set (only previewed in a dry
+// run), with rollbacks otherwise. It stops at the first dependent that fails and returns an error
+// along with the outcomes so far.
+func (e *Executor) rollBackDependents(ctx context.Context, migration *backends.MigrationScript, schemas []string, down, dryRun bool) ([]CascadedRollback, error) {
+	if len(schemas) == 0 {
+		schemas = []string{migration.Schema}
+	}
+	// Dependents are rolled back in order, so their own dependents are gone by the time they run
+	ctx = context.WithValue(ctx, cascadeKey, false)
+
+	var cascaded []CascadedRollback
+	seen := make(map[string]bool)
+	for _, schema := range schemas {
+		migrationID := e.getMigrationIDWithSchema(migration, schema)
+		if applied, err := e.stateTracker.IsMigrationApplied(ctx, migrationID); err != nil || !applied {
+			continue // Reported by the rollback of the migration itself
+		}
+		dependents, err := e.appliedDependents(ctx, migration, schema)
+		if err != nil {
+			return cascaded, err
+		}
+		for _, dependent := range dependents {
+			if seen[dependent.id] {
+				continue
+			}
+			seen[dependent.id] = true
+
+			var dependentSchemas []string
+			if dependent.schema != "" {
+				dependentSchemas = []string{dependent.schema}
+			}
+			step := CascadedRollback{MigrationID: dependent.id}
+			if down {
+				// A dry run leaves the dependents of the dependent applied
+				result, err := e.ExecuteDown(ctx, e.getMigrationID(dependent.migration), dependentSchemas, dryRun, dryRun)
+				if err != nil {
+					step.Errors = []string{err.Error()}
+				} else {
+					step.Success, step.Applied, step.Skipped, step.Errors = result.Success, result.Applied, result.Skipped, result.Errors
+				}
+			} else {
+				result, err := e.Rollback(ctx, e.getMigrationID(dependent.migration), dependentSchemas)
+				if err != nil {
+					step.Errors = []string{err.Error()}
+				} else {
+					step.Success, step.Applied, step.Skipped, step.Errors = result.Success, result.Applied, result.Skipped, result.Errors
+				}
+			}
+			cascaded = append(cascaded, step)
+			if len(step.Errors) > 0 {
+				return cascaded, fmt.Errorf("cascade stopped: rolling back dependent %s failed, %s was not rolled back", dependent.id, migrationID)
+			}
+		}
+	}
+	return cascaded, nil
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_DownRefusesAppliedDependents(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+
+	// users <- orders <- order_items, and users <- profiles
+	for _, m := range []*backends.MigrationScript{
+		{Version: "20240101120000", Name: "users"},
+		{Version: "20240102120000", Name: "orders", Dependencies: []string{"users"}},
+		{Version: "20240103120000", Name: "order_items", Dependencies: []string{"orders"}},
+		{Version: "20240104120000", Name: "profiles", Dependencies: []string{"users"}},
+	} {
+		m.Schema, m.Connection, m.Backend = "public", "test", "postgresql"
+		m.UpSQL, m.DownSQL = "SELECT 1;", "SELECT 1;"
+		_ = reg.Register(m)
+	}
+	id := func(name string) string {
+		return exec.getMigrationID(reg.GetMigrationByName(name)[0])
+	}
+	// Migrations are tracked per schema
+	schemaID := func(name string) string {
+		return exec.getMigrationIDWithSchema(reg.GetMigrationByName(name)[0], "public")
+	}
+
+	var order []string
+	for _, m := range exec.dependentsInRollbackOrder(reg.GetMigrationByName("users")[0]) {
+		order = append(order, m.Name)
+	}
+	if got := strings.Join(order, ","); got != "profiles,order_items,orders" {
+		t.Fatalf("rollback order = %s, want profiles,order_items,orders", got)
+	}
+
+	for _, name := range []string{"users", "orders", "profiles"} {
+		tracker.MarkApplied(schemaID(name))
+	}
+
+	_, err := exec.ExecuteDown(context.Background(), id("users"), nil, false, false)
+	if !errors.Is(err, ErrDependentsApplied) {
+		t.Fatalf("ExecuteDown() error = %v, want ErrDependentsApplied", err)
+	}
+	if want := schemaID("profiles") + ", " + schemaID("orders") + " remain applied"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not list %q", err, want)
+	}
+	if _, err := exec.Rollback(context.Background(), id("users"), nil); !errors.Is(err, ErrDependentsApplied) {
+		t.Errorf("Rollback() error = %v, want ErrDependentsApplied", err)
+	}
+
+	// Leaf migrations and explicitly ignored dependencies are not blocked
+	if _, err := exec.ExecuteDown(context.Background(), id("profiles"), nil, false, false); err != nil {
+		t.Errorf("ExecuteDown(leaf) error = %v", err)
+	}
+	result, err := exec.ExecuteDown(context.Background(), id("users"), nil, false, true)
+	if err != nil || !result.Success {
+		t.Errorf("ExecuteDown(ignore dependencies) = %+v, %v", result, err)
+	}
+}
+
+func TestExecutor_DownCascadesToAppliedDependents(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+
+	// users <- orders <- order_items, and users <- profiles
+	for _, m := range []*backends.MigrationScript{
+		{Version: "20240101120000", Name: "users"},
+		{Version: "20240102120000", Name: "orders", Dependencies: []string{"users"}},
+		{Version: "20240103120000", Name: "order_items", Dependencies: []string{"orders"}},
+		{Version: "20240104120000", Name: "profiles", Dependencies: []string{"users"}},
+	} {
+		m.Schema, m.Connection, m.Backend = "public", "test", "postgresql"
+		m.UpSQL, m.DownSQL = "SELECT 1;", "SELECT 1;"
+		_ = reg.Register(m)
+	}
+	id := func(name string) string {
+		return exec.getMigrationID(reg.GetMigrationByName(name)[0])
+	}
+	// Migrations are tracked per schema
+	schemaID := func(name string) string {
+		return exec.getMigrationIDWithSchema(reg.GetMigrationByName(name)[0], "public")
+	}
+	for _, name := range []string{"users", "orders", "profiles"} {
+		tracker.MarkApplied(schemaID(name))
+	}
+	ctx := WithCascade(context.Background())
+
+	// A dry run previews the cascade and leaves everything applied
+	result, err := exec.ExecuteDown(ctx, id("users"), nil, true, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteDown(dry run) = %+v, %v", result, err)
+	}
+	if len(result.Cascaded) != 2 || result.Cascaded[0].MigrationID != schemaID("profiles") || result.Cascaded[1].MigrationID != schemaID("orders") {
+		t.Fatalf("dry run cascade = %+v, want profiles then orders", result.Cascaded)
+	}
+	if !isApplied(tracker, schemaID("profiles")) || !isApplied(tracker, schemaID("users")) {
+		t.Fatalf("expected a dry run to leave the migrations applied")
+	}
+
+	result, err = exec.ExecuteDown(ctx, id("users"), nil, false, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteDown(cascade) = %+v, %v", result, err)
+	}
+	if len(result.Cascaded) != 2 || !result.Cascaded[0].Success || !result.Cascaded[1].Success {
+		t.Fatalf("cascade = %+v, want two successful rollbacks", result.Cascaded)
+	}
+	for _, name := range []string{"users", "orders", "profiles"} {
+		if isApplied(tracker, schemaID(name)) {
+			t.Errorf("expected %s to be rolled back", name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// Destructive policies: what an execution needs to apply a migration that discards data (see
+// backends.DestructiveStatements)
+const (
+	DestructiveAllow    = "allow"    // Destructive migrations run like any other (default)
+	DestructiveForce    = "force"    // The execution must be forced (WithAllowDestructive)
+	DestructiveApproval = "approval" // The execution must run an approved plan (WithPinnedChecksums)
+	DestructiveBlock    = "block"    // Destructive migrations are refused
+)
+
+// ExtraDestructivePolicy overrides the destructive policy of a connection
+// ({CONNECTION}_DESTRUCTIVE_POLICY), e.g. block on production and allow on staging
+const ExtraDestructivePolicy = "DESTRUCTIVE_POLICY"
+
+// destructivePolicyValidator names the destructive policy in validator findings
+const destructivePolicyValidator = "destructive_policy"
+
+const (
+	allowDestructiveKey contextKey = "bfm_allow_destructive"
+	planningKey         contextKey = "bfm_planning"
+)
+
+// ParseDestructivePolicy parses a destructive policy; "" is DestructiveAllow
+func ParseDestructivePolicy(value string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(value)); policy {
+	case "":
+		return DestructiveAllow, nil
+	case DestructiveAllow, DestructiveForce, DestructiveApproval, DestructiveBlock:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown destructive policy %q (allow, force, approval or block)", value)
+	}
+}
+
+// SetDestructivePolicy sets the destructive policy of connections without one of their own
+func (e *Executor) SetDestructivePolicy(policy string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.destructivePolicy = policy
+}
+
+// WithAllowDestructive marks ctx as forcing destructive migrations on connections whose policy is
+// DestructiveForce
+func WithAllowDestructive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowDestructiveKey, true)
+}
+
+// allowsDestructive reports whether ctx was marked by WithAllowDestructive
+func allowsDestructive(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowDestructiveKey).(bool)
+	return allowed
+}
+
+// withPlanning marks ctx as resolving a plan rather than executing it, so that the policies a
+// forced or approved execution satisfies are reported as warnings
+func withPlanning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, planningKey, true)
+}
+
+func isPlanning(ctx context.Context) bool {
+	planning, _ := ctx.Value(planningKey).(bool)
+	return planning
+}
+
+// destructivePolicyOf returns the destructive policy of a connection. An invalid override is
+// treated as DestructiveBlock, so a typo does not let destructive migrations through.
+func (e *Executor) destructivePolicyOf(connection string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if value := e.connections[connection].ExtraValue(ExtraDestructivePolicy); value != "" {
+		policy, err := ParseDestructivePolicy(value)
+		if err != nil {
+			return DestructiveBlock
+		}
+		return policy
+	}
+	if e.destructivePolicy == "" {
+		return DestructiveAllow
+	}
+	return e.destructivePolicy
+}
+
+// enforcesDestructivePolicy reports whether any connection has a destructive policy other than
+// DestructiveAllow
+func (e *Executor) enforcesDestructivePolicy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.destructivePolicy != "" && e.destructivePolicy != DestructiveAllow {
+		return true
+	}
+	for _, cfg := range e.connections {
+		if policy := cfg.ExtraValue(ExtraDestructivePolicy); policy != "" && !strings.EqualFold(policy, DestructiveAllow) {
+			return true
+		}
+	}
+	return false
+}
+
+// validates reports whether migrations are checked before they are applied
+func (e *Executor) validates() bool {
+	return len(e.registeredValidators()) > 0 || e.enforcesDestructivePolicy()
+}
+
+// checkDestructivePolicy returns the finding of the connection's destructive policy for a
+// migration that would be applied to schemaName ("" for its own schema), none when it is safe or the execution satisfies the
+// policy
+func (e *Executor) checkDestructivePolicy(ctx context.Context, migration *backends.MigrationScript, schemaName string) []ValidatorFinding {
+	kinds := migration.DestructiveStatements()
+	if len(kinds) == 0 {
+		return nil
+	}
+	what := "destructive (" + strings.Join(kinds, ", ") + ")"
+
+	var message string
+	switch e.destructivePolicyOf(migration.Connection) {
+	case DestructiveBlock:
+		return []ValidatorFinding{{
+			Validator: destructivePolicyValidator, Severity: FindingError,
+			Message: fmt.Sprintf("%s migrations are blocked on connection %s", what, migration.Connection),
+		}}
+	case DestructiveForce:
+		if allowsDestructive(ctx) {
+			return nil
+		}
+		message = fmt.Sprintf("%s migrations on connection %s must be forced with allow_destructive", what, migration.Connection)
+	case DestructiveApproval:
+		if _, approved := PinnedChecksums(ctx)[e.executionMigrationID(migration, schemaName)]; approved {
+			return nil
+		}
+		message = fmt.Sprintf("%s migrations on connection %s must run an approved plan (its checksums)", what, migration.Connection)
+	default:
+		return nil
+	}
+
+	severity := FindingError
+	if isPlanning(ctx) {
+		severity = FindingWarning
+	}
+	return []ValidatorFinding{{Validator: destructivePolicyValidator, Severity: severity, Message: message}}
+}
+
+// VerifyDestructivePolicy returns an ErrValidationFailed error when migration, applied to
+// schemaName ("" for its own schema), is refused by its connection's destructive policy. It is for callers that run migrations
+// outside the executor's executions.
+func (e *Executor) VerifyDestructivePolicy(ctx context.Context, migration *backends.MigrationScript, schemaName string) error {
+	for _, finding := range e.checkDestructivePolicy(ctx, migration, schemaName) {
+		if finding.Severity == FindingError {
+			return fmt.Errorf("%w: %s: %s", ErrValidationFailed, finding.Validator, finding.Message)
+		}
+	}
+	return nil
+}
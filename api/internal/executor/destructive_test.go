@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func newDestructiveTestExecutor(t *testing.T, policy string) (*Executor, *testsupport.StateTracker, *mockBackend) {
+	t.Helper()
+	exec, tracker := newLockTestExecutor(t)
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "drop_nickname", Connection: "test", Backend: "postgresql",
+		UpSQL: "ALTER TABLE users DROP COLUMN nickname;\nDELETE FROM sessions;",
+	})
+	exec.connections["test"].Extra = map[string]string{ExtraDestructivePolicy: policy}
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	return exec, tracker, backend
+}
+
+func TestExecutor_DestructivePolicy(t *testing.T) {
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// The plan classifies the migrations and warns about what the execution will need
+	exec, _, backend := newDestructiveTestExecutor(t, DestructiveForce)
+	plan, err := exec.Plan(context.Background(), target, "test", nil, false)
+	if err != nil || len(plan.Steps) != 2 || len(plan.Errors) != 0 {
+		t.Fatalf("Plan() = %+v, %v", plan, err)
+	}
+	if got := strings.Join(plan.Steps[1].Destructive, ", "); got != "DROP COLUMN, DELETE without WHERE" || len(plan.Steps[0].Destructive) != 0 {
+		t.Fatalf("expected only the second migration to be classified destructive, got %q", got)
+	}
+	if findings := plan.Steps[1].Findings; len(findings) != 1 || findings[0].Validator != "destructive_policy" || findings[0].Severity != FindingWarning {
+		t.Fatalf("expected a policy warning in the plan, got %+v", findings)
+	}
+
+	// force: refused unless forced
+	_, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if !errors.Is(err, ErrValidationFailed) || !strings.Contains(err.Error(), "allow_destructive") || backend.executeCalled {
+		t.Fatalf("expected the unforced execution to be refused, got %v", err)
+	}
+	result, err := exec.ExecuteSync(WithAllowDestructive(context.Background()), target, "test", "", false, false)
+	if err != nil || !result.Success || len(result.Applied) != 2 {
+		t.Fatalf("forced ExecuteSync() = %+v, %v", result, err)
+	}
+
+	// approval: refused unless running the approved plan
+	exec, _, _ = newDestructiveTestExecutor(t, DestructiveApproval)
+	plan, _ = exec.Plan(context.Background(), target, "test", nil, false)
+	if _, err := exec.ExecuteSync(WithAllowDestructive(context.Background()), target, "test", "", false, false); !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected the unapproved execution to be refused, got %v", err)
+	}
+	result, err = exec.ExecuteSync(WithPinnedChecksums(context.Background(), plan.Checksums), target, "test", "", false, false)
+	if err != nil || !result.Success || len(result.Applied) != 2 {
+		t.Fatalf("approved ExecuteSync() = %+v, %v", result, err)
+	}
+
+	// block: refused even when forced, and reported as an error in the plan
+	exec, _, _ = newDestructiveTestExecutor(t, DestructiveBlock)
+	plan, _ = exec.Plan(context.Background(), target, "test", nil, false)
+	if len(plan.Errors) != 1 || !strings.Contains(plan.Errors[0], "blocked on connection test") {
+		t.Fatalf("expected the blocked migration in the plan's errors, got %v", plan.Errors)
+	}
+	if _, err := exec.ExecuteSync(WithAllowDestructive(context.Background()), target, "test", "", false, false); !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected the blocked execution to be refused, got %v", err)
+	}
+}
+
+func TestExecutor_DestructivePolicy_Defaults(t *testing.T) {
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// Connections follow the executor's policy unless they set their own
+	exec, _, _ := newDestructiveTestExecutor(t, "")
+	exec.SetDestructivePolicy(DestructiveBlock)
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected the default policy to block the execution, got %v", err)
+	}
+	exec.connections["test"].Extra = map[string]string{ExtraDestructivePolicy: DestructiveAllow}
+	if result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil || !result.Success {
+		t.Fatalf("expected the connection's policy to allow the execution, got %+v, %v", result, err)
+	}
+
+	// An invalid policy blocks rather than letting destructive migrations through
+	exec, _, _ = newDestructiveTestExecutor(t, "sometimes")
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected an invalid policy to block the execution, got %v", err)
+	}
+
+	if policy, err := ParseDestructivePolicy(" Approval "); err != nil || policy != DestructiveApproval {
+		t.Errorf("ParseDestructivePolicy() = %q, %v", policy, err)
+	}
+	if _, err := ParseDestructivePolicy("sometimes"); err == nil {
+		t.Errorf("expected an unknown policy to be rejected")
+	}
+}
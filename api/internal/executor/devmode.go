@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// Developer mode: the loader watcher applies new and edited migrations of one dev connection as
+// soon as it detects them, giving the feedback of running scripts by hand in psql. It is meant for
+// a local database: drift never blocks a run, and each step is logged at info level or above.
+
+const devModeKey contextKey = "bfm_dev_mode"
+
+// DefaultDevWatchInterval is how often the watcher checks for new files in developer mode
+const DefaultDevWatchInterval = 2 * time.Second
+
+// WithDevMode marks ctx as a developer-mode run: applied migrations whose script changed are
+// logged instead of refusing the run, whatever the drift mode
+func WithDevMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, devModeKey, true)
+}
+
+func isDevMode(ctx context.Context) bool {
+	v, ok := ctx.Value(devModeKey).(bool)
+	return ok && v
+}
+
+// SetDevMode makes the watcher check for new files every interval (DefaultDevWatchInterval if 0)
+// and apply the new and edited migrations of connection. Dynamic-schema migrations are applied
+// to schemas, and skipped when there are none. Call before StartWatching.
+func (l *Loader) SetDevMode(connection string, schemas []string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDevWatchInterval
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.devConnection = connection
+	l.devSchemas = schemas
+	l.watchInterval = interval
+}
+
+// devModeConnection returns the dev connection, or "" outside developer mode
+func (l *Loader) devModeConnection() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.devConnection
+}
+
+// devSnapshot returns the up script checksums of the registered migrations of the dev connection
+func (l *Loader) devSnapshot() map[string]string {
+	connection := l.devModeConnection()
+	snapshot := make(map[string]string)
+	if connection == "" || l.registry == nil {
+		return snapshot
+	}
+	for _, migration := range l.registry.GetByConnection(connection) {
+		snapshot[fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)] = migration.Checksum()
+	}
+	return snapshot
+}
+
+// devChanges returns the migrations of the dev connection added or edited since the previous
+// snapshot, in version order, and takes a new snapshot
+func (l *Loader) devChanges() []string {
+	snapshot := l.devSnapshot()
+	l.mu.Lock()
+	previous := l.devChecksums
+	l.devChecksums = snapshot
+	l.mu.Unlock()
+
+	var changed []string
+	for id, checksum := range snapshot {
+		if before, ok := previous[id]; !ok || before != checksum {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// applyDevChanges applies the migrations of the dev connection added or edited since the last scan
+func (l *Loader) applyDevChanges(ctx context.Context) {
+	l.mu.RLock()
+	exec, connection, schemas := l.executor, l.devConnection, l.devSchemas
+	l.mu.RUnlock()
+	if exec == nil || connection == "" {
+		return
+	}
+
+	ids := l.devChanges()
+	if len(ids) == 0 {
+		return
+	}
+	logger.Infof("[dev mode] Applying %d new or edited migration(s) to connection %s: %s", len(ids), connection, strings.Join(ids, ", "))
+
+	ctx = SetExecutionContext(WithDevMode(WithAutoMigrateContext(ctx)), "bfm-dev-mode", "watcher", nil)
+	result, err := exec.ExecuteUpIDs(ctx, ids, connection, schemas, false, false)
+	if err != nil {
+		logger.Errorf("[dev mode] Failed to apply migrations to connection %s: %v", connection, err)
+		// Forget them so they are retried on the next scan
+		l.mu.Lock()
+		for _, id := range ids {
+			delete(l.devChecksums, id)
+		}
+		l.mu.Unlock()
+		return
+	}
+	for _, id := range result.Applied {
+		logger.Infof("[dev mode] Applied %s", id)
+	}
+	for _, id := range result.Skipped {
+		logger.Warnf("[dev mode] Skipped %s: already applied; roll it back to apply an edited script", id)
+	}
+	for _, msg := range result.Errors {
+		logger.Errorf("[dev mode] %s", msg)
+	}
+}
+
+// scriptModTime returns the later of modTime and the modification time of the up script of the
+// migration defined by goFilePath, so that editing a script is detected like editing its .go file
+func scriptModTime(goFilePath, backend string, modTime time.Time) time.Time {
+	dir := filepath.Dir(goFilePath)
+	baseName := strings.TrimSuffix(filepath.Base(goFilePath), ".go")
+	upFile := filepath.Join(dir, baseName+".up"+migrationScriptExtension(dir, baseName, backend))
+	if info, err := os.Stat(upFile); err == nil && info.ModTime().After(modTime) {
+		return info.ModTime()
+	}
+	return modTime
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestLoader_DevMode(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "postgresql", "core")
+	writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.up.sql"), "CREATE TABLE users (id INT);")
+	writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.down.sql"), "DROP TABLE users;")
+
+	reg := registry.NewInMemoryRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+
+	loader := NewLoader(root)
+	loader.SetExecutor(exec)
+	loader.SetSource(SourceScripts)
+	loader.SetDevMode("core", []string{"dev"}, 0)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	loader.devChanges() // As StartWatching does
+
+	const users, orders = "dev_20240101120000_create_users_postgresql_core", "dev_20240201120000_create_orders_postgresql_core"
+
+	// Migrations loaded before watching are left alone
+	if err := loader.scanAndLoad(); err != nil {
+		t.Fatalf("scanAndLoad() error = %v", err)
+	}
+	loader.applyDevChanges(context.Background())
+	if len(tracker.History()) != 0 {
+		t.Fatalf("expected nothing applied without new files, got %v", tracker.History())
+	}
+
+	// A new migration is applied on the next scan
+	writeTestFile(t, filepath.Join(dir, "20240201120000_create_orders.up.sql"), "CREATE TABLE orders (id INT);")
+	writeTestFile(t, filepath.Join(dir, "20240201120000_create_orders.down.sql"), "DROP TABLE orders;")
+	if err := loader.scanAndLoad(); err != nil {
+		t.Fatalf("scanAndLoad() error = %v", err)
+	}
+	loader.applyDevChanges(context.Background())
+	if !isApplied(tracker, orders) || isApplied(tracker, users) {
+		t.Fatalf("expected only create_orders to be applied, got %v", tracker.History())
+	}
+
+	// Editing a pending migration applies it
+	writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.up.sql"), "CREATE TABLE users (id BIGINT);")
+	if err := loader.scanAndLoad(); err != nil {
+		t.Fatalf("scanAndLoad() error = %v", err)
+	}
+	loader.applyDevChanges(context.Background())
+	if !isApplied(tracker, users) {
+		t.Fatalf("expected the edited create_users to be applied, got %v", tracker.History())
+	}
+
+	// Nothing changed, nothing run
+	runs := len(tracker.History())
+	if err := loader.scanAndLoad(); err != nil {
+		t.Fatalf("scanAndLoad() error = %v", err)
+	}
+	loader.applyDevChanges(context.Background())
+	if len(tracker.History()) != runs {
+		t.Errorf("expected no further runs, got %v", tracker.History()[runs:])
+	}
+}
+
+func TestExecutor_DevModeIgnoresDrift(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	target := &registry.MigrationTarget{Connection: "test"}
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	exec.registry.GetAll()[0].UpSQL = "CREATE TABLE users (id BIGINT);"
+
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrMigrationDrift) {
+		t.Fatalf("expected ErrMigrationDrift, got %v", err)
+	}
+	if _, err := exec.ExecuteSync(WithDevMode(context.Background()), target, "test", "", false, false); err != nil {
+		t.Errorf("expected drift to only warn in developer mode, got %v", err)
+	}
+}
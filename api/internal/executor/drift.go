@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// Drift modes: what executions do when an applied migration's script has changed since it ran
+const (
+	DriftModeFail = "fail" // Refuse to execute
+	DriftModeWarn = "warn" // Log a warning and execute
+)
+
+// ErrMigrationDrift is returned when an applied migration's script was modified and the drift mode is fail
+var ErrMigrationDrift = errors.New("applied migrations were modified since they ran")
+
+// DriftedMigration is an applied migration whose UpSQL no longer matches the checksum recorded when it ran
+type DriftedMigration struct {
+	MigrationID     string
+	Version         string
+	Name            string
+	Connection      string
+	Backend         string
+	AppliedAt       string
+	AppliedChecksum string
+	CurrentChecksum string
+}
+
+// SetDriftMode sets how executions react to drift (DriftModeFail or DriftModeWarn)
+func (e *Executor) SetDriftMode(mode string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.driftMode = mode
+}
+
+// DetectDrift returns all registered migrations whose script changed after being applied.
+// Migrations applied before checksums were recorded are not reported, nor are repeatable
+// migrations, which are applied again instead.
+func (e *Executor) DetectDrift(ctx context.Context) ([]*DriftedMigration, error) {
+	return e.detectDrift(ctx, e.registry.GetAll())
+}
+
+// detectDrift compares the recorded checksums of applied migrations with the given scripts
+func (e *Executor) detectDrift(ctx context.Context, migrations []*backends.MigrationScript) ([]*DriftedMigration, error) {
+	items, _, err := e.stateTracker.GetMigrationList(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration list: %w", err)
+	}
+
+	byID := make(map[string]*backends.MigrationScript, len(migrations))
+	for _, migration := range migrations {
+		byID[e.getMigrationID(migration)] = migration
+	}
+
+	drifted := []*DriftedMigration{}
+	for _, item := range items {
+		if !item.Applied || item.Checksum == "" {
+			continue
+		}
+		migration, ok := byID[item.MigrationID]
+		if !ok || migration.IsRepeatable() {
+			continue
+		}
+		if current := migration.Checksum(); current != item.Checksum {
+			drifted = append(drifted, &DriftedMigration{
+				MigrationID:     item.MigrationID,
+				Version:         migration.Version,
+				Name:            migration.Name,
+				Connection:      migration.Connection,
+				Backend:         migration.Backend,
+				AppliedAt:       item.LastAppliedAt,
+				AppliedChecksum: item.Checksum,
+				CurrentChecksum: current,
+			})
+		}
+	}
+	sort.Slice(drifted, func(i, j int) bool {
+		return drifted[i].MigrationID < drifted[j].MigrationID
+	})
+	return drifted, nil
+}
+
+// checkDrift applies the drift mode to the migrations about to be executed; developer-mode and
+// emergency runs only warn
+func (e *Executor) checkDrift(ctx context.Context, migrations []*backends.MigrationScript) error {
+	drifted, err := e.detectDrift(ctx, migrations)
+	if err != nil {
+		return err
+	}
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(drifted))
+	for i, d := range drifted {
+		ids[i] = d.MigrationID
+	}
+
+	e.mu.Lock()
+	mode := e.driftMode
+	e.mu.Unlock()
+	if mode == DriftModeWarn || isDevMode(ctx) || isEmergency(ctx) {
+		logger.Warnf("Applied migrations were modified since they ran: %s", strings.Join(ids, ", "))
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrMigrationDrift, strings.Join(ids, ", "))
+}
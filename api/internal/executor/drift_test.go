@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestExecutor_DetectDrift(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	target := &registry.MigrationTarget{Connection: "test"}
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	applied := lastRecord(tracker)
+	if applied.Checksum == "" {
+		t.Fatalf("expected the applied checksum to be recorded")
+	}
+
+	drifted, err := exec.DetectDrift(context.Background())
+	if err != nil || len(drifted) != 0 {
+		t.Fatalf("DetectDrift() = %v, %v; want no drift", drifted, err)
+	}
+
+	// Edit the applied migration
+	exec.registry.GetAll()[0].UpSQL = "CREATE TABLE users (id BIGINT);"
+	drifted, err = exec.DetectDrift(context.Background())
+	if err != nil {
+		t.Fatalf("DetectDrift() error = %v", err)
+	}
+	if len(drifted) != 1 || drifted[0].AppliedChecksum != applied.Checksum || drifted[0].CurrentChecksum == applied.Checksum {
+		t.Fatalf("expected one drifted migration, got %v", drifted)
+	}
+
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrMigrationDrift) {
+		t.Errorf("expected ErrMigrationDrift, got %v", err)
+	}
+	exec.SetDriftMode(DriftModeWarn)
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Errorf("expected drift to only warn, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// DefaultPostmortemWindow is how long after an emergency run its postmortem is due unless
+// configured otherwise
+const DefaultPostmortemWindow = 48 * time.Hour
+
+// emergencyRunKey is the context key of the emergency run an execution belongs to
+const emergencyRunKey contextKey = "bfm_emergency_run"
+
+// ErrInvalidPostmortem is returned when recording an empty postmortem
+var ErrInvalidPostmortem = errors.New("invalid postmortem")
+
+// SetPostmortemWindow sets how long after an emergency run its postmortem is due
+// (DefaultPostmortemWindow if 0)
+func (e *Executor) SetPostmortemWindow(window time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.postmortemWindow = window
+}
+
+// PostmortemWindow returns how long after an emergency run its postmortem is due
+func (e *Executor) PostmortemWindow() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.postmortemWindow <= 0 {
+		return DefaultPostmortemWindow
+	}
+	return e.postmortemWindow
+}
+
+// StartEmergencyRun records an emergency run with its postmortem due after the postmortem window,
+// publishes it, and returns ctx marked so the executions run with it bypass the drift check and
+// the validators (see WithEmergencyRun). The run must be recorded: if it cannot be, nothing
+// should be executed.
+func (e *Executor) StartEmergencyRun(ctx context.Context, run *state.EmergencyRun) (context.Context, *state.EmergencyRun, error) {
+	now := time.Now()
+	run.ID = fmt.Sprintf("emergency_%d", now.UnixNano())
+	run.PostmortemDue = now.Add(e.PostmortemWindow()).UTC().Format(time.RFC3339)
+	if err := e.stateTracker.RecordEmergencyRun(ctx, run); err != nil {
+		return ctx, nil, err
+	}
+	recorded, err := e.stateTracker.GetEmergencyRun(ctx, run.ID)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	logger.Warnf("Emergency run %s: %s %s by %q bypasses the execution safeguards: %s (postmortem due %s)",
+		recorded.ID, recorded.Operation, recorded.Method, recorded.Actor, recorded.Reason, recorded.PostmortemDue)
+	e.events.Publish(ctx, events.Event{Type: events.EmergencyStarted, Data: emergencyEventData(recorded)})
+	return WithEmergencyRun(ctx, recorded), recorded, nil
+}
+
+// WithEmergencyRun marks ctx as part of an emergency run: drift and validator errors are logged
+// instead of refusing executions
+func WithEmergencyRun(ctx context.Context, run *state.EmergencyRun) context.Context {
+	return context.WithValue(ctx, emergencyRunKey, run)
+}
+
+// EmergencyRunFromContext returns the emergency run ctx was marked with by WithEmergencyRun
+func EmergencyRunFromContext(ctx context.Context) (*state.EmergencyRun, bool) {
+	run, ok := ctx.Value(emergencyRunKey).(*state.EmergencyRun)
+	return run, ok && run != nil
+}
+
+// isEmergency reports whether ctx is part of an emergency run
+func isEmergency(ctx context.Context) bool {
+	_, ok := EmergencyRunFromContext(ctx)
+	return ok
+}
+
+// RecordPostmortem records the follow-up annotation of an emergency run (an incident report link,
+// a summary), by the caller by. A postmortem already recorded is replaced.
+func (e *Executor) RecordPostmortem(ctx context.Context, id, postmortem, by string) (*state.EmergencyRun, error) {
+	postmortem = strings.TrimSpace(postmortem)
+	if postmortem == "" {
+		return nil, fmt.Errorf("%w: the postmortem cannot be empty", ErrInvalidPostmortem)
+	}
+	run, err := e.stateTracker.GetEmergencyRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	run.Postmortem, run.PostmortemBy = postmortem, by
+	if err := e.stateTracker.RecordEmergencyRun(ctx, run); err != nil {
+		return nil, err
+	}
+	if run, err = e.stateTracker.GetEmergencyRun(ctx, id); err != nil {
+		return nil, err
+	}
+	logger.Infof("Postmortem of emergency run %s recorded by %q", id, by)
+	e.events.Publish(ctx, events.Event{Type: events.PostmortemRecorded, Data: emergencyEventData(run)})
+	return run, nil
+}
+
+// GetEmergencyRun returns an emergency run by ID, or state.ErrEmergencyRunNotFound
+func (e *Executor) GetEmergencyRun(ctx context.Context, id string) (*state.EmergencyRun, error) {
+	return e.stateTracker.GetEmergencyRun(ctx, id)
+}
+
+// GetEmergencyRuns lists emergency runs, most recent first
+func (e *Executor) GetEmergencyRuns(ctx context.Context, filters *state.EmergencyRunFilters) ([]*state.EmergencyRun, int, error) {
+	return e.stateTracker.GetEmergencyRuns(ctx, filters)
+}
+
+// RunPostmortemReminders publishes PostmortemOverdue for each emergency run whose postmortem is
+// overdue every interval, until the postmortem is recorded or ctx is done. Only the primary
+// reminds; a standby starts once promoted.
+func (e *Executor) RunPostmortemReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.remindPostmortems(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remindPostmortems publishes PostmortemOverdue for the pending emergency runs due before now
+func (e *Executor) remindPostmortems(ctx context.Context, now time.Time) {
+	if e.IsStandby() {
+		return
+	}
+	runs, _, err := e.stateTracker.GetEmergencyRuns(ctx, &state.EmergencyRunFilters{Pending: true})
+	if err != nil {
+		logger.Warnf("Failed to list emergency runs: %v", err)
+		return
+	}
+	for _, run := range runs {
+		due, err := time.Parse(time.RFC3339, run.PostmortemDue)
+		if err != nil || due.After(now) {
+			continue
+		}
+		logger.Warnf("Postmortem of emergency run %s by %q is overdue since %s", run.ID, run.Actor, run.PostmortemDue)
+		e.events.Publish(ctx, events.Event{Type: events.PostmortemOverdue, Data: emergencyEventData(run)})
+	}
+}
+
+// emergencyEventData returns the event data of an emergency run
+func emergencyEventData(run *state.EmergencyRun) map[string]interface{} {
+	data := map[string]interface{}{
+		"emergency_run":  run.ID,
+		"operation":      run.Operation,
+		"actor":          run.Actor,
+		"reason":         run.Reason,
+		"method":         run.Method,
+		"started_at":     run.StartedAt,
+		"postmortem_due": run.PostmortemDue,
+	}
+	if !run.Pending() {
+		data["postmortem"] = run.Postmortem
+		data["postmortem_by"] = run.PostmortemBy
+	}
+	return data
+}
@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_EmergencyRunPostmortem(t *testing.T) {
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
+	exec.SetPostmortemWindow(time.Hour)
+	var got []events.Event
+	exec.Events().Subscribe(events.All, func(_ context.Context, e events.Event) {
+		got = append(got, e)
+	})
+	ctx := context.Background()
+
+	runCtx, run, err := exec.StartEmergencyRun(ctx, &state.EmergencyRun{
+		Operation: "up", Actor: "oncall", Reason: "INC-42: hotfix", Method: "POST /api/v1/migrations/up",
+	})
+	if err != nil {
+		t.Fatalf("StartEmergencyRun() error = %v", err)
+	}
+	if marked, ok := EmergencyRunFromContext(runCtx); !ok || marked.ID != run.ID {
+		t.Fatalf("expected the context to be marked with %s, got %v", run.ID, marked)
+	}
+	if !run.Pending() || run.StartedAt == "" || run.Actor != "oncall" {
+		t.Fatalf("expected a pending run by oncall, got %+v", run)
+	}
+	if len(got) != 1 || got[0].Type != events.EmergencyStarted || got[0].Data["emergency_run"] != run.ID {
+		t.Fatalf("expected emergency.started, got %v", got)
+	}
+
+	// Not yet due, then overdue on every reminder until the postmortem is recorded
+	got = nil
+	exec.remindPostmortems(ctx, time.Now())
+	if len(got) != 0 {
+		t.Fatalf("expected no reminder before the postmortem is due, got %v", got)
+	}
+	exec.remindPostmortems(ctx, time.Now().Add(2*time.Hour))
+	exec.remindPostmortems(ctx, time.Now().Add(3*time.Hour))
+	if len(got) != 2 || got[0].Type != events.PostmortemOverdue || got[0].Data["actor"] != "oncall" {
+		t.Fatalf("expected two emergency.postmortem_overdue, got %v", got)
+	}
+
+	if _, err := exec.RecordPostmortem(ctx, run.ID, "  ", "oncall"); !errors.Is(err, ErrInvalidPostmortem) {
+		t.Errorf("expected ErrInvalidPostmortem, got %v", err)
+	}
+	if _, err := exec.RecordPostmortem(ctx, "emergency_0", "https://wiki/INC-42", "oncall"); !errors.Is(err, state.ErrEmergencyRunNotFound) {
+		t.Errorf("expected ErrEmergencyRunNotFound, got %v", err)
+	}
+	got = nil
+	recorded, err := exec.RecordPostmortem(ctx, run.ID, "https://wiki/INC-42", "lead")
+	if err != nil {
+		t.Fatalf("RecordPostmortem() error = %v", err)
+	}
+	if recorded.Pending() || recorded.PostmortemBy != "lead" || recorded.PostmortemAt == "" {
+		t.Fatalf("expected the postmortem to be recorded, got %+v", recorded)
+	}
+	exec.remindPostmortems(ctx, time.Now().Add(3*time.Hour))
+	if len(got) != 1 || got[0].Type != events.PostmortemRecorded || got[0].Data["postmortem"] != "https://wiki/INC-42" {
+		t.Fatalf("expected only emergency.postmortem_recorded, got %v", got)
+	}
+
+	pending, total, err := exec.GetEmergencyRuns(ctx, &state.EmergencyRunFilters{Pending: true})
+	if err != nil || total != 0 || len(pending) != 0 {
+		t.Errorf("expected no pending run, got %v (%d), %v", pending, total, err)
+	}
+
+	// A standby leaves reminders to the primary
+	_, overdue, _ := exec.StartEmergencyRun(ctx, &state.EmergencyRun{Operation: "down", Actor: "oncall", Reason: "INC-43"})
+	exec.SetStandby(true)
+	got = nil
+	exec.remindPostmortems(ctx, time.Now().Add(2*time.Hour))
+	if len(got) != 0 {
+		t.Errorf("expected no reminder of %s from a standby, got %v", overdue.ID, got)
+	}
+}
+
+func TestExecutor_EmergencyRunBypassesSafeguards(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	exec.RegisterValidator(forbidSchema("public"))
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected ErrValidationFailed, got %v", err)
+	}
+	ctx := WithEmergencyRun(context.Background(), &state.EmergencyRun{ID: "emergency_1"})
+	if _, err := exec.ExecuteSync(ctx, target, "test", "", false, false); err != nil {
+		t.Fatalf("expected the emergency run to ignore the validator, got %v", err)
+	}
+	if len(tracker.History()) == 0 {
+		t.Fatalf("expected the migration to be applied")
+	}
+
+	// Edit the applied migration, which refuses further executions
+	exec.registry.GetAll()[0].UpSQL = "CREATE TABLE users (id BIGINT);"
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrMigrationDrift) {
+		t.Fatalf("expected ErrMigrationDrift, got %v", err)
+	}
+	if _, err := exec.ExecuteSync(ctx, target, "test", "", false, false); err != nil {
+		t.Errorf("expected the emergency run to only warn of drift, got %v", err)
+	}
+}
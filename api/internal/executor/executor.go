@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,18 +8,26 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
-	"text/template"
 	"time"
 
 	"github.com/toolsascode/bfm/api/internal/backends"
 	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/features"
 	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/metrics"
 	"github.com/toolsascode/bfm/api/internal/queue"
 	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/secrets"
 	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Context keys for execution metadata
@@ -77,9 +84,28 @@ func GetExecutionContext(ctx context.Context) (executedBy, executionMethod, exec
 			executionContext = s
 		}
 	}
+	if runID := RunIDFromContext(ctx); runID != "" {
+		executionContext = withExecutionContextValue(executionContext, "run_id", runID)
+	}
 	return executedBy, executionMethod, executionContext
 }
 
+// withExecutionContextValue returns executionContext (JSON) with key set to value
+func withExecutionContextValue(executionContext, key string, value interface{}) string {
+	execCtx := make(map[string]interface{})
+	if executionContext != "" {
+		if err := json.Unmarshal([]byte(executionContext), &execCtx); err != nil {
+			execCtx = make(map[string]interface{})
+		}
+	}
+	execCtx[key] = value
+	updated, err := json.Marshal(execCtx)
+	if err != nil {
+		return executionContext
+	}
+	return string(updated)
+}
+
 // Executor executes migrations
 type Executor struct {
 	registry     registry.Registry
@@ -87,7 +113,38 @@ type Executor struct {
 	backends     map[string]backends.Backend
 	connections  map[string]*backends.ConnectionConfig
 	queue        queue.Queue // Optional queue for async execution
+	events       *events.Bus
+	driftMode    string               // DriftModeFail (default) or DriftModeWarn
+	standby      bool                 // Standby instance: writes are refused until promoted
+	starting     bool                 // The server has not finished loading migrations yet
+	conflicts    []*MigrationConflict // Migrations the loader refused, see MigrationConflicts
+	promotedAt   time.Time
+	promotedBy   string
+	onPromote    []func()
 	mu           sync.Mutex
+
+	primaryMu   sync.Mutex        // Serializes primary lock election and promotion
+	primaryLock state.PrimaryLock // Held primary lock, if any
+
+	validators []Validator // Custom checks run before migrations are applied
+
+	schemaPolicy SchemaPolicy // Schemas requests may target
+
+	maintenanceWindow MaintenanceWindow // When scheduled runs may start
+	scheduleMu        sync.Mutex        // Serializes starting and cancelling scheduled jobs
+
+	reports   map[string]*Report // Reports generated by this instance, see reports.go
+	reportsMu sync.Mutex
+
+	postmortemWindow time.Duration // How long after an emergency run its postmortem is due, see emergency.go
+
+	migrationTimeout  time.Duration // Default limit of a migration's execution, see runs.go
+	destructivePolicy string        // Default destructive policy of connections, see destructive.go
+	outOfOrderPolicy  string        // Default out-of-order policy of connections, see outoforder.go
+
+	connectionsSource ConnectionsSource // Where ReloadConnections loads connections from, see connections.go
+	secrets           *secrets.Resolver // Resolves the secret references of connections, see connections.go
+	runs              map[string]*Run   // Synchronous runs in flight, by ID
 }
 
 // NewExecutor creates a new migration executor
@@ -97,9 +154,17 @@ func NewExecutor(reg registry.Registry, tracker state.StateTracker) *Executor {
 		stateTracker: tracker,
 		backends:     make(map[string]backends.Backend),
 		connections:  make(map[string]*backends.ConnectionConfig),
+		events:       events.NewBus(),
+		schemaPolicy: DefaultSchemaPolicy(),
 	}
 }
 
+// Events returns the executor's event bus. Subsystems subscribe to it to react to migrations
+// being applied or failing, jobs being queued, reindexes and newly detected migration files.
+func (e *Executor) Events() *events.Bus {
+	return e.events
+}
+
 // SetConnections sets the connection configurations
 func (e *Executor) SetConnections(connections map[string]*backends.ConnectionConfig) error {
 	if connections == nil {
@@ -137,9 +202,37 @@ func (e *Executor) GetBackend(name string) backends.Backend {
 	return e.backends[name]
 }
 
+// registeredBackend returns the backend registered under name, shared by every execution; the
+// execution path uses executionBackend instead
+func (e *Executor) registeredBackend(name string) (backends.Backend, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	backend, ok := e.backends[name]
+	return backend, ok
+}
+
+// BackendNames returns the names of the registered backends, sorted
+func (e *Executor) BackendNames() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	names := make([]string, 0, len(e.backends))
+	for name := range e.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// QueueEnabled reports whether a queue is set for async execution
+func (e *Executor) QueueEnabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.queue != nil
+}
+
 // GetConnectionConfig returns a connection config by name
-func (e *Executor) GetConnectionConfig(name string) (*backends.ConnectionConfig, error) {
-	return e.getConnectionConfig(name)
+func (e *Executor) GetConnectionConfig(ctx context.Context, name string) (*backends.ConnectionConfig, error) {
+	return e.getConnectionConfig(ctx, name)
 }
 
 // GetSkippedMigrations retrieves skipped migrations from the state tracker
@@ -149,18 +242,31 @@ func (e *Executor) GetSkippedMigrations(ctx context.Context, migrationID string,
 
 // ExecuteSync executes migrations synchronously (bypasses queue, used by worker)
 func (e *Executor) ExecuteSync(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+	ctx = withRunID(ctx)
+	if err := e.validateSchemas(schemaName); err != nil {
+		return nil, err
+	}
 	return e.executeSync(ctx, target, connectionName, schemaName, dryRun, ignoreDependencies)
 }
 
 // Execute executes migrations based on a target specification
 // If queue is configured, it will queue the job instead of executing directly
 func (e *Executor) Execute(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+	ctx = withRunID(ctx)
+	if err := e.validateSchemas(schemaName); err != nil {
+		return nil, err
+	}
+
 	// If queue is enabled, queue the job instead of executing
 	e.mu.Lock()
 	hasQueue := e.queue != nil
 	e.mu.Unlock()
 
-	if hasQueue {
+	// Emergency runs execute now: the worker would not run the job with the safeguards bypassed
+	if hasQueue && !isEmergency(ctx) {
+		if e.IsStandby() {
+			return nil, ErrStandby
+		}
 		return e.queueJob(ctx, target, connectionName, schemaName, dryRun)
 	}
 
@@ -178,16 +284,24 @@ func (e *Executor) queueJob(ctx context.Context, target *registry.MigrationTarge
 		Schema:     schemaName,
 		DryRun:     dryRun,
 		Metadata:   make(map[string]interface{}),
+		// Pinned checksums and destructive policies are verified by the worker, when the job runs
+		PinnedChecksums:  PinnedChecksums(ctx),
+		AllowDestructive: allowsDestructive(ctx),
 	}
 
-	// Publish job to queue
 	e.mu.Lock()
 	q := e.queue
 	e.mu.Unlock()
 
-	if err := q.PublishJob(ctx, job); err != nil {
-		return nil, fmt.Errorf("failed to queue migration job: %w", err)
+	if err := e.publishJob(ctx, q, job); err != nil {
+		return nil, err
 	}
+	e.events.Publish(ctx, events.Event{
+		Type:       events.JobQueued,
+		Connection: connectionName,
+		Schema:     schemaName,
+		Data:       map[string]interface{}{"job_id": job.ID, "dry_run": dryRun},
+	})
 
 	// Return queued result
 	return &ExecuteResult{
@@ -200,6 +314,27 @@ func (e *Executor) queueJob(ctx context.Context, target *registry.MigrationTarge
 	}, nil
 }
 
+// publishJob publishes a job to the queue, carrying the trace context to the worker, and records
+// it as queued, or failed when it could not be published
+func (e *Executor) publishJob(ctx context.Context, q queue.Queue, job *queue.Job) error {
+	ctx, span := tracing.Tracer().Start(ctx, "queue.PublishJob", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("bfm.job.id", job.ID),
+		attribute.String("bfm.connection", job.Connection),
+	))
+	tracing.Inject(ctx, job.Metadata)
+	features.Inject(ctx, job.Metadata)
+	// Recorded before publishing, so a worker picking the job up at once finds it
+	e.RecordJobStatus(ctx, job, state.JobQueued, nil, nil)
+	err := q.PublishJob(ctx, job)
+	tracing.End(span, err)
+	if err != nil {
+		err = fmt.Errorf("failed to queue migration job: %w", err)
+		e.RecordJobStatus(ctx, job, state.JobFailed, nil, err)
+		return err
+	}
+	return nil
+}
+
 // convertTarget converts registry.MigrationTarget to queue.MigrationTarget
 func convertTarget(target *registry.MigrationTarget) *queue.MigrationTarget {
 	if target == nil {
@@ -210,6 +345,7 @@ func convertTarget(target *registry.MigrationTarget) *queue.MigrationTarget {
 		Schema:     target.Schema,
 		Tables:     target.Tables,
 		Version:    target.Version,
+		MaxVersion: target.MaxVersion,
 		Connection: target.Connection,
 		Tags:       target.Tags,
 	}
@@ -415,6 +551,13 @@ func (e *Executor) expandWithPendingDependencies(ctx context.Context, migrations
 				continue
 			}
 
+			if dep.AnyTarget() {
+				targetMigrations, err = e.pendingAnyTarget(ctx, dep, targetMigrations, selected)
+				if err != nil {
+					return nil, make(map[string]bool), make(map[string]string), err
+				}
+			}
+
 			for _, target := range targetMigrations {
 				targetID := e.getMigrationID(target)
 				logger.Debug("Checking dependency migration %s (connection=%s, schema=%s, version=%s, name=%s)", targetID, target.Connection, target.Schema, target.Version, target.Name)
@@ -463,6 +606,26 @@ func (e *Executor) expandWithPendingDependencies(ctx context.Context, migrations
 	return expanded, dependencyMap, dependencyParentMap, nil
 }
 
+// pendingAnyTarget narrows the candidates of a "min_version" or "version_range" dependency (lowest
+// version first) to the one migration to auto-include: none when a candidate is already in the
+// execution set or a migration the dependency targets is applied, otherwise the lowest candidate
+func (e *Executor) pendingAnyTarget(ctx context.Context, dep backends.Dependency, candidates []*backends.MigrationScript, selected map[string]*backends.MigrationScript) ([]*backends.MigrationScript, error) {
+	for _, candidate := range candidates {
+		if _, exists := selected[e.getMigrationID(candidate)]; exists {
+			return nil, nil
+		}
+	}
+	applied, err := registry.AnyTargetApplied(ctx, e.stateTracker, dep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s dependency %s on %s: %w", dep.TargetType, dep.Target, dep.Connection, err)
+	}
+	if applied {
+		logger.Debug("%s dependency %s on %s already satisfied", dep.TargetType, dep.Target, dep.Connection)
+		return nil, nil
+	}
+	return candidates[:1], nil
+}
+
 // runSingleMigrationUp records pending state, runs the migration backend, and records the outcome.
 // Caller must hold WithMigrationExecutionLock for the same (migrationID, schema, connection).
 func (e *Executor) runSingleMigrationUp(
@@ -527,7 +690,7 @@ func (e *Executor) runSingleMigrationUp(
 	if recordErr != nil {
 		// Re-check if migration was applied by another process (concurrency control)
 		// Use IsMigrationApplied (not IsMigrationPendingOrApplied) because we want to skip only if actually applied
-		applied, checkErr := e.stateTracker.IsMigrationApplied(ctx, migrationID)
+		applied, checkErr := e.isUpToDate(ctx, migration, migrationID, schema)
 		if checkErr == nil && applied {
 			result.Skipped = append(result.Skipped, migrationID)
 			return
@@ -540,7 +703,7 @@ func (e *Executor) runSingleMigrationUp(
 	// Double-check after recording to ensure we didn't race with another process (concurrency control)
 	// Use IsMigrationApplied (not IsMigrationPendingOrApplied) because we just recorded it as pending ourselves
 	// We only want to skip if another process marked it as APPLIED while we were recording
-	applied, checkErr := e.stateTracker.IsMigrationApplied(ctx, migrationID)
+	applied, checkErr := e.isUpToDate(ctx, migration, migrationID, schema)
 	if checkErr == nil && applied {
 		// Another process marked it as applied, skip
 		result.Skipped = append(result.Skipped, migrationID)
@@ -548,10 +711,11 @@ func (e *Executor) runSingleMigrationUp(
 	}
 
 	// Get backend for this migration's connection (may differ from target connection for cross-connection dependencies)
-	migrationConnectionConfig, err := e.getConnectionConfig(migration.Connection)
+	migrationConnectionConfig, err := e.getConnectionConfig(ctx, migration.Connection)
 	if err != nil {
 		record.Status = "failed"
 		record.ErrorMessage = fmt.Sprintf("failed to get connection config for %s: %v", migration.Connection, err)
+		record.ErrorClass = result.classifyFailure(migrationID, backends.FailureUnknown)
 		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
 		if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to record migration %s: %v", migrationID, err))
@@ -559,10 +723,11 @@ func (e *Executor) runSingleMigrationUp(
 		return
 	}
 
-	migrationBackend, ok := e.backends[migrationConnectionConfig.Backend]
+	migrationBackend, ok := e.executionBackend(ctx, migrationConnectionConfig.Backend)
 	if !ok {
 		record.Status = "failed"
 		record.ErrorMessage = fmt.Sprintf("backend %s not registered for connection %s", migrationConnectionConfig.Backend, migration.Connection)
+		record.ErrorClass = result.classifyFailure(migrationID, backends.FailureUnknown)
 		result.Errors = append(result.Errors, fmt.Sprintf("%s: backend %s not registered", migrationID, migrationConnectionConfig.Backend))
 		if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to record migration %s: %v", migrationID, err))
@@ -574,6 +739,7 @@ func (e *Executor) runSingleMigrationUp(
 	if err := migrationBackend.Connect(migrationConnectionConfig); err != nil {
 		record.Status = "failed"
 		record.ErrorMessage = fmt.Sprintf("failed to connect to backend for %s: %v", migration.Connection, err)
+		record.ErrorClass = result.classifyFailure(migrationID, backends.Classify(migrationBackend, err))
 		result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to connect: %v", migrationID, err))
 		if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to record migration %s: %v", migrationID, err))
@@ -581,12 +747,15 @@ func (e *Executor) runSingleMigrationUp(
 		return
 	}
 
-	// Apply template variable replacement
-	upSQL, err := replaceTemplateVariables(migration.UpSQL, migration, schema)
+	// Apply template variable replacement using the connection's template policy
+	templatePolicy := templatePolicyFromConnection(migrationConnectionConfig)
+	templateAudit := make(map[string]string)
+	upSQL, resolvedUp, err := renderTemplate(migration.UpSQL, migration, schema, templatePolicy)
 	if err != nil {
 		// Migration was already marked as pending, update to failed
 		record.Status = "failed"
 		record.ErrorMessage = fmt.Sprintf("failed to replace template variables in UpSQL: %v", err)
+		record.ErrorClass = result.classifyFailure(migrationID, backends.FailureSyntax)
 		result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to replace template variables in UpSQL: %v", migrationID, err))
 		// Record the failure
 		if isDependency {
@@ -601,14 +770,18 @@ func (e *Executor) runSingleMigrationUp(
 		return
 	}
 
+	mergeTemplateAudit(templateAudit, resolvedUp)
+
 	downSQL := migration.DownSQL
 	if downSQL != "" {
 		var err error
-		downSQL, err = replaceTemplateVariables(migration.DownSQL, migration, schema)
+		var resolvedDown map[string]string
+		downSQL, resolvedDown, err = renderTemplate(migration.DownSQL, migration, schema, templatePolicy)
 		if err != nil {
 			// Migration was already marked as pending, update to failed
 			record.Status = "failed"
 			record.ErrorMessage = fmt.Sprintf("failed to replace template variables in DownSQL: %v", err)
+			record.ErrorClass = result.classifyFailure(migrationID, backends.FailureSyntax)
 			result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to replace template variables in DownSQL: %v", migrationID, err))
 			// Record the failure
 			if isDependency {
@@ -622,29 +795,105 @@ func (e *Executor) runSingleMigrationUp(
 			}
 			return
 		}
+		mergeTemplateAudit(templateAudit, resolvedDown)
 	}
 
+	// Record the resolved template variables so audited SQL can be reproduced
+	if templatePolicy.auditEnabled() {
+		executionContext = withExecutionContextValue(executionContext, "template_variables", templateAudit)
+		record.ExecutionContext = executionContext
+	}
+
+	logStatement(ctx, migrationConnectionConfig, migrationID, upSQL, e.statementCount(migrationConnectionConfig, upSQL), result)
+
 	// Convert executor.MigrationScript to backends.MigrationScript
 	// Use provided schema instead of migration.Schema for dynamic schemas
 	backendMigration := &backends.MigrationScript{
-		Schema:     schema,
-		Version:    migration.Version,
-		Name:       migration.Name,
-		Connection: migration.Connection,
-		Backend:    migration.Backend,
-		UpSQL:      upSQL,
-		DownSQL:    downSQL,
+		Schema:        schema,
+		Version:       migration.Version,
+		Name:          migration.Name,
+		Connection:    migration.Connection,
+		Backend:       migration.Backend,
+		UpSQL:         upSQL,
+		DownSQL:       downSQL,
+		Declarative:   migration.Declarative,
+		Transactional: backends.IsTransactional(upSQL),
+		UpFunc:        migration.UpFunc,
+		DownFunc:      migration.DownFunc,
+		Timeout:       e.timeoutOf(migration),
+	}
+
+	// Check the migration is not older than the latest applied one, back up what a destructive
+	// migration discards, then run the pre hooks of the connection and migration; a failure fails
+	// the migration before it runs
+	hooks, err := hooksOf(migrationConnectionConfig, migration)
+	if err == nil {
+		var outOfOrder *OutOfOrderDecision
+		if outOfOrder, err = e.checkOutOfOrder(ctx, migrationConnectionConfig, migration, migrationID, schema); outOfOrder != nil {
+			executionContext = withExecutionContextValue(executionContext, "out_of_order", outOfOrder)
+			record.ExecutionContext = executionContext
+		}
+	}
+	if err == nil {
+		var backup string
+		if backup, err = backupIfDestructive(ctx, migrationBackend, migrationConnectionConfig, backendMigration, migrationID); backup != "" {
+			executionContext = withExecutionContextValue(executionContext, "backup", backup)
+			record.ExecutionContext = executionContext
+		}
+	}
+	if err == nil {
+		err = runHooks(ctx, HookPre, hooks.Pre, migrationBackend, migrationConnectionConfig, migration, migrationID, schema)
+	}
+	if err != nil {
+		_ = migrationBackend.Close()
+		record.Status = "failed"
+		record.ErrorMessage = err.Error()
+		record.ErrorClass = result.classifyFailure(migrationID, backends.Classify(migrationBackend, err))
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+		ctx = context.WithoutCancel(ctx)
+		if isDependency {
+			if recordErr := e.stateTracker.RecordDependencyMigration(ctx, record); recordErr != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("failed to record dependency migration failure %s: %v", migrationID, recordErr))
+			}
+		} else {
+			if recordErr := e.stateTracker.RecordMigration(ctx, record); recordErr != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("failed to record migration failure %s: %v", migrationID, recordErr))
+			}
+		}
+		return
 	}
 
 	// Execute the migration using its own backend
-	err = migrationBackend.ExecuteMigration(ctx, backendMigration)
+	executionCtx, reportOutcome := e.reportExecution(ctx, migration, migrationID, schema)
+	err = executeOnBackend(executionCtx, metrics.DirectionUp, migrationBackend, backendMigration)
+	// A migration whose verification fails is failed, and reverted when its verification asks for it
+	if err == nil {
+		err = verifyMigration(executionCtx, migrationBackend, migrationConnectionConfig, migration, migrationID, schema)
+		if err != nil && verifyRollback(migrationConnectionConfig, migration) {
+			if rollbackErr := rollbackUnverified(executionCtx, migrationBackend, migration, backendMigration, migrationID); rollbackErr != nil {
+				err = fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+			} else {
+				err = fmt.Errorf("%w (rolled back)", err)
+			}
+		}
+	}
+	elapsed := reportOutcome(err)
+	// Post hooks run once the migration is applied: a failing one is reported, the migration stays applied
+	if err == nil {
+		if hookErr := runHooks(ctx, HookPost, hooks.Post, migrationBackend, migrationConnectionConfig, migration, migrationID, schema); hookErr != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, hookErr))
+		}
+	}
 	_ = migrationBackend.Close() // Close after execution
+	e.publishExecution(ctx, state.OperationUp, migration, migrationID, schema, elapsed, err, map[string]interface{}{"dependency": isDependency})
 	if err != nil {
 		record.Status = "failed"
 		record.ErrorMessage = err.Error()
+		record.ErrorClass = result.classifyFailure(migrationID, backends.Classify(migrationBackend, err))
 		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
 	} else {
 		record.Status = "success"
+		record.Checksum = migration.Checksum()
 		// Fresh completion time so history ordering is deterministic (pending row may share the pre-exec timestamp).
 		record.AppliedAt = time.Now().Format(time.RFC3339)
 		result.Applied = append(result.Applied, migrationID)
@@ -663,8 +912,9 @@ func (e *Executor) runSingleMigrationUp(
 		}
 	}
 
-	// Record migration in state tracker
+	// Record migration in state tracker, even when the run was canceled or timed out
 	// Requirement 4: Dependencies use RecordDependencyMigration (no history)
+	ctx = context.WithoutCancel(ctx)
 	// CRITICAL: Ensure record.Schema is set correctly for schema-specific migrations
 	// The schema must match the schema used in migrations_executions for ON CONFLICT to work
 	if isDependency {
@@ -700,13 +950,65 @@ func (e *Executor) runSingleMigrationUp(
 }
 
 // executeSync executes migrations synchronously
-func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (result *ExecuteResult, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "executor.ExecuteSync", trace.WithAttributes(
+		attribute.String("bfm.connection", connectionName),
+		attribute.String("bfm.schema", schemaName),
+		attribute.Bool("bfm.dry_run", dryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	err = e.withExecutionLock(ctx, connectionName, dryRun, func() error {
+		var err error
+		result, err = e.executeSchemaLocked(ctx, target, connectionName, schemaName, dryRun, ignoreDependencies)
+		return err
+	})
+	return result, err
+}
+
+// withExecutionLock runs fn holding the connection lock, unless dryRun: plain dry runs only read
+// state, while deep dry runs rehearse the scripts on the database, so they take the lock like executions
+func (e *Executor) withExecutionLock(ctx context.Context, connectionName string, dryRun bool, fn func() error) error {
+	if dryRun && !features.Enabled(ctx, features.DeepDryRun) {
+		return fn()
+	}
+	return e.withConnectionLock(ctx, connectionName, fn)
+}
+
+// executeSchemaLocked runs executeSyncLocked and publishes the run once it applied migrations
+func (e *Executor) executeSchemaLocked(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+	result, err := e.executeSyncLocked(ctx, target, connectionName, schemaName, dryRun, ignoreDependencies)
+	if err == nil && !dryRun && len(result.Applied) > 0 {
+		e.events.Publish(ctx, events.Event{
+			Type:       events.RunCompleted,
+			Connection: connectionName,
+			Schema:     schemaName,
+			Data:       map[string]interface{}{"applied": slices.Clone(result.Applied), "errors": len(result.Errors)},
+		})
+	}
+	return result, err
+}
+
+// executeSyncLocked executes migrations for a target. Unless dryRun, the caller must hold the connection lock.
+func (e *Executor) executeSyncLocked(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
 	// Find migrations matching the target
 	migrations, err := e.registry.FindByTarget(target)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find migrations: %w", err)
 	}
 
+	// Log initial migrations found
+	logger.Debug("Found %d migration(s) matching target (backend=%s, connection=%s, schema=%s)", len(migrations), target.Backend, target.Connection, target.Schema)
+	for _, m := range migrations {
+		logger.Debug("  - %s_%s (connection=%s, schema=%s)", m.Version, m.Name, m.Connection, m.Schema)
+	}
+
+	return e.executeMigrationsLocked(ctx, migrations, connectionName, schemaName, dryRun, ignoreDependencies)
+}
+
+// executeMigrationsLocked executes the selected migrations, with their pending dependencies unless
+// ignoreDependencies, in dependency order. Unless dryRun, the caller must hold the connection lock.
+func (e *Executor) executeMigrationsLocked(ctx context.Context, migrations []*backends.MigrationScript, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
 	if len(migrations) == 0 {
 		return &ExecuteResult{
 			Success: true,
@@ -716,12 +1018,11 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 		}, nil
 	}
 
-	// Log initial migrations found
-	logger.Debug("Found %d migration(s) matching target (backend=%s, connection=%s, schema=%s)", len(migrations), target.Backend, target.Connection, target.Schema)
-	for _, m := range migrations {
-		logger.Debug("  - %s_%s (connection=%s, schema=%s)", m.Version, m.Name, m.Connection, m.Schema)
+	if err := e.checkDrift(ctx, migrations); err != nil {
+		return nil, err
 	}
 
+	var err error
 	// Expand with pending dependencies (unless ignore_dependencies is true)
 	var sortedMigrations []*backends.MigrationScript
 	var dependencyResolutionError error
@@ -747,12 +1048,14 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 		logger.Infof("After dependency expansion: %d migration(s) ready for execution", len(migrations))
 
 		// Get backend for the target connection (needed for validation)
-		connectionConfig, err := e.getConnectionConfig(connectionName)
+		connectionConfig, err := e.getConnectionConfig(ctx, connectionName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get connection config: %w", err)
 		}
 
-		targetBackend, ok := e.backends[connectionConfig.Backend]
+		// Concurrent schemas validate on their own instance: closing a shared one would close the
+		// pool the others still use
+		targetBackend, ok := e.executionBackend(ctx, connectionConfig.Backend)
 		if !ok {
 			return nil, fmt.Errorf("backend %s not registered", connectionConfig.Backend)
 		}
@@ -812,6 +1115,16 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 		logger.Warnf("No migrations to execute after dependency expansion and sorting")
 	}
 
+	// Refuse to run anything if the execution is pinned to a plan and the migrations changed since
+	if err := e.checkPinnedChecksums(ctx, sortedMigrations, schemaName); err != nil {
+		return nil, err
+	}
+
+	// Refuse to run anything a registered validator reports an error for
+	if err := e.checkValidators(ctx, sortedMigrations, schemaName); err != nil {
+		return nil, err
+	}
+
 	result := &ExecuteResult{
 		Applied: []string{},
 		Skipped: []string{},
@@ -829,6 +1142,11 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 	// Process each migration
 	logger.Infof("Starting execution of %d migration(s)", len(sortedMigrations))
 	for _, migration := range sortedMigrations {
+		// A canceled run or request starts no further migration
+		if cause := stopped(ctx); cause != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: not run: %v", e.getMigrationID(migration), cause))
+			continue
+		}
 		// Resolve schema name (use provided or from migration)
 		schema := schemaName
 		if schema == "" {
@@ -836,35 +1154,22 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 		}
 
 		// Determine migration ID based on schema requirements
-		// CRITICAL: If schemaName was explicitly provided, ALWAYS use schema-specific ID for per-schema tracking
-		// This ensures migrations are tracked per-schema in migrations_executions, not just globally in migrations_list
-		var migrationID string
-		if schemaName != "" && schema != "" {
-			// Schema was explicitly provided in request - ALWAYS use schema-specific ID
-			// This is required even if migration.Schema has a value, because the user wants to execute for a specific schema
-			migrationID = e.getMigrationIDWithSchema(migration, schema)
-			logger.Debug("Using schema-specific migration ID: %s (requested schema: %s, migration.Schema: %s)", migrationID, schema, migration.Schema)
-		} else if migration.Schema == "" {
-			// Dynamic schema mode: track per schema
-			// If schema is still empty, we can't track it properly - this is an error condition
-			if schema == "" {
-				if isAutoMigrateContext(ctx) {
-					logger.Infof("Skipping migration %s_%s: dynamic schema requires an explicit schema in the request (auto-migrate)", migration.Version, migration.Name)
-					continue
-				}
-				result.Errors = append(result.Errors, fmt.Sprintf("migration %s_%s has dynamic schema but no schema provided in request", migration.Version, migration.Name))
+		migrationID := e.executionMigrationID(migration, schemaName)
+		if migrationID == "" {
+			// Dynamic schema mode without a schema: we can't track it properly - this is an error condition
+			if isAutoMigrateContext(ctx) {
+				logger.Infof("Skipping migration %s_%s: dynamic schema requires an explicit schema in the request (auto-migrate)", migration.Version, migration.Name)
 				continue
 			}
-			migrationID = e.getMigrationIDWithSchema(migration, schema)
-		} else {
-			// Fixed schema mode: use base migration ID
-			migrationID = e.getMigrationID(migration)
+			result.Errors = append(result.Errors, fmt.Sprintf("migration %s_%s has dynamic schema but no schema provided in request", migration.Version, migration.Name))
+			continue
 		}
 
 		logger.Debug("Checking migration status: migrationID=%s, schema=%s, migration.Schema=%s, schemaName=%s", migrationID, schema, migration.Schema, schemaName)
 
-		// Check if already applied using the migration ID (which is schema-specific if schemaName was provided)
-		applied, err := e.stateTracker.IsMigrationApplied(ctx, migrationID)
+		// Check if already applied using the migration ID (which is schema-specific if schemaName was
+		// provided); repeatable migrations are applied again when they changed
+		applied, err := e.isUpToDate(ctx, migration, migrationID, schema)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to check migration status for %s: %v", migrationID, err))
 			continue
@@ -876,9 +1181,36 @@ func (e *Executor) executeSync(ctx context.Context, target *registry.MigrationTa
 			continue
 		}
 
+		// A squashed baseline is recorded, not executed, where the migrations it consolidates ran
+		superseded, err := e.squashSuperseded(ctx, migration)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+			continue
+		}
+		if superseded {
+			if !dryRun {
+				if err := e.recordSquash(ctx, migration, migrationID, schema); err != nil {
+					result.Errors = append(result.Errors, err.Error())
+					continue
+				}
+			}
+			result.Skipped = append(result.Skipped, migrationID)
+			continue
+		}
+
+		if migration.Declarative && !features.Enabled(ctx, features.Declarative) {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: desired-state migrations are disabled (feature flag %s)", migrationID, features.Declarative))
+			continue
+		}
+
 		// Execute migration
 		if dryRun {
 			result.Applied = append(result.Applied, fmt.Sprintf("%s (dry-run)", migrationID))
+			e.captureDryRunSQL(ctx, migration, migrationID, schema, migration.UpSQL, result)
+			hooks := e.dryRunHooks(ctx, migration, migrationID, result)
+			if features.Enabled(ctx, features.DeepDryRun) {
+				e.rehearse(ctx, migration, hooks, migrationID, schema, result)
+			}
 			continue
 		}
 
@@ -1070,13 +1402,13 @@ func (e *Executor) GetMigrationByID(migrationID string) *backends.MigrationScrip
 	return nil
 }
 
-// GetMigrationHistory retrieves migration history
-func (e *Executor) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
+// GetMigrationHistory retrieves migration history and the total number of matching records
+func (e *Executor) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
 	return e.stateTracker.GetMigrationHistory(ctx, filters)
 }
 
-// GetMigrationList retrieves the list of migrations with their last status
-func (e *Executor) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
+// GetMigrationList retrieves the list of migrations with their last status and the total number of matches
+func (e *Executor) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
 	return e.stateTracker.GetMigrationList(ctx, filters)
 }
 
@@ -1095,8 +1427,30 @@ func (e *Executor) GetRecentExecutions(ctx context.Context, limit int) ([]*state
 	return e.stateTracker.GetRecentExecutions(ctx, limit)
 }
 
-// RegisterScannedMigration registers a scanned migration in migrations_list
+// RecordAudit appends a record to the audit log. Standbys record audits too, as they refuse
+// writes with an error that belongs in the log.
+func (e *Executor) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	return e.stateTracker.RecordAudit(ctx, record)
+}
+
+// GetAuditLog retrieves audit records matching filters, ordered by created_at DESC, with the total
+// number of matching records
+func (e *Executor) GetAuditLog(ctx context.Context, filters *state.AuditFilters) ([]*state.AuditRecord, int, error) {
+	return e.stateTracker.GetAuditLog(ctx, filters)
+}
+
+// GetStateChanges returns the state changes after the cursor since, oldest first, at most limit of
+// them
+func (e *Executor) GetStateChanges(ctx context.Context, since int64, limit int) ([]*state.StateChange, error) {
+	return e.stateTracker.GetStateChanges(ctx, since, limit)
+}
+
+// RegisterScannedMigration registers a scanned migration in migrations_list. A standby leaves
+// migrations_list to the primary; it is reindexed once the standby is promoted.
 func (e *Executor) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	if e.IsStandby() {
+		return nil
+	}
 	return e.stateTracker.RegisterScannedMigration(ctx, migrationID, schema, table, version, name, connection, backend)
 }
 
@@ -1113,21 +1467,32 @@ type ReindexResult struct {
 	Total   int      `json:"total"`
 }
 
-// ReindexMigrations scans the filesystem and synchronizes the database with existing migration files
-func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*ReindexResult, error) {
+// ReindexMigrations scans the filesystem and synchronizes the database with existing migration files.
+// With several SFM roots, the migrations of all roots are kept; roots defining the same version
+// of a connection are refused like at load time.
+func (e *Executor) ReindexMigrations(ctx context.Context, sfmPaths ...string) (*ReindexResult, error) {
+	if e.IsStandby() {
+		return nil, ErrStandby
+	}
+
 	result := &ReindexResult{
 		Added:   []string{},
 		Removed: []string{},
 		Updated: []string{},
 	}
 
-	if sfmPath == "" {
+	if len(sfmPaths) == 0 || slices.Contains(sfmPaths, "") {
 		return nil, fmt.Errorf("SFM path is required for reindexing")
 	}
 
-	// Check if directory exists
-	if _, err := os.Stat(sfmPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("SFM directory does not exist: %s", sfmPath)
+	// Check if directories exist
+	for _, sfmPath := range sfmPaths {
+		if _, err := os.Stat(sfmPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("SFM directory does not exist: %s", sfmPath)
+		}
+	}
+	if err := checkSFMRootConflicts(sfmPaths); err != nil {
+		return nil, err
 	}
 
 	// Scan all migration files from filesystem
@@ -1139,74 +1504,81 @@ func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*Rein
 		name       string
 		filePath   string
 		schema     string
+		table      string
 	})
 
-	err := filepath.Walk(sfmPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	for _, sfmPath := range sfmPaths {
+		err := filepath.Walk(sfmPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
 
-		// Only process .go files
-		if !strings.HasSuffix(path, ".go") {
-			return nil
-		}
+			// Only process .go files
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
 
-		// Skip test files
-		if strings.HasSuffix(path, "_test.go") {
-			return nil
-		}
+			// Skip test files
+			if strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
 
-		// Verify directory structure: sfm/{backend}/{connection}/{version}_{name}.go
-		relPath, err := filepath.Rel(sfmPath, path)
-		if err != nil {
-			return nil // Skip files we can't process
-		}
+			// Verify directory structure: sfm/{backend}/{connection}/{version}_{name}.go
+			relPath, err := filepath.Rel(sfmPath, path)
+			if err != nil {
+				return nil // Skip files we can't process
+			}
 
-		parts := strings.Split(relPath, string(filepath.Separator))
-		if len(parts) < 3 {
-			return nil // Not in expected structure
-		}
+			parts := strings.Split(relPath, string(filepath.Separator))
+			if len(parts) < 3 {
+				return nil // Not in expected structure
+			}
 
-		filename := parts[len(parts)-1]
-		filenameWithoutExt := strings.TrimSuffix(filename, ".go")
+			filename := parts[len(parts)-1]
+			filenameWithoutExt := strings.TrimSuffix(filename, ".go")
 
-		// Verify filename format: {version}_{name}.go where version is 14 digits
-		versionRegex := regexp.MustCompile(`^(\d{14})_(.+)$`)
-		matches := versionRegex.FindStringSubmatch(filenameWithoutExt)
-		if len(matches) != 3 {
-			return nil // Skip files that don't match expected format
-		}
+			// Verify filename format: {version}_{name}.go where version is 14 digits
+			versionRegex := regexp.MustCompile(`^(\d{14})_(.+)$`)
+			matches := versionRegex.FindStringSubmatch(filenameWithoutExt)
+			if len(matches) != 3 {
+				return nil // Skip files that don't match expected format
+			}
 
-		version := matches[1]
-		name := matches[2]
-		backend := parts[0]
-		connection := parts[1]
+			version := matches[1]
+			name := matches[2]
+			backend := parts[0]
+			connection := parts[1]
 
-		// Extract schema from .go file (for reference, not used in ID)
-		schema := extractSchemaFromGoFile(path)
+			// Extract schema from .go file (for reference, not used in ID)
+			schema := extractSchemaFromGoFile(path)
 
-		// Generate migration ID using the same format as getMigrationID
-		// Format: {version}_{name}_{backend}_{connection}
-		migrationID := fmt.Sprintf("%s_%s_%s_%s", version, name, backend, connection)
+			// The table is declared by a "-- bfm-table:" line in the up script
+			table := upScriptTable(filepath.Dir(path), filenameWithoutExt, backend)
 
-		fileMigrations[migrationID] = struct {
-			backend    string
-			connection string
-			version    string
-			name       string
-			filePath   string
-			schema     string
-		}{backend, connection, version, name, path, schema}
+			// Generate migration ID using the same format as getMigrationID
+			// Format: {version}_{name}_{backend}_{connection}
+			migrationID := fmt.Sprintf("%s_%s_%s_%s", version, name, backend, connection)
 
-		return nil
-	})
+			fileMigrations[migrationID] = struct {
+				backend    string
+				connection string
+				version    string
+				name       string
+				filePath   string
+				schema     string
+				table      string
+			}{backend, connection, version, name, path, schema, table}
 
-	if err != nil {
-		return nil, fmt.Errorf("error scanning SFM directory: %w", err)
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning SFM directory: %w", err)
+		}
 	}
 
 	// Get all migrations from database
-	dbMigrations, err := e.stateTracker.GetMigrationList(ctx, nil)
+	dbMigrations, _, err := e.stateTracker.GetMigrationList(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get migrations from database: %w", err)
 	}
@@ -1221,7 +1593,7 @@ func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*Rein
 		dbMigration, exists := dbMigrationMap[migrationID]
 		if !exists {
 			// Register this migration with schema from .go file
-			if err := e.stateTracker.RegisterScannedMigration(ctx, migrationID, fileMigration.schema, "", fileMigration.version, fileMigration.name, fileMigration.connection, fileMigration.backend); err != nil {
+			if err := e.stateTracker.RegisterScannedMigration(ctx, migrationID, fileMigration.schema, fileMigration.table, fileMigration.version, fileMigration.name, fileMigration.connection, fileMigration.backend); err != nil {
 				// Log error but continue
 				fmt.Printf("Warning: Failed to register migration %s: %v\n", migrationID, err)
 			} else {
@@ -1238,8 +1610,9 @@ func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*Rein
 				updateSchema = fileMigration.schema
 			}
 
-			// Check if other fields differ (version, name, connection, backend)
-			if dbMigration.Version != fileMigration.version ||
+			// Check if other fields differ (table, version, name, connection, backend)
+			if dbMigration.Table != fileMigration.table ||
+				dbMigration.Version != fileMigration.version ||
 				dbMigration.Name != fileMigration.name ||
 				dbMigration.Connection != fileMigration.connection ||
 				dbMigration.Backend != fileMigration.backend {
@@ -1248,7 +1621,7 @@ func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*Rein
 
 			if needsUpdate {
 				// Update the migration metadata without affecting status/history
-				if err := e.UpdateMigrationInfo(ctx, migrationID, updateSchema, dbMigration.Table, fileMigration.version, fileMigration.name, fileMigration.connection, fileMigration.backend); err != nil {
+				if err := e.UpdateMigrationInfo(ctx, migrationID, updateSchema, fileMigration.table, fileMigration.version, fileMigration.name, fileMigration.connection, fileMigration.backend); err != nil {
 					fmt.Printf("Warning: Failed to update migration %s: %v\n", migrationID, err)
 				} else {
 					result.Updated = append(result.Updated, migrationID)
@@ -1306,11 +1679,21 @@ func (e *Executor) ReindexMigrations(ctx context.Context, sfmPath string) (*Rein
 	}
 
 	// Get updated count
-	updatedMigrations, err := e.stateTracker.GetMigrationList(ctx, nil)
+	updatedMigrations, _, err := e.stateTracker.GetMigrationList(ctx, nil)
 	if err == nil {
 		result.Total = len(updatedMigrations)
 	}
 
+	e.events.Publish(ctx, events.Event{
+		Type: events.ReindexCompleted,
+		Data: map[string]interface{}{
+			"added":   len(result.Added),
+			"removed": len(result.Removed),
+			"updated": len(result.Updated),
+			"total":   result.Total,
+		},
+	})
+
 	return result, nil
 }
 
@@ -1338,7 +1721,7 @@ func (e *Executor) CountPendingAutoMigratable(ctx context.Context, connectionNam
 			continue
 		}
 		id := e.getMigrationID(m)
-		applied, err := e.stateTracker.IsMigrationApplied(ctx, id)
+		applied, err := e.isUpToDate(ctx, m, id, "")
 		if err != nil {
 			return 0, err
 		}
@@ -1349,8 +1732,26 @@ func (e *Executor) CountPendingAutoMigratable(ctx context.Context, connectionNam
 	return n, nil
 }
 
-// ExecuteUp executes up migrations for the given schemas
+// ExecuteUp executes up migrations for the given schemas, or for the schemas of the connection's
+// database matching target.SchemaPattern. With several schemas, each is reported in
+// ExecuteResult.Schemas, and up to the number given WithSchemaConcurrency run at once.
 func (e *Executor) ExecuteUp(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemas []string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+	ctx = withRunID(ctx)
+	if target != nil && target.SchemaPattern != "" {
+		if len(schemas) > 0 {
+			return nil, fmt.Errorf("%w: schemas and a schema pattern cannot be combined", ErrInvalidSchema)
+		}
+		discovered, err := e.DiscoverSchemas(ctx, connectionName, target.SchemaPattern)
+		if err != nil {
+			return nil, err
+		}
+		schemas = discovered
+	}
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	fanOut := len(schemas) > 0
+
 	result := &ExecuteResult{
 		Applied: []string{},
 		Skipped: []string{},
@@ -1362,9 +1763,92 @@ func (e *Executor) ExecuteUp(ctx context.Context, target *registry.MigrationTarg
 		schemas = []string{""}
 	}
 
+	if PinnedChecksums(ctx) != nil || e.validates() {
+		migrations, err := e.registry.FindByTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find migrations: %w", err)
+		}
+		if err := e.verifyPinnedPlan(ctx, migrations, schemas, ignoreDependencies); err != nil {
+			return nil, err
+		}
+		if err := e.verifyValidators(ctx, migrations, schemas, ignoreDependencies); err != nil {
+			return nil, err
+		}
+	}
+
 	// Execute for each schema
+	concurrency := e.schemaConcurrency(ctx, connectionName, len(schemas))
+	for _, run := range e.executeSchemas(ctx, target, connectionName, schemas, dryRun, ignoreDependencies, concurrency) {
+		schema, schemaResult, err := run.schema, run.result, run.err
+		if err != nil && stopsExecution(err) {
+			return nil, err
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: %v", schema, err))
+			if fanOut {
+				result.Schemas = append(result.Schemas, SchemaResult{Schema: schema, Applied: []string{}, Skipped: []string{}, Errors: []string{err.Error()}})
+			}
+			continue
+		}
+		if fanOut {
+			result.Schemas = append(result.Schemas, SchemaResult{
+				Schema:  schema,
+				Success: len(schemaResult.Errors) == 0,
+				Applied: schemaResult.Applied,
+				Skipped: schemaResult.Skipped,
+				Errors:  schemaResult.Errors,
+			})
+		}
+
+		result.Applied = append(result.Applied, schemaResult.Applied...)
+		result.Skipped = append(result.Skipped, schemaResult.Skipped...)
+		result.Errors = append(result.Errors, schemaResult.Errors...)
+		result.ExecutedSQL = append(result.ExecutedSQL, schemaResult.ExecutedSQL...)
+		for migrationID, class := range schemaResult.FailureClasses {
+			result.classifyFailure(migrationID, class)
+		}
+	}
+
+	result.Success = len(result.Errors) == 0
+	return result, nil
+}
+
+// ExecuteUpIDs executes exactly the migrations listed in migrationIDs for the given schemas, in
+// dependency order. Unlike a target, which selects every matching migration of the connection, only
+// the listed migrations run, plus their pending dependencies unless ignoreDependencies is set. Every
+// ID must be registered and belong to connectionName.
+func (e *Executor) ExecuteUpIDs(ctx context.Context, migrationIDs []string, connectionName string, schemas []string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+	ctx = withRunID(ctx)
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	migrations, err := e.migrationsByIDs(migrationIDs, connectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExecuteResult{
+		Applied: []string{},
+		Skipped: []string{},
+		Errors:  []string{},
+	}
+
+	if len(schemas) == 0 {
+		schemas = []string{""}
+	}
+
+	if err := e.verifyPinnedPlan(ctx, migrations, schemas, ignoreDependencies); err != nil {
+		return nil, err
+	}
+	if err := e.verifyValidators(ctx, migrations, schemas, ignoreDependencies); err != nil {
+		return nil, err
+	}
+
 	for _, schema := range schemas {
-		schemaResult, err := e.executeSync(ctx, target, connectionName, schema, dryRun, ignoreDependencies)
+		schemaResult, err := e.executeMigrations(ctx, migrations, connectionName, schema, dryRun, ignoreDependencies)
+		if errors.Is(err, ErrPlanChanged) || errors.Is(err, ErrValidationFailed) {
+			return nil, err
+		}
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: %v", schema, err))
 			continue
@@ -1373,14 +1857,119 @@ func (e *Executor) ExecuteUp(ctx context.Context, target *registry.MigrationTarg
 		result.Applied = append(result.Applied, schemaResult.Applied...)
 		result.Skipped = append(result.Skipped, schemaResult.Skipped...)
 		result.Errors = append(result.Errors, schemaResult.Errors...)
+		result.ExecutedSQL = append(result.ExecutedSQL, schemaResult.ExecutedSQL...)
+		for migrationID, class := range schemaResult.FailureClasses {
+			result.classifyFailure(migrationID, class)
+		}
 	}
 
 	result.Success = len(result.Errors) == 0
 	return result, nil
 }
 
+// migrationsByIDs returns the registered migrations for migrationIDs, once each, checking that all of
+// them exist and belong to connection
+func (e *Executor) migrationsByIDs(migrationIDs []string, connection string) ([]*backends.MigrationScript, error) {
+	if len(migrationIDs) == 0 {
+		return nil, fmt.Errorf("migration_ids is empty")
+	}
+	if connection == "" {
+		return nil, fmt.Errorf("connection is required")
+	}
+
+	var migrations []*backends.MigrationScript
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, id := range migrationIDs {
+		m := e.GetMigrationByID(id)
+		if m == nil {
+			unknown = append(unknown, id)
+			continue
+		}
+		if m.Connection != connection {
+			return nil, fmt.Errorf("migration %s belongs to connection %q, expected %q", id, m.Connection, connection)
+		}
+		base := e.getMigrationID(m)
+		if !seen[base] {
+			seen[base] = true
+			migrations = append(migrations, m)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown migration_id(s): %s", strings.Join(unknown, ", "))
+	}
+	return migrations, nil
+}
+
+// executeMigrations executes the selected migrations for one schema under the connection lock
+func (e *Executor) executeMigrations(ctx context.Context, migrations []*backends.MigrationScript, connectionName string, schemaName string, dryRun bool, ignoreDependencies bool) (result *ExecuteResult, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "executor.ExecuteMigrations", trace.WithAttributes(
+		attribute.String("bfm.connection", connectionName),
+		attribute.String("bfm.schema", schemaName),
+		attribute.Int("bfm.migrations", len(migrations)),
+		attribute.Bool("bfm.dry_run", dryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Deep dry runs rehearse the scripts on the database, so they take the lock like executions
+	if dryRun && !features.Enabled(ctx, features.DeepDryRun) {
+		return e.executeMigrationsLocked(ctx, migrations, connectionName, schemaName, dryRun, ignoreDependencies)
+	}
+
+	err = e.withConnectionLock(ctx, connectionName, func() error {
+		var err error
+		result, err = e.executeMigrationsLocked(ctx, migrations, connectionName, schemaName, dryRun, ignoreDependencies)
+		return err
+	})
+	return result, err
+}
+
 // ExecuteDown executes down migrations for the given schemas
-func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas []string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
+func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas []string, dryRun bool, ignoreDependencies bool) (result *ExecuteResult, err error) {
+	ctx = withRunID(ctx)
+	ctx, span := tracing.Tracer().Start(ctx, "executor.ExecuteDown", trace.WithAttributes(
+		attribute.String("bfm.migration.id", migrationID),
+		attribute.Bool("bfm.dry_run", dryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	migration := e.GetMigrationByID(migrationID)
+	if migration == nil {
+		return e.executeDown(ctx, migrationID, schemas, dryRun, ignoreDependencies)
+	}
+
+	// Roll back the applied dependents first, without the connection lock as they may be on
+	// other connections
+	var cascaded []CascadedRollback
+	if cascades(ctx) && !ignoreDependencies {
+		cascaded, err = e.rollBackDependents(ctx, migration, schemas, true, dryRun)
+		if err != nil {
+			return &ExecuteResult{Success: false, Applied: []string{}, Skipped: []string{}, Errors: []string{err.Error()}, Cascaded: cascaded}, nil
+		}
+		// A dry run leaves the dependents applied
+		ignoreDependencies = dryRun
+	}
+
+	if dryRun {
+		result, err = e.executeDown(ctx, migrationID, schemas, dryRun, ignoreDependencies)
+	} else {
+		err = e.withConnectionLock(ctx, migration.Connection, func() error {
+			var err error
+			result, err = e.executeDown(ctx, migrationID, schemas, dryRun, ignoreDependencies)
+			return err
+		})
+	}
+	if result != nil {
+		result.Cascaded = cascaded
+	}
+	return result, err
+}
+
+// executeDown executes down migrations. Unless dryRun, the caller must hold the connection lock.
+func (e *Executor) executeDown(ctx context.Context, migrationID string, schemas []string, dryRun bool, ignoreDependencies bool) (*ExecuteResult, error) {
 	result := &ExecuteResult{
 		Applied: []string{},
 		Skipped: []string{},
@@ -1402,14 +1991,27 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 		}
 	}
 
+	// Refuse to strand applied dependents, unless dependencies are ignored
+	if !ignoreDependencies {
+		for _, schema := range schemas {
+			applied, err := e.stateTracker.IsMigrationApplied(ctx, e.getMigrationIDWithSchema(migration, schema))
+			if err != nil || !applied {
+				continue // Reported per schema below
+			}
+			if err := e.checkAppliedDependents(ctx, migration, schema); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Get connection config
-	connectionConfig, err := e.getConnectionConfig(migration.Connection)
+	connectionConfig, err := e.getConnectionConfig(ctx, migration.Connection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connection config: %w", err)
 	}
 
 	// Get backend
-	backend, ok := e.backends[connectionConfig.Backend]
+	backend, ok := e.executionBackend(ctx, connectionConfig.Backend)
 	if !ok {
 		return nil, fmt.Errorf("backend %s not registered", connectionConfig.Backend)
 	}
@@ -1422,6 +2024,10 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 
 	// Execute down migration for each schema
 	for _, schema := range schemas {
+		if cause := stopped(ctx); cause != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: not run: %v", schema, cause))
+			continue
+		}
 		// Check if migration is applied for this schema
 		schemaMigrationID := e.getMigrationIDWithSchema(migration, schema)
 		applied, err := e.stateTracker.IsMigrationApplied(ctx, schemaMigrationID)
@@ -1437,27 +2043,30 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 
 		if dryRun {
 			result.Applied = append(result.Applied, fmt.Sprintf("%s (dry-run)", schemaMigrationID))
+			e.captureDryRunSQL(ctx, migration, schemaMigrationID, schema, migration.DownSQL, result)
 			continue
 		}
 
 		// Execute down migration
-		if migration.DownSQL == "" {
+		if !migration.HasDown() {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: migration does not have rollback SQL", schema))
 			continue
 		}
 
 		// Apply template variable replacement to down SQL
-		downSQL, err := replaceTemplateVariables(migration.DownSQL, migration, schema)
+		templatePolicy := templatePolicyFromConnection(connectionConfig)
+		downSQL, _, err := renderTemplate(migration.DownSQL, migration, schema, templatePolicy)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: failed to replace template variables in DownSQL: %v", schema, err))
 			continue
 		}
+		logStatement(ctx, connectionConfig, schemaMigrationID, downSQL, e.statementCount(connectionConfig, downSQL), result)
 
 		// Apply template variable replacement to up SQL (used as DownSQL in rollback)
 		upSQL := migration.UpSQL
 		if upSQL != "" {
 			var err error
-			upSQL, err = replaceTemplateVariables(migration.UpSQL, migration, schema)
+			upSQL, _, err = renderTemplate(migration.UpSQL, migration, schema, templatePolicy)
 			if err != nil {
 				result.Errors = append(result.Errors, fmt.Sprintf("schema %s: failed to replace template variables in UpSQL: %v", schema, err))
 				continue
@@ -1466,16 +2075,24 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 
 		// Create a down migration script with schema
 		downMigration := &backends.MigrationScript{
-			Schema:     schema,
-			Version:    migration.Version,
-			Name:       migration.Name + "_down",
-			Connection: migration.Connection,
-			Backend:    migration.Backend,
-			UpSQL:      downSQL, // Use DownSQL as UpSQL for down migration
-			DownSQL:    upSQL,   // Use UpSQL as DownSQL
-		}
-
-		err = backend.ExecuteMigration(ctx, downMigration)
+			Schema:        schema,
+			Version:       migration.Version,
+			Name:          migration.Name + "_down",
+			Connection:    migration.Connection,
+			Backend:       migration.Backend,
+			UpSQL:         downSQL, // Use DownSQL as UpSQL for down migration
+			DownSQL:       upSQL,   // Use UpSQL as DownSQL
+			Declarative:   migration.Declarative,
+			Transactional: backends.IsTransactional(downSQL),
+			Reverts:       true,
+			UpFunc:        migration.DownFunc, // Runs instead of downSQL when set
+			DownFunc:      migration.UpFunc,
+			Timeout:       e.timeoutOf(migration),
+		}
+
+		start := time.Now()
+		err = executeOnBackend(ctx, metrics.DirectionDown, backend, downMigration)
+		e.publishExecution(ctx, state.OperationDown, migration, schemaMigrationID, schema, time.Since(start), err, nil)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: %v", schema, err))
 
@@ -1484,7 +2101,8 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 
 			// Record failed down migration
 			record := &state.MigrationRecord{
-				MigrationID:      schemaMigrationID + "_down",
+				MigrationID:      schemaMigrationID,
+				Operation:        state.OperationDown,
 				Schema:           schema,
 				Table:            "",
 				Version:          migration.Version,
@@ -1493,11 +2111,12 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 				Status:           "failed",
 				AppliedAt:        time.Now().Format(time.RFC3339),
 				ErrorMessage:     err.Error(),
+				ErrorClass:       string(backends.Classify(backend, err)),
 				ExecutedBy:       executedBy,
 				ExecutionMethod:  executionMethod,
 				ExecutionContext: executionContext,
 			}
-			_ = e.stateTracker.RecordMigration(ctx, record)
+			_ = e.stateTracker.RecordMigration(context.WithoutCancel(ctx), record)
 			continue
 		}
 
@@ -1506,7 +2125,8 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 
 		// Record successful down migration
 		record := &state.MigrationRecord{
-			MigrationID:      schemaMigrationID + "_down",
+			MigrationID:      schemaMigrationID,
+			Operation:        state.OperationDown,
 			Schema:           schema,
 			Table:            "",
 			Version:          migration.Version,
@@ -1519,7 +2139,7 @@ func (e *Executor) ExecuteDown(ctx context.Context, migrationID string, schemas
 			ExecutionMethod:  executionMethod,
 			ExecutionContext: executionContext,
 		}
-		if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
+		if err := e.stateTracker.RecordMigration(context.WithoutCancel(ctx), record); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: failed to record migration: %v", schema, err))
 		} else {
 			result.Applied = append(result.Applied, schemaMigrationID)
@@ -1543,8 +2163,60 @@ func (e *Executor) getMigrationIDWithSchema(migration *backends.MigrationScript,
 	return baseID
 }
 
+// executionMigrationID returns the ID a migration is tracked under when executed for schemaName.
+// If schemaName was explicitly provided, the schema-specific ID is ALWAYS used so that migrations are
+// tracked per-schema in migrations_executions, not just globally in migrations_list. Dynamic-schema
+// migrations are tracked per schema; without a schema they cannot be tracked and "" is returned.
+// Fixed-schema migrations use the base migration ID.
+func (e *Executor) executionMigrationID(migration *backends.MigrationScript, schemaName string) string {
+	if schemaName != "" {
+		return e.getMigrationIDWithSchema(migration, schemaName)
+	}
+	if migration.Schema == "" {
+		return ""
+	}
+	return e.getMigrationID(migration)
+}
+
 // Rollback rolls back a migration
-func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []string) (*RollbackResult, error) {
+func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []string) (result *RollbackResult, err error) {
+	ctx = withRunID(ctx)
+	ctx, span := tracing.Tracer().Start(ctx, "executor.Rollback", trace.WithAttributes(
+		attribute.String("bfm.migration.id", migrationID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	migration := e.GetMigrationByID(migrationID)
+	if migration == nil {
+		return nil, fmt.Errorf("migration not found: %s", migrationID)
+	}
+
+	// Roll back the applied dependents first, without the connection lock as they may be on
+	// other connections
+	var cascaded []CascadedRollback
+	if cascades(ctx) {
+		cascaded, err = e.rollBackDependents(ctx, migration, schemas, false, false)
+		if err != nil {
+			return &RollbackResult{Success: false, Message: "rollback failed", Applied: []string{}, Errors: []string{err.Error()}, Cascaded: cascaded}, nil
+		}
+	}
+
+	err = e.withConnectionLock(ctx, migration.Connection, func() error {
+		var err error
+		result, err = e.rollback(ctx, migrationID, schemas)
+		return err
+	})
+	if result != nil {
+		result.Cascaded = cascaded
+	}
+	return result, err
+}
+
+// rollback rolls back a migration. The caller must hold the connection lock.
+func (e *Executor) rollback(ctx context.Context, migrationID string, schemas []string) (*RollbackResult, error) {
 	// Get migration from registry
 	migration := e.GetMigrationByID(migrationID)
 	if migration == nil {
@@ -1562,14 +2234,21 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 		}
 	}
 
+	// Refuse to strand applied dependents
+	for _, schema := range schemasToUse {
+		if err := e.checkAppliedDependents(ctx, migration, schema); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get connection config
-	connectionConfig, err := e.getConnectionConfig(migration.Connection)
+	connectionConfig, err := e.getConnectionConfig(ctx, migration.Connection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connection config: %w", err)
 	}
 
 	// Get backend
-	backend, ok := e.backends[connectionConfig.Backend]
+	backend, ok := e.executionBackend(ctx, connectionConfig.Backend)
 	if !ok {
 		return nil, fmt.Errorf("backend %s not registered", connectionConfig.Backend)
 	}
@@ -1581,7 +2260,7 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 	defer func() { _ = backend.Close() }()
 
 	// Execute rollback SQL
-	if migration.DownSQL == "" {
+	if !migration.HasDown() {
 		return &RollbackResult{
 			Success: false,
 			Message: "migration does not have rollback SQL",
@@ -1596,6 +2275,10 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 
 	// Execute rollback for each schema
 	for _, schema := range schemasToUse {
+		if cause := stopped(ctx); cause != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: not run: %v", schema, cause))
+			continue
+		}
 		// Check if migration is applied for this schema by checking executions table
 		// This is more accurate than checking migrations_list since executions table tracks per-schema
 		baseMigrationID := e.getMigrationID(migration)
@@ -1637,24 +2320,33 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 
 		// Create a rollback migration script with the specific schema
 		rollbackMigration := &backends.MigrationScript{
-			Schema:     schema,
-			Version:    migration.Version,
-			Name:       migration.Name + "_rollback",
-			Connection: migration.Connection,
-			Backend:    migration.Backend,
-			UpSQL:      migration.DownSQL, // Use DownSQL as UpSQL for rollback
-			DownSQL:    migration.UpSQL,   // Use UpSQL as DownSQL for rollback
+			Schema:        schema,
+			Version:       migration.Version,
+			Name:          migration.Name + "_rollback",
+			Connection:    migration.Connection,
+			Backend:       migration.Backend,
+			UpSQL:         migration.DownSQL, // Use DownSQL as UpSQL for rollback
+			DownSQL:       migration.UpSQL,   // Use UpSQL as DownSQL for rollback
+			Declarative:   migration.Declarative,
+			Transactional: backends.IsTransactional(migration.DownSQL),
+			Reverts:       true,
+			UpFunc:        migration.DownFunc, // Runs instead of DownSQL when set
+			DownFunc:      migration.UpFunc,
+			Timeout:       e.timeoutOf(migration),
 		}
 
 		// Execute rollback
-		err = backend.ExecuteMigration(ctx, rollbackMigration)
+		start := time.Now()
+		err = executeOnBackend(ctx, metrics.DirectionDown, backend, rollbackMigration)
+		e.publishExecution(ctx, state.OperationRollback, migration, schemaMigrationID, schema, time.Since(start), err, nil)
 		if err != nil {
 			// Extract execution context
 			executedBy, executionMethod, executionContext := GetExecutionContext(ctx)
 
 			// Record failed rollback
 			record := &state.MigrationRecord{
-				MigrationID:      schemaMigrationID + "_rollback",
+				MigrationID:      schemaMigrationID,
+				Operation:        state.OperationRollback,
 				Schema:           schema,
 				Table:            "",
 				Version:          migration.Version,
@@ -1663,11 +2355,12 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 				Status:           "failed",
 				AppliedAt:        time.Now().Format(time.RFC3339),
 				ErrorMessage:     err.Error(),
+				ErrorClass:       string(backends.Classify(backend, err)),
 				ExecutedBy:       executedBy,
 				ExecutionMethod:  executionMethod,
 				ExecutionContext: executionContext,
 			}
-			_ = e.stateTracker.RecordMigration(ctx, record)
+			_ = e.stateTracker.RecordMigration(context.WithoutCancel(ctx), record)
 
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: %v", schema, err))
 			continue
@@ -1678,7 +2371,8 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 
 		// Record successful rollback
 		record := &state.MigrationRecord{
-			MigrationID:      schemaMigrationID + "_rollback",
+			MigrationID:      schemaMigrationID,
+			Operation:        state.OperationRollback,
 			Schema:           schema,
 			Table:            "",
 			Version:          migration.Version,
@@ -1691,7 +2385,7 @@ func (e *Executor) Rollback(ctx context.Context, migrationID string, schemas []s
 			ExecutionMethod:  executionMethod,
 			ExecutionContext: executionContext,
 		}
-		if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
+		if err := e.stateTracker.RecordMigration(context.WithoutCancel(ctx), record); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("schema %s: failed to record migration: %v", schema, err))
 		} else {
 			result.Applied = append(result.Applied, schemaMigrationID)
@@ -1718,6 +2412,8 @@ type RollbackResult struct {
 	Applied []string
 	Skipped []string
 	Errors  []string
+
+	Cascaded []CascadedRollback // Dependents rolled back first, in order, when requested with WithCascade
 }
 
 // HealthCheck performs health checks on the executor
@@ -1742,43 +2438,23 @@ type ExecuteResult struct {
 	Errors  []string
 	Queued  bool   // Whether the job was queued instead of executed
 	JobID   string // Job ID if queued
-}
 
-// replaceTemplateVariables replaces template variables in SQL/JSON content
-// Variables: {{.Connection}}, {{.Schema}}, {{.Backend}}, {{.Version}}
-// Note: Variable names are case-insensitive (e.g., {{.connection}} == {{.Connection}})
-func replaceTemplateVariables(content string, migration *backends.MigrationScript, schema string) (string, error) {
-	// Determine schema to use
-	schemaToUse := schema
-	if schemaToUse == "" {
-		schemaToUse = migration.Schema
-	}
+	ExecutedSQL []ExecutedSQL // Rendered SQL per migration, only when requested with WithCaptureSQL
 
-	// Create template data (using canonical case)
-	data := map[string]string{
-		"Connection": migration.Connection,
-		"Schema":     schemaToUse,
-		"Backend":    migration.Backend,
-		"Version":    migration.Version,
-	}
+	Schemas []SchemaResult // Outcome per schema, when ExecuteUp was given schemas or a schema pattern
 
-	// Normalize template variables to canonical case (first letter uppercase, rest lowercase)
-	// This makes the template variables case-insensitive
-	normalizedContent := normalizeTemplateVariables(content)
+	FailureClasses map[string]backends.FailureClass // Root cause of each failed migration, by migration ID
 
-	// Parse template
-	tmpl, err := template.New("migration").Parse(normalizedContent)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
+	Cascaded []CascadedRollback // Dependents rolled back first by ExecuteDown, in order, when requested with WithCascade
+}
 
-	// Execute template
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+// classifyFailure records the failure class of a migration and returns it for its history record
+func (r *ExecuteResult) classifyFailure(migrationID string, class backends.FailureClass) string {
+	if r.FailureClasses == nil {
+		r.FailureClasses = make(map[string]backends.FailureClass)
 	}
-
-	return buf.String(), nil
+	r.FailureClasses[migrationID] = class
+	return string(class)
 }
 
 // normalizeTemplateVariables normalizes template variable names to canonical case
@@ -1792,17 +2468,16 @@ func normalizeTemplateVariables(content string) string {
 		"version":    "Version",
 	}
 
-	// Regex to match template variables: {{.VariableName}}
-	re := regexp.MustCompile(`\{\{\.([A-Za-z][A-Za-z0-9_]*)\}\}`)
+	// Regex to match template variables: {{.VariableName}}, {{ .VariableName }}, {{- .VariableName -}}
+	re := regexp.MustCompile(`(\{\{-?\s*)\.([A-Za-z][A-Za-z0-9_]*)(\s*-?\}\})`)
 
 	return re.ReplaceAllStringFunc(content, func(match string) string {
-		// Extract the variable name (remove {{. and }})
-		varName := match[3 : len(match)-2]
+		parts := re.FindStringSubmatch(match)
 
 		// Convert to lowercase to look up canonical name
-		lowerVarName := strings.ToLower(varName)
+		lowerVarName := strings.ToLower(parts[2])
 		if canonicalName, exists := canonicalVars[lowerVarName]; exists {
-			return "{{." + canonicalName + "}}"
+			return parts[1] + "." + canonicalName + parts[3]
 		}
 
 		// If not in our canonical list, return as-is
@@ -1817,14 +2492,23 @@ func (e *Executor) getMigrationID(migration *backends.MigrationScript) string {
 }
 
 // getConnectionConfig gets connection config
-func (e *Executor) getConnectionConfig(connectionName string) (*backends.ConnectionConfig, error) {
+func (e *Executor) getConnectionConfig(ctx context.Context, connectionName string) (*backends.ConnectionConfig, error) {
 	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	config, ok := e.connections[connectionName]
+	resolver := e.secrets
+	e.mu.Unlock()
+
 	if !ok {
 		return nil, fmt.Errorf("connection %s not found", connectionName)
 	}
+	if resolver == nil {
+		return config, nil
+	}
 
-	return config, nil
+	// Secret references are resolved when the connection is used, from the resolver's cache
+	resolved, err := resolver.ResolveConnection(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("connection %s: %w", connectionName, err)
+	}
+	return resolved, nil
 }
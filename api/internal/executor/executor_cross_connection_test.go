@@ -7,68 +7,9 @@ import (
 	"github.com/toolsascode/bfm/api/internal/backends"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
 )
 
-// fakeStateTracker implements the minimal methods we need from state.StateTracker
-// for testing expandWithPendingDependencies without hitting a real database.
-type fakeStateTracker struct {
-	applied map[string]bool
-}
-
-func (f *fakeStateTracker) IsMigrationApplied(_ interface{}, migrationID string) (bool, error) {
-	return f.applied[migrationID], nil
-}
-
-func (f *fakeStateTracker) IsMigrationPendingOrApplied(_ interface{}, migrationID string) (bool, error) {
-	// For fake tracker, treat pending/applied the same as applied
-	return f.applied[migrationID], nil
-}
-
-// The remaining methods are not used in these tests; provide empty implementations
-// to satisfy the interface.
-
-func (f *fakeStateTracker) Initialize(_ interface{}) error                                { return nil }
-func (f *fakeStateTracker) RecordMigration(_ interface{}, _ *state.MigrationRecord) error { return nil }
-func (f *fakeStateTracker) RecordDependencyMigration(_ interface{}, _ *state.MigrationRecord) error {
-	return nil
-}
-func (f *fakeStateTracker) GetMigrationHistory(_ interface{}, _ *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) GetMigrationList(_ interface{}, _ *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) RegisterScannedMigration(_ interface{}, _ string, _ string, _ string, _ string, _ string, _ string, _ string) error {
-	return nil
-}
-func (f *fakeStateTracker) UpdateMigrationInfo(_ interface{}, _ string, _ string, _ string, _ string, _ string, _ string, _ string) error {
-	return nil
-}
-func (f *fakeStateTracker) GetLastMigrationVersion(_ interface{}, _ string, _ string) (string, error) {
-	return "", nil
-}
-func (f *fakeStateTracker) DeleteMigration(_ interface{}, _ string) error        { return nil }
-func (f *fakeStateTracker) ReindexMigrations(_ interface{}, _ interface{}) error { return nil }
-func (f *fakeStateTracker) GetMigrationDetail(_ interface{}, _ string) (*state.MigrationDetail, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) GetMigrationExecutions(_ interface{}, _ string) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) GetRecentExecutions(_ interface{}, _ int) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) RecordSkippedMigrations(_ interface{}, _ []string, _ string, _ string, _ string) error {
-	return nil
-}
-func (f *fakeStateTracker) GetSkippedMigrations(_ interface{}, _ string, _ int) ([]*state.SkippedMigration, error) {
-	return nil, nil
-}
-func (f *fakeStateTracker) WithMigrationExecutionLock(_ interface{}, _, _, _ string, fn func() error) error {
-	return fn()
-}
-func (f *fakeStateTracker) Close() error { return nil }
-
 // fakeRegistry provides a minimal Registry for the dependency resolver.
 type fakeRegistry struct {
 	migrations []*backends.MigrationScript
@@ -137,13 +78,9 @@ func TestExpandWithPendingDependenciesCrossConnection(t *testing.T) {
 	}
 
 	// State tracker reports that neither migration has been applied yet.
-	tracker := &fakeStateTracker{
-		applied: map[string]bool{},
-	}
-
 	exec := &Executor{
 		registry:     reg,
-		stateTracker: tracker,
+		stateTracker: testsupport.NewStateTracker(),
 	}
 
 	ctx := context.Background()
@@ -173,3 +110,65 @@ func TestExpandWithPendingDependenciesCrossConnection(t *testing.T) {
 		t.Fatalf("expanded set missing expected migrations: guard=%v core=%v", foundGuard, foundCore)
 	}
 }
+
+// TestExpandWithPendingDependenciesMinVersion verifies that a min_version dependency
+// auto-includes only the lowest pending migration at or above the version, and nothing
+// once a migration at or above the version is applied.
+func TestExpandWithPendingDependenciesMinVersion(t *testing.T) {
+	platformOld := &backends.MigrationScript{Version: "20250101000000", Name: "platform_init", Connection: "platform", Backend: "postgresql"}
+	platformBaseline := &backends.MigrationScript{Version: "20250301000000", Name: "platform_baseline", Connection: "platform", Backend: "postgresql"}
+	platformNext := &backends.MigrationScript{Version: "20250401000000", Name: "platform_next", Connection: "platform", Backend: "postgresql"}
+	module := &backends.MigrationScript{
+		Version:    "20250501000000",
+		Name:       "billing_init",
+		Connection: "billing",
+		Backend:    "postgresql",
+		StructuredDependencies: []backends.Dependency{
+			{Connection: "platform", Target: "20250215000000", TargetType: "min_version"},
+		},
+	}
+
+	reg := &fakeRegistry{
+		migrations: []*backends.MigrationScript{platformNext, platformOld, platformBaseline, module},
+	}
+	tracker := testsupport.NewStateTracker()
+	exec := &Executor{registry: reg, stateTracker: tracker}
+	ctx := context.Background()
+	apply := func(migration *backends.MigrationScript) {
+		record := &state.MigrationRecord{MigrationID: exec.getMigrationID(migration), Version: migration.Version, Connection: migration.Connection, Backend: migration.Backend, Status: "success"}
+		if err := tracker.RecordMigration(ctx, record); err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	expanded, dependencies, _, err := exec.expandWithPendingDependencies(ctx, []*backends.MigrationScript{module})
+	if err != nil {
+		t.Fatalf("expandWithPendingDependencies returned error: %v", err)
+	}
+	if len(expanded) != 2 || expanded[1] != platformBaseline || !dependencies[exec.getMigrationID(platformBaseline)] {
+		t.Fatalf("expected the module and platform_baseline, got %d migrations", len(expanded))
+	}
+
+	// A migration above the version is applied: the baseline is met
+	apply(platformNext)
+	expanded, _, _, err = exec.expandWithPendingDependencies(ctx, []*backends.MigrationScript{module})
+	if err != nil {
+		t.Fatalf("expandWithPendingDependencies returned error: %v", err)
+	}
+	if len(expanded) != 1 {
+		t.Fatalf("expected only the module once the baseline is applied, got %d migrations", len(expanded))
+	}
+
+	// Only an older migration is applied: the baseline is still missing
+	if err := tracker.DeleteMigration(ctx, exec.getMigrationID(platformNext)); err != nil {
+		t.Fatalf("DeleteMigration() error = %v", err)
+	}
+	apply(platformOld)
+	expanded, _, _, err = exec.expandWithPendingDependencies(ctx, []*backends.MigrationScript{module})
+	if err != nil {
+		t.Fatalf("expandWithPendingDependencies returned error: %v", err)
+	}
+	if len(expanded) != 2 || expanded[1] != platformBaseline {
+		t.Fatalf("expected platform_baseline to be included while only older migrations are applied, got %d migrations", len(expanded))
+	}
+}
@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
 	"github.com/toolsascode/bfm/api/internal/queue"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
 )
 
 // mockRegistry is a mock implementation of registry.Registry
@@ -120,254 +122,16 @@ func (m *mockRegistry) getMigrationID(migration *backends.MigrationScript) strin
 	return fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
 }
 
-// mockStateTracker is a mock implementation of state.StateTracker
-type mockStateTracker struct {
-	appliedMigrations             map[string]bool
-	history                       []*state.MigrationRecord
-	listItems                     []*state.MigrationListItem
-	healthCheckError              error
-	recordError                   error
-	isAppliedError                error
-	getMigrationListError         error
-	getMigrationHistoryError      error
-	registerScannedMigrationError error
-	updateMigrationInfoError      error
-	getMigrationExecutionsError   error
+// lastRecord returns the last history record of tracker
+func lastRecord(tracker *testsupport.StateTracker) *state.MigrationRecord {
+	history := tracker.History()
+	return history[len(history)-1]
 }
 
-func newMockStateTracker() *mockStateTracker {
-	return &mockStateTracker{
-		appliedMigrations: make(map[string]bool),
-		history:           make([]*state.MigrationRecord, 0),
-		listItems:         make([]*state.MigrationListItem, 0),
-	}
-}
-
-func (m *mockStateTracker) RecordMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	if m.recordError != nil {
-		return m.recordError
-	}
-	m.history = append(m.history, migration)
-	switch migration.Status {
-	case "success":
-		m.appliedMigrations[migration.MigrationID] = true
-	case "rolled_back":
-		m.appliedMigrations[migration.MigrationID] = false
-	}
-	return nil
-}
-
-func (m *mockStateTracker) RecordDependencyMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	if m.recordError != nil {
-		return m.recordError
-	}
-	// Requirement: Dependencies should NOT be recorded in history, only marked as applied
-	// Do NOT append to m.history - this is the key difference from RecordMigration
-	switch migration.Status {
-	case "success":
-		m.appliedMigrations[migration.MigrationID] = true
-	case "rolled_back":
-		m.appliedMigrations[migration.MigrationID] = false
-	}
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	if m.getMigrationHistoryError != nil {
-		return nil, m.getMigrationHistoryError
-	}
-	return m.history, nil
-}
-
-func (m *mockStateTracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	if m.getMigrationListError != nil {
-		return nil, m.getMigrationListError
-	}
-
-	// Apply filters if provided
-	if filters == nil {
-		return m.listItems, nil
-	}
-
-	var filtered []*state.MigrationListItem
-	for _, item := range m.listItems {
-		// Apply filters
-		if filters.Schema != "" && item.Schema != filters.Schema {
-			continue
-		}
-		if filters.Table != "" && item.Table != filters.Table {
-			continue
-		}
-		if filters.Connection != "" && item.Connection != filters.Connection {
-			continue
-		}
-		if filters.Backend != "" && item.Backend != filters.Backend {
-			continue
-		}
-		if filters.Status != "" && item.LastStatus != filters.Status {
-			continue
-		}
-		if filters.Version != "" && item.Version != filters.Version {
-			continue
-		}
-		filtered = append(filtered, item)
-	}
-
-	return filtered, nil
-}
-
-func (m *mockStateTracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool, error) {
-	if m.isAppliedError != nil {
-		return false, m.isAppliedError
-	}
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error) {
-	if m.isAppliedError != nil {
-		return false, m.isAppliedError
-	}
-	// For mock, treat pending/applied the same as applied
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error) {
-	return "", nil
-}
-
-func (m *mockStateTracker) RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	if m.registerScannedMigrationError != nil {
-		return m.registerScannedMigrationError
-	}
-	// Add to listItems so it appears in GetMigrationList
-	m.listItems = append(m.listItems, &state.MigrationListItem{
-		MigrationID: migrationID,
-		Schema:      schema,
-		Table:       table,
-		Version:     version,
-		Name:        name,
-		Connection:  connection,
-		Backend:     backend,
-		LastStatus:  "pending",
-		Applied:     false,
-	})
-	return nil
-}
-
-func (m *mockStateTracker) DeleteMigration(ctx interface{}, migrationID string) error {
-	// Remove from appliedMigrations
-	delete(m.appliedMigrations, migrationID)
-	// Remove from listItems
-	for i, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			m.listItems = append(m.listItems[:i], m.listItems[i+1:]...)
-			break
-		}
-	}
-	return nil
-}
-
-func (m *mockStateTracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	if m.updateMigrationInfoError != nil {
-		return m.updateMigrationInfoError
-	}
-	// Update listItems
-	for i, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			m.listItems[i].Schema = schema
-			m.listItems[i].Table = table
-			m.listItems[i].Version = version
-			m.listItems[i].Name = name
-			m.listItems[i].Connection = connection
-			m.listItems[i].Backend = backend
-			break
-		}
-	}
-	return nil
-}
-
-func (m *mockStateTracker) Initialize(ctx interface{}) error {
-	return m.healthCheckError
-}
-
-func (m *mockStateTracker) ReindexMigrations(ctx interface{}, registry interface{}) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationDetail(ctx interface{}, migrationID string) (*state.MigrationDetail, error) {
-	// Find migration in listItems
-	for _, item := range m.listItems {
-		if item.MigrationID == migrationID {
-			return &state.MigrationDetail{
-				MigrationID:            item.MigrationID,
-				Schema:                 item.Schema,
-				Version:                item.Version,
-				Name:                   item.Name,
-				Connection:             item.Connection,
-				Backend:                item.Backend,
-				UpSQL:                  "",
-				DownSQL:                "",
-				Dependencies:           []string{},
-				StructuredDependencies: []backends.Dependency{},
-				Status:                 item.LastStatus,
-			}, nil
-		}
-	}
-	return nil, nil
-}
-
-func (m *mockStateTracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([]*state.MigrationExecution, error) {
-	if m.getMigrationExecutionsError != nil {
-		return nil, m.getMigrationExecutionsError
-	}
-	// Check if this migration is applied
-	applied := m.appliedMigrations[migrationID]
-	if !applied {
-		return []*state.MigrationExecution{}, nil
-	}
-
-	// Parse migration ID to extract details: {version}_{name}_{backend}_{connection}
-	parts := strings.Split(migrationID, "_")
-	if len(parts) < 4 {
-		return []*state.MigrationExecution{}, nil
-	}
-
-	// Extract version, backend, and connection
-	version := parts[0]
-	backend := parts[len(parts)-2]
-	connection := parts[len(parts)-1]
-
-	// Return an execution record with applied=true
-	// Use empty schema since tests don't specify schemas
-	return []*state.MigrationExecution{
-		{
-			MigrationID: migrationID,
-			Schema:      "", // Empty schema for tests
-			Version:     version,
-			Connection:  connection,
-			Backend:     backend,
-			Status:      "applied",
-			Applied:     true,
-			AppliedAt:   time.Now().Format(time.RFC3339),
-			CreatedAt:   time.Now().Format(time.RFC3339),
-			UpdatedAt:   time.Now().Format(time.RFC3339),
-		},
-	}, nil
-}
-func (m *mockStateTracker) GetRecentExecutions(ctx interface{}, limit int) ([]*state.MigrationExecution, error) {
-	return []*state.MigrationExecution{}, nil
-}
-
-func (m *mockStateTracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*state.SkippedMigration, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) WithMigrationExecutionLock(_ interface{}, _, _, _ string, fn func() error) error {
-	return fn()
+// isApplied reports whether tracker has migrationID applied
+func isApplied(tracker *testsupport.StateTracker, migrationID string) bool {
+	applied, _ := tracker.IsMigrationApplied(context.Background(), migrationID)
+	return applied
 }
 
 // mockBackend is a mock implementation of backends.Backend
@@ -451,7 +215,7 @@ func (m *mockQueue) Close() error {
 
 func TestNewExecutor(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 
 	exec := NewExecutor(reg, tracker)
 
@@ -467,7 +231,7 @@ func TestNewExecutor(t *testing.T) {
 }
 
 func TestExecutor_SetConnections(t *testing.T) {
-	exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 
 	connections := map[string]*backends.ConnectionConfig{
 		"test": {
@@ -481,7 +245,7 @@ func TestExecutor_SetConnections(t *testing.T) {
 		t.Errorf("SetConnections() error = %v", err)
 	}
 
-	config, err := exec.GetConnectionConfig("test")
+	config, err := exec.GetConnectionConfig(context.Background(), "test")
 	if err != nil {
 		t.Errorf("GetConnectionConfig() error = %v", err)
 	}
@@ -497,7 +261,7 @@ func TestExecutor_SetConnections(t *testing.T) {
 }
 
 func TestExecutor_RegisterBackend(t *testing.T) {
-	exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 	backend := newMockBackend("postgresql")
 
 	exec.RegisterBackend("postgresql", backend)
@@ -509,7 +273,7 @@ func TestExecutor_RegisterBackend(t *testing.T) {
 }
 
 func TestExecutor_GetConnectionConfig(t *testing.T) {
-	exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 
 	connections := map[string]*backends.ConnectionConfig{
 		"test": {
@@ -540,7 +304,7 @@ func TestExecutor_GetConnectionConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			config, err := exec.GetConnectionConfig(tt.connName)
+			config, err := exec.GetConnectionConfig(context.Background(), tt.connName)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetConnectionConfig() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -559,7 +323,7 @@ func TestExecutor_GetConnectionConfig(t *testing.T) {
 
 func TestExecutor_GetMigrationByID(t *testing.T) {
 	reg := newMockRegistry()
-	exec := NewExecutor(reg, newMockStateTracker())
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
 
 	migration := &backends.MigrationScript{
 		Schema:     "public",
@@ -647,7 +411,7 @@ func TestGetExecutionContext(t *testing.T) {
 
 func TestExecutor_ExecuteSync_NoMigrations(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	target := &registry.MigrationTarget{
@@ -672,7 +436,7 @@ func TestExecutor_ExecuteSync_NoMigrations(t *testing.T) {
 
 func TestExecutor_ExecuteSync_AlreadyApplied(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -697,7 +461,7 @@ func TestExecutor_ExecuteSync_AlreadyApplied(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	target := &registry.MigrationTarget{
 		Connection: "test",
@@ -721,7 +485,7 @@ func TestExecutor_ExecuteSync_AlreadyApplied(t *testing.T) {
 
 func TestExecutor_ExecuteSync_DynamicSchema_NoSchema_ErrorWithoutAutoMigrateContext(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -766,7 +530,7 @@ func TestExecutor_ExecuteSync_DynamicSchema_NoSchema_ErrorWithoutAutoMigrateCont
 
 func TestExecutor_ExecuteSync_DynamicSchema_AutoMigrateContextSkips(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -816,7 +580,7 @@ func TestExecutor_ExecuteSync_DynamicSchema_AutoMigrateContextSkips(t *testing.T
 
 func TestExecutor_ExecuteSync_MixedFixedAndDynamic_AutoMigrateContext_AppliesFixedOnly(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	fixed := &backends.MigrationScript{
@@ -875,7 +639,7 @@ func TestExecutor_ExecuteSync_MixedFixedAndDynamic_AutoMigrateContext_AppliesFix
 
 func TestExecutor_ExecuteSync_DryRun(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -921,7 +685,7 @@ func TestExecutor_ExecuteSync_DryRun(t *testing.T) {
 
 func TestExecutor_ExecuteSync_BackendNotFound(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Register a migration so we actually try to execute it
@@ -959,7 +723,7 @@ func TestExecutor_ExecuteSync_BackendNotFound(t *testing.T) {
 
 func TestExecutor_ExecuteSync_ConnectionNotFound(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Register a migration so we actually try to execute it
@@ -989,7 +753,7 @@ func TestExecutor_ExecuteSync_ConnectionNotFound(t *testing.T) {
 
 func TestExecutor_ExecuteUp(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	target := &registry.MigrationTarget{
@@ -1011,7 +775,7 @@ func TestExecutor_ExecuteUp(t *testing.T) {
 
 func TestExecutor_ExecuteUp_WithSchemas(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	target := &registry.MigrationTarget{
@@ -1028,9 +792,54 @@ func TestExecutor_ExecuteUp_WithSchemas(t *testing.T) {
 	}
 }
 
+func TestExecutor_ExecuteUpIDs(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test":  {Backend: "postgresql", Host: "localhost"},
+		"other": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+
+	// hotfix depends on users; orders is pending but unrelated
+	for _, m := range []*backends.MigrationScript{
+		{Version: "20240101120000", Name: "users", Connection: "test"},
+		{Version: "20240102120000", Name: "orders", Connection: "test"},
+		{Version: "20240103120000", Name: "hotfix", Connection: "test", StructuredDependencies: []backends.Dependency{
+			{Connection: "test", Target: "users", TargetType: "name"},
+		}},
+		{Version: "20240104120000", Name: "audit", Connection: "other"},
+	} {
+		m.Schema, m.Backend, m.UpSQL, m.DownSQL = "public", "postgresql", "SELECT 1;", "SELECT 1;"
+		_ = reg.Register(m)
+	}
+	id := func(name string) string {
+		return exec.getMigrationID(reg.GetMigrationByName(name)[0])
+	}
+
+	if _, err := exec.ExecuteUpIDs(context.Background(), []string{id("hotfix"), "20240105120000_missing_postgresql_test"}, "test", nil, false, false); err == nil || !strings.Contains(err.Error(), "unknown migration_id(s): 20240105120000_missing_postgresql_test") {
+		t.Errorf("ExecuteUpIDs(unknown) error = %v", err)
+	}
+	if _, err := exec.ExecuteUpIDs(context.Background(), []string{id("audit")}, "test", nil, false, false); err == nil || !strings.Contains(err.Error(), `belongs to connection "other"`) {
+		t.Errorf("ExecuteUpIDs(other connection) error = %v", err)
+	}
+
+	result, err := exec.ExecuteUpIDs(context.Background(), []string{id("hotfix")}, "test", nil, false, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteUpIDs() = %+v, %v", result, err)
+	}
+	if got, want := strings.Join(result.Applied, ","), id("users")+","+id("hotfix"); got != want {
+		t.Errorf("applied = %s, want %s", got, want)
+	}
+	if isApplied(tracker, id("orders")) {
+		t.Error("unlisted migration orders was applied")
+	}
+}
+
 func TestExecutor_ExecuteDown_MigrationNotFound(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	_, err := exec.ExecuteDown(context.Background(), "nonexistent", []string{}, false, false)
@@ -1044,7 +853,7 @@ func TestExecutor_ExecuteDown_MigrationNotFound(t *testing.T) {
 
 func TestExecutor_ExecuteDown_NotApplied(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1085,7 +894,7 @@ func TestExecutor_ExecuteDown_NotApplied(t *testing.T) {
 
 func TestExecutor_ExecuteDown_Successful(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1110,7 +919,7 @@ func TestExecutor_ExecuteDown_Successful(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	result, err := exec.ExecuteDown(context.Background(), migrationID, []string{}, false, false)
 	if err != nil {
@@ -1132,7 +941,7 @@ func TestExecutor_ExecuteDown_Successful(t *testing.T) {
 
 func TestExecutor_ExecuteDown_WithSchemas(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1158,8 +967,8 @@ func TestExecutor_ExecuteDown_WithSchemas(t *testing.T) {
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
 	baseID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations["schema1_"+baseID] = true
-	tracker.appliedMigrations["schema2_"+baseID] = true
+	tracker.MarkApplied("schema1_" + baseID)
+	tracker.MarkApplied("schema2_" + baseID)
 
 	result, err := exec.ExecuteDown(context.Background(), migrationID, []string{"schema1", "schema2"}, false, false)
 	if err != nil {
@@ -1175,7 +984,7 @@ func TestExecutor_ExecuteDown_WithSchemas(t *testing.T) {
 
 func TestExecutor_ExecuteDown_NoDownSQL(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1200,7 +1009,7 @@ func TestExecutor_ExecuteDown_NoDownSQL(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	result, err := exec.ExecuteDown(context.Background(), migrationID, []string{}, false, false)
 	if err != nil {
@@ -1216,7 +1025,7 @@ func TestExecutor_ExecuteDown_NoDownSQL(t *testing.T) {
 
 func TestExecutor_ExecuteDown_ExecutionError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1242,7 +1051,7 @@ func TestExecutor_ExecuteDown_ExecutionError(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	result, err := exec.ExecuteDown(context.Background(), migrationID, []string{}, false, false)
 	if err != nil {
@@ -1261,8 +1070,8 @@ func TestExecutor_ExecuteDown_ExecutionError(t *testing.T) {
 
 func TestExecutor_ExecuteDown_CheckStatusError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.isAppliedError = errors.New("check failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("IsMigrationApplied", errors.New("check failed"))
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1302,7 +1111,7 @@ func TestExecutor_ExecuteDown_CheckStatusError(t *testing.T) {
 
 func TestExecutor_Rollback_MigrationNotFound(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	_, err := exec.Rollback(context.Background(), "nonexistent", []string{})
@@ -1316,7 +1125,7 @@ func TestExecutor_Rollback_MigrationNotFound(t *testing.T) {
 
 func TestExecutor_Rollback_NotApplied(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1360,8 +1169,8 @@ func TestExecutor_Rollback_NotApplied(t *testing.T) {
 
 func TestExecutor_Rollback_CheckStatusError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationExecutionsError = errors.New("check failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationExecutions", errors.New("check failed"))
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1404,7 +1213,7 @@ func TestExecutor_Rollback_CheckStatusError(t *testing.T) {
 
 func TestExecutor_Rollback_NoDownSQL(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1429,7 +1238,7 @@ func TestExecutor_Rollback_NoDownSQL(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	result, err := exec.Rollback(context.Background(), migrationID, []string{})
 	if err != nil {
@@ -1448,7 +1257,7 @@ func TestExecutor_Rollback_NoDownSQL(t *testing.T) {
 
 func TestExecutor_Rollback_Successful(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1473,10 +1282,10 @@ func TestExecutor_Rollback_Successful(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	// Mark migration as applied - this will make GetMigrationExecutions return an execution record
-	tracker.appliedMigrations[migrationID] = true
+	// Mark migration as applied on a schema, which gives it an execution to roll back
+	tracker.MarkApplied("public_" + migrationID)
 
-	result, err := exec.Rollback(context.Background(), migrationID, []string{})
+	result, err := exec.Rollback(context.Background(), migrationID, []string{"public"})
 	if err != nil {
 		t.Errorf("Rollback() error = %v", err)
 	}
@@ -1493,7 +1302,7 @@ func TestExecutor_Rollback_Successful(t *testing.T) {
 
 func TestExecutor_Rollback_ExecutionError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1519,10 +1328,10 @@ func TestExecutor_Rollback_ExecutionError(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	// Mark migration as applied - this will make GetMigrationExecutions return an execution record
-	tracker.appliedMigrations[migrationID] = true
+	// Mark migration as applied on a schema, which gives it an execution to roll back
+	tracker.MarkApplied("public_" + migrationID)
 
-	result, err := exec.Rollback(context.Background(), migrationID, []string{})
+	result, err := exec.Rollback(context.Background(), migrationID, []string{"public"})
 	if err != nil {
 		t.Errorf("Rollback() error = %v", err)
 	}
@@ -1541,7 +1350,7 @@ func TestExecutor_Rollback_ExecutionError(t *testing.T) {
 }
 
 func TestExecutor_HealthCheck(t *testing.T) {
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(newMockRegistry(), tracker)
 
 	err := exec.HealthCheck(context.Background())
@@ -1551,8 +1360,8 @@ func TestExecutor_HealthCheck(t *testing.T) {
 }
 
 func TestExecutor_HealthCheck_Error(t *testing.T) {
-	tracker := newMockStateTracker()
-	tracker.healthCheckError = errors.New("health check failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("Initialize", errors.New("health check failed"))
 	exec := NewExecutor(newMockRegistry(), tracker)
 
 	err := exec.HealthCheck(context.Background())
@@ -1565,14 +1374,14 @@ func TestExecutor_HealthCheck_Error(t *testing.T) {
 }
 
 func TestExecutor_SetQueue(t *testing.T) {
-	exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 	queue := newMockQueue()
 
 	exec.SetQueue(queue)
 
 	// Test that queue is used when executing
 	reg := newMockRegistry()
-	exec = NewExecutor(reg, newMockStateTracker())
+	exec = NewExecutor(reg, testsupport.NewStateTracker())
 	exec.SetQueue(queue)
 
 	connections := map[string]*backends.ConnectionConfig{
@@ -1614,7 +1423,7 @@ func TestExecutor_SetQueue(t *testing.T) {
 
 func TestExecutor_Execute_WithoutQueue(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	target := &registry.MigrationTarget{
@@ -1636,7 +1445,7 @@ func TestExecutor_Execute_WithoutQueue(t *testing.T) {
 
 func TestExecutor_Execute_QueueError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 	queue := newMockQueue()
 	queue.publishError = errors.New("queue error")
@@ -1674,7 +1483,7 @@ func TestExecutor_Execute_QueueError(t *testing.T) {
 }
 
 func TestExecutor_GetMigrationHistory(t *testing.T) {
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(newMockRegistry(), tracker)
 
 	record := &state.MigrationRecord{
@@ -1684,7 +1493,7 @@ func TestExecutor_GetMigrationHistory(t *testing.T) {
 	}
 	_ = tracker.RecordMigration(context.Background(), record)
 
-	history, err := exec.GetMigrationHistory(context.Background(), nil)
+	history, _, err := exec.GetMigrationHistory(context.Background(), nil)
 	if err != nil {
 		t.Errorf("GetMigrationHistory() error = %v", err)
 	}
@@ -1694,16 +1503,12 @@ func TestExecutor_GetMigrationHistory(t *testing.T) {
 }
 
 func TestExecutor_GetMigrationList(t *testing.T) {
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(newMockRegistry(), tracker)
 
-	item := &state.MigrationListItem{
-		MigrationID: "test_migration",
-		LastStatus:  "success",
-	}
-	tracker.listItems = append(tracker.listItems, item)
+	tracker.MarkApplied("20240101120000_test_migration_postgresql_test")
 
-	list, err := exec.GetMigrationList(context.Background(), nil)
+	list, _, err := exec.GetMigrationList(context.Background(), nil)
 	if err != nil {
 		t.Errorf("GetMigrationList() error = %v", err)
 	}
@@ -1713,10 +1518,10 @@ func TestExecutor_GetMigrationList(t *testing.T) {
 }
 
 func TestExecutor_IsMigrationApplied(t *testing.T) {
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(newMockRegistry(), tracker)
 
-	tracker.appliedMigrations["test_migration"] = true
+	tracker.MarkApplied("test_migration")
 
 	applied, err := exec.IsMigrationApplied(context.Background(), "test_migration")
 	if err != nil {
@@ -1736,7 +1541,7 @@ func TestExecutor_IsMigrationApplied(t *testing.T) {
 }
 
 func TestExecutor_RegisterScannedMigration(t *testing.T) {
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(newMockRegistry(), tracker)
 
 	err := exec.RegisterScannedMigration(
@@ -1756,7 +1561,7 @@ func TestExecutor_RegisterScannedMigration(t *testing.T) {
 
 func TestExecutor_GetAllMigrations(t *testing.T) {
 	reg := newMockRegistry()
-	exec := NewExecutor(reg, newMockStateTracker())
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
 
 	migration := &backends.MigrationScript{
 		Version:    "20240101120000",
@@ -1775,7 +1580,7 @@ func TestExecutor_GetAllMigrations(t *testing.T) {
 
 func TestExecutor_ExecuteSync_WithError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1822,7 +1627,7 @@ func TestExecutor_ExecuteSync_WithError(t *testing.T) {
 
 func TestExecutor_ExecuteSync_BackendConnectError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1861,7 +1666,7 @@ func TestExecutor_ExecuteSync_BackendConnectError(t *testing.T) {
 }
 
 func TestExecutor_GetMigrationID(t *testing.T) {
-	exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 
 	tests := []struct {
 		name      string
@@ -1894,7 +1699,7 @@ func TestExecutor_GetMigrationID(t *testing.T) {
 			// Access private method through GetMigrationByID which uses it
 			reg := newMockRegistry()
 			_ = reg.Register(tt.migration)
-			exec = NewExecutor(reg, newMockStateTracker())
+			exec = NewExecutor(reg, testsupport.NewStateTracker())
 
 			found := exec.GetMigrationByID(tt.want)
 			if found == nil {
@@ -1906,7 +1711,7 @@ func TestExecutor_GetMigrationID(t *testing.T) {
 
 func TestExecutor_GetMigrationIDWithSchema(t *testing.T) {
 	reg := newMockRegistry()
-	exec := NewExecutor(reg, newMockStateTracker())
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
 
 	migration := &backends.MigrationScript{
 		Connection: "test",
@@ -1931,8 +1736,8 @@ func TestExecutor_GetMigrationIDWithSchema(t *testing.T) {
 
 func TestExecutor_ExecuteSync_RecordMigrationError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.recordError = errors.New("record failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("RecordMigration", errors.New("record failed"))
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -1975,8 +1780,8 @@ func TestExecutor_ExecuteSync_RecordMigrationError(t *testing.T) {
 
 func TestExecutor_ExecuteDown_RecordMigrationError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.recordError = errors.New("record failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("RecordMigration", errors.New("record failed"))
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -2001,7 +1806,7 @@ func TestExecutor_ExecuteDown_RecordMigrationError(t *testing.T) {
 	exec.RegisterBackend("postgresql", backend)
 
 	migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
-	tracker.appliedMigrations[migrationID] = true
+	tracker.MarkApplied(migrationID)
 
 	result, err := exec.ExecuteDown(context.Background(), migrationID, []string{}, false, false)
 	if err != nil {
@@ -2018,7 +1823,7 @@ func TestExecutor_ExecuteDown_RecordMigrationError(t *testing.T) {
 func TestConvertTarget(t *testing.T) {
 	// Test convertTarget through Execute with queue
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 	queue := newMockQueue()
 	exec.SetQueue(queue)
@@ -2068,7 +1873,7 @@ func TestConvertTarget(t *testing.T) {
 func TestConvertTarget_Nil(t *testing.T) {
 	// Test convertTarget with nil target through Execute with queue
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 	queue := newMockQueue()
 	exec.SetQueue(queue)
@@ -2103,8 +1908,8 @@ func TestNewLoader(t *testing.T) {
 	if loader == nil {
 		t.Fatal("NewLoader() returned nil")
 	}
-	if loader.sfmPath != "/test/path" {
-		t.Errorf("Expected sfmPath = /test/path, got %v", loader.sfmPath)
+	if len(loader.sfmPaths) != 1 || loader.sfmPaths[0] != "/test/path" {
+		t.Errorf("Expected sfmPaths = [/test/path], got %v", loader.sfmPaths)
 	}
 	if loader.seenFiles == nil {
 		t.Error("Expected seenFiles map to be initialized")
@@ -2114,7 +1919,7 @@ func TestNewLoader(t *testing.T) {
 func TestLoader_SetExecutor(t *testing.T) {
 	loader := NewLoader("/test/path")
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	loader.SetExecutor(exec)
@@ -2127,7 +1932,7 @@ func TestLoader_SetExecutor(t *testing.T) {
 func TestExecutor_ExecuteSync_FindByTargetError(t *testing.T) {
 	reg := newMockRegistry()
 	reg.findByTargetError = errors.New("find failed")
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	target := &registry.MigrationTarget{
@@ -2146,8 +1951,8 @@ func TestExecutor_ExecuteSync_FindByTargetError(t *testing.T) {
 
 func TestExecutor_ExecuteSync_IsMigrationAppliedError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.isAppliedError = errors.New("check failed")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("IsMigrationApplied", errors.New("check failed"))
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -2190,7 +1995,7 @@ func TestExecutor_ExecuteSync_IsMigrationAppliedError(t *testing.T) {
 
 func TestExecutor_ExecuteSync_MultipleMigrations(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration1 := &backends.MigrationScript{
@@ -2243,7 +2048,7 @@ func TestExecutor_ExecuteSync_MultipleMigrations(t *testing.T) {
 
 func TestExecutor_ExecuteSync_WithSchema(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -2285,7 +2090,7 @@ func TestExecutor_ExecuteSync_WithSchema(t *testing.T) {
 
 func TestExecutor_ExecuteSync_WithStructuredDependencies(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Base migration
@@ -2319,7 +2124,7 @@ func TestExecutor_ExecuteSync_WithStructuredDependencies(t *testing.T) {
 	_ = reg.Register(dependentMigration)
 
 	// Mark base as applied
-	tracker.appliedMigrations[fmt.Sprintf("%s_%s_%s_%s", baseMigration.Version, baseMigration.Name, baseMigration.Backend, baseMigration.Connection)] = true
+	tracker.MarkApplied(fmt.Sprintf("%s_%s_%s_%s", baseMigration.Version, baseMigration.Name, baseMigration.Backend, baseMigration.Connection))
 
 	connections := map[string]*backends.ConnectionConfig{
 		"test": {
@@ -2356,7 +2161,7 @@ func TestExecutor_ExecuteSync_WithStructuredDependencies(t *testing.T) {
 
 func TestExecutor_ExecuteSync_WithSimpleDependencies(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Base migration
@@ -2384,7 +2189,7 @@ func TestExecutor_ExecuteSync_WithSimpleDependencies(t *testing.T) {
 	_ = reg.Register(dependentMigration)
 
 	// Mark base as applied
-	tracker.appliedMigrations[fmt.Sprintf("%s_%s_%s_%s", baseMigration.Version, baseMigration.Name, baseMigration.Backend, baseMigration.Connection)] = true
+	tracker.MarkApplied(fmt.Sprintf("%s_%s_%s_%s", baseMigration.Version, baseMigration.Name, baseMigration.Backend, baseMigration.Connection))
 
 	connections := map[string]*backends.ConnectionConfig{
 		"test": {
@@ -2417,7 +2222,7 @@ func TestExecutor_ExecuteSync_WithSimpleDependencies(t *testing.T) {
 
 func TestExecutor_ExecuteSync_MigrationWithSchema(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	migration := &backends.MigrationScript{
@@ -2460,7 +2265,7 @@ func TestExecutor_ExecuteSync_MigrationWithSchema(t *testing.T) {
 
 func TestExecutor_ExecuteSync_CircularDependency(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Create circular dependency: m1 -> m2 -> m1
@@ -2513,7 +2318,7 @@ func TestExecutor_ExecuteSync_CircularDependency(t *testing.T) {
 
 func TestExecutor_ExecuteSync_MissingDependency(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Migration with missing dependency
@@ -2555,7 +2360,7 @@ func TestExecutor_ExecuteSync_MissingDependency(t *testing.T) {
 
 func TestExecutor_ExecuteSync_BothDependencyTypes(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Base migration
@@ -2589,7 +2394,7 @@ func TestExecutor_ExecuteSync_BothDependencyTypes(t *testing.T) {
 	}
 	_ = reg.Register(hybrid)
 
-	tracker.appliedMigrations[fmt.Sprintf("%s_%s_%s_%s", base.Version, base.Name, base.Backend, base.Connection)] = true
+	tracker.MarkApplied(fmt.Sprintf("%s_%s_%s_%s", base.Version, base.Name, base.Backend, base.Connection))
 
 	connections := map[string]*backends.ConnectionConfig{
 		"test": {
@@ -2622,11 +2427,13 @@ func TestExecutor_ExecuteSync_BothDependencyTypes(t *testing.T) {
 
 func TestExecutor_UpdateMigrationInfo(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
+	tracker.MarkApplied("20240101120000_test_migration_postgresql_test_conn")
+
 	ctx := context.Background()
-	err := exec.UpdateMigrationInfo(ctx, "test_migration", "test_schema", "test_table", "20240101120000", "test_migration", "test_conn", "postgresql")
+	err := exec.UpdateMigrationInfo(ctx, "20240101120000_test_migration_postgresql_test_conn", "test_schema", "test_table", "20240101120000", "test_migration", "test_conn", "postgresql")
 	if err != nil {
 		t.Errorf("UpdateMigrationInfo() error = %v", err)
 	}
@@ -2634,7 +2441,7 @@ func TestExecutor_UpdateMigrationInfo(t *testing.T) {
 
 func TestExecutor_ReindexMigrations_EmptyPath(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
@@ -2649,7 +2456,7 @@ func TestExecutor_ReindexMigrations_EmptyPath(t *testing.T) {
 
 func TestExecutor_ReindexMigrations_NonExistentPath(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
@@ -2664,7 +2471,7 @@ func TestExecutor_ReindexMigrations_NonExistentPath(t *testing.T) {
 
 func TestExecutor_ReindexMigrations_Success(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
+	tracker := testsupport.NewStateTracker()
 	exec := NewExecutor(reg, tracker)
 
 	// Create a temporary directory structure
@@ -2706,12 +2513,12 @@ func init() {
 
 func TestExecutor_GetMigrationList_Error(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationListError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationList", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
-	_, err := exec.GetMigrationList(ctx, nil)
+	_, _, err := exec.GetMigrationList(ctx, nil)
 	if err == nil {
 		t.Error("Expected error from GetMigrationList, got nil")
 	}
@@ -2719,12 +2526,12 @@ func TestExecutor_GetMigrationList_Error(t *testing.T) {
 
 func TestExecutor_GetMigrationHistory_Error(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationHistoryError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationHistory", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
-	_, err := exec.GetMigrationHistory(ctx, nil)
+	_, _, err := exec.GetMigrationHistory(ctx, nil)
 	if err == nil {
 		t.Error("Expected error from GetMigrationHistory, got nil")
 	}
@@ -2732,8 +2539,8 @@ func TestExecutor_GetMigrationHistory_Error(t *testing.T) {
 
 func TestExecutor_IsMigrationApplied_Error(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.isAppliedError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("IsMigrationApplied", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
@@ -2745,8 +2552,8 @@ func TestExecutor_IsMigrationApplied_Error(t *testing.T) {
 
 func TestExecutor_RegisterScannedMigration_Error(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.registerScannedMigrationError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("RegisterScannedMigration", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
 	ctx := context.Background()
@@ -2758,12 +2565,14 @@ func TestExecutor_RegisterScannedMigration_Error(t *testing.T) {
 
 func TestExecutor_UpdateMigrationInfo_Error(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.updateMigrationInfoError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("UpdateMigrationInfo", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
+	tracker.MarkApplied("20240101120000_test_migration_postgresql_test_conn")
+
 	ctx := context.Background()
-	err := exec.UpdateMigrationInfo(ctx, "test_migration", "test_schema", "test_table", "20240101120000", "test_migration", "test_conn", "postgresql")
+	err := exec.UpdateMigrationInfo(ctx, "20240101120000_test_migration_postgresql_test_conn", "test_schema", "test_table", "20240101120000", "test_migration", "test_conn", "postgresql")
 	if err == nil {
 		t.Error("Expected error from UpdateMigrationInfo, got nil")
 	}
@@ -2771,8 +2580,8 @@ func TestExecutor_UpdateMigrationInfo_Error(t *testing.T) {
 
 func TestExecutor_ReindexMigrations_GetMigrationListError(t *testing.T) {
 	reg := newMockRegistry()
-	tracker := newMockStateTracker()
-	tracker.getMigrationListError = errors.New("database error")
+	tracker := testsupport.NewStateTracker()
+	tracker.Fail("GetMigrationList", errors.New("database error"))
 	exec := NewExecutor(reg, tracker)
 
 	tmpDir := t.TempDir()
@@ -2795,7 +2604,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	fixedID := "1_a_postgresql_core"
 
 	t.Run("empty registry", func(t *testing.T) {
-		exec := NewExecutor(newMockRegistry(), newMockStateTracker())
+		exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 0 {
 			t.Fatalf("got n=%d err=%v, want 0 nil", n, err)
@@ -2805,7 +2614,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	t.Run("fixed pending", func(t *testing.T) {
 		reg := newMockRegistry()
 		_ = reg.Register(fixed)
-		exec := NewExecutor(reg, newMockStateTracker())
+		exec := NewExecutor(reg, testsupport.NewStateTracker())
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 1 {
 			t.Fatalf("got n=%d err=%v, want 1 nil", n, err)
@@ -2815,8 +2624,8 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	t.Run("fixed applied", func(t *testing.T) {
 		reg := newMockRegistry()
 		_ = reg.Register(fixed)
-		tracker := newMockStateTracker()
-		tracker.appliedMigrations[fixedID] = true
+		tracker := testsupport.NewStateTracker()
+		tracker.MarkApplied(fixedID)
 		exec := NewExecutor(reg, tracker)
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 0 {
@@ -2827,7 +2636,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	t.Run("dynamic only does not count", func(t *testing.T) {
 		reg := newMockRegistry()
 		_ = reg.Register(dynamic)
-		exec := NewExecutor(reg, newMockStateTracker())
+		exec := NewExecutor(reg, testsupport.NewStateTracker())
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 0 {
 			t.Fatalf("got n=%d err=%v, want 0 nil", n, err)
@@ -2838,7 +2647,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 		reg := newMockRegistry()
 		_ = reg.Register(fixed)
 		_ = reg.Register(dynamic)
-		exec := NewExecutor(reg, newMockStateTracker())
+		exec := NewExecutor(reg, testsupport.NewStateTracker())
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 1 {
 			t.Fatalf("got n=%d err=%v, want 1 nil", n, err)
@@ -2852,7 +2661,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 			UpSQL: "SELECT 1",
 		}
 		_ = reg.Register(alias)
-		exec := NewExecutor(reg, newMockStateTracker())
+		exec := NewExecutor(reg, testsupport.NewStateTracker())
 		n, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err != nil || n != 1 {
 			t.Fatalf("got n=%d err=%v, want 1 nil", n, err)
@@ -2862,7 +2671,7 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	t.Run("FindByTarget error", func(t *testing.T) {
 		reg := newMockRegistry()
 		reg.findByTargetError = errors.New("boom")
-		exec := NewExecutor(reg, newMockStateTracker())
+		exec := NewExecutor(reg, testsupport.NewStateTracker())
 		_, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err == nil {
 			t.Fatal("expected error")
@@ -2872,8 +2681,8 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 	t.Run("IsMigrationApplied error", func(t *testing.T) {
 		reg := newMockRegistry()
 		_ = reg.Register(fixed)
-		tracker := newMockStateTracker()
-		tracker.isAppliedError = errors.New("db down")
+		tracker := testsupport.NewStateTracker()
+		tracker.Fail("IsMigrationApplied", errors.New("db down"))
 		exec := NewExecutor(reg, tracker)
 		_, err := exec.CountPendingAutoMigratable(context.Background(), "core", "postgresql")
 		if err == nil {
@@ -2881,3 +2690,104 @@ func TestExecutor_CountPendingAutoMigratable(t *testing.T) {
 		}
 	})
 }
+
+func TestExecutor_ExecuteSync_PublishesEvents(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	var got []events.Event
+	exec.Events().Subscribe(events.All, func(_ context.Context, e events.Event) {
+		got = append(got, e)
+	})
+
+	target := &registry.MigrationTarget{Connection: "test"}
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if len(got) != 3 || got[0].Type != events.MigrationStarted || got[1].Type != events.MigrationApplied || got[1].Connection != "test" ||
+		got[0].MigrationID != got[1].MigrationID {
+		t.Fatalf("expected migration.started, migration.applied then run.completed, got %v", got)
+	}
+	if _, ok := got[1].Data["elapsed_ms"].(int64); !ok {
+		t.Errorf("expected migration.applied to carry elapsed_ms, got %v", got[1].Data)
+	}
+	if applied, _ := got[2].Data["applied"].([]string); got[2].Type != events.RunCompleted || len(applied) != 1 || applied[0] != got[1].MigrationID {
+		t.Fatalf("expected run.completed with the applied migration, got %v", got[2])
+	}
+
+	got = nil
+	backend := newMockBackend("postgresql")
+	backend.executeError = errors.New("execution failed")
+	exec.RegisterBackend("postgresql", backend)
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "create_orders", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE orders (id INT);",
+	})
+	_, _ = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if len(got) != 2 || got[0].Type != events.MigrationStarted || got[1].Type != events.MigrationFailed || got[1].Data["error"] != "execution failed" {
+		t.Fatalf("expected migration.started, migration.failed and no run.completed, got %v", got)
+	}
+	if got[1].Data["operation"] != state.OperationUp || got[1].Data["backend"] != "postgresql" {
+		t.Errorf("expected migration.failed to carry the operation and backend, got %v", got[1].Data)
+	}
+}
+
+func TestExecutor_ExecuteDown_PublishesEvents(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	migration := &backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);", DownSQL: "DROP TABLE users;",
+	}
+	_ = reg.Register(migration)
+	tracker.MarkApplied(exec.getMigrationIDWithSchema(migration, "public"))
+
+	var got []events.Event
+	exec.Events().Subscribe(events.All, func(_ context.Context, e events.Event) {
+		got = append(got, e)
+	})
+	if result, err := exec.ExecuteDown(context.Background(), exec.getMigrationID(migration), nil, false, false); err != nil || !result.Success {
+		t.Fatalf("ExecuteDown() = %+v, %v", result, err)
+	}
+	if len(got) != 1 || got[0].Type != events.RollbackCompleted || got[0].Data["operation"] != state.OperationDown ||
+		got[0].MigrationID != exec.getMigrationIDWithSchema(migration, "public") {
+		t.Fatalf("expected migration.rolled_back for the down migration, got %v", got)
+	}
+
+	got = nil
+	tracker.MarkApplied(exec.getMigrationIDWithSchema(migration, "public"))
+	backend.executeError = errors.New("drop failed")
+	_, _ = exec.ExecuteDown(context.Background(), exec.getMigrationID(migration), nil, false, false)
+	if len(got) != 1 || got[0].Type != events.MigrationFailed || got[0].Data["error"] != "drop failed" || got[0].Data["operation"] != state.OperationDown {
+		t.Fatalf("expected migration.failed for the down migration, got %v", got)
+	}
+}
+
+func TestExecutor_ExecuteSync_Transactional(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if !backend.executeMigration.Transactional {
+		t.Errorf("expected migration to run in a transaction by default")
+	}
+
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "index_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "-- bfm:no-transaction\nCREATE INDEX CONCURRENTLY idx_users_id ON users (id);",
+	})
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if backend.executeMigration.Transactional {
+		t.Errorf("expected -- bfm:no-transaction to disable the transaction")
+	}
+}
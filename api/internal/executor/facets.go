@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Facet fields: the fields of migrations_list whose distinct values GetMigrationFacets counts
+const (
+	FacetConnection = "connection"
+	FacetBackend    = "backend"
+	FacetSchema     = "schema"
+	FacetVersion    = "version"
+	FacetTag        = "tag"
+)
+
+// FacetFields lists the facet fields
+var FacetFields = []string{FacetConnection, FacetBackend, FacetSchema, FacetVersion, FacetTag}
+
+// FacetValue is a distinct value of a field and the number of migrations having it
+type FacetValue struct {
+	Value string
+	Count int
+}
+
+// GetMigrationFacets returns the distinct values of each facet field among the migrations in
+// migrations_list, with their counts, sorted by value. A migration registered for several schemas
+// counts for each; tags come from the registry. The values of a field are counted among the
+// migrations matching the filters on the other fields, so a filter dropdown keeps offering the
+// alternatives to its own selection; the Table, Status and Label filters apply to every field.
+// Paging and ordering of filters are ignored.
+func (e *Executor) GetMigrationFacets(ctx context.Context, filters *state.MigrationFilters) (map[string][]FacetValue, error) {
+	if filters == nil {
+		filters = &state.MigrationFilters{}
+	}
+	items, _, err := e.stateTracker.GetMigrationList(ctx, &state.MigrationFilters{
+		Table:  filters.Table,
+		Status: filters.Status,
+		Label:  filters.Label,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]string)
+	for _, migration := range e.registry.GetAll() {
+		tags[e.getMigrationID(migration)] = migration.Tags
+	}
+
+	counts := make(map[string]map[string]int, len(FacetFields))
+	for _, field := range FacetFields {
+		counts[field] = make(map[string]int)
+	}
+	for _, item := range items {
+		schemas := splitSchemas(item.Schema)
+		values := map[string][]string{
+			FacetConnection: {item.Connection},
+			FacetBackend:    {item.Backend},
+			FacetSchema:     schemas,
+			FacetVersion:    {item.Version},
+			FacetTag:        tags[item.MigrationID],
+		}
+		for _, field := range FacetFields {
+			if !matchesFacetFilters(item, schemas, filters, field) {
+				continue
+			}
+			for _, value := range values[field] {
+				if value != "" {
+					counts[field][value]++
+				}
+			}
+		}
+	}
+
+	facets := make(map[string][]FacetValue, len(FacetFields))
+	for _, field := range FacetFields {
+		values := make([]FacetValue, 0, len(counts[field]))
+		for value, count := range counts[field] {
+			values = append(values, FacetValue{Value: value, Count: count})
+		}
+		slices.SortFunc(values, func(a, b FacetValue) int { return strings.Compare(a.Value, b.Value) })
+		facets[field] = values
+	}
+	return facets, nil
+}
+
+// matchesFacetFilters reports whether a migration matches the connection, backend, schema and
+// version filters, except the filter on field
+func matchesFacetFilters(item *state.MigrationListItem, schemas []string, filters *state.MigrationFilters, field string) bool {
+	if field != FacetConnection && filters.Connection != "" && item.Connection != filters.Connection {
+		return false
+	}
+	if field != FacetBackend && filters.Backend != "" && item.Backend != filters.Backend {
+		return false
+	}
+	if field != FacetSchema && filters.Schema != "" && !slices.Contains(schemas, filters.Schema) {
+		return false
+	}
+	if field != FacetVersion && filters.Version != "" && item.Version != filters.Version {
+		return false
+	}
+	return true
+}
+
+// splitSchemas returns the schemas of a migrations_list schema column, which holds one schema or
+// a comma-separated list
+func splitSchemas(schema string) []string {
+	var schemas []string
+	for _, s := range strings.Split(schema, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			schemas = append(schemas, s)
+		}
+	}
+	return schemas
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_GetMigrationFacets(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+
+	_ = reg.Register(&backends.MigrationScript{Version: "20240101000000", Name: "users", Connection: "core", Backend: "postgresql", Tags: []string{"ddl"}})
+	_ = reg.Register(&backends.MigrationScript{Version: "20240102000000", Name: "orders", Connection: "core", Backend: "postgresql", Tags: []string{"ddl", "billing"}})
+	ctx := context.Background()
+	_ = tracker.RegisterScannedMigration(ctx, "20240101000000_users_postgresql_core", "tenant_a,tenant_b", "", "20240101000000", "users", "core", "postgresql")
+	_ = tracker.RegisterScannedMigration(ctx, "20240102000000_orders_postgresql_core", "tenant_a", "", "20240102000000", "orders", "core", "postgresql")
+	_ = tracker.RegisterScannedMigration(ctx, "20240103000000_config_etcd_cache", "", "", "20240103000000", "config", "cache", "etcd")
+	tracker.MarkApplied("20240101000000_users_postgresql_core")
+
+	format := func(values []FacetValue) string {
+		return fmt.Sprint(values)
+	}
+
+	facets, err := exec.GetMigrationFacets(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetMigrationFacets() error = %v", err)
+	}
+	want := map[string]string{
+		FacetConnection: "[{cache 1} {core 2}]",
+		FacetBackend:    "[{etcd 1} {postgresql 2}]",
+		FacetSchema:     "[{tenant_a 2} {tenant_b 1}]",
+		FacetVersion:    "[{20240101000000 1} {20240102000000 1} {20240103000000 1}]",
+		FacetTag:        "[{billing 1} {ddl 2}]",
+	}
+	for field, values := range want {
+		if got := format(facets[field]); got != values {
+			t.Errorf("%s facet = %s, want %s", field, got, values)
+		}
+	}
+
+	// A field's own filter does not narrow its values; the other filters do
+	facets, err = exec.GetMigrationFacets(ctx, &state.MigrationFilters{Connection: "core", Schema: "tenant_b"})
+	if err != nil {
+		t.Fatalf("GetMigrationFacets() error = %v", err)
+	}
+	want = map[string]string{
+		FacetConnection: "[{core 1}]",
+		FacetBackend:    "[{postgresql 1}]",
+		FacetSchema:     "[{tenant_a 2} {tenant_b 1}]",
+		FacetTag:        "[{ddl 1}]",
+	}
+	for field, values := range want {
+		if got := format(facets[field]); got != values {
+			t.Errorf("filtered %s facet = %s, want %s", field, got, values)
+		}
+	}
+
+	// Status applies to every field
+	facets, _ = exec.GetMigrationFacets(ctx, &state.MigrationFilters{Status: "pending"})
+	if got := format(facets[FacetConnection]); got != "[{cache 1} {core 1}]" {
+		t.Errorf("pending connection facet = %s", got)
+	}
+}
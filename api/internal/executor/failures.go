@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// FailureClassCount is the number of failed executions with a root cause, and the latest of them
+type FailureClassCount struct {
+	Class  backends.FailureClass
+	Count  int
+	Latest *state.MigrationRecord
+}
+
+// GetFailureSummary counts the failed executions in history by root-cause class, among the records
+// matching the connection, backend, schema, version and applied_at filters, and returns the number
+// of failed executions. Records from before failure classification are classified by their error
+// message. Classes are ordered by count, most frequent first, then in the order of
+// backends.FailureClasses; those without failures are left out.
+func (e *Executor) GetFailureSummary(ctx context.Context, filters *state.MigrationFilters) ([]FailureClassCount, int, error) {
+	if filters == nil {
+		filters = &state.MigrationFilters{}
+	}
+	records, total, err := e.stateTracker.GetMigrationHistory(ctx, &state.MigrationFilters{
+		Schema:        filters.Schema,
+		Connection:    filters.Connection,
+		Backend:       filters.Backend,
+		Version:       filters.Version,
+		Status:        "failed",
+		AppliedAfter:  filters.AppliedAfter,
+		AppliedBefore: filters.AppliedBefore,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	counts := make(map[backends.FailureClass]*FailureClassCount)
+	for _, record := range records {
+		class := failureClassOf(record)
+		count, ok := counts[class]
+		if !ok {
+			// History is newest first: the first record of a class is its latest
+			count = &FailureClassCount{Class: class, Latest: record}
+			counts[class] = count
+		}
+		count.Count++
+	}
+
+	summary := make([]FailureClassCount, 0, len(counts))
+	for _, class := range backends.FailureClasses {
+		if count, ok := counts[class]; ok {
+			summary = append(summary, *count)
+		}
+	}
+	slices.SortStableFunc(summary, func(a, b FailureClassCount) int { return b.Count - a.Count })
+	return summary, total, nil
+}
+
+// failureClassOf returns the failure class of a failed history record
+func failureClassOf(record *state.MigrationRecord) backends.FailureClass {
+	if class := backends.FailureClass(record.ErrorClass); slices.Contains(backends.FailureClasses, class) {
+		return class
+	}
+	return backends.ClassifyMessage(record.ErrorMessage)
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// classifyingBackend classifies its execution errors as constraint violations
+type classifyingBackend struct {
+	*mockBackend
+}
+
+func (b *classifyingBackend) ClassifyError(err error) backends.FailureClass {
+	return backends.FailureConstraint
+}
+
+func TestExecutor_ClassifiesFailures(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	backend := newMockBackend("postgresql")
+	backend.executeError = errors.New(`ERROR: could not create unique index "users_email_key" (SQLSTATE 23505)`)
+	exec.RegisterBackend("postgresql", &classifyingBackend{mockBackend: backend})
+
+	result, err := exec.ExecuteSync(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	migrationID := "20240101120000_create_users_postgresql_test"
+	if result.FailureClasses[migrationID] != backends.FailureConstraint {
+		t.Errorf("FailureClasses = %v, want %s classified as a constraint violation", result.FailureClasses, migrationID)
+	}
+	last := lastRecord(tracker)
+	if last.Status != "failed" || last.ErrorClass != string(backends.FailureConstraint) {
+		t.Errorf("expected the failure to be recorded with its class, got %+v", last)
+	}
+
+	// Backends that do not classify their errors get the generic classification
+	backend.executeError = errors.New("read tcp 10.0.0.1:5432: i/o timeout")
+	exec.RegisterBackend("postgresql", backend)
+	result, _ = exec.ExecuteSync(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false)
+	if result.FailureClasses[migrationID] != backends.FailureConnectivity {
+		t.Errorf("FailureClasses = %v, want connectivity", result.FailureClasses)
+	}
+}
+
+func TestExecutor_GetFailureSummary(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+
+	for _, record := range []*state.MigrationRecord{
+		// Recorded before failure classification
+		{MigrationID: "m0", Status: "failed", ErrorMessage: "permission denied for schema public", AppliedAt: "2026-10-11T00:00:00Z"},
+		{MigrationID: "m1", Status: "failed", ErrorClass: "syntax", ErrorMessage: "syntax error at end of input", AppliedAt: "2026-10-12T00:00:00Z"},
+		{MigrationID: "m2", Status: "failed", ErrorClass: "connectivity", ErrorMessage: "connection refused", AppliedAt: "2026-10-13T00:00:00Z"},
+		{MigrationID: "m3", Status: "failed", ErrorClass: "syntax", ErrorMessage: "syntax error at or near \"CREAT\"", AppliedAt: "2026-10-14T00:00:00Z"},
+	} {
+		_ = tracker.RecordMigration(context.Background(), record)
+	}
+
+	summary, total, err := exec.GetFailureSummary(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetFailureSummary() error = %v", err)
+	}
+	if total != 4 || len(summary) != 3 {
+		t.Fatalf("GetFailureSummary() = %+v, %d", summary, total)
+	}
+	if summary[0].Class != backends.FailureSyntax || summary[0].Count != 2 || summary[0].Latest.MigrationID != "m3" {
+		t.Errorf("expected syntax first with 2 failures, latest m3, got %+v", summary[0])
+	}
+	// Ties keep the order of backends.FailureClasses
+	if summary[1].Class != backends.FailurePermission || summary[2].Class != backends.FailureConnectivity {
+		t.Errorf("unexpected order %v, %v", summary[1].Class, summary[2].Class)
+	}
+}
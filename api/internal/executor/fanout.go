@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaxSchemaConcurrency is the most schemas of a connection an up request runs at once unless
+// configured otherwise
+const DefaultMaxSchemaConcurrency = 10
+
+const (
+	schemaConcurrencyKey contextKey = "bfm_schema_concurrency"
+	backendInstancesKey  contextKey = "bfm_backend_instances"
+)
+
+// SchemaResult is the outcome of an up execution in one schema of a multi-schema request
+type SchemaResult struct {
+	Schema  string
+	Success bool
+	Applied []string
+	Skipped []string
+	Errors  []string
+}
+
+// WithSchemaConcurrency marks ctx so ExecuteUp runs up to n schemas at once (capped by the schema
+// policy's MaxConcurrency). Schemas run one at a time by default.
+func WithSchemaConcurrency(ctx context.Context, n int) context.Context {
+	if n <= 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, schemaConcurrencyKey, n)
+}
+
+// schemaConcurrency returns the schemas an execution may run at once on a connection: the requested
+// number, capped by the schema policy, and 1 unless the connection's backend can be cloned
+func (e *Executor) schemaConcurrency(ctx context.Context, connectionName string, schemas int) int {
+	n, _ := ctx.Value(schemaConcurrencyKey).(int)
+	if n <= 1 || schemas <= 1 {
+		return 1
+	}
+
+	e.mu.Lock()
+	limit := e.schemaPolicy.MaxConcurrency
+	e.mu.Unlock()
+	if limit > 0 && n > limit {
+		logger.Infof("Schema concurrency %d exceeds the limit, running %d schemas at once", n, limit)
+		n = limit
+	}
+	cfg, err := e.getConnectionConfig(ctx, connectionName)
+	if err != nil {
+		return 1
+	}
+	if backend, _ := e.registeredBackend(cfg.Backend); !isCloner(backend) {
+		logger.Infof("Backend %s cannot run schemas concurrently, running them one at a time", cfg.Backend)
+		return 1
+	}
+	return min(n, schemas)
+}
+
+// DiscoverSchemas returns the schemas of a connection's database whose name matches a SQL LIKE
+// pattern (e.g. "tenant_%"), sorted, to fan an execution out to. Schemas the schema policy refuses
+// (system schemas, names not matching its pattern) are left out.
+func (e *Executor) DiscoverSchemas(ctx context.Context, connectionName, pattern string) ([]string, error) {
+	cfg, err := e.getConnectionConfig(ctx, connectionName)
+	if err != nil {
+		return nil, err
+	}
+	backend, ok := e.registeredBackend(cfg.Backend)
+	if !ok {
+		return nil, fmt.Errorf("backend %s not registered", cfg.Backend)
+	}
+	if cloner, ok := backend.(backends.Cloner); ok {
+		backend = cloner.Clone()
+	}
+	lister, ok := backend.(backends.SchemaLister)
+	if !ok {
+		return nil, fmt.Errorf("%w: backend %s cannot discover schemas", ErrInvalidSchema, cfg.Backend)
+	}
+
+	if err := backend.Connect(cfg); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	names, err := lister.ListSchemas(ctx, pattern)
+	_ = backend.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	policy := e.schemaPolicy
+	e.mu.Unlock()
+	schemas := make([]string, 0, len(names))
+	for _, name := range names {
+		if err := policy.validateName(name); err != nil {
+			logger.Infof("Schema discovery on %s: skipping %v", connectionName, err)
+			continue
+		}
+		schemas = append(schemas, name)
+	}
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("%w: no schema matches %q on connection %s", ErrInvalidSchema, pattern, connectionName)
+	}
+	return schemas, nil
+}
+
+// schemaRun is the outcome of executeSync in one schema
+type schemaRun struct {
+	schema string
+	result *ExecuteResult
+	err    error
+}
+
+// executeSchemas runs executeSync in each schema, up to concurrency at once, and returns the
+// outcomes in the order of schemas. Concurrent schemas share one hold of the connection lock and
+// each use their own backend instances.
+func (e *Executor) executeSchemas(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemas []string, dryRun bool, ignoreDependencies bool, concurrency int) []schemaRun {
+	runs := make([]schemaRun, len(schemas))
+	if concurrency <= 1 {
+		for i, schema := range schemas {
+			result, err := e.executeSync(ctx, target, connectionName, schema, dryRun, ignoreDependencies)
+			runs[i] = schemaRun{schema: schema, result: result, err: err}
+			if err != nil && stopsExecution(err) {
+				return runs[:i+1]
+			}
+		}
+		return runs
+	}
+
+	logger.Infof("Executing %d schemas of %s, %d at a time", len(schemas), connectionName, concurrency)
+	err := e.withExecutionLock(ctx, connectionName, dryRun, func() error {
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i, schema := range schemas {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				ctx, span := tracing.Tracer().Start(ctx, "executor.ExecuteSync", trace.WithAttributes(
+					attribute.String("bfm.connection", connectionName),
+					attribute.String("bfm.schema", schema),
+					attribute.Bool("bfm.dry_run", dryRun),
+				))
+				ctx = context.WithValue(ctx, backendInstancesKey, make(map[string]backends.Backend))
+				result, err := e.executeSchemaLocked(ctx, target, connectionName, schema, dryRun, ignoreDependencies)
+				tracing.End(span, err)
+				runs[i] = schemaRun{schema: schema, result: result, err: err}
+			}()
+		}
+		wg.Wait()
+		return nil
+	})
+	if err != nil {
+		for i, schema := range schemas {
+			runs[i] = schemaRun{schema: schema, err: err}
+		}
+	}
+	return runs
+}
+
+// stopsExecution reports whether an error of one schema refuses the whole request
+func stopsExecution(err error) bool {
+	return errors.Is(err, ErrPlanChanged) || errors.Is(err, ErrValidationFailed)
+}
+
+// isCloner reports whether executions can get a backend instance of their own (see backends.Cloner)
+func isCloner(backend backends.Backend) bool {
+	_, ok := backend.(backends.Cloner)
+	return ok
+}
+
+// executionBackend returns the backend migrations of a connection are executed on. Concurrent
+// schema executions get an instance of their own for each backend that can be cloned.
+func (e *Executor) executionBackend(ctx context.Context, name string) (backends.Backend, bool) {
+	backend, ok := e.registeredBackend(name)
+	if !ok {
+		return nil, false
+	}
+	instances, _ := ctx.Value(backendInstancesKey).(map[string]backends.Backend)
+	if instances == nil {
+		return backend, true
+	}
+	if instance, ok := instances[name]; ok {
+		return instance, true
+	}
+	if cloner, ok := backend.(backends.Cloner); ok {
+		backend = cloner.Clone()
+	}
+	instances[name] = backend
+	return backend, true
+}
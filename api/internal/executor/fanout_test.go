@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// cloningBackend hands out itself as a clone; the in-memory backend is safe for concurrent use
+type cloningBackend struct {
+	*testsupport.Backend
+}
+
+func (b cloningBackend) Clone() backends.Backend {
+	return b
+}
+
+func TestExecutor_ExecuteUp_SchemaPattern(t *testing.T) {
+	reg := testsupport.NewRegistry(
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE users (id INT);"},
+		&backends.MigrationScript{Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE orders (id INT);"},
+	)
+	tracker := testsupport.NewStateTracker()
+	backend := testsupport.NewBackend("postgresql")
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	exec.RegisterBackend("postgresql", cloningBackend{backend})
+
+	ctx := context.Background()
+	for _, schema := range []string{"tenant_a", "tenant_b", "tenant_c", "billing"} {
+		_ = backend.CreateSchema(ctx, schema)
+	}
+
+	if _, err := exec.ExecuteUp(ctx, &registry.MigrationTarget{Connection: "core", SchemaPattern: "nobody_%"}, "core", nil, false, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ExecuteUp(no matching schema) error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.ExecuteUp(ctx, &registry.MigrationTarget{Connection: "core", SchemaPattern: "tenant_%"}, "core", []string{"tenant_a"}, false, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ExecuteUp(schemas and pattern) error = %v, want ErrInvalidSchema", err)
+	}
+
+	target := &registry.MigrationTarget{Connection: "core", SchemaPattern: "tenant_%"}
+	result, err := exec.ExecuteUp(WithSchemaConcurrency(ctx, 2), target, "core", nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUp() error = %v", err)
+	}
+	if !result.Success || len(result.Applied) != 6 {
+		t.Fatalf("expected both migrations applied in 3 schemas, got %+v", result)
+	}
+	var schemas []string
+	for _, schemaResult := range result.Schemas {
+		schemas = append(schemas, schemaResult.Schema)
+		if !schemaResult.Success || len(schemaResult.Applied) != 2 {
+			t.Errorf("unexpected result for %s: %+v", schemaResult.Schema, schemaResult)
+		}
+	}
+	if want := []string{"tenant_a", "tenant_b", "tenant_c"}; !slices.Equal(schemas, want) {
+		t.Errorf("Schemas = %v, want %v", schemas, want)
+	}
+
+	// The discovered schemas are applied, so a second run skips everything
+	result, err = exec.ExecuteUp(ctx, target, "core", nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUp() error = %v", err)
+	}
+	if len(result.Applied) != 0 || len(result.Skipped) != 6 {
+		t.Errorf("expected everything skipped, got %+v", result)
+	}
+}
+
+// poolBackend mimics the SQL backends: each clone has a pool of its own, opened by Connect and
+// dropped by Close, that ExecuteMigration needs. Executions and schemas are shared. Connections
+// whose Database is set only run their own migrations.
+type poolBackend struct {
+	*testsupport.Backend
+	pool *backends.ConnectionConfig
+}
+
+func (b *poolBackend) Clone() backends.Backend {
+	return &poolBackend{Backend: b.Backend}
+}
+
+func (b *poolBackend) Connect(config *backends.ConnectionConfig) error {
+	b.pool = config
+	return nil
+}
+
+func (b *poolBackend) Close() error {
+	b.pool = nil
+	return nil
+}
+
+func (b *poolBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	if b.pool == nil {
+		return errors.New("pool is closed")
+	}
+	if b.pool.Database != "" && b.pool.Database != migration.Connection {
+		return fmt.Errorf("%s ran on the pool of %s", migration.Connection, b.pool.Database)
+	}
+	return b.Backend.ExecuteMigration(ctx, migration)
+}
+
+// Concurrent schemas validating dependencies must each connect their own instance: one closing a
+// shared instance would close the pool the others run on (run with -race)
+func TestExecutor_ExecuteUp_SchemaPatternConcurrentInstances(t *testing.T) {
+	reg := testsupport.NewRegistry(
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE users (id INT);"},
+		&backends.MigrationScript{Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE orders (id INT);"},
+	)
+	backend := testsupport.NewBackend("postgresql")
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	exec.RegisterBackend("postgresql", &poolBackend{Backend: backend})
+
+	ctx := context.Background()
+	schemas := []string{"tenant_a", "tenant_b", "tenant_c", "tenant_d", "tenant_e", "tenant_f"}
+	for _, schema := range schemas {
+		_ = backend.CreateSchema(ctx, schema)
+	}
+
+	target := &registry.MigrationTarget{Connection: "core", SchemaPattern: "tenant_%"}
+	result, err := exec.ExecuteUp(WithSchemaConcurrency(ctx, 3), target, "core", nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUp() error = %v", err)
+	}
+	if !result.Success || len(result.Applied) != 2*len(schemas) {
+		t.Errorf("expected both migrations applied in %d schemas, got %+v", len(schemas), result)
+	}
+}
+
+func TestExecutor_SchemaConcurrency(t *testing.T) {
+	exec := NewExecutor(testsupport.NewRegistry(), testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core":  {Backend: "postgresql"},
+		"plain": {Backend: "greptimedb"},
+	})
+	exec.RegisterBackend("postgresql", cloningBackend{testsupport.NewBackend("postgresql")})
+	exec.RegisterBackend("greptimedb", testsupport.NewBackend("greptimedb"))
+	ctx := WithSchemaConcurrency(context.Background(), 50)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		connection string
+		schemas    int
+		want       int
+	}{
+		{"not requested", context.Background(), "core", 20, 1},
+		{"capped by the policy", ctx, "core", 20, DefaultMaxSchemaConcurrency},
+		{"capped by the schemas", ctx, "core", 3, 3},
+		{"backend without clones", ctx, "plain", 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exec.schemaConcurrency(tt.ctx, tt.connection, tt.schemas); got != tt.want {
+				t.Errorf("schemaConcurrency() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
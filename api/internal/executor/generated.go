@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// Migration sources, deciding which registration wins when a migration is registered from a
+// compiled .go file (its init function) and also found as SFM scripts by the loader
+const (
+	// SourceGo keeps the compiled registration and skips the scripts. The loader generates a
+	// .go file for scripts that have none, as the CLI build does.
+	SourceGo = "go"
+	// SourceScripts replaces the compiled registration with the one loaded from the scripts.
+	// The loader loads scripts without generating .go files; existing .go files are only
+	// read for their metadata (schema, dependencies, tags).
+	SourceScripts = "scripts"
+)
+
+// generatedGoFileRe matches the init registration written by the .go file template
+var generatedGoFileRe = regexp.MustCompile(`migrations\.GlobalRegistry\.Register\(\s*migration\s*\)`)
+
+// SetSource sets which registration wins when a migration comes from both a compiled .go file
+// and SFM scripts (SourceGo or SourceScripts)
+func (l *Loader) SetSource(source string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.source = source
+}
+
+// shouldRegister reports whether the loader registers migration, warning when the registry
+// already holds it from another source such as a compiled .go file
+func (l *Loader) shouldRegister(migrationID string, migration *backends.MigrationScript) bool {
+	l.mu.RLock()
+	ownRegistration := l.loaded[migrationID]
+	source := l.source
+	l.mu.RUnlock()
+	if ownRegistration {
+		return true
+	}
+
+	existing := registeredMigration(l.registry, migration)
+	if existing == nil {
+		return true
+	}
+
+	detail := ""
+	if existing.UpSQL != migration.UpSQL {
+		detail = " and their up scripts differ"
+	}
+	if source == SourceGo {
+		logger.Warnf("Migration %s is registered from a compiled .go file and also found in the SFM scripts%s; keeping the .go registration (migration source %q)", migrationID, detail, source)
+		return false
+	}
+	logger.Warnf("Migration %s is registered from a compiled .go file and also found in the SFM scripts%s; the scripts replace it (migration source %q)", migrationID, detail, source)
+	return true
+}
+
+// registeredMigration returns the migration with the same version, name, backend and
+// connection already in reg, or nil
+func registeredMigration(reg registry.Registry, migration *backends.MigrationScript) *backends.MigrationScript {
+	for _, candidate := range reg.GetMigrationByConnectionAndVersion(migration.Connection, migration.Version) {
+		if candidate.Name == migration.Name && registry.BackendNamesMatch(candidate.Backend, migration.Backend) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// RedundantGoFiles returns the generated .go files under sfmPath that add nothing to their
+// scripts: the up script exists, and the file declares no schema, dependencies or tags other
+// than the up script's bfm-tags. Loading from the scripts alone registers the same migration,
+// so these files can be removed when scripts are the source of truth. Hand-written files and
+// files carrying metadata are never reported.
+func RedundantGoFiles(sfmPath string) ([]string, error) {
+	var redundant []string
+	err := filepath.Walk(sfmPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sfmPath, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(relPath, string(filepath.Separator))
+		if len(parts) < 3 {
+			return nil
+		}
+		baseName := strings.TrimSuffix(parts[len(parts)-1], ".go")
+		if !versionedNameRe.MatchString(baseName) {
+			return nil
+		}
+
+		ok, err := isRedundantGoFile(path, baseName, parts[0])
+		if err != nil {
+			return err
+		}
+		if ok {
+			redundant = append(redundant, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning SFM directory: %w", err)
+	}
+	return redundant, nil
+}
+
+// versionedNameRe matches {version}_{name} where version is 14 digits
+var versionedNameRe = regexp.MustCompile(`^\d{14}_.+$`)
+
+// isRedundantGoFile reports whether the .go file at path is generated and carries no metadata
+// beyond its scripts
+func isRedundantGoFile(path, baseName, backend string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !generatedGoFileRe.Match(content) {
+		return false, nil
+	}
+
+	dir := filepath.Dir(path)
+	upFile := filepath.Join(dir, baseName+".up"+migrationScriptExtension(dir, baseName, backend))
+	upSQL, err := os.ReadFile(upFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %w", upFile, err)
+	}
+
+	if extractSchemaFromGoFile(path) != "" ||
+		len(extractDependenciesFromGoFile(path)) > 0 ||
+		len(extractStructuredDependenciesFromGoFile(path)) > 0 {
+		return false, nil
+	}
+	if tags := extractTagsFromGoFile(path); len(tags) > 0 {
+		scriptTags, err := parseBFMTagsFromUpSQL(string(upSQL))
+		if err != nil || strings.Join(tags, ",") != strings.Join(scriptTags, ",") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// RemoveRedundantGoFiles deletes the files reported by RedundantGoFiles and returns them.
+// With dryRun the files are only reported.
+func RemoveRedundantGoFiles(sfmPath string, dryRun bool) ([]string, error) {
+	files, err := RedundantGoFiles(sfmPath)
+	if err != nil || dryRun {
+		return files, err
+	}
+	for i, file := range files {
+		if err := os.Remove(file); err != nil {
+			return files[:i], fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+	return files, nil
+}
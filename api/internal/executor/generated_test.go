@@ -0,0 +1,199 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+const testGeneratedGoFile = `package core
+
+import (
+	_ "embed"
+
+	"github.com/toolsascode/bfm/api/migrations"
+)
+
+//go:embed %[1]s.up.sql
+var upSQL string
+
+//go:embed %[1]s.down.sql
+var downSQL string
+
+func init() {
+	migration := &migrations.MigrationScript{
+		Schema:                 "%[2]s",
+		Version:                "20240101120000",
+		Name:                   "create_users",
+		Connection:             "core",
+		Backend:                "postgresql",
+		UpSQL:                  upSQL,
+		DownSQL:                downSQL,
+		Dependencies:           []string{},
+		StructuredDependencies: []migrations.Dependency{},
+	}
+	migrations.GlobalRegistry.Register(migration)
+}
+`
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_Source(t *testing.T) {
+	compiled := &backends.MigrationScript{
+		Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);",
+	}
+
+	for _, tt := range []struct {
+		source     string
+		wantUpSQL  string
+		wantGoFile bool
+	}{
+		{SourceGo, "CREATE TABLE users (id INT);", true},
+		{SourceScripts, "CREATE TABLE users (id BIGINT);", false},
+	} {
+		t.Run(tt.source, func(t *testing.T) {
+			sfm := t.TempDir()
+			dir := filepath.Join(sfm, "postgresql", "core")
+			writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.up.sql"), "CREATE TABLE users (id BIGINT);")
+			writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.down.sql"), "DROP TABLE users;")
+
+			reg := registry.NewInMemoryRegistry()
+			_ = reg.Register(compiled)
+
+			loader := NewLoader(sfm)
+			loader.SetSource(tt.source)
+			if err := loader.LoadAll(reg); err != nil {
+				t.Fatalf("LoadAll() error = %v", err)
+			}
+
+			all := reg.GetAll()
+			if len(all) != 1 {
+				t.Fatalf("expected 1 registered migration, got %d", len(all))
+			}
+			if all[0].UpSQL != tt.wantUpSQL {
+				t.Errorf("registered UpSQL = %q, want %q", all[0].UpSQL, tt.wantUpSQL)
+			}
+
+			_, err := os.Stat(filepath.Join(dir, "20240101120000_create_users.go"))
+			if gotGoFile := err == nil; gotGoFile != tt.wantGoFile {
+				t.Errorf(".go file generated = %v, want %v", gotGoFile, tt.wantGoFile)
+			}
+		})
+	}
+}
+
+func TestLoader_ReloadsOwnRegistration(t *testing.T) {
+	sfm := t.TempDir()
+	upFile := filepath.Join(sfm, "postgresql", "core", "20240101120000_create_users.up.sql")
+	writeTestFile(t, upFile, "CREATE TABLE users (id INT);")
+	writeTestFile(t, strings.Replace(upFile, ".up.", ".down.", 1), "DROP TABLE users;")
+
+	reg := registry.NewInMemoryRegistry()
+	loader := NewLoader(sfm)
+	loader.SetSource(SourceGo)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	// A modified script replaces the loader's own registration whatever the source
+	writeTestFile(t, upFile, "CREATE TABLE users (id BIGINT);")
+	if err := loader.loadMigrationFromFile(strings.Replace(upFile, ".up.sql", ".go", 1), "postgresql", "core", "20240101120000", "create_users"); err != nil {
+		t.Fatalf("loadMigrationFromFile() error = %v", err)
+	}
+	if got := reg.GetAll()[0].UpSQL; got != "CREATE TABLE users (id BIGINT);" {
+		t.Errorf("expected the reloaded script, got %q", got)
+	}
+}
+
+func TestRedundantGoFiles(t *testing.T) {
+	sfm := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(sfm, rel)
+		writeTestFile(t, path, content)
+		return path
+	}
+	generated := func(baseName, schema string) string {
+		return fmt.Sprintf(testGeneratedGoFile, baseName, schema)
+	}
+
+	// Generated, no metadata, scripts present: redundant
+	write("postgresql/core/20240101120000_create_users.up.sql", "CREATE TABLE users (id INT);")
+	redundant := write("postgresql/core/20240101120000_create_users.go", generated("20240101120000_create_users", ""))
+
+	// Generated but pins a schema: kept
+	write("postgresql/core/20240102120000_create_orders.up.sql", "CREATE TABLE orders (id INT);")
+	write("postgresql/core/20240102120000_create_orders.go", generated("20240102120000_create_orders", "core"))
+
+	// Generated but its up script is gone: kept
+	write("postgresql/core/20240103120000_orphan.go", generated("20240103120000_orphan", ""))
+
+	// Hand-written Go file: kept
+	write("postgresql/core/20240104120000_custom.up.sql", "SELECT 1;")
+	write("postgresql/core/20240104120000_custom.go", "package core\n")
+
+	files, err := RemoveRedundantGoFiles(sfm, true)
+	if err != nil {
+		t.Fatalf("RemoveRedundantGoFiles(dry run) error = %v", err)
+	}
+	if len(files) != 1 || files[0] != redundant {
+		t.Fatalf("expected only %s, got %v", redundant, files)
+	}
+	if _, err := os.Stat(redundant); err != nil {
+		t.Fatalf("dry run removed the file: %v", err)
+	}
+
+	if _, err := RemoveRedundantGoFiles(sfm, false); err != nil {
+		t.Fatalf("RemoveRedundantGoFiles() error = %v", err)
+	}
+	if _, err := os.Stat(redundant); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", redundant)
+	}
+	if files, _ := RedundantGoFiles(sfm); len(files) != 0 {
+		t.Errorf("expected no redundant files left, got %v", files)
+	}
+}
+
+func TestLoader_TableDirective(t *testing.T) {
+	sfm := t.TempDir()
+	dir := filepath.Join(sfm, "postgresql", "core")
+	writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.up.sql"), "-- bfm-tags: team=identity\n-- bfm-table: users\nCREATE TABLE users (id INT);")
+	writeTestFile(t, filepath.Join(dir, "20240101120000_create_users.down.sql"), "DROP TABLE users;")
+	writeTestFile(t, filepath.Join(dir, "20240102120000_seed.up.sql"), "INSERT INTO users VALUES (1);")
+	writeTestFile(t, filepath.Join(dir, "20240102120000_seed.down.sql"), "DELETE FROM users;")
+
+	reg := registry.NewInMemoryRegistry()
+	loader := NewLoader(sfm)
+	loader.SetSource(SourceScripts)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	found, err := reg.FindByTarget(&registry.MigrationTarget{Connection: "core", Tables: []string{"users"}})
+	if err != nil {
+		t.Fatalf("FindByTarget() error = %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "create_users" {
+		t.Fatalf("expected only create_users, got %d migrations", len(found))
+	}
+	if got := upScriptTable(dir, "20240101120000_create_users", "postgresql"); got != "users" {
+		t.Errorf("upScriptTable() = %q, want users", got)
+	}
+
+	if _, err := reg.FindByTarget(&registry.MigrationTarget{Connection: "core", Tables: []string{"user"}}); err == nil {
+		t.Error("expected an error for a table no migration declares")
+	}
+}
@@ -0,0 +1,268 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// ExtraHooksFile is the hooks document run around every migration of a connection
+// ({CONNECTION}_HOOKS_FILE), in the format of backends.ParseHooks
+const ExtraHooksFile = "HOOKS_FILE"
+
+// ExtraHookEnv lists the environment variables of the server passed on to shell hooks and snapshot
+// commands ({CONNECTION}_HOOK_ENV, comma-separated). Other variables, tokens and credentials among
+// them, are not.
+const ExtraHookEnv = "HOOK_ENV"
+
+// hookBaseEnv are the environment variables of the server every command run for a migration gets
+var hookBaseEnv = []string{"PATH", "HOME"}
+
+// HooksExtension is the extension of the hooks sidecar of a migration ({version}_{name}.hooks.yaml,
+// next to its scripts)
+const HooksExtension = ".hooks.yaml"
+
+// HookURLTimeout bounds each call to a URL hook
+const HookURLTimeout = 10 * time.Second
+
+// Hook phases
+const (
+	HookPre  = "pre"
+	HookPost = "post"
+)
+
+// maxHookOutput is how much of the output of a failing shell hook is kept in its error
+const maxHookOutput = 1024
+
+// HookNotification is the JSON body POSTed to URL hooks
+type HookNotification struct {
+	Phase       string `json:"phase"` // pre or post
+	MigrationID string `json:"migration_id"`
+	Connection  string `json:"connection"`
+	Schema      string `json:"schema,omitempty"`
+	Time        string `json:"time"` // RFC 3339
+}
+
+// readHooksSidecar returns the hooks of a migration's sidecar file, or none when it has no sidecar
+func readHooksSidecar(path string) (backends.Hooks, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return backends.Hooks{}, nil
+	}
+	if err != nil {
+		return backends.Hooks{}, err
+	}
+	return backends.ParseHooks(data)
+}
+
+// connectionHooks returns the hooks of the connection's hooks file, read at every execution so
+// changes apply without a restart
+func connectionHooks(cfg *backends.ConnectionConfig) (backends.Hooks, error) {
+	path := cfg.ExtraValue(ExtraHooksFile)
+	if path == "" {
+		return backends.Hooks{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return backends.Hooks{}, fmt.Errorf("failed to read hooks file: %w", err)
+	}
+	hooks, err := backends.ParseHooks(data)
+	if err != nil {
+		return backends.Hooks{}, fmt.Errorf("failed to parse hooks file %s: %w", path, err)
+	}
+	return hooks, nil
+}
+
+// hooksOf returns the hooks run around migration on the connection cfg: the connection's pre hooks
+// then the migration's, and the migration's post hooks then the connection's
+func hooksOf(cfg *backends.ConnectionConfig, migration *backends.MigrationScript) (backends.Hooks, error) {
+	connection, err := connectionHooks(cfg)
+	if err != nil {
+		return backends.Hooks{}, err
+	}
+	return backends.Hooks{
+		Pre:  append(append([]backends.Hook{}, connection.Pre...), migration.Hooks.Pre...),
+		Post: append(append([]backends.Hook{}, migration.Hooks.Post...), connection.Post...),
+	}, nil
+}
+
+// runHooks runs the hooks of a phase for migration, tracked as migrationID in schema, in order and
+// stops at the first that fails. SQL hooks are rendered like the migration's scripts and executed
+// on backend, which must be connected to cfg.
+func runHooks(ctx context.Context, phase string, hooks []backends.Hook, backend backends.Backend, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID, schema string) error {
+	for i, hook := range hooks {
+		logger.Infof("Running %s hook %d (%s) of %s", phase, i+1, hook.Kind(), migrationID)
+		var err error
+		switch hook.Kind() {
+		case backends.HookSQL:
+			err = runSQLHook(ctx, hook.SQL, backend, cfg, migration, phase, schema)
+		case backends.HookShell:
+			err = runShellHook(ctx, hook.Shell, phase, cfg, migration, migrationID, schema)
+		case backends.HookURL:
+			err = runURLHook(ctx, hook.URL, phase, migration, migrationID, schema)
+		default:
+			err = fmt.Errorf("set exactly one of sql, shell and url")
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %d (%s): %w", phase, i+1, hook.Kind(), err)
+		}
+	}
+	return nil
+}
+
+// runSQLHook executes the SQL of a hook on backend, in a transaction unless it opts out like a script
+func runSQLHook(ctx context.Context, sql string, backend backends.Backend, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, phase, schema string) error {
+	rendered, _, err := renderTemplate(sql, migration, schema, templatePolicyFromConnection(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to replace template variables: %w", err)
+	}
+	return backend.ExecuteMigration(ctx, &backends.MigrationScript{
+		Schema:        schema,
+		Version:       migration.Version,
+		Name:          migration.Name + "_" + phase + "_hook",
+		Connection:    migration.Connection,
+		Backend:       migration.Backend,
+		UpSQL:         rendered,
+		Transactional: backends.IsTransactional(rendered),
+	})
+}
+
+// migrationEnv returns the environment of commands run for a migration on the connection cfg: PATH,
+// HOME and the variables its ExtraHookEnv lists, with the migration described in BFM_MIGRATION_ID,
+// BFM_CONNECTION, BFM_BACKEND, BFM_SCHEMA and BFM_VERSION
+func migrationEnv(cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID, schema string) []string {
+	var env []string
+	for _, name := range append(append([]string{}, hookBaseEnv...), splitList(cfg.ExtraValue(ExtraHookEnv))...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return append(env,
+		"BFM_MIGRATION_ID="+migrationID,
+		"BFM_CONNECTION="+migration.Connection,
+		"BFM_BACKEND="+migration.Backend,
+		"BFM_SCHEMA="+schema,
+		"BFM_VERSION="+migration.Version,
+	)
+}
+
+// runShellHook runs command with sh -c, in the migration's environment (see migrationEnv) with
+// BFM_HOOK_PHASE
+func runShellHook(ctx context.Context, command, phase string, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID, schema string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(migrationEnv(cfg, migration, migrationID, schema), "BFM_HOOK_PHASE="+phase)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if len(out) > maxHookOutput {
+			out = out[len(out)-maxHookOutput:]
+		}
+		if out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// runURLHook POSTs a HookNotification to url and fails unless it answers with a 2xx status
+func runURLHook(ctx context.Context, url, phase string, migration *backends.MigrationScript, migrationID, schema string) error {
+	body, err := json.Marshal(HookNotification{
+		Phase:       phase,
+		MigrationID: migrationID,
+		Connection:  migration.Connection,
+		Schema:      schema,
+		Time:        time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, HookURLTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+// listHooks logs the hooks a dry run of migration skips: dry runs never run hooks, and deep dry
+// runs rehearse the SQL ones with the up script (see rehearsalScript)
+func listHooks(hooks backends.Hooks, migrationID string) {
+	for i, hook := range hooks.Pre {
+		logger.Infof("Dry run: would run pre hook %d (%s) of %s", i+1, hook.Kind(), migrationID)
+	}
+	for i, hook := range hooks.Post {
+		logger.Infof("Dry run: would run post hook %d (%s) of %s", i+1, hook.Kind(), migrationID)
+	}
+}
+
+// rehearsalScript returns the rendered upSQL surrounded by the SQL pre and post hooks, for a deep
+// dry run to rehearse them in the same rolled-back transaction. Each hook is rendered for
+// migration in schema as runSQLHook renders it, so the rehearsal runs the SQL a real run would.
+func rehearsalScript(hooks backends.Hooks, upSQL string, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, schema string) (string, error) {
+	policy := templatePolicyFromConnection(cfg)
+	var parts []string
+	add := func(phase string, i int, hook backends.Hook) error {
+		if hook.SQL == "" {
+			return nil
+		}
+		sql, _, err := renderTemplate(hook.SQL, migration, schema, policy)
+		if err != nil {
+			return fmt.Errorf("%s hook %d (%s): failed to replace template variables: %w", phase, i+1, hook.Kind(), err)
+		}
+		sql = strings.TrimSpace(sql)
+		if sql != "" && !strings.HasSuffix(sql, ";") {
+			sql += ";"
+		}
+		parts = append(parts, sql)
+		return nil
+	}
+	for i, hook := range hooks.Pre {
+		if err := add(HookPre, i, hook); err != nil {
+			return "", err
+		}
+	}
+	parts = append(parts, upSQL)
+	for i, hook := range hooks.Post {
+		if err := add(HookPost, i, hook); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// dryRunHooks returns the hooks of migration for a dry run, listing them and the backup a real run
+// would take in the log, and reports a hooks file that cannot be read in result
+func (e *Executor) dryRunHooks(ctx context.Context, migration *backends.MigrationScript, migrationID string, result *ExecuteResult) backends.Hooks {
+	cfg, err := e.getConnectionConfig(ctx, migration.Connection)
+	if err != nil {
+		return migration.Hooks
+	}
+	listBackup(cfg, migration, migrationID)
+	hooks, err := hooksOf(cfg, migration)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+		return migration.Hooks
+	}
+	listHooks(hooks, migrationID)
+	return hooks
+}
@@ -0,0 +1,172 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// hookRecordingBackend records the scripts it executes, failing those containing failOn
+type hookRecordingBackend struct {
+	*mockBackend
+	executed []string
+	failOn   string
+}
+
+func (b *hookRecordingBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	b.executed = append(b.executed, migration.UpSQL)
+	if b.failOn != "" && strings.Contains(migration.UpSQL, b.failOn) {
+		return errors.New("hook failed")
+	}
+	return b.mockBackend.ExecuteMigration(ctx, migration)
+}
+
+func TestExecutor_Hooks(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+	var notified []HookNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n HookNotification
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		notified = append(notified, n)
+	}))
+	defer server.Close()
+
+	hooksFile := filepath.Join(dir, "core-hooks.yaml")
+	if err := os.WriteFile(hooksFile, []byte("pre:\n  - sql: SET lock_timeout = '5s'\npost:\n  - url: "+server.URL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost", Extra: map[string]string{ExtraHooksFile: hooksFile}},
+	})
+	backend := &hookRecordingBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", backend)
+	migration := &backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);",
+		Hooks: backends.Hooks{
+			Pre:  []backends.Hook{{Shell: `echo "$BFM_HOOK_PHASE $BFM_MIGRATION_ID" >> ` + log}},
+			Post: []backends.Hook{{SQL: "ANALYZE {{.Schema}}.users;"}},
+		},
+	}
+	_ = reg.Register(migration)
+	id := exec.getMigrationID(migration)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// Dry runs list the hooks without running them; deep dry runs rehearse the SQL ones with the script
+	rehearsing := &rehearsingBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", rehearsing)
+	ctx := features.WithOverrides(context.Background(), features.Set{features.DeepDryRun: true})
+	if result, err := exec.ExecuteSync(ctx, target, "test", "", true, false); err != nil || !result.Success {
+		t.Fatalf("deep dry run = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(log); !os.IsNotExist(err) || len(notified) != 0 {
+		t.Fatalf("expected the dry run not to run shell or URL hooks")
+	}
+	// SQL hooks are rehearsed rendered, as they run
+	want := "SET lock_timeout = '5s';\nCREATE TABLE users (id INT);\nANALYZE public.users;"
+	if len(rehearsing.rehearsed) != 1 || rehearsing.rehearsed[0].UpSQL != want {
+		t.Fatalf("expected the SQL hooks to be rehearsed around the script, got %+v", rehearsing.rehearsed)
+	}
+	exec.RegisterBackend("postgresql", backend)
+
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+	}
+	if got := strings.Join(backend.executed, " | "); got != "SET lock_timeout = '5s' | CREATE TABLE users (id INT); | ANALYZE public.users;" {
+		t.Errorf("expected the connection's pre hook, the script then the migration's post hook, got %q", got)
+	}
+	if out, _ := os.ReadFile(log); strings.TrimSpace(string(out)) != "pre "+id {
+		t.Errorf("expected the shell hook to see the migration, got %q", out)
+	}
+	if len(notified) != 1 || notified[0].Phase != HookPost || notified[0].MigrationID != id || notified[0].Connection != "test" {
+		t.Errorf("expected the URL hook to be notified after the migration, got %+v", notified)
+	}
+}
+
+func TestMigrationEnv(t *testing.T) {
+	t.Setenv("BFM_API_TOKEN", "secret")
+	t.Setenv("DATABASE_URL", "postgres://core")
+	migration := &backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql"}
+
+	env := func(cfg *backends.ConnectionConfig) map[string]string {
+		vars := make(map[string]string)
+		for _, entry := range migrationEnv(cfg, migration, "public_20240101120000_create_users_postgresql_core", "public") {
+			name, value, _ := strings.Cut(entry, "=")
+			vars[name] = value
+		}
+		return vars
+	}
+
+	vars := env(&backends.ConnectionConfig{Backend: "postgresql"})
+	if _, ok := vars["BFM_API_TOKEN"]; ok {
+		t.Error("expected the server's token to be kept from commands")
+	}
+	if _, ok := vars["DATABASE_URL"]; ok {
+		t.Error("expected variables not listed in HOOK_ENV to be kept from commands")
+	}
+	if vars["PATH"] != os.Getenv("PATH") || vars["BFM_SCHEMA"] != "public" || vars["BFM_CONNECTION"] != "core" {
+		t.Errorf("expected PATH and the migration variables, got %v", vars)
+	}
+
+	vars = env(&backends.ConnectionConfig{Backend: "postgresql", Extra: map[string]string{ExtraHookEnv: "DATABASE_URL, UNSET_VARIABLE"}})
+	if vars["DATABASE_URL"] != "postgres://core" {
+		t.Errorf("expected HOOK_ENV to pass DATABASE_URL on, got %v", vars)
+	}
+	if _, ok := vars["UNSET_VARIABLE"]; ok || vars["BFM_API_TOKEN"] != "" {
+		t.Errorf("expected only set, listed variables to be passed on, got %v", vars)
+	}
+}
+
+func TestExecutor_Hooks_Failures(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	backend := &hookRecordingBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", backend)
+	migration := exec.registry.GetAll()[0]
+	id := exec.getMigrationID(migration)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// A failing pre hook fails the migration before it runs
+	migration.Hooks = backends.Hooks{Pre: []backends.Hook{{Shell: "echo snapshot failed >&2; exit 3"}}}
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "pre hook 1 (shell)") ||
+		!strings.Contains(result.Errors[0], "snapshot failed") {
+		t.Fatalf("expected the pre hook failure to be reported, got %+v", result)
+	}
+	if len(backend.executed) != 0 || lastRecord(tracker).Status != "failed" {
+		t.Fatalf("expected the migration to be recorded as failed without running, executed %v", backend.executed)
+	}
+
+	// A failing post hook is reported, but the migration stays applied
+	migration.Hooks = backends.Hooks{Post: []backends.Hook{{SQL: "VACUUM ANALYZE users;"}}}
+	backend.failOn = "VACUUM"
+	result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Applied) != 1 || result.Applied[0] != id || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "post hook 1 (sql)") {
+		t.Fatalf("expected the migration applied and the post hook failure reported, got %+v", result)
+	}
+	if record := lastRecord(tracker); record.Status != "applied" {
+		t.Errorf("expected the migration to be recorded as applied, got %+v", record)
+	}
+}
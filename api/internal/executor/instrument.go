@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// executeOnBackend executes script on backend in a trace span; its metrics are recorded from the
+// execution event the caller publishes (see metrics.Subscribe). The up function of a code migration
+// runs instead of its script (see backends.CodeRunner). The execution is canceled after
+// script.Timeout, if set; the error of a canceled execution wraps its cause (ErrMigrationTimeout,
+// ErrRunCanceled).
+func executeOnBackend(ctx context.Context, direction string, backend backends.Backend, script *backends.MigrationScript) error {
+	ctx, span := tracing.Tracer().Start(ctx, "backend.ExecuteMigration", trace.WithAttributes(
+		attribute.String("bfm.direction", direction),
+		attribute.String("bfm.backend", script.Backend),
+		attribute.String("bfm.connection", script.Connection),
+		attribute.String("bfm.schema", script.Schema),
+		attribute.String("bfm.migration.version", script.Version),
+		attribute.String("bfm.migration.name", script.Name),
+	))
+
+	if script.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, script.Timeout, fmt.Errorf("%w after %s", ErrMigrationTimeout, script.Timeout))
+		defer cancel()
+	}
+
+	var err error
+	if script.IsCode() {
+		err = executeCode(ctx, backend, script)
+	} else {
+		err = backend.ExecuteMigration(ctx, script)
+	}
+	if cause := stopped(ctx); err != nil && cause != nil && !errors.Is(err, cause) {
+		err = fmt.Errorf("%w: %v", cause, err)
+	}
+	tracing.End(span, err)
+	return err
+}
+
+// executeCode runs the up function of a code migration on backend
+func executeCode(ctx context.Context, backend backends.Backend, script *backends.MigrationScript) error {
+	runner, ok := backend.(backends.CodeRunner)
+	if !ok {
+		return fmt.Errorf("%w: %s", backends.ErrCodeMigrationUnsupported, script.Backend)
+	}
+	return runner.ExecuteMigrationFunc(ctx, script)
+}
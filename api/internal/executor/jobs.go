@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrQueueDisabled is returned when replaying a job on a server without a queue
+var ErrQueueDisabled = errors.New("queue is not enabled")
+
+// ErrJobNotReplayable is returned when replaying a job that did not fail
+var ErrJobNotReplayable = errors.New("only failed or dead-lettered jobs can be replayed")
+
+// RecordJobStatus records the status of an async job in the state database (see state.Job), with
+// the result of its execution once it completed or failed. Failures are logged rather than
+// returned, so job tracking never fails the job itself.
+func (e *Executor) RecordJobStatus(ctx context.Context, job *queue.Job, status string, result *ExecuteResult, execErr error) {
+	record := &state.Job{
+		ID:         job.ID,
+		Status:     status,
+		Connection: job.Connection,
+		Schema:     job.Schema,
+		DryRun:     job.DryRun,
+	}
+	if job.Target != nil {
+		target, _ := json.Marshal(job.Target)
+		record.Target = string(target)
+	}
+	record.Attempts = job.Attempts
+	if payload, err := json.Marshal(job); err == nil {
+		record.Payload = string(payload)
+	}
+	if status == state.JobPickedUp {
+		record.Worker = jobWorker()
+	}
+	if result != nil {
+		record.Applied, record.Skipped, record.Errors = result.Applied, result.Skipped, result.Errors
+	}
+	if execErr != nil {
+		record.Errors = append(record.Errors, execErr.Error())
+	}
+
+	if err := e.stateTracker.RecordJob(ctx, record); err != nil {
+		logger.Warnf("Failed to record status %s of job %s: %v", status, job.ID, err)
+	}
+}
+
+// jobWorker identifies this process in job records
+func jobWorker() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// GetJob returns an async job by ID, or state.ErrJobNotFound
+func (e *Executor) GetJob(ctx context.Context, id string) (*state.Job, error) {
+	return e.stateTracker.GetJob(ctx, id)
+}
+
+// GetJobs retrieves async jobs matching filters, most recently queued first, and the total number
+// of matching jobs
+func (e *Executor) GetJobs(ctx context.Context, filters *state.JobFilters) ([]*state.Job, int, error) {
+	return e.stateTracker.GetJobs(ctx, filters)
+}
+
+// ReplayJob publishes a failed or dead-lettered job to the queue again, under the same ID, so its
+// status starts over from queued. It returns the job as recorded once queued.
+func (e *Executor) ReplayJob(ctx context.Context, id string) (*state.Job, error) {
+	e.mu.Lock()
+	q := e.queue
+	e.mu.Unlock()
+	if q == nil {
+		return nil, ErrQueueDisabled
+	}
+
+	record, err := e.stateTracker.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != state.JobFailed && record.Status != state.JobDeadLettered {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotReplayable, id, record.Status)
+	}
+	var job queue.Job
+	if err := json.Unmarshal([]byte(record.Payload), &job); err != nil {
+		return nil, fmt.Errorf("%w: job %s was recorded without its payload", ErrJobNotReplayable, id)
+	}
+	job.Attempts = 0
+	job.Metadata = make(map[string]interface{})
+
+	logger.Infof("Replaying migration job %s", id)
+	if err := e.publishJob(ctx, q, &job); err != nil {
+		return nil, err
+	}
+	return e.stateTracker.GetJob(ctx, id)
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_QueuedJobIsRecorded(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	q := newMockQueue()
+	exec.SetQueue(q)
+
+	target := &registry.MigrationTarget{Connection: "test", Backend: "postgresql"}
+	result, err := exec.Execute(context.Background(), target, "test", "tenant_a", true, false)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	job, err := exec.GetJob(context.Background(), result.JobID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != state.JobQueued || job.Connection != "test" || job.Schema != "tenant_a" || !job.DryRun {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if !strings.Contains(job.Target, `"backend":"postgresql"`) {
+		t.Errorf("expected the target in the job, got %s", job.Target)
+	}
+
+	// The worker records its progress on the same job
+	exec.RecordJobStatus(context.Background(), q.publishedJobs[0], state.JobPickedUp, nil, nil)
+	exec.RecordJobStatus(context.Background(), q.publishedJobs[0], state.JobFailed, &ExecuteResult{Errors: []string{"m1: boom"}}, errors.New("1 migration failed"))
+	job, _ = exec.GetJob(context.Background(), result.JobID)
+	if job.Status != state.JobFailed || job.Worker == "" || strings.Join(job.Errors, "; ") != "m1: boom; 1 migration failed" {
+		t.Errorf("unexpected failed job %+v", job)
+	}
+}
+
+func TestExecutor_UnpublishedJobFails(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	q := newMockQueue()
+	q.publishError = errors.New("broker unavailable")
+	exec.SetQueue(q)
+
+	if _, err := exec.Execute(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false); err == nil {
+		t.Fatal("expected the publish error")
+	}
+	jobs, total, err := exec.GetJobs(context.Background(), &state.JobFilters{Status: state.JobFailed})
+	if err != nil || total != 1 {
+		t.Fatalf("GetJobs(failed) = %d, %v", total, err)
+	}
+	if len(jobs[0].Errors) != 1 || !strings.Contains(jobs[0].Errors[0], "broker unavailable") {
+		t.Errorf("expected the publish error in the job, got %v", jobs[0].Errors)
+	}
+}
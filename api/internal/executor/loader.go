@@ -6,12 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"text/template"
 	"time"
 
 	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
 	"github.com/toolsascode/bfm/api/internal/logger"
 	"github.com/toolsascode/bfm/api/internal/registry"
 	"github.com/toolsascode/bfm/api/migrations"
@@ -20,26 +22,54 @@ import (
 // bfmTagsLineRe matches the optional tag declaration line at the top of .up.sql / .up.json sources.
 var bfmTagsLineRe = regexp.MustCompile(`(?i)^\s*--\s*bfm-tags:\s*(.+)\s*$`)
 
-// Loader loads migration scripts from the SFM directory
+// bfmTableLineRe matches the optional table declaration line at the top of .up.sql / .up.json sources.
+var bfmTableLineRe = regexp.MustCompile(`(?i)^\s*--\s*bfm-table:(.*)$`)
+
+// bfmTimeoutLineRe matches the optional timeout declaration line at the top of .up.sql sources.
+var bfmTimeoutLineRe = regexp.MustCompile(`(?i)^\s*--\s*bfm-timeout:(.*)$`)
+
+// Loader loads migration scripts from one or more SFM directories (roots)
 type Loader struct {
-	sfmPath      string
+	sfmPaths     []string
 	registry     registry.Registry
-	executor     *Executor            // Optional executor for registering scanned migrations
-	seenFiles    map[string]time.Time // Track files we've seen and their mod times
+	executor     *Executor                     // Optional executor for registering scanned migrations
+	source       string                        // SourceGo (default) or SourceScripts, see SetSource
+	loaded       map[string]bool               // Migration IDs registered by this loader
+	conflicts    map[string]*MigrationConflict // Migration IDs refused by the registry, see Conflicts
+	seenFiles    map[string]time.Time          // Track files we've seen and their mod times
 	mu           sync.RWMutex
 	watchContext context.Context
 	watchCancel  context.CancelFunc
 	watching     bool
+
+	// Developer mode (see SetDevMode)
+	watchInterval time.Duration
+	devConnection string
+	devSchemas    []string
+	devChecksums  map[string]string // Up script checksums of the dev connection's migrations at the last scan
 }
 
-// NewLoader creates a new migration loader
-func NewLoader(sfmPath string) *Loader {
+// NewLoader creates a new migration loader for the given SFM roots. Migrations of all roots are
+// merged into one registry; a connection version defined in two roots is a conflict (see LoadAll).
+func NewLoader(sfmPaths ...string) *Loader {
 	ctx, cancel := context.WithCancel(context.Background())
+	var roots []string
+	for _, path := range sfmPaths {
+		// A root listed twice is loaded once rather than conflicting with itself
+		if path != "" && !slices.Contains(roots, filepath.Clean(path)) {
+			roots = append(roots, filepath.Clean(path))
+		}
+	}
 	return &Loader{
-		sfmPath:      sfmPath,
+		sfmPaths:     roots,
+		source:       SourceGo,
+		loaded:       make(map[string]bool),
+		conflicts:    make(map[string]*MigrationConflict),
 		seenFiles:    make(map[string]time.Time),
 		watchContext: ctx,
 		watchCancel:  cancel,
+
+		watchInterval: time.Minute,
 	}
 }
 
@@ -53,37 +83,42 @@ func (l *Loader) SetExecutor(exec *Executor) {
 // LoadAll loads all migration scripts from the SFM directory structure
 // It reads .go files to extract metadata, then reads the corresponding SQL/JSON files
 // and registers migrations directly in the registry.
+// With several SFM roots, nothing is loaded while two roots define the same version of a
+// connection: the error lists every conflict (see ErrSFMRootConflict).
 func (l *Loader) LoadAll(reg registry.Registry) error {
 	l.registry = reg
 
-	if l.sfmPath == "" {
+	if len(l.sfmPaths) == 0 {
 		// Default to ../sfm relative to bfm
-		l.sfmPath = "../sfm"
+		l.sfmPaths = []string{"../sfm"}
 	}
 
-	// Initial load - force load all existing files
-	if err := l.scanAndLoadAll(); err != nil {
+	if err := checkSFMRootConflicts(l.sfmPaths); err != nil {
 		return err
 	}
 
+	// Initial load - force load all existing files
+	for _, root := range l.sfmPaths {
+		if err := l.scanAndLoadAll(root); err != nil {
+			return err
+		}
+	}
+	l.publishConflicts(true)
+
 	return nil
 }
 
-// scanAndLoadAll scans and loads all migration files (used for initial load)
-func (l *Loader) scanAndLoadAll() error {
-	if l.sfmPath == "" {
-		return nil
-	}
-
+// scanAndLoadAll scans and loads all migration files of an SFM root (used for initial load)
+func (l *Loader) scanAndLoadAll(root string) error {
 	// Check if directory exists
-	if _, err := os.Stat(l.sfmPath); os.IsNotExist(err) {
-		logger.Warnf("SFM directory does not exist: %s", l.sfmPath)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		logger.Warnf("SFM directory does not exist: %s", root)
 		return nil
 	}
 
 	// First, scan for SQL/JSON files and auto-create .go files if needed
 	// Also loads migrations directly from SQL/JSON if .go file creation fails
-	if migrations, err := l.findMigrationFilesFromSQLOrJSON(); err != nil {
+	if migrations, err := l.findMigrationFilesFromSQLOrJSON(root); err != nil {
 		logger.Warnf("Failed to scan for SQL/JSON migration files: %v", err)
 	} else {
 		createdCount := 0
@@ -99,12 +134,12 @@ func (l *Loader) scanAndLoadAll() error {
 			logger.Infof("Auto-created %d .go file(s) from SQL/JSON files", createdCount)
 		}
 		if loadedCount > 0 {
-			logger.Infof("Loaded %d migration(s) directly from SQL/JSON files (read-only filesystem)", loadedCount)
+			logger.Infof("Loaded %d migration(s) directly from SQL/JSON files", loadedCount)
 		}
 	}
 
 	var loadedCount int
-	err := filepath.Walk(l.sfmPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -120,7 +155,7 @@ func (l *Loader) scanAndLoadAll() error {
 		}
 
 		// Verify directory structure: sfm/{backend}/{connection}/{version}_{name}.go
-		relPath, err := filepath.Rel(l.sfmPath, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
@@ -167,11 +202,12 @@ func (l *Loader) scanAndLoadAll() error {
 		return fmt.Errorf("error scanning SFM directory: %w", err)
 	}
 
-	logger.Infof("Loaded %d migration(s) from %s", loadedCount, l.sfmPath)
+	logger.Infof("Loaded %d migration(s) from %s", loadedCount, root)
 	return nil
 }
 
-// StartWatching starts a background goroutine that checks for new migration files every minute
+// StartWatching starts a background goroutine that checks for new migration files every minute,
+// or every dev mode watch interval. In developer mode new and edited migrations are then applied.
 func (l *Loader) StartWatching() {
 	if l.watching {
 		return // Already watching
@@ -179,12 +215,19 @@ func (l *Loader) StartWatching() {
 
 	l.mu.Lock()
 	l.watching = true
+	interval := l.watchInterval
 	l.mu.Unlock()
 
-	logger.Info("Starting migration file watcher (checking every minute)")
+	devConnection := l.devModeConnection()
+	if devConnection != "" {
+		l.devChanges() // Migrations loaded before watching are not applied by dev mode
+		logger.Warnf("[dev mode] Migration file watcher applies new and edited migrations to connection %s (checking every %v)", devConnection, interval)
+	} else {
+		logger.Infof("Starting migration file watcher (checking every %v)", interval)
+	}
 
 	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
@@ -195,6 +238,10 @@ func (l *Loader) StartWatching() {
 			case <-ticker.C:
 				if err := l.scanAndLoad(); err != nil {
 					logger.Warnf("Error scanning for new migrations: %v", err)
+					continue
+				}
+				if devConnection != "" {
+					l.applyDevChanges(l.watchContext)
 				}
 			}
 		}
@@ -214,20 +261,44 @@ func (l *Loader) StopWatching() {
 	l.watching = false
 }
 
-// scanAndLoad scans the SFM directory and loads any new migration files
+// scanAndLoad scans the SFM roots and loads any new migration files. While two roots define
+// the same version of a connection, nothing is loaded until the conflict is resolved.
 func (l *Loader) scanAndLoad() error {
-	if l.sfmPath == "" {
-		return nil
+	if err := checkSFMRootConflicts(l.sfmPaths); err != nil {
+		return err
 	}
 
+	newFiles := make(map[string]time.Time)
+	for _, root := range l.sfmPaths {
+		if err := l.scanAndLoadRoot(root, newFiles); err != nil {
+			return err
+		}
+	}
+
+	// Update seen files map, and forget the conflicts of deleted migrations
+	l.mu.Lock()
+	l.seenFiles = newFiles
+	for id, conflict := range l.conflicts {
+		if _, err := os.Stat(conflict.Path); os.IsNotExist(err) {
+			delete(l.conflicts, id)
+		}
+	}
+	l.mu.Unlock()
+	l.publishConflicts(false)
+
+	return nil
+}
+
+// scanAndLoadRoot scans an SFM root and loads any new migration files, adding the files found to newFiles
+func (l *Loader) scanAndLoadRoot(root string, newFiles map[string]time.Time) error {
 	// Check if directory exists
-	if _, err := os.Stat(l.sfmPath); os.IsNotExist(err) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
 		return nil // Directory doesn't exist, skip
 	}
 
 	// First, scan for SQL/JSON files and auto-create .go files if needed
 	// Also loads migrations directly from SQL/JSON if .go file creation fails
-	if migrations, err := l.findMigrationFilesFromSQLOrJSON(); err != nil {
+	if migrations, err := l.findMigrationFilesFromSQLOrJSON(root); err != nil {
 		logger.Warnf("Failed to scan for SQL/JSON migration files: %v", err)
 	} else {
 		createdCount := 0
@@ -243,15 +314,13 @@ func (l *Loader) scanAndLoad() error {
 			logger.Infof("Auto-created %d .go file(s) from SQL/JSON files", createdCount)
 		}
 		if loadedCount > 0 {
-			logger.Infof("Loaded %d migration(s) directly from SQL/JSON files (read-only filesystem)", loadedCount)
+			logger.Infof("Loaded %d migration(s) directly from SQL/JSON files", loadedCount)
 		}
 	}
 
 	// Walk through the SFM directory structure
 	// Structure: sfm/{backend}/{connection}/{version}_{name}.go
-	newFiles := make(map[string]time.Time)
-
-	err := filepath.Walk(l.sfmPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -267,7 +336,7 @@ func (l *Loader) scanAndLoad() error {
 		}
 
 		// Verify directory structure: sfm/{backend}/{connection}/{version}_{name}.go
-		relPath, err := filepath.Rel(l.sfmPath, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
@@ -290,8 +359,11 @@ func (l *Loader) scanAndLoad() error {
 			return nil
 		}
 
-		// Track this file
+		// Track this file; in developer mode editing its up script counts as modifying it
 		modTime := info.ModTime()
+		if l.devModeConnection() != "" {
+			modTime = scriptModTime(path, parts[0], modTime)
+		}
 		newFiles[path] = modTime
 
 		// Check if this is a new or modified file
@@ -315,6 +387,14 @@ func (l *Loader) scanAndLoad() error {
 			needsLoad = true
 			logger.Infof("Migration file modified: %s (version: %s, name: %s)", path, version, name)
 		}
+		if needsLoad && l.executor != nil {
+			l.executor.Events().Publish(context.Background(), events.Event{
+				Type:        events.LoaderFileDetected,
+				MigrationID: fmt.Sprintf("%s_%s_%s_%s", version, name, backend, connection),
+				Connection:  connection,
+				Data:        map[string]interface{}{"path": path, "modified": seen},
+			})
+		}
 
 		// Load the migration if needed
 		if needsLoad && l.registry != nil {
@@ -331,11 +411,6 @@ func (l *Loader) scanAndLoad() error {
 		return fmt.Errorf("error scanning SFM directory: %w", err)
 	}
 
-	// Update seen files map
-	l.mu.Lock()
-	l.seenFiles = newFiles
-	l.mu.Unlock()
-
 	return nil
 }
 
@@ -430,6 +505,67 @@ func parseBFMTagsFromUpSQL(upSQL string) ([]string, error) {
 	return nil, nil
 }
 
+// parseBFMTableFromUpSQL returns the table named by the first -- bfm-table: line in the up migration
+// body, or nil when the migration doesn't declare one. Requests targeting tables only match migrations
+// that declare them.
+func parseBFMTableFromUpSQL(upSQL string) (*string, error) {
+	lines := strings.Split(upSQL, "\n")
+	n := len(lines)
+	if n > 80 {
+		n = 80
+	}
+	for _, line := range lines[:n] {
+		m := bfmTableLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		table := strings.TrimSpace(m[1])
+		if table == "" || strings.ContainsAny(table, " \t,") {
+			return nil, fmt.Errorf("invalid bfm-table %q (expected a single table name)", table)
+		}
+		return &table, nil
+	}
+	return nil, nil
+}
+
+// parseBFMTimeoutFromUpSQL returns the duration given by the first -- bfm-timeout: line in the up
+// migration body (e.g. "-- bfm-timeout: 2h"), or 0 when the migration doesn't declare one. The
+// migration is canceled when it runs longer, instead of after the executor's default timeout.
+func parseBFMTimeoutFromUpSQL(upSQL string) (time.Duration, error) {
+	lines := strings.Split(upSQL, "\n")
+	n := len(lines)
+	if n > 80 {
+		n = 80
+	}
+	for _, line := range lines[:n] {
+		m := bfmTimeoutLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(m[1]))
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("invalid bfm-timeout %q (expected a positive duration such as 30m)", strings.TrimSpace(m[1]))
+		}
+		return timeout, nil
+	}
+	return 0, nil
+}
+
+// upScriptTable returns the table declared by the up script of the migration {baseName} in dir,
+// or an empty string when it declares none or can't be read
+func upScriptTable(dir, baseName, backend string) string {
+	ext := migrationScriptExtension(dir, baseName, backend)
+	upSQL, err := os.ReadFile(filepath.Join(dir, baseName+".up"+ext))
+	if err != nil {
+		return ""
+	}
+	table, err := parseBFMTableFromUpSQL(string(upSQL))
+	if err != nil || table == nil {
+		return ""
+	}
+	return *table
+}
+
 // extractDependenciesFromGoFile extracts the Dependencies field value from a .go migration file
 func extractDependenciesFromGoFile(goFilePath string) []string {
 	// Read the .go file
@@ -582,19 +718,15 @@ func extractStructuredDependenciesFromGoFile(goFilePath string) []backends.Depen
 
 // loadMigrationFromFile loads a migration by reading the .go file and corresponding SQL/JSON files
 func (l *Loader) loadMigrationFromFile(goFilePath, backend, connection, version, name string) error {
+	dir := filepath.Dir(goFilePath)
+	baseName := fmt.Sprintf("%s_%s", version, name)
+
 	// Determine file extensions based on backend
-	var upExt, downExt string
-	if backend == "etcd" || backend == "mongodb" {
-		upExt = ".up.json"
-		downExt = ".down.json"
-	} else {
-		upExt = ".up.sql"
-		downExt = ".down.sql"
-	}
+	ext := migrationScriptExtension(dir, baseName, backend)
+	upExt := ".up" + ext
+	downExt := ".down" + ext
 
 	// Build file paths
-	dir := filepath.Dir(goFilePath)
-	baseName := fmt.Sprintf("%s_%s", version, name)
 	upFile := filepath.Join(dir, baseName+upExt)
 	downFile := filepath.Join(dir, baseName+downExt)
 
@@ -610,6 +742,16 @@ func (l *Loader) loadMigrationFromFile(goFilePath, backend, connection, version,
 		return fmt.Errorf("failed to read down migration file %s: %w", downFile, err)
 	}
 
+	// Without a down file, generate one when every up statement is trivially reversible
+	downGenerated := false
+	if os.IsNotExist(err) && canGenerateDown(backend, ext) {
+		if generated, ok := generateDownSQL(string(upSQL)); ok {
+			downSQL = []byte(generated)
+			downGenerated = true
+			logger.Infof("Generated down migration for %s from its up script (no %s file)", baseName, downExt)
+		}
+	}
+
 	// Extract schema from .go file if it exists
 	schema := extractSchemaFromGoFile(goFilePath)
 
@@ -628,9 +770,32 @@ func (l *Loader) loadMigrationFromFile(goFilePath, backend, connection, version,
 		}
 	}
 
+	table, err := parseBFMTableFromUpSQL(string(upSQL))
+	if err != nil {
+		return fmt.Errorf("bfm-table in %s: %w", upFile, err)
+	}
+	tableName := ""
+	if table != nil {
+		tableName = *table
+	}
+	timeout, err := parseBFMTimeoutFromUpSQL(string(upSQL))
+	if err != nil {
+		return fmt.Errorf("bfm-timeout in %s: %w", upFile, err)
+	}
+	hooksFile := filepath.Join(dir, baseName+HooksExtension)
+	hooks, err := readHooksSidecar(hooksFile)
+	if err != nil {
+		return fmt.Errorf("hooks in %s: %w", hooksFile, err)
+	}
+	verify, err := readVerifySidecars(dir, baseName)
+	if err != nil {
+		return fmt.Errorf("verification of %s: %w", baseName, err)
+	}
+
 	// Create and register migration
 	migration := &backends.MigrationScript{
 		Schema:                 schema, // Use schema from .go file if available, otherwise empty (dynamic)
+		Table:                  table,
 		Version:                version,
 		Name:                   name,
 		Connection:             connection,
@@ -640,21 +805,35 @@ func (l *Loader) loadMigrationFromFile(goFilePath, backend, connection, version,
 		Dependencies:           dependencies,
 		StructuredDependencies: structuredDependencies,
 		Tags:                   tags,
+		Declarative:            ext == backends.DeclarativeExtension,
+		DownGenerated:          downGenerated,
+		Transactional:          backends.IsTransactional(string(upSQL)),
+		Timeout:                timeout,
+		Hooks:                  hooks,
+		Verify:                 verify,
+	}
+
+	// Generate migration ID using the same format as executor.getMigrationID
+	// Format: {version}_{name}_{backend}_{connection}
+	migrationID := fmt.Sprintf("%s_%s_%s_%s", version, name, backend, connection)
+	if !l.shouldRegister(migrationID, migration) {
+		return nil
 	}
 
 	if err := l.registry.Register(migration); err != nil {
+		l.recordConflict(migrationID, upFile, err)
 		return fmt.Errorf("failed to register migration: %w", err)
 	}
+	l.mu.Lock()
+	l.loaded[migrationID] = true
+	delete(l.conflicts, migrationID)
+	l.mu.Unlock()
 
 	// Register scanned migration in migrations_list table if executor is available
 	if l.executor != nil {
-		// Generate migration ID using the same format as executor.getMigrationID
-		// Format: {version}_{name}_{backend}_{connection}
-		migrationID := fmt.Sprintf("%s_%s_%s_%s", version, name, backend, connection)
-
 		// Register in database (use schema from .go file if available)
 		ctx := context.Background()
-		if err := l.executor.RegisterScannedMigration(ctx, migrationID, schema, "", version, name, connection, backend); err != nil {
+		if err := l.executor.RegisterScannedMigration(ctx, migrationID, schema, tableName, version, name, connection, backend); err != nil {
 			// Log warning but don't fail - migration is still registered in memory
 			logger.Warnf("Failed to register scanned migration in database: %v", err)
 		}
@@ -664,25 +843,51 @@ func (l *Loader) loadMigrationFromFile(goFilePath, backend, connection, version,
 	return nil
 }
 
+// migrationScriptExtension returns the script extension of the migration {baseName} in dir.
+// Desired-state documents ({baseName}.up.yaml) take precedence on backends that support them.
+func migrationScriptExtension(dir, baseName, backend string) string {
+	if backends.SupportsDeclarative(backend) {
+		if _, err := os.Stat(filepath.Join(dir, baseName+".up"+backends.DeclarativeExtension)); err == nil {
+			return backends.DeclarativeExtension
+		}
+	}
+	return backends.ScriptExtension(backend)
+}
+
+// canGenerateDown reports whether down scripts can be generated for a backend's scripts
+func canGenerateDown(backend, ext string) bool {
+	return autoDownBackends[backend] && ext == ".sql"
+}
+
+// upFileIsReversible reports whether a down script can be generated for upFile
+func upFileIsReversible(upFile, backend, ext string) bool {
+	if !canGenerateDown(backend, ext) {
+		return false
+	}
+	upSQL, err := os.ReadFile(upFile)
+	if err != nil {
+		return false
+	}
+	_, ok := generateDownSQL(string(upSQL))
+	return ok
+}
+
 // ensureGoFileExists checks if a .go file exists for the given migration files.
 // If the .go file doesn't exist but the .up.sql/.up.json and .down.sql/.down.json files do,
-// it automatically creates the .go file.
+// it automatically creates the .go file. The down file may be omitted when it can be
+// generated from the up file (see generateDownSQL).
 // Returns the goFilePath if it exists or was created, or an empty string if creation failed
-// (e.g., read-only filesystem). The error indicates whether SQL/JSON files are missing.
-func (l *Loader) ensureGoFileExists(backend, connection, version, name string) (string, error) {
-	// Determine file extensions based on backend
-	var upExt, downExt string
-	if backend == "etcd" || backend == "mongodb" {
-		upExt = ".up.json"
-		downExt = ".down.json"
-	} else {
-		upExt = ".up.sql"
-		downExt = ".down.sql"
-	}
-
+// (e.g., read-only filesystem) or is disabled by SourceScripts. The error indicates whether
+// SQL/JSON files are missing.
+func (l *Loader) ensureGoFileExists(root, backend, connection, version, name string) (string, error) {
 	// Build directory path
-	dir := filepath.Join(l.sfmPath, backend, connection)
+	dir := filepath.Join(root, backend, connection)
 	baseName := fmt.Sprintf("%s_%s", version, name)
+
+	// Determine file extensions based on backend
+	ext := migrationScriptExtension(dir, baseName, backend)
+	upExt := ".up" + ext
+	downExt := ".down" + ext
 	goFilePath := filepath.Join(dir, baseName+".go")
 	upFile := filepath.Join(dir, baseName+upExt)
 	downFile := filepath.Join(dir, baseName+downExt)
@@ -692,16 +897,25 @@ func (l *Loader) ensureGoFileExists(backend, connection, version, name string) (
 		return goFilePath, nil // .go file exists, no need to create
 	}
 
+	// With scripts as the source of truth the .go file is not needed to load the migration
+	l.mu.RLock()
+	generate := l.source != SourceScripts
+	l.mu.RUnlock()
+
 	// Check if .up file exists
 	if _, err := os.Stat(upFile); os.IsNotExist(err) {
 		return "", fmt.Errorf("up migration file does not exist: %s", upFile)
 	}
 
-	// Check if .down file exists (required per user requirement)
-	if _, err := os.Stat(downFile); os.IsNotExist(err) {
+	// Check if .down file exists (required unless it can be generated from the up file)
+	if _, err := os.Stat(downFile); os.IsNotExist(err) && !upFileIsReversible(upFile, backend, ext) {
 		return "", fmt.Errorf("down migration file does not exist: %s", downFile)
 	}
 
+	if !generate {
+		return "", nil
+	}
+
 	// Try to create directory if it doesn't exist (may fail on read-only filesystem)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		// If directory creation fails, it might be read-only filesystem
@@ -744,6 +958,7 @@ func (l *Loader) ensureGoFileExists(backend, connection, version, name string) (
 		Connection   string
 		Backend      string
 		Dependencies string
+		TagsGo       string
 	}{
 		PackageName:  connection,
 		UpFileName:   upFileName,
@@ -767,15 +982,15 @@ func (l *Loader) ensureGoFileExists(backend, connection, version, name string) (
 // Also loads migrations directly from SQL/JSON files if .go file creation fails (e.g., read-only filesystem)
 // Returns a map of goFilePath -> (backend, connection, version, name)
 // If goFilePath is empty, the migration was loaded directly from SQL/JSON files
-func (l *Loader) findMigrationFilesFromSQLOrJSON() (map[string][]string, error) {
+func (l *Loader) findMigrationFilesFromSQLOrJSON(root string) (map[string][]string, error) {
 	migrations := make(map[string][]string) // goFilePath -> [backend, connection, version, name]
 
-	err := filepath.Walk(l.sfmPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Look for .up.sql or .up.json files
+		// Look for .up.sql, .up.json or .up.yaml (desired-state) files
 		var isUpFile bool
 		var upExt string
 		if strings.HasSuffix(path, ".up.sql") {
@@ -784,6 +999,9 @@ func (l *Loader) findMigrationFilesFromSQLOrJSON() (map[string][]string, error)
 		} else if strings.HasSuffix(path, ".up.json") {
 			isUpFile = true
 			upExt = ".up.json"
+		} else if strings.HasSuffix(path, ".up.yaml") {
+			isUpFile = true
+			upExt = ".up.yaml"
 		}
 
 		if !isUpFile {
@@ -791,7 +1009,7 @@ func (l *Loader) findMigrationFilesFromSQLOrJSON() (map[string][]string, error)
 		}
 
 		// Verify directory structure: sfm/{backend}/{connection}/{version}_{name}.up.{sql|json}
-		relPath, err := filepath.Rel(l.sfmPath, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
@@ -817,21 +1035,21 @@ func (l *Loader) findMigrationFilesFromSQLOrJSON() (map[string][]string, error)
 		connection := parts[1]
 
 		// Check if .go file exists, if not try to create it
-		goFilePath, err := l.ensureGoFileExists(backend, connection, version, name)
+		goFilePath, err := l.ensureGoFileExists(root, backend, connection, version, name)
 		if err != nil {
 			// Error means SQL/JSON files are missing, skip this migration
 			logger.Warnf("Failed to ensure .go file exists for %s: %v", path, err)
 			return nil // Continue with other files
 		}
 
-		// If goFilePath is empty, .go file creation failed (e.g., read-only filesystem)
-		// but SQL/JSON files exist, so load migration directly
+		// If goFilePath is empty, .go file creation failed (e.g., read-only filesystem) or
+		// was skipped (SourceScripts) but SQL/JSON files exist, so load migration directly
 		if goFilePath == "" {
 			// Load migration directly from SQL/JSON files
 			if l.registry != nil {
 				// Build the path to the .go file (even though it doesn't exist)
 				// loadMigrationFromFile will read SQL/JSON files directly
-				dir := filepath.Join(l.sfmPath, backend, connection)
+				dir := filepath.Join(root, backend, connection)
 				baseName := fmt.Sprintf("%s_%s", version, name)
 				virtualGoPath := filepath.Join(dir, baseName+".go")
 
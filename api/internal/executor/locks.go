@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// withConnectionLock runs fn while holding the state tracker's lock on connection, so that two
+// server replicas (or a server and a worker) never apply migrations on the same connection at once.
+func (e *Executor) withConnectionLock(ctx context.Context, connection string, fn func() error) error {
+	if e.IsStandby() {
+		return fmt.Errorf("connection %s: %w", connection, ErrStandby)
+	}
+	err := e.stateTracker.WithConnectionLock(ctx, connection, connectionLockHolder(ctx), fn)
+	if errors.Is(err, state.ErrConnectionLocked) {
+		return fmt.Errorf("connection %s: %w", connection, err)
+	}
+	return err
+}
+
+// connectionLockHolder identifies this process and execution method in lock listings
+func connectionLockHolder(ctx context.Context) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	executedBy, executionMethod, _ := GetExecutionContext(ctx)
+	return fmt.Sprintf("%s:%d (%s, %s)", host, os.Getpid(), executionMethod, executedBy)
+}
+
+// ListLocks returns the connection locks currently held
+func (e *Executor) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	return e.stateTracker.ListLocks(ctx)
+}
+
+// ReleaseLock force-releases the lock on a connection. Returns false if it was not locked.
+func (e *Executor) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	if e.IsStandby() {
+		return false, ErrStandby
+	}
+	return e.stateTracker.ReleaseLock(ctx, connection)
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func newLockTestExecutor(t *testing.T) (*Executor, *testsupport.StateTracker) {
+	t.Helper()
+	reg := newMockRegistry()
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);", DownSQL: "DROP TABLE users;",
+	})
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	return exec, tracker
+}
+
+// lockObservingBackend calls onExecute before executing each migration
+type lockObservingBackend struct {
+	*mockBackend
+	onExecute func()
+}
+
+func (b *lockObservingBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	b.onExecute()
+	return b.mockBackend.ExecuteMigration(ctx, migration)
+}
+
+func TestExecutor_ExecuteSync_ConnectionLocked(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	tracker.HoldLock("test", "other-replica:1 (api, system)")
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, state.ErrConnectionLocked) {
+		t.Fatalf("expected ErrConnectionLocked, got %v", err)
+	}
+	if len(tracker.History()) != 0 {
+		t.Errorf("expected nothing to be applied while the connection is locked")
+	}
+	if _, err := exec.Rollback(context.Background(), "20240101120000_create_users_postgresql_test", nil); !errors.Is(err, state.ErrConnectionLocked) {
+		t.Errorf("expected rollback to respect the lock, got %v", err)
+	}
+
+	// Dry runs don't apply anything and don't need the lock
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", true, false); err != nil {
+		t.Errorf("dry run error = %v", err)
+	}
+
+	released, err := exec.ReleaseLock(context.Background(), "test")
+	if err != nil || !released {
+		t.Fatalf("ReleaseLock() = %v, %v", released, err)
+	}
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() after release error = %v", err)
+	}
+	if len(result.Applied) != 1 {
+		t.Errorf("expected 1 applied migration, got %v", result.Applied)
+	}
+}
+
+func TestExecutor_ExecuteSync_HoldsConnectionLock(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	var holders []*state.MigrationLock
+	exec.RegisterBackend("postgresql", &lockObservingBackend{
+		mockBackend: newMockBackend("postgresql"),
+		onExecute: func() {
+			holders, _ = exec.ListLocks(context.Background())
+		},
+	})
+
+	ctx := SetExecutionContext(context.Background(), "alice", "cli", nil)
+	if _, err := exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: "test"}, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if len(holders) != 1 || holders[0].Connection != "test" {
+		t.Fatalf("expected the connection to be locked during execution, got %v", holders)
+	}
+	if !strings.HasSuffix(holders[0].Holder, " (cli, alice)") {
+		t.Errorf("unexpected holder %q", holders[0].Holder)
+	}
+	if locks, _ := tracker.ListLocks(context.Background()); len(locks) != 0 {
+		t.Errorf("expected the lock to be released after execution, got %v", locks)
+	}
+}
@@ -0,0 +1,184 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+const parallelConnectionsKey contextKey = "bfm_parallel_connections"
+
+// ConnectionResult is the outcome of an up execution on one connection of a multi-connection request
+type ConnectionResult struct {
+	Connection string
+	Result     *ExecuteResult
+}
+
+// ResolveConnections expands connection names and glob patterns (path.Match syntax, e.g. "tenant_*")
+// against the configured connections. Each entry must match at least one connection; the result is
+// sorted and free of duplicates.
+func (e *Executor) ResolveConnections(patterns []string) ([]string, error) {
+	e.mu.Lock()
+	configured := make([]string, 0, len(e.connections))
+	for name := range e.connections {
+		configured = append(configured, name)
+	}
+	e.mu.Unlock()
+	sort.Strings(configured)
+
+	seen := make(map[string]bool)
+	var resolved []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid connection pattern %q: %w", pattern, err)
+		}
+		matched := false
+		for _, name := range configured {
+			if ok, _ := path.Match(pattern, name); ok {
+				matched = true
+				if !seen[name] {
+					seen[name] = true
+					resolved = append(resolved, name)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no connection matches %q", pattern)
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("no connections given")
+	}
+	sort.Strings(resolved)
+	return resolved, nil
+}
+
+// WithParallelConnections marks ctx so ExecuteUpConnections runs the connections at the same time
+// instead of one after another
+func WithParallelConnections(ctx context.Context) context.Context {
+	return context.WithValue(ctx, parallelConnectionsKey, true)
+}
+
+// ExecuteUpConnections runs ExecuteUp on each connection matching the given names or glob patterns,
+// one connection at a time unless ctx is marked WithParallelConnections, and reports a result per
+// connection in the order of the names. A failing connection does not stop the others. The target's
+// Connection filter is replaced by each connection in turn.
+func (e *Executor) ExecuteUpConnections(ctx context.Context, target *registry.MigrationTarget, connections []string, schemas []string, dryRun bool, ignoreDependencies bool) ([]*ConnectionResult, error) {
+	ctx = withRunID(ctx)
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	names, err := e.ResolveConnections(connections)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*registry.MigrationTarget, len(names))
+	for i, name := range names {
+		targets[i] = &registry.MigrationTarget{}
+		if target != nil {
+			*targets[i] = *target
+		}
+		targets[i].Connection = name
+	}
+
+	results := make([]*ConnectionResult, len(names))
+	run := func(ctx context.Context, i int) {
+		result, err := e.ExecuteUp(ctx, targets[i], names[i], schemas, dryRun, ignoreDependencies)
+		if err != nil {
+			result = &ExecuteResult{Applied: []string{}, Skipped: []string{}, Errors: []string{err.Error()}}
+		}
+		results[i] = &ConnectionResult{Connection: names[i], Result: result}
+	}
+
+	lanes := e.connectionLanes(ctx, targets, ignoreDependencies)
+	if len(lanes) == 1 {
+		for _, i := range lanes[0] {
+			run(ctx, i)
+		}
+		return results, nil
+	}
+
+	logger.Infof("Executing %d connections in %d parallel lanes", len(names), len(lanes))
+	var wg sync.WaitGroup
+	for _, lane := range lanes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each lane connects its own instances of the backends that can be cloned
+			ctx := context.WithValue(ctx, backendInstancesKey, make(map[string]backends.Backend))
+			for _, i := range lane {
+				run(ctx, i)
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// connectionLanes groups the indexes of targets into lanes that may run at the same time, each
+// lane running its connections in turn. Without WithParallelConnections everything is one lane.
+// Connections whose backend cannot be cloned share a lane, since they would share one backend
+// instance, and connections are not run in parallel at all when a migration of one of them depends
+// on a migration of another (unless ignoreDependencies), since both would then apply it.
+func (e *Executor) connectionLanes(ctx context.Context, targets []*registry.MigrationTarget, ignoreDependencies bool) [][]int {
+	all := make([]int, len(targets))
+	for i := range targets {
+		all[i] = i
+	}
+	if parallel, _ := ctx.Value(parallelConnectionsKey).(bool); !parallel || len(targets) <= 1 {
+		return [][]int{all}
+	}
+
+	names := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		names[target.Connection] = true
+	}
+	if !ignoreDependencies {
+		for _, target := range targets {
+			migrations, err := e.registry.FindByTarget(target)
+			if err != nil {
+				return [][]int{all}
+			}
+			for _, migration := range migrations {
+				for _, dependency := range migration.StructuredDependencies {
+					if dependency.Connection != "" && dependency.Connection != target.Connection && names[dependency.Connection] {
+						logger.Infof("Migration %s_%s of %s depends on connection %s, running the connections one at a time",
+							migration.Version, migration.Name, target.Connection, dependency.Connection)
+						return [][]int{all}
+					}
+				}
+			}
+		}
+	}
+
+	var lanes [][]int
+	var shared []int
+	for i, target := range targets {
+		cfg, err := e.getConnectionConfig(ctx, target.Connection)
+		if err != nil {
+			shared = append(shared, i)
+			continue
+		}
+		if backend, _ := e.registeredBackend(cfg.Backend); !isCloner(backend) {
+			shared = append(shared, i)
+			continue
+		}
+		lanes = append(lanes, []int{i})
+	}
+	if len(shared) > 0 {
+		lanes = append(lanes, shared)
+	}
+	return lanes
+}
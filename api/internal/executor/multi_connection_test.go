@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func newMultiConnectionTestExecutor(t *testing.T) *Executor {
+	t.Helper()
+	reg := newMockRegistry()
+	for _, connection := range []string{"tenant_a", "tenant_b", "core"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20240101120000", Name: "create_users", Connection: connection, Backend: "postgresql",
+			UpSQL: "CREATE TABLE users (id INT);",
+		})
+	}
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"tenant_a": {Backend: "postgresql"},
+		"tenant_b": {Backend: "postgresql"},
+		"core":     {Backend: "postgresql"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	return exec
+}
+
+func TestExecutor_ResolveConnections(t *testing.T) {
+	exec := newMultiConnectionTestExecutor(t)
+
+	got, err := exec.ResolveConnections([]string{"tenant_*", "core", "tenant_a"})
+	if err != nil {
+		t.Fatalf("ResolveConnections() error = %v", err)
+	}
+	if want := []string{"core", "tenant_a", "tenant_b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveConnections() = %v, want %v", got, want)
+	}
+
+	for _, patterns := range [][]string{{"missing"}, {"tenant_["}, {}} {
+		if _, err := exec.ResolveConnections(patterns); err == nil {
+			t.Errorf("ResolveConnections(%q) expected an error", patterns)
+		}
+	}
+}
+
+func TestExecutor_ExecuteUpConnections(t *testing.T) {
+	exec := newMultiConnectionTestExecutor(t)
+
+	results, err := exec.ExecuteUpConnections(context.Background(), &registry.MigrationTarget{Backend: "postgresql"}, []string{"tenant_*"}, nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUpConnections() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 connection results, got %d", len(results))
+	}
+	for i, connection := range []string{"tenant_a", "tenant_b"} {
+		r := results[i]
+		if r.Connection != connection || !r.Result.Success {
+			t.Errorf("result %d = %s (success=%v, errors=%v)", i, r.Connection, r.Result.Success, r.Result.Errors)
+		}
+		if want := []string{"20240101120000_create_users_postgresql_" + connection}; !reflect.DeepEqual(r.Result.Applied, want) {
+			t.Errorf("%s applied = %v, want %v", connection, r.Result.Applied, want)
+		}
+	}
+}
+
+func TestExecutor_ExecuteUpConnections_Parallel(t *testing.T) {
+	reg := testsupport.NewRegistry()
+	for _, connection := range []string{"tenant_a", "tenant_b", "tenant_c"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20240101120000", Name: "create_users", Connection: connection, Backend: "postgresql",
+			UpSQL: "CREATE TABLE users (id INT);",
+		})
+	}
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"tenant_a": {Backend: "postgresql"},
+		"tenant_b": {Backend: "postgresql"},
+		"tenant_c": {Backend: "postgresql"},
+	})
+	backend := testsupport.NewBackend("postgresql")
+	exec.RegisterBackend("postgresql", cloningBackend{backend})
+
+	ctx := WithParallelConnections(context.Background())
+	results, err := exec.ExecuteUpConnections(ctx, &registry.MigrationTarget{Backend: "postgresql"}, []string{"tenant_*"}, nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUpConnections() error = %v", err)
+	}
+	for i, connection := range []string{"tenant_a", "tenant_b", "tenant_c"} {
+		r := results[i]
+		if r.Connection != connection || !r.Result.Success || len(r.Result.Applied) != 1 {
+			t.Errorf("result %d = %s (applied=%v, errors=%v)", i, r.Connection, r.Result.Applied, r.Result.Errors)
+		}
+	}
+	if executed := backend.Executed(); len(executed) != 3 {
+		t.Errorf("expected one migration per connection, got %d", len(executed))
+	}
+}
+
+// Parallel lanes on one backend type must each validate and execute on an instance connected to
+// their own connection (run with -race)
+func TestExecutor_ExecuteUpConnections_ParallelInstances(t *testing.T) {
+	reg := testsupport.NewRegistry()
+	connections := map[string]*backends.ConnectionConfig{}
+	for _, connection := range []string{"tenant_a", "tenant_b", "tenant_c", "tenant_d"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20240101120000", Name: "create_users", Connection: connection, Backend: "postgresql",
+			UpSQL: "CREATE TABLE users (id INT);",
+		})
+		connections[connection] = &backends.ConnectionConfig{Backend: "postgresql", Database: connection}
+	}
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(connections)
+	exec.RegisterBackend("postgresql", &poolBackend{Backend: testsupport.NewBackend("postgresql")})
+
+	ctx := WithParallelConnections(context.Background())
+	results, err := exec.ExecuteUpConnections(ctx, &registry.MigrationTarget{Backend: "postgresql"}, []string{"tenant_*"}, nil, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteUpConnections() error = %v", err)
+	}
+	for _, r := range results {
+		if !r.Result.Success || len(r.Result.Applied) != 1 {
+			t.Errorf("%s: applied=%v, errors=%v", r.Connection, r.Result.Applied, r.Result.Errors)
+		}
+	}
+}
+
+func TestExecutor_ConnectionLanes(t *testing.T) {
+	reg := testsupport.NewRegistry(
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql"},
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_orders", Connection: "billing", Backend: "postgresql"},
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_metrics", Connection: "metrics", Backend: "greptimedb"},
+		&backends.MigrationScript{Version: "20240101120000", Name: "create_logs", Connection: "logs", Backend: "greptimedb"},
+		&backends.MigrationScript{
+			Version: "20240102120000", Name: "link_users", Connection: "guard", Backend: "postgresql",
+			StructuredDependencies: []backends.Dependency{{Connection: "core", Target: "create_users"}},
+		},
+	)
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core":    {Backend: "postgresql"},
+		"billing": {Backend: "postgresql"},
+		"guard":   {Backend: "postgresql"},
+		"metrics": {Backend: "greptimedb"},
+		"logs":    {Backend: "greptimedb"},
+	})
+	exec.RegisterBackend("postgresql", cloningBackend{testsupport.NewBackend("postgresql")})
+	exec.RegisterBackend("greptimedb", testsupport.NewBackend("greptimedb"))
+	parallel := WithParallelConnections(context.Background())
+
+	targets := func(connections ...string) []*registry.MigrationTarget {
+		var targets []*registry.MigrationTarget
+		for _, connection := range connections {
+			targets = append(targets, &registry.MigrationTarget{Connection: connection})
+		}
+		return targets
+	}
+	tests := []struct {
+		name               string
+		ctx                context.Context
+		targets            []*registry.MigrationTarget
+		ignoreDependencies bool
+		want               [][]int
+	}{
+		{"not requested", context.Background(), targets("billing", "core"), false, [][]int{{0, 1}}},
+		{"cloned backends", parallel, targets("billing", "core"), false, [][]int{{0}, {1}}},
+		{"shared backend", parallel, targets("billing", "logs", "metrics"), false, [][]int{{0}, {1, 2}}},
+		{"cross-connection dependency", parallel, targets("core", "guard"), false, [][]int{{0, 1}}},
+		{"dependencies ignored", parallel, targets("core", "guard"), true, [][]int{{0}, {1}}},
+		{"dependency outside the request", parallel, targets("billing", "guard"), false, [][]int{{0}, {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exec.connectionLanes(tt.ctx, tt.targets, tt.ignoreDependencies); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("connectionLanes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Out-of-order policies: what executions do with a migration older than the latest one applied to
+// its connection and schema, such as a branch merged after newer migrations were deployed
+const (
+	OutOfOrderAllow = "allow" // Apply it
+	OutOfOrderWarn  = "warn"  // Apply it and log a warning (default)
+	OutOfOrderBlock = "block" // Fail it without running it
+)
+
+// ExtraOutOfOrder overrides the out-of-order policy of a connection ({CONNECTION}_OUT_OF_ORDER)
+const ExtraOutOfOrder = "OUT_OF_ORDER"
+
+// OutOfOrderDecision is recorded under "out_of_order" in the execution_context of the history
+// record of a migration that was older than the latest applied one
+type OutOfOrderDecision struct {
+	Policy        string `json:"policy"`         // Policy that decided: allow, warn or block
+	LatestApplied string `json:"latest_applied"` // Version of the latest applied migration
+}
+
+// ParseOutOfOrderPolicy parses an out-of-order policy; "" is OutOfOrderWarn
+func ParseOutOfOrderPolicy(value string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(value)); policy {
+	case "":
+		return OutOfOrderWarn, nil
+	case OutOfOrderAllow, OutOfOrderWarn, OutOfOrderBlock:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown out-of-order policy %q (allow, warn or block)", value)
+	}
+}
+
+// SetOutOfOrderPolicy sets the out-of-order policy of connections without one of their own
+func (e *Executor) SetOutOfOrderPolicy(policy string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.outOfOrderPolicy = policy
+}
+
+// outOfOrderPolicyOf returns the out-of-order policy of a connection. An invalid override is
+// treated as OutOfOrderBlock, so a typo does not let late migrations through.
+func (e *Executor) outOfOrderPolicyOf(cfg *backends.ConnectionConfig) string {
+	if value := cfg.ExtraValue(ExtraOutOfOrder); value != "" {
+		policy, err := ParseOutOfOrderPolicy(value)
+		if err != nil {
+			return OutOfOrderBlock
+		}
+		return policy
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.outOfOrderPolicy == "" {
+		return OutOfOrderWarn
+	}
+	return e.outOfOrderPolicy
+}
+
+// latestAppliedVersion returns the latest version applied to a connection and schema other than
+// migrationID's, "" when none is
+func (e *Executor) latestAppliedVersion(ctx context.Context, connection, schema, migrationID string) (string, error) {
+	items, _, err := e.stateTracker.GetMigrationList(ctx, &state.MigrationFilters{Connection: connection, Schema: schema})
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	for _, item := range items {
+		if item.Applied && item.MigrationID != migrationID && registry.VersionAfter(item.Version, latest) {
+			latest = item.Version
+		}
+	}
+	return latest, nil
+}
+
+// checkOutOfOrder compares migration, about to be applied to schema as migrationID, with the
+// latest migration applied to its connection and schema. It returns the decision to record when
+// the migration is older, and an error when the connection's policy blocks it.
+func (e *Executor) checkOutOfOrder(ctx context.Context, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID, schema string) (*OutOfOrderDecision, error) {
+	latest, err := e.latestAppliedVersion(ctx, migration.Connection, schema, migrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migration order: %w", err)
+	}
+	if latest == "" || !registry.VersionAfter(latest, migration.Version) {
+		return nil, nil
+	}
+
+	decision := &OutOfOrderDecision{Policy: e.outOfOrderPolicyOf(cfg), LatestApplied: latest}
+	switch decision.Policy {
+	case OutOfOrderBlock:
+		return decision, fmt.Errorf("out of order: version %s is older than %s, already applied to connection %s; set %s to allow it",
+			migration.Version, latest, migration.Connection, ExtraOutOfOrder)
+	case OutOfOrderWarn:
+		logger.Warnf("Applying %s out of order: version %s is older than %s, already applied to connection %s",
+			migrationID, migration.Version, latest, migration.Connection)
+	}
+	return decision, nil
+}
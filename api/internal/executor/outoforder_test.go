@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestExecutor_OutOfOrder(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	const migrationID = "20240101120000_create_users_postgresql_test"
+
+	// A newer migration of another schema does not make it late
+	tracker.MarkApplied("tenant_a_20240301120000_add_index_postgresql_test")
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success || strings.Contains(lastRecord(tracker).ExecutionContext, "out_of_order") {
+		t.Fatalf("expected the migration to be applied in order, got %+v, %v", result, err)
+	}
+
+	// warn (default): applied, and the decision recorded
+	_ = tracker.DeleteMigration(context.Background(), migrationID)
+	tracker.MarkApplied("public_20240201120000_add_email_postgresql_test")
+	result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+	}
+	var execCtx map[string]OutOfOrderDecision
+	record := lastRecord(tracker)
+	_ = json.Unmarshal([]byte(record.ExecutionContext), &execCtx)
+	if decision := execCtx["out_of_order"]; decision.Policy != OutOfOrderWarn || decision.LatestApplied != "20240201120000" {
+		t.Fatalf("expected the warn decision in the history record, got %q", record.ExecutionContext)
+	}
+
+	// block: failed without running, and the decision recorded
+	_ = tracker.DeleteMigration(context.Background(), migrationID)
+	backend.executeCalled = false
+	exec.connections["test"].Extra = map[string]string{ExtraOutOfOrder: OutOfOrderBlock}
+	result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "out of order: version 20240101120000 is older than 20240201120000") || backend.executeCalled {
+		t.Fatalf("expected the late migration to be blocked, got %+v", result)
+	}
+	if record := lastRecord(tracker); record.Status != "failed" || !strings.Contains(record.ExecutionContext, `"policy":"block"`) {
+		t.Errorf("expected the block decision in the failed history record, got %+v", record)
+	}
+
+	// The executor's policy applies to connections without their own
+	_ = tracker.DeleteMigration(context.Background(), migrationID)
+	exec.connections["test"].Extra = nil
+	exec.SetOutOfOrderPolicy(OutOfOrderAllow)
+	if result, err = exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil || !result.Success {
+		t.Fatalf("expected the late migration to be allowed, got %+v, %v", result, err)
+	}
+	if record := lastRecord(tracker); !strings.Contains(record.ExecutionContext, `"policy":"allow"`) {
+		t.Errorf("expected the allow decision in the history record, got %q", record.ExecutionContext)
+	}
+
+	if _, err := ParseOutOfOrderPolicy("sometimes"); err == nil {
+		t.Errorf("expected an unknown policy to be rejected")
+	}
+}
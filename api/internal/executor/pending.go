@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// PendingMigration is a registered migration that has not been applied
+type PendingMigration struct {
+	MigrationID string
+	Version     string
+	Name        string
+	Connection  string
+	Backend     string
+	Schema      string
+}
+
+// PendingMigrations returns the registered migrations for connection that are not applied, ordered
+// by version. Dynamic-schema migrations are only tracked per schema, so they are included when
+// schemaName is given and left out otherwise.
+func (e *Executor) PendingMigrations(ctx context.Context, connection, schemaName string) ([]*PendingMigration, error) {
+	if _, err := e.getConnectionConfig(ctx, connection); err != nil {
+		return nil, err
+	}
+
+	migrations := e.registry.GetByConnection(connection)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		if migrations[i].Version != migrations[j].Version {
+			return migrations[i].Version < migrations[j].Version
+		}
+		return migrations[i].Name < migrations[j].Name
+	})
+
+	pending := []*PendingMigration{}
+	for _, migration := range migrations {
+		id := e.executionMigrationID(migration, schemaName)
+		if id == "" {
+			continue
+		}
+		applied, err := e.isUpToDate(ctx, migration, id, schemaName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check migration %s: %w", id, err)
+		}
+		if applied {
+			continue
+		}
+
+		schema := migration.Schema
+		if schemaName != "" {
+			schema = schemaName
+		}
+		pending = append(pending, &PendingMigration{
+			MigrationID: id,
+			Version:     migration.Version,
+			Name:        migration.Name,
+			Connection:  migration.Connection,
+			Backend:     migration.Backend,
+			Schema:      schema,
+		})
+	}
+	return pending, nil
+}
@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestExecutor_PendingMigrations(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Version: "20240102120000", Name: "create_orders", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE {{.Schema}}.orders (id INT);",
+	})
+
+	pending, err := exec.PendingMigrations(context.Background(), "test", "")
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].MigrationID != "20240101120000_create_users_postgresql_test" {
+		t.Fatalf("expected only the fixed-schema migration without a schema, got %v", pending)
+	}
+
+	pending, err = exec.PendingMigrations(context.Background(), "test", "tenant_a")
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if len(pending) != 2 || pending[1].MigrationID != "tenant_a_20240102120000_create_orders_postgresql_test" || pending[1].Schema != "tenant_a" {
+		t.Fatalf("expected both migrations for the schema in version order, got %v", pending)
+	}
+
+	tracker.MarkApplied("20240101120000_create_users_postgresql_test")
+	pending, err = exec.PendingMigrations(context.Background(), "test", "")
+	if err != nil || len(pending) != 0 {
+		t.Errorf("PendingMigrations() = %v, %v; want none pending", pending, err)
+	}
+
+	if _, err := exec.PendingMigrations(context.Background(), "missing", ""); err == nil {
+		t.Errorf("expected an error for an unknown connection")
+	}
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// ErrPlanChanged is returned when an execution is pinned to the checksums of an approved plan and
+// a migration it would apply was modified or added since the plan was made
+var ErrPlanChanged = errors.New("migrations changed since the plan was approved")
+
+const pinnedChecksumsKey contextKey = "bfm_pinned_checksums"
+
+// WithPinnedChecksums pins executions on ctx to the checksums of a plan (ExecutionPlan.Checksums),
+// keyed by migration ID. Before anything runs, every migration that would be applied must be in
+// the plan with an unchanged checksum; otherwise the execution is refused with ErrPlanChanged.
+// An empty map pins nothing.
+func WithPinnedChecksums(ctx context.Context, checksums map[string]string) context.Context {
+	if len(checksums) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedChecksumsKey, checksums)
+}
+
+// PinnedChecksums returns the checksums ctx is pinned to, or nil
+func PinnedChecksums(ctx context.Context) map[string]string {
+	checksums, _ := ctx.Value(pinnedChecksumsKey).(map[string]string)
+	return checksums
+}
+
+// verifyPinnedPlan checks migrations, with their pending dependencies unless ignoreDependencies,
+// against the pinned plan for every schema before any of them runs, so a multi-schema execution
+// is refused as a whole rather than after its first schemas were applied
+func (e *Executor) verifyPinnedPlan(ctx context.Context, migrations []*backends.MigrationScript, schemas []string, ignoreDependencies bool) error {
+	if PinnedChecksums(ctx) == nil || len(migrations) == 0 {
+		return nil
+	}
+	if !ignoreDependencies {
+		var err error
+		migrations, _, _, err = e.expandWithPendingDependencies(ctx, migrations)
+		if err != nil {
+			return fmt.Errorf("failed to expand migrations with dependencies: %w", err)
+		}
+	}
+	for _, schema := range schemas {
+		if err := e.checkPinnedChecksums(ctx, migrations, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPinnedChecksums verifies the migrations an execution would apply against the pinned plan.
+// Migrations that are already applied are skipped by the execution and not checked. The error
+// lists each difference, e.g. "20240101120000_add_users_postgresql_core: planned 3f2a9c1b, now 8d41e07a".
+func (e *Executor) checkPinnedChecksums(ctx context.Context, migrations []*backends.MigrationScript, schemaName string) error {
+	pinned := PinnedChecksums(ctx)
+	if pinned == nil {
+		return nil
+	}
+
+	var diffs []string
+	for _, migration := range migrations {
+		migrationID := e.executionMigrationID(migration, schemaName)
+		if migrationID == "" {
+			continue
+		}
+		applied, err := e.isUpToDate(ctx, migration, migrationID, schemaName)
+		if err != nil {
+			return fmt.Errorf("failed to check migration status for %s: %w", migrationID, err)
+		}
+		if applied {
+			continue
+		}
+
+		planned, ok := pinned[migrationID]
+		if !ok {
+			planned, ok = pinned[e.getMigrationID(migration)]
+		}
+		current := migration.Checksum()
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s: not in the plan", migrationID))
+		case planned != current:
+			diffs = append(diffs, fmt.Sprintf("%s: planned %s, now %s", migrationID, shortChecksum(planned), shortChecksum(current)))
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	sort.Strings(diffs)
+	return fmt.Errorf("%w: %s", ErrPlanChanged, strings.Join(diffs, "; "))
+}
+
+// shortChecksum abbreviates a checksum for messages
+func shortChecksum(checksum string) string {
+	if len(checksum) > 8 {
+		return checksum[:8]
+	}
+	return checksum
+}
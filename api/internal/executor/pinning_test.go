@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestExecutor_PinnedChecksums(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	target := &registry.MigrationTarget{Connection: "test"}
+	const id = "20240101120000_create_users_postgresql_test"
+
+	plan, err := exec.Plan(context.Background(), target, "test", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.Checksums[id] == "" || plan.Checksums[id] != plan.Steps[0].Checksum {
+		t.Fatalf("expected the plan to pin %s, got %v", id, plan.Checksums)
+	}
+	ctx := WithPinnedChecksums(context.Background(), plan.Checksums)
+
+	// Edit the migration after the plan was approved
+	original := exec.registry.GetAll()[0].UpSQL
+	exec.registry.GetAll()[0].UpSQL = "CREATE TABLE users (id BIGINT);"
+	_, err = exec.ExecuteUp(ctx, target, "test", []string{"tenant1", "tenant2"}, false, false)
+	if !errors.Is(err, ErrPlanChanged) || !strings.Contains(err.Error(), id+": planned "+plan.Checksums[id][:8]) {
+		t.Fatalf("expected ErrPlanChanged for %s, got %v", id, err)
+	}
+	if len(tracker.History()) != 0 {
+		t.Fatalf("expected nothing to be applied, got %d records", len(tracker.History()))
+	}
+
+	// A migration added after the plan is refused as well
+	exec.registry.GetAll()[0].UpSQL = original
+	_ = exec.registry.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "create_orders", Connection: "test", Backend: "postgresql",
+		UpSQL: "CREATE TABLE orders (id INT);",
+	})
+	if _, err := exec.ExecuteSync(ctx, target, "test", "", false, false); !errors.Is(err, ErrPlanChanged) ||
+		!strings.Contains(err.Error(), "20240102120000_create_orders_postgresql_test: not in the plan") {
+		t.Fatalf("expected the new migration to be refused, got %v", err)
+	}
+
+	// The planned migration alone still runs as approved
+	result, err := exec.ExecuteUpIDs(ctx, []string{id}, "test", nil, false, false)
+	if err != nil || !result.Success || len(result.Applied) != 1 {
+		t.Fatalf("ExecuteUpIDs() = %+v, %v; want the pinned migration applied", result, err)
+	}
+}
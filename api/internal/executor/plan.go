@@ -0,0 +1,370 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// Plan step actions
+const (
+	PlanActionApply = "apply"
+	PlanActionSkip  = "skip"
+)
+
+// PlanStep is one migration in a resolved execution plan
+type PlanStep struct {
+	Order       int      // 1-based position in the execution order
+	MigrationID string   // ID the migration is tracked under (schema-specific when a schema was requested)
+	Version     string   // Migration version
+	Name        string   // Migration name
+	Backend     string   // Backend name
+	Connection  string   // Connection name
+	Schema      string   // Schema the migration would run against
+	Action      string   // PlanActionApply or PlanActionSkip
+	Reason      string   // Human-readable reason for the action and position
+	DependsOn   []string // Migrations in the plan that must run before this one
+	RequiredBy  string   // Set when the migration was auto-included as a pending dependency
+	// DownGenerated is set when the migration has no down script and one was generated from
+	// its up script; DownSQL then holds the generated script for review
+	DownGenerated bool
+	DownSQL       string
+	Checksum      string // Checksum of the up script, for pinning an execution to this plan
+	// Statements is the number of statements the up script would be executed as, for migrations
+	// that would be applied on a backend that splits scripts (0 otherwise)
+	Statements int
+	// Destructive lists the kinds of statements of the up script that discard data (see
+	// backends.DestructiveStatements); empty for safe migrations
+	Destructive []string
+
+	// Findings of the registered validators, for migrations that would be applied. Error
+	// findings are also reported in ExecutionPlan.Errors, and refuse the execution.
+	Findings []ValidatorFinding
+}
+
+// ExecutionPlan is the ordered result of resolving a migration target without executing it
+type ExecutionPlan struct {
+	Steps  []PlanStep
+	Apply  []string // Migration IDs that would be applied, in order
+	Skip   []string // Migration IDs that would be skipped as already applied
+	Errors []string
+	// Checksums maps each migration that would be applied to its checksum. Passing it to
+	// WithPinnedChecksums refuses the execution if a migration changes before it runs.
+	Checksums map[string]string
+}
+
+// Plan resolves the migrations an up request would run, in execution order, without
+// executing anything. It performs the same target lookup, pending-dependency expansion and
+// topological sort as ExecuteUp, and reports for each step whether it would be applied or
+// skipped and which dependencies forced its position.
+func (e *Executor) Plan(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemas []string, ignoreDependencies bool) (*ExecutionPlan, error) {
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	if _, err := e.getConnectionConfig(ctx, connectionName); err != nil {
+		return nil, fmt.Errorf("failed to get connection config: %w", err)
+	}
+	if target == nil {
+		target = &registry.MigrationTarget{Connection: connectionName}
+	}
+
+	migrations, err := e.registry.FindByTarget(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find migrations: %w", err)
+	}
+
+	plan := &ExecutionPlan{
+		Steps:     []PlanStep{},
+		Apply:     []string{},
+		Skip:      []string{},
+		Errors:    []string{},
+		Checksums: map[string]string{},
+	}
+	if len(migrations) == 0 {
+		return plan, nil
+	}
+
+	var sortedMigrations []*backends.MigrationScript
+	dependencyParentMap := make(map[string]string)
+	if ignoreDependencies {
+		sort.Slice(migrations, func(i, j int) bool {
+			return migrations[i].Version < migrations[j].Version
+		})
+		sortedMigrations = migrations
+	} else {
+		migrations, _, dependencyParentMap, err = e.expandWithPendingDependencies(ctx, migrations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand migrations with dependencies: %w", err)
+		}
+
+		sortedMigrations, err = e.resolveDependencies(migrations)
+		if err != nil {
+			// Same fallback as execution: version order, with the resolution error reported
+			sort.Slice(migrations, func(i, j int) bool {
+				return migrations[i].Version < migrations[j].Version
+			})
+			sortedMigrations = migrations
+			plan.Errors = append(plan.Errors, fmt.Sprintf("dependency resolution: %v", err))
+		}
+	}
+
+	var dependsOn map[string][]string
+	if !ignoreDependencies {
+		dependsOn = e.planDependencyEdges(sortedMigrations)
+	}
+
+	if len(schemas) == 0 {
+		schemas = []string{""}
+	}
+
+	for _, schemaName := range schemas {
+		// Tracking IDs for this schema, keyed by base migration ID
+		planIDs := make(map[string]string)
+		for _, migration := range sortedMigrations {
+			planIDs[e.getMigrationID(migration)] = e.executionMigrationID(migration, schemaName)
+		}
+
+		for _, migration := range sortedMigrations {
+			baseID := e.getMigrationID(migration)
+			migrationID := planIDs[baseID]
+			if migrationID == "" {
+				plan.Errors = append(plan.Errors, fmt.Sprintf("migration %s_%s has dynamic schema but no schema provided in request", migration.Version, migration.Name))
+				continue
+			}
+
+			schema := schemaName
+			if schema == "" {
+				schema = migration.Schema
+			}
+
+			step := PlanStep{
+				Order:       len(plan.Steps) + 1,
+				MigrationID: migrationID,
+				Version:     migration.Version,
+				Name:        migration.Name,
+				Backend:     migration.Backend,
+				Connection:  migration.Connection,
+				Schema:      schema,
+				DependsOn:   []string{},
+				Checksum:    migration.Checksum(),
+				Destructive: migration.DestructiveStatements(),
+			}
+			for _, depBaseID := range dependsOn[baseID] {
+				if depID := planIDs[depBaseID]; depID != "" {
+					step.DependsOn = append(step.DependsOn, depID)
+				}
+			}
+			if parentID, ok := dependencyParentMap[baseID]; ok {
+				step.RequiredBy = planIDs[parentID]
+			}
+			if migration.DownGenerated {
+				step.DownGenerated = true
+				step.DownSQL = migration.DownSQL
+			}
+
+			applied, err := e.isUpToDate(ctx, migration, migrationID, schema)
+			if err != nil {
+				plan.Errors = append(plan.Errors, fmt.Sprintf("failed to check migration status for %s: %v", migrationID, err))
+				continue
+			}
+
+			superseded := false
+			if !applied {
+				if superseded, err = e.squashSuperseded(ctx, migration); err != nil {
+					plan.Errors = append(plan.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+					continue
+				}
+			}
+
+			if applied {
+				step.Action = PlanActionSkip
+				step.Reason = "already applied"
+				plan.Skip = append(plan.Skip, migrationID)
+			} else if superseded {
+				step.Action = PlanActionSkip
+				step.Reason = "squashed baseline of applied migrations: recorded, not executed"
+				plan.Skip = append(plan.Skip, migrationID)
+			} else {
+				step.Action = PlanActionApply
+				step.Reason = planStepReason(step)
+				plan.Apply = append(plan.Apply, migrationID)
+				plan.Checksums[migrationID] = step.Checksum
+				step.Statements = e.planStatementCount(ctx, migration, schema)
+				step.Findings = e.validateMigration(withPlanning(ctx), migration, schemaName)
+				for _, finding := range step.Findings {
+					if finding.Severity == FindingError {
+						plan.Errors = append(plan.Errors, fmt.Sprintf("%s: %s: %s", migrationID, finding.Validator, finding.Message))
+					}
+				}
+			}
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+
+	return plan, nil
+}
+
+// planStepReason describes why a pending migration is in the plan and at its position
+func planStepReason(step PlanStep) string {
+	reason := "pending"
+	if step.RequiredBy != "" {
+		reason = fmt.Sprintf("pending dependency of %s", step.RequiredBy)
+	}
+	if len(step.DependsOn) > 0 {
+		reason += "; ordered after " + strings.Join(step.DependsOn, ", ")
+	}
+	return reason
+}
+
+// planStatementCount returns the number of statements the up script of a migration would be
+// executed as in a schema. A script whose template variables cannot be rendered is counted as is;
+// the execution reports the rendering error.
+func (e *Executor) planStatementCount(ctx context.Context, migration *backends.MigrationScript, schema string) int {
+	if migration.Declarative {
+		return 0
+	}
+	cfg, err := e.getConnectionConfig(ctx, migration.Connection)
+	if err != nil {
+		return 0
+	}
+	upSQL, _, err := renderTemplate(migration.UpSQL, migration, schema, templatePolicyFromConnection(cfg))
+	if err != nil {
+		upSQL = migration.UpSQL
+	}
+	return e.statementCount(cfg, upSQL)
+}
+
+// planDependencyEdges returns, for each migration in the set, the base IDs of the
+// migrations in the same set that it depends on (structured and name dependencies)
+func (e *Executor) planDependencyEdges(migrations []*backends.MigrationScript) map[string][]string {
+	inSet := make(map[string]bool, len(migrations))
+	for _, migration := range migrations {
+		inSet[e.getMigrationID(migration)] = true
+	}
+
+	resolver := registry.NewDependencyResolver(e.registry, e.stateTracker)
+	edges := make(map[string][]string)
+	for _, migration := range migrations {
+		migrationID := e.getMigrationID(migration)
+		seen := make(map[string]bool)
+		addEdge := func(targets []*backends.MigrationScript) {
+			for _, target := range targets {
+				targetID := e.getMigrationID(target)
+				if inSet[targetID] && targetID != migrationID && !seen[targetID] {
+					seen[targetID] = true
+					edges[migrationID] = append(edges[migrationID], targetID)
+				}
+			}
+		}
+
+		for _, dep := range migration.StructuredDependencies {
+			targets, err := resolver.ResolveDependencyTargets(dep)
+			if err != nil {
+				continue
+			}
+			addEdge(targets)
+		}
+		for _, depName := range migration.Dependencies {
+			addEdge(e.registry.GetMigrationByName(depName))
+		}
+	}
+	return edges
+}
+
+// MachinePlanFormatVersion is the version of the MachinePlan format. Fields are only added
+// within a version; it changes when a field is removed or changes meaning.
+const MachinePlanFormatVersion = 1
+
+// MachinePlan is the stable, machine-readable form of an execution plan, for tools that gate on
+// it (CD pipelines, Terraform external data sources) rather than review it
+type MachinePlan struct {
+	FormatVersion int      `json:"format_version"`
+	Connection    string   `json:"connection"`
+	Schemas       []string `json:"schemas"`
+	// HasChanges is set when the plan would apply at least one migration
+	HasChanges bool `json:"has_changes"`
+	// Hash identifies what the plan would apply: the same migrations, in the same order, with the
+	// same checksums give the same hash, whatever is skipped
+	Hash        string            `json:"hash"`
+	Apply       []MachinePlanStep `json:"apply"`
+	Skip        []string          `json:"skip"`
+	Destructive bool              `json:"destructive"` // At least one migration in apply discards data
+	Errors      []string          `json:"errors"`
+}
+
+// MachinePlanStep is a migration a MachinePlan would apply
+type MachinePlanStep struct {
+	MigrationID string   `json:"migration_id"`
+	Version     string   `json:"version"`
+	Name        string   `json:"name"`
+	Backend     string   `json:"backend"`
+	Connection  string   `json:"connection"`
+	Schema      string   `json:"schema"`
+	Checksum    string   `json:"checksum"`
+	Destructive []string `json:"destructive"`
+}
+
+// Machine returns the machine-readable form of the plan of an up request on a connection
+func (p *ExecutionPlan) Machine(connection string, schemas []string) *MachinePlan {
+	machine := &MachinePlan{
+		FormatVersion: MachinePlanFormatVersion,
+		Connection:    connection,
+		Schemas:       append([]string{}, schemas...),
+		Apply:         []MachinePlanStep{},
+		Skip:          append([]string{}, p.Skip...),
+		Errors:        append([]string{}, p.Errors...),
+	}
+	sort.Strings(machine.Schemas)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\x00%s\x00%s\x00", MachinePlanFormatVersion, connection, strings.Join(machine.Schemas, ","))
+	for _, step := range p.Steps {
+		if step.Action != PlanActionApply {
+			continue
+		}
+		destructive := append([]string{}, step.Destructive...)
+		machine.Apply = append(machine.Apply, MachinePlanStep{
+			MigrationID: step.MigrationID,
+			Version:     step.Version,
+			Name:        step.Name,
+			Backend:     step.Backend,
+			Connection:  step.Connection,
+			Schema:      step.Schema,
+			Checksum:    step.Checksum,
+			Destructive: destructive,
+		})
+		machine.Destructive = machine.Destructive || len(destructive) > 0
+		fmt.Fprintf(hash, "%s\x00%s\x00", step.MigrationID, step.Checksum)
+	}
+	machine.HasChanges = len(machine.Apply) > 0
+	machine.Hash = hex.EncodeToString(hash.Sum(nil))
+	return machine
+}
+
+// Flatten returns the plan as a flat object of strings, the result format of Terraform's
+// external data source: lists are comma-separated and booleans are "true" or "false"
+func (m *MachinePlan) Flatten() map[string]string {
+	apply := make([]string, 0, len(m.Apply))
+	for _, step := range m.Apply {
+		apply = append(apply, step.MigrationID)
+	}
+	return map[string]string{
+		"format_version": strconv.Itoa(m.FormatVersion),
+		"connection":     m.Connection,
+		"schemas":        strings.Join(m.Schemas, ","),
+		"has_changes":    strconv.FormatBool(m.HasChanges),
+		"hash":           m.Hash,
+		"apply":          strings.Join(apply, ","),
+		"apply_count":    strconv.Itoa(len(apply)),
+		"skip_count":     strconv.Itoa(len(m.Skip)),
+		"destructive":    strconv.FormatBool(m.Destructive),
+		"errors":         strings.Join(m.Errors, "; "),
+	}
+}
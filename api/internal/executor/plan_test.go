@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func newPlanTestExecutor(t *testing.T, migrations ...*backends.MigrationScript) (*Executor, *testsupport.StateTracker) {
+	t.Helper()
+	reg := newMockRegistry()
+	for _, m := range migrations {
+		_ = reg.Register(m)
+	}
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core": {Backend: "postgresql"},
+	})
+	return exec, tracker
+}
+
+func TestExecutor_Plan_OrdersByDependencyAndReportsSkipped(t *testing.T) {
+	baseline := &backends.MigrationScript{
+		Schema: "core", Version: "20240101000000", Name: "baseline", Connection: "core", Backend: "postgresql",
+	}
+	// orders has the earlier version but depends on users, so it must be planned after it
+	orders := &backends.MigrationScript{
+		Schema: "core", Version: "20240102000000", Name: "create_orders", Connection: "core", Backend: "postgresql",
+		Dependencies: []string{"create_users"},
+	}
+	users := &backends.MigrationScript{
+		Schema: "core", Version: "20240103000000", Name: "create_users", Connection: "core", Backend: "postgresql",
+	}
+	exec, tracker := newPlanTestExecutor(t, baseline, orders, users)
+	tracker.MarkApplied("20240101000000_baseline_postgresql_core")
+
+	plan, err := exec.Plan(context.Background(), &registry.MigrationTarget{Connection: "core"}, "core", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Errors) != 0 {
+		t.Fatalf("unexpected plan errors: %v", plan.Errors)
+	}
+
+	wantOrder := []string{
+		"20240101000000_baseline_postgresql_core",
+		"20240103000000_create_users_postgresql_core",
+		"20240102000000_create_orders_postgresql_core",
+	}
+	if len(plan.Steps) != len(wantOrder) {
+		t.Fatalf("expected %d steps, got %d", len(wantOrder), len(plan.Steps))
+	}
+	for i, id := range wantOrder {
+		if plan.Steps[i].MigrationID != id {
+			t.Errorf("step %d = %s, want %s", i+1, plan.Steps[i].MigrationID, id)
+		}
+		if plan.Steps[i].Order != i+1 {
+			t.Errorf("step %d has Order %d", i+1, plan.Steps[i].Order)
+		}
+	}
+
+	if plan.Steps[0].Action != PlanActionSkip {
+		t.Errorf("expected applied migration to be skipped, got %s", plan.Steps[0].Action)
+	}
+	if len(plan.Skip) != 1 || plan.Skip[0] != wantOrder[0] {
+		t.Errorf("Skip = %v", plan.Skip)
+	}
+	if len(plan.Apply) != 2 || plan.Apply[0] != wantOrder[1] || plan.Apply[1] != wantOrder[2] {
+		t.Errorf("Apply = %v", plan.Apply)
+	}
+
+	ordersStep := plan.Steps[2]
+	if len(ordersStep.DependsOn) != 1 || ordersStep.DependsOn[0] != wantOrder[1] {
+		t.Errorf("expected orders to depend on users, got %v", ordersStep.DependsOn)
+	}
+}
+
+func TestExecutor_Plan_SchemaSpecificIDs(t *testing.T) {
+	dynamic := &backends.MigrationScript{
+		Version: "20240101000000", Name: "tenant_tables", Connection: "core", Backend: "postgresql",
+	}
+	exec, tracker := newPlanTestExecutor(t, dynamic)
+	tracker.MarkApplied("tenant_a_20240101000000_tenant_tables_postgresql_core")
+
+	plan, err := exec.Plan(context.Background(), nil, "core", []string{"tenant_a", "tenant_b"}, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Skip) != 1 || plan.Skip[0] != "tenant_a_20240101000000_tenant_tables_postgresql_core" {
+		t.Errorf("Skip = %v", plan.Skip)
+	}
+	if len(plan.Apply) != 1 || plan.Apply[0] != "tenant_b_20240101000000_tenant_tables_postgresql_core" {
+		t.Errorf("Apply = %v", plan.Apply)
+	}
+
+	// Without a schema a dynamic migration cannot be planned
+	plan, err = exec.Plan(context.Background(), nil, "core", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Steps) != 0 || len(plan.Errors) != 1 {
+		t.Errorf("expected no steps and one error, got steps=%d errors=%v", len(plan.Steps), plan.Errors)
+	}
+}
+
+func TestExecutor_Plan_ConnectionNotFound(t *testing.T) {
+	exec, _ := newPlanTestExecutor(t)
+	if _, err := exec.Plan(context.Background(), nil, "missing", nil, false); err == nil {
+		t.Error("expected error for unknown connection")
+	}
+}
+
+func TestExecutor_Plan_ReportsGeneratedDown(t *testing.T) {
+	upSQL := "CREATE TABLE users (id INT);"
+	downSQL, _ := generateDownSQL(upSQL)
+	users := &backends.MigrationScript{
+		Schema: "core", Version: "20240101000000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: upSQL, DownSQL: downSQL, DownGenerated: true,
+	}
+	exec, _ := newPlanTestExecutor(t, users)
+
+	plan, err := exec.Plan(context.Background(), nil, "core", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Steps) != 1 || !plan.Steps[0].DownGenerated || plan.Steps[0].DownSQL != downSQL {
+		t.Errorf("expected generated down script in plan, got %+v", plan.Steps)
+	}
+}
+
+// splittingBackend splits scripts at every semicolon
+type splittingBackend struct {
+	*mockBackend
+}
+
+func (b *splittingBackend) SplitStatements(sql string) []string {
+	return strings.Split(strings.TrimSuffix(sql, ";"), ";")
+}
+
+func TestExecutor_Plan_ReportsStatementCount(t *testing.T) {
+	users := &backends.MigrationScript{
+		Schema: "core", Version: "20240101000000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);CREATE INDEX users_id ON users (id);",
+	}
+	exec, _ := newPlanTestExecutor(t, users)
+
+	plan, err := exec.Plan(context.Background(), nil, "core", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].Statements != 0 {
+		t.Fatalf("expected no statement count without a splitting backend, got %+v", plan.Steps)
+	}
+
+	exec.RegisterBackend("postgresql", &splittingBackend{newMockBackend("postgresql")})
+	plan, err = exec.Plan(context.Background(), nil, "core", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].Statements != 2 {
+		t.Errorf("expected 2 statements, got %+v", plan.Steps)
+	}
+}
+
+func TestExecutionPlan_Machine(t *testing.T) {
+	users := &backends.MigrationScript{
+		Schema: "core", Version: "20240101000000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);",
+	}
+	orders := &backends.MigrationScript{
+		Schema: "core", Version: "20240102000000", Name: "drop_orders", Connection: "core", Backend: "postgresql",
+		UpSQL: "DROP TABLE orders;",
+	}
+	exec, tracker := newPlanTestExecutor(t, users, orders)
+	planMachine := func() *MachinePlan {
+		t.Helper()
+		plan, err := exec.Plan(context.Background(), nil, "core", nil, false)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		return plan.Machine("core", nil)
+	}
+
+	first := planMachine()
+	if !first.HasChanges || len(first.Apply) != 2 || !first.Destructive || first.FormatVersion != MachinePlanFormatVersion {
+		t.Fatalf("Machine() = %+v", first)
+	}
+	if again := planMachine(); again.Hash != first.Hash {
+		t.Errorf("hash of the same plan changed: %s, then %s", first.Hash, again.Hash)
+	}
+
+	// Editing a migration to apply changes the hash
+	orders.UpSQL = "DROP TABLE orders CASCADE;"
+	edited := planMachine()
+	if edited.Hash == first.Hash {
+		t.Error("hash did not change with the checksum of a migration to apply")
+	}
+
+	// Once everything is applied the plan has no changes
+	tracker.MarkApplied("20240101000000_create_users_postgresql_core")
+	tracker.MarkApplied("20240102000000_drop_orders_postgresql_core")
+	applied := planMachine()
+	if applied.HasChanges || len(applied.Apply) != 0 || len(applied.Skip) != 2 || applied.Destructive {
+		t.Errorf("Machine() of an applied plan = %+v", applied)
+	}
+	flat := applied.Flatten()
+	if flat["has_changes"] != "false" || flat["apply_count"] != "0" || flat["skip_count"] != "2" || flat["hash"] != applied.Hash {
+		t.Errorf("Flatten() = %v", flat)
+	}
+}
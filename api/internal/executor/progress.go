@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Progress statuses
+const (
+	ProgressRunning   = "running"   // The migration started
+	ProgressStatement = "statement" // A statement of the migration started or completed
+	ProgressSuccess   = "success"   // The migration was applied
+	ProgressFailed    = "failed"    // The migration failed
+)
+
+const progressKey contextKey = "bfm_progress"
+
+// Progress reports an up migration while it executes. Statement fields are set for statement
+// progress only, from backends that execute scripts statement by statement.
+type Progress struct {
+	MigrationID  string
+	Schema       string
+	Status       string // One of the Progress* statuses
+	Message      string // The error of a failed migration
+	Statement    int    // 1-based position of the statement in the script
+	Statements   int
+	Done         bool  // The statement completed; false when it starts
+	RowsAffected int64 // Rows affected by a completed statement
+	Elapsed      time.Duration
+}
+
+// ProgressFunc receives the progress of the migrations executed under a context. Schemas executed
+// concurrently report from several goroutines at once.
+type ProgressFunc func(Progress)
+
+// WithProgress marks ctx so up executions report each migration they execute, and each statement
+// of it, to report
+func WithProgress(ctx context.Context, report ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey, report)
+}
+
+// progressOf returns the progress receiver of ctx, or nil
+func progressOf(ctx context.Context) ProgressFunc {
+	report, _ := ctx.Value(progressKey).(ProgressFunc)
+	return report
+}
+
+// reportExecution reports the start of an up migration tracked as migrationID in schema, to the
+// progress receiver of ctx and as a MigrationStarted event, and returns the context to execute it
+// under, which reports its completed statements the same way, and a function reporting its outcome
+// to the progress receiver and returning how long it ran (the caller publishes the outcome with
+// publishExecution)
+func (e *Executor) reportExecution(ctx context.Context, migration *backends.MigrationScript, migrationID, schema string) (context.Context, func(error) time.Duration) {
+	report := progressOf(ctx)
+	if report == nil {
+		report = func(Progress) {}
+	}
+
+	start := time.Now()
+	report(Progress{MigrationID: migrationID, Schema: schema, Status: ProgressRunning})
+	e.events.Publish(ctx, events.Event{Type: events.MigrationStarted, MigrationID: migrationID, Connection: migration.Connection, Schema: schema})
+	executionCtx := backends.WithStatementObserver(ctx, func(p backends.StatementProgress) {
+		report(Progress{
+			MigrationID:  migrationID,
+			Schema:       schema,
+			Status:       ProgressStatement,
+			Statement:    p.Statement,
+			Statements:   p.Statements,
+			Done:         p.Done,
+			RowsAffected: p.RowsAffected,
+			Elapsed:      p.Elapsed,
+		})
+		if p.Done {
+			e.events.Publish(ctx, events.Event{
+				Type:        events.StatementCompleted,
+				MigrationID: migrationID,
+				Connection:  migration.Connection,
+				Schema:      schema,
+				Data: map[string]interface{}{
+					"statement": p.Statement, "statements": p.Statements, "rows_affected": p.RowsAffected, "elapsed_ms": p.Elapsed.Milliseconds(),
+				},
+			})
+		}
+	})
+	return executionCtx, func(err error) time.Duration {
+		progress := Progress{MigrationID: migrationID, Schema: schema, Status: ProgressSuccess, Elapsed: time.Since(start)}
+		if err != nil {
+			progress.Status, progress.Message = ProgressFailed, err.Error()
+		}
+		report(progress)
+		return progress.Elapsed
+	}
+}
+
+// publishExecution publishes the outcome of executing migration as migrationID in schema for
+// operation (state.OperationUp, OperationDown or OperationRollback): MigrationFailed when err is
+// set, otherwise MigrationApplied for an up migration and RollbackCompleted for a revert. data
+// holds additional details for the event.
+func (e *Executor) publishExecution(ctx context.Context, operation string, migration *backends.MigrationScript, migrationID, schema string, elapsed time.Duration, err error, data map[string]interface{}) {
+	event := events.Event{
+		Type:        events.MigrationApplied,
+		MigrationID: migrationID,
+		Connection:  migration.Connection,
+		Schema:      schema,
+		Data:        map[string]interface{}{"operation": operation, "backend": migration.Backend, "elapsed_ms": elapsed.Milliseconds()},
+	}
+	for k, v := range data {
+		event.Data[k] = v
+	}
+	switch {
+	case err != nil:
+		event.Type = events.MigrationFailed
+		event.Data["error"] = err.Error()
+	case operation != state.OperationUp:
+		event.Type = events.RollbackCompleted
+	}
+	e.events.Publish(ctx, event)
+}
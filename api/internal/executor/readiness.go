@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/queue"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrStarting is returned for requests received before the initial migration scan has finished
+var ErrStarting = errors.New("server is starting; migrations are still being loaded")
+
+// ReadinessCheckTimeout bounds each check of Readiness that reaches another service
+const ReadinessCheckTimeout = 3 * time.Second
+
+// Readiness check names
+const (
+	ReadinessLoader = "loader" // The initial migration scan has finished
+	ReadinessState  = "state"  // The state database is reachable
+	ReadinessQueue  = "queue"  // The queue broker is reachable; only checked when a queue is set
+)
+
+// ReadinessCheck is the outcome of one readiness check
+type ReadinessCheck struct {
+	Name string
+	Err  error // nil if the check passed
+}
+
+// SetStarting marks the executor as starting: the server sets it until the loader has finished
+// its initial scan of the migration directories, and refuses API requests meanwhile
+func (e *Executor) SetStarting(starting bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.starting = starting
+}
+
+// IsStarting reports whether the executor is starting
+func (e *Executor) IsStarting() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.starting
+}
+
+// Readiness checks whether the server can serve requests: the initial migration scan has finished,
+// the state database is reachable and, when a queue is set, so is its broker. Unlike HealthCheck
+// it does not create missing state tables, so it is cheap enough for frequent probes.
+func (e *Executor) Readiness(ctx context.Context) []ReadinessCheck {
+	checks := []ReadinessCheck{{Name: ReadinessLoader}}
+	if e.IsStarting() {
+		checks[0].Err = ErrStarting
+	}
+
+	checks = append(checks, ReadinessCheck{Name: ReadinessState, Err: e.pingState(ctx)})
+
+	e.mu.Lock()
+	q := e.queue
+	e.mu.Unlock()
+	if q != nil {
+		checks = append(checks, ReadinessCheck{Name: ReadinessQueue, Err: pingQueue(ctx, q)})
+	}
+	return checks
+}
+
+// pingState checks the state database is reachable, with Initialize for trackers that cannot ping
+func (e *Executor) pingState(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessCheckTimeout)
+	defer cancel()
+
+	var err error
+	if pinger, ok := e.stateTracker.(state.Pinger); ok {
+		err = pinger.Ping(ctx)
+	} else {
+		err = e.stateTracker.Initialize(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("state database unreachable: %w", err)
+	}
+	return nil
+}
+
+// pingQueue checks the broker of q is reachable. Queues that cannot ping are assumed reachable:
+// publishing reports their errors.
+func pingQueue(ctx context.Context, q queue.Queue) error {
+	pinger, ok := q.(queue.Pinger)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, ReadinessCheckTimeout)
+	defer cancel()
+	if err := pinger.Ping(ctx); err != nil {
+		return fmt.Errorf("queue unreachable: %w", err)
+	}
+	return nil
+}
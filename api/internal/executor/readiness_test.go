@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/queue/memory"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_Readiness(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	ctx := context.Background()
+
+	failed := func(checks []ReadinessCheck) map[string]error {
+		errs := make(map[string]error)
+		for _, check := range checks {
+			if check.Err != nil {
+				errs[check.Name] = check.Err
+			}
+		}
+		return errs
+	}
+
+	// Without a queue, only the loader and the state database are checked
+	if checks := exec.Readiness(ctx); len(checks) != 2 || len(failed(checks)) != 0 {
+		t.Fatalf("expected 2 passing checks, got %+v", checks)
+	}
+
+	exec.SetStarting(true)
+	if errs := failed(exec.Readiness(ctx)); !errors.Is(errs[ReadinessLoader], ErrStarting) || len(errs) != 1 {
+		t.Errorf("expected only the loader check to fail while starting, got %v", errs)
+	}
+	exec.SetStarting(false)
+
+	tracker.Fail("Initialize", errors.New("connection refused"))
+	if errs := failed(exec.Readiness(ctx)); errs[ReadinessState] == nil || len(errs) != 1 {
+		t.Errorf("expected only the state check to fail, got %v", errs)
+	}
+	tracker.Fail("Initialize", nil)
+
+	q := memory.NewQueue(1)
+	exec.SetQueue(q)
+	if checks := exec.Readiness(ctx); len(checks) != 3 || len(failed(checks)) != 0 {
+		t.Fatalf("expected 3 passing checks with a queue, got %+v", checks)
+	}
+	_ = q.Close()
+	if errs := failed(exec.Readiness(ctx)); !errors.Is(errs[ReadinessQueue], memory.ErrQueueClosed) {
+		t.Errorf("expected the queue check to fail once closed, got %v", errs)
+	}
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// rehearse runs the up script of a migration, with its SQL hooks, in a transaction that is rolled
+// back, for deep dry runs (feature flag deep_dry_run), and reports a failing script in result.
+// Migrations whose backend or script cannot be rolled back are only listed, as in a plain dry run.
+func (e *Executor) rehearse(ctx context.Context, migration *backends.MigrationScript, hooks backends.Hooks, migrationID, schema string, result *ExecuteResult) {
+	cfg, err := e.getConnectionConfig(ctx, migration.Connection)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+		return
+	}
+	backend, ok := e.executionBackend(ctx, cfg.Backend)
+	if !ok {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: backend %s not registered", migrationID, cfg.Backend))
+		return
+	}
+	rehearser, ok := backend.(backends.Rehearser)
+	if !ok {
+		logger.Infof("Deep dry run: backend %s cannot rehearse migrations, %s is only listed", cfg.Backend, migrationID)
+		return
+	}
+
+	upSQL, _, err := renderTemplate(migration.UpSQL, migration, schema, templatePolicyFromConnection(cfg))
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to replace template variables in UpSQL: %v", migrationID, err))
+		return
+	}
+	if !migration.Declarative && !migration.IsCode() {
+		if upSQL, err = rehearsalScript(hooks, upSQL, cfg, migration, schema); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+			return
+		}
+	}
+
+	if err := backend.Connect(cfg); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to connect: %v", migrationID, err))
+		return
+	}
+	err = rehearser.RehearseMigration(ctx, &backends.MigrationScript{
+		Schema:        schema,
+		Version:       migration.Version,
+		Name:          migration.Name,
+		Connection:    migration.Connection,
+		Backend:       migration.Backend,
+		UpSQL:         upSQL,
+		Declarative:   migration.Declarative,
+		Transactional: backends.IsTransactional(upSQL),
+		UpFunc:        migration.UpFunc,
+	})
+	_ = backend.Close()
+	switch {
+	case errors.Is(err, backends.ErrRehearsalUnsupported):
+		logger.Infof("Deep dry run: %s cannot be rolled back, so it is only listed", migrationID)
+	case err != nil:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: rehearsal failed: %v", migrationID, err))
+	}
+}
@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// rehearsingBackend records the migrations it rehearses, failing with rehearseError
+type rehearsingBackend struct {
+	*mockBackend
+	rehearsed     []*backends.MigrationScript
+	rehearseError error
+}
+
+func (b *rehearsingBackend) RehearseMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	b.rehearsed = append(b.rehearsed, migration)
+	return b.rehearseError
+}
+
+func TestExecutor_DeepDryRun(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	backend := &rehearsingBackend{mockBackend: newMockBackend("postgresql")}
+	exec.RegisterBackend("postgresql", backend)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	// Plain dry runs only list the migrations, without taking the lock
+	tracker.HoldLock("test", "other-replica:1 (api, system)")
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", true, false)
+	if err != nil || !result.Success || len(backend.rehearsed) != 0 {
+		t.Fatalf("dry run = %+v, %v; rehearsed %d", result, err, len(backend.rehearsed))
+	}
+
+	// Deep dry runs rehearse on the database, so they respect the lock
+	ctx := features.WithOverrides(context.Background(), features.Set{features.DeepDryRun: true})
+	if _, err := exec.ExecuteSync(ctx, target, "test", "", true, false); !errors.Is(err, state.ErrConnectionLocked) {
+		t.Fatalf("expected ErrConnectionLocked, got %v", err)
+	}
+	_, _ = tracker.ReleaseLock(context.Background(), "test")
+
+	result, err = exec.ExecuteSync(ctx, target, "test", "", true, false)
+	if err != nil || !result.Success {
+		t.Fatalf("deep dry run = %+v, %v", result, err)
+	}
+	if len(backend.rehearsed) != 1 || backend.rehearsed[0].UpSQL != "CREATE TABLE users (id INT);" || !backend.rehearsed[0].Transactional {
+		t.Fatalf("expected the up script to be rehearsed in a transaction, got %+v", backend.rehearsed)
+	}
+	if backend.executeCalled || len(tracker.History()) != 0 {
+		t.Errorf("deep dry run applied the migration")
+	}
+
+	backend.rehearseError = errors.New(`relation "users" already exists`)
+	result, err = exec.ExecuteSync(ctx, target, "test", "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "rehearsal failed") {
+		t.Errorf("expected the failing rehearsal to be reported, got %+v", result)
+	}
+
+	// Scripts that cannot be rolled back are only listed
+	backend.rehearseError = backends.ErrRehearsalUnsupported
+	result, err = exec.ExecuteSync(ctx, target, "test", "", true, false)
+	if err != nil || !result.Success {
+		t.Errorf("expected unsupported rehearsals to be skipped, got %+v, %v", result, err)
+	}
+}
+
+func TestExecutor_DeclarativeFlag(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	backend := newMockBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "users", Connection: "test", Backend: "postgresql",
+		UpSQL: "tables:\n  - name: users\n", Declarative: true,
+	})
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	ctx := features.WithOverrides(context.Background(), features.Set{features.Declarative: false})
+	result, err := exec.ExecuteSync(ctx, target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "feature flag declarative") {
+		t.Errorf("expected desired-state migrations to be refused, got %+v", result)
+	}
+	if backend.executeCalled || len(tracker.History()) != 0 {
+		t.Errorf("disabled desired-state migration was applied")
+	}
+}
@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// isUpToDate reports whether a migration needs no execution under migrationID: it is applied and,
+// if repeatable (see backends.MigrationScript.IsRepeatable), was last applied in schema (the
+// migration's own schema when empty) with its current checksum. Repeatable migrations applied
+// before checksums were recorded per schema are applied again once.
+func (e *Executor) isUpToDate(ctx context.Context, migration *backends.MigrationScript, migrationID, schema string) (bool, error) {
+	applied, err := e.stateTracker.IsMigrationApplied(ctx, migrationID)
+	if err != nil || !applied || !migration.IsRepeatable() {
+		return applied, err
+	}
+
+	if schema == "" {
+		schema = migration.Schema
+	}
+	executions, err := e.stateTracker.GetMigrationExecutions(ctx, e.getMigrationID(migration))
+	if err != nil {
+		return false, fmt.Errorf("failed to read executions: %w", err)
+	}
+	for _, execution := range executions {
+		if execution.Schema == schema && execution.Applied && execution.Version == migration.Version {
+			return execution.Checksum == migration.Checksum(), nil
+		}
+	}
+	return false, nil
+}
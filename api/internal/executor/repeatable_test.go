@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_RepeatableMigrations(t *testing.T) {
+	reg := newMockRegistry()
+	exec := NewExecutor(reg, testsupport.NewStateTracker())
+	exec.SetDriftMode(DriftModeFail)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	backend := testsupport.NewBackend("postgresql")
+	exec.RegisterBackend("postgresql", backend)
+
+	view := &backends.MigrationScript{
+		Schema: "public", Version: "20240102120000", Name: "active_users_view", Connection: "core", Backend: "postgresql",
+		UpSQL: "-- bfm:repeatable\nCREATE OR REPLACE VIEW active_users AS SELECT id FROM users;",
+	}
+	for _, migration := range []*backends.MigrationScript{{
+		Schema: "public", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+		UpSQL: "CREATE TABLE users (id INT);",
+	}, view} {
+		_ = reg.Register(migration)
+	}
+	viewID := exec.getMigrationID(view)
+	target := &registry.MigrationTarget{Connection: "core"}
+	ctx := context.Background()
+
+	run := func() *ExecuteResult {
+		t.Helper()
+		result, err := exec.ExecuteSync(ctx, target, "core", "", false, false)
+		if err != nil || !result.Success {
+			t.Fatalf("ExecuteSync() = %+v, %v", result, err)
+		}
+		return result
+	}
+	if result := run(); len(result.Applied) != 2 {
+		t.Fatalf("expected both migrations to be applied, got %+v", result)
+	}
+	if result := run(); len(result.Applied) != 0 || len(result.Skipped) != 2 {
+		t.Fatalf("expected unchanged migrations to be skipped, got %+v", result)
+	}
+
+	// Redefining the view makes it pending again rather than drifted
+	view.UpSQL = "-- bfm:repeatable\nCREATE OR REPLACE VIEW active_users AS SELECT id FROM users WHERE id > 0;"
+	if drifted, err := exec.DetectDrift(ctx); err != nil || len(drifted) != 0 {
+		t.Fatalf("expected repeatable migrations not to drift, got %+v, %v", drifted, err)
+	}
+	pending, err := exec.PendingMigrations(ctx, "core", "")
+	if err != nil || len(pending) != 1 || pending[0].MigrationID != viewID {
+		t.Fatalf("expected the changed view to be pending, got %+v, %v", pending, err)
+	}
+	result := run()
+	if len(result.Applied) != 1 || result.Applied[0] != viewID {
+		t.Fatalf("expected only the changed view to be applied again, got %+v", result)
+	}
+	executed := backend.Executed()
+	if len(executed) != 3 || executed[2].UpSQL != view.UpSQL {
+		t.Errorf("expected the new definition to be executed, got %d executions", len(executed))
+	}
+	if result := run(); len(result.Applied) != 0 {
+		t.Errorf("expected the reapplied view to be up to date, got %+v", result)
+	}
+}
@@ -0,0 +1,205 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrReportNotFound is returned for a report that does not exist, or was dropped after
+// ReportRetention
+var ErrReportNotFound = errors.New("report not found")
+
+// ErrInvalidReport is returned when requesting a report on an unknown connection or in an unknown
+// format
+var ErrInvalidReport = errors.New("invalid report request")
+
+// ReportRetention is how long a finished report is kept for download
+const ReportRetention = time.Hour
+
+// ReportTimeout bounds the generation of a report
+const ReportTimeout = 10 * time.Minute
+
+// Report types
+const (
+	ReportTenantStatus = "tenant_status"
+)
+
+// Report formats
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatJSON = "json"
+)
+
+// Report statuses
+const (
+	ReportPending   = "pending"
+	ReportCompleted = "completed"
+	ReportFailed    = "failed"
+)
+
+// Report is a report generated in the background. Reports are kept in memory by the instance that
+// generates them.
+type Report struct {
+	ID         string
+	Type       string
+	Connection string
+	Format     string
+	Status     string
+	Error      string // Why generation failed, when Status is ReportFailed
+	CreatedAt  time.Time
+	FinishedAt time.Time // Zero while pending
+
+	TenantStatus *TenantStatusMatrix // Set once a ReportTenantStatus report has completed
+}
+
+// TenantStatusMatrix is the status of each migration of a connection in each schema it was
+// executed in
+type TenantStatusMatrix struct {
+	Migrations []*state.MigrationListItem // Columns, by version
+	Schemas    []string                   // Rows, sorted
+
+	statuses map[string]map[string]string // Schema, then migration ID
+}
+
+// Status returns the execution status of a migration in a schema, or "" if it never ran there
+func (m *TenantStatusMatrix) Status(schema, migrationID string) string {
+	return m.statuses[schema][migrationID]
+}
+
+// Counts returns the number of migrations applied, failed, and neither (pending, rolled back or
+// never run) in a schema
+func (m *TenantStatusMatrix) Counts(schema string) (applied, failed, pending int) {
+	for _, migration := range m.Migrations {
+		switch status := m.Status(schema, migration.MigrationID); {
+		case state.HistoryStatusIndicatesApplied(status):
+			applied++
+		case status == "failed":
+			failed++
+		default:
+			pending++
+		}
+	}
+	return applied, failed, pending
+}
+
+// StartTenantStatusReport starts generating the tenant status report of a connection: the status
+// of each of its migrations in each schema, from the executions in the state database read at once.
+// schemas lists tenants to include even if nothing ran in them yet. The report is returned pending;
+// GetReport returns it once completed, in format (ReportFormatCSV or ReportFormatJSON).
+func (e *Executor) StartTenantStatusReport(ctx context.Context, connectionName string, schemas []string, format string) (*Report, error) {
+	if format == "" {
+		format = ReportFormatCSV
+	}
+	if format != ReportFormatCSV && format != ReportFormatJSON {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidReport, format)
+	}
+	if _, err := e.getConnectionConfig(ctx, connectionName); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &Report{
+		ID:         fmt.Sprintf("report_%d", now.UnixNano()),
+		Type:       ReportTenantStatus,
+		Connection: connectionName,
+		Format:     format,
+		Status:     ReportPending,
+		CreatedAt:  now,
+	}
+	e.reportsMu.Lock()
+	e.pruneReports(now)
+	if e.reports == nil {
+		e.reports = make(map[string]*Report)
+	}
+	e.reports[report.ID] = report
+	pending := *report
+	e.reportsMu.Unlock()
+
+	go func() {
+		// The report outlives the request that started it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ReportTimeout)
+		defer cancel()
+		matrix, err := e.tenantStatusMatrix(ctx, connectionName, schemas)
+
+		e.reportsMu.Lock()
+		defer e.reportsMu.Unlock()
+		report.FinishedAt = time.Now()
+		if err != nil {
+			logger.Errorf("Failed to generate tenant status report %s on %s: %v", report.ID, connectionName, err)
+			report.Status, report.Error = ReportFailed, err.Error()
+			return
+		}
+		report.Status, report.TenantStatus = ReportCompleted, matrix
+		logger.Infof("Generated tenant status report %s on %s: %d schema(s), %d migration(s) in %s",
+			report.ID, connectionName, len(matrix.Schemas), len(matrix.Migrations), report.FinishedAt.Sub(report.CreatedAt).Round(time.Millisecond))
+	}()
+	return &pending, nil
+}
+
+// GetReport returns a report as generated so far, or ErrReportNotFound
+func (e *Executor) GetReport(id string) (*Report, error) {
+	e.reportsMu.Lock()
+	defer e.reportsMu.Unlock()
+	e.pruneReports(time.Now())
+	report, ok := e.reports[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrReportNotFound, id)
+	}
+	copied := *report
+	return &copied, nil
+}
+
+// pruneReports drops the reports finished more than ReportRetention before now. e.reportsMu must be
+// held.
+func (e *Executor) pruneReports(now time.Time) {
+	for id, report := range e.reports {
+		if !report.FinishedAt.IsZero() && now.Sub(report.FinishedAt) > ReportRetention {
+			delete(e.reports, id)
+		}
+	}
+}
+
+// tenantStatusMatrix reads the status of each migration of a connection in each schema. Executions
+// without a schema are not tenants and are left out.
+func (e *Executor) tenantStatusMatrix(ctx context.Context, connectionName string, schemas []string) (*TenantStatusMatrix, error) {
+	migrations, _, err := e.stateTracker.GetMigrationList(ctx, &state.MigrationFilters{
+		Connection: connectionName,
+		SortBy:     state.SortByVersion,
+		SortOrder:  state.SortAsc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	executions, err := state.GetConnectionExecutions(ctx, e.stateTracker, connectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read executions: %w", err)
+	}
+
+	matrix := &TenantStatusMatrix{Migrations: migrations, statuses: make(map[string]map[string]string)}
+	for _, schema := range schemas {
+		matrix.statuses[schema] = make(map[string]string)
+	}
+	for _, execution := range executions {
+		if execution.Schema == "" {
+			continue
+		}
+		if matrix.statuses[execution.Schema] == nil {
+			matrix.statuses[execution.Schema] = make(map[string]string)
+		}
+		matrix.statuses[execution.Schema][execution.MigrationID] = execution.Status
+	}
+	for schema := range matrix.statuses {
+		matrix.Schemas = append(matrix.Schemas, schema)
+	}
+	slices.Sort(matrix.Schemas)
+	return matrix, nil
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_TenantStatusReport(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	ctx := context.Background()
+
+	const users, orders = "20240101120000_create_users_postgresql_core", "20240102120000_create_orders_postgresql_core"
+	for _, execution := range []struct{ migrationID, version, schema, status string }{
+		{orders, "20240102120000", "tenant_a", "success"},
+		{users, "20240101120000", "tenant_a", "success"},
+		{users, "20240101120000", "tenant_b", "success"},
+		{orders, "20240102120000", "tenant_b", "failed"},
+		{users, "20240101120000", "", "success"},
+	} {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: execution.migrationID, Version: execution.version, Schema: execution.schema,
+			Connection: "core", Backend: "postgresql", Status: execution.status,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	if _, err := exec.StartTenantStatusReport(ctx, "core", nil, "xlsx"); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("StartTenantStatusReport(xlsx) error = %v, want ErrInvalidReport", err)
+	}
+	if _, err := exec.StartTenantStatusReport(ctx, "unknown", nil, ""); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("StartTenantStatusReport(unknown connection) error = %v, want ErrInvalidReport", err)
+	}
+	if _, err := exec.StartTenantStatusReport(ctx, "core", []string{"pg_catalog"}, ""); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("StartTenantStatusReport(pg_catalog) error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.GetReport("report_0"); !errors.Is(err, ErrReportNotFound) {
+		t.Errorf("GetReport(unknown) error = %v, want ErrReportNotFound", err)
+	}
+
+	started, err := exec.StartTenantStatusReport(ctx, "core", []string{"tenant_c"}, "")
+	if err != nil {
+		t.Fatalf("StartTenantStatusReport() error = %v", err)
+	}
+	if started.Format != ReportFormatCSV || started.Type != ReportTenantStatus {
+		t.Errorf("unexpected report %+v", started)
+	}
+
+	var report *Report
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if report, err = exec.GetReport(started.ID); err != nil {
+			t.Fatalf("GetReport() error = %v", err)
+		}
+		if report.Status != ReportPending || time.Now().After(deadline) {
+			break
+		}
+	}
+	if report.Status != ReportCompleted {
+		t.Fatalf("expected the report to complete, got %+v", report)
+	}
+
+	matrix := report.TenantStatus
+	if len(matrix.Migrations) != 2 || matrix.Migrations[0].MigrationID != users {
+		t.Fatalf("expected the 2 migrations by version, got %+v", matrix.Migrations)
+	}
+	if want := []string{"tenant_a", "tenant_b", "tenant_c"}; len(matrix.Schemas) != 3 || matrix.Schemas[0] != want[0] || matrix.Schemas[2] != want[2] {
+		t.Fatalf("Schemas = %v, want %v", matrix.Schemas, want)
+	}
+	if status := matrix.Status("tenant_b", orders); status != "failed" {
+		t.Errorf("Status(tenant_b, orders) = %q, want failed", status)
+	}
+	for schema, want := range map[string][3]int{"tenant_a": {2, 0, 0}, "tenant_b": {1, 1, 0}, "tenant_c": {0, 0, 2}} {
+		if applied, failed, pending := matrix.Counts(schema); [3]int{applied, failed, pending} != want {
+			t.Errorf("Counts(%s) = %d, %d, %d, want %v", schema, applied, failed, pending, want)
+		}
+	}
+}
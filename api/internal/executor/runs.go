@@ -0,0 +1,317 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrRunNotFound is returned by CancelRun for runs that are not in flight in this process, and by
+// GetRecordedRun for runs without history records
+var ErrRunNotFound = errors.New("run not found")
+
+// ErrRunExists is returned by StartRun when a run with the same ID is already in flight
+var ErrRunExists = errors.New("run already in flight")
+
+// ErrRunCanceled is the cause of the failures of a run canceled with CancelRun
+var ErrRunCanceled = errors.New("run canceled")
+
+// ErrMigrationTimeout is the cause of the failure of a migration that ran longer than its timeout
+var ErrMigrationTimeout = errors.New("migration timed out")
+
+// Run is a synchronous execution (up, down or rollback) in flight in this process, which
+// CancelRun aborts
+type Run struct {
+	ID         string
+	Operation  string // "up", "down" or "rollback"
+	StartedBy  string
+	StartedAt  time.Time
+	CanceledBy string // Set once the run is canceled
+
+	cancel context.CancelCauseFunc
+}
+
+// runKey is the context key of the *Run an execution belongs to
+type runKey struct{}
+
+// RunFromContext returns the run ctx belongs to (see StartRun)
+func RunFromContext(ctx context.Context) (*Run, bool) {
+	run, ok := ctx.Value(runKey{}).(*Run)
+	return run, ok
+}
+
+// runIDKey is the context key of the run ID withRunID assigns to an execution outside a Run
+type runIDKey struct{}
+
+// RunIDFromContext returns the ID of the run ctx executes in: its Run (see StartRun), or the ID
+// assigned when the execution started. Every history record of the run is stamped with it as the
+// run_id of its execution context.
+func RunIDFromContext(ctx context.Context) string {
+	if run, ok := RunFromContext(ctx); ok {
+		return run.ID
+	}
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// withRunID assigns a run ID to an execution that has none, so that every history record it
+// creates, including those of the executions it starts, carries the same run_id
+func withRunID(ctx context.Context) context.Context {
+	if RunIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, runIDKey{}, newRunID())
+}
+
+// newRunID generates a run ID
+func newRunID() string {
+	return fmt.Sprintf("run_%d", time.Now().UnixNano())
+}
+
+// GetRecordedRuns lists the runs recorded in history, most recent first, and the number of runs
+// matching filters
+func (e *Executor) GetRecordedRuns(ctx context.Context, filters *state.RunFilters) ([]*state.RunSummary, int, error) {
+	return state.GetRuns(ctx, e.stateTracker, filters)
+}
+
+// GetRecordedRun returns the summary of a run recorded in history and its records, oldest first.
+// Returns ErrRunNotFound when no record of the run exists.
+func (e *Executor) GetRecordedRun(ctx context.Context, id string) (*state.RunSummary, []*state.MigrationRecord, error) {
+	records, _, err := e.stateTracker.GetMigrationHistory(ctx, &state.MigrationFilters{
+		RunID:     id,
+		SortBy:    state.SortByAppliedAt,
+		SortOrder: state.SortAsc,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	return state.SummarizeRun(id, records), records, nil
+}
+
+// SetMigrationTimeout sets how long a migration may run before it is canceled and recorded as
+// failed, for migrations without a Timeout of their own; 0 (the default) sets no limit
+func (e *Executor) SetMigrationTimeout(timeout time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.migrationTimeout = timeout
+}
+
+// timeoutOf returns how long migration may run, 0 for no limit
+func (e *Executor) timeoutOf(migration *backends.MigrationScript) time.Duration {
+	if migration.Timeout > 0 {
+		return migration.Timeout
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.migrationTimeout
+}
+
+// StartRun registers a run of operation started by startedBy, with id or a generated ID when id
+// is empty, and returns a context that CancelRun cancels. The caller executes under that context,
+// then calls FinishRun.
+func (e *Executor) StartRun(ctx context.Context, id, operation, startedBy string) (context.Context, *Run, error) {
+	if id == "" {
+		id = newRunID()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	run := &Run{ID: id, Operation: operation, StartedBy: startedBy, StartedAt: time.Now(), cancel: cancel}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.runs[id] != nil {
+		cancel(nil)
+		return nil, nil, fmt.Errorf("%w: %s", ErrRunExists, id)
+	}
+	if e.runs == nil {
+		e.runs = make(map[string]*Run)
+	}
+	e.runs[id] = run
+	return context.WithValue(ctx, runKey{}, run), run, nil
+}
+
+// FinishRun unregisters a run once its execution returned
+func (e *Executor) FinishRun(run *Run) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.runs[run.ID] == run {
+		delete(e.runs, run.ID)
+	}
+	run.cancel(nil)
+}
+
+// Runs returns the runs in flight in this process, oldest first
+func (e *Executor) Runs() []Run {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	runs := make([]Run, 0, len(e.runs))
+	for _, run := range e.runs {
+		runs = append(runs, *run)
+	}
+	slices.SortFunc(runs, func(a, b Run) int { return a.StartedAt.Compare(b.StartedAt) })
+	return runs
+}
+
+// CancelRun cancels a run in flight in this process: the statement running is interrupted, the
+// migration is recorded as failed and the migrations not started yet are not run. Returns
+// ErrRunNotFound for runs that finished or run in another process.
+func (e *Executor) CancelRun(id, canceledBy string) (Run, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	run := e.runs[id]
+	if run == nil {
+		return Run{}, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	if run.CanceledBy == "" {
+		run.CanceledBy = canceledBy
+	}
+	run.cancel(fmt.Errorf("%w by %s", ErrRunCanceled, run.CanceledBy))
+	return *run, nil
+}
+
+// stopped returns why ctx was canceled, or nil while the execution may go on
+func stopped(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// RunRollback is the outcome of rolling back a recorded run (see RollbackRun)
+type RunRollback struct {
+	RunID   string
+	Success bool
+	Steps   []CascadedRollback // One per migration the run applied, in the order they were rolled back
+}
+
+// runMigration is a migration a run applied in schema
+type runMigration struct {
+	migration *backends.MigrationScript
+	schema    string
+	id        string // Schema-qualified ID
+}
+
+// RollbackRun rolls back, with down migrations, the migrations a recorded run applied: the most
+// recent first, and always before the migrations of the run they depend on. Migrations already
+// rolled back since are skipped. A dry run only previews the down migrations, and reports those
+// that migrations applied outside the run still depend on; otherwise it stops at the first
+// migration that fails. Returns ErrRunNotFound when no record of the run exists.
+func (e *Executor) RollbackRun(ctx context.Context, id string, dryRun bool) (*RunRollback, error) {
+	_, records, err := e.GetRecordedRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := e.runMigrations(records)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run's migrations are rolled back in order, so their dependents in the run are gone by
+	// the time they run
+	ctx = context.WithValue(ctx, cascadeKey, false)
+	inRun := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		inRun[m.id] = true
+	}
+
+	rollback := &RunRollback{RunID: id, Success: true}
+	for _, m := range migrations {
+		var schemas []string
+		if m.schema != "" {
+			schemas = []string{m.schema}
+		}
+		step := CascadedRollback{MigrationID: m.id}
+		if dryRun {
+			// Dependents in the run are only previewed too, so only the others block
+			dependents, err := e.appliedDependents(ctx, m.migration, m.schema)
+			if err != nil {
+				return nil, err
+			}
+			var blocking []string
+			for _, dependent := range dependents {
+				if !inRun[dependent.id] {
+					blocking = append(blocking, dependent.id)
+				}
+			}
+			if len(blocking) > 0 {
+				step.Errors = []string{fmt.Sprintf("%v: cannot roll back %s while %s remain applied", ErrDependentsApplied, m.id, strings.Join(blocking, ", "))}
+				rollback.Steps = append(rollback.Steps, step)
+				rollback.Success = false
+				continue
+			}
+		}
+		result, err := e.ExecuteDown(ctx, e.getMigrationID(m.migration), schemas, dryRun, dryRun)
+		if err != nil {
+			step.Errors = []string{err.Error()}
+		} else {
+			step.Success, step.Applied, step.Skipped, step.Errors = result.Success, result.Applied, result.Skipped, result.Errors
+		}
+		rollback.Steps = append(rollback.Steps, step)
+		if len(step.Errors) > 0 {
+			rollback.Success = false
+			if !dryRun {
+				break
+			}
+		}
+	}
+	return rollback, nil
+}
+
+// runMigrations returns the migrations that the up records of a run applied, in the order to roll
+// them back in: most recent first, each before the migrations of the run it depends on
+func (e *Executor) runMigrations(records []*state.MigrationRecord) ([]runMigration, error) {
+	var applied []runMigration
+	seen := make(map[string]bool)
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.HistoryOperation() != state.OperationUp || record.HistoryStatus() != "applied" {
+			continue
+		}
+		migration := e.GetMigrationByID(record.MigrationID)
+		if migration == nil {
+			return nil, fmt.Errorf("migration not found: %s", record.MigrationID)
+		}
+		id := e.getMigrationIDWithSchema(migration, record.Schema)
+		if !seen[id] {
+			seen[id] = true
+			applied = append(applied, runMigration{migration: migration, schema: record.Schema, id: id})
+		}
+	}
+
+	// Records applied in the same second are not ordered: emit each migration once the
+	// migrations of the run depending on it were
+	var ordered []runMigration
+	for len(applied) > 0 {
+		next := 0
+		for i, m := range applied {
+			if !e.dependsOnAny(applied, m) {
+				next = i
+				break
+			}
+		}
+		ordered = append(ordered, applied[next])
+		applied = slices.Delete(applied, next, next+1)
+	}
+	return ordered, nil
+}
+
+// dependsOnAny reports whether a migration of others, other than m, depends on m
+func (e *Executor) dependsOnAny(others []runMigration, m runMigration) bool {
+	for _, dependent := range e.dependentsInRollbackOrder(m.migration) {
+		dependentID := e.getMigrationID(dependent)
+		for _, other := range others {
+			if other.id != m.id && e.getMigrationID(other.migration) == dependentID {
+				return true
+			}
+		}
+	}
+	return false
+}
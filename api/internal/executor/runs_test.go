@@ -0,0 +1,260 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// blockingBackend runs each migration until its context is done
+type blockingBackend struct {
+	*mockBackend
+	started chan struct{}
+}
+
+func (b *blockingBackend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newBlockingExecutor(t *testing.T) (*Executor, *testsupport.StateTracker, *blockingBackend) {
+	t.Helper()
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	backend := &blockingBackend{mockBackend: newMockBackend("postgresql"), started: make(chan struct{}, 2)}
+	exec.RegisterBackend("postgresql", backend)
+	for _, version := range []string{"20240101120000", "20240102120000"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: version, Name: "slow_" + version, Connection: "test", Backend: "postgresql",
+			UpSQL: "SELECT pg_sleep(3600);",
+		})
+	}
+	return exec, tracker, backend
+}
+
+func TestExecutor_MigrationTimeout(t *testing.T) {
+	exec, tracker, _ := newBlockingExecutor(t)
+	exec.SetMigrationTimeout(10 * time.Millisecond)
+
+	target := &registry.MigrationTarget{Connection: "test"}
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Applied) != 0 || len(result.Errors) != 2 {
+		t.Fatalf("expected both migrations to time out, got %+v", result)
+	}
+	if !strings.Contains(result.Errors[0], ErrMigrationTimeout.Error()) {
+		t.Errorf("expected ErrMigrationTimeout, got %v", result.Errors)
+	}
+	record := lastRecord(tracker)
+	if record.Status != "failed" || !strings.Contains(record.ErrorMessage, ErrMigrationTimeout.Error()) {
+		t.Errorf("expected the timeout to be recorded as a failure, got %+v", record)
+	}
+
+	// A migration's own timeout overrides the default
+	migration := &backends.MigrationScript{Timeout: time.Hour}
+	if timeout := exec.timeoutOf(migration); timeout != time.Hour {
+		t.Errorf("timeoutOf() = %s, want 1h", timeout)
+	}
+}
+
+func TestExecutor_CancelRun(t *testing.T) {
+	exec, tracker, backend := newBlockingExecutor(t)
+
+	ctx, run, err := exec.StartRun(context.Background(), "run-1", "up", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := exec.StartRun(context.Background(), "run-1", "up", "bob"); !errors.Is(err, ErrRunExists) {
+		t.Errorf("expected ErrRunExists for a run ID in flight, got %v", err)
+	}
+	if found, ok := RunFromContext(ctx); !ok || found != run {
+		t.Errorf("expected the run in its context")
+	}
+
+	done := make(chan *ExecuteResult)
+	go func() {
+		defer exec.FinishRun(run)
+		result, _ := exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: "test"}, "test", "", false, false)
+		done <- result
+	}()
+	<-backend.started
+	if runs := exec.Runs(); len(runs) != 1 || runs[0].ID != "run-1" || runs[0].StartedBy != "alice" {
+		t.Errorf("expected the run to be in flight, got %+v", runs)
+	}
+	canceled, err := exec.CancelRun("run-1", "bob")
+	if err != nil || canceled.CanceledBy != "bob" {
+		t.Fatalf("CancelRun() = %+v, %v", canceled, err)
+	}
+
+	result := <-done
+	if result.Success || len(result.Applied) != 0 || len(result.Errors) != 2 {
+		t.Fatalf("expected the run to stop, got %+v", result)
+	}
+	if !strings.Contains(result.Errors[1], "not run") || !strings.Contains(result.Errors[1], "run canceled by bob") {
+		t.Errorf("expected the next migration not to run, got %v", result.Errors)
+	}
+	if len(backend.started) != 0 {
+		t.Errorf("expected only the first migration to start")
+	}
+	if record := lastRecord(tracker); record.Status != "failed" {
+		t.Errorf("expected the interrupted migration to be recorded as failed, got %+v", record)
+	}
+	if _, err := exec.CancelRun("run-1", "bob"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expected ErrRunNotFound once the run finished, got %v", err)
+	}
+}
+
+func TestExecutor_StampsRunID(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	for _, version := range []string{"20240101120000", "20240102120000"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: version, Name: "m_" + version, Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;", DownSQL: "SELECT 2;",
+		})
+	}
+
+	target := &registry.MigrationTarget{Connection: "test"}
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	runID := tracker.History()[0].RunID()
+	if runID == "" {
+		t.Fatalf("expected the records to be stamped with a run_id, got %q", tracker.History()[0].ExecutionContext)
+	}
+	for _, record := range tracker.History() {
+		if record.RunID() != runID {
+			t.Fatalf("expected every record to share %s, got %q", runID, record.ExecutionContext)
+		}
+	}
+	recorded := len(tracker.History())
+
+	// A Run's ID is the run_id of its records
+	tracker.MarkApplied("public_20240102120000_m_20240102120000_postgresql_test")
+	ctx, run, err := exec.StartRun(context.Background(), "release-2025-06", "down", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exec.FinishRun(run)
+	if result, err := exec.ExecuteDown(ctx, "20240102120000_m_20240102120000_postgresql_test", nil, false, true); err != nil || len(result.Applied) != 1 {
+		t.Fatalf("ExecuteDown() = %+v, %v", result, err)
+	}
+	if last := lastRecord(tracker); last.RunID() != "release-2025-06" {
+		t.Errorf("expected the down migration to be stamped with the run ID, got %q", last.ExecutionContext)
+	}
+
+	summary, records, err := exec.GetRecordedRun(context.Background(), runID)
+	if err != nil || summary.Records != recorded || len(records) != recorded {
+		t.Errorf("expected the %d records of %s, got %+v, %v", recorded, runID, summary, err)
+	}
+}
+
+func TestParseBFMTimeoutFromUpSQL(t *testing.T) {
+	tests := []struct {
+		upSQL   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{upSQL: "CREATE TABLE users (id INT);"},
+		{upSQL: "-- bfm-timeout: 30m\nCREATE INDEX CONCURRENTLY users_id ON users (id);", want: 30 * time.Minute},
+		{upSQL: "-- bfm-timeout: soon\nSELECT 1;", wantErr: true},
+		{upSQL: "-- bfm-timeout: -1s\nSELECT 1;", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBFMTimeoutFromUpSQL(tt.upSQL)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseBFMTimeoutFromUpSQL(%q) = %s, %v", tt.upSQL, got, err)
+		}
+	}
+}
+
+func TestExecutor_RollbackRun(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+
+	// users <- orders, and users <- profiles
+	for _, m := range []*backends.MigrationScript{
+		{Version: "20240101120000", Name: "users"},
+		{Version: "20240102120000", Name: "orders", Dependencies: []string{"users"}},
+		{Version: "20240103120000", Name: "profiles", Dependencies: []string{"users"}},
+	} {
+		m.Schema, m.Connection, m.Backend = "public", "test", "postgresql"
+		m.UpSQL, m.DownSQL = "SELECT 1;", "SELECT 1;"
+		_ = reg.Register(m)
+	}
+	id := func(name string) string {
+		return exec.getMigrationID(reg.GetMigrationByName(name)[0])
+	}
+	schemaID := func(name string) string {
+		return exec.getMigrationIDWithSchema(reg.GetMigrationByName(name)[0], "public")
+	}
+
+	// The run applied users and orders in the same second; profiles was applied by another run
+	appliedAt := time.Now().Format(time.RFC3339)
+	for _, name := range []string{"users", "orders"} {
+		_ = tracker.RecordMigration(context.Background(), &state.MigrationRecord{
+			MigrationID: schemaID(name), Schema: "public", Connection: "test", Backend: "postgresql", Status: "success",
+			AppliedAt: appliedAt, ExecutionContext: `{"run_id":"release-2025-06"}`,
+		})
+	}
+	tracker.MarkApplied(schemaID("profiles"))
+
+	if _, err := exec.RollbackRun(context.Background(), "run_0", false); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("expected ErrRunNotFound, got %v", err)
+	}
+
+	preview, err := exec.RollbackRun(context.Background(), "release-2025-06", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Success || len(preview.Steps) != 2 || preview.Steps[0].MigrationID != schemaID("orders") || !preview.Steps[0].Success {
+		t.Fatalf("expected orders to be previewed first, got %+v", preview)
+	}
+	if errs := preview.Steps[1].Errors; len(errs) != 1 || !strings.Contains(errs[0], id("profiles")) {
+		t.Errorf("expected users to be blocked by profiles, got %v", errs)
+	}
+	if !isApplied(tracker, schemaID("orders")) {
+		t.Fatalf("expected a dry run to leave the migrations applied")
+	}
+
+	_ = tracker.DeleteMigration(context.Background(), id("profiles"))
+	rollback, err := exec.RollbackRun(context.Background(), "release-2025-06", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rollback.Success || len(rollback.Steps) != 2 || rollback.Steps[0].MigrationID != schemaID("orders") || rollback.Steps[1].MigrationID != schemaID("users") {
+		t.Fatalf("expected orders then users to be rolled back, got %+v", rollback)
+	}
+	if isApplied(tracker, schemaID("orders")) || isApplied(tracker, schemaID("users")) {
+		t.Errorf("expected the run's migrations to be rolled back")
+	}
+
+	// Rolling back again skips the migrations already rolled back
+	again, err := exec.RollbackRun(context.Background(), "release-2025-06", false)
+	if err != nil || !again.Success || len(again.Steps) != 2 || len(again.Steps[0].Applied) != 0 || len(again.Steps[0].Skipped) != 1 {
+		t.Errorf("expected every migration to be skipped, got %+v, %v", again, err)
+	}
+}
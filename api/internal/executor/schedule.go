@@ -0,0 +1,321 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/features"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrInvalidSchedule is returned when scheduling a run for a time that has passed, or on an
+// unknown connection
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrJobNotCancellable is returned when cancelling a job that is not waiting for its scheduled time
+var ErrJobNotCancellable = errors.New("only scheduled jobs can be cancelled")
+
+// MaintenanceWindow is the daily time range, in UTC, in which scheduled runs may start. A run due
+// outside the window waits until it opens. The zero window is always open.
+type MaintenanceWindow struct {
+	Start time.Duration // Since midnight UTC
+	End   time.Duration // Since midnight UTC; before Start for a window spanning midnight
+}
+
+// AlwaysOpen reports whether the window places no limit on when runs start
+func (w MaintenanceWindow) AlwaysOpen() bool {
+	return w.Start == w.End
+}
+
+// Contains reports whether runs may start at t
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	if w.AlwaysOpen() {
+		return true
+	}
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Next returns the earliest time at or after t at which runs may start
+func (w MaintenanceWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Add(w.Start)
+	if start.Before(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+// String formats the window as HH:MM-HH:MM, or "always" for the zero window
+func (w MaintenanceWindow) String() string {
+	if w.AlwaysOpen() {
+		return "always"
+	}
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+// SetMaintenanceWindow sets the window scheduled runs start in
+func (e *Executor) SetMaintenanceWindow(window MaintenanceWindow) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maintenanceWindow = window
+}
+
+// MaintenanceWindow returns the window scheduled runs start in
+func (e *Executor) MaintenanceWindow() MaintenanceWindow {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.maintenanceWindow
+}
+
+// ScheduleUp stores an up run of target on connectionName, to start at runAt or, outside the
+// maintenance window, once the window opens. It records one job per schema with status
+// state.JobScheduled; when due, the scheduler queues each job, or runs it in the server without a
+// queue. Like queued jobs, scheduled runs honor pinned checksums but not ignore_dependencies.
+func (e *Executor) ScheduleUp(ctx context.Context, target *registry.MigrationTarget, connectionName string, schemas []string, dryRun bool, runAt time.Time) ([]*state.Job, error) {
+	if err := e.validateSchemas(schemas...); err != nil {
+		return nil, err
+	}
+	if e.IsStandby() {
+		return nil, ErrStandby
+	}
+	if !runAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: %s has passed", ErrInvalidSchedule, runAt.UTC().Format(time.RFC3339))
+	}
+	if _, err := e.getConnectionConfig(ctx, connectionName); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	if len(schemas) == 0 {
+		schemas = []string{""}
+	}
+	scheduledAt := runAt.UTC().Format(time.RFC3339)
+	base := time.Now().UnixNano()
+	jobs := make([]*state.Job, 0, len(schemas))
+	for i, schema := range schemas {
+		job := &queue.Job{
+			ID:               fmt.Sprintf("job_%d_%d", base, i),
+			Target:           convertTarget(target),
+			Connection:       connectionName,
+			Schema:           schema,
+			DryRun:           dryRun,
+			Metadata:         make(map[string]interface{}),
+			PinnedChecksums:  PinnedChecksums(ctx),
+			AllowDestructive: allowsDestructive(ctx),
+		}
+		features.Inject(ctx, job.Metadata)
+		payload, err := json.Marshal(job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode scheduled job: %w", err)
+		}
+		record := &state.Job{
+			ID:          job.ID,
+			Status:      state.JobScheduled,
+			Connection:  connectionName,
+			Schema:      schema,
+			DryRun:      dryRun,
+			Payload:     string(payload),
+			ScheduledAt: scheduledAt,
+		}
+		if target != nil {
+			encoded, _ := json.Marshal(target)
+			record.Target = string(encoded)
+		}
+		if err := e.stateTracker.RecordJob(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to schedule migration job: %w", err)
+		}
+		e.events.Publish(ctx, events.Event{
+			Type:       events.JobScheduled,
+			Connection: connectionName,
+			Schema:     schema,
+			Data:       map[string]interface{}{"job_id": job.ID, "dry_run": dryRun, "scheduled_at": scheduledAt},
+		})
+
+		scheduled, err := e.stateTracker.GetJob(ctx, job.ID)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, scheduled)
+	}
+	logger.Infof("Scheduled %d migration job(s) on %s for %s (maintenance window %s)", len(jobs), connectionName, scheduledAt, e.MaintenanceWindow())
+	return jobs, nil
+}
+
+// GetScheduledJobs returns the jobs waiting for their scheduled time, on connectionName unless
+// empty, soonest first
+func (e *Executor) GetScheduledJobs(ctx context.Context, connectionName string) ([]*state.Job, error) {
+	jobs, _, err := e.stateTracker.GetJobs(ctx, &state.JobFilters{Status: state.JobScheduled, Connection: connectionName})
+	if err != nil {
+		return nil, err
+	}
+	// Scheduled times are all RFC3339 in UTC, so they sort as strings
+	slices.SortStableFunc(jobs, func(a, b *state.Job) int {
+		if c := strings.Compare(a.ScheduledAt, b.ScheduledAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return jobs, nil
+}
+
+// CancelScheduledJob cancels a job waiting for its scheduled time, so it never runs, and returns it
+// as recorded
+func (e *Executor) CancelScheduledJob(ctx context.Context, id string) (*state.Job, error) {
+	if e.IsStandby() {
+		return nil, ErrStandby
+	}
+	e.scheduleMu.Lock()
+	defer e.scheduleMu.Unlock()
+
+	record, err := e.stateTracker.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != state.JobScheduled {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotCancellable, id, record.Status)
+	}
+	record.Status = state.JobCancelled
+	if err := e.stateTracker.RecordJob(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to cancel job %s: %w", id, err)
+	}
+	logger.Infof("Cancelled scheduled migration job %s", id)
+	return e.stateTracker.GetJob(ctx, id)
+}
+
+// RunScheduler starts the scheduled jobs that are due every interval, while the maintenance window
+// is open, until ctx is done. Only the primary runs it; a standby starts it once promoted.
+func (e *Executor) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.runDueJobs(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDueJobs starts the scheduled jobs due at now, the earliest first, unless the maintenance
+// window is closed
+func (e *Executor) runDueJobs(ctx context.Context, now time.Time) {
+	if e.IsStandby() || !e.MaintenanceWindow().Contains(now) {
+		return
+	}
+	jobs, err := e.GetScheduledJobs(ctx, "")
+	if err != nil {
+		logger.Warnf("Failed to list scheduled migration jobs: %v", err)
+		return
+	}
+	for _, record := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		scheduledAt, err := time.Parse(time.RFC3339, record.ScheduledAt)
+		if err != nil {
+			logger.Warnf("Scheduled migration job %s has an invalid scheduled time %q", record.ID, record.ScheduledAt)
+			continue
+		}
+		if scheduledAt.After(now) {
+			// Jobs are sorted by scheduled time: the rest are not due either
+			return
+		}
+		e.startScheduledJob(ctx, record.ID)
+	}
+}
+
+// startScheduledJob publishes a due job to the queue, or runs it when there is no queue. A job
+// cancelled in the meantime is left alone.
+func (e *Executor) startScheduledJob(ctx context.Context, id string) {
+	e.scheduleMu.Lock()
+	record, err := e.stateTracker.GetJob(ctx, id)
+	if err != nil || record.Status != state.JobScheduled {
+		e.scheduleMu.Unlock()
+		return
+	}
+	var job queue.Job
+	if err := json.Unmarshal([]byte(record.Payload), &job); err != nil {
+		job = queue.Job{ID: record.ID, Connection: record.Connection, Schema: record.Schema, DryRun: record.DryRun}
+		e.RecordJobStatus(ctx, &job, state.JobFailed, nil, fmt.Errorf("scheduled job %s was recorded without its payload", id))
+		e.scheduleMu.Unlock()
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]interface{})
+	}
+
+	e.mu.Lock()
+	q := e.queue
+	e.mu.Unlock()
+	if q != nil {
+		defer e.scheduleMu.Unlock()
+		logger.Infof("Queuing scheduled migration job %s", id)
+		if err := e.publishJob(ctx, q, &job); err != nil {
+			logger.Errorf("Failed to queue scheduled migration job %s: %v", id, err)
+			return
+		}
+		e.events.Publish(ctx, events.Event{
+			Type:       events.JobQueued,
+			Connection: job.Connection,
+			Schema:     job.Schema,
+			Data:       map[string]interface{}{"job_id": job.ID, "dry_run": job.DryRun},
+		})
+		return
+	}
+
+	// Without a queue the server runs the job itself; once picked up it can no longer be cancelled
+	logger.Infof("Running scheduled migration job %s", id)
+	e.RecordJobStatus(ctx, &job, state.JobPickedUp, nil, nil)
+	e.scheduleMu.Unlock()
+
+	runCtx := WithPinnedChecksums(features.Extract(ctx, job.Metadata), job.PinnedChecksums)
+	if job.AllowDestructive {
+		runCtx = WithAllowDestructive(runCtx)
+	}
+	job.Attempts = 1
+	e.RecordJobStatus(ctx, &job, state.JobRunning, nil, nil)
+	result, err := e.ExecuteSync(runCtx, convertQueueTarget(job.Target), job.Connection, job.Schema, job.DryRun, false)
+	if err == nil && result.Success {
+		e.RecordJobStatus(ctx, &job, state.JobCompleted, result, nil)
+		return
+	}
+	logger.Errorf("Scheduled migration job %s failed", id)
+	e.RecordJobStatus(ctx, &job, state.JobFailed, result, err)
+}
+
+// convertQueueTarget converts queue.MigrationTarget to registry.MigrationTarget
+func convertQueueTarget(target *queue.MigrationTarget) *registry.MigrationTarget {
+	if target == nil {
+		return nil
+	}
+	return &registry.MigrationTarget{
+		Backend:    target.Backend,
+		Schema:     target.Schema,
+		Tables:     target.Tables,
+		Version:    target.Version,
+		MaxVersion: target.MaxVersion,
+		Connection: target.Connection,
+		Tags:       target.Tags,
+	}
+}
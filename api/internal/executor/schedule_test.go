@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339, "2030-01-02T"+clock+":00Z")
+		return parsed
+	}
+	overnight := MaintenanceWindow{Start: 22 * time.Hour, End: 4 * time.Hour}
+	daytime := MaintenanceWindow{Start: 9 * time.Hour, End: 17*time.Hour + 30*time.Minute}
+
+	tests := []struct {
+		window MaintenanceWindow
+		clock  string
+		open   bool
+		next   string
+	}{
+		{MaintenanceWindow{}, "12:00", true, "2030-01-02T12:00:00Z"},
+		{overnight, "23:00", true, "2030-01-02T23:00:00Z"},
+		{overnight, "03:59", true, "2030-01-02T03:59:00Z"},
+		{overnight, "04:00", false, "2030-01-02T22:00:00Z"},
+		{daytime, "08:00", false, "2030-01-02T09:00:00Z"},
+		{daytime, "17:30", false, "2030-01-03T09:00:00Z"},
+	}
+	for _, tt := range tests {
+		if open := tt.window.Contains(at(tt.clock)); open != tt.open {
+			t.Errorf("%s Contains(%s) = %v, want %v", tt.window, tt.clock, open, tt.open)
+		}
+		if next := tt.window.Next(at(tt.clock)).Format(time.RFC3339); next != tt.next {
+			t.Errorf("%s Next(%s) = %s, want %s", tt.window, tt.clock, next, tt.next)
+		}
+	}
+	if overnight.String() != "22:00-04:00" || daytime.String() != "09:00-17:30" || (MaintenanceWindow{}).String() != "always" {
+		t.Errorf("unexpected window strings %s, %s", overnight, daytime)
+	}
+}
+
+func TestExecutor_ScheduleUp(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	ctx := context.Background()
+	target := &registry.MigrationTarget{Connection: "test"}
+	runAt := time.Now().Add(time.Hour)
+
+	if _, err := exec.ScheduleUp(ctx, target, "test", nil, false, time.Now().Add(-time.Minute)); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("ScheduleUp(past) error = %v, want ErrInvalidSchedule", err)
+	}
+	if _, err := exec.ScheduleUp(ctx, target, "unknown", nil, false, runAt); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("ScheduleUp(unknown connection) error = %v, want ErrInvalidSchedule", err)
+	}
+	if _, err := exec.ScheduleUp(ctx, target, "test", []string{"pg_catalog"}, false, runAt); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ScheduleUp(pg_catalog) error = %v, want ErrInvalidSchema", err)
+	}
+
+	jobs, err := exec.ScheduleUp(ctx, target, "test", []string{"tenant_a", "tenant_b"}, false, runAt)
+	if err != nil {
+		t.Fatalf("ScheduleUp() error = %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Status != state.JobScheduled || jobs[1].Schema != "tenant_b" || jobs[0].ScheduledAt != runAt.UTC().Format(time.RFC3339) {
+		t.Fatalf("unexpected scheduled jobs %+v", jobs)
+	}
+
+	// Nothing runs before the scheduled time
+	exec.runDueJobs(ctx, time.Now())
+	if scheduled, _ := exec.GetScheduledJobs(ctx, "test"); len(scheduled) != 2 {
+		t.Fatalf("expected 2 scheduled jobs before they are due, got %d", len(scheduled))
+	}
+
+	// A cancelled job never runs, and cannot be cancelled twice
+	if _, err := exec.CancelScheduledJob(ctx, jobs[1].ID); err != nil {
+		t.Fatalf("CancelScheduledJob() error = %v", err)
+	}
+	if _, err := exec.CancelScheduledJob(ctx, jobs[1].ID); !errors.Is(err, ErrJobNotCancellable) {
+		t.Errorf("CancelScheduledJob(cancelled) error = %v, want ErrJobNotCancellable", err)
+	}
+
+	// Without a queue, the due job runs in the server
+	exec.runDueJobs(ctx, runAt.Add(time.Second))
+	if job, _ := exec.GetJob(ctx, jobs[0].ID); job.Status != state.JobCompleted || len(job.Applied) != 1 {
+		t.Errorf("expected the due job to complete, got %+v", job)
+	}
+	if job, _ := exec.GetJob(ctx, jobs[1].ID); job.Status != state.JobCancelled {
+		t.Errorf("expected the cancelled job to stay cancelled, got %+v", job)
+	}
+	for _, record := range tracker.History() {
+		if record.Schema == "tenant_b" {
+			t.Errorf("expected nothing to run for the cancelled job, got %+v", record)
+		}
+	}
+}
+
+func TestExecutor_ScheduledJobWaitsForWindow(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	q := newMockQueue()
+	exec.SetQueue(q)
+	ctx := context.Background()
+
+	runAt := time.Now().Add(time.Minute)
+	// A two-hour window opening 3 hours after the scheduled time
+	open := runAt.UTC().Add(3 * time.Hour)
+	start := time.Duration(open.Hour())*time.Hour + time.Duration(open.Minute())*time.Minute
+	exec.SetMaintenanceWindow(MaintenanceWindow{Start: start, End: (start + 2*time.Hour) % (24 * time.Hour)})
+
+	jobs, err := exec.ScheduleUp(ctx, &registry.MigrationTarget{Connection: "test"}, "test", nil, true, runAt)
+	if err != nil {
+		t.Fatalf("ScheduleUp() error = %v", err)
+	}
+
+	exec.runDueJobs(ctx, runAt.Add(time.Hour))
+	if len(q.publishedJobs) != 0 {
+		t.Fatalf("expected the job to wait for the maintenance window, got %d published", len(q.publishedJobs))
+	}
+
+	// Once the window opens, the job is queued under its ID for a worker to run
+	exec.runDueJobs(ctx, runAt.Add(3*time.Hour+time.Minute))
+	if len(q.publishedJobs) != 1 || q.publishedJobs[0].ID != jobs[0].ID || !q.publishedJobs[0].DryRun {
+		t.Fatalf("expected the scheduled job to be queued, got %+v", q.publishedJobs)
+	}
+	if job, _ := exec.GetJob(ctx, jobs[0].ID); job.Status != state.JobQueued || job.ScheduledAt == "" {
+		t.Errorf("expected the job to be queued, got %+v", job)
+	}
+	if _, err := exec.CancelScheduledJob(ctx, jobs[0].ID); !errors.Is(err, ErrJobNotCancellable) {
+		t.Errorf("CancelScheduledJob(queued) error = %v, want ErrJobNotCancellable", err)
+	}
+}
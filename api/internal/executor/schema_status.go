@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Statuses of a migration in a schema (see MigrationSchemaStatuses)
+const (
+	SchemaApplied = "applied"
+	SchemaFailed  = "failed"
+	SchemaPending = "pending" // Never ran in the schema, or rolled back
+)
+
+// SchemaStatus is the status of a migration in one schema
+type SchemaStatus struct {
+	Schema    string
+	Status    string                    // SchemaApplied, SchemaFailed or SchemaPending
+	Execution *state.MigrationExecution // Nil if the migration never ran in the schema
+}
+
+// MigrationSchemaStatus is the status of a migration in each schema of its connection
+type MigrationSchemaStatus struct {
+	MigrationID string
+	Connection  string
+	Schemas     []*SchemaStatus // Sorted by schema
+}
+
+// Counts returns the number of schemas the migration is applied in, failed in, and pending in
+func (s *MigrationSchemaStatus) Counts() (applied, failed, pending int) {
+	for _, schema := range s.Schemas {
+		switch schema.Status {
+		case SchemaApplied:
+			applied++
+		case SchemaFailed:
+			failed++
+		default:
+			pending++
+		}
+	}
+	return applied, failed, pending
+}
+
+// MigrationSchemaStatuses returns the status of a migration in each schema of its connection, from
+// migrations_executions: the schemas it was executed in, and as pending the schemas only other
+// migrations of the connection ran in (tenants it has not reached yet), those of schemas, and with a
+// schemaPattern those of the connection's database matching it (see DiscoverSchemas). Executions
+// without a schema are not tenants and are left out. Returns state.ErrMigrationNotFound if the
+// migration is neither registered nor in migrations_list.
+func (e *Executor) MigrationSchemaStatuses(ctx context.Context, migrationID string, schemas []string, schemaPattern string) (*MigrationSchemaStatus, error) {
+	var connection string
+	if migration := e.GetMigrationByID(migrationID); migration != nil {
+		migrationID, connection = e.getMigrationID(migration), migration.Connection
+	} else {
+		detail, err := e.stateTracker.GetMigrationDetail(ctx, migrationID)
+		if err != nil {
+			return nil, err
+		}
+		if detail == nil {
+			return nil, fmt.Errorf("%w: %s", state.ErrMigrationNotFound, migrationID)
+		}
+		connection = detail.Connection
+	}
+
+	if schemaPattern != "" {
+		discovered, err := e.DiscoverSchemas(ctx, connection, schemaPattern)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(slices.Clone(schemas), discovered...)
+	}
+	executions, err := state.GetConnectionExecutions(ctx, e.stateTracker, connection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read executions: %w", err)
+	}
+
+	statuses := make(map[string]*SchemaStatus)
+	add := func(schema string) {
+		if schema != "" && statuses[schema] == nil {
+			statuses[schema] = &SchemaStatus{Schema: schema, Status: SchemaPending}
+		}
+	}
+	for _, schema := range schemas {
+		add(schema)
+	}
+	for _, execution := range executions {
+		add(execution.Schema)
+		if execution.Schema == "" || execution.MigrationID != migrationID {
+			continue
+		}
+		// A schema has several executions if the migration's version or backend changed; the most
+		// recently updated is its current state
+		status := statuses[execution.Schema]
+		if status.Execution != nil && status.Execution.UpdatedAt >= execution.UpdatedAt {
+			continue
+		}
+		status.Execution, status.Status = execution, SchemaPending
+		switch {
+		case execution.Applied || state.HistoryStatusIndicatesApplied(execution.Status):
+			status.Status = SchemaApplied
+		case execution.Status == "failed":
+			status.Status = SchemaFailed
+		}
+	}
+
+	result := &MigrationSchemaStatus{MigrationID: migrationID, Connection: connection}
+	for _, status := range statuses {
+		result.Schemas = append(result.Schemas, status)
+	}
+	slices.SortFunc(result.Schemas, func(a, b *SchemaStatus) int { return strings.Compare(a.Schema, b.Schema) })
+	return result, nil
+}
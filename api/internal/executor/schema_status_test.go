@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_MigrationSchemaStatuses(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+	ctx := context.Background()
+
+	const users, orders = "20240101120000_create_users_postgresql_core", "20240102120000_create_orders_postgresql_core"
+	for _, execution := range []struct{ migrationID, version, schema, status string }{
+		{orders, "20240102120000", "tenant_a", "success"},
+		{users, "20240101120000", "tenant_a", "success"},
+		{users, "20240101120000", "tenant_b", "failed"},
+		{orders, "20240102120000", "tenant_c", "success"},
+		{users, "20240101120000", "", "success"},
+	} {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: execution.migrationID, Version: execution.version, Schema: execution.schema,
+			Connection: "core", Backend: "postgresql", Status: execution.status,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	status, err := exec.MigrationSchemaStatuses(ctx, users, []string{"tenant_d"}, "")
+	if err != nil {
+		t.Fatalf("MigrationSchemaStatuses() error = %v", err)
+	}
+	if status.MigrationID != users || status.Connection != "core" {
+		t.Errorf("unexpected migration %s on %s", status.MigrationID, status.Connection)
+	}
+	want := []struct{ schema, status string }{
+		{"tenant_a", SchemaApplied},
+		{"tenant_b", SchemaFailed},
+		{"tenant_c", SchemaPending}, // Only orders ran there
+		{"tenant_d", SchemaPending}, // Requested
+	}
+	if len(status.Schemas) != len(want) {
+		t.Fatalf("expected %d schemas, got %+v", len(want), status.Schemas)
+	}
+	for i, w := range want {
+		if got := status.Schemas[i]; got.Schema != w.schema || got.Status != w.status {
+			t.Errorf("schema %d = %s %s, want %s %s", i, got.Schema, got.Status, w.schema, w.status)
+		}
+	}
+	if status.Schemas[0].Execution == nil || status.Schemas[2].Execution != nil {
+		t.Errorf("expected executions only where the migration ran, got %+v", status.Schemas)
+	}
+	if applied, failed, pending := status.Counts(); applied != 1 || failed != 1 || pending != 2 {
+		t.Errorf("Counts() = %d, %d, %d; want 1, 1, 2", applied, failed, pending)
+	}
+
+	if _, err := exec.MigrationSchemaStatuses(ctx, "20240103120000_missing_postgresql_core", nil, ""); !errors.Is(err, state.ErrMigrationNotFound) {
+		t.Errorf("expected ErrMigrationNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+)
+
+// ErrDiffUnsupported is returned when a connection's backend cannot inspect its schema
+var ErrDiffUnsupported = errors.New("schema diff is not supported by the backend")
+
+// SchemaDiff is the difference between the schema of a connection and that of a reference
+// connection, as a candidate migration that brings the connection to the reference
+type SchemaDiff struct {
+	Connection string
+	Reference  string
+	Schemas    []string
+	Diff       *schemadiff.Diff
+}
+
+// SchemaDiff compares the given schemas of a connection with those of a reference connection,
+// e.g. a database where a change was made by hand. Schemas default to the connection's configured
+// schema, or public. Both connections must use the same backend.
+func (e *Executor) SchemaDiff(ctx context.Context, connectionName, referenceName string, schemas []string) (*SchemaDiff, error) {
+	cfg, err := e.getConnectionConfig(ctx, connectionName)
+	if err != nil {
+		return nil, err
+	}
+	refCfg, err := e.getConnectionConfig(ctx, referenceName)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Backend != refCfg.Backend {
+		return nil, fmt.Errorf("%w: connection %s uses %s and reference %s uses %s", ErrDiffUnsupported, connectionName, cfg.Backend, referenceName, refCfg.Backend)
+	}
+	if len(schemas) == 0 {
+		schema := cfg.Schema
+		if schema == "" {
+			schema = "public"
+		}
+		schemas = []string{schema}
+	}
+
+	target, err := e.inspectSchema(ctx, cfg, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect connection %s: %w", connectionName, err)
+	}
+	reference, err := e.inspectSchema(ctx, refCfg, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect reference %s: %w", referenceName, err)
+	}
+
+	return &SchemaDiff{
+		Connection: connectionName,
+		Reference:  referenceName,
+		Schemas:    schemas,
+		Diff:       schemadiff.Compare(target, reference),
+	}, nil
+}
+
+// inspectSchema reads the schemas of a connection through its own backend instance
+func (e *Executor) inspectSchema(ctx context.Context, cfg *backends.ConnectionConfig, schemas []string) (*schemadiff.Schema, error) {
+	backend, ok := e.registeredBackend(cfg.Backend)
+	if !ok {
+		return nil, fmt.Errorf("backend %s not registered", cfg.Backend)
+	}
+	if cloner, ok := backend.(backends.Cloner); ok {
+		backend = cloner.Clone()
+	}
+	inspector, ok := backend.(schemadiff.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDiffUnsupported, cfg.Backend)
+	}
+
+	if err := backend.Connect(cfg); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() { _ = backend.Close() }()
+	return inspector.InspectSchema(ctx, schemas)
+}
+
+// UpScript returns the up statements as a migration script, with a header asking for review
+func (d *SchemaDiff) UpScript() string {
+	return schemadiff.Script(d.header("brings "+d.Connection+" to the schema of "+d.Reference), d.Diff.Up)
+}
+
+// DownScript returns the down statements as a migration script, with a header asking for review
+func (d *SchemaDiff) DownScript() string {
+	return schemadiff.Script(d.header("reverts "+d.Connection+" to its schema before the up script"), d.Diff.Down)
+}
+
+func (d *SchemaDiff) header(purpose string) string {
+	return fmt.Sprintf("-- Generated by bfm diff: %s (schemas: %s).\n"+
+		"-- Review before committing: type changes may need a USING clause, and dropped tables and\n"+
+		"-- columns lose their data.\n", purpose, strings.Join(d.Schemas, ", "))
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// inspectorBackend is a backend whose databases have fixed schemas, by database name
+type inspectorBackend struct {
+	*testsupport.Backend
+	databases map[string]*schemadiff.Schema
+}
+
+func (b *inspectorBackend) InspectSchema(ctx context.Context, schemas []string) (*schemadiff.Schema, error) {
+	_, cfg := b.Connected()
+	return b.databases[cfg.Database], nil
+}
+
+func TestExecutor_SchemaDiff(t *testing.T) {
+	users := func(columns ...*schemadiff.Column) *schemadiff.Table {
+		return &schemadiff.Table{Schema: "app", Name: "users", Columns: columns}
+	}
+	id := &schemadiff.Column{Name: "id", Type: "bigint", NotNull: true}
+	email := &schemadiff.Column{Name: "email", Type: "text"}
+
+	exec := NewExecutor(newMockRegistry(), testsupport.NewStateTracker())
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"core":    {Backend: "postgresql", Database: "prod", Schema: "app"},
+		"dev":     {Backend: "postgresql", Database: "dev"},
+		"metrics": {Backend: "greptimedb"},
+	})
+	exec.RegisterBackend("postgresql", &inspectorBackend{
+		Backend: testsupport.NewBackend("postgresql"),
+		databases: map[string]*schemadiff.Schema{
+			"prod": schemadiff.NewSchema(users(id)),
+			"dev":  schemadiff.NewSchema(users(id, email)),
+		},
+	})
+	exec.RegisterBackend("greptimedb", testsupport.NewBackend("greptimedb"))
+	ctx := context.Background()
+
+	diff, err := exec.SchemaDiff(ctx, "core", "dev", nil)
+	if err != nil {
+		t.Fatalf("SchemaDiff failed: %v", err)
+	}
+	if !reflect.DeepEqual(diff.Schemas, []string{"app"}) {
+		t.Errorf("expected the connection's schema by default, got %v", diff.Schemas)
+	}
+	if want := []string{"ALTER TABLE app.users ADD COLUMN email text;"}; !reflect.DeepEqual(diff.Diff.Up, want) {
+		t.Errorf("unexpected up statements %q", diff.Diff.Up)
+	}
+	if want := []string{"ALTER TABLE app.users DROP COLUMN email;"}; !reflect.DeepEqual(diff.Diff.Down, want) {
+		t.Errorf("unexpected down statements %q", diff.Diff.Down)
+	}
+
+	if _, err := exec.SchemaDiff(ctx, "metrics", "core", nil); !errors.Is(err, ErrDiffUnsupported) {
+		t.Errorf("expected ErrDiffUnsupported across backends, got %v", err)
+	}
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"metrics": {Backend: "greptimedb"}, "other": {Backend: "greptimedb"}})
+	if _, err := exec.SchemaDiff(ctx, "metrics", "other", nil); !errors.Is(err, ErrDiffUnsupported) {
+		t.Errorf("expected ErrDiffUnsupported for a backend without an inspector, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxSchemaNameLength is the longest schema name a request may target: PostgreSQL truncates
+// identifiers to 63 bytes, so longer names would silently target another schema
+const MaxSchemaNameLength = 63
+
+// DefaultSchemaPattern is the pattern schema names must match unless configured otherwise:
+// a letter or underscore, then letters, digits, underscores and hyphens
+const DefaultSchemaPattern = `^[A-Za-z_][A-Za-z0-9_-]*$`
+
+// DefaultDeniedSchemas are the schema names requests may not target unless configured otherwise:
+// the PostgreSQL system schemas
+var DefaultDeniedSchemas = []string{"pg_*", "information_schema"}
+
+// ErrInvalidSchema is returned when a request targets a schema name the schema policy refuses
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// SchemaPolicy limits the schemas requests may fan migrations out to. Names come from callers and
+// end up in quoted identifiers and schema-prefixed migration IDs, so they are checked before
+// anything is executed.
+type SchemaPolicy struct {
+	Pattern    *regexp.Regexp // Names must match it; nil allows any name
+	Denied     []string       // Glob patterns (see path.Match) of names refused, ignoring case
+	MaxSchemas int            // Most schemas per request; 0 allows any number
+	// MaxConcurrency caps the schemas of a connection one request runs at once; 0 sets no cap
+	MaxConcurrency int
+}
+
+// DefaultSchemaPolicy returns the policy of a new executor: DefaultSchemaPattern and
+// DefaultDeniedSchemas, with no limit on the number of schemas and at most
+// DefaultMaxSchemaConcurrency run at once
+func DefaultSchemaPolicy() SchemaPolicy {
+	return SchemaPolicy{
+		Pattern:        regexp.MustCompile(DefaultSchemaPattern),
+		Denied:         DefaultDeniedSchemas,
+		MaxConcurrency: DefaultMaxSchemaConcurrency,
+	}
+}
+
+// NewSchemaPolicy returns the default policy with pattern instead of DefaultSchemaPattern (unless
+// nil), denied names in addition to DefaultDeniedSchemas and at most maxSchemas schemas per
+// request (0 for any number)
+func NewSchemaPolicy(pattern *regexp.Regexp, denied []string, maxSchemas int) SchemaPolicy {
+	policy := DefaultSchemaPolicy()
+	if pattern != nil {
+		policy.Pattern = pattern
+	}
+	policy.Denied = append(slices.Clone(policy.Denied), denied...)
+	policy.MaxSchemas = maxSchemas
+	return policy
+}
+
+// Validate checks the schemas of a request. An empty name stands for the migration's own schema
+// and is always allowed.
+func (p SchemaPolicy) Validate(schemas []string) error {
+	if p.MaxSchemas > 0 && len(schemas) > p.MaxSchemas {
+		return fmt.Errorf("%w: %d schemas requested, at most %d allowed", ErrInvalidSchema, len(schemas), p.MaxSchemas)
+	}
+	for _, schema := range schemas {
+		if err := p.validateName(schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateName checks one schema name
+func (p SchemaPolicy) validateName(schema string) error {
+	if schema == "" {
+		return nil
+	}
+	if len(schema) > MaxSchemaNameLength {
+		return fmt.Errorf("%w %q: longer than %d bytes", ErrInvalidSchema, schema, MaxSchemaNameLength)
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(schema) {
+		return fmt.Errorf("%w %q: must match %s", ErrInvalidSchema, schema, p.Pattern)
+	}
+	lower := strings.ToLower(schema)
+	for _, denied := range p.Denied {
+		if matched, _ := path.Match(strings.ToLower(denied), lower); matched {
+			return fmt.Errorf("%w %q: reserved schema (matches %s)", ErrInvalidSchema, schema, denied)
+		}
+	}
+	return nil
+}
+
+// SetSchemaPolicy sets the schemas requests may target
+func (e *Executor) SetSchemaPolicy(policy SchemaPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.schemaPolicy = policy
+}
+
+// validateSchemas checks the schemas of a request against the schema policy
+func (e *Executor) validateSchemas(schemas ...string) error {
+	e.mu.Lock()
+	policy := e.schemaPolicy
+	e.mu.Unlock()
+	return policy.Validate(schemas)
+}
@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestSchemaPolicy_Validate(t *testing.T) {
+	policy := DefaultSchemaPolicy()
+	tests := []struct {
+		schema string
+		valid  bool
+	}{
+		{"", true},
+		{"tenant_42", true},
+		{"tenant-a", true},
+		{"_staging", true},
+		{"pg_catalog", false},
+		{"PG_TOAST", false},
+		{"information_schema", false},
+		{"tenant a", false},
+		{`tenant"; DROP SCHEMA public; --`, false},
+		{"42tenant", false},
+		{strings.Repeat("t", MaxSchemaNameLength), true},
+		{strings.Repeat("t", MaxSchemaNameLength+1), false},
+	}
+	for _, tt := range tests {
+		err := policy.Validate([]string{tt.schema})
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%q) error = %v, want nil", tt.schema, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Validate(%q) error = %v, want ErrInvalidSchema", tt.schema, err)
+		}
+	}
+}
+
+func TestNewSchemaPolicy(t *testing.T) {
+	policy := NewSchemaPolicy(regexp.MustCompile(`^tenant_[0-9]+$`), []string{"tenant_0"}, 2)
+	if err := policy.Validate([]string{"tenant_1", "tenant_2"}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, schemas := range [][]string{
+		{"tenant_a"},                         // pattern
+		{"tenant_0"},                         // configured deny list
+		{"tenant_1", "tenant_2", "tenant_3"}, // too many
+	} {
+		if err := policy.Validate(schemas); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Validate(%v) error = %v, want ErrInvalidSchema", schemas, err)
+		}
+	}
+	// The system schemas stay denied whatever the pattern
+	if err := NewSchemaPolicy(regexp.MustCompile(`.*`), nil, 0).Validate([]string{"pg_catalog"}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("expected pg_catalog to stay denied, got %v", err)
+	}
+	if len(DefaultDeniedSchemas) != 2 {
+		t.Errorf("NewSchemaPolicy modified DefaultDeniedSchemas: %v", DefaultDeniedSchemas)
+	}
+}
+
+func TestExecutor_RefusesInvalidSchemas(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	target := &registry.MigrationTarget{Connection: "test"}
+	ctx := context.Background()
+	migrationID := "20240101120000_create_users_postgresql_test"
+
+	if _, err := exec.ExecuteUp(ctx, target, "test", []string{"tenant_a", "pg_catalog"}, false, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ExecuteUp() error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.ExecuteSync(ctx, target, "test", "information_schema", false, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ExecuteSync() error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.Plan(ctx, target, "test", []string{"bad name"}, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Plan() error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.ExecuteDown(ctx, migrationID, []string{"pg_temp_1"}, false, false); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ExecuteDown() error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := exec.Rollback(ctx, migrationID, []string{"pg_toast"}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Rollback() error = %v, want ErrInvalidSchema", err)
+	}
+	if len(tracker.History()) != 0 {
+		t.Errorf("expected nothing to be recorded, got %d records", len(tracker.History()))
+	}
+}
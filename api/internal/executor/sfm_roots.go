@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrSFMRootConflict is returned when two SFM roots define the same version of a connection
+var ErrSFMRootConflict = errors.New("conflicting migrations in SFM roots")
+
+// sfmMigrationFileRe matches the migration files of an SFM root: {version}_{name}.go and up scripts
+var sfmMigrationFileRe = regexp.MustCompile(`^(\d{14})_(.+?)(\.go|\.up\.sql|\.up\.json|\.up\.yaml)$`)
+
+// sfmRootMigrations returns the migrations of an SFM root, as {backend}/{connection}/{version}
+// mapped to the first file defining it. A missing root has no migrations.
+func sfmRootMigrations(root string) (map[string]string, error) {
+	found := make(map[string]string)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return found, nil
+	}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		// Structure: {root}/{backend}/{connection}/{version}_{name}.{go|up.sql|up.json|up.yaml}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(relPath, string(filepath.Separator))
+		if len(parts) < 3 {
+			return nil
+		}
+		matches := sfmMigrationFileRe.FindStringSubmatch(parts[len(parts)-1])
+		if matches == nil {
+			return nil
+		}
+
+		key := parts[0] + "/" + parts[1] + "/" + matches[1]
+		if _, ok := found[key]; !ok {
+			found[key] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning SFM directory %s: %w", root, err)
+	}
+	return found, nil
+}
+
+// checkSFMRootConflicts returns an ErrSFMRootConflict listing every version of a connection
+// defined in more than one of roots. Versions order the migrations of a connection, so two
+// roots may not share one even under different migration names.
+func checkSFMRootConflicts(roots []string) error {
+	if len(roots) < 2 {
+		return nil
+	}
+
+	owners := make(map[string]string) // {backend}/{connection}/{version} -> file
+	var conflicts []string
+	for _, root := range roots {
+		migrations, err := sfmRootMigrations(root)
+		if err != nil {
+			return err
+		}
+		for key, path := range migrations {
+			if existing, ok := owners[key]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%s is defined by %s and %s", key, existing, path))
+				continue
+			}
+			owners[key] = path
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	sort.Strings(conflicts)
+	return fmt.Errorf("%w: %s", ErrSFMRootConflict, strings.Join(conflicts, "; "))
+}
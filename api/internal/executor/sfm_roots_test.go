@@ -0,0 +1,45 @@
+package executor
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestLoader_MultipleRoots(t *testing.T) {
+	platform, product := t.TempDir(), t.TempDir()
+	writeTestFile(t, filepath.Join(platform, "postgresql", "core", "20240101120000_create_users.up.sql"), "CREATE TABLE users (id INT);")
+	writeTestFile(t, filepath.Join(platform, "postgresql", "core", "20240101120000_create_users.down.sql"), "DROP TABLE users;")
+	writeTestFile(t, filepath.Join(product, "postgresql", "core", "20240201120000_create_orders.up.sql"), "CREATE TABLE orders (id INT);")
+	writeTestFile(t, filepath.Join(product, "postgresql", "core", "20240201120000_create_orders.down.sql"), "DROP TABLE orders;")
+	writeTestFile(t, filepath.Join(product, "postgresql", "billing", "20240101120000_create_invoices.up.sql"), "CREATE TABLE invoices (id INT);")
+	writeTestFile(t, filepath.Join(product, "postgresql", "billing", "20240101120000_create_invoices.down.sql"), "DROP TABLE invoices;")
+
+	reg := registry.NewInMemoryRegistry()
+	loader := NewLoader(platform, product, platform+"/")
+	loader.SetSource(SourceScripts)
+	if err := loader.LoadAll(reg); err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if got := len(reg.GetAll()); got != 3 {
+		t.Fatalf("expected the 3 migrations of both roots, got %d", got)
+	}
+
+	// The same version of a connection in both roots is a conflict, even under another name
+	writeTestFile(t, filepath.Join(product, "postgresql", "core", "20240101120000_create_accounts.up.sql"), "CREATE TABLE accounts (id INT);")
+	err := NewLoader(platform, product).LoadAll(registry.NewInMemoryRegistry())
+	if !errors.Is(err, ErrSFMRootConflict) || !strings.Contains(err.Error(), "postgresql/core/20240101120000") {
+		t.Fatalf("LoadAll() error = %v, want ErrSFMRootConflict for postgresql/core/20240101120000", err)
+	}
+
+	// The watcher loads nothing while the conflict remains
+	if err := loader.scanAndLoad(); !errors.Is(err, ErrSFMRootConflict) {
+		t.Errorf("scanAndLoad() error = %v, want ErrSFMRootConflict", err)
+	}
+	if got := len(reg.GetAll()); got != 3 {
+		t.Errorf("expected no migration loaded during a conflict, got %d", got)
+	}
+}
@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// squashSuperseded reports whether migration is a squashed baseline (see
+// MigrationScript.SquashedBefore) of a connection that already applied one of the migrations it
+// consolidates. Such a database has the schema the squash would create, so the squash is recorded
+// as applied instead of executed; fresh databases execute it instead of the old migrations.
+func (e *Executor) squashSuperseded(ctx context.Context, migration *backends.MigrationScript) (bool, error) {
+	before := migration.SquashedBefore()
+	if before == "" {
+		return false, nil
+	}
+	items, _, err := e.stateTracker.GetMigrationList(ctx, &state.MigrationFilters{Connection: migration.Connection})
+	if err != nil {
+		return false, fmt.Errorf("failed to list migrations of connection %s: %w", migration.Connection, err)
+	}
+	squashID := e.getMigrationID(migration)
+	for _, item := range items {
+		if item.Applied && item.Version < before && item.MigrationID != squashID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RecordSquash records the squashed baseline migration as applied without executing it, as a
+// baseline with the squashed version in its execution context. bfm squash records it in the state
+// of the database it dumped; other databases record it when they first see it (see squashSuperseded).
+func (e *Executor) RecordSquash(ctx context.Context, migration *backends.MigrationScript) (string, error) {
+	migrationID := e.executionMigrationID(migration, "")
+	if migrationID == "" {
+		return "", fmt.Errorf("squashed baseline %s_%s has no schema", migration.Version, migration.Name)
+	}
+	return migrationID, e.recordSquash(ctx, migration, migrationID, migration.Schema)
+}
+
+// recordSquash records migration as applied in schema, without executing it
+func (e *Executor) recordSquash(ctx context.Context, migration *backends.MigrationScript, migrationID, schema string) error {
+	executedBy, _, executionContext := GetExecutionContext(ctx)
+	executionContext = withExecutionContextValue(executionContext, "squashed_before", migration.SquashedBefore())
+
+	record := &state.MigrationRecord{
+		MigrationID:      migrationID,
+		Schema:           schema,
+		Version:          migration.Version,
+		Connection:       migration.Connection,
+		Backend:          migration.Backend,
+		Status:           "success",
+		AppliedAt:        time.Now().Format(time.RFC3339),
+		ExecutedBy:       executedBy,
+		ExecutionMethod:  BaselineExecutionMethod,
+		ExecutionContext: executionContext,
+		Checksum:         migration.Checksum(),
+	}
+	if err := e.stateTracker.RecordMigration(ctx, record); err != nil {
+		return fmt.Errorf("failed to record squashed baseline %s: %w", migrationID, err)
+	}
+	logger.Infof("Recorded squashed baseline %s (not executed: the migrations it consolidates were applied)", migrationID)
+
+	e.events.Publish(ctx, events.Event{
+		Type:        events.MigrationApplied,
+		MigrationID: migrationID,
+		Connection:  migration.Connection,
+		Schema:      schema,
+		Data:        map[string]interface{}{"baseline": true, "squashed_before": migration.SquashedBefore()},
+	})
+	return nil
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_SquashedBaseline(t *testing.T) {
+	newExecutor := func() (*Executor, *testsupport.Backend, *testsupport.StateTracker) {
+		reg := newMockRegistry()
+		tracker := testsupport.NewStateTracker()
+		exec := NewExecutor(reg, tracker)
+		_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}})
+		backend := testsupport.NewBackend("postgresql")
+		exec.RegisterBackend("postgresql", backend)
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20231201120000", Name: "squashed_baseline", Connection: "core", Backend: "postgresql",
+			UpSQL: backends.SquashDirective("20240101000000") + "\nCREATE TABLE users (id INT);",
+		})
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql",
+			UpSQL: "CREATE TABLE orders (id INT);",
+		})
+		return exec, backend, tracker
+	}
+	target := &registry.MigrationTarget{Connection: "core"}
+	ctx := context.Background()
+	squashID := "20231201120000_squashed_baseline_postgresql_core"
+
+	// A fresh database executes the squash
+	exec, backend, _ := newExecutor()
+	result, err := exec.ExecuteSync(ctx, target, "core", "", false, false)
+	if err != nil || len(result.Applied) != 2 || len(backend.Executed()) != 2 {
+		t.Fatalf("expected the squash to be executed on a fresh database, got %+v, %v", result, err)
+	}
+
+	// A database that applied the squashed migrations records it instead
+	exec, backend, tracker := newExecutor()
+	_ = tracker.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "20231101120000_create_users_postgresql_core", Schema: "public", Version: "20231101120000",
+		Connection: "core", Backend: "postgresql", Status: "success",
+	})
+	plan, err := exec.Plan(ctx, target, "core", nil, false)
+	if err != nil || len(plan.Skip) != 1 || plan.Skip[0] != squashID || len(plan.Apply) != 1 {
+		t.Fatalf("expected the plan to skip the squash, got %+v, %v", plan, err)
+	}
+	result, err = exec.ExecuteSync(ctx, target, "core", "", false, false)
+	if err != nil || len(result.Applied) != 1 || len(result.Skipped) != 1 || result.Skipped[0] != squashID {
+		t.Fatalf("expected the squash to be recorded, got %+v, %v", result, err)
+	}
+	if executed := backend.Executed(); len(executed) != 1 || executed[0].Name != "create_orders" {
+		t.Errorf("expected only create_orders to be executed, got %d executions", len(executed))
+	}
+	history, _, _ := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{MigrationID: squashID})
+	if len(history) != 1 || history[0].ExecutionMethod != BaselineExecutionMethod || history[0].Checksum == "" {
+		t.Errorf("expected the squash to be recorded as a baseline, got %+v", history)
+	}
+	if applied, _ := tracker.IsMigrationApplied(ctx, squashID); !applied {
+		t.Errorf("expected the squash to be applied")
+	}
+}
@@ -0,0 +1,163 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrStandby is returned for operations that write to databases or migration state while the
+// server is a standby
+var ErrStandby = errors.New("server is in standby; promote it first")
+
+// Promotion sources reported in StandbyStatus.PromotedBy
+const (
+	PromotedByAPI  = "api"  // An operator called the promote endpoint
+	PromotedByLock = "lock" // The primary lock became free and auto-promotion took it
+)
+
+// StandbyStatus reports whether this instance is a standby or the primary
+type StandbyStatus struct {
+	Standby          bool
+	HoldsPrimaryLock bool
+	PromotedAt       time.Time // Zero unless this instance was promoted from standby
+	PromotedBy       string    // PromotedByAPI or PromotedByLock
+}
+
+// SetStandby puts the executor in standby: it reads migrations and state, but refuses to apply,
+// roll back or reindex migrations until it is promoted
+func (e *Executor) SetStandby(standby bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.standby = standby
+}
+
+// IsStandby reports whether the executor is in standby
+func (e *Executor) IsStandby() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.standby
+}
+
+// OnPromote registers fn to run when a standby is promoted. The server uses it to start the
+// background work a standby skips (reindexing, auto-migrate).
+func (e *Executor) OnPromote(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onPromote = append(e.onPromote, fn)
+}
+
+// StandbyStatus returns whether this instance is a standby and holds the primary lock
+func (e *Executor) StandbyStatus() StandbyStatus {
+	e.primaryMu.Lock()
+	holdsLock := e.primaryLock != nil
+	e.primaryMu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return StandbyStatus{
+		Standby:          e.standby,
+		HoldsPrimaryLock: holdsLock,
+		PromotedAt:       e.promotedAt,
+		PromotedBy:       e.promotedBy,
+	}
+}
+
+// Promote turns a standby into the primary. It takes the primary lock first, so it fails with
+// state.ErrPrimaryLocked while the primary is still running. Promoting the primary is a no-op.
+func (e *Executor) Promote(ctx context.Context) error {
+	e.primaryMu.Lock()
+	defer e.primaryMu.Unlock()
+
+	if !e.IsStandby() {
+		return nil
+	}
+	if e.primaryLock == nil {
+		lock, err := e.stateTracker.AcquirePrimaryLock(ctx)
+		if err != nil {
+			return err
+		}
+		e.primaryLock = lock
+	}
+	e.promote(PromotedByAPI)
+	return nil
+}
+
+// RunPrimaryElection keeps competing for the primary lock until ctx is done, checking every
+// interval. The primary holds the lock for as long as it runs, and takes it back if its session is
+// lost. A standby takes the lock and promotes itself when the primary stops, unless autoPromote is
+// false, in which case it waits for Promote. The lock is released when ctx is done.
+func (e *Executor) RunPrimaryElection(ctx context.Context, interval time.Duration, autoPromote bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.electPrimary(ctx, autoPromote)
+		select {
+		case <-ctx.Done():
+			e.primaryMu.Lock()
+			if e.primaryLock != nil {
+				e.primaryLock.Release()
+				e.primaryLock = nil
+			}
+			e.primaryMu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// electPrimary checks the held primary lock, or tries to take it
+func (e *Executor) electPrimary(ctx context.Context, autoPromote bool) {
+	e.primaryMu.Lock()
+	defer e.primaryMu.Unlock()
+
+	if e.primaryLock != nil {
+		err := e.primaryLock.Check(ctx)
+		if err == nil {
+			return
+		}
+		logger.Warnf("Lost the primary lock: %v", err)
+		e.primaryLock.Release()
+		e.primaryLock = nil
+	}
+
+	standby := e.IsStandby()
+	if standby && !autoPromote {
+		return
+	}
+
+	lock, err := e.stateTracker.AcquirePrimaryLock(ctx)
+	if errors.Is(err, state.ErrPrimaryLocked) {
+		return
+	}
+	if err != nil {
+		logger.Warnf("Failed to acquire the primary lock: %v", err)
+		return
+	}
+	e.primaryLock = lock
+	if standby {
+		logger.Warnf("Primary lock is free: the primary stopped, promoting this standby")
+		e.promote(PromotedByLock)
+		return
+	}
+	logger.Info("Acquired the primary lock")
+}
+
+// promote leaves standby and runs the OnPromote hooks. The caller holds primaryMu.
+func (e *Executor) promote(by string) {
+	e.mu.Lock()
+	e.standby = false
+	e.promotedAt = time.Now()
+	e.promotedBy = by
+	hooks := e.onPromote
+	e.mu.Unlock()
+
+	logger.Infof("Promoted from standby to primary (%s)", by)
+	for _, fn := range hooks {
+		fn()
+	}
+}
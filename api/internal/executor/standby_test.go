@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_StandbyRefusesWrites(t *testing.T) {
+	reg := newMockRegistry()
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{
+		"test": {Backend: "postgresql", Host: "localhost"},
+	})
+	exec.RegisterBackend("postgresql", newMockBackend("postgresql"))
+	_ = reg.Register(&backends.MigrationScript{
+		Schema: "public", Version: "20240101120000", Name: "users", Connection: "test", Backend: "postgresql", UpSQL: "SELECT 1;",
+	})
+	exec.SetStandby(true)
+
+	target := &registry.MigrationTarget{Connection: "test"}
+	if _, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false); !errors.Is(err, ErrStandby) {
+		t.Errorf("ExecuteSync() error = %v, want ErrStandby", err)
+	}
+	if _, err := exec.ReindexMigrations(context.Background(), t.TempDir()); !errors.Is(err, ErrStandby) {
+		t.Errorf("ReindexMigrations() error = %v, want ErrStandby", err)
+	}
+	if _, err := exec.ReleaseLock(context.Background(), "test"); !errors.Is(err, ErrStandby) {
+		t.Errorf("ReleaseLock() error = %v, want ErrStandby", err)
+	}
+
+	// Dry runs only read
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", true, false)
+	if err != nil || !result.Success {
+		t.Errorf("ExecuteSync(dry run) = %+v, %v", result, err)
+	}
+	if history := tracker.History(); len(history) != 0 {
+		t.Errorf("standby recorded %d execution(s)", len(history))
+	}
+}
+
+func TestExecutor_Promote(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	exec := NewExecutor(newMockRegistry(), tracker)
+	exec.SetStandby(true)
+	promoted := 0
+	exec.OnPromote(func() { promoted++ })
+
+	// The primary still holds the lock
+	primary, err := tracker.AcquirePrimaryLock(context.Background())
+	if err != nil {
+		t.Fatalf("AcquirePrimaryLock() error = %v", err)
+	}
+	if err := exec.Promote(context.Background()); !errors.Is(err, state.ErrPrimaryLocked) {
+		t.Fatalf("Promote() error = %v, want ErrPrimaryLocked", err)
+	}
+	if !exec.IsStandby() || promoted != 0 {
+		t.Fatalf("standby was promoted while the primary held the lock")
+	}
+
+	primary.Release()
+	if err := exec.Promote(context.Background()); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	status := exec.StandbyStatus()
+	if status.Standby || !status.HoldsPrimaryLock || status.PromotedBy != PromotedByAPI || status.PromotedAt.IsZero() {
+		t.Errorf("StandbyStatus() = %+v after promotion", status)
+	}
+	if promoted != 1 {
+		t.Errorf("OnPromote hooks ran %d time(s), want 1", promoted)
+	}
+
+	// Promoting the primary is a no-op
+	if err := exec.Promote(context.Background()); err != nil || promoted != 1 {
+		t.Errorf("Promote() on the primary = %v, hooks ran %d time(s)", err, promoted)
+	}
+}
+
+func TestExecutor_ElectPrimary(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	standby := NewExecutor(newMockRegistry(), tracker)
+	standby.SetStandby(true)
+	primaryHeld := func() bool {
+		lock, err := tracker.AcquirePrimaryLock(context.Background())
+		if err == nil {
+			lock.Release()
+		}
+		return errors.Is(err, state.ErrPrimaryLocked)
+	}
+
+	// While the primary holds the lock, the standby waits
+	primary, err := tracker.AcquirePrimaryLock(context.Background())
+	if err != nil {
+		t.Fatalf("AcquirePrimaryLock() error = %v", err)
+	}
+	standby.electPrimary(context.Background(), true)
+	if !standby.IsStandby() {
+		t.Fatal("standby promoted while the primary held the lock")
+	}
+
+	// Without auto-promotion, a free lock is left for Promote
+	primary.Release()
+	standby.electPrimary(context.Background(), false)
+	if !standby.IsStandby() || primaryHeld() {
+		t.Fatal("standby took the lock without auto-promotion")
+	}
+
+	standby.electPrimary(context.Background(), true)
+	status := standby.StandbyStatus()
+	if status.Standby || !status.HoldsPrimaryLock || status.PromotedBy != PromotedByLock {
+		t.Fatalf("StandbyStatus() = %+v, want promoted by lock", status)
+	}
+
+	// A lost lock is released and taken again
+	lock := standby.primaryLock
+	lock.Release()
+	standby.electPrimary(context.Background(), true)
+	if standby.primaryLock == nil || standby.primaryLock == lock || !primaryHeld() {
+		t.Error("lost primary lock was not taken again")
+	}
+}
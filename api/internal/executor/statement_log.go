@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// ExtraSQLLog controls statement logging for a connection ({CONNECTION}_SQL_LOG): "debug" (default)
+// logs the rendered SQL of each migration at debug level, "none" keeps it out of logs and responses
+// for connections whose scripts carry sensitive values.
+const ExtraSQLLog = "SQL_LOG"
+
+// Statement logging modes
+const (
+	SQLLogDebug = "debug"
+	SQLLogNone  = "none"
+)
+
+// maxCapturedSQL bounds the SQL returned per migration when capture is requested
+const maxCapturedSQL = 64 * 1024
+
+const captureSQLKey contextKey = "bfm_capture_sql"
+
+// WithCaptureSQL marks ctx so executions return the rendered SQL of each migration (including
+// dry runs) in ExecuteResult.ExecutedSQL
+func WithCaptureSQL(ctx context.Context) context.Context {
+	return context.WithValue(ctx, captureSQLKey, true)
+}
+
+func isCaptureSQL(ctx context.Context) bool {
+	v, ok := ctx.Value(captureSQLKey).(bool)
+	return ok && v
+}
+
+// ExecutedSQL is the rendered script of a migration, returned when SQL capture is requested
+type ExecutedSQL struct {
+	MigrationID string
+	SQL         string
+	Truncated   bool // SQL was cut at maxCapturedSQL bytes
+	Suppressed  bool // The connection's SQL_LOG is "none", so SQL is omitted
+}
+
+// sqlLogSuppressed reports whether a connection keeps SQL out of logs and responses
+func sqlLogSuppressed(cfg *backends.ConnectionConfig) bool {
+	return strings.EqualFold(cfg.ExtraValue(ExtraSQLLog), SQLLogNone)
+}
+
+// statementCount returns the number of statements the backend of a connection executes a script
+// as, or 0 when the backend runs scripts whole (see backends.StatementSplitter)
+func (e *Executor) statementCount(cfg *backends.ConnectionConfig, sql string) int {
+	if cfg == nil {
+		return 0
+	}
+	backend, _ := e.registeredBackend(cfg.Backend)
+	splitter, ok := backend.(backends.StatementSplitter)
+	if !ok {
+		return 0
+	}
+	return len(splitter.SplitStatements(sql))
+}
+
+// logStatement logs the rendered SQL of a migration, with the number of statements it is executed
+// as when known, and captures it into result when requested
+func logStatement(ctx context.Context, cfg *backends.ConnectionConfig, migrationID, sql string, statements int, result *ExecuteResult) {
+	label := migrationID
+	if statements > 0 {
+		label = fmt.Sprintf("%s (%d statements)", migrationID, statements)
+	}
+	suppressed := sqlLogSuppressed(cfg)
+	if suppressed {
+		logger.Debug("Executing %s (SQL logging disabled for connection)", label)
+	} else {
+		logger.Debug("Executing %s:\n%s", label, sql)
+	}
+
+	if !isCaptureSQL(ctx) {
+		return
+	}
+	captured := ExecutedSQL{MigrationID: migrationID, Suppressed: suppressed}
+	if !suppressed {
+		captured.SQL = sql
+		if len(sql) > maxCapturedSQL {
+			captured.SQL = sql[:maxCapturedSQL]
+			captured.Truncated = true
+		}
+	}
+	result.ExecutedSQL = append(result.ExecutedSQL, captured)
+}
+
+// captureDryRunSQL renders a script the way it would be executed and captures it for a dry run
+func (e *Executor) captureDryRunSQL(ctx context.Context, migration *backends.MigrationScript, migrationID, schema, script string, result *ExecuteResult) {
+	if !isCaptureSQL(ctx) {
+		return
+	}
+	cfg, err := e.getConnectionConfig(ctx, migration.Connection)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", migrationID, err))
+		return
+	}
+	rendered, _, err := renderTemplate(script, migration, schema, templatePolicyFromConnection(cfg))
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to replace template variables: %v", migrationID, err))
+		return
+	}
+	logStatement(ctx, cfg, migrationID, rendered, e.statementCount(cfg, rendered), result)
+}
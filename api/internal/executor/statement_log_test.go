@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+func TestExecutor_ExecuteSync_CaptureSQL(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	result, err := exec.ExecuteSync(context.Background(), target, "test", "", true, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if len(result.ExecutedSQL) != 0 {
+		t.Errorf("expected no SQL without capture, got %v", result.ExecutedSQL)
+	}
+
+	ctx := WithCaptureSQL(context.Background())
+	result, err = exec.ExecuteSync(ctx, target, "test", "", true, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() dry run error = %v", err)
+	}
+	if len(result.ExecutedSQL) != 1 || result.ExecutedSQL[0].SQL != "CREATE TABLE users (id INT);" {
+		t.Fatalf("expected the dry run SQL to be captured, got %v", result.ExecutedSQL)
+	}
+
+	result, err = exec.ExecuteSync(ctx, target, "test", "", false, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if len(result.ExecutedSQL) != 1 || result.ExecutedSQL[0].MigrationID != "20240101120000_create_users_postgresql_test" {
+		t.Fatalf("expected the executed SQL to be captured, got %v", result.ExecutedSQL)
+	}
+}
+
+func TestLogStatement(t *testing.T) {
+	ctx := WithCaptureSQL(context.Background())
+	sensitive := &backends.ConnectionConfig{Extra: map[string]string{"SQL_LOG": "none"}}
+
+	result := &ExecuteResult{}
+	logStatement(ctx, sensitive, "m1", "ALTER ROLE app PASSWORD 'secret';", 1, result)
+	if len(result.ExecutedSQL) != 1 || !result.ExecutedSQL[0].Suppressed || result.ExecutedSQL[0].SQL != "" {
+		t.Errorf("expected SQL to be suppressed, got %+v", result.ExecutedSQL)
+	}
+
+	result = &ExecuteResult{}
+	logStatement(ctx, nil, "m2", strings.Repeat("x", maxCapturedSQL+1), 0, result)
+	if len(result.ExecutedSQL) != 1 || !result.ExecutedSQL[0].Truncated || len(result.ExecutedSQL[0].SQL) != maxCapturedSQL {
+		t.Errorf("expected SQL to be truncated, got truncated=%v len=%d", result.ExecutedSQL[0].Truncated, len(result.ExecutedSQL[0].SQL))
+	}
+}
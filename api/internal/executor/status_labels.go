@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidStatusLabel is returned for status labels that are malformed or reserved
+var ErrInvalidStatusLabel = errors.New("invalid status label")
+
+// maxStatusLabels is the number of status labels a migration can carry
+const maxStatusLabels = 16
+
+// statusLabelPattern matches status labels: lowercase letters, digits, '-' and '_'
+var statusLabelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// reservedStatusLabels are the statuses set by executions, which labels must not be mistaken for
+var reservedStatusLabels = []string{"pending", "applied", "success", "failed", "rolled_back"}
+
+// SetStatusLabels replaces the user-defined status labels of a migration (e.g. "verified",
+// "needs-backfill") and returns them normalized: lowercased, deduplicated and sorted. Labels
+// describe operational workflow state and never change the status set by executions.
+func (e *Executor) SetStatusLabels(ctx context.Context, migrationID string, labels []string) ([]string, error) {
+	if e.IsStandby() {
+		return nil, ErrStandby
+	}
+	normalized, err := normalizeStatusLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.stateTracker.SetStatusLabels(ctx, migrationID, normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// normalizeStatusLabels validates labels and returns them lowercased, deduplicated and sorted
+func normalizeStatusLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if !statusLabelPattern.MatchString(label) {
+			return nil, fmt.Errorf("%w %q: use up to 63 lowercase letters, digits, '-' and '_'", ErrInvalidStatusLabel, label)
+		}
+		if slices.Contains(reservedStatusLabels, label) {
+			return nil, fmt.Errorf("%w %q: reserved for execution statuses", ErrInvalidStatusLabel, label)
+		}
+		normalized = append(normalized, label)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > maxStatusLabels {
+		return nil, fmt.Errorf("%w: at most %d labels per migration", ErrInvalidStatusLabel, maxStatusLabels)
+	}
+	return normalized, nil
+}
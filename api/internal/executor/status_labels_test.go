@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestExecutor_SetStatusLabels(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	tracker.MarkApplied("20240101120000_users_postgresql_test")
+	exec := NewExecutor(newMockRegistry(), tracker)
+	ctx := context.Background()
+	stored := func() *state.MigrationListItem {
+		list, _, _ := tracker.GetMigrationList(ctx, nil)
+		return list[0]
+	}
+
+	labels, err := exec.SetStatusLabels(ctx, "20240101120000_users_postgresql_test", []string{" Verified", "needs-backfill", "verified"})
+	if err != nil {
+		t.Fatalf("SetStatusLabels() error = %v", err)
+	}
+	want := []string{"needs-backfill", "verified"}
+	if !reflect.DeepEqual(labels, want) || !reflect.DeepEqual(stored().StatusLabels, want) {
+		t.Errorf("SetStatusLabels() = %v, stored %v, want %v", labels, stored().StatusLabels, want)
+	}
+	if status := stored().LastStatus; status != "applied" {
+		t.Errorf("SetStatusLabels() changed the status to %q", status)
+	}
+
+	tests := []struct {
+		name   string
+		labels []string
+	}{
+		{name: "execution status", labels: []string{"applied"}},
+		{name: "spaces", labels: []string{"needs backfill"}},
+		{name: "empty", labels: []string{""}},
+		{name: "too many", labels: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := exec.SetStatusLabels(ctx, "20240101120000_users_postgresql_test", tt.labels); !errors.Is(err, ErrInvalidStatusLabel) {
+				t.Errorf("SetStatusLabels(%q) error = %v, want ErrInvalidStatusLabel", tt.labels, err)
+			}
+		})
+	}
+
+	if _, err := exec.SetStatusLabels(ctx, "missing", []string{"verified"}); !errors.Is(err, state.ErrMigrationNotFound) {
+		t.Errorf("SetStatusLabels() on an unknown migration error = %v, want ErrMigrationNotFound", err)
+	}
+
+	// An empty list removes every label
+	if labels, err := exec.SetStatusLabels(ctx, "20240101120000_users_postgresql_test", nil); err != nil || len(labels) != 0 || len(stored().StatusLabels) != 0 {
+		t.Errorf("SetStatusLabels(nil) = %v, %v, stored %v", labels, err, stored().StatusLabels)
+	}
+
+	exec.SetStandby(true)
+	if _, err := exec.SetStatusLabels(ctx, "20240101120000_users_postgresql_test", []string{"verified"}); !errors.Is(err, ErrStandby) {
+		t.Errorf("SetStatusLabels() on a standby error = %v, want ErrStandby", err)
+	}
+}
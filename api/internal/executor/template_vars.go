@@ -0,0 +1,254 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// Connection Extra keys controlling template variable handling.
+// They are read from {CONNECTION}_<KEY> environment variables by the config loader.
+const (
+	// ExtraSQLEnvInterpolation enables {{.Env.NAME}} placeholders for the connection ("true" to enable).
+	ExtraSQLEnvInterpolation = "SQL_ENV_INTERPOLATION"
+	// ExtraSQLTemplateStrict makes unknown placeholders and non allow-listed variables fail validation.
+	ExtraSQLTemplateStrict = "SQL_TEMPLATE_STRICT"
+	// ExtraSQLTemplateAllowlist is a comma-separated list of environment variables migrations may reference.
+	ExtraSQLTemplateAllowlist = "SQL_TEMPLATE_ALLOWLIST"
+	// ExtraSQLTemplateRedact is a comma-separated list of variables whose values are redacted in the audit record ("*" redacts all).
+	ExtraSQLTemplateRedact = "SQL_TEMPLATE_REDACT"
+	// ExtraSQLVarPrefix prefixes the connection's template variables: SQL_VAR_NAME defines NAME, referenced
+	// like an environment variable. Connection variables need no interpolation opt-in or allow-listing,
+	// and take precedence over the process environment.
+	ExtraSQLVarPrefix = "SQL_VAR_"
+)
+
+// redactedValue replaces sensitive variable values in execution context audit records
+const redactedValue = "[REDACTED]"
+
+// builtinTemplateVars are the variables always available to migration templates
+var builtinTemplateVars = []string{"Connection", "Schema", "Backend", "Version"}
+
+// sensitiveVarMarkers are always redacted from the audit record, regardless of configuration
+var sensitiveVarMarkers = []string{"PASSWORD", "SECRET", "TOKEN", "CREDENTIAL", "PRIVATE_KEY"}
+
+var (
+	envPrefixPattern = regexp.MustCompile(`(\{\{-?\s*)\.(?i:env)\.`)
+	envCallPattern   = regexp.MustCompile(`(\{\{-?\s*)\.(?i:env)\s+"([A-Za-z_][A-Za-z0-9_]*)"`)
+	envRefPattern    = regexp.MustCompile(`\{\{-?\s*\.Env\.([A-Za-z_][A-Za-z0-9_]*)\s*-?\}\}`)
+	actionPattern    = regexp.MustCompile(`\{\{-?\s*(.*?)\s*-?\}\}`)
+	strictBuiltin    = regexp.MustCompile(`^\.(Connection|Schema|Backend|Version)$`)
+	strictEnvRef     = regexp.MustCompile(`^\.Env\.([A-Za-z_][A-Za-z0-9_]*)$`)
+)
+
+// lookupEnv resolves environment variables for {{.Env.NAME}} placeholders (overridable in tests)
+var lookupEnv = os.LookupEnv
+
+// templateVarPolicy controls which variables a connection's migrations may reference
+type templateVarPolicy struct {
+	EnvEnabled bool
+	Strict     bool
+	Allowed    map[string]bool
+	Redacted   map[string]bool
+	RedactAll  bool
+	Vars       map[string]string // Connection variables (SQL_VAR_*), by name
+}
+
+// templatePolicyFromConnection builds the template policy from a connection's Extra settings.
+// Environment interpolation is disabled unless explicitly enabled.
+func templatePolicyFromConnection(cfg *backends.ConnectionConfig) *templateVarPolicy {
+	policy := &templateVarPolicy{
+		Allowed:  make(map[string]bool),
+		Redacted: make(map[string]bool),
+		Vars:     make(map[string]string),
+	}
+	if cfg == nil {
+		return policy
+	}
+
+	for key, value := range cfg.Extra {
+		if len(key) > len(ExtraSQLVarPrefix) && strings.EqualFold(key[:len(ExtraSQLVarPrefix)], ExtraSQLVarPrefix) {
+			policy.Vars[key[len(ExtraSQLVarPrefix):]] = value
+		}
+	}
+
+	policy.EnvEnabled = isTruthy(cfg.ExtraValue(ExtraSQLEnvInterpolation))
+	policy.Strict = isTruthy(cfg.ExtraValue(ExtraSQLTemplateStrict))
+	for _, name := range splitList(cfg.ExtraValue(ExtraSQLTemplateAllowlist)) {
+		policy.Allowed[name] = true
+	}
+	for _, name := range splitList(cfg.ExtraValue(ExtraSQLTemplateRedact)) {
+		if name == "*" {
+			policy.RedactAll = true
+			continue
+		}
+		policy.Redacted[name] = true
+	}
+	return policy
+}
+
+// auditEnabled reports whether resolved values should be recorded for executions
+func (p *templateVarPolicy) auditEnabled() bool {
+	return p != nil && (p.EnvEnabled || p.Strict || len(p.Vars) > 0)
+}
+
+// redact returns the value to store in the audit record for a variable
+func (p *templateVarPolicy) redact(name, value string) string {
+	if p == nil {
+		p = &templateVarPolicy{}
+	}
+	if p.RedactAll || p.Redacted[name] {
+		return redactedValue
+	}
+	upper := strings.ToUpper(name)
+	for _, marker := range sensitiveVarMarkers {
+		if strings.Contains(upper, marker) {
+			return redactedValue
+		}
+	}
+	return value
+}
+
+// validate checks the placeholders in content against the policy without rendering it
+func (p *templateVarPolicy) validate(content string) error {
+	if p == nil {
+		p = &templateVarPolicy{}
+	}
+
+	for _, ref := range envRefPattern.FindAllStringSubmatch(content, -1) {
+		name := ref[1]
+		if _, ok := p.Vars[name]; ok {
+			continue
+		}
+		if !p.EnvEnabled {
+			return fmt.Errorf("environment variable interpolation is disabled for this connection (set %s=true to enable, or define %s%s)", ExtraSQLEnvInterpolation, ExtraSQLVarPrefix, name)
+		}
+		if (p.Strict || len(p.Allowed) > 0) && !p.Allowed[name] {
+			return fmt.Errorf("environment variable %q is not in the allow-list", name)
+		}
+	}
+
+	if !p.Strict {
+		return nil
+	}
+	var unknown []string
+	for _, action := range actionPattern.FindAllStringSubmatch(content, -1) {
+		body := action[1]
+		if strictBuiltin.MatchString(body) || strictEnvRef.MatchString(body) {
+			continue
+		}
+		unknown = append(unknown, "{{"+body+"}}")
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown template placeholders in strict mode: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// renderTemplate replaces template variables in SQL/JSON content according to policy.
+// Variables: {{.Connection}}, {{.Schema}}, {{.Backend}}, {{.Version}} and {{.Env.NAME}} (also written
+// {{.Env "NAME"}}), resolved from the connection's variables, then, when enabled, the environment
+// Note: Built-in variable names are case-insensitive (e.g., {{.connection}} == {{.Connection}})
+// It returns the rendered content and the resolved variables (redacted) for auditing.
+func renderTemplate(content string, migration *backends.MigrationScript, schema string, policy *templateVarPolicy) (string, map[string]string, error) {
+	// Determine schema to use
+	schemaToUse := schema
+	if schemaToUse == "" {
+		schemaToUse = migration.Schema
+	}
+
+	// Normalize template variables to canonical case (first letter uppercase, rest lowercase)
+	// This makes the template variables case-insensitive
+	normalizedContent := normalizeTemplateVariables(content)
+	normalizedContent = envPrefixPattern.ReplaceAllString(normalizedContent, "${1}.Env.")
+	normalizedContent = envCallPattern.ReplaceAllString(normalizedContent, "${1}.Env.${2}")
+
+	if err := policy.validate(normalizedContent); err != nil {
+		return "", nil, err
+	}
+
+	// Create template data (using canonical case)
+	data := map[string]interface{}{
+		"Connection": migration.Connection,
+		"Schema":     schemaToUse,
+		"Backend":    migration.Backend,
+		"Version":    migration.Version,
+	}
+	resolved := make(map[string]string)
+	for _, name := range builtinTemplateVars {
+		resolved[name] = data[name].(string)
+	}
+
+	env := make(map[string]string)
+	for _, ref := range envRefPattern.FindAllStringSubmatch(normalizedContent, -1) {
+		name := ref[1]
+		value, ok := policy.lookup(name)
+		if !ok && policy != nil && policy.Strict {
+			return "", nil, fmt.Errorf("environment variable %q referenced by migration is not set", name)
+		}
+		env[name] = value
+		resolved["Env."+name] = policy.redact(name, value)
+	}
+	data["Env"] = env
+
+	// Parse template
+	tmpl, err := template.New("migration").Parse(normalizedContent)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if policy != nil && policy.Strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+
+	// Execute template
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.String(), resolved, nil
+}
+
+// lookup resolves a variable from the connection's variables, then the environment
+func (p *templateVarPolicy) lookup(name string) (string, bool) {
+	if p != nil {
+		if value, ok := p.Vars[name]; ok {
+			return value, true
+		}
+	}
+	return lookupEnv(name)
+}
+
+// mergeTemplateAudit copies resolved variables into audit, keeping the first value seen per key
+func mergeTemplateAudit(audit, resolved map[string]string) {
+	for k, v := range resolved {
+		if _, exists := audit[k]; !exists {
+			audit[k] = v
+		}
+	}
+}
+
+// isTruthy reports whether a configuration value enables a feature
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// splitList splits a comma-separated configuration value, dropping empty entries
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func withLookupEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	original := lookupEnv
+	lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	t.Cleanup(func() { lookupEnv = original })
+}
+
+func TestRenderTemplate_EnvDisabledByDefault(t *testing.T) {
+	migration := &backends.MigrationScript{Connection: "core", Backend: "postgresql", Version: "20240101120000"}
+	policy := templatePolicyFromConnection(&backends.ConnectionConfig{Extra: map[string]string{}})
+
+	_, _, err := renderTemplate("GRANT SELECT ON t TO {{.Env.APP_ROLE}};", migration, "public", policy)
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("expected interpolation disabled error, got %v", err)
+	}
+}
+
+func TestRenderTemplate_EnvEnabled(t *testing.T) {
+	withLookupEnv(t, map[string]string{"APP_ROLE": "app_rw"})
+	migration := &backends.MigrationScript{Connection: "core", Backend: "postgresql", Version: "20240101120000"}
+	policy := templatePolicyFromConnection(&backends.ConnectionConfig{Extra: map[string]string{
+		"SQL_ENV_INTERPOLATION": "true",
+	}})
+
+	out, resolved, err := renderTemplate("GRANT SELECT ON {{.schema}}.t TO {{.env.APP_ROLE}};", migration, "tenant_a", policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "GRANT SELECT ON tenant_a.t TO app_rw;" {
+		t.Errorf("unexpected output %q", out)
+	}
+	if resolved["Env.APP_ROLE"] != "app_rw" || resolved["Schema"] != "tenant_a" {
+		t.Errorf("unexpected resolved variables %v", resolved)
+	}
+}
+
+func TestRenderTemplate_StrictMode(t *testing.T) {
+	withLookupEnv(t, map[string]string{"APP_ROLE": "app_rw", "APP_PASSWORD": "s3cret"})
+	migration := &backends.MigrationScript{Connection: "core", Backend: "postgresql", Version: "20240101120000"}
+	policy := templatePolicyFromConnection(&backends.ConnectionConfig{Extra: map[string]string{
+		"SQL_ENV_INTERPOLATION":  "true",
+		"SQL_TEMPLATE_STRICT":    "true",
+		"SQL_TEMPLATE_ALLOWLIST": "APP_ROLE,APP_PASSWORD,MISSING",
+		"SQL_TEMPLATE_REDACT":    "APP_ROLE",
+	}})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown placeholder", content: "SELECT {{.Tenant}};", wantErr: "unknown template placeholders"},
+		{name: "not allow-listed", content: "SELECT '{{.Env.HOME}}';", wantErr: "not in the allow-list"},
+		{name: "unset variable", content: "SELECT '{{.Env.MISSING}}';", wantErr: "is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := renderTemplate(tt.content, migration, "", policy)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	_, resolved, err := renderTemplate("ALTER ROLE {{.Env.APP_ROLE}} PASSWORD '{{.Env.APP_PASSWORD}}';", migration, "", policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved["Env.APP_ROLE"] != redactedValue || resolved["Env.APP_PASSWORD"] != redactedValue {
+		t.Errorf("expected redacted values, got %v", resolved)
+	}
+}
+
+func TestRenderTemplate_ConnectionVars(t *testing.T) {
+	withLookupEnv(t, map[string]string{"TENANT_PREFIX": "from_env_", "APP_ROLE": "app_rw"})
+	migration := &backends.MigrationScript{Connection: "core", Backend: "postgresql", Version: "20240101120000"}
+	policy := templatePolicyFromConnection(&backends.ConnectionConfig{Extra: map[string]string{
+		"SQL_VAR_TENANT_PREFIX": "acme_",
+	}})
+
+	out, resolved, err := renderTemplate(`CREATE SCHEMA {{ .Env "TENANT_PREFIX" }}{{ .schema }};`, migration, "tenant_a", policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "CREATE SCHEMA acme_tenant_a;" {
+		t.Errorf("unexpected output %q", out)
+	}
+	if resolved["Env.TENANT_PREFIX"] != "acme_" || !policy.auditEnabled() {
+		t.Errorf("expected the connection variable to be audited, got %v", resolved)
+	}
+
+	// Connection variables don't enable the rest of the environment
+	if _, _, err := renderTemplate("GRANT SELECT ON t TO {{.Env.APP_ROLE}};", migration, "", policy); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("expected interpolation disabled error, got %v", err)
+	}
+}
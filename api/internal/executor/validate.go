@@ -0,0 +1,418 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/backends/greptimedb"
+	"github.com/toolsascode/bfm/api/internal/declarative"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// ValidationIssue describes a problem found while validating an SFM tree
+type ValidationIssue struct {
+	Path    string
+	Message string
+}
+
+// String formats the issue as "path: message"
+func (i ValidationIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// sfmScriptRe matches {version}_{name}.{up|down}.{sql|json|yaml}
+var sfmScriptRe = regexp.MustCompile(`^(.+?)_(.+)\.(up|down)\.(sql|json|yaml)$`)
+
+// sfmVersionRe matches the 14-digit version format (YYYYMMDDHHMMSS)
+var sfmVersionRe = regexp.MustCompile(`^\d{14}$`)
+
+// concurrentlyRe matches PostgreSQL index operations that cannot run inside a transaction
+var concurrentlyRe = regexp.MustCompile(`(?i)\bINDEX\s+CONCURRENTLY\b`)
+
+// sfmEntry collects the files that make up one migration during validation
+type sfmEntry struct {
+	backend    string
+	connection string
+	version    string
+	name       string
+	dir        string
+	upFile     string
+	downFile   string
+}
+
+// ValidateSFM walks an SFM directory ({sfm_path}/{backend}/{connection}/{version}_{name}.{up|down}.{sql|json})
+// and reports problems that would otherwise only surface at load or execution time:
+// missing down files, invalid versions, duplicate versions within a connection,
+// unresolvable dependencies and scripts that fail a basic parse.
+func ValidateSFM(sfmPath string) ([]ValidationIssue, error) {
+	info, err := os.Stat(sfmPath)
+	if err != nil {
+		return nil, fmt.Errorf("SFM path does not exist: %s", sfmPath)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("SFM path is not a directory: %s", sfmPath)
+	}
+
+	var issues []ValidationIssue
+	entries := make(map[string]*sfmEntry)
+
+	err = filepath.Walk(sfmPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		m := sfmScriptRe.FindStringSubmatch(info.Name())
+		if m == nil {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sfmPath, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(relPath, string(filepath.Separator))
+		if len(parts) != 3 {
+			issues = append(issues, ValidationIssue{Path: path, Message: "migration script must be located at {backend}/{connection}/{file}"})
+			return nil
+		}
+
+		version, name, direction, ext := m[1], m[2], m[3], m[4]
+		if !validMigrationVersion(version) {
+			issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf("invalid version %q (expected 14-digit YYYYMMDDHHMMSS timestamp)", version)})
+			return nil
+		}
+
+		backend := parts[0]
+		if "."+ext == backends.DeclarativeExtension {
+			if !backends.SupportsDeclarative(backend) {
+				issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf("backend %s does not support desired-state (.yaml) migrations", backend)})
+			}
+		} else if expected := backends.ScriptExtension(backend); "."+ext != expected {
+			issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf("backend %s expects %s scripts", backend, expected)})
+		}
+
+		key := strings.Join([]string{backend, parts[1], version, name}, "/")
+		entry, ok := entries[key]
+		if !ok {
+			entry = &sfmEntry{
+				backend:    backend,
+				connection: parts[1],
+				version:    version,
+				name:       name,
+				dir:        filepath.Dir(path),
+			}
+			entries[key] = entry
+		}
+		if direction == "up" {
+			entry.upFile = path
+		} else {
+			entry.downFile = path
+		}
+
+		issues = append(issues, validateScriptContent(path, backend, ext, direction)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk SFM directory: %w", err)
+	}
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Pairing and duplicate version checks
+	versionsByConnection := make(map[string][]*sfmEntry)
+	for _, k := range keys {
+		entry := entries[k]
+		switch {
+		case entry.upFile == "":
+			issues = append(issues, ValidationIssue{Path: entry.downFile, Message: "down file has no matching up file"})
+		case entry.downFile == "" && !upFileIsReversible(entry.upFile, entry.backend, filepath.Ext(entry.upFile)):
+			issues = append(issues, ValidationIssue{Path: entry.upFile, Message: "up file has no matching down file"})
+		}
+		connKey := entry.backend + "/" + entry.connection + "/" + entry.version
+		versionsByConnection[connKey] = append(versionsByConnection[connKey], entry)
+	}
+	connKeys := make([]string, 0, len(versionsByConnection))
+	for k := range versionsByConnection {
+		connKeys = append(connKeys, k)
+	}
+	sort.Strings(connKeys)
+	for _, k := range connKeys {
+		dups := versionsByConnection[k]
+		if len(dups) < 2 {
+			continue
+		}
+		names := make([]string, len(dups))
+		for i, d := range dups {
+			names[i] = d.name
+		}
+		issues = append(issues, ValidationIssue{
+			Path:    dups[0].dir,
+			Message: fmt.Sprintf("duplicate version %s in connection %s: %s", dups[0].version, dups[0].connection, strings.Join(names, ", ")),
+		})
+	}
+
+	issues = append(issues, validateSFMDependencies(entries, keys)...)
+	return issues, nil
+}
+
+// validateSFMDependencies checks that dependencies declared in generated .go files resolve
+// against the migrations in the tree, and that they do not form a cycle.
+func validateSFMDependencies(entries map[string]*sfmEntry, keys []string) []ValidationIssue {
+	var issues []ValidationIssue
+
+	reg := registry.NewInMemoryRegistry()
+	migrations := make([]*backends.MigrationScript, 0, len(keys))
+	goFiles := make(map[*backends.MigrationScript]string)
+	for _, k := range keys {
+		entry := entries[k]
+		if entry.upFile == "" {
+			continue
+		}
+		goFile := filepath.Join(entry.dir, fmt.Sprintf("%s_%s.go", entry.version, entry.name))
+		migration := &backends.MigrationScript{
+			Schema:                 extractSchemaFromGoFile(goFile),
+			Version:                entry.version,
+			Name:                   entry.name,
+			Connection:             entry.connection,
+			Backend:                entry.backend,
+			Dependencies:           extractDependenciesFromGoFile(goFile),
+			StructuredDependencies: extractStructuredDependenciesFromGoFile(goFile),
+		}
+		if err := reg.Register(migration); err != nil {
+			// Version conflicts are already reported as duplicate versions
+			var conflict *registry.ConflictError
+			if !errors.As(err, &conflict) || conflict.Kind != registry.ConflictVersion {
+				issues = append(issues, ValidationIssue{Path: entry.upFile, Message: err.Error()})
+			}
+			continue
+		}
+		migrations = append(migrations, migration)
+		goFiles[migration] = goFile
+	}
+
+	getID := func(m *backends.MigrationScript) string {
+		return fmt.Sprintf("%s_%s_%s_%s", m.Version, m.Name, m.Backend, m.Connection)
+	}
+	graph := registry.NewDependencyGraph()
+	for _, migration := range migrations {
+		graph.AddNode(migration, getID(migration))
+	}
+
+	resolver := registry.NewDependencyResolver(reg, nil)
+	for _, migration := range migrations {
+		var targets []*backends.MigrationScript
+		for _, dep := range migration.StructuredDependencies {
+			found, err := resolver.ResolveDependencyTargets(dep)
+			if err != nil && dep.Schema != "" {
+				// A schema-qualified dependency may point at a dynamic-schema migration
+				// that is applied per schema at execution time.
+				dynamicDep := dep
+				dynamicDep.Schema = ""
+				if dynFound, dynErr := resolver.ResolveDependencyTargets(dynamicDep); dynErr == nil && hasDynamicSchemaTarget(dynFound) {
+					found, err = dynFound, nil
+				}
+			}
+			if err != nil {
+				issues = append(issues, ValidationIssue{Path: goFiles[migration], Message: err.Error()})
+				continue
+			}
+			targets = append(targets, found...)
+		}
+		for _, depName := range migration.Dependencies {
+			found := reg.GetMigrationByName(depName)
+			if len(found) == 0 {
+				issues = append(issues, ValidationIssue{Path: goFiles[migration], Message: fmt.Sprintf("dependency '%s' not found", depName)})
+				continue
+			}
+			targets = append(targets, found...)
+		}
+		for _, target := range targets {
+			if from, to := getID(migration), getID(target); from != to {
+				graph.AddEdge(from, to)
+			}
+		}
+	}
+
+	if _, err := graph.DetectCycles(); err != nil {
+		issues = append(issues, ValidationIssue{Message: err.Error()})
+	}
+
+	return issues
+}
+
+// hasDynamicSchemaTarget reports whether any of the targets has an empty (dynamic) schema
+func hasDynamicSchemaTarget(targets []*backends.MigrationScript) bool {
+	for _, t := range targets {
+		if t.Schema == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateScriptContent performs a basic parse of a migration script of backend
+func validateScriptContent(path, backend, ext, direction string) []ValidationIssue {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []ValidationIssue{{Path: path, Message: fmt.Sprintf("failed to read file: %v", err)}}
+	}
+	body := string(content)
+
+	var issues []ValidationIssue
+	if direction == "up" && strings.TrimSpace(stripSQLComments(body)) == "" {
+		issues = append(issues, ValidationIssue{Path: path, Message: "up script is empty"})
+	}
+	if direction == "up" {
+		if _, err := parseBFMTagsFromUpSQL(body); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Message: err.Error()})
+		}
+		if _, err := parseBFMTableFromUpSQL(body); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Message: err.Error()})
+		}
+		if _, err := parseBFMTimeoutFromUpSQL(body); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Message: err.Error()})
+		}
+	}
+
+	if "."+ext == backends.DeclarativeExtension {
+		if _, err := declarative.Parse(body); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Message: err.Error()})
+		}
+		return issues
+	}
+	if ext == "json" {
+		// Leading "-- bfm-tags:" and "-- bfm-table:" lines are allowed in JSON scripts
+		jsonBody := stripSQLComments(body)
+		if strings.TrimSpace(jsonBody) != "" && !json.Valid([]byte(jsonBody)) {
+			issues = append(issues, ValidationIssue{Path: path, Message: "invalid JSON"})
+		}
+		return issues
+	}
+	if err := checkSQLSyntax(body); err != nil {
+		issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf("SQL parse error: %v", err)})
+	}
+	if backends.IsTransactional(body) && concurrentlyRe.MatchString(stripSQLComments(body)) {
+		issues = append(issues, ValidationIssue{Path: path, Message: "CONCURRENTLY cannot run inside a transaction; add a \"-- bfm:no-transaction\" line"})
+	}
+	if backend == "greptimedb" {
+		if err := greptimedb.ValidateDDL(body); err != nil {
+			issues = append(issues, ValidationIssue{Path: path, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// checkSQLSyntax performs a lexical sanity check: string literals, quoted identifiers,
+// dollar-quoted bodies and block comments must be terminated and parentheses balanced.
+// It is not a full SQL parser; it catches the truncation and typo classes of errors
+// that otherwise fail at execution time.
+func checkSQLSyntax(sql string) error {
+	depth := 0
+	line := 1
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			line++
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			start := line
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("unterminated block comment starting on line %d", start)
+			}
+			line += strings.Count(sql[i:i+2+end], "\n")
+			i += end + 3
+		case c == '\'' || c == '"':
+			start := line
+			closed := false
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\n' {
+					line++
+				}
+				if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+						continue
+					}
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return fmt.Errorf("unterminated quote (%c) starting on line %d", c, start)
+			}
+		case c == '$':
+			tag := dollarQuoteTag(sql[i:])
+			if tag == "" {
+				continue
+			}
+			start := line
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				return fmt.Errorf("unterminated dollar-quoted string %s starting on line %d", tag, start)
+			}
+			line += strings.Count(sql[i:i+len(tag)+end], "\n")
+			i += len(tag) + end + len(tag) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unexpected ')' on line %d", line)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%d unclosed '('", depth)
+	}
+	return nil
+}
+
+var dollarTagRe = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// dollarQuoteTag returns the opening dollar-quote tag ($$ or $tag$) at the start of s, if any
+func dollarQuoteTag(s string) string {
+	return dollarTagRe.FindString(s)
+}
+
+// blockCommentRe and lineCommentRe match the /* */ and -- comments stripSQLComments removes
+var (
+	blockCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	lineCommentRe  = regexp.MustCompile(`(?m)--.*$`)
+)
+
+// stripSQLComments removes -- line comments and /* */ block comments
+func stripSQLComments(sql string) string {
+	return lineCommentRe.ReplaceAllString(blockCommentRe.ReplaceAllString(sql, ""), "")
+}
+
+// validMigrationVersion reports whether version is a 14-digit timestamp with valid date fields
+func validMigrationVersion(version string) bool {
+	if !sfmVersionRe.MatchString(version) {
+		return false
+	}
+	_, err := time.Parse("20060102150405", version)
+	return err == nil
+}
@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSFMFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestValidateSFM_Clean(t *testing.T) {
+	root := t.TempDir()
+	writeSFMFile(t, root, "postgresql/core/20250101120000_init.up.sql", "CREATE TABLE {{.Schema}}.t (id INT, note TEXT DEFAULT 'a;b');\n")
+	writeSFMFile(t, root, "postgresql/core/20250101120000_init.down.sql", "DROP TABLE {{.Schema}}.t;\n")
+	writeSFMFile(t, root, "etcd/meta/20250101120000_flags.up.json", `[{"operation":"put","key":"/a","value":"b"}]`)
+	writeSFMFile(t, root, "etcd/meta/20250101120000_flags.down.json", `[{"operation":"delete","key":"/a"}]`)
+	// No down file, but one can be generated
+	writeSFMFile(t, root, "postgresql/core/20250103120000_orders.up.sql", "CREATE TABLE {{.Schema}}.orders (id INT);\n")
+	writeSFMFile(t, root, "postgresql/core/20250102120000_roles.up.yaml", "roles:\n  - name: app\n    login: true\n")
+	writeSFMFile(t, root, "postgresql/core/20250102120000_roles.down.yaml", "roles:\n  - name: app\n    absent: true\n")
+
+	issues, err := ValidateSFM(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateSFM_ReportsIssues(t *testing.T) {
+	root := t.TempDir()
+	// Missing down file that cannot be generated
+	writeSFMFile(t, root, "postgresql/core/20250101120000_init.up.sql", "CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);")
+	// Duplicate version in the same connection
+	writeSFMFile(t, root, "postgresql/core/20250101120000_other.up.sql", "CREATE TABLE o (id INT);")
+	writeSFMFile(t, root, "postgresql/core/20250101120000_other.down.sql", "DROP TABLE o;")
+	// Invalid version (month 13)
+	writeSFMFile(t, root, "postgresql/core/20251301120000_bad.up.sql", "SELECT 1;")
+	// SQL that fails a basic parse
+	writeSFMFile(t, root, "postgresql/core/20250102120000_broken.up.sql", "CREATE TABLE b (id INT;")
+	writeSFMFile(t, root, "postgresql/core/20250102120000_broken.down.sql", "DROP TABLE b;")
+	// CONCURRENTLY without the no-transaction directive
+	writeSFMFile(t, root, "postgresql/core/20250102130000_idx.up.sql", "CREATE INDEX CONCURRENTLY idx_b ON b (id);")
+	writeSFMFile(t, root, "postgresql/core/20250102130000_idx.down.sql", "-- bfm:no-transaction\nDROP INDEX CONCURRENTLY idx_b;")
+	// Unresolvable dependency declared in the generated .go file
+	writeSFMFile(t, root, "postgresql/core/20250103120000_dep.up.sql", "SELECT 1;")
+	writeSFMFile(t, root, "postgresql/core/20250103120000_dep.down.sql", "SELECT 1;")
+	// Invalid desired-state document, and one on a backend without desired-state support
+	writeSFMFile(t, root, "postgresql/core/20250104120000_roles.up.yaml", "roles:\n  - name: app\n  - name: app\n")
+	writeSFMFile(t, root, "postgresql/core/20250104120000_roles.down.yaml", "roles: []\n")
+	writeSFMFile(t, root, "etcd/meta/20250104120000_roles.up.yaml", "roles: []\n")
+	writeSFMFile(t, root, "etcd/meta/20250104120000_roles.down.yaml", "roles: []\n")
+	// A bfm-table line naming more than one table
+	writeSFMFile(t, root, "postgresql/core/20250105120000_tables.up.sql", "-- bfm-table: a, b\nCREATE TABLE a (id INT);")
+	writeSFMFile(t, root, "postgresql/core/20250105120000_tables.down.sql", "DROP TABLE a;")
+	// A GreptimeDB table without a time index
+	writeSFMFile(t, root, "greptimedb/metrics/20250106120000_cpu.up.sql", "CREATE TABLE cpu (host STRING, usage DOUBLE, PRIMARY KEY (host));")
+	writeSFMFile(t, root, "greptimedb/metrics/20250106120000_cpu.down.sql", "DROP TABLE cpu;")
+	writeSFMFile(t, root, "postgresql/core/20250103120000_dep.go", `package core
+var m = migrations.MigrationScript{
+	Dependencies: []string{ "does_not_exist" },
+}
+`)
+
+	issues, err := ValidateSFM(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var all []string
+	for _, issue := range issues {
+		all = append(all, issue.String())
+	}
+	joined := strings.Join(all, "\n")
+	for _, want := range []string{
+		"up file has no matching down file",
+		"duplicate version 20250101120000 in connection core",
+		"invalid version \"20251301120000\"",
+		"SQL parse error",
+		"dependency 'does_not_exist' not found",
+		"role app is declared more than once",
+		"backend etcd does not support desired-state (.yaml) migrations",
+		"CONCURRENTLY cannot run inside a transaction",
+		"invalid bfm-table \"a, b\"",
+		"table cpu: missing TIME INDEX",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected issue containing %q, got:\n%s", want, joined)
+		}
+	}
+}
+
+func TestCheckSQLSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr bool
+	}{
+		{name: "valid", sql: "SELECT (1 + 2);", wantErr: false},
+		{name: "dollar quoted function", sql: "CREATE FUNCTION f() RETURNS int AS $body$ SELECT ')'; $body$ LANGUAGE sql;", wantErr: false},
+		{name: "positional params", sql: "SELECT $1, $2;", wantErr: false},
+		{name: "unterminated string", sql: "SELECT 'abc;", wantErr: true},
+		{name: "unterminated block comment", sql: "/* SELECT 1;", wantErr: true},
+		{name: "unbalanced parens", sql: "SELECT (1;", wantErr: true},
+		{name: "stray closing paren", sql: "SELECT 1);", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSQLSyntax(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSQLSyntax() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
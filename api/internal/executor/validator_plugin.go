@@ -0,0 +1,35 @@
+package executor
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// ValidatorPluginSymbol is the symbol a validator plugin exports: a variable of type Validator,
+// or a value implementing it
+const ValidatorPluginSymbol = "Validator"
+
+// LoadValidatorPlugin opens a Go plugin (go build -buildmode=plugin) and returns the Validator it
+// exports. The plugin must be built with the same Go version and module versions as the server.
+func LoadValidatorPlugin(path string) (Validator, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open validator plugin %s: %w", path, err)
+	}
+	symbol, err := p.Lookup(ValidatorPluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("validator plugin %s: %w", path, err)
+	}
+
+	switch v := symbol.(type) {
+	case *Validator:
+		if *v == nil {
+			return nil, fmt.Errorf("validator plugin %s: %s is nil", path, ValidatorPluginSymbol)
+		}
+		return *v, nil
+	case Validator:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("validator plugin %s: %s is a %T, not a Validator", path, ValidatorPluginSymbol, symbol)
+	}
+}
@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// Validator is a custom check run on each migration before it is applied, such as naming
+// conventions, forbidden schemas or company-specific rules. Validators are registered with
+// RegisterValidator, at compile time or from Go plugins (see LoadValidatorPlugin). Their findings
+// are reported in plans, and error findings refuse executions with ErrValidationFailed.
+type Validator interface {
+	// Name identifies the validator in findings
+	Name() string
+
+	// Validate checks a migration that would be applied to schema (the migration's own schema
+	// unless the execution targets other schemas) and returns its findings, none when it passes
+	Validate(ctx context.Context, migration *backends.MigrationScript, schema string) []ValidatorFinding
+}
+
+// Validator finding severities
+const (
+	FindingError   = "error"   // Refuses the execution
+	FindingWarning = "warning" // Reported in plans and logs only
+)
+
+// ValidatorFinding is a problem a Validator found in a migration
+type ValidatorFinding struct {
+	Validator string // Name of the validator; set by the executor
+	Severity  string // FindingError or FindingWarning
+	Message   string
+}
+
+// ErrValidationFailed is returned when a registered validator reports an error for a migration an
+// execution would apply
+var ErrValidationFailed = errors.New("migrations failed validation")
+
+// RegisterValidator adds a validator run on every migration before it is applied
+func (e *Executor) RegisterValidator(validator Validator) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.validators = append(e.validators, validator)
+}
+
+// registeredValidators returns the registered validators
+func (e *Executor) registeredValidators() []Validator {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.validators
+}
+
+// validateMigration runs the registered validators on a migration, and checks it against its
+// connection's destructive policy. A validator that panics is reported as an error finding rather
+// than taking the server down.
+func (e *Executor) validateMigration(ctx context.Context, migration *backends.MigrationScript, schemaName string) []ValidatorFinding {
+	schema := schemaName
+	if schema == "" {
+		schema = migration.Schema
+	}
+	var findings []ValidatorFinding
+	for _, validator := range e.registeredValidators() {
+		findings = append(findings, runValidator(ctx, validator, migration, schema)...)
+	}
+	return append(findings, e.checkDestructivePolicy(ctx, migration, schemaName)...)
+}
+
+func runValidator(ctx context.Context, validator Validator, migration *backends.MigrationScript, schema string) (findings []ValidatorFinding) {
+	name := validator.Name()
+	defer func() {
+		if r := recover(); r != nil {
+			findings = []ValidatorFinding{{Validator: name, Severity: FindingError, Message: fmt.Sprintf("validator panicked: %v", r)}}
+		}
+	}()
+
+	for _, finding := range validator.Validate(ctx, migration, schema) {
+		finding.Validator = name
+		if finding.Severity != FindingWarning {
+			finding.Severity = FindingError
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// verifyValidators runs the validators on migrations, with their pending dependencies unless
+// ignoreDependencies, for every schema before any of them runs, so a multi-schema execution is
+// refused as a whole rather than after its first schemas were applied
+func (e *Executor) verifyValidators(ctx context.Context, migrations []*backends.MigrationScript, schemas []string, ignoreDependencies bool) error {
+	if !e.validates() || len(migrations) == 0 {
+		return nil
+	}
+	if !ignoreDependencies {
+		var err error
+		migrations, _, _, err = e.expandWithPendingDependencies(ctx, migrations)
+		if err != nil {
+			return fmt.Errorf("failed to expand migrations with dependencies: %w", err)
+		}
+	}
+	for _, schema := range schemas {
+		if err := e.checkValidators(ctx, migrations, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValidators runs the validators on the migrations an execution would apply. Migrations that
+// are already applied are skipped by the execution and not checked. Warnings are logged; errors
+// refuse the execution, listed as "{migration}: {validator}: {message}", except in emergency runs,
+// which log them too.
+func (e *Executor) checkValidators(ctx context.Context, migrations []*backends.MigrationScript, schemaName string) error {
+	if !e.validates() {
+		return nil
+	}
+
+	var failures []string
+	for _, migration := range migrations {
+		migrationID := e.executionMigrationID(migration, schemaName)
+		if migrationID == "" {
+			continue
+		}
+		applied, err := e.isUpToDate(ctx, migration, migrationID, schemaName)
+		if err != nil {
+			return fmt.Errorf("failed to check migration status for %s: %w", migrationID, err)
+		}
+		if applied {
+			continue
+		}
+
+		for _, finding := range e.validateMigration(ctx, migration, schemaName) {
+			if finding.Severity == FindingWarning {
+				logger.Warnf("Validator %s: %s: %s", finding.Validator, migrationID, finding.Message)
+				continue
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s: %s", migrationID, finding.Validator, finding.Message))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Strings(failures)
+	if isEmergency(ctx) {
+		logger.Warnf("Emergency run ignores failed validation: %s", strings.Join(failures, "; "))
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrValidationFailed, strings.Join(failures, "; "))
+}
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// testValidator reports the findings returned by check
+type testValidator struct {
+	name  string
+	check func(migration *backends.MigrationScript, schema string) []ValidatorFinding
+}
+
+func (v *testValidator) Name() string { return v.name }
+
+func (v *testValidator) Validate(_ context.Context, migration *backends.MigrationScript, schema string) []ValidatorFinding {
+	return v.check(migration, schema)
+}
+
+// forbidSchema reports an error for migrations applied to schema
+func forbidSchema(schema string) *testValidator {
+	return &testValidator{name: "forbidden-schemas", check: func(_ *backends.MigrationScript, s string) []ValidatorFinding {
+		if s == schema {
+			return []ValidatorFinding{{Severity: FindingError, Message: "schema " + s + " is forbidden"}}
+		}
+		return nil
+	}}
+}
+
+func TestExecutor_ValidatorErrorRefusesExecution(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	exec.RegisterValidator(forbidSchema("public"))
+	target := &registry.MigrationTarget{Connection: "test"}
+
+	_, err := exec.ExecuteSync(context.Background(), target, "test", "", false, false)
+	if !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected ErrValidationFailed, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "forbidden-schemas: schema public is forbidden") {
+		t.Errorf("expected the finding in the error, got %v", err)
+	}
+	if len(tracker.History()) != 0 {
+		t.Errorf("expected nothing to be applied, got %v", tracker.History())
+	}
+
+	// Schemas are all checked before any of them runs
+	result, err := exec.ExecuteUp(context.Background(), target, "test", []string{"tenant_a", "public"}, false, false)
+	if !errors.Is(err, ErrValidationFailed) {
+		t.Fatalf("expected ErrValidationFailed, got %v (result %+v)", err, result)
+	}
+	if len(tracker.History()) != 0 {
+		t.Errorf("expected no schema to be applied, got %v", tracker.History())
+	}
+
+	if _, err := exec.ExecuteUp(context.Background(), target, "test", []string{"tenant_a"}, false, false); err != nil {
+		t.Errorf("expected other schemas to pass, got %v", err)
+	}
+}
+
+func TestExecutor_ValidatorWarningDoesNotBlock(t *testing.T) {
+	exec, tracker := newLockTestExecutor(t)
+	exec.RegisterValidator(&testValidator{name: "naming", check: func(*backends.MigrationScript, string) []ValidatorFinding {
+		return []ValidatorFinding{{Severity: FindingWarning, Message: "name should start with a verb"}}
+	}})
+
+	if _, err := exec.ExecuteSync(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false); err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if len(tracker.History()) == 0 {
+		t.Error("expected the migration to be applied")
+	}
+}
+
+func TestExecutor_ValidatorPanicIsAnError(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	exec.RegisterValidator(&testValidator{name: "broken", check: func(*backends.MigrationScript, string) []ValidatorFinding {
+		panic("boom")
+	}})
+
+	_, err := exec.ExecuteSync(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false)
+	if !errors.Is(err, ErrValidationFailed) || !strings.Contains(err.Error(), "validator panicked: boom") {
+		t.Errorf("expected the panic to fail validation, got %v", err)
+	}
+}
+
+func TestExecutor_Plan_ReportsValidatorFindings(t *testing.T) {
+	exec, _ := newLockTestExecutor(t)
+	exec.RegisterValidator(forbidSchema("public"))
+	exec.RegisterValidator(&testValidator{name: "naming", check: func(*backends.MigrationScript, string) []ValidatorFinding {
+		return []ValidatorFinding{{Severity: FindingWarning, Message: "name should start with a verb"}}
+	}})
+
+	plan, err := exec.Plan(context.Background(), nil, "test", nil, false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Steps) != 1 || len(plan.Steps[0].Findings) != 2 {
+		t.Fatalf("expected one step with two findings, got %+v", plan.Steps)
+	}
+	if finding := plan.Steps[0].Findings[0]; finding.Validator != "forbidden-schemas" || finding.Severity != FindingError {
+		t.Errorf("unexpected first finding %+v", finding)
+	}
+	if len(plan.Errors) != 1 {
+		t.Errorf("expected the error finding in plan errors, got %v", plan.Errors)
+	}
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/metrics"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+)
+
+// Verification sidecars of a migration, next to its scripts: {version}_{name}.verify.sql runs on
+// the connection and fails by raising an error, {version}_{name}.verify.json holds assertions
+// (see backends.ParseVerifyAssertions)
+const (
+	VerifySQLExtension  = ".verify.sql"
+	VerifyJSONExtension = ".verify.json"
+)
+
+// ExtraVerifyRollback makes every migration of a connection whose verification fails run its down
+// script ({CONNECTION}_VERIFY_ROLLBACK=true), as if its .verify.json set rollback
+const ExtraVerifyRollback = "VERIFY_ROLLBACK"
+
+// ErrVerificationFailed is returned when a migration ran but its verification failed
+var ErrVerificationFailed = errors.New("migration verification failed")
+
+// readVerifySidecars returns the verification of the migration {baseName} in dir, or none when it
+// has no sidecar
+func readVerifySidecars(dir, baseName string) (backends.Verify, error) {
+	var verify backends.Verify
+	data, err := os.ReadFile(filepath.Join(dir, baseName+VerifyJSONExtension))
+	if err == nil {
+		if verify, err = backends.ParseVerifyAssertions(data); err != nil {
+			return backends.Verify{}, fmt.Errorf("%s: %w", baseName+VerifyJSONExtension, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return backends.Verify{}, err
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, baseName+VerifySQLExtension))
+	if err == nil {
+		verify.SQL = string(data)
+	} else if !os.IsNotExist(err) {
+		return backends.Verify{}, err
+	}
+	return verify, nil
+}
+
+// verifyMigration checks the verification of migration once its up script ran in schema: the
+// assertions against the schema read from backend, which must be connected to cfg and able to
+// inspect it, then the verification script. The error wraps ErrVerificationFailed and lists every
+// failing assertion.
+func verifyMigration(ctx context.Context, backend backends.Backend, cfg *backends.ConnectionConfig, migration *backends.MigrationScript, migrationID, schema string) error {
+	verify := migration.Verify
+	if verify.IsZero() {
+		return nil
+	}
+	logger.Infof("Verifying %s", migrationID)
+
+	if len(verify.Assertions) > 0 {
+		inspector, ok := backend.(schemadiff.Inspector)
+		if !ok {
+			return fmt.Errorf("%w: backend %s cannot check assertions", ErrVerificationFailed, migration.Backend)
+		}
+		assertions := make([]*schemadiff.Assertion, 0, len(verify.Assertions))
+		schemas := []string{schema}
+		for _, text := range verify.Assertions {
+			assertion, err := schemadiff.ParseAssertion(text)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+			}
+			assertions = append(assertions, assertion)
+			if key := assertion.Key(schema); !strings.HasPrefix(key, schema+".") {
+				schemas = append(schemas, strings.SplitN(key, ".", 2)[0])
+			}
+		}
+		inspected, err := inspector.InspectSchema(ctx, schemas)
+		if err != nil {
+			return fmt.Errorf("%w: failed to inspect the schema: %v", ErrVerificationFailed, err)
+		}
+		var failed []string
+		for _, assertion := range assertions {
+			if err := assertion.Check(inspected, schema); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(failed, "; "))
+		}
+	}
+
+	if verify.SQL != "" {
+		if err := runSQLHook(ctx, verify.SQL, backend, cfg, migration, "verify", schema); err != nil {
+			return fmt.Errorf("%w: verify script: %v", ErrVerificationFailed, err)
+		}
+	}
+	return nil
+}
+
+// verifyRollback reports whether a migration whose verification failed runs its down script
+func verifyRollback(cfg *backends.ConnectionConfig, migration *backends.MigrationScript) bool {
+	return migration.Verify.Rollback || isTruthy(cfg.ExtraValue(ExtraVerifyRollback))
+}
+
+// rollbackUnverified runs the down script of a migration whose verification failed, so the
+// database is left as it was before the migration. backendMigration is the migration as executed,
+// with its rendered scripts.
+func rollbackUnverified(ctx context.Context, backend backends.Backend, migration, backendMigration *backends.MigrationScript, migrationID string) error {
+	if !migration.HasDown() {
+		return fmt.Errorf("%s has no down script", migrationID)
+	}
+	logger.Infof("Verification of %s failed, running its down script", migrationID)
+	return executeOnBackend(ctx, metrics.DirectionDown, backend, &backends.MigrationScript{
+		Schema:        backendMigration.Schema,
+		Version:       migration.Version,
+		Name:          migration.Name + "_rollback",
+		Connection:    migration.Connection,
+		Backend:       migration.Backend,
+		UpSQL:         backendMigration.DownSQL,
+		DownSQL:       backendMigration.UpSQL,
+		Declarative:   migration.Declarative,
+		Transactional: backends.IsTransactional(backendMigration.DownSQL),
+		Reverts:       true,
+		UpFunc:        migration.DownFunc,
+		DownFunc:      migration.UpFunc,
+		Timeout:       backendMigration.Timeout,
+	})
+}
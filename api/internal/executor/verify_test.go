@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/schemadiff"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// verifyingBackend records the scripts it executes and inspects a fixed schema
+type verifyingBackend struct {
+	*hookRecordingBackend
+	schema *schemadiff.Schema
+}
+
+func (b *verifyingBackend) InspectSchema(ctx context.Context, schemas []string) (*schemadiff.Schema, error) {
+	return b.schema, nil
+}
+
+func TestExecutor_Verify(t *testing.T) {
+	users := &schemadiff.Table{Schema: "public", Name: "users", Columns: []*schemadiff.Column{{Name: "email", Type: "text"}}}
+	run := func(verify backends.Verify, extra map[string]string) (*ExecuteResult, *verifyingBackend, *testsupport.StateTracker) {
+		reg := newMockRegistry()
+		tracker := testsupport.NewStateTracker()
+		exec := NewExecutor(reg, tracker)
+		_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"test": {Backend: "postgresql", Extra: extra}})
+		backend := &verifyingBackend{
+			hookRecordingBackend: &hookRecordingBackend{mockBackend: newMockBackend("postgresql"), failOn: "RAISE"},
+			schema:               schemadiff.NewSchema(users),
+		}
+		exec.RegisterBackend("postgresql", backend)
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: "20240101120000", Name: "add_email", Connection: "test", Backend: "postgresql",
+			UpSQL: "ALTER TABLE users ADD COLUMN email TEXT NOT NULL;", DownSQL: "ALTER TABLE users DROP COLUMN email;",
+			Verify: verify,
+		})
+		result, err := exec.ExecuteSync(context.Background(), &registry.MigrationTarget{Connection: "test"}, "test", "", false, false)
+		if err != nil {
+			t.Fatalf("ExecuteSync failed: %v", err)
+		}
+		return result, backend, tracker
+	}
+
+	result, backend, _ := run(backends.Verify{Assertions: []string{"table users has column email"}, SQL: "SELECT 1;"}, nil)
+	if !result.Success || len(result.Applied) != 1 || len(backend.executed) != 2 || backend.executed[1] != "SELECT 1;" {
+		t.Fatalf("expected a passing verification, got %+v, executed %q", result, backend.executed)
+	}
+
+	// The column is nullable: the migration fails and stays in place
+	result, backend, tracker := run(backends.Verify{Assertions: []string{"table users has column email NOT NULL"}}, nil)
+	if result.Success || len(result.Applied) != 0 || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "column email is nullable") {
+		t.Fatalf("expected the verification to fail the migration, got %+v", result)
+	}
+	if last := lastRecord(tracker); last.Status != "failed" || !strings.Contains(last.ErrorMessage, ErrVerificationFailed.Error()) {
+		t.Errorf("expected the migration to be recorded as failed, got %+v", last)
+	}
+	if len(backend.executed) != 1 {
+		t.Errorf("expected no rollback, executed %q", backend.executed)
+	}
+
+	// Rollback, asked by the verification or by the connection, runs the down script
+	for _, tc := range []struct {
+		verify backends.Verify
+		extra  map[string]string
+	}{
+		{backends.Verify{SQL: "DO $$ BEGIN RAISE EXCEPTION 'no'; END $$;", Rollback: true}, nil},
+		{backends.Verify{SQL: "DO $$ BEGIN RAISE EXCEPTION 'no'; END $$;"}, map[string]string{ExtraVerifyRollback: "true"}},
+	} {
+		result, backend, _ = run(tc.verify, tc.extra)
+		if result.Success || !strings.Contains(strings.Join(result.Errors, "\n"), "(rolled back)") {
+			t.Errorf("expected the migration to fail and be rolled back, got %+v", result)
+		}
+		if n := len(backend.executed); n != 3 || backend.executed[2] != "ALTER TABLE users DROP COLUMN email;" {
+			t.Errorf("expected the down script to run after the verification, executed %q", backend.executed)
+		}
+	}
+}
+
+func TestReadVerifySidecars(t *testing.T) {
+	dir := t.TempDir()
+	if verify, err := readVerifySidecars(dir, "20240101120000_add_email"); err != nil || !verify.IsZero() {
+		t.Fatalf("expected no verification without sidecars, got %+v, %v", verify, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "20240101120000_add_email"+VerifyJSONExtension), []byte(`{"assert": ["table users has column email NOT NULL"], "rollback": true}`), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "20240101120000_add_email"+VerifySQLExtension), []byte("SELECT 1;"), 0o600)
+	verify, err := readVerifySidecars(dir, "20240101120000_add_email")
+	if err != nil || len(verify.Assertions) != 1 || !verify.Rollback || verify.SQL != "SELECT 1;" {
+		t.Fatalf("unexpected verification %+v, %v", verify, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "20240101120000_add_email"+VerifyJSONExtension), []byte(`["table users has email"]`), 0o600)
+	if _, err := readVerifySidecars(dir, "20240101120000_add_email"); err == nil || !strings.Contains(err.Error(), "assertion 1") {
+		t.Errorf("expected an invalid assertion to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package export writes migration lists and execution history as CSV, for the spreadsheets
+// used in change reviews.
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// ErrUnknownColumn is returned when a selected column does not exist
+var ErrUnknownColumn = errors.New("unknown column")
+
+// column is a CSV column and how to read its value from a row
+type column[T any] struct {
+	name  string
+	value func(T) string
+}
+
+// listColumns are the columns of a migration list export, in their default order
+var listColumns = []column[*state.MigrationListItem]{
+	{"migration_id", func(m *state.MigrationListItem) string { return m.MigrationID }},
+	{"schema", func(m *state.MigrationListItem) string { return m.Schema }},
+	{"table", func(m *state.MigrationListItem) string { return m.Table }},
+	{"version", func(m *state.MigrationListItem) string { return m.Version }},
+	{"name", func(m *state.MigrationListItem) string { return m.Name }},
+	{"connection", func(m *state.MigrationListItem) string { return m.Connection }},
+	{"backend", func(m *state.MigrationListItem) string { return m.Backend }},
+	{"status", func(m *state.MigrationListItem) string { return m.LastStatus }},
+	{"applied", func(m *state.MigrationListItem) string { return strconv.FormatBool(m.Applied) }},
+	{"applied_at", func(m *state.MigrationListItem) string { return m.LastAppliedAt }},
+	{"error_message", func(m *state.MigrationListItem) string { return m.LastErrorMessage }},
+	{"checksum", func(m *state.MigrationListItem) string { return m.Checksum }},
+	{"status_labels", func(m *state.MigrationListItem) string { return strings.Join(m.StatusLabels, ";") }},
+}
+
+// historyColumns are the columns of a migration history export, in their default order
+var historyColumns = []column[*state.MigrationRecord]{
+	{"migration_id", func(r *state.MigrationRecord) string { return r.MigrationID }},
+	{"schema", func(r *state.MigrationRecord) string { return r.Schema }},
+	{"table", func(r *state.MigrationRecord) string { return r.Table }},
+	{"version", func(r *state.MigrationRecord) string { return r.Version }},
+	{"connection", func(r *state.MigrationRecord) string { return r.Connection }},
+	{"backend", func(r *state.MigrationRecord) string { return r.Backend }},
+	{"status", func(r *state.MigrationRecord) string { return r.Status }},
+	{"operation", func(r *state.MigrationRecord) string { return r.HistoryOperation() }},
+	{"applied_at", func(r *state.MigrationRecord) string { return r.AppliedAt }},
+	{"executed_by", func(r *state.MigrationRecord) string { return r.ExecutedBy }},
+	{"execution_method", func(r *state.MigrationRecord) string { return r.ExecutionMethod }},
+	{"error_message", func(r *state.MigrationRecord) string { return r.ErrorMessage }},
+	{"error_class", func(r *state.MigrationRecord) string { return r.ErrorClass }},
+	{"checksum", func(r *state.MigrationRecord) string { return r.Checksum }},
+}
+
+// ListColumns returns the columns of a migration list export
+func ListColumns() []string {
+	return columnNames(listColumns)
+}
+
+// HistoryColumns returns the columns of a migration history export
+func HistoryColumns() []string {
+	return columnNames(historyColumns)
+}
+
+// ParseColumns splits a comma-separated column selection, e.g. "migration_id, status".
+// An empty selection returns nil, which selects every column.
+func ParseColumns(raw string) []string {
+	var columns []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// WriteMigrationList writes items as CSV with the selected columns, or every column when none are selected
+func WriteMigrationList(w io.Writer, items []*state.MigrationListItem, columns []string) error {
+	return writeCSV(w, items, listColumns, columns)
+}
+
+// WriteHistory writes records as CSV with the selected columns, or every column when none are selected
+func WriteHistory(w io.Writer, records []*state.MigrationRecord, columns []string) error {
+	return writeCSV(w, records, historyColumns, columns)
+}
+
+func columnNames[T any](all []column[T]) []string {
+	names := make([]string, 0, len(all))
+	for _, col := range all {
+		names = append(names, col.name)
+	}
+	return names
+}
+
+// selectColumns returns the columns named in selected, in that order
+func selectColumns[T any](all []column[T], selected []string) ([]column[T], error) {
+	if len(selected) == 0 {
+		return all, nil
+	}
+	columns := make([]column[T], 0, len(selected))
+	for _, name := range selected {
+		found := false
+		for _, col := range all {
+			if col.name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownColumn, name, strings.Join(columnNames(all), ", "))
+		}
+	}
+	return columns, nil
+}
+
+func writeCSV[T any](w io.Writer, rows []T, all []column[T], selected []string) error {
+	columns, err := selectColumns(all, selected)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = cell(col.value(row))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell keeps spreadsheets from evaluating a value as a formula. Error messages and labels come
+// from migration scripts and users, so a value starting with a formula character is prefixed
+// with a quote.
+func cell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// TenantStatusRow is a row of a tenant status report: a schema, its counts of applied, failed and
+// pending migrations, and the status of each migration
+type TenantStatusRow struct {
+	Schema   string
+	Applied  int
+	Failed   int
+	Pending  int
+	Statuses []string // In the order of the migration IDs of the report; "" for a migration that never ran
+}
+
+// WriteTenantStatus writes a tenant status report as CSV: a column per migration after the counts,
+// and a row per schema
+func WriteTenantStatus(w io.Writer, migrationIDs []string, rows []TenantStatusRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"schema", "applied", "failed", "pending"}, migrationIDs...)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{cell(row.Schema), strconv.Itoa(row.Applied), strconv.Itoa(row.Failed), strconv.Itoa(row.Pending)}
+		for _, status := range row.Statuses {
+			record = append(record, cell(status))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+func TestWriteMigrationList(t *testing.T) {
+	items := []*state.MigrationListItem{
+		{MigrationID: "a", Version: "1", LastStatus: "success", Applied: true, StatusLabels: []string{"cab-approved", "verified"}},
+		{MigrationID: "b", Version: "2", LastStatus: "failed", LastErrorMessage: "syntax error, near \"x\""},
+	}
+
+	var b strings.Builder
+	if err := WriteMigrationList(&b, items, nil); err != nil {
+		t.Fatalf("WriteMigrationList() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(ListColumns(), ",") {
+		t.Fatalf("WriteMigrationList() = %q", b.String())
+	}
+
+	b.Reset()
+	if err := WriteMigrationList(&b, items, []string{"status_labels", "migration_id", "applied", "error_message"}); err != nil {
+		t.Fatalf("WriteMigrationList() error = %v", err)
+	}
+	want := "status_labels,migration_id,applied,error_message\n" +
+		"cab-approved;verified,a,true,\n" +
+		",b,false,\"syntax error, near \"\"x\"\"\"\n"
+	if b.String() != want {
+		t.Errorf("WriteMigrationList() = %q, want %q", b.String(), want)
+	}
+
+	if err := WriteMigrationList(&b, items, []string{"owner"}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("WriteMigrationList() error = %v, want ErrUnknownColumn", err)
+	}
+}
+
+func TestWriteHistory(t *testing.T) {
+	records := []*state.MigrationRecord{
+		{MigrationID: "a", Status: "success", ExecutedBy: "alice", ExecutionMethod: "cli"},
+	}
+
+	var b strings.Builder
+	if err := WriteHistory(&b, records, ParseColumns(" migration_id, executed_by ,,execution_method")); err != nil {
+		t.Fatalf("WriteHistory() error = %v", err)
+	}
+	if want := "migration_id,executed_by,execution_method\na,alice,cli\n"; b.String() != want {
+		t.Errorf("WriteHistory() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteTenantStatus(t *testing.T) {
+	rows := []TenantStatusRow{
+		{Schema: "tenant_a", Applied: 2, Statuses: []string{"applied", "applied"}},
+		{Schema: "tenant_b", Applied: 1, Failed: 1, Statuses: []string{"applied", "failed"}},
+		{Schema: "tenant_c", Pending: 2, Statuses: []string{"", ""}},
+	}
+
+	var b strings.Builder
+	if err := WriteTenantStatus(&b, []string{"m1", "m2"}, rows); err != nil {
+		t.Fatalf("WriteTenantStatus() error = %v", err)
+	}
+	want := "schema,applied,failed,pending,m1,m2\n" +
+		"tenant_a,2,0,0,applied,applied\n" +
+		"tenant_b,1,1,0,applied,failed\n" +
+		"tenant_c,0,0,2,,\n"
+	if b.String() != want {
+		t.Errorf("WriteTenantStatus() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestCell(t *testing.T) {
+	tests := map[string]string{
+		"":               "",
+		"applied":        "applied",
+		"=HYPERLINK(1)":  "'=HYPERLINK(1)",
+		"+1":             "'+1",
+		"-1":             "'-1",
+		"@SUM(A1)":       "'@SUM(A1)",
+		"\tindented":     "'\tindented",
+		"20240101120000": "20240101120000",
+	}
+	for value, want := range tests {
+		if got := cell(value); got != want {
+			t.Errorf("cell(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package features gates experimental behaviors behind flags, so they can ship dark and be enabled
+// per environment (BFM_FEATURES) without separate builds. Trusted callers may also turn flags on
+// or off for a single request; those overrides travel with the request context, and with the jobs
+// it queues.
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/auth"
+)
+
+// Flag names an experimental behavior
+type Flag string
+
+const (
+	// Declarative allows desired-state migrations ({name}.up.yaml)
+	Declarative Flag = "declarative"
+	// DeepDryRun makes dry runs rehearse each script against the database in a transaction that
+	// is rolled back, on backends that support it, instead of only listing the migrations
+	DeepDryRun Flag = "deep_dry_run"
+)
+
+// defaults is the state of each flag when neither the environment nor the request sets it.
+// Experimental behaviors are off; declarative migrations shipped before flags and stay on.
+var defaults = map[Flag]bool{
+	Declarative: true,
+	DeepDryRun:  false,
+}
+
+// Set maps flags to whether they are enabled
+type Set map[Flag]bool
+
+// Header is the request header trusted callers set to override flags for the request, in the
+// format of Parse
+const Header = "X-BFM-Features"
+
+// ErrOverrideForbidden is returned for a request overriding flags with a role below the override
+// role
+var ErrOverrideForbidden = errors.New("feature flag overrides are not allowed for this role")
+
+var (
+	mu           sync.RWMutex
+	configured   = Set{}
+	overrideRole = auth.RoleAdmin
+)
+
+// Flags returns the known flags, sorted
+func Flags() []Flag {
+	flags := make([]Flag, 0, len(defaults))
+	for flag := range defaults {
+		flags = append(flags, flag)
+	}
+	slices.Sort(flags)
+	return flags
+}
+
+// Parse parses a comma-separated list of flags: "name" enables a flag, "-name" disables it.
+// Unknown flags are an error.
+func Parse(list string) (Set, error) {
+	set := Set{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, disable := strings.CutPrefix(entry, "-")
+		flag := Flag(strings.ToLower(name))
+		if _, ok := defaults[flag]; !ok {
+			return nil, fmt.Errorf("unknown feature flag %q (known: %s)", name, strings.Join(flagNames(), ", "))
+		}
+		set[flag] = !disable
+	}
+	return set, nil
+}
+
+func flagNames() []string {
+	var names []string
+	for _, flag := range Flags() {
+		names = append(names, string(flag))
+	}
+	return names
+}
+
+// String formats the set the way Parse reads it
+func (s Set) String() string {
+	var entries []string
+	for _, flag := range Flags() {
+		enabled, ok := s[flag]
+		if !ok {
+			continue
+		}
+		if enabled {
+			entries = append(entries, string(flag))
+		} else {
+			entries = append(entries, "-"+string(flag))
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+// Configure sets the flags of the environment (BFM_FEATURES), applied when a request does not
+// override them
+func Configure(set Set) {
+	mu.Lock()
+	defer mu.Unlock()
+	configured = Set{}
+	for flag, enabled := range set {
+		configured[flag] = enabled
+	}
+}
+
+// SetOverrideRole sets the least role allowed to override flags per request (default admin)
+func SetOverrideRole(role auth.Role) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrideRole = role
+}
+
+// Override returns ctx with the overrides of a request's Header value, when identity may override
+// flags. An empty value leaves ctx unchanged.
+func Override(ctx context.Context, identity *auth.Identity, header string) (context.Context, error) {
+	if strings.TrimSpace(header) == "" {
+		return ctx, nil
+	}
+	mu.RLock()
+	role := overrideRole
+	mu.RUnlock()
+	if identity == nil || !identity.Role.Allows(role) {
+		return nil, ErrOverrideForbidden
+	}
+	set, err := Parse(header)
+	if err != nil {
+		return nil, err
+	}
+	return WithOverrides(ctx, set), nil
+}
+
+type overridesKey struct{}
+
+// WithOverrides returns a context where the flags of set override the ones of the environment, on
+// top of any overrides ctx already carries
+func WithOverrides(ctx context.Context, set Set) context.Context {
+	if len(set) == 0 {
+		return ctx
+	}
+	merged := Set{}
+	for flag, enabled := range Overrides(ctx) {
+		merged[flag] = enabled
+	}
+	for flag, enabled := range set {
+		merged[flag] = enabled
+	}
+	return context.WithValue(ctx, overridesKey{}, merged)
+}
+
+// Overrides returns the flags set on ctx with WithOverrides
+func Overrides(ctx context.Context) Set {
+	set, _ := ctx.Value(overridesKey{}).(Set)
+	return set
+}
+
+// Enabled reports whether flag is enabled for ctx: as overridden by the request, else as
+// configured for the environment, else by default
+func Enabled(ctx context.Context, flag Flag) bool {
+	if enabled, ok := Overrides(ctx)[flag]; ok {
+		return enabled
+	}
+	mu.RLock()
+	enabled, ok := configured[flag]
+	mu.RUnlock()
+	if ok {
+		return enabled
+	}
+	return defaults[flag]
+}
+
+// EnabledFlags returns the flags enabled for ctx, sorted
+func EnabledFlags(ctx context.Context) []Flag {
+	var enabled []Flag
+	for _, flag := range Flags() {
+		if Enabled(ctx, flag) {
+			enabled = append(enabled, flag)
+		}
+	}
+	return enabled
+}
+
+// metadataKey is the job metadata key of the request's overrides
+const metadataKey = "features"
+
+// Inject stores the overrides of ctx in job metadata, so the worker running the job applies them
+func Inject(ctx context.Context, metadata map[string]interface{}) {
+	if set := Overrides(ctx); len(set) > 0 {
+		metadata[metadataKey] = set.String()
+	}
+}
+
+// Extract returns ctx with the overrides stored in job metadata by Inject. Flags the worker does
+// not know are ignored.
+func Extract(ctx context.Context, metadata map[string]interface{}) context.Context {
+	list, _ := metadata[metadataKey].(string)
+	set := Set{}
+	for _, entry := range strings.Split(list, ",") {
+		if parsed, err := Parse(entry); err == nil {
+			for flag, enabled := range parsed {
+				set[flag] = enabled
+			}
+		}
+	}
+	return WithOverrides(ctx, set)
+}
@@ -0,0 +1,103 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/auth"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    Set
+		wantErr bool
+	}{
+		{"empty", "", Set{}, false},
+		{"enable", "deep_dry_run", Set{DeepDryRun: true}, false},
+		{"disable", "-declarative", Set{Declarative: false}, false},
+		{"list", " Deep_Dry_Run , -declarative,", Set{DeepDryRun: true, Declarative: false}, false},
+		{"unknown", "canary", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want.String() {
+				t.Errorf("Parse(%q) = %q, want %q", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	ctx := context.Background()
+
+	if !Enabled(ctx, Declarative) || Enabled(ctx, DeepDryRun) {
+		t.Fatalf("unexpected defaults: %v", EnabledFlags(ctx))
+	}
+
+	Configure(Set{DeepDryRun: true, Declarative: false})
+	if Enabled(ctx, Declarative) || !Enabled(ctx, DeepDryRun) {
+		t.Errorf("configured flags not applied: %v", EnabledFlags(ctx))
+	}
+
+	ctx = WithOverrides(ctx, Set{Declarative: true})
+	ctx = WithOverrides(ctx, Set{DeepDryRun: false})
+	if !Enabled(ctx, Declarative) || Enabled(ctx, DeepDryRun) {
+		t.Errorf("request overrides not applied: %v", EnabledFlags(ctx))
+	}
+}
+
+func TestOverride(t *testing.T) {
+	t.Cleanup(func() { SetOverrideRole(auth.RoleAdmin) })
+	ctx := context.Background()
+	admin := &auth.Identity{Role: auth.RoleAdmin}
+	operator := &auth.Identity{Role: auth.RoleOperator}
+
+	if got, err := Override(ctx, operator, ""); err != nil || got != ctx {
+		t.Errorf("expected an empty header to leave the context unchanged, got error %v", err)
+	}
+	if _, err := Override(ctx, operator, "deep_dry_run"); !errors.Is(err, ErrOverrideForbidden) {
+		t.Errorf("expected operators to be forbidden by default, got %v", err)
+	}
+	if _, err := Override(ctx, nil, "deep_dry_run"); !errors.Is(err, ErrOverrideForbidden) {
+		t.Errorf("expected anonymous requests to be forbidden, got %v", err)
+	}
+	if _, err := Override(ctx, admin, "canary"); err == nil || errors.Is(err, ErrOverrideForbidden) {
+		t.Errorf("expected an unknown flag error, got %v", err)
+	}
+
+	got, err := Override(ctx, admin, "deep_dry_run")
+	if err != nil || !Enabled(got, DeepDryRun) {
+		t.Errorf("expected admins to enable deep_dry_run, got error %v", err)
+	}
+
+	SetOverrideRole(auth.RoleOperator)
+	if _, err := Override(ctx, operator, "deep_dry_run"); err != nil {
+		t.Errorf("expected operators to override with BFM_FEATURES_OVERRIDE_ROLE=operator, got %v", err)
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	metadata := map[string]interface{}{}
+	Inject(context.Background(), metadata)
+	if _, ok := metadata[metadataKey]; ok {
+		t.Fatalf("expected no metadata without overrides, got %v", metadata)
+	}
+
+	ctx := WithOverrides(context.Background(), Set{DeepDryRun: true, Declarative: false})
+	Inject(ctx, metadata)
+
+	// A worker running an older or newer build ignores the flags it does not know
+	metadata[metadataKey] = metadata[metadataKey].(string) + ",canary"
+	got := Overrides(Extract(context.Background(), metadata))
+	if got.String() != "-declarative,deep_dry_run" {
+		t.Errorf("Extract() = %q, want %q", got, "-declarative,deep_dry_run")
+	}
+}
@@ -0,0 +1,170 @@
+// Package i18n is the catalog of user-facing messages: HTTP API errors and CLI output. Messages
+// are fmt formats identified by the IDs in messages.go, with one catalog per locale under
+// locales/. A message missing from a locale falls back to English, so a partial translation never
+// hides a message. Logs are not translated.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// English is the reference locale: every message ID has an English message
+const English = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	// catalogs holds the messages of each locale, by message ID
+	catalogs map[string]map[string]string
+	// locales are the supported locales, English first as the fallback of the matcher
+	locales []string
+	matcher language.Matcher
+
+	mu            sync.RWMutex
+	defaultLocale = English
+)
+
+func init() {
+	var err error
+	catalogs, err = loadCatalogs()
+	if err != nil {
+		panic(err)
+	}
+
+	tags := []language.Tag{language.English}
+	locales = []string{English}
+	for locale := range catalogs {
+		if locale != English {
+			locales = append(locales, locale)
+		}
+	}
+	slices.Sort(locales[1:])
+	for _, locale := range locales[1:] {
+		tags = append(tags, language.MustParse(locale))
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// loadCatalogs reads the embedded catalogs, named after their locale (ja.json)
+func loadCatalogs() (map[string]map[string]string, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", entry.Name(), err)
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	if _, ok := loaded[English]; !ok {
+		return nil, fmt.Errorf("missing %s message catalog", English)
+	}
+	return loaded, nil
+}
+
+// Locales returns the supported locales, English first
+func Locales() []string {
+	return slices.Clone(locales)
+}
+
+// match returns the supported locale closest to the first of tags that has one
+func match(tags ...language.Tag) (string, bool) {
+	if len(tags) == 0 {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return "", false
+	}
+	return locales[index], true
+}
+
+// Normalize returns the supported locale of a locale setting such as "ja", "ja-JP" or the POSIX
+// form "ja_JP.UTF-8"
+func Normalize(locale string) (string, error) {
+	name, _, _ := strings.Cut(strings.TrimSpace(locale), ".")
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err == nil {
+		if supported, ok := match(tag); ok {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported locale %q (supported: %s)", locale, strings.Join(locales, ", "))
+}
+
+// SetDefault sets the locale of messages when the caller expresses no preference: the BFM_LOCALE
+// setting
+func SetDefault(locale string) error {
+	normalized, err := Normalize(locale)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLocale = normalized
+	return nil
+}
+
+// Default returns the locale of messages when the caller expresses no preference
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// Negotiate returns the supported locale best matching an Accept-Language header, or the default
+// locale when the header is empty, invalid or matches no supported locale
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return Default()
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return Default()
+	}
+	if locale, ok := match(tags...); ok {
+		return locale
+	}
+	return Default()
+}
+
+// format returns the message format of id in locale, falling back to English, then to id itself
+func format(locale, id string) string {
+	if message, ok := catalogs[locale][id]; ok {
+		return message
+	}
+	if message, ok := catalogs[English][id]; ok {
+		return message
+	}
+	return id
+}
+
+// T returns the message id in locale, formatted with args
+func T(locale, id string, args ...interface{}) string {
+	if len(args) == 0 {
+		return format(locale, id)
+	}
+	return fmt.Sprintf(format(locale, id), args...)
+}
+
+// Errorf returns an error with the message id in locale, formatted with args. As with fmt.Errorf,
+// an error argument formatted with %w is wrapped.
+func Errorf(locale, id string, args ...interface{}) error {
+	return fmt.Errorf(format(locale, id), args...)
+}
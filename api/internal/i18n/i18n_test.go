@@ -0,0 +1,157 @@
+package i18n
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// verbRe matches fmt verbs, with an optional explicit argument index
+var verbRe = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+// verbs returns the verb applied to each argument of a format
+func verbs(format string) map[int]byte {
+	args := make(map[int]byte)
+	next := 1
+	for _, m := range verbRe.FindAllStringSubmatch(format, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			next, _ = strconv.Atoi(m[1])
+		}
+		args[next] = m[2][0]
+		next++
+	}
+	return args
+}
+
+func TestCatalogs(t *testing.T) {
+	// Every message ID has an English message
+	file, err := parser.ParseFile(token.NewFileSet(), "messages.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			id, _ := strconv.Unquote(lit.Value)
+			if _, ok := catalogs[English][id]; !ok {
+				t.Errorf("message %s has no English message", id)
+			}
+			ids++
+		}
+		return true
+	})
+	if ids != len(catalogs[English]) {
+		t.Errorf("%d message IDs, %d English messages: remove the unused ones", ids, len(catalogs[English]))
+	}
+
+	// Translations take the arguments of the English message, with the same verbs
+	for locale, messages := range catalogs {
+		for id, message := range messages {
+			english, ok := catalogs[English][id]
+			if !ok {
+				t.Errorf("%s: unknown message %s", locale, id)
+				continue
+			}
+			want, got := verbs(english), verbs(message)
+			if len(want) != len(got) {
+				t.Errorf("%s: %s takes %d arguments, want %d", locale, id, len(got), len(want))
+			}
+			for arg, verb := range want {
+				if got[arg] != verb {
+					t.Errorf("%s: %s formats argument %d with %%%c, want %%%c", locale, id, arg, got[arg], verb)
+				}
+			}
+		}
+	}
+
+	entries, _ := fs.ReadDir(localeFiles, "locales")
+	if len(Locales()) != len(entries) || Locales()[0] != English {
+		t.Errorf("Locales() = %v", Locales())
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "en"},
+		{"ja", "ja"},
+		{"ja-JP", "ja"},
+		{"ja_JP.UTF-8", "ja"},
+		{"en_US", "en"},
+		{"fr", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.locale)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.locale, got, err, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Cleanup(func() { _ = SetDefault(English) })
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", English},
+		{"ja", "ja"},
+		{"ja-JP,ja;q=0.9,en-US;q=0.8", "ja"},
+		{"fr-FR,en;q=0.5", English},
+		{"fr-FR", English},
+		{"not a header;;", English},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+
+	// Without a preference the default locale applies, not English
+	if err := SetDefault("ja_JP.UTF-8"); err != nil {
+		t.Fatalf("SetDefault() error = %v", err)
+	}
+	if got := Negotiate(""); got != "ja" {
+		t.Errorf("Negotiate(\"\") with default ja = %q", got)
+	}
+	if got := Negotiate("en"); got != English {
+		t.Errorf("Negotiate(en) with default ja = %q", got)
+	}
+	if err := SetDefault("xx"); err == nil || Default() != "ja" {
+		t.Errorf("SetDefault(xx) = %v, default %q", err, Default())
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("ja", APIInvalidLimit, 1000); got != "limit が不正です: 1 から 1000 の範囲で指定してください" {
+		t.Errorf("T(ja) = %q", got)
+	}
+	if got := T("ja", CLIBaselinedCount, 3, "core", "20240101000000"); got != "core で 20240101000000 までの 3 件のマイグレーションをベースライン化しました" {
+		t.Errorf("T(ja) with indexed arguments = %q", got)
+	}
+	// Unknown locales fall back to English, unknown IDs to the ID
+	if got := T("fr", APIMigrationNotFound); got != "migration not found" {
+		t.Errorf("T(fr) = %q", got)
+	}
+	if got := T(English, "api.unknown"); got != "api.unknown" {
+		t.Errorf("T(unknown ID) = %q", got)
+	}
+
+	cause := errors.New("disk full")
+	err := Errorf("ja", CLIWriteFailed, "up.sql", cause)
+	if !errors.Is(err, cause) || err.Error() != "up.sql に書き込めませんでした: disk full" {
+		t.Errorf("Errorf() = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package i18n
+
+// Message IDs. Every ID has a message in locales/en.json; other locales may leave some out.
+const (
+	// Shared by the HTTP API and the CLI
+	InvalidTime = "invalid_time"
+
+	// HTTP API errors
+	APIRequestBodyUnreadable   = "api.request_body_unreadable"
+	APIMissingAuthorization    = "api.missing_authorization"
+	APIInvalidAuthorization    = "api.invalid_authorization"
+	APIBearerRequired          = "api.bearer_required"
+	APIInvalidToken            = "api.invalid_token"
+	APIForbidden               = "api.forbidden"
+	APIStandby                 = "api.standby"
+	APIStarting                = "api.starting"
+	APIConnectionOrConnections = "api.connection_or_connections"
+	APIIDsAndTarget            = "api.ids_and_target"
+	APIIDsRequireConnection    = "api.ids_require_connection"
+	APIConnectionRequired      = "api.connection_required"
+	APIMigrationNotFound       = "api.migration_not_found"
+	APIInvalidLimit            = "api.invalid_limit"
+	APIInvalidOffset           = "api.invalid_offset"
+	APIInvalidOutcome          = "api.invalid_outcome"
+	APIInvalidJobStatus        = "api.invalid_job_status"
+	APIInvalidSince            = "api.invalid_since"
+	APIUnsupportedFormat       = "api.unsupported_format"
+	APIUnsupportedPlanFormat   = "api.unsupported_plan_format"
+	APIOpenAPISpecUnparsable   = "api.openapi_spec_unparsable"
+	APIFeatureOverrideDenied   = "api.feature_override_denied"
+	APIInvalidFeatureFlags     = "api.invalid_feature_flags"
+	APIUnknownFacet            = "api.unknown_facet"
+	APIScheduleUnsupported     = "api.schedule_unsupported"
+	APIStreamUnsupported       = "api.stream_unsupported"
+	APIUnknownEventType        = "api.unknown_event_type"
+	APIShuttingDown            = "api.shutting_down"
+	APIEmergencyForbidden      = "api.emergency_forbidden"
+	APIEmergencyReasonRequired = "api.emergency_reason_required"
+	APIStateNotEmpty           = "api.state_not_empty"
+	APIReferenceRequired       = "api.reference_required"
+
+	// CLI output and errors
+	CLIError                    = "cli.error"
+	CLIVersion                  = "cli.version"
+	CLISFMPathMissing           = "cli.sfm_path_missing"
+	CLIScanning                 = "cli.scanning"
+	CLIDryRunMode               = "cli.dry_run_mode"
+	CLIBuildCompleted           = "cli.build_completed"
+	CLIFoundFile                = "cli.found_file"
+	CLIInvalidFilename          = "cli.invalid_filename"
+	CLIInvalidDirectory         = "cli.invalid_directory"
+	CLINoMigrationFiles         = "cli.no_migration_files"
+	CLIFoundMigrations          = "cli.found_migrations"
+	CLITemplateParseFailed      = "cli.template_parse_failed"
+	CLIMissingUpFile            = "cli.missing_up_file"
+	CLIInvalidTagsEntry         = "cli.invalid_tags_entry"
+	CLIWouldGenerate            = "cli.would_generate"
+	CLIGenerated                = "cli.generated"
+	CLIGenerateFailed           = "cli.generate_failed"
+	CLIGeneratedCount           = "cli.generated_count"
+	CLIWouldGenerateCount       = "cli.would_generate_count"
+	CLICreateDirectoryFailed    = "cli.create_directory_failed"
+	CLICreateFileFailed         = "cli.create_file_failed"
+	CLIWriteFailed              = "cli.write_failed"
+	CLILoadMigrationsFailed     = "cli.load_migrations_failed"
+	CLIBaselined                = "cli.baselined"
+	CLIBaselineSkipped          = "cli.baseline_skipped"
+	CLIBaselinedCount           = "cli.baselined_count"
+	CLIBenchFlagsNotPositive    = "cli.bench_flags_not_positive"
+	CLIBenchTooManyConns        = "cli.bench_too_many_connections"
+	CLIBenchGenerated           = "cli.bench_generated"
+	CLIRegisterFailed           = "cli.register_failed"
+	CLIFindFailed               = "cli.find_failed"
+	CLIStateInitFailed          = "cli.state_init_failed"
+	CLIReindexFailed            = "cli.reindex_failed"
+	CLIApplyFailed              = "cli.apply_failed"
+	CLIPlanFailed               = "cli.plan_failed"
+	CLIListPendingFailed        = "cli.list_pending_failed"
+	CLICheckFailed              = "cli.check_failed"
+	CLIListFailed               = "cli.list_failed"
+	CLIStateConnectFailed       = "cli.state_connect_failed"
+	CLIStateOpenFailed          = "cli.state_open_failed"
+	CLIEtcdConnectFailed        = "cli.etcd_connect_failed"
+	CLIUnsupportedState         = "cli.unsupported_state_backend"
+	CLIWouldRemove              = "cli.would_remove"
+	CLIRemoved                  = "cli.removed"
+	CLIRedundantCount           = "cli.redundant_count"
+	CLIRemovedCount             = "cli.removed_count"
+	CLIBackendConnRequired      = "cli.backend_connection_required"
+	CLIInvalidMigrationName     = "cli.invalid_migration_name"
+	CLIFileExists               = "cli.file_exists"
+	CLICreated                  = "cli.created"
+	CLIUnknownJSONTemplate      = "cli.unknown_json_template"
+	CLIUnknownTemplate          = "cli.unknown_template"
+	CLIReadTemplateFailed       = "cli.read_template_failed"
+	CLITemplateNameInvalid      = "cli.template_name_invalid"
+	CLIReadDownTemplateFailed   = "cli.read_down_template_failed"
+	CLIUnknownExport            = "cli.unknown_export"
+	CLILabelListOnly            = "cli.label_list_only"
+	CLIHistoryFlagsOnly         = "cli.history_flags_only"
+	CLIReadListFailed           = "cli.read_list_failed"
+	CLIReadHistoryFailed        = "cli.read_history_failed"
+	CLIValidating               = "cli.validating"
+	CLIValidationFailed         = "cli.validation_failed"
+	CLINoIssues                 = "cli.no_issues"
+	CLIConfigInvalid            = "cli.config_invalid"
+	CLIConfigValid              = "cli.config_valid"
+	CLIUnknownPlanOutput        = "cli.unknown_plan_output"
+	CLIConnectionsLoadFailed    = "cli.connections_load_failed"
+	CLIPlanNoChanges            = "cli.plan_no_changes"
+	CLIPlanSummary              = "cli.plan_summary"
+	CLIWaitReady                = "cli.wait_ready"
+	CLIWaitPending              = "cli.wait_pending"
+	CLIWaitUnreachable          = "cli.wait_unreachable"
+	CLIWaitRefused              = "cli.wait_refused"
+	CLIWaitTimedOut             = "cli.wait_timed_out"
+	CLIStateBundleUnreadable    = "cli.state_bundle_unreadable"
+	CLIStateExported            = "cli.state_exported"
+	CLIStateImported            = "cli.state_imported"
+	CLISquashInvalidVersion     = "cli.squash_invalid_version"
+	CLISquashNothing            = "cli.squash_nothing"
+	CLISquashUnsupportedBackend = "cli.squash_unsupported_backend"
+	CLISquashSchemaRequired     = "cli.squash_schema_required"
+	CLISquashDependedOn         = "cli.squash_depended_on"
+	CLISquashNotApplied         = "cli.squash_not_applied"
+	CLISquashAppliedAfter       = "cli.squash_applied_after"
+	CLISquashDumpFailed         = "cli.squash_dump_failed"
+	CLISquashArchiveFailed      = "cli.squash_archive_failed"
+	CLISquashKeptDynamic        = "cli.squash_kept_dynamic"
+	CLISquashWouldArchive       = "cli.squash_would_archive"
+	CLISquashDryRun             = "cli.squash_dry_run"
+	CLISquashed                 = "cli.squashed"
+	CLIDiffFailed               = "cli.diff_failed"
+	CLIDiffNoChanges            = "cli.diff_no_changes"
+	CLIDiffReview               = "cli.diff_review"
+)
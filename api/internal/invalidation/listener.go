@@ -0,0 +1,141 @@
+// Package invalidation refreshes schema-dependent caches after migrations are applied. It listens
+// for runs that applied migrations on the event bus and calls the cache-invalidation endpoints
+// configured for their connection (an application reload endpoint, a CDN purge) with the list of
+// migrations applied.
+package invalidation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// ExtraURLs lists, comma-separated, the endpoints called after a run applies migrations on a
+// connection ({CONNECTION}_CACHE_INVALIDATION_URLS)
+const ExtraURLs = "CACHE_INVALIDATION_URLS"
+
+// ExtraToken is sent as a bearer token to the endpoints of a connection
+// ({CONNECTION}_CACHE_INVALIDATION_TOKEN)
+const ExtraToken = "CACHE_INVALIDATION_TOKEN"
+
+// Timeout bounds each call to an endpoint
+const Timeout = 10 * time.Second
+
+// Notification is the JSON body POSTed to the endpoints
+type Notification struct {
+	Connection string   `json:"connection"`
+	Schema     string   `json:"schema,omitempty"`
+	Applied    []string `json:"applied"` // Migration IDs applied by the run, in order
+	Time       string   `json:"time"`    // RFC 3339
+}
+
+// ConnectionLookup returns the configuration of a connection
+type ConnectionLookup func(ctx context.Context, name string) (*backends.ConnectionConfig, error)
+
+// Listener calls the cache-invalidation endpoints of a connection after a run applies migrations on
+// it. Calls are made in the background, so they never delay or fail the run; failures are logged.
+type Listener struct {
+	connection ConnectionLookup
+	client     *http.Client
+	wg         sync.WaitGroup
+}
+
+// NewListener creates a listener reading the endpoints of each connection through connection
+func NewListener(connection ConnectionLookup) *Listener {
+	return &Listener{
+		connection: connection,
+		client:     &http.Client{Timeout: Timeout},
+	}
+}
+
+// Subscribe starts listening to the runs published on bus and returns a function that stops it
+func (l *Listener) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return bus.Subscribe(events.RunCompleted, l.handle)
+}
+
+// Wait blocks until the calls started so far have finished
+func (l *Listener) Wait() {
+	l.wg.Wait()
+}
+
+// handle hands a completed run off to a call per endpoint of its connection
+func (l *Listener) handle(ctx context.Context, event events.Event) {
+	applied, _ := event.Data["applied"].([]string)
+	if len(applied) == 0 {
+		return
+	}
+	cfg, err := l.connection(ctx, event.Connection)
+	if err != nil || cfg == nil {
+		return
+	}
+	urls := endpoints(cfg)
+	if len(urls) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(Notification{
+		Connection: event.Connection,
+		Schema:     event.Schema,
+		Applied:    applied,
+		Time:       event.Time.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		logger.Errorf("Failed to encode cache invalidation for %s: %v", event.Connection, err)
+		return
+	}
+	token := cfg.Extra[ExtraToken]
+	// The run's context ends with its request; the calls outlive it
+	ctx = context.WithoutCancel(ctx)
+	for _, url := range urls {
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			if err := l.call(ctx, url, token, body); err != nil {
+				logger.Warnf("Cache invalidation %s for %s failed: %v", url, event.Connection, err)
+				return
+			}
+			logger.Infof("Cache invalidation %s called for %d migration(s) on %s", url, len(applied), event.Connection)
+		}()
+	}
+}
+
+// call POSTs a notification to an endpoint; any status other than 2xx is an error
+func (l *Listener) call(ctx context.Context, url, token string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// endpoints returns the cache-invalidation URLs configured for a connection
+func endpoints(cfg *backends.ConnectionConfig) []string {
+	var urls []string
+	for _, url := range strings.Split(cfg.Extra[ExtraURLs], ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
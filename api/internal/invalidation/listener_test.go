@@ -0,0 +1,68 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+)
+
+func TestListener_CallsConnectionEndpoints(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+	var authorization string
+	reload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		received = append(received, notification)
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+	}))
+	defer reload.Close()
+	purge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer purge.Close()
+
+	connections := map[string]*backends.ConnectionConfig{
+		"core":    {Extra: map[string]string{ExtraURLs: reload.URL + ", " + purge.URL, ExtraToken: "secret"}},
+		"metrics": {Extra: map[string]string{}},
+	}
+	listener := NewListener(func(_ context.Context, name string) (*backends.ConnectionConfig, error) {
+		if cfg, ok := connections[name]; ok {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("connection %s not found", name)
+	})
+	bus := events.NewBus()
+	listener.Subscribe(bus)
+
+	ctx := context.Background()
+	applied := []string{"20240101120000_create_users_postgresql_core"}
+	bus.Publish(ctx, events.Event{Type: events.RunCompleted, Connection: "core", Schema: "tenant_a", Data: map[string]interface{}{"applied": applied}})
+	bus.Publish(ctx, events.Event{Type: events.RunCompleted, Connection: "metrics", Data: map[string]interface{}{"applied": applied}})
+	bus.Publish(ctx, events.Event{Type: events.RunCompleted, Connection: "unknown", Data: map[string]interface{}{"applied": applied}})
+	bus.Publish(ctx, events.Event{Type: events.MigrationApplied, Connection: "core", MigrationID: applied[0]})
+	listener.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("expected one notification, got %+v", received)
+	}
+	if got := received[0]; got.Connection != "core" || got.Schema != "tenant_a" || len(got.Applied) != 1 || got.Applied[0] != applied[0] || got.Time == "" {
+		t.Errorf("unexpected notification %+v", got)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the connection's token", authorization)
+	}
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+)
+
+// Subscribe records the execution events of bus (applied, failed and rolled back migrations) as
+// migration metrics, so every execution path is measured without calling into this package
+func Subscribe(bus *events.Bus) (unsubscribe func()) {
+	unsubscribes := []func(){
+		bus.Subscribe(events.MigrationApplied, observeExecution),
+		bus.Subscribe(events.MigrationFailed, observeExecution),
+		bus.Subscribe(events.RollbackCompleted, observeExecution),
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// observeExecution records an execution event. Baselined migrations were not executed and are skipped.
+func observeExecution(_ context.Context, event events.Event) {
+	operation, _ := event.Data["operation"].(string)
+	if operation == "" {
+		return
+	}
+	direction := DirectionUp
+	if operation != DirectionUp {
+		direction = DirectionDown
+	}
+	backend, _ := event.Data["backend"].(string)
+	elapsedMs, _ := event.Data["elapsed_ms"].(int64)
+
+	var err error
+	if event.Type == events.MigrationFailed {
+		message, _ := event.Data["error"].(string)
+		err = errors.New(message)
+	}
+	ObserveMigration(direction, backend, event.Connection, time.Duration(elapsedMs)*time.Millisecond, err)
+}
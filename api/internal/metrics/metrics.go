@@ -0,0 +1,99 @@
+// Package metrics holds the Prometheus collectors of the server and worker. Collectors are
+// registered on the default registry and exposed by Handler on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Migration directions
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down" // Down migrations and rollbacks
+)
+
+var (
+	migrationsApplied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bfm_migrations_applied_total",
+		Help: "Migrations executed successfully.",
+	}, []string{"backend", "connection", "direction"})
+
+	migrationsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bfm_migrations_failed_total",
+		Help: "Migrations whose execution failed.",
+	}, []string{"backend", "connection", "direction"})
+
+	migrationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bfm_migration_duration_seconds",
+		Help:    "Time spent executing a migration on its backend.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"backend", "connection", "direction"})
+
+	stateQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bfm_state_query_duration_seconds",
+		Help:    "Latency of state tracker operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	jobsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bfm_queue_jobs_processed_total",
+		Help: "Queued migration jobs processed by the worker, by outcome (success, failed).",
+	}, []string{"status"})
+
+	queueDepthMu sync.RWMutex
+	queueDepth   func() int64
+
+	queueDepthGauge = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "bfm_queue_depth",
+		Help: "Migration jobs waiting in the queue, for queues that report it (0 otherwise).",
+	}, func() float64 {
+		queueDepthMu.RLock()
+		defer queueDepthMu.RUnlock()
+		if queueDepth == nil {
+			return 0
+		}
+		return float64(queueDepth())
+	})
+)
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveMigration records the outcome and duration of a migration execution
+func ObserveMigration(direction, backend, connection string, duration time.Duration, err error) {
+	migrationDuration.WithLabelValues(backend, connection, direction).Observe(duration.Seconds())
+	if err != nil {
+		migrationsFailed.WithLabelValues(backend, connection, direction).Inc()
+		return
+	}
+	migrationsApplied.WithLabelValues(backend, connection, direction).Inc()
+}
+
+// ObserveStateQuery records the latency of a state tracker operation
+func ObserveStateQuery(operation string, duration time.Duration) {
+	stateQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ObserveJob records a processed queue job
+func ObserveJob(success bool) {
+	status := "success"
+	if !success {
+		status = "failed"
+	}
+	jobsProcessed.WithLabelValues(status).Inc()
+}
+
+// SetQueueDepth sets the function reporting the number of jobs waiting in the queue
+func SetQueueDepth(depth func() int64) {
+	queueDepthMu.Lock()
+	defer queueDepthMu.Unlock()
+	queueDepth = depth
+}
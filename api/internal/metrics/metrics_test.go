@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+func TestObserveMigration(t *testing.T) {
+	ObserveMigration(DirectionUp, "postgresql", "metrics_test", time.Second, nil)
+	ObserveMigration(DirectionUp, "postgresql", "metrics_test", time.Second, nil)
+	ObserveMigration(DirectionDown, "postgresql", "metrics_test", time.Second, errors.New("boom"))
+
+	if got := testutil.ToFloat64(migrationsApplied.WithLabelValues("postgresql", "metrics_test", DirectionUp)); got != 2 {
+		t.Errorf("applied = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(migrationsFailed.WithLabelValues("postgresql", "metrics_test", DirectionDown)); got != 1 {
+		t.Errorf("failed = %v, want 1", got)
+	}
+}
+
+func TestSetQueueDepth(t *testing.T) {
+	defer SetQueueDepth(nil)
+
+	if got := testutil.ToFloat64(queueDepthGauge); got != 0 {
+		t.Errorf("depth without a reporting queue = %v, want 0", got)
+	}
+	SetQueueDepth(func() int64 { return 7 })
+	if got := testutil.ToFloat64(queueDepthGauge); got != 7 {
+		t.Errorf("depth = %v, want 7", got)
+	}
+}
+
+// listTracker is a state tracker returning an empty migration list
+type listTracker struct {
+	state.StateTracker
+}
+
+func (listTracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	return nil, 0, nil
+}
+
+// pingTracker is a state tracker whose database is unreachable
+type pingTracker struct {
+	state.StateTracker
+}
+
+func (pingTracker) Ping(ctx context.Context) error {
+	return errors.New("unreachable")
+}
+
+func TestInstrumentStateTracker_Ping(t *testing.T) {
+	pinger, ok := InstrumentStateTracker(pingTracker{}).(state.Pinger)
+	if !ok {
+		t.Fatal("expected the instrumented tracker to implement state.Pinger")
+	}
+	if err := pinger.Ping(context.Background()); err == nil {
+		t.Error("expected Ping to reach the wrapped tracker")
+	}
+}
+
+func TestInstrumentStateTracker(t *testing.T) {
+	tracker := InstrumentStateTracker(listTracker{})
+	if _, _, err := tracker.GetMigrationList(nil, nil); err != nil {
+		t.Fatalf("GetMigrationList() error = %v", err)
+	}
+	if got := testutil.CollectAndCount(stateQueryDuration); got != 1 {
+		t.Errorf("expected one observed operation, got %d series", got)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	bus := events.NewBus()
+	unsubscribe := Subscribe(bus)
+	defer unsubscribe()
+
+	ctx := context.Background()
+	execution := func(eventType, operation string, data map[string]interface{}) events.Event {
+		event := events.Event{Type: eventType, Connection: "metrics_events", Data: map[string]interface{}{
+			"operation": operation, "backend": "postgresql", "elapsed_ms": int64(1500),
+		}}
+		for k, v := range data {
+			event.Data[k] = v
+		}
+		return event
+	}
+	bus.Publish(ctx, execution(events.MigrationApplied, "up", nil))
+	bus.Publish(ctx, execution(events.RollbackCompleted, "rollback", nil))
+	bus.Publish(ctx, execution(events.MigrationFailed, "down", map[string]interface{}{"error": "boom"}))
+	// Baselined migrations were not executed
+	bus.Publish(ctx, events.Event{Type: events.MigrationApplied, Connection: "metrics_events", Data: map[string]interface{}{"baseline": true}})
+
+	if got := testutil.ToFloat64(migrationsApplied.WithLabelValues("postgresql", "metrics_events", DirectionUp)); got != 1 {
+		t.Errorf("applied up = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(migrationsApplied.WithLabelValues("postgresql", "metrics_events", DirectionDown)); got != 1 {
+		t.Errorf("applied down = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(migrationsFailed.WithLabelValues("postgresql", "metrics_events", DirectionDown)); got != 1 {
+		t.Errorf("failed down = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(migrationDuration); got == 0 {
+		t.Errorf("expected the execution durations to be observed")
+	}
+}
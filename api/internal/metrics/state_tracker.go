@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// stateTracker records the latency of each state tracker operation
+type stateTracker struct {
+	state.StateTracker
+}
+
+// InstrumentStateTracker wraps tracker so its operations are observed in
+// bfm_state_query_duration_seconds. The lock helpers are not timed, since they run the whole
+// migration while holding the lock.
+func InstrumentStateTracker(tracker state.StateTracker) state.StateTracker {
+	return &stateTracker{StateTracker: tracker}
+}
+
+// observeSince is deferred by each instrumented operation
+func observeSince(operation string, start time.Time) {
+	ObserveStateQuery(operation, time.Since(start))
+}
+
+// Ping is not timed: readiness probes would swamp the query latencies. Trackers that cannot ping
+// are checked with Initialize.
+func (t *stateTracker) Ping(ctx context.Context) error {
+	if pinger, ok := t.StateTracker.(state.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return t.StateTracker.Initialize(ctx)
+}
+
+func (t *stateTracker) RecordMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	defer observeSince("record_migration", time.Now())
+	return t.StateTracker.RecordMigration(ctx, migration)
+}
+
+func (t *stateTracker) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
+	defer observeSince("get_migration_history", time.Now())
+	return t.StateTracker.GetMigrationHistory(ctx, filters)
+}
+
+func (t *stateTracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	defer observeSince("get_migration_list", time.Now())
+	return t.StateTracker.GetMigrationList(ctx, filters)
+}
+
+func (t *stateTracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
+	defer observeSince("is_migration_applied", time.Now())
+	return t.StateTracker.IsMigrationApplied(ctx, migrationID)
+}
+
+func (t *stateTracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
+	defer observeSince("is_migration_pending_or_applied", time.Now())
+	return t.StateTracker.IsMigrationPendingOrApplied(ctx, migrationID)
+}
+
+func (t *stateTracker) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	defer observeSince("list_locks", time.Now())
+	return t.StateTracker.ListLocks(ctx)
+}
+
+func (t *stateTracker) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	defer observeSince("release_lock", time.Now())
+	return t.StateTracker.ReleaseLock(ctx, connection)
+}
+
+func (t *stateTracker) GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error) {
+	defer observeSince("get_last_migration_version", time.Now())
+	return t.StateTracker.GetLastMigrationVersion(ctx, schema, table)
+}
+
+func (t *stateTracker) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	defer observeSince("register_scanned_migration", time.Now())
+	return t.StateTracker.RegisterScannedMigration(ctx, migrationID, schema, table, version, name, connection, backend)
+}
+
+func (t *stateTracker) UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	defer observeSince("update_migration_info", time.Now())
+	return t.StateTracker.UpdateMigrationInfo(ctx, migrationID, schema, table, version, name, connection, backend)
+}
+
+func (t *stateTracker) DeleteMigration(ctx context.Context, migrationID string) error {
+	defer observeSince("delete_migration", time.Now())
+	return t.StateTracker.DeleteMigration(ctx, migrationID)
+}
+
+func (t *stateTracker) ReindexMigrations(ctx context.Context, registry interface{}) error {
+	defer observeSince("reindex_migrations", time.Now())
+	return t.StateTracker.ReindexMigrations(ctx, registry)
+}
+
+func (t *stateTracker) GetMigrationDetail(ctx context.Context, migrationID string) (*state.MigrationDetail, error) {
+	defer observeSince("get_migration_detail", time.Now())
+	return t.StateTracker.GetMigrationDetail(ctx, migrationID)
+}
+
+func (t *stateTracker) GetMigrationExecutions(ctx context.Context, migrationID string) ([]*state.MigrationExecution, error) {
+	defer observeSince("get_migration_executions", time.Now())
+	return t.StateTracker.GetMigrationExecutions(ctx, migrationID)
+}
+
+// GetConnectionExecutions reads the executions migration by migration when the wrapped tracker
+// cannot read them at once
+func (t *stateTracker) GetConnectionExecutions(ctx context.Context, connection string) ([]*state.MigrationExecution, error) {
+	defer observeSince("get_connection_executions", time.Now())
+	return state.GetConnectionExecutions(ctx, t.StateTracker, connection)
+}
+
+func (t *stateTracker) GetRecentExecutions(ctx context.Context, limit int) ([]*state.MigrationExecution, error) {
+	defer observeSince("get_recent_executions", time.Now())
+	return t.StateTracker.GetRecentExecutions(ctx, limit)
+}
+
+func (t *stateTracker) RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
+	defer observeSince("record_skipped_migrations", time.Now())
+	return t.StateTracker.RecordSkippedMigrations(ctx, skippedMigrationIDs, executedBy, executionMethod, executionContext)
+}
+
+func (t *stateTracker) GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*state.SkippedMigration, error) {
+	defer observeSince("get_skipped_migrations", time.Now())
+	return t.StateTracker.GetSkippedMigrations(ctx, migrationID, limit)
+}
+
+func (t *stateTracker) RecordDependencyMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	defer observeSince("record_dependency_migration", time.Now())
+	return t.StateTracker.RecordDependencyMigration(ctx, migration)
+}
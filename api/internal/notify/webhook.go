@@ -0,0 +1,120 @@
+// Package notify sends events of the event bus to webhooks (a chat integration, a paging service),
+// so operators hear about emergency runs and overdue postmortems without watching the logs.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/logger"
+)
+
+// DefaultEvents are the event types sent when none are configured
+var DefaultEvents = []string{events.EmergencyStarted, events.PostmortemOverdue, events.PostmortemRecorded}
+
+// Timeout bounds each call to a webhook
+const Timeout = 10 * time.Second
+
+// Notification is the JSON body POSTed to the webhooks
+type Notification struct {
+	Type        string                 `json:"type"`
+	Time        string                 `json:"time"` // RFC 3339
+	MigrationID string                 `json:"migration_id,omitempty"`
+	Connection  string                 `json:"connection,omitempty"`
+	Schema      string                 `json:"schema,omitempty"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// Webhook POSTs events to a list of URLs. Calls are made in the background, so they never delay
+// or fail what published the event; failures are logged.
+type Webhook struct {
+	urls   []string
+	token  string
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+// NewWebhook creates a webhook notifier calling urls, with token as a bearer token when set
+func NewWebhook(urls []string, token string) *Webhook {
+	return &Webhook{
+		urls:   urls,
+		token:  token,
+		client: &http.Client{Timeout: Timeout},
+	}
+}
+
+// Subscribe starts sending the events of eventTypes (DefaultEvents if none) published on bus, and
+// returns a function that stops it
+func (w *Webhook) Subscribe(bus *events.Bus, eventTypes ...string) (unsubscribe func()) {
+	if len(eventTypes) == 0 {
+		eventTypes = DefaultEvents
+	}
+	unsubscribes := make([]func(), 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		unsubscribes = append(unsubscribes, bus.Subscribe(eventType, w.handle))
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// Wait blocks until the calls started so far have finished
+func (w *Webhook) Wait() {
+	w.wg.Wait()
+}
+
+// handle hands an event off to a call per URL
+func (w *Webhook) handle(ctx context.Context, event events.Event) {
+	body, err := json.Marshal(Notification{
+		Type:        event.Type,
+		Time:        event.Time.UTC().Format(time.RFC3339),
+		MigrationID: event.MigrationID,
+		Connection:  event.Connection,
+		Schema:      event.Schema,
+		Data:        event.Data,
+	})
+	if err != nil {
+		logger.Errorf("Failed to encode notification of %s: %v", event.Type, err)
+		return
+	}
+	// The publisher's context may end with its request; the calls outlive it
+	ctx = context.WithoutCancel(ctx)
+	for _, url := range w.urls {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			if err := w.call(ctx, url, body); err != nil {
+				logger.Warnf("Notification of %s to %s failed: %v", event.Type, url, err)
+			}
+		}()
+	}
+}
+
+// call POSTs a notification to a URL; any status other than 2xx is an error
+func (w *Webhook) call(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
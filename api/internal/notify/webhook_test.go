@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/events"
+)
+
+func TestWebhook_SendsSubscribedEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		received = append(received, notification)
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	webhook := NewWebhook([]string{server.URL, failing.URL}, "secret")
+	bus := events.NewBus()
+	unsubscribe := webhook.Subscribe(bus)
+
+	ctx := context.Background()
+	bus.Publish(ctx, events.Event{Type: events.EmergencyStarted, Data: map[string]interface{}{"emergency_run": "emergency_1", "reason": "INC-42"}})
+	bus.Publish(ctx, events.Event{Type: events.MigrationApplied, Connection: "core", MigrationID: "m1"})
+	unsubscribe()
+	bus.Publish(ctx, events.Event{Type: events.PostmortemOverdue, Data: map[string]interface{}{"emergency_run": "emergency_1"}})
+	webhook.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("expected one notification, got %+v", received)
+	}
+	if got := received[0]; got.Type != events.EmergencyStarted || got.Data["reason"] != "INC-42" || got.Time == "" {
+		t.Errorf("unexpected notification %+v", got)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the configured token", authorization)
+	}
+}
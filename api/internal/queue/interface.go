@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"time"
 )
 
 // Job represents a migration job to be queued
@@ -13,6 +14,12 @@ type Job struct {
 	SchemaName string                 `json:"schema_name,omitempty"`
 	DryRun     bool                   `json:"dry_run,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	// Checksums of the approved plan the job is pinned to, keyed by migration ID
+	PinnedChecksums map[string]string `json:"pinned_checksums,omitempty"`
+	// Forces destructive migrations on connections whose destructive policy is "force"
+	AllowDestructive bool `json:"allow_destructive,omitempty"`
+	// Execution attempts so far; set by the worker, which retries transient failures
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // MigrationTarget specifies which migrations to execute
@@ -21,6 +28,7 @@ type MigrationTarget struct {
 	Schema     string   `json:"schema,omitempty"`
 	Tables     []string `json:"tables,omitempty"`
 	Version    string   `json:"version,omitempty"`
+	MaxVersion string   `json:"max_version,omitempty"`
 	Connection string   `json:"connection,omitempty"`
 	Tags       []string `json:"tags,omitempty"`
 }
@@ -61,3 +69,30 @@ type Queue interface {
 	Producer
 	Consumer
 }
+
+// DepthReporter is implemented by queues that can report how many jobs are waiting to be consumed
+type DepthReporter interface {
+	// Depth returns the number of jobs not yet consumed by this consumer group
+	Depth() int64
+}
+
+// Pinger is implemented by queues that can check their broker is reachable
+type Pinger interface {
+	// Ping returns an error if the broker cannot be reached
+	Ping(ctx context.Context) error
+}
+
+// DeadLetter is a job that failed permanently (a non-transient error, or transient errors on
+// every attempt), published to the dead-letter topic with its error
+type DeadLetter struct {
+	Job      *Job      `json:"job"`
+	Error    string    `json:"error"`
+	Errors   []string  `json:"errors,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterPublisher is implemented by queues with a dead-letter topic
+type DeadLetterPublisher interface {
+	// PublishDeadLetter publishes a permanently failed job to the dead-letter topic
+	PublishDeadLetter(ctx context.Context, letter *DeadLetter) error
+}
@@ -89,6 +89,11 @@ func (c *Consumer) Consume(ctx context.Context, handler queue.JobHandler) error
 	}
 }
 
+// Depth returns the consumer group's lag on the topic, as of the last fetch
+func (c *Consumer) Depth() int64 {
+	return c.reader.Stats().Lag
+}
+
 // Close closes the Kafka consumer
 func (c *Consumer) Close() error {
 	return c.reader.Close()
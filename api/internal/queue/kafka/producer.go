@@ -67,6 +67,32 @@ func (p *Producer) PublishJob(ctx context.Context, job *queue.Job) error {
 	return nil
 }
 
+// PublishDeadLetter publishes a permanently failed job to Kafka, on the dead-letter topic this
+// producer writes to
+func (p *Producer) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	letterData, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	message := kafka.Message{
+		Key:   []byte(letter.Job.ID),
+		Value: letterData,
+		Headers: []kafka.Header{
+			{Key: "job-id", Value: []byte(letter.Job.ID)},
+			{Key: "connection", Value: []byte(letter.Job.Connection)},
+			{Key: "error", Value: []byte(letter.Error)},
+		},
+	}
+
+	if err := p.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to write dead letter to Kafka: %w", err)
+	}
+
+	logger.Warnf("Published failed migration job %s to Kafka dead-letter topic %s", letter.Job.ID, p.topic)
+	return nil
+}
+
 // Close closes the Kafka producer
 func (p *Producer) Close() error {
 	return p.writer.Close()
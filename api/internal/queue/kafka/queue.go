@@ -2,22 +2,30 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/toolsascode/bfm/api/internal/queue"
+
+	"github.com/segmentio/kafka-go"
 )
 
 // Queue implements queue.Queue using Kafka
 type Queue struct {
-	producer *Producer
-	consumer *Consumer
+	brokers     []string
+	producer    *Producer
+	consumer    *Consumer
+	deadLetters *Producer
 }
 
-// NewQueue creates a new Kafka queue with both producer and consumer
-func NewQueue(brokers []string, topic, groupID string) *Queue {
+// NewQueue creates a new Kafka queue with both producer and consumer, and a producer for the
+// dead-letter topic
+func NewQueue(brokers []string, topic, groupID, deadLetterTopic string) *Queue {
 	return &Queue{
-		producer: NewProducer(brokers, topic),
-		consumer: NewConsumer(brokers, topic, groupID),
+		brokers:     brokers,
+		producer:    NewProducer(brokers, topic),
+		consumer:    NewConsumer(brokers, topic, groupID),
+		deadLetters: NewProducer(brokers, deadLetterTopic),
 	}
 }
 
@@ -31,6 +39,32 @@ func (q *Queue) Consume(ctx context.Context, handler queue.JobHandler) error {
 	return q.consumer.Consume(ctx, handler)
 }
 
+// PublishDeadLetter publishes a permanently failed job to the Kafka dead-letter topic
+func (q *Queue) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	return q.deadLetters.PublishDeadLetter(ctx, letter)
+}
+
+// Ping checks at least one broker is reachable
+func (q *Queue) Ping(ctx context.Context) error {
+	var errs []error
+	for _, broker := range q.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("no Kafka brokers configured")
+	}
+	return fmt.Errorf("no Kafka broker reachable: %w", errors.Join(errs...))
+}
+
+// Depth returns the number of jobs waiting in Kafka
+func (q *Queue) Depth() int64 {
+	return q.consumer.Depth()
+}
+
 // Close closes both producer and consumer
 func (q *Queue) Close() error {
 	var errs []error
@@ -43,6 +77,10 @@ func (q *Queue) Close() error {
 		errs = append(errs, err)
 	}
 
+	if err := q.deadLetters.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("errors closing queue: %v", errs)
 	}
@@ -0,0 +1,218 @@
+// Package memory implements queue.Queue with a buffered channel, for single-binary deployments
+// that run the worker in the server process, and for tests of the queue path. Jobs live in the
+// process: those still queued when it stops are lost.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+)
+
+var (
+	// ErrQueueFull is returned when publishing to a queue holding capacity jobs
+	ErrQueueFull = errors.New("in-memory queue is full")
+	// ErrQueueClosed is returned when publishing to a closed queue
+	ErrQueueClosed = errors.New("in-memory queue is closed")
+)
+
+// Queue implements queue.Queue with a buffered channel. Jobs are encoded and decoded as on the
+// wire, so the consumer gets its own copy, and are consumed in publish order. Dead letters are
+// logged and kept in memory.
+type Queue struct {
+	jobs      chan *queue.Job
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu          sync.Mutex
+	unhandled   int           // Jobs published and not yet handled
+	idle        chan struct{} // Closed while unhandled is 0
+	deadLetters []*queue.DeadLetter
+}
+
+var (
+	_ queue.Queue               = (*Queue)(nil)
+	_ queue.DepthReporter       = (*Queue)(nil)
+	_ queue.DeadLetterPublisher = (*Queue)(nil)
+	_ queue.Pinger              = (*Queue)(nil)
+)
+
+// NewQueue creates an in-memory queue holding up to capacity jobs waiting to be consumed
+func NewQueue(capacity int) *Queue {
+	idle := make(chan struct{})
+	close(idle)
+	return &Queue{
+		jobs:   make(chan *queue.Job, capacity),
+		closed: make(chan struct{}),
+		idle:   idle,
+	}
+}
+
+// PublishJob publishes a migration job to the queue. It does not wait for room: a full queue
+// returns ErrQueueFull.
+func (q *Queue) PublishJob(ctx context.Context, job *queue.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Generate job ID if not provided
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("job_%d", time.Now().UnixNano())
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	var consumed queue.Job
+	if err := json.Unmarshal(data, &consumed); err != nil {
+		return fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	select {
+	case <-q.closed:
+		return ErrQueueClosed
+	default:
+	}
+
+	q.begin()
+	select {
+	case q.jobs <- &consumed:
+	default:
+		q.done()
+		return fmt.Errorf("%w (%d jobs waiting)", ErrQueueFull, cap(q.jobs))
+	}
+
+	logger.Infof("Published migration job %s to the in-memory queue", job.ID)
+	return nil
+}
+
+// PublishDeadLetter logs a permanently failed job and keeps it in memory
+func (q *Queue) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	q.mu.Lock()
+	q.deadLetters = append(q.deadLetters, letter)
+	q.mu.Unlock()
+
+	logger.Warnf("Migration job %s failed permanently: %s", letter.Job.ID, letter.Error)
+	return nil
+}
+
+// DeadLetters returns the permanently failed jobs, in the order they failed
+func (q *Queue) DeadLetters() []*queue.DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.deadLetters)
+}
+
+// Consume calls handler for each job, in publish order, until ctx is done or the queue is closed.
+// A job whose handler fails is logged and not redelivered.
+func (q *Queue) Consume(ctx context.Context, handler queue.JobHandler) error {
+	logger.Info("Starting in-memory queue consumer")
+	for {
+		// A closed queue delivers no more jobs, even with jobs waiting
+		select {
+		case <-q.closed:
+			return nil
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("In-memory queue consumer context cancelled")
+			return ctx.Err()
+		case <-q.closed:
+			return nil
+		case job := <-q.jobs:
+			q.process(ctx, handler, job)
+		}
+	}
+}
+
+// process handles a job and marks it handled
+func (q *Queue) process(ctx context.Context, handler queue.JobHandler, job *queue.Job) {
+	defer q.done()
+
+	logger.Infof("Processing migration job %s from the in-memory queue", job.ID)
+	result, err := handler(ctx, job)
+	if err != nil {
+		logger.Errorf("Failed to process migration job %s: %v", job.ID, err)
+		return
+	}
+
+	if result != nil {
+		if result.Success {
+			logger.Infof("Successfully processed migration job %s: %d applied, %d skipped",
+				job.ID, len(result.Applied), len(result.Skipped))
+		} else {
+			logger.Warnf("Migration job %s completed with errors: %v", job.ID, result.Errors)
+		}
+	}
+}
+
+// begin counts a published job as unhandled
+func (q *Queue) begin() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.unhandled == 0 {
+		q.idle = make(chan struct{})
+	}
+	q.unhandled++
+}
+
+// done counts a job as handled
+func (q *Queue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.unhandled--
+	if q.unhandled == 0 {
+		close(q.idle)
+	}
+}
+
+// Wait blocks until every job published so far has been handled, or ctx is done. Tests use it to
+// assert on the outcome of queued jobs without polling.
+func (q *Queue) Wait(ctx context.Context) error {
+	q.mu.Lock()
+	idle := q.idle
+	q.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Depth returns the number of jobs waiting to be consumed
+func (q *Queue) Depth() int64 {
+	return int64(len(q.jobs))
+}
+
+// Ping returns ErrQueueClosed once the queue is closed
+func (q *Queue) Ping(ctx context.Context) error {
+	select {
+	case <-q.closed:
+		return ErrQueueClosed
+	default:
+		return nil
+	}
+}
+
+// Close closes the queue: publishing fails and Consume returns. Jobs still waiting are dropped.
+func (q *Queue) Close() error {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+		if dropped := len(q.jobs); dropped > 0 {
+			logger.Warnf("Closing the in-memory queue with %d migration job(s) not yet consumed", dropped)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/queue"
+)
+
+func TestQueue_ConsumesInPublishOrder(t *testing.T) {
+	q := NewQueue(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job := &queue.Job{Connection: "core", Metadata: map[string]interface{}{"source": "api"}}
+	if err := q.PublishJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" {
+		t.Error("expected PublishJob to assign a job ID")
+	}
+	if err := q.PublishJob(ctx, &queue.Job{ID: "second", Connection: "core"}); err != nil {
+		t.Fatal(err)
+	}
+	if q.Depth() != 2 {
+		t.Errorf("Depth() = %d, want 2", q.Depth())
+	}
+	// The consumer gets its own copy of the job
+	job.Metadata["source"] = "changed"
+
+	var handled []*queue.Job
+	go func() {
+		_ = q.Consume(ctx, func(ctx context.Context, job *queue.Job) (*queue.JobResult, error) {
+			handled = append(handled, job)
+			if job.ID == "second" {
+				return nil, errors.New("boom")
+			}
+			return &queue.JobResult{JobID: job.ID, Success: true}, nil
+		})
+	}()
+
+	if err := q.Wait(ctx); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if len(handled) != 2 || handled[0].ID != job.ID || handled[1].ID != "second" {
+		t.Fatalf("expected both jobs to be handled in publish order, got %+v", handled)
+	}
+	if handled[0].Metadata["source"] != "api" {
+		t.Errorf("consumer saw a change made after publishing: %v", handled[0].Metadata)
+	}
+	if q.Depth() != 0 {
+		t.Errorf("Depth() = %d after handling, want 0", q.Depth())
+	}
+}
+
+func TestQueue_FullAndClosed(t *testing.T) {
+	q := NewQueue(1)
+	ctx := context.Background()
+
+	if err := q.PublishJob(ctx, &queue.Job{ID: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.PublishJob(ctx, &queue.Job{ID: "second"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	// A rejected job is not waited for
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := q.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Wait() to wait for the unhandled job, got %v", err)
+	}
+
+	_ = q.Close()
+	if err := q.PublishJob(ctx, &queue.Job{ID: "third"}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+	if err := q.Consume(ctx, func(context.Context, *queue.Job) (*queue.JobResult, error) {
+		t.Error("closed queue delivered a job")
+		return nil, nil
+	}); err != nil {
+		t.Errorf("Consume() on a closed queue = %v, want nil", err)
+	}
+	_ = q.Close()
+}
+
+func TestQueue_DeadLetters(t *testing.T) {
+	q := NewQueue(1)
+	letter := &queue.DeadLetter{Job: &queue.Job{ID: "job-1"}, Error: "syntax error"}
+	if err := q.PublishDeadLetter(context.Background(), letter); err != nil {
+		t.Fatal(err)
+	}
+	if got := q.DeadLetters(); len(got) != 1 || got[0] != letter {
+		t.Errorf("DeadLetters() = %+v", got)
+	}
+}
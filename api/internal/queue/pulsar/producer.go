@@ -76,6 +76,32 @@ func (p *Producer) PublishJob(ctx context.Context, job *queue.Job) error {
 	return nil
 }
 
+// PublishDeadLetter publishes a permanently failed job to Pulsar, on the dead-letter topic this
+// producer sends to
+func (p *Producer) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	letterData, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	msg := &pulsar.ProducerMessage{
+		Payload: letterData,
+		Key:     letter.Job.ID,
+		Properties: map[string]string{
+			"job-id":     letter.Job.ID,
+			"connection": letter.Job.Connection,
+			"error":      letter.Error,
+		},
+	}
+
+	if _, err := p.producer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send dead letter to Pulsar: %w", err)
+	}
+
+	logger.Warnf("Published failed migration job %s to Pulsar dead-letter topic %s", letter.Job.ID, p.topic)
+	return nil
+}
+
 // Close closes the Pulsar producer
 func (p *Producer) Close() error {
 	p.producer.Close()
@@ -9,12 +9,14 @@ import (
 
 // Queue implements queue.Queue using Pulsar
 type Queue struct {
-	producer *Producer
-	consumer *Consumer
+	producer    *Producer
+	consumer    *Consumer
+	deadLetters *Producer
 }
 
-// NewQueue creates a new Pulsar queue with both producer and consumer
-func NewQueue(url, topic, subscriptionName string) (*Queue, error) {
+// NewQueue creates a new Pulsar queue with both producer and consumer, and a producer for the
+// dead-letter topic
+func NewQueue(url, topic, subscriptionName, deadLetterTopic string) (*Queue, error) {
 	producer, err := NewProducer(url, topic)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer: %w", err)
@@ -26,9 +28,17 @@ func NewQueue(url, topic, subscriptionName string) (*Queue, error) {
 		return nil, fmt.Errorf("failed to create consumer: %w", err)
 	}
 
+	deadLetters, err := NewProducer(url, deadLetterTopic)
+	if err != nil {
+		_ = producer.Close()
+		_ = consumer.Close()
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+
 	return &Queue{
-		producer: producer,
-		consumer: consumer,
+		producer:    producer,
+		consumer:    consumer,
+		deadLetters: deadLetters,
 	}, nil
 }
 
@@ -37,6 +47,11 @@ func (q *Queue) PublishJob(ctx context.Context, job *queue.Job) error {
 	return q.producer.PublishJob(ctx, job)
 }
 
+// PublishDeadLetter publishes a permanently failed job to the Pulsar dead-letter topic
+func (q *Queue) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	return q.deadLetters.PublishDeadLetter(ctx, letter)
+}
+
 // Consume starts consuming jobs from Pulsar
 func (q *Queue) Consume(ctx context.Context, handler queue.JobHandler) error {
 	return q.consumer.Consume(ctx, handler)
@@ -54,6 +69,10 @@ func (q *Queue) Close() error {
 		errs = append(errs, err)
 	}
 
+	if err := q.deadLetters.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("errors closing queue: %v", errs)
 	}
@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// jobField and deadLetterField hold the JSON of a job and of a dead letter in stream entries
+	jobField        = "job"
+	deadLetterField = "dead_letter"
+
+	// readBlock bounds how long a read waits for new entries, so cancellation and claims are
+	// checked in between
+	readBlock = 5 * time.Second
+	// claimBatch is the number of pending entries claimed per XAUTOCLAIM call
+	claimBatch = 10
+)
+
+// Consumer implements queue.Consumer using a Redis stream and consumer group. Workers sharing the
+// group share the stream: each entry is delivered to one of them. An entry stays pending until
+// its job is handled; entries left pending by a crashed worker are claimed by another worker once
+// they have been idle for claimIdle.
+type Consumer struct {
+	client    *redis.Client
+	stream    string
+	group     string
+	consumer  string
+	claimIdle time.Duration
+}
+
+// NewConsumer creates a new Redis Streams consumer named consumer in group; the client is shared
+// and closed by its owner
+func NewConsumer(client *redis.Client, stream, group, consumer string, claimIdle time.Duration) *Consumer {
+	return &Consumer{
+		client:    client,
+		stream:    stream,
+		group:     group,
+		consumer:  consumer,
+		claimIdle: claimIdle,
+	}
+}
+
+// Consume starts consuming jobs from the Redis stream
+func (c *Consumer) Consume(ctx context.Context, handler queue.JobHandler) error {
+	logger.Infof("Starting Redis Streams consumer %s in group %s for stream %s", c.consumer, c.group, c.stream)
+
+	// Read the stream from its start, so jobs published before the group existed are consumed
+	err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create Redis consumer group %s: %w", c.group, err)
+	}
+
+	// Entries still pending for this consumer were interrupted by a restart: handle them first
+	for {
+		handled, err := c.read(ctx, handler, "0")
+		if err != nil {
+			return err
+		}
+		if handled == 0 {
+			break
+		}
+	}
+
+	var nextClaim time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Redis Streams consumer context cancelled")
+			return ctx.Err()
+		default:
+		}
+
+		if !time.Now().Before(nextClaim) {
+			if err := c.claim(ctx, handler); err != nil {
+				return err
+			}
+			nextClaim = time.Now().Add(c.claimIdle)
+		}
+
+		if _, err := c.read(ctx, handler, ">"); err != nil {
+			return err
+		}
+	}
+}
+
+// read handles the entries of the group read from id, and returns how many: ">" for a new entry,
+// waiting up to readBlock for one, or "0" for an entry pending for this consumer
+func (c *Consumer) read(ctx context.Context, handler queue.JobHandler, id string) (int, error) {
+	args := &redis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.consumer,
+		Streams:  []string{c.stream, id},
+		Count:    1,
+	}
+	if id == ">" {
+		args.Block = readBlock
+	}
+	streams, err := c.client.XReadGroup(ctx, args).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("failed to read from Redis stream: %w", err)
+	}
+
+	handled := 0
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			c.process(ctx, handler, msg)
+			handled++
+		}
+	}
+	return handled, nil
+}
+
+// claim takes over and handles the entries pending for longer than claimIdle: their worker
+// crashed or lost its connection while handling them
+func (c *Consumer) claim(ctx context.Context, handler queue.JobHandler) error {
+	start := "0-0"
+	for {
+		messages, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.stream,
+			Group:    c.group,
+			Consumer: c.consumer,
+			MinIdle:  c.claimIdle,
+			Start:    start,
+			Count:    claimBatch,
+		}).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to claim pending entries of Redis stream: %w", err)
+		}
+
+		if len(messages) > 0 {
+			logger.Warnf("Claimed %d migration job(s) left pending in Redis stream %s for over %s", len(messages), c.stream, c.claimIdle)
+		}
+		for _, msg := range messages {
+			c.process(ctx, handler, msg)
+		}
+
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// process handles the job of an entry, then acknowledges and deletes the entry. Entries are
+// deleted once handled so the stream holds only the jobs waiting or in progress.
+func (c *Consumer) process(ctx context.Context, handler queue.JobHandler, msg redis.XMessage) {
+	// The entry is acknowledged even when ctx is cancelled: the handler has run to completion
+	defer c.ack(context.WithoutCancel(ctx), msg.ID)
+
+	// Deserialize job
+	data, _ := msg.Values[jobField].(string)
+	var job queue.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		logger.Errorf("Failed to unmarshal job from Redis stream entry %s: %v", msg.ID, err)
+		return
+	}
+
+	// Extract job ID from the entry if not in body
+	if job.ID == "" {
+		job.ID, _ = msg.Values["job-id"].(string)
+	}
+
+	logger.Infof("Processing migration job %s from Redis", job.ID)
+
+	// Keep the entry from being claimed by other workers while the job runs
+	stop := c.heartbeat(ctx, msg.ID)
+	result, err := handler(ctx, &job)
+	stop()
+	if err != nil {
+		logger.Errorf("Failed to process migration job %s: %v", job.ID, err)
+		return
+	}
+
+	if result != nil {
+		if result.Success {
+			logger.Infof("Successfully processed migration job %s: %d applied, %d skipped",
+				job.ID, len(result.Applied), len(result.Skipped))
+		} else {
+			logger.Warnf("Migration job %s completed with errors: %v", job.ID, result.Errors)
+		}
+	}
+}
+
+// heartbeat resets the idle time of a pending entry at a third of claimIdle, until stopped, so a
+// job running for longer than claimIdle is not claimed by another worker
+func (c *Consumer) heartbeat(ctx context.Context, id string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.claimIdle / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := c.client.XClaimJustID(ctx, &redis.XClaimArgs{
+					Stream:   c.stream,
+					Group:    c.group,
+					Consumer: c.consumer,
+					Messages: []string{id},
+				}).Err()
+				if err != nil && ctx.Err() == nil {
+					logger.Warnf("Failed to refresh Redis stream entry %s: %v", id, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// ack acknowledges and deletes a handled entry
+func (c *Consumer) ack(ctx context.Context, id string) {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, c.stream, c.group, id)
+		pipe.XDel(ctx, c.stream, id)
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Failed to acknowledge Redis stream entry %s: %v", id, err)
+	}
+}
+
+// Depth returns the number of jobs in the stream: waiting, or being handled. Handled entries are
+// deleted, so it is the length of the stream.
+func (c *Consumer) Depth() int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	depth, err := c.client.XLen(ctx, c.stream).Result()
+	if err != nil {
+		return 0
+	}
+	return depth
+}
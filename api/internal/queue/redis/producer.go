@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Producer implements queue.Producer using a Redis stream
+type Producer struct {
+	client *redis.Client
+	stream string
+}
+
+// NewProducer creates a new Redis Streams producer; the client is shared and closed by its owner
+func NewProducer(client *redis.Client, stream string) *Producer {
+	return &Producer{
+		client: client,
+		stream: stream,
+	}
+}
+
+// PublishJob publishes a migration job to the Redis stream
+func (p *Producer) PublishJob(ctx context.Context, job *queue.Job) error {
+	// Generate job ID if not provided
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("job_%d", time.Now().UnixNano())
+	}
+
+	// Serialize job to JSON
+	jobData, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]interface{}{
+			jobField:     jobData,
+			"job-id":     job.ID,
+			"connection": job.Connection,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add job to Redis stream: %w", err)
+	}
+
+	logger.Infof("Published migration job %s to Redis stream %s", job.ID, p.stream)
+	return nil
+}
+
+// PublishDeadLetter publishes a permanently failed job to Redis, on the dead-letter stream this
+// producer writes to
+func (p *Producer) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	letterData, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]interface{}{
+			deadLetterField: letterData,
+			"job-id":        letter.Job.ID,
+			"connection":    letter.Job.Connection,
+			"error":         letter.Error,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add dead letter to Redis stream: %w", err)
+	}
+
+	logger.Warnf("Published failed migration job %s to Redis dead-letter stream %s", letter.Job.ID, p.stream)
+	return nil
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/queue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Queue implements queue.Queue using Redis Streams, for deployments too small to run Kafka or
+// Pulsar
+type Queue struct {
+	client      *redis.Client
+	producer    *Producer
+	consumer    *Consumer
+	deadLetters *Producer
+}
+
+// NewQueue connects to Redis and creates a queue on stream, consumed by group under the name
+// consumer, with a producer for the dead-letter stream. Pending entries idle for claimIdle are
+// claimed from crashed workers.
+func NewQueue(addr, password string, db int, stream, group, consumer, deadLetterStream string, claimIdle time.Duration) (*Queue, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", addr, err)
+	}
+
+	return &Queue{
+		client:      client,
+		producer:    NewProducer(client, stream),
+		consumer:    NewConsumer(client, stream, group, consumer, claimIdle),
+		deadLetters: NewProducer(client, deadLetterStream),
+	}, nil
+}
+
+// Ping checks the Redis server is reachable
+func (q *Queue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
+
+// PublishJob publishes a migration job to the Redis stream
+func (q *Queue) PublishJob(ctx context.Context, job *queue.Job) error {
+	return q.producer.PublishJob(ctx, job)
+}
+
+// Consume starts consuming jobs from the Redis stream
+func (q *Queue) Consume(ctx context.Context, handler queue.JobHandler) error {
+	return q.consumer.Consume(ctx, handler)
+}
+
+// PublishDeadLetter publishes a permanently failed job to the Redis dead-letter stream
+func (q *Queue) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	return q.deadLetters.PublishDeadLetter(ctx, letter)
+}
+
+// Depth returns the number of jobs waiting in the Redis stream
+func (q *Queue) Depth() int64 {
+	return q.consumer.Depth()
+}
+
+// Close closes the Redis connection shared by the producers and the consumer
+func (q *Queue) Close() error {
+	if err := q.client.Close(); err != nil {
+		return fmt.Errorf("failed to close Redis connection: %w", err)
+	}
+	return nil
+}
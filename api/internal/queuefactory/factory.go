@@ -2,22 +2,45 @@ package queuefactory
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/toolsascode/bfm/api/internal/queue"
 	"github.com/toolsascode/bfm/api/internal/queue/kafka"
+	"github.com/toolsascode/bfm/api/internal/queue/memory"
 	"github.com/toolsascode/bfm/api/internal/queue/pulsar"
+	"github.com/toolsascode/bfm/api/internal/queue/redis"
 )
 
 // QueueConfig holds configuration for creating a queue
 type QueueConfig struct {
-	Type               string   // "kafka" or "pulsar"
+	Type               string   // "kafka", "pulsar", "redis" or "memory"
 	KafkaBrokers       []string // Kafka broker addresses
 	KafkaTopic         string   // Kafka topic name
 	KafkaGroupID       string   // Kafka consumer group ID
+	KafkaDLQTopic      string   // Kafka dead-letter topic (default: "{KafkaTopic}-dlq")
 	PulsarURL          string   // Pulsar service URL
 	PulsarTopic        string   // Pulsar topic name
 	PulsarSubscription string   // Pulsar subscription name
+	PulsarDLQTopic     string   // Pulsar dead-letter topic (default: "{PulsarTopic}-dlq")
+
+	RedisAddr      string        // Redis address (host:port)
+	RedisPassword  string        // Redis password
+	RedisDB        int           // Redis database number
+	RedisStream    string        // Redis stream name
+	RedisGroup     string        // Redis consumer group, shared by the workers
+	RedisConsumer  string        // Name of this worker in the consumer group (default: the hostname)
+	RedisDLQStream string        // Redis dead-letter stream (default: "{RedisStream}-dlq")
+	RedisClaimIdle time.Duration // Idle time after which a pending job of a crashed worker is claimed (default: 5m)
+
+	MemoryCapacity int // Jobs the in-memory queue holds waiting to be consumed (default: 100)
+}
+
+// InProcess reports whether queues of queueType are consumed in the process publishing to them,
+// by a worker the server runs, rather than by separate worker processes
+func InProcess(queueType string) bool {
+	return strings.EqualFold(queueType, "memory")
 }
 
 // NewQueue creates a new queue based on the configuration
@@ -38,7 +61,10 @@ func NewQueue(config *QueueConfig) (queue.Queue, error) {
 		if config.KafkaGroupID == "" {
 			config.KafkaGroupID = "bfm-migration-workers"
 		}
-		return kafka.NewQueue(config.KafkaBrokers, config.KafkaTopic, config.KafkaGroupID), nil
+		if config.KafkaDLQTopic == "" {
+			config.KafkaDLQTopic = config.KafkaTopic + "-dlq"
+		}
+		return kafka.NewQueue(config.KafkaBrokers, config.KafkaTopic, config.KafkaGroupID, config.KafkaDLQTopic), nil
 
 	case "pulsar":
 		if config.PulsarURL == "" {
@@ -50,9 +76,45 @@ func NewQueue(config *QueueConfig) (queue.Queue, error) {
 		if config.PulsarSubscription == "" {
 			config.PulsarSubscription = "bfm-migration-workers"
 		}
-		return pulsar.NewQueue(config.PulsarURL, config.PulsarTopic, config.PulsarSubscription)
+		if config.PulsarDLQTopic == "" {
+			config.PulsarDLQTopic = config.PulsarTopic + "-dlq"
+		}
+		return pulsar.NewQueue(config.PulsarURL, config.PulsarTopic, config.PulsarSubscription, config.PulsarDLQTopic)
+
+	case "redis":
+		if config.RedisAddr == "" {
+			return nil, fmt.Errorf("redis address is required")
+		}
+		if config.RedisStream == "" {
+			return nil, fmt.Errorf("redis stream is required")
+		}
+		if config.RedisGroup == "" {
+			config.RedisGroup = "bfm-migration-workers"
+		}
+		if config.RedisConsumer == "" {
+			// Stable across restarts, so a restarted worker resumes the jobs it was handling
+			hostname, err := os.Hostname()
+			if err != nil || hostname == "" {
+				hostname = "bfm-worker"
+			}
+			config.RedisConsumer = hostname
+		}
+		if config.RedisDLQStream == "" {
+			config.RedisDLQStream = config.RedisStream + "-dlq"
+		}
+		if config.RedisClaimIdle <= 0 {
+			config.RedisClaimIdle = 5 * time.Minute
+		}
+		return redis.NewQueue(config.RedisAddr, config.RedisPassword, config.RedisDB, config.RedisStream, config.RedisGroup,
+			config.RedisConsumer, config.RedisDLQStream, config.RedisClaimIdle)
+
+	case "memory":
+		if config.MemoryCapacity <= 0 {
+			config.MemoryCapacity = 100
+		}
+		return memory.NewQueue(config.MemoryCapacity), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported queue type: %s (supported: kafka, pulsar)", config.Type)
+		return nil, fmt.Errorf("unsupported queue type: %s (supported: kafka, pulsar, redis, memory)", config.Type)
 	}
 }
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// ErrMigrationConflict is returned by Register for a migration that conflicts with a registered one
+var ErrMigrationConflict = errors.New("conflicting migration")
+
+// Conflict kinds
+const (
+	// ConflictVersion is two migrations of a connection with the same version: versions order the
+	// migrations of a connection
+	ConflictVersion = "version"
+	// ConflictName is two migrations of a connection with the same name: dependencies by name
+	// could resolve to either
+	ConflictName = "name"
+)
+
+// ConflictError describes a migration Register refused because a registered migration of the same
+// connection has its version or name. It wraps ErrMigrationConflict.
+type ConflictError struct {
+	Kind      string                    // ConflictVersion or ConflictName
+	Migration *backends.MigrationScript // The migration refused
+	Existing  *backends.MigrationScript // The registered migration it conflicts with
+}
+
+func (e *ConflictError) Error() string {
+	value := e.Migration.Version
+	if e.Kind == ConflictName {
+		value = e.Migration.Name
+	}
+	return fmt.Sprintf("%v: %s_%s and %s_%s of connection %s have the same %s %s",
+		ErrMigrationConflict, e.Existing.Version, e.Existing.Name, e.Migration.Version, e.Migration.Name,
+		e.Migration.Connection, e.Kind, value)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrMigrationConflict
+}
+
+// findConflict returns the conflict of migration with one of registered, or nil. Registering the
+// same migration again (same version, name, backend and connection) replaces it and is no conflict.
+func findConflict(registered map[string]*backends.MigrationScript, migrationID string, migration *backends.MigrationScript) *ConflictError {
+	if _, ok := registered[migrationID]; ok {
+		return nil
+	}
+	for _, existing := range registered {
+		if existing.Connection != migration.Connection || !BackendNamesMatch(existing.Backend, migration.Backend) {
+			continue
+		}
+		switch {
+		case existing.Version == migration.Version:
+			return &ConflictError{Kind: ConflictVersion, Migration: migration, Existing: existing}
+		case existing.Name == migration.Name:
+			return &ConflictError{Kind: ConflictName, Migration: migration, Existing: existing}
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -217,6 +218,11 @@ func (r *DependencyResolver) ResolveDependencyTargets(dep backends.Dependency) (
 // findDependencyTarget finds migration(s) matching a dependency specification
 func (r *DependencyResolver) findDependencyTarget(dep backends.Dependency) ([]*backends.MigrationScript, error) {
 	var candidates []*backends.MigrationScript
+	if dep.TargetType == "version_range" {
+		if _, err := backends.ParseVersionRange(dep.Target); err != nil {
+			return nil, fmt.Errorf("invalid dependency target: connection=%s, schema=%s: %w", dep.Connection, dep.Schema, err)
+		}
+	}
 
 	// Get all migrations
 	allMigrations := r.registry.GetAll()
@@ -233,16 +239,8 @@ func (r *DependencyResolver) findDependencyTarget(dep backends.Dependency) ([]*b
 			continue
 		}
 
-		// Match target based on type
-		if dep.TargetType == "version" {
-			if migration.Version == dep.Target {
-				candidates = append(candidates, migration)
-			}
-		} else {
-			// Default to "name"
-			if migration.Name == dep.Target {
-				candidates = append(candidates, migration)
-			}
+		if dep.MatchesTarget(migration.Version, migration.Name) {
+			candidates = append(candidates, migration)
 		}
 	}
 
@@ -251,9 +249,44 @@ func (r *DependencyResolver) findDependencyTarget(dep backends.Dependency) ([]*b
 			dep.Connection, dep.Schema, dep.Target, dep.TargetType)
 	}
 
+	// Any of the min_version and version_range candidates satisfies the dependency; the lowest
+	// version comes first
+	if dep.AnyTarget() {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Version < candidates[j].Version
+		})
+	}
+
 	return candidates, nil
 }
 
+// AnyTargetApplied reports whether a "min_version" or "version_range" dependency is satisfied by
+// the applied set: some migration it targets is applied on dep.Connection (and dep.Schema when
+// set). For min_version it looks up the highest applied version only, rather than checking each
+// candidate migration.
+func AnyTargetApplied(ctx context.Context, tracker state.StateTracker, dep backends.Dependency) (bool, error) {
+	filters := &state.MigrationFilters{
+		Connection: dep.Connection,
+		Schema:     dep.Schema,
+		Status:     "applied",
+		SortBy:     state.SortByVersion,
+		SortOrder:  state.SortDesc,
+	}
+	if dep.TargetType == "min_version" {
+		filters.Limit = 1
+	}
+	applied, _, err := tracker.GetMigrationList(ctx, filters)
+	if err != nil {
+		return false, fmt.Errorf("failed to get applied migrations of connection %s: %w", dep.Connection, err)
+	}
+	for _, item := range applied {
+		if dep.MatchesTarget(item.Version, item.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // buildDependencyGraph builds a dependency graph from migrations
 func (r *DependencyResolver) buildDependencyGraph(migrations []*backends.MigrationScript, getMigrationID func(*backends.MigrationScript) string) (*DependencyGraph, []string) {
 	graph := NewDependencyGraph()
@@ -283,6 +316,10 @@ func (r *DependencyResolver) buildDependencyGraph(migrations []*backends.Migrati
 				// Only add edge if target is in our current migration set and not a self-loop
 				if _, exists := graph.nodes[targetID]; exists && migrationID != targetID {
 					graph.AddEdge(migrationID, targetID)
+					// A min_version or version_range dependency only needs its lowest candidate in the set
+					if dep.AnyTarget() {
+						break
+					}
 				}
 			}
 		}
@@ -0,0 +1,249 @@
+package registry_test
+
+// These tests are in an external package as they use testsupport, which imports registry
+
+import (
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestDependencyResolver_FindDependencyTarget(t *testing.T) {
+	reg := registry.NewInMemoryRegistry()
+	tracker := testsupport.NewStateTracker()
+	resolver := registry.NewDependencyResolver(reg, tracker)
+
+	// Register test migrations
+	m1 := &backends.MigrationScript{
+		Version:    "20240101120000",
+		Name:       "bootstrap",
+		Connection: "core",
+		Schema:     "core",
+		Backend:    "postgresql",
+	}
+	_ = reg.Register(m1)
+
+	m2 := &backends.MigrationScript{
+		Version:    "20240101120001",
+		Name:       "bootstrap",
+		Connection: "guard",
+		Schema:     "guard",
+		Backend:    "postgresql",
+	}
+	_ = reg.Register(m2)
+
+	tests := []struct {
+		name    string
+		dep     backends.Dependency
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name: "find by name",
+			dep: backends.Dependency{
+				Target:     "bootstrap",
+				TargetType: "name",
+			},
+			wantLen: 2, // Should find both migrations with name "bootstrap"
+			wantErr: false,
+		},
+		{
+			name: "find by name and connection",
+			dep: backends.Dependency{
+				Connection: "core",
+				Target:     "bootstrap",
+				TargetType: "name",
+			},
+			wantLen: 1, // Should find only core connection
+			wantErr: false,
+		},
+		{
+			name: "find by version",
+			dep: backends.Dependency{
+				Target:     "20240101120000",
+				TargetType: "version",
+			},
+			wantLen: 1, // Only m1 has this version
+			wantErr: false,
+		},
+		{
+			name: "find by version and connection",
+			dep: backends.Dependency{
+				Connection: "core",
+				Target:     "20240101120000",
+				TargetType: "version",
+			},
+			wantLen: 1,
+			wantErr: false,
+		},
+		{
+			name: "find by min version",
+			dep: backends.Dependency{
+				Target:     "20240101120000",
+				TargetType: "min_version",
+			},
+			wantLen: 2, // m1 and the later m2
+			wantErr: false,
+		},
+		{
+			name: "find by min version and connection",
+			dep: backends.Dependency{
+				Connection: "core",
+				Target:     "20240101120001",
+				TargetType: "min_version",
+			},
+			wantLen: 0, // core has nothing at or above this version
+			wantErr: true,
+		},
+		{
+			name: "find by version range",
+			dep: backends.Dependency{
+				Target:     ">=20240101120000 <20240101120001",
+				TargetType: "version_range",
+			},
+			wantLen: 1, // Only m1, the upper bound is exclusive
+			wantErr: false,
+		},
+		{
+			name: "find by version range and name prefix",
+			dep: backends.Dependency{
+				Target:     "boot* >20240101120000",
+				TargetType: "version_range",
+			},
+			wantLen: 1, // Only m2, the lower bound is exclusive
+			wantErr: false,
+		},
+		{
+			name: "invalid version range",
+			dep: backends.Dependency{
+				Target:     ">=20240101120000 >=20240101120001",
+				TargetType: "version_range",
+			},
+			wantLen: 0,
+			wantErr: true,
+		},
+		{
+			name: "find by name prefix",
+			dep: backends.Dependency{
+				Target: "boot*",
+			},
+			wantLen: 2,
+			wantErr: false,
+		},
+		{
+			name: "not found",
+			dep: backends.Dependency{
+				Target:     "nonexistent",
+				TargetType: "name",
+			},
+			wantLen: 0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := resolver.ResolveDependencyTargets(tt.dep)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveDependencyTargets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(targets) != tt.wantLen {
+				t.Errorf("ResolveDependencyTargets() len = %v, want %v", len(targets), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestDependencyResolver_ResolveDependencies(t *testing.T) {
+	reg := registry.NewInMemoryRegistry()
+	tracker := testsupport.NewStateTracker()
+	resolver := registry.NewDependencyResolver(reg, tracker)
+
+	// Create migrations
+	m1 := &backends.MigrationScript{
+		Version:      "20240101120000",
+		Name:         "base",
+		Connection:   "core",
+		Backend:      "postgresql",
+		Dependencies: []string{},
+	}
+	_ = reg.Register(m1)
+
+	m2 := &backends.MigrationScript{
+		Version:      "20240101120001",
+		Name:         "dependent",
+		Connection:   "core",
+		Backend:      "postgresql",
+		Dependencies: []string{"base"},
+	}
+	_ = reg.Register(m2)
+
+	getMigrationID := func(m *backends.MigrationScript) string {
+		return m.Version + "_" + m.Name
+	}
+
+	t.Run("resolve simple dependencies", func(t *testing.T) {
+		migrations := []*backends.MigrationScript{m1, m2}
+		sorted, err := resolver.ResolveDependencies(migrations, getMigrationID)
+		if err != nil {
+			t.Fatalf("ResolveDependencies() error = %v", err)
+		}
+		if len(sorted) != 2 {
+			t.Fatalf("ResolveDependencies() len = %v, want 2", len(sorted))
+		}
+		// base should come before dependent
+		if sorted[0].Name != "base" {
+			t.Errorf("Expected base first, got %s", sorted[0].Name)
+		}
+		if sorted[1].Name != "dependent" {
+			t.Errorf("Expected dependent second, got %s", sorted[1].Name)
+		}
+	})
+
+	t.Run("resolve structured dependencies", func(t *testing.T) {
+		m3 := &backends.MigrationScript{
+			Version:    "20240101120002",
+			Name:       "structured_dep",
+			Connection: "core",
+			Backend:    "postgresql",
+			StructuredDependencies: []backends.Dependency{
+				{
+					Connection: "core",
+					Target:     "base",
+					TargetType: "name",
+				},
+			},
+		}
+		_ = reg.Register(m3)
+
+		migrations := []*backends.MigrationScript{m1, m3}
+		sorted, err := resolver.ResolveDependencies(migrations, getMigrationID)
+		if err != nil {
+			t.Fatalf("ResolveDependencies() error = %v", err)
+		}
+		if len(sorted) != 2 {
+			t.Fatalf("ResolveDependencies() len = %v, want 2", len(sorted))
+		}
+		if sorted[0].Name != "base" {
+			t.Errorf("Expected base first, got %s", sorted[0].Name)
+		}
+	})
+
+	t.Run("missing dependency", func(t *testing.T) {
+		m4 := &backends.MigrationScript{
+			Version:      "20240101120003",
+			Name:         "missing_dep",
+			Connection:   "core",
+			Backend:      "postgresql",
+			Dependencies: []string{"nonexistent"},
+		}
+		migrations := []*backends.MigrationScript{m4}
+		_, err := resolver.ResolveDependencies(migrations, getMigrationID)
+		if err == nil {
+			t.Error("Expected error for missing dependency")
+		}
+	})
+}
@@ -4,92 +4,8 @@ import (
 	"testing"
 
 	"github.com/toolsascode/bfm/api/internal/backends"
-	"github.com/toolsascode/bfm/api/internal/state"
 )
 
-// mockStateTracker for testing
-type mockStateTracker struct {
-	appliedMigrations map[string]bool
-}
-
-func newMockStateTracker() *mockStateTracker {
-	return &mockStateTracker{
-		appliedMigrations: make(map[string]bool),
-	}
-}
-
-func (m *mockStateTracker) RecordMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	return nil
-}
-
-func (m *mockStateTracker) RecordDependencyMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool, error) {
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error) {
-	// For mock, treat pending/applied the same as applied
-	return m.appliedMigrations[migrationID], nil
-}
-
-func (m *mockStateTracker) GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error) {
-	return "", nil
-}
-
-func (m *mockStateTracker) RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	return nil
-}
-
-func (m *mockStateTracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	return nil
-}
-
-func (m *mockStateTracker) DeleteMigration(ctx interface{}, migrationID string) error {
-	return nil
-}
-
-func (m *mockStateTracker) Initialize(ctx interface{}) error {
-	return nil
-}
-
-func (m *mockStateTracker) ReindexMigrations(ctx interface{}, registry interface{}) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetMigrationDetail(ctx interface{}, migrationID string) (*state.MigrationDetail, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-func (m *mockStateTracker) GetRecentExecutions(ctx interface{}, limit int) ([]*state.MigrationExecution, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
-	return nil
-}
-
-func (m *mockStateTracker) GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*state.SkippedMigration, error) {
-	return nil, nil
-}
-
-func (m *mockStateTracker) WithMigrationExecutionLock(_ interface{}, _, _, _ string, fn func() error) error {
-	return fn()
-}
-
 func TestDependencyGraph_AddNode(t *testing.T) {
 	graph := NewDependencyGraph()
 	migration := &backends.MigrationScript{
@@ -280,187 +196,3 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 		})
 	}
 }
-
-func TestDependencyResolver_FindDependencyTarget(t *testing.T) {
-	reg := NewInMemoryRegistry()
-	tracker := newMockStateTracker()
-	resolver := NewDependencyResolver(reg, tracker)
-
-	// Register test migrations
-	m1 := &backends.MigrationScript{
-		Version:    "20240101120000",
-		Name:       "bootstrap",
-		Connection: "core",
-		Schema:     "core",
-		Backend:    "postgresql",
-	}
-	_ = reg.Register(m1)
-
-	m2 := &backends.MigrationScript{
-		Version:    "20240101120001",
-		Name:       "bootstrap",
-		Connection: "guard",
-		Schema:     "guard",
-		Backend:    "postgresql",
-	}
-	_ = reg.Register(m2)
-
-	tests := []struct {
-		name    string
-		dep     backends.Dependency
-		wantLen int
-		wantErr bool
-	}{
-		{
-			name: "find by name",
-			dep: backends.Dependency{
-				Target:     "bootstrap",
-				TargetType: "name",
-			},
-			wantLen: 2, // Should find both migrations with name "bootstrap"
-			wantErr: false,
-		},
-		{
-			name: "find by name and connection",
-			dep: backends.Dependency{
-				Connection: "core",
-				Target:     "bootstrap",
-				TargetType: "name",
-			},
-			wantLen: 1, // Should find only core connection
-			wantErr: false,
-		},
-		{
-			name: "find by version",
-			dep: backends.Dependency{
-				Target:     "20240101120000",
-				TargetType: "version",
-			},
-			wantLen: 1, // Only m1 has this version
-			wantErr: false,
-		},
-		{
-			name: "find by version and connection",
-			dep: backends.Dependency{
-				Connection: "core",
-				Target:     "20240101120000",
-				TargetType: "version",
-			},
-			wantLen: 1,
-			wantErr: false,
-		},
-		{
-			name: "not found",
-			dep: backends.Dependency{
-				Target:     "nonexistent",
-				TargetType: "name",
-			},
-			wantLen: 0,
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			targets, err := resolver.findDependencyTarget(tt.dep)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("findDependencyTarget() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if len(targets) != tt.wantLen {
-				t.Errorf("findDependencyTarget() len = %v, want %v", len(targets), tt.wantLen)
-			}
-		})
-	}
-}
-
-func TestDependencyResolver_ResolveDependencies(t *testing.T) {
-	reg := NewInMemoryRegistry()
-	tracker := newMockStateTracker()
-	resolver := NewDependencyResolver(reg, tracker)
-
-	// Create migrations
-	m1 := &backends.MigrationScript{
-		Version:      "20240101120000",
-		Name:         "base",
-		Connection:   "core",
-		Backend:      "postgresql",
-		Dependencies: []string{},
-	}
-	_ = reg.Register(m1)
-
-	m2 := &backends.MigrationScript{
-		Version:      "20240101120001",
-		Name:         "dependent",
-		Connection:   "core",
-		Backend:      "postgresql",
-		Dependencies: []string{"base"},
-	}
-	_ = reg.Register(m2)
-
-	getMigrationID := func(m *backends.MigrationScript) string {
-		return m.Version + "_" + m.Name
-	}
-
-	t.Run("resolve simple dependencies", func(t *testing.T) {
-		migrations := []*backends.MigrationScript{m1, m2}
-		sorted, err := resolver.ResolveDependencies(migrations, getMigrationID)
-		if err != nil {
-			t.Fatalf("ResolveDependencies() error = %v", err)
-		}
-		if len(sorted) != 2 {
-			t.Fatalf("ResolveDependencies() len = %v, want 2", len(sorted))
-		}
-		// base should come before dependent
-		if sorted[0].Name != "base" {
-			t.Errorf("Expected base first, got %s", sorted[0].Name)
-		}
-		if sorted[1].Name != "dependent" {
-			t.Errorf("Expected dependent second, got %s", sorted[1].Name)
-		}
-	})
-
-	t.Run("resolve structured dependencies", func(t *testing.T) {
-		m3 := &backends.MigrationScript{
-			Version:    "20240101120002",
-			Name:       "structured_dep",
-			Connection: "core",
-			Backend:    "postgresql",
-			StructuredDependencies: []backends.Dependency{
-				{
-					Connection: "core",
-					Target:     "base",
-					TargetType: "name",
-				},
-			},
-		}
-		_ = reg.Register(m3)
-
-		migrations := []*backends.MigrationScript{m1, m3}
-		sorted, err := resolver.ResolveDependencies(migrations, getMigrationID)
-		if err != nil {
-			t.Fatalf("ResolveDependencies() error = %v", err)
-		}
-		if len(sorted) != 2 {
-			t.Fatalf("ResolveDependencies() len = %v, want 2", len(sorted))
-		}
-		if sorted[0].Name != "base" {
-			t.Errorf("Expected base first, got %s", sorted[0].Name)
-		}
-	})
-
-	t.Run("missing dependency", func(t *testing.T) {
-		m4 := &backends.MigrationScript{
-			Version:      "20240101120003",
-			Name:         "missing_dep",
-			Connection:   "core",
-			Backend:      "postgresql",
-			Dependencies: []string{"nonexistent"},
-		}
-		migrations := []*backends.MigrationScript{m4}
-		_, err := resolver.ResolveDependencies(migrations, getMigrationID)
-		if err == nil {
-			t.Error("Expected error for missing dependency")
-		}
-	})
-}
@@ -23,17 +23,24 @@ func normalizedBackendName(backend string) string {
 
 // MigrationTarget specifies which migrations to execute (moved here to avoid import cycle)
 type MigrationTarget struct {
-	Backend    string   `json:"backend"`        // Backend type filter
-	Schema     string   `json:"schema"`         // Schema filter (optional)
-	Tables     []string `json:"tables"`         // Table filters (optional, empty = all)
-	Version    string   `json:"version"`        // Version filter (optional, empty = latest)
+	Backend string   `json:"backend"` // Backend type filter
+	Schema  string   `json:"schema"`  // Schema filter (optional)
+	Tables  []string `json:"tables"`  // Table filters (optional, empty = all); matches migrations declaring one with "-- bfm-table:"
+	Version string   `json:"version"` // Version filter (optional, empty = latest)
+	// MaxVersion limits the migrations to this version and older ("migrate to version X"), e.g.
+	// the latest version of a tagged release (optional, empty = no limit)
+	MaxVersion string   `json:"max_version,omitempty"`
 	Connection string   `json:"connection"`     // Connection name filter
 	Tags       []string `json:"tags,omitempty"` // Optional key=value filters (AND); empty = no tag filter
+	// SchemaPattern fans an up execution out to the schemas of the connection's database matching
+	// this SQL LIKE pattern (e.g. "tenant_%"), instead of explicit schemas. It does not filter migrations.
+	SchemaPattern string `json:"schema_pattern,omitempty"`
 }
 
 // Registry manages migration script registration and lookup
 type Registry interface {
-	// Register registers a migration script
+	// Register registers a migration script. A migration with the version or name of a registered
+	// migration of its connection is refused with a *ConflictError.
 	Register(migration *backends.MigrationScript) error
 
 	// FindByTarget finds migrations matching a target specification
@@ -74,6 +81,9 @@ type inMemoryRegistry struct {
 
 func (r *inMemoryRegistry) Register(migration *backends.MigrationScript) error {
 	migrationID := r.getMigrationID(migration)
+	if conflict := findConflict(r.migrations, migrationID, migration); conflict != nil {
+		return conflict
+	}
 	r.migrations[migrationID] = migration
 	return nil
 }
@@ -89,6 +99,9 @@ func (r *inMemoryRegistry) FindByTarget(target *MigrationTarget) ([]*backends.Mi
 			return nil, err
 		}
 	}
+	if err := ValidateTargetTables(r, target); err != nil {
+		return nil, err
+	}
 
 	for _, migration := range r.migrations {
 		if target.Backend != "" && !BackendNamesMatch(target.Backend, migration.Backend) {
@@ -119,6 +132,9 @@ func (r *inMemoryRegistry) FindByTarget(target *MigrationTarget) ([]*backends.Mi
 		if target.Version != "" && migration.Version != target.Version {
 			continue
 		}
+		if target.MaxVersion != "" && VersionAfter(migration.Version, target.MaxVersion) {
+			continue
+		}
 		if len(requiredTags) > 0 && !MatchesTagFilter(migration.Tags, requiredTags) {
 			continue
 		}
@@ -128,6 +144,15 @@ func (r *inMemoryRegistry) FindByTarget(target *MigrationTarget) ([]*backends.Mi
 	return results, nil
 }
 
+// VersionAfter reports whether migration version a sorts after b. Versions are timestamps, so
+// longer ones are later.
+func VersionAfter(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
 func (r *inMemoryRegistry) GetAll() []*backends.MigrationScript {
 	results := make([]*backends.MigrationScript, 0, len(r.migrations))
 	for _, migration := range r.migrations {
@@ -1,6 +1,9 @@
 package registry
 
 import (
+	"errors"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/toolsascode/bfm/api/internal/backends"
@@ -36,6 +39,47 @@ func TestInMemoryRegistry_Register(t *testing.T) {
 	}
 }
 
+func TestInMemoryRegistry_RegisterConflicts(t *testing.T) {
+	reg := NewInMemoryRegistry()
+	migration := func(version, name, connection string) *backends.MigrationScript {
+		return &backends.MigrationScript{Version: version, Name: name, Connection: connection, Backend: "postgresql"}
+	}
+	users := migration("20240101120000", "create_users", "core")
+	if err := reg.Register(users); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Registering the same migration again replaces it
+	users = migration("20240101120000", "create_users", "core")
+	if err := reg.Register(users); err != nil {
+		t.Errorf("expected re-registering a migration to replace it, got %v", err)
+	}
+	// The same version or name on another connection is fine
+	if err := reg.Register(migration("20240101120000", "create_users", "billing")); err != nil {
+		t.Errorf("expected no conflict across connections, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		migration *backends.MigrationScript
+		kind      string
+	}{
+		{migration("20240101120000", "create_accounts", "core"), ConflictVersion},
+		{&backends.MigrationScript{Version: "20240201120000", Name: "create_users", Connection: "core", Backend: "postgres"}, ConflictName},
+	} {
+		err := reg.Register(tc.migration)
+		conflict, ok := err.(*ConflictError)
+		if !ok || !errors.Is(err, ErrMigrationConflict) || conflict.Kind != tc.kind || conflict.Existing != users {
+			t.Errorf("expected a %s conflict with create_users, got %v", tc.kind, err)
+		}
+	}
+	if got := len(reg.GetAll()); got != 2 {
+		t.Errorf("expected the conflicting migrations not to be registered, got %d migrations", got)
+	}
+	if err := reg.Register(migration("20240101120000", "create_accounts", "core")); !strings.Contains(err.Error(), "20240101120000_create_users and 20240101120000_create_accounts of connection core have the same version 20240101120000") {
+		t.Errorf("unexpected message %q", err)
+	}
+}
+
 func TestInMemoryRegistry_FindByTarget(t *testing.T) {
 	reg := NewInMemoryRegistry()
 
@@ -71,6 +115,7 @@ func TestInMemoryRegistry_FindByTarget(t *testing.T) {
 		target   *MigrationTarget
 		wantLen  int
 		wantName string
+		wantErr  bool
 	}{
 		{
 			name: "filter by connection",
@@ -109,19 +154,20 @@ func TestInMemoryRegistry_FindByTarget(t *testing.T) {
 			wantLen: 0,
 		},
 		{
-			name: "filter by tables",
+			name: "filter by undeclared table",
 			target: &MigrationTarget{
 				Tables: []string{"users"},
 			},
 			wantLen: 0,
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			results, err := reg.FindByTarget(tt.target)
-			if err != nil {
-				t.Errorf("FindByTarget() error = %v", err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FindByTarget() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if len(results) != tt.wantLen {
 				t.Errorf("Expected %d results, got %d", tt.wantLen, len(results))
@@ -321,6 +367,46 @@ func TestInMemoryRegistry_FindByTarget_WithSchema(t *testing.T) {
 	}
 }
 
+func TestInMemoryRegistry_FindByTarget_MaxVersion(t *testing.T) {
+	reg := NewInMemoryRegistry()
+	for _, version := range []string{"20240101120000", "20250101120000", "20250201120000"} {
+		_ = reg.Register(&backends.MigrationScript{
+			Schema: "public", Version: version, Name: "migration_" + version, Connection: "test", Backend: "postgresql",
+			UpSQL: "SELECT 1;",
+		})
+	}
+
+	results, err := reg.FindByTarget(&MigrationTarget{Connection: "test", MaxVersion: "20250101120000"})
+	if err != nil {
+		t.Fatalf("FindByTarget() error = %v", err)
+	}
+	var versions []string
+	for _, migration := range results {
+		versions = append(versions, migration.Version)
+	}
+	sort.Strings(versions)
+	if got := strings.Join(versions, ","); got != "20240101120000,20250101120000" {
+		t.Errorf("expected the migrations up to and including the max version, got %s", got)
+	}
+}
+
+func TestVersionAfter(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"20250101120000", "20240101120000", true},
+		{"20240101120000", "20240101120000", false},
+		{"20240101120000", "", true},
+		{"100", "99", true},
+		{"99", "100", false},
+	} {
+		if got := VersionAfter(tt.a, tt.b); got != tt.want {
+			t.Errorf("VersionAfter(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func TestInMemoryRegistry_FindByTarget_WithTables(t *testing.T) {
 	reg := NewInMemoryRegistry()
 
@@ -363,3 +449,34 @@ func TestInMemoryRegistry_FindByTarget_WithTables(t *testing.T) {
 		t.Errorf("Expected migration1, got %v", results[0].Name)
 	}
 }
+
+func TestValidateTargetTables(t *testing.T) {
+	reg := NewInMemoryRegistry()
+	users := "users"
+	_ = reg.Register(&backends.MigrationScript{
+		Table: &users, Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql",
+	})
+	_ = reg.Register(&backends.MigrationScript{
+		Version: "20240102120000", Name: "seed", Connection: "core", Backend: "postgresql",
+	})
+
+	tests := []struct {
+		name    string
+		target  *MigrationTarget
+		wantErr bool
+	}{
+		{name: "no tables", target: &MigrationTarget{Connection: "core"}},
+		{name: "nil target", target: nil},
+		{name: "declared table", target: &MigrationTarget{Tables: []string{"users"}}},
+		{name: "postgres alias", target: &MigrationTarget{Backend: "postgres", Tables: []string{"users"}}},
+		{name: "undeclared table", target: &MigrationTarget{Tables: []string{"users", "orders"}}, wantErr: true},
+		{name: "other backend", target: &MigrationTarget{Backend: "greptimedb", Tables: []string{"users"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTargetTables(reg, tt.target); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTargetTables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
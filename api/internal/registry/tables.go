@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateTargetTables checks that every table in the target is declared by at least one registered
+// migration of the target's backend. Migrations declare their table with a "-- bfm-table: <name>"
+// line in the up script; a table no migration declares (typically a typo) would otherwise match
+// nothing and run no migrations without any error.
+func ValidateTargetTables(reg Registry, target *MigrationTarget) error {
+	if target == nil || len(target.Tables) == 0 {
+		return nil
+	}
+
+	declared := make(map[string]bool)
+	for _, migration := range reg.GetAll() {
+		if migration.Table == nil {
+			continue
+		}
+		if target.Backend != "" && !BackendNamesMatch(target.Backend, migration.Backend) {
+			continue
+		}
+		declared[*migration.Table] = true
+	}
+
+	var unknown []string
+	for _, table := range target.Tables {
+		if !declared[table] {
+			unknown = append(unknown, table)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("tables not declared by any migration: %s (declare a migration's table with a \"-- bfm-table: <name>\" line in its up script)", strings.Join(unknown, ", "))
+}
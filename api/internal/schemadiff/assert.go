@@ -0,0 +1,131 @@
+package schemadiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Assertion is a statement about the tables of a schema, checked after a migration ran:
+//
+//	table users exists
+//	table old_users does not exist
+//	table users has column email
+//	table users has column email NOT NULL
+//	table users has column email type character varying(255) NOT NULL
+//	table users has no column nickname
+//	table users has index users_email_idx
+//	table users has constraint users_pkey
+//	table users has no constraint users_email_key
+//
+// Keywords are case-insensitive. Tables may be qualified with their schema (billing.invoices);
+// unqualified tables are in the schema the migration ran in.
+type Assertion struct {
+	Text    string // The assertion as written
+	Table   string // Table name, possibly schema-qualified
+	Negated bool   // "does not exist", "has no ..."
+	Object  string // "", "column", "index" or "constraint"
+	Name    string // Column, index or constraint name
+	Type    string // Expected column type, "" for any
+	NotNull *bool  // Expected column nullability, nil for any
+}
+
+var (
+	tableAssertionRe  = regexp.MustCompile(`(?i)^table\s+(\S+)\s+(exists|does\s+not\s+exist)$`)
+	objectAssertionRe = regexp.MustCompile(`(?i)^table\s+(\S+)\s+has\s+(no\s+)?(column|index|constraint)\s+(\S+)(.*)$`)
+	columnSuffixRe    = regexp.MustCompile(`(?i)^(?:\s+type\s+(.+?))?(?:\s+(not\s+null|null))?$`)
+)
+
+// ParseAssertion parses an assertion (see Assertion)
+func ParseAssertion(text string) (*Assertion, error) {
+	text = strings.TrimSpace(text)
+	if m := tableAssertionRe.FindStringSubmatch(text); m != nil {
+		return &Assertion{Text: text, Table: unquote(m[1]), Negated: !strings.EqualFold(m[2], "exists")}, nil
+	}
+	m := objectAssertionRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("invalid assertion %q (expected e.g. \"table users has column email NOT NULL\")", text)
+	}
+	assertion := &Assertion{Text: text, Table: unquote(m[1]), Negated: m[2] != "", Object: strings.ToLower(m[3]), Name: unquote(m[4])}
+	if m[5] == "" {
+		return assertion, nil
+	}
+	suffix := columnSuffixRe.FindStringSubmatch(m[5])
+	if assertion.Object != "column" || assertion.Negated || suffix == nil {
+		return nil, fmt.Errorf("invalid assertion %q: only columns that must exist can be given a type or NOT NULL", text)
+	}
+	assertion.Type = strings.TrimSpace(suffix[1])
+	if suffix[2] != "" {
+		notNull := strings.HasPrefix(strings.ToLower(suffix[2]), "not")
+		assertion.NotNull = &notNull
+	}
+	return assertion, nil
+}
+
+// Key returns the schema-qualified name of the asserted table, in defaultSchema when unqualified
+func (a *Assertion) Key(defaultSchema string) string {
+	if strings.Contains(a.Table, ".") {
+		return a.Table
+	}
+	return defaultSchema + "." + a.Table
+}
+
+// Check returns an error describing how schema fails the assertion, or nil when it holds.
+// Unqualified tables are looked up in defaultSchema.
+func (a *Assertion) Check(schema *Schema, defaultSchema string) error {
+	table := schema.Tables[a.Key(defaultSchema)]
+	if a.Object == "" {
+		switch {
+		case table == nil && !a.Negated:
+			return fmt.Errorf("%s: table %s does not exist", a.Text, a.Key(defaultSchema))
+		case table != nil && a.Negated:
+			return fmt.Errorf("%s: table %s exists", a.Text, a.Key(defaultSchema))
+		}
+		return nil
+	}
+	if table == nil {
+		return fmt.Errorf("%s: table %s does not exist", a.Text, a.Key(defaultSchema))
+	}
+
+	var found bool
+	switch a.Object {
+	case "column":
+		column := table.column(a.Name)
+		found = column != nil
+		if found && !a.Negated {
+			if a.Type != "" && !strings.EqualFold(column.Type, a.Type) {
+				return fmt.Errorf("%s: column %s has type %s", a.Text, a.Name, column.Type)
+			}
+			if a.NotNull != nil && column.NotNull && !*a.NotNull {
+				return fmt.Errorf("%s: column %s is NOT NULL", a.Text, a.Name)
+			}
+			if a.NotNull != nil && !column.NotNull && *a.NotNull {
+				return fmt.Errorf("%s: column %s is nullable", a.Text, a.Name)
+			}
+		}
+	case "index":
+		// Primary key, unique and exclusion constraints are backed by an index of their name
+		constraint := table.constraint(a.Name)
+		found = table.index(a.Name) != nil || (constraint != nil && strings.ContainsAny(constraint.Type, "pux"))
+	case "constraint":
+		found = table.constraint(a.Name) != nil
+	}
+	if found == a.Negated {
+		if found {
+			return fmt.Errorf("%s: %s %s exists", a.Text, a.Object, a.Name)
+		}
+		return fmt.Errorf("%s: %s %s does not exist", a.Text, a.Object, a.Name)
+	}
+	return nil
+}
+
+// unquote removes the double quotes of a quoted identifier, per dot-separated part
+func unquote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if len(part) >= 2 && strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) {
+			parts[i] = strings.ReplaceAll(part[1:len(part)-1], `""`, `"`)
+		}
+	}
+	return strings.Join(parts, ".")
+}
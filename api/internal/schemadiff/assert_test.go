@@ -0,0 +1,63 @@
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssertion_Check(t *testing.T) {
+	schema := NewSchema(usersTable(), &Table{Schema: "billing", Name: "invoices"})
+	schema.Tables["public.users"].Indexes = []*Index{{Name: "users_email_idx", Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"}}
+
+	holds := []string{
+		"table users exists",
+		"TABLE billing.invoices EXISTS",
+		"table old_users does not exist",
+		"table users has column email",
+		"table users has column email NOT NULL",
+		"table users has column email type character varying(255) not null",
+		"table users has column id type bigint",
+		"table users has no column nickname",
+		"table users has index users_email_idx",
+		"table users has index users_pkey",
+		"table users has constraint users_pkey",
+		"table users has no constraint users_email_key",
+		`table "users" has column "email"`,
+	}
+	for _, text := range holds {
+		assertion, err := ParseAssertion(text)
+		if err != nil {
+			t.Fatalf("ParseAssertion(%q): %v", text, err)
+		}
+		if err := assertion.Check(schema, "public"); err != nil {
+			t.Errorf("expected %q to hold, got %v", text, err)
+		}
+	}
+
+	fails := map[string]string{
+		"table orders exists":                      "table public.orders does not exist",
+		"table users does not exist":               "table public.users exists",
+		"table users has column email NULL":        "column email is NOT NULL",
+		"table users has column email type text":   "column email has type character varying(255)",
+		"table users has column nickname":          "column nickname does not exist",
+		"table users has no column email":          "column email exists",
+		"table orders has column id":               "table public.orders does not exist",
+		"table users has index users_nickname_idx": "index users_nickname_idx does not exist",
+		"table users has no constraint users_pkey": "constraint users_pkey exists",
+	}
+	for text, want := range fails {
+		assertion, err := ParseAssertion(text)
+		if err != nil {
+			t.Fatalf("ParseAssertion(%q): %v", text, err)
+		}
+		if err := assertion.Check(schema, "public"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to fail with %q, got %v", text, want, err)
+		}
+	}
+
+	for _, text := range []string{"users has column email", "table users has column", "table users has index idx NOT NULL", "table users has no column email NOT NULL"} {
+		if _, err := ParseAssertion(text); err == nil {
+			t.Errorf("expected %q to be invalid", text)
+		}
+	}
+}
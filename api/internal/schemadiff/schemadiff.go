@@ -0,0 +1,283 @@
+// Package schemadiff compares the tables of two database schemas and generates the statements
+// that turn one into the other. It covers a conservative subset of PostgreSQL: tables, columns
+// (type, nullability, default, identity), indexes and table constraints. Views, functions,
+// sequences, triggers and privileges are not compared.
+//
+// The statements are a candidate migration for review, not a guaranteed-safe one: a column type
+// change may need a USING clause, and dropped tables and columns lose their data.
+package schemadiff
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Inspector is implemented by backends that can read the tables of a database
+type Inspector interface {
+	// InspectSchema returns the tables of the given schemas of the connected database
+	InspectSchema(ctx context.Context, schemas []string) (*Schema, error)
+}
+
+// Schema is the tables of one or more database schemas, keyed by Table.Key
+type Schema struct {
+	Tables map[string]*Table
+}
+
+// Table is a table with its columns, indexes and constraints
+type Table struct {
+	Schema      string
+	Name        string
+	Columns     []*Column     // In column order
+	Indexes     []*Index      // Indexes that don't back a constraint
+	Constraints []*Constraint // Primary keys, unique, check, foreign key and exclusion constraints
+}
+
+// Column is a table column
+type Column struct {
+	Name     string
+	Type     string // As given by format_type, e.g. "character varying(255)"
+	NotNull  bool
+	Default  string // Default expression, "" when the column has none
+	Identity string // "ALWAYS" or "BY DEFAULT" for identity columns, else ""
+}
+
+// Index is an index that doesn't back a constraint
+type Index struct {
+	Name       string
+	Definition string // Full CREATE INDEX statement, as given by pg_get_indexdef
+}
+
+// Constraint is a table constraint
+type Constraint struct {
+	Name       string
+	Type       string // "p", "u", "c", "f" or "x", as in pg_constraint.contype
+	Definition string // As given by pg_get_constraintdef, e.g. "PRIMARY KEY (id)"
+}
+
+// NewSchema returns a schema of tables
+func NewSchema(tables ...*Table) *Schema {
+	schema := &Schema{Tables: make(map[string]*Table)}
+	for _, table := range tables {
+		schema.Tables[table.Key()] = table
+	}
+	return schema
+}
+
+// Key returns the schema-qualified name of the table
+func (t *Table) Key() string {
+	return t.Schema + "." + t.Name
+}
+
+// qualifiedName returns the quoted schema-qualified name of the table
+func (t *Table) qualifiedName() string {
+	return QuoteIdent(t.Schema) + "." + QuoteIdent(t.Name)
+}
+
+func (t *Table) column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+func (t *Table) index(name string) *Index {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+	return nil
+}
+
+func (t *Table) constraint(name string) *Constraint {
+	for _, constraint := range t.Constraints {
+		if constraint.Name == name {
+			return constraint
+		}
+	}
+	return nil
+}
+
+// simpleIdentRe matches identifiers that need no quoting, unless they are reserved words
+var simpleIdentRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedWords are the reserved words most likely to be used as table or column names
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "asc": true, "case": true, "check": true, "column": true,
+	"constraint": true, "default": true, "desc": true, "end": true, "false": true, "from": true,
+	"group": true, "index": true, "limit": true, "not": true, "null": true, "offset": true, "on": true,
+	"or": true, "order": true, "primary": true, "references": true, "select": true, "table": true,
+	"to": true, "true": true, "user": true, "when": true, "where": true,
+}
+
+// QuoteIdent quotes a PostgreSQL identifier when it needs to be
+func QuoteIdent(name string) string {
+	if simpleIdentRe.MatchString(name) && !reservedWords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// serialTypes are the serial pseudo-types of the integer types whose default is a sequence
+var serialTypes = map[string]string{"smallint": "smallserial", "integer": "serial", "bigint": "bigserial"}
+
+// definition returns the column definition of CREATE TABLE and ADD COLUMN. A column defaulting
+// to a sequence becomes serial, so the sequence is created with it.
+func (c *Column) definition() string {
+	def := QuoteIdent(c.Name) + " "
+	serial, isSerial := serialTypes[c.Type]
+	switch {
+	case isSerial && strings.HasPrefix(c.Default, "nextval("):
+		def += serial
+	case c.Identity != "":
+		def += c.Type + " GENERATED " + c.Identity + " AS IDENTITY"
+	default:
+		def += c.Type
+		if c.Default != "" {
+			def += " DEFAULT " + c.Default
+		}
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// Statements returns the statements that turn from into to: constraints and indexes that change
+// are dropped first, then tables are dropped and created, columns added, altered and dropped, and
+// constraints and indexes created last, so foreign keys are added once the tables they reference
+// exist. Objects are visited in name order, so the same schemas always give the same statements.
+func Statements(from, to *Schema) []string {
+	var drops, tables, columns, adds []string
+
+	for _, key := range sortedKeys(from.Tables) {
+		old := from.Tables[key]
+		table, kept := to.Tables[key]
+		if !kept {
+			tables = append(tables, fmt.Sprintf("DROP TABLE %s;", old.qualifiedName()))
+			continue
+		}
+		for _, constraint := range old.Constraints {
+			if c := table.constraint(constraint.Name); c == nil || c.Definition != constraint.Definition {
+				drops = append(drops, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", old.qualifiedName(), QuoteIdent(constraint.Name)))
+			}
+		}
+		for _, index := range old.Indexes {
+			if i := table.index(index.Name); i == nil || i.Definition != index.Definition {
+				drops = append(drops, fmt.Sprintf("DROP INDEX %s.%s;", QuoteIdent(old.Schema), QuoteIdent(index.Name)))
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(to.Tables) {
+		table := to.Tables[key]
+		old, exists := from.Tables[key]
+		if !exists {
+			var defs []string
+			for _, column := range table.Columns {
+				defs = append(defs, "    "+column.definition())
+			}
+			tables = append(tables, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table.qualifiedName(), strings.Join(defs, ",\n")))
+			old = &Table{Schema: table.Schema, Name: table.Name}
+		} else {
+			columns = append(columns, alterColumns(old, table)...)
+		}
+
+		for _, constraint := range table.Constraints {
+			if c := old.constraint(constraint.Name); c == nil || c.Definition != constraint.Definition {
+				adds = append(adds, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", table.qualifiedName(), QuoteIdent(constraint.Name), constraint.Definition))
+			}
+		}
+		for _, index := range table.Indexes {
+			if i := old.index(index.Name); i == nil || i.Definition != index.Definition {
+				adds = append(adds, index.Definition+";")
+			}
+		}
+	}
+
+	statements := append(drops, tables...)
+	statements = append(statements, columns...)
+	return append(statements, adds...)
+}
+
+// alterColumns returns the statements that turn the columns of from into those of to
+func alterColumns(from, to *Table) []string {
+	var statements []string
+	alter := func(format string, args ...interface{}) {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ", to.qualifiedName())+fmt.Sprintf(format, args...)+";")
+	}
+	for _, column := range to.Columns {
+		old := from.column(column.Name)
+		if old == nil {
+			alter("ADD COLUMN %s", column.definition())
+			continue
+		}
+		name := QuoteIdent(column.Name)
+		if old.Type != column.Type {
+			alter("ALTER COLUMN %s TYPE %s", name, column.Type)
+		}
+		if old.Default != column.Default {
+			if column.Default == "" {
+				alter("ALTER COLUMN %s DROP DEFAULT", name)
+			} else {
+				alter("ALTER COLUMN %s SET DEFAULT %s", name, column.Default)
+			}
+		}
+		if old.NotNull != column.NotNull {
+			if column.NotNull {
+				alter("ALTER COLUMN %s SET NOT NULL", name)
+			} else {
+				alter("ALTER COLUMN %s DROP NOT NULL", name)
+			}
+		}
+	}
+	for _, column := range from.Columns {
+		if to.column(column.Name) == nil {
+			alter("DROP COLUMN %s", QuoteIdent(column.Name))
+		}
+	}
+	return statements
+}
+
+func sortedKeys(tables map[string]*Table) []string {
+	keys := make([]string, 0, len(tables))
+	for key := range tables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Diff is the difference between a target schema and a reference schema, as a candidate
+// migration of the target
+type Diff struct {
+	Up   []string // Statements that turn the target into the reference
+	Down []string // Statements that turn it back
+}
+
+// Compare returns the diff that brings target to reference
+func Compare(target, reference *Schema) *Diff {
+	return &Diff{Up: Statements(target, reference), Down: Statements(reference, target)}
+}
+
+// HasChanges reports whether the schemas differ
+func (d *Diff) HasChanges() bool {
+	return len(d.Up) > 0
+}
+
+// Script returns statements as a migration script, one statement per paragraph
+func Script(header string, statements []string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for _, statement := range statements {
+		b.WriteString("\n")
+		b.WriteString(statement)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package schemadiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func usersTable() *Table {
+	return &Table{
+		Schema: "public",
+		Name:   "users",
+		Columns: []*Column{
+			{Name: "id", Type: "bigint", NotNull: true, Default: "nextval('public.users_id_seq'::regclass)"},
+			{Name: "email", Type: "character varying(255)", NotNull: true},
+		},
+		Constraints: []*Constraint{{Name: "users_pkey", Type: "p", Definition: "PRIMARY KEY (id)"}},
+	}
+}
+
+func TestStatements_CreateAndDropTable(t *testing.T) {
+	orders := &Table{
+		Schema: "public",
+		Name:   "orders",
+		Columns: []*Column{
+			{Name: "id", Type: "integer", NotNull: true, Identity: "ALWAYS"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "order", Type: "text", Default: "'new'::text"},
+		},
+		Indexes:     []*Index{{Name: "orders_user_id_idx", Definition: "CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id)"}},
+		Constraints: []*Constraint{{Name: "orders_user_id_fkey", Type: "f", Definition: "FOREIGN KEY (user_id) REFERENCES public.users(id)"}},
+	}
+	target := NewSchema()
+	reference := NewSchema(usersTable(), orders)
+
+	diff := Compare(target, reference)
+	want := []string{
+		"CREATE TABLE public.orders (\n    id integer GENERATED ALWAYS AS IDENTITY NOT NULL,\n    user_id bigint,\n    \"order\" text DEFAULT 'new'::text\n);",
+		"CREATE TABLE public.users (\n    id bigserial NOT NULL,\n    email character varying(255) NOT NULL\n);",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);",
+		"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id);",
+		"ALTER TABLE public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);",
+	}
+	if !reflect.DeepEqual(diff.Up, want) {
+		t.Errorf("unexpected up statements:\n%q", diff.Up)
+	}
+	if want := []string{"DROP TABLE public.orders;", "DROP TABLE public.users;"}; !reflect.DeepEqual(diff.Down, want) {
+		t.Errorf("unexpected down statements:\n%q", diff.Down)
+	}
+}
+
+func TestStatements_AlterTable(t *testing.T) {
+	old := usersTable()
+	old.Columns = append(old.Columns, &Column{Name: "nickname", Type: "text"})
+	old.Indexes = []*Index{{Name: "users_email_idx", Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"}}
+
+	changed := usersTable()
+	changed.Columns[1] = &Column{Name: "email", Type: "text", Default: "''::text"}
+	changed.Columns = append(changed.Columns, &Column{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Default: "now()"})
+	changed.Indexes = []*Index{{Name: "users_email_idx", Definition: "CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email)"}}
+
+	up := Statements(NewSchema(old), NewSchema(changed))
+	want := []string{
+		"DROP INDEX public.users_email_idx;",
+		"ALTER TABLE public.users ALTER COLUMN email TYPE text;",
+		"ALTER TABLE public.users ALTER COLUMN email SET DEFAULT ''::text;",
+		"ALTER TABLE public.users ALTER COLUMN email DROP NOT NULL;",
+		"ALTER TABLE public.users ADD COLUMN created_at timestamp with time zone DEFAULT now() NOT NULL;",
+		"ALTER TABLE public.users DROP COLUMN nickname;",
+		"CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email);",
+	}
+	if !reflect.DeepEqual(up, want) {
+		t.Errorf("unexpected statements:\n%q", up)
+	}
+
+	if diff := Compare(NewSchema(usersTable()), NewSchema(usersTable())); diff.HasChanges() || len(diff.Down) != 0 {
+		t.Errorf("expected identical schemas to have no changes, got %+v", diff)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	for name, want := range map[string]string{
+		"users":     "users",
+		"user":      `"user"`,
+		"Users":     `"Users"`,
+		"my table":  `"my table"`,
+		`say"hi"`:   `"say""hi"""`,
+		"_private1": "_private1",
+	} {
+		if got := QuoteIdent(name); got != want {
+			t.Errorf("QuoteIdent(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the static credentials requests to AWS are signed with
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// AWS reads secrets from AWS Secrets Manager, calling GetSecretValue with Signature Version 4.
+// The path of a reference is the secret's name or ARN.
+type AWS struct {
+	region      string
+	endpoint    string
+	credentials AWSCredentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewAWS creates an AWS Secrets Manager provider for region, calling endpoint instead of the
+// region's when set (a VPC endpoint, LocalStack)
+func NewAWS(region, endpoint string, credentials AWSCredentials) *AWS {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	return &AWS{
+		region:      region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		credentials: credentials,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+}
+
+// Get reads the current version of the secret at path. With a key, the secret string must be a
+// JSON object (as the console stores key/value secrets) and the key's value is returned; without
+// one, the whole secret string is.
+func (a *AWS) Get(ctx context.Context, path, key string) (string, time.Duration, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, body, a.credentials, a.region, "secretsmanager", a.now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("aws secrets manager: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var parsed struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", 0, fmt.Errorf("aws secrets manager: failed to decode secret: %w", err)
+	}
+	if key == "" {
+		return parsed.SecretString, 0, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(parsed.SecretString), &fields); err != nil {
+		return "", 0, fmt.Errorf("aws secrets manager: secret %s is not a JSON object, reference it without #%s", path, key)
+	}
+	value, err := field(fields, key)
+	if err != nil {
+		return "", 0, fmt.Errorf("aws secrets manager: %w", err)
+	}
+	return value, 0, nil
+}
+
+// signV4 signs req, of payload body, with AWS Signature Version 4. The host, the content type and
+// the X-Amz-* headers are signed.
+func signV4(req *http.Request, body []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.Query().Encode(), canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
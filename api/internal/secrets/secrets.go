@@ -0,0 +1,178 @@
+// Package secrets resolves references to secrets kept in a secrets manager, so connection
+// passwords and tokens need not be set in plaintext. A reference is {provider}:{path}#{key}, such
+// as "vault:kv/data/bfm/core#password" or "awssm:bfm/core#password".
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// Providers of the references
+const (
+	ProviderVault = "vault" // HashiCorp Vault, KV v1 or v2: vault:{api path}#{field}
+	ProviderAWS   = "awssm" // AWS Secrets Manager: awssm:{secret id}#{JSON key}
+)
+
+// DefaultCacheTTL is how long a resolved secret is used before it is read again
+const DefaultCacheTTL = 5 * time.Minute
+
+// ErrNotConfigured is returned when resolving a reference of a provider that was not set up
+var ErrNotConfigured = errors.New("secrets provider is not configured")
+
+// Provider reads secrets from a secrets manager
+type Provider interface {
+	// Get returns the value of key in the secret at path ("" for the whole secret), and how long
+	// it may be cached (0 for the resolver's TTL)
+	Get(ctx context.Context, path, key string) (value string, ttl time.Duration, err error)
+}
+
+// Ref is a parsed reference to a secret
+type Ref struct {
+	Provider string
+	Path     string
+	Key      string // Field or JSON key of the secret; "" for the whole secret
+}
+
+// String returns the reference as written
+func (r Ref) String() string {
+	if r.Key == "" {
+		return r.Provider + ":" + r.Path
+	}
+	return r.Provider + ":" + r.Path + "#" + r.Key
+}
+
+// ParseRef parses value as a reference. ok is false when value is not a reference (a plain value,
+// or a prefix that is not one of the providers), so it is used as is.
+func ParseRef(value string) (ref Ref, ok bool) {
+	provider, rest, found := strings.Cut(value, ":")
+	if !found || (provider != ProviderVault && provider != ProviderAWS) || rest == "" {
+		return Ref{}, false
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Ref{Provider: provider, Path: strings.Trim(path, "/"), Key: key}, true
+}
+
+// cached is a resolved secret and when it must be read again
+type cached struct {
+	value   string
+	expires time.Time
+}
+
+// Resolver resolves references with the registered providers and caches the values, so a
+// connection does not read its password at every execution. Cached values expire after the
+// cache TTL, so rotated secrets are picked up without restarting.
+type Resolver struct {
+	mu        sync.Mutex
+	providers map[string]Provider
+	cache     map[Ref]cached
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewResolver creates a resolver caching secrets for ttl (DefaultCacheTTL when not positive)
+func NewResolver(ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Resolver{
+		providers: make(map[string]Provider),
+		cache:     make(map[Ref]cached),
+		ttl:       ttl,
+		now:       time.Now,
+	}
+}
+
+// Register sets the provider of the references of a provider name (ProviderVault, ProviderAWS)
+func (r *Resolver) Register(name string, provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// Flush forgets the cached secrets, so the next resolutions read them again
+func (r *Resolver) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[Ref]cached)
+}
+
+// Resolve returns the secret value references, or value itself when it is not a reference
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := ParseRef(value)
+	if !ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	entry, hit := r.cache[ref]
+	provider := r.providers[ref.Provider]
+	now := r.now()
+	r.mu.Unlock()
+	if hit && now.Before(entry.expires) {
+		return entry.value, nil
+	}
+	if provider == nil {
+		return "", fmt.Errorf("%s: %w", ref, ErrNotConfigured)
+	}
+
+	secret, ttl, err := provider.Get(ctx, ref.Path, ref.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if ttl <= 0 || ttl > r.ttl {
+		ttl = r.ttl
+	}
+	r.mu.Lock()
+	r.cache[ref] = cached{value: secret, expires: now.Add(ttl)}
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// ResolveConnection returns cfg with the references of its username, password and extra settings
+// resolved. cfg is returned itself when it has none, and is never modified.
+func (r *Resolver) ResolveConnection(ctx context.Context, cfg *backends.ConnectionConfig) (*backends.ConnectionConfig, error) {
+	if !HasRefs(cfg) {
+		return cfg, nil
+	}
+	resolved := *cfg
+	var err error
+	if resolved.Username, err = r.Resolve(ctx, cfg.Username); err != nil {
+		return nil, err
+	}
+	if resolved.Password, err = r.Resolve(ctx, cfg.Password); err != nil {
+		return nil, err
+	}
+	resolved.Extra = make(map[string]string, len(cfg.Extra))
+	for key, value := range cfg.Extra {
+		if resolved.Extra[key], err = r.Resolve(ctx, value); err != nil {
+			return nil, err
+		}
+	}
+	return &resolved, nil
+}
+
+// HasRefs reports whether the username, password or an extra setting of cfg is a reference
+func HasRefs(cfg *backends.ConnectionConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	if _, ok := ParseRef(cfg.Username); ok {
+		return true
+	}
+	if _, ok := ParseRef(cfg.Password); ok {
+		return true
+	}
+	for _, value := range cfg.Extra {
+		if _, ok := ParseRef(value); ok {
+			return true
+		}
+	}
+	return false
+}
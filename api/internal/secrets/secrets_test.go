@@ -0,0 +1,130 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+func TestParseRef(t *testing.T) {
+	ref, ok := ParseRef("vault:kv/data/bfm/core#password")
+	if !ok || ref != (Ref{Provider: ProviderVault, Path: "kv/data/bfm/core", Key: "password"}) {
+		t.Fatalf("ParseRef() = %+v, %v", ref, ok)
+	}
+	if ref, ok := ParseRef("awssm:bfm/core"); !ok || ref.Key != "" || ref.String() != "awssm:bfm/core" {
+		t.Errorf("ParseRef() = %+v, %v", ref, ok)
+	}
+	for _, value := range []string{"", "s3cr3t", "postgres://user:pw@host", "vault:"} {
+		if _, ok := ParseRef(value); ok {
+			t.Errorf("ParseRef(%q) should not be a reference", value)
+		}
+	}
+}
+
+func TestResolver_CachesUntilExpired(t *testing.T) {
+	var reads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/bfm/core" || r.Header.Get("X-Vault-Token") != "root" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		reads++
+		password := "first"
+		if reads > 1 {
+			password = "rotated"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"username": "bfm", "password": password},
+				"metadata": map[string]interface{}{"version": reads},
+			},
+		})
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(time.Minute)
+	now := time.Now()
+	resolver.now = func() time.Time { return now }
+	resolver.Register(ProviderVault, NewVault(server.URL, "root", "team"))
+
+	cfg := &backends.ConnectionConfig{Backend: "postgresql", Username: "bfm", Password: "vault:kv/data/bfm/core#password", Extra: map[string]string{"SQL_LOG": "none"}}
+	resolved, err := resolver.ResolveConnection(context.Background(), cfg)
+	if err != nil || resolved.Password != "first" || resolved.Extra["SQL_LOG"] != "none" {
+		t.Fatalf("ResolveConnection() = %+v, %v", resolved, err)
+	}
+	if cfg.Password != "vault:kv/data/bfm/core#password" {
+		t.Errorf("the connection's reference was modified: %q", cfg.Password)
+	}
+
+	// Cached until the TTL expires, then read again
+	if value, _ := resolver.Resolve(context.Background(), cfg.Password); value != "first" || reads != 1 {
+		t.Fatalf("expected the cached secret, got %q after %d reads", value, reads)
+	}
+	now = now.Add(2 * time.Minute)
+	if value, _ := resolver.Resolve(context.Background(), cfg.Password); value != "rotated" || reads != 2 {
+		t.Fatalf("expected the rotated secret, got %q after %d reads", value, reads)
+	}
+	resolver.Flush()
+	_, _ = resolver.Resolve(context.Background(), cfg.Password)
+	if reads != 3 {
+		t.Errorf("expected Flush to forget the cached secret, got %d reads", reads)
+	}
+
+	// Plain connections are returned as is
+	plain := &backends.ConnectionConfig{Backend: "postgresql", Password: "s3cr3t"}
+	if resolved, err := resolver.ResolveConnection(context.Background(), plain); err != nil || resolved != plain {
+		t.Errorf("ResolveConnection() = %+v, %v, want the connection itself", resolved, err)
+	}
+	if _, err := resolver.Resolve(context.Background(), "vault:kv/data/bfm/core#token"); err == nil || !strings.Contains(err.Error(), "no field token") {
+		t.Errorf("expected a missing field to fail, got %v", err)
+	}
+	if _, err := resolver.Resolve(context.Background(), "awssm:bfm/core#password"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestAWS_GetSecretValue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		auth := r.Header.Get("Authorization")
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || body["SecretId"] != "bfm/core" ||
+			!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240101/eu-west-1/secretsmanager/aws4_request") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"username":"bfm","password":"s3cr3t","port":5432}`})
+	}))
+	defer server.Close()
+
+	provider := NewAWS("eu-west-1", server.URL, AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"})
+	provider.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	if value, _, err := provider.Get(context.Background(), "bfm/core", "password"); err != nil || value != "s3cr3t" {
+		t.Fatalf("Get() = %q, %v", value, err)
+	}
+	if value, _, err := provider.Get(context.Background(), "bfm/core", "port"); err != nil || value != "5432" {
+		t.Errorf("Get() = %q, %v", value, err)
+	}
+	if value, _, err := provider.Get(context.Background(), "bfm/core", ""); err != nil || !strings.HasPrefix(value, `{"username"`) {
+		t.Errorf("expected the whole secret string, got %q, %v", value, err)
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signV4(req, nil, AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		"us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from HashiCorp Vault. The path of a reference is the API path of the
+// secret, after /v1/: kv/data/{name} for a KV v2 mount named kv, secret/{name} for KV v1, or the
+// path of a dynamic secrets engine such as database/creds/{role}.
+type Vault struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// NewVault creates a Vault provider for the server at addr (such as https://vault:8200)
+// authenticating with token, in namespace when set (Vault Enterprise)
+func NewVault(addr, token, namespace string) *Vault {
+	return &Vault{
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// vaultResponse is the part of a Vault read response the provider uses
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"` // Seconds; 0 for static secrets
+	Data          map[string]interface{} `json:"data"`
+}
+
+// Get reads the secret at path and returns its field key. A secret of a single field may be
+// referenced without the key. The lease of dynamic secrets bounds how long they are cached.
+func (v *Vault) Get(ctx context.Context, path, key string) (string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("vault: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var parsed vaultResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", 0, fmt.Errorf("vault: failed to decode secret: %w", err)
+	}
+	fields := parsed.Data
+	// KV v2 nests the fields under data, next to the version's metadata
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+	value, err := field(fields, key)
+	if err != nil {
+		return "", 0, fmt.Errorf("vault: %w", err)
+	}
+	return value, time.Duration(parsed.LeaseDuration) * time.Second, nil
+}
+
+// field returns the field key of a secret as a string; a secret of a single field may be read
+// without the key
+func field(fields map[string]interface{}, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d fields, reference one with #{key}", len(fields))
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %s", key)
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
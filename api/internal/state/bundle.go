@@ -0,0 +1,287 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BundleFormatVersion is the version of the state bundle format. It changes only when fields
+// are removed or change meaning.
+const BundleFormatVersion = 1
+
+// ErrStateNotEmpty is returned when a bundle is imported into a state database that already has
+// migration history
+var ErrStateNotEmpty = errors.New("state database already has migration history")
+
+// ErrUnsupportedBundle is returned when a bundle has a format version this build cannot import
+var ErrUnsupportedBundle = errors.New("unsupported state bundle format version")
+
+// Bundle is a portable export of the migration state: migrations_list, migrations_history and
+// migrations_executions. It is imported into another state database to move bfm between
+// environments or to rebuild a lost state database.
+type Bundle struct {
+	FormatVersion int               `json:"format_version"`
+	ExportedAt    string            `json:"exported_at"`
+	Migrations    []BundleMigration `json:"migrations"`
+	History       []BundleRecord    `json:"history"` // Oldest first
+	Executions    []BundleExecution `json:"executions"`
+}
+
+// BundleMigration is a migrations_list entry of a bundle
+type BundleMigration struct {
+	MigrationID      string   `json:"migration_id"`
+	Schema           string   `json:"schema"`
+	Table            string   `json:"table,omitempty"`
+	Version          string   `json:"version"`
+	Name             string   `json:"name"`
+	Connection       string   `json:"connection"`
+	Backend          string   `json:"backend"`
+	LastStatus       string   `json:"last_status"`
+	LastAppliedAt    string   `json:"last_applied_at,omitempty"`
+	LastErrorMessage string   `json:"last_error_message,omitempty"`
+	Applied          bool     `json:"applied"`
+	Checksum         string   `json:"checksum,omitempty"`
+	StatusLabels     []string `json:"status_labels,omitempty"`
+}
+
+// BundleRecord is a migrations_history record of a bundle
+type BundleRecord struct {
+	MigrationID      string `json:"migration_id"`
+	Schema           string `json:"schema"`
+	Table            string `json:"table,omitempty"`
+	Version          string `json:"version"`
+	Connection       string `json:"connection"`
+	Backend          string `json:"backend"`
+	AppliedAt        string `json:"applied_at"`
+	Status           string `json:"status"`
+	Operation        string `json:"operation,omitempty"`
+	ErrorMessage     string `json:"error_message,omitempty"`
+	ErrorClass       string `json:"error_class,omitempty"`
+	ExecutedBy       string `json:"executed_by,omitempty"`
+	ExecutionMethod  string `json:"execution_method,omitempty"`
+	ExecutionContext string `json:"execution_context,omitempty"`
+}
+
+// BundleExecution is a migrations_executions entry of a bundle
+type BundleExecution struct {
+	MigrationID string `json:"migration_id"`
+	Schema      string `json:"schema"`
+	Version     string `json:"version"`
+	Connection  string `json:"connection"`
+	Backend     string `json:"backend"`
+	Status      string `json:"status"`
+	Applied     bool   `json:"applied"`
+	AppliedAt   string `json:"applied_at,omitempty"`
+	Checksum    string `json:"checksum,omitempty"`
+}
+
+// ImportResult counts what ImportBundle wrote
+type ImportResult struct {
+	Migrations int `json:"migrations"` // migrations_list entries
+	History    int `json:"history"`    // Replayed history records
+	Executions int `json:"executions"` // Applied executions without history, recorded as dependencies
+}
+
+// ExportBundle exports the whole state of tracker
+func ExportBundle(ctx context.Context, tracker StateTracker) (*Bundle, error) {
+	bundle := &Bundle{
+		FormatVersion: BundleFormatVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Migrations:    []BundleMigration{},
+		History:       []BundleRecord{},
+		Executions:    []BundleExecution{},
+	}
+
+	migrations, _, err := tracker.GetMigrationList(ctx, &MigrationFilters{SortBy: SortByMigrationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export migrations list: %w", err)
+	}
+	connections := make(map[string]bool)
+	for _, m := range migrations {
+		bundle.Migrations = append(bundle.Migrations, BundleMigration{
+			MigrationID:      m.MigrationID,
+			Schema:           m.Schema,
+			Table:            m.Table,
+			Version:          m.Version,
+			Name:             m.Name,
+			Connection:       m.Connection,
+			Backend:          m.Backend,
+			LastStatus:       m.LastStatus,
+			LastAppliedAt:    m.LastAppliedAt,
+			LastErrorMessage: m.LastErrorMessage,
+			Applied:          m.Applied,
+			Checksum:         m.Checksum,
+			StatusLabels:     m.StatusLabels,
+		})
+		connections[m.Connection] = true
+	}
+
+	history, _, err := tracker.GetMigrationHistory(ctx, &MigrationFilters{SortBy: SortByAppliedAt, SortOrder: SortAsc})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export migration history: %w", err)
+	}
+	for _, r := range history {
+		bundle.History = append(bundle.History, BundleRecord{
+			MigrationID:      r.MigrationID,
+			Schema:           r.Schema,
+			Table:            r.Table,
+			Version:          r.Version,
+			Connection:       r.Connection,
+			Backend:          r.Backend,
+			AppliedAt:        r.AppliedAt,
+			Status:           r.Status,
+			Operation:        r.Operation,
+			ErrorMessage:     r.ErrorMessage,
+			ErrorClass:       r.ErrorClass,
+			ExecutedBy:       r.ExecutedBy,
+			ExecutionMethod:  r.ExecutionMethod,
+			ExecutionContext: r.ExecutionContext,
+		})
+	}
+
+	names := make([]string, 0, len(connections))
+	for connection := range connections {
+		names = append(names, connection)
+	}
+	sort.Strings(names)
+	for _, connection := range names {
+		executions, err := GetConnectionExecutions(ctx, tracker, connection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export executions of connection %s: %w", connection, err)
+		}
+		for _, e := range executions {
+			bundle.Executions = append(bundle.Executions, BundleExecution{
+				MigrationID: e.MigrationID,
+				Schema:      e.Schema,
+				Version:     e.Version,
+				Connection:  e.Connection,
+				Backend:     e.Backend,
+				Status:      e.Status,
+				Applied:     e.Applied,
+				AppliedAt:   e.AppliedAt,
+				Checksum:    e.Checksum,
+			})
+		}
+	}
+	return bundle, nil
+}
+
+// ImportBundle imports bundle into tracker, which must have no migration history (scanned
+// migrations are fine). The migrations are registered, then history is replayed oldest first so
+// migrations_list and migrations_executions end in the state the history leads to. Applied
+// executions and migrations without history (recorded as dependencies) are recorded as
+// dependencies again. Status labels are restored last.
+func ImportBundle(ctx context.Context, tracker StateTracker, bundle *Bundle) (*ImportResult, error) {
+	if bundle.FormatVersion != BundleFormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBundle, bundle.FormatVersion)
+	}
+	if _, total, err := tracker.GetMigrationHistory(ctx, &MigrationFilters{Limit: 1}); err != nil {
+		return nil, fmt.Errorf("failed to check migration history: %w", err)
+	} else if total > 0 {
+		return nil, ErrStateNotEmpty
+	}
+
+	// History has no checksum: successful up records take the one of their execution
+	checksums := make(map[string]string)
+	for _, e := range bundle.Executions {
+		checksums[e.MigrationID+"\x00"+e.Schema] = e.Checksum
+	}
+	listChecksums := make(map[string]string)
+	for _, m := range bundle.Migrations {
+		listChecksums[m.MigrationID] = m.Checksum
+	}
+
+	// Registering the list first keeps the names and tables history does not carry
+	result := &ImportResult{}
+	for _, m := range bundle.Migrations {
+		if err := tracker.RegisterScannedMigration(ctx, m.MigrationID, m.Schema, m.Table, m.Version, m.Name, m.Connection, m.Backend); err != nil {
+			return result, fmt.Errorf("failed to import migration %s: %w", m.MigrationID, err)
+		}
+		result.Migrations++
+	}
+
+	recorded := make(map[string]bool)        // Migrations with history or executions
+	recordedSchemas := make(map[string]bool) // (migration, schema) pairs with history
+	for _, r := range bundle.History {
+		record := &MigrationRecord{
+			MigrationID:      r.MigrationID,
+			Schema:           r.Schema,
+			Table:            r.Table,
+			Version:          r.Version,
+			Connection:       r.Connection,
+			Backend:          r.Backend,
+			AppliedAt:        r.AppliedAt,
+			Status:           r.Status,
+			Operation:        r.Operation,
+			ErrorMessage:     r.ErrorMessage,
+			ErrorClass:       r.ErrorClass,
+			ExecutedBy:       r.ExecutedBy,
+			ExecutionMethod:  r.ExecutionMethod,
+			ExecutionContext: r.ExecutionContext,
+		}
+		if HistoryStatusIndicatesApplied(r.Status) && !record.Reverts() {
+			record.Checksum = checksums[r.MigrationID+"\x00"+r.Schema]
+			if record.Checksum == "" {
+				record.Checksum = listChecksums[r.MigrationID]
+			}
+		}
+		if err := tracker.RecordMigration(ctx, record); err != nil {
+			return result, fmt.Errorf("failed to import history of %s: %w", r.MigrationID, err)
+		}
+		recorded[r.MigrationID] = true
+		recordedSchemas[r.MigrationID+"\x00"+r.Schema] = true
+		result.History++
+	}
+
+	// Executions and migrations applied without history were recorded as dependencies
+	for _, e := range bundle.Executions {
+		if !e.Applied || recordedSchemas[e.MigrationID+"\x00"+e.Schema] {
+			continue
+		}
+		if err := tracker.RecordDependencyMigration(ctx, &MigrationRecord{
+			MigrationID: e.MigrationID,
+			Schema:      e.Schema,
+			Version:     e.Version,
+			Connection:  e.Connection,
+			Backend:     e.Backend,
+			AppliedAt:   e.AppliedAt,
+			Status:      "success",
+			Checksum:    e.Checksum,
+		}); err != nil {
+			return result, fmt.Errorf("failed to import execution of %s: %w", e.MigrationID, err)
+		}
+		recorded[e.MigrationID] = true
+		result.Executions++
+	}
+	for _, m := range bundle.Migrations {
+		if !m.Applied || recorded[m.MigrationID] {
+			continue
+		}
+		if err := tracker.RecordDependencyMigration(ctx, &MigrationRecord{
+			MigrationID: m.MigrationID,
+			Schema:      m.Schema,
+			Table:       m.Table,
+			Version:     m.Version,
+			Connection:  m.Connection,
+			Backend:     m.Backend,
+			AppliedAt:   m.LastAppliedAt,
+			Status:      "success",
+			Checksum:    m.Checksum,
+		}); err != nil {
+			return result, fmt.Errorf("failed to import migration %s: %w", m.MigrationID, err)
+		}
+	}
+
+	for _, m := range bundle.Migrations {
+		if len(m.StatusLabels) == 0 {
+			continue
+		}
+		if err := tracker.SetStatusLabels(ctx, m.MigrationID, m.StatusLabels); err != nil {
+			return result, fmt.Errorf("failed to import status labels of %s: %w", m.MigrationID, err)
+		}
+	}
+	return result, nil
+}
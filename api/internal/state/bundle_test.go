@@ -0,0 +1,82 @@
+package state_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+func TestBundle_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := testsupport.NewStateTracker()
+	_ = source.RegisterScannedMigration(ctx, "core_001_users", "core", "users", "001", "users", "core", "postgresql")
+	_ = source.RegisterScannedMigration(ctx, "core_002_orders", "core", "", "002", "orders", "core", "postgresql")
+	_ = source.RegisterScannedMigration(ctx, "core_003_later", "core", "", "003", "later", "core", "postgresql")
+	_ = source.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "core_001_users", Schema: "core", Version: "001", Connection: "core", Backend: "postgresql",
+		AppliedAt: "2025-01-01T00:00:00Z", Status: "success", ExecutedBy: "deployer", Checksum: "sum1",
+	})
+	_ = source.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "core_002_orders", Schema: "core", Version: "002", Connection: "core", Backend: "postgresql",
+		AppliedAt: "2025-01-02T00:00:00Z", Status: "failed", ErrorMessage: "boom",
+	})
+	_ = source.SetStatusLabels(ctx, "core_001_users", []string{"verified"})
+
+	bundle, err := state.ExportBundle(ctx, source)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if bundle.FormatVersion != state.BundleFormatVersion || len(bundle.Migrations) != 3 || len(bundle.History) != 2 || len(bundle.Executions) != 2 {
+		t.Fatalf("ExportBundle() = %d migrations, %d history, %d executions", len(bundle.Migrations), len(bundle.History), len(bundle.Executions))
+	}
+	if bundle.History[0].MigrationID != "core_001_users" {
+		t.Errorf("History[0] = %s, want the oldest record first", bundle.History[0].MigrationID)
+	}
+
+	// The bundle survives a JSON round trip
+	data, _ := json.Marshal(bundle)
+	var decoded state.Bundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	target := testsupport.NewStateTracker()
+	result, err := state.ImportBundle(ctx, target, &decoded)
+	if err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+	if result.Migrations != 3 || result.History != 2 {
+		t.Errorf("ImportBundle() = %+v", result)
+	}
+
+	list, _, _ := target.GetMigrationList(ctx, &state.MigrationFilters{SortBy: state.SortByMigrationID})
+	if len(list) != 3 {
+		t.Fatalf("imported list has %d migrations, want 3", len(list))
+	}
+	if users := list[0]; !users.Applied || users.Checksum != "sum1" || users.Name != "users" || users.Table != "users" || len(users.StatusLabels) != 1 {
+		t.Errorf("imported core_001_users = %+v", users)
+	}
+	if orders := list[1]; orders.Applied || orders.LastStatus != "failed" {
+		t.Errorf("imported core_002_orders = %+v", orders)
+	}
+	if later := list[2]; later.LastStatus != "pending" {
+		t.Errorf("imported core_003_later = %+v", later)
+	}
+	history, _, _ := target.GetMigrationHistory(ctx, &state.MigrationFilters{SortBy: state.SortByAppliedAt, SortOrder: state.SortAsc})
+	if len(history) != 2 || history[0].AppliedAt != "2025-01-01T00:00:00Z" || history[0].ExecutedBy != "deployer" || history[1].ErrorMessage != "boom" {
+		t.Errorf("imported history = %+v", history)
+	}
+
+	// A second import would duplicate history
+	if _, err := state.ImportBundle(ctx, target, &decoded); !errors.Is(err, state.ErrStateNotEmpty) {
+		t.Errorf("ImportBundle() into a non-empty state error = %v, want ErrStateNotEmpty", err)
+	}
+	decoded.FormatVersion = 99
+	if _, err := state.ImportBundle(ctx, testsupport.NewStateTracker(), &decoded); !errors.Is(err, state.ErrUnsupportedBundle) {
+		t.Errorf("ImportBundle() of format 99 error = %v, want ErrUnsupportedBundle", err)
+	}
+}
@@ -5,3 +5,24 @@ import "errors"
 // ErrMigrationAlreadyInProgress is returned when another process holds the execution
 // lock for the same migration key (migration_id + schema + connection).
 var ErrMigrationAlreadyInProgress = errors.New("migration is already being executed")
+
+// ErrConnectionLocked is returned when another process holds the lock for a connection,
+// i.e. it is already applying migrations on it.
+var ErrConnectionLocked = errors.New("connection is locked by another migration run")
+
+// ErrPrimaryLocked is returned when another server instance holds the primary lock, i.e. it is the
+// primary and standby instances must not take over.
+var ErrPrimaryLocked = errors.New("primary lock is held by another server instance")
+
+// ErrMigrationNotFound is returned when a migration is not in migrations_list
+var ErrMigrationNotFound = errors.New("migration not found")
+
+// ErrInvalidFilters is returned when MigrationFilters sorts by an unknown field or order, has a
+// negative limit or offset, or an AppliedBefore that is not after AppliedAfter
+var ErrInvalidFilters = errors.New("invalid migration filters")
+
+// ErrJobNotFound is returned when a job is not in migrations_jobs
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrEmergencyRunNotFound is returned when an emergency run is not in migrations_emergency_runs
+var ErrEmergencyRunNotFound = errors.New("emergency run not found")
@@ -0,0 +1,127 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// The audit log is kept under audit/ and never trimmed. The tracker only appends to it; to make it
+// append-only for other clients too, grant them read-only access to the prefix with etcd RBAC.
+
+// auditRecord is a migrations_audit entry
+type auditRecord struct {
+	ID          int64     `json:"id"`
+	Operation   string    `json:"operation"`
+	Actor       string    `json:"actor,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Protocol    string    `json:"protocol"`
+	Method      string    `json:"method"`
+	SourceIP    string    `json:"source_ip,omitempty"`
+	RequestBody string    `json:"request_body,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Status      string    `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RecordAudit appends a record to the audit log, setting its ID and CreatedAt
+func (t *Tracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	createdAt := time.Now()
+	var id int64
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		id = t.nextID(stm, "audit")
+		return putJSON(stm, fmt.Sprintf("%saudit/%020d", t.prefix, id), &auditRecord{
+			ID:          id,
+			Operation:   record.Operation,
+			Actor:       record.Actor,
+			Role:        record.Role,
+			Protocol:    record.Protocol,
+			Method:      record.Method,
+			SourceIP:    record.SourceIP,
+			RequestBody: record.RequestBody,
+			Outcome:     record.Outcome,
+			Status:      record.Status,
+			Error:       record.Error,
+			CreatedAt:   createdAt,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit: %w", err)
+	}
+	record.ID = id
+	record.CreatedAt = formatTime(createdAt)
+	return nil
+}
+
+// GetAuditLog retrieves audit records matching filters, ordered by created_at DESC
+func (t *Tracker) GetAuditLog(ctx context.Context, filters *state.AuditFilters) ([]*state.AuditRecord, int, error) {
+	if filters == nil {
+		filters = &state.AuditFilters{}
+	}
+
+	var matched []*auditRecord
+	err := t.getPrefix(ctx, t.prefix+"audit/", func(value []byte) error {
+		var record auditRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		switch {
+		case filters.Operation != "" && record.Operation != filters.Operation,
+			filters.Actor != "" && record.Actor != filters.Actor,
+			filters.Outcome != "" && record.Outcome != filters.Outcome,
+			!filters.Since.IsZero() && record.CreatedAt.Before(filters.Since),
+			!filters.Until.IsZero() && !record.CreatedAt.Before(filters.Until):
+			return nil
+		}
+		matched = append(matched, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit records: %w", err)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := len(matched)
+	if filters.Offset > 0 {
+		if filters.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[filters.Offset:]
+		}
+	}
+	if filters.Limit > 0 && len(matched) > filters.Limit {
+		matched = matched[:filters.Limit]
+	}
+
+	records := make([]*state.AuditRecord, 0, len(matched))
+	for _, r := range matched {
+		records = append(records, &state.AuditRecord{
+			ID:          r.ID,
+			Operation:   r.Operation,
+			Actor:       r.Actor,
+			Role:        r.Role,
+			Protocol:    r.Protocol,
+			Method:      r.Method,
+			SourceIP:    r.SourceIP,
+			RequestBody: r.RequestBody,
+			Outcome:     r.Outcome,
+			Status:      r.Status,
+			Error:       r.Error,
+			CreatedAt:   formatTime(r.CreatedAt),
+		})
+	}
+	return records, total, nil
+}
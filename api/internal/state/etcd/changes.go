@@ -0,0 +1,131 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// The state change feed is kept under changes/, keyed by cursor. Changes are written in the
+// transaction of the state they record, so they are visible in cursor order. As for history, only
+// the most recent historyLimit changes are kept.
+
+// changeRecord is a migrations_changes entry
+type changeRecord struct {
+	Cursor      int64     `json:"cursor"`
+	Type        string    `json:"type"`
+	MigrationID string    `json:"migration_id"`
+	Schema      string    `json:"schema,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Connection  string    `json:"connection,omitempty"`
+	Backend     string    `json:"backend,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (t *Tracker) changeKey(cursor int64) string {
+	return fmt.Sprintf("%schanges/%020d", t.prefix, cursor)
+}
+
+// putChange appends a change within an STM transaction and returns its cursor
+func (t *Tracker) putChange(stm concurrency.STM, change *state.StateChange, now time.Time) (int64, error) {
+	cursor := t.nextID(stm, "changes")
+	return cursor, putJSON(stm, t.changeKey(cursor), &changeRecord{
+		Cursor:      cursor,
+		Type:        change.Type,
+		MigrationID: change.MigrationID,
+		Schema:      change.Schema,
+		Version:     change.Version,
+		Connection:  change.Connection,
+		Backend:     change.Backend,
+		Status:      change.Status,
+		Error:       change.Error,
+		CreatedAt:   now,
+	})
+}
+
+// executionChange returns the change of type changeType of a recorded execution, or nil for
+// executions that change no state (changeType "")
+func executionChange(baseMigrationID string, migration *state.MigrationRecord, changeType, status string) *state.StateChange {
+	if changeType == "" {
+		return nil
+	}
+	return &state.StateChange{
+		Type:        changeType,
+		MigrationID: baseMigrationID,
+		Schema:      migration.Schema,
+		Version:     migration.Version,
+		Connection:  migration.Connection,
+		Backend:     migration.Backend,
+		Status:      status,
+		Error:       migration.ErrorMessage,
+	}
+}
+
+// recordChange appends a change in its own transaction
+func (t *Tracker) recordChange(ctx context.Context, change *state.StateChange) error {
+	var cursor int64
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		var err error
+		cursor, err = t.putChange(stm, change, time.Now())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s change of %s: %w", change.Type, change.MigrationID, err)
+	}
+	t.trimChanges(ctx, cursor)
+	return nil
+}
+
+// trimChanges removes the changes that fall out of the limit once lastCursor was recorded
+func (t *Tracker) trimChanges(ctx context.Context, lastCursor int64) {
+	oldestKept := lastCursor - int64(t.historyLimit) + 1
+	if lastCursor == 0 || oldestKept <= 1 {
+		return
+	}
+	_, err := t.client.Delete(ctx, t.changeKey(0), clientv3.WithRange(t.changeKey(oldestKept)))
+	if err != nil {
+		logger.Warnf("Failed to remove old state changes: %v", err)
+	}
+}
+
+// GetStateChanges returns the changes after the cursor since, oldest first
+func (t *Tracker) GetStateChanges(ctx context.Context, since int64, limit int) ([]*state.StateChange, error) {
+	opts := []clientv3.OpOption{clientv3.WithRange(clientv3.GetPrefixRangeEnd(t.prefix + "changes/"))}
+	if limit > 0 {
+		opts = append(opts, clientv3.WithLimit(int64(limit)))
+	}
+	resp, err := t.client.Get(ctx, t.changeKey(since+1), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state changes: %w", err)
+	}
+
+	changes := make([]*state.StateChange, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var record changeRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", kv.Key, err)
+		}
+		changes = append(changes, &state.StateChange{
+			Cursor:      record.Cursor,
+			Type:        record.Type,
+			MigrationID: record.MigrationID,
+			Schema:      record.Schema,
+			Version:     record.Version,
+			Connection:  record.Connection,
+			Backend:     record.Backend,
+			Status:      record.Status,
+			Error:       record.Error,
+			CreatedAt:   formatTime(record.CreatedAt),
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,146 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// emergencyRunRecord is a migrations_emergency_runs entry
+type emergencyRunRecord struct {
+	ID            string    `json:"id"`
+	Operation     string    `json:"operation"`
+	Actor         string    `json:"actor,omitempty"`
+	Reason        string    `json:"reason"`
+	Method        string    `json:"method,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	PostmortemDue time.Time `json:"postmortem_due"`
+	Postmortem    string    `json:"postmortem,omitempty"`
+	PostmortemBy  string    `json:"postmortem_by,omitempty"`
+	PostmortemAt  time.Time `json:"postmortem_at"`
+}
+
+func (t *Tracker) emergencyRunKey(id string) string {
+	return t.prefix + "emergency_runs/" + id
+}
+
+// RecordEmergencyRun creates an emergency run, or records the postmortem of an existing one
+func (t *Tracker) RecordEmergencyRun(ctx context.Context, run *state.EmergencyRun) error {
+	due, err := time.Parse(time.RFC3339, run.PostmortemDue)
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: invalid postmortem_due: %w", run.ID, err)
+	}
+	now := time.Now()
+	err = t.update(ctx, func(stm concurrency.STM) error {
+		var record emergencyRunRecord
+		exists, err := getJSON(stm, t.emergencyRunKey(run.ID), &record)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			record = emergencyRunRecord{
+				ID:            run.ID,
+				Operation:     run.Operation,
+				Actor:         run.Actor,
+				Reason:        run.Reason,
+				Method:        run.Method,
+				StartedAt:     now,
+				PostmortemDue: due,
+			}
+		}
+		if run.Postmortem != "" {
+			record.Postmortem, record.PostmortemBy, record.PostmortemAt = run.Postmortem, run.PostmortemBy, now
+		}
+		return putJSON(stm, t.emergencyRunKey(run.ID), &record)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// toEmergencyRun converts a stored emergency run to a state.EmergencyRun
+func (r *emergencyRunRecord) toEmergencyRun() *state.EmergencyRun {
+	return &state.EmergencyRun{
+		ID:            r.ID,
+		Operation:     r.Operation,
+		Actor:         r.Actor,
+		Reason:        r.Reason,
+		Method:        r.Method,
+		StartedAt:     formatTime(r.StartedAt),
+		PostmortemDue: formatTime(r.PostmortemDue),
+		Postmortem:    r.Postmortem,
+		PostmortemBy:  r.PostmortemBy,
+		PostmortemAt:  formatTime(r.PostmortemAt),
+	}
+}
+
+// GetEmergencyRun returns an emergency run by ID
+func (t *Tracker) GetEmergencyRun(ctx context.Context, id string) (*state.EmergencyRun, error) {
+	resp, err := t.client.Get(ctx, t.emergencyRunKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency run %s: %w", id, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, state.ErrEmergencyRunNotFound
+	}
+	var record emergencyRunRecord
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode emergency run %s: %w", id, err)
+	}
+	return record.toEmergencyRun(), nil
+}
+
+// GetEmergencyRuns retrieves emergency runs matching filters, most recent first
+func (t *Tracker) GetEmergencyRuns(ctx context.Context, filters *state.EmergencyRunFilters) ([]*state.EmergencyRun, int, error) {
+	if filters == nil {
+		filters = &state.EmergencyRunFilters{}
+	}
+
+	var matched []*emergencyRunRecord
+	err := t.getPrefix(ctx, t.prefix+"emergency_runs/", func(value []byte) error {
+		var record emergencyRunRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		if filters.Pending && record.Postmortem != "" {
+			return nil
+		}
+		matched = append(matched, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query emergency runs: %w", err)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].StartedAt.Equal(matched[j].StartedAt) {
+			return matched[i].StartedAt.After(matched[j].StartedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := len(matched)
+	if filters.Offset > 0 {
+		if filters.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[filters.Offset:]
+		}
+	}
+	if filters.Limit > 0 && len(matched) > filters.Limit {
+		matched = matched[:filters.Limit]
+	}
+
+	runs := make([]*state.EmergencyRun, 0, len(matched))
+	for _, r := range matched {
+		runs = append(runs, r.toEmergencyRun())
+	}
+	return runs, total, nil
+}
@@ -0,0 +1,178 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// jobRecord is a migrations_jobs entry
+type jobRecord struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Connection string    `json:"connection,omitempty"`
+	Schema     string    `json:"schema,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+	Worker     string    `json:"worker,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Payload    string    `json:"payload,omitempty"`
+	Applied    []string  `json:"applied,omitempty"`
+	Skipped    []string  `json:"skipped,omitempty"`
+	Errors     []string  `json:"errors,omitempty"`
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Scheduled jobs only
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+func (t *Tracker) jobKey(id string) string {
+	return t.prefix + "jobs/" + id
+}
+
+// RecordJob creates a job or updates its status, worker and results. A job queued again
+// (replayed) starts over.
+func (t *Tracker) RecordJob(ctx context.Context, job *state.Job) error {
+	now := time.Now()
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		var record jobRecord
+		exists, err := getJSON(stm, t.jobKey(job.ID), &record)
+		if err != nil {
+			return err
+		}
+		if !exists || job.Status == state.JobQueued {
+			scheduledAt := record.ScheduledAt
+			record = jobRecord{
+				ScheduledAt: scheduledAt,
+				ID:          job.ID,
+				Connection:  job.Connection,
+				Schema:      job.Schema,
+				Target:      job.Target,
+				DryRun:      job.DryRun,
+				QueuedAt:    now,
+			}
+		}
+		record.Status = job.Status
+		if job.ScheduledAt != "" {
+			scheduledAt, err := time.Parse(time.RFC3339, job.ScheduledAt)
+			if err != nil {
+				return fmt.Errorf("invalid scheduled_at: %w", err)
+			}
+			record.ScheduledAt = scheduledAt
+		}
+		if job.Worker != "" {
+			record.Worker = job.Worker
+		}
+		record.Attempts, record.Payload = job.Attempts, job.Payload
+		record.Applied, record.Skipped, record.Errors = job.Applied, job.Skipped, job.Errors
+		if record.StartedAt.IsZero() && (job.Status == state.JobPickedUp || job.Status == state.JobRunning) {
+			record.StartedAt = now
+		}
+		if state.JobFinished(job.Status) {
+			record.FinishedAt = now
+		}
+		record.UpdatedAt = now
+		return putJSON(stm, t.jobKey(job.ID), &record)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// toJob converts a stored job to a state.Job
+func (r *jobRecord) toJob() *state.Job {
+	return &state.Job{
+		ID:          r.ID,
+		Status:      r.Status,
+		Connection:  r.Connection,
+		Schema:      r.Schema,
+		Target:      r.Target,
+		DryRun:      r.DryRun,
+		Worker:      r.Worker,
+		Attempts:    r.Attempts,
+		Payload:     r.Payload,
+		Applied:     r.Applied,
+		Skipped:     r.Skipped,
+		Errors:      r.Errors,
+		QueuedAt:    formatTime(r.QueuedAt),
+		StartedAt:   formatTime(r.StartedAt),
+		FinishedAt:  formatTime(r.FinishedAt),
+		UpdatedAt:   formatTime(r.UpdatedAt),
+		ScheduledAt: formatTime(r.ScheduledAt),
+	}
+}
+
+// GetJob returns a job by ID
+func (t *Tracker) GetJob(ctx context.Context, id string) (*state.Job, error) {
+	resp, err := t.client.Get(ctx, t.jobKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, state.ErrJobNotFound
+	}
+	var record jobRecord
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return record.toJob(), nil
+}
+
+// GetJobs retrieves jobs matching filters, most recently queued first
+func (t *Tracker) GetJobs(ctx context.Context, filters *state.JobFilters) ([]*state.Job, int, error) {
+	if filters == nil {
+		filters = &state.JobFilters{}
+	}
+
+	var matched []*jobRecord
+	err := t.getPrefix(ctx, t.prefix+"jobs/", func(value []byte) error {
+		var record jobRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		switch {
+		case filters.Status != "" && record.Status != filters.Status,
+			filters.Connection != "" && record.Connection != filters.Connection:
+			return nil
+		}
+		matched = append(matched, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query jobs: %w", err)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].QueuedAt.Equal(matched[j].QueuedAt) {
+			return matched[i].QueuedAt.After(matched[j].QueuedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := len(matched)
+	if filters.Offset > 0 {
+		if filters.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[filters.Offset:]
+		}
+	}
+	if filters.Limit > 0 && len(matched) > filters.Limit {
+		matched = matched[:filters.Limit]
+	}
+
+	jobs := make([]*state.Job, 0, len(matched))
+	for _, r := range matched {
+		jobs = append(jobs, r.toJob())
+	}
+	return jobs, total, nil
+}
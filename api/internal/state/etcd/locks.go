@@ -0,0 +1,208 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// Locks are keys under locks/ attached to the lease of this tracker's session: locks/connection/
+// holds connection locks, locks/execution/ per-migration execution locks and locks/primary the
+// primary lock. A key is created only if it does not exist, and removed on release. If the process
+// dies its lease expires after sessionTTL and its locks go with it.
+
+// sessionTTL is the lease TTL in seconds; a crashed holder's locks are released after it
+const sessionTTL = 15
+
+// errLockHeld is returned by tryLock when another session holds the lock
+var errLockHeld = errors.New("lock is held")
+
+// lockRecord is the value of a lock key
+type lockRecord struct {
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+func (t *Tracker) connectionLockKey(connection string) string {
+	return t.prefix + "locks/connection/" + connection
+}
+
+func (t *Tracker) primaryLockKey() string {
+	return t.prefix + "locks/primary"
+}
+
+// lockSession returns the session locks are held under, starting a new one if the previous one
+// ended (e.g. its lease expired while etcd was unreachable)
+func (t *Tracker) lockSession() (*concurrency.Session, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session != nil {
+		select {
+		case <-t.session.Done():
+			t.session = nil
+		default:
+			return t.session, nil
+		}
+	}
+	session, err := concurrency.NewSession(t.client, concurrency.WithTTL(sessionTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start etcd session: %w", err)
+	}
+	t.session = session
+	return session, nil
+}
+
+// tryLock creates the lock key unless it exists, and returns the revision that created it, which
+// identifies this acquisition
+func (t *Tracker) tryLock(ctx context.Context, key, holder string) (int64, *concurrency.Session, error) {
+	session, err := t.lockSession()
+	if err != nil {
+		return 0, nil, err
+	}
+	value, err := json.Marshal(&lockRecord{Holder: holder, AcquiredAt: time.Now()})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to encode lock: %w", err)
+	}
+
+	resp, err := t.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(value), clientv3.WithLease(session.Lease()))).
+		Commit()
+	if err != nil {
+		return 0, nil, fmt.Errorf("record lock: %w", err)
+	}
+	if !resp.Succeeded {
+		return 0, nil, errLockHeld
+	}
+	return resp.Header.Revision, session, nil
+}
+
+// unlock removes a lock key created by tryLock, unless it was force-released and taken since
+func (t *Tracker) unlock(key string, revision int64) {
+	_, _ = t.client.Txn(context.Background()).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", revision)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+}
+
+// WithMigrationExecutionLock runs fn while holding the lock for (migration_id, execution schema,
+// connection), so the same migration can run for different schemas concurrently.
+func (t *Tracker) WithMigrationExecutionLock(ctx context.Context, migrationID, schema, connection string, fn func() error) error {
+	key := t.prefix + "locks/execution/" + migrationID + "/" + schema + "/" + connection
+	revision, _, err := t.tryLock(ctx, key, "")
+	if errors.Is(err, errLockHeld) {
+		return state.ErrMigrationAlreadyInProgress
+	}
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer t.unlock(key, revision)
+
+	return fn()
+}
+
+// WithConnectionLock runs fn while holding the lock on a connection
+func (t *Tracker) WithConnectionLock(ctx context.Context, connection, holder string, fn func() error) error {
+	key := t.connectionLockKey(connection)
+	revision, _, err := t.tryLock(ctx, key, holder)
+	if errors.Is(err, errLockHeld) {
+		return state.ErrConnectionLocked
+	}
+	if err != nil {
+		return fmt.Errorf("acquire connection lock: %w", err)
+	}
+	defer t.unlock(key, revision)
+
+	return fn()
+}
+
+// ListLocks returns the connection locks currently held
+func (t *Tracker) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	prefix := t.connectionLockKey("")
+	resp, err := t.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query connection locks: %w", err)
+	}
+
+	locks := []*state.MigrationLock{}
+	for _, kv := range resp.Kvs {
+		var record lockRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode connection lock %s: %w", kv.Key, err)
+		}
+		locks = append(locks, &state.MigrationLock{
+			Connection: string(kv.Key[len(prefix):]),
+			Holder:     record.Holder,
+			AcquiredAt: formatTime(record.AcquiredAt),
+		})
+	}
+	return locks, nil
+}
+
+// ReleaseLock force-releases a connection lock by removing its key. Unlike the PostgreSQL tracker
+// the holding process is not stopped: a run that is only stuck keeps going, but no longer excludes
+// other runs. Returns false if the connection was not locked.
+func (t *Tracker) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	resp, err := t.client.Delete(ctx, t.connectionLockKey(connection))
+	if err != nil {
+		return false, fmt.Errorf("failed to remove connection lock: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
+
+// AcquirePrimaryLock takes the lock electing the primary server instance among instances sharing
+// the state prefix. If the instance dies, its lease expires and another instance can take over.
+func (t *Tracker) AcquirePrimaryLock(ctx context.Context) (state.PrimaryLock, error) {
+	holder, _ := os.Hostname()
+	key := t.primaryLockKey()
+	revision, session, err := t.tryLock(ctx, key, holder)
+	if errors.Is(err, errLockHeld) {
+		return nil, state.ErrPrimaryLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("acquire primary lock: %w", err)
+	}
+	return &primaryLock{tracker: t, key: key, revision: revision, session: session}, nil
+}
+
+// primaryLock is a primary lock held by this process
+type primaryLock struct {
+	tracker  *Tracker
+	key      string
+	revision int64
+	session  *concurrency.Session
+	once     sync.Once
+}
+
+func (l *primaryLock) Check(ctx context.Context) error {
+	select {
+	case <-l.session.Done():
+		return fmt.Errorf("primary lock session ended")
+	default:
+	}
+
+	resp, err := l.tracker.client.Get(ctx, l.key)
+	if err != nil {
+		return fmt.Errorf("primary lock check failed: %w", err)
+	}
+	if len(resp.Kvs) == 0 || resp.Kvs[0].CreateRevision != l.revision {
+		return fmt.Errorf("primary lock was taken over")
+	}
+	return nil
+}
+
+func (l *primaryLock) Release() {
+	l.once.Do(func() {
+		l.tracker.unlock(l.key, l.revision)
+	})
+}
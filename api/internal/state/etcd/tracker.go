@@ -0,0 +1,1472 @@
+// Package etcd implements state.StateTracker on etcd, for deployments that only manage etcd
+// configuration and should not need a relational database for migration state. Records are
+// JSON values under a key prefix (/bfm/state/ by default):
+//
+//	list/{migration_id}                       migrations_list
+//	executions/{migration_id}/{schema}        migrations_executions
+//	history/{id}                              migrations_history, the most recent records only
+//	skipped/{migration_id}/{schema}/{id}      migrations_skipped, 5 per migration and schema
+//	dependencies/{migration_id}               migrations_dependencies
+//	audit/{id}                                migrations_audit
+//	jobs/{job_id}                             migrations_jobs
+//	sequence/{name}                           counters for the {id} keys
+//	schema/{name}                             markers of the data migrations that have run
+//	locks/...                                 locks held by a session lease (see locks.go)
+//
+// {id} is a zero-padded sequence number, so keys sort in insertion order.
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// DefaultPrefix is the key prefix state is kept under unless configured otherwise
+const DefaultPrefix = "/bfm/state/"
+
+// DefaultHistoryLimit is the number of history records kept unless configured otherwise
+const DefaultHistoryLimit = 1000
+
+// skippedLimit is the number of skipped records kept per migration and schema, as in the
+// PostgreSQL tracker
+const skippedLimit = 5
+
+// Tracker implements StateTracker for etcd
+type Tracker struct {
+	client       *clientv3.Client
+	prefix       string
+	historyLimit int
+
+	mu      sync.Mutex
+	session *concurrency.Session // Lease that locks are held under, see locks.go
+}
+
+// listRecord is a migrations_list entry
+type listRecord struct {
+	MigrationID            string                `json:"migration_id"`
+	Schema                 string                `json:"schema"`
+	Table                  string                `json:"table,omitempty"`
+	Version                string                `json:"version"`
+	Name                   string                `json:"name"`
+	Connection             string                `json:"connection"`
+	Backend                string                `json:"backend"`
+	UpSQL                  string                `json:"up_sql,omitempty"`
+	DownSQL                string                `json:"down_sql,omitempty"`
+	Dependencies           []string              `json:"dependencies"`
+	StructuredDependencies []backends.Dependency `json:"structured_dependencies,omitempty"`
+	Status                 string                `json:"status"`
+	Checksum               string                `json:"checksum,omitempty"`
+	StatusLabels           []string              `json:"status_labels"`
+	CreatedAt              time.Time             `json:"created_at"`
+	UpdatedAt              time.Time             `json:"updated_at"`
+}
+
+// historyRecord is a migrations_history entry
+type historyRecord struct {
+	ID               int64     `json:"id"`
+	MigrationID      string    `json:"migration_id"`
+	Schema           string    `json:"schema"`
+	Version          string    `json:"version"`
+	Connection       string    `json:"connection"`
+	Backend          string    `json:"backend"`
+	Status           string    `json:"status"`
+	Operation        string    `json:"operation,omitempty"`
+	ErrorMessage     string    `json:"error_message,omitempty"`
+	ErrorClass       string    `json:"error_class,omitempty"`
+	ExecutedBy       string    `json:"executed_by"`
+	ExecutionMethod  string    `json:"execution_method"`
+	ExecutionContext string    `json:"execution_context,omitempty"`
+	AppliedAt        time.Time `json:"applied_at"`
+}
+
+// executionRecord is a migrations_executions entry
+type executionRecord struct {
+	MigrationID string    `json:"migration_id"`
+	Schema      string    `json:"schema"`
+	Version     string    `json:"version"`
+	Connection  string    `json:"connection"`
+	Backend     string    `json:"backend"`
+	Status      string    `json:"status"`
+	Applied     bool      `json:"applied"`
+	AppliedAt   time.Time `json:"applied_at"` // Zero unless applied
+	Checksum    string    `json:"checksum,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// skippedRecord is a migrations_skipped entry
+type skippedRecord struct {
+	ID               int64     `json:"id"`
+	MigrationID      string    `json:"migration_id"`
+	Schema           string    `json:"schema"`
+	Version          string    `json:"version"`
+	Connection       string    `json:"connection"`
+	Backend          string    `json:"backend"`
+	ExecutedBy       string    `json:"executed_by,omitempty"`
+	ExecutionMethod  string    `json:"execution_method"`
+	ExecutionContext string    `json:"execution_context,omitempty"`
+	SkippedAt        time.Time `json:"skipped_at"`
+}
+
+// dependencyRecord is a migrations_dependencies entry
+type dependencyRecord struct {
+	DependencyID   string   `json:"dependency_id"`
+	Connection     string   `json:"connection"`
+	Schema         []string `json:"schema"`
+	Target         string   `json:"target"`
+	TargetType     string   `json:"target_type"`
+	RequiresTable  string   `json:"requires_table,omitempty"`
+	RequiresSchema string   `json:"requires_schema,omitempty"`
+}
+
+// NewTracker connects to the etcd cluster at endpoints and keeps state under prefix (DefaultPrefix
+// when empty). At most historyLimit history records and state changes are kept (DefaultHistoryLimit
+// when not positive); older ones are removed as new ones are recorded.
+func NewTracker(endpoints []string, username, password, prefix string, historyLimit int) (*Tracker, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		Username:    username,
+		Password:    password,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if historyLimit <= 0 {
+		historyLimit = DefaultHistoryLimit
+	}
+
+	tracker := &Tracker{client: client, prefix: prefix, historyLimit: historyLimit}
+	if err := tracker.Initialize(context.Background()); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+
+	return tracker, nil
+}
+
+// Initialize checks that the cluster is reachable. etcd needs no tables; keys are created as
+// state is recorded.
+func (t *Tracker) Initialize(ctx context.Context) error {
+	if err := t.Ping(ctx); err != nil {
+		return err
+	}
+	return t.migrateHistoryOperations(ctx)
+}
+
+// Ping checks that the cluster is reachable
+func (t *Tracker) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := t.client.Get(ctx, t.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		return fmt.Errorf("failed to reach etcd: %w", err)
+	}
+	return nil
+}
+
+// migrateHistoryOperations sets the operation of the history records recorded before records had
+// one, once. Down migrations were recorded under the ID of their migration with a "_down" suffix,
+// in a list entry of their own: their records move to the migration, and the entry and its
+// executions are removed. Successful rollbacks were recorded under the ID of their migration with
+// status rolled_back. Failed rollbacks cannot be told from failed up migrations and stay up.
+func (t *Tracker) migrateHistoryOperations(ctx context.Context) error {
+	marker := t.prefix + "schema/history_operations"
+	resp, err := t.client.Get(ctx, marker, clientv3.WithCountOnly())
+	if err != nil {
+		return fmt.Errorf("failed to migrate history operations: %w", err)
+	}
+	if resp.Count > 0 {
+		return nil
+	}
+
+	list, err := t.getList(ctx)
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool, len(list))
+	for _, record := range list {
+		listed[record.MigrationID] = true
+	}
+	downOf := func(migrationID string) (string, bool) {
+		base := strings.TrimSuffix(migrationID, "_down")
+		return base, base != migrationID && listed[base]
+	}
+
+	var ops []clientv3.Op
+	for _, record := range list {
+		if _, ok := downOf(record.MigrationID); ok {
+			ops = append(ops,
+				clientv3.OpDelete(t.listKey(record.MigrationID)),
+				clientv3.OpDelete(t.executionsPrefix(record.MigrationID), clientv3.WithPrefix()))
+		}
+	}
+
+	resp, err = t.client.Get(ctx, t.prefix+"history/", clientv3.WithPrefix())
+	if err != nil {
+		return fmt.Errorf("failed to migrate history operations: %w", err)
+	}
+	for _, kv := range resp.Kvs {
+		var record historyRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil || record.Operation != "" {
+			continue
+		}
+		if base, ok := downOf(record.MigrationID); ok {
+			record.MigrationID, record.Operation = base, state.OperationDown
+		} else if record.Status == "rolled_back" {
+			record.Operation = state.OperationRollback
+		} else {
+			continue
+		}
+		value, _ := json.Marshal(&record)
+		ops = append(ops, clientv3.OpPut(string(kv.Key), string(value)))
+	}
+
+	ops = append(ops, clientv3.OpPut(marker, formatTime(time.Now())))
+	if err := t.commit(ctx, ops); err != nil {
+		return fmt.Errorf("failed to migrate history operations: %w", err)
+	}
+	return nil
+}
+
+// commit applies ops in as many transactions as etcd needs: it limits the operations in a
+// transaction (--max-txn-ops, 128 by default)
+func (t *Tracker) commit(ctx context.Context, ops []clientv3.Op) error {
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > 128 {
+			n = 128
+		}
+		if _, err := t.client.Txn(ctx).Then(ops[:n]...).Commit(); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// Keys
+
+func (t *Tracker) listKey(migrationID string) string {
+	return t.prefix + "list/" + migrationID
+}
+
+func (t *Tracker) executionsPrefix(migrationID string) string {
+	return t.prefix + "executions/" + migrationID + "/"
+}
+
+func (t *Tracker) executionKey(migrationID, schema string) string {
+	return t.executionsPrefix(migrationID) + schema
+}
+
+func (t *Tracker) historyKey(id int64) string {
+	return fmt.Sprintf("%shistory/%020d", t.prefix, id)
+}
+
+func (t *Tracker) skippedPrefix(migrationID, schema string) string {
+	return t.prefix + "skipped/" + migrationID + "/" + schema + "/"
+}
+
+func (t *Tracker) dependenciesKey(migrationID string) string {
+	return t.prefix + "dependencies/" + migrationID
+}
+
+func (t *Tracker) sequenceKey(name string) string {
+	return t.prefix + "sequence/" + name
+}
+
+// nextID increments the named sequence within an STM transaction and returns the new value
+func (t *Tracker) nextID(stm concurrency.STM, name string) int64 {
+	id, _ := strconv.ParseInt(stm.Get(t.sequenceKey(name)), 10, 64)
+	id++
+	stm.Put(t.sequenceKey(name), strconv.FormatInt(id, 10))
+	return id
+}
+
+// update runs apply in a serializable transaction, retried when a key it read was changed
+// concurrently
+func (t *Tracker) update(ctx context.Context, apply func(stm concurrency.STM) error) error {
+	_, err := concurrency.NewSTM(t.client, apply, concurrency.WithAbortContext(ctx))
+	return err
+}
+
+// getJSON decodes the value at key in an STM transaction into v, and reports whether it exists
+func getJSON(stm concurrency.STM, key string, v interface{}) (bool, error) {
+	value := stm.Get(key)
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// putJSON encodes v as the value at key in an STM transaction
+func putJSON(stm concurrency.STM, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	stm.Put(key, string(value))
+	return nil
+}
+
+// getPrefix decodes every value under prefix, in key order, with decode
+func (t *Tracker) getPrefix(ctx context.Context, prefix string, decode func(value []byte) error) error {
+	resp, err := t.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	for _, kv := range resp.Kvs {
+		if err := decode(kv.Value); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", kv.Key, err)
+		}
+	}
+	return nil
+}
+
+// getList returns the migrations_list entries, in migration ID order
+func (t *Tracker) getList(ctx context.Context) ([]*listRecord, error) {
+	var records []*listRecord
+	err := t.getPrefix(ctx, t.prefix+"list/", func(value []byte) error {
+		var record listRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migrations list: %w", err)
+	}
+	return records, nil
+}
+
+// getListRecord returns the migrations_list entry of migrationID, or nil
+func (t *Tracker) getListRecord(ctx context.Context, migrationID string) (*listRecord, error) {
+	resp, err := t.client.Get(ctx, t.listKey(migrationID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration %s: %w", migrationID, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var record listRecord
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode migration %s: %w", migrationID, err)
+	}
+	return &record, nil
+}
+
+// formatTime formats a stored time as RFC3339, as returned by the PostgreSQL tracker
+func formatTime(tm time.Time) string {
+	if tm.IsZero() {
+		return ""
+	}
+	return tm.UTC().Format(time.RFC3339)
+}
+
+// schemaMatches reports whether a schema value, alone or a comma-separated list, contains schema
+func schemaMatches(value, schema string) bool {
+	for _, s := range strings.Split(value, ",") {
+		if s == schema {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertList creates the migrations_list entry of a recorded migration, or updates the status of an
+// existing one unless it is already applied
+func (t *Tracker) upsertList(stm concurrency.STM, baseMigrationID string, migration *state.MigrationRecord, status string, now time.Time) error {
+	var record listRecord
+	exists, err := getJSON(stm, t.listKey(baseMigrationID), &record)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		record = listRecord{
+			MigrationID:  baseMigrationID,
+			Schema:       migration.Schema,
+			Version:      migration.Version,
+			Name:         state.MigrationName(baseMigrationID),
+			Connection:   migration.Connection,
+			Backend:      migration.Backend,
+			Dependencies: []string{},
+			StatusLabels: []string{},
+			CreatedAt:    now,
+		}
+	}
+	if record.Status != "applied" {
+		record.Status = status
+	}
+	if migration.Checksum != "" {
+		record.Checksum = migration.Checksum
+	}
+	record.UpdatedAt = now
+	return putJSON(stm, t.listKey(baseMigrationID), &record)
+}
+
+// recordExecution upserts the migrations_executions entry of a migration run for a schema. Runs
+// without a schema have no execution entry.
+func (t *Tracker) recordExecution(stm concurrency.STM, baseMigrationID string, migration *state.MigrationRecord, status string, appliedAt, now time.Time) error {
+	if migration.Schema == "" {
+		return nil
+	}
+
+	key := t.executionKey(baseMigrationID, migration.Schema)
+	var record executionRecord
+	exists, err := getJSON(stm, key, &record)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		record.CreatedAt = now
+	}
+
+	record.MigrationID = baseMigrationID
+	record.Schema = migration.Schema
+	record.Version = migration.Version
+	record.Connection = migration.Connection
+	record.Backend = migration.Backend
+	record.Applied = status == "applied"
+	record.AppliedAt = time.Time{}
+	record.Status = "pending"
+	if record.Applied {
+		record.Status = "applied"
+		record.AppliedAt = appliedAt
+	} else if status == "failed" {
+		record.Status = "failed"
+	}
+	if migration.Checksum != "" {
+		record.Checksum = migration.Checksum
+	}
+	record.UpdatedAt = now
+	return putJSON(stm, key, &record)
+}
+
+// RecordMigration records a migration execution
+func (t *Tracker) RecordMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	appliedAt := migration.AppliedTime()
+	isRollback := migration.Reverts()
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+
+	executedBy := migration.ExecutedBy
+	if executedBy == "" {
+		executedBy = "system"
+	}
+	executionMethod := migration.ExecutionMethod
+	if executionMethod == "" {
+		executionMethod = "api"
+	}
+
+	status := migration.HistoryStatus()
+	listStatus := status
+	if isRollback {
+		listStatus = "rolled_back"
+	}
+
+	logger.Infof("Recording migration: id=%s, status=%s, connection=%s, backend=%s, execution_method=%s",
+		baseMigrationID, status, migration.Connection, migration.Backend, executionMethod)
+
+	change := executionChange(baseMigrationID, migration, state.ExecutionChange(status, isRollback), listStatus)
+	var historyID, cursor int64
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		now := time.Now()
+		if err := t.upsertList(stm, baseMigrationID, migration, listStatus, now); err != nil {
+			return err
+		}
+
+		// History is recorded even without a schema
+		historyID = t.nextID(stm, "history")
+		err := putJSON(stm, t.historyKey(historyID), &historyRecord{
+			ID:               historyID,
+			MigrationID:      baseMigrationID,
+			Schema:           migration.Schema,
+			Version:          migration.Version,
+			Connection:       migration.Connection,
+			Backend:          migration.Backend,
+			Status:           status,
+			Operation:        migration.HistoryOperation(),
+			ErrorMessage:     migration.ErrorMessage,
+			ErrorClass:       migration.ErrorClass,
+			ExecutedBy:       executedBy,
+			ExecutionMethod:  executionMethod,
+			ExecutionContext: migration.ExecutionContext,
+			AppliedAt:        appliedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if change != nil {
+			if cursor, err = t.putChange(stm, change, now); err != nil {
+				return err
+			}
+		}
+		return t.recordExecution(stm, baseMigrationID, migration, status, appliedAt, now)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", baseMigrationID, err)
+	}
+
+	t.trimHistory(ctx, historyID)
+	t.trimChanges(ctx, cursor)
+	return nil
+}
+
+// trimHistory removes the history records that fall out of the limit once lastID was recorded
+func (t *Tracker) trimHistory(ctx context.Context, lastID int64) {
+	oldestKept := lastID - int64(t.historyLimit) + 1
+	if oldestKept <= 1 {
+		return
+	}
+	_, err := t.client.Delete(ctx, t.historyKey(0), clientv3.WithRange(t.historyKey(oldestKept)))
+	if err != nil {
+		logger.Warnf("Failed to remove old migration history records: %v", err)
+	}
+}
+
+// RecordDependencyMigration records a dependency migration as applied without creating history entries.
+// Dependencies are only recorded in the execution history of the migration that depends on them.
+func (t *Tracker) RecordDependencyMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+	status := migration.Status
+	if status == "success" {
+		status = "applied"
+	}
+
+	change := executionChange(baseMigrationID, migration, state.ExecutionChange(status, false), status)
+	var cursor int64
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		now := time.Now()
+		if err := t.upsertList(stm, baseMigrationID, migration, status, now); err != nil {
+			return err
+		}
+		if change != nil {
+			var err error
+			if cursor, err = t.putChange(stm, change, now); err != nil {
+				return err
+			}
+		}
+		return t.recordExecution(stm, baseMigrationID, migration, status, migration.AppliedTime(), now)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record dependency migration %s: %w", baseMigrationID, err)
+	}
+	t.trimChanges(ctx, cursor)
+
+	logger.Debug("Recorded dependency migration %s as applied (no history entry created)", baseMigrationID)
+	return nil
+}
+
+// GetMigrationHistory retrieves migration history with optional filters. Only the most recent
+// records are kept (see NewTracker).
+func (t *Tracker) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
+	if filters == nil {
+		filters = &state.MigrationFilters{}
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	// History records don't carry the table; it is declared on the migration in migrations_list
+	var tableMigrations map[string]bool
+	if filters.Table != "" {
+		list, err := t.getList(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		tableMigrations = make(map[string]bool)
+		for _, item := range list {
+			if item.Table == filters.Table {
+				tableMigrations[item.MigrationID] = true
+			}
+		}
+	}
+
+	var history []*historyRecord
+	err := t.getPrefix(ctx, t.prefix+"history/", func(value []byte) error {
+		var record historyRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		switch {
+		case !filters.MatchesMigrationID(record.MigrationID),
+			filters.Schema != "" && !schemaMatches(record.Schema, filters.Schema),
+			tableMigrations != nil && !tableMigrations[record.MigrationID],
+			filters.Connection != "" && record.Connection != filters.Connection,
+			filters.Backend != "" && record.Backend != filters.Backend,
+			filters.Status != "" && record.Status != filters.Status,
+			filters.Version != "" && record.Version != filters.Version,
+			!filters.MatchesAppliedAt(record.AppliedAt),
+			filters.ExecutedBy != "" && record.ExecutedBy != filters.ExecutedBy,
+			filters.ExecutionMethod != "" && record.ExecutionMethod != filters.ExecutionMethod,
+			filters.ErrorClass != "" && record.ErrorClass != filters.ErrorClass,
+			filters.RunID != "" && (&state.MigrationRecord{ExecutionContext: record.ExecutionContext}).RunID() != filters.RunID:
+			return nil
+		}
+		history = append(history, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query migrations: %w", err)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByAppliedAt, state.SortDesc)
+	sort.SliceStable(history, func(i, j int) bool {
+		a, b := historySortKey(history[i], sortBy), historySortKey(history[j], sortBy)
+		if a == b {
+			a, b = fmt.Sprintf("%020d", history[i].ID), fmt.Sprintf("%020d", history[j].ID)
+		}
+		return (a < b) != desc
+	})
+	start, end := filters.Page(len(history))
+
+	records := make([]*state.MigrationRecord, 0, end-start)
+	for _, h := range history[start:end] {
+		records = append(records, &state.MigrationRecord{
+			ID:               strconv.FormatInt(h.ID, 10),
+			MigrationID:      h.MigrationID,
+			Schema:           h.Schema,
+			Version:          h.Version,
+			Connection:       h.Connection,
+			Backend:          h.Backend,
+			AppliedAt:        formatTime(h.AppliedAt),
+			Status:           h.Status,
+			Operation:        h.Operation,
+			ErrorMessage:     h.ErrorMessage,
+			ErrorClass:       h.ErrorClass,
+			ExecutedBy:       h.ExecutedBy,
+			ExecutionMethod:  h.ExecutionMethod,
+			ExecutionContext: h.ExecutionContext,
+		})
+	}
+	return records, len(history), nil
+}
+
+// GetMigrationList retrieves the list of migrations with their last status
+func (t *Tracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	if filters == nil {
+		filters = &state.MigrationFilters{}
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+	list, err := t.getList(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*listRecord
+	for _, record := range list {
+		switch {
+		case filters.Schema != "" && !schemaMatches(record.Schema, filters.Schema),
+			filters.Table != "" && record.Table != filters.Table,
+			filters.Connection != "" && record.Connection != filters.Connection,
+			filters.Backend != "" && record.Backend != filters.Backend,
+			filters.Status != "" && record.Status != filters.Status,
+			filters.Version != "" && record.Version != filters.Version,
+			filters.Label != "" && !containsString(record.StatusLabels, filters.Label):
+			continue
+		}
+		matched = append(matched, record)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByVersion, state.SortAsc)
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := listSortKey(matched[i], sortBy), listSortKey(matched[j], sortBy)
+		if a == b {
+			a, b = matched[i].MigrationID, matched[j].MigrationID
+		}
+		return (a < b) != desc
+	})
+	start, end := filters.Page(len(matched))
+
+	items := make([]*state.MigrationListItem, 0, end-start)
+	for _, record := range matched[start:end] {
+		item := &state.MigrationListItem{
+			MigrationID:  record.MigrationID,
+			Schema:       record.Schema,
+			Table:        record.Table,
+			Version:      record.Version,
+			Name:         record.Name,
+			Connection:   record.Connection,
+			Backend:      record.Backend,
+			LastStatus:   record.Status,
+			Checksum:     record.Checksum,
+			StatusLabels: record.StatusLabels,
+		}
+		// updated_at is when the migration was applied, for applied migrations
+		item.Applied = item.LastStatus == "applied"
+		if item.Applied {
+			item.LastAppliedAt = formatTime(record.UpdatedAt)
+		}
+		items = append(items, item)
+	}
+	return items, len(matched), nil
+}
+
+// sortableTime formats times so that they sort as strings
+const sortableTime = "2006-01-02T15:04:05.000000000"
+
+// historySortKey returns the value of a MigrationFilters sort field of a history record
+func historySortKey(record *historyRecord, sortBy string) string {
+	switch sortBy {
+	case state.SortByMigrationID:
+		return record.MigrationID
+	case state.SortBySchema:
+		return record.Schema
+	case state.SortByVersion:
+		return record.Version
+	case state.SortByConnection:
+		return record.Connection
+	case state.SortByBackend:
+		return record.Backend
+	case state.SortByStatus:
+		return record.Status
+	default:
+		return record.AppliedAt.UTC().Format(sortableTime)
+	}
+}
+
+// listSortKey returns the value of a MigrationFilters sort field of a migrations_list record.
+// Migrations that are not applied have no applied_at and sort after applied ones, as NULLs do in SQL.
+func listSortKey(record *listRecord, sortBy string) string {
+	switch sortBy {
+	case state.SortByMigrationID:
+		return record.MigrationID
+	case state.SortBySchema:
+		return record.Schema
+	case state.SortByConnection:
+		return record.Connection
+	case state.SortByBackend:
+		return record.Backend
+	case state.SortByStatus:
+		return record.Status
+	case state.SortByAppliedAt:
+		if record.Status != "applied" {
+			return "~"
+		}
+		return record.UpdatedAt.UTC().Format(sortableTime)
+	default:
+		return record.Version
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMigrationDetail retrieves detailed information about a single migration from migrations_list
+func (t *Tracker) GetMigrationDetail(ctx context.Context, migrationID string) (*state.MigrationDetail, error) {
+	record, err := t.getListRecord(ctx, state.BaseMigrationID(migrationID))
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return &state.MigrationDetail{
+		MigrationID:            record.MigrationID,
+		Schema:                 record.Schema,
+		Version:                record.Version,
+		Name:                   record.Name,
+		Connection:             record.Connection,
+		Backend:                record.Backend,
+		UpSQL:                  record.UpSQL,
+		DownSQL:                record.DownSQL,
+		Dependencies:           record.Dependencies,
+		StructuredDependencies: record.StructuredDependencies,
+		Status:                 record.Status,
+		StatusLabels:           record.StatusLabels,
+	}, nil
+}
+
+// getExecutions returns the migrations_executions entries under prefix, ordered by created_at DESC
+func (t *Tracker) getExecutions(ctx context.Context, prefix string) ([]*state.MigrationExecution, error) {
+	records, err := t.getExecutionRecords(ctx, prefix, func(*executionRecord) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return toExecutions(records), nil
+}
+
+// getExecutionRecords returns the migrations_executions entries under prefix that match, in key
+// order: by migration ID, then schema
+func (t *Tracker) getExecutionRecords(ctx context.Context, prefix string, match func(*executionRecord) bool) ([]*executionRecord, error) {
+	var records []*executionRecord
+	err := t.getPrefix(ctx, prefix, func(value []byte) error {
+		var record executionRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		if match(&record) {
+			records = append(records, &record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration executions: %w", err)
+	}
+	return records, nil
+}
+
+// toExecutions converts migrations_executions entries
+func toExecutions(records []*executionRecord) []*state.MigrationExecution {
+
+	executions := make([]*state.MigrationExecution, 0, len(records))
+	for _, record := range records {
+		executions = append(executions, &state.MigrationExecution{
+			MigrationID: record.MigrationID,
+			Schema:      record.Schema,
+			Version:     record.Version,
+			Connection:  record.Connection,
+			Backend:     record.Backend,
+			Status:      record.Status,
+			Applied:     record.Applied,
+			AppliedAt:   formatTime(record.AppliedAt),
+			Checksum:    record.Checksum,
+			CreatedAt:   formatTime(record.CreatedAt),
+			UpdatedAt:   formatTime(record.UpdatedAt),
+		})
+	}
+	return executions
+}
+
+// GetMigrationExecutions retrieves all execution records for a migration, ordered by created_at DESC
+func (t *Tracker) GetMigrationExecutions(ctx context.Context, migrationID string) ([]*state.MigrationExecution, error) {
+	return t.getExecutions(ctx, t.executionsPrefix(state.BaseMigrationID(migrationID)))
+}
+
+// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+// ordered by migration_id and schema
+func (t *Tracker) GetConnectionExecutions(ctx context.Context, connection string) ([]*state.MigrationExecution, error) {
+	records, err := t.getExecutionRecords(ctx, t.prefix+"executions/", func(record *executionRecord) bool {
+		return record.Connection == connection
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toExecutions(records), nil
+}
+
+// GetRecentExecutions retrieves recent execution records across all migrations, ordered by created_at DESC
+func (t *Tracker) GetRecentExecutions(ctx context.Context, limit int) ([]*state.MigrationExecution, error) {
+	executions, err := t.getExecutions(ctx, t.prefix+"executions/")
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 && len(executions) > limit {
+		executions = executions[:limit]
+	}
+	return executions, nil
+}
+
+// RecordSkippedMigrations records skipped migrations for a given execution context. Only the 5
+// most recent records are kept per migration and schema.
+func (t *Tracker) RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
+	for _, migrationID := range skippedMigrationIDs {
+		baseMigrationID := state.BaseMigrationID(migrationID)
+
+		migration, err := t.getListRecord(ctx, baseMigrationID)
+		if err != nil || migration == nil {
+			// Not registered yet; continue with the other migrations
+			logger.Warnf("Skipped migration %s (base: %s) not found in migrations_list, skipping record", migrationID, baseMigrationID)
+			continue
+		}
+
+		schema := state.SchemaPrefix(migrationID, baseMigrationID)
+		if schema == "" {
+			schema = migration.Schema
+		}
+
+		prefix := t.skippedPrefix(baseMigrationID, schema)
+		err = t.update(ctx, func(stm concurrency.STM) error {
+			id := t.nextID(stm, "skipped")
+			return putJSON(stm, fmt.Sprintf("%s%020d", prefix, id), &skippedRecord{
+				ID:               id,
+				MigrationID:      baseMigrationID,
+				Schema:           schema,
+				Version:          migration.Version,
+				Connection:       migration.Connection,
+				Backend:          migration.Backend,
+				ExecutedBy:       executedBy,
+				ExecutionMethod:  executionMethod,
+				ExecutionContext: executionContext,
+				SkippedAt:        time.Now(),
+			})
+		})
+		if err != nil {
+			logger.Warnf("Failed to record skipped migration %s: %v", migrationID, err)
+			continue
+		}
+
+		resp, err := t.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			logger.Warnf("Failed to cleanup old skipped migration records for %s (schema: %s): %v", migrationID, schema, err)
+			continue
+		}
+		// Keys are in insertion order; keep the last ones
+		for i := 0; i < len(resp.Kvs)-skippedLimit; i++ {
+			if _, err := t.client.Delete(ctx, string(resp.Kvs[i].Key)); err != nil {
+				logger.Warnf("Failed to cleanup old skipped migration records for %s (schema: %s): %v", migrationID, schema, err)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetSkippedMigrations retrieves skipped migrations, optionally filtered by migration_id or recent limit
+func (t *Tracker) GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*state.SkippedMigration, error) {
+	prefix := t.prefix + "skipped/"
+	if migrationID != "" {
+		prefix += migrationID + "/"
+	}
+
+	var records []*skippedRecord
+	err := t.getPrefix(ctx, prefix, func(value []byte) error {
+		var record skippedRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skipped migrations: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].SkippedAt.Equal(records[j].SkippedAt) {
+			return records[i].SkippedAt.After(records[j].SkippedAt)
+		}
+		return records[i].ID > records[j].ID
+	})
+	if limit >= 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	skippedMigrations := make([]*state.SkippedMigration, 0, len(records))
+	for _, record := range records {
+		skippedMigrations = append(skippedMigrations, &state.SkippedMigration{
+			ID:               int(record.ID),
+			MigrationID:      record.MigrationID,
+			Schema:           record.Schema,
+			Version:          record.Version,
+			Connection:       record.Connection,
+			Backend:          record.Backend,
+			ExecutedBy:       record.ExecutedBy,
+			ExecutionMethod:  record.ExecutionMethod,
+			ExecutionContext: record.ExecutionContext,
+			SkippedAt:        formatTime(record.SkippedAt),
+			CreatedAt:        formatTime(record.SkippedAt),
+		})
+	}
+	return skippedMigrations, nil
+}
+
+// executionStatusIn reports whether a schema-specific migration ID has an execution entry with one
+// of statuses. A migration that is not in migrations_list has none.
+func (t *Tracker) executionStatusIn(ctx context.Context, baseMigrationID, schema string, statuses ...string) (bool, error) {
+	migration, err := t.getListRecord(ctx, baseMigrationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get migration metadata: %w", err)
+	}
+	if migration == nil {
+		return false, nil
+	}
+
+	resp, err := t.client.Get(ctx, t.executionKey(baseMigrationID, schema))
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration status in executions: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return false, nil
+	}
+	var record executionRecord
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return false, fmt.Errorf("failed to decode execution of %s: %w", baseMigrationID, err)
+	}
+	if record.Version != migration.Version || record.Connection != migration.Connection || record.Backend != migration.Backend {
+		return false, nil
+	}
+	return containsString(statuses, record.Status), nil
+}
+
+// IsMigrationApplied checks if a migration has been successfully applied.
+// Schema-specific IDs are checked per schema in migrations_executions, base IDs in migrations_list.
+func (t *Tracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	if schema := state.SchemaPrefix(migrationID, baseMigrationID); schema != "" {
+		return t.executionStatusIn(ctx, baseMigrationID, schema, "applied")
+	}
+
+	for _, id := range []string{migrationID, baseMigrationID} {
+		record, err := t.getListRecord(ctx, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to check migration status: %w", err)
+		}
+		if record != nil && record.Status == "applied" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsMigrationPendingOrApplied checks if a migration is pending or applied. For base IDs a
+// migrations_list "pending" only means registered-not-applied, so this matches IsMigrationApplied.
+func (t *Tracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	schema := state.SchemaPrefix(migrationID, baseMigrationID)
+	if schema == "" {
+		return t.IsMigrationApplied(ctx, migrationID)
+	}
+	return t.executionStatusIn(ctx, baseMigrationID, schema, "applied", "pending")
+}
+
+// GetLastMigrationVersion gets the last applied version for a schema/table
+func (t *Tracker) GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error) {
+	list, err := t.getList(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get last migration version: %w", err)
+	}
+	var version string
+	for _, record := range list {
+		if record.Status == "applied" && schemaMatches(record.Schema, schema) && record.Version > version {
+			version = record.Version
+		}
+	}
+	return version, nil
+}
+
+// RegisterScannedMigration registers a scanned migration in migrations_list (status: pending)
+func (t *Tracker) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		var record listRecord
+		exists, err := getJSON(stm, t.listKey(migrationID), &record)
+		if err != nil {
+			return err
+		}
+		// An already registered migration keeps its entry, but picks up a table declared since
+		if exists {
+			if record.Table == table {
+				return nil
+			}
+			record.Table = table
+			return putJSON(stm, t.listKey(migrationID), &record)
+		}
+
+		now := time.Now()
+		return putJSON(stm, t.listKey(migrationID), &listRecord{
+			MigrationID:  migrationID,
+			Schema:       schema,
+			Table:        table,
+			Version:      version,
+			Name:         name,
+			Connection:   connection,
+			Backend:      backend,
+			Dependencies: []string{},
+			Status:       "pending",
+			StatusLabels: []string{},
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register scanned migration: %w", err)
+	}
+	return nil
+}
+
+// SetStatusLabels replaces the user-defined status labels of a migration. updated_at is left
+// alone, as it reports when the migration was applied.
+func (t *Tracker) SetStatusLabels(ctx context.Context, migrationID string, labels []string) error {
+	if labels == nil {
+		labels = []string{}
+	}
+
+	key := t.listKey(state.BaseMigrationID(migrationID))
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		var record listRecord
+		exists, err := getJSON(stm, key, &record)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", state.ErrMigrationNotFound, migrationID)
+		}
+		record.StatusLabels = labels
+		return putJSON(stm, key, &record)
+	})
+	if errors.Is(err, state.ErrMigrationNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set status labels: %w", err)
+	}
+	return nil
+}
+
+// UpdateMigrationInfo updates migration metadata (schema, table, version, name, connection, backend) without affecting status/history
+func (t *Tracker) UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	errNotFound := fmt.Errorf("migration %s not found", migrationID)
+	err := t.update(ctx, func(stm concurrency.STM) error {
+		var record listRecord
+		exists, err := getJSON(stm, t.listKey(migrationID), &record)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errNotFound
+		}
+		record.Schema = schema
+		record.Table = table
+		record.Version = version
+		record.Name = name
+		record.Connection = connection
+		record.Backend = backend
+		record.UpdatedAt = time.Now()
+		return putJSON(stm, t.listKey(migrationID), &record)
+	})
+	if errors.Is(err, errNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update migration info: %w", err)
+	}
+	return nil
+}
+
+// DeleteMigration deletes a migration from migrations_list, with its history, executions, skipped
+// records and dependencies, as the foreign keys of the PostgreSQL tracker cascade
+func (t *Tracker) DeleteMigration(ctx context.Context, migrationID string) error {
+	ops := []clientv3.Op{
+		clientv3.OpDelete(t.listKey(migrationID)),
+		clientv3.OpDelete(t.executionsPrefix(migrationID), clientv3.WithPrefix()),
+		clientv3.OpDelete(t.prefix+"skipped/"+migrationID+"/", clientv3.WithPrefix()),
+		clientv3.OpDelete(t.dependenciesKey(migrationID)),
+	}
+
+	resp, err := t.client.Get(ctx, t.prefix+"history/", clientv3.WithPrefix())
+	if err != nil {
+		return fmt.Errorf("failed to delete migration: %w", err)
+	}
+	for _, kv := range resp.Kvs {
+		var record historyRecord
+		if err := json.Unmarshal(kv.Value, &record); err == nil && record.MigrationID == migrationID {
+			ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+		}
+	}
+
+	// Dependencies of other migrations on this one
+	resp, err = t.client.Get(ctx, t.prefix+"dependencies/", clientv3.WithPrefix())
+	if err != nil {
+		return fmt.Errorf("failed to delete migration: %w", err)
+	}
+	for _, kv := range resp.Kvs {
+		var dependencies []dependencyRecord
+		if err := json.Unmarshal(kv.Value, &dependencies); err != nil {
+			continue
+		}
+		kept := dependencies[:0]
+		for _, dep := range dependencies {
+			if dep.DependencyID != migrationID {
+				kept = append(kept, dep)
+			}
+		}
+		if len(kept) != len(dependencies) && string(kv.Key) != t.dependenciesKey(migrationID) {
+			value, _ := json.Marshal(kept)
+			ops = append(ops, clientv3.OpPut(string(kv.Key), string(value)))
+		}
+	}
+
+	if err := t.commit(ctx, ops); err != nil {
+		return fmt.Errorf("failed to delete migration: %w", err)
+	}
+	return nil
+}
+
+// ReindexMigrations reloads the BfM migration list and updates the database state
+// This should be called asynchronously in the background
+func (t *Tracker) ReindexMigrations(ctx context.Context, registry interface{}) error {
+	type Registry interface {
+		GetAll() []*backends.MigrationScript
+	}
+	reg, ok := registry.(Registry)
+	if !ok {
+		return fmt.Errorf("registry does not implement GetAll() method")
+	}
+
+	bfmMigrationMap := make(map[string]*backends.MigrationScript)
+	for _, migration := range reg.GetAll() {
+		migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
+		bfmMigrationMap[migrationID] = migration
+	}
+
+	dbMigrations, _, err := t.GetMigrationList(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get database migrations: %w", err)
+	}
+	dbMigrationMap := make(map[string]*state.MigrationListItem)
+	for _, migration := range dbMigrations {
+		dbMigrationMap[migration.MigrationID] = migration
+	}
+
+	for migrationID, migration := range bfmMigrationMap {
+		// Stop when ctx is cancelled (e.g. the reindexer stopped) rather than finishing the pass
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		dependencies := migration.Dependencies
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+
+		// Keep the execution state of known migrations
+		dbMigration, exists := dbMigrationMap[migrationID]
+		status := "pending"
+		if exists {
+			if applied, err := t.IsMigrationApplied(ctx, migrationID); err == nil && applied {
+				status = "applied"
+			} else if dbMigration.LastStatus == "success" {
+				status = "applied"
+			} else {
+				status = dbMigration.LastStatus
+			}
+		}
+
+		tableName := ""
+		if migration.Table != nil {
+			tableName = *migration.Table
+		}
+
+		var cursor int64
+		err := t.update(ctx, func(stm concurrency.STM) error {
+			now := time.Now()
+			var record listRecord
+			found, err := getJSON(stm, t.listKey(migrationID), &record)
+			if err != nil {
+				return err
+			}
+			if !found {
+				record = listRecord{StatusLabels: []string{}, CreatedAt: now}
+			}
+			record.MigrationID = migrationID
+			record.Schema = migration.Schema
+			record.Table = tableName
+			record.Version = migration.Version
+			record.Name = migration.Name
+			record.Connection = migration.Connection
+			record.Backend = migration.Backend
+			// Filename pattern: {version}_{name}.up.{sql|json} and {version}_{name}.down.{sql|json}
+			record.UpSQL = fmt.Sprintf("%s_%s.up%s", migration.Version, migration.Name, migration.ScriptExtension())
+			record.DownSQL = fmt.Sprintf("%s_%s.down%s", migration.Version, migration.Name, migration.ScriptExtension())
+			record.Dependencies = dependencies
+			record.StructuredDependencies = migration.StructuredDependencies
+			record.Status = status
+			record.UpdatedAt = now
+			if err := putJSON(stm, t.listKey(migrationID), &record); err != nil {
+				return err
+			}
+			if !exists {
+				cursor, err = t.putChange(stm, &state.StateChange{
+					Type:        state.ChangeReindexed,
+					MigrationID: migrationID,
+					Schema:      migration.Schema,
+					Version:     migration.Version,
+					Connection:  migration.Connection,
+					Backend:     migration.Backend,
+					Status:      status,
+				}, now)
+				if err != nil {
+					return err
+				}
+			}
+
+			if migration.Schema == "" {
+				return nil
+			}
+			appliedAt := now
+			if exists && dbMigration.LastAppliedAt != "" {
+				if parsed, err := time.Parse(time.RFC3339, dbMigration.LastAppliedAt); err == nil {
+					appliedAt = parsed
+				}
+			}
+			return t.recordExecution(stm, migrationID, &state.MigrationRecord{
+				Schema:     migration.Schema,
+				Version:    migration.Version,
+				Connection: migration.Connection,
+				Backend:    migration.Backend,
+			}, status, appliedAt, now)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert migration %s: %w", migrationID, err)
+		}
+		t.trimChanges(ctx, cursor)
+	}
+
+	// Dependencies are resolved once every migration is registered
+	list, err := t.getList(ctx)
+	if err != nil {
+		return err
+	}
+	for migrationID, migration := range bfmMigrationMap {
+		if err := t.updateMigrationDependencies(ctx, list, migrationID, migration); err != nil {
+			return fmt.Errorf("failed to update dependencies for %s: %w", migrationID, err)
+		}
+	}
+
+	// Delete migrations that no longer exist in BfM
+	for migrationID, dbMigration := range dbMigrationMap {
+		if _, exists := bfmMigrationMap[migrationID]; !exists {
+			if err := t.DeleteMigration(ctx, migrationID); err != nil {
+				logger.Warnf("Failed to delete migration %s: %v", migrationID, err)
+				continue
+			}
+			if err := t.recordChange(ctx, state.RemovedChange(dbMigration)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateMigrationDependencies replaces the migrations_dependencies entry of a migration.
+// Dependencies that are not registered (other connections, not scanned yet) are skipped; the
+// registry resolves them at execution time.
+func (t *Tracker) updateMigrationDependencies(ctx context.Context, list []*listRecord, migrationID string, migration *backends.MigrationScript) error {
+	findID := func(match func(record *listRecord) bool) string {
+		for _, record := range list {
+			if match(record) {
+				return record.MigrationID
+			}
+		}
+		return ""
+	}
+
+	// lowestID finds the lowest version a version bound or name prefix dependency targets
+	lowestID := func(dep backends.Dependency) string {
+		var lowest *listRecord
+		for _, record := range list {
+			if record.Connection == dep.Connection && dep.MatchesTarget(record.Version, record.Name) && (lowest == nil || record.Version < lowest.Version) {
+				lowest = record
+			}
+		}
+		if lowest == nil {
+			return ""
+		}
+		return lowest.MigrationID
+	}
+
+	dependencies := []dependencyRecord{}
+	for _, dep := range migration.StructuredDependencies {
+		var dependencyID string
+		if dep.IsPattern() {
+			dependencyID = lowestID(dep)
+		} else {
+			dependencyID = findID(func(record *listRecord) bool {
+				if dep.TargetType == "version" {
+					return record.Connection == dep.Connection && record.Version == dep.Target
+				}
+				return record.Connection == dep.Connection && record.Name == dep.Target
+			})
+		}
+		if dependencyID == "" {
+			continue
+		}
+
+		targetType := dep.TargetType
+		if targetType == "" {
+			targetType = "name"
+		}
+		dependencies = append(dependencies, dependencyRecord{
+			DependencyID:   dependencyID,
+			Connection:     dep.Connection,
+			Schema:         schemaList(dep.Schema),
+			Target:         dep.Target,
+			TargetType:     targetType,
+			RequiresTable:  dep.RequiresTable,
+			RequiresSchema: dep.RequiresSchema,
+		})
+	}
+
+	for _, depName := range migration.Dependencies {
+		dependencyID := findID(func(record *listRecord) bool { return record.Name == depName })
+		if dependencyID == "" {
+			continue
+		}
+		dependencies = append(dependencies, dependencyRecord{
+			DependencyID: dependencyID,
+			Connection:   migration.Connection,
+			Schema:       schemaList(migration.Schema),
+			Target:       depName,
+			TargetType:   "name",
+		})
+	}
+
+	value, err := json.Marshal(dependencies)
+	if err != nil {
+		return fmt.Errorf("failed to encode dependencies: %w", err)
+	}
+	if _, err := t.client.Put(ctx, t.dependenciesKey(migrationID), string(value)); err != nil {
+		return fmt.Errorf("failed to store dependencies: %w", err)
+	}
+	return nil
+}
+
+// schemaList returns a schema as the list stored in migrations_dependencies
+func schemaList(schema string) []string {
+	if schema == "" {
+		return []string{}
+	}
+	return []string{schema}
+}
+
+// Close ends the lock session and closes the etcd client
+func (t *Tracker) Close() error {
+	t.mu.Lock()
+	if t.session != nil {
+		_ = t.session.Close()
+		t.session = nil
+	}
+	t.mu.Unlock()
+
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
@@ -0,0 +1,564 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	_ state.StateTracker              = (*Tracker)(nil)
+	_ state.Pinger                    = (*Tracker)(nil)
+	_ state.ConnectionExecutionLister = (*Tracker)(nil)
+)
+
+// newTestTracker connects to the etcd cluster in BFM_TEST_ETCD_ENDPOINTS, with a prefix of its own
+// that is removed after the test
+func newTestTracker(t *testing.T, historyLimit int) *Tracker {
+	t.Helper()
+	endpoints := os.Getenv("BFM_TEST_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("BFM_TEST_ETCD_ENDPOINTS is not set")
+	}
+
+	prefix := fmt.Sprintf("/bfm-test/%s-%d/", t.Name(), time.Now().UnixNano())
+	tracker, err := NewTracker(strings.Split(endpoints, ","), "", "", prefix, historyLimit)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = tracker.client.Delete(context.Background(), prefix, clientv3.WithPrefix())
+		_ = tracker.Close()
+	})
+	return tracker
+}
+
+func TestTracker_RecordMigration(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	const id = "20240101120000_create_users_etcd_core"
+	if err := tracker.RegisterScannedMigration(ctx, id, "", "users", "20240101120000", "create_users", "core", "etcd"); err != nil {
+		t.Fatalf("RegisterScannedMigration() error = %v", err)
+	}
+	if applied, _ := tracker.IsMigrationApplied(ctx, id); applied {
+		t.Fatal("registered migration reported as applied")
+	}
+
+	// Applied for one tenant schema
+	err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "tenant1_" + id,
+		Schema:      "tenant1",
+		Version:     "20240101120000",
+		Connection:  "core",
+		Backend:     "etcd",
+		Status:      "success",
+		Checksum:    "abc",
+	})
+	if err != nil {
+		t.Fatalf("RecordMigration() error = %v", err)
+	}
+
+	if applied, err := tracker.IsMigrationApplied(ctx, "tenant1_"+id); err != nil || !applied {
+		t.Errorf("IsMigrationApplied(tenant1) = %v, %v, want true", applied, err)
+	}
+	if applied, err := tracker.IsMigrationApplied(ctx, "tenant2_"+id); err != nil || applied {
+		t.Errorf("IsMigrationApplied(tenant2) = %v, %v, want false", applied, err)
+	}
+
+	items, _, err := tracker.GetMigrationList(ctx, &state.MigrationFilters{Table: "users"})
+	if err != nil {
+		t.Fatalf("GetMigrationList() error = %v", err)
+	}
+	if len(items) != 1 || !items[0].Applied || items[0].Checksum != "abc" || items[0].Name != "create_users" || items[0].LastAppliedAt == "" {
+		t.Fatalf("GetMigrationList() = %+v", items)
+	}
+
+	history, _, err := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{Schema: "tenant1"})
+	if err != nil {
+		t.Fatalf("GetMigrationHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0].Status != "applied" || history[0].ExecutedBy != "system" {
+		t.Fatalf("GetMigrationHistory() = %+v", history)
+	}
+
+	executions, err := tracker.GetMigrationExecutions(ctx, id)
+	if err != nil {
+		t.Fatalf("GetMigrationExecutions() error = %v", err)
+	}
+	if len(executions) != 1 || !executions[0].Applied || executions[0].Schema != "tenant1" || executions[0].Checksum != "abc" {
+		t.Fatalf("GetMigrationExecutions() = %+v", executions)
+	}
+
+	if err := tracker.SetStatusLabels(ctx, id, []string{"needs-review"}); err != nil {
+		t.Fatalf("SetStatusLabels() error = %v", err)
+	}
+	if err := tracker.SetStatusLabels(ctx, "20990101000000_missing_etcd_core", nil); !errors.Is(err, state.ErrMigrationNotFound) {
+		t.Errorf("SetStatusLabels(missing) error = %v, want ErrMigrationNotFound", err)
+	}
+	if items, _, _ := tracker.GetMigrationList(ctx, &state.MigrationFilters{Label: "needs-review"}); len(items) != 1 {
+		t.Errorf("GetMigrationList(label) = %+v", items)
+	}
+
+	// Deleting the migration removes its history and executions
+	if err := tracker.DeleteMigration(ctx, id); err != nil {
+		t.Fatalf("DeleteMigration() error = %v", err)
+	}
+	if history, _, _ := tracker.GetMigrationHistory(ctx, nil); len(history) != 0 {
+		t.Errorf("history after delete = %+v, want none", history)
+	}
+	if executions, _ := tracker.GetMigrationExecutions(ctx, id); len(executions) != 0 {
+		t.Errorf("executions after delete = %+v, want none", executions)
+	}
+}
+
+func TestTracker_HistoryIsBounded(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 3)
+
+	for i := 0; i < 5; i++ {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: "20240101120000_create_users_etcd_core",
+			Version:     "20240101120000",
+			Connection:  "core",
+			Backend:     "etcd",
+			Status:      "failed",
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	history, _, err := tracker.GetMigrationHistory(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetMigrationHistory() error = %v", err)
+	}
+	if len(history) != 3 || history[0].ID != "5" || history[2].ID != "3" {
+		t.Fatalf("GetMigrationHistory() = %d records, want the last 3", len(history))
+	}
+}
+
+func TestTracker_PagingAndSorting(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	for _, version := range []string{"20240103000000", "20240101000000", "20240104000000", "20240102000000"} {
+		id := version + "_create_table_etcd_core"
+		if err := tracker.RegisterScannedMigration(ctx, id, "", "", version, "create_table", "core", "etcd"); err != nil {
+			t.Fatalf("RegisterScannedMigration() error = %v", err)
+		}
+	}
+	const users = "20240101000000_create_table_etcd_core"
+	for _, status := range []string{"failed", "success"} {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: users, Version: "20240101000000", Connection: "core", Backend: "etcd", Status: status,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	items, total, err := tracker.GetMigrationList(ctx, &state.MigrationFilters{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("GetMigrationList() error = %v", err)
+	}
+	if total != 4 || len(items) != 2 || items[0].Version != "20240102000000" || items[1].Version != "20240103000000" {
+		t.Fatalf("GetMigrationList(page) = %d, %+v", total, items)
+	}
+	items, _, err = tracker.GetMigrationList(ctx, &state.MigrationFilters{SortBy: state.SortByAppliedAt, Limit: 1})
+	if err != nil || len(items) != 1 || items[0].MigrationID != users {
+		t.Fatalf("GetMigrationList(applied_at) = %+v, %v", items, err)
+	}
+
+	history, total, err := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{MigrationID: users, Limit: 1})
+	if err != nil {
+		t.Fatalf("GetMigrationHistory() error = %v", err)
+	}
+	if total != 2 || len(history) != 1 || history[0].Status != "applied" {
+		t.Fatalf("GetMigrationHistory(newest) = %d, %+v", total, history)
+	}
+	history, _, err = tracker.GetMigrationHistory(ctx, &state.MigrationFilters{MigrationID: users, SortBy: state.SortByAppliedAt, Limit: 1})
+	if err != nil || len(history) != 1 || history[0].Status != "failed" {
+		t.Fatalf("GetMigrationHistory(oldest) = %+v, %v", history, err)
+	}
+
+	if _, _, err := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{SortOrder: "sideways"}); !errors.Is(err, state.ErrInvalidFilters) {
+		t.Errorf("GetMigrationHistory(unknown order) error = %v, want ErrInvalidFilters", err)
+	}
+}
+
+func TestTracker_HistoryFilters(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	executions := []struct{ executedBy, method, appliedAt string }{
+		{"alice", "api", "2026-10-12T23:59:59Z"},
+		{"alice", "cli", "2026-10-13T09:00:00Z"},
+		{"bob", "api", "2026-10-13T10:00:00Z"},
+		{"alice", "api", "2026-10-14T00:00:00Z"},
+	}
+	for i, e := range executions {
+		version := fmt.Sprintf("2024010%d000000", i+1)
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: version + "_create_table_etcd_core", Version: version, Connection: "core", Backend: "etcd",
+			Status: "success", ExecutedBy: e.executedBy, ExecutionMethod: e.method, AppliedAt: e.appliedAt,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	tuesday := &state.MigrationFilters{
+		AppliedAfter:  time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+		AppliedBefore: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+	}
+	if _, total, err := tracker.GetMigrationHistory(ctx, tuesday); err != nil || total != 2 {
+		t.Errorf("GetMigrationHistory(day) total = %d, %v, want 2", total, err)
+	}
+	tuesday.ExecutedBy = "alice"
+	history, total, err := tracker.GetMigrationHistory(ctx, tuesday)
+	if err != nil || total != 1 || history[0].ExecutionMethod != "cli" {
+		t.Errorf("GetMigrationHistory(day, alice) = %d, %+v, %v", total, history, err)
+	}
+	if _, total, _ := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{ExecutedBy: "alice", ExecutionMethod: "api"}); total != 2 {
+		t.Errorf("GetMigrationHistory(alice, api) total = %d, want 2", total)
+	}
+
+	err = tracker.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "20240105000000_create_table_etcd_core", Version: "20240105000000", Connection: "core", Backend: "etcd",
+		Status: "failed", ErrorMessage: "permission denied for schema public", ErrorClass: "permission",
+	})
+	if err != nil {
+		t.Fatalf("RecordMigration() error = %v", err)
+	}
+	history, total, err = tracker.GetMigrationHistory(ctx, &state.MigrationFilters{ErrorClass: "permission"})
+	if err != nil || total != 1 || history[0].ErrorClass != "permission" || history[0].Status != "failed" {
+		t.Errorf("GetMigrationHistory(permission) = %d, %+v, %v", total, history, err)
+	}
+
+	backwards := &state.MigrationFilters{AppliedAfter: tuesday.AppliedBefore, AppliedBefore: tuesday.AppliedAfter}
+	if _, _, err := tracker.GetMigrationHistory(ctx, backwards); !errors.Is(err, state.ErrInvalidFilters) {
+		t.Errorf("GetMigrationHistory(empty window) error = %v, want ErrInvalidFilters", err)
+	}
+}
+
+func TestTracker_GetConnectionExecutions(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	for _, execution := range []struct{ version, schema, connection string }{
+		{"20240102120000", "tenant_b", "core"},
+		{"20240101120000", "tenant_b", "core"},
+		{"20240101120000", "tenant_a", "core"},
+		{"20240101120000", "tenant_a", "audit"},
+	} {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: execution.schema + "_" + execution.version + "_create_users_etcd_" + execution.connection,
+			Schema:      execution.schema, Version: execution.version, Connection: execution.connection, Backend: "etcd", Status: "success",
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration() error = %v", err)
+		}
+	}
+
+	executions, err := tracker.GetConnectionExecutions(ctx, "core")
+	if err != nil {
+		t.Fatalf("GetConnectionExecutions() error = %v", err)
+	}
+	var got []string
+	for _, execution := range executions {
+		got = append(got, execution.Version+"/"+execution.Schema+"/"+execution.Status)
+	}
+	if want := "[20240101120000/tenant_a/applied 20240101120000/tenant_b/applied 20240102120000/tenant_b/applied]"; fmt.Sprint(got) != want {
+		t.Errorf("GetConnectionExecutions() = %v, want %s", got, want)
+	}
+}
+
+func TestTracker_Locks(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	err := tracker.WithConnectionLock(ctx, "core", "host-1", func() error {
+		if err := tracker.WithConnectionLock(ctx, "core", "host-2", func() error { return nil }); !errors.Is(err, state.ErrConnectionLocked) {
+			t.Errorf("nested WithConnectionLock() error = %v, want ErrConnectionLocked", err)
+		}
+		locks, err := tracker.ListLocks(ctx)
+		if err != nil || len(locks) != 1 || locks[0].Holder != "host-1" || locks[0].Connection != "core" {
+			t.Errorf("ListLocks() = %+v, %v", locks, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithConnectionLock() error = %v", err)
+	}
+	if locks, _ := tracker.ListLocks(ctx); len(locks) != 0 {
+		t.Errorf("ListLocks() after release = %+v, want none", locks)
+	}
+
+	err = tracker.WithMigrationExecutionLock(ctx, "m1", "tenant1", "core", func() error {
+		if err := tracker.WithMigrationExecutionLock(ctx, "m1", "tenant1", "core", func() error { return nil }); !errors.Is(err, state.ErrMigrationAlreadyInProgress) {
+			t.Errorf("nested WithMigrationExecutionLock() error = %v, want ErrMigrationAlreadyInProgress", err)
+		}
+		// Other schemas run concurrently
+		return tracker.WithMigrationExecutionLock(ctx, "m1", "tenant2", "core", func() error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("WithMigrationExecutionLock() error = %v", err)
+	}
+
+	primary, err := tracker.AcquirePrimaryLock(ctx)
+	if err != nil {
+		t.Fatalf("AcquirePrimaryLock() error = %v", err)
+	}
+	if _, err := tracker.AcquirePrimaryLock(ctx); !errors.Is(err, state.ErrPrimaryLocked) {
+		t.Errorf("second AcquirePrimaryLock() error = %v, want ErrPrimaryLocked", err)
+	}
+	if err := primary.Check(ctx); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	primary.Release()
+	if err := primary.Check(ctx); err == nil {
+		t.Error("Check() after Release() error = nil, want lost lock")
+	}
+}
+
+func TestTracker_Audit(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	for _, op := range []string{"up", "down", "up"} {
+		record := &state.AuditRecord{Operation: op, Protocol: "http", Method: "POST /api/v1/migrations/" + op, Outcome: state.AuditOutcomeSuccess}
+		if err := tracker.RecordAudit(ctx, record); err != nil {
+			t.Fatalf("RecordAudit() error = %v", err)
+		}
+		if record.ID == 0 || record.CreatedAt == "" {
+			t.Errorf("RecordAudit() did not set ID and CreatedAt: %+v", record)
+		}
+	}
+
+	records, total, err := tracker.GetAuditLog(ctx, &state.AuditFilters{Operation: "up", Offset: 1})
+	if err != nil {
+		t.Fatalf("GetAuditLog() error = %v", err)
+	}
+	if total != 2 || len(records) != 1 || records[0].ID != 1 {
+		t.Errorf("GetAuditLog() = %+v, total %d", records, total)
+	}
+}
+
+func TestTracker_Jobs(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	for _, status := range []string{state.JobQueued, state.JobPickedUp, state.JobRunning} {
+		job := &state.Job{ID: "job-1", Status: status, Connection: "core", Schema: "tenant_a", Target: `{"connection":"core"}`}
+		if status == state.JobPickedUp {
+			job.Worker = "worker-1:42"
+		}
+		if err := tracker.RecordJob(ctx, job); err != nil {
+			t.Fatalf("RecordJob(%s) error = %v", status, err)
+		}
+	}
+	err := tracker.RecordJob(ctx, &state.Job{ID: "job-1", Status: state.JobCompleted, Connection: "core", Applied: []string{"m1", "m2"}})
+	if err != nil {
+		t.Fatalf("RecordJob(completed) error = %v", err)
+	}
+	if err := tracker.RecordJob(ctx, &state.Job{ID: "job-2", Status: state.JobFailed, Connection: "core", Errors: []string{"boom"}}); err != nil {
+		t.Fatalf("RecordJob(failed) error = %v", err)
+	}
+
+	job, err := tracker.GetJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != state.JobCompleted || job.Worker != "worker-1:42" || job.Schema != "tenant_a" || len(job.Applied) != 2 {
+		t.Errorf("GetJob() = %+v", job)
+	}
+	if job.QueuedAt == "" || job.StartedAt == "" || job.FinishedAt == "" {
+		t.Errorf("GetJob() timestamps = %q, %q, %q", job.QueuedAt, job.StartedAt, job.FinishedAt)
+	}
+
+	jobs, total, err := tracker.GetJobs(ctx, &state.JobFilters{Status: state.JobFailed})
+	if err != nil {
+		t.Fatalf("GetJobs() error = %v", err)
+	}
+	if total != 1 || len(jobs) != 1 || jobs[0].ID != "job-2" || jobs[0].Errors[0] != "boom" {
+		t.Errorf("GetJobs(failed) = %+v, total %d", jobs, total)
+	}
+	if _, total, _ := tracker.GetJobs(ctx, &state.JobFilters{Limit: 1}); total != 2 {
+		t.Errorf("GetJobs() total = %d, want 2", total)
+	}
+
+	if _, err := tracker.GetJob(ctx, "unknown"); !errors.Is(err, state.ErrJobNotFound) {
+		t.Errorf("GetJob(unknown) error = %v, want ErrJobNotFound", err)
+	}
+
+	// A dead-lettered job queued again (replayed) starts over
+	err = tracker.RecordJob(ctx, &state.Job{ID: "job-2", Status: state.JobDeadLettered, Connection: "core", Attempts: 3, Payload: `{"id":"job-2"}`})
+	if err != nil {
+		t.Fatalf("RecordJob(dead_lettered) error = %v", err)
+	}
+	if job, _ := tracker.GetJob(ctx, "job-2"); job.Attempts != 3 || job.Payload != `{"id":"job-2"}` || job.FinishedAt == "" {
+		t.Errorf("GetJob(dead_lettered) = %+v", job)
+	}
+	if err := tracker.RecordJob(ctx, &state.Job{ID: "job-2", Status: state.JobQueued, Connection: "core", Payload: `{"id":"job-2"}`}); err != nil {
+		t.Fatalf("RecordJob(queued) error = %v", err)
+	}
+	if job, _ := tracker.GetJob(ctx, "job-2"); job.Status != state.JobQueued || job.Attempts != 0 || job.StartedAt != "" || job.FinishedAt != "" {
+		t.Errorf("GetJob(replayed) = %+v", job)
+	}
+
+	// A scheduled job keeps its scheduled time once queued
+	const scheduledAt = "2030-01-02T03:04:05Z"
+	if err := tracker.RecordJob(ctx, &state.Job{ID: "job-3", Status: state.JobScheduled, Connection: "core", ScheduledAt: scheduledAt}); err != nil {
+		t.Fatalf("RecordJob(scheduled) error = %v", err)
+	}
+	if err := tracker.RecordJob(ctx, &state.Job{ID: "job-3", Status: state.JobQueued, Connection: "core"}); err != nil {
+		t.Fatalf("RecordJob(queued) error = %v", err)
+	}
+	if job, _ := tracker.GetJob(ctx, "job-3"); job.Status != state.JobQueued || job.ScheduledAt != scheduledAt {
+		t.Errorf("GetJob(scheduled) = %+v", job)
+	}
+}
+
+func TestTracker_EmergencyRuns(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	due := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	for _, id := range []string{"emergency_1", "emergency_2"} {
+		run := &state.EmergencyRun{ID: id, Operation: "up", Actor: "oncall", Reason: "INC-42", Method: "POST /api/v1/migrations/up", PostmortemDue: due}
+		if err := tracker.RecordEmergencyRun(ctx, run); err != nil {
+			t.Fatalf("RecordEmergencyRun(%s) error = %v", id, err)
+		}
+	}
+	// Recording a run again without a postmortem changes nothing
+	if err := tracker.RecordEmergencyRun(ctx, &state.EmergencyRun{ID: "emergency_1", Operation: "down", Reason: "other", PostmortemDue: due}); err != nil {
+		t.Fatalf("RecordEmergencyRun() error = %v", err)
+	}
+	run, err := tracker.GetEmergencyRun(ctx, "emergency_1")
+	if err != nil {
+		t.Fatalf("GetEmergencyRun() error = %v", err)
+	}
+	if run.Operation != "up" || run.Reason != "INC-42" || run.PostmortemDue != due || run.StartedAt == "" || !run.Pending() {
+		t.Fatalf("unexpected emergency run %+v", run)
+	}
+
+	run.Postmortem, run.PostmortemBy = "https://wiki/INC-42", "lead"
+	if err := tracker.RecordEmergencyRun(ctx, run); err != nil {
+		t.Fatalf("RecordEmergencyRun(postmortem) error = %v", err)
+	}
+	if run, err = tracker.GetEmergencyRun(ctx, "emergency_1"); err != nil || run.Pending() || run.PostmortemBy != "lead" || run.PostmortemAt == "" {
+		t.Fatalf("expected the postmortem to be recorded, got %+v, %v", run, err)
+	}
+
+	runs, total, err := tracker.GetEmergencyRuns(ctx, &state.EmergencyRunFilters{Pending: true})
+	if err != nil || total != 1 || len(runs) != 1 || runs[0].ID != "emergency_2" {
+		t.Errorf("expected only emergency_2 to be pending, got %v (%d), %v", runs, total, err)
+	}
+	runs, total, err = tracker.GetEmergencyRuns(ctx, &state.EmergencyRunFilters{Limit: 1, Offset: 1})
+	if err != nil || total != 2 || len(runs) != 1 {
+		t.Errorf("expected the second of 2 runs, got %v (%d), %v", runs, total, err)
+	}
+	if _, err := tracker.GetEmergencyRun(ctx, "emergency_0"); !errors.Is(err, state.ErrEmergencyRunNotFound) {
+		t.Errorf("expected ErrEmergencyRunNotFound, got %v", err)
+	}
+}
+
+func TestSchemaMatches(t *testing.T) {
+	tests := []struct {
+		value, schema string
+		want          bool
+	}{
+		{"core", "core", true},
+		{"tenant1,tenant2", "tenant2", true},
+		{"tenant10", "tenant1", false},
+		{"", "core", false},
+	}
+	for _, tt := range tests {
+		if got := schemaMatches(tt.value, tt.schema); got != tt.want {
+			t.Errorf("schemaMatches(%q, %q) = %v, want %v", tt.value, tt.schema, got, tt.want)
+		}
+	}
+}
+
+func TestTracker_StateChanges(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(t, 0)
+
+	const id = "20240101120000_create_users_postgresql_core"
+	reg := registry.NewInMemoryRegistry()
+	if err := reg.Register(&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	// A reindex records the migrations it registers, once
+	for i := 0; i < 2; i++ {
+		if err := tracker.ReindexMigrations(ctx, reg); err != nil {
+			t.Fatalf("ReindexMigrations() error = %v", err)
+		}
+	}
+
+	record := func(operation, status, message string) {
+		t.Helper()
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID:  id,
+			Operation:    operation,
+			Version:      "20240101120000",
+			Connection:   "core",
+			Backend:      "postgresql",
+			Status:       status,
+			ErrorMessage: message,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration(%s) error = %v", status, err)
+		}
+	}
+	record(state.OperationUp, "success", "")
+	record(state.OperationUp, "pending", "")
+	record(state.OperationUp, "failed", "boom")
+	record(state.OperationRollback, "success", "")
+
+	// Reindexing without the migration removes it
+	if err := tracker.ReindexMigrations(ctx, registry.NewInMemoryRegistry()); err != nil {
+		t.Fatalf("ReindexMigrations() error = %v", err)
+	}
+
+	changes, err := tracker.GetStateChanges(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GetStateChanges() error = %v", err)
+	}
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Type+":"+change.Status)
+		if change.MigrationID != id || change.Connection != "core" || change.CreatedAt == "" {
+			t.Errorf("GetStateChanges() change = %+v", change)
+		}
+	}
+	want := "[reindexed:pending applied:applied failed:failed rolled_back:rolled_back reindexed:removed]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("GetStateChanges() = %v, want %s", got, want)
+	}
+	if changes[2].Error != "boom" {
+		t.Errorf("failed change error = %q, want boom", changes[2].Error)
+	}
+
+	page, err := tracker.GetStateChanges(ctx, changes[1].Cursor, 2)
+	if err != nil {
+		t.Fatalf("GetStateChanges(since) error = %v", err)
+	}
+	if len(page) != 2 || page[0].Cursor != changes[2].Cursor || page[1].Cursor != changes[3].Cursor {
+		t.Errorf("GetStateChanges(since %d, 2) = %+v", changes[1].Cursor, page)
+	}
+	if rest, _ := tracker.GetStateChanges(ctx, changes[4].Cursor, 0); len(rest) != 0 {
+		t.Errorf("GetStateChanges(last cursor) = %+v, want none", rest)
+	}
+}
@@ -1,8 +1,80 @@
 package state
 
+import "encoding/json"
+
+// History operations: what a history record did to its migration. Every record is stored under
+// the ID of the migration it ran.
+const (
+	OperationUp       = "up"       // Applied the migration
+	OperationDown     = "down"     // Reverted it with a down migration
+	OperationRollback = "rollback" // Reverted it with a rollback
+)
+
 // HistoryStatusIndicatesApplied returns true if a migrations_history status means
 // the migration completed successfully. The tracker maps executor "success" to "applied"
 // when persisting; both must be treated as applied everywhere we interpret history.
 func HistoryStatusIndicatesApplied(status string) bool {
 	return status == "success" || status == "applied"
 }
+
+// HistoryOperation returns the operation of a record, OperationUp if it has none
+func (r *MigrationRecord) HistoryOperation() string {
+	if r.Operation == "" {
+		return OperationUp
+	}
+	return r.Operation
+}
+
+// Reverts reports whether the record is a down migration or a rollback
+func (r *MigrationRecord) Reverts() bool {
+	return r.Operation == OperationDown || r.Operation == OperationRollback
+}
+
+// EmergencyRunID returns the emergency run a record was executed in (the emergency_run of its
+// execution context), or "" for a regular execution
+func (r *MigrationRecord) EmergencyRunID() string {
+	if r.ExecutionContext == "" {
+		return ""
+	}
+	var execCtx struct {
+		EmergencyRun string `json:"emergency_run"`
+	}
+	if err := json.Unmarshal([]byte(r.ExecutionContext), &execCtx); err != nil {
+		return ""
+	}
+	return execCtx.EmergencyRun
+}
+
+// RunID returns the run a record was executed in (the run_id of its execution context), or "" for
+// records created before runs were recorded
+func (r *MigrationRecord) RunID() string {
+	if r.ExecutionContext == "" {
+		return ""
+	}
+	var execCtx struct {
+		RunID string `json:"run_id"`
+	}
+	if err := json.Unmarshal([]byte(r.ExecutionContext), &execCtx); err != nil {
+		return ""
+	}
+	return execCtx.RunID
+}
+
+// RunIDNeedle returns the text an execution context stamped with runID contains, for trackers that
+// filter history on MigrationFilters.RunID by substring: execution contexts are compact JSON
+func RunIDNeedle(runID string) string {
+	quoted, _ := json.Marshal(runID)
+	return `"run_id":` + string(quoted)
+}
+
+// HistoryStatus returns the status a record is persisted with: "success" is stored as "applied",
+// or as "rolled_back" for a down migration or rollback
+func (r *MigrationRecord) HistoryStatus() string {
+	if !HistoryStatusIndicatesApplied(r.Status) {
+		return r.Status
+	}
+	if r.Reverts() {
+		return "rolled_back"
+	}
+	return "applied"
+}
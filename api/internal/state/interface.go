@@ -1,6 +1,14 @@
 package state
 
-import "github.com/toolsascode/bfm/api/internal/backends"
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
 
 // MigrationRecord represents a migration execution record in state tracking (moved here to avoid import cycle)
 type MigrationRecord struct {
@@ -13,10 +21,13 @@ type MigrationRecord struct {
 	Backend          string
 	AppliedAt        string
 	Status           string // "success", "failed", "pending", "rolled_back"
+	Operation        string // OperationUp (also when empty), OperationDown or OperationRollback
 	ErrorMessage     string
+	ErrorClass       string // Root cause of a failure (see backends.FailureClass); empty unless Status is "failed"
 	ExecutedBy       string // User identifier (from auth context)
 	ExecutionMethod  string // "manual", "api", "cli", "worker"
 	ExecutionContext string // JSON with additional context (job_id, request_id, etc.)
+	Checksum         string // SHA-256 of the applied UpSQL; set for successful up executions only
 }
 
 // MigrationListItem represents a migration in the list with its last execution status
@@ -32,72 +43,140 @@ type MigrationListItem struct {
 	LastAppliedAt    string
 	LastErrorMessage string
 	Applied          bool
+	Checksum         string // Checksum of the UpSQL that was applied, empty if unknown
+	StatusLabels     []string
 }
 
-// StateTracker manages migration state tracking
+// StateTracker manages migration state tracking. Every method honors the deadline and cancellation
+// of ctx.
 type StateTracker interface {
 	// RecordMigration records a migration execution
-	RecordMigration(ctx interface{}, migration *MigrationRecord) error
+	RecordMigration(ctx context.Context, migration *MigrationRecord) error
 
-	// GetMigrationHistory retrieves migration history with optional filters
-	GetMigrationHistory(ctx interface{}, filters *MigrationFilters) ([]*MigrationRecord, error)
+	// GetMigrationHistory retrieves migration history with optional filters, newest first unless
+	// filters sort it otherwise, and the total number of matching records before Limit and Offset
+	// are applied
+	GetMigrationHistory(ctx context.Context, filters *MigrationFilters) ([]*MigrationRecord, int, error)
 
-	// GetMigrationList retrieves the list of migrations with their last status
-	GetMigrationList(ctx interface{}, filters *MigrationFilters) ([]*MigrationListItem, error)
+	// GetMigrationList retrieves the list of migrations with their last status, ordered by version
+	// unless filters sort it otherwise, and the total number of matching migrations before Limit and
+	// Offset are applied
+	GetMigrationList(ctx context.Context, filters *MigrationFilters) ([]*MigrationListItem, int, error)
 
 	// IsMigrationApplied checks if a migration has been successfully applied.
 	// This only returns true for migrations with status 'applied', not 'pending'.
 	// For concurrency control (checking if a migration is pending or applied),
 	// use IsMigrationPendingOrApplied instead.
-	IsMigrationApplied(ctx interface{}, migrationID string) (bool, error)
+	IsMigrationApplied(ctx context.Context, migrationID string) (bool, error)
 
 	// IsMigrationPendingOrApplied checks if a migration is pending or applied.
 	// For schema-specific IDs, a row in migrations_executions with status pending may indicate
 	// an in-flight run. For base IDs, migrations_list "pending" only means registered-not-applied;
 	// cross-process exclusion is enforced via WithMigrationExecutionLock.
-	IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error)
+	IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error)
 
 	// WithMigrationExecutionLock runs fn while holding an exclusive lock for this migration
 	// execution key. If another session holds the lock, returns ErrMigrationAlreadyInProgress.
-	WithMigrationExecutionLock(ctx interface{}, migrationID, schema, connection string, fn func() error) error
+	WithMigrationExecutionLock(ctx context.Context, migrationID, schema, connection string, fn func() error) error
+
+	// WithConnectionLock runs fn while holding an exclusive lock on a connection, so that only one
+	// process (server replica, worker) applies migrations on it at a time. holder identifies the
+	// process in ListLocks. If another session holds the lock, returns ErrConnectionLocked.
+	WithConnectionLock(ctx context.Context, connection, holder string, fn func() error) error
+
+	// ListLocks returns the connection locks currently held
+	ListLocks(ctx context.Context) ([]*MigrationLock, error)
+
+	// ReleaseLock force-releases the lock on a connection held by another session (e.g. a stuck or
+	// crashed process). Returns false if the connection was not locked.
+	ReleaseLock(ctx context.Context, connection string) (bool, error)
+
+	// AcquirePrimaryLock takes the lock that elects the primary among server instances sharing the
+	// state database, and holds it until the returned lock is released or its session ends. If
+	// another instance holds it, returns ErrPrimaryLocked.
+	AcquirePrimaryLock(ctx context.Context) (PrimaryLock, error)
 
 	// GetLastMigrationVersion gets the last applied version for a schema/table
-	GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error)
+	GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error)
 
 	// RegisterScannedMigration registers a scanned migration in migrations_list (status: pending)
-	RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error
+	RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error
+
+	// SetStatusLabels replaces the user-defined status labels of a migration in migrations_list.
+	// Labels are operational workflow state kept apart from the status set by executions. Returns
+	// ErrMigrationNotFound when the migration is not in migrations_list.
+	SetStatusLabels(ctx context.Context, migrationID string, labels []string) error
 
 	// UpdateMigrationInfo updates migration metadata (schema, version, name, connection, backend) without affecting status/history
-	UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error
+	UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error
 
 	// DeleteMigration deletes a migration from migrations_list (cascades to history via foreign key)
-	DeleteMigration(ctx interface{}, migrationID string) error
+	DeleteMigration(ctx context.Context, migrationID string) error
 
 	// Initialize sets up the state tracking tables
-	Initialize(ctx interface{}) error
+	Initialize(ctx context.Context) error
 
 	// ReindexMigrations reloads the BfM migration list and updates the database state
 	// This should be called asynchronously in the background
-	ReindexMigrations(ctx interface{}, registry interface{}) error
+	ReindexMigrations(ctx context.Context, registry interface{}) error
 
 	// GetMigrationDetail retrieves detailed information about a single migration from migrations_list
-	GetMigrationDetail(ctx interface{}, migrationID string) (*MigrationDetail, error)
+	GetMigrationDetail(ctx context.Context, migrationID string) (*MigrationDetail, error)
 
 	// GetMigrationExecutions retrieves all execution records for a migration, ordered by created_at DESC
-	GetMigrationExecutions(ctx interface{}, migrationID string) ([]*MigrationExecution, error)
+	GetMigrationExecutions(ctx context.Context, migrationID string) ([]*MigrationExecution, error)
 
 	// GetRecentExecutions retrieves recent execution records across all migrations, ordered by created_at DESC
-	GetRecentExecutions(ctx interface{}, limit int) ([]*MigrationExecution, error)
+	GetRecentExecutions(ctx context.Context, limit int) ([]*MigrationExecution, error)
 
 	// RecordSkippedMigrations records skipped migrations for a given execution context
-	RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error
+	RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error
 
 	// GetSkippedMigrations retrieves skipped migrations, optionally filtered by migration_id or recent limit
-	GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*SkippedMigration, error)
+	GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*SkippedMigration, error)
 
 	// RecordDependencyMigration records a dependency migration as applied without creating history entries.
 	// Dependencies should only be recorded in the execution history of the migration that depends on them.
-	RecordDependencyMigration(ctx interface{}, migration *MigrationRecord) error
+	RecordDependencyMigration(ctx context.Context, migration *MigrationRecord) error
+
+	// RecordAudit appends a record to the audit log (migrations_audit). Audit records are never
+	// updated or deleted.
+	RecordAudit(ctx context.Context, record *AuditRecord) error
+
+	// GetAuditLog retrieves audit records matching filters, ordered by created_at DESC, and the total
+	// number of matching records before Limit and Offset are applied
+	GetAuditLog(ctx context.Context, filters *AuditFilters) ([]*AuditRecord, int, error)
+
+	// RecordJob records an async job in migrations_jobs: it creates the job, or updates the status,
+	// worker, attempts, results and errors of an existing one. The tracker sets QueuedAt when the job
+	// is created or queued again (replayed), StartedAt when it is picked up, FinishedAt when it
+	// finishes, and UpdatedAt. ScheduledAt is kept when an update leaves it empty.
+	RecordJob(ctx context.Context, job *Job) error
+
+	// GetJob returns a job by ID, or ErrJobNotFound
+	GetJob(ctx context.Context, id string) (*Job, error)
+
+	// GetJobs retrieves jobs matching filters, most recently queued first, and the total number of
+	// matching jobs before Limit and Offset are applied
+	GetJobs(ctx context.Context, filters *JobFilters) ([]*Job, int, error)
+
+	// RecordEmergencyRun records an emergency (break-glass) request in migrations_emergency_runs: it
+	// creates the run, or records the postmortem of an existing one. The tracker sets StartedAt when
+	// the run is created and PostmortemAt when its postmortem is recorded.
+	RecordEmergencyRun(ctx context.Context, run *EmergencyRun) error
+
+	// GetEmergencyRun returns an emergency run by ID, or ErrEmergencyRunNotFound
+	GetEmergencyRun(ctx context.Context, id string) (*EmergencyRun, error)
+
+	// GetEmergencyRuns retrieves emergency runs matching filters, most recent first, and the total
+	// number of matching runs before Limit and Offset are applied
+	GetEmergencyRuns(ctx context.Context, filters *EmergencyRunFilters) ([]*EmergencyRun, int, error)
+
+	// GetStateChanges returns the state changes after the cursor since (0 for the start of the
+	// feed), oldest first, at most limit of them when limit is positive. The tracker appends a
+	// change when it records an applied, failed or rolled back execution, and when a reindex
+	// registers or removes a migration.
+	GetStateChanges(ctx context.Context, since int64, limit int) ([]*StateChange, error)
 }
 
 // MigrationDetail represents detailed information about a migration from migrations_list
@@ -113,6 +192,61 @@ type MigrationDetail struct {
 	Dependencies           []string
 	StructuredDependencies []backends.Dependency
 	Status                 string
+	StatusLabels           []string
+}
+
+// MigrationLock represents a held connection lock
+type MigrationLock struct {
+	Connection string
+	Holder     string // Process that holds the lock (host, pid and execution method)
+	AcquiredAt string
+}
+
+// PrimaryLock is a held primary lock (see StateTracker.AcquirePrimaryLock)
+type PrimaryLock interface {
+	// Check returns an error if the lock was lost, e.g. because the session holding it ended
+	Check(ctx context.Context) error
+
+	// Release releases the lock
+	Release()
+}
+
+// Pinger is implemented by state trackers that can check the state database is reachable more
+// cheaply than Initialize, which also creates missing tables
+type Pinger interface {
+	// Ping returns an error if the state database cannot be reached
+	Ping(ctx context.Context) error
+}
+
+// ConnectionExecutionLister is implemented by trackers that read the executions of every migration
+// on a connection at once, for reports over all its tenants. Other trackers are read one migration
+// at a time.
+type ConnectionExecutionLister interface {
+	// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+	// ordered by migration_id and schema
+	GetConnectionExecutions(ctx context.Context, connection string) ([]*MigrationExecution, error)
+}
+
+// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+// ordered by migration_id and schema, in one query when tracker is a ConnectionExecutionLister
+func GetConnectionExecutions(ctx context.Context, tracker StateTracker, connection string) ([]*MigrationExecution, error) {
+	if lister, ok := tracker.(ConnectionExecutionLister); ok {
+		return lister.GetConnectionExecutions(ctx, connection)
+	}
+	migrations, _, err := tracker.GetMigrationList(ctx, &MigrationFilters{Connection: connection, SortBy: SortByMigrationID})
+	if err != nil {
+		return nil, err
+	}
+	var executions []*MigrationExecution
+	for _, migration := range migrations {
+		records, err := tracker.GetMigrationExecutions(ctx, migration.MigrationID)
+		if err != nil {
+			return nil, err
+		}
+		slices.SortFunc(records, func(a, b *MigrationExecution) int { return strings.Compare(a.Schema, b.Schema) })
+		executions = append(executions, records...)
+	}
+	return executions, nil
 }
 
 // MigrationExecution represents an execution record in migrations_executions
@@ -125,6 +259,7 @@ type MigrationExecution struct {
 	Status      string
 	Applied     bool
 	AppliedAt   string
+	Checksum    string // Checksum of the UpSQL last applied in the schema, empty if unknown
 	CreatedAt   string
 	UpdatedAt   string
 }
@@ -137,6 +272,159 @@ type MigrationFilters struct {
 	Backend    string
 	Status     string
 	Version    string
+	Label      string // User-defined status label (see StateTracker.SetStatusLabels)
+
+	// History only: records of this migration, whatever their operation (see MatchesMigrationID)
+	MigrationID string
+
+	// History only: records of this run (see MigrationRecord.RunID)
+	RunID string
+
+	// History only: records applied at or after AppliedAfter and before AppliedBefore (when not
+	// zero), executed by ExecutedBy with ExecutionMethod ("manual", "api", "cli", "worker")
+	AppliedAfter    time.Time
+	AppliedBefore   time.Time
+	ExecutedBy      string
+	ExecutionMethod string
+	ErrorClass      string // Failure class (see backends.FailureClass)
+
+	// Paging and ordering. Limit 0 returns every match. SortBy is one of the SortBy constants and
+	// SortOrder SortAsc or SortDesc; lists default to version ascending, history to applied_at
+	// descending.
+	Limit     int
+	Offset    int
+	SortBy    string
+	SortOrder string
+}
+
+// Sort fields of MigrationFilters
+const (
+	SortByMigrationID = "migration_id"
+	SortBySchema      = "schema"
+	SortByVersion     = "version"
+	SortByConnection  = "connection"
+	SortByBackend     = "backend"
+	SortByStatus      = "status"
+	SortByAppliedAt   = "applied_at"
+)
+
+// Sort orders of MigrationFilters
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Validate checks the paging and ordering of filters, returning an error wrapping ErrInvalidFilters
+func (f *MigrationFilters) Validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.SortBy {
+	case "", SortByMigrationID, SortBySchema, SortByVersion, SortByConnection, SortByBackend, SortByStatus, SortByAppliedAt:
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidFilters, f.SortBy)
+	}
+	switch f.SortOrder {
+	case "", SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("%w: sort order must be %q or %q", ErrInvalidFilters, SortAsc, SortDesc)
+	}
+	if f.Limit < 0 || f.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must be zero or more", ErrInvalidFilters)
+	}
+	if !f.AppliedAfter.IsZero() && !f.AppliedBefore.IsZero() && !f.AppliedBefore.After(f.AppliedAfter) {
+		return fmt.Errorf("%w: applied_before must be after applied_after", ErrInvalidFilters)
+	}
+	return nil
+}
+
+// MatchesAppliedAt reports whether a history record applied at appliedAt is in the window filters
+// select with AppliedAfter and AppliedBefore
+func (f *MigrationFilters) MatchesAppliedAt(appliedAt time.Time) bool {
+	if f == nil {
+		return true
+	}
+	return (f.AppliedAfter.IsZero() || !appliedAt.Before(f.AppliedAfter)) &&
+		(f.AppliedBefore.IsZero() || appliedAt.Before(f.AppliedBefore))
+}
+
+// Ordering returns the field filters sort by and whether in descending order, defaulting to
+// defaultBy in defaultOrder
+func (f *MigrationFilters) Ordering(defaultBy, defaultOrder string) (string, bool) {
+	by, order := defaultBy, defaultOrder
+	if f != nil && f.SortBy != "" {
+		by, order = f.SortBy, SortAsc
+	}
+	if f != nil && f.SortOrder != "" {
+		order = f.SortOrder
+	}
+	return by, order == SortDesc
+}
+
+// MatchesMigrationID reports whether a history record's migration ID belongs to the migration
+// filters select with MigrationID. Down migrations and rollbacks are recorded under the ID of the
+// migration they revert.
+func (f *MigrationFilters) MatchesMigrationID(recordID string) bool {
+	return f == nil || f.MigrationID == "" || recordID == f.MigrationID
+}
+
+// Page returns the bounds of the page of n sorted matches that filters select
+func (f *MigrationFilters) Page(n int) (start, end int) {
+	if f == nil {
+		return 0, n
+	}
+	start, end = f.Offset, n
+	if start > n {
+		start = n
+	}
+	if f.Limit > 0 && start+f.Limit < end {
+		end = start + f.Limit
+	}
+	return start, end
+}
+
+// BaseMigrationID removes the prefixes (organization ID, schema) of a migration ID. The base ID is
+// {version}_{name}_{backend}_{connection}, starting at the first 14-digit version; IDs without one
+// (legacy formats) are returned as-is.
+func BaseMigrationID(migrationID string) string {
+	parts := strings.Split(migrationID, "_")
+	if len(parts) < 4 {
+		return migrationID
+	}
+	for i, part := range parts {
+		if len(part) == 14 && strings.Trim(part, "0123456789") == "" {
+			return strings.Join(parts[i:], "_")
+		}
+	}
+	return migrationID
+}
+
+// SchemaPrefix returns the schema a schema-specific migration ID was prefixed with, or "" for a
+// base ID. The whole prefix is the schema, so schema names may contain underscores (tenant_a).
+func SchemaPrefix(migrationID, baseMigrationID string) string {
+	if baseMigrationID == migrationID {
+		return ""
+	}
+	return strings.TrimSuffix(migrationID, "_"+baseMigrationID)
+}
+
+// MigrationName extracts the name from a base ID: {version}_{name}_{backend}_{connection}
+func MigrationName(baseMigrationID string) string {
+	parts := strings.Split(baseMigrationID, "_")
+	if len(parts) < 4 {
+		return ""
+	}
+	return strings.Join(parts[1:len(parts)-2], "_")
+}
+
+// AppliedTime returns when the record was applied, defaulting to now
+func (r *MigrationRecord) AppliedTime() time.Time {
+	if r.AppliedAt != "" {
+		if parsed, err := time.Parse(time.RFC3339, r.AppliedAt); err == nil {
+			return parsed
+		}
+	}
+	return time.Now()
 }
 
 // SkippedMigration represents a skipped migration record
@@ -153,3 +441,166 @@ type SkippedMigration struct {
 	SkippedAt        string
 	CreatedAt        string
 }
+
+// Audit record outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomePartial = "partial" // Some migrations failed
+	AuditOutcomeFailed  = "failed"
+	AuditOutcomeDenied  = "denied" // The caller was not authenticated or not allowed
+)
+
+// AuditRecord represents an API call that mutates state, in migrations_audit
+type AuditRecord struct {
+	ID          int64
+	Operation   string // up, down, rollback, reindex, labels, release_lock, promote or replay
+	Actor       string // Identity name of the caller; empty when not authenticated
+	Role        string
+	Protocol    string // http, grpc or connect
+	Method      string // HTTP method and path, or gRPC method
+	SourceIP    string
+	RequestBody string
+	Outcome     string // One of the AuditOutcome constants
+	Status      string // HTTP status code or gRPC status code
+	Error       string
+	CreatedAt   string
+}
+
+// AuditFilters specifies filters for querying the audit log
+type AuditFilters struct {
+	Operation string
+	Actor     string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+// Job statuses, in lifecycle order
+const (
+	JobScheduled    = "scheduled" // Waiting for its scheduled time, then queued or run by the server
+	JobQueued       = "queued"    // Published to the queue
+	JobPickedUp     = "picked_up" // Consumed from the queue by a worker
+	JobRunning      = "running"   // Migrations are being executed
+	JobRetrying     = "retrying"  // Failed transiently; the worker retries after a backoff
+	JobCompleted    = "completed"
+	JobFailed       = "failed"        // The job could not be queued, or some migrations failed
+	JobDeadLettered = "dead_lettered" // Failed and published to the dead-letter topic, to be replayed
+	JobCancelled    = "cancelled"     // Cancelled while scheduled; it never ran
+)
+
+// JobFinished reports whether a job with status is completed, failed, dead-lettered or cancelled
+func JobFinished(status string) bool {
+	return status == JobCompleted || status == JobFailed || status == JobDeadLettered || status == JobCancelled
+}
+
+// Job is an async migration job published to the queue, in migrations_jobs
+type Job struct {
+	ID          string
+	Status      string // One of the Job status constants
+	Connection  string
+	Schema      string
+	Target      string // JSON of the migration target
+	DryRun      bool
+	Worker      string // Process that picked the job up ({host}:{pid})
+	Attempts    int    // Execution attempts so far
+	Payload     string // JSON of the queued job, to replay it
+	Applied     []string
+	Skipped     []string
+	Errors      []string
+	QueuedAt    string
+	ScheduledAt string // When a scheduled job is due to run (RFC3339); empty for jobs queued at once
+	StartedAt   string // When a worker picked the job up
+	FinishedAt  string
+	UpdatedAt   string
+}
+
+// JobFilters specifies filters for querying jobs
+type JobFilters struct {
+	Status     string
+	Connection string
+	Limit      int
+	Offset     int
+}
+
+// EmergencyRun is a request that bypassed the execution safeguards with an emergency token, in
+// migrations_emergency_runs. It stays pending until a postmortem is recorded for it.
+type EmergencyRun struct {
+	ID            string
+	Operation     string // up, down or rollback
+	Actor         string // Identity name of the caller
+	Reason        string
+	Method        string // HTTP method and path
+	StartedAt     string
+	PostmortemDue string // When the postmortem is due (RFC3339)
+	Postmortem    string // Follow-up annotation, such as the incident report link; empty while pending
+	PostmortemBy  string
+	PostmortemAt  string
+}
+
+// Pending reports whether the run has no postmortem yet
+func (r *EmergencyRun) Pending() bool {
+	return r.Postmortem == ""
+}
+
+// EmergencyRunFilters specifies filters for querying emergency runs
+type EmergencyRunFilters struct {
+	Pending bool // Only runs without a postmortem
+	Limit   int
+	Offset  int
+}
+
+// State change types
+const (
+	ChangeApplied    = "applied"
+	ChangeFailed     = "failed"
+	ChangeRolledBack = "rolled_back"
+	ChangeReindexed  = "reindexed" // Registered or removed by a reindex
+)
+
+// ChangeRemoved is the Status of a StateChange of a migration removed by a reindex
+const ChangeRemoved = "removed"
+
+// StateChange is an entry of the state change feed, in migrations_changes
+type StateChange struct {
+	Cursor      int64  // Position in the feed; later changes have greater cursors
+	Type        string // One of the Change type constants
+	MigrationID string
+	Schema      string
+	Version     string
+	Connection  string
+	Backend     string
+	Status      string // Status recorded for the migration, or ChangeRemoved
+	Error       string // Error message of a failed execution
+	CreatedAt   string
+}
+
+// ExecutionChange returns the type of the state change of an execution recorded with a history
+// status, rollback when it reverted the migration, or "" when the execution changes no state
+// (pending)
+func ExecutionChange(status string, rollback bool) string {
+	switch {
+	case status == "failed":
+		return ChangeFailed
+	case rollback && (status == "rolled_back" || HistoryStatusIndicatesApplied(status)):
+		return ChangeRolledBack
+	case !HistoryStatusIndicatesApplied(status):
+		return ""
+	default:
+		return ChangeApplied
+	}
+}
+
+// RemovedChange returns the state change of a migration removed by a reindex
+func RemovedChange(migration *MigrationListItem) *StateChange {
+	return &StateChange{
+		Type:        ChangeReindexed,
+		MigrationID: migration.MigrationID,
+		Schema:      migration.Schema,
+		Version:     migration.Version,
+		Connection:  migration.Connection,
+		Backend:     migration.Backend,
+		Status:      ChangeRemoved,
+	}
+}
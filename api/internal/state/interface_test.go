@@ -0,0 +1,29 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+func TestBaseMigrationID(t *testing.T) {
+	tests := []struct {
+		migrationID, base, schema, name string
+	}{
+		{"20240101120000_create_users_postgresql_core", "20240101120000_create_users_postgresql_core", "", "create_users"},
+		{"tenant_a_20240101120000_create_users_postgresql_core", "20240101120000_create_users_postgresql_core", "tenant_a", "create_users"},
+		{"legacy_migration", "legacy_migration", "", ""},
+	}
+	for _, tt := range tests {
+		base := state.BaseMigrationID(tt.migrationID)
+		if base != tt.base {
+			t.Errorf("BaseMigrationID(%q) = %q, want %q", tt.migrationID, base, tt.base)
+		}
+		if got := state.SchemaPrefix(tt.migrationID, base); got != tt.schema {
+			t.Errorf("SchemaPrefix(%q) = %q, want %q", tt.migrationID, got, tt.schema)
+		}
+		if got := state.MigrationName(base); got != tt.name {
+			t.Errorf("MigrationName(%q) = %q, want %q", base, got, tt.name)
+		}
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/toolsascode/bfm/api/internal/state"
 )
 
@@ -18,17 +22,15 @@ func migrationAdvisoryLockKeys(migrationID, schema, connection string) (int32, i
 
 // WithMigrationExecutionLock runs fn while holding a session-level advisory lock on the state DB.
 // The lock is per (migration_id, execution schema, connection) so the same migration can run for different schemas concurrently.
-func (t *Tracker) WithMigrationExecutionLock(ctx interface{}, migrationID, schema, connection string, fn func() error) error {
-	ctxVal := ctx.(context.Context)
-
-	conn, err := t.pool.Acquire(ctxVal)
+func (t *Tracker) WithMigrationExecutionLock(ctx context.Context, migrationID, schema, connection string, fn func() error) error {
+	conn, err := t.pool.Acquire(ctx)
 	if err != nil {
 		return fmt.Errorf("acquire connection for migration lock: %w", err)
 	}
 
 	k1, k2 := migrationAdvisoryLockKeys(migrationID, schema, connection)
 	var ok bool
-	if err := conn.QueryRow(ctxVal, `SELECT pg_try_advisory_lock($1::integer, $2::integer)`, k1, k2).Scan(&ok); err != nil {
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1::integer, $2::integer)`, k1, k2).Scan(&ok); err != nil {
 		conn.Release()
 		return fmt.Errorf("pg_try_advisory_lock: %w", err)
 	}
@@ -45,3 +47,182 @@ func (t *Tracker) WithMigrationExecutionLock(ctx interface{}, migrationID, schem
 
 	return fn()
 }
+
+// connectionAdvisoryLockKeys derives the advisory lock keys for a connection lock. The prefix keeps
+// them apart from the per-migration execution locks.
+func connectionAdvisoryLockKeys(connection string) (int32, int32) {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "connection\x00%s", connection)
+	v := h.Sum64()
+	return int32(v >> 32), int32(v & 0xffffffff)
+}
+
+// locksTableName returns the (schema-qualified) migrations_locks table name
+func (t *Tracker) locksTableName() string {
+	if t.schema != "" && t.schema != "public" {
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_locks"))
+	}
+	return "migrations_locks"
+}
+
+// WithConnectionLock runs fn while holding a session-level advisory lock for the connection.
+// The holding session is recorded in migrations_locks so ListLocks can report it and ReleaseLock
+// can terminate it; the advisory lock itself is what excludes other processes.
+func (t *Tracker) WithConnectionLock(ctx context.Context, connection, holder string, fn func() error) error {
+	conn, err := t.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection for connection lock: %w", err)
+	}
+
+	k1, k2 := connectionAdvisoryLockKeys(connection)
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1::integer, $2::integer)`, k1, k2).Scan(&ok); err != nil {
+		conn.Release()
+		return fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	if !ok {
+		conn.Release()
+		return state.ErrConnectionLocked
+	}
+
+	defer func() {
+		ctxUnlock := context.Background()
+		_, _ = conn.Exec(ctxUnlock, fmt.Sprintf(`DELETE FROM %s WHERE connection = $1 AND pid = pg_backend_pid()`, t.locksTableName()), connection)
+		_, _ = conn.Exec(ctxUnlock, `SELECT pg_advisory_unlock($1::integer, $2::integer)`, k1, k2)
+		conn.Release()
+	}()
+
+	// A previous holder that crashed may have left its row behind; the advisory lock proves it is gone
+	recordSQL := fmt.Sprintf(`
+		INSERT INTO %s (connection, holder, pid, lock_key1, lock_key2, acquired_at)
+		VALUES ($1, $2, pg_backend_pid(), $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (connection) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			pid = EXCLUDED.pid,
+			lock_key1 = EXCLUDED.lock_key1,
+			lock_key2 = EXCLUDED.lock_key2,
+			acquired_at = EXCLUDED.acquired_at
+	`, t.locksTableName())
+	if _, err := conn.Exec(ctx, recordSQL, connection, holder, k1, k2); err != nil {
+		return fmt.Errorf("record connection lock: %w", err)
+	}
+
+	return fn()
+}
+
+// ListLocks returns the connection locks whose advisory lock is still held
+func (t *Tracker) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	query := fmt.Sprintf(`
+		SELECT l.connection, l.holder, l.acquired_at
+		FROM %s l
+		WHERE EXISTS (
+			SELECT 1 FROM pg_locks p
+			WHERE p.locktype = 'advisory' AND p.granted AND p.pid = l.pid
+				AND p.classid = l.lock_key1::oid AND p.objid = l.lock_key2::oid AND p.objsubid = 2
+		)
+		ORDER BY l.connection
+	`, t.locksTableName())
+
+	rows, err := t.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query connection locks: %w", err)
+	}
+	defer rows.Close()
+
+	locks := []*state.MigrationLock{}
+	for rows.Next() {
+		var lock state.MigrationLock
+		var acquiredAt time.Time
+		if err := rows.Scan(&lock.Connection, &lock.Holder, &acquiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan connection lock: %w", err)
+		}
+		lock.AcquiredAt = acquiredAt.Format(time.RFC3339)
+		locks = append(locks, &lock)
+	}
+	return locks, rows.Err()
+}
+
+// ReleaseLock force-releases a connection lock. Advisory locks can only be released by the session
+// that holds them, so the holding backend is terminated; its in-flight migration transaction is
+// rolled back. A row left behind by a session that is already gone is removed.
+func (t *Tracker) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	k1, k2 := connectionAdvisoryLockKeys(connection)
+	var pid *int32
+	err := t.pool.QueryRow(ctx, `
+		SELECT pid FROM pg_locks
+		WHERE locktype = 'advisory' AND granted
+			AND classid = $1::integer::oid AND objid = $2::integer::oid AND objsubid = 2
+		LIMIT 1
+	`, k1, k2).Scan(&pid)
+	if err != nil && err != pgx.ErrNoRows {
+		return false, fmt.Errorf("failed to look up connection lock: %w", err)
+	}
+
+	released := false
+	if pid != nil {
+		if err := t.pool.QueryRow(ctx, `SELECT pg_terminate_backend($1)`, *pid).Scan(&released); err != nil {
+			return false, fmt.Errorf("failed to terminate lock holder (pid %d): %w", *pid, err)
+		}
+		if !released {
+			return false, fmt.Errorf("failed to terminate lock holder (pid %d)", *pid)
+		}
+	}
+
+	if _, err := t.pool.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE connection = $1`, t.locksTableName()), connection); err != nil {
+		return released, fmt.Errorf("failed to remove connection lock record: %w", err)
+	}
+	return released, nil
+}
+
+// primaryAdvisoryLockKeys derives the advisory lock keys for the primary lock. Instances sharing a
+// state schema compete for the same lock.
+func (t *Tracker) primaryAdvisoryLockKeys() (int32, int32) {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "primary\x00%s", t.schema)
+	v := h.Sum64()
+	return int32(v >> 32), int32(v & 0xffffffff)
+}
+
+// AcquirePrimaryLock takes a session-level advisory lock electing the primary server instance. The
+// session is kept out of the pool until the lock is released, so the lock is held for as long as
+// the session lives; if the instance dies, the database ends the session and another instance can
+// take over.
+func (t *Tracker) AcquirePrimaryLock(ctx context.Context) (state.PrimaryLock, error) {
+	conn, err := t.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection for primary lock: %w", err)
+	}
+
+	k1, k2 := t.primaryAdvisoryLockKeys()
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1::integer, $2::integer)`, k1, k2).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	if !ok {
+		conn.Release()
+		return nil, state.ErrPrimaryLocked
+	}
+	return &primaryLock{conn: conn, k1: k1, k2: k2}, nil
+}
+
+// primaryLock is a primary lock held by a pooled session
+type primaryLock struct {
+	conn   *pgxpool.Conn
+	k1, k2 int32
+	once   sync.Once
+}
+
+func (l *primaryLock) Check(ctx context.Context) error {
+	if err := l.conn.Ping(ctx); err != nil {
+		return fmt.Errorf("primary lock session lost: %w", err)
+	}
+	return nil
+}
+
+func (l *primaryLock) Release() {
+	l.once.Do(func() {
+		_, _ = l.conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1::integer, $2::integer)`, l.k1, l.k2)
+		l.conn.Release()
+	})
+}
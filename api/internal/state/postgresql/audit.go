@@ -0,0 +1,200 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// auditTableName returns the (schema-qualified) migrations_audit table name
+func (t *Tracker) auditTableName() string {
+	if t.schema != "" && t.schema != "public" {
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_audit"))
+	}
+	return "migrations_audit"
+}
+
+// initializeAudit creates the migrations_audit table. Triggers reject UPDATE, DELETE and TRUNCATE
+// so the audit log stays append-only, even for clients connecting to the state database directly.
+func (t *Tracker) initializeAudit(ctx context.Context) error {
+	auditTableName := t.auditTableName()
+	createAuditTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			operation VARCHAR(50) NOT NULL,
+			actor VARCHAR(255) NOT NULL DEFAULT '',
+			role VARCHAR(50) NOT NULL DEFAULT '',
+			protocol VARCHAR(20) NOT NULL,
+			method VARCHAR(255) NOT NULL,
+			source_ip VARCHAR(64) NOT NULL DEFAULT '',
+			request_body TEXT,
+			outcome VARCHAR(20) NOT NULL,
+			status VARCHAR(50) NOT NULL DEFAULT '',
+			error TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, auditTableName)
+
+	if _, err := t.pool.Exec(ctx, createAuditTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_audit table: %w", err)
+	}
+
+	indexSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_audit_created_at ON %s (created_at DESC)", auditTableName)
+	_, _ = t.pool.Exec(ctx, indexSQL)
+
+	functionName := "migrations_audit_append_only"
+	if t.schema != "" && t.schema != "public" {
+		functionName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_audit_append_only"))
+	}
+	createFunctionSQL := fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'migrations_audit is append-only: %% is not allowed', TG_OP;
+		END;
+		$$ LANGUAGE plpgsql
+	`, functionName)
+	if _, err := t.pool.Exec(ctx, createFunctionSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_audit trigger function: %w", err)
+	}
+
+	// CREATE TRIGGER has no IF NOT EXISTS, and instances may initialize concurrently
+	createTriggersSQL := fmt.Sprintf(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'migrations_audit_append_only' AND tgrelid = '%[1]s'::regclass) THEN
+				CREATE TRIGGER migrations_audit_append_only BEFORE UPDATE OR DELETE ON %[1]s
+					FOR EACH ROW EXECUTE FUNCTION %[2]s();
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'migrations_audit_no_truncate' AND tgrelid = '%[1]s'::regclass) THEN
+				CREATE TRIGGER migrations_audit_no_truncate BEFORE TRUNCATE ON %[1]s
+					FOR EACH STATEMENT EXECUTE FUNCTION %[2]s();
+			END IF;
+		END
+		$$
+	`, auditTableName, functionName)
+	if _, err := t.pool.Exec(ctx, createTriggersSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_audit triggers: %w", err)
+	}
+	return nil
+}
+
+// RecordAudit appends a record to migrations_audit, setting its ID and CreatedAt
+func (t *Tracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (operation, actor, role, protocol, method, source_ip, request_body, outcome, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, t.auditTableName())
+
+	var createdAt time.Time
+	err := t.pool.QueryRow(ctx, query,
+		record.Operation,
+		record.Actor,
+		record.Role,
+		record.Protocol,
+		record.Method,
+		record.SourceIP,
+		record.RequestBody,
+		record.Outcome,
+		record.Status,
+		record.Error,
+	).Scan(&record.ID, &createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit: %w", err)
+	}
+	record.CreatedAt = createdAt.Format(time.RFC3339)
+	return nil
+}
+
+// GetAuditLog retrieves audit records matching filters, ordered by created_at DESC
+func (t *Tracker) GetAuditLog(ctx context.Context, filters *state.AuditFilters) ([]*state.AuditRecord, int, error) {
+	if filters == nil {
+		filters = &state.AuditFilters{}
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filters.Operation != "" {
+		addCondition("operation = $%d", filters.Operation)
+	}
+	if filters.Actor != "" {
+		addCondition("actor = $%d", filters.Actor)
+	}
+	if filters.Outcome != "" {
+		addCondition("outcome = $%d", filters.Outcome)
+	}
+	if !filters.Since.IsZero() {
+		addCondition("created_at >= $%d", filters.Since.UTC())
+	}
+	if !filters.Until.IsZero() {
+		addCondition("created_at < $%d", filters.Until.UTC())
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", t.auditTableName(), where)
+	if err := t.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit records: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, operation, actor, role, protocol, method, source_ip,
+		       COALESCE(request_body, ''), outcome, status, COALESCE(error, ''), created_at
+		FROM %s
+		%s
+		ORDER BY created_at DESC, id DESC
+	`, t.auditTableName(), where)
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := t.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*state.AuditRecord
+	for rows.Next() {
+		var record state.AuditRecord
+		var createdAt time.Time
+		err := rows.Scan(
+			&record.ID,
+			&record.Operation,
+			&record.Actor,
+			&record.Role,
+			&record.Protocol,
+			&record.Method,
+			&record.SourceIP,
+			&record.RequestBody,
+			&record.Outcome,
+			&record.Status,
+			&record.Error,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit record: %w", err)
+		}
+		record.CreatedAt = createdAt.Format(time.RFC3339)
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate audit records: %w", err)
+	}
+	return records, total, nil
+}
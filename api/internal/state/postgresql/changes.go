@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// changesTableName returns the (schema-qualified) migrations_changes table name
+func (t *Tracker) changesTableName() string {
+	if t.schema != "" && t.schema != "public" {
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_changes"))
+	}
+	return "migrations_changes"
+}
+
+// initializeChanges creates the migrations_changes table, the state change feed. Its ID is the
+// cursor of a change.
+func (t *Tracker) initializeChanges(ctx context.Context) error {
+	createChangesTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			type VARCHAR(20) NOT NULL,
+			migration_id VARCHAR(255) NOT NULL,
+			schema VARCHAR(255) NOT NULL DEFAULT '',
+			version VARCHAR(255) NOT NULL DEFAULT '',
+			connection VARCHAR(255) NOT NULL DEFAULT '',
+			backend VARCHAR(50) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, t.changesTableName())
+
+	if _, err := t.pool.Exec(ctx, createChangesTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_changes table: %w", err)
+	}
+	return nil
+}
+
+// changesAdvisoryLockKeys derives the advisory lock keys serializing the changes of a state schema
+func (t *Tracker) changesAdvisoryLockKeys() (int32, int32) {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "changes\x00%s", t.schema)
+	v := h.Sum64()
+	return int32(v >> 32), int32(v & 0xffffffff)
+}
+
+// recordChange appends a change to migrations_changes. Sequence values are taken before commit, so
+// concurrent inserts could commit out of order and a reader past the later cursor would never see
+// the earlier change; a transaction-level advisory lock makes changes commit in cursor order.
+func (t *Tracker) recordChange(ctx context.Context, change *state.StateChange) error {
+	err := pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		k1, k2 := t.changesAdvisoryLockKeys()
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1::integer, $2::integer)`, k1, k2); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf(`
+			INSERT INTO %s (type, migration_id, schema, version, connection, backend, status, error)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, t.changesTableName()),
+			change.Type, change.MigrationID, change.Schema, change.Version, change.Connection, change.Backend,
+			change.Status, change.Error)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s change of %s: %w", change.Type, change.MigrationID, err)
+	}
+	return nil
+}
+
+// recordExecutionChange appends the change of type changeType of a recorded execution; executions
+// that change no state (changeType "") are not in the feed
+func (t *Tracker) recordExecutionChange(ctx context.Context, baseMigrationID string, migration *state.MigrationRecord, changeType, status string) error {
+	if changeType == "" {
+		return nil
+	}
+	return t.recordChange(ctx, &state.StateChange{
+		Type:        changeType,
+		MigrationID: baseMigrationID,
+		Schema:      migration.Schema,
+		Version:     migration.Version,
+		Connection:  migration.Connection,
+		Backend:     migration.Backend,
+		Status:      status,
+		Error:       migration.ErrorMessage,
+	})
+}
+
+// GetStateChanges returns the changes after the cursor since, oldest first
+func (t *Tracker) GetStateChanges(ctx context.Context, since int64, limit int) ([]*state.StateChange, error) {
+	query := fmt.Sprintf(`
+		SELECT id, type, migration_id, schema, version, connection, backend, status, error, created_at
+		FROM %s
+		WHERE id > $1
+		ORDER BY id
+	`, t.changesTableName())
+	args := []interface{}{since}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	rows, err := t.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*state.StateChange
+	for rows.Next() {
+		var change state.StateChange
+		var createdAt time.Time
+		err := rows.Scan(&change.Cursor, &change.Type, &change.MigrationID, &change.Schema, &change.Version,
+			&change.Connection, &change.Backend, &change.Status, &change.Error, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan state change: %w", err)
+		}
+		change.CreatedAt = createdAt.Format(time.RFC3339)
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate state changes: %w", err)
+	}
+	return changes, nil
+}
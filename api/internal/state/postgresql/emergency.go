@@ -0,0 +1,153 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// emergencyRunsTableName returns the (schema-qualified) migrations_emergency_runs table name
+func (t *Tracker) emergencyRunsTableName() string {
+	if t.schema != "" && t.schema != "public" {
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_emergency_runs"))
+	}
+	return "migrations_emergency_runs"
+}
+
+// initializeEmergencyRuns creates the migrations_emergency_runs table, the requests that bypassed
+// the execution safeguards and their postmortems
+func (t *Tracker) initializeEmergencyRuns(ctx context.Context) error {
+	tableName := t.emergencyRunsTableName()
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(255) PRIMARY KEY,
+			operation VARCHAR(20) NOT NULL,
+			actor VARCHAR(255) NOT NULL DEFAULT '',
+			reason TEXT NOT NULL,
+			method VARCHAR(255) NOT NULL DEFAULT '',
+			started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			postmortem_due TIMESTAMPTZ NOT NULL,
+			postmortem TEXT NOT NULL DEFAULT '',
+			postmortem_by VARCHAR(255) NOT NULL DEFAULT '',
+			postmortem_at TIMESTAMPTZ
+		)
+	`, tableName)
+	if _, err := t.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_emergency_runs table: %w", err)
+	}
+
+	indexSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_emergency_runs_started_at ON %s (started_at DESC)", tableName)
+	_, _ = t.pool.Exec(ctx, indexSQL)
+	return nil
+}
+
+// RecordEmergencyRun creates an emergency run, or records the postmortem of an existing one
+func (t *Tracker) RecordEmergencyRun(ctx context.Context, run *state.EmergencyRun) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s AS r (id, operation, actor, reason, method, postmortem_due, postmortem, postmortem_by, postmortem_at)
+		VALUES ($1, $2, $3, $4, $5, $6::TIMESTAMPTZ, $7, $8, CASE WHEN $7 <> '' THEN CURRENT_TIMESTAMP END)
+		ON CONFLICT (id) DO UPDATE SET
+			postmortem = EXCLUDED.postmortem,
+			postmortem_by = EXCLUDED.postmortem_by,
+			postmortem_at = EXCLUDED.postmortem_at
+		WHERE EXCLUDED.postmortem <> ''
+	`, t.emergencyRunsTableName())
+
+	_, err := t.pool.Exec(ctx, query,
+		run.ID,
+		run.Operation,
+		run.Actor,
+		run.Reason,
+		run.Method,
+		run.PostmortemDue,
+		run.Postmortem,
+		run.PostmortemBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+const emergencyRunColumns = `id, operation, actor, reason, method, started_at, postmortem_due, postmortem, postmortem_by, postmortem_at`
+
+// scanEmergencyRun scans a migrations_emergency_runs row selected with emergencyRunColumns
+func scanEmergencyRun(row pgx.Row) (*state.EmergencyRun, error) {
+	var run state.EmergencyRun
+	var startedAt, postmortemDue time.Time
+	var postmortemAt *time.Time
+	err := row.Scan(&run.ID, &run.Operation, &run.Actor, &run.Reason, &run.Method, &startedAt, &postmortemDue,
+		&run.Postmortem, &run.PostmortemBy, &postmortemAt)
+	if err != nil {
+		return nil, err
+	}
+	run.StartedAt = startedAt.UTC().Format(time.RFC3339)
+	run.PostmortemDue = postmortemDue.UTC().Format(time.RFC3339)
+	if postmortemAt != nil {
+		run.PostmortemAt = postmortemAt.UTC().Format(time.RFC3339)
+	}
+	return &run, nil
+}
+
+// GetEmergencyRun returns an emergency run by ID
+func (t *Tracker) GetEmergencyRun(ctx context.Context, id string) (*state.EmergencyRun, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", emergencyRunColumns, t.emergencyRunsTableName())
+	run, err := scanEmergencyRun(t.pool.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, state.ErrEmergencyRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency run %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// GetEmergencyRuns retrieves emergency runs matching filters, most recent first
+func (t *Tracker) GetEmergencyRuns(ctx context.Context, filters *state.EmergencyRunFilters) ([]*state.EmergencyRun, int, error) {
+	if filters == nil {
+		filters = &state.EmergencyRunFilters{}
+	}
+	where := ""
+	if filters.Pending {
+		where = "WHERE postmortem = ''"
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", t.emergencyRunsTableName(), where)
+	if err := t.pool.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count emergency runs: %w", err)
+	}
+
+	var args []interface{}
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY started_at DESC, id DESC", emergencyRunColumns, t.emergencyRunsTableName(), where)
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := t.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query emergency runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*state.EmergencyRun
+	for rows.Next() {
+		run, err := scanEmergencyRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan emergency run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate emergency runs: %w", err)
+	}
+	return runs, total, nil
+}
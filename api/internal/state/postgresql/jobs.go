@@ -0,0 +1,218 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// jobsTableName returns the (schema-qualified) migrations_jobs table name
+func (t *Tracker) jobsTableName() string {
+	if t.schema != "" && t.schema != "public" {
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_jobs"))
+	}
+	return "migrations_jobs"
+}
+
+// initializeJobs creates the migrations_jobs table, the lifecycle of async jobs
+func (t *Tracker) initializeJobs(ctx context.Context) error {
+	jobsTableName := t.jobsTableName()
+	createJobsTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(255) PRIMARY KEY,
+			status VARCHAR(20) NOT NULL,
+			connection VARCHAR(255) NOT NULL DEFAULT '',
+			schema VARCHAR(255) NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT '',
+			dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+			worker VARCHAR(255) NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			payload TEXT NOT NULL DEFAULT '',
+			applied TEXT[] NOT NULL DEFAULT '{}',
+			skipped TEXT[] NOT NULL DEFAULT '{}',
+			errors TEXT[] NOT NULL DEFAULT '{}',
+			queued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, jobsTableName)
+
+	if _, err := t.pool.Exec(ctx, createJobsTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_jobs table: %w", err)
+	}
+
+	// Columns added after the table was introduced
+	for _, column := range []string{"attempts INTEGER NOT NULL DEFAULT 0", "payload TEXT NOT NULL DEFAULT ''", "scheduled_at TIMESTAMPTZ"} {
+		addColumnSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", jobsTableName, column)
+		if _, err := t.pool.Exec(ctx, addColumnSQL); err != nil {
+			return fmt.Errorf("failed to add column to migrations_jobs table: %w", err)
+		}
+	}
+
+	indexSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_jobs_queued_at ON %s (queued_at DESC)", jobsTableName)
+	_, _ = t.pool.Exec(ctx, indexSQL)
+	indexSQL = fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_jobs_status ON %s (status)", jobsTableName)
+	_, _ = t.pool.Exec(ctx, indexSQL)
+	return nil
+}
+
+// RecordJob creates a job in migrations_jobs or updates its status, worker and results. A job
+// queued again (replayed) starts over.
+func (t *Tracker) RecordJob(ctx context.Context, job *state.Job) error {
+	started := job.Status == state.JobPickedUp || job.Status == state.JobRunning
+	query := fmt.Sprintf(`
+		INSERT INTO %s AS j (id, status, connection, schema, target, dry_run, worker, applied, skipped, errors,
+		                       started_at, finished_at, attempts, payload, scheduled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+		        CASE WHEN $11::BOOLEAN THEN CURRENT_TIMESTAMP END, CASE WHEN $12::BOOLEAN THEN CURRENT_TIMESTAMP END,
+		        $13, $14, NULLIF($15, '')::TIMESTAMPTZ)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			worker = CASE WHEN EXCLUDED.worker <> '' OR EXCLUDED.status = 'queued' THEN EXCLUDED.worker ELSE j.worker END,
+			attempts = EXCLUDED.attempts,
+			payload = EXCLUDED.payload,
+			applied = EXCLUDED.applied,
+			skipped = EXCLUDED.skipped,
+			errors = EXCLUDED.errors,
+			queued_at = CASE WHEN EXCLUDED.status = 'queued' THEN CURRENT_TIMESTAMP ELSE j.queued_at END,
+			started_at = CASE WHEN EXCLUDED.status = 'queued' THEN NULL ELSE COALESCE(j.started_at, EXCLUDED.started_at) END,
+			finished_at = CASE WHEN EXCLUDED.status = 'queued' THEN NULL ELSE COALESCE(EXCLUDED.finished_at, j.finished_at) END,
+			updated_at = CURRENT_TIMESTAMP,
+			scheduled_at = COALESCE(EXCLUDED.scheduled_at, j.scheduled_at)
+	`, t.jobsTableName())
+
+	_, err := t.pool.Exec(ctx, query,
+		job.ID,
+		job.Status,
+		job.Connection,
+		job.Schema,
+		job.Target,
+		job.DryRun,
+		job.Worker,
+		nonNil(job.Applied),
+		nonNil(job.Skipped),
+		nonNil(job.Errors),
+		started,
+		state.JobFinished(job.Status),
+		job.Attempts,
+		job.Payload,
+		job.ScheduledAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// nonNil returns values, or an empty list for nil so it is stored as '{}' rather than NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// scanJob scans a migrations_jobs row selected with jobColumns
+func scanJob(row pgx.Row) (*state.Job, error) {
+	var job state.Job
+	var queuedAt, updatedAt time.Time
+	var startedAt, finishedAt, scheduledAt *time.Time
+	err := row.Scan(&job.ID, &job.Status, &job.Connection, &job.Schema, &job.Target, &job.DryRun, &job.Worker,
+		&job.Attempts, &job.Payload, &job.Applied, &job.Skipped, &job.Errors, &queuedAt, &startedAt, &finishedAt, &updatedAt,
+		&scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	job.QueuedAt = queuedAt.Format(time.RFC3339)
+	job.UpdatedAt = updatedAt.Format(time.RFC3339)
+	if startedAt != nil {
+		job.StartedAt = startedAt.Format(time.RFC3339)
+	}
+	if finishedAt != nil {
+		job.FinishedAt = finishedAt.Format(time.RFC3339)
+	}
+	if scheduledAt != nil {
+		job.ScheduledAt = scheduledAt.UTC().Format(time.RFC3339)
+	}
+	return &job, nil
+}
+
+const jobColumns = `id, status, connection, schema, target, dry_run, worker, attempts, payload, applied, skipped, errors,
+		       queued_at, started_at, finished_at, updated_at, scheduled_at`
+
+// GetJob returns a job by ID
+func (t *Tracker) GetJob(ctx context.Context, id string) (*state.Job, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", jobColumns, t.jobsTableName())
+	job, err := scanJob(t.pool.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, state.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// GetJobs retrieves jobs matching filters, most recently queued first
+func (t *Tracker) GetJobs(ctx context.Context, filters *state.JobFilters) ([]*state.Job, int, error) {
+	if filters == nil {
+		filters = &state.JobFilters{}
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filters.Status != "" {
+		addCondition("status = $%d", filters.Status)
+	}
+	if filters.Connection != "" {
+		addCondition("connection = $%d", filters.Connection)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", t.jobsTableName(), where)
+	if err := t.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY queued_at DESC, id DESC", jobColumns, t.jobsTableName(), where)
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := t.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*state.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+	return jobs, total, nil
+}
@@ -54,13 +54,11 @@ func NewTracker(connStr string, schema string) (*Tracker, error) {
 }
 
 // Initialize creates the migration state tables
-func (t *Tracker) Initialize(ctx interface{}) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) Initialize(ctx context.Context) error {
 	// Ensure schema exists
 	if t.schema != "" && t.schema != "public" {
 		schemaQuery := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", quoteIdentifier(t.schema))
-		if _, err := t.pool.Exec(ctxVal, schemaQuery); err != nil {
+		if _, err := t.pool.Exec(ctx, schemaQuery); err != nil {
 			return fmt.Errorf("failed to create schema: %w", err)
 		}
 	}
@@ -84,26 +82,50 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 			dependencies TEXT[],
 			structured_dependencies JSONB,
 			status VARCHAR(50) NOT NULL DEFAULT 'pending',
+			checksum VARCHAR(64),
+			table_name VARCHAR(255),
+			status_labels TEXT[] NOT NULL DEFAULT '{}',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`, listTableName)
 
-	if _, err := t.pool.Exec(ctxVal, createListTableSQL); err != nil {
+	if _, err := t.pool.Exec(ctx, createListTableSQL); err != nil {
 		return fmt.Errorf("failed to create migrations_list table: %w", err)
 	}
 
+	// Tables created before checksum tracking lack the column
+	addChecksumSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS checksum VARCHAR(64)", listTableName)
+	if _, err := t.pool.Exec(ctx, addChecksumSQL); err != nil {
+		return fmt.Errorf("failed to add checksum column to migrations_list: %w", err)
+	}
+
+	// Tables created before table-level targeting lack the column
+	addTableNameSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS table_name VARCHAR(255)", listTableName)
+	if _, err := t.pool.Exec(ctx, addTableNameSQL); err != nil {
+		return fmt.Errorf("failed to add table_name column to migrations_list: %w", err)
+	}
+
+	// Tables created before user-defined status labels lack the column
+	addStatusLabelsSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS status_labels TEXT[] NOT NULL DEFAULT '{}'", listTableName)
+	if _, err := t.pool.Exec(ctx, addStatusLabelsSQL); err != nil {
+		return fmt.Errorf("failed to add status_labels column to migrations_list: %w", err)
+	}
+
 	// Create indexes for migrations_list
 	// Note: migration_id is PRIMARY KEY so already indexed, but explicit index is kept for consistency
 	// All tables with migration_id column must have an index on it for performance and foreign key constraints
 	indexSQL1 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_list_migration_id ON %s (migration_id)", listTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL1)
+	_, _ = t.pool.Exec(ctx, indexSQL1)
 
 	indexSQL2 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_list_connection_backend ON %s (connection, backend)", listTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL2)
+	_, _ = t.pool.Exec(ctx, indexSQL2)
 
 	indexSQL3 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_list_status ON %s (status)", listTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL3)
+	_, _ = t.pool.Exec(ctx, indexSQL3)
+
+	indexSQLLabels := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_list_status_labels ON %s USING GIN (status_labels)", listTableName)
+	_, _ = t.pool.Exec(ctx, indexSQLLabels)
 
 	// Create migrations_history table
 	historyTableName := "migrations_history"
@@ -120,7 +142,9 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 			connection VARCHAR(255) NOT NULL,
 			backend VARCHAR(50) NOT NULL,
 			status VARCHAR(20) NOT NULL,
+			operation VARCHAR(16) NOT NULL DEFAULT 'up',
 			error_message TEXT,
+			error_class VARCHAR(32),
 			executed_by VARCHAR(255),
 			execution_method VARCHAR(20) NOT NULL DEFAULT 'api',
 			execution_context TEXT,
@@ -130,20 +154,44 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		)
 	`, historyTableName, listTableName)
 
-	if _, err := t.pool.Exec(ctxVal, createHistoryTableSQL); err != nil {
+	if _, err := t.pool.Exec(ctx, createHistoryTableSQL); err != nil {
 		return fmt.Errorf("failed to create migrations_history table: %w", err)
 	}
 
+	// Tables created before failure classification lack the column
+	addErrorClassSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS error_class VARCHAR(32)", historyTableName)
+	if _, err := t.pool.Exec(ctx, addErrorClassSQL); err != nil {
+		return fmt.Errorf("failed to add error_class column to migrations_history: %w", err)
+	}
+
+	// Tables created before history operations lack the column, and their rows are migrated once
+	var hasOperation bool
+	err := t.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema())
+			  AND table_name = 'migrations_history' AND column_name = 'operation'
+		)
+	`, t.schema).Scan(&hasOperation)
+	if err != nil {
+		return fmt.Errorf("failed to inspect migrations_history: %w", err)
+	}
+	if !hasOperation {
+		if err := t.migrateHistoryOperations(ctx, listTableName, historyTableName); err != nil {
+			return err
+		}
+	}
+
 	// Create indexes for migrations_history
 	// Index on migration_id is required for foreign key performance and to avoid using migration names that don't exist in migrations_list
 	indexSQL4 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_history_migration_id ON %s (migration_id)", historyTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL4)
+	_, _ = t.pool.Exec(ctx, indexSQL4)
 
 	indexSQL5 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_history_applied_at ON %s (applied_at DESC)", historyTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL5)
+	_, _ = t.pool.Exec(ctx, indexSQL5)
 
 	indexSQL6 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_history_status ON %s (status)", historyTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL6)
+	_, _ = t.pool.Exec(ctx, indexSQL6)
 
 	// Create migrations_executions table
 	executionsTableName := "migrations_executions"
@@ -161,6 +209,7 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 			status VARCHAR(20) NOT NULL,
 			applied BOOLEAN NOT NULL DEFAULT FALSE,
 			applied_at TIMESTAMP,
+			checksum VARCHAR(64),
 			actions TEXT,
 			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -169,23 +218,29 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		)
 	`, executionsTableName, listTableName)
 
-	if _, err := t.pool.Exec(ctxVal, createExecutionsTableSQL); err != nil {
+	if _, err := t.pool.Exec(ctx, createExecutionsTableSQL); err != nil {
 		return fmt.Errorf("failed to create migrations_executions table: %w", err)
 	}
 
+	// Tables created before repeatable migrations lack the column
+	addExecutionChecksumSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS checksum VARCHAR(64)", executionsTableName)
+	if _, err := t.pool.Exec(ctx, addExecutionChecksumSQL); err != nil {
+		return fmt.Errorf("failed to add checksum column to migrations_executions: %w", err)
+	}
+
 	// Migrate existing schema if needed (handle databases with old id column)
 	// Try to drop id column if it exists (for existing databases)
 	// This is safe because CREATE TABLE IF NOT EXISTS won't recreate the column
 	dropIDColumnSQL := fmt.Sprintf(`
 		ALTER TABLE %s DROP COLUMN IF EXISTS id
 	`, executionsTableName)
-	_, _ = t.pool.Exec(ctxVal, dropIDColumnSQL)
+	_, _ = t.pool.Exec(ctx, dropIDColumnSQL)
 
 	// Drop old unique constraint if it exists (will be replaced by PRIMARY KEY)
 	dropUniqueSQL := fmt.Sprintf(`
 		ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_migration_id_schema_version_connection_backend_key
 	`, executionsTableName, executionsTableName)
-	_, _ = t.pool.Exec(ctxVal, dropUniqueSQL)
+	_, _ = t.pool.Exec(ctx, dropUniqueSQL)
 
 	// Ensure composite primary key exists (CREATE TABLE already created it, but this handles existing tables)
 	// First check if a primary key on these columns already exists
@@ -206,18 +261,18 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		AND array_length(c.conkey, 1) = 5
 	`
 	var pkCount int
-	if err := t.pool.QueryRow(ctxVal, checkPKSQL, schemaNameForCheck, "migrations_executions").Scan(&pkCount); err == nil && pkCount == 0 {
+	if err := t.pool.QueryRow(ctx, checkPKSQL, schemaNameForCheck, "migrations_executions").Scan(&pkCount); err == nil && pkCount == 0 {
 		// Drop any existing primary key first
 		dropOldPKSQL := fmt.Sprintf(`
 			ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_pkey
 		`, executionsTableName, executionsTableName)
-		_, _ = t.pool.Exec(ctxVal, dropOldPKSQL)
+		_, _ = t.pool.Exec(ctx, dropOldPKSQL)
 
 		// Create composite primary key
 		createPKSQL := fmt.Sprintf(`
 			ALTER TABLE %s ADD PRIMARY KEY (migration_id, schema, version, connection, backend)
 		`, executionsTableName)
-		if _, err := t.pool.Exec(ctxVal, createPKSQL); err != nil {
+		if _, err := t.pool.Exec(ctx, createPKSQL); err != nil {
 			// Log warning but don't fail - table might already have the constraint
 			fmt.Printf("Note: Could not create composite primary key on %s (may already exist): %v\n", executionsTableName, err)
 		}
@@ -226,13 +281,17 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 	// Create indexes for migrations_executions
 	// Index on migration_id is required for foreign key performance and to avoid using migration names that don't exist in migrations_list
 	indexSQL7 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_executions_migration_id ON %s (migration_id)", executionsTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL7)
+	_, _ = t.pool.Exec(ctx, indexSQL7)
 
 	indexSQL8 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_executions_status ON %s (status)", executionsTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL8)
+	_, _ = t.pool.Exec(ctx, indexSQL8)
 
 	indexSQL9 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_executions_created_at ON %s (created_at DESC)", executionsTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL9)
+	_, _ = t.pool.Exec(ctx, indexSQL9)
+
+	// Tenant status reports read every execution on a connection
+	indexSQLExecutionsConnection := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_executions_connection ON %s (connection, migration_id, schema)", executionsTableName)
+	_, _ = t.pool.Exec(ctx, indexSQLExecutionsConnection)
 
 	// Ensure foreign key constraint exists on migrations_executions.migration_id
 	// This constraint prevents invalid migration IDs from being inserted
@@ -248,14 +307,14 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		AND c.contype = 'f'
 		AND c.conname LIKE '%migration_id%'
 	`
-	if err := t.pool.QueryRow(ctxVal, checkFKSQL, schemaNameForCheck, "migrations_executions", "migrations_list").Scan(&fkCount); err == nil && fkCount == 0 {
+	if err := t.pool.QueryRow(ctx, checkFKSQL, schemaNameForCheck, "migrations_executions", "migrations_list").Scan(&fkCount); err == nil && fkCount == 0 {
 		// Foreign key constraint doesn't exist, create it
 		createFKSQL := fmt.Sprintf(`
 			ALTER TABLE %s
 			ADD CONSTRAINT migrations_executions_migration_id_fkey
 			FOREIGN KEY (migration_id) REFERENCES %s(migration_id) ON DELETE CASCADE
 		`, executionsTableName, listTableName)
-		if _, err := t.pool.Exec(ctxVal, createFKSQL); err != nil {
+		if _, err := t.pool.Exec(ctx, createFKSQL); err != nil {
 			// Log warning but don't fail - constraint might already exist with different name
 			fmt.Printf("Note: Could not create foreign key constraint on %s (may already exist): %v\n", executionsTableName, err)
 		} else {
@@ -286,17 +345,17 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		)
 	`, dependenciesTableName, listTableName, listTableName)
 
-	if _, err := t.pool.Exec(ctxVal, createDependenciesTableSQL); err != nil {
+	if _, err := t.pool.Exec(ctx, createDependenciesTableSQL); err != nil {
 		return fmt.Errorf("failed to create migrations_dependencies table: %w", err)
 	}
 
 	// Create indexes for migrations_dependencies
 	// Index on migration_id is required for foreign key performance and to avoid using migration names that don't exist in migrations_list
 	indexSQL10 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_dependencies_migration_id ON %s (migration_id)", dependenciesTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL10)
+	_, _ = t.pool.Exec(ctx, indexSQL10)
 
 	indexSQL11 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_dependencies_dependency_id ON %s (dependency_id)", dependenciesTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL11)
+	_, _ = t.pool.Exec(ctx, indexSQL11)
 
 	// Create migrations_skipped table
 	skippedTableName := "migrations_skipped"
@@ -321,24 +380,60 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 		)
 	`, skippedTableName, listTableName)
 
-	if _, err := t.pool.Exec(ctxVal, createSkippedTableSQL); err != nil {
+	if _, err := t.pool.Exec(ctx, createSkippedTableSQL); err != nil {
 		return fmt.Errorf("failed to create migrations_skipped table: %w", err)
 	}
 
 	// Create indexes for migrations_skipped
 	indexSQL12 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_skipped_migration_id ON %s (migration_id)", skippedTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL12)
+	_, _ = t.pool.Exec(ctx, indexSQL12)
 
 	indexSQL13 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_skipped_skipped_at ON %s (skipped_at DESC)", skippedTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL13)
+	_, _ = t.pool.Exec(ctx, indexSQL13)
 
 	indexSQL14 := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_migrations_skipped_connection_backend ON %s (connection, backend)", skippedTableName)
-	_, _ = t.pool.Exec(ctxVal, indexSQL14)
+	_, _ = t.pool.Exec(ctx, indexSQL14)
+
+	// Create migrations_locks table (holders of connection locks, see WithConnectionLock)
+	createLocksTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			connection VARCHAR(255) PRIMARY KEY,
+			holder VARCHAR(255) NOT NULL,
+			pid INTEGER NOT NULL,
+			lock_key1 INTEGER NOT NULL,
+			lock_key2 INTEGER NOT NULL,
+			acquired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, t.locksTableName())
+
+	if _, err := t.pool.Exec(ctx, createLocksTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations_locks table: %w", err)
+	}
+
+	// Create migrations_audit table (append-only log of mutating API calls, see RecordAudit)
+	if err := t.initializeAudit(ctx); err != nil {
+		return err
+	}
+
+	// Create migrations_jobs table (lifecycle of async jobs, see RecordJob)
+	if err := t.initializeJobs(ctx); err != nil {
+		return err
+	}
+
+	// Create migrations_emergency_runs table (break-glass requests, see RecordEmergencyRun)
+	if err := t.initializeEmergencyRuns(ctx); err != nil {
+		return err
+	}
+
+	// Create migrations_changes table (state change feed, see GetStateChanges)
+	if err := t.initializeChanges(ctx); err != nil {
+		return err
+	}
 
 	// Migrate existing data from old tables if they exist
 	executionsTableNameForMigration := executionsTableName
 	dependenciesTableNameForMigration := dependenciesTableName
-	if err := t.migrateExistingData(ctxVal, listTableName, historyTableName, executionsTableNameForMigration, dependenciesTableNameForMigration); err != nil {
+	if err := t.migrateExistingData(ctx, listTableName, historyTableName, executionsTableNameForMigration, dependenciesTableNameForMigration); err != nil {
 		// Log warning but don't fail initialization
 		fmt.Printf("Warning: Failed to migrate existing data: %v\n", err)
 	}
@@ -346,51 +441,44 @@ func (t *Tracker) Initialize(ctx interface{}) error {
 	return nil
 }
 
-// extractBaseMigrationID removes prefixes (organization ID, schema, etc.) to get base migration_id
-// Migration ID can have multiple prefixes: {org_id}_{schema}_{version}_{name}_{backend}_{connection}
-// Base format: {version}_{name}_{backend}_{connection}
-// Version is typically 14 digits (YYYYMMDDHHMMSS), so we keep removing prefixes until we find a version
-func extractBaseMigrationID(migrationID string) string {
-	// Remove rollback suffix if present
-	id := migrationID
-	if strings.Contains(id, "_rollback") {
-		id = strings.TrimSuffix(id, "_rollback")
-	}
-
-	parts := strings.Split(id, "_")
-	if len(parts) < 4 {
-		// Not enough parts, return as-is
-		return id
-	}
-
-	// Find the first part that looks like a version (14 digits)
-	// Keep removing prefixes until we find a version
-	for i := 0; i < len(parts); i++ {
-		part := parts[i]
-		// Check if this part is a version (14 digits, YYYYMMDDHHMMSS)
-		if len(part) == 14 {
-			allDigits := true
-			for _, r := range part {
-				if r < '0' || r > '9' {
-					allDigits = false
-					break
-				}
-			}
-			if allDigits {
-				// Found the version, this is the start of the base migration ID
-				return strings.Join(parts[i:], "_")
-			}
-		}
-	}
-
-	// If no version found, return original (might be a legacy format)
-	return id
+// migrateHistoryOperations adds the operation column to migrations_history and sets it on the rows
+// recorded before rows had one. Down migrations were recorded under the ID of their migration with
+// a "_down" suffix, in a migrations_list entry of their own: their rows move to the migration, and
+// the entry is removed. Successful rollbacks were recorded under the ID of their migration with
+// status rolled_back. Failed rollbacks cannot be told from failed up migrations and stay up.
+func (t *Tracker) migrateHistoryOperations(ctx context.Context, listTableName, historyTableName string) error {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin history operation migration: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	statements := []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS operation VARCHAR(16) NOT NULL DEFAULT 'up'", historyTableName),
+		fmt.Sprintf(`
+			UPDATE %[1]s
+			SET operation = 'down', migration_id = LEFT(migration_id, LENGTH(migration_id) - 5)
+			WHERE RIGHT(migration_id, 5) = '_down'
+			  AND LEFT(migration_id, LENGTH(migration_id) - 5) IN (SELECT migration_id FROM %[2]s)
+		`, historyTableName, listTableName),
+		fmt.Sprintf(`
+			DELETE FROM %[2]s
+			WHERE RIGHT(migration_id, 5) = '_down'
+			  AND LEFT(migration_id, LENGTH(migration_id) - 5) IN (SELECT migration_id FROM %[2]s)
+			  AND migration_id NOT IN (SELECT migration_id FROM %[1]s)
+		`, historyTableName, listTableName),
+		fmt.Sprintf("UPDATE %s SET operation = 'rollback' WHERE operation = 'up' AND status = 'rolled_back'", historyTableName),
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate history operations: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
 }
 
 // RecordMigration records a migration execution
-func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) RecordMigration(ctx context.Context, migration *state.MigrationRecord) error {
 	listTableName := "migrations_list"
 	historyTableName := "migrations_history"
 	executionsTableName := "migrations_executions"
@@ -412,11 +500,9 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 	// - Base: {version}_{name}_{backend}_{connection}
 	// - Schema-specific: {schema}_{version}_{name}_{backend}_{connection}
 	// - With organization/tenant prefix: {org_id}_{schema}_{version}_{name}_{backend}_{connection}
-	// - With rollback suffix: ..._rollback
 	// migrations_list should always use the base ID (without prefixes)
-	migrationID := migration.MigrationID
-	isRollback := strings.Contains(migrationID, "_rollback")
-	baseMigrationID := extractBaseMigrationID(migrationID)
+	isRollback := migration.Reverts()
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
 
 	executedBy := migration.ExecutedBy
 	if executedBy == "" {
@@ -428,10 +514,7 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 	}
 
 	// Map status values
-	status := migration.Status
-	if status == "success" {
-		status = "applied"
-	}
+	status := migration.HistoryStatus()
 
 	// Extract connection type from execution context for logging
 	connectionType := "unknown"
@@ -488,19 +571,20 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 	// Only update status if it's not already 'applied' to prevent overwriting successful migrations
 	// Reference the existing row using the table name in the CASE expression
 	upsertListSQL := fmt.Sprintf(`
-		INSERT INTO %s AS ml (migration_id, schema, version, name, connection, backend, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO %s AS ml (migration_id, schema, version, name, connection, backend, status, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT (migration_id) DO UPDATE SET
 			status = CASE
 				WHEN ml.status = 'applied' THEN ml.status
 				ELSE EXCLUDED.status
 			END,
+			checksum = COALESCE(EXCLUDED.checksum, ml.checksum),
 			updated_at = CURRENT_TIMESTAMP
 	`, listTableName)
 
-	_, err := t.pool.Exec(ctxVal, upsertListSQL,
+	_, err := t.pool.Exec(ctx, upsertListSQL,
 		baseMigrationID, schemaValue, migration.Version, migrationName,
-		migration.Connection, migration.Backend, listStatus)
+		migration.Connection, migration.Backend, listStatus, migration.Checksum)
 	if err != nil {
 		return fmt.Errorf("failed to upsert migration in migrations_list: %w", err)
 	}
@@ -509,8 +593,8 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 	// Insert one record per schema into migrations_history
 	insertHistorySQL := fmt.Sprintf(`
 		INSERT INTO %s (migration_id, schema, version, connection, backend,
-		                status, error_message, executed_by, execution_method, execution_context, applied_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		                status, operation, error_message, error_class, executed_by, execution_method, execution_context, applied_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13, $14)
 		RETURNING id
 	`, historyTableName)
 
@@ -525,9 +609,9 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 		var historyID int
 		logger.Debug("RecordMigration: Inserting into migrations_history: migration_id=%s, schema=%s, version=%s, connection=%s, backend=%s, status=%s",
 			baseMigrationID, schema, migration.Version, migration.Connection, migration.Backend, status)
-		err = t.pool.QueryRow(ctxVal, insertHistorySQL,
+		err = t.pool.QueryRow(ctx, insertHistorySQL,
 			baseMigrationID, schema, migration.Version,
-			migration.Connection, migration.Backend, status, migration.ErrorMessage,
+			migration.Connection, migration.Backend, status, migration.HistoryOperation(), migration.ErrorMessage, migration.ErrorClass,
 			executedBy, executionMethod, migration.ExecutionContext, appliedAt, appliedAt).Scan(&historyID)
 		if err != nil {
 			logger.Errorf("RecordMigration: Failed to insert into migrations_history: migration_id=%s, schema=%s, error=%v",
@@ -538,6 +622,10 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 			historyID, baseMigrationID, schema)
 	}
 
+	if err := t.recordExecutionChange(ctx, baseMigrationID, migration, state.ExecutionChange(status, isRollback), listStatus); err != nil {
+		return err
+	}
+
 	// Skip migrations_executions if no schemas specified (this table requires schema)
 	if len(schemas) == 0 {
 		return nil
@@ -563,7 +651,7 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 		SELECT COUNT(*) FROM %s WHERE migration_id = $1
 	`, listTableName)
 	var count int
-	err = t.pool.QueryRow(ctxVal, checkExistsSQL, baseMigrationID).Scan(&count)
+	err = t.pool.QueryRow(ctx, checkExistsSQL, baseMigrationID).Scan(&count)
 	if err != nil {
 		return fmt.Errorf("failed to validate migration_id %s: %w", baseMigrationID, err)
 	}
@@ -571,7 +659,7 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 		// This should not happen since we upsert into migrations_list above, but log as warning
 		fmt.Fprintf(os.Stderr, "⚠️  WARNING: Migration ID '%s' (extracted from '%s') does not exist in migrations_list. This may indicate an invalid migration ID format.\n", baseMigrationID, migration.MigrationID)
 		// Try to upsert again to ensure it exists
-		_, err = t.pool.Exec(ctxVal, upsertListSQL,
+		_, err = t.pool.Exec(ctx, upsertListSQL,
 			baseMigrationID, schemaValue, migration.Version, migrationName,
 			migration.Connection, migration.Backend, listStatus)
 		if err != nil {
@@ -580,12 +668,13 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 	}
 
 	insertExecutionSQL := fmt.Sprintf(`
-		INSERT INTO %s (migration_id, schema, version, connection, backend, status, applied, applied_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO %s AS me (migration_id, schema, version, connection, backend, status, applied, applied_at, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT (migration_id, schema, version, connection, backend) DO UPDATE SET
 			status = EXCLUDED.status,
 			applied = EXCLUDED.applied,
 			applied_at = EXCLUDED.applied_at,
+			checksum = COALESCE(EXCLUDED.checksum, me.checksum),
 			updated_at = CURRENT_TIMESTAMP
 	`, executionsTableName)
 
@@ -594,9 +683,9 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 		// Insert into migrations_executions with foreign key validation
 		logger.Debug("RecordMigration: Upserting into migrations_executions: migration_id=%s, schema=%s, version=%s, connection=%s, backend=%s, status=%s, applied=%v",
 			baseMigrationID, schema, migration.Version, migration.Connection, migration.Backend, execStatus, applied)
-		_, err = t.pool.Exec(ctxVal, insertExecutionSQL,
+		_, err = t.pool.Exec(ctx, insertExecutionSQL,
 			baseMigrationID, schema, migration.Version,
-			migration.Connection, migration.Backend, execStatus, applied, appliedAtPtr)
+			migration.Connection, migration.Backend, execStatus, applied, appliedAtPtr, migration.Checksum)
 		if err != nil {
 			// Check if this is a foreign key violation
 			errStr := err.Error()
@@ -621,9 +710,7 @@ func (t *Tracker) RecordMigration(ctx interface{}, migration *state.MigrationRec
 // RecordDependencyMigration records a dependency migration as applied without creating history entries.
 // Requirement: Dependencies should only be recorded in the execution history of the migration that depends on them.
 // This method marks the dependency as applied in migrations_list and migrations_executions but skips migrations_history.
-func (t *Tracker) RecordDependencyMigration(ctx interface{}, migration *state.MigrationRecord) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) RecordDependencyMigration(ctx context.Context, migration *state.MigrationRecord) error {
 	listTableName := "migrations_list"
 	executionsTableName := "migrations_executions"
 	if t.schema != "" && t.schema != "public" {
@@ -639,7 +726,7 @@ func (t *Tracker) RecordDependencyMigration(ctx interface{}, migration *state.Mi
 	}
 
 	migrationID := migration.MigrationID
-	baseMigrationID := extractBaseMigrationID(migrationID)
+	baseMigrationID := state.BaseMigrationID(migrationID)
 
 	// Map status values
 	status := migration.Status
@@ -669,22 +756,26 @@ func (t *Tracker) RecordDependencyMigration(ctx interface{}, migration *state.Mi
 
 	// Update migrations_list to mark dependency as applied (but don't create history)
 	upsertListSQL := fmt.Sprintf(`
-		INSERT INTO %s AS ml (migration_id, schema, version, name, connection, backend, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO %s AS ml (migration_id, schema, version, name, connection, backend, status, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT (migration_id) DO UPDATE SET
 			status = CASE
 				WHEN ml.status = 'applied' THEN ml.status
 				ELSE EXCLUDED.status
 			END,
+			checksum = COALESCE(EXCLUDED.checksum, ml.checksum),
 			updated_at = CURRENT_TIMESTAMP
 	`, listTableName)
 
-	_, err := t.pool.Exec(ctxVal, upsertListSQL,
+	_, err := t.pool.Exec(ctx, upsertListSQL,
 		baseMigrationID, schemaValue, migration.Version, migrationName,
-		migration.Connection, migration.Backend, listStatus)
+		migration.Connection, migration.Backend, listStatus, migration.Checksum)
 	if err != nil {
 		return fmt.Errorf("failed to upsert dependency migration in migrations_list: %w", err)
 	}
+	if err := t.recordExecutionChange(ctx, baseMigrationID, migration, state.ExecutionChange(status, false), listStatus); err != nil {
+		return err
+	}
 
 	// Update migrations_executions (but skip migrations_history - requirement 4)
 	if len(schemas) == 0 {
@@ -705,19 +796,20 @@ func (t *Tracker) RecordDependencyMigration(ctx interface{}, migration *state.Mi
 	}
 
 	insertExecutionSQL := fmt.Sprintf(`
-		INSERT INTO %s (migration_id, schema, version, connection, backend, status, applied, applied_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO %s AS me (migration_id, schema, version, connection, backend, status, applied, applied_at, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT (migration_id, schema, version, connection, backend) DO UPDATE SET
 			status = EXCLUDED.status,
 			applied = EXCLUDED.applied,
 			applied_at = EXCLUDED.applied_at,
+			checksum = COALESCE(EXCLUDED.checksum, me.checksum),
 			updated_at = CURRENT_TIMESTAMP
 	`, executionsTableName)
 
 	for _, schema := range schemas {
-		_, err = t.pool.Exec(ctxVal, insertExecutionSQL,
+		_, err = t.pool.Exec(ctx, insertExecutionSQL,
 			baseMigrationID, schema, migration.Version,
-			migration.Connection, migration.Backend, execStatus, applied, appliedAtPtr)
+			migration.Connection, migration.Backend, execStatus, applied, appliedAtPtr, migration.Checksum)
 		if err != nil {
 			return fmt.Errorf("failed to insert dependency execution state for %s: %w", baseMigrationID, err)
 		}
@@ -728,57 +820,109 @@ func (t *Tracker) RecordDependencyMigration(ctx interface{}, migration *state.Mi
 }
 
 // GetMigrationHistory retrieves migration history with optional filters
-func (t *Tracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationRecord, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
 	historyTableName := "migrations_history"
+	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		historyTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_history"))
+		listTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_list"))
 	}
 
-	query := fmt.Sprintf(`
-		SELECT id, migration_id, schema, version, connection, backend,
-		       applied_at, status, error_message, executed_by, execution_method, execution_context
-		FROM %s WHERE 1=1
-	`, historyTableName)
+	from := fmt.Sprintf("FROM %s WHERE 1=1", historyTableName)
 
 	args := []interface{}{}
 	argIndex := 1
 
 	if filters != nil {
+		if filters.MigrationID != "" {
+			from += fmt.Sprintf(" AND migration_id = $%d", argIndex)
+			args = append(args, filters.MigrationID)
+			argIndex++
+		}
 		if filters.Schema != "" {
 			// For VARCHAR schema column, check if schema is in comma-separated string
 			// Match exact schema or schema in comma-separated list
-			query += fmt.Sprintf(" AND (schema = $%d OR schema LIKE $%d || ',%%' OR schema LIKE '%%,' || $%d || ',%%' OR schema LIKE '%%,' || $%d)", argIndex, argIndex, argIndex, argIndex)
+			from += fmt.Sprintf(" AND (schema = $%d OR schema LIKE $%d || ',%%' OR schema LIKE '%%,' || $%d || ',%%' OR schema LIKE '%%,' || $%d)", argIndex, argIndex, argIndex, argIndex)
 			args = append(args, filters.Schema)
 			argIndex++
 		}
+		if filters.Table != "" {
+			// History rows don't carry the table; it is declared on the migration in migrations_list
+			from += fmt.Sprintf(" AND migration_id IN (SELECT migration_id FROM %s WHERE table_name = $%d)", listTableName, argIndex)
+			args = append(args, filters.Table)
+			argIndex++
+		}
 		if filters.Connection != "" {
-			query += fmt.Sprintf(" AND connection = $%d", argIndex)
+			from += fmt.Sprintf(" AND connection = $%d", argIndex)
 			args = append(args, filters.Connection)
 			argIndex++
 		}
 		if filters.Backend != "" {
-			query += fmt.Sprintf(" AND backend = $%d", argIndex)
+			from += fmt.Sprintf(" AND backend = $%d", argIndex)
 			args = append(args, filters.Backend)
 			argIndex++
 		}
 		if filters.Status != "" {
-			query += fmt.Sprintf(" AND status = $%d", argIndex)
+			from += fmt.Sprintf(" AND status = $%d", argIndex)
 			args = append(args, filters.Status)
 			argIndex++
 		}
 		if filters.Version != "" {
-			query += fmt.Sprintf(" AND version = $%d", argIndex)
+			from += fmt.Sprintf(" AND version = $%d", argIndex)
 			args = append(args, filters.Version)
+			argIndex++
+		}
+		if !filters.AppliedAfter.IsZero() {
+			from += fmt.Sprintf(" AND applied_at >= $%d", argIndex)
+			args = append(args, filters.AppliedAfter.UTC())
+			argIndex++
+		}
+		if !filters.AppliedBefore.IsZero() {
+			from += fmt.Sprintf(" AND applied_at < $%d", argIndex)
+			args = append(args, filters.AppliedBefore.UTC())
+			argIndex++
+		}
+		if filters.ExecutedBy != "" {
+			from += fmt.Sprintf(" AND executed_by = $%d", argIndex)
+			args = append(args, filters.ExecutedBy)
+			argIndex++
+		}
+		if filters.ExecutionMethod != "" {
+			from += fmt.Sprintf(" AND execution_method = $%d", argIndex)
+			args = append(args, filters.ExecutionMethod)
+			argIndex++
+		}
+		if filters.ErrorClass != "" {
+			from += fmt.Sprintf(" AND error_class = $%d", argIndex)
+			args = append(args, filters.ErrorClass)
+			argIndex++
+		}
+		if filters.RunID != "" {
+			from += fmt.Sprintf(" AND strpos(execution_context, $%d) > 0", argIndex)
+			args = append(args, state.RunIDNeedle(filters.RunID))
 		}
 	}
 
-	query += " ORDER BY applied_at DESC, id DESC"
+	var total int
+	if err := t.pool.QueryRow(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count migrations: %w", err)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByAppliedAt, state.SortDesc)
+	query := fmt.Sprintf(`
+		SELECT id, migration_id, schema, version, connection, backend,
+		       applied_at, status, operation, error_message, COALESCE(error_class, ''), executed_by, execution_method, execution_context
+		%s
+		ORDER BY %s
+	`, from, orderBy(sortBy, desc, "id"))
+	query += pageClause(filters, &args)
 
-	rows, err := t.pool.Query(ctxVal, query, args...)
+	rows, err := t.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query migrations: %w", err)
+		return nil, 0, fmt.Errorf("failed to query migrations: %w", err)
 	}
 	defer rows.Close()
 
@@ -797,13 +941,15 @@ func (t *Tracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationF
 			&record.Backend,
 			&appliedAt,
 			&record.Status,
+			&record.Operation,
 			&record.ErrorMessage,
+			&record.ErrorClass,
 			&record.ExecutedBy,
 			&record.ExecutionMethod,
 			&record.ExecutionContext,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan migration record: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan migration record: %w", err)
 		}
 
 		record.ID = fmt.Sprintf("%d", id)
@@ -811,23 +957,23 @@ func (t *Tracker) GetMigrationHistory(ctx interface{}, filters *state.MigrationF
 		records = append(records, &record)
 	}
 
-	return records, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
 }
 
 // GetMigrationList retrieves the list of migrations with their last status
-func (t *Tracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilters) ([]*state.MigrationListItem, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_list"))
 	}
 
-	query := fmt.Sprintf(`
-		SELECT migration_id, schema, version, name, connection, backend,
-		       status, created_at, updated_at
-		FROM %s WHERE 1=1
-	`, listTableName)
+	from := fmt.Sprintf("FROM %s WHERE 1=1", listTableName)
 
 	args := []interface{}{}
 	argIndex := 1
@@ -836,34 +982,62 @@ func (t *Tracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilt
 		if filters.Schema != "" {
 			// For VARCHAR schema column, check if schema is in comma-separated string
 			// Match exact schema or schema in comma-separated list
-			query += fmt.Sprintf(" AND (schema = $%d OR schema LIKE $%d || ',%%' OR schema LIKE '%%,' || $%d || ',%%' OR schema LIKE '%%,' || $%d)", argIndex, argIndex, argIndex, argIndex)
+			from += fmt.Sprintf(" AND (schema = $%d OR schema LIKE $%d || ',%%' OR schema LIKE '%%,' || $%d || ',%%' OR schema LIKE '%%,' || $%d)", argIndex, argIndex, argIndex, argIndex)
 			args = append(args, filters.Schema)
 			argIndex++
 		}
+		if filters.Table != "" {
+			from += fmt.Sprintf(" AND table_name = $%d", argIndex)
+			args = append(args, filters.Table)
+			argIndex++
+		}
 		if filters.Connection != "" {
-			query += fmt.Sprintf(" AND connection = $%d", argIndex)
+			from += fmt.Sprintf(" AND connection = $%d", argIndex)
 			args = append(args, filters.Connection)
 			argIndex++
 		}
 		if filters.Backend != "" {
-			query += fmt.Sprintf(" AND backend = $%d", argIndex)
+			from += fmt.Sprintf(" AND backend = $%d", argIndex)
 			args = append(args, filters.Backend)
 			argIndex++
 		}
 		if filters.Status != "" {
-			query += fmt.Sprintf(" AND status = $%d", argIndex)
+			from += fmt.Sprintf(" AND status = $%d", argIndex)
 			args = append(args, filters.Status)
 			argIndex++
 		}
 		if filters.Version != "" {
-			query += fmt.Sprintf(" AND version = $%d", argIndex)
+			from += fmt.Sprintf(" AND version = $%d", argIndex)
 			args = append(args, filters.Version)
+			argIndex++
+		}
+		if filters.Label != "" {
+			from += fmt.Sprintf(" AND status_labels @> ARRAY[$%d]::TEXT[]", argIndex)
+			args = append(args, filters.Label)
 		}
 	}
 
-	rows, err := t.pool.Query(ctxVal, query, args...)
+	var total int
+	if err := t.pool.QueryRow(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count migrations list: %w", err)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByVersion, state.SortAsc)
+	if sortBy == state.SortByAppliedAt {
+		// updated_at is when the migration was applied, for applied migrations
+		sortBy = "CASE WHEN status = 'applied' THEN updated_at END"
+	}
+	query := fmt.Sprintf(`
+		SELECT migration_id, schema, COALESCE(table_name, ''), version, name, connection, backend,
+		       status, COALESCE(checksum, ''), status_labels, created_at, updated_at
+		%s
+		ORDER BY %s
+	`, from, orderBy(sortBy, desc, "migration_id"))
+	query += pageClause(filters, &args)
+
+	rows, err := t.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query migrations list: %w", err)
+		return nil, 0, fmt.Errorf("failed to query migrations list: %w", err)
 	}
 	defer rows.Close()
 
@@ -875,16 +1049,19 @@ func (t *Tracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilt
 		err := rows.Scan(
 			&item.MigrationID,
 			&item.Schema,
+			&item.Table,
 			&item.Version,
 			&item.Name,
 			&item.Connection,
 			&item.Backend,
 			&item.LastStatus,
+			&item.Checksum,
+			&item.StatusLabels,
 			&createdAt,
 			&updatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan migration list item: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan migration list item: %w", err)
 		}
 
 		// Map status values for compatibility
@@ -902,24 +1079,52 @@ func (t *Tracker) GetMigrationList(ctx interface{}, filters *state.MigrationFilt
 		items = append(items, &item)
 	}
 
-	return items, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
 }
 
-// GetMigrationDetail retrieves detailed information about a single migration from migrations_list
-func (t *Tracker) GetMigrationDetail(ctx interface{}, migrationID string) (*state.MigrationDetail, error) {
-	ctxVal := ctx.(context.Context)
+// orderBy returns the ORDER BY expressions sorting by column, then by tiebreak in the same
+// direction so pages are stable. Sort fields are column names, validated by MigrationFilters.Validate.
+func orderBy(column string, desc bool, tiebreak string) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, %s %s", column, direction, tiebreak, direction)
+}
 
+// pageClause returns the LIMIT and OFFSET of filters, appending their values to args
+func pageClause(filters *state.MigrationFilters, args *[]interface{}) string {
+	if filters == nil {
+		return ""
+	}
+	clause := ""
+	if filters.Limit > 0 {
+		*args = append(*args, filters.Limit)
+		clause += fmt.Sprintf(" LIMIT $%d", len(*args))
+	}
+	if filters.Offset > 0 {
+		*args = append(*args, filters.Offset)
+		clause += fmt.Sprintf(" OFFSET $%d", len(*args))
+	}
+	return clause
+}
+
+// GetMigrationDetail retrieves detailed information about a single migration from migrations_list
+func (t *Tracker) GetMigrationDetail(ctx context.Context, migrationID string) (*state.MigrationDetail, error) {
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_list"))
 	}
 
 	// Remove prefixes to get base migration_id
-	baseMigrationID := extractBaseMigrationID(migrationID)
+	baseMigrationID := state.BaseMigrationID(migrationID)
 
 	query := fmt.Sprintf(`
 		SELECT migration_id, schema, version, name, connection, backend,
-		       up_sql, down_sql, dependencies, structured_dependencies, status, created_at, updated_at
+		       up_sql, down_sql, dependencies, structured_dependencies, status, status_labels, created_at, updated_at
 		FROM %s WHERE migration_id = $1
 	`, listTableName)
 
@@ -930,7 +1135,7 @@ func (t *Tracker) GetMigrationDetail(ctx interface{}, migrationID string) (*stat
 	var structuredDepsJSON *string
 	var createdAt, updatedAt *time.Time
 
-	err := t.pool.QueryRow(ctxVal, query, baseMigrationID).Scan(
+	err := t.pool.QueryRow(ctx, query, baseMigrationID).Scan(
 		&detail.MigrationID,
 		&schemaStr,
 		&detail.Version,
@@ -942,6 +1147,7 @@ func (t *Tracker) GetMigrationDetail(ctx interface{}, migrationID string) (*stat
 		&dependencies,
 		&structuredDepsJSON,
 		&detail.Status,
+		&detail.StatusLabels,
 		&createdAt,
 		&updatedAt,
 	)
@@ -974,26 +1180,17 @@ func (t *Tracker) GetMigrationDetail(ctx interface{}, migrationID string) (*stat
 	return &detail, nil
 }
 
-// GetMigrationExecutions retrieves all execution records for a migration, ordered by created_at DESC
-func (t *Tracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([]*state.MigrationExecution, error) {
-	ctxVal := ctx.(context.Context)
-
-	executionsTableName := "migrations_executions"
+// executionsTable returns the qualified name of migrations_executions
+func (t *Tracker) executionsTable() string {
 	if t.schema != "" && t.schema != "public" {
-		executionsTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_executions"))
+		return fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_executions"))
 	}
+	return "migrations_executions"
+}
 
-	// Remove prefixes to get base migration_id
-	baseMigrationID := extractBaseMigrationID(migrationID)
-
-	query := fmt.Sprintf(`
-		SELECT migration_id, schema, version, connection, backend,
-		       status, applied, applied_at, created_at, updated_at
-		FROM %s WHERE migration_id = $1
-		ORDER BY created_at DESC
-	`, executionsTableName)
-
-	rows, err := t.pool.Query(ctxVal, query, baseMigrationID)
+// queryExecutions runs a migrations_executions query and converts the rows
+func (t *Tracker) queryExecutions(ctx context.Context, query string, args ...interface{}) ([]*state.MigrationExecution, error) {
+	rows, err := t.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query migration executions: %w", err)
 	}
@@ -1014,6 +1211,7 @@ func (t *Tracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([
 			&exec.Status,
 			&exec.Applied,
 			&appliedAt,
+			&exec.Checksum,
 			&createdAt,
 			&updatedAt,
 		)
@@ -1040,78 +1238,47 @@ func (t *Tracker) GetMigrationExecutions(ctx interface{}, migrationID string) ([
 	return executions, rows.Err()
 }
 
-// GetRecentExecutions retrieves recent execution records across all migrations, ordered by created_at DESC
-func (t *Tracker) GetRecentExecutions(ctx interface{}, limit int) ([]*state.MigrationExecution, error) {
-	ctxVal := ctx.(context.Context)
+// GetMigrationExecutions retrieves all execution records for a migration, ordered by created_at DESC
+func (t *Tracker) GetMigrationExecutions(ctx context.Context, migrationID string) ([]*state.MigrationExecution, error) {
+	// Remove prefixes to get base migration_id
+	baseMigrationID := state.BaseMigrationID(migrationID)
 
-	executionsTableName := "migrations_executions"
-	if t.schema != "" && t.schema != "public" {
-		executionsTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_executions"))
-	}
+	return t.queryExecutions(ctx, fmt.Sprintf(`
+		SELECT migration_id, schema, version, connection, backend,
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
+		FROM %s WHERE migration_id = $1
+		ORDER BY created_at DESC
+	`, t.executionsTable()), baseMigrationID)
+}
 
-	query := fmt.Sprintf(`
+// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+// ordered by migration_id and schema
+func (t *Tracker) GetConnectionExecutions(ctx context.Context, connection string) ([]*state.MigrationExecution, error) {
+	return t.queryExecutions(ctx, fmt.Sprintf(`
+		SELECT migration_id, schema, version, connection, backend,
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
+		FROM %s WHERE connection = $1
+		ORDER BY migration_id, schema
+	`, t.executionsTable()), connection)
+}
+
+// GetRecentExecutions retrieves recent execution records across all migrations, ordered by created_at DESC
+func (t *Tracker) GetRecentExecutions(ctx context.Context, limit int) ([]*state.MigrationExecution, error) {
+	return t.queryExecutions(ctx, fmt.Sprintf(`
 		SELECT migration_id, schema, version, connection, backend,
-		       status, applied, applied_at, created_at, updated_at
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
 		FROM %s
 		ORDER BY created_at DESC
 		LIMIT $1
-	`, executionsTableName)
-
-	rows, err := t.pool.Query(ctxVal, query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query recent executions: %w", err)
-	}
-	defer rows.Close()
-
-	var executions []*state.MigrationExecution
-	for rows.Next() {
-		var exec state.MigrationExecution
-		var schemaStr *string
-		var appliedAt, createdAt, updatedAt *time.Time
-
-		err := rows.Scan(
-			&exec.MigrationID,
-			&schemaStr,
-			&exec.Version,
-			&exec.Connection,
-			&exec.Backend,
-			&exec.Status,
-			&exec.Applied,
-			&appliedAt,
-			&createdAt,
-			&updatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan migration execution: %w", err)
-		}
-
-		if schemaStr != nil {
-			exec.Schema = *schemaStr
-		}
-		if appliedAt != nil {
-			exec.AppliedAt = appliedAt.Format(time.RFC3339)
-		}
-		if createdAt != nil {
-			exec.CreatedAt = createdAt.Format(time.RFC3339)
-		}
-		if updatedAt != nil {
-			exec.UpdatedAt = updatedAt.Format(time.RFC3339)
-		}
-
-		executions = append(executions, &exec)
-	}
-
-	return executions, rows.Err()
+	`, t.executionsTable()), limit)
 }
 
 // RecordSkippedMigrations records skipped migrations for a given execution context
-func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
+func (t *Tracker) RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
 	if len(skippedMigrationIDs) == 0 {
 		return nil
 	}
 
-	ctxVal := ctx.(context.Context)
-
 	skippedTableName := "migrations_skipped"
 	if t.schema != "" && t.schema != "public" {
 		skippedTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_skipped"))
@@ -1127,20 +1294,10 @@ func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs [
 	for _, migrationID := range skippedMigrationIDs {
 		// Extract base migration ID (remove schema prefix if present)
 		// migrations_list stores base IDs, and migrations_skipped foreign key references base IDs
-		baseMigrationID := extractBaseMigrationID(migrationID)
+		baseMigrationID := state.BaseMigrationID(migrationID)
 
 		// Extract schema from the original migrationID if it has a prefix
-		var schema string
-		if baseMigrationID != migrationID {
-			// There was a prefix, extract it
-			parts := strings.Split(migrationID, "_")
-			baseParts := strings.Split(baseMigrationID, "_")
-			if len(parts) > len(baseParts) {
-				// The difference is the prefix, which could be organization ID + schema
-				// For simplicity, use the first part as schema
-				schema = parts[0]
-			}
-		}
+		schema := state.SchemaPrefix(migrationID, baseMigrationID)
 
 		// Query migrations_list to get migration details using base migration ID
 		query := fmt.Sprintf(`
@@ -1150,7 +1307,7 @@ func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs [
 		`, listTableName)
 
 		var dbSchema, version, connection, backend string
-		err := t.pool.QueryRow(ctxVal, query, baseMigrationID).Scan(&dbSchema, &version, &connection, &backend)
+		err := t.pool.QueryRow(ctx, query, baseMigrationID).Scan(&dbSchema, &version, &connection, &backend)
 		if err != nil {
 			// If migration not found in list, skip it (it might not be registered yet)
 			// Log warning but continue with other migrations
@@ -1169,7 +1326,7 @@ func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs [
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		`, skippedTableName)
 
-		_, err = t.pool.Exec(ctxVal, insertSQL,
+		_, err = t.pool.Exec(ctx, insertSQL,
 			baseMigrationID, schema, version, connection, backend,
 			executedBy, executionMethod, executionContext)
 		if err != nil {
@@ -1192,7 +1349,7 @@ func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs [
 			)
 		`, skippedTableName, skippedTableName)
 
-		_, deleteErr := t.pool.Exec(ctxVal, deleteSQL, baseMigrationID, schema)
+		_, deleteErr := t.pool.Exec(ctx, deleteSQL, baseMigrationID, schema)
 		if deleteErr != nil {
 			// Log error but don't fail - this is cleanup
 			fmt.Printf("Warning: Failed to cleanup old skipped migration records for %s (base: %s, schema: %s): %v\n", migrationID, baseMigrationID, schema, deleteErr)
@@ -1203,9 +1360,7 @@ func (t *Tracker) RecordSkippedMigrations(ctx interface{}, skippedMigrationIDs [
 }
 
 // GetSkippedMigrations retrieves skipped migrations, optionally filtered by migration_id or recent limit
-func (t *Tracker) GetSkippedMigrations(ctx interface{}, migrationID string, limit int) ([]*state.SkippedMigration, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*state.SkippedMigration, error) {
 	skippedTableName := "migrations_skipped"
 	if t.schema != "" && t.schema != "public" {
 		skippedTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_skipped"))
@@ -1235,7 +1390,7 @@ func (t *Tracker) GetSkippedMigrations(ctx interface{}, migrationID string, limi
 		args = []interface{}{limit}
 	}
 
-	rows, err := t.pool.Query(ctxVal, query, args...)
+	rows, err := t.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query skipped migrations: %w", err)
 	}
@@ -1290,9 +1445,7 @@ func (t *Tracker) GetSkippedMigrations(ctx interface{}, migrationID string, limi
 // This only returns true for migrations with status 'applied', not 'pending'.
 // For concurrency control (checking if a migration is pending or applied),
 // use IsMigrationPendingOrApplied instead.
-func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
 	listTableName := "migrations_list"
 	executionsTableName := "migrations_executions"
 	if t.schema != "" && t.schema != "public" {
@@ -1301,20 +1454,8 @@ func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool,
 	}
 
 	// Extract base migration_id and detect schema prefix
-	baseMigrationID := extractBaseMigrationID(migrationID)
-	var schemaName string
-	// If baseMigrationID is different from migrationID, there was a prefix
-	if baseMigrationID != migrationID {
-		// Extract schema name from the prefix
-		parts := strings.Split(migrationID, "_")
-		baseParts := strings.Split(baseMigrationID, "_")
-		if len(parts) > len(baseParts) {
-			// The difference is the prefix, which could be organization ID + schema
-			// For simplicity, use the first part as schema (could be org_id or schema)
-			// This will be validated when querying migrations_executions
-			schemaName = parts[0]
-		}
-	}
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	schemaName := state.SchemaPrefix(migrationID, baseMigrationID)
 
 	// For dynamic schemas (with schema prefix), check migrations_executions table
 	// This tracks per-schema executions and is more accurate for dynamic schemas
@@ -1330,7 +1471,7 @@ func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool,
 			WHERE migration_id = $1
 			LIMIT 1
 		`, listTableName)
-		err := t.pool.QueryRow(ctxVal, getMetadataQuery, baseMigrationID).Scan(&version, &connection, &backend)
+		err := t.pool.QueryRow(ctx, getMetadataQuery, baseMigrationID).Scan(&version, &connection, &backend)
 		if err != nil {
 			// If migration not found in migrations_list, it's not applied
 			if err == pgx.ErrNoRows {
@@ -1353,7 +1494,7 @@ func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool,
 				AND status = 'applied'
 			)`, executionsTableName)
 		var exists bool
-		err = t.pool.QueryRow(ctxVal, query, baseMigrationID, schemaName, version, connection, backend).Scan(&exists)
+		err = t.pool.QueryRow(ctx, query, baseMigrationID, schemaName, version, connection, backend).Scan(&exists)
 		if err != nil {
 			return false, fmt.Errorf("failed to check migration status in executions table: %w", err)
 		}
@@ -1372,7 +1513,7 @@ func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool,
 			AND status = 'applied'
 		)`, listTableName)
 	var exists bool
-	err := t.pool.QueryRow(ctxVal, query, migrationID, baseMigrationID).Scan(&exists)
+	err := t.pool.QueryRow(ctx, query, migrationID, baseMigrationID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check migration status: %w", err)
 	}
@@ -1384,9 +1525,7 @@ func (t *Tracker) IsMigrationApplied(ctx interface{}, migrationID string) (bool,
 // For base migration IDs, migrations_list "pending" means registered-not-applied, not in-flight; this
 // matches IsMigrationApplied (applied only). For schema-specific IDs, migrations_executions may hold
 // status pending while a run is in progress.
-func (t *Tracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID string) (bool, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
 	listTableName := "migrations_list"
 	executionsTableName := "migrations_executions"
 	if t.schema != "" && t.schema != "public" {
@@ -1395,15 +1534,8 @@ func (t *Tracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID strin
 	}
 
 	// Extract base migration_id and detect schema prefix
-	baseMigrationID := extractBaseMigrationID(migrationID)
-	var schemaName string
-	if baseMigrationID != migrationID {
-		parts := strings.Split(migrationID, "_")
-		baseParts := strings.Split(baseMigrationID, "_")
-		if len(parts) > len(baseParts) {
-			schemaName = parts[0]
-		}
-	}
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	schemaName := state.SchemaPrefix(migrationID, baseMigrationID)
 
 	// Fixed-schema / base ID: list "pending" is not an execution lock; align with applied-only check.
 	if schemaName == "" {
@@ -1418,7 +1550,7 @@ func (t *Tracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID strin
 		WHERE migration_id = $1
 		LIMIT 1
 	`, listTableName)
-	err := t.pool.QueryRow(ctxVal, getMetadataQuery, baseMigrationID).Scan(&version, &connection, &backend)
+	err := t.pool.QueryRow(ctx, getMetadataQuery, baseMigrationID).Scan(&version, &connection, &backend)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return false, nil
@@ -1437,7 +1569,7 @@ func (t *Tracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID strin
 			AND (status = 'applied' OR status = 'pending')
 		)`, executionsTableName)
 	var exists bool
-	err = t.pool.QueryRow(ctxVal, query, baseMigrationID, schemaName, version, connection, backend).Scan(&exists)
+	err = t.pool.QueryRow(ctx, query, baseMigrationID, schemaName, version, connection, backend).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check migration status in executions table: %w", err)
 	}
@@ -1445,9 +1577,7 @@ func (t *Tracker) IsMigrationPendingOrApplied(ctx interface{}, migrationID strin
 }
 
 // GetLastMigrationVersion gets the last applied version for a schema/table
-func (t *Tracker) GetLastMigrationVersion(ctx interface{}, schema, table string) (string, error) {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error) {
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_list"))
@@ -1462,7 +1592,7 @@ func (t *Tracker) GetLastMigrationVersion(ctx interface{}, schema, table string)
 	`, listTableName)
 
 	var version string
-	err := t.pool.QueryRow(ctxVal, query, schema).Scan(&version)
+	err := t.pool.QueryRow(ctx, query, schema).Scan(&version)
 	if err == pgx.ErrNoRows {
 		return "", nil
 	}
@@ -1474,9 +1604,7 @@ func (t *Tracker) GetLastMigrationVersion(ctx interface{}, schema, table string)
 }
 
 // RegisterScannedMigration registers a scanned migration in migrations_list (status: pending)
-func (t *Tracker) RegisterScannedMigration(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = quoteIdentifier(t.schema) + "." + quoteIdentifier("migrations_list")
@@ -1489,14 +1617,16 @@ func (t *Tracker) RegisterScannedMigration(ctx interface{}, migrationID, schema,
 		schemaValue = "" // Empty string is allowed for migrations_list
 	}
 
-	insertListSQL := `INSERT INTO ` + listTableName + ` (migration_id, schema, version, name, connection, backend, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (migration_id) DO NOTHING`
+	// An already registered migration keeps its row, but picks up a table declared since
+	insertListSQL := `INSERT INTO ` + listTableName + ` (migration_id, schema, version, name, connection, backend, status, table_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10)
+		ON CONFLICT (migration_id) DO UPDATE SET table_name = EXCLUDED.table_name
+		WHERE ` + listTableName + `.table_name IS DISTINCT FROM EXCLUDED.table_name`
 
 	now := time.Now()
-	_, err := t.pool.Exec(ctxVal, insertListSQL,
+	_, err := t.pool.Exec(ctx, insertListSQL,
 		migrationID, schemaValue, version, name, connection, backend,
-		"pending", now, now)
+		"pending", table, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to register scanned migration: %w", err)
 	}
@@ -1504,10 +1634,30 @@ func (t *Tracker) RegisterScannedMigration(ctx interface{}, migrationID, schema,
 	return nil
 }
 
-// UpdateMigrationInfo updates migration metadata (schema, version, name, connection, backend) without affecting status/history
-func (t *Tracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, table, version, name, connection, backend string) error {
-	ctxVal := ctx.(context.Context)
+// SetStatusLabels replaces the user-defined status labels of a migration. updated_at is left
+// alone, as it reports when the migration was applied.
+func (t *Tracker) SetStatusLabels(ctx context.Context, migrationID string, labels []string) error {
+	listTableName := "migrations_list"
+	if t.schema != "" && t.schema != "public" {
+		listTableName = fmt.Sprintf("%s.%s", quoteIdentifier(t.schema), quoteIdentifier("migrations_list"))
+	}
+
+	if labels == nil {
+		labels = []string{}
+	}
+	updateSQL := fmt.Sprintf("UPDATE %s SET status_labels = $1 WHERE migration_id = $2", listTableName)
+	result, err := t.pool.Exec(ctx, updateSQL, labels, state.BaseMigrationID(migrationID))
+	if err != nil {
+		return fmt.Errorf("failed to set status labels: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", state.ErrMigrationNotFound, migrationID)
+	}
+	return nil
+}
 
+// UpdateMigrationInfo updates migration metadata (schema, table, version, name, connection, backend) without affecting status/history
+func (t *Tracker) UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = quoteIdentifier(t.schema) + "." + quoteIdentifier("migrations_list")
@@ -1527,12 +1677,13 @@ func (t *Tracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, tabl
 		    name = $3,
 		    connection = $4,
 		    backend = $5,
+		    table_name = NULLIF($6, ''),
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE migration_id = $6
+		WHERE migration_id = $7
 	`, listTableName)
 
-	result, err := t.pool.Exec(ctxVal, updateSQL,
-		schemaValue, version, name, connection, backend, migrationID)
+	result, err := t.pool.Exec(ctx, updateSQL,
+		schemaValue, version, name, connection, backend, table, migrationID)
 	if err != nil {
 		return fmt.Errorf("failed to update migration info: %w", err)
 	}
@@ -1547,16 +1698,14 @@ func (t *Tracker) UpdateMigrationInfo(ctx interface{}, migrationID, schema, tabl
 }
 
 // DeleteMigration deletes a migration from migrations_list (cascades to history via foreign key)
-func (t *Tracker) DeleteMigration(ctx interface{}, migrationID string) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) DeleteMigration(ctx context.Context, migrationID string) error {
 	listTableName := "migrations_list"
 	if t.schema != "" && t.schema != "public" {
 		listTableName = quoteIdentifier(t.schema) + "." + quoteIdentifier("migrations_list")
 	}
 
 	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE migration_id = $1", listTableName)
-	_, err := t.pool.Exec(ctxVal, deleteSQL, migrationID)
+	_, err := t.pool.Exec(ctx, deleteSQL, migrationID)
 	if err != nil {
 		return fmt.Errorf("failed to delete migration: %w", err)
 	}
@@ -1571,9 +1720,7 @@ func (t *Tracker) getMigrationID(migration *backends.MigrationScript) string {
 
 // ReindexMigrations reloads the BfM migration list and updates the database state
 // This should be called asynchronously in the background
-func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error {
-	ctxVal := ctx.(context.Context)
-
+func (t *Tracker) ReindexMigrations(ctx context.Context, registry interface{}) error {
 	// Type assert registry to get GetAll method
 	type Registry interface {
 		GetAll() []*backends.MigrationScript
@@ -1600,7 +1747,7 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 	}
 
 	// Step 2: Get all migrations from database
-	dbMigrations, err := t.GetMigrationList(ctx, nil)
+	dbMigrations, _, err := t.GetMigrationList(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to get database migrations: %w", err)
 	}
@@ -1612,6 +1759,11 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 
 	// Step 3: For each BfM migration, update or insert into migrations_list
 	for migrationID, migration := range bfmMigrationMap {
+		// Stop when ctx is cancelled (e.g. the reindexer stopped) rather than finishing the pass
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Convert schema to array (handle single schema or multiple)
 		schemas := []string{}
 		if migration.Schema != "" {
@@ -1632,14 +1784,8 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 
 		// Construct filenames for up_sql and down_sql
 		// Filename pattern: {version}_{name}.up.{sql|json} and {version}_{name}.down.{sql|json}
-		var upExt, downExt string
-		if migration.Backend == "etcd" || migration.Backend == "mongodb" {
-			upExt = ".up.json"
-			downExt = ".down.json"
-		} else {
-			upExt = ".up.sql"
-			downExt = ".down.sql"
-		}
+		upExt := ".up" + migration.ScriptExtension()
+		downExt := ".down" + migration.ScriptExtension()
 		upSQLFilename := fmt.Sprintf("%s_%s%s", migration.Version, migration.Name, upExt)
 		downSQLFilename := fmt.Sprintf("%s_%s%s", migration.Version, migration.Name, downExt)
 
@@ -1671,9 +1817,9 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 		upsertSQL := fmt.Sprintf(`
 			INSERT INTO %s (
 				migration_id, schema, version, name, connection, backend,
-				up_sql, down_sql, dependencies, structured_dependencies, status, updated_at
+				up_sql, down_sql, dependencies, structured_dependencies, status, table_name, updated_at
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), CURRENT_TIMESTAMP)
 			ON CONFLICT (migration_id) DO UPDATE SET
 				schema = EXCLUDED.schema,
 				version = EXCLUDED.version,
@@ -1685,6 +1831,7 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 				dependencies = EXCLUDED.dependencies,
 				structured_dependencies = EXCLUDED.structured_dependencies,
 				status = EXCLUDED.status,
+				table_name = EXCLUDED.table_name,
 				updated_at = CURRENT_TIMESTAMP
 		`, listTableName)
 
@@ -1695,8 +1842,13 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 			schemaValue = schemas[0]
 		}
 
+		tableName := ""
+		if migration.Table != nil {
+			tableName = *migration.Table
+		}
+
 		// Insert/update migrations_list (always, even with empty schema)
-		_, err = t.pool.Exec(ctxVal, upsertSQL,
+		_, err = t.pool.Exec(ctx, upsertSQL,
 			migrationID,
 			schemaValue,
 			migration.Version,
@@ -1708,15 +1860,31 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 			dependencies,
 			string(structuredDepsJSON),
 			status,
+			tableName,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to upsert migration %s: %w", migrationID, err)
 		}
 
+		if !exists {
+			err := t.recordChange(ctx, &state.StateChange{
+				Type:        state.ChangeReindexed,
+				MigrationID: migrationID,
+				Schema:      schemaValue,
+				Version:     migration.Version,
+				Connection:  migration.Connection,
+				Backend:     migration.Backend,
+				Status:      status,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
 		// Skip migrations_executions if no schemas specified
 		if len(schemas) == 0 {
 			// Still update dependencies even if no schema
-			if err := t.updateMigrationDependencies(ctxVal, migrationID, migration, listTableName); err != nil {
+			if err := t.updateMigrationDependencies(ctx, migrationID, migration, listTableName); err != nil {
 				return fmt.Errorf("failed to update dependencies for %s: %w", migrationID, err)
 			}
 			continue
@@ -1750,7 +1918,7 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 
 		// Create one record per schema
 		for _, schema := range schemas {
-			_, err = t.pool.Exec(ctxVal, insertExecutionSQL,
+			_, err = t.pool.Exec(ctx, insertExecutionSQL,
 				migrationID,
 				schema,
 				migration.Version,
@@ -1766,17 +1934,21 @@ func (t *Tracker) ReindexMigrations(ctx interface{}, registry interface{}) error
 		}
 
 		// Update dependencies table
-		if err := t.updateMigrationDependencies(ctxVal, migrationID, migration, listTableName); err != nil {
+		if err := t.updateMigrationDependencies(ctx, migrationID, migration, listTableName); err != nil {
 			return fmt.Errorf("failed to update dependencies for %s: %w", migrationID, err)
 		}
 	}
 
 	// Step 4: Delete migrations that no longer exist in BfM
-	for migrationID := range dbMigrationMap {
+	for migrationID, dbMigration := range dbMigrationMap {
 		if _, exists := bfmMigrationMap[migrationID]; !exists {
 			if err := t.DeleteMigration(ctx, migrationID); err != nil {
 				// Log but continue
 				fmt.Printf("Warning: Failed to delete migration %s: %v\n", migrationID, err)
+				continue
+			}
+			if err := t.recordChange(ctx, state.RemovedChange(dbMigration)); err != nil {
+				return err
 			}
 		}
 	}
@@ -1883,6 +2055,10 @@ func (t *Tracker) updateMigrationDependencies(ctx context.Context, migrationID s
 
 // resolveDependencyID resolves a dependency to a migration_id
 func (t *Tracker) resolveDependencyID(ctx context.Context, dep backends.Dependency, listTableName string) (string, error) {
+	if dep.IsPattern() {
+		return t.resolvePatternDependencyID(ctx, dep, listTableName)
+	}
+
 	var query string
 	var args []interface{}
 
@@ -1911,6 +2087,34 @@ func (t *Tracker) resolveDependencyID(ctx context.Context, dep backends.Dependen
 	return migrationID, nil
 }
 
+// resolvePatternDependencyID resolves a version bound or name prefix dependency to the lowest
+// migration_id it targets
+func (t *Tracker) resolvePatternDependencyID(ctx context.Context, dep backends.Dependency, listTableName string) (string, error) {
+	rows, err := t.pool.Query(ctx, fmt.Sprintf(`
+		SELECT migration_id, version, name FROM %s
+		WHERE connection = $1
+		ORDER BY version
+	`, listTableName), dep.Connection)
+	if err != nil {
+		return "", fmt.Errorf("failed to query migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var migrationID, version, name string
+		if err := rows.Scan(&migrationID, &version, &name); err != nil {
+			return "", fmt.Errorf("failed to scan migration: %w", err)
+		}
+		if dep.MatchesTarget(version, name) {
+			return migrationID, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to query migrations: %w", err)
+	}
+	return "", fmt.Errorf("dependency not found: connection=%s, target=%s", dep.Connection, dep.Target)
+}
+
 // findMigrationIDByName finds a migration_id by name
 func (t *Tracker) findMigrationIDByName(ctx context.Context, name string, listTableName string) (string, error) {
 	query := fmt.Sprintf(`
@@ -1928,6 +2132,14 @@ func (t *Tracker) findMigrationIDByName(ctx context.Context, name string, listTa
 	return migrationID, nil
 }
 
+// Ping checks the state database is reachable
+func (t *Tracker) Ping(ctx context.Context) error {
+	if err := t.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to reach the state database: %w", err)
+	}
+	return nil
+}
+
 // Close closes the database connection
 func (t *Tracker) Close() error {
 	if t.pool != nil {
@@ -2003,8 +2215,8 @@ func (t *Tracker) migrateExistingData(ctx context.Context, listTableName, histor
 			continue
 		}
 
-		// Extract base migration_id (remove _rollback suffix and prefixes)
-		baseMigrationID := extractBaseMigrationID(migrationID)
+		// Extract base migration_id (remove the _rollback suffix of legacy rollbacks, and prefixes)
+		baseMigrationID := state.BaseMigrationID(strings.TrimSuffix(migrationID, "_rollback"))
 
 		// Store record for later processing
 		if migrationRecords[baseMigrationID] == nil {
@@ -2073,7 +2285,7 @@ func (t *Tracker) migrateExistingData(ctx context.Context, listTableName, histor
 				latestRecord = record
 			}
 			// Track most recent successful, non-rollback record
-			if !strings.Contains(record.migrationID, "_rollback") && record.status == "success" {
+			if !strings.HasSuffix(record.migrationID, "_rollback") && record.status == "success" {
 				if latestSuccessRecord == nil || record.appliedAt.After(latestSuccessRecord.appliedAt) {
 					latestSuccessRecord = &record
 				}
@@ -2090,7 +2302,7 @@ func (t *Tracker) migrateExistingData(ctx context.Context, listTableName, histor
 		}
 
 		// If latest is a rollback, check if there's a more recent success
-		if strings.Contains(latestRecord.migrationID, "_rollback") {
+		if strings.HasSuffix(latestRecord.migrationID, "_rollback") {
 			lastStatus = "rolled_back"
 			if latestSuccessRecord != nil {
 				lastAppliedAt = latestSuccessRecord.appliedAt
@@ -2185,8 +2397,11 @@ func (t *Tracker) migrateExistingData(ctx context.Context, listTableName, histor
 	// PHASE 2: Now insert all history records (foreign key constraint is satisfied)
 	for baseMigrationID, records := range migrationRecords {
 		for _, record := range records {
-			// Extract base migration_id (remove _rollback suffix if present)
-			isRollback := strings.Contains(record.migrationID, "_rollback")
+			// Legacy rollbacks were recorded with a _rollback suffix
+			operation := state.OperationUp
+			if strings.HasSuffix(record.migrationID, "_rollback") {
+				operation = state.OperationRollback
+			}
 
 			// Skip if schema is empty
 			if record.schema == "" {
@@ -2202,18 +2417,15 @@ func (t *Tracker) migrateExistingData(ctx context.Context, listTableName, histor
 			// Insert into migrations_history (all records, including rollbacks) - one record per schema
 			insertHistorySQL := fmt.Sprintf(`
 				INSERT INTO %s (migration_id, schema, version, connection, backend,
-				                status, error_message, executed_by, execution_method, applied_at, created_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				                status, operation, error_message, executed_by, execution_method, applied_at, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 			`, historyTableName)
 
 			executionMethod := "api" // Default for migrated data
-			if isRollback {
-				executionMethod = "api" // Rollbacks are typically via API
-			}
 
 			_, err := t.pool.Exec(ctx, insertHistorySQL,
 				baseMigrationID, record.schema, record.version, record.connection, record.backend,
-				status, record.errorMsg, "system", executionMethod, record.appliedAt, record.appliedAt)
+				status, operation, record.errorMsg, "system", executionMethod, record.appliedAt, record.appliedAt)
 			if err != nil {
 				return fmt.Errorf("failed to insert into migrations_history: %w", err)
 			}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// RunSummary sums up the history records of a run: one up, down or rollback invocation, whose
+// records share a run_id (see MigrationRecord.RunID)
+type RunSummary struct {
+	RunID           string
+	StartedAt       time.Time // applied_at of its first record
+	FinishedAt      time.Time // applied_at of its last record
+	ExecutedBy      string
+	ExecutionMethod string
+	Operations      []string // Distinct operations of its records, sorted
+	Connections     []string // Distinct connections of its records, sorted
+	Records         int
+	Applied         int // Records of migrations applied
+	Failed          int
+	RolledBack      int // Records of down migrations and rollbacks
+}
+
+// RunFilters specifies filters for listing runs
+type RunFilters struct {
+	Connection    string    // Runs with records on the connection
+	StartedAfter  time.Time // Runs with records applied at or after StartedAfter, when not zero
+	StartedBefore time.Time // Runs with records applied before StartedBefore, when not zero
+	Limit         int       // 0 returns every run
+	Offset        int
+}
+
+// SummarizeRun sums up the history records of one run
+func SummarizeRun(runID string, records []*MigrationRecord) *RunSummary {
+	summary := &RunSummary{RunID: runID, Records: len(records)}
+	for _, record := range records {
+		appliedAt, _ := time.Parse(time.RFC3339, record.AppliedAt)
+		if summary.StartedAt.IsZero() || appliedAt.Before(summary.StartedAt) {
+			summary.StartedAt = appliedAt
+			summary.ExecutedBy, summary.ExecutionMethod = record.ExecutedBy, record.ExecutionMethod
+		}
+		if appliedAt.After(summary.FinishedAt) {
+			summary.FinishedAt = appliedAt
+		}
+		if operation := record.HistoryOperation(); !slices.Contains(summary.Operations, operation) {
+			summary.Operations = append(summary.Operations, operation)
+		}
+		if !slices.Contains(summary.Connections, record.Connection) {
+			summary.Connections = append(summary.Connections, record.Connection)
+		}
+		switch status := record.HistoryStatus(); {
+		case status == "failed":
+			summary.Failed++
+		case status == "rolled_back":
+			summary.RolledBack++
+		case status == "applied":
+			summary.Applied++
+		}
+	}
+	slices.Sort(summary.Operations)
+	slices.Sort(summary.Connections)
+	return summary
+}
+
+// GetRuns lists the runs recorded in the history of tracker, most recent first, and the number of
+// runs matching filters. Records created before runs were recorded belong to no run.
+func GetRuns(ctx context.Context, tracker StateTracker, filters *RunFilters) ([]*RunSummary, int, error) {
+	if filters == nil {
+		filters = &RunFilters{}
+	}
+	records, _, err := tracker.GetMigrationHistory(ctx, &MigrationFilters{
+		AppliedAfter:  filters.StartedAfter,
+		AppliedBefore: filters.StartedBefore,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byRun := make(map[string][]*MigrationRecord)
+	var runIDs []string
+	for _, record := range records {
+		runID := record.RunID()
+		if runID == "" {
+			continue
+		}
+		if _, ok := byRun[runID]; !ok {
+			runIDs = append(runIDs, runID)
+		}
+		byRun[runID] = append(byRun[runID], record)
+	}
+
+	var runs []*RunSummary
+	for _, runID := range runIDs {
+		summary := SummarizeRun(runID, byRun[runID])
+		if filters.Connection == "" || slices.Contains(summary.Connections, filters.Connection) {
+			runs = append(runs, summary)
+		}
+	}
+	slices.SortStableFunc(runs, func(a, b *RunSummary) int { return b.StartedAt.Compare(a.StartedAt) })
+
+	total := len(runs)
+	start := min(filters.Offset, total)
+	end := total
+	if filters.Limit > 0 {
+		end = min(start+filters.Limit, total)
+	}
+	return runs[start:end], total, nil
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// initializeAudit creates the migrations_audit table. Triggers reject UPDATE and DELETE so the
+// audit log stays append-only, even for clients opening the state file directly.
+func (t *Tracker) initializeAudit(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS migrations_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			operation TEXT NOT NULL,
+			actor TEXT NOT NULL DEFAULT '',
+			role TEXT NOT NULL DEFAULT '',
+			protocol TEXT NOT NULL,
+			method TEXT NOT NULL,
+			source_ip TEXT NOT NULL DEFAULT '',
+			request_body TEXT,
+			outcome TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT '',
+			error TEXT,
+			created_at TEXT NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_migrations_audit_created_at ON migrations_audit (created_at DESC)",
+		`CREATE TRIGGER IF NOT EXISTS migrations_audit_no_update BEFORE UPDATE ON migrations_audit
+		BEGIN
+			SELECT RAISE(ABORT, 'migrations_audit is append-only: UPDATE is not allowed');
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS migrations_audit_no_delete BEFORE DELETE ON migrations_audit
+		BEGIN
+			SELECT RAISE(ABORT, 'migrations_audit is append-only: DELETE is not allowed');
+		END`,
+	}
+	for _, stmt := range statements {
+		if _, err := t.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create migrations_audit table: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordAudit appends a record to migrations_audit, setting its ID and CreatedAt
+func (t *Tracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	createdAt := time.Now()
+	result, err := t.db.ExecContext(ctx, `
+		INSERT INTO migrations_audit (operation, actor, role, protocol, method, source_ip, request_body, outcome, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		record.Operation,
+		record.Actor,
+		record.Role,
+		record.Protocol,
+		record.Method,
+		record.SourceIP,
+		record.RequestBody,
+		record.Outcome,
+		record.Status,
+		record.Error,
+		timestamp(createdAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit: %w", err)
+	}
+	if record.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to record audit: %w", err)
+	}
+	record.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// GetAuditLog retrieves audit records matching filters, ordered by created_at DESC
+func (t *Tracker) GetAuditLog(ctx context.Context, filters *state.AuditFilters) ([]*state.AuditRecord, int, error) {
+	if filters == nil {
+		filters = &state.AuditFilters{}
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+	if filters.Operation != "" {
+		addCondition("operation = ?", filters.Operation)
+	}
+	if filters.Actor != "" {
+		addCondition("actor = ?", filters.Actor)
+	}
+	if filters.Outcome != "" {
+		addCondition("outcome = ?", filters.Outcome)
+	}
+	if !filters.Since.IsZero() {
+		addCondition("created_at >= ?", timestamp(filters.Since))
+	}
+	if !filters.Until.IsZero() {
+		addCondition("created_at < ?", timestamp(filters.Until))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM migrations_audit "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit records: %w", err)
+	}
+
+	query := `
+		SELECT id, operation, actor, role, protocol, method, source_ip,
+		       COALESCE(request_body, ''), outcome, status, COALESCE(error, ''), created_at
+		FROM migrations_audit
+		` + where + `
+		ORDER BY created_at DESC, id DESC
+	`
+	// SQLite only accepts OFFSET after a LIMIT; -1 means no limit
+	if filters.Limit > 0 || filters.Offset > 0 {
+		limit := filters.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filters.Offset)
+	}
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []*state.AuditRecord
+	for rows.Next() {
+		var record state.AuditRecord
+		var createdAt string
+		err := rows.Scan(
+			&record.ID,
+			&record.Operation,
+			&record.Actor,
+			&record.Role,
+			&record.Protocol,
+			&record.Method,
+			&record.SourceIP,
+			&record.RequestBody,
+			&record.Outcome,
+			&record.Status,
+			&record.Error,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit record: %w", err)
+		}
+		record.CreatedAt = formatTimestamp(createdAt)
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate audit records: %w", err)
+	}
+	return records, total, nil
+}
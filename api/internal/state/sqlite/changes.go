@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// initializeChanges creates the migrations_changes table, the state change feed. Its ID is the
+// cursor of a change; SQLite serializes writes, so changes are visible in cursor order.
+func (t *Tracker) initializeChanges(ctx context.Context) error {
+	_, err := t.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS migrations_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			migration_id TEXT NOT NULL,
+			schema TEXT NOT NULL DEFAULT '',
+			version TEXT NOT NULL DEFAULT '',
+			connection TEXT NOT NULL DEFAULT '',
+			backend TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations_changes table: %w", err)
+	}
+	return nil
+}
+
+// recordChange appends a change to migrations_changes
+func (t *Tracker) recordChange(ctx context.Context, change *state.StateChange) error {
+	_, err := t.db.ExecContext(ctx, `
+		INSERT INTO migrations_changes (type, migration_id, schema, version, connection, backend, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, change.Type, change.MigrationID, change.Schema, change.Version, change.Connection, change.Backend,
+		change.Status, change.Error, timestamp(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to record %s change of %s: %w", change.Type, change.MigrationID, err)
+	}
+	return nil
+}
+
+// GetStateChanges returns the changes after the cursor since, oldest first
+func (t *Tracker) GetStateChanges(ctx context.Context, since int64, limit int) ([]*state.StateChange, error) {
+	query := `
+		SELECT id, type, migration_id, schema, version, connection, backend, status, error, created_at
+		FROM migrations_changes
+		WHERE id > ?
+		ORDER BY id
+	`
+	args := []interface{}{since}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state changes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []*state.StateChange
+	for rows.Next() {
+		var change state.StateChange
+		err := rows.Scan(&change.Cursor, &change.Type, &change.MigrationID, &change.Schema, &change.Version,
+			&change.Connection, &change.Backend, &change.Status, &change.Error, &change.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan state change: %w", err)
+		}
+		change.CreatedAt = formatTimestamp(change.CreatedAt)
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate state changes: %w", err)
+	}
+	return changes, nil
+}
+
+// recordExecutionChange appends the change of type changeType of a recorded execution; executions
+// that change no state (changeType "") are not in the feed
+func (t *Tracker) recordExecutionChange(ctx context.Context, baseMigrationID string, migration *state.MigrationRecord, changeType, status string) error {
+	if changeType == "" {
+		return nil
+	}
+	return t.recordChange(ctx, &state.StateChange{
+		Type:        changeType,
+		MigrationID: baseMigrationID,
+		Schema:      migration.Schema,
+		Version:     migration.Version,
+		Connection:  migration.Connection,
+		Backend:     migration.Backend,
+		Status:      status,
+		Error:       migration.ErrorMessage,
+	})
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// initializeEmergencyRuns creates the migrations_emergency_runs table, the requests that bypassed
+// the execution safeguards and their postmortems
+func (t *Tracker) initializeEmergencyRuns(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS migrations_emergency_runs (
+			id TEXT PRIMARY KEY,
+			operation TEXT NOT NULL,
+			actor TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL,
+			method TEXT NOT NULL DEFAULT '',
+			started_at TEXT NOT NULL,
+			postmortem_due TEXT NOT NULL,
+			postmortem TEXT NOT NULL DEFAULT '',
+			postmortem_by TEXT NOT NULL DEFAULT '',
+			postmortem_at TEXT
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_migrations_emergency_runs_started_at ON migrations_emergency_runs (started_at DESC)",
+	}
+	for _, stmt := range statements {
+		if _, err := t.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create migrations_emergency_runs table: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordEmergencyRun creates an emergency run, or records the postmortem of an existing one
+func (t *Tracker) RecordEmergencyRun(ctx context.Context, run *state.EmergencyRun) error {
+	due, err := time.Parse(time.RFC3339, run.PostmortemDue)
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: invalid postmortem_due: %w", run.ID, err)
+	}
+	now := timestamp(time.Now())
+	_, err = t.db.ExecContext(ctx, `
+		INSERT INTO migrations_emergency_runs (id, operation, actor, reason, method, started_at, postmortem_due,
+		                                       postmortem, postmortem_by, postmortem_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CASE WHEN ? <> '' THEN ? END)
+		ON CONFLICT (id) DO UPDATE SET
+			postmortem = excluded.postmortem,
+			postmortem_by = excluded.postmortem_by,
+			postmortem_at = excluded.postmortem_at
+		WHERE excluded.postmortem <> ''
+	`, run.ID, run.Operation, run.Actor, run.Reason, run.Method, now, timestamp(due),
+		run.Postmortem, run.PostmortemBy, run.Postmortem, now)
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+const emergencyRunColumns = `id, operation, actor, reason, method, started_at, postmortem_due, postmortem, postmortem_by,
+		       COALESCE(postmortem_at, '')`
+
+// scanEmergencyRun scans a migrations_emergency_runs row selected with emergencyRunColumns
+func scanEmergencyRun(row rowScanner) (*state.EmergencyRun, error) {
+	var run state.EmergencyRun
+	err := row.Scan(&run.ID, &run.Operation, &run.Actor, &run.Reason, &run.Method, &run.StartedAt, &run.PostmortemDue,
+		&run.Postmortem, &run.PostmortemBy, &run.PostmortemAt)
+	if err != nil {
+		return nil, err
+	}
+	run.StartedAt = formatTimestamp(run.StartedAt)
+	run.PostmortemDue = formatTimestamp(run.PostmortemDue)
+	run.PostmortemAt = formatTimestamp(run.PostmortemAt)
+	return &run, nil
+}
+
+// GetEmergencyRun returns an emergency run by ID
+func (t *Tracker) GetEmergencyRun(ctx context.Context, id string) (*state.EmergencyRun, error) {
+	run, err := scanEmergencyRun(t.db.QueryRowContext(ctx,
+		"SELECT "+emergencyRunColumns+" FROM migrations_emergency_runs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, state.ErrEmergencyRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency run %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// GetEmergencyRuns retrieves emergency runs matching filters, most recent first
+func (t *Tracker) GetEmergencyRuns(ctx context.Context, filters *state.EmergencyRunFilters) ([]*state.EmergencyRun, int, error) {
+	if filters == nil {
+		filters = &state.EmergencyRunFilters{}
+	}
+	where := ""
+	if filters.Pending {
+		where = "WHERE postmortem = ''"
+	}
+
+	var total int
+	if err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM migrations_emergency_runs "+where).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count emergency runs: %w", err)
+	}
+
+	var args []interface{}
+	query := "SELECT " + emergencyRunColumns + " FROM migrations_emergency_runs " + where + " ORDER BY started_at DESC, id DESC"
+	// SQLite only accepts OFFSET after a LIMIT; -1 means no limit
+	if filters.Limit > 0 || filters.Offset > 0 {
+		limit := filters.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filters.Offset)
+	}
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query emergency runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*state.EmergencyRun
+	for rows.Next() {
+		run, err := scanEmergencyRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan emergency run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate emergency runs: %w", err)
+	}
+	return runs, total, nil
+}
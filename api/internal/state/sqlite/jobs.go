@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// initializeJobs creates the migrations_jobs table, the lifecycle of async jobs
+func (t *Tracker) initializeJobs(ctx context.Context) error {
+	statements := []string{`
+		CREATE TABLE IF NOT EXISTS migrations_jobs (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			connection TEXT NOT NULL DEFAULT '',
+			schema TEXT NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT '',
+			dry_run INTEGER NOT NULL DEFAULT 0,
+			worker TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			payload TEXT NOT NULL DEFAULT '',
+			applied TEXT NOT NULL DEFAULT '[]',
+			skipped TEXT NOT NULL DEFAULT '[]',
+			errors TEXT NOT NULL DEFAULT '[]',
+			queued_at TEXT NOT NULL,
+			started_at TEXT,
+			finished_at TEXT,
+			updated_at TEXT NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_migrations_jobs_queued_at ON migrations_jobs (queued_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_jobs_status ON migrations_jobs (status)",
+	}
+	for _, stmt := range statements {
+		if _, err := t.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create migrations_jobs table: %w", err)
+		}
+	}
+
+	// Columns added after the table was introduced
+	for _, column := range []string{"attempts INTEGER NOT NULL DEFAULT 0", "payload TEXT NOT NULL DEFAULT ''", "scheduled_at TEXT"} {
+		if err := t.addColumnIfMissing(ctx, "migrations_jobs", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column, given by its definition, to a table created without it
+func (t *Tracker) addColumnIfMissing(ctx context.Context, table, definition string) error {
+	name := strings.Fields(definition)[0]
+	exists, err := t.hasColumn(ctx, table, name)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := t.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition)); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %w", name, table, err)
+	}
+	return nil
+}
+
+// hasColumn reports whether table has the column name
+func (t *Tracker) hasColumn(ctx context.Context, table, name string) (bool, error) {
+	var exists bool
+	err := t.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pragma_table_info(?) WHERE name = ?)", table, name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	return exists, nil
+}
+
+// RecordJob creates a job in migrations_jobs or updates its status, worker and results. A job
+// queued again (replayed) starts over.
+func (t *Tracker) RecordJob(ctx context.Context, job *state.Job) error {
+	now := timestamp(time.Now())
+	var startedAt, finishedAt interface{}
+	if job.Status == state.JobPickedUp || job.Status == state.JobRunning {
+		startedAt = now
+	}
+	if state.JobFinished(job.Status) {
+		finishedAt = now
+	}
+	applied, skipped, jobErrors := jsonList(job.Applied), jsonList(job.Skipped), jsonList(job.Errors)
+	var scheduledAt interface{}
+	if job.ScheduledAt != "" {
+		parsed, err := time.Parse(time.RFC3339, job.ScheduledAt)
+		if err != nil {
+			return fmt.Errorf("failed to record job %s: invalid scheduled_at: %w", job.ID, err)
+		}
+		scheduledAt = timestamp(parsed)
+	}
+
+	_, err := t.db.ExecContext(ctx, `
+		INSERT INTO migrations_jobs (id, status, connection, schema, target, dry_run, worker, attempts, payload,
+		                             applied, skipped, errors, queued_at, started_at, finished_at, updated_at, scheduled_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			worker = CASE WHEN excluded.worker <> '' OR excluded.status = 'queued' THEN excluded.worker
+			              ELSE migrations_jobs.worker END,
+			attempts = excluded.attempts,
+			payload = excluded.payload,
+			applied = excluded.applied,
+			skipped = excluded.skipped,
+			errors = excluded.errors,
+			queued_at = CASE WHEN excluded.status = 'queued' THEN excluded.queued_at ELSE migrations_jobs.queued_at END,
+			started_at = CASE WHEN excluded.status = 'queued' THEN NULL
+			                  ELSE COALESCE(migrations_jobs.started_at, excluded.started_at) END,
+			finished_at = CASE WHEN excluded.status = 'queued' THEN NULL
+			                   ELSE COALESCE(excluded.finished_at, migrations_jobs.finished_at) END,
+			updated_at = excluded.updated_at,
+			scheduled_at = COALESCE(excluded.scheduled_at, migrations_jobs.scheduled_at)
+	`, job.ID, job.Status, job.Connection, job.Schema, job.Target, job.DryRun, job.Worker, job.Attempts, job.Payload,
+		applied, skipped, jobErrors, now, startedAt, finishedAt, now, scheduledAt)
+	if err != nil {
+		return fmt.Errorf("failed to record job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// jsonList encodes a list of strings as a JSON array
+func jsonList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+const jobColumns = `id, status, connection, schema, target, dry_run, worker, attempts, payload, applied, skipped, errors,
+		       queued_at, COALESCE(started_at, ''), COALESCE(finished_at, ''), updated_at, COALESCE(scheduled_at, '')`
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a migrations_jobs row selected with jobColumns
+func scanJob(row rowScanner) (*state.Job, error) {
+	var job state.Job
+	var applied, skipped, jobErrors string
+	err := row.Scan(&job.ID, &job.Status, &job.Connection, &job.Schema, &job.Target, &job.DryRun, &job.Worker,
+		&job.Attempts, &job.Payload, &applied, &skipped, &jobErrors, &job.QueuedAt, &job.StartedAt, &job.FinishedAt, &job.UpdatedAt,
+		&job.ScheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range []struct {
+		raw    string
+		values *[]string
+	}{{applied, &job.Applied}, {skipped, &job.Skipped}, {jobErrors, &job.Errors}} {
+		if err := json.Unmarshal([]byte(list.raw), list.values); err != nil {
+			return nil, fmt.Errorf("failed to decode job %s: %w", job.ID, err)
+		}
+	}
+	job.QueuedAt = formatTimestamp(job.QueuedAt)
+	job.StartedAt = formatTimestamp(job.StartedAt)
+	job.FinishedAt = formatTimestamp(job.FinishedAt)
+	job.UpdatedAt = formatTimestamp(job.UpdatedAt)
+	job.ScheduledAt = formatTimestamp(job.ScheduledAt)
+	return &job, nil
+}
+
+// GetJob returns a job by ID
+func (t *Tracker) GetJob(ctx context.Context, id string) (*state.Job, error) {
+	job, err := scanJob(t.db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM migrations_jobs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, state.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// GetJobs retrieves jobs matching filters, most recently queued first
+func (t *Tracker) GetJobs(ctx context.Context, filters *state.JobFilters) ([]*state.Job, int, error) {
+	if filters == nil {
+		filters = &state.JobFilters{}
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filters.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filters.Status)
+	}
+	if filters.Connection != "" {
+		conditions = append(conditions, "connection = ?")
+		args = append(args, filters.Connection)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM migrations_jobs "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	query := "SELECT " + jobColumns + " FROM migrations_jobs " + where + " ORDER BY queued_at DESC, id DESC"
+	// SQLite only accepts OFFSET after a LIMIT; -1 means no limit
+	if filters.Limit > 0 || filters.Offset > 0 {
+		limit := filters.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filters.Offset)
+	}
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var jobs []*state.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+	return jobs, total, nil
+}
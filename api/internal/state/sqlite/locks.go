@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// SQLite has no advisory locks, so locks are rows: migrations_locks holds connection locks (as in
+// the PostgreSQL tracker, where it only records them) and migrations_execution_locks holds
+// per-migration execution locks and the primary lock. A row is held while the process that wrote
+// it is alive, so a crashed holder does not block others. The state file must be local to every
+// process using it, as SQLite requires anyway.
+
+// primaryLockKey is the migrations_execution_locks key of the primary lock
+const primaryLockKey = "primary"
+
+// errLockHeld is returned by tryLock when another live process holds the lock
+var errLockHeld = errors.New("lock is held")
+
+// initializeLocks creates the lock tables
+func (t *Tracker) initializeLocks(ctx context.Context) error {
+	for _, table := range []struct{ name, key string }{
+		{"migrations_locks", "connection"},
+		{"migrations_execution_locks", "lock_key"},
+	} {
+		createSQL := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				%s TEXT PRIMARY KEY,
+				holder TEXT NOT NULL,
+				pid INTEGER NOT NULL,
+				token TEXT NOT NULL,
+				acquired_at TEXT NOT NULL
+			)
+		`, table.name, table.key)
+		if _, err := t.db.ExecContext(ctx, createSQL); err != nil {
+			return fmt.Errorf("failed to create %s table: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// tryLock writes the lock row for key unless a live process holds it, and returns the token that
+// identifies this acquisition
+func (t *Tracker) tryLock(ctx context.Context, table, keyColumn, key, holder string) (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(buf[:])
+
+	// The transaction takes the write lock up front (_txlock=immediate), so the check and the
+	// write are atomic across processes
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin lock transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var pid int
+	err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT pid FROM %s WHERE %s = ?", table, keyColumn), key).Scan(&pid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("look up lock: %w", err)
+	}
+	if err == nil && processAlive(pid) {
+		return "", errLockHeld
+	}
+
+	// A row left by a holder that is gone is taken over
+	recordSQL := fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s, holder, pid, token, acquired_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (%[2]s) DO UPDATE SET
+			holder = excluded.holder,
+			pid = excluded.pid,
+			token = excluded.token,
+			acquired_at = excluded.acquired_at
+	`, table, keyColumn)
+	if _, err := tx.ExecContext(ctx, recordSQL, key, holder, os.Getpid(), token, timestamp(time.Now())); err != nil {
+		return "", fmt.Errorf("record lock: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit lock: %w", err)
+	}
+	return token, nil
+}
+
+// unlock removes a lock row written by tryLock, unless it was force-released and taken since
+func (t *Tracker) unlock(table, keyColumn, key, token string) {
+	_, _ = t.db.ExecContext(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND token = ?", table, keyColumn), key, token)
+}
+
+// WithMigrationExecutionLock runs fn while holding the lock for (migration_id, execution schema,
+// connection), so the same migration can run for different schemas concurrently.
+func (t *Tracker) WithMigrationExecutionLock(ctx context.Context, migrationID, schema, connection string, fn func() error) error {
+	key := fmt.Sprintf("migration\x00%s\x00%s\x00%s", migrationID, schema, connection)
+	token, err := t.tryLock(ctx, "migrations_execution_locks", "lock_key", key, "")
+	if errors.Is(err, errLockHeld) {
+		return state.ErrMigrationAlreadyInProgress
+	}
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer t.unlock("migrations_execution_locks", "lock_key", key, token)
+
+	return fn()
+}
+
+// WithConnectionLock runs fn while holding the lock on a connection, recorded in migrations_locks
+func (t *Tracker) WithConnectionLock(ctx context.Context, connection, holder string, fn func() error) error {
+	token, err := t.tryLock(ctx, "migrations_locks", "connection", connection, holder)
+	if errors.Is(err, errLockHeld) {
+		return state.ErrConnectionLocked
+	}
+	if err != nil {
+		return fmt.Errorf("acquire connection lock: %w", err)
+	}
+	defer t.unlock("migrations_locks", "connection", connection, token)
+
+	return fn()
+}
+
+// ListLocks returns the connection locks whose holder is still alive
+func (t *Tracker) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	rows, err := t.db.QueryContext(ctx,
+		"SELECT connection, holder, pid, acquired_at FROM migrations_locks ORDER BY connection")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query connection locks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	locks := []*state.MigrationLock{}
+	for rows.Next() {
+		var lock state.MigrationLock
+		var pid int
+		var acquiredAt string
+		if err := rows.Scan(&lock.Connection, &lock.Holder, &pid, &acquiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan connection lock: %w", err)
+		}
+		if !processAlive(pid) {
+			continue
+		}
+		lock.AcquiredAt = formatTimestamp(acquiredAt)
+		locks = append(locks, &lock)
+	}
+	return locks, rows.Err()
+}
+
+// ReleaseLock force-releases a connection lock by removing its row. Unlike the PostgreSQL tracker
+// the holding process is not stopped: a run that is only stuck keeps going, but no longer excludes
+// other runs. Returns false if no live process held the lock.
+func (t *Tracker) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	var pid int
+	err := t.db.QueryRowContext(ctx, "SELECT pid FROM migrations_locks WHERE connection = ?", connection).Scan(&pid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up connection lock: %w", err)
+	}
+
+	if _, err := t.db.ExecContext(ctx, "DELETE FROM migrations_locks WHERE connection = ?", connection); err != nil {
+		return false, fmt.Errorf("failed to remove connection lock record: %w", err)
+	}
+	return processAlive(pid), nil
+}
+
+// AcquirePrimaryLock takes the lock electing the primary server instance among instances sharing
+// the state file. If the instance dies, its row no longer counts and another instance can take over.
+func (t *Tracker) AcquirePrimaryLock(ctx context.Context) (state.PrimaryLock, error) {
+	holder, _ := os.Hostname()
+	token, err := t.tryLock(ctx, "migrations_execution_locks", "lock_key", primaryLockKey, holder)
+	if errors.Is(err, errLockHeld) {
+		return nil, state.ErrPrimaryLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("acquire primary lock: %w", err)
+	}
+	return &primaryLock{tracker: t, token: token}, nil
+}
+
+// primaryLock is a primary lock held by this process
+type primaryLock struct {
+	tracker *Tracker
+	token   string
+	once    sync.Once
+}
+
+func (l *primaryLock) Check(ctx context.Context) error {
+	var held bool
+	err := l.tracker.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM migrations_execution_locks WHERE lock_key = ? AND token = ?)",
+		primaryLockKey, l.token).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("primary lock check failed: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("primary lock was taken over")
+	}
+	return nil
+}
+
+func (l *primaryLock) Release() {
+	l.once.Do(func() {
+		l.tracker.unlock("migrations_execution_locks", "lock_key", primaryLockKey, l.token)
+	})
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package sqlite
+
+import "os"
+
+// processAlive reports whether the process holding a lock row is still running
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	if pid <= 0 {
+		return false
+	}
+	// FindProcess opens a handle to the process, which fails once it has exited
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
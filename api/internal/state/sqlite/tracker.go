@@ -0,0 +1,1253 @@
+// Package sqlite implements state.StateTracker on a single SQLite file, for the CLI and local
+// development where running a PostgreSQL state database is not worth it. It keeps the same
+// tables as the PostgreSQL tracker (migrations_list, migrations_history, migrations_executions,
+// ...), with arrays stored as JSON text and timestamps as UTC text.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/state"
+
+	_ "modernc.org/sqlite" // database/sql driver "sqlite"
+)
+
+// timestampLayout is how timestamps are stored. It sorts lexically, so ORDER BY and range
+// filters work on the text columns.
+const timestampLayout = "2006-01-02 15:04:05.000000"
+
+// Tracker implements StateTracker for SQLite
+type Tracker struct {
+	db *sql.DB
+}
+
+// NewTracker opens (creating it if needed) the SQLite state database at path
+func NewTracker(path string) (*Tracker, error) {
+	// Foreign keys are off by default in SQLite, and the cascades from migrations_list rely on them.
+	// Transactions take the write lock up front so lock rows are checked and written atomically.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite state database: %w", err)
+	}
+	// A single connection serializes writes from this process instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	tracker := &Tracker{db: db}
+	if err := tracker.Initialize(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+
+	return tracker, nil
+}
+
+// Initialize creates the migration state tables
+func (t *Tracker) Initialize(ctx context.Context) error {
+	statements := []struct {
+		table string
+		sql   string
+	}{
+		{"migrations_list", `
+			CREATE TABLE IF NOT EXISTS migrations_list (
+				migration_id TEXT PRIMARY KEY,
+				schema TEXT NOT NULL,
+				version TEXT NOT NULL,
+				name TEXT NOT NULL,
+				connection TEXT NOT NULL,
+				backend TEXT NOT NULL,
+				up_sql TEXT,
+				down_sql TEXT,
+				dependencies TEXT NOT NULL DEFAULT '[]',
+				structured_dependencies TEXT,
+				status TEXT NOT NULL DEFAULT 'pending',
+				checksum TEXT,
+				table_name TEXT,
+				status_labels TEXT NOT NULL DEFAULT '[]',
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`},
+		{"migrations_history", `
+			CREATE TABLE IF NOT EXISTS migrations_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				migration_id TEXT NOT NULL REFERENCES migrations_list(migration_id) ON DELETE CASCADE,
+				schema TEXT NOT NULL,
+				version TEXT NOT NULL,
+				connection TEXT NOT NULL,
+				backend TEXT NOT NULL,
+				status TEXT NOT NULL,
+				operation TEXT NOT NULL DEFAULT 'up',
+				error_message TEXT,
+				error_class TEXT,
+				executed_by TEXT,
+				execution_method TEXT NOT NULL DEFAULT 'api',
+				execution_context TEXT,
+				applied_at TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`},
+		{"migrations_executions", `
+			CREATE TABLE IF NOT EXISTS migrations_executions (
+				migration_id TEXT NOT NULL REFERENCES migrations_list(migration_id) ON DELETE CASCADE,
+				schema TEXT NOT NULL,
+				version TEXT NOT NULL,
+				connection TEXT NOT NULL,
+				backend TEXT NOT NULL,
+				status TEXT NOT NULL,
+				applied INTEGER NOT NULL DEFAULT 0,
+				applied_at TEXT,
+				checksum TEXT,
+				actions TEXT,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (migration_id, schema, version, connection, backend)
+			)`},
+		{"migrations_dependencies", `
+			CREATE TABLE IF NOT EXISTS migrations_dependencies (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				migration_id TEXT NOT NULL REFERENCES migrations_list(migration_id) ON DELETE CASCADE,
+				dependency_id TEXT NOT NULL REFERENCES migrations_list(migration_id) ON DELETE CASCADE,
+				connection TEXT NOT NULL,
+				schema TEXT NOT NULL DEFAULT '[]',
+				target TEXT NOT NULL,
+				target_type TEXT NOT NULL DEFAULT 'name',
+				requires_table TEXT,
+				requires_schema TEXT,
+				created_at TEXT NOT NULL
+			)`},
+		{"migrations_skipped", `
+			CREATE TABLE IF NOT EXISTS migrations_skipped (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				migration_id TEXT NOT NULL REFERENCES migrations_list(migration_id) ON DELETE CASCADE,
+				schema TEXT NOT NULL,
+				version TEXT NOT NULL,
+				connection TEXT NOT NULL,
+				backend TEXT NOT NULL,
+				executed_by TEXT,
+				execution_method TEXT NOT NULL DEFAULT 'api',
+				execution_context TEXT,
+				skipped_at TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`},
+	}
+	for _, stmt := range statements {
+		if _, err := t.db.ExecContext(ctx, stmt.sql); err != nil {
+			return fmt.Errorf("failed to create %s table: %w", stmt.table, err)
+		}
+	}
+
+	// Columns added after the tables were introduced
+	if err := t.addColumnIfMissing(ctx, "migrations_history", "error_class TEXT"); err != nil {
+		return err
+	}
+	if err := t.addColumnIfMissing(ctx, "migrations_executions", "checksum TEXT"); err != nil {
+		return err
+	}
+	hasOperation, err := t.hasColumn(ctx, "migrations_history", "operation")
+	if err != nil {
+		return err
+	}
+	if !hasOperation {
+		if err := t.addColumnIfMissing(ctx, "migrations_history", "operation TEXT NOT NULL DEFAULT 'up'"); err != nil {
+			return err
+		}
+		if err := t.migrateHistoryOperations(ctx); err != nil {
+			return err
+		}
+	}
+
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_migrations_list_connection_backend ON migrations_list (connection, backend)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_list_status ON migrations_list (status)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_history_migration_id ON migrations_history (migration_id)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_history_applied_at ON migrations_history (applied_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_executions_migration_id ON migrations_executions (migration_id)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_executions_created_at ON migrations_executions (created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_executions_connection ON migrations_executions (connection, migration_id, schema)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_dependencies_migration_id ON migrations_dependencies (migration_id)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_dependencies_dependency_id ON migrations_dependencies (dependency_id)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_skipped_migration_id ON migrations_skipped (migration_id)",
+		"CREATE INDEX IF NOT EXISTS idx_migrations_skipped_skipped_at ON migrations_skipped (skipped_at DESC)",
+	}
+	for _, indexSQL := range indexes {
+		if _, err := t.db.ExecContext(ctx, indexSQL); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	// Lock records (see locks.go), the append-only audit log (see audit.go), async jobs (see
+	// jobs.go), emergency runs (see emergency.go) and the state change feed (see changes.go)
+	if err := t.initializeLocks(ctx); err != nil {
+		return err
+	}
+	if err := t.initializeAudit(ctx); err != nil {
+		return err
+	}
+	if err := t.initializeJobs(ctx); err != nil {
+		return err
+	}
+	if err := t.initializeEmergencyRuns(ctx); err != nil {
+		return err
+	}
+	return t.initializeChanges(ctx)
+}
+
+// migrateHistoryOperations sets the operation of the history rows recorded before rows had one.
+// Down migrations were recorded under the ID of their migration with a "_down" suffix, in a
+// migrations_list entry of their own: their rows move to the migration, and the entry is removed.
+// Successful rollbacks were recorded under the ID of their migration with status rolled_back. Failed
+// rollbacks cannot be told from failed up migrations and stay up.
+func (t *Tracker) migrateHistoryOperations(ctx context.Context) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin history operation migration: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	statements := []string{
+		`UPDATE migrations_history
+		 SET operation = 'down', migration_id = substr(migration_id, 1, length(migration_id) - 5)
+		 WHERE substr(migration_id, -5) = '_down'
+		   AND substr(migration_id, 1, length(migration_id) - 5) IN (SELECT migration_id FROM migrations_list)`,
+		`DELETE FROM migrations_list
+		 WHERE substr(migration_id, -5) = '_down'
+		   AND substr(migration_id, 1, length(migration_id) - 5) IN (SELECT migration_id FROM migrations_list)
+		   AND migration_id NOT IN (SELECT migration_id FROM migrations_history)`,
+		`UPDATE migrations_history SET operation = 'rollback' WHERE operation = 'up' AND status = 'rolled_back'`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate history operations: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// timestamp formats a time for storage
+func timestamp(tm time.Time) string {
+	return tm.UTC().Format(timestampLayout)
+}
+
+// formatTimestamp converts a stored timestamp to RFC3339, as returned by the PostgreSQL tracker
+func formatTimestamp(value string) string {
+	if value == "" {
+		return ""
+	}
+	parsed, err := time.Parse(timestampLayout, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Format(time.RFC3339)
+}
+
+// upsertListSQL creates a migrations_list row, or updates the status of an existing one unless it
+// is already applied
+const upsertListSQL = `
+	INSERT INTO migrations_list AS ml (migration_id, schema, version, name, connection, backend, status, checksum, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+	ON CONFLICT (migration_id) DO UPDATE SET
+		status = CASE
+			WHEN ml.status = 'applied' THEN ml.status
+			ELSE excluded.status
+		END,
+		checksum = COALESCE(excluded.checksum, ml.checksum),
+		updated_at = excluded.updated_at
+`
+
+// upsertExecutionSQL records the execution state of a migration for one schema
+const upsertExecutionSQL = `
+	INSERT INTO migrations_executions AS me (migration_id, schema, version, connection, backend, status, applied, applied_at, checksum, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+	ON CONFLICT (migration_id, schema, version, connection, backend) DO UPDATE SET
+		status = excluded.status,
+		applied = excluded.applied,
+		applied_at = excluded.applied_at,
+		checksum = COALESCE(excluded.checksum, me.checksum),
+		updated_at = excluded.updated_at
+`
+
+// recordExecution upserts the migrations_executions row of a migration run for a schema.
+// Runs without a schema have no execution row.
+func (t *Tracker) recordExecution(ctx context.Context, baseMigrationID string, migration *state.MigrationRecord, status string, appliedAt time.Time) error {
+	if migration.Schema == "" {
+		return nil
+	}
+
+	applied := status == "applied"
+	var appliedAtValue interface{}
+	if applied {
+		appliedAtValue = timestamp(appliedAt)
+	}
+	execStatus := "pending"
+	if applied {
+		execStatus = "applied"
+	} else if status == "failed" {
+		execStatus = "failed"
+	}
+
+	now := timestamp(time.Now())
+	_, err := t.db.ExecContext(ctx, upsertExecutionSQL,
+		baseMigrationID, migration.Schema, migration.Version,
+		migration.Connection, migration.Backend, execStatus, applied, appliedAtValue, migration.Checksum, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to insert into migrations_executions: %w", err)
+	}
+	return nil
+}
+
+// RecordMigration records a migration execution
+func (t *Tracker) RecordMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	appliedAt := migration.AppliedTime()
+	isRollback := migration.Reverts()
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+
+	executedBy := migration.ExecutedBy
+	if executedBy == "" {
+		executedBy = "system"
+	}
+	executionMethod := migration.ExecutionMethod
+	if executionMethod == "" {
+		executionMethod = "api"
+	}
+
+	status := migration.HistoryStatus()
+	listStatus := status
+	if isRollback {
+		listStatus = "rolled_back"
+	}
+
+	logger.Infof("Recording migration: id=%s, status=%s, connection=%s, backend=%s, execution_method=%s",
+		baseMigrationID, status, migration.Connection, migration.Backend, executionMethod)
+
+	now := timestamp(time.Now())
+	_, err := t.db.ExecContext(ctx, upsertListSQL,
+		baseMigrationID, migration.Schema, migration.Version, state.MigrationName(baseMigrationID),
+		migration.Connection, migration.Backend, listStatus, migration.Checksum, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to upsert migration in migrations_list: %w", err)
+	}
+
+	// History is recorded even without a schema
+	_, err = t.db.ExecContext(ctx, `
+		INSERT INTO migrations_history (migration_id, schema, version, connection, backend,
+		                                status, operation, error_message, error_class, executed_by, execution_method, execution_context, applied_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)
+	`, baseMigrationID, migration.Schema, migration.Version,
+		migration.Connection, migration.Backend, status, migration.HistoryOperation(), migration.ErrorMessage, migration.ErrorClass,
+		executedBy, executionMethod, migration.ExecutionContext, timestamp(appliedAt), timestamp(appliedAt))
+	if err != nil {
+		return fmt.Errorf("failed to insert into migrations_history: %w", err)
+	}
+
+	if err := t.recordExecution(ctx, baseMigrationID, migration, status, appliedAt); err != nil {
+		return err
+	}
+	return t.recordExecutionChange(ctx, baseMigrationID, migration, state.ExecutionChange(status, isRollback), listStatus)
+}
+
+// RecordDependencyMigration records a dependency migration as applied without creating history entries.
+// Dependencies are only recorded in the execution history of the migration that depends on them.
+func (t *Tracker) RecordDependencyMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+	status := migration.Status
+	if status == "success" {
+		status = "applied"
+	}
+
+	now := timestamp(time.Now())
+	_, err := t.db.ExecContext(ctx, upsertListSQL,
+		baseMigrationID, migration.Schema, migration.Version, state.MigrationName(baseMigrationID),
+		migration.Connection, migration.Backend, status, migration.Checksum, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to upsert dependency migration in migrations_list: %w", err)
+	}
+
+	if err := t.recordExecution(ctx, baseMigrationID, migration, status, migration.AppliedTime()); err != nil {
+		return fmt.Errorf("failed to insert dependency execution state for %s: %w", baseMigrationID, err)
+	}
+	if err := t.recordExecutionChange(ctx, baseMigrationID, migration, state.ExecutionChange(status, false), status); err != nil {
+		return err
+	}
+
+	logger.Debug("Recorded dependency migration %s as applied (no history entry created)", baseMigrationID)
+	return nil
+}
+
+// schemaCondition matches a schema column holding the schema, alone or in a comma-separated list
+const schemaCondition = " AND instr(',' || schema || ',', ',' || ? || ',') > 0"
+
+// GetMigrationHistory retrieves migration history with optional filters
+func (t *Tracker) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	from := "FROM migrations_history WHERE 1=1"
+	var args []interface{}
+	if filters != nil {
+		if filters.MigrationID != "" {
+			from += " AND migration_id = ?"
+			args = append(args, filters.MigrationID)
+		}
+		if filters.Schema != "" {
+			from += schemaCondition
+			args = append(args, filters.Schema)
+		}
+		if filters.Table != "" {
+			// History rows don't carry the table; it is declared on the migration in migrations_list
+			from += " AND migration_id IN (SELECT migration_id FROM migrations_list WHERE table_name = ?)"
+			args = append(args, filters.Table)
+		}
+		if filters.Connection != "" {
+			from += " AND connection = ?"
+			args = append(args, filters.Connection)
+		}
+		if filters.Backend != "" {
+			from += " AND backend = ?"
+			args = append(args, filters.Backend)
+		}
+		if filters.Status != "" {
+			from += " AND status = ?"
+			args = append(args, filters.Status)
+		}
+		if filters.Version != "" {
+			from += " AND version = ?"
+			args = append(args, filters.Version)
+		}
+		if !filters.AppliedAfter.IsZero() {
+			from += " AND applied_at >= ?"
+			args = append(args, timestamp(filters.AppliedAfter))
+		}
+		if !filters.AppliedBefore.IsZero() {
+			from += " AND applied_at < ?"
+			args = append(args, timestamp(filters.AppliedBefore))
+		}
+		if filters.ExecutedBy != "" {
+			from += " AND executed_by = ?"
+			args = append(args, filters.ExecutedBy)
+		}
+		if filters.ExecutionMethod != "" {
+			from += " AND execution_method = ?"
+			args = append(args, filters.ExecutionMethod)
+		}
+		if filters.ErrorClass != "" {
+			from += " AND error_class = ?"
+			args = append(args, filters.ErrorClass)
+		}
+		if filters.RunID != "" {
+			from += " AND instr(execution_context, ?) > 0"
+			args = append(args, state.RunIDNeedle(filters.RunID))
+		}
+	}
+
+	var total int
+	if err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count migrations: %w", err)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByAppliedAt, state.SortDesc)
+	query := `
+		SELECT id, migration_id, schema, version, connection, backend, applied_at, status, operation,
+		       COALESCE(error_message, ''), COALESCE(error_class, ''), COALESCE(executed_by, ''), execution_method,
+		       COALESCE(execution_context, '')
+		` + from + `
+		ORDER BY ` + orderBy(sortBy, desc, "id")
+	query += pageClause(filters, &args)
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []*state.MigrationRecord
+	for rows.Next() {
+		var record state.MigrationRecord
+		var id int64
+		var appliedAt string
+		err := rows.Scan(
+			&id,
+			&record.MigrationID,
+			&record.Schema,
+			&record.Version,
+			&record.Connection,
+			&record.Backend,
+			&appliedAt,
+			&record.Status,
+			&record.Operation,
+			&record.ErrorMessage,
+			&record.ErrorClass,
+			&record.ExecutedBy,
+			&record.ExecutionMethod,
+			&record.ExecutionContext,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan migration record: %w", err)
+		}
+		record.ID = fmt.Sprintf("%d", id)
+		record.AppliedAt = formatTimestamp(appliedAt)
+		records = append(records, &record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// GetMigrationList retrieves the list of migrations with their last status
+func (t *Tracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	from := "FROM migrations_list WHERE 1=1"
+	var args []interface{}
+	if filters != nil {
+		if filters.Schema != "" {
+			from += schemaCondition
+			args = append(args, filters.Schema)
+		}
+		if filters.Table != "" {
+			from += " AND table_name = ?"
+			args = append(args, filters.Table)
+		}
+		if filters.Connection != "" {
+			from += " AND connection = ?"
+			args = append(args, filters.Connection)
+		}
+		if filters.Backend != "" {
+			from += " AND backend = ?"
+			args = append(args, filters.Backend)
+		}
+		if filters.Status != "" {
+			from += " AND status = ?"
+			args = append(args, filters.Status)
+		}
+		if filters.Version != "" {
+			from += " AND version = ?"
+			args = append(args, filters.Version)
+		}
+		if filters.Label != "" {
+			from += " AND EXISTS (SELECT 1 FROM json_each(status_labels) WHERE value = ?)"
+			args = append(args, filters.Label)
+		}
+	}
+
+	var total int
+	if err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count migrations list: %w", err)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByVersion, state.SortAsc)
+	if sortBy == state.SortByAppliedAt {
+		// updated_at is when the migration was applied, for applied migrations
+		sortBy = "CASE WHEN status = 'applied' THEN updated_at END"
+	}
+	query := `
+		SELECT migration_id, schema, COALESCE(table_name, ''), version, name, connection, backend,
+		       status, COALESCE(checksum, ''), status_labels, updated_at
+		` + from + `
+		ORDER BY ` + orderBy(sortBy, desc, "migration_id")
+	query += pageClause(filters, &args)
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query migrations list: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []*state.MigrationListItem
+	for rows.Next() {
+		var item state.MigrationListItem
+		var labels, updatedAt string
+		err := rows.Scan(
+			&item.MigrationID,
+			&item.Schema,
+			&item.Table,
+			&item.Version,
+			&item.Name,
+			&item.Connection,
+			&item.Backend,
+			&item.LastStatus,
+			&item.Checksum,
+			&labels,
+			&updatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan migration list item: %w", err)
+		}
+		if err := json.Unmarshal([]byte(labels), &item.StatusLabels); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode status labels of %s: %w", item.MigrationID, err)
+		}
+
+		// updated_at is when the migration was applied, for applied migrations
+		item.Applied = item.LastStatus == "applied"
+		if item.Applied {
+			item.LastAppliedAt = formatTimestamp(updatedAt)
+		}
+
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// orderBy returns the ORDER BY expressions sorting by column, then by tiebreak in the same
+// direction so pages are stable. Sort fields are column names, validated by MigrationFilters.Validate.
+func orderBy(column string, desc bool, tiebreak string) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, %s %s", column, direction, tiebreak, direction)
+}
+
+// pageClause returns the LIMIT and OFFSET of filters, appending their values to args. SQLite only
+// accepts OFFSET after a LIMIT; -1 means no limit.
+func pageClause(filters *state.MigrationFilters, args *[]interface{}) string {
+	if filters == nil || (filters.Limit <= 0 && filters.Offset <= 0) {
+		return ""
+	}
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	*args = append(*args, limit, filters.Offset)
+	return " LIMIT ? OFFSET ?"
+}
+
+// GetMigrationDetail retrieves detailed information about a single migration from migrations_list
+func (t *Tracker) GetMigrationDetail(ctx context.Context, migrationID string) (*state.MigrationDetail, error) {
+	var detail state.MigrationDetail
+	var dependencies, labels string
+	var structuredDeps sql.NullString
+	err := t.db.QueryRowContext(ctx, `
+		SELECT migration_id, schema, version, name, connection, backend,
+		       COALESCE(up_sql, ''), COALESCE(down_sql, ''), dependencies, structured_dependencies, status, status_labels
+		FROM migrations_list WHERE migration_id = ?
+	`, state.BaseMigrationID(migrationID)).Scan(
+		&detail.MigrationID,
+		&detail.Schema,
+		&detail.Version,
+		&detail.Name,
+		&detail.Connection,
+		&detail.Backend,
+		&detail.UpSQL,
+		&detail.DownSQL,
+		&dependencies,
+		&structuredDeps,
+		&detail.Status,
+		&labels,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration detail: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(dependencies), &detail.Dependencies); err != nil {
+		return nil, fmt.Errorf("failed to decode dependencies of %s: %w", detail.MigrationID, err)
+	}
+	if err := json.Unmarshal([]byte(labels), &detail.StatusLabels); err != nil {
+		return nil, fmt.Errorf("failed to decode status labels of %s: %w", detail.MigrationID, err)
+	}
+	if structuredDeps.Valid && structuredDeps.String != "" {
+		var deps []backends.Dependency
+		if err := json.Unmarshal([]byte(structuredDeps.String), &deps); err == nil {
+			detail.StructuredDependencies = deps
+		}
+	}
+
+	return &detail, nil
+}
+
+// queryExecutions runs a migrations_executions query and converts the rows
+func (t *Tracker) queryExecutions(ctx context.Context, query string, args ...interface{}) ([]*state.MigrationExecution, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration executions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var executions []*state.MigrationExecution
+	for rows.Next() {
+		var exec state.MigrationExecution
+		var appliedAt sql.NullString
+		var createdAt, updatedAt string
+		err := rows.Scan(
+			&exec.MigrationID,
+			&exec.Schema,
+			&exec.Version,
+			&exec.Connection,
+			&exec.Backend,
+			&exec.Status,
+			&exec.Applied,
+			&appliedAt,
+			&exec.Checksum,
+			&createdAt,
+			&updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan migration execution: %w", err)
+		}
+		exec.AppliedAt = formatTimestamp(appliedAt.String)
+		exec.CreatedAt = formatTimestamp(createdAt)
+		exec.UpdatedAt = formatTimestamp(updatedAt)
+		executions = append(executions, &exec)
+	}
+
+	return executions, rows.Err()
+}
+
+// GetMigrationExecutions retrieves all execution records for a migration, ordered by created_at DESC
+func (t *Tracker) GetMigrationExecutions(ctx context.Context, migrationID string) ([]*state.MigrationExecution, error) {
+	return t.queryExecutions(ctx, `
+		SELECT migration_id, schema, version, connection, backend,
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
+		FROM migrations_executions WHERE migration_id = ?
+		ORDER BY created_at DESC
+	`, state.BaseMigrationID(migrationID))
+}
+
+// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+// ordered by migration_id and schema
+func (t *Tracker) GetConnectionExecutions(ctx context.Context, connection string) ([]*state.MigrationExecution, error) {
+	return t.queryExecutions(ctx, `
+		SELECT migration_id, schema, version, connection, backend,
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
+		FROM migrations_executions WHERE connection = ?
+		ORDER BY migration_id, schema
+	`, connection)
+}
+
+// GetRecentExecutions retrieves recent execution records across all migrations, ordered by created_at DESC
+func (t *Tracker) GetRecentExecutions(ctx context.Context, limit int) ([]*state.MigrationExecution, error) {
+	return t.queryExecutions(ctx, `
+		SELECT migration_id, schema, version, connection, backend,
+		       status, applied, applied_at, COALESCE(checksum, ''), created_at, updated_at
+		FROM migrations_executions
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, limit)
+}
+
+// RecordSkippedMigrations records skipped migrations for a given execution context. Only the 5
+// most recent records are kept per migration and schema.
+func (t *Tracker) RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
+	for _, migrationID := range skippedMigrationIDs {
+		baseMigrationID := state.BaseMigrationID(migrationID)
+
+		var dbSchema, version, connection, backend string
+		err := t.db.QueryRowContext(ctx,
+			"SELECT schema, version, connection, backend FROM migrations_list WHERE migration_id = ?",
+			baseMigrationID).Scan(&dbSchema, &version, &connection, &backend)
+		if err != nil {
+			// Not registered yet; continue with the other migrations
+			logger.Warnf("Skipped migration %s (base: %s) not found in migrations_list, skipping record", migrationID, baseMigrationID)
+			continue
+		}
+
+		schema := state.SchemaPrefix(migrationID, baseMigrationID)
+		if schema == "" {
+			schema = dbSchema
+		}
+
+		now := timestamp(time.Now())
+		_, err = t.db.ExecContext(ctx, `
+			INSERT INTO migrations_skipped (migration_id, schema, version, connection, backend, executed_by, execution_method, execution_context, skipped_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, baseMigrationID, schema, version, connection, backend, executedBy, executionMethod, executionContext, now, now)
+		if err != nil {
+			logger.Warnf("Failed to record skipped migration %s: %v", migrationID, err)
+			continue
+		}
+
+		_, err = t.db.ExecContext(ctx, `
+			DELETE FROM migrations_skipped
+			WHERE migration_id = ?1 AND schema = ?2
+			AND id NOT IN (
+				SELECT id FROM migrations_skipped
+				WHERE migration_id = ?1 AND schema = ?2
+				ORDER BY skipped_at DESC, id DESC
+				LIMIT 5
+			)
+		`, baseMigrationID, schema)
+		if err != nil {
+			logger.Warnf("Failed to cleanup old skipped migration records for %s (schema: %s): %v", migrationID, schema, err)
+		}
+	}
+
+	return nil
+}
+
+// GetSkippedMigrations retrieves skipped migrations, optionally filtered by migration_id or recent limit
+func (t *Tracker) GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*state.SkippedMigration, error) {
+	query := `
+		SELECT id, migration_id, schema, version, connection, backend,
+		       COALESCE(executed_by, ''), execution_method, COALESCE(execution_context, ''), skipped_at, created_at
+		FROM migrations_skipped
+	`
+	var args []interface{}
+	if migrationID != "" {
+		query += " WHERE migration_id = ?"
+		args = append(args, migrationID)
+	}
+	query += " ORDER BY skipped_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skipped migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var skippedMigrations []*state.SkippedMigration
+	for rows.Next() {
+		var skipped state.SkippedMigration
+		var skippedAt, createdAt string
+		err := rows.Scan(
+			&skipped.ID,
+			&skipped.MigrationID,
+			&skipped.Schema,
+			&skipped.Version,
+			&skipped.Connection,
+			&skipped.Backend,
+			&skipped.ExecutedBy,
+			&skipped.ExecutionMethod,
+			&skipped.ExecutionContext,
+			&skippedAt,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan skipped migration: %w", err)
+		}
+		skipped.SkippedAt = formatTimestamp(skippedAt)
+		skipped.CreatedAt = formatTimestamp(createdAt)
+		skippedMigrations = append(skippedMigrations, &skipped)
+	}
+
+	return skippedMigrations, rows.Err()
+}
+
+// executionStatusIn reports whether a schema-specific migration ID has an execution row with one of
+// statuses. A migration that is not in migrations_list has none.
+func (t *Tracker) executionStatusIn(ctx context.Context, baseMigrationID, schema string, statuses ...string) (bool, error) {
+	var version, connection, backend string
+	err := t.db.QueryRowContext(ctx,
+		"SELECT version, connection, backend FROM migrations_list WHERE migration_id = ?",
+		baseMigrationID).Scan(&version, &connection, &backend)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get migration metadata: %w", err)
+	}
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM migrations_executions
+			WHERE migration_id = ? AND schema = ? AND version = ? AND connection = ? AND backend = ?
+			AND status IN (?` + strings.Repeat(", ?", len(statuses)-1) + `)
+		)`
+	args := []interface{}{baseMigrationID, schema, version, connection, backend}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	var exists bool
+	if err := t.db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check migration status in executions table: %w", err)
+	}
+	return exists, nil
+}
+
+// IsMigrationApplied checks if a migration has been successfully applied.
+// Schema-specific IDs are checked per schema in migrations_executions, base IDs in migrations_list.
+func (t *Tracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	if schema := state.SchemaPrefix(migrationID, baseMigrationID); schema != "" {
+		return t.executionStatusIn(ctx, baseMigrationID, schema, "applied")
+	}
+
+	var exists bool
+	err := t.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM migrations_list WHERE migration_id IN (?, ?) AND status = 'applied')",
+		migrationID, baseMigrationID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration status: %w", err)
+	}
+	return exists, nil
+}
+
+// IsMigrationPendingOrApplied checks if a migration is pending or applied. For base IDs a
+// migrations_list "pending" only means registered-not-applied, so this matches IsMigrationApplied.
+func (t *Tracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	schema := state.SchemaPrefix(migrationID, baseMigrationID)
+	if schema == "" {
+		return t.IsMigrationApplied(ctx, migrationID)
+	}
+	return t.executionStatusIn(ctx, baseMigrationID, schema, "applied", "pending")
+}
+
+// GetLastMigrationVersion gets the last applied version for a schema/table
+func (t *Tracker) GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error) {
+	var version string
+	err := t.db.QueryRowContext(ctx, `
+		SELECT version FROM migrations_list
+		WHERE status = 'applied'`+schemaCondition+`
+		ORDER BY version DESC
+		LIMIT 1
+	`, schema).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get last migration version: %w", err)
+	}
+	return version, nil
+}
+
+// RegisterScannedMigration registers a scanned migration in migrations_list (status: pending)
+func (t *Tracker) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	// An already registered migration keeps its row, but picks up a table declared since
+	now := timestamp(time.Now())
+	_, err := t.db.ExecContext(ctx, `
+		INSERT INTO migrations_list (migration_id, schema, version, name, connection, backend, status, table_name, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending', NULLIF(?, ''), ?, ?)
+		ON CONFLICT (migration_id) DO UPDATE SET table_name = excluded.table_name
+		WHERE migrations_list.table_name IS NOT excluded.table_name
+	`, migrationID, schema, version, name, connection, backend, table, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to register scanned migration: %w", err)
+	}
+	return nil
+}
+
+// SetStatusLabels replaces the user-defined status labels of a migration. updated_at is left
+// alone, as it reports when the migration was applied.
+func (t *Tracker) SetStatusLabels(ctx context.Context, migrationID string, labels []string) error {
+	if labels == nil {
+		labels = []string{}
+	}
+	encoded, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode status labels: %w", err)
+	}
+
+	result, err := t.db.ExecContext(ctx,
+		"UPDATE migrations_list SET status_labels = ? WHERE migration_id = ?",
+		string(encoded), state.BaseMigrationID(migrationID))
+	if err != nil {
+		return fmt.Errorf("failed to set status labels: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: %s", state.ErrMigrationNotFound, migrationID)
+	}
+	return nil
+}
+
+// UpdateMigrationInfo updates migration metadata (schema, table, version, name, connection, backend) without affecting status/history
+func (t *Tracker) UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	result, err := t.db.ExecContext(ctx, `
+		UPDATE migrations_list
+		SET schema = ?, version = ?, name = ?, connection = ?, backend = ?, table_name = NULLIF(?, ''), updated_at = ?
+		WHERE migration_id = ?
+	`, schema, version, name, connection, backend, table, timestamp(time.Now()), migrationID)
+	if err != nil {
+		return fmt.Errorf("failed to update migration info: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("migration %s not found", migrationID)
+	}
+	return nil
+}
+
+// DeleteMigration deletes a migration from migrations_list (cascades to history via foreign key)
+func (t *Tracker) DeleteMigration(ctx context.Context, migrationID string) error {
+	if _, err := t.db.ExecContext(ctx, "DELETE FROM migrations_list WHERE migration_id = ?", migrationID); err != nil {
+		return fmt.Errorf("failed to delete migration: %w", err)
+	}
+	return nil
+}
+
+// ReindexMigrations reloads the BfM migration list and updates the database state
+// This should be called asynchronously in the background
+func (t *Tracker) ReindexMigrations(ctx context.Context, registry interface{}) error {
+	type Registry interface {
+		GetAll() []*backends.MigrationScript
+	}
+	reg, ok := registry.(Registry)
+	if !ok {
+		return fmt.Errorf("registry does not implement GetAll() method")
+	}
+
+	bfmMigrationMap := make(map[string]*backends.MigrationScript)
+	for _, migration := range reg.GetAll() {
+		migrationID := fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)
+		bfmMigrationMap[migrationID] = migration
+	}
+
+	dbMigrations, _, err := t.GetMigrationList(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get database migrations: %w", err)
+	}
+	dbMigrationMap := make(map[string]*state.MigrationListItem)
+	for _, migration := range dbMigrations {
+		dbMigrationMap[migration.MigrationID] = migration
+	}
+
+	for migrationID, migration := range bfmMigrationMap {
+		// Stop when ctx is cancelled (e.g. the reindexer stopped) rather than finishing the pass
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		dependencies := migration.Dependencies
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+		dependenciesJSON, err := json.Marshal(dependencies)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dependencies: %w", err)
+		}
+		structuredDepsJSON, err := json.Marshal(migration.StructuredDependencies)
+		if err != nil {
+			return fmt.Errorf("failed to marshal structured dependencies: %w", err)
+		}
+
+		// Filename pattern: {version}_{name}.up.{sql|json} and {version}_{name}.down.{sql|json}
+		upFilename := fmt.Sprintf("%s_%s.up%s", migration.Version, migration.Name, migration.ScriptExtension())
+		downFilename := fmt.Sprintf("%s_%s.down%s", migration.Version, migration.Name, migration.ScriptExtension())
+
+		// Keep the execution state of known migrations
+		dbMigration, exists := dbMigrationMap[migrationID]
+		status := "pending"
+		if exists {
+			if applied, err := t.IsMigrationApplied(ctx, migrationID); err == nil && applied {
+				status = "applied"
+			} else if dbMigration.LastStatus == "success" {
+				status = "applied"
+			} else {
+				status = dbMigration.LastStatus
+			}
+		}
+
+		tableName := ""
+		if migration.Table != nil {
+			tableName = *migration.Table
+		}
+
+		now := timestamp(time.Now())
+		_, err = t.db.ExecContext(ctx, `
+			INSERT INTO migrations_list (
+				migration_id, schema, version, name, connection, backend,
+				up_sql, down_sql, dependencies, structured_dependencies, status, table_name, created_at, updated_at
+			)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+			ON CONFLICT (migration_id) DO UPDATE SET
+				schema = excluded.schema,
+				version = excluded.version,
+				name = excluded.name,
+				connection = excluded.connection,
+				backend = excluded.backend,
+				up_sql = excluded.up_sql,
+				down_sql = excluded.down_sql,
+				dependencies = excluded.dependencies,
+				structured_dependencies = excluded.structured_dependencies,
+				status = excluded.status,
+				table_name = excluded.table_name,
+				updated_at = excluded.updated_at
+		`, migrationID, migration.Schema, migration.Version, migration.Name, migration.Connection, migration.Backend,
+			upFilename, downFilename, string(dependenciesJSON), string(structuredDepsJSON), status, tableName, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to upsert migration %s: %w", migrationID, err)
+		}
+
+		if migration.Schema != "" {
+			applied := status == "applied"
+			var appliedAt interface{}
+			if applied && exists && dbMigration.LastAppliedAt != "" {
+				if parsed, err := time.Parse(time.RFC3339, dbMigration.LastAppliedAt); err == nil {
+					appliedAt = timestamp(parsed)
+				}
+			}
+			execStatus := "pending"
+			if applied {
+				execStatus = "applied"
+			} else if status == "failed" {
+				execStatus = "failed"
+			}
+			_, err = t.db.ExecContext(ctx, upsertExecutionSQL,
+				migrationID, migration.Schema, migration.Version, migration.Connection, migration.Backend,
+				execStatus, applied, appliedAt, "", now, now)
+			if err != nil {
+				return fmt.Errorf("failed to insert execution state for %s: %w", migrationID, err)
+			}
+		}
+
+		if err := t.updateMigrationDependencies(ctx, migrationID, migration); err != nil {
+			return fmt.Errorf("failed to update dependencies for %s: %w", migrationID, err)
+		}
+
+		if !exists {
+			err := t.recordChange(ctx, &state.StateChange{
+				Type:        state.ChangeReindexed,
+				MigrationID: migrationID,
+				Schema:      migration.Schema,
+				Version:     migration.Version,
+				Connection:  migration.Connection,
+				Backend:     migration.Backend,
+				Status:      status,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Delete migrations that no longer exist in BfM
+	for migrationID, dbMigration := range dbMigrationMap {
+		if _, exists := bfmMigrationMap[migrationID]; !exists {
+			if err := t.DeleteMigration(ctx, migrationID); err != nil {
+				logger.Warnf("Failed to delete migration %s: %v", migrationID, err)
+				continue
+			}
+			if err := t.recordChange(ctx, state.RemovedChange(dbMigration)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateMigrationDependencies replaces the migrations_dependencies rows of a migration. Dependencies
+// that are not registered (other connections, not scanned yet) are skipped; the registry resolves
+// them at execution time.
+func (t *Tracker) updateMigrationDependencies(ctx context.Context, migrationID string, migration *backends.MigrationScript) error {
+	if _, err := t.db.ExecContext(ctx, "DELETE FROM migrations_dependencies WHERE migration_id = ?", migrationID); err != nil {
+		return fmt.Errorf("failed to delete existing dependencies: %w", err)
+	}
+
+	insertSQL := `
+		INSERT INTO migrations_dependencies (
+			migration_id, dependency_id, connection, schema, target, target_type,
+			requires_table, requires_schema, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)
+	`
+	now := timestamp(time.Now())
+
+	for _, dep := range migration.StructuredDependencies {
+		dependencyID, err := t.resolveDependencyID(ctx, dep)
+		if err != nil {
+			continue
+		}
+
+		targetType := dep.TargetType
+		if targetType == "" {
+			targetType = "name"
+		}
+		_, err = t.db.ExecContext(ctx, insertSQL,
+			migrationID, dependencyID, dep.Connection, schemaArray(dep.Schema), dep.Target, targetType,
+			dep.RequiresTable, dep.RequiresSchema, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert dependency: %w", err)
+		}
+	}
+
+	for _, depName := range migration.Dependencies {
+		var dependencyID string
+		err := t.db.QueryRowContext(ctx,
+			"SELECT migration_id FROM migrations_list WHERE name = ? LIMIT 1",
+			depName).Scan(&dependencyID)
+		if err != nil {
+			continue
+		}
+
+		_, err = t.db.ExecContext(ctx, insertSQL,
+			migrationID, dependencyID, migration.Connection, schemaArray(migration.Schema), depName, "name",
+			"", "", now)
+		if err != nil {
+			return fmt.Errorf("failed to insert simple dependency: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resolveDependencyID resolves a structured dependency to a migration_id of migrations_list: the
+// lowest migration a version bound or name prefix dependency targets
+func (t *Tracker) resolveDependencyID(ctx context.Context, dep backends.Dependency) (string, error) {
+	var dependencyID string
+	if !dep.IsPattern() {
+		column := "name"
+		if dep.TargetType == "version" {
+			column = "version"
+		}
+		err := t.db.QueryRowContext(ctx,
+			"SELECT migration_id FROM migrations_list WHERE connection = ? AND "+column+" = ? LIMIT 1",
+			dep.Connection, dep.Target).Scan(&dependencyID)
+		return dependencyID, err
+	}
+
+	rows, err := t.db.QueryContext(ctx,
+		"SELECT migration_id, version, name FROM migrations_list WHERE connection = ? ORDER BY version",
+		dep.Connection)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var version, name string
+		if err := rows.Scan(&dependencyID, &version, &name); err != nil {
+			return "", err
+		}
+		if dep.MatchesTarget(version, name) {
+			return dependencyID, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "", sql.ErrNoRows
+}
+
+// schemaArray encodes a schema as the JSON array stored in migrations_dependencies.schema
+func schemaArray(schema string) string {
+	if schema == "" {
+		return "[]"
+	}
+	encoded, _ := json.Marshal([]string{schema})
+	return string(encoded)
+}
+
+// Ping checks the state database can be read
+func (t *Tracker) Ping(ctx context.Context) error {
+	if err := t.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to reach the state database: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (t *Tracker) Close() error {
+	if t.db == nil {
+		return nil
+	}
+	err := t.db.Close()
+	t.db = nil
+	return err
+}
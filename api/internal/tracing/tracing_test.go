@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract_JobMetadata(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := Setup(context.Background(), "bfm-test")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer func() { _ = shutdown(context.Background()) }()
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "request")
+	defer span.End()
+
+	metadata := map[string]interface{}{"source": "api"}
+	Inject(ctx, metadata)
+
+	// Jobs travel through the queue as JSON
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var received map[string]interface{}
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), received))
+	if got.TraceID() != span.SpanContext().TraceID() || !got.IsRemote() {
+		t.Errorf("extracted span context %v, want remote parent in trace %s", got, span.SpanContext().TraceID())
+	}
+	if received["source"] != "api" {
+		t.Errorf("expected existing metadata to be kept, got %v", received)
+	}
+}
+
+func TestExtract_NoMetadata(t *testing.T) {
+	if sc := trace.SpanContextFromContext(Extract(context.Background(), nil)); sc.IsValid() {
+		t.Errorf("expected no span context, got %v", sc)
+	}
+}
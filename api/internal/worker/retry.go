@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// RetryPolicy configures how the worker retries jobs that fail transiently
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per job, the first included; 1 disables retries
+	Backoff     time.Duration // Wait before the first retry, doubled for each next one
+	MaxBackoff  time.Duration // Upper bound of the wait between retries
+}
+
+// DefaultRetryPolicy retries a job twice, after 5s then 10s
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 5 * time.Second, MaxBackoff: time.Minute}
+
+// delay returns the wait before the retry that follows attempt (1 for the first attempt)
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// isTransient reports whether a failed execution is worth retrying: the connection was locked by
+// another run, or every failure it reported has a retryable class (connectivity, lock timeout).
+// Failed migrations have the class the executor gave them from their driver's error codes; other
+// errors are classified by their message.
+func isTransient(err error, result *executor.ExecuteResult) bool {
+	if errors.Is(err, state.ErrConnectionLocked) || errors.Is(err, state.ErrMigrationAlreadyInProgress) {
+		return true
+	}
+	var classes []backends.FailureClass
+	if err != nil {
+		classes = append(classes, backends.ClassifyError(err))
+	}
+	if result != nil {
+		for _, message := range result.Errors {
+			classes = append(classes, failureClass(result, message))
+		}
+	}
+	if len(classes) == 0 {
+		return false
+	}
+	for _, class := range classes {
+		if !class.Retryable() {
+			return false
+		}
+	}
+	return true
+}
+
+// failureClass returns the class of an error of result: the class of the failed migration it
+// reports, or the class of the message when it does not report a classified migration
+func failureClass(result *executor.ExecuteResult, message string) backends.FailureClass {
+	for migrationID, class := range result.FailureClasses {
+		if strings.HasPrefix(message, migrationID+": ") {
+			return class
+		}
+	}
+	return backends.ClassifyMessage(message)
+}
+
+// failure describes why a job failed: its execution error, or the errors of its migrations
+func failure(result *executor.ExecuteResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if result != nil && len(result.Errors) > 0 {
+		return strings.Join(result.Errors, "; ")
+	}
+	return "migration job failed"
+}
+
+// sleep waits for d, or returns false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -2,31 +2,50 @@ package worker
 
 import (
 	"context"
+	"time"
 
 	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/features"
 	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/metrics"
 	"github.com/toolsascode/bfm/api/internal/queue"
 	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Worker processes migration jobs from the queue
 type Worker struct {
 	executor *executor.Executor
 	queue    queue.Queue
+	retry    RetryPolicy
 }
 
-// NewWorker creates a new migration worker
+// NewWorker creates a new migration worker, retrying jobs with DefaultRetryPolicy
 func NewWorker(exec *executor.Executor, q queue.Queue) *Worker {
 	return &Worker{
 		executor: exec,
 		queue:    q,
+		retry:    DefaultRetryPolicy,
 	}
 }
 
+// SetRetryPolicy sets how jobs that fail transiently are retried
+func (w *Worker) SetRetryPolicy(policy RetryPolicy) {
+	w.retry = policy
+}
+
 // Start starts the worker to consume and process jobs
 func (w *Worker) Start(ctx context.Context) error {
 	logger.Info("Starting migration worker...")
 
+	if reporter, ok := w.queue.(queue.DepthReporter); ok {
+		metrics.SetQueueDepth(reporter.Depth)
+	}
+
 	// Create job handler
 	handler := func(ctx context.Context, job *queue.Job) (*queue.JobResult, error) {
 		return w.processJob(ctx, job)
@@ -37,14 +56,51 @@ func (w *Worker) Start(ctx context.Context) error {
 }
 
 // processJob processes a single migration job
-func (w *Worker) processJob(ctx context.Context, job *queue.Job) (*queue.JobResult, error) {
+func (w *Worker) processJob(ctx context.Context, job *queue.Job) (_ *queue.JobResult, err error) {
 	logger.Infof("Processing migration job %s", job.ID)
+	w.executor.RecordJobStatus(ctx, job, state.JobPickedUp, nil, nil)
+
+	// Continue the trace of the request that queued the job, with its feature flags
+	ctx = features.Extract(ctx, job.Metadata)
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, job.Metadata), "worker.processJob",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("bfm.job.id", job.ID), attribute.String("bfm.connection", job.Connection)))
+	defer func() { tracing.End(span, err) }()
 
 	// Convert queue.MigrationTarget to registry.MigrationTarget
 	target := convertQueueTarget(job.Target)
+	ctx = executor.WithPinnedChecksums(ctx, job.PinnedChecksums)
+	if job.AllowDestructive {
+		ctx = executor.WithAllowDestructive(ctx)
+	}
 
-	// Execute migration (queue jobs don't support ignore_dependencies yet, use default false)
-	result, err := w.executor.ExecuteSync(ctx, target, job.Connection, job.Schema, job.DryRun, false)
+	// Execute migration (queue jobs don't support ignore_dependencies yet, use default false),
+	// retrying transient failures
+	var result *executor.ExecuteResult
+	for {
+		job.Attempts++
+		w.executor.RecordJobStatus(ctx, job, state.JobRunning, nil, nil)
+		result, err = w.executor.ExecuteSync(ctx, target, job.Connection, job.Schema, job.DryRun, false)
+		if err == nil && result.Success {
+			break
+		}
+		if job.Attempts >= w.retry.MaxAttempts || !isTransient(err, result) {
+			break
+		}
+		delay := w.retry.delay(job.Attempts)
+		logger.Warnf("Migration job %s failed transiently (attempt %d of %d), retrying in %s: %s",
+			job.ID, job.Attempts, w.retry.MaxAttempts, delay, failure(result, err))
+		w.executor.RecordJobStatus(ctx, job, state.JobRetrying, result, err)
+		if !sleep(ctx, delay) {
+			break
+		}
+	}
+	metrics.ObserveJob(err == nil && result.Success)
+	if err == nil && result.Success {
+		w.executor.RecordJobStatus(ctx, job, state.JobCompleted, result, nil)
+	} else {
+		w.deadLetter(ctx, job, result, err)
+	}
 	if err != nil {
 		return &queue.JobResult{
 			JobID:   job.ID,
@@ -63,6 +119,28 @@ func (w *Worker) processJob(ctx context.Context, job *queue.Job) (*queue.JobResu
 	}, nil
 }
 
+// deadLetter publishes a permanently failed job to the dead-letter topic, with its error, and
+// records it as dead-lettered; or as failed when the queue has no dead-letter topic or the job
+// could not be published to it
+func (w *Worker) deadLetter(ctx context.Context, job *queue.Job, result *executor.ExecuteResult, execErr error) {
+	publisher, ok := w.queue.(queue.DeadLetterPublisher)
+	if !ok {
+		w.executor.RecordJobStatus(ctx, job, state.JobFailed, result, execErr)
+		return
+	}
+
+	letter := &queue.DeadLetter{Job: job, Error: failure(result, execErr), FailedAt: time.Now()}
+	if result != nil {
+		letter.Errors = result.Errors
+	}
+	if err := publisher.PublishDeadLetter(ctx, letter); err != nil {
+		logger.Errorf("Failed to publish migration job %s to the dead-letter topic: %v", job.ID, err)
+		w.executor.RecordJobStatus(ctx, job, state.JobFailed, result, execErr)
+		return
+	}
+	w.executor.RecordJobStatus(ctx, job, state.JobDeadLettered, result, execErr)
+}
+
 // convertQueueTarget converts queue.MigrationTarget to registry.MigrationTarget
 func convertQueueTarget(target *queue.MigrationTarget) *registry.MigrationTarget {
 	if target == nil {
@@ -73,6 +151,7 @@ func convertQueueTarget(target *queue.MigrationTarget) *registry.MigrationTarget
 		Schema:     target.Schema,
 		Tables:     target.Tables,
 		Version:    target.Version,
+		MaxVersion: target.MaxVersion,
 		Connection: target.Connection,
 		Tags:       target.Tags,
 	}
@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/queue"
+	"github.com/toolsascode/bfm/api/internal/queue/memory"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/internal/state/sqlite"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+// newTestWorker returns a worker on a SQLite state database, with a migration on connection "core"
+// whose backend is not registered, so executing it always fails
+func newTestWorker(t *testing.T, q queue.Queue) (*Worker, *executor.Executor, *sqlite.Tracker) {
+	t.Helper()
+	tracker, err := sqlite.NewTracker(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	t.Cleanup(func() { _ = tracker.Close() })
+
+	reg := registry.NewInMemoryRegistry()
+	_ = reg.Register(&backends.MigrationScript{Version: "20250101000000", Name: "init", Connection: "core", Backend: "missing", UpSQL: "SELECT 1"})
+	exec := executor.NewExecutor(reg, tracker)
+	_ = exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "missing"}})
+
+	w := NewWorker(exec, q)
+	w.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	return w, exec, tracker
+}
+
+func TestWorker_PermanentFailureIsDeadLettered(t *testing.T) {
+	q := testsupport.NewQueue()
+	w, exec, _ := newTestWorker(t, q)
+	ctx := context.Background()
+	job := &queue.Job{ID: "job-1", Connection: "core", Target: &queue.MigrationTarget{Connection: "core"}}
+
+	if result, _ := w.processJob(ctx, job); result.Success {
+		t.Fatal("expected the job to fail")
+	}
+	if deadLetters := q.DeadLetters(); len(deadLetters) != 1 || deadLetters[0].Job.ID != "job-1" || deadLetters[0].Error == "" {
+		t.Fatalf("expected the job on the dead-letter topic with its error, got %+v", deadLetters)
+	}
+	record, err := exec.GetJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	// Not transient: no retry
+	if record.Status != state.JobDeadLettered || record.Attempts != 1 || record.FinishedAt == "" {
+		t.Errorf("unexpected job %+v", record)
+	}
+
+	// Replay publishes the job again under the same ID
+	exec.SetQueue(q)
+	replayed, err := exec.ReplayJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("ReplayJob() error = %v", err)
+	}
+	if replayed.Status != state.JobQueued || replayed.Attempts != 0 || replayed.FinishedAt != "" {
+		t.Errorf("unexpected replayed job %+v", replayed)
+	}
+	if published := q.Published(); len(published) != 1 || published[0].ID != "job-1" || published[0].Target.Connection != "core" {
+		t.Errorf("expected the job to be published again, got %+v", published)
+	}
+	if _, err := exec.ReplayJob(ctx, "job-1"); !errors.Is(err, executor.ErrJobNotReplayable) {
+		t.Errorf("ReplayJob() of a queued job error = %v, want ErrJobNotReplayable", err)
+	}
+}
+
+func TestWorker_TransientFailureIsRetried(t *testing.T) {
+	q := testsupport.NewQueue()
+	w, exec, tracker := newTestWorker(t, q)
+	ctx := context.Background()
+	job := &queue.Job{ID: "job-2", Connection: "core", Target: &queue.MigrationTarget{Connection: "core"}}
+
+	// Another run holds the connection lock for every attempt
+	err := tracker.WithConnectionLock(ctx, "core", "other-run", func() error {
+		_, err := w.processJob(ctx, job)
+		return err
+	})
+	if !errors.Is(err, state.ErrConnectionLocked) {
+		t.Fatalf("processJob() error = %v, want ErrConnectionLocked", err)
+	}
+	record, _ := exec.GetJob(ctx, "job-2")
+	if record.Status != state.JobDeadLettered || record.Attempts != 3 {
+		t.Errorf("expected 3 attempts before dead-lettering, got %+v", record)
+	}
+	if deadLetters := q.DeadLetters(); len(deadLetters) != 1 || deadLetters[0].Job.Attempts != 3 {
+		t.Errorf("unexpected dead letters %+v", deadLetters)
+	}
+}
+
+func TestWorker_FailedWhenDeadLetterUnavailable(t *testing.T) {
+	q := testsupport.NewQueue()
+	q.FailDeadLetter(errors.New("broker unavailable"))
+	w, exec, _ := newTestWorker(t, q)
+	ctx := context.Background()
+
+	_, _ = w.processJob(ctx, &queue.Job{ID: "job-3", Connection: "core", Target: &queue.MigrationTarget{Connection: "core"}})
+	if record, _ := exec.GetJob(ctx, "job-3"); record.Status != state.JobFailed {
+		t.Errorf("expected a failed job when the dead-letter topic is unavailable, got %+v", record)
+	}
+}
+
+func TestWorker_InProcessQueue(t *testing.T) {
+	q := memory.NewQueue(10)
+	w, exec, _ := newTestWorker(t, q)
+	exec.SetQueue(q)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() { _ = w.Start(ctx) }()
+
+	result, err := exec.Execute(ctx, &registry.MigrationTarget{Connection: "core"}, "core", "", false, false)
+	if err != nil || !result.Queued {
+		t.Fatalf("Execute() = %+v, %v; want a queued job", result, err)
+	}
+	if err := q.Wait(ctx); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	record, err := exec.GetJob(ctx, result.JobID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if record.Status != state.JobDeadLettered {
+		t.Errorf("expected the job to run and be dead-lettered, got %+v", record)
+	}
+	if deadLetters := q.DeadLetters(); len(deadLetters) != 1 || deadLetters[0].Job.ID != result.JobID {
+		t.Errorf("unexpected dead letters %+v", deadLetters)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		result *executor.ExecuteResult
+		want   bool
+	}{
+		{"connection locked", state.ErrConnectionLocked, nil, true},
+		{"connection refused", errors.New("dial tcp 10.0.0.1:5432: connect: connection refused"), nil, true},
+		{"lock timeout", nil, &executor.ExecuteResult{Errors: []string{"m1: ERROR: canceling statement due to lock timeout (SQLSTATE 55P03)"}}, true},
+		{"syntax error", nil, &executor.ExecuteResult{Errors: []string{"m1: ERROR: syntax error at or near \"CREAT\""}}, false},
+		{"mixed errors", nil, &executor.ExecuteResult{Errors: []string{"m1: connection reset by peer", "m2: relation \"users\" already exists"}}, false},
+		{"no error", nil, &executor.ExecuteResult{}, false},
+		// The class from the driver's error code wins over the message
+		{"classified connectivity", nil, &executor.ExecuteResult{
+			Errors:         []string{"m1: ERROR: the server is shutting down (SQLSTATE 57P01)"},
+			FailureClasses: map[string]backends.FailureClass{"m1": backends.FailureConnectivity},
+		}, true},
+		{"classified syntax", nil, &executor.ExecuteResult{
+			Errors:         []string{"m1: ERROR: relation \"lock timeout\" does not exist"},
+			FailureClasses: map[string]backends.FailureClass{"m1": backends.FailureSyntax},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err, tt.result); got != tt.want {
+				t.Errorf("isTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	var delays []string
+	for attempt := 1; attempt <= 4; attempt++ {
+		delays = append(delays, policy.delay(attempt).String())
+	}
+	if got := strings.Join(delays, " "); got != "1s 2s 4s 5s" {
+		t.Errorf("delays = %s, want 1s 2s 4s 5s", got)
+	}
+}
@@ -40,4 +40,7 @@
 //		}
 //		migrations.GlobalRegistry.Register(migration)
 //	}
+//
+// Migrations written in Go set UpFunc and DownFunc (see MigrationFunc) instead of UpSQL and
+// DownSQL; the function runs in a transaction on backends that support it (PostgreSQL).
 package migrations
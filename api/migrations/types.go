@@ -11,3 +11,8 @@ type MigrationScript = backends.MigrationScript
 // Dependency is a public alias for backends.Dependency that allows
 // migration files outside the bfm module to use this type when declaring dependencies.
 type Dependency = backends.Dependency
+
+// MigrationFunc is the up or down function of a code migration.
+// MigrationFunc is a public alias for backends.MigrationFunc that allows
+// migration files outside the bfm module to register migrations written in Go.
+type MigrationFunc = backends.MigrationFunc
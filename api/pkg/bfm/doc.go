@@ -0,0 +1,47 @@
+// Package bfm runs migrations inside another Go service, without the HTTP and gRPC server. A
+// Runner wraps the migration registry, the executor and a state tracker: the service registers
+// its migrations (from compiled .go files, SFM directories or directly), then calls Up at startup.
+// Migrations run exactly as they do through the server: dependencies, locks, drift detection and
+// history in the state database all apply, so an embedded runner and a bfm server can share a
+// state database.
+//
+// Example usage at service startup:
+//
+//	package main
+//
+//	import (
+//		"context"
+//		"log"
+//
+//		"github.com/toolsascode/bfm/api/pkg/bfm"
+//	)
+//
+//	func main() {
+//		ctx := context.Background()
+//		tracker, err := bfm.NewPostgresStateTracker("host=localhost user=bfm password=secret dbname=bfm_state sslmode=disable", "public")
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		defer tracker.Close()
+//
+//		runner, err := bfm.New(bfm.Config{
+//			StateTracker: tracker,
+//			SFMPaths:     []string{"./sfm"},
+//			Connections: map[string]*bfm.ConnectionConfig{
+//				"core": {Backend: "postgresql", Host: "localhost", Port: "5432", Username: "app", Password: "secret", Database: "app", Schema: "public"},
+//			},
+//		})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		defer runner.Close()
+//
+//		result, err := runner.Up(ctx, &bfm.Target{Connection: "core"})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		if !result.Success {
+//			log.Fatalf("migrations failed: %v", result.Errors)
+//		}
+//	}
+package bfm
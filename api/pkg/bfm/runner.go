@@ -0,0 +1,216 @@
+package bfm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/toolsascode/bfm/api/internal/backends/cassandra"
+	"github.com/toolsascode/bfm/api/internal/backends/consul"
+	"github.com/toolsascode/bfm/api/internal/backends/etcd"
+	"github.com/toolsascode/bfm/api/internal/backends/greptimedb"
+	"github.com/toolsascode/bfm/api/internal/backends/postgresql"
+	"github.com/toolsascode/bfm/api/internal/backends/spanner"
+	"github.com/toolsascode/bfm/api/internal/backends/vault"
+	"github.com/toolsascode/bfm/api/internal/config"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	statepg "github.com/toolsascode/bfm/api/internal/state/postgresql"
+	statesqlite "github.com/toolsascode/bfm/api/internal/state/sqlite"
+)
+
+// ExecutionMethod is the execution method recorded in the history of runs made by a Runner
+const ExecutionMethod = "embedded"
+
+// Config configures a Runner
+type Config struct {
+	// StateTracker records which migrations are applied (required). See NewPostgresStateTracker
+	// and NewSQLiteStateTracker; the caller closes it.
+	StateTracker StateTracker
+
+	// Connections the migrations run on, by name (required). See LoadConnections to read them from
+	// the environment like the server.
+	Connections map[string]*ConnectionConfig
+
+	// Registry holds the migrations. Defaults to the global registry, which compiled migration
+	// files register with through migrations.GlobalRegistry.
+	Registry Registry
+
+	// SFMPaths are SFM directories whose scripts are loaded into the registry by New. No .go files
+	// are generated in them.
+	SFMPaths []string
+
+	// Backends replace or add to the built-in backends, by name (e.g. "postgresql")
+	Backends map[string]Backend
+
+	// ExecutedBy is recorded in the history of runs (default "bfm-embedded")
+	ExecutedBy string
+}
+
+// Runner runs migrations in-process
+type Runner struct {
+	exec       *executor.Executor
+	backends   map[string]Backend
+	executedBy string
+}
+
+// New returns a runner for cfg, with the migrations of cfg.SFMPaths loaded
+func New(cfg Config) (*Runner, error) {
+	if cfg.StateTracker == nil {
+		return nil, errors.New("bfm: a state tracker is required")
+	}
+	if len(cfg.Connections) == 0 {
+		return nil, errors.New("bfm: at least one connection is required")
+	}
+	reg := cfg.Registry
+	if reg == nil {
+		reg = registry.GlobalRegistry
+	}
+
+	exec := executor.NewExecutor(reg, cfg.StateTracker)
+	if err := exec.SetConnections(cfg.Connections); err != nil {
+		return nil, fmt.Errorf("bfm: %w", err)
+	}
+
+	runner := &Runner{
+		exec: exec,
+		backends: map[string]Backend{
+			"postgresql": postgresql.NewBackend(),
+			"greptimedb": greptimedb.NewBackend(),
+			"etcd":       etcd.NewBackend(),
+			"cassandra":  cassandra.NewBackend(),
+			"consul":     consul.NewBackend(),
+			"vault":      vault.NewBackend(),
+			"spanner":    spanner.NewBackend(),
+		},
+		executedBy: cfg.ExecutedBy,
+	}
+	if runner.executedBy == "" {
+		runner.executedBy = "bfm-embedded"
+	}
+	for name, backend := range cfg.Backends {
+		runner.backends[name] = backend
+	}
+	for name, backend := range runner.backends {
+		exec.RegisterBackend(name, backend)
+	}
+
+	if len(cfg.SFMPaths) > 0 {
+		for _, sfmPath := range cfg.SFMPaths {
+			if _, err := os.Stat(sfmPath); err != nil {
+				return nil, fmt.Errorf("bfm: SFM directory %s: %w", sfmPath, err)
+			}
+		}
+		loader := executor.NewLoader(cfg.SFMPaths...)
+		loader.SetSource(executor.SourceScripts)
+		if err := loader.LoadAll(reg); err != nil {
+			return nil, fmt.Errorf("bfm: failed to load migrations: %w", err)
+		}
+	}
+
+	return runner, nil
+}
+
+// Register adds migrations to the runner's registry
+func (r *Runner) Register(migrations ...*MigrationScript) error {
+	for _, migration := range migrations {
+		if err := r.exec.GetRegistry().Register(migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Up applies the pending migrations matching target on target.Connection, once per schema given
+// (dynamic-schema migrations need one). A migration failing is reported in Result.Errors, with
+// Result.Success false; the error is for runs that could not start (unknown connection, lock held
+// by another run, drift).
+func (r *Runner) Up(ctx context.Context, target *Target, schemas ...string) (*Result, error) {
+	if target == nil || target.Connection == "" {
+		return nil, errors.New("bfm: the target connection is required")
+	}
+	return r.exec.ExecuteUp(r.context(ctx), target, target.Connection, schemas, false, false)
+}
+
+// Plan returns what Up would do for target, without running anything
+func (r *Runner) Plan(ctx context.Context, target *Target, schemas ...string) (*Plan, error) {
+	if target == nil || target.Connection == "" {
+		return nil, errors.New("bfm: the target connection is required")
+	}
+	return r.exec.Plan(r.context(ctx), target, target.Connection, schemas, false)
+}
+
+// Down reverts an applied migration by ID, once per schema given
+func (r *Runner) Down(ctx context.Context, migrationID string, schemas ...string) (*Result, error) {
+	return r.exec.ExecuteDown(r.context(ctx), migrationID, schemas, false, false)
+}
+
+// Rollback rolls back an applied migration by ID, once per schema given
+func (r *Runner) Rollback(ctx context.Context, migrationID string, schemas ...string) (*RollbackResult, error) {
+	return r.exec.Rollback(r.context(ctx), migrationID, schemas)
+}
+
+// Pending returns the migrations of connection not applied yet, in version order. Dynamic-schema
+// migrations are included only when schema is given.
+func (r *Runner) Pending(ctx context.Context, connection, schema string) ([]*PendingMigration, error) {
+	return r.exec.PendingMigrations(ctx, connection, schema)
+}
+
+// IsApplied reports whether the migration with the given ID is applied
+func (r *Runner) IsApplied(ctx context.Context, migrationID string) (bool, error) {
+	return r.exec.IsMigrationApplied(ctx, migrationID)
+}
+
+// Events returns the runner's event bus, to follow migrations being applied or failing
+func (r *Runner) Events() *EventBus {
+	return r.exec.Events()
+}
+
+// Close closes the connections of the runner's backends. The state tracker is left open.
+func (r *Runner) Close() error {
+	var errs []error
+	for name, backend := range r.backends {
+		if err := backend.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s backend: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// context records the runner as the executor of the runs made with ctx
+func (r *Runner) context(ctx context.Context) context.Context {
+	return executor.SetExecutionContext(ctx, r.executedBy, ExecutionMethod, nil)
+}
+
+// LoadConnections reads connections like the server: from the {CONNECTION}_BACKEND environment
+// variables and those of the same prefix and, when file is set, from that YAML or JSON file
+func LoadConnections(file string) (map[string]*ConnectionConfig, error) {
+	return config.LoadConnections(file)
+}
+
+// ClosableStateTracker is a state tracker holding a connection to its database
+type ClosableStateTracker interface {
+	StateTracker
+	Close() error
+}
+
+// NewPostgresStateTracker returns a state tracker storing migration state in the given schema of a
+// PostgreSQL database (connStr in key=value or URL form). Close it when done.
+func NewPostgresStateTracker(connStr, schema string) (ClosableStateTracker, error) {
+	tracker, err := statepg.NewTracker(connStr, schema)
+	if err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+// NewSQLiteStateTracker returns a state tracker storing migration state in a SQLite file. Close
+// it when done.
+func NewSQLiteStateTracker(path string) (ClosableStateTracker, error) {
+	tracker, err := statesqlite.NewTracker(path)
+	if err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
@@ -0,0 +1,127 @@
+package bfm_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/pkg/bfm"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+const usersID = "20240101120000_create_users_postgresql_core"
+
+func newTestRunner(t *testing.T, cfg bfm.Config) (*bfm.Runner, *testsupport.StateTracker, *testsupport.Backend) {
+	t.Helper()
+	tracker := testsupport.NewStateTracker()
+	backend := testsupport.NewBackend("postgresql")
+	cfg.StateTracker = tracker
+	cfg.Connections = map[string]*bfm.ConnectionConfig{"core": {Backend: "postgresql"}}
+	cfg.Backends = map[string]bfm.Backend{"postgresql": backend}
+	if cfg.Registry == nil {
+		cfg.Registry = registry.NewInMemoryRegistry()
+	}
+	runner, err := bfm.New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = runner.Close() })
+	return runner, tracker, backend
+}
+
+func TestNew_RequiresStateTrackerAndConnections(t *testing.T) {
+	if _, err := bfm.New(bfm.Config{Connections: map[string]*bfm.ConnectionConfig{"core": {Backend: "postgresql"}}}); err == nil {
+		t.Error("New() without a state tracker: want error")
+	}
+	if _, err := bfm.New(bfm.Config{StateTracker: testsupport.NewStateTracker()}); err == nil {
+		t.Error("New() without connections: want error")
+	}
+	_, err := bfm.New(bfm.Config{
+		StateTracker: testsupport.NewStateTracker(),
+		Connections:  map[string]*bfm.ConnectionConfig{"core": {Backend: "postgresql"}},
+		Registry:     registry.NewInMemoryRegistry(),
+		SFMPaths:     []string{filepath.Join(t.TempDir(), "missing")},
+	})
+	if err == nil {
+		t.Error("New() with a missing SFM directory: want error")
+	}
+}
+
+func TestRunner_UpAndDown(t *testing.T) {
+	ctx := context.Background()
+	runner, tracker, backend := newTestRunner(t, bfm.Config{ExecutedBy: "orders-service"})
+	err := runner.Register(
+		&bfm.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE users (id INT)", DownSQL: "DROP TABLE users"},
+		&bfm.MigrationScript{Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE orders (id INT)", DownSQL: "DROP TABLE orders"},
+	)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, err := runner.Up(ctx, &bfm.Target{}); err == nil {
+		t.Error("Up() without a connection: want error")
+	}
+
+	plan, err := runner.Plan(ctx, &bfm.Target{Connection: "core"}, "app")
+	if err != nil || len(plan.Apply) != 2 {
+		t.Fatalf("Plan() = %+v, %v, want 2 migrations to apply", plan, err)
+	}
+	if len(backend.Executed()) != 0 {
+		t.Fatal("Plan() executed migrations")
+	}
+
+	result, err := runner.Up(ctx, &bfm.Target{Connection: "core"}, "app")
+	if err != nil || !result.Success || len(result.Applied) != 2 {
+		t.Fatalf("Up() = %+v, %v, want 2 migrations applied", result, err)
+	}
+	if pending, err := runner.Pending(ctx, "core", "app"); err != nil || len(pending) != 0 {
+		t.Errorf("Pending() = %+v, %v, want none", pending, err)
+	}
+	history, _, _ := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{Schema: "app"})
+	if len(history) == 0 || history[0].ExecutedBy != "orders-service" || history[0].ExecutionMethod != bfm.ExecutionMethod {
+		t.Errorf("history = %+v, want runs recorded as made by orders-service, embedded", history)
+	}
+
+	if _, err := runner.Down(ctx, "20240102120000_create_orders_postgresql_core", "app"); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if applied, _ := runner.IsApplied(ctx, "app_20240102120000_create_orders_postgresql_core"); applied {
+		t.Error("create_orders still applied after Down()")
+	}
+	if applied, _ := runner.IsApplied(ctx, "app_"+usersID); !applied {
+		t.Error("create_users no longer applied after Down() of create_orders")
+	}
+}
+
+func TestRunner_LoadsSFMPaths(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "postgresql", "core")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"20240101120000_create_users.up.sql":   "CREATE TABLE users (id INT);",
+		"20240101120000_create_users.down.sql": "DROP TABLE users;",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner, _, backend := newTestRunner(t, bfm.Config{SFMPaths: []string{root}})
+	if _, err := os.Stat(filepath.Join(dir, "20240101120000_create_users.go")); !os.IsNotExist(err) {
+		t.Errorf("New() generated a .go file in the SFM directory (stat error %v)", err)
+	}
+
+	result, err := runner.Up(ctx, &bfm.Target{Connection: "core"}, "app")
+	if err != nil || !result.Success || len(result.Applied) != 1 {
+		t.Fatalf("Up() = %+v, %v, want create_users applied", result, err)
+	}
+	if executed := backend.Executed(); len(executed) != 1 || executed[0].Name != "create_users" {
+		t.Errorf("Executed() = %+v", executed)
+	}
+}
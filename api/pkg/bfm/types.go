@@ -0,0 +1,55 @@
+package bfm
+
+import (
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/events"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Public aliases of the types a Runner takes and returns, so services outside the bfm module can
+// build and inspect them.
+type (
+	// MigrationScript is a migration registered with a Runner
+	MigrationScript = backends.MigrationScript
+
+	// Dependency is a structured dependency of a migration on another
+	Dependency = backends.Dependency
+
+	// MigrationFunc is the up or down function of a migration written in Go
+	MigrationFunc = backends.MigrationFunc
+
+	// ConnectionConfig is the configuration of a connection migrations run on
+	ConnectionConfig = backends.ConnectionConfig
+
+	// Backend runs migrations on a database engine
+	Backend = backends.Backend
+
+	// Target selects the migrations of an up run
+	Target = registry.MigrationTarget
+
+	// Registry holds the migrations a Runner knows about
+	Registry = registry.Registry
+
+	// StateTracker records which migrations are applied
+	StateTracker = state.StateTracker
+
+	// Result is the outcome of an up or down run
+	Result = executor.ExecuteResult
+
+	// RollbackResult is the outcome of a rollback
+	RollbackResult = executor.RollbackResult
+
+	// Plan is the execution plan of an up run
+	Plan = executor.ExecutionPlan
+
+	// PendingMigration is a registered migration that is not applied yet
+	PendingMigration = executor.PendingMigration
+
+	// Event is a lifecycle event of a run, such as a migration being applied or failing
+	Event = events.Event
+
+	// EventBus publishes the events of a Runner
+	EventBus = events.Bus
+)
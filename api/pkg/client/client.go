@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Client calls the bfm API. NewHTTP and NewGRPC return one for the REST and the gRPC API; both
+// authenticate with the token of WithToken and retry calls the server could not take.
+type Client interface {
+	// MigrateUp applies the pending migrations of a connection
+	MigrateUp(ctx context.Context, req *MigrateUpRequest) (*MigrateResponse, error)
+	// MigrateDown reverts an applied migration
+	MigrateDown(ctx context.Context, req *MigrateDownRequest) (*MigrateResponse, error)
+	// List returns a page of the migration list
+	List(ctx context.Context, opts *ListOptions) (*MigrationList, error)
+	// Status returns the current status of a migration
+	Status(ctx context.Context, migrationID string) (*MigrationStatus, error)
+	// History returns a page of the execution history
+	History(ctx context.Context, opts *HistoryOptions) (*History, error)
+	// Rollback rolls back an applied migration, in the given schemas of a dynamic-schema migration
+	Rollback(ctx context.Context, migrationID string, schemas ...string) (*RollbackResponse, error)
+	// Reindex synchronizes the state database with the server's migration files
+	Reindex(ctx context.Context) (*ReindexResponse, error)
+	// Close releases the client's connections
+	Close() error
+}
+
+// Error is an error response of the API
+type Error struct {
+	// StatusCode is the HTTP status of the response; for gRPC calls, the HTTP status equivalent
+	// to the call's status code (e.g. 404 for NotFound)
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bfm API error %d: %s", e.StatusCode, e.Message)
+}
+
+// retryable reports whether the server could not take the call and it may be sent again: the
+// server or a proxy in front of it is unavailable, overloaded or in standby
+func (e *Error) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Option configures a client
+type Option func(*options)
+
+type options struct {
+	token       string
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	dialOptions []grpc.DialOption
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		httpClient:  &http.Client{Timeout: 5 * time.Minute},
+		maxAttempts: 3,
+		backoff:     500 * time.Millisecond,
+		maxBackoff:  10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithToken authenticates calls with an API token or OIDC JWT, sent as a bearer token
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithHTTPClient sets the HTTP client of an HTTP client (default: 5 minute timeout, since up
+// requests run the migrations before responding)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithRetry makes up to maxAttempts attempts at calls that fail because the server is
+// unreachable, unavailable or overloaded, waiting backoff before the second attempt and twice as
+// long before each next one, up to 10 seconds (default: 3 attempts, 500ms). 1 disables retries.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.maxAttempts = max(maxAttempts, 1)
+		o.backoff = backoff
+	}
+}
+
+// retry calls call until it succeeds, fails with an error retryable does not accept, or the
+// attempts are used up
+func (o *options) retry(ctx context.Context, retryable func(error) bool, call func() error) error {
+	backoff := o.backoff
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= o.maxAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, o.maxBackoff)
+	}
+}
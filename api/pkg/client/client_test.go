@@ -0,0 +1,182 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/toolsascode/bfm/api/internal/api/protobuf"
+	"github.com/toolsascode/bfm/api/pkg/client"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHTTPClient_MigrateUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/migrations/up" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req client.MigrateUpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Connection != "core" || len(req.Schemas) != 1 {
+			t.Errorf("request body = %+v, %v", req, err)
+		}
+		// The first attempt finds the server in standby
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"server is in standby"}`))
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte(`{"success":false,"applied":["a"],"skipped":[],"errors":["b: boom"]}`))
+	}))
+	defer server.Close()
+
+	c, err := client.NewHTTP(server.URL, client.WithToken("secret"), client.WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewHTTP() error = %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	result, err := c.MigrateUp(context.Background(), &client.MigrateUpRequest{Connection: "core", Schemas: []string{"tenant_a"}})
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if result.Success || len(result.Applied) != 1 || len(result.Errors) != 1 {
+		t.Errorf("MigrateUp() = %+v, want a partial result", result)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want the unavailable call retried once", calls.Load())
+	}
+}
+
+func TestHTTPClient_Errors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/api/v1/migrations/missing/status" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"migration not found"}`))
+	}))
+	defer server.Close()
+
+	c, _ := client.NewHTTP(server.URL+"/api/v1/", client.WithRetry(3, time.Millisecond))
+	_, err := c.Status(context.Background(), "missing")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "migration not found" {
+		t.Fatalf("Status() error = %v, want a 404 *client.Error", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no retry of a 404", calls.Load())
+	}
+
+	if _, err := client.NewHTTP("bfm:7070"); err == nil {
+		t.Error("NewHTTP() without a scheme: want error")
+	}
+}
+
+func TestHTTPClient_History(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/v1/migrations/m1/history" || query.Get("executed_by") != "deployer" || query.Get("limit") != "10" || query.Get("applied_after") != "2025-01-01T00:00:00Z" {
+			t.Errorf("request = %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"migration_id":"m1","history":[{"migration_id":"m1","status":"applied","operation":"up"}],"total":1,"limit":10,"offset":0}`))
+	}))
+	defer server.Close()
+
+	c, _ := client.NewHTTP(server.URL)
+	history, err := c.History(context.Background(), &client.HistoryOptions{
+		MigrationID:  "m1",
+		ExecutedBy:   "deployer",
+		AppliedAfter: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:        10,
+	})
+	if err != nil || history.Total != 1 || len(history.Records) != 1 || history.Records[0].Operation != "up" {
+		t.Fatalf("History() = %+v, %v", history, err)
+	}
+}
+
+// migrationServer is a gRPC MigrationService answering Migrate and GetMigrationStatus
+type migrationServer struct {
+	pb.UnimplementedMigrationServiceServer
+	calls atomic.Int32
+}
+
+func (s *migrationServer) Migrate(ctx context.Context, req *pb.MigrateRequest) (*pb.MigrateResponse, error) {
+	if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("authorization")) == 0 || md.Get("authorization")[0] != "Bearer secret" {
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+	if s.calls.Add(1) == 1 {
+		return nil, status.Error(codes.Unavailable, "server is in standby")
+	}
+	return &pb.MigrateResponse{Success: true, Applied: []string{req.SchemaName + "_m1"}, Skipped: []string{}, Errors: []string{}}, nil
+}
+
+func (s *migrationServer) GetMigrationStatus(ctx context.Context, req *pb.GetMigrationStatusRequest) (*pb.MigrationStatusResponse, error) {
+	return nil, status.Errorf(codes.NotFound, "migration not found: %s", req.MigrationId)
+}
+
+func newGRPCClient(t *testing.T, server *migrationServer) *client.GRPCClient {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterMigrationServiceServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := client.NewGRPC("passthrough:///bufnet",
+		client.WithToken("secret"),
+		client.WithRetry(3, time.Millisecond),
+		client.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		})),
+	)
+	if err != nil {
+		t.Fatalf("NewGRPC() error = %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestGRPCClient_MigrateUp(t *testing.T) {
+	server := &migrationServer{}
+	c := newGRPCClient(t, server)
+
+	result, err := c.MigrateUp(context.Background(), &client.MigrateUpRequest{Connection: "core", Schemas: []string{"tenant_a", "tenant_b"}})
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if !result.Success || len(result.Applied) != 2 || len(result.Schemas) != 2 || result.Schemas[1].Applied[0] != "tenant_b_m1" {
+		t.Errorf("MigrateUp() = %+v, want a result per schema", result)
+	}
+	// One call per schema, plus the retry of the first
+	if server.calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", server.calls.Load())
+	}
+}
+
+func TestGRPCClient_Errors(t *testing.T) {
+	c := newGRPCClient(t, &migrationServer{})
+
+	_, err := c.Status(context.Background(), "missing")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Status() error = %v, want a 404 *client.Error", err)
+	}
+}
@@ -0,0 +1,37 @@
+// Package client is a typed client of the bfm API, for deployment tooling and services that drive
+// a bfm server. NewHTTP calls the REST API and NewGRPC the gRPC API; both implement Client, send
+// the token of WithToken and retry calls while the server is unreachable, unavailable (e.g. in
+// standby) or overloaded.
+//
+// Example usage in a deployment step:
+//
+//	package main
+//
+//	import (
+//		"context"
+//		"log"
+//		"os"
+//
+//		"github.com/toolsascode/bfm/api/pkg/client"
+//	)
+//
+//	func main() {
+//		ctx := context.Background()
+//		c, err := client.NewHTTP("http://bfm:7070", client.WithToken(os.Getenv("BFM_API_TOKEN")))
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		defer c.Close()
+//
+//		result, err := c.MigrateUp(ctx, &client.MigrateUpRequest{
+//			Target:     &client.Target{Backend: "postgresql"},
+//			Connection: "core",
+//		})
+//		if err != nil {
+//			log.Fatal(err) // *client.Error for error responses, e.g. 409 when a plan changed
+//		}
+//		if !result.Success {
+//			log.Fatalf("migrations failed: %v", result.Errors)
+//		}
+//	}
+package client
@@ -0,0 +1,350 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	pb "github.com/toolsascode/bfm/api/internal/api/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCClient calls the gRPC API
+type GRPCClient struct {
+	conn    *grpc.ClientConn
+	service pb.MigrationServiceClient
+	opts    *options
+}
+
+var _ Client = (*GRPCClient)(nil)
+
+// WithDialOptions sets the dial options of a gRPC client, e.g. transport credentials for TLS
+// (default: plaintext)
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, dialOptions...)
+	}
+}
+
+// NewGRPC returns a client of the gRPC API of the server at target (e.g. bfm:9090)
+func NewGRPC(target string, opts ...Option) (*GRPCClient, error) {
+	o := newOptions(opts)
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, o.dialOptions...)
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCClient{conn: conn, service: pb.NewMigrationServiceClient(conn), opts: o}, nil
+}
+
+// MigrateUp applies the pending migrations of a connection. The gRPC API runs one schema per
+// call, so each schema of req.Schemas is a call of its own, reported in MigrateResponse.Schemas.
+func (c *GRPCClient) MigrateUp(ctx context.Context, req *MigrateUpRequest) (*MigrateResponse, error) {
+	target := &pb.MigrationTarget{}
+	if req.Target != nil {
+		target = &pb.MigrationTarget{
+			Backend:    req.Target.Backend,
+			Schema:     req.Target.Schema,
+			Tables:     req.Target.Tables,
+			Version:    req.Target.Version,
+			MaxVersion: req.Target.MaxVersion,
+			Connection: req.Target.Connection,
+			Tags:       req.Target.Tags,
+		}
+	}
+	migrate := func(schema string) (*pb.MigrateResponse, error) {
+		var response *pb.MigrateResponse
+		err := c.call(ctx, func(ctx context.Context) (err error) {
+			response, err = c.service.Migrate(ctx, &pb.MigrateRequest{
+				Target:             target,
+				Connection:         req.Connection,
+				SchemaName:         schema,
+				DryRun:             req.DryRun,
+				IgnoreDependencies: req.IgnoreDependencies,
+				CaptureSql:         req.CaptureSQL,
+				PinnedChecksums:    req.PinnedChecksums,
+				AllowDestructive:   req.AllowDestructive,
+			})
+			return err
+		})
+		return response, err
+	}
+
+	if len(req.Schemas) == 0 {
+		response, err := migrate("")
+		if err != nil {
+			return nil, err
+		}
+		return migrateResponse(response), nil
+	}
+
+	result := &MigrateResponse{Success: true, Applied: []string{}, Skipped: []string{}, Errors: []string{}}
+	for _, schema := range req.Schemas {
+		response, err := migrate(schema)
+		if err != nil {
+			return nil, err
+		}
+		schemaResult := migrateResponse(response)
+		result.Success = result.Success && schemaResult.Success
+		result.Applied = append(result.Applied, schemaResult.Applied...)
+		result.Skipped = append(result.Skipped, schemaResult.Skipped...)
+		result.Errors = append(result.Errors, schemaResult.Errors...)
+		result.ExecutedSQL = append(result.ExecutedSQL, schemaResult.ExecutedSQL...)
+		result.Schemas = append(result.Schemas, SchemaResult{
+			Schema:  schema,
+			Success: schemaResult.Success,
+			Applied: schemaResult.Applied,
+			Skipped: schemaResult.Skipped,
+			Errors:  schemaResult.Errors,
+		})
+	}
+	return result, nil
+}
+
+func (c *GRPCClient) MigrateDown(ctx context.Context, req *MigrateDownRequest) (*MigrateResponse, error) {
+	var response *pb.MigrateResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.MigrateDown(ctx, &pb.MigrateDownRequest{
+			MigrationId:        req.MigrationID,
+			Schemas:            req.Schemas,
+			DryRun:             req.DryRun,
+			IgnoreDependencies: req.IgnoreDependencies,
+			CaptureSql:         req.CaptureSQL,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return migrateResponse(response), nil
+}
+
+func (c *GRPCClient) List(ctx context.Context, opts *ListOptions) (*MigrationList, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	var response *pb.ListMigrationsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.ListMigrations(ctx, &pb.ListMigrationsRequest{
+			Schema:     opts.Schema,
+			Table:      opts.Table,
+			Connection: opts.Connection,
+			Backend:    opts.Backend,
+			Status:     opts.Status,
+			Version:    opts.Version,
+			Label:      opts.Label,
+			Limit:      int32(opts.Limit),
+			Offset:     int32(opts.Offset),
+			SortBy:     opts.SortBy,
+			SortOrder:  opts.SortOrder,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := &MigrationList{
+		Items:  make([]Migration, 0, len(response.Items)),
+		Total:  int(response.Total),
+		Limit:  int(response.Limit),
+		Offset: int(response.Offset),
+	}
+	for _, item := range response.Items {
+		list.Items = append(list.Items, Migration{
+			MigrationID:  item.MigrationId,
+			Schema:       item.Schema,
+			Table:        item.Table,
+			Version:      item.Version,
+			Name:         item.Name,
+			Connection:   item.Connection,
+			Backend:      item.Backend,
+			Applied:      item.Applied,
+			Status:       item.Status,
+			AppliedAt:    item.AppliedAt,
+			ErrorMessage: item.ErrorMessage,
+			Tags:         item.Tags,
+			StatusLabels: item.StatusLabels,
+			Destructive:  item.Destructive,
+		})
+	}
+	return list, nil
+}
+
+func (c *GRPCClient) Status(ctx context.Context, migrationID string) (*MigrationStatus, error) {
+	var response *pb.MigrationStatusResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.GetMigrationStatus(ctx, &pb.GetMigrationStatusRequest{MigrationId: migrationID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationStatus{
+		MigrationID:  response.MigrationId,
+		Status:       response.Status,
+		Applied:      response.Applied,
+		AppliedAt:    response.AppliedAt,
+		ErrorMessage: response.ErrorMessage,
+	}, nil
+}
+
+func (c *GRPCClient) History(ctx context.Context, opts *HistoryOptions) (*History, error) {
+	if opts == nil {
+		opts = &HistoryOptions{}
+	}
+	req := &pb.GetMigrationHistoryRequest{
+		MigrationId:     opts.MigrationID,
+		Limit:           int32(opts.Limit),
+		Offset:          int32(opts.Offset),
+		SortBy:          opts.SortBy,
+		SortOrder:       opts.SortOrder,
+		ExecutedBy:      opts.ExecutedBy,
+		ExecutionMethod: opts.ExecutionMethod,
+	}
+	if !opts.AppliedAfter.IsZero() {
+		req.AppliedAfter = opts.AppliedAfter.Format(time.RFC3339)
+	}
+	if !opts.AppliedBefore.IsZero() {
+		req.AppliedBefore = opts.AppliedBefore.Format(time.RFC3339)
+	}
+	var response *pb.MigrationHistoryResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.GetMigrationHistory(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := &History{
+		MigrationID: response.MigrationId,
+		Records:     make([]HistoryRecord, 0, len(response.History)),
+		Total:       int(response.Total),
+		Limit:       int(response.Limit),
+		Offset:      int(response.Offset),
+	}
+	for _, record := range response.History {
+		history.Records = append(history.Records, HistoryRecord{
+			MigrationID:      record.MigrationId,
+			Schema:           record.Schema,
+			Table:            record.Table,
+			Version:          record.Version,
+			Connection:       record.Connection,
+			Backend:          record.Backend,
+			AppliedAt:        record.AppliedAt,
+			Status:           record.Status,
+			Operation:        record.Operation,
+			ErrorMessage:     record.ErrorMessage,
+			ExecutedBy:       record.ExecutedBy,
+			ExecutionMethod:  record.ExecutionMethod,
+			ExecutionContext: record.ExecutionContext,
+		})
+	}
+	return history, nil
+}
+
+func (c *GRPCClient) Rollback(ctx context.Context, migrationID string, schemas ...string) (*RollbackResponse, error) {
+	var response *pb.RollbackResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.RollbackMigration(ctx, &pb.RollbackMigrationRequest{MigrationId: migrationID, Schemas: schemas})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RollbackResponse{Success: response.Success, Message: response.Message, Errors: response.Errors}, nil
+}
+
+func (c *GRPCClient) Reindex(ctx context.Context) (*ReindexResponse, error) {
+	var response *pb.ReindexResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		response, err = c.service.ReindexMigrations(ctx, &pb.ReindexMigrationsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ReindexResponse{
+		Added:   response.Added,
+		Removed: response.Removed,
+		Updated: response.Updated,
+		Total:   int(response.Total),
+	}, nil
+}
+
+// Close closes the connection to the server
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+// call makes a call with the token in its metadata, retrying it while the server is unavailable.
+// Status errors are returned as an *Error.
+func (c *GRPCClient) call(ctx context.Context, call func(ctx context.Context) error) error {
+	if c.opts.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.opts.token)
+	}
+	return c.opts.retry(ctx, func(err error) bool {
+		var apiErr *Error
+		return errors.As(err, &apiErr) && apiErr.retryable()
+	}, func() error {
+		err := call(ctx)
+		if s, ok := status.FromError(err); ok && err != nil {
+			return &Error{StatusCode: httpStatus(s.Code()), Message: s.Message()}
+		}
+		return err
+	})
+}
+
+// httpStatus returns the HTTP status equivalent to a gRPC status code
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return 499 // Client closed request
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// migrateResponse converts the response of an up or down call
+func migrateResponse(response *pb.MigrateResponse) *MigrateResponse {
+	result := &MigrateResponse{
+		Success: response.Success,
+		Applied: append([]string{}, response.Applied...),
+		Skipped: append([]string{}, response.Skipped...),
+		Errors:  append([]string{}, response.Errors...),
+	}
+	for _, executed := range response.ExecutedSql {
+		result.ExecutedSQL = append(result.ExecutedSQL, ExecutedSQL{
+			MigrationID: executed.MigrationId,
+			SQL:         executed.Sql,
+			Truncated:   executed.Truncated,
+			Suppressed:  executed.Suppressed,
+		})
+	}
+	return result
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPClient calls the REST API
+type HTTPClient struct {
+	baseURL string // Including /api/v1
+	opts    *options
+}
+
+var _ Client = (*HTTPClient)(nil)
+
+// NewHTTP returns a client of the REST API of the server at baseURL (e.g. http://bfm:7070)
+func NewHTTP(baseURL string, opts ...Option) (*HTTPClient, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid bfm server URL %q", baseURL)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/api/v1") {
+		baseURL += "/api/v1"
+	}
+	return &HTTPClient{baseURL: baseURL, opts: newOptions(opts)}, nil
+}
+
+func (c *HTTPClient) MigrateUp(ctx context.Context, req *MigrateUpRequest) (*MigrateResponse, error) {
+	var response MigrateResponse
+	if err := c.do(ctx, http.MethodPost, "/migrations/up", nil, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) MigrateDown(ctx context.Context, req *MigrateDownRequest) (*MigrateResponse, error) {
+	var response MigrateResponse
+	if err := c.do(ctx, http.MethodPost, "/migrations/down", nil, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) List(ctx context.Context, opts *ListOptions) (*MigrationList, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	query := url.Values{}
+	setQuery(query, "schema", opts.Schema)
+	setQuery(query, "table", opts.Table)
+	setQuery(query, "connection", opts.Connection)
+	setQuery(query, "backend", opts.Backend)
+	setQuery(query, "status", opts.Status)
+	setQuery(query, "version", opts.Version)
+	setQuery(query, "label", opts.Label)
+	setPageQuery(query, opts.Limit, opts.Offset, opts.SortBy, opts.SortOrder)
+
+	var response MigrationList
+	if err := c.do(ctx, http.MethodGet, "/migrations", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Status(ctx context.Context, migrationID string) (*MigrationStatus, error) {
+	var response MigrationStatus
+	if err := c.do(ctx, http.MethodGet, "/migrations/"+url.PathEscape(migrationID)+"/status", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) History(ctx context.Context, opts *HistoryOptions) (*History, error) {
+	if opts == nil {
+		opts = &HistoryOptions{}
+	}
+	query := url.Values{}
+	if !opts.AppliedAfter.IsZero() {
+		query.Set("applied_after", opts.AppliedAfter.Format(time.RFC3339))
+	}
+	if !opts.AppliedBefore.IsZero() {
+		query.Set("applied_before", opts.AppliedBefore.Format(time.RFC3339))
+	}
+	setQuery(query, "executed_by", opts.ExecutedBy)
+	setQuery(query, "execution_method", opts.ExecutionMethod)
+	setPageQuery(query, opts.Limit, opts.Offset, opts.SortBy, opts.SortOrder)
+
+	path := "/migrations/history"
+	if opts.MigrationID != "" {
+		path = "/migrations/" + url.PathEscape(opts.MigrationID) + "/history"
+	}
+	var response History
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Rollback(ctx context.Context, migrationID string, schemas ...string) (*RollbackResponse, error) {
+	body := struct {
+		Schemas []string `json:"schemas,omitempty"`
+	}{Schemas: schemas}
+	var response RollbackResponse
+	if err := c.do(ctx, http.MethodPost, "/migrations/"+url.PathEscape(migrationID)+"/rollback", nil, body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Reindex(ctx context.Context) (*ReindexResponse, error) {
+	var response ReindexResponse
+	if err := c.do(ctx, http.MethodPost, "/migrations/reindex", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Close closes the idle connections of the HTTP client
+func (c *HTTPClient) Close() error {
+	c.opts.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// do sends a request to path (relative to /api/v1) and decodes the JSON response into response.
+// Responses with a status of 300 or more are returned as an *Error.
+func (c *HTTPClient) do(ctx context.Context, method, path string, query url.Values, body, response interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	requestURL := c.baseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	return c.opts.retry(ctx, httpRetryable, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.opts.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.opts.token)
+		}
+
+		resp, err := c.opts.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		// 206 Partial Content reports migrations that failed, in a regular response
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			var errorBody struct {
+				Error string `json:"error"`
+			}
+			message := strings.TrimSpace(string(data))
+			if json.Unmarshal(data, &errorBody) == nil && errorBody.Error != "" {
+				message = errorBody.Error
+			}
+			if message == "" {
+				message = http.StatusText(resp.StatusCode)
+			}
+			return &Error{StatusCode: resp.StatusCode, Message: message}
+		}
+		if err := json.Unmarshal(data, response); err != nil {
+			return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+		return nil
+	})
+}
+
+// httpRetryable reports whether a failed request may be sent again: the server responded that it
+// could not take it, or could not be reached
+func httpRetryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.retryable()
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setPageQuery(query url.Values, limit, offset int, sortBy, sortOrder string) {
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	setQuery(query, "sort_by", sortBy)
+	setQuery(query, "sort_order", sortOrder)
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// Target selects the migrations of an up request
+type Target = registry.MigrationTarget
+
+// MigrateUpRequest is a request to apply the pending migrations of a connection
+type MigrateUpRequest struct {
+	Target             *Target  `json:"target"`
+	Connection         string   `json:"connection"` // Required
+	Schemas            []string `json:"schemas"`    // Schemas of dynamic-schema migrations
+	DryRun             bool     `json:"dry_run"`
+	IgnoreDependencies bool     `json:"ignore_dependencies"`
+	CaptureSQL         bool     `json:"capture_sql"` // Return the rendered SQL of each migration
+	// Checksums of an approved plan; the request is refused if a migration it would apply is
+	// missing from them or was modified since
+	PinnedChecksums map[string]string `json:"pinned_checksums,omitempty"`
+	// Force destructive migrations on connections whose destructive policy is "force"
+	AllowDestructive bool `json:"allow_destructive,omitempty"`
+}
+
+// MigrateDownRequest is a request to revert an applied migration
+type MigrateDownRequest struct {
+	MigrationID        string   `json:"migration_id"` // Required
+	Schemas            []string `json:"schemas"`
+	DryRun             bool     `json:"dry_run"`
+	IgnoreDependencies bool     `json:"ignore_dependencies"`
+	CaptureSQL         bool     `json:"capture_sql"`
+}
+
+// MigrateResponse is the outcome of an up or down request. A migration failing is reported in
+// Errors, with Success false, not as an error of the call.
+type MigrateResponse struct {
+	Success     bool           `json:"success"`
+	Applied     []string       `json:"applied"`
+	Skipped     []string       `json:"skipped"`
+	Errors      []string       `json:"errors"`
+	ExecutedSQL []ExecutedSQL  `json:"executed_sql,omitempty"` // Only when capture_sql was requested
+	Schemas     []SchemaResult `json:"schemas,omitempty"`      // Per-schema results, when schemas were given
+}
+
+// ExecutedSQL is the rendered script of a migration (or the script a dry run would execute)
+type ExecutedSQL struct {
+	MigrationID string `json:"migration_id"`
+	SQL         string `json:"sql,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`  // SQL was cut at 64 KiB
+	Suppressed  bool   `json:"suppressed,omitempty"` // The connection's SQL_LOG is "none", so SQL is omitted
+}
+
+// SchemaResult is the outcome of an up request in one schema
+type SchemaResult struct {
+	Schema  string   `json:"schema"`
+	Success bool     `json:"success"`
+	Applied []string `json:"applied"`
+	Skipped []string `json:"skipped"`
+	Errors  []string `json:"errors"`
+}
+
+// ListOptions filters and pages the migration list; zero values don't filter
+type ListOptions struct {
+	Schema     string
+	Table      string
+	Connection string
+	Backend    string
+	Status     string // applied, pending, failed, rolled_back
+	Version    string
+	Label      string // User-defined status label
+	Limit      int    // 0 returns every match
+	Offset     int
+	SortBy     string // migration_id, schema, version, connection, backend, status or applied_at
+	SortOrder  string // asc or desc
+}
+
+// MigrationList is a page of the migration list
+type MigrationList struct {
+	Items  []Migration `json:"items"`
+	Total  int         `json:"total"` // Number of migrations matching the filters
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// Migration is a migration in the migration list
+type Migration struct {
+	MigrationID  string   `json:"migration_id"`
+	Schema       string   `json:"schema"`
+	Table        string   `json:"table"`
+	Version      string   `json:"version"`
+	Name         string   `json:"name"`
+	Connection   string   `json:"connection"`
+	Backend      string   `json:"backend"`
+	Applied      bool     `json:"applied"`
+	Status       string   `json:"status"`
+	AppliedAt    string   `json:"applied_at,omitempty"`
+	ErrorMessage string   `json:"error_message,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	StatusLabels []string `json:"status_labels,omitempty"`
+	Destructive  []string `json:"destructive,omitempty"`
+}
+
+// MigrationStatus is the current status of a migration
+type MigrationStatus struct {
+	MigrationID  string `json:"migration_id"`
+	Status       string `json:"status"` // pending, applied, rolled_back, failed...
+	Applied      bool   `json:"applied"`
+	AppliedAt    string `json:"applied_at,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// HistoryOptions filters and pages the execution history; zero values don't filter
+type HistoryOptions struct {
+	MigrationID     string // The history of one migration; all migrations when empty
+	AppliedAfter    time.Time
+	AppliedBefore   time.Time
+	ExecutedBy      string
+	ExecutionMethod string // manual, api, cli or worker
+	Limit           int    // 0 returns every match
+	Offset          int
+	SortBy          string // migration_id, schema, version, connection, backend, status or applied_at
+	SortOrder       string // asc or desc
+}
+
+// History is a page of the execution history, newest first unless sorted otherwise
+type History struct {
+	MigrationID string          `json:"migration_id,omitempty"`
+	Records     []HistoryRecord `json:"history"`
+	Total       int             `json:"total"` // Number of records matching the filters
+	Limit       int             `json:"limit"`
+	Offset      int             `json:"offset"`
+}
+
+// HistoryRecord is an execution of a migration
+type HistoryRecord struct {
+	MigrationID      string `json:"migration_id"`
+	Schema           string `json:"schema"`
+	Table            string `json:"table"`
+	Version          string `json:"version"`
+	Connection       string `json:"connection"`
+	Backend          string `json:"backend"`
+	AppliedAt        string `json:"applied_at"`
+	Status           string `json:"status"`
+	Operation        string `json:"operation"` // up, down or rollback
+	ErrorMessage     string `json:"error_message,omitempty"`
+	ErrorClass       string `json:"error_class,omitempty"` // HTTP only
+	ExecutedBy       string `json:"executed_by"`
+	ExecutionMethod  string `json:"execution_method"`
+	ExecutionContext string `json:"execution_context,omitempty"`
+	Emergency        bool   `json:"emergency,omitempty"` // HTTP only
+}
+
+// RollbackResponse is the outcome of a rollback
+type RollbackResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Applied []string `json:"applied,omitempty"` // HTTP only
+	Skipped []string `json:"skipped,omitempty"` // HTTP only
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// ReindexResponse is the outcome of a reindex
+type ReindexResponse struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"`
+	Total   int      `json:"total"`
+}
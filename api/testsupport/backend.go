@@ -0,0 +1,171 @@
+package testsupport
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+)
+
+// Backend is an in-memory backends.Backend. It runs nothing: executed migrations and created
+// schemas are recorded for assertions, and failures can be injected. It is safe for concurrent use.
+type Backend struct {
+	mu sync.Mutex
+
+	name      string
+	connected bool
+	config    *backends.ConnectionConfig
+	executed  []*backends.MigrationScript
+	schemas   map[string]bool
+
+	connectErr   error
+	healthErr    error
+	migrationErr map[string]error // By migration name
+}
+
+var (
+	_ backends.Backend      = (*Backend)(nil)
+	_ backends.SchemaLister = (*Backend)(nil)
+)
+
+// NewBackend returns an in-memory backend registered under name (e.g. "postgresql")
+func NewBackend(name string) *Backend {
+	return &Backend{
+		name:         name,
+		schemas:      make(map[string]bool),
+		migrationErr: make(map[string]error),
+	}
+}
+
+// FailConnect makes Connect return err; nil makes it succeed again
+func (b *Backend) FailConnect(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connectErr = err
+}
+
+// FailHealthCheck makes HealthCheck return err; nil makes it succeed again
+func (b *Backend) FailHealthCheck(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthErr = err
+}
+
+// FailMigration makes ExecuteMigration return err for the migrations named name; nil makes them
+// succeed again. Failed migrations are not recorded as executed.
+func (b *Backend) FailMigration(name string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		delete(b.migrationErr, name)
+		return
+	}
+	b.migrationErr[name] = err
+}
+
+// Executed returns the migrations executed successfully, in execution order
+func (b *Backend) Executed() []*backends.MigrationScript {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.executed)
+}
+
+// Schemas returns the schemas created, sorted
+func (b *Backend) Schemas() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	schemas := make([]string, 0, len(b.schemas))
+	for schema := range b.schemas {
+		schemas = append(schemas, schema)
+	}
+	slices.Sort(schemas)
+	return schemas
+}
+
+// Connected reports whether the backend is connected, and the configuration it was connected with
+func (b *Backend) Connected() (bool, *backends.ConnectionConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected, b.config
+}
+
+func (b *Backend) Name() string {
+	return b.name
+}
+
+func (b *Backend) Connect(config *backends.ConnectionConfig) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connectErr != nil {
+		return b.connectErr
+	}
+	b.connected = true
+	b.config = config
+	return nil
+}
+
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connected = false
+	return nil
+}
+
+func (b *Backend) ExecuteMigration(ctx context.Context, migration *backends.MigrationScript) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.migrationErr[migration.Name]; err != nil {
+		return err
+	}
+	b.executed = append(b.executed, migration)
+	return nil
+}
+
+func (b *Backend) CreateSchema(ctx context.Context, schemaName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.schemas[schemaName] = true
+	return nil
+}
+
+func (b *Backend) SchemaExists(ctx context.Context, schemaName string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.schemas[schemaName], nil
+}
+
+// ListSchemas returns the schemas created whose name matches a SQL LIKE pattern, sorted
+func (b *Backend) ListSchemas(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	like := regexp.MustCompile("^" + strings.NewReplacer("%", ".*", "_", ".").Replace(regexp.QuoteMeta(pattern)) + "$")
+	var schemas []string
+	for _, schema := range b.Schemas() {
+		if like.MatchString(schema) {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas, nil
+}
+
+func (b *Backend) HealthCheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.healthErr
+}
@@ -0,0 +1,39 @@
+// Package testsupport provides in-memory implementations of the interfaces bfm is built on: the
+// migration Registry, the state tracker (StateTracker), the job Queue and a database Backend. They
+// follow the rules of the real implementations (the state tracker those of the SQL trackers), are
+// safe for concurrent use and need no database or broker, so they serve both as test doubles and
+// for load tests of code driving the executor.
+//
+// Example usage in a test:
+//
+//	package mypkg_test
+//
+//	import (
+//		"context"
+//		"testing"
+//
+//		"github.com/toolsascode/bfm/api/migrations"
+//		"github.com/toolsascode/bfm/api/testsupport"
+//	)
+//
+//	func TestMigrate(t *testing.T) {
+//		ctx := context.Background()
+//		reg := testsupport.NewRegistry(&migrations.MigrationScript{
+//			Version:    "20250101120000",
+//			Name:       "create_users",
+//			Connection: "core",
+//			Backend:    "postgresql",
+//			UpSQL:      "CREATE TABLE users (id INT)",
+//		})
+//		tracker := testsupport.NewStateTracker()
+//		backend := testsupport.NewBackend("postgresql")
+//
+//		// ... run the code under test against reg, tracker and backend, then:
+//		if len(backend.Executed()) != 1 {
+//			t.Fatalf("expected create_users to run, got %v", backend.Executed())
+//		}
+//		if applied, _ := tracker.IsMigrationApplied(ctx, "20250101120000_create_users_postgresql_core"); !applied {
+//			t.Fatal("expected create_users to be recorded as applied")
+//		}
+//	}
+package testsupport
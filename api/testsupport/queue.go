@@ -0,0 +1,177 @@
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/logger"
+	"github.com/toolsascode/bfm/api/internal/queue"
+)
+
+// ErrQueueClosed is returned when publishing to a closed Queue
+var ErrQueueClosed = errors.New("queue is closed")
+
+// Queue is an in-memory queue.Queue with a dead-letter topic. Jobs are encoded and decoded as on
+// the wire, so the consumer gets its own copy, and are consumed in publish order. It is safe for
+// concurrent use.
+type Queue struct {
+	mu sync.Mutex
+
+	pending     []*queue.Job
+	published   []*queue.Job
+	deadLetters []*queue.DeadLetter
+	closed      bool
+	notify      chan struct{} // Signalled when a job is published or the queue is closed
+
+	publishErr    error
+	deadLetterErr error
+}
+
+var (
+	_ queue.Queue               = (*Queue)(nil)
+	_ queue.DepthReporter       = (*Queue)(nil)
+	_ queue.DeadLetterPublisher = (*Queue)(nil)
+)
+
+// NewQueue returns an empty in-memory queue
+func NewQueue() *Queue {
+	return &Queue{notify: make(chan struct{}, 1)}
+}
+
+// FailPublish makes PublishJob return err; nil makes it succeed again
+func (q *Queue) FailPublish(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.publishErr = err
+}
+
+// FailDeadLetter makes PublishDeadLetter return err, as when the dead-letter topic is unavailable;
+// nil makes it succeed again
+func (q *Queue) FailDeadLetter(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deadLetterErr = err
+}
+
+// Published returns every job published, consumed or not, in publish order
+func (q *Queue) Published() []*queue.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.published)
+}
+
+// DeadLetters returns the jobs published to the dead-letter topic, in publish order
+func (q *Queue) DeadLetters() []*queue.DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.deadLetters)
+}
+
+// signal wakes up the consumer, if waiting
+func (q *Queue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// PublishJob publishes a migration job to the queue
+func (q *Queue) PublishJob(ctx context.Context, job *queue.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	var consumed queue.Job
+	if err := json.Unmarshal(data, &consumed); err != nil {
+		return fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.publishErr != nil {
+		return q.publishErr
+	}
+	q.published = append(q.published, job)
+	q.pending = append(q.pending, &consumed)
+	q.signal()
+	return nil
+}
+
+// PublishDeadLetter publishes a permanently failed job to the dead-letter topic
+func (q *Queue) PublishDeadLetter(ctx context.Context, letter *queue.DeadLetter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.deadLetterErr != nil {
+		return q.deadLetterErr
+	}
+	q.deadLetters = append(q.deadLetters, letter)
+	return nil
+}
+
+// Consume calls handler for each job, in publish order, until ctx is done or the queue is closed.
+// As with the Kafka consumer, a job whose handler fails is logged and not redelivered.
+func (q *Queue) Consume(ctx context.Context, handler queue.JobHandler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil
+		}
+		var job *queue.Job
+		if len(q.pending) > 0 {
+			job = q.pending[0]
+			q.pending = q.pending[1:]
+		}
+		q.mu.Unlock()
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-q.notify:
+			}
+			continue
+		}
+
+		result, err := handler(ctx, job)
+		if err != nil {
+			logger.Errorf("Failed to process migration job %s: %v", job.ID, err)
+			continue
+		}
+		if result != nil && !result.Success {
+			logger.Warnf("Migration job %s completed with errors: %v", job.ID, result.Errors)
+		}
+	}
+}
+
+// Depth returns the number of jobs not yet consumed
+func (q *Queue) Depth() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.pending))
+}
+
+// Close closes the queue: publishing fails and Consume returns
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+	return nil
+}
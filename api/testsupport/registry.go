@@ -0,0 +1,76 @@
+package testsupport
+
+import (
+	"sync"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/registry"
+)
+
+// Registry is an in-memory registry.Registry with the lookup rules of the server's registry. Unlike
+// the server's, which is only written at startup, it is safe for concurrent use, so migrations can
+// be registered while a load test runs.
+type Registry struct {
+	mu  sync.RWMutex
+	reg registry.Registry
+}
+
+var _ registry.Registry = (*Registry)(nil)
+
+// NewRegistry returns an in-memory registry holding migrations
+func NewRegistry(migrations ...*backends.MigrationScript) *Registry {
+	r := &Registry{reg: registry.NewInMemoryRegistry()}
+	for _, migration := range migrations {
+		// The in-memory registry never fails to register
+		_ = r.reg.Register(migration)
+	}
+	return r
+}
+
+func (r *Registry) Register(migration *backends.MigrationScript) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reg.Register(migration)
+}
+
+func (r *Registry) FindByTarget(target *registry.MigrationTarget) ([]*backends.MigrationScript, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.FindByTarget(target)
+}
+
+func (r *Registry) GetAll() []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetAll()
+}
+
+func (r *Registry) GetByConnection(connectionName string) []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetByConnection(connectionName)
+}
+
+func (r *Registry) GetByBackend(backendName string) []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetByBackend(backendName)
+}
+
+func (r *Registry) GetMigrationByName(name string) []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetMigrationByName(name)
+}
+
+func (r *Registry) GetMigrationByVersion(version string) []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetMigrationByVersion(version)
+}
+
+func (r *Registry) GetMigrationByConnectionAndVersion(connection, version string) []*backends.MigrationScript {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reg.GetMigrationByConnectionAndVersion(connection, version)
+}
@@ -0,0 +1,1275 @@
+package testsupport
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// skippedLimit is the number of skipped records kept per migration and schema, as in the SQL
+// trackers
+const skippedLimit = 5
+
+// StateTracker is an in-memory state.StateTracker. It keeps the same records as the SQL trackers
+// (migrations_list, migrations_history, migrations_executions, locks, audit log, jobs, emergency
+// runs and the state change feed) and follows their rules, so code tested against it behaves the same on a real state
+// database. It is safe for concurrent use; locks are held by the tracker, so two callers sharing it
+// exclude each other as two processes sharing a state database would.
+type StateTracker struct {
+	mu sync.Mutex
+
+	list       map[string]*listEntry // By base migration ID
+	executions map[executionKey]*state.MigrationExecution
+	history    []*state.MigrationRecord
+	skipped    []*state.SkippedMigration
+	audit      []*state.AuditRecord
+	jobs       map[string]*state.Job
+	emergency  map[string]*state.EmergencyRun
+	changes    []*state.StateChange
+
+	executionLocks map[executionLockKey]bool
+	locks          map[string]*state.MigrationLock
+	primary        *primaryLock // Holder of the primary lock, if any
+
+	sequences map[string]int64 // Last ID by table, as in the SQL trackers
+
+	failures map[string]error // Injected errors, by method name
+}
+
+// listEntry is a migrations_list row
+type listEntry struct {
+	item      state.MigrationListItem
+	detail    state.MigrationDetail
+	updatedAt time.Time
+}
+
+type executionKey struct {
+	migrationID, schema string
+}
+
+type executionLockKey struct {
+	migrationID, schema, connection string
+}
+
+var _ state.StateTracker = (*StateTracker)(nil)
+
+// NewStateTracker returns an empty in-memory state tracker
+func NewStateTracker() *StateTracker {
+	return &StateTracker{
+		list:           make(map[string]*listEntry),
+		executions:     make(map[executionKey]*state.MigrationExecution),
+		jobs:           make(map[string]*state.Job),
+		emergency:      make(map[string]*state.EmergencyRun),
+		executionLocks: make(map[executionLockKey]bool),
+		locks:          make(map[string]*state.MigrationLock),
+		sequences:      make(map[string]int64),
+		failures:       make(map[string]error),
+	}
+}
+
+// Fail makes the method named method (e.g. "IsMigrationApplied") return err; nil makes it succeed
+// again
+func (t *StateTracker) Fail(method string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures[method] = err
+}
+
+// MarkApplied records migrations as applied, without history as for dependency migrations. IDs
+// are base IDs ({version}_{name}_{backend}_{connection}), or schema-specific ones ({schema}_{base
+// ID}) that mark the migration applied on the schema.
+func (t *StateTracker) MarkApplied(migrationIDs ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, migrationID := range migrationIDs {
+		baseMigrationID := state.BaseMigrationID(migrationID)
+		migration := &state.MigrationRecord{MigrationID: migrationID, Schema: state.SchemaPrefix(migrationID, baseMigrationID)}
+		if parts := strings.Split(baseMigrationID, "_"); len(parts) >= 4 {
+			migration.Version, migration.Backend, migration.Connection = parts[0], parts[len(parts)-2], parts[len(parts)-1]
+		}
+		t.upsertList(baseMigrationID, migration, "applied")
+		t.recordExecution(baseMigrationID, migration, "applied", time.Now())
+	}
+}
+
+// HoldLock takes the lock of a connection for holder until it is force-released, as a replica
+// that died during a migration leaves it
+func (t *StateTracker) HoldLock(connection, holder string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.locks[connection] = &state.MigrationLock{Connection: connection, Holder: holder, AcquiredAt: formatTime(time.Now())}
+}
+
+// History returns the recorded history, oldest first
+func (t *StateTracker) History() []*state.MigrationRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	history := make([]*state.MigrationRecord, len(t.history))
+	for i, record := range t.history {
+		copied := *record
+		history[i] = &copied
+	}
+	return history
+}
+
+// failure returns the error injected for method by Fail, if any
+func (t *StateTracker) failure(method string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failures[method]
+}
+
+// nextID returns the next ID of a table; the caller holds t.mu
+func (t *StateTracker) nextID(table string) int64 {
+	t.sequences[table]++
+	return t.sequences[table]
+}
+
+// formatTime formats a time as the trackers return it
+func formatTime(tm time.Time) string {
+	if tm.IsZero() {
+		return ""
+	}
+	return tm.UTC().Format(time.RFC3339)
+}
+
+// hasSchema reports whether a schema column, alone or a comma-separated list, holds schema
+func hasSchema(column, schema string) bool {
+	return slices.Contains(strings.Split(column, ","), schema)
+}
+
+// upsertList creates the migrations_list entry of a recorded migration, or updates the status of an
+// existing one unless it is already applied; the caller holds t.mu
+func (t *StateTracker) upsertList(baseMigrationID string, migration *state.MigrationRecord, status string) {
+	entry, ok := t.list[baseMigrationID]
+	if !ok {
+		entry = &listEntry{item: state.MigrationListItem{
+			MigrationID:  baseMigrationID,
+			Schema:       migration.Schema,
+			Version:      migration.Version,
+			Name:         state.MigrationName(baseMigrationID),
+			Connection:   migration.Connection,
+			Backend:      migration.Backend,
+			StatusLabels: []string{},
+		}}
+		t.list[baseMigrationID] = entry
+	}
+	if entry.item.LastStatus != "applied" {
+		entry.item.LastStatus = status
+	}
+	if migration.Checksum != "" {
+		entry.item.Checksum = migration.Checksum
+	}
+	entry.updatedAt = time.Now()
+}
+
+// recordExecution upserts the migrations_executions entry of a migration run for a schema. Runs
+// without a schema have no execution entry. The caller holds t.mu.
+func (t *StateTracker) recordExecution(baseMigrationID string, migration *state.MigrationRecord, status string, appliedAt time.Time) {
+	if migration.Schema == "" {
+		return
+	}
+	now := formatTime(time.Now())
+	key := executionKey{baseMigrationID, migration.Schema}
+	execution, ok := t.executions[key]
+	if !ok {
+		execution = &state.MigrationExecution{CreatedAt: now}
+		t.executions[key] = execution
+	}
+	execution.MigrationID = baseMigrationID
+	execution.Schema = migration.Schema
+	execution.Version = migration.Version
+	execution.Connection = migration.Connection
+	execution.Backend = migration.Backend
+	execution.Applied = status == "applied"
+	execution.AppliedAt = ""
+	execution.Status = "pending"
+	if execution.Applied {
+		execution.Status = "applied"
+		execution.AppliedAt = formatTime(appliedAt)
+	} else if status == "failed" {
+		execution.Status = "failed"
+	}
+	if migration.Checksum != "" {
+		execution.Checksum = migration.Checksum
+	}
+	execution.UpdatedAt = now
+}
+
+// appendChange appends a change to the feed; the caller holds t.mu
+func (t *StateTracker) appendChange(change *state.StateChange) {
+	change.Cursor = t.nextID("changes")
+	change.CreatedAt = formatTime(time.Now())
+	t.changes = append(t.changes, change)
+}
+
+// appendExecutionChange appends the change of type changeType of a recorded execution, if any;
+// the caller holds t.mu
+func (t *StateTracker) appendExecutionChange(baseMigrationID string, migration *state.MigrationRecord, changeType, status string) {
+	if changeType == "" {
+		return
+	}
+	t.appendChange(&state.StateChange{
+		Type:        changeType,
+		MigrationID: baseMigrationID,
+		Schema:      migration.Schema,
+		Version:     migration.Version,
+		Connection:  migration.Connection,
+		Backend:     migration.Backend,
+		Status:      status,
+		Error:       migration.ErrorMessage,
+	})
+}
+
+// RecordMigration records a migration execution
+func (t *StateTracker) RecordMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	if err := t.failure("RecordMigration"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	appliedAt := migration.AppliedTime()
+	isRollback := migration.Reverts()
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+
+	record := *migration
+	record.MigrationID = baseMigrationID
+	record.Operation = migration.HistoryOperation()
+	record.AppliedAt = formatTime(appliedAt)
+	if record.ExecutedBy == "" {
+		record.ExecutedBy = "system"
+	}
+	if record.ExecutionMethod == "" {
+		record.ExecutionMethod = "api"
+	}
+	record.Status = migration.HistoryStatus()
+	listStatus := record.Status
+	if isRollback {
+		listStatus = "rolled_back"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.upsertList(baseMigrationID, migration, listStatus)
+	record.ID = strconv.FormatInt(t.nextID("history"), 10)
+	t.history = append(t.history, &record)
+	t.recordExecution(baseMigrationID, migration, record.Status, appliedAt)
+	t.appendExecutionChange(baseMigrationID, migration, state.ExecutionChange(record.Status, isRollback), listStatus)
+	return nil
+}
+
+// RecordDependencyMigration records a dependency migration as applied without creating history
+// entries
+func (t *StateTracker) RecordDependencyMigration(ctx context.Context, migration *state.MigrationRecord) error {
+	if err := t.failure("RecordDependencyMigration"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	baseMigrationID := state.BaseMigrationID(migration.MigrationID)
+	status := migration.Status
+	if status == "success" {
+		status = "applied"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.upsertList(baseMigrationID, migration, status)
+	t.recordExecution(baseMigrationID, migration, status, migration.AppliedTime())
+	t.appendExecutionChange(baseMigrationID, migration, state.ExecutionChange(status, false), status)
+	return nil
+}
+
+// historyField returns the value of a history record that filters sort by
+func historyField(record *state.MigrationRecord, field string) string {
+	switch field {
+	case state.SortByMigrationID:
+		return record.MigrationID
+	case state.SortBySchema:
+		return record.Schema
+	case state.SortByVersion:
+		return record.Version
+	case state.SortByConnection:
+		return record.Connection
+	case state.SortByBackend:
+		return record.Backend
+	case state.SortByStatus:
+		return record.Status
+	default:
+		return record.AppliedAt
+	}
+}
+
+// GetMigrationHistory retrieves migration history with optional filters, newest first unless
+// filters sort it otherwise
+func (t *StateTracker) GetMigrationHistory(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationRecord, int, error) {
+	if err := t.failure("GetMigrationHistory"); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var matched []*state.MigrationRecord
+	for _, record := range t.history {
+		appliedAt, _ := time.Parse(time.RFC3339, record.AppliedAt)
+		if filters != nil {
+			entry := t.list[record.MigrationID]
+			switch {
+			case !filters.MatchesMigrationID(record.MigrationID),
+				!filters.MatchesAppliedAt(appliedAt),
+				filters.Schema != "" && !hasSchema(record.Schema, filters.Schema),
+				filters.Table != "" && (entry == nil || entry.item.Table != filters.Table),
+				filters.Connection != "" && record.Connection != filters.Connection,
+				filters.Backend != "" && record.Backend != filters.Backend,
+				filters.Status != "" && record.Status != filters.Status,
+				filters.Version != "" && record.Version != filters.Version,
+				filters.ExecutedBy != "" && record.ExecutedBy != filters.ExecutedBy,
+				filters.ExecutionMethod != "" && record.ExecutionMethod != filters.ExecutionMethod,
+				filters.ErrorClass != "" && record.ErrorClass != filters.ErrorClass,
+				filters.RunID != "" && record.RunID() != filters.RunID:
+				continue
+			}
+		}
+		copied := *record
+		matched = append(matched, &copied)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByAppliedAt, state.SortDesc)
+	slices.SortStableFunc(matched, func(a, b *state.MigrationRecord) int {
+		c := cmp.Compare(historyField(a, sortBy), historyField(b, sortBy))
+		if c == 0 {
+			idA, _ := strconv.ParseInt(a.ID, 10, 64)
+			idB, _ := strconv.ParseInt(b.ID, 10, 64)
+			c = cmp.Compare(idA, idB)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	start, end := filters.Page(len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+// listField returns the value of a migrations_list item that filters sort by
+func listField(item *state.MigrationListItem, field string) string {
+	switch field {
+	case state.SortByMigrationID:
+		return item.MigrationID
+	case state.SortBySchema:
+		return item.Schema
+	case state.SortByConnection:
+		return item.Connection
+	case state.SortByBackend:
+		return item.Backend
+	case state.SortByStatus:
+		return item.LastStatus
+	case state.SortByAppliedAt:
+		return item.LastAppliedAt
+	default:
+		return item.Version
+	}
+}
+
+// listItem returns a copy of the list item of an entry; the caller holds t.mu
+func (e *listEntry) listItem() *state.MigrationListItem {
+	item := e.item
+	item.StatusLabels = slices.Clone(e.item.StatusLabels)
+	item.Applied = item.LastStatus == "applied"
+	if item.Applied {
+		// As in the SQL trackers, the last update of an applied migration is when it was applied
+		item.LastAppliedAt = formatTime(e.updatedAt)
+	}
+	return &item
+}
+
+// GetMigrationList retrieves the list of migrations with their last status, ordered by version
+// unless filters sort it otherwise
+func (t *StateTracker) GetMigrationList(ctx context.Context, filters *state.MigrationFilters) ([]*state.MigrationListItem, int, error) {
+	if err := t.failure("GetMigrationList"); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var matched []*state.MigrationListItem
+	for _, entry := range t.list {
+		item := entry.listItem()
+		if filters != nil {
+			switch {
+			case filters.Schema != "" && !hasSchema(item.Schema, filters.Schema),
+				filters.Table != "" && item.Table != filters.Table,
+				filters.Connection != "" && item.Connection != filters.Connection,
+				filters.Backend != "" && item.Backend != filters.Backend,
+				filters.Status != "" && item.LastStatus != filters.Status,
+				filters.Version != "" && item.Version != filters.Version,
+				filters.Label != "" && !slices.Contains(item.StatusLabels, filters.Label):
+				continue
+			}
+		}
+		matched = append(matched, item)
+	}
+
+	sortBy, desc := filters.Ordering(state.SortByVersion, state.SortAsc)
+	slices.SortFunc(matched, func(a, b *state.MigrationListItem) int {
+		c := cmp.Or(cmp.Compare(listField(a, sortBy), listField(b, sortBy)), cmp.Compare(a.MigrationID, b.MigrationID))
+		if desc {
+			return -c
+		}
+		return c
+	})
+	start, end := filters.Page(len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+// executionStatusIn reports whether a migration has an execution for schema with one of statuses;
+// the caller holds t.mu
+func (t *StateTracker) executionStatusIn(baseMigrationID, schema string, statuses ...string) bool {
+	execution, ok := t.executions[executionKey{baseMigrationID, schema}]
+	return ok && slices.Contains(statuses, execution.Status)
+}
+
+// IsMigrationApplied checks if a migration has been successfully applied. Schema-specific IDs are
+// checked per schema, base IDs in migrations_list.
+func (t *StateTracker) IsMigrationApplied(ctx context.Context, migrationID string) (bool, error) {
+	if err := t.failure("IsMigrationApplied"); err != nil {
+		return false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	baseMigrationID := state.BaseMigrationID(migrationID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if schema := state.SchemaPrefix(migrationID, baseMigrationID); schema != "" {
+		return t.executionStatusIn(baseMigrationID, schema, "applied"), nil
+	}
+	entry, ok := t.list[baseMigrationID]
+	return ok && entry.item.LastStatus == "applied", nil
+}
+
+// IsMigrationPendingOrApplied checks if a migration is pending or applied. For base IDs this
+// matches IsMigrationApplied.
+func (t *StateTracker) IsMigrationPendingOrApplied(ctx context.Context, migrationID string) (bool, error) {
+	if err := t.failure("IsMigrationPendingOrApplied"); err != nil {
+		return false, err
+	}
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	schema := state.SchemaPrefix(migrationID, baseMigrationID)
+	if schema == "" {
+		return t.IsMigrationApplied(ctx, migrationID)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.executionStatusIn(baseMigrationID, schema, "applied", "pending"), nil
+}
+
+// WithMigrationExecutionLock runs fn while holding the lock of a migration execution, or returns
+// state.ErrMigrationAlreadyInProgress if it is held
+func (t *StateTracker) WithMigrationExecutionLock(ctx context.Context, migrationID, schema, connection string, fn func() error) error {
+	if err := t.failure("WithMigrationExecutionLock"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key := executionLockKey{migrationID, schema, connection}
+	t.mu.Lock()
+	if t.executionLocks[key] {
+		t.mu.Unlock()
+		return state.ErrMigrationAlreadyInProgress
+	}
+	t.executionLocks[key] = true
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.executionLocks, key)
+		t.mu.Unlock()
+	}()
+	return fn()
+}
+
+// WithConnectionLock runs fn while holding the lock of a connection, or returns
+// state.ErrConnectionLocked if it is held
+func (t *StateTracker) WithConnectionLock(ctx context.Context, connection, holder string, fn func() error) error {
+	if err := t.failure("WithConnectionLock"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	lock := &state.MigrationLock{Connection: connection, Holder: holder, AcquiredAt: formatTime(time.Now())}
+	t.mu.Lock()
+	if _, held := t.locks[connection]; held {
+		t.mu.Unlock()
+		return fmt.Errorf("%w: %s", state.ErrConnectionLocked, connection)
+	}
+	t.locks[connection] = lock
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		// Unless it was force-released and taken again since
+		if t.locks[connection] == lock {
+			delete(t.locks, connection)
+		}
+		t.mu.Unlock()
+	}()
+	return fn()
+}
+
+// ListLocks returns the connection locks currently held, by connection
+func (t *StateTracker) ListLocks(ctx context.Context) ([]*state.MigrationLock, error) {
+	if err := t.failure("ListLocks"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	locks := make([]*state.MigrationLock, 0, len(t.locks))
+	for _, lock := range t.locks {
+		copied := *lock
+		locks = append(locks, &copied)
+	}
+	slices.SortFunc(locks, func(a, b *state.MigrationLock) int { return cmp.Compare(a.Connection, b.Connection) })
+	return locks, nil
+}
+
+// ReleaseLock force-releases the lock on a connection. The holder is not stopped, as with the
+// SQLite tracker.
+func (t *StateTracker) ReleaseLock(ctx context.Context, connection string) (bool, error) {
+	if err := t.failure("ReleaseLock"); err != nil {
+		return false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, held := t.locks[connection]
+	delete(t.locks, connection)
+	return held, nil
+}
+
+// primaryLock is the primary lock of a StateTracker, never lost until released
+type primaryLock struct {
+	tracker *StateTracker
+	once    sync.Once
+}
+
+func (l *primaryLock) Check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.tracker.mu.Lock()
+	defer l.tracker.mu.Unlock()
+	if l.tracker.primary != l {
+		return fmt.Errorf("primary lock was taken over")
+	}
+	return nil
+}
+
+func (l *primaryLock) Release() {
+	l.once.Do(func() {
+		l.tracker.mu.Lock()
+		defer l.tracker.mu.Unlock()
+		if l.tracker.primary == l {
+			l.tracker.primary = nil
+		}
+	})
+}
+
+// AcquirePrimaryLock takes the primary lock, or returns state.ErrPrimaryLocked if it is held
+func (t *StateTracker) AcquirePrimaryLock(ctx context.Context) (state.PrimaryLock, error) {
+	if err := t.failure("AcquirePrimaryLock"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.primary != nil {
+		return nil, state.ErrPrimaryLocked
+	}
+	t.primary = &primaryLock{tracker: t}
+	return t.primary, nil
+}
+
+// GetLastMigrationVersion gets the last applied version for a schema
+func (t *StateTracker) GetLastMigrationVersion(ctx context.Context, schema, table string) (string, error) {
+	if err := t.failure("GetLastMigrationVersion"); err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var version string
+	for _, entry := range t.list {
+		if entry.item.LastStatus == "applied" && hasSchema(entry.item.Schema, schema) && entry.item.Version > version {
+			version = entry.item.Version
+		}
+	}
+	return version, nil
+}
+
+// RegisterScannedMigration registers a scanned migration in migrations_list (status: pending). An
+// already registered migration keeps its status, but picks up a table declared since.
+func (t *StateTracker) RegisterScannedMigration(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	if err := t.failure("RegisterScannedMigration"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.list[migrationID]; ok {
+		entry.item.Table = table
+		return nil
+	}
+	t.list[migrationID] = &listEntry{
+		item: state.MigrationListItem{
+			MigrationID:  migrationID,
+			Schema:       schema,
+			Table:        table,
+			Version:      version,
+			Name:         name,
+			Connection:   connection,
+			Backend:      backend,
+			LastStatus:   "pending",
+			StatusLabels: []string{},
+		},
+		updatedAt: time.Now(),
+	}
+	return nil
+}
+
+// SetStatusLabels replaces the user-defined status labels of a migration
+func (t *StateTracker) SetStatusLabels(ctx context.Context, migrationID string, labels []string) error {
+	if err := t.failure("SetStatusLabels"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.list[state.BaseMigrationID(migrationID)]
+	if !ok {
+		return fmt.Errorf("%w: %s", state.ErrMigrationNotFound, migrationID)
+	}
+	entry.item.StatusLabels = append([]string{}, labels...)
+	return nil
+}
+
+// UpdateMigrationInfo updates migration metadata without affecting status or history
+func (t *StateTracker) UpdateMigrationInfo(ctx context.Context, migrationID, schema, table, version, name, connection, backend string) error {
+	if err := t.failure("UpdateMigrationInfo"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.list[migrationID]
+	if !ok {
+		return fmt.Errorf("migration %s not found", migrationID)
+	}
+	entry.item.Schema = schema
+	entry.item.Table = table
+	entry.item.Version = version
+	entry.item.Name = name
+	entry.item.Connection = connection
+	entry.item.Backend = backend
+	entry.updatedAt = time.Now()
+	return nil
+}
+
+// DeleteMigration deletes a migration from migrations_list, with its history, executions and
+// skipped records, as the foreign keys of the SQL trackers cascade
+func (t *StateTracker) DeleteMigration(ctx context.Context, migrationID string) error {
+	if err := t.failure("DeleteMigration"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deleteMigration(migrationID)
+	return nil
+}
+
+// deleteMigration deletes a migration and the records referencing it; the caller holds t.mu
+func (t *StateTracker) deleteMigration(migrationID string) {
+	delete(t.list, migrationID)
+	for key := range t.executions {
+		if key.migrationID == migrationID {
+			delete(t.executions, key)
+		}
+	}
+	t.history = slices.DeleteFunc(t.history, func(record *state.MigrationRecord) bool {
+		return record.MigrationID == migrationID
+	})
+	t.skipped = slices.DeleteFunc(t.skipped, func(skipped *state.SkippedMigration) bool {
+		return skipped.MigrationID == migrationID
+	})
+}
+
+// Initialize does nothing: the tracker needs no tables
+func (t *StateTracker) Initialize(ctx context.Context) error {
+	if err := t.failure("Initialize"); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// ReindexMigrations synchronizes migrations_list with the migrations of registry (anything with a
+// GetAll() []*backends.MigrationScript method), keeping the execution state of known migrations
+// and removing the ones no longer registered
+func (t *StateTracker) ReindexMigrations(ctx context.Context, registry interface{}) error {
+	if err := t.failure("ReindexMigrations"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	type Registry interface {
+		GetAll() []*backends.MigrationScript
+	}
+	reg, ok := registry.(Registry)
+	if !ok {
+		return fmt.Errorf("registry does not implement GetAll() method")
+	}
+
+	registered := make(map[string]*backends.MigrationScript)
+	for _, migration := range reg.GetAll() {
+		registered[fmt.Sprintf("%s_%s_%s_%s", migration.Version, migration.Name, migration.Backend, migration.Connection)] = migration
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for migrationID, migration := range registered {
+		// Stop when ctx is cancelled rather than finishing the pass
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry, exists := t.list[migrationID]
+		status := "pending"
+		if exists {
+			status = entry.item.LastStatus
+			if status == "success" {
+				status = "applied"
+			}
+		} else {
+			entry = &listEntry{item: state.MigrationListItem{StatusLabels: []string{}}}
+			t.list[migrationID] = entry
+		}
+
+		table := ""
+		if migration.Table != nil {
+			table = *migration.Table
+		}
+		entry.item.MigrationID = migrationID
+		entry.item.Schema = migration.Schema
+		entry.item.Table = table
+		entry.item.Version = migration.Version
+		entry.item.Name = migration.Name
+		entry.item.Connection = migration.Connection
+		entry.item.Backend = migration.Backend
+		entry.item.LastStatus = status
+		entry.detail.UpSQL = fmt.Sprintf("%s_%s.up%s", migration.Version, migration.Name, migration.ScriptExtension())
+		entry.detail.DownSQL = fmt.Sprintf("%s_%s.down%s", migration.Version, migration.Name, migration.ScriptExtension())
+		entry.detail.Dependencies = append([]string{}, migration.Dependencies...)
+		entry.detail.StructuredDependencies = slices.Clone(migration.StructuredDependencies)
+		entry.updatedAt = time.Now()
+
+		if migration.Schema != "" {
+			t.recordExecution(migrationID, &state.MigrationRecord{
+				Schema:     migration.Schema,
+				Version:    migration.Version,
+				Connection: migration.Connection,
+				Backend:    migration.Backend,
+			}, status, entry.updatedAt)
+		}
+
+		if !exists {
+			t.appendChange(&state.StateChange{
+				Type:        state.ChangeReindexed,
+				MigrationID: migrationID,
+				Schema:      migration.Schema,
+				Version:     migration.Version,
+				Connection:  migration.Connection,
+				Backend:     migration.Backend,
+				Status:      status,
+			})
+		}
+	}
+
+	for migrationID, entry := range t.list {
+		if _, ok := registered[migrationID]; !ok {
+			removed := entry.listItem()
+			t.deleteMigration(migrationID)
+			t.appendChange(state.RemovedChange(removed))
+		}
+	}
+	return nil
+}
+
+// GetMigrationDetail retrieves a migration from migrations_list, or nil if it is not registered
+func (t *StateTracker) GetMigrationDetail(ctx context.Context, migrationID string) (*state.MigrationDetail, error) {
+	if err := t.failure("GetMigrationDetail"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.list[state.BaseMigrationID(migrationID)]
+	if !ok {
+		return nil, nil
+	}
+	detail := entry.detail
+	detail.MigrationID = entry.item.MigrationID
+	detail.Schema = entry.item.Schema
+	detail.Version = entry.item.Version
+	detail.Name = entry.item.Name
+	detail.Connection = entry.item.Connection
+	detail.Backend = entry.item.Backend
+	detail.Status = entry.item.LastStatus
+	detail.StatusLabels = slices.Clone(entry.item.StatusLabels)
+	if detail.Dependencies == nil {
+		detail.Dependencies = []string{}
+	}
+	return &detail, nil
+}
+
+// executionsWhere returns copies of the executions matching match, most recently created first;
+// the caller holds t.mu
+func (t *StateTracker) executionsWhere(match func(execution *state.MigrationExecution) bool) []*state.MigrationExecution {
+	var executions []*state.MigrationExecution
+	for _, execution := range t.executions {
+		if match(execution) {
+			copied := *execution
+			executions = append(executions, &copied)
+		}
+	}
+	slices.SortFunc(executions, func(a, b *state.MigrationExecution) int {
+		return cmp.Or(cmp.Compare(b.CreatedAt, a.CreatedAt), cmp.Compare(a.MigrationID, b.MigrationID), cmp.Compare(a.Schema, b.Schema))
+	})
+	return executions
+}
+
+// GetMigrationExecutions retrieves the execution records of a migration, ordered by created_at DESC
+func (t *StateTracker) GetMigrationExecutions(ctx context.Context, migrationID string) ([]*state.MigrationExecution, error) {
+	if err := t.failure("GetMigrationExecutions"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	baseMigrationID := state.BaseMigrationID(migrationID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.executionsWhere(func(execution *state.MigrationExecution) bool {
+		return execution.MigrationID == baseMigrationID
+	}), nil
+}
+
+// GetConnectionExecutions retrieves the execution records of the migrations on a connection,
+// ordered by migration_id and schema
+func (t *StateTracker) GetConnectionExecutions(ctx context.Context, connection string) ([]*state.MigrationExecution, error) {
+	if err := t.failure("GetConnectionExecutions"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	executions := t.executionsWhere(func(execution *state.MigrationExecution) bool {
+		return execution.Connection == connection
+	})
+	slices.SortFunc(executions, func(a, b *state.MigrationExecution) int {
+		return cmp.Or(cmp.Compare(a.MigrationID, b.MigrationID), cmp.Compare(a.Schema, b.Schema))
+	})
+	return executions, nil
+}
+
+// GetRecentExecutions retrieves recent execution records across all migrations, ordered by
+// created_at DESC
+func (t *StateTracker) GetRecentExecutions(ctx context.Context, limit int) ([]*state.MigrationExecution, error) {
+	if err := t.failure("GetRecentExecutions"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	executions := t.executionsWhere(func(*state.MigrationExecution) bool { return true })
+	if len(executions) > limit {
+		executions = executions[:limit]
+	}
+	return executions, nil
+}
+
+// RecordSkippedMigrations records skipped migrations for a given execution context. Only the 5
+// most recent records are kept per migration and schema; migrations that are not registered are
+// not recorded.
+func (t *StateTracker) RecordSkippedMigrations(ctx context.Context, skippedMigrationIDs []string, executedBy, executionMethod, executionContext string) error {
+	if err := t.failure("RecordSkippedMigrations"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, migrationID := range skippedMigrationIDs {
+		baseMigrationID := state.BaseMigrationID(migrationID)
+		entry, ok := t.list[baseMigrationID]
+		if !ok {
+			continue
+		}
+		schema := state.SchemaPrefix(migrationID, baseMigrationID)
+		if schema == "" {
+			schema = entry.item.Schema
+		}
+
+		now := formatTime(time.Now())
+		t.skipped = append(t.skipped, &state.SkippedMigration{
+			ID:               int(t.nextID("skipped")),
+			MigrationID:      baseMigrationID,
+			Schema:           schema,
+			Version:          entry.item.Version,
+			Connection:       entry.item.Connection,
+			Backend:          entry.item.Backend,
+			ExecutedBy:       executedBy,
+			ExecutionMethod:  executionMethod,
+			ExecutionContext: executionContext,
+			SkippedAt:        now,
+			CreatedAt:        now,
+		})
+
+		// Records are appended in order, so the oldest come first
+		kept := 0
+		for i := len(t.skipped) - 1; i >= 0; i-- {
+			if skipped := t.skipped[i]; skipped.MigrationID == baseMigrationID && skipped.Schema == schema {
+				if kept++; kept > skippedLimit {
+					t.skipped = slices.Delete(t.skipped, i, i+1)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// GetSkippedMigrations retrieves the most recent skipped migrations, optionally of one migration
+func (t *StateTracker) GetSkippedMigrations(ctx context.Context, migrationID string, limit int) ([]*state.SkippedMigration, error) {
+	if err := t.failure("GetSkippedMigrations"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var skipped []*state.SkippedMigration
+	for i := len(t.skipped) - 1; i >= 0 && len(skipped) < limit; i-- {
+		if migrationID == "" || t.skipped[i].MigrationID == migrationID {
+			copied := *t.skipped[i]
+			skipped = append(skipped, &copied)
+		}
+	}
+	return skipped, nil
+}
+
+// RecordAudit appends a record to the audit log, setting its ID and CreatedAt
+func (t *StateTracker) RecordAudit(ctx context.Context, record *state.AuditRecord) error {
+	if err := t.failure("RecordAudit"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record.ID = t.nextID("audit")
+	record.CreatedAt = formatTime(time.Now())
+	copied := *record
+	t.audit = append(t.audit, &copied)
+	return nil
+}
+
+// GetAuditLog retrieves audit records matching filters, newest first
+func (t *StateTracker) GetAuditLog(ctx context.Context, filters *state.AuditFilters) ([]*state.AuditRecord, int, error) {
+	if err := t.failure("GetAuditLog"); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if filters == nil {
+		filters = &state.AuditFilters{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var matched []*state.AuditRecord
+	for i := len(t.audit) - 1; i >= 0; i-- {
+		record := t.audit[i]
+		createdAt, _ := time.Parse(time.RFC3339, record.CreatedAt)
+		switch {
+		case filters.Operation != "" && record.Operation != filters.Operation,
+			filters.Actor != "" && record.Actor != filters.Actor,
+			filters.Outcome != "" && record.Outcome != filters.Outcome,
+			!filters.Since.IsZero() && createdAt.Before(filters.Since.Truncate(time.Second)),
+			!filters.Until.IsZero() && !createdAt.Before(filters.Until):
+			continue
+		}
+		copied := *record
+		matched = append(matched, &copied)
+	}
+	return page(matched, filters.Limit, filters.Offset), len(matched), nil
+}
+
+// page returns the items of a page of values
+func page[T any](values []T, limit, offset int) []T {
+	if offset >= len(values) {
+		return nil
+	}
+	values = values[offset:]
+	if limit > 0 && len(values) > limit {
+		values = values[:limit]
+	}
+	return values
+}
+
+// RecordJob creates a job or updates its status, worker and results. A job queued again
+// (replayed) starts over.
+func (t *StateTracker) RecordJob(ctx context.Context, job *state.Job) error {
+	if err := t.failure("RecordJob"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := formatTime(time.Now())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.jobs[job.ID]
+	if !ok || job.Status == state.JobQueued {
+		queuedAt := now
+		if ok && job.Status != state.JobQueued {
+			queuedAt = record.QueuedAt
+		}
+		scheduledAt := ""
+		if ok {
+			scheduledAt = record.ScheduledAt
+		}
+		record = &state.Job{ID: job.ID, Connection: job.Connection, Schema: job.Schema, Target: job.Target, DryRun: job.DryRun, QueuedAt: queuedAt, ScheduledAt: scheduledAt}
+		t.jobs[job.ID] = record
+	}
+	record.Status = job.Status
+	if job.ScheduledAt != "" {
+		record.ScheduledAt = job.ScheduledAt
+	}
+	if job.Worker != "" {
+		record.Worker = job.Worker
+	}
+	record.Attempts = job.Attempts
+	record.Payload = job.Payload
+	record.Applied = slices.Clone(job.Applied)
+	record.Skipped = slices.Clone(job.Skipped)
+	record.Errors = slices.Clone(job.Errors)
+	if (job.Status == state.JobPickedUp || job.Status == state.JobRunning) && record.StartedAt == "" {
+		record.StartedAt = now
+	}
+	if state.JobFinished(job.Status) {
+		record.FinishedAt = now
+	}
+	record.UpdatedAt = now
+	return nil
+}
+
+// copyJob returns a copy of a job, with empty rather than nil lists as the SQL trackers return
+func copyJob(job *state.Job) *state.Job {
+	copied := *job
+	copied.Applied = append([]string{}, job.Applied...)
+	copied.Skipped = append([]string{}, job.Skipped...)
+	copied.Errors = append([]string{}, job.Errors...)
+	return &copied
+}
+
+// GetJob returns a job by ID, or state.ErrJobNotFound
+func (t *StateTracker) GetJob(ctx context.Context, id string) (*state.Job, error) {
+	if err := t.failure("GetJob"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return nil, state.ErrJobNotFound
+	}
+	return copyJob(job), nil
+}
+
+// GetJobs retrieves jobs matching filters, most recently queued first
+func (t *StateTracker) GetJobs(ctx context.Context, filters *state.JobFilters) ([]*state.Job, int, error) {
+	if err := t.failure("GetJobs"); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if filters == nil {
+		filters = &state.JobFilters{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var matched []*state.Job
+	for _, job := range t.jobs {
+		if filters.Status != "" && job.Status != filters.Status || filters.Connection != "" && job.Connection != filters.Connection {
+			continue
+		}
+		matched = append(matched, copyJob(job))
+	}
+	slices.SortFunc(matched, func(a, b *state.Job) int {
+		return cmp.Or(cmp.Compare(b.QueuedAt, a.QueuedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return page(matched, filters.Limit, filters.Offset), len(matched), nil
+}
+
+// RecordEmergencyRun creates an emergency run, or records the postmortem of an existing one
+func (t *StateTracker) RecordEmergencyRun(ctx context.Context, run *state.EmergencyRun) error {
+	if err := t.failure("RecordEmergencyRun"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	due, err := time.Parse(time.RFC3339, run.PostmortemDue)
+	if err != nil {
+		return fmt.Errorf("failed to record emergency run %s: invalid postmortem_due: %w", run.ID, err)
+	}
+	now := formatTime(time.Now())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.emergency[run.ID]
+	if !ok {
+		record = &state.EmergencyRun{ID: run.ID, Operation: run.Operation, Actor: run.Actor, Reason: run.Reason,
+			Method: run.Method, StartedAt: now, PostmortemDue: formatTime(due)}
+		t.emergency[run.ID] = record
+	}
+	if run.Postmortem != "" {
+		record.Postmortem, record.PostmortemBy, record.PostmortemAt = run.Postmortem, run.PostmortemBy, now
+	}
+	return nil
+}
+
+// GetEmergencyRun returns an emergency run by ID, or state.ErrEmergencyRunNotFound
+func (t *StateTracker) GetEmergencyRun(ctx context.Context, id string) (*state.EmergencyRun, error) {
+	if err := t.failure("GetEmergencyRun"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	run, ok := t.emergency[id]
+	if !ok {
+		return nil, state.ErrEmergencyRunNotFound
+	}
+	copied := *run
+	return &copied, nil
+}
+
+// GetEmergencyRuns retrieves emergency runs matching filters, most recent first
+func (t *StateTracker) GetEmergencyRuns(ctx context.Context, filters *state.EmergencyRunFilters) ([]*state.EmergencyRun, int, error) {
+	if err := t.failure("GetEmergencyRuns"); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if filters == nil {
+		filters = &state.EmergencyRunFilters{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var matched []*state.EmergencyRun
+	for _, run := range t.emergency {
+		if filters.Pending && !run.Pending() {
+			continue
+		}
+		copied := *run
+		matched = append(matched, &copied)
+	}
+	slices.SortFunc(matched, func(a, b *state.EmergencyRun) int {
+		return cmp.Or(cmp.Compare(b.StartedAt, a.StartedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return page(matched, filters.Limit, filters.Offset), len(matched), nil
+}
+
+// GetStateChanges returns the changes after the cursor since, oldest first
+func (t *StateTracker) GetStateChanges(ctx context.Context, since int64, limit int) ([]*state.StateChange, error) {
+	if err := t.failure("GetStateChanges"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var changes []*state.StateChange
+	for _, change := range t.changes {
+		if change.Cursor > since {
+			copied := *change
+			changes = append(changes, &copied)
+		}
+	}
+	return page(changes, limit, 0), nil
+}
@@ -0,0 +1,376 @@
+package testsupport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/executor"
+	"github.com/toolsascode/bfm/api/internal/registry"
+	"github.com/toolsascode/bfm/api/internal/state"
+	"github.com/toolsascode/bfm/api/testsupport"
+)
+
+const usersID = "20240101120000_create_users_postgresql_core"
+
+func newTestExecutor(t *testing.T) (*executor.Executor, *testsupport.StateTracker, *testsupport.Backend) {
+	t.Helper()
+	reg := testsupport.NewRegistry(
+		&backends.MigrationScript{Schema: "app", Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE users (id INT)", DownSQL: "DROP TABLE users"},
+		&backends.MigrationScript{Schema: "app", Version: "20240102120000", Name: "create_orders", Connection: "core", Backend: "postgresql", UpSQL: "CREATE TABLE orders (id INT)", DownSQL: "DROP TABLE orders"},
+	)
+	tracker := testsupport.NewStateTracker()
+	backend := testsupport.NewBackend("postgresql")
+	exec := executor.NewExecutor(reg, tracker)
+	exec.RegisterBackend("postgresql", backend)
+	if err := exec.SetConnections(map[string]*backends.ConnectionConfig{"core": {Backend: "postgresql"}}); err != nil {
+		t.Fatalf("SetConnections() error = %v", err)
+	}
+	return exec, tracker, backend
+}
+
+func TestExecutor_WithDoubles(t *testing.T) {
+	ctx := context.Background()
+	exec, tracker, backend := newTestExecutor(t)
+
+	backend.FailMigration("create_orders", errors.New("relation \"orders\" already exists"))
+	result, err := exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: "core"}, "core", "", false, false)
+	if err != nil {
+		t.Fatalf("ExecuteSync() error = %v", err)
+	}
+	if result.Success || len(result.Applied) != 1 || len(result.Errors) != 1 {
+		t.Fatalf("ExecuteSync() = %+v, want create_users applied and create_orders failed", result)
+	}
+	if executed := backend.Executed(); len(executed) != 1 || executed[0].Name != "create_users" {
+		t.Errorf("Executed() = %+v", executed)
+	}
+	if applied, _ := tracker.IsMigrationApplied(ctx, usersID); !applied {
+		t.Error("create_users not recorded as applied")
+	}
+
+	// Once fixed, only the failed migration runs again
+	backend.FailMigration("create_orders", nil)
+	result, err = exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: "core"}, "core", "", false, false)
+	if err != nil || !result.Success || len(result.Applied) != 1 {
+		t.Fatalf("ExecuteSync() again = %+v, %v", result, err)
+	}
+	items, total, err := tracker.GetMigrationList(ctx, &state.MigrationFilters{Status: "applied"})
+	if err != nil || total != 2 || items[0].MigrationID != usersID {
+		t.Errorf("GetMigrationList(applied) = %d, %+v, %v", total, items, err)
+	}
+	// Each run is recorded as pending, then with its outcome
+	history, _, _ := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{MigrationID: "20240102120000_create_orders_postgresql_core"})
+	var statuses []string
+	for _, record := range history {
+		statuses = append(statuses, record.Status)
+	}
+	if fmt.Sprint(statuses) != "[applied pending failed pending]" {
+		t.Errorf("create_orders history = %v", statuses)
+	}
+}
+
+func TestExecutor_ConcurrentRuns(t *testing.T) {
+	ctx := context.Background()
+	exec, tracker, backend := newTestExecutor(t)
+
+	// Runs on the same connection exclude each other through the tracker's connection lock: each
+	// migration runs once, whichever run gets it
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = exec.ExecuteSync(ctx, &registry.MigrationTarget{Connection: "core"}, "core", "", false, false)
+		}()
+	}
+	wg.Wait()
+
+	if executed := backend.Executed(); len(executed) != 2 {
+		t.Errorf("Executed() = %d migrations, want each of the 2 once", len(executed))
+	}
+	if _, total, _ := tracker.GetMigrationList(ctx, &state.MigrationFilters{Status: "applied"}); total != 2 {
+		t.Errorf("applied migrations = %d, want 2", total)
+	}
+}
+
+func TestStateTracker_RecordMigration(t *testing.T) {
+	ctx := context.Background()
+	tracker := testsupport.NewStateTracker()
+
+	if err := tracker.RegisterScannedMigration(ctx, usersID, "", "users", "20240101120000", "create_users", "core", "postgresql"); err != nil {
+		t.Fatalf("RegisterScannedMigration() error = %v", err)
+	}
+	err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+		MigrationID: "tenant1_" + usersID,
+		Schema:      "tenant1",
+		Version:     "20240101120000",
+		Connection:  "core",
+		Backend:     "postgresql",
+		Status:      "success",
+		Checksum:    "abc",
+	})
+	if err != nil {
+		t.Fatalf("RecordMigration() error = %v", err)
+	}
+
+	if applied, err := tracker.IsMigrationApplied(ctx, "tenant1_"+usersID); err != nil || !applied {
+		t.Errorf("IsMigrationApplied(tenant1) = %v, %v, want true", applied, err)
+	}
+	if applied, err := tracker.IsMigrationApplied(ctx, "tenant2_"+usersID); err != nil || applied {
+		t.Errorf("IsMigrationApplied(tenant2) = %v, %v, want false", applied, err)
+	}
+
+	items, _, err := tracker.GetMigrationList(ctx, &state.MigrationFilters{Table: "users"})
+	if err != nil {
+		t.Fatalf("GetMigrationList() error = %v", err)
+	}
+	if len(items) != 1 || !items[0].Applied || items[0].Checksum != "abc" || items[0].Name != "create_users" || items[0].LastAppliedAt == "" {
+		t.Fatalf("GetMigrationList() = %+v", items)
+	}
+	history, _, err := tracker.GetMigrationHistory(ctx, &state.MigrationFilters{Schema: "tenant1"})
+	if err != nil || len(history) != 1 || history[0].Status != "applied" || history[0].ExecutedBy != "system" {
+		t.Fatalf("GetMigrationHistory() = %+v, %v", history, err)
+	}
+	executions, err := tracker.GetMigrationExecutions(ctx, usersID)
+	if err != nil || len(executions) != 1 || !executions[0].Applied || executions[0].Schema != "tenant1" {
+		t.Fatalf("GetMigrationExecutions() = %+v, %v", executions, err)
+	}
+
+	// Deleting the migration cascades to its history and executions
+	if err := tracker.DeleteMigration(ctx, usersID); err != nil {
+		t.Fatalf("DeleteMigration() error = %v", err)
+	}
+	if history, _, _ := tracker.GetMigrationHistory(ctx, nil); len(history) != 0 {
+		t.Errorf("history after delete = %+v, want none", history)
+	}
+	if executions, _ := tracker.GetMigrationExecutions(ctx, usersID); len(executions) != 0 {
+		t.Errorf("executions after delete = %+v, want none", executions)
+	}
+}
+
+func TestStateTracker_Locks(t *testing.T) {
+	ctx := context.Background()
+	tracker := testsupport.NewStateTracker()
+
+	err := tracker.WithConnectionLock(ctx, "core", "host-1", func() error {
+		if err := tracker.WithConnectionLock(ctx, "core", "host-2", func() error { return nil }); !errors.Is(err, state.ErrConnectionLocked) {
+			t.Errorf("nested WithConnectionLock() error = %v, want ErrConnectionLocked", err)
+		}
+		locks, err := tracker.ListLocks(ctx)
+		if err != nil || len(locks) != 1 || locks[0].Holder != "host-1" {
+			t.Errorf("ListLocks() = %+v, %v", locks, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithConnectionLock() error = %v", err)
+	}
+	if locks, _ := tracker.ListLocks(ctx); len(locks) != 0 {
+		t.Errorf("ListLocks() after release = %+v, want none", locks)
+	}
+
+	err = tracker.WithMigrationExecutionLock(ctx, "m1", "tenant1", "core", func() error {
+		if err := tracker.WithMigrationExecutionLock(ctx, "m1", "tenant1", "core", func() error { return nil }); !errors.Is(err, state.ErrMigrationAlreadyInProgress) {
+			t.Errorf("nested WithMigrationExecutionLock() error = %v, want ErrMigrationAlreadyInProgress", err)
+		}
+		// Other schemas run concurrently
+		return tracker.WithMigrationExecutionLock(ctx, "m1", "tenant2", "core", func() error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("WithMigrationExecutionLock() error = %v", err)
+	}
+
+	primary, err := tracker.AcquirePrimaryLock(ctx)
+	if err != nil {
+		t.Fatalf("AcquirePrimaryLock() error = %v", err)
+	}
+	if _, err := tracker.AcquirePrimaryLock(ctx); !errors.Is(err, state.ErrPrimaryLocked) {
+		t.Errorf("second AcquirePrimaryLock() error = %v, want ErrPrimaryLocked", err)
+	}
+	primary.Release()
+	if err := primary.Check(ctx); err == nil {
+		t.Error("Check() after Release() error = nil, want lost lock")
+	}
+}
+
+func TestStateTracker_Jobs(t *testing.T) {
+	ctx := context.Background()
+	tracker := testsupport.NewStateTracker()
+
+	for _, status := range []string{state.JobQueued, state.JobPickedUp, state.JobCompleted} {
+		job := &state.Job{ID: "job-1", Status: status, Connection: "core", Schema: "tenant_a"}
+		if status == state.JobPickedUp {
+			job.Worker = "worker-1:42"
+		}
+		if err := tracker.RecordJob(ctx, job); err != nil {
+			t.Fatalf("RecordJob(%s) error = %v", status, err)
+		}
+	}
+	job, err := tracker.GetJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != state.JobCompleted || job.Worker != "worker-1:42" || job.Schema != "tenant_a" || job.StartedAt == "" || job.FinishedAt == "" {
+		t.Errorf("GetJob() = %+v", job)
+	}
+
+	// A job queued again starts over
+	if err := tracker.RecordJob(ctx, &state.Job{ID: "job-1", Status: state.JobQueued, Connection: "core"}); err != nil {
+		t.Fatalf("RecordJob(queued) error = %v", err)
+	}
+	if job, _ := tracker.GetJob(ctx, "job-1"); job.Worker != "" || job.StartedAt != "" || job.FinishedAt != "" {
+		t.Errorf("GetJob(replayed) = %+v", job)
+	}
+	if _, err := tracker.GetJob(ctx, "unknown"); !errors.Is(err, state.ErrJobNotFound) {
+		t.Errorf("GetJob(unknown) error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestStateTracker_StateChanges(t *testing.T) {
+	ctx := context.Background()
+	tracker := testsupport.NewStateTracker()
+
+	reg := testsupport.NewRegistry(&backends.MigrationScript{Version: "20240101120000", Name: "create_users", Connection: "core", Backend: "postgresql"})
+	// A reindex records the migrations it registers, once
+	for i := 0; i < 2; i++ {
+		if err := tracker.ReindexMigrations(ctx, reg); err != nil {
+			t.Fatalf("ReindexMigrations() error = %v", err)
+		}
+	}
+	for _, record := range []struct{ operation, status string }{{state.OperationUp, "success"}, {state.OperationUp, "failed"}, {state.OperationRollback, "success"}} {
+		err := tracker.RecordMigration(ctx, &state.MigrationRecord{
+			MigrationID: usersID, Operation: record.operation, Version: "20240101120000", Connection: "core", Backend: "postgresql", Status: record.status,
+		})
+		if err != nil {
+			t.Fatalf("RecordMigration(%s) error = %v", record.status, err)
+		}
+	}
+	if err := tracker.ReindexMigrations(ctx, testsupport.NewRegistry()); err != nil {
+		t.Fatalf("ReindexMigrations() error = %v", err)
+	}
+
+	changes, err := tracker.GetStateChanges(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GetStateChanges() error = %v", err)
+	}
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Type+":"+change.Status)
+	}
+	want := "[reindexed:pending applied:applied failed:failed rolled_back:rolled_back reindexed:removed]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("GetStateChanges() = %v, want %s", got, want)
+	}
+	if page, _ := tracker.GetStateChanges(ctx, changes[1].Cursor, 2); len(page) != 2 || page[0].Cursor != changes[2].Cursor {
+		t.Errorf("GetStateChanges(since %d, 2) = %+v", changes[1].Cursor, page)
+	}
+}
+
+func TestStateTracker_HonorsCancellation(t *testing.T) {
+	tracker := testsupport.NewStateTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := tracker.GetMigrationList(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetMigrationList() error = %v, want context.Canceled", err)
+	}
+	if err := tracker.RecordMigration(ctx, &state.MigrationRecord{MigrationID: usersID, Status: "success"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("RecordMigration() error = %v, want context.Canceled", err)
+	}
+	if err := tracker.ReindexMigrations(ctx, testsupport.NewRegistry()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReindexMigrations() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestStateTracker_Seeding(t *testing.T) {
+	ctx := context.Background()
+	tracker := testsupport.NewStateTracker()
+
+	tracker.MarkApplied("app_" + usersID)
+	if applied, err := tracker.IsMigrationApplied(ctx, "app_"+usersID); err != nil || !applied {
+		t.Errorf("IsMigrationApplied(app) = %v, %v, want applied", applied, err)
+	}
+	if applied, _ := tracker.IsMigrationApplied(ctx, "tenant_"+usersID); applied {
+		t.Error("IsMigrationApplied(tenant) = true, want only the marked schema applied")
+	}
+	if len(tracker.History()) != 0 {
+		t.Errorf("History() = %+v, want MarkApplied to record none", tracker.History())
+	}
+
+	tracker.HoldLock("core", "replica-1")
+	if err := tracker.WithConnectionLock(ctx, "core", "replica-2", func() error { return nil }); !errors.Is(err, state.ErrConnectionLocked) {
+		t.Errorf("WithConnectionLock() on a held lock error = %v, want ErrConnectionLocked", err)
+	}
+
+	boom := errors.New("boom")
+	tracker.Fail("RecordMigration", boom)
+	if err := tracker.RecordMigration(ctx, &state.MigrationRecord{MigrationID: usersID, Status: "success"}); !errors.Is(err, boom) {
+		t.Errorf("RecordMigration() error = %v, want the injected error", err)
+	}
+	tracker.Fail("RecordMigration", nil)
+	if err := tracker.RecordMigration(ctx, &state.MigrationRecord{MigrationID: usersID, Status: "success"}); err != nil {
+		t.Errorf("RecordMigration() after clearing the failure error = %v", err)
+	}
+	if history := tracker.History(); len(history) != 1 || history[0].MigrationID != usersID {
+		t.Errorf("History() = %+v, want the recorded migration", history)
+	}
+}
+
+func TestQueue_Consume(t *testing.T) {
+	ctx := context.Background()
+	q := testsupport.NewQueue()
+
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		if err := q.PublishJob(ctx, &testsupport.Job{ID: id, Connection: "core"}); err != nil {
+			t.Fatalf("PublishJob() error = %v", err)
+		}
+	}
+	if depth := q.Depth(); depth != 3 {
+		t.Errorf("Depth() = %d, want 3", depth)
+	}
+
+	var consumed []string
+	done := make(chan error)
+	go func() {
+		done <- q.Consume(ctx, func(_ context.Context, job *testsupport.Job) (*testsupport.JobResult, error) {
+			consumed = append(consumed, job.ID)
+			if job.ID == "job-3" {
+				_ = q.Close()
+			}
+			// A failed job does not stop the consumer
+			return nil, errors.New("boom")
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Consume() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Consume() did not return once the queue was closed")
+	}
+	if fmt.Sprint(consumed) != "[job-1 job-2 job-3]" || q.Depth() != 0 {
+		t.Errorf("consumed %v, depth %d", consumed, q.Depth())
+	}
+	if err := q.PublishJob(ctx, &testsupport.Job{ID: "job-4"}); !errors.Is(err, testsupport.ErrQueueClosed) {
+		t.Errorf("PublishJob() after Close() error = %v, want ErrQueueClosed", err)
+	}
+	if published := q.Published(); len(published) != 3 {
+		t.Errorf("Published() = %d jobs, want 3", len(published))
+	}
+}
+
+func TestQueue_ConsumeStopsWithContext(t *testing.T) {
+	q := testsupport.NewQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := q.Consume(ctx, func(context.Context, *testsupport.Job) (*testsupport.JobResult, error) { return nil, nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Consume() error = %v, want context.DeadlineExceeded", err)
+	}
+}
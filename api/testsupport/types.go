@@ -0,0 +1,35 @@
+package testsupport
+
+import (
+	"github.com/toolsascode/bfm/api/internal/backends"
+	"github.com/toolsascode/bfm/api/internal/queue"
+	"github.com/toolsascode/bfm/api/internal/state"
+)
+
+// Public aliases of the types the test doubles take and return, so tests outside the bfm module
+// can build and inspect them.
+type (
+	// ConnectionConfig is the configuration a Backend is connected with
+	ConnectionConfig = backends.ConnectionConfig
+
+	// MigrationRecord is a migration execution recorded in a StateTracker
+	MigrationRecord = state.MigrationRecord
+
+	// MigrationListItem is a migration listed by a StateTracker
+	MigrationListItem = state.MigrationListItem
+
+	// MigrationFilters filters the migrations and history of a StateTracker
+	MigrationFilters = state.MigrationFilters
+
+	// StateChange is a change in the state change feed of a StateTracker
+	StateChange = state.StateChange
+
+	// Job is a migration job published to a Queue
+	Job = queue.Job
+
+	// JobResult is the result a Queue consumer returns for a job
+	JobResult = queue.JobResult
+
+	// DeadLetter is a job published to the dead-letter topic of a Queue
+	DeadLetter = queue.DeadLetter
+)
@@ -19,6 +19,11 @@ function historyStatusIndicatesApplied(s: string): boolean {
   return s === "success" || s === "applied";
 }
 
+// historyRecordReverts reports whether a history record is a down migration or a rollback
+function historyRecordReverts(record: MigrationHistoryItem): boolean {
+  return record.operation === "down" || record.operation === "rollback";
+}
+
 // Confirmation Modal Component
 function ConfirmModal({
   isOpen,
@@ -243,18 +248,38 @@ export default function MigrationDetail() {
 
   useEffect(() => {
     if (id) {
-      loadMigration();
-      loadStatus();
-      loadHistory();
-      loadExecutions();
-      loadSkippedMigrations();
-      const interval = setInterval(() => {
+      const refresh = () => {
         loadStatus();
         loadHistory();
         loadExecutions();
         loadSkippedMigrations();
-      }, 5000); // Refresh status every 5 seconds
-      return () => clearInterval(interval);
+      };
+      loadMigration();
+      refresh();
+
+      // Refresh as soon as this migration (or one of its schemas) starts or finishes on the server
+      const controller = new AbortController();
+      apiClient
+        .streamEvents((event) => {
+          const ours =
+            event.migration_id === id ||
+            event.migration_id?.endsWith(`_${id}`);
+          if (
+            event.type === "dropped" ||
+            (ours && event.type !== "migration.statement_completed")
+          ) {
+            refresh();
+          }
+        }, controller.signal)
+        .catch(() => {
+          // Polling below still refreshes the page
+        });
+      // Runs executed by workers or other replicas are not streamed
+      const interval = setInterval(refresh, 15000);
+      return () => {
+        controller.abort();
+        clearInterval(interval);
+      };
     }
   }, [id]);
 
@@ -399,7 +424,7 @@ export default function MigrationDetail() {
     const latestRecord = history[0];
     // Migration is applied if the latest record is not a rollback
     return (
-      !latestRecord.migration_id.includes("_rollback") &&
+      !historyRecordReverts(latestRecord) &&
       historyStatusIndicatesApplied(latestRecord.status)
     );
   }, [history, migration?.applied]);
@@ -412,7 +437,7 @@ export default function MigrationDetail() {
     // Find the latest successful, non-rollback record
     const latestSuccessRecord = history.find(
       (record) =>
-        !record.migration_id.includes("_rollback") &&
+        !historyRecordReverts(record) &&
         historyStatusIndicatesApplied(record.status),
     );
     return latestSuccessRecord?.applied_at || null;
@@ -427,11 +452,14 @@ export default function MigrationDetail() {
     const latestRecord = history[0];
 
     // If the latest record is a rollback, check if there's a more recent successful application
-    if (latestRecord.migration_id.includes("_rollback")) {
+    if (
+      historyRecordReverts(latestRecord) &&
+      latestRecord.status === "rolled_back"
+    ) {
       // Find the most recent successful, non-rollback record
       const latestSuccessRecord = history.find(
         (record) =>
-          !record.migration_id.includes("_rollback") &&
+          !historyRecordReverts(record) &&
           historyStatusIndicatesApplied(record.status),
       );
       if (latestSuccessRecord) {
@@ -1874,9 +1902,11 @@ export default function MigrationDetail() {
                                 >
                                   {record.migration_id}
                                 </span>
-                                {record.migration_id.includes("_rollback") && (
+                                {historyRecordReverts(record) && (
                                   <span className="text-xs text-orange-600 italic mt-1">
-                                    Rollback
+                                    {record.operation === "down"
+                                      ? "Down"
+                                      : "Rollback"}
                                   </span>
                                 )}
                               </div>
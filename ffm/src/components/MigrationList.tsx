@@ -5,6 +5,7 @@ import { toastService } from "../services/toast";
 import type {
   MigrationListItem,
   MigrationListFilters,
+  MigrationFacetsResponse,
   MigrationTarget,
 } from "../types/api";
 import { format } from "date-fns";
@@ -12,7 +13,7 @@ import { parseExecutionTags } from "../utils/migrationTags";
 
 export default function MigrationList() {
   const [migrations, setMigrations] = useState<MigrationListItem[]>([]);
-  const [allMigrations, setAllMigrations] = useState<MigrationListItem[]>([]);
+  const [facets, setFacets] = useState<MigrationFacetsResponse | null>(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
   const [filters, setFilters] = useState<MigrationListFilters>({});
@@ -46,18 +47,17 @@ export default function MigrationList() {
     loadMigrations();
   }, [filters]);
 
-  // Load all migrations once to get unique filter values
+  // Load the filter values; a field's own filter does not narrow its values
   useEffect(() => {
-    const loadAllMigrations = async () => {
+    const loadFacets = async () => {
       try {
-        const response = await apiClient.listMigrations({});
-        setAllMigrations(response.items);
+        setFacets(await apiClient.getMigrationFacets(filters));
       } catch (err) {
         // Silently fail - filters will just be empty
       }
     };
-    loadAllMigrations();
-  }, []);
+    loadFacets();
+  }, [filters]);
 
   const loadMigrations = async () => {
     try {
@@ -76,19 +76,16 @@ export default function MigrationList() {
     }
   };
 
-  // Extract unique values for filter options
+  // Filter options, sorted by the server
   const filterOptions = useMemo(() => {
-    const backends = Array.from(
-      new Set(allMigrations.map((m) => m.backend).filter(Boolean)),
-    ).sort();
-    const schemas = Array.from(
-      new Set(allMigrations.map((m) => m.schema).filter(Boolean)),
-    ).sort();
-    const connections = Array.from(
-      new Set(allMigrations.map((m) => m.connection).filter(Boolean)),
-    ).sort();
-    return { backends, schemas, connections };
-  }, [allMigrations]);
+    const values = (facet?: { value: string }[]) =>
+      (facet ?? []).map((f) => f.value);
+    return {
+      backends: values(facets?.backends),
+      schemas: values(facets?.schemas),
+      connections: values(facets?.connections),
+    };
+  }, [facets]);
 
   // Filtered schemas based on search query
   const filteredSchemas = useMemo(() => {
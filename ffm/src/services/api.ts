@@ -7,6 +7,7 @@ import type {
   MigrateDownRequest,
   MigrateResponse,
   MigrationListResponse,
+  MigrationFacetsResponse,
   MigrationDetailResponse,
   MigrationStatusResponse,
   MigrationHistoryResponse,
@@ -16,6 +17,7 @@ import type {
   MigrationListFilters,
   ReindexResponse,
   SkippedMigrationsResponse,
+  LifecycleEvent,
 } from "../types/api";
 import { toastService } from "./toast";
 
@@ -186,6 +188,18 @@ class BFMApiClient {
     return response.data;
   }
 
+  async getMigrationFacets(
+    filters?: MigrationListFilters,
+  ): Promise<MigrationFacetsResponse> {
+    const response = await this.client.get<MigrationFacetsResponse>(
+      "/v1/migrations/facets",
+      {
+        params: filters,
+      },
+    );
+    return response.data;
+  }
+
   async getMigration(migrationId: string): Promise<MigrationDetailResponse> {
     const response = await this.client.get<MigrationDetailResponse>(
       `/v1/migrations/${migrationId}`,
@@ -299,6 +313,47 @@ class BFMApiClient {
     return response.data;
   }
 
+  // Streams lifecycle events to onEvent until signal aborts. Uses fetch rather than EventSource,
+  // which cannot send the Authorization header. Resolves when the server ends the stream.
+  async streamEvents(
+    onEvent: (event: LifecycleEvent) => void,
+    signal: AbortSignal,
+  ): Promise<void> {
+    const response = await fetch(
+      `${this.client.defaults.baseURL}/v1/migrations/events`,
+      {
+        headers: this.apiToken
+          ? { Authorization: `Bearer ${this.apiToken}` }
+          : {},
+        signal,
+      },
+    );
+    if (!response.ok || !response.body) {
+      throw new Error(`Event stream failed with status ${response.status}`);
+    }
+
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        return;
+      }
+      buffer += decoder.decode(value, { stream: true });
+      let end: number;
+      while ((end = buffer.indexOf("\n\n")) >= 0) {
+        const lines = buffer.slice(0, end).split("\n");
+        buffer = buffer.slice(end + 2);
+        const name = lines.find((line) => line.startsWith("event:"));
+        const data = lines.find((line) => line.startsWith("data:"));
+        if (name && data) {
+          onEvent({ type: name.slice(6), ...JSON.parse(data.slice(5)) });
+        }
+      }
+    }
+  }
+
   async getRecentSkippedMigrations(
     limit: number = 5,
   ): Promise<SkippedMigrationsResponse> {
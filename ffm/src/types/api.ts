@@ -71,6 +71,19 @@ export interface MigrationListResponse {
   total: number;
 }
 
+export interface FacetValue {
+  value: string;
+  count: number;
+}
+
+export interface MigrationFacetsResponse {
+  connections: FacetValue[];
+  backends: FacetValue[];
+  schemas: FacetValue[];
+  versions: FacetValue[];
+  tags: FacetValue[];
+}
+
 export interface Dependency {
   connection: string;
   schema: string;
@@ -113,7 +126,9 @@ export interface MigrationHistoryItem {
   backend: string;
   applied_at: string;
   status: string;
+  operation?: "up" | "down" | "rollback";
   error_message?: string;
+  error_class?: string;
   executed_by?: string;
   execution_method?: string;
   execution_context?: string;
@@ -189,3 +204,15 @@ export interface SkippedMigrationsResponse {
   migration_id?: string;
   skipped: SkippedMigration[];
 }
+
+export interface LifecycleEvent {
+  // migration.started, migration.statement_completed, migration.applied, migration.failed,
+  // run.completed, job.queued, job.scheduled, reindex.completed; "dropped" when events were lost
+  type: string;
+  time?: string;
+  migration_id?: string;
+  connection?: string;
+  schema?: string;
+  data?: Record<string, unknown>;
+  count?: number; // dropped events
+}